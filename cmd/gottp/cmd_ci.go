@@ -0,0 +1,367 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/environment"
+	"github.com/sadopc/gottp/internal/core/secrets"
+	"github.com/sadopc/gottp/pkg/version"
+)
+
+// Files written by the generated CI jobs, relative to the repository root.
+const (
+	ciJUnitFile    = "gottp-junit.xml"
+	ciBaselineFile = ".gottp/perf-baseline.json"
+)
+
+// ciOptions describes the gottp run invocation a CI config wraps.
+type ciOptions struct {
+	Collection    string // slash-separated path relative to the repository root
+	Env           string
+	Folder        string
+	Workflow      string
+	Perf          bool
+	PerfThreshold float64
+	Secrets       []string // OS environment variables for secret:// references
+	Passphrase    bool     // the environment has enc:v1: values
+}
+
+func ciCmd() {
+	fs := flag.NewFlagSet("ci", flag.ExitOnError)
+	var output string
+	fs.StringVar(&output, "o", "", "Output file path (default: stdout)")
+	fs.StringVar(&output, "output", "", "Same as -o")
+	envFlag := fs.String("env", "", "Environment to run against")
+	folderFlag := fs.String("folder", "", "Run only the requests in a folder")
+	workflowFlag := fs.String("workflow", "", "Run a named workflow instead of the requests")
+	perfFlag := fs.Bool("perf", false, "Cache a performance baseline between runs and fail on regressions")
+	perfThresholdFlag := fs.Float64("perf-threshold", 20.0, "Regression threshold percentage for --perf")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gottp ci <github|gitlab> <collection.gottp.yaml> [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Generate a CI workflow that runs a collection with gottp run.\n\n")
+		fmt.Fprintf(os.Stderr, "The job installs gottp, runs the collection with JUnit output and uploads\n")
+		fmt.Fprintf(os.Stderr, "the report. secret:// values in the chosen environment become CI secrets.\n")
+		fmt.Fprintf(os.Stderr, "Run it from the repository root so paths in the workflow resolve.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  gottp ci github api.gottp.yaml --env Staging -o .github/workflows/api.yml\n")
+		fmt.Fprintf(os.Stderr, "  gottp ci gitlab api.gottp.yaml --env Staging --perf -o .gitlab-ci.yml\n")
+	}
+
+	args, err := parseInterspersed(fs, os.Args[2:])
+	if err != nil {
+		os.Exit(1)
+	}
+	if len(args) != 2 {
+		fmt.Fprintf(os.Stderr, "Error: a CI provider and a collection file are required\n\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	opts, err := loadCIOptions(args[1], *envFlag, *folderFlag, *workflowFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	opts.Perf = *perfFlag
+	opts.PerfThreshold = *perfThresholdFlag
+
+	config, err := generateCIConfig(args[0], opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if output == "" {
+		fmt.Print(config)
+	} else {
+		if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(output, []byte(config), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", output)
+	}
+	for _, name := range opts.ciSecrets() {
+		fmt.Fprintf(os.Stderr, "Add a CI secret named %s\n", name)
+	}
+}
+
+// loadCIOptions checks the collection, environment, folder and workflow
+// names, and collects the secrets the environment needs.
+func loadCIOptions(path, env, folder, workflow string) (ciOptions, error) {
+	opts := ciOptions{Env: env, Folder: folder, Workflow: workflow}
+	if folder != "" && workflow != "" {
+		return opts, fmt.Errorf("--folder and --workflow are mutually exclusive")
+	}
+
+	col, err := collection.LoadFromFile(path)
+	if err != nil {
+		return opts, fmt.Errorf("loading %s: %w", path, err)
+	}
+	rel, err := repoRelative(path)
+	if err != nil {
+		return opts, err
+	}
+	opts.Collection = rel
+
+	if folder != "" && !hasFolder(col.Items, folder) {
+		return opts, fmt.Errorf("folder %q not found in %s", folder, path)
+	}
+	if workflow != "" {
+		found := false
+		for _, wf := range col.Workflows {
+			found = found || wf.Name == workflow
+		}
+		if !found {
+			return opts, fmt.Errorf("workflow %q not found in %s", workflow, path)
+		}
+	}
+
+	envFile, err := environment.LoadEnvironments(filepath.Join(filepath.Dir(path), "environments.yaml"))
+	if err != nil {
+		return opts, err
+	}
+	active := env
+	if active == "" && len(envFile.Environments) > 0 {
+		// gottp run uses the first environment by default
+		active = envFile.Environments[0].Name
+	}
+	found := active == ""
+	seen := map[string]bool{}
+	for _, e := range envFile.Environments {
+		if e.Name != active {
+			continue
+		}
+		found = true
+		for _, v := range e.Variables {
+			switch {
+			case secrets.IsRef(v.Value):
+				if name := secrets.EnvVar(secrets.RefName(v.Value)); !seen[name] {
+					seen[name] = true
+					opts.Secrets = append(opts.Secrets, name)
+				}
+			case environment.IsEncrypted(v.Value):
+				opts.Passphrase = true
+			}
+		}
+	}
+	if !found {
+		return opts, fmt.Errorf("environment %q not found (available: %s)", env, strings.Join(envFile.Names(), ", "))
+	}
+	sort.Strings(opts.Secrets)
+	return opts, nil
+}
+
+// repoRelative returns path relative to the working directory, which the
+// generated job treats as the repository root.
+func repoRelative(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the current directory; run gottp ci from the repository root", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func hasFolder(items []collection.Item, name string) bool {
+	for _, item := range items {
+		if item.Folder != nil && (item.Folder.Name == name || hasFolder(item.Folder.Items, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// generateCIConfig renders the workflow for a CI provider.
+func generateCIConfig(provider string, opts ciOptions) (string, error) {
+	if opts.Perf && opts.Workflow != "" {
+		return "", fmt.Errorf("--perf does not apply to workflows; gottp run --workflow records no baseline")
+	}
+	switch provider {
+	case "github":
+		return generateGitHubWorkflow(opts), nil
+	case "gitlab":
+		return generateGitLabPipeline(opts), nil
+	}
+	return "", fmt.Errorf("unsupported CI provider %q (use github or gitlab)", provider)
+}
+
+// ciSecrets lists the OS environment variables the job must receive.
+func (o ciOptions) ciSecrets() []string {
+	names := append([]string(nil), o.Secrets...)
+	if o.Passphrase {
+		names = append(names, secrets.PassphraseEnv)
+	}
+	return names
+}
+
+// runScript returns the shell lines that run the collection.
+func (o ciOptions) runScript() []string {
+	args := []string{"gottp", "run", shellQuote(o.Collection)}
+	if o.Env != "" {
+		args = append(args, "--env", shellQuote(o.Env))
+	}
+	if o.Folder != "" {
+		args = append(args, "--folder", shellQuote(o.Folder))
+	}
+	if o.Workflow != "" {
+		args = append(args, "--workflow", shellQuote(o.Workflow))
+	}
+	args = append(args, "--output", "junit")
+	if !o.Perf {
+		return []string{strings.Join(args, " ") + " > " + ciJUnitFile}
+	}
+
+	// Compare with the cached baseline when there is one, then replace it;
+	// the cache only keeps the new baseline when the job passes.
+	args = append(args, "--perf-save", ciBaselineFile)
+	if o.PerfThreshold != 20 {
+		args = append(args, "--perf-threshold", fmt.Sprint(o.PerfThreshold))
+	}
+	args = append(args, "$baseline")
+	return []string{
+		"mkdir -p " + filepath.ToSlash(filepath.Dir(ciBaselineFile)),
+		`baseline=""`,
+		fmt.Sprintf(`if [ -f %[1]s ]; then baseline="--perf-baseline %[1]s"; fi`, ciBaselineFile),
+		strings.Join(args, " ") + " > " + ciJUnitFile,
+	}
+}
+
+// installRef is the module version the job installs: this binary's release,
+// or latest for development builds.
+func installRef() string {
+	if strings.HasPrefix(version.Version, "v") {
+		return version.Version
+	}
+	return "latest"
+}
+
+func generateGitHubWorkflow(o ciOptions) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by gottp ci github. Runs %s and uploads a JUnit report.\n", o.Collection)
+	b.WriteString(`name: API tests
+
+on:
+  push:
+  pull_request:
+  workflow_dispatch:
+
+jobs:
+  gottp:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+          cache: false
+
+      - name: Install gottp
+`)
+	fmt.Fprintf(&b, "        run: go install github.com/sadopc/gottp/cmd/gottp@%s\n", installRef())
+	if o.Perf {
+		fmt.Fprintf(&b, `
+      - name: Restore performance baseline
+        uses: actions/cache@v4
+        with:
+          path: %s
+          key: gottp-perf-${{ github.ref_name }}-${{ github.run_id }}
+          restore-keys: |
+            gottp-perf-${{ github.ref_name }}-
+            gottp-perf-
+`, ciBaselineFile)
+	}
+
+	b.WriteString("\n      - name: Run collection\n")
+	if names := o.ciSecrets(); len(names) > 0 {
+		b.WriteString("        env:\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "          %s: ${{ secrets.%s }}\n", name, name)
+		}
+	}
+	b.WriteString("        run: |\n")
+	for _, line := range o.runScript() {
+		fmt.Fprintf(&b, "          %s\n", line)
+	}
+
+	fmt.Fprintf(&b, `
+      - name: Upload JUnit report
+        if: always()
+        uses: actions/upload-artifact@v4
+        with:
+          name: gottp-junit
+          path: %s
+`, ciJUnitFile)
+	return b.String()
+}
+
+func generateGitLabPipeline(o ciOptions) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by gottp ci gitlab. Runs %s and publishes a JUnit report.\n", o.Collection)
+	if names := o.ciSecrets(); len(names) > 0 {
+		b.WriteString("# Define these masked CI/CD variables in Settings > CI/CD > Variables:\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "#   %s\n", name)
+		}
+	}
+	b.WriteString(`
+gottp:
+  stage: test
+  image: golang:1
+`)
+	if o.Perf {
+		fmt.Fprintf(&b, `  cache:
+    key: gottp-perf-$CI_COMMIT_REF_SLUG
+    paths:
+      - %s
+    when: on_success
+`, ciBaselineFile)
+	}
+	fmt.Fprintf(&b, `  before_script:
+    - go install github.com/sadopc/gottp/cmd/gottp@%s
+  script:
+    - |
+`, installRef())
+	for _, line := range o.runScript() {
+		fmt.Fprintf(&b, "      %s\n", line)
+	}
+	fmt.Fprintf(&b, `  artifacts:
+    when: always
+    paths:
+      - %[1]s
+    reports:
+      junit: %[1]s
+`, ciJUnitFile)
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell when it contains special
+// characters.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:@", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+func TestGenerateCIConfig(t *testing.T) {
+	opts := ciOptions{
+		Collection:    "api/my api.gottp.yaml",
+		Env:           "Staging",
+		Perf:          true,
+		PerfThreshold: 20,
+		Secrets:       []string{"GOTTP_SECRET_API_TOKEN"},
+		Passphrase:    true,
+	}
+
+	gh, err := generateCIConfig("github", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"gottp run 'api/my api.gottp.yaml' --env Staging --output junit --perf-save .gottp/perf-baseline.json $baseline > gottp-junit.xml",
+		`if [ -f .gottp/perf-baseline.json ]; then baseline="--perf-baseline .gottp/perf-baseline.json"; fi`,
+		"uses: actions/cache@v4",
+		"GOTTP_SECRET_API_TOKEN: ${{ secrets.GOTTP_SECRET_API_TOKEN }}",
+		"GOTTP_PASSPHRASE: ${{ secrets.GOTTP_PASSPHRASE }}",
+		"if: always()",
+		"path: gottp-junit.xml",
+	} {
+		if !strings.Contains(gh, want) {
+			t.Errorf("github workflow missing %q:\n%s", want, gh)
+		}
+	}
+
+	gl, err := generateCIConfig("gitlab", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"#   GOTTP_SECRET_API_TOKEN",
+		"key: gottp-perf-$CI_COMMIT_REF_SLUG",
+		"      gottp run 'api/my api.gottp.yaml' --env Staging",
+		"junit: gottp-junit.xml",
+	} {
+		if !strings.Contains(gl, want) {
+			t.Errorf("gitlab pipeline missing %q:\n%s", want, gl)
+		}
+	}
+
+	plain, _ := generateCIConfig("github", ciOptions{Collection: "api.gottp.yaml", Folder: "Users"})
+	if strings.Contains(plain, "actions/cache") || strings.Contains(plain, "env:") {
+		t.Errorf("workflow without perf or secrets should have no cache or env block:\n%s", plain)
+	}
+	if !strings.Contains(plain, "gottp run api.gottp.yaml --folder Users --output junit > gottp-junit.xml") {
+		t.Errorf("unexpected run line:\n%s", plain)
+	}
+
+	if _, err := generateCIConfig("github", ciOptions{Workflow: "Login", Perf: true}); err == nil {
+		t.Error("expected an error for --perf with --workflow")
+	}
+	if _, err := generateCIConfig("jenkins", opts); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestLoadCIOptions(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	col := &collection.Collection{
+		Name:    "API",
+		Version: "1",
+		Items: []collection.Item{
+			{Folder: &collection.Folder{Name: "Users", Items: []collection.Item{
+				{Request: collection.NewRequest("List", "GET", "{{base_url}}/users")},
+			}}},
+		},
+	}
+	if err := os.MkdirAll("api", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := collection.SaveToFile(col, filepath.Join("api", "api.gottp.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	env := `environments:
+  - name: Staging
+    variables:
+      token:
+        value: "secret://api-token"
+      base_url:
+        value: "https://staging.example.com"
+  - name: Production
+    variables: {}
+`
+	if err := os.WriteFile(filepath.Join("api", "environments.yaml"), []byte(env), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := loadCIOptions(filepath.Join(dir, "api", "api.gottp.yaml"), "", "Users", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Collection != "api/api.gottp.yaml" {
+		t.Errorf("Collection = %q", opts.Collection)
+	}
+	if len(opts.Secrets) != 1 || opts.Secrets[0] != "GOTTP_SECRET_API_TOKEN" {
+		t.Errorf("Secrets = %v, want the first environment's secret", opts.Secrets)
+	}
+
+	if _, err := loadCIOptions("api/api.gottp.yaml", "Missing", "", ""); err == nil {
+		t.Error("expected an error for an unknown environment")
+	}
+	if _, err := loadCIOptions("api/api.gottp.yaml", "", "Nope", ""); err == nil {
+		t.Error("expected an error for an unknown folder")
+	}
+	if _, err := loadCIOptions("api/api.gottp.yaml", "", "", "Nope"); err == nil {
+		t.Error("expected an error for an unknown workflow")
+	}
+}
@@ -52,23 +52,32 @@ _gottp() {
     local cur prev words cword
     _init_completion || return
 
-    local commands="run init validate fmt import export mock completion version help"
+    local commands="run init validate lint fmt migrate import export curl merge diff mock ci doctor history completion version help"
 
     # Flags per subcommand
-    local run_flags="--env --request --folder --workflow --output --verbose --timeout --perf-save --perf-baseline --perf-threshold"
+    local run_flags="--env --request --folder --workflow --output --verbose --report --timeout --oauth-browser --perf-save --perf-baseline --perf-threshold --parallel --per-host --watch --watch-interval"
     local init_flags="--name --output --with-env"
     local validate_flags=""
-    local fmt_flags="-w --check"
+    local lint_flags="--format --strict"
+    local fmt_flags="-w --check --reassign-ids --id-style"
+    local migrate_flags="--check"
     local import_flags="--format --output"
-    local export_flags="--format --request --output"
+    local export_flags="--format --request --folder --workflow --env --output"
+    local curl_flags="--collection --env --save --name --include --timeout"
+    local merge_flags="-o --output --name --strict"
+    local diff_flags="--format --exit-code"
     local mock_flags=""
+    local ci_flags="-o --output --env --folder --workflow --perf --perf-threshold"
+    local doctor_flags="--timeout --offline"
+    local history_flags="--tag --search --since --until --limit --format --name -o"
     local completion_flags=""
 
     # Output format values
-    local output_formats="text json junit"
-    local export_formats="curl har postman insomnia"
-    local import_formats="curl postman insomnia openapi har"
+    local output_formats="text json junit tap github markdown"
+    local export_formats="curl har postman insomnia openapi shell http hurl"
+    local import_formats="curl postman insomnia openapi har http hurl"
     local shells="bash zsh fish"
+    local ci_providers="github gitlab"
 
     if [[ ${cword} -eq 1 ]]; then
         COMPREPLY=($(compgen -W "${commands}" -- "${cur}"))
@@ -92,6 +101,10 @@ _gottp() {
                     ;;
             esac
             ;;
+        --id-style)
+            COMPREPLY=($(compgen -W "uuid ulid slug" -- "${cur}"))
+            return
+            ;;
         --format)
             case "${command}" in
                 export)
@@ -102,14 +115,22 @@ _gottp() {
                     COMPREPLY=($(compgen -W "${import_formats}" -- "${cur}"))
                     return
                     ;;
+                history)
+                    COMPREPLY=($(compgen -W "json collection" -- "${cur}"))
+                    return
+                    ;;
+                diff|lint)
+                    COMPREPLY=($(compgen -W "text json" -- "${cur}"))
+                    return
+                    ;;
             esac
             ;;
-        --env|--request|--folder|--workflow|--name|--timeout|--perf-threshold)
+        --env|--request|--folder|--workflow|--name|--timeout|--perf-threshold|--parallel|--per-host|--watch-interval)
             # These take user-provided values, no completion
             return
             ;;
-        --perf-save|--perf-baseline)
-            # File completion for baseline files
+        --perf-save|--perf-baseline|--report)
+            # File completion for baseline and report files
             _filedir
             return
             ;;
@@ -135,6 +156,14 @@ _gottp() {
             COMPREPLY=($(compgen -f -X '!*.gottp.yaml' -- "${cur}"))
             _filedir -d
             ;;
+        lint)
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W "${lint_flags}" -- "${cur}"))
+            else
+                COMPREPLY=($(compgen -f -X '!*.gottp.yaml' -- "${cur}"))
+                _filedir -d
+            fi
+            ;;
         fmt)
             if [[ "${cur}" == -* ]]; then
                 COMPREPLY=($(compgen -W "${fmt_flags}" -- "${cur}"))
@@ -143,6 +172,14 @@ _gottp() {
                 _filedir -d
             fi
             ;;
+        migrate)
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W "${migrate_flags}" -- "${cur}"))
+            else
+                COMPREPLY=($(compgen -f -X '!*.yaml' -- "${cur}"))
+                _filedir -d
+            fi
+            ;;
         import)
             if [[ "${cur}" == -* ]]; then
                 COMPREPLY=($(compgen -W "${import_flags}" -- "${cur}"))
@@ -158,6 +195,52 @@ _gottp() {
                 _filedir -d
             fi
             ;;
+        curl)
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W "${curl_flags}" -- "${cur}"))
+            fi
+            ;;
+        merge)
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W "${merge_flags}" -- "${cur}"))
+            else
+                COMPREPLY=($(compgen -f -X '!*.gottp.yaml' -- "${cur}"))
+                _filedir -d
+            fi
+            ;;
+        diff)
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W "${diff_flags}" -- "${cur}"))
+            else
+                COMPREPLY=($(compgen -f -X '!*.gottp.yaml' -- "${cur}"))
+                _filedir -d
+            fi
+            ;;
+        ci)
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W "${ci_flags}" -- "${cur}"))
+            elif [[ ${cword} -eq 2 ]]; then
+                COMPREPLY=($(compgen -W "${ci_providers}" -- "${cur}"))
+            else
+                COMPREPLY=($(compgen -f -X '!*.gottp.yaml' -- "${cur}"))
+                _filedir -d
+            fi
+            ;;
+        doctor)
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W "${doctor_flags}" -- "${cur}"))
+            else
+                COMPREPLY=($(compgen -f -X '!*.gottp.yaml' -- "${cur}"))
+                _filedir -d
+            fi
+            ;;
+        history)
+            if [[ ${cword} -eq 2 ]]; then
+                COMPREPLY=($(compgen -W "export" -- "${cur}"))
+            elif [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W "${history_flags}" -- "${cur}"))
+            fi
+            ;;
         completion)
             COMPREPLY=($(compgen -W "${shells}" -- "${cur}"))
             ;;
@@ -179,10 +262,18 @@ _gottp() {
         'run:Run API requests headlessly from a collection file'
         'init:Create a new .gottp.yaml collection interactively'
         'validate:Validate collection and environment YAML files'
+        'lint:Check collections against style and safety rules'
         'fmt:Format and normalize collection YAML files'
+        'migrate:Upgrade collection and environment files to the current schema'
         'import:Import collection from cURL/Postman/Insomnia/OpenAPI/HAR'
         'export:Export collection to cURL/HAR/Postman/Insomnia format'
+        'curl:Send a curl command through gottp'
+        'merge:Combine collections into one, reporting conflicts'
+        'diff:Compare two collections, listing added, removed and changed requests'
         'mock:Start a mock server from a collection'
+        'ci:Generate a GitHub Actions or GitLab CI workflow for a collection'
+        'doctor:Check the gottp setup and suggest fixes'
+        'history:Export request history'
         'completion:Generate shell completion scripts'
         'version:Print version information'
         'help:Show help message'
@@ -204,12 +295,18 @@ _gottp() {
                         '--request[Run a single request by name]:request name:' \
                         '--folder[Run all requests in a folder]:folder name:' \
                         '--workflow[Run a named workflow]:workflow name:' \
-                        '--output[Output format]:format:(text json junit)' \
+                        '--output[Output format]:format:(text json junit tap github markdown)' \
                         '--verbose[Show response bodies and headers]' \
+                        '--report[Write an HTML report of the run]:file:_files' \
                         '--timeout[Request timeout]:timeout:' \
+                        '--oauth-browser[Open the browser for OAuth2 authorization_code grants]' \
                         '--perf-save[Save timing results as a performance baseline file]:file:_files' \
                         '--perf-baseline[Compare timings against a baseline file]:file:_files' \
                         '--perf-threshold[Regression threshold percentage]:threshold:' \
+                        '--parallel[Requests to run at once]:count:' \
+                        '--per-host[Requests to run at once against the same host]:count:' \
+                        '--watch[Run again when the collection or files it uses change]' \
+                        '--watch-interval[How often --watch checks files]:duration:' \
                         '*:collection file:_files -g "*.gottp.yaml"'
                     ;;
                 init)
@@ -222,25 +319,95 @@ _gottp() {
                     _arguments \
                         '*:collection file:_files -g "*.gottp.yaml"'
                     ;;
+                lint)
+                    _arguments \
+                        '--format[Output format]:format:(text json)' \
+                        '--strict[Exit 1 on warnings as well as errors]' \
+                        '*:collection file:_files -g "*.gottp.yaml"'
+                    ;;
                 fmt)
                     _arguments \
                         '-w[Write result to file instead of stdout]' \
                         '--check[Check if files are formatted]' \
+                        '--reassign-ids[Give requests with missing or duplicate IDs new IDs]' \
+                        '--id-style[ID style for --reassign-ids]:style:(uuid ulid slug)' \
                         '*:collection file:_files -g "*.gottp.yaml"'
                     ;;
+                migrate)
+                    _arguments \
+                        '--check[Report files that need migrating without writing them]' \
+                        '*:collection or environments file:_files -g "*.yaml"'
+                    ;;
                 import)
                     _arguments \
-                        '--format[Force format]:format:(curl postman insomnia openapi har)' \
+                        '--format[Force format]:format:(curl postman insomnia openapi har http hurl)' \
                         '--output[Output .gottp.yaml file path]:output file:_files -g "*.gottp.yaml"' \
                         '*:input file:_files'
                     ;;
                 export)
                     _arguments \
-                        '--format[Export format]:format:(curl har postman insomnia)' \
+                        '--format[Export format]:format:(curl har postman insomnia openapi shell http hurl)' \
                         '--request[Export a single request by name]:request name:' \
+                        '--folder[Export the requests in a folder]:folder name:' \
+                        '--workflow[Export the steps of a workflow]:workflow name:' \
+                        '--env[Use environment values as defaults]:environment:' \
                         '--output[Output file path]:output file:_files' \
                         '*:collection file:_files -g "*.gottp.yaml"'
                     ;;
+                curl)
+                    _arguments \
+                        '--collection[Collection for environments and --save]:collection file:_files -g "*.gottp.yaml"' \
+                        '--env[Environment name]:environment:' \
+                        '--save[Append the request to the collection]' \
+                        '--name[Name of the saved request]:name:' \
+                        '--include[Print the status line and headers]' \
+                        '--timeout[Request timeout]:duration:' \
+                        '1:curl command:'
+                    ;;
+                merge)
+                    _arguments \
+                        '-o[Output file path]:output file:_files -g "*.gottp.yaml"' \
+                        '--output[Output file path]:output file:_files -g "*.gottp.yaml"' \
+                        '--name[Name of the merged collection]:name:' \
+                        '--strict[Exit 1 if any conflicts were found]' \
+                        '*:collection file:_files -g "*.gottp.yaml"'
+                    ;;
+                diff)
+                    _arguments \
+                        '--format[Output format]:format:(text json)' \
+                        '--exit-code[Exit 1 if the collections differ]' \
+                        '*:collection file:_files -g "*.gottp.yaml"'
+                    ;;
+                ci)
+                    _arguments \
+                        '-o[Output file path]:output file:_files' \
+                        '--output[Output file path]:output file:_files' \
+                        '--env[Environment to run against]:environment name:' \
+                        '--folder[Run only the requests in a folder]:folder name:' \
+                        '--workflow[Run a named workflow]:workflow name:' \
+                        '--perf[Cache a performance baseline and fail on regressions]' \
+                        '--perf-threshold[Regression threshold percentage]:threshold:' \
+                        '1:provider:(github gitlab)' \
+                        '2:collection file:_files -g "*.gottp.yaml"'
+                    ;;
+                doctor)
+                    _arguments \
+                        '--timeout[Timeout for each network check]:duration:' \
+                        '--offline[Skip network checks]' \
+                        '1:collection file:_files -g "*.gottp.yaml"'
+                    ;;
+                history)
+                    _arguments \
+                        '*--tag[Only entries with this tag]:tag:' \
+                        '--search[Only entries whose URL, note or tags contain this text]:text:' \
+                        '--since[Only entries from this long ago or since a date]:age or date:' \
+                        '--until[Only entries up to this long ago or until a date]:age or date:' \
+                        '--limit[Maximum number of entries]:count:' \
+                        '--format[Export format]:format:(json collection)' \
+                        '--name[Collection name]:name:' \
+                        '-o[Output file path]:output file:_files' \
+                        '1:action:(export)'
+                    ;;
                 completion)
                     _arguments \
                         '1:shell:(bash zsh fish)'
@@ -264,10 +431,18 @@ complete -c gottp -f
 complete -c gottp -n '__fish_use_subcommand' -a run -d 'Run API requests headlessly from a collection file'
 complete -c gottp -n '__fish_use_subcommand' -a init -d 'Create a new .gottp.yaml collection interactively'
 complete -c gottp -n '__fish_use_subcommand' -a validate -d 'Validate collection and environment YAML files'
+complete -c gottp -n '__fish_use_subcommand' -a lint -d 'Check collections against style and safety rules'
 complete -c gottp -n '__fish_use_subcommand' -a fmt -d 'Format and normalize collection YAML files'
+complete -c gottp -n '__fish_use_subcommand' -a migrate -d 'Upgrade collection and environment files to the current schema'
 complete -c gottp -n '__fish_use_subcommand' -a import -d 'Import collection from cURL/Postman/Insomnia/OpenAPI/HAR'
 complete -c gottp -n '__fish_use_subcommand' -a export -d 'Export collection to cURL/HAR/Postman/Insomnia format'
+complete -c gottp -n '__fish_use_subcommand' -a curl -d 'Send a curl command through gottp'
+complete -c gottp -n '__fish_use_subcommand' -a merge -d 'Combine collections into one, reporting conflicts'
+complete -c gottp -n '__fish_use_subcommand' -a diff -d 'Compare two collections, listing added, removed and changed requests'
 complete -c gottp -n '__fish_use_subcommand' -a mock -d 'Start a mock server from a collection'
+complete -c gottp -n '__fish_use_subcommand' -a ci -d 'Generate a GitHub Actions or GitLab CI workflow for a collection'
+complete -c gottp -n '__fish_use_subcommand' -a doctor -d 'Check the gottp setup and suggest fixes'
+complete -c gottp -n '__fish_use_subcommand' -a history -d 'Export request history'
 complete -c gottp -n '__fish_use_subcommand' -a completion -d 'Generate shell completion scripts'
 complete -c gottp -n '__fish_use_subcommand' -a version -d 'Print version information'
 complete -c gottp -n '__fish_use_subcommand' -a help -d 'Show help message'
@@ -277,14 +452,46 @@ complete -c gottp -n '__fish_seen_subcommand_from run' -l env -d 'Environment na
 complete -c gottp -n '__fish_seen_subcommand_from run' -l request -d 'Run a single request by name' -r
 complete -c gottp -n '__fish_seen_subcommand_from run' -l folder -d 'Run all requests in a folder' -r
 complete -c gottp -n '__fish_seen_subcommand_from run' -l workflow -d 'Run a named workflow' -r
-complete -c gottp -n '__fish_seen_subcommand_from run' -l output -d 'Output format' -ra 'text json junit'
+complete -c gottp -n '__fish_seen_subcommand_from run' -l output -d 'Output format' -ra 'text json junit tap github markdown'
 complete -c gottp -n '__fish_seen_subcommand_from run' -l verbose -d 'Show response bodies and headers'
 complete -c gottp -n '__fish_seen_subcommand_from run' -l timeout -d 'Request timeout' -r
+complete -c gottp -n '__fish_seen_subcommand_from run' -l oauth-browser -d 'Open the browser for OAuth2 authorization_code grants'
+complete -c gottp -n '__fish_seen_subcommand_from run' -l report -d 'Write an HTML report of the run' -rF
 complete -c gottp -n '__fish_seen_subcommand_from run' -l perf-save -d 'Save timing results as a performance baseline file' -rF
 complete -c gottp -n '__fish_seen_subcommand_from run' -l perf-baseline -d 'Compare timings against a baseline file' -rF
 complete -c gottp -n '__fish_seen_subcommand_from run' -l perf-threshold -d 'Regression threshold percentage' -r
+complete -c gottp -n '__fish_seen_subcommand_from run' -l parallel -d 'Requests to run at once' -r
+complete -c gottp -n '__fish_seen_subcommand_from run' -l per-host -d 'Requests to run at once against the same host' -r
+complete -c gottp -n '__fish_seen_subcommand_from run' -l watch -d 'Run again when the collection or files it uses change'
+complete -c gottp -n '__fish_seen_subcommand_from run' -l watch-interval -d 'How often --watch checks files' -r
 complete -c gottp -n '__fish_seen_subcommand_from run' -F
 
+# ci providers and flags
+complete -c gottp -n '__fish_seen_subcommand_from ci; and not __fish_seen_subcommand_from github gitlab' -a 'github gitlab'
+complete -c gottp -n '__fish_seen_subcommand_from ci' -s o -l output -d 'Output file path' -rF
+complete -c gottp -n '__fish_seen_subcommand_from ci' -l env -d 'Environment to run against' -r
+complete -c gottp -n '__fish_seen_subcommand_from ci' -l folder -d 'Run only the requests in a folder' -r
+complete -c gottp -n '__fish_seen_subcommand_from ci' -l workflow -d 'Run a named workflow' -r
+complete -c gottp -n '__fish_seen_subcommand_from ci' -l perf -d 'Cache a performance baseline and fail on regressions'
+complete -c gottp -n '__fish_seen_subcommand_from ci' -l perf-threshold -d 'Regression threshold percentage' -r
+complete -c gottp -n '__fish_seen_subcommand_from ci; and __fish_seen_subcommand_from github gitlab' -F
+
+# doctor flags
+complete -c gottp -n '__fish_seen_subcommand_from doctor' -l timeout -d 'Timeout for each network check' -r
+complete -c gottp -n '__fish_seen_subcommand_from doctor' -l offline -d 'Skip network checks'
+complete -c gottp -n '__fish_seen_subcommand_from doctor' -F
+
+# history actions and flags
+complete -c gottp -n '__fish_seen_subcommand_from history; and not __fish_seen_subcommand_from export' -a export -d 'Export history as JSON or a collection'
+complete -c gottp -n '__fish_seen_subcommand_from history' -l tag -d 'Only entries with this tag' -r
+complete -c gottp -n '__fish_seen_subcommand_from history' -l search -d 'Only entries whose URL, note or tags contain this text' -r
+complete -c gottp -n '__fish_seen_subcommand_from history' -l since -d 'Only entries from this long ago or since a date' -r
+complete -c gottp -n '__fish_seen_subcommand_from history' -l until -d 'Only entries up to this long ago or until a date' -r
+complete -c gottp -n '__fish_seen_subcommand_from history' -l limit -d 'Maximum number of entries' -r
+complete -c gottp -n '__fish_seen_subcommand_from history' -l format -d 'Export format' -ra 'json collection'
+complete -c gottp -n '__fish_seen_subcommand_from history' -l name -d 'Collection name' -r
+complete -c gottp -n '__fish_seen_subcommand_from history' -s o -d 'Output file path' -rF
+
 # init flags
 complete -c gottp -n '__fish_seen_subcommand_from init' -l name -d 'Collection name' -r
 complete -c gottp -n '__fish_seen_subcommand_from init' -l output -d 'Output file path' -rF
@@ -293,22 +500,55 @@ complete -c gottp -n '__fish_seen_subcommand_from init' -l with-env -d 'Also cre
 # validate - file completion
 complete -c gottp -n '__fish_seen_subcommand_from validate' -F
 
+# lint flags
+complete -c gottp -n '__fish_seen_subcommand_from lint' -l format -d 'Output format' -xa 'text json'
+complete -c gottp -n '__fish_seen_subcommand_from lint' -l strict -d 'Exit 1 on warnings as well as errors'
+complete -c gottp -n '__fish_seen_subcommand_from lint' -F
+
 # fmt flags
 complete -c gottp -n '__fish_seen_subcommand_from fmt' -s w -d 'Write result to file instead of stdout'
 complete -c gottp -n '__fish_seen_subcommand_from fmt' -l check -d 'Check if files are formatted'
+complete -c gottp -n '__fish_seen_subcommand_from fmt' -l reassign-ids -d 'Give requests with missing or duplicate IDs new IDs'
+complete -c gottp -n '__fish_seen_subcommand_from fmt' -l id-style -d 'ID style for --reassign-ids' -ra 'uuid ulid slug'
 complete -c gottp -n '__fish_seen_subcommand_from fmt' -F
 
+# migrate flags
+complete -c gottp -n '__fish_seen_subcommand_from migrate' -l check -d 'Report files that need migrating without writing them'
+complete -c gottp -n '__fish_seen_subcommand_from migrate' -F
+
 # import flags
-complete -c gottp -n '__fish_seen_subcommand_from import' -l format -d 'Force format' -ra 'curl postman insomnia openapi har'
+complete -c gottp -n '__fish_seen_subcommand_from import' -l format -d 'Force format' -ra 'curl postman insomnia openapi har http hurl'
 complete -c gottp -n '__fish_seen_subcommand_from import' -l output -d 'Output .gottp.yaml file path' -rF
 complete -c gottp -n '__fish_seen_subcommand_from import' -F
 
 # export flags
-complete -c gottp -n '__fish_seen_subcommand_from export' -l format -d 'Export format' -ra 'curl har postman insomnia'
+complete -c gottp -n '__fish_seen_subcommand_from export' -l format -d 'Export format' -ra 'curl har postman insomnia openapi shell http hurl'
 complete -c gottp -n '__fish_seen_subcommand_from export' -l request -d 'Export a single request by name' -r
+complete -c gottp -n '__fish_seen_subcommand_from export' -l folder -d 'Export the requests in a folder' -r
+complete -c gottp -n '__fish_seen_subcommand_from export' -l workflow -d 'Export the steps of a workflow' -r
+complete -c gottp -n '__fish_seen_subcommand_from export' -l env -d 'Use environment values as defaults' -r
 complete -c gottp -n '__fish_seen_subcommand_from export' -l output -d 'Output file path' -rF
 complete -c gottp -n '__fish_seen_subcommand_from export' -F
 
+# curl flags
+complete -c gottp -n '__fish_seen_subcommand_from curl' -l collection -d 'Collection for environments and --save' -rF
+complete -c gottp -n '__fish_seen_subcommand_from curl' -l env -d 'Environment name' -r
+complete -c gottp -n '__fish_seen_subcommand_from curl' -l save -d 'Append the request to the collection'
+complete -c gottp -n '__fish_seen_subcommand_from curl' -l name -d 'Name of the saved request' -r
+complete -c gottp -n '__fish_seen_subcommand_from curl' -l include -d 'Print the status line and headers'
+complete -c gottp -n '__fish_seen_subcommand_from curl' -l timeout -d 'Request timeout' -r
+
+# merge flags
+complete -c gottp -n '__fish_seen_subcommand_from merge' -s o -l output -d 'Output file path' -rF
+complete -c gottp -n '__fish_seen_subcommand_from merge' -l name -d 'Name of the merged collection' -r
+complete -c gottp -n '__fish_seen_subcommand_from merge' -l strict -d 'Exit 1 if any conflicts were found'
+complete -c gottp -n '__fish_seen_subcommand_from merge' -F
+
+# diff flags
+complete -c gottp -n '__fish_seen_subcommand_from diff' -l format -d 'Output format' -xa 'text json'
+complete -c gottp -n '__fish_seen_subcommand_from diff' -l exit-code -d 'Exit 1 if the collections differ'
+complete -c gottp -n '__fish_seen_subcommand_from diff' -F
+
 # completion - shell names
 complete -c gottp -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish' -d 'Shell type'
 `
@@ -33,7 +33,7 @@ func TestGenerateBashCompletion(t *testing.T) {
 	}
 
 	// Verify run flags are included
-	runFlags := []string{"--env", "--request", "--folder", "--workflow", "--output", "--verbose", "--timeout"}
+	runFlags := []string{"--env", "--request", "--folder", "--workflow", "--output", "--verbose", "--report", "--timeout"}
 	for _, flag := range runFlags {
 		if !strings.Contains(output, flag) {
 			t.Errorf("bash completion should contain run flag %q", flag)
@@ -41,7 +41,7 @@ func TestGenerateBashCompletion(t *testing.T) {
 	}
 
 	// Verify output format values
-	outputFormats := []string{"text", "json", "junit"}
+	outputFormats := []string{"text", "json", "junit", "tap", "github", "markdown"}
 	for _, fmt := range outputFormats {
 		if !strings.Contains(output, fmt) {
 			t.Errorf("bash completion should contain output format %q", fmt)
@@ -49,7 +49,7 @@ func TestGenerateBashCompletion(t *testing.T) {
 	}
 
 	// Verify export format values
-	exportFormats := []string{"curl", "har", "postman", "insomnia"}
+	exportFormats := []string{"curl", "har", "postman", "insomnia", "openapi", "shell"}
 	for _, fmt := range exportFormats {
 		if !strings.Contains(output, fmt) {
 			t.Errorf("bash completion should contain export format %q", fmt)
@@ -85,7 +85,7 @@ func TestGenerateZshCompletion(t *testing.T) {
 	}
 
 	// Verify run flags
-	runFlags := []string{"--env", "--request", "--folder", "--workflow", "--output", "--verbose", "--timeout"}
+	runFlags := []string{"--env", "--request", "--folder", "--workflow", "--output", "--verbose", "--report", "--timeout"}
 	for _, flag := range runFlags {
 		if !strings.Contains(output, flag) {
 			t.Errorf("zsh completion should contain run flag %q", flag)
@@ -93,13 +93,13 @@ func TestGenerateZshCompletion(t *testing.T) {
 	}
 
 	// Verify format value completions
-	if !strings.Contains(output, "(text json junit)") {
+	if !strings.Contains(output, "(text json junit tap github markdown)") {
 		t.Error("zsh completion should provide output format values")
 	}
-	if !strings.Contains(output, "(curl postman insomnia openapi har)") {
+	if !strings.Contains(output, "(curl postman insomnia openapi har http hurl)") {
 		t.Error("zsh completion should provide import format values")
 	}
-	if !strings.Contains(output, "(curl har postman insomnia)") {
+	if !strings.Contains(output, "(curl har postman insomnia openapi shell http hurl)") {
 		t.Error("zsh completion should provide export format values")
 	}
 }
@@ -140,7 +140,7 @@ func TestGenerateFishCompletion(t *testing.T) {
 	}
 
 	// Verify run flags
-	runFlags := []string{"env", "request", "folder", "workflow", "output", "verbose", "timeout"}
+	runFlags := []string{"env", "request", "folder", "workflow", "output", "verbose", "report", "timeout"}
 	for _, flag := range runFlags {
 		if !strings.Contains(output, "-l "+flag) {
 			t.Errorf("fish completion should contain run long flag %q", flag)
@@ -148,13 +148,13 @@ func TestGenerateFishCompletion(t *testing.T) {
 	}
 
 	// Verify format completions
-	if !strings.Contains(output, "'text json junit'") {
+	if !strings.Contains(output, "'text json junit tap github markdown'") {
 		t.Error("fish completion should provide output format values for run")
 	}
-	if !strings.Contains(output, "'curl har postman insomnia'") {
+	if !strings.Contains(output, "'curl har postman insomnia openapi shell http hurl'") {
 		t.Error("fish completion should provide export format values")
 	}
-	if !strings.Contains(output, "'curl postman insomnia openapi har'") {
+	if !strings.Contains(output, "'curl postman insomnia openapi har http hurl'") {
 		t.Error("fish completion should provide import format values")
 	}
 }
@@ -376,9 +376,10 @@ func TestReadStdin(t *testing.T) {
 
 func TestValidateHelpers(t *testing.T) {
 	items := []collection.Item{
-		{Request: &collection.Request{ID: "id-1", Name: "A", URL: "https://example.com/a"}},
+		{Request: &collection.Request{ID: "id-1", Name: "A", URL: "https://example.com/a",
+			Links: []collection.Link{{Name: "Runbook", URL: "https://wiki/runbook"}}}},
 		{Folder: &collection.Folder{Items: []collection.Item{
-			{Request: &collection.Request{ID: "id-1", Name: "B", URL: ""}},
+			{Request: &collection.Request{ID: "id-1", Name: "B", URL: "", Links: []collection.Link{{Name: "Dashboard"}}}},
 		}}},
 	}
 
@@ -395,6 +396,10 @@ func TestValidateHelpers(t *testing.T) {
 	if len(empty) != 1 || empty[0] != "B" {
 		t.Fatalf("unexpected empty URL list: %v", empty)
 	}
+
+	if bad := checkLinks(items); len(bad) != 1 || bad[0] != "B" {
+		t.Fatalf("unexpected link warnings: %v", bad)
+	}
 }
 
 func TestValidateEnvironmentAndFile(t *testing.T) {
@@ -479,7 +484,7 @@ items:
 	}
 
 	hasUnformatted := false
-	if err := formatFile(path, false, true, &hasUnformatted); err != nil {
+	if err := formatFile(path, false, true, "", &hasUnformatted); err != nil {
 		t.Fatalf("formatFile(check) failed: %v", err)
 	}
 	if !hasUnformatted {
@@ -487,11 +492,11 @@ items:
 	}
 
 	hasUnformatted = false
-	if err := formatFile(path, true, false, &hasUnformatted); err != nil {
+	if err := formatFile(path, true, false, "", &hasUnformatted); err != nil {
 		t.Fatalf("formatFile(write) failed: %v", err)
 	}
 
-	if err := formatFile(path, false, true, &hasUnformatted); err != nil {
+	if err := formatFile(path, false, true, "", &hasUnformatted); err != nil {
 		t.Fatalf("formatFile(check after write) failed: %v", err)
 	}
 	if hasUnformatted {
@@ -499,6 +504,47 @@ items:
 	}
 }
 
+func TestFormatFile_ReassignIDs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "api.gottp.yaml")
+	content := `name: API
+items:
+  - request:
+      id: same
+      name: One
+      method: GET
+      url: https://example.com/1
+  - request:
+      id: same
+      name: Two
+      method: GET
+      url: https://example.com/2
+  - request:
+      name: Three
+      method: GET
+      url: https://example.com/3
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hasUnformatted := false
+	if err := formatFile(path, true, false, collection.IDStyleSlug, &hasUnformatted); err != nil {
+		t.Fatalf("formatFile(reassign) failed: %v", err)
+	}
+	col, err := collection.LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, item := range col.Items {
+		ids = append(ids, item.Request.ID)
+	}
+	if got := strings.Join(ids, ","); got != "same,two,three" {
+		t.Errorf("ids = %s, want same,two,three", got)
+	}
+}
+
 func TestPrintHelp_WritesExpectedSections(t *testing.T) {
 	r, w, err := os.Pipe()
 	if err != nil {
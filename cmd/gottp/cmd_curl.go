@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/sadopc/gottp/internal/config"
+	"github.com/sadopc/gottp/internal/core/collection"
+	curlimport "github.com/sadopc/gottp/internal/import/curl"
+	"github.com/sadopc/gottp/internal/protocol"
+	"github.com/sadopc/gottp/internal/runner"
+)
+
+func curlCmd() {
+	fs := flag.NewFlagSet("curl", flag.ExitOnError)
+	collectionFlag := fs.String("collection", "", "Collection whose environments to use and --save to (default: the one in the current directory)")
+	envFlag := fs.String("env", "", "Environment name to use")
+	saveFlag := fs.Bool("save", false, "Append the request to the collection")
+	nameFlag := fs.String("name", "", "Name of the saved request (default: method and path)")
+	includeFlag := fs.Bool("include", false, "Print the status line and response headers before the body")
+	timeoutFlag := fs.Duration("timeout", 30*time.Second, "Request timeout")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gottp curl '<curl command>' [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Send a curl command through gottp. {{variables}} resolve from the\n")
+		fmt.Fprintf(os.Stderr, "collection's environment, the body goes to stdout and the status and\n")
+		fmt.Fprintf(os.Stderr, "timing to stderr. Pass - to read the command from stdin.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  gottp curl 'curl https://api.example.com/users'\n")
+		fmt.Fprintf(os.Stderr, "  gottp curl 'curl -H \"Authorization: Bearer {{token}}\" {{base_url}}/me' --env Staging\n")
+		fmt.Fprintf(os.Stderr, "  gottp curl 'curl -X POST -d name=Ada {{base_url}}/users' --save --name \"Create User\"\n")
+		fmt.Fprintf(os.Stderr, "  pbpaste | gottp curl - --save\n")
+	}
+
+	args, err := parseInterspersed(fs, os.Args[2:])
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: pass the curl command as one quoted argument\n\n")
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	command := args[0]
+	if command == "-" {
+		data, err := readStdin()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+			os.Exit(2)
+		}
+		command = string(data)
+	}
+	parsed, err := curlimport.ParseCurl(command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing curl command: %v\n", err)
+		os.Exit(2)
+	}
+	req := curlToRequest(parsed, *nameFlag)
+
+	colPath := *collectionFlag
+	if colPath == "" {
+		colPath = findCollection(".")
+	}
+	col := &collection.Collection{Name: "curl", Version: "1"}
+	dir := "."
+	if colPath != "" {
+		if col, err = collection.LoadFromFile(colPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading collection: %v\n", err)
+			os.Exit(2)
+		}
+		dir = filepath.Dir(colPath)
+	} else if *saveFlag {
+		fmt.Fprintf(os.Stderr, "Error: no collection to save to; use --collection\n")
+		os.Exit(2)
+	}
+
+	appCfg := config.Load()
+	r, err := runner.NewForCollection(col, dir, runner.Config{
+		Environment: *envFlag,
+		Timeout:     *timeoutFlag,
+		Retry:       appCfg.Retry,
+		Connections: appCfg.Connections,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	defer r.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result := r.RunRequest(ctx, req, true)
+	code := printCurlResult(os.Stdout, os.Stderr, result, *includeFlag)
+
+	if *saveFlag {
+		col.Items = append(col.Items, collection.Item{Request: req})
+		if err := collection.SaveToFile(col, colPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving collection: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "Saved %q to %s\n", req.Name, colPath)
+	}
+	if code != 0 {
+		r.Close()
+		os.Exit(code)
+	}
+}
+
+// curlToRequest converts a parsed curl command into a collection request,
+// named after its method and path unless name is given.
+func curlToRequest(parsed *protocol.Request, name string) *collection.Request {
+	if name == "" {
+		name = parsed.Method + " " + requestPath(parsed.URL)
+	}
+	req := collection.NewRequest(name, parsed.Method, parsed.URL)
+	for _, k := range slices.Sorted(maps.Keys(parsed.Headers)) {
+		req.Headers = append(req.Headers, collection.KVPair{Key: k, Value: parsed.Headers[k], Enabled: true})
+	}
+	for _, k := range slices.Sorted(maps.Keys(parsed.Params)) {
+		req.Params = append(req.Params, collection.KVPair{Key: k, Value: parsed.Params[k], Enabled: true})
+	}
+	if len(parsed.Body) > 0 {
+		kind := "text"
+		if json.Valid(parsed.Body) {
+			kind = "json"
+		}
+		req.Body = &collection.Body{Type: kind, Content: string(parsed.Body)}
+	}
+	if parsed.Auth != nil && parsed.Auth.Type == "basic" {
+		req.Auth = &collection.Auth{Type: "basic", Basic: &collection.BasicAuth{
+			Username: parsed.Auth.Username,
+			Password: parsed.Auth.Password,
+		}}
+	}
+	return req
+}
+
+// requestPath returns the path of rawURL for naming a request, falling
+// back to the URL itself when it has none or holds a {{variable}} host.
+func requestPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || u.Path == "" {
+		return rawURL
+	}
+	return u.Path
+}
+
+// printCurlResult writes the response body to out, preceded by the status
+// line and headers when include is set, and a summary to errOut. It
+// returns the exit code: 2 when the request failed, 1 when a test or
+// assertion failed.
+func printCurlResult(out, errOut io.Writer, result runner.Result, include bool) int {
+	if result.Error != nil {
+		fmt.Fprintf(errOut, "Error: %s\n", result.ErrorString)
+		return 2
+	}
+	if include {
+		fmt.Fprintln(out, result.Status)
+		for _, k := range slices.Sorted(maps.Keys(result.Headers)) {
+			for _, v := range result.Headers[k] {
+				fmt.Fprintf(out, "%s: %s\n", k, v)
+			}
+		}
+		fmt.Fprintln(out)
+	}
+	out.Write(result.Body)
+	if len(result.Body) > 0 && result.Body[len(result.Body)-1] != '\n' {
+		fmt.Fprintln(out)
+	}
+
+	fmt.Fprintf(errOut, "%s · %s · %d bytes\n", result.Status, result.Duration.Round(time.Millisecond), result.Size)
+	for _, line := range result.ScriptLogs {
+		fmt.Fprintf(errOut, "  log: %s\n", line)
+	}
+	if !result.TestsPassed {
+		return 1
+	}
+	return 0
+}
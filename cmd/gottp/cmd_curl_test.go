@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	curlimport "github.com/sadopc/gottp/internal/import/curl"
+	"github.com/sadopc/gottp/internal/runner"
+)
+
+func TestCurlToRequest(t *testing.T) {
+	parsed, err := curlimport.ParseCurl(`curl -X POST -u admin:pw -H 'X-B: 2' -H 'Content-Type: application/json' -d '{"a":1}' {{base_url}}/items`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := curlToRequest(parsed, "")
+	if req.Name != "POST {{base_url}}/items" {
+		t.Errorf("name = %q", req.Name)
+	}
+	if len(req.Headers) != 2 || req.Headers[0].Key != "Content-Type" || req.Headers[1].Key != "X-B" {
+		t.Errorf("headers should be sorted: %+v", req.Headers)
+	}
+	if req.Body == nil || req.Body.Type != "json" || req.Body.Content != `{"a":1}` {
+		t.Errorf("body = %+v", req.Body)
+	}
+	if req.Auth == nil || req.Auth.Basic == nil || req.Auth.Basic.Username != "admin" || req.Auth.Basic.Password != "pw" {
+		t.Errorf("auth = %+v", req.Auth)
+	}
+
+	parsed, _ = curlimport.ParseCurl(`curl -d name=Ada https://api.example.com/users?x=1`)
+	req = curlToRequest(parsed, "")
+	if req.Name != "POST /users" || req.Body.Type != "text" {
+		t.Errorf("name = %q, body type = %q", req.Name, req.Body.Type)
+	}
+	if req = curlToRequest(parsed, "Create User"); req.Name != "Create User" {
+		t.Errorf("name = %q, want the one given", req.Name)
+	}
+}
+
+func TestPrintCurlResult(t *testing.T) {
+	result := runner.Result{
+		StatusCode:  200,
+		Status:      "200 OK",
+		Body:        []byte(`{"ok":true}`),
+		Headers:     http.Header{"Content-Type": {"application/json"}, "X-Id": {"7"}},
+		Size:        11,
+		TestsPassed: true,
+	}
+	var out, errOut bytes.Buffer
+	if code := printCurlResult(&out, &errOut, result, false); code != 0 {
+		t.Errorf("exit code = %d", code)
+	}
+	if out.String() != "{\"ok\":true}\n" {
+		t.Errorf("stdout = %q, want only the body", out.String())
+	}
+	if !strings.Contains(errOut.String(), "200 OK") || !strings.Contains(errOut.String(), "11 bytes") {
+		t.Errorf("stderr = %q", errOut.String())
+	}
+
+	out.Reset()
+	printCurlResult(&out, &errOut, result, true)
+	if !strings.HasPrefix(out.String(), "200 OK\nContent-Type: application/json\nX-Id: 7\n\n{") {
+		t.Errorf("--include output = %q", out.String())
+	}
+
+	result.TestsPassed = false
+	if code := printCurlResult(&out, &errOut, result, false); code != 1 {
+		t.Errorf("failed tests should exit 1, got %d", code)
+	}
+	failed := runner.Result{Error: errors.New("refused"), ErrorString: "dial tcp: refused"}
+	errOut.Reset()
+	if code := printCurlResult(&out, &errOut, failed, false); code != 2 || !strings.Contains(errOut.String(), "refused") {
+		t.Errorf("exit code = %d, stderr = %q", code, errOut.String())
+	}
+}
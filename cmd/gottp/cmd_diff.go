@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+func diffCmd() {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	formatFlag := fs.String("format", "text", "Output format: text, json")
+	exitCodeFlag := fs.Bool("exit-code", false, "Exit 1 if the collections differ")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gottp diff <old.gottp.yaml> <new.gottp.yaml> [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Compare two versions of a collection.\n\n")
+		fmt.Fprintf(os.Stderr, "Lists added, removed and changed requests with the fields that changed,\n")
+		fmt.Fprintf(os.Stderr, "such as method, URL, headers, body and auth, plus changes to collection\n")
+		fmt.Fprintf(os.Stderr, "variables, auth and workflows. Requests are paired by ID, then by path, then\n")
+		fmt.Fprintf(os.Stderr, "by method and URL, so renames and re-imports show as changes.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  gottp diff api.gottp.yaml imported.gottp.yaml\n")
+		fmt.Fprintf(os.Stderr, "  gottp diff <(git show main:api.gottp.yaml) api.gottp.yaml\n")
+		fmt.Fprintf(os.Stderr, "  gottp diff old.gottp.yaml new.gottp.yaml --format json --exit-code\n")
+	}
+
+	paths, err := parseInterspersed(fs, os.Args[2:])
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(paths) != 2 {
+		fmt.Fprintf(os.Stderr, "Error: two collection files are required\n\n")
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *formatFlag != "text" && *formatFlag != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (use text or json)\n", *formatFlag)
+		os.Exit(2)
+	}
+
+	var cols [2]*collection.Collection
+	for i, path := range paths {
+		// Missing IDs stay empty rather than differing at random
+		col, err := collection.ParseFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", path, err)
+			os.Exit(2)
+		}
+		cols[i] = col
+	}
+
+	d := collection.Diff(cols[0], cols[1])
+	if *formatFlag == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+	} else {
+		writeDiff(os.Stdout, d)
+	}
+
+	if *exitCodeFlag && !d.Empty() {
+		os.Exit(1)
+	}
+}
+
+// writeDiff prints d for reading, one line per request marked + (added),
+// - (removed) or ~ (changed), with the changed fields indented below.
+func writeDiff(w io.Writer, d collection.CollectionDiff) {
+	if d.Empty() {
+		fmt.Fprintln(w, "No differences")
+		return
+	}
+	if len(d.Changes) > 0 {
+		fmt.Fprintln(w, "Collection")
+		writeFieldChanges(w, d.Changes)
+		fmt.Fprintln(w)
+	}
+
+	counts := make(map[string]int)
+	for _, r := range d.Requests {
+		counts[r.Kind]++
+		mark := map[string]string{collection.DiffAdded: "+", collection.DiffRemoved: "-", collection.DiffChanged: "~"}[r.Kind]
+		fmt.Fprintf(w, "%s %-7s %s  %s\n", mark, r.Method, r.Path, r.URL)
+		writeFieldChanges(w, r.Changes)
+	}
+	if len(d.Requests) > 0 {
+		fmt.Fprintf(w, "\n%d added, %d removed, %d changed\n",
+			counts[collection.DiffAdded], counts[collection.DiffRemoved], counts[collection.DiffChanged])
+	}
+}
+
+// writeFieldChanges prints changes as "field: old -> new", or old and new
+// lines marked - and + when either value spans several lines.
+func writeFieldChanges(w io.Writer, changes []collection.FieldChange) {
+	show := func(v string) string {
+		if v == "" {
+			return "(none)"
+		}
+		return v
+	}
+	for _, c := range changes {
+		if !strings.Contains(c.Old, "\n") && !strings.Contains(c.New, "\n") {
+			fmt.Fprintf(w, "    %s: %s -> %s\n", c.Field, show(c.Old), show(c.New))
+			continue
+		}
+		fmt.Fprintf(w, "    %s:\n", c.Field)
+		for _, line := range strings.Split(strings.TrimRight(c.Old, "\n"), "\n") {
+			if c.Old != "" {
+				fmt.Fprintf(w, "      - %s\n", line)
+			}
+		}
+		for _, line := range strings.Split(strings.TrimRight(c.New, "\n"), "\n") {
+			if c.New != "" {
+				fmt.Fprintf(w, "      + %s\n", line)
+			}
+		}
+	}
+}
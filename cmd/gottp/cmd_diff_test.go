@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+func TestWriteDiff(t *testing.T) {
+	var buf bytes.Buffer
+	writeDiff(&buf, collection.CollectionDiff{})
+	if buf.String() != "No differences\n" {
+		t.Errorf("empty diff = %q", buf.String())
+	}
+
+	buf.Reset()
+	writeDiff(&buf, collection.CollectionDiff{
+		Changes: []collection.FieldChange{{Field: "variables.token", New: "abc"}},
+		Requests: []collection.RequestDiff{
+			{Kind: collection.DiffAdded, Path: "/Users/Delete", Method: "DELETE", URL: "/users/1"},
+			{Kind: collection.DiffChanged, Path: "/Users/List", Method: "GET", URL: "/users", Changes: []collection.FieldChange{
+				{Field: "url", Old: "/people", New: "/users"},
+				{Field: "body.content", Old: "{\n  \"a\": 1\n}", New: "{}"},
+			}},
+			{Kind: collection.DiffRemoved, Path: "/Old", Method: "GET", URL: "/old"},
+		},
+	})
+	want := `Collection
+    variables.token: (none) -> abc
+
++ DELETE  /Users/Delete  /users/1
+~ GET     /Users/List  /users
+    url: /people -> /users
+    body.content:
+      - {
+      -   "a": 1
+      - }
+      + {}
+- GET     /Old  /old
+
+1 added, 1 removed, 1 changed
+`
+	if got := buf.String(); got != want {
+		t.Errorf("diff output:\n%s\nwant:\n%s", got, want)
+	}
+}
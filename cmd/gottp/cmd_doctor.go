@@ -0,0 +1,462 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sadopc/gottp/internal/config"
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/environment"
+	gotls "github.com/sadopc/gottp/internal/core/tls"
+	"github.com/sadopc/gottp/internal/protocol"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// Outcomes of a doctor check.
+const (
+	checkOK   = "OK"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+)
+
+// doctorCheck is the result of one doctor check, with a suggested fix for
+// warnings and failures.
+type doctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+	Fix    string
+}
+
+// baseURL is a URL variable of an environment or the collection.
+type baseURL struct {
+	Env   string // "" for collection variables
+	Var   string
+	URL   string
+	Proxy *protocol.ProxyConfig
+	TLS   *gotls.Config
+	Hosts protocol.Hosts
+}
+
+func doctorCmd() {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	timeoutFlag := fs.Duration("timeout", 5*time.Second, "Timeout for each network check")
+	offlineFlag := fs.Bool("offline", false, "Skip network checks")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gottp doctor [collection.gottp.yaml] [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Check the gottp setup and suggest fixes: config file, data directory,\n")
+		fmt.Fprintf(os.Stderr, "clipboard, editor, terminal, and whether the base URLs of the\n")
+		fmt.Fprintf(os.Stderr, "collection's environments are reachable through the configured proxy.\n")
+		fmt.Fprintf(os.Stderr, "Without a collection argument, the first *.gottp.yaml in the current\n")
+		fmt.Fprintf(os.Stderr, "directory is used.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExit codes:\n")
+		fmt.Fprintf(os.Stderr, "  0  No check failed (warnings are allowed)\n")
+		fmt.Fprintf(os.Stderr, "  1  One or more checks failed\n")
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		os.Exit(2)
+	}
+
+	var checks []doctorCheck
+	cfg := config.Load()
+	checks = append(checks, checkConfigFile(config.Path()))
+	checks = append(checks, checkDataDir(config.DataDir()))
+	checks = append(checks, checkClipboard())
+	checks = append(checks, checkEditor(cfg.Editor, os.Getenv("EDITOR")))
+	checks = append(checks, checkTerminal(cfg.ColorProfile))
+
+	colPath := fs.Arg(0)
+	if colPath == "" {
+		matches, _ := filepath.Glob("*.gottp.yaml")
+		if len(matches) > 0 {
+			colPath = matches[0]
+		}
+	}
+	switch {
+	case colPath == "":
+		checks = append(checks, doctorCheck{Name: "Collection", Status: checkOK, Detail: "none in the current directory; network checks skipped"})
+	case *offlineFlag:
+		checks = append(checks, doctorCheck{Name: "Network", Status: checkOK, Detail: "skipped (--offline)"})
+	default:
+		col, err := collection.LoadFromFile(colPath)
+		if err != nil {
+			checks = append(checks, doctorCheck{Name: "Collection", Status: checkFail, Detail: err.Error(), Fix: "run gottp validate " + colPath + " for details"})
+			break
+		}
+		if _, err := environment.LoadDotEnv(filepath.Join(filepath.Dir(colPath), ".env")); err != nil {
+			checks = append(checks, doctorCheck{Name: "Environments", Status: checkFail, Detail: err.Error(), Fix: "use KEY=VALUE lines in the .env file"})
+			break
+		}
+		ef, err := environment.LoadEnvironments(filepath.Join(filepath.Dir(colPath), "environments.yaml"))
+		if err != nil {
+			checks = append(checks, doctorCheck{Name: "Environments", Status: checkFail, Detail: err.Error(), Fix: "run gottp validate " + colPath + " for details"})
+			break
+		}
+		global := &protocol.ProxyConfig{URL: cfg.ProxyURL, NoProxy: cfg.NoProxy}
+		urls := collectBaseURLs(col, ef, global, &cfg.TLS, filepath.Dir(colPath))
+		if len(urls) == 0 {
+			checks = append(checks, doctorCheck{Name: "Network", Status: checkOK, Detail: "no http(s) URL variables in " + colPath})
+		}
+		checks = append(checks, checkBaseURLs(context.Background(), urls, *timeoutFlag)...)
+	}
+
+	failed := printDoctor(os.Stdout, checks)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// printDoctor writes the checks and returns how many failed.
+func printDoctor(w io.Writer, checks []doctorCheck) int {
+	failed := 0
+	for _, c := range checks {
+		fmt.Fprintf(w, "%-4s %-12s %s\n", c.Status, c.Name, c.Detail)
+		if c.Fix != "" {
+			fmt.Fprintf(w, "     %-12s fix: %s\n", "", c.Fix)
+		}
+		if c.Status == checkFail {
+			failed++
+		}
+	}
+	return failed
+}
+
+// checkConfigFile reports syntax errors, unknown keys and invalid values in
+// the config file. A missing file is fine: the defaults apply.
+func checkConfigFile(path string) doctorCheck {
+	c := doctorCheck{Name: "Config", Status: checkOK, Detail: path}
+	if path == "" {
+		c.Status, c.Detail = checkWarn, "home directory unknown; using defaults"
+		c.Fix = "set $HOME"
+		return c
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		c.Detail = path + " not found; using defaults"
+		return c
+	}
+	if err != nil {
+		c.Status, c.Detail, c.Fix = checkFail, err.Error(), "make the file readable: chmod u+r "+path
+		return c
+	}
+
+	cfg := config.DefaultConfig()
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		c.Status, c.Detail = checkFail, fmt.Sprintf("%s: %v", path, err)
+		c.Fix = "fix the YAML syntax; gottp currently ignores the whole file"
+		return c
+	}
+
+	var problems, fixes []string
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&config.Config{}); err != nil && !errors.Is(err, io.EOF) {
+		problems = append(problems, strings.TrimPrefix(err.Error(), "yaml: unmarshal errors:\n  "))
+		fixes = append(fixes, "remove or rename unknown keys")
+	}
+	if _, ok := theme.Get(cfg.Theme); !ok && cfg.Theme != "" {
+		dir := filepath.Join(filepath.Dir(path), "themes")
+		if _, ok := theme.LoadCustomThemes(dir)[strings.ToLower(strings.ReplaceAll(cfg.Theme, " ", "-"))]; !ok {
+			names := theme.Names()
+			sort.Strings(names)
+			problems = append(problems, fmt.Sprintf("unknown theme %q", cfg.Theme))
+			fixes = append(fixes, "use one of "+strings.Join(names, ", ")+" or add it to "+dir)
+		}
+	}
+	if _, err := theme.DetectProfile(cfg.ColorProfile); err != nil {
+		problems = append(problems, err.Error())
+		fixes = append(fixes, "set color_profile to auto, truecolor, 256, 16 or none")
+	}
+	if cfg.HeadlessAction != "" && !slices.Contains(config.HeadlessActions, cfg.HeadlessAction) {
+		problems = append(problems, fmt.Sprintf("unknown headless_action %q", cfg.HeadlessAction))
+		fixes = append(fixes, "set headless_action to "+strings.Join(config.HeadlessActions, ", "))
+	}
+	if cfg.IDStyle != "" && !slices.Contains(collection.IDStyles, cfg.IDStyle) {
+		problems = append(problems, fmt.Sprintf("unknown id_style %q", cfg.IDStyle))
+		fixes = append(fixes, "set id_style to "+strings.Join(collection.IDStyles, ", "))
+	}
+	if cfg.DownloadThreshold != "" && cfg.DownloadThreshold != "0" && cfg.DownloadThresholdBytes() == 0 {
+		problems = append(problems, fmt.Sprintf("invalid download_threshold %q", cfg.DownloadThreshold))
+		fixes = append(fixes, `use a size such as "10MB", or "0" to disable`)
+	}
+	if cfg.ProxyURL != "" {
+		if _, err := protocol.ProxyTransport(&protocol.ProxyConfig{URL: cfg.ProxyURL}); err != nil {
+			problems = append(problems, "proxy_url: "+err.Error())
+			fixes = append(fixes, "use an http://, https:// or socks5:// proxy URL")
+		}
+	}
+	if len(problems) > 0 {
+		c.Status = checkWarn
+		c.Detail = path + ": " + strings.Join(problems, "; ")
+		c.Fix = strings.Join(fixes, "; ")
+	}
+	return c
+}
+
+// checkDataDir verifies that history, state and token caches can be
+// written.
+func checkDataDir(dir string) doctorCheck {
+	c := doctorCheck{Name: "Data dir", Status: checkOK, Detail: dir}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		c.Fix = "create it with mkdir -p " + dir + " and make it yours: chown -R $USER " + dir
+		return c
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		c.Status, c.Detail = checkFail, "not writable: "+err.Error()
+		c.Fix = "chmod u+rwx " + dir + " (history and sessions are not saved until then)"
+		return c
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return c
+}
+
+// checkClipboard reports whether copy and paste work.
+func checkClipboard() doctorCheck {
+	c := doctorCheck{Name: "Clipboard", Status: checkOK, Detail: "available"}
+	if clipboard.Unsupported {
+		c.Status, c.Detail = checkWarn, "no clipboard utility found; copy and paste actions will fail"
+		c.Fix = "install wl-clipboard (Wayland), xclip or xsel (X11)"
+		return c
+	}
+	if _, err := clipboard.ReadAll(); err != nil {
+		c.Status, c.Detail = checkWarn, "clipboard not readable: "+err.Error()
+		c.Fix = "check that a display is available ($DISPLAY or $WAYLAND_DISPLAY)"
+	}
+	return c
+}
+
+// checkEditor reports the editor opened for bodies and request sources,
+// resolved like the TUI does: config editor, then $EDITOR, then vi.
+func checkEditor(cfgEditor, envEditor string) doctorCheck {
+	c := doctorCheck{Name: "Editor", Status: checkOK}
+	editor, source := cfgEditor, "config editor"
+	switch {
+	case editor != "":
+	case envEditor != "":
+		editor, source = envEditor, "$EDITOR"
+	default:
+		editor, source = "vi", "default, $EDITOR is not set"
+	}
+	c.Detail = fmt.Sprintf("%s (%s)", editor, source)
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		c.Status, c.Fix = checkWarn, "set editor: in config.yaml or export EDITOR"
+		return c
+	}
+	if len(fields) > 1 {
+		// The TUI runs the editor setting as a single program name
+		c.Status = checkWarn
+		c.Detail += ": arguments are not supported"
+		c.Fix = "wrap the command in a script, or use an editor that needs no flags"
+		return c
+	}
+	if _, err := exec.LookPath(fields[0]); err != nil {
+		c.Status = checkFail
+		c.Detail += ": not found in $PATH"
+		c.Fix = "install it, or set editor: in config.yaml or export EDITOR to an installed editor"
+	}
+	return c
+}
+
+// checkTerminal reports whether stdout is a terminal and which colors are
+// used.
+func checkTerminal(colorProfile string) doctorCheck {
+	c := doctorCheck{Name: "Terminal", Status: checkOK}
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		c.Status, c.Detail = checkWarn, "stdout is not a terminal"
+		c.Fix = "run gottp in an interactive terminal; use gottp run for scripts"
+		return c
+	}
+	profile, err := theme.DetectProfile(colorProfile)
+	if err != nil {
+		profile = termenv.EnvColorProfile()
+	}
+	colors := map[termenv.Profile]string{
+		termenv.TrueColor: "true color",
+		termenv.ANSI256:   "256 colors",
+		termenv.ANSI:      "16 colors",
+		termenv.Ascii:     "no colors",
+	}[profile]
+	w, h, _ := term.GetSize(os.Stdout.Fd())
+	c.Detail = fmt.Sprintf("TERM=%s, %s, %dx%d", os.Getenv("TERM"), colors, w, h)
+	if w < 80 || h < 24 {
+		c.Status = checkWarn
+		c.Fix = "enlarge the window to at least 80x24 for the three-panel layout"
+	}
+	if profile == termenv.Ascii && os.Getenv("NO_COLOR") == "" && colorProfile != "none" {
+		c.Status = checkWarn
+		c.Fix = "set TERM=xterm-256color, or color_profile: 256 in config.yaml"
+	}
+	return c
+}
+
+// collectBaseURLs returns the http(s) URL variables of every environment
+// and the collection, each with the proxy and TLS settings its requests
+// would use. Relative certificate paths of environments are resolved
+// against dir.
+func collectBaseURLs(col *collection.Collection, ef *environment.EnvironmentFile, global *protocol.ProxyConfig, globalTLS *gotls.Config, dir string) []baseURL {
+	var urls []baseURL
+	seen := make(map[string]bool)
+	add := func(env string, vars map[string]string, proxy *protocol.ProxyConfig, tlsConf *gotls.Config, hosts protocol.Hosts) {
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			u := environment.Resolve(vars[name], vars, col.Variables)
+			if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") || strings.Contains(u, "{{") {
+				continue
+			}
+			key := u + "\x00" + proxy.Label() + "\x00" + hosts.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			urls = append(urls, baseURL{Env: env, Var: name, URL: u, Proxy: proxy, TLS: tlsConf, Hosts: hosts})
+		}
+	}
+	for _, name := range ef.Names() {
+		vars := ef.GetVariables(name)
+		proxy := global
+		if ep := ef.GetProxy(name); ep != nil {
+			proxy = &protocol.ProxyConfig{
+				URL:      environment.Resolve(ep.URL, vars, col.Variables),
+				NoProxy:  ep.NoProxy,
+				Username: environment.Resolve(ep.Username, vars, col.Variables),
+				Password: environment.Resolve(ep.Password, vars, col.Variables),
+			}
+		}
+		var envTLS *gotls.Config
+		if t := ef.GetTLS(name); t != nil {
+			copied := *t
+			copied.ResolvePaths(dir)
+			envTLS = &copied
+		}
+		var hosts protocol.Hosts
+		for k, v := range ef.GetHosts(name) {
+			if hosts == nil {
+				hosts = make(protocol.Hosts)
+			}
+			hosts[k] = environment.Resolve(v, vars, col.Variables)
+		}
+		add(name, vars, proxy, globalTLS.Merge(envTLS), hosts)
+	}
+	add("", col.Variables, global, globalTLS, nil)
+	return urls
+}
+
+// checkBaseURLs requests each URL concurrently. Any HTTP response, even an
+// error status, means the server is reachable.
+func checkBaseURLs(ctx context.Context, urls []baseURL, timeout time.Duration) []doctorCheck {
+	checks := make([]doctorCheck, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checks[i] = checkBaseURL(ctx, u, timeout)
+		}()
+	}
+	wg.Wait()
+	return checks
+}
+
+func checkBaseURL(ctx context.Context, u baseURL, timeout time.Duration) doctorCheck {
+	where := "{{" + u.Var + "}}"
+	if u.Env != "" {
+		where = u.Env + " " + where
+	}
+	via := ""
+	if label := u.Proxy.Label(); label != "" {
+		via = " via " + label
+	}
+	c := doctorCheck{Name: "Network", Status: checkOK}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !u.TLS.IsEmpty() {
+		tlsConf, err := u.TLS.BuildTLSConfig()
+		if err != nil {
+			c.Status, c.Detail = checkFail, fmt.Sprintf("%s: tls: %v", where, err)
+			c.Fix = "fix the certificate paths in config.yaml or environments.yaml"
+			return c
+		}
+		transport.TLSClientConfig = tlsConf
+	}
+	if err := u.Proxy.Apply(transport); err != nil {
+		c.Status, c.Detail = checkFail, fmt.Sprintf("%s: proxy: %v", where, err)
+		c.Fix = "fix the proxy URL in config.yaml or environments.yaml"
+		return c
+	}
+	u.Hosts.Apply(transport)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.URL, nil)
+	if err != nil {
+		c.Status, c.Detail, c.Fix = checkFail, fmt.Sprintf("%s: %v", where, err), "fix the URL in environments.yaml"
+		return c
+	}
+	start := time.Now()
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		c.Status = checkFail
+		c.Detail = fmt.Sprintf("%s %s%s: %v", where, u.URL, via, unwrapURLError(err))
+		c.Fix = networkFix(err, via != "")
+		return c
+	}
+	resp.Body.Close()
+	c.Detail = fmt.Sprintf("%s %s%s: %s in %s", where, u.URL, via, resp.Status, time.Since(start).Round(time.Millisecond))
+	return c
+}
+
+func unwrapURLError(err error) error {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		return ue.Err
+	}
+	return err
+}
+
+// networkFix suggests a fix for a failed request.
+func networkFix(err error, proxied bool) string {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "certificate"):
+		return "trust the server's CA with tls.ca_file, or set tls.insecure_skip_verify for development servers"
+	case strings.Contains(msg, "no such host"):
+		return "check the host name, DNS, or VPN connection"
+	case proxied && (strings.Contains(msg, "proxy") || strings.Contains(msg, "socks")):
+		return "check that the proxy is running and its credentials, or add the host to no_proxy"
+	case errors.Is(err, context.DeadlineExceeded) || strings.Contains(msg, "timeout"):
+		return "the server did not answer in time; check firewalls, VPN and proxy settings, or raise --timeout"
+	case strings.Contains(msg, "connection refused"):
+		return "nothing is listening there; start the server or fix the port"
+	}
+	return "check the URL and your network connection"
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/environment"
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+func TestCheckConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	if c := checkConfigFile(path); c.Status != checkOK || !strings.Contains(c.Detail, "using defaults") {
+		t.Errorf("missing file: %+v", c)
+	}
+
+	os.WriteFile(path, []byte("theme: nord\nvim_mode: [\n"), 0o644)
+	if c := checkConfigFile(path); c.Status != checkFail || c.Fix == "" {
+		t.Errorf("broken YAML: %+v", c)
+	}
+
+	os.WriteFile(path, []byte("theme: solarized\nvim_mod: true\ncolor_profile: 8\nid_style: uuid\n"), 0o644)
+	c := checkConfigFile(path)
+	if c.Status != checkWarn {
+		t.Fatalf("invalid values: %+v", c)
+	}
+	for _, want := range []string{"vim_mod", `unknown theme "solarized"`, "color"} {
+		if !strings.Contains(c.Detail, want) {
+			t.Errorf("detail missing %q: %s", want, c.Detail)
+		}
+	}
+	if strings.Contains(c.Detail, "id_style") {
+		t.Errorf("valid id_style reported: %s", c.Detail)
+	}
+
+	os.WriteFile(path, []byte("theme: Tokyo Night\ndownload_threshold: 0\n"), 0o644)
+	if c := checkConfigFile(path); c.Status != checkOK {
+		t.Errorf("valid config: %+v", c)
+	}
+}
+
+func TestCheckDataDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "gottp")
+	if c := checkDataDir(dir); c.Status != checkOK {
+		t.Errorf("new dir: %+v", c)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("probe file left behind: %v", entries)
+	}
+
+	file := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(file, nil, 0o644)
+	if c := checkDataDir(filepath.Join(file, "gottp")); c.Status != checkFail || c.Fix == "" {
+		t.Errorf("dir under a file: %+v", c)
+	}
+}
+
+func TestCheckEditor(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh in PATH")
+	}
+	if c := checkEditor("", sh); c.Status != checkOK || !strings.Contains(c.Detail, "$EDITOR") {
+		t.Errorf("$EDITOR: %+v", c)
+	}
+	if c := checkEditor("no-such-editor-gottp", sh); c.Status != checkFail || !strings.Contains(c.Detail, "config editor") {
+		t.Errorf("missing config editor: %+v", c)
+	}
+	if c := checkEditor("code --wait", ""); c.Status != checkWarn {
+		t.Errorf("editor with flags: %+v", c)
+	}
+	if c := checkEditor("", ""); !strings.Contains(c.Detail, "vi (default") {
+		t.Errorf("fallback: %+v", c)
+	}
+}
+
+func TestCheckBaseURLs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	// A port nothing listens on
+	lis, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := "http://" + lis.Addr().String()
+	lis.Close()
+
+	col := &collection.Collection{Variables: map[string]string{"docs": "https://docs.invalid/{{missing}}", "host": "localhost"}}
+	ef := &environment.EnvironmentFile{Environments: []environment.Environment{
+		{Name: "Dev", Variables: map[string]environment.Variable{
+			"baseUrl": {Value: ts.URL},
+			"apiUrl":  {Value: "{{baseUrl}}/v1"},
+			"token":   {Value: "abc"},
+		}},
+		{Name: "Down", Variables: map[string]environment.Variable{"baseUrl": {Value: closed}}},
+		{Name: "Bad proxy", Variables: map[string]environment.Variable{"baseUrl": {Value: ts.URL}},
+			Proxy: &environment.Proxy{URL: "ftp://proxy"}},
+	}}
+	urls := collectBaseURLs(col, ef, &protocol.ProxyConfig{}, nil, "")
+	if len(urls) != 4 {
+		t.Fatalf("expected 4 URLs, got %+v", urls)
+	}
+	if urls[0].Var != "apiUrl" || urls[0].URL != ts.URL+"/v1" {
+		t.Errorf("variables not resolved: %+v", urls[0])
+	}
+
+	checks := checkBaseURLs(context.Background(), urls, 2*time.Second)
+	want := []string{checkOK, checkOK, checkFail, checkFail}
+	for i, c := range checks {
+		if c.Status != want[i] {
+			t.Errorf("%s: status %s, want %s (%s)", urls[i].URL, c.Status, want[i], c.Detail)
+		}
+	}
+	if !strings.Contains(checks[1].Detail, "401") {
+		t.Errorf("error statuses count as reachable: %s", checks[1].Detail)
+	}
+	if !strings.Contains(checks[2].Fix, "nothing is listening") {
+		t.Errorf("refused fix: %s", checks[2].Fix)
+	}
+	if !strings.Contains(checks[3].Detail, "proxy") {
+		t.Errorf("bad proxy: %s", checks[3].Detail)
+	}
+
+	var out strings.Builder
+	if failed := printDoctor(&out, checks); failed != 2 {
+		t.Errorf("printDoctor counted %d failures", failed)
+	}
+	if !strings.Contains(out.String(), "FAIL Network") || !strings.Contains(out.String(), "fix: ") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
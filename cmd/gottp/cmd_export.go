@@ -5,45 +5,65 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/environment"
 	"github.com/sadopc/gottp/internal/export"
 	harexport "github.com/sadopc/gottp/internal/export/har"
+	httpfileexport "github.com/sadopc/gottp/internal/export/httpfile"
+	hurlexport "github.com/sadopc/gottp/internal/export/hurl"
 	insomniaexport "github.com/sadopc/gottp/internal/export/insomnia"
+	openapiexport "github.com/sadopc/gottp/internal/export/openapi"
 	postmanexport "github.com/sadopc/gottp/internal/export/postman"
+	shellexport "github.com/sadopc/gottp/internal/export/shell"
 	"github.com/sadopc/gottp/internal/protocol"
 )
 
 func exportCmd() {
 	fs := flag.NewFlagSet("export", flag.ExitOnError)
-	formatFlag := fs.String("format", "curl", "Export format: curl, har, postman, insomnia")
+	formatFlag := fs.String("format", "curl", "Export format: curl, har, postman, insomnia, openapi, shell, http, hurl")
 	requestFlag := fs.String("request", "", "Export a single request by name")
+	folderFlag := fs.String("folder", "", "Export the requests in a folder (shell)")
+	workflowFlag := fs.String("workflow", "", "Export a workflow's steps (shell)")
+	envFlag := fs.String("env", "", "Use an environment's values as variable defaults (shell)")
 	outputFlag := fs.String("output", "", "Output file path (default: stdout)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: gottp export <collection.gottp.yaml> [flags]\n\n")
 		fmt.Fprintf(os.Stderr, "Export a collection to various formats.\n\n")
-		fmt.Fprintf(os.Stderr, "Supported formats: curl, har, postman, insomnia\n\n")
+		fmt.Fprintf(os.Stderr, "Supported formats: curl, har, postman, insomnia, openapi, shell, http, hurl\n\n")
+		fmt.Fprintf(os.Stderr, "The shell format writes a bash script of curl calls for the collection, a\n")
+		fmt.Fprintf(os.Stderr, "folder, a request or a workflow. {{variables}} are read from the environment,\n")
+		fmt.Fprintf(os.Stderr, "defaulting to collection values, and values workflows extract or scripts\n")
+		fmt.Fprintf(os.Stderr, "capture with gottp.query are read from responses with jq.\n\n")
+		fmt.Fprintf(os.Stderr, "The http format writes an .http file for the JetBrains HTTP Client and the\n")
+		fmt.Fprintf(os.Stderr, "VS Code REST Client; hurl writes a Hurl file, with assertions as asserts.\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		fs.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  gottp export api.gottp.yaml --format curl\n")
 		fmt.Fprintf(os.Stderr, "  gottp export api.gottp.yaml --format har --output api.har\n")
 		fmt.Fprintf(os.Stderr, "  gottp export api.gottp.yaml --format curl --request \"Get Users\"\n")
+		fmt.Fprintf(os.Stderr, "  gottp export api.gottp.yaml --format openapi --output openapi.json\n")
+		fmt.Fprintf(os.Stderr, "  gottp export api.gottp.yaml --format shell --folder Users --output users.sh\n")
+		fmt.Fprintf(os.Stderr, "  gottp export api.gottp.yaml --format shell --workflow \"Sign up\" --env Local\n")
+		fmt.Fprintf(os.Stderr, "  gottp export api.gottp.yaml --format hurl --output api.hurl\n")
 	}
 
-	if err := fs.Parse(os.Args[2:]); err != nil {
+	args, err := parseInterspersed(fs, os.Args[2:])
+	if err != nil {
 		os.Exit(1)
 	}
 
-	if fs.NArg() < 1 {
+	if len(args) < 1 {
 		fmt.Fprintf(os.Stderr, "Error: collection file path is required\n\n")
 		fs.Usage()
 		os.Exit(1)
 	}
 
-	colPath := fs.Arg(0)
+	colPath := args[0]
 	col, err := collection.LoadFromFile(colPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading collection: %v\n", err)
@@ -83,7 +103,31 @@ func exportCmd() {
 		out = f
 	}
 
+	count := len(requests)
 	switch *formatFlag {
+	case "shell":
+		if *outputFlag != "" {
+			_ = out.Chmod(0755)
+		}
+		steps, err := shellSteps(col, *folderFlag, *workflowFlag, requests)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defaults, err := shellDefaults(col, colPath, *envFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		title := col.Name
+		switch {
+		case *workflowFlag != "":
+			title += ": " + *workflowFlag
+		case *folderFlag != "":
+			title += ": " + *folderFlag
+		}
+		_, _ = out.Write(shellexport.Export(title, steps, defaults))
+		count = len(steps)
 	case "curl":
 		exportAsCurl(out, requests)
 	case "har":
@@ -92,14 +136,78 @@ func exportCmd() {
 		exportAsPostman(out, col)
 	case "insomnia":
 		exportAsInsomnia(out, col)
+	case "openapi":
+		exportAsOpenAPI(out, col)
+	case "http", "hurl":
+		exportAsText(out, *formatFlag, filterCollection(col, requests))
 	default:
-		fmt.Fprintf(os.Stderr, "Error: unsupported format %q (use curl, har, postman, or insomnia)\n", *formatFlag)
+		fmt.Fprintf(os.Stderr, "Error: unsupported format %q (use curl, har, postman, insomnia, openapi, shell, http, or hurl)\n", *formatFlag)
 		os.Exit(1)
 	}
 
 	if *outputFlag != "" {
-		fmt.Fprintf(os.Stderr, "Exported %d requests to %s\n", len(requests), *outputFlag)
+		fmt.Fprintf(os.Stderr, "Exported %d requests to %s\n", count, *outputFlag)
+	}
+}
+
+// shellSteps picks the requests a shell export covers: a workflow, a
+// folder, the requests named with --request, or the whole collection.
+func shellSteps(col *collection.Collection, folder, workflow string, requests []*collection.Request) ([]shellexport.Step, error) {
+	if workflow != "" {
+		for _, wf := range col.Workflows {
+			if strings.EqualFold(wf.Name, workflow) {
+				return shellexport.WorkflowSteps(col, wf)
+			}
+		}
+		return nil, fmt.Errorf("workflow %q not found in collection", workflow)
+	}
+	if folder != "" {
+		for _, item := range collection.FlattenItems(col.Items, 0, "") {
+			if item.IsFolder && (strings.EqualFold(item.Folder.Name, folder) || strings.EqualFold(item.Path, "/"+strings.Trim(folder, "/"))) {
+				return shellexport.ItemSteps(col, collection.Item{Folder: item.Folder}), nil
+			}
+		}
+		return nil, fmt.Errorf("folder %q not found in collection", folder)
+	}
+	var steps []shellexport.Step
+	for _, req := range requests {
+		steps = append(steps, shellexport.ItemSteps(col, collection.Item{Request: req})...)
+	}
+	return steps, nil
+}
+
+// shellDefaults returns the collection variables, overlaid by the values
+// of env when one is named. Secret values are left out of the script.
+func shellDefaults(col *collection.Collection, colPath, env string) (map[string]string, error) {
+	defaults := make(map[string]string, len(col.Variables))
+	for k, v := range col.Variables {
+		defaults[k] = v
+	}
+	if env == "" {
+		return defaults, nil
+	}
+	ef, err := environment.LoadEnvironments(filepath.Join(filepath.Dir(colPath), "environments.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, e := range ef.Environments {
+		if e.Name != env {
+			continue
+		}
+		found = true
+		for k, v := range e.Variables {
+			if v.Secret {
+				delete(defaults, k)
+				continue
+			}
+			defaults[k] = v.Value
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("environment %q not found", env)
 	}
+	return defaults, nil
 }
 
 func collectAllRequests(items []collection.Item) []*collection.Request {
@@ -179,6 +287,58 @@ func exportAsInsomnia(out *os.File, col *collection.Collection) {
 	fmt.Fprintln(out)
 }
 
+func exportAsOpenAPI(out *os.File, col *collection.Collection) {
+	data, err := openapiexport.Export(col)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting to OpenAPI: %v\n", err)
+		os.Exit(1)
+	}
+	_, _ = out.Write(data)
+	fmt.Fprintln(out)
+}
+
+// filterCollection returns col limited to requests, keeping its folders,
+// so --request applies to whole-collection formats too.
+func filterCollection(col *collection.Collection, requests []*collection.Request) *collection.Collection {
+	keep := make(map[*collection.Request]bool, len(requests))
+	for _, req := range requests {
+		keep[req] = true
+	}
+	var filter func(items []collection.Item) []collection.Item
+	filter = func(items []collection.Item) []collection.Item {
+		var out []collection.Item
+		for _, item := range items {
+			switch {
+			case item.Request != nil && keep[item.Request]:
+				out = append(out, item)
+			case item.Folder != nil:
+				if inner := filter(item.Folder.Items); len(inner) > 0 {
+					f := *item.Folder
+					f.Items = inner
+					out = append(out, collection.Item{Folder: &f})
+				}
+			}
+		}
+		return out
+	}
+	filtered := *col
+	filtered.Items = filter(col.Items)
+	return &filtered
+}
+
+func exportAsText(out *os.File, format string, col *collection.Collection) {
+	export := httpfileexport.Export
+	if format == "hurl" {
+		export = hurlexport.Export
+	}
+	data, err := export(col)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting to %s: %v\n", format, err)
+		os.Exit(1)
+	}
+	_, _ = out.Write(data)
+}
+
 func collectionRequestToProtocol(colReq *collection.Request) *protocol.Request {
 	req := &protocol.Request{
 		Protocol: colReq.Protocol,
@@ -186,6 +346,9 @@ func collectionRequestToProtocol(colReq *collection.Request) *protocol.Request {
 		URL:      colReq.URL,
 		Headers:  make(map[string]string),
 		Params:   make(map[string]string),
+
+		ExpectContinue: colReq.ExpectContinue,
+		Chunked:        colReq.Chunked,
 	}
 	if req.Protocol == "" {
 		req.Protocol = "http"
@@ -200,8 +363,29 @@ func collectionRequestToProtocol(colReq *collection.Request) *protocol.Request {
 			req.Headers[h.Key] = h.Value
 		}
 	}
-	if colReq.Body != nil && colReq.Body.Content != "" {
-		req.Body = []byte(colReq.Body.Content)
+	for _, c := range colReq.Cookies {
+		if c.Enabled && c.Key != "" {
+			req.Cookies = append(req.Cookies, protocol.Cookie{Name: c.Key, Value: c.Value})
+		}
+	}
+	req.ApplyCookies()
+	if body := colReq.Body; !body.IsEmpty() {
+		switch body.Kind() {
+		case collection.BodyBinaryFile:
+			req.BodyFile = body.File
+		case collection.BodyFormURLEncoded, collection.BodyMultipart:
+			req.Multipart = body.Kind() == collection.BodyMultipart
+			for _, f := range body.Fields {
+				if f.Enabled && f.Key != "" {
+					req.Form = append(req.Form, protocol.FormField{Name: f.Key, Value: f.Value, File: f.File})
+				}
+			}
+			if len(body.Fields) == 0 {
+				req.Body = []byte(body.Content)
+			}
+		default:
+			req.Body = []byte(body.Content)
+		}
 	}
 	return req
 }
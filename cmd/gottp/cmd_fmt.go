@@ -4,7 +4,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/sadopc/gottp/internal/config"
 	"github.com/sadopc/gottp/internal/core/collection"
 )
 
@@ -12,18 +15,29 @@ func fmtCmd() {
 	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
 	writeFlag := fs.Bool("w", false, "Write result to file instead of stdout")
 	checkFlag := fs.Bool("check", false, "Check if files are formatted (exit 1 if not)")
+	reassignFlag := fs.Bool("reassign-ids", false, "Give requests with missing or duplicate IDs new, deterministic IDs")
+	styleFlag := fs.String("id-style", "", "ID style for --reassign-ids: uuid, ulid, slug (default: id_style from config)")
+	splitFlag := fs.Bool("split", false, "Convert each file to a split collection directory with a file per request")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: gottp fmt [flags] <file.gottp.yaml> [files...]\n\n")
 		fmt.Fprintf(os.Stderr, "Format and normalize collection YAML files.\n\n")
 		fmt.Fprintf(os.Stderr, "By default, formatted output is written to stdout.\n")
-		fmt.Fprintf(os.Stderr, "Use -w to write back to the source file.\n\n")
+		fmt.Fprintf(os.Stderr, "Use -w to write back to the source file.\n")
+		fmt.Fprintf(os.Stderr, "With --reassign-ids, requests without an ID or reusing an earlier request's\n")
+		fmt.Fprintf(os.Stderr, "ID get a new one derived from their path, so reruns give the same result.\n")
+		fmt.Fprintf(os.Stderr, "With --split, api.gottp.yaml is replaced by an api.gottp/ directory holding\n")
+		fmt.Fprintf(os.Stderr, "a YAML file per request and a subdirectory per folder, for smaller diffs.\n")
+		fmt.Fprintf(os.Stderr, "Split directories are formatted in place with -w or checked with --check.\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		fs.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  gottp fmt api.gottp.yaml           # print formatted to stdout\n")
 		fmt.Fprintf(os.Stderr, "  gottp fmt -w api.gottp.yaml        # overwrite file in-place\n")
 		fmt.Fprintf(os.Stderr, "  gottp fmt --check *.gottp.yaml     # check formatting (CI)\n")
+		fmt.Fprintf(os.Stderr, "  gottp fmt -w --reassign-ids --id-style ulid api.gottp.yaml\n")
+		fmt.Fprintf(os.Stderr, "  gottp fmt --split api.gottp.yaml   # convert to api.gottp/\n")
+		fmt.Fprintf(os.Stderr, "  gottp fmt --check api.gottp        # check a split collection\n")
 	}
 
 	if err := fs.Parse(os.Args[2:]); err != nil {
@@ -36,9 +50,27 @@ func fmtCmd() {
 		os.Exit(1)
 	}
 
+	reassignStyle := ""
+	if *reassignFlag {
+		reassignStyle = *styleFlag
+		if reassignStyle == "" {
+			reassignStyle = config.Load().IDStyle
+		}
+		if reassignStyle == "" {
+			reassignStyle = collection.IDStyleUUID
+		}
+	}
+
 	hasUnformatted := false
 	for _, path := range fs.Args() {
-		if err := formatFile(path, *writeFlag, *checkFlag, &hasUnformatted); err != nil {
+		if *splitFlag {
+			if err := splitFile(path); err != nil {
+				fmt.Fprintf(os.Stderr, "Error splitting %s: %v\n", path, err)
+				os.Exit(1)
+			}
+			continue
+		}
+		if err := formatFile(path, *writeFlag, *checkFlag, reassignStyle, &hasUnformatted); err != nil {
 			fmt.Fprintf(os.Stderr, "Error formatting %s: %v\n", path, err)
 			os.Exit(1)
 		}
@@ -49,18 +81,35 @@ func fmtCmd() {
 	}
 }
 
-func formatFile(path string, write, check bool, hasUnformatted *bool) error {
+// formatFile normalizes a collection file. A non-empty reassignStyle fixes
+// missing and duplicate request IDs using that ID style.
+func formatFile(path string, write, check bool, reassignStyle string, hasUnformatted *bool) error {
+	if collection.IsSplit(path) {
+		return formatSplit(path, write, check, reassignStyle, hasUnformatted)
+	}
 	original, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("reading file: %w", err)
 	}
 
 	// Parse and re-serialize to normalize
-	col, err := collection.LoadFromBytes(original)
+	var col *collection.Collection
+	if reassignStyle != "" {
+		// Keep missing IDs empty so they are reassigned deterministically
+		col, err = collection.ParseBytes(original)
+	} else {
+		col, err = collection.LoadFromBytes(original)
+	}
 	if err != nil {
 		return fmt.Errorf("parsing: %w", err)
 	}
 
+	if reassignStyle != "" {
+		if err := reassignIDs(col, path, reassignStyle); err != nil {
+			return err
+		}
+	}
+
 	// Normalize: ensure all requests have IDs, version is set
 	if col.Version == "" {
 		col.Version = "1"
@@ -98,3 +147,95 @@ func formatFile(path string, write, check bool, hasUnformatted *bool) error {
 
 	return nil
 }
+
+// reassignIDs fixes missing and duplicate request IDs in col, reporting
+// each change on stderr.
+func reassignIDs(col *collection.Collection, path, style string) error {
+	changes, err := collection.ReassignIDs(col, style)
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		old := c.OldID
+		if old == "" {
+			old = "(missing)"
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s %s -> %s\n", path, c.Path, old, c.NewID)
+	}
+	return nil
+}
+
+// formatSplit normalizes the files of a split collection directory. Only
+// files whose content changes are rewritten.
+func formatSplit(dir string, write, check bool, reassignStyle string, hasUnformatted *bool) error {
+	if !write && !check {
+		return fmt.Errorf("%s is a split collection; use -w to format it in place or --check", dir)
+	}
+	var col *collection.Collection
+	var err error
+	if reassignStyle != "" {
+		col, err = collection.ParseFile(dir)
+	} else {
+		col, err = collection.LoadSplit(dir)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing: %w", err)
+	}
+	if reassignStyle != "" {
+		if err := reassignIDs(col, dir, reassignStyle); err != nil {
+			return err
+		}
+	}
+
+	if check {
+		changed, err := collection.SplitChanges(col, dir)
+		if err != nil {
+			return err
+		}
+		if len(changed) > 0 {
+			for _, f := range changed {
+				fmt.Fprintf(os.Stderr, "UNFORMATTED %s\n", f)
+			}
+			*hasUnformatted = true
+		} else {
+			fmt.Printf("OK          %s\n", dir)
+		}
+		return nil
+	}
+
+	if err := collection.SaveSplit(col, dir); err != nil {
+		return err
+	}
+	fmt.Printf("Formatted %s\n", dir)
+	return nil
+}
+
+// splitFile converts the collection file at path into a split collection
+// directory named after it without the .yaml extension, then removes the
+// file.
+func splitFile(path string) error {
+	if collection.IsSplit(path) {
+		return fmt.Errorf("already a split collection")
+	}
+	ext := filepath.Ext(path)
+	if ext != ".yaml" && ext != ".yml" {
+		return fmt.Errorf("expected a .yaml file")
+	}
+	dir := strings.TrimSuffix(path, ext)
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("%s already exists", dir)
+	}
+
+	col, err := collection.LoadFromFile(path)
+	if err != nil {
+		return err
+	}
+	if err := collection.SaveSplit(col, dir); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("removing %s: %w", path, err)
+	}
+	fmt.Printf("Split %s into %s/ (%d files)\n", path, dir, len(collection.Files(dir)))
+	return nil
+}
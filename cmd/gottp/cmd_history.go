@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sadopc/gottp/internal/config"
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/history"
+)
+
+// tagList collects repeated --tag flags.
+type tagList []string
+
+func (t *tagList) String() string { return strings.Join(*t, ",") }
+
+func (t *tagList) Set(v string) error {
+	*t = append(*t, history.ParseTags(v)...)
+	return nil
+}
+
+func historyCmd() {
+	if len(os.Args) < 3 || os.Args[2] != "export" {
+		fmt.Fprintf(os.Stderr, "Usage: gottp history export [flags]\n\nRun 'gottp history export --help' for flags.\n")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("history export", flag.ExitOnError)
+	var tags tagList
+	fs.Var(&tags, "tag", "Only entries with this tag (repeatable)")
+	searchFlag := fs.String("search", "", "Only entries whose URL, note or tags contain this text")
+	sinceFlag := fs.String("since", "", "Only entries from this long ago (e.g. 24h, 7d) or since a date (2006-01-02)")
+	untilFlag := fs.String("until", "", "Only entries up to this long ago or until a date")
+	limitFlag := fs.Int("limit", 1000, "Maximum number of entries")
+	formatFlag := fs.String("format", "json", "Export format: json, collection")
+	nameFlag := fs.String("name", "History", "Collection name (with --format collection)")
+	outputFlag := fs.String("o", "", "Output file path (default: stdout)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gottp history export [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Export request history as JSON, newest first, with tags and notes, or as a\n")
+		fmt.Fprintf(os.Stderr, "collection that replays it: oldest first, repeats dropped and the most used\n")
+		fmt.Fprintf(os.Stderr, "base URL moved into a {{baseUrl}} variable.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  gottp history export --tag prod-incident\n")
+		fmt.Fprintf(os.Stderr, "  gottp history export --since 7d -o last-week.json\n")
+		fmt.Fprintf(os.Stderr, "  gottp history export --tag checkout --format collection -o checkout.gottp.yaml\n")
+	}
+	if err := fs.Parse(os.Args[3:]); err != nil {
+		os.Exit(1)
+	}
+	if *formatFlag != "json" && *formatFlag != "collection" {
+		fmt.Fprintf(os.Stderr, "Error: unsupported format %q (use json or collection)\n", *formatFlag)
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	filter := history.Filter{Tags: tags, Text: *searchFlag, Limit: *limitFlag}
+	var err error
+	if filter.Since, err = history.ParseTime(*sinceFlag, now); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --since: %v\n", err)
+		os.Exit(1)
+	}
+	if filter.Until, err = history.ParseTime(*untilFlag, now); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --until: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := history.NewStore(filepath.Join(config.DataDir(), "history.db"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	entries, err := store.ListFiltered(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *formatFlag == "collection" {
+		writeHistoryCollection(history.ToCollection(*nameFlag, entries), *outputFlag)
+		return
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outputFlag != "" {
+		f, err := os.Create(*outputFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := writeHistoryJSON(out, entries); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *outputFlag != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d history entries to %s\n", len(entries), *outputFlag)
+	}
+}
+
+// writeHistoryCollection saves col to output, or prints it when output is
+// empty.
+func writeHistoryCollection(col *collection.Collection, output string) {
+	if output == "" {
+		data, err := yaml.Marshal(col)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+	if err := collection.SaveToFile(col, output); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d requests to %s\n", len(col.Items), output)
+}
+
+// historyRecord is the exported form of a history entry.
+type historyRecord struct {
+	ID           int64             `json:"id"`
+	Timestamp    time.Time         `json:"timestamp"`
+	Method       string            `json:"method"`
+	URL          string            `json:"url"`
+	Status       int               `json:"status"`
+	DurationMS   float64           `json:"duration_ms"`
+	Size         int64             `json:"size"`
+	Tags         []string          `json:"tags,omitempty"`
+	Note         string            `json:"note,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	RequestBody  string            `json:"request_body,omitempty"`
+	ResponseBody string            `json:"response_body,omitempty"`
+}
+
+func writeHistoryJSON(w io.Writer, entries []history.Entry) error {
+	records := make([]historyRecord, len(entries))
+	for i, e := range entries {
+		records[i] = historyRecord{
+			ID:           e.ID,
+			Timestamp:    e.Timestamp,
+			Method:       e.Method,
+			URL:          e.URL,
+			Status:       e.StatusCode,
+			DurationMS:   float64(e.Duration.Microseconds()) / 1000,
+			Size:         e.Size,
+			Tags:         e.Tags,
+			Note:         e.Note,
+			RequestBody:  e.RequestBody,
+			ResponseBody: e.ResponseBody,
+		}
+		if e.Headers != "" {
+			_ = json.Unmarshal([]byte(e.Headers), &records[i].Headers)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
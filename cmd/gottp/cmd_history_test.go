@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sadopc/gottp/internal/core/history"
+)
+
+func TestWriteHistoryJSON(t *testing.T) {
+	var out strings.Builder
+	err := writeHistoryJSON(&out, []history.Entry{{
+		ID: 7, Method: "GET", URL: "https://api.example.com/orders", StatusCode: 500,
+		Duration: 1500 * time.Microsecond, Headers: `{"Accept":"*/*"}`,
+		Tags: []string{"prod-incident"}, Note: "500 on empty cart",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]any
+	if err := json.Unmarshal([]byte(out.String()), &records); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	r := records[0]
+	if r["note"] != "500 on empty cart" || r["tags"].([]any)[0] != "prod-incident" || r["duration_ms"] != 1.5 {
+		t.Errorf("record = %v", r)
+	}
+	if r["headers"].(map[string]any)["Accept"] != "*/*" {
+		t.Errorf("headers = %v", r["headers"])
+	}
+}
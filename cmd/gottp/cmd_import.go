@@ -3,13 +3,19 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/environment"
 	importutil "github.com/sadopc/gottp/internal/import"
 	curlimport "github.com/sadopc/gottp/internal/import/curl"
 	"github.com/sadopc/gottp/internal/import/har"
+	"github.com/sadopc/gottp/internal/import/httpfile"
+	"github.com/sadopc/gottp/internal/import/hurl"
 	"github.com/sadopc/gottp/internal/import/insomnia"
 	"github.com/sadopc/gottp/internal/import/openapi"
 	"github.com/sadopc/gottp/internal/import/postman"
@@ -18,20 +24,27 @@ import (
 
 func importCmd() {
 	fs := flag.NewFlagSet("import", flag.ExitOnError)
-	formatFlag := fs.String("format", "", "Force format: curl, postman, insomnia, openapi, har (default: auto-detect)")
+	formatFlag := fs.String("format", "", "Force format: curl, postman, insomnia, openapi, har, http, hurl (default: auto-detect)")
 	outputFlag := fs.String("output", "", "Output .gottp.yaml file path (default: imported.gottp.yaml)")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: gottp import <file> [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: gottp import <file|url> [flags]\n\n")
 		fmt.Fprintf(os.Stderr, "Import a collection from various formats.\n\n")
-		fmt.Fprintf(os.Stderr, "Supported formats: cURL, Postman, Insomnia, OpenAPI, HAR.\n")
-		fmt.Fprintf(os.Stderr, "Format is auto-detected from file content unless --format is specified.\n\n")
+		fmt.Fprintf(os.Stderr, "Supported formats: cURL, Postman, Insomnia, OpenAPI, HAR, .http (JetBrains\n")
+		fmt.Fprintf(os.Stderr, "HTTP Client, VS Code REST Client) and Hurl.\n")
+		fmt.Fprintf(os.Stderr, "Format is auto-detected from file content unless --format is specified.\n")
+		fmt.Fprintf(os.Stderr, "An http(s) URL is fetched first. OpenAPI $refs to other files and URLs\n")
+		fmt.Fprintf(os.Stderr, "are resolved relative to the document they appear in. Insomnia environments\n")
+		fmt.Fprintf(os.Stderr, "are added to environments.yaml next to the output file.\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		fs.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  gottp import postman-collection.json\n")
 		fmt.Fprintf(os.Stderr, "  gottp import openapi.yaml --output api.gottp.yaml\n")
+		fmt.Fprintf(os.Stderr, "  gottp import --format openapi https://petstore3.swagger.io/api/v3/openapi.json\n")
 		fmt.Fprintf(os.Stderr, "  gottp import request.har --format har\n")
+		fmt.Fprintf(os.Stderr, "  gottp import --format http requests.http\n")
+		fmt.Fprintf(os.Stderr, "  gottp import smoke.hurl\n")
 		fmt.Fprintf(os.Stderr, "  echo 'curl -X GET https://api.example.com' | gottp import -\n")
 	}
 
@@ -58,7 +71,7 @@ func importCmd() {
 			os.Exit(1)
 		}
 	} else {
-		data, err = os.ReadFile(inputPath)
+		data, err = openapi.Fetch(inputPath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", inputPath, err)
 			os.Exit(1)
@@ -96,11 +109,19 @@ func importCmd() {
 	case "insomnia":
 		col, err = insomnia.ParseInsomnia(data)
 	case "openapi":
-		col, err = openapi.ParseOpenAPI(data)
+		location := inputPath
+		if location == "-" {
+			location = "" // refs are relative to the working directory
+		}
+		col, err = openapi.ParseOpenAPIFrom(data, location, openapi.Fetch)
 	case "har":
 		col, err = har.ParseHAR(data)
+	case "http":
+		col, err = httpfile.ParseHTTPFile(data)
+	case "hurl":
+		col, err = hurl.ParseHurl(data)
 	default:
-		fmt.Fprintf(os.Stderr, "Error: unsupported format %q (use curl, postman, insomnia, openapi, or har)\n", format)
+		fmt.Fprintf(os.Stderr, "Error: unsupported format %q (use curl, postman, insomnia, openapi, har, http, or hurl)\n", format)
 		os.Exit(1)
 	}
 
@@ -117,7 +138,13 @@ func importCmd() {
 	// Determine output path
 	output := *outputFlag
 	if output == "" {
-		if inputPath != "-" {
+		if u, err := url.Parse(inputPath); err == nil && openapi.IsURL(inputPath) {
+			name := strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path))
+			if name == "" || name == "." || name == "/" {
+				name = "imported"
+			}
+			output = name + ".gottp.yaml"
+		} else if inputPath != "-" {
 			base := filepath.Base(inputPath)
 			ext := filepath.Ext(base)
 			output = base[:len(base)-len(ext)] + ".gottp.yaml"
@@ -133,6 +160,44 @@ func importCmd() {
 
 	requestCount := countRequests(col.Items)
 	fmt.Printf("Imported %d requests from %s -> %s\n", requestCount, format, output)
+
+	if format == "insomnia" {
+		envs, err := insomnia.ParseInsomniaEnvironments(data)
+		if err == nil && len(envs) > 0 {
+			importEnvironments(envs, filepath.Join(filepath.Dir(output), "environments.yaml"))
+		}
+	}
+}
+
+// importEnvironments adds envs to the environments file at path, creating
+// it if needed. Environments already in the file are kept as they are.
+func importEnvironments(envs []environment.Environment, path string) {
+	ef, err := environment.LoadEnvironments(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	existing := make(map[string]bool, len(ef.Environments))
+	for _, env := range ef.Environments {
+		existing[env.Name] = true
+	}
+	added := 0
+	for _, env := range envs {
+		if existing[env.Name] {
+			fmt.Fprintf(os.Stderr, "Skipped environment %q: already in %s\n", env.Name, path)
+			continue
+		}
+		ef.Environments = append(ef.Environments, env)
+		added++
+	}
+	if added == 0 {
+		return
+	}
+	if err := environment.SaveEnvironments(ef, path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported %d environments -> %s\n", added, path)
 }
 
 func readStdin() ([]byte, error) {
@@ -151,22 +216,11 @@ func readStdin() ([]byte, error) {
 }
 
 func curlRequestToCollection(req *protocol.Request) *collection.Collection {
-	colReq := collection.NewRequest("Imported Request", req.Method, req.URL)
-	for k, v := range req.Headers {
-		colReq.Headers = append(colReq.Headers, collection.KVPair{Key: k, Value: v, Enabled: true})
-	}
-	for k, v := range req.Params {
-		colReq.Params = append(colReq.Params, collection.KVPair{Key: k, Value: v, Enabled: true})
-	}
-	if len(req.Body) > 0 {
-		colReq.Body = &collection.Body{Type: "json", Content: string(req.Body)}
-	}
-
 	return &collection.Collection{
 		Name:    "cURL Import",
 		Version: "1",
 		Items: []collection.Item{
-			{Request: colReq},
+			{Request: curlToRequest(req, "Imported Request")},
 		},
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/environment"
 )
 
 func initCmd() {
@@ -102,7 +103,8 @@ func initCmd() {
 		if _, err := os.Stat(envPath); err == nil {
 			fmt.Fprintf(os.Stderr, "Warning: %s already exists, skipping\n", envPath)
 		} else {
-			envContent := `environments:
+			envContent := fmt.Sprintf(`schema_version: %d
+environments:
   - name: Development
     variables:
       base_url:
@@ -111,9 +113,10 @@ func initCmd() {
     variables:
       base_url:
         value: "https://api.example.com"
-`
+`, environment.SchemaVersion)
 			if baseURL != "" {
-				envContent = fmt.Sprintf(`environments:
+				envContent = fmt.Sprintf(`schema_version: %d
+environments:
   - name: Development
     variables:
       base_url:
@@ -122,7 +125,7 @@ func initCmd() {
     variables:
       base_url:
         value: %q
-`, baseURL)
+`, environment.SchemaVersion, baseURL)
 			}
 			if err := os.WriteFile(envPath, []byte(envContent), 0644); err != nil {
 				fmt.Fprintf(os.Stderr, "Error creating environments.yaml: %v\n", err)
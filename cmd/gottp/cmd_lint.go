@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+// lintResult is one file's issues in --format json output.
+type lintResult struct {
+	File   string                 `json:"file"`
+	Issues []collection.LintIssue `json:"issues"`
+}
+
+func lintCmd() {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	formatFlag := fs.String("format", "text", "Output format: text, json")
+	strictFlag := fs.Bool("strict", false, "Exit 1 on warnings as well as errors")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gottp lint <file.gottp.yaml> [files...] [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Check collections against style and safety rules.\n\n")
+		fmt.Fprintf(os.Stderr, "Rules (default severity):\n")
+		fmt.Fprintf(os.Stderr, "  no-hardcoded-secrets  error    credentials written out in headers, params,\n")
+		fmt.Fprintf(os.Stderr, "                                 cookies, auth or variables\n")
+		fmt.Fprintf(os.Stderr, "  base-url-variable     warning  URLs that don't start with {{base_url}}\n")
+		fmt.Fprintf(os.Stderr, "  require-assertions    warning  requests with no assertions or tests\n")
+		fmt.Fprintf(os.Stderr, "  unique-names          error    requests named alike in the same folder\n\n")
+		fmt.Fprintf(os.Stderr, "A lint block in the collection changes severities (error, warning, info,\n")
+		fmt.Fprintf(os.Stderr, "off) and the base URL variable:\n\n")
+		fmt.Fprintf(os.Stderr, "  lint:\n")
+		fmt.Fprintf(os.Stderr, "    base_url: api_url\n")
+		fmt.Fprintf(os.Stderr, "    rules:\n")
+		fmt.Fprintf(os.Stderr, "      require-assertions: off\n\n")
+		fmt.Fprintf(os.Stderr, "Exits 1 when an error is found (or a warning, with --strict) and 2 when a\n")
+		fmt.Fprintf(os.Stderr, "file can't be read.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  gottp lint api.gottp.yaml\n")
+		fmt.Fprintf(os.Stderr, "  gottp lint *.gottp.yaml --format json --strict\n")
+	}
+
+	paths, err := parseInterspersed(fs, os.Args[2:])
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(paths) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: at least one file path is required\n\n")
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *formatFlag != "text" && *formatFlag != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (use text or json)\n", *formatFlag)
+		os.Exit(2)
+	}
+
+	var results []lintResult
+	for _, path := range paths {
+		col, err := collection.ParseFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", path, err)
+			os.Exit(2)
+		}
+		issues, err := collection.Lint(col)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error in %s: %v\n", path, err)
+			os.Exit(2)
+		}
+		if collection.IsSplit(path) {
+			// Lines are in the request files, not in path
+			for i := range issues {
+				issues[i].Line = 0
+			}
+		}
+		results = append(results, lintResult{File: path, Issues: issues})
+	}
+
+	if *formatFlag == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+	} else {
+		writeLint(os.Stdout, results)
+	}
+
+	for _, r := range results {
+		for _, issue := range r.Issues {
+			if issue.Severity == collection.SeverityError || (*strictFlag && issue.Severity == collection.SeverityWarning) {
+				os.Exit(1)
+			}
+		}
+	}
+}
+
+// writeLint prints one line per issue, as file:line: severity: path:
+// message [rule], then a count for each file.
+func writeLint(w io.Writer, results []lintResult) {
+	for _, r := range results {
+		counts := make(map[string]int)
+		for _, issue := range r.Issues {
+			loc := r.File
+			if issue.Line > 0 {
+				loc = fmt.Sprintf("%s:%d", r.File, issue.Line)
+			}
+			where := "collection"
+			if issue.Path != "" {
+				where = strings.TrimPrefix(issue.Path, "/")
+			}
+			fmt.Fprintf(w, "%s: %s: %s: %s [%s]\n", loc, issue.Severity, where, issue.Message, issue.Rule)
+			counts[issue.Severity]++
+		}
+		if len(r.Issues) == 0 {
+			fmt.Fprintf(w, "OK   %s\n", r.File)
+			continue
+		}
+		var parts []string
+		for _, sev := range []string{collection.SeverityError, collection.SeverityWarning, collection.SeverityInfo} {
+			if n := counts[sev]; n > 0 {
+				if n > 1 && sev != collection.SeverityInfo {
+					sev += "s"
+				}
+				parts = append(parts, fmt.Sprintf("%d %s", n, sev))
+			}
+		}
+		fmt.Fprintf(w, "%s: %s\n", r.File, strings.Join(parts, ", "))
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+func TestWriteLint(t *testing.T) {
+	var buf bytes.Buffer
+	writeLint(&buf, []lintResult{
+		{File: "clean.gottp.yaml"},
+		{File: "api.gottp.yaml", Issues: []collection.LintIssue{
+			{Rule: collection.RuleHardcodedSecrets, Severity: collection.SeverityError, Message: `variable "token" has a hardcoded value`},
+			{Rule: collection.RuleAssertions, Severity: collection.SeverityWarning, Path: "/Users/Create", Line: 12, Message: "no assertions or tests"},
+			{Rule: collection.RuleBaseURL, Severity: collection.SeverityWarning, Path: "/Health", Line: 30, Message: "URL http://localhost/health doesn't start with {{base_url}}"},
+		}},
+	})
+	want := `OK   clean.gottp.yaml
+api.gottp.yaml: error: collection: variable "token" has a hardcoded value [no-hardcoded-secrets]
+api.gottp.yaml:12: warning: Users/Create: no assertions or tests [require-assertions]
+api.gottp.yaml:30: warning: Health: URL http://localhost/health doesn't start with {{base_url}} [base-url-variable]
+api.gottp.yaml: 1 error, 2 warnings
+`
+	if got := buf.String(); got != want {
+		t.Errorf("lint output:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+func mergeCmd() {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	var output string
+	fs.StringVar(&output, "o", "", "Output file path (default: stdout)")
+	fs.StringVar(&output, "output", "", "Same as -o")
+	nameFlag := fs.String("name", "", "Name of the merged collection (default: first collection's name)")
+	strictFlag := fs.Bool("strict", false, "Exit 1 if any conflicts were found")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gottp merge <a.gottp.yaml> <b.gottp.yaml> [files...] [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Combine collections into one.\n\n")
+		fmt.Fprintf(os.Stderr, "Folders with the same name are merged and identical requests are kept once.\n")
+		fmt.Fprintf(os.Stderr, "Requests that share a name but differ are kept with the source collection's\n")
+		fmt.Fprintf(os.Stderr, "name appended, and colliding request IDs are replaced. Variables and auth\n")
+		fmt.Fprintf(os.Stderr, "from earlier files win. Every decision is reported on stderr.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  gottp merge users.gottp.yaml orders.gottp.yaml -o team.gottp.yaml\n")
+		fmt.Fprintf(os.Stderr, "  gottp merge services/*.gottp.yaml --name Platform --strict -o all.gottp.yaml\n")
+	}
+
+	paths, err := parseInterspersed(fs, os.Args[2:])
+	if err != nil {
+		os.Exit(1)
+	}
+	if len(paths) < 2 {
+		fmt.Fprintf(os.Stderr, "Error: at least two collection files are required\n\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cols := make([]*collection.Collection, len(paths))
+	for i, path := range paths {
+		col, err := collection.LoadFromFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		cols[i] = col
+	}
+
+	merged, notes := collection.Merge(cols)
+	if *nameFlag != "" {
+		merged.Name = *nameFlag
+	}
+
+	conflicts := 0
+	for _, n := range notes {
+		if n.Kind == collection.MergeConflict {
+			conflicts++
+		}
+		fmt.Fprintln(os.Stderr, n)
+	}
+
+	if output != "" {
+		if err := collection.SaveToFile(merged, output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Merged %d collections into %s (%d requests, %d conflicts)\n",
+			len(cols), output, countRequests(merged.Items), conflicts)
+	} else {
+		data, err := yaml.Marshal(merged)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+	}
+
+	if *strictFlag && conflicts > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	out := fs.String("o", "", "")
+	strict := fs.Bool("strict", false, "")
+
+	args, err := parseInterspersed(fs, []string{"a.gottp.yaml", "--strict", "b.gottp.yaml", "-o", "merged.gottp.yaml", "c.gottp.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(args, ","); got != "a.gottp.yaml,b.gottp.yaml,c.gottp.yaml" {
+		t.Errorf("positional args = %s", got)
+	}
+	if *out != "merged.gottp.yaml" || !*strict {
+		t.Errorf("flags not parsed: -o=%q --strict=%v", *out, *strict)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/environment"
+	"github.com/sadopc/gottp/internal/core/migrate"
+)
+
+func migrateCmd() {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	checkFlag := fs.Bool("check", false, "Report files that need migrating without writing them (exit 1 if any)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gottp migrate [flags] <file|dir> [files...]\n\n")
+		fmt.Fprintf(os.Stderr, "Upgrade collection and environment files to the current schema version.\n\n")
+		fmt.Fprintf(os.Stderr, "Files are rewritten in place, keeping comments and key order. Older files\n")
+		fmt.Fprintf(os.Stderr, "also load without migrating; this makes the upgrade permanent. Directories\n")
+		fmt.Fprintf(os.Stderr, "are searched for *.gottp.yaml files and environments.yaml.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  gottp migrate api.gottp.yaml environments.yaml\n")
+		fmt.Fprintf(os.Stderr, "  gottp migrate .\n")
+		fmt.Fprintf(os.Stderr, "  gottp migrate --check .   # fail CI on outdated files\n")
+	}
+
+	args, err := parseInterspersed(fs, os.Args[2:])
+	if err != nil {
+		os.Exit(1)
+	}
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: at least one file or directory is required\n\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	paths, err := migrationPaths(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	outdated := false
+	for _, path := range paths {
+		res, err := migrateFile(path, !*checkFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error migrating %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		switch {
+		case !res.Changed():
+			fmt.Printf("OK       %s (schema version %d)\n", path, res.To)
+		case *checkFlag:
+			fmt.Fprintf(os.Stderr, "OUTDATED %s (schema version %d, current %d)\n", path, res.From, res.To)
+			outdated = true
+		default:
+			fmt.Printf("Migrated %s (schema version %d -> %d)\n", path, res.From, res.To)
+			for _, desc := range res.Applied {
+				fmt.Printf("  - %s\n", desc)
+			}
+		}
+	}
+	if outdated {
+		os.Exit(1)
+	}
+}
+
+// migrationPaths expands directories to the collection and environment
+// files directly inside them.
+func migrationPaths(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(arg, "*.gottp.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+		if env := filepath.Join(arg, "environments.yaml"); fileExists(env) {
+			paths = append(paths, env)
+		}
+	}
+	return paths, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// migrateFile upgrades a collection file, or an environments.yaml, and
+// writes it back when write is set and something changed.
+func migrateFile(path string, write bool) (migrate.Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return migrate.Result{}, fmt.Errorf("reading file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return migrate.Result{}, fmt.Errorf("parsing: %w", err)
+	}
+
+	upgrade := collection.Migrate
+	if filepath.Base(path) == "environments.yaml" {
+		upgrade = environment.Migrate
+	}
+	res, err := upgrade(&doc)
+	if err != nil || !res.Changed() || !write {
+		return res, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(yamlIndent(data))
+	if err := enc.Encode(&doc); err != nil {
+		return res, fmt.Errorf("serializing: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return res, fmt.Errorf("serializing: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return res, fmt.Errorf("writing: %w", err)
+	}
+	return res, nil
+}
+
+// yamlIndent returns the indentation step of a YAML file: the smallest
+// indent of a nested line, or 4 (what gottp writes) when there is none.
+func yamlIndent(data []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		n := len(line) - len(trimmed)
+		if n == 0 || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if indent == 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent < 2 {
+		return 4
+	}
+	return indent
+}
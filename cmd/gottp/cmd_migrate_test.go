@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "api.gottp.yaml")
+	legacy := `# Shared API collection
+name: API
+items:
+  - request:
+      name: Login # form login
+      url: http://a
+      body:
+        type: form
+`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := migrateFile(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Changed() || res.From != 0 {
+		t.Errorf("check result = %+v", res)
+	}
+	if data, _ := os.ReadFile(path); string(data) != legacy {
+		t.Errorf("check mode rewrote the file:\n%s", data)
+	}
+
+	if _, err := migrateFile(path, true); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{
+		"# Shared API collection\nschema_version: 1\nname: API\n",
+		"  - request:\n      name: Login # form login\n",
+		"        type: form-urlencoded\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("migrated file missing %q:\n%s", want, data)
+		}
+	}
+
+	res, err = migrateFile(path, true)
+	if err != nil || res.Changed() {
+		t.Errorf("second run: result %+v, err %v", res, err)
+	}
+}
+
+func TestMigrationPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.gottp.yaml", "b.gottp.yaml", "environments.yaml", "notes.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("name: x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths, err := migrationPaths([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range paths {
+		names = append(names, filepath.Base(p))
+	}
+	if got := strings.Join(names, ","); got != "a.gottp.yaml,b.gottp.yaml,environments.yaml" {
+		t.Errorf("paths = %s", got)
+	}
+	if _, err := migrationPaths([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("expected an error for a missing path")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"os/signal"
 
@@ -17,6 +18,10 @@ func mockCmd() {
 	latencyFlag := fs.Duration("latency", 0, "Artificial response latency (e.g., 200ms, 1s)")
 	errorRateFlag := fs.Float64("error-rate", 0, "Random error rate (0.0-1.0)")
 	corsOriginFlag := fs.String("cors-origin", "*", "Access-Control-Allow-Origin header value")
+	scenarioFlag := fs.String("scenario", "", "Serve the mock example of this name on routes that have one")
+	proxyFlag := fs.String("proxy", "", "Forward requests that match no route to this upstream URL")
+	recordFlag := fs.Bool("record", false, "Save proxied responses into the collection file as mock examples (needs --proxy)")
+	recordHARFlag := fs.String("record-har", "", "Write proxied requests and responses to this HAR file (needs --proxy)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: gottp mock <collection.gottp.yaml> [flags]\n\n")
@@ -30,12 +35,18 @@ func mockCmd() {
 		fmt.Fprintf(os.Stderr, "  {{$timestamp}}   Current Unix timestamp\n")
 		fmt.Fprintf(os.Stderr, "  {{$uuid}}        Random UUID v4\n")
 		fmt.Fprintf(os.Stderr, "  {{$randomInt}}   Random integer (0-9999)\n")
+		fmt.Fprintf(os.Stderr, "\nExample responses (mock.examples in a request):\n")
+		fmt.Fprintf(os.Stderr, "  ?__scenario=<name>        Serve the named example\n")
+		fmt.Fprintf(os.Stderr, "  X-Mock-Scenario: <name>   Serve the named example\n")
+		fmt.Fprintf(os.Stderr, "  mock.select               first, round-robin or sequence\n")
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  gottp mock api.gottp.yaml\n")
 		fmt.Fprintf(os.Stderr, "  gottp mock api.gottp.yaml --port 3000\n")
 		fmt.Fprintf(os.Stderr, "  gottp mock api.gottp.yaml --latency 200ms\n")
 		fmt.Fprintf(os.Stderr, "  gottp mock api.gottp.yaml --error-rate 0.1\n")
 		fmt.Fprintf(os.Stderr, "  gottp mock api.gottp.yaml --cors-origin https://myapp.example.com\n")
+		fmt.Fprintf(os.Stderr, "  gottp mock api.gottp.yaml --scenario server-error\n")
+		fmt.Fprintf(os.Stderr, "  gottp mock api.gottp.yaml --proxy https://api.example.com --record\n")
 	}
 
 	if err := fs.Parse(os.Args[2:]); err != nil {
@@ -61,6 +72,19 @@ func mockCmd() {
 		os.Exit(2)
 	}
 
+	var upstream *url.URL
+	if *proxyFlag != "" {
+		u, err := url.Parse(*proxyFlag)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fmt.Fprintf(os.Stderr, "Error: --proxy must be an http:// or https:// URL\n")
+			os.Exit(2)
+		}
+		upstream = u
+	} else if *recordFlag || *recordHARFlag != "" {
+		fmt.Fprintf(os.Stderr, "Error: --record and --record-har need --proxy\n")
+		os.Exit(2)
+	}
+
 	// Load collection
 	col, err := collection.LoadFromFile(collectionPath)
 	if err != nil {
@@ -81,10 +105,22 @@ func mockCmd() {
 	if *corsOriginFlag != "*" {
 		opts = append(opts, mock.WithCORSOrigin(*corsOriginFlag))
 	}
+	if *scenarioFlag != "" {
+		opts = append(opts, mock.WithScenario(*scenarioFlag))
+	}
+	if upstream != nil {
+		opts = append(opts, mock.WithUpstream(upstream))
+	}
+	if *recordFlag {
+		opts = append(opts, mock.WithRecording(collectionPath))
+	}
+	if *recordHARFlag != "" {
+		opts = append(opts, mock.WithHARRecording(*recordHARFlag))
+	}
 
 	srv := mock.New(col, opts...)
 
-	if len(srv.Routes()) == 0 {
+	if len(srv.Routes()) == 0 && upstream == nil {
 		fmt.Fprintf(os.Stderr, "Warning: no HTTP routes found in collection %q\n", col.Name)
 		fmt.Fprintf(os.Stderr, "The mock server will return 404 for all requests.\n\n")
 	}
@@ -98,6 +134,15 @@ func mockCmd() {
 	if *errorRateFlag > 0 {
 		fmt.Fprintf(os.Stderr, "Error rate: %.0f%%\n", *errorRateFlag*100)
 	}
+	if *scenarioFlag != "" {
+		fmt.Fprintf(os.Stderr, "Scenario: %s\n", *scenarioFlag)
+	}
+	if *recordFlag {
+		fmt.Fprintf(os.Stderr, "Recording proxied responses into %s (folder %q)\n", collectionPath, mock.RecordedFolder)
+	}
+	if *recordHARFlag != "" {
+		fmt.Fprintf(os.Stderr, "Recording proxied traffic to %s\n", *recordHARFlag)
+	}
 
 	if err := srv.Start(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
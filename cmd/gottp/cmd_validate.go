@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -9,6 +10,9 @@ import (
 
 	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/core/environment"
+	"github.com/sadopc/gottp/internal/mock"
+	"github.com/sadopc/gottp/internal/protocol"
+	"github.com/sadopc/gottp/internal/scripting"
 )
 
 func validateCmd() {
@@ -17,7 +21,9 @@ func validateCmd() {
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: gottp validate <file.gottp.yaml> [files...]\n\n")
 		fmt.Fprintf(os.Stderr, "Validate collection and environment YAML files.\n\n")
-		fmt.Fprintf(os.Stderr, "If an environments.yaml exists next to the collection, it is also validated.\n\n")
+		fmt.Fprintf(os.Stderr, "If an environments.yaml exists next to the collection, it is also validated.\n")
+		fmt.Fprintf(os.Stderr, "Scripts with syntax errors fail validation. Requests that send the same\n")
+		fmt.Fprintf(os.Stderr, "method, URL and body are reported as warnings.\n\n")
 		fmt.Fprintf(os.Stderr, "Examples:\n")
 		fmt.Fprintf(os.Stderr, "  gottp validate api.gottp.yaml\n")
 		fmt.Fprintf(os.Stderr, "  gottp validate *.gottp.yaml\n")
@@ -49,24 +55,34 @@ func validateCmd() {
 }
 
 func validateFile(path string) error {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("reading file: %w", err)
-	}
+	var col *collection.Collection
+	if collection.IsSplit(path) {
+		c, err := collection.LoadSplit(path)
+		if err != nil {
+			return err
+		}
+		col = c
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading file: %w", err)
+		}
 
-	if len(data) == 0 {
-		return fmt.Errorf("file is empty")
-	}
+		if len(data) == 0 {
+			return fmt.Errorf("file is empty")
+		}
 
-	// Check if it's an environment file
-	if filepath.Base(path) == "environments.yaml" {
-		return validateEnvironment(path)
-	}
+		// Check if it's an environment file
+		if filepath.Base(path) == "environments.yaml" {
+			return validateEnvironment(path)
+		}
 
-	// Validate as collection
-	col, err := collection.LoadFromBytes(data)
-	if err != nil {
-		return err
+		// Validate as collection
+		c, err := collection.LoadFromBytes(data)
+		if err != nil {
+			return err
+		}
+		col = c
 	}
 
 	// Structural checks
@@ -85,7 +101,7 @@ func validateFile(path string) error {
 	ids := make(map[string]string)
 	duplicates := checkDuplicateIDs(col.Items, ids)
 	for _, dup := range duplicates {
-		warnings = append(warnings, fmt.Sprintf("duplicate request ID: %s", dup))
+		warnings = append(warnings, fmt.Sprintf("duplicate request ID: %s (fix with gottp fmt -w --reassign-ids)", dup))
 	}
 
 	// Check for empty URLs
@@ -94,10 +110,36 @@ func validateFile(path string) error {
 		warnings = append(warnings, fmt.Sprintf("request %q has empty URL", name))
 	}
 
+	// Check for links without a URL
+	for _, name := range checkLinks(col.Items) {
+		warnings = append(warnings, fmt.Sprintf("request %q has a link with empty URL", name))
+	}
+
+	// Check for protocols this build cannot send
+	warnings = append(warnings, checkProtocols(col.Items)...)
+
+	// Check mock example settings
+	warnings = append(warnings, checkMockConfigs(col.Items)...)
+
+	// Check the lint block names known rules and severities
+	if _, err := col.Lint.Severities(); err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	// Check that scripts parse
+	for _, d := range scripting.CheckCollection(col) {
+		warnings = append(warnings, d.String())
+	}
+
 	if len(warnings) > 0 {
 		return fmt.Errorf("validation warnings:\n  - %s", strings.Join(warnings, "\n  - "))
 	}
 
+	// Identical requests may be intentional, so they don't fail validation
+	for _, dup := range collection.FindDuplicates(col) {
+		fmt.Fprintf(os.Stderr, "WARN %s: %s\n", path, duplicateSummary(dup))
+	}
+
 	// Also validate environments.yaml if present
 	dir := filepath.Dir(path)
 	envPath := filepath.Join(dir, "environments.yaml")
@@ -178,3 +220,71 @@ func checkEmptyURLs(items []collection.Item) []string {
 	}
 	return empty
 }
+
+func checkLinks(items []collection.Item) []string {
+	var bad []string
+	for _, item := range items {
+		if item.Request != nil {
+			for _, link := range item.Request.Links {
+				if link.URL == "" {
+					bad = append(bad, item.Request.Name)
+					break
+				}
+			}
+		}
+		if item.Folder != nil {
+			bad = append(bad, checkLinks(item.Folder.Items)...)
+		}
+	}
+	return bad
+}
+
+func checkProtocols(items []collection.Item) []string {
+	var bad []string
+	for _, item := range items {
+		if req := item.Request; req != nil {
+			var unsupported *protocol.UnsupportedError
+			if err := protocol.CheckProtocol(req.Protocol, protocol.Builtin); errors.As(err, &unsupported) {
+				bad = append(bad, fmt.Sprintf("request %q: %v (%s)", req.Name, err, unsupported.Hint()))
+			}
+		}
+		if item.Folder != nil {
+			bad = append(bad, checkProtocols(item.Folder.Items)...)
+		}
+	}
+	return bad
+}
+
+func checkMockConfigs(items []collection.Item) []string {
+	var bad []string
+	for _, item := range items {
+		if req := item.Request; req != nil && req.Mock != nil {
+			if !mock.ValidSelection(req.Mock.Select) {
+				bad = append(bad, fmt.Sprintf("request %q has unknown mock select %q (use first, round-robin or sequence)", req.Name, req.Mock.Select))
+			}
+			names := make(map[string]bool)
+			for _, ex := range req.Mock.Examples {
+				if ex.Status != 0 && (ex.Status < 100 || ex.Status > 599) {
+					bad = append(bad, fmt.Sprintf("request %q mock example %q has invalid status %d", req.Name, ex.Name, ex.Status))
+				}
+				if names[ex.Name] {
+					bad = append(bad, fmt.Sprintf("request %q has duplicate mock example %q", req.Name, ex.Name))
+				}
+				names[ex.Name] = true
+			}
+		}
+		if item.Folder != nil {
+			bad = append(bad, checkMockConfigs(item.Folder.Items)...)
+		}
+	}
+	return bad
+}
+
+// duplicateSummary describes a group of identical requests.
+func duplicateSummary(dup collection.Duplicate) string {
+	paths := make([]string, len(dup.Items))
+	for i, item := range dup.Items {
+		paths[i] = strings.TrimPrefix(item.Path, "/")
+	}
+	return fmt.Sprintf("%d requests send the same %s %s: %s", len(dup.Items), dup.Method, dup.URL, strings.Join(paths, ", "))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/sadopc/gottp/internal/config"
+)
+
+// interactive reports whether the TUI can run: it needs a terminal for
+// both input and output.
+func interactive() bool {
+	return term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+}
+
+// headlessArgs returns the subcommand arguments for running action on the
+// collection at colPath instead of the TUI. Help needs no arguments.
+func headlessArgs(action, colPath string) ([]string, error) {
+	if action == "" {
+		action = "help"
+	}
+	if !slices.Contains(config.HeadlessActions, action) {
+		return nil, fmt.Errorf("unknown headless action %q (use %s)", action, strings.Join(config.HeadlessActions, ", "))
+	}
+	if action == "help" {
+		return nil, nil
+	}
+	if colPath == "" {
+		return nil, fmt.Errorf("no collection to %s: pass --collection or run in a directory with a .gottp.yaml file", action)
+	}
+	return []string{action, colPath}, nil
+}
+
+// runHeadless runs action in place of the TUI when gottp is started
+// without a terminal, e.g. from CI or with output piped.
+func runHeadless(action, colPath string) {
+	args, err := headlessArgs(action, colPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if args == nil {
+		fmt.Fprintf(os.Stderr, "gottp: not running in a terminal, so the TUI cannot start (use --force-tui to try anyway).\n\n")
+		printHelp()
+		os.Exit(2)
+	}
+
+	// Subcommands parse their flags from os.Args
+	os.Args = append([]string{os.Args[0]}, args...)
+	switch args[0] {
+	case "run":
+		runCmd()
+	case "validate":
+		validateCmd()
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHeadlessArgs(t *testing.T) {
+	for _, action := range []string{"", "help"} {
+		if args, err := headlessArgs(action, "api.gottp.yaml"); err != nil || args != nil {
+			t.Errorf("%q: got (%v, %v), want help", action, args, err)
+		}
+	}
+	if args, err := headlessArgs("run", "api.gottp.yaml"); err != nil || !slices.Equal(args, []string{"run", "api.gottp.yaml"}) {
+		t.Errorf("run: got (%v, %v)", args, err)
+	}
+	if _, err := headlessArgs("validate", ""); err == nil || !strings.Contains(err.Error(), "--collection") {
+		t.Errorf("validate without collection: %v", err)
+	}
+	if _, err := headlessArgs("serve", "api.gottp.yaml"); err == nil || !strings.Contains(err.Error(), "help, run, validate") {
+		t.Errorf("unknown action: %v", err)
+	}
+}
@@ -4,21 +4,34 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"log"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 
 	"github.com/sadopc/gottp/internal/app"
 	"github.com/sadopc/gottp/internal/config"
 	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/profiling"
 	"github.com/sadopc/gottp/internal/runner"
+	"github.com/sadopc/gottp/internal/ui/theme"
 	"github.com/sadopc/gottp/pkg/version"
 )
 
+// shutdownTimeout bounds how long the TUI waits for connections to close
+// when it quits.
+const shutdownTimeout = 2 * time.Second
+
 func main() {
+	// New request IDs follow the configured style in every command
+	if err := collection.SetIDStyle(config.Load().IDStyle); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
 		case "run":
@@ -30,6 +43,9 @@ func main() {
 		case "validate":
 			validateCmd()
 			return
+		case "lint":
+			lintCmd()
+			return
 		case "fmt":
 			fmtCmd()
 			return
@@ -39,9 +55,30 @@ func main() {
 		case "export":
 			exportCmd()
 			return
+		case "curl":
+			curlCmd()
+			return
+		case "merge":
+			mergeCmd()
+			return
+		case "diff":
+			diffCmd()
+			return
 		case "mock":
 			mockCmd()
 			return
+		case "migrate":
+			migrateCmd()
+			return
+		case "ci":
+			ciCmd()
+			return
+		case "doctor":
+			doctorCmd()
+			return
+		case "history":
+			historyCmd()
+			return
 		case "completion":
 			completionCmd()
 			return
@@ -67,16 +104,29 @@ Commands:
   run       Run API requests headlessly from a collection file
   init      Create a new .gottp.yaml collection interactively
   validate  Validate collection and environment YAML files
+  lint      Check collections for hardcoded secrets, missing assertions and more
   fmt       Format and normalize collection YAML files
   import    Import collection from cURL/Postman/Insomnia/OpenAPI/HAR
   export    Export collection to cURL/HAR format
+  curl      Send a curl command through gottp, optionally saving it
+  merge     Combine collections into one, reporting conflicts
+  diff      Compare two collections: added, removed and changed requests
   mock      Start a mock HTTP server from a collection file
+  migrate   Upgrade collection and environment files to the current schema
+  ci        Generate a GitHub Actions or GitLab CI workflow for a collection
+  doctor    Check config, data directory, clipboard, editor, terminal and network
+  history   Export request history, filtered by tag, text or time
   completion  Generate shell completion scripts (bash, zsh, fish)
   version   Print version information
   help      Show this help message
 
 TUI Flags:
   --collection <path>  Path to a .gottp.yaml collection file
+  --pprof <addr>       Serve pprof profiles on addr (e.g. localhost:6060)
+  --debug-log <path>   Append debug output and memory stats to a file
+  --force-tui          Start the TUI even without a terminal
+  --headless <action>  Without a terminal: help (default), run or validate
+  --record <path>      Record the session's requests and responses to a HAR file
   --version            Print version and exit
 
 Run 'gottp <command> --help' for more information about a command.
@@ -89,12 +139,18 @@ func runCmd() {
 	requestFlag := fs.String("request", "", "Run a single request by name")
 	folderFlag := fs.String("folder", "", "Run all requests in a folder")
 	workflowFlag := fs.String("workflow", "", "Run a named workflow")
-	outputFlag := fs.String("output", "text", "Output format: text, json, junit")
+	outputFlag := fs.String("output", "text", "Output format: text, json, junit, tap, github, markdown")
 	verboseFlag := fs.Bool("verbose", false, "Show response bodies and headers")
+	reportFlag := fs.String("report", "", "Write an HTML report of the run to this file")
 	timeoutFlag := fs.Duration("timeout", 30*time.Second, "Request timeout")
+	oauthBrowserFlag := fs.Bool("oauth-browser", false, "Open the browser for OAuth2 authorization_code grants")
 	perfSaveFlag := fs.String("perf-save", "", "Save timing results as a performance baseline file")
 	perfBaselineFlag := fs.String("perf-baseline", "", "Compare timings against a baseline file")
 	perfThresholdFlag := fs.Float64("perf-threshold", 20.0, "Regression threshold percentage (default 20%)")
+	parallelFlag := fs.Int("parallel", 1, "Requests to run at once")
+	perHostFlag := fs.Int("per-host", 1, "Requests to run at once against the same host with --parallel (0 for no limit)")
+	watchFlag := fs.Bool("watch", false, "Run again whenever the collection, environments.yaml, .env or a referenced file changes")
+	watchIntervalFlag := fs.Duration("watch-interval", 500*time.Millisecond, "How often --watch checks files for changes")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: gottp run <collection.gottp.yaml> [flags]\n\n")
@@ -108,6 +164,10 @@ func runCmd() {
 		fmt.Fprintf(os.Stderr, "  gottp run api.gottp.yaml --folder Auth --output json\n")
 		fmt.Fprintf(os.Stderr, "  gottp run api.gottp.yaml --workflow \"Create and Verify\" --verbose\n")
 		fmt.Fprintf(os.Stderr, "  gottp run api.gottp.yaml --output junit > results.xml\n")
+		fmt.Fprintf(os.Stderr, "  gottp run api.gottp.yaml --output markdown >> \"$GITHUB_STEP_SUMMARY\"\n")
+		fmt.Fprintf(os.Stderr, "  gottp run api.gottp.yaml --env Staging --report report.html\n")
+		fmt.Fprintf(os.Stderr, "  gottp run api.gottp.yaml --parallel 8 --per-host 2\n")
+		fmt.Fprintf(os.Stderr, "  gottp run --watch --folder Users --env Local api.gottp.yaml\n")
 		fmt.Fprintf(os.Stderr, "\nExit codes:\n")
 		fmt.Fprintf(os.Stderr, "  0  All requests succeeded, all tests passed\n")
 		fmt.Fprintf(os.Stderr, "  1  One or more script test assertions failed\n")
@@ -129,12 +189,13 @@ func runCmd() {
 
 	// Validate output format
 	switch *outputFlag {
-	case "text", "json", "junit":
+	case "text", "json", "junit", "tap", "github", "markdown":
 	default:
-		fmt.Fprintf(os.Stderr, "Error: invalid output format %q (must be text, json, or junit)\n", *outputFlag)
+		fmt.Fprintf(os.Stderr, "Error: invalid output format %q (must be text, json, junit, tap, github, or markdown)\n", *outputFlag)
 		os.Exit(2)
 	}
 
+	appCfg := config.Load()
 	cfg := runner.Config{
 		CollectionPath: collectionPath,
 		Environment:    *envFlag,
@@ -142,99 +203,251 @@ func runCmd() {
 		FolderName:     *folderFlag,
 		WorkflowName:   *workflowFlag,
 		OutputFormat:   *outputFlag,
+		Report:         *reportFlag,
 		Verbose:        *verboseFlag,
 		Timeout:        *timeoutFlag,
+		OAuthBrowser:   *oauthBrowserFlag,
+		Retry:          appCfg.Retry,
+		Connections:    appCfg.Connections,
+		Parallel:       *parallelFlag,
+		PerHost:        *perHostFlag,
 	}
-
-	r, err := runner.New(cfg)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if cfg.Parallel < 1 || cfg.PerHost < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --parallel must be at least 1 and --per-host at least 0\n")
 		os.Exit(2)
 	}
+	if *watchIntervalFlag <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: --watch-interval must be positive\n")
+		os.Exit(2)
+	}
+
+	perf := perfOptions{save: *perfSaveFlag, baseline: *perfBaselineFlag, threshold: *perfThresholdFlag}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	if *watchFlag {
+		os.Exit(watchRun(ctx, cfg, perf, *watchIntervalFlag))
+	}
+	_, code := runOnce(ctx, cfg, perf)
+	os.Exit(code)
+}
+
+// printReport writes results in one of the CI output formats.
+func printReport(format string, results []runner.Result, info runner.RunInfo) {
+	switch format {
+	case "tap":
+		runner.PrintTAP(os.Stdout, results)
+	case "github":
+		runner.PrintGitHub(os.Stdout, results, info)
+	case "markdown":
+		runner.PrintMarkdown(os.Stdout, results, info)
+	}
+}
+
+// saveReport writes the HTML report to path, if one was asked for, and
+// reports whether that went well.
+func saveReport(path string, results []runner.Result, info runner.RunInfo) bool {
+	if path == "" {
+		return true
+	}
+	if err := runner.SaveHTMLReport(path, results, info); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving report: %v\n", err)
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "Report saved to %s\n", path)
+	return true
+}
+
+// perfOptions are the run flags for saving and comparing timings.
+type perfOptions struct {
+	save      string
+	baseline  string
+	threshold float64
+}
+
+// runOnce loads the collection, runs it as cfg says and prints the results.
+// It returns the results and the exit code for them.
+func runOnce(ctx context.Context, cfg runner.Config, perf perfOptions) ([]runner.Result, int) {
+	r, err := runner.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, 2
+	}
+	defer r.Close()
+	info := r.Info()
+	info.Version = version.Version
+	// A split collection's request lines are in its request files
+	if !collection.IsSplit(cfg.CollectionPath) {
+		info.File = cfg.CollectionPath
+	}
+
 	// Workflow mode
 	if cfg.WorkflowName != "" {
 		wfResult, err := r.RunWorkflow(ctx, cfg.WorkflowName, cfg.Verbose)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(2)
+			return nil, 2
 		}
+		info.Workflow = wfResult.Name
+		info.Failure = wfResult.Error
 
 		switch cfg.OutputFormat {
 		case "json":
 			if err := runner.PrintWorkflowJSON(os.Stdout, wfResult); err != nil {
 				fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
-				os.Exit(2)
+				return nil, 2
 			}
 		case "junit":
-			if err := runner.PrintWorkflowJUnit(os.Stdout, wfResult); err != nil {
+			if err := runner.PrintWorkflowJUnit(os.Stdout, wfResult, info); err != nil {
 				fmt.Fprintf(os.Stderr, "Error writing JUnit XML: %v\n", err)
-				os.Exit(2)
+				return nil, 2
+			}
+		case "tap", "github", "markdown":
+			printReport(cfg.OutputFormat, wfResult.Steps, info)
+			if wfResult.Error != "" {
+				fmt.Fprintf(os.Stderr, "Workflow failed: %s\n", wfResult.Error)
 			}
 		default:
 			runner.PrintWorkflowText(os.Stdout, wfResult, cfg.Verbose)
 		}
+		if !saveReport(cfg.Report, wfResult.Steps, info) {
+			return wfResult.Steps, 2
+		}
 
 		if !wfResult.Success {
-			os.Exit(1)
+			return wfResult.Steps, 1
 		}
-		os.Exit(0)
+		return wfResult.Steps, 0
 	}
 
 	results, err := r.Run(ctx, cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(2)
+		return nil, 2
 	}
 
 	switch cfg.OutputFormat {
 	case "json":
 		if err := runner.PrintJSON(os.Stdout, results); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
-			os.Exit(2)
+			return results, 2
 		}
 	case "junit":
-		if err := runner.PrintJUnit(os.Stdout, results); err != nil {
+		if err := runner.PrintJUnit(os.Stdout, results, info); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing JUnit XML: %v\n", err)
-			os.Exit(2)
+			return results, 2
 		}
+	case "tap", "github", "markdown":
+		printReport(cfg.OutputFormat, results, info)
 	default:
 		runner.PrintText(os.Stdout, results, cfg.Verbose)
 	}
+	if !saveReport(cfg.Report, results, info) {
+		return results, 2
+	}
+
+	// Performance baseline: load before saving, so --perf-baseline and
+	// --perf-save can name the same file to compare with and then replace
+	// the previous run
+	var baseline *runner.PerfBaseline
+	if perf.baseline != "" {
+		baseline, err = runner.LoadPerfBaseline(perf.baseline)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading perf baseline: %v\n", err)
+			return results, 2
+		}
+	}
 
 	// Performance baseline: save
-	if *perfSaveFlag != "" {
-		if err := runner.SavePerfBaseline(*perfSaveFlag, results); err != nil {
+	if perf.save != "" {
+		if err := runner.SavePerfBaseline(perf.save, results); err != nil {
 			fmt.Fprintf(os.Stderr, "Error saving perf baseline: %v\n", err)
-			os.Exit(2)
+			return results, 2
 		}
-		fmt.Fprintf(os.Stderr, "Performance baseline saved to %s\n", *perfSaveFlag)
+		fmt.Fprintf(os.Stderr, "Performance baseline saved to %s\n", perf.save)
 	}
 
-	// Performance baseline: compare
-	if *perfBaselineFlag != "" {
-		baseline, err := runner.LoadPerfBaseline(*perfBaselineFlag)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading perf baseline: %v\n", err)
-			os.Exit(2)
+	// Performance baseline: compare. JSON and JUnit output stay parseable
+	// by reporting on stderr.
+	if baseline != nil {
+		perfOut := os.Stdout
+		if cfg.OutputFormat != "text" {
+			perfOut = os.Stderr
 		}
-		comparisons := runner.ComparePerfBaseline(results, baseline, *perfThresholdFlag)
-		fmt.Fprintln(os.Stdout)
-		runner.PrintPerfComparison(os.Stdout, comparisons, *perfThresholdFlag)
+		comparisons := runner.ComparePerfBaseline(results, baseline, perf.threshold)
+		fmt.Fprintln(perfOut)
+		runner.PrintPerfComparison(perfOut, comparisons, perf.threshold)
 		if runner.HasRegressions(comparisons) {
-			os.Exit(1)
+			return results, 1
 		}
 	}
 
-	os.Exit(runner.ExitCode(results))
+	return results, runner.ExitCode(results)
+}
+
+// watchRun runs the collection, then again whenever a file it depends on
+// changes, until interrupted. Status lines go to stderr so JSON and JUnit
+// output stay parseable. It returns the last run's exit code.
+func watchRun(ctx context.Context, cfg runner.Config, perf perfOptions, interval time.Duration) int {
+	var prev []runner.Result
+	for {
+		fmt.Fprintf(os.Stderr, "\n── %s ──\n", time.Now().Format("15:04:05"))
+		results, code := runOnce(ctx, cfg, perf)
+		if prev != nil && results != nil {
+			changes := runner.ResultChanges(prev, results)
+			if len(changes) == 0 {
+				fmt.Fprintf(os.Stderr, "No changes since the last run\n")
+			} else {
+				fmt.Fprintf(os.Stderr, "Since the last run:\n")
+				for _, c := range changes {
+					fmt.Fprintf(os.Stderr, "  %s\n", c)
+				}
+			}
+		}
+		if results != nil {
+			prev = results
+		}
+
+		files := runner.WatchedFiles(cfg.CollectionPath)
+		fmt.Fprintf(os.Stderr, "Watching %d files for changes (Ctrl+C to stop)\n", len(files))
+		changed, err := runner.WaitForChange(ctx, files, interval)
+		if err != nil {
+			return code
+		}
+		for _, p := range changed {
+			fmt.Fprintf(os.Stderr, "Changed: %s\n", p)
+		}
+	}
+}
+
+// findCollection returns the first .gottp.yaml file or split .gottp
+// directory in dir, or "" when there is none.
+func findCollection(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.gottp.yaml"))
+	dirs, _ := filepath.Glob(filepath.Join(dir, "*.gottp"))
+	for _, d := range dirs {
+		if collection.IsSplit(d) {
+			matches = append(matches, d)
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
 }
 
 func tuiCmd() {
 	versionFlag := flag.Bool("version", false, "Print version and exit")
 	collectionFlag := flag.String("collection", "", "Path to a .gottp.yaml collection file")
+	pprofFlag := flag.String("pprof", "", "Serve pprof profiles on this address, e.g. localhost:6060")
+	debugLogFlag := flag.String("debug-log", "", "Append debug output, including periodic memory stats, to this file")
+	forceTUIFlag := flag.Bool("force-tui", false, "Start the TUI even when not attached to a terminal")
+	headlessFlag := flag.String("headless", "", "Without a terminal: help (default), run or validate the collection")
+	recordFlag := flag.String("record", "", "Record every request and response of the session to this HAR file")
 	flag.Parse()
 
 	if *versionFlag {
@@ -254,28 +467,76 @@ func tuiCmd() {
 		}
 		col = c
 		colPath = *collectionFlag
-	} else {
-		// Try to find a .gottp.yaml file in the current directory
-		cwd, _ := os.Getwd()
-		matches, _ := filepath.Glob(filepath.Join(cwd, "*.gottp.yaml"))
-		if len(matches) > 0 {
-			c, err := collection.LoadFromFile(matches[0])
-			if err == nil {
-				col = c
-				colPath = matches[0]
-			}
+	} else if path := findCollection("."); path != "" {
+		if c, err := collection.LoadFromFile(path); err == nil {
+			col = c
+			colPath = path
 		}
 	}
 
 	cfg := config.Load()
+	if !*forceTUIFlag && !interactive() {
+		action := *headlessFlag
+		if action == "" {
+			action = cfg.HeadlessAction
+		}
+		runHeadless(action, colPath)
+		return
+	}
+	if profile, err := theme.DetectProfile(cfg.ColorProfile); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else {
+		lipgloss.SetColorProfile(profile)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *debugLogFlag != "" {
+		f, err := tea.LogToFile(*debugLogFlag, "gottp")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening debug log: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		log.Printf("gottp %s starting", version.Version)
+		go profiling.LogMemStats(ctx, log.Default(), profiling.DefaultInterval)
+	}
+	if *pprofFlag != "" {
+		addr, err := profiling.Serve(ctx, *pprofFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "pprof listening on http://%s/debug/pprof/\n", addr)
+		if *debugLogFlag != "" {
+			log.Printf("pprof listening on http://%s/debug/pprof/", addr)
+		}
+	}
+
 	model := app.New(col, colPath, cfg)
+	if *recordFlag != "" {
+		if err := model.StartRecording(*recordFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	p := tea.NewProgram(
 		model,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if m, ok := final.(app.App); ok {
+		model = m
+	}
+	// Close connections and the history database, without hanging on exit
+	// if a server doesn't answer
+	shutdownCtx, stop := context.WithTimeout(context.Background(), shutdownTimeout)
+	if err := model.Shutdown(shutdownCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	stop()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -1,6 +1,9 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
@@ -8,13 +11,20 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	oauth2auth "github.com/sadopc/gottp/internal/auth/oauth2"
 	"github.com/sadopc/gottp/internal/config"
 	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/core/cookies"
 	"github.com/sadopc/gottp/internal/core/environment"
 	"github.com/sadopc/gottp/internal/core/history"
+	"github.com/sadopc/gottp/internal/core/httpcache"
+	"github.com/sadopc/gottp/internal/core/scratch"
+	"github.com/sadopc/gottp/internal/core/secrets"
+	"github.com/sadopc/gottp/internal/core/session"
 	"github.com/sadopc/gottp/internal/core/state"
 	gotls "github.com/sadopc/gottp/internal/core/tls"
+	harexport "github.com/sadopc/gottp/internal/export/har"
+	"github.com/sadopc/gottp/internal/profiling"
 	"github.com/sadopc/gottp/internal/protocol"
 	"github.com/sadopc/gottp/internal/protocol/graphql"
 	grpcclient "github.com/sadopc/gottp/internal/protocol/grpc"
@@ -43,6 +53,15 @@ type App struct {
 	toast          components.Toast
 	modal          components.Modal
 	jump           components.JumpOverlay
+	findReplace    components.FindReplace
+	duplicates     components.Duplicates
+	variables      components.Variables
+	runResults     components.RunResults
+	annotate       components.Annotate
+	historyNote    components.HistoryNote
+	prompt         components.Prompt
+	playground     components.Playground
+	codeGen        components.CodeGen
 
 	store        *state.Store
 	protocols    *protocol.Registry
@@ -50,6 +69,30 @@ type App struct {
 	envFile      *environment.EnvironmentFile
 	cfg          config.Config
 	history      *history.Store
+	historyQuery string // sidebar filter the history list follows
+	secrets      *secrets.Resolver
+	oauthTokens  *oauth2auth.TokenStore
+	respCache    *httpcache.Cache
+	recorder     *harexport.Recorder // nil unless recording the session
+	scratchPath  string              // where tabs outside the collection are kept
+	sessionPath  string              // where open tabs and layout are kept
+
+	// varsSeen is what the editor's variable warnings were computed from
+	varsSeen varsSeen
+
+	// collectionStamp identifies the collection file as last seen on disk
+	collectionStamp fileStamp
+	// stopWatching ends the collection file watch when closed
+	stopWatching chan struct{}
+
+	ws             *wsSession
+	wsScript       []protocol.WSScriptMessage
+	wsPingInterval time.Duration
+
+	// progress delivers body download progress for the in-flight request
+	progress <-chan msgs.DownloadProgressMsg
+	// sendSeq numbers sends, so ticks from an earlier one stop
+	sendSeq int
 
 	mode           msgs.AppMode
 	focus          msgs.PanelFocus
@@ -67,12 +110,15 @@ type App struct {
 
 // New creates a new App model.
 func New(col *collection.Collection, colPath string, cfg config.Config) App {
-	t := theme.Resolve(cfg.Theme)
+	t := theme.ForProfile(theme.Resolve(cfg.Theme), lipgloss.ColorProfile())
 	s := theme.NewStyles(t)
 
 	store := state.NewStore()
 	store.Collection = col
 	store.CollectionPath = colPath
+	if col != nil {
+		store.CollectionHash, _ = collection.Fingerprint(col)
+	}
 	store.NewTab()
 
 	// Set up protocol registry
@@ -84,6 +130,8 @@ func New(col *collection.Collection, colPath string, cfg config.Config) App {
 	if cfg.ProxyURL != "" {
 		httpClient.SetProxy(cfg.ProxyURL, cfg.NoProxy)
 	}
+	httpClient.SetDownloadThreshold(cfg.DownloadThresholdBytes(), cfg.DownloadDir)
+	httpClient.SetPoolOptions(httpclient.PoolOptions(cfg.Connections))
 	if !cfg.TLS.IsEmpty() {
 		tlsCfg, err := (&gotls.Config{
 			CertFile:           cfg.TLS.CertFile,
@@ -97,6 +145,8 @@ func New(col *collection.Collection, colPath string, cfg config.Config) App {
 	}
 	cookieJar := cookies.New()
 	httpClient.SetCookieJar(cookieJar)
+	respCache := httpcache.New()
+	httpClient.SetCache(respCache)
 	registry.Register(httpClient)
 	registry.Register(graphql.New())
 	registry.Register(wsclient.New())
@@ -108,28 +158,41 @@ func New(col *collection.Collection, colPath string, cfg config.Config) App {
 		scriptTimeout = 5 * time.Second
 	}
 	scriptEngine := scripting.NewEngine(scriptTimeout)
+	scriptEngine.SetRegistry(registry)
+
+	// Secret references and encrypted values are resolved at send time
+	secretResolver := secrets.DefaultResolver()
 
-	// Load environments from environments.yaml next to the collection file
+	// Load environments from environments.yaml next to the collection file,
+	// which can read variables from a .env file there via {{env:NAME}}
 	var envFile *environment.EnvironmentFile
 	if colPath != "" {
 		dir := filepath.Dir(colPath)
+		_, _ = environment.LoadDotEnv(filepath.Join(dir, ".env"))
 		ef, err := environment.LoadEnvironments(filepath.Join(dir, "environments.yaml"))
 		if err == nil && len(ef.Environments) > 0 {
 			envFile = ef
 			// Auto-select first environment
 			store.ActiveEnv = ef.Environments[0].Name
 			store.EnvVars = ef.GetVariables(store.ActiveEnv)
+			secretResolver.Remember(ef.SecretValues(store.ActiveEnv)...)
 		}
 	}
 
 	// Init history store
 	var histStore *history.Store
-	dataDir := filepath.Join(os.Getenv("HOME"), ".local", "share", "gottp")
+	dataDir := config.DataDir()
 	_ = os.MkdirAll(dataDir, 0755)
 	if hs, err := history.NewStore(filepath.Join(dataDir, "history.db")); err == nil {
 		histStore = hs
 	}
 
+	// OAuth2 tokens are shared across tabs and persisted between sessions
+	tokenStore, err := oauth2auth.OpenTokenStore(filepath.Join(dataDir, "oauth2_tokens.json"))
+	if err != nil {
+		tokenStore = oauth2auth.NewTokenStore()
+	}
+
 	a := App{
 		sidebar:  sidebar.New(t, s),
 		editor:   editor.New(t, s),
@@ -142,6 +205,15 @@ func New(col *collection.Collection, colPath string, cfg config.Config) App {
 		toast:          components.NewToast(t, s),
 		modal:          components.NewModal(t, s),
 		jump:           components.NewJumpOverlay(t, s),
+		findReplace:    components.NewFindReplace(t, s),
+		duplicates:     components.NewDuplicates(t, s),
+		variables:      components.NewVariables(t, s),
+		runResults:     components.NewRunResults(t, s),
+		annotate:       components.NewAnnotate(t, s),
+		historyNote:    components.NewHistoryNote(t, s),
+		prompt:         components.NewPrompt(t, s),
+		playground:     components.NewPlayground(t, s),
+		codeGen:        components.NewCodeGen(t, s),
 
 		store:        store,
 		protocols:    registry,
@@ -149,6 +221,14 @@ func New(col *collection.Collection, colPath string, cfg config.Config) App {
 		envFile:      envFile,
 		cfg:          cfg,
 		history:      histStore,
+		secrets:      secretResolver,
+		oauthTokens:  tokenStore,
+		respCache:    respCache,
+		scratchPath:  filepath.Join(dataDir, scratch.FileName),
+		sessionPath:  filepath.Join(dataDir, session.FileName),
+
+		collectionStamp: statFile(colPath),
+		stopWatching:    make(chan struct{}),
 
 		mode:           msgs.ModeNormal,
 		focus:          msgs.FocusEditor,
@@ -159,10 +239,10 @@ func New(col *collection.Collection, colPath string, cfg config.Config) App {
 		styles: s,
 	}
 
-	if col != nil {
-		items := collection.FlattenItems(col.Items, 0, "")
-		a.sidebar.SetItems(items)
-	}
+	a.response.SetWSBufferSize(cfg.WSBufferSize)
+
+	a.reportScriptErrors(a.refreshSidebar())
+	a.restoreTabs()
 
 	if store.ActiveEnv != "" {
 		a.statusBar.SetEnv(store.ActiveEnv)
@@ -176,10 +256,56 @@ func New(col *collection.Collection, colPath string, cfg config.Config) App {
 }
 
 func (a App) Init() tea.Cmd {
-	return a.response.Init()
+	return tea.Batch(a.response.Init(), a.watchCollection())
+}
+
+// Shutdown releases what the app holds open once the program has exited:
+// the WebSocket session and its message log's temporary file, protocol
+// connections such as gRPC channels, and the history database. Connections still closing when ctx is done are
+// abandoned, but the database is always closed. Scratch tabs and the
+// session are saved first.
+func (a App) Shutdown(ctx context.Context) error {
+	var errs []error
+	if err := a.saveScratch(); err != nil {
+		errs = append(errs, fmt.Errorf("saving scratch requests: %w", err))
+	}
+	if err := a.saveSession(); err != nil {
+		errs = append(errs, fmt.Errorf("saving session: %w", err))
+	}
+	close(a.stopWatching)
+	a.response.ClearWSLog()
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		a.closeWS()
+	}()
+	select {
+	case <-closed:
+	case <-ctx.Done():
+	}
+	if err := a.protocols.Close(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if a.history != nil {
+		if err := a.history.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing history: %w", err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
+// Update handles msg, then refreshes the editor's variable warnings if
+// the request or its variables may have changed.
 func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := a.update(msg)
+	if next, ok := m.(App); ok && next.varsChanged(msg) {
+		next.refreshVarWarnings()
+		m = next
+	}
+	return m, cmd
+}
+
+func (a App) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -212,10 +338,65 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.jump, cmd = a.jump.Update(msg)
 			return a, cmd
 		}
+		if a.findReplace.Visible {
+			var cmd tea.Cmd
+			a.findReplace, cmd = a.findReplace.Update(msg)
+			return a, cmd
+		}
+		if a.duplicates.Visible {
+			var cmd tea.Cmd
+			a.duplicates, cmd = a.duplicates.Update(msg)
+			return a, cmd
+		}
+		if a.variables.Visible {
+			var cmd tea.Cmd
+			a.variables, cmd = a.variables.Update(msg)
+			return a, cmd
+		}
+		if a.runResults.Visible {
+			var cmd tea.Cmd
+			a.runResults, cmd = a.runResults.Update(msg)
+			return a, cmd
+		}
+		if a.annotate.Visible {
+			var cmd tea.Cmd
+			a.annotate, cmd = a.annotate.Update(msg)
+			return a, cmd
+		}
+		if a.historyNote.Visible {
+			var cmd tea.Cmd
+			a.historyNote, cmd = a.historyNote.Update(msg)
+			return a, cmd
+		}
+		if a.prompt.Visible {
+			var cmd tea.Cmd
+			a.prompt, cmd = a.prompt.Update(msg)
+			return a, cmd
+		}
+		if a.playground.Visible {
+			var cmd tea.Cmd
+			a.playground, cmd = a.playground.Update(msg)
+			return a, cmd
+		}
+		if a.codeGen.Visible {
+			var cmd tea.Cmd
+			a.codeGen, cmd = a.codeGen.Update(msg)
+			return a, cmd
+		}
 
 		if a.focus == msgs.FocusEditor && a.editor.Editing() {
 			return a.updateEditorInsert(msg)
 		}
+		if a.focus == msgs.FocusSidebar && a.sidebar.Filtering() && msg.String() != "ctrl+c" {
+			var cmd tea.Cmd
+			a.sidebar, cmd = a.sidebar.Update(msg)
+			return a, cmd
+		}
+		if a.focus == msgs.FocusResponse && a.response.Editing() && msg.String() != "ctrl+c" {
+			var cmd tea.Cmd
+			a.response, cmd = a.response.Update(msg)
+			return a, cmd
+		}
 
 		cmd := a.handleGlobalKey(msg)
 		if cmd != nil {
@@ -224,30 +405,49 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a.handlePanelKey(msg)
 
 	case msgs.SendRequestMsg:
-		return a.sendRequest()
+		return a.sendRequestWith(msg.Download, msg.Confirmed)
+
+	case msgs.DownloadProgressMsg:
+		a.response.SetProgress(msg.Received, msg.Total)
+		a.statusBar.SetProgress(msg.Received, msg.Total)
+		return a, listenProgress(a.progress)
+
+	case msgs.RequestTickMsg:
+		if msg.Seq != a.sendSeq {
+			return a, nil
+		}
+		if !a.response.Loading() {
+			a.statusBar.StopLoading()
+			return a, nil
+		}
+		a.statusBar.Tick(msg.Time)
+		return a, requestTick(msg.Seq)
 
 	case msgs.RequestSentMsg:
 		return a.handleRequestSent(msg)
 
 	case msgs.NewRequestMsg:
+		a.leaveTab()
 		a.store.NewTab()
 		a.syncTabs()
 		a.loadActiveRequest()
 		return a, nil
 
 	case msgs.CloseTabMsg:
-		a.store.CloseTab()
-		a.syncTabs()
-		a.loadActiveRequest()
-		return a, nil
+		return a.closeTab(msg)
+
+	case msgs.QuitMsg:
+		return a.quit()
 
 	case msgs.NextTabMsg:
+		a.leaveTab()
 		a.store.NextTab()
 		a.syncTabs()
 		a.loadActiveRequest()
 		return a, nil
 
 	case msgs.PrevTabMsg:
+		a.leaveTab()
 		a.store.PrevTab()
 		a.syncTabs()
 		a.loadActiveRequest()
@@ -255,6 +455,7 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case msgs.SwitchTabMsg:
 		if msg.Index >= 0 && msg.Index < len(a.store.Tabs) {
+			a.leaveTab()
 			a.store.ActiveTab = msg.Index
 			a.syncTabs()
 			a.loadActiveRequest()
@@ -263,11 +464,7 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case msgs.SwitchEnvMsg:
 		if msg.Name != "" && a.envFile != nil {
-			a.store.ActiveEnv = msg.Name
-			a.store.EnvVars = a.envFile.GetVariables(msg.Name)
-			a.statusBar.SetEnv(msg.Name)
-			cmd := a.toast.Show("Environment: "+msg.Name, false, 2*time.Second)
-			return a, cmd
+			return a.switchEnv(msg.Name)
 		}
 		// If Name is empty, open env picker via command palette
 		if a.envFile != nil && len(a.envFile.Environments) > 0 {
@@ -279,6 +476,12 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmd := a.toast.Show("No environments found", true, 2*time.Second)
 		return a, cmd
 
+	case msgs.CycleEnvMsg:
+		return a.cycleEnv()
+
+	case msgs.PinEnvMsg:
+		return a.handlePinEnv(msg)
+
 	case msgs.SwitchThemeMsg:
 		return a.handleSwitchTheme(msg)
 
@@ -306,6 +509,9 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case msgs.SaveRequestMsg:
 		return a.saveCollection()
 
+	case msgs.SaveAllMsg:
+		return a.saveAll(msg)
+
 	case msgs.RequestSelectedMsg:
 		return a.handleRequestSelected(msg)
 
@@ -322,9 +528,30 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmd := a.toast.Show(msg.Text, msg.IsError, msg.Duration)
 		return a, cmd
 
+	case msgs.CopyTextMsg:
+		return a.copyText(msg)
+
+	case msgs.OpenURLMsg:
+		return a.openLink(msg)
+
+	case msgs.AnnotateResponseMsg:
+		return a.openAnnotate()
+
+	case msgs.ShareSnippetMsg:
+		return a.shareSnippet(msg)
+
 	case msgs.CopyAsCurlMsg:
 		return a.copyAsCurl()
 
+	case msgs.ExportWSLogMsg:
+		return a.exportWSLog()
+
+	case msgs.ExportShellScriptMsg:
+		return a.exportShellScript()
+
+	case msgs.FreeMemoryMsg:
+		return a.freeMemory()
+
 	case msgs.ImportCurlMsg:
 		return a.importCurl()
 
@@ -337,17 +564,103 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case msgs.SetBaselineMsg:
 		return a.handleSetBaseline()
 
+	case msgs.PinResponseMsg:
+		return a.handlePinResponse(msg)
+
 	case msgs.ClearBaselineMsg:
 		a.response.ClearBaseline()
 		cmd := a.toast.Show("Baseline cleared", false, 2*time.Second)
 		return a, cmd
 
+	case msgs.FindReplaceMsg:
+		return a.openFindReplace()
+
+	case msgs.ApplyReplaceMsg:
+		return a.applyReplace(msg)
+
+	case msgs.RunFolderMsg:
+		return a.handleRunFolder(msg)
+
+	case msgs.FolderRunResultMsg:
+		return a.handleFolderRunResult(msg)
+
+	case msgs.MoveRequestMsg:
+		return a.moveRequest(msg)
+
+	case msgs.PasteItemMsg:
+		return a.pasteItem(msg)
+
+	case msgs.AddItemMsg:
+		return a.addItem(msg)
+
+	case msgs.RenameItemMsg:
+		return a.renameItem(msg)
+
+	case msgs.DeleteItemMsg:
+		return a.deleteItem(msg)
+
+	case msgs.SaveToCollectionMsg:
+		return a.saveToCollection(msg)
+
+	case msgs.MoveItemMsg:
+		return a.moveItem(msg)
+
+	case msgs.FindDuplicatesMsg:
+		return a.openDuplicates()
+
+	case msgs.EditVariablesMsg:
+		return a.openVariables()
+
+	case msgs.VariablesChangedMsg:
+		return a.saveVariables()
+
+	case msgs.MergeDuplicatesMsg:
+		return a.mergeDuplicates(msg)
+
+	case msgs.ManageOAuth2TokensMsg:
+		return a.handleManageOAuth2Tokens()
+
+	case msgs.InvalidateOAuth2TokenMsg:
+		return a.handleInvalidateOAuth2Token(msg)
+
+	case msgs.ToggleRecordingMsg:
+		return a.toggleRecording()
+
+	case msgs.RecordingFailedMsg:
+		return a.recordingFailed(msg)
+
+	case msgs.ManageResponseCacheMsg:
+		return a.handleManageResponseCache()
+
+	case msgs.ForgetCachedResponseMsg:
+		return a.handleForgetCachedResponse(msg)
+
 	case msgs.OAuth2TokenMsg:
 		return a.handleOAuth2Token(msg)
 
 	case msgs.HistorySelectedMsg:
 		return a.handleHistorySelected(msg)
 
+	case msgs.EditHistoryNoteMsg:
+		return a.openHistoryNote(msg)
+
+	case msgs.HistoryNoteSavedMsg:
+		return a.saveHistoryNote(msg)
+
+	case msgs.FilterHistoryMsg:
+		a.historyQuery = msg.Query
+		a.loadHistory()
+		return a, nil
+
+	case msgs.ExportHistoryMsg:
+		return a.exportHistory()
+
+	case msgs.OpenPlaygroundMsg:
+		return a.openPlayground(msg)
+
+	case msgs.InsertQueryMsg:
+		return a.insertQuery(msg)
+
 	case msgs.FocusPanelMsg:
 		a.focus = msg.Panel
 		a.updateFocus()
@@ -356,6 +669,18 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case msgs.OpenEditorMsg:
 		return a.openExternalEditor()
 
+	case msgs.EditRequestSourceMsg:
+		return a.editRequestSource()
+
+	case msgs.CollectionEditedMsg:
+		return a.reloadEditedCollection(msg)
+
+	case msgs.CollectionFileChangedMsg:
+		return a.checkCollectionFile()
+
+	case msgs.ReloadCollectionMsg:
+		return a.reloadCollection()
+
 	case msgs.EditorDoneMsg:
 		if msg.Content != "" {
 			a.editor.SetBody(msg.Content)
@@ -375,6 +700,9 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case msgs.IntrospectionResultMsg:
 		return a.handleIntrospectionResult(msg)
 
+	case msgs.ScaffoldGraphQLMsg:
+		return a.handleScaffoldGraphQL(msg)
+
 	case msgs.ScriptResultMsg:
 		return a.handleScriptResult(msg)
 
@@ -386,7 +714,29 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmd := a.toast.Show("WebSocket connected", false, 2*time.Second)
 		return a, cmd
 
+	case msgs.WSDisconnectMsg:
+		if a.ws == nil {
+			cmd := a.toast.Show("WebSocket not connected", true, 2*time.Second)
+			return a, cmd
+		}
+		a.closeWS()
+		return a, nil
+
+	case msgs.WSSendMsg:
+		return a.handleWSSend(msg)
+
+	case msgs.WSEventMsg:
+		return a.handleWSEvent(msg)
+
+	case msgs.WSEventBatchMsg:
+		return a.handleWSEvents(msg.Events)
+
 	case msgs.WSDisconnectedMsg:
+		if a.ws != nil {
+			a.closeWS()
+			a.ws = nil
+		}
+		a.editor.WSForm().SetConnected(false)
 		if msg.Err != nil {
 			cmd := a.toast.Show("WebSocket closed: "+msg.Err.Error(), true, 3*time.Second)
 			return a, cmd
@@ -460,7 +810,18 @@ func (a App) View() string {
 		panels = lipgloss.JoinHorizontal(lipgloss.Top, panelViews...)
 	}
 
-	statusBar := a.statusBar.View()
+	sb := a.statusBar
+	if a.store.TabPinned() {
+		sb.SetEnv("tab: " + a.store.EffectiveEnv())
+	}
+	sb.SetProxy(a.activeProxy(a.store.EffectiveVars(), a.collectionVars()).Label())
+	if a.recorder != nil {
+		sb.SetRecording(fmt.Sprintf("REC %d", a.recorder.Len()))
+	}
+	if a.focus == msgs.FocusResponse {
+		sb.SetBreadcrumb(a.response.Breadcrumb())
+	}
+	statusBar := sb.View()
 	main := lipgloss.JoinVertical(lipgloss.Left, tabBar, panels, statusBar)
 
 	if a.commandPalette.Visible {
@@ -475,6 +836,33 @@ func (a App) View() string {
 	if a.jump.Visible {
 		main = overlayCenter(main, a.jump.View(), a.width, a.height)
 	}
+	if a.findReplace.Visible {
+		main = overlayCenter(main, a.findReplace.View(), a.width, a.height)
+	}
+	if a.duplicates.Visible {
+		main = overlayCenter(main, a.duplicates.View(), a.width, a.height)
+	}
+	if a.variables.Visible {
+		main = overlayCenter(main, a.variables.View(), a.width, a.height)
+	}
+	if a.runResults.Visible {
+		main = overlayCenter(main, a.runResults.View(), a.width, a.height)
+	}
+	if a.annotate.Visible {
+		main = overlayCenter(main, a.annotate.View(), a.width, a.height)
+	}
+	if a.historyNote.Visible {
+		main = overlayCenter(main, a.historyNote.View(), a.width, a.height)
+	}
+	if a.prompt.Visible {
+		main = overlayCenter(main, a.prompt.View(), a.width, a.height)
+	}
+	if a.playground.Visible {
+		main = overlayCenter(main, a.playground.View(), a.width, a.height)
+	}
+	if a.codeGen.Visible {
+		main = overlayCenter(main, a.codeGen.View(), a.width, a.height)
+	}
 	if a.toast.Visible {
 		toastView := a.toast.View()
 		main = overlayTopRight(main, toastView, a.width)
@@ -511,6 +899,15 @@ func (a *App) resizePanels() {
 	a.updateFocus()
 }
 
+// freeMemory runs a garbage collection and shows how much heap it freed.
+func (a App) freeMemory() (tea.Model, tea.Cmd) {
+	before, after := profiling.FreeMemory()
+	text := fmt.Sprintf("Heap %s → %s, %s from OS",
+		profiling.FormatSize(before.HeapAlloc), profiling.FormatSize(after.HeapAlloc), profiling.FormatSize(after.Sys))
+	cmd := a.toast.Show(text, false, 4*time.Second)
+	return a, cmd
+}
+
 func findRequest(items []collection.Item, id string) *collection.Request {
 	for i := range items {
 		if items[i].Request != nil && items[i].Request.ID == id {
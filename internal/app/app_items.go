@@ -0,0 +1,210 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+)
+
+// itemName returns the name of a request or folder.
+func itemName(item collection.Item) string {
+	if item.Folder != nil {
+		return item.Folder.Name
+	}
+	if item.Request != nil {
+		return item.Request.Name
+	}
+	return ""
+}
+
+// commitStructure shows a change to the collection's tree in the sidebar
+// with selected under the cursor, and saves it, since the tree and its
+// order are what gottp run follows. done, if set, is shown as a toast.
+func (a *App) commitStructure(selected collection.Item, done string) tea.Cmd {
+	a.refreshSidebar()
+	a.sidebar.SelectItem(selected)
+	if a.store.CollectionPath != "" {
+		if _, err := a.writeCollection(); err != nil {
+			return a.toast.Show("Save failed: "+err.Error(), true, 3*time.Second)
+		}
+	}
+	if done == "" {
+		return nil
+	}
+	return a.toast.Show(done, false, 2*time.Second)
+}
+
+// addItem asks for a name, then adds a request or folder inside the folder
+// under the sidebar cursor, or beside the request there. New requests open
+// in a tab.
+func (a App) addItem(msg msgs.AddItemMsg) (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil {
+		cmd := a.toast.Show("No collection to add to", true, 2*time.Second)
+		return a, cmd
+	}
+	if msg.Name == "" {
+		title, value := "New Request", "New Request"
+		if msg.Folder {
+			title, value = "New Folder", "New Folder"
+		}
+		a.prompt.Open(title, value, func(name string) tea.Msg {
+			msg.Name = name
+			return msg
+		})
+		a.mode = msgs.ModeModal
+		return a, nil
+	}
+
+	parent := msg.At.Folder
+	if msg.At.Request != nil {
+		parent, _ = collection.ParentFolder(a.store.Collection, msg.At)
+	}
+	if msg.Folder {
+		item := collection.Item{Folder: &collection.Folder{Name: msg.Name}}
+		collection.AddItem(a.store.Collection, parent, item)
+		cmd := a.commitStructure(item, fmt.Sprintf("Added folder %q", msg.Name))
+		return a, cmd
+	}
+
+	req := collection.NewRequest(msg.Name, "GET", "")
+	collection.AddItem(a.store.Collection, parent, collection.Item{Request: req})
+	a.leaveTab()
+	a.store.OpenRequest(req)
+	a.syncTabs()
+	a.editor.LoadRequest(req)
+	a.recordEdit()
+	cmd := a.commitStructure(collection.Item{Request: req}, fmt.Sprintf("Added request %q", msg.Name))
+	return a, cmd
+}
+
+// renameItem asks for a new name, then renames a request or folder.
+func (a App) renameItem(msg msgs.RenameItemMsg) (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil {
+		return a, nil
+	}
+	old := itemName(msg.Item)
+	if msg.Name == "" {
+		a.prompt.Open("Rename "+old, old, func(name string) tea.Msg {
+			msg.Name = name
+			return msg
+		})
+		a.mode = msgs.ModeModal
+		return a, nil
+	}
+	if msg.Name == old {
+		return a, nil
+	}
+
+	if msg.Item.Folder != nil {
+		msg.Item.Folder.Name = msg.Name
+	} else {
+		collection.RenameRequest(a.store.Collection, msg.Item.Request, msg.Name)
+		a.syncTabs()
+	}
+	cmd := a.commitStructure(msg.Item, fmt.Sprintf("Renamed %q to %q", old, msg.Name))
+	return a, cmd
+}
+
+// deleteItem asks for confirmation, then deletes a request or folder and
+// closes the tabs of the requests that went with it.
+func (a App) deleteItem(msg msgs.DeleteItemMsg) (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil {
+		return a, nil
+	}
+	name := itemName(msg.Item)
+	var reqs []*collection.Request
+	if msg.Item.Folder != nil {
+		for _, item := range collection.FlattenItems(msg.Item.Folder.Items, 0, "") {
+			if item.Request != nil {
+				reqs = append(reqs, item.Request)
+			}
+		}
+	} else {
+		reqs = []*collection.Request{msg.Item.Request}
+	}
+
+	if !msg.Confirmed {
+		text := fmt.Sprintf("Delete request %q?", name)
+		if msg.Item.Folder != nil {
+			text = fmt.Sprintf("Delete folder %q and the %d requests in it?", name, len(reqs))
+		}
+		msg.Confirmed = true
+		a.modal.Show("Delete", text, msg)
+		a.mode = msgs.ModeModal
+		return a, nil
+	}
+
+	parent, _ := collection.ParentFolder(a.store.Collection, msg.Item)
+	if !collection.RemoveItem(a.store.Collection, msg.Item) {
+		return a, nil
+	}
+	active := a.store.ActiveRequest()
+	a.store.CloseRequestTabs(reqs)
+	if a.store.ActiveRequest() != active {
+		a.loadActiveRequest()
+	}
+	a.syncTabs()
+	cmd := a.commitStructure(collection.Item{Folder: parent}, fmt.Sprintf("Deleted %q", name))
+	return a, cmd
+}
+
+// moveItem asks for a folder, then moves a request or folder into it.
+func (a App) moveItem(msg msgs.MoveItemMsg) (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil {
+		return a, nil
+	}
+	if !msg.Picked {
+		var folders []collection.FolderRef
+		self := ""
+		for _, ref := range collection.Folders(a.store.Collection) {
+			if ref.Folder == msg.Item.Folder {
+				self = ref.Path
+			}
+			// A folder cannot go inside itself
+			if self != "" && (ref.Path == self || strings.HasPrefix(ref.Path, self+"/")) {
+				continue
+			}
+			folders = append(folders, ref)
+		}
+		a.commandPalette.OpenMovePicker(msg.Item, folders)
+		a.mode = msgs.ModeCommandPalette
+		return a, nil
+	}
+
+	err := collection.MoveItem(a.store.Collection, msg.Item, msg.Into)
+	if errors.Is(err, collection.ErrMoveIntoSelf) {
+		cmd := a.toast.Show("Cannot move a folder into itself", true, 2*time.Second)
+		return a, cmd
+	} else if err != nil {
+		return a, nil
+	}
+	into := "the top level"
+	if msg.Into != nil {
+		into = fmt.Sprintf("%q", msg.Into.Name)
+	}
+	cmd := a.commitStructure(msg.Item, fmt.Sprintf("Moved %q to %s", itemName(msg.Item), into))
+	return a, cmd
+}
+
+// pasteItem moves the item cut in the sidebar to below the cursor.
+func (a App) pasteItem(msg msgs.PasteItemMsg) (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil {
+		return a, nil
+	}
+	switch err := collection.PlaceItem(a.store.Collection, msg.Item, msg.At); {
+	case errors.Is(err, collection.ErrMoveIntoSelf):
+		cmd := a.toast.Show("Cannot move a folder into itself", true, 2*time.Second)
+		return a, cmd
+	case err != nil:
+		cmd := a.toast.Show(fmt.Sprintf("%q is no longer in the collection", itemName(msg.Item)), true, 2*time.Second)
+		return a, cmd
+	}
+	cmd := a.commitStructure(msg.Item, "")
+	return a, cmd
+}
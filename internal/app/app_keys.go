@@ -12,6 +12,9 @@ import (
 func (a App) handleGlobalKey(msg tea.KeyMsg) tea.Cmd {
 	switch {
 	case key.Matches(msg, a.keys.Quit):
+		if a.unsavedChanges() {
+			return func() tea.Msg { return msgs.QuitMsg{} }
+		}
 		return tea.Quit
 	case key.Matches(msg, a.keys.SendRequest):
 		return func() tea.Msg { return msgs.SendRequestMsg{} }
@@ -25,6 +28,8 @@ func (a App) handleGlobalKey(msg tea.KeyMsg) tea.Cmd {
 		return func() tea.Msg { return msgs.CloseTabMsg{} }
 	case key.Matches(msg, a.keys.SaveRequest):
 		return func() tea.Msg { return msgs.SaveRequestMsg{} }
+	case key.Matches(msg, a.keys.SwitchEnv):
+		return func() tea.Msg { return msgs.SwitchEnvMsg{} }
 	case key.Matches(msg, a.keys.PrevTab):
 		return func() tea.Msg { return msgs.PrevTabMsg{} }
 	case key.Matches(msg, a.keys.NextTab):
@@ -71,9 +76,15 @@ func (a App) handlePanelKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Activate jump mode
 		a.activateJumpMode()
 		return a, nil
+	case "u", "U":
+		// Undo and redo editor changes
+		return a.undoEdit(msg.String() == "U")
 	case "E":
 		// Open body in $EDITOR
 		return a.openExternalEditor()
+	case "e":
+		// Cycle environments
+		return a.cycleEnv()
 	}
 
 	var cmd tea.Cmd
@@ -82,6 +93,8 @@ func (a App) handlePanelKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		a.sidebar, cmd = a.sidebar.Update(msg)
 	case msgs.FocusEditor:
 		a.editor, cmd = a.editor.Update(msg)
+		a.markModified()
+		a.recordEdit()
 	case msgs.FocusResponse:
 		a.response, cmd = a.response.Update(msg)
 	}
@@ -100,6 +113,8 @@ func (a App) updateEditorInsert(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	var cmd tea.Cmd
 	a.editor, cmd = a.editor.Update(msg)
+	a.markModified()
+	a.recordEdit()
 
 	if a.editor.Editing() {
 		a.mode = msgs.ModeInsert
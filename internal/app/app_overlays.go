@@ -3,14 +3,20 @@ package app
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 
 	"github.com/sadopc/gottp/internal/core/collection"
 	importutil "github.com/sadopc/gottp/internal/import"
+	"github.com/sadopc/gottp/internal/import/har"
+	"github.com/sadopc/gottp/internal/import/httpfile"
+	"github.com/sadopc/gottp/internal/import/hurl"
 	"github.com/sadopc/gottp/internal/import/insomnia"
 	"github.com/sadopc/gottp/internal/import/openapi"
 	"github.com/sadopc/gottp/internal/import/postman"
@@ -30,7 +36,7 @@ func (a App) handleSwitchTheme(msg msgs.SwitchThemeMsg) (tea.Model, tea.Cmd) {
 		return a, nil
 	}
 
-	t := theme.Resolve(msg.Name)
+	t := theme.ForProfile(theme.Resolve(msg.Name), lipgloss.ColorProfile())
 	s := theme.NewStyles(t)
 	a.theme = t
 	a.styles = s
@@ -45,12 +51,18 @@ func (a App) handleSwitchTheme(msg msgs.SwitchThemeMsg) (tea.Model, tea.Cmd) {
 	a.toast = components.NewToast(t, s)
 	a.modal = components.NewModal(t, s)
 	a.jump = components.NewJumpOverlay(t, s)
+	a.findReplace = components.NewFindReplace(t, s)
+	a.duplicates = components.NewDuplicates(t, s)
+	a.variables = components.NewVariables(t, s)
+	a.runResults = components.NewRunResults(t, s)
+	a.annotate = components.NewAnnotate(t, s)
+	a.historyNote = components.NewHistoryNote(t, s)
+	a.prompt = components.NewPrompt(t, s)
+	a.playground = components.NewPlayground(t, s)
+	a.codeGen = components.NewCodeGen(t, s)
 
 	// Re-set state
-	if a.store.Collection != nil {
-		items := collection.FlattenItems(a.store.Collection.Items, 0, "")
-		a.sidebar.SetItems(items)
-	}
+	a.refreshSidebar()
 	a.loadHistory()
 	if a.store.ActiveEnv != "" {
 		a.statusBar.SetEnv(a.store.ActiveEnv)
@@ -93,6 +105,12 @@ func (a App) handleImportFile(msg msgs.ImportFileMsg) (tea.Model, tea.Cmd) {
 			col, parseErr = insomnia.ParseInsomnia(data)
 		case "openapi":
 			col, parseErr = openapi.ParseOpenAPI(data)
+		case "har":
+			col, parseErr = har.ParseHAR(data)
+		case "http":
+			col, parseErr = httpfile.ParseHTTPFile(data)
+		case "hurl":
+			col, parseErr = hurl.ParseHurl(data)
 		default:
 			// Try auto-detection
 			detected := importutil.DetectFormat(data)
@@ -103,6 +121,12 @@ func (a App) handleImportFile(msg msgs.ImportFileMsg) (tea.Model, tea.Cmd) {
 				col, parseErr = insomnia.ParseInsomnia(data)
 			case "openapi":
 				col, parseErr = openapi.ParseOpenAPI(data)
+			case "har":
+				col, parseErr = har.ParseHAR(data)
+			case "http":
+				col, parseErr = httpfile.ParseHTTPFile(data)
+			case "hurl":
+				col, parseErr = hurl.ParseHurl(data)
 			default:
 				return msgs.ImportCompleteMsg{Err: os.ErrInvalid}
 			}
@@ -132,8 +156,7 @@ func (a App) handleImportComplete(msg msgs.ImportCompleteMsg) (tea.Model, tea.Cm
 		a.store.Collection.Items = append(a.store.Collection.Items, msg.Collection.Items...)
 	}
 
-	items := collection.FlattenItems(a.store.Collection.Items, 0, "")
-	a.sidebar.SetItems(items)
+	a.reportScriptErrors(a.refreshSidebar())
 
 	cmd := a.toast.Show("Imported "+msg.Collection.Name, false, 2*time.Second)
 	return a, cmd
@@ -150,14 +173,33 @@ func (a App) handleSetBaseline() (tea.Model, tea.Cmd) {
 	return a, cmd
 }
 
-func (a App) openExternalEditor() (tea.Model, tea.Cmd) {
-	editorCmd := a.cfg.Editor
-	if editorCmd == "" {
-		editorCmd = os.Getenv("EDITOR")
+func (a App) handlePinResponse(msg msgs.PinResponseMsg) (tea.Model, tea.Cmd) {
+	if msg.Unpin {
+		a.response.Unpin()
+		return a, nil
+	}
+	if !a.response.Pin() {
+		cmd := a.toast.Show("No HTTP response to pin", true, 2*time.Second)
+		return a, cmd
 	}
-	if editorCmd == "" {
-		editorCmd = "vi"
+	cmd := a.toast.Show("Response pinned: send again to compare side by side", false, 2*time.Second)
+	return a, cmd
+}
+
+// externalEditor returns the configured editor command, falling back to
+// $EDITOR and vi.
+func (a App) externalEditor() string {
+	if a.cfg.Editor != "" {
+		return a.cfg.Editor
 	}
+	if e := os.Getenv("EDITOR"); e != "" {
+		return e
+	}
+	return "vi"
+}
+
+func (a App) openExternalEditor() (tea.Model, tea.Cmd) {
+	editorCmd := a.externalEditor()
 
 	// Write body to temp file
 	tmpFile, err := os.CreateTemp("", "gottp-body-*.txt")
@@ -186,3 +228,75 @@ func (a App) openExternalEditor() (tea.Model, tea.Cmd) {
 		return msgs.EditorDoneMsg{Content: string(data)}
 	})
 }
+
+// editRequestSource saves the collection and opens its file in $EDITOR at
+// the selected request. The collection is reloaded when the editor exits.
+func (a App) editRequestSource() (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil || a.store.CollectionPath == "" {
+		cmd := a.toast.Show("No collection file to edit", true, 2*time.Second)
+		return a, cmd
+	}
+
+	req := a.store.ActiveRequest()
+	if a.focus == msgs.FocusSidebar {
+		if sel := a.sidebar.SelectedRequest(); sel != nil {
+			req = sel
+		}
+	}
+
+	// Save pending edits first so the file matches what is on screen
+	a.syncActiveRequest()
+	if _, err := a.writeCollection(); err != nil {
+		cmd := a.toast.Show("Save failed: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+
+	line := 0
+	if req != nil {
+		line = req.Line
+		if saved, err := collection.LoadFromFile(a.store.CollectionPath); err == nil {
+			if r := findRequest(saved.Items, req.ID); r != nil {
+				line = r.Line
+			}
+		}
+	}
+
+	c := editorAtLine(a.externalEditor(), a.store.CollectionPath, line)
+	return a, tea.ExecProcess(c, func(err error) tea.Msg {
+		return msgs.CollectionEditedMsg{Err: err}
+	})
+}
+
+// editorAtLine builds a command opening path at line in editor. Most
+// editors take "+N file"; VS Code style editors take "-g file:N" and a few
+// others "file:N". A line of 0 opens the file at the top.
+func editorAtLine(editor, path string, line int) *exec.Cmd {
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		fields = []string{"vi"}
+	}
+	args := fields[1:]
+	if line <= 0 {
+		return exec.Command(fields[0], append(args, path)...)
+	}
+	pos := path + ":" + strconv.Itoa(line)
+	switch filepath.Base(fields[0]) {
+	case "code", "code-insiders", "codium", "cursor":
+		args = append(args, "-g", pos)
+	case "subl", "hx", "helix", "zed":
+		args = append(args, pos)
+	default:
+		args = append(args, "+"+strconv.Itoa(line), path)
+	}
+	return exec.Command(fields[0], args...)
+}
+
+// reloadEditedCollection reloads the collection after it was edited in
+// $EDITOR.
+func (a App) reloadEditedCollection(msg msgs.CollectionEditedMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		cmd := a.toast.Show("Editor failed: "+msg.Err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	return a.reloadCollection()
+}
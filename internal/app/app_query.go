@@ -0,0 +1,124 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sadopc/gottp/internal/core/assertion"
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+)
+
+func (a App) openPlayground(msg msgs.OpenPlaygroundMsg) (tea.Model, tea.Cmd) {
+	body := a.response.ResponseBody()
+	if len(body) == 0 {
+		cmd := a.toast.Show("No response body to query", true, 2*time.Second)
+		return a, cmd
+	}
+	a.playground.Open(body, msg.Expr)
+	a.mode = msgs.ModeModal
+	return a, nil
+}
+
+// insertQuery adds an expression from the playground to the active request
+// and saves the collection.
+func (a App) insertQuery(msg msgs.InsertQueryMsg) (tea.Model, tea.Cmd) {
+	a.syncActiveRequest()
+	req := a.store.ActiveRequest()
+	if req == nil {
+		cmd := a.toast.Show("No active request to add to", true, 2*time.Second)
+		return a, cmd
+	}
+	value := plainJSON(msg.Value)
+
+	var text string
+	switch msg.Target {
+	case msgs.QueryAssertion:
+		check := assertion.Assertion{JSONPath: msg.Expr}
+		switch value.(type) {
+		case string, float64, bool:
+			check.Equals = value
+		default:
+			exists := msg.Count > 0
+			check.Exists = &exists
+		}
+		req.Assertions = append(req.Assertions, check)
+		text = "Added assertion: " + check.Describe()
+	case msgs.QueryCapture:
+		n := addCapture(a.store.Collection, req.Name, msg.Name, msg.Expr)
+		if n == 0 {
+			snippet := fmt.Sprintf("%s: %q", msg.Name, msg.Expr)
+			note := fmt.Sprintf("%q is in no workflow, so the capture was copied instead", req.Name)
+			cmd := a.toast.Show(note, true, 3*time.Second)
+			return a, tea.Batch(cmd, func() tea.Msg {
+				return msgs.CopyTextMsg{Text: snippet, Label: "capture expression"}
+			})
+		}
+		text = fmt.Sprintf("Added capture %s to %d workflow steps", msg.Name, n)
+	case msgs.QueryScript:
+		call := fmt.Sprintf("gottp.query(%q)", msg.Expr)
+		switch value.(type) {
+		case string, float64, bool, nil:
+		default:
+			call = "JSON.stringify(" + call + ")"
+		}
+		line := fmt.Sprintf("gottp.setEnvVar(%q, %s);", msg.Name, call)
+		if req.PostScript != "" && !strings.HasSuffix(req.PostScript, "\n") {
+			req.PostScript += "\n"
+		}
+		req.PostScript += line + "\n"
+		text = "Added to post-script: " + line
+	}
+
+	if a.store.CollectionPath == "" {
+		cmd := a.toast.Show(text+" (no collection file to save)", false, 3*time.Second)
+		return a, cmd
+	}
+	if _, err := a.writeCollection(); err != nil {
+		cmd := a.toast.Show("Save failed: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	cmd := a.toast.Show(text, false, 3*time.Second)
+	return a, cmd
+}
+
+// addCapture adds an extract to every workflow step that runs the request
+// named reqName, and returns how many steps it added it to.
+func addCapture(col *collection.Collection, reqName, name, expr string) int {
+	if col == nil {
+		return 0
+	}
+	n := 0
+	for i := range col.Workflows {
+		steps := col.Workflows[i].Steps
+		for j := range steps {
+			if steps[j].Request != reqName {
+				continue
+			}
+			if steps[j].Extracts == nil {
+				steps[j].Extracts = make(map[string]string)
+			}
+			steps[j].Extracts[name] = expr
+			n++
+		}
+	}
+	return n
+}
+
+// plainJSON converts a query result holding json.Numbers into plain Go
+// values, so it is written to YAML as a number.
+func plainJSON(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if json.Unmarshal(data, &out) != nil {
+		return v
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	harexport "github.com/sadopc/gottp/internal/export/har"
+	"github.com/sadopc/gottp/internal/protocol"
+	httpclient "github.com/sadopc/gottp/internal/protocol/http"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+)
+
+// StartRecording records every request sent from now on, with its response
+// and timings, to the HAR file at path. Known secrets are masked.
+func (a *App) StartRecording(path string) error {
+	rec, err := harexport.NewRecorder(path)
+	if err != nil {
+		return err
+	}
+	rec.Mask = a.secrets.Mask
+	a.recorder = rec
+	return nil
+}
+
+// toggleRecording stops the session recording, or starts one to a new
+// timestamped file in the download directory.
+func (a App) toggleRecording() (tea.Model, tea.Cmd) {
+	if a.recorder != nil {
+		rec := a.recorder
+		a.recorder = nil
+		cmd := a.toast.Show(fmt.Sprintf("Recorded %d requests to %s", rec.Len(), rec.Path()), false, 4*time.Second)
+		return a, cmd
+	}
+	dir := a.cfg.DownloadDir
+	if dir == "" {
+		dir = httpclient.DefaultDownloadDir()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		cmd := a.toast.Show("Recording failed: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	path := filepath.Join(dir, "session-"+time.Now().Format("20060102-150405")+".har")
+	if err := a.StartRecording(path); err != nil {
+		cmd := a.toast.Show("Recording failed: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	cmd := a.toast.Show("Recording session to "+path, false, 3*time.Second)
+	return a, cmd
+}
+
+// recordExchange adds a completed send to the session recording, if one is
+// running. The file is written by the returned command, off the UI
+// goroutine.
+func (a *App) recordExchange(msg msgs.RequestSentMsg, resp *protocol.Response) tea.Cmd {
+	if a.recorder == nil || msg.Request == nil {
+		return nil
+	}
+	rec := a.recorder
+	return func() tea.Msg {
+		if err := rec.Record(msg.Request, resp, msg.Started, msg.Err); err != nil {
+			return msgs.RecordingFailedMsg{Path: rec.Path(), Err: err}
+		}
+		return nil
+	}
+}
+
+// recordingFailed stops a recording that can't be written, with a toast.
+// A failure from an earlier recording, already stopped, is ignored.
+func (a App) recordingFailed(msg msgs.RecordingFailedMsg) (tea.Model, tea.Cmd) {
+	if a.recorder == nil || a.recorder.Path() != msg.Path {
+		return a, nil
+	}
+	a.recorder = nil
+	cmd := a.toast.Show("Recording stopped: "+msg.Err.Error(), true, 5*time.Second)
+	return a, cmd
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+)
+
+// collectionRecheckInterval is how long to wait before checking the
+// collection again when the check can't happen now, and how often to poll
+// its files where they can't be watched.
+const collectionRecheckInterval = time.Second
+
+// collectionSettle is how long to wait after a change for the rest of a
+// save, which editors and git often make in several writes.
+const collectionSettle = 100 * time.Millisecond
+
+// fileStamp is the modification time and size of a file, which change
+// whenever it is rewritten.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+// statFile returns path's stamp, or the zero stamp if it cannot be read.
+// For a split collection it is the latest modification time and total size
+// of its files, so editing any of them counts as a change.
+func statFile(path string) fileStamp {
+	if path == "" {
+		return fileStamp{}
+	}
+	var stamp fileStamp
+	for _, f := range collection.Files(path) {
+		info, err := os.Stat(f)
+		if err != nil {
+			return fileStamp{}
+		}
+		if info.ModTime().After(stamp.mod) {
+			stamp.mod = info.ModTime()
+		}
+		stamp.size += info.Size()
+	}
+	return stamp
+}
+
+// watchedDirs returns the directories to watch for changes to the
+// collection at path: the file's directory, as editors often save by
+// replacing the file, or every directory of a split collection, so files
+// added to it are noticed too.
+func watchedDirs(path string) []string {
+	if !collection.IsSplit(path) {
+		return []string{filepath.Dir(path)}
+	}
+	var dirs []string
+	_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, p)
+		}
+		return nil
+	})
+	return dirs
+}
+
+// collectionEvent reports whether a change to name may change the
+// collection at path.
+func collectionEvent(path, name string) bool {
+	path, name = filepath.Clean(path), filepath.Clean(name)
+	return name == path || strings.HasPrefix(name, path+string(filepath.Separator))
+}
+
+// watchCollection waits for the collection's files to change on disk, then
+// asks for a check. It returns when a.stopWatching is closed. Where files
+// can't be watched, they are polled instead.
+func (a App) watchCollection() tea.Cmd {
+	path := a.store.CollectionPath
+	if path == "" {
+		return nil
+	}
+	seen, stop := a.collectionStamp, a.stopWatching
+	return func() tea.Msg {
+		w, err := fsnotify.NewWatcher()
+		if err != nil {
+			return pollCollection(stop)
+		}
+		defer w.Close()
+		for _, dir := range watchedDirs(path) {
+			if err := w.Add(dir); err != nil {
+				return pollCollection(stop)
+			}
+		}
+		// A change made before the watch started would otherwise be missed
+		if statFile(path) != seen {
+			return msgs.CollectionFileChangedMsg{}
+		}
+
+		changed := false
+		var settle <-chan time.Time
+		for {
+			select {
+			case <-stop:
+				return nil
+			case ev, ok := <-w.Events:
+				if !ok {
+					return nil
+				}
+				if !changed && collectionEvent(path, ev.Name) {
+					changed = true
+					settle = time.After(collectionSettle)
+				}
+			case <-w.Errors:
+				// Events may have been dropped, so check anyway
+				return msgs.CollectionFileChangedMsg{}
+			case <-settle:
+				return msgs.CollectionFileChangedMsg{}
+			}
+		}
+	}
+}
+
+// pollCollection asks for a check after collectionRecheckInterval, unless
+// stop closes first.
+func pollCollection(stop <-chan struct{}) tea.Msg {
+	select {
+	case <-stop:
+		return nil
+	case <-time.After(collectionRecheckInterval):
+		return msgs.CollectionFileChangedMsg{}
+	}
+}
+
+// checkCollectionFile reloads the collection if its file changed on disk.
+// Changes matching the collection in memory, such as gottp's own saves,
+// are ignored. Without unsaved edits the reload happens straight away;
+// otherwise the user is asked first.
+func (a App) checkCollectionFile() (tea.Model, tea.Cmd) {
+	// Wait until any dialog is answered, so prompts don't stack
+	if a.mode == msgs.ModeModal || a.store.Collection == nil {
+		stop := a.stopWatching
+		return a, func() tea.Msg { return pollCollection(stop) }
+	}
+	stamp := statFile(a.store.CollectionPath)
+	if stamp == a.collectionStamp || stamp == (fileStamp{}) {
+		return a, a.watchCollection()
+	}
+	a.collectionStamp = stamp
+	next := a.watchCollection()
+
+	col, err := collection.LoadFromFile(a.store.CollectionPath)
+	if err != nil {
+		// Likely saved mid-edit; the next change will be picked up
+		a.statusBar.SetMessage("Collection changed on disk but does not load: " + err.Error())
+		return a, next
+	}
+	if hash, _ := collection.Fingerprint(col); hash == a.store.CollectionHash {
+		return a, next
+	}
+
+	if a.unsavedChanges() {
+		a.modal.Show("Collection changed on disk",
+			filepath.Base(a.store.CollectionPath)+" was changed outside gottp. Reload it and discard your unsaved changes?",
+			msgs.ReloadCollectionMsg{})
+		a.mode = msgs.ModeModal
+		return a, next
+	}
+
+	a.applyCollection(col)
+	cmd := a.toast.Show("Reloaded "+filepath.Base(a.store.CollectionPath)+" (changed on disk)", false, 2*time.Second)
+	return a, tea.Batch(cmd, next)
+}
+
+// reloadCollection replaces the collection with its file's contents.
+func (a App) reloadCollection() (tea.Model, tea.Cmd) {
+	col, err := collection.LoadFromFile(a.store.CollectionPath)
+	if err != nil {
+		cmd := a.toast.Show("Reload failed, keeping previous collection: "+err.Error(), true, 4*time.Second)
+		return a, cmd
+	}
+	a.applyCollection(col)
+	cmd := a.toast.Show("Reloaded "+filepath.Base(a.store.CollectionPath), false, 2*time.Second)
+	return a, cmd
+}
+
+// applyCollection switches to a freshly loaded collection, re-pointing
+// open tabs at its requests by ID and dropping their unsaved changes. Tabs
+// of requests that no longer exist are closed; new tabs stay open.
+func (a *App) applyCollection(col *collection.Collection) {
+	old := a.store.Collection
+	var gone []*collection.Request
+	for i, tab := range a.store.Tabs {
+		if req := findRequest(col.Items, tab.Request.ID); req != nil {
+			a.store.Tabs[i].Request = req
+		} else if old != nil && findRequest(old.Items, tab.Request.ID) == tab.Request {
+			gone = append(gone, tab.Request)
+		}
+	}
+	a.store.CloseRequestTabs(gone)
+	for i := range a.store.Tabs {
+		a.store.Tabs[i].Modified = false
+	}
+
+	a.store.Collection = col
+	a.store.CollectionHash, _ = collection.Fingerprint(col)
+	a.collectionStamp = statFile(a.store.CollectionPath)
+	a.loadActiveRequest()
+	a.syncTabs()
+	a.reportScriptErrors(a.refreshSidebar())
+}
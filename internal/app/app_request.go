@@ -3,33 +3,193 @@ package app
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dustin/go-humanize"
 
 	oauth2auth "github.com/sadopc/gottp/internal/auth/oauth2"
+	"github.com/sadopc/gottp/internal/core/assertion"
+	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/core/environment"
 	"github.com/sadopc/gottp/internal/core/history"
+	"github.com/sadopc/gottp/internal/core/retry"
+	gotls "github.com/sadopc/gottp/internal/core/tls"
 	"github.com/sadopc/gottp/internal/protocol"
 	"github.com/sadopc/gottp/internal/protocol/graphql"
+	grpcclient "github.com/sadopc/gottp/internal/protocol/grpc"
 	"github.com/sadopc/gottp/internal/scripting"
+	"github.com/sadopc/gottp/internal/ui/components"
 	"github.com/sadopc/gottp/internal/ui/msgs"
 	"github.com/sadopc/gottp/internal/ui/panels/response"
 )
 
+// activeProxy returns the proxy for the active request with variables
+// resolved: the request's own proxy_url, else the active environment's
+// proxy, else the global proxy from the config. Nil means no proxy.
+func (a App) activeProxy(envVars, colVars map[string]string) *protocol.ProxyConfig {
+	var p *protocol.ProxyConfig
+	if req := a.store.ActiveRequest(); req != nil && req.ProxyURL != "" {
+		p = &protocol.ProxyConfig{URL: req.ProxyURL}
+	} else if ep := a.envProxy(); ep != nil {
+		p = &protocol.ProxyConfig{URL: ep.URL, NoProxy: ep.NoProxy, Username: ep.Username, Password: ep.Password}
+	} else if a.cfg.ProxyURL != "" {
+		p = &protocol.ProxyConfig{URL: a.cfg.ProxyURL, NoProxy: a.cfg.NoProxy}
+	} else {
+		return nil
+	}
+	p.URL = environment.Resolve(p.URL, envVars, colVars)
+	p.NoProxy = environment.Resolve(p.NoProxy, envVars, colVars)
+	p.Username = environment.Resolve(p.Username, envVars, colVars)
+	p.Password = environment.Resolve(p.Password, envVars, colVars)
+	return p
+}
+
+// activeTLS layers the request's TLS settings over the active environment's
+// and the global config. Nil keeps the HTTP client's global TLS config.
+func (a App) activeTLS(reqTLS *gotls.Config) *gotls.Config {
+	var envTLS *gotls.Config
+	if a.envFile != nil {
+		envTLS = a.envFile.GetTLS(a.store.EffectiveEnv())
+	}
+	if reqTLS == nil && envTLS == nil {
+		return nil
+	}
+	return a.cfg.TLS.Merge(envTLS).Merge(reqTLS)
+}
+
+// activeHosts returns the active environment's host overrides with
+// variables resolved, or nil if it has none.
+func (a App) activeHosts(envVars, colVars map[string]string) protocol.Hosts {
+	if a.envFile == nil {
+		return nil
+	}
+	overrides := a.envFile.GetHosts(a.store.EffectiveEnv())
+	if len(overrides) == 0 {
+		return nil
+	}
+	hosts := make(protocol.Hosts, len(overrides))
+	for k, v := range overrides {
+		hosts[k] = environment.Resolve(v, envVars, colVars)
+	}
+	return hosts
+}
+
+// activeRetry layers the active request's retry policy over the
+// collection's and the global config.
+func (a App) activeRetry() *retry.Policy {
+	policy := a.cfg.Retry.Merge(nil)
+	if a.store.Collection != nil {
+		policy = policy.Merge(a.store.Collection.Retry)
+	}
+	if req := a.store.ActiveRequest(); req != nil {
+		policy = policy.Merge(req.Retry)
+	}
+	return policy
+}
+
+func (a App) envProxy() *environment.Proxy {
+	if a.envFile == nil {
+		return nil
+	}
+	if p := a.envFile.GetProxy(a.store.EffectiveEnv()); p != nil && p.URL != "" {
+		return p
+	}
+	return nil
+}
+
+// resolveSecrets resolves the secret references and encrypted values in
+// envVars and colVars that req uses, counting the proxy and host overrides
+// it will be sent with and the variables scripts name.
+func (a App) resolveSecrets(req *protocol.Request, envVars, colVars map[string]string, scripts ...string) (map[string]string, map[string]string, error) {
+	if a.secrets == nil {
+		return envVars, colVars, nil
+	}
+	texts := req.Templates()
+	if r := a.store.ActiveRequest(); r != nil {
+		texts = append(texts, r.ProxyURL)
+	}
+	if p := a.envProxy(); p != nil {
+		texts = append(texts, p.URL, p.NoProxy, p.Username, p.Password)
+	}
+	texts = append(texts, a.cfg.ProxyURL, a.cfg.NoProxy)
+	if a.envFile != nil {
+		for _, v := range a.envFile.GetHosts(a.store.EffectiveEnv()) {
+			texts = append(texts, v)
+		}
+	}
+
+	envVars, err := a.secrets.ResolveUsed(envVars, texts, scripts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	colVars, err = a.secrets.ResolveUsed(colVars, texts, scripts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return envVars, colVars, nil
+}
+
+// requestVars returns the environment variables in effect for the active
+// tab, overridden by the active request's folder and request variables.
+func (a App) requestVars() map[string]string {
+	return environment.Overlay(a.store.EffectiveVars(), collection.ScopedVars(a.store.Collection, a.store.ActiveRequest()))
+}
+
+func (a App) collectionVars() map[string]string {
+	if a.store.Collection == nil {
+		return nil
+	}
+	return a.store.Collection.Variables
+}
+
 func (a App) sendRequest() (tea.Model, tea.Cmd) {
+	return a.sendRequestWith(false, false)
+}
+
+// sendRequestWith sends the active request. With download set the response
+// body is streamed to a file instead of memory. Unless confirmed, a request
+// using variables that are empty in the active environment asks first.
+func (a App) sendRequestWith(download, confirmed bool) (tea.Model, tea.Cmd) {
 	req := a.editor.BuildRequest()
 	if req.URL == "" {
 		a.statusBar.SetMessage("URL is required")
 		return a, nil
 	}
+	if _, err := a.protocols.Lookup(req.Protocol); err != nil {
+		text := err.Error()
+		var unsupported *protocol.UnsupportedError
+		if errors.As(err, &unsupported) {
+			text += "; " + unsupported.Hint()
+		}
+		cmd := a.toast.Show(text, true, 5*time.Second)
+		return a, cmd
+	}
+	if names := a.emptyVars(); len(names) > 0 && !confirmed {
+		refs := make([]string, len(names))
+		for i, name := range names {
+			refs[i] = "{{" + name + "}}"
+		}
+		a.modal.Show("Empty variables",
+			fmt.Sprintf("%s empty in %q. Send anyway?", strings.Join(refs, ", "), a.store.EffectiveEnv()),
+			msgs.SendRequestMsg{Download: download, Confirmed: true})
+		a.mode = msgs.ModeModal
+		return a, nil
+	}
 
 	// Set response mode based on protocol
 	a.response.SetMode(a.editor.Protocol())
 
 	// Resolve environment variables
-	envVars := a.store.EnvVars
+	envVars := a.requestVars()
 	var colVars map[string]string
 	if a.store.Collection != nil {
 		colVars = a.store.Collection.Variables
@@ -40,24 +200,51 @@ func (a App) sendRequest() (tea.Model, tea.Cmd) {
 	if colVars == nil {
 		colVars = map[string]string{}
 	}
-	if len(envVars) > 0 || len(colVars) > 0 {
-		req.URL = environment.Resolve(req.URL, envVars, colVars)
-		for k, v := range req.Headers {
-			req.Headers[k] = environment.Resolve(v, envVars, colVars)
-		}
-		for k, v := range req.Params {
-			req.Params[k] = environment.Resolve(v, envVars, colVars)
-		}
-		if len(req.Body) > 0 {
-			req.Body = []byte(environment.Resolve(string(req.Body), envVars, colVars))
-		}
-		if req.Auth != nil {
-			req.Auth.Username = environment.Resolve(req.Auth.Username, envVars, colVars)
-			req.Auth.Password = environment.Resolve(req.Auth.Password, envVars, colVars)
-			req.Auth.Token = environment.Resolve(req.Auth.Token, envVars, colVars)
-			req.Auth.APIKey = environment.Resolve(req.Auth.APIKey, envVars, colVars)
-			req.Auth.APIValue = environment.Resolve(req.Auth.APIValue, envVars, colVars)
-		}
+
+	// Resolve the secret:// references and encrypted values the request uses
+	req.TLS = a.activeTLS(req.TLS)
+	envVars, colVars, err := a.resolveSecrets(req, envVars, colVars, req.PreScript, req.PostScript)
+	if err != nil {
+		cmd := a.toast.Show("Secret error: "+err.Error(), true, 5*time.Second)
+		return a, cmd
+	}
+
+	req.Proxy = a.activeProxy(envVars, colVars)
+	req.Hosts = a.activeHosts(envVars, colVars)
+
+	req.URL = environment.Resolve(req.URL, envVars, colVars)
+	for k, v := range req.Headers {
+		req.Headers[k] = environment.Resolve(v, envVars, colVars)
+	}
+	for k, v := range req.Params {
+		req.Params[k] = environment.Resolve(v, envVars, colVars)
+	}
+	for i := range req.Cookies {
+		req.Cookies[i].Value = environment.Resolve(req.Cookies[i].Value, envVars, colVars)
+	}
+	req.ApplyCookies()
+	if len(req.Body) > 0 {
+		req.Body = []byte(environment.Resolve(string(req.Body), envVars, colVars))
+	}
+	req.BodyFile = environment.Resolve(req.BodyFile, envVars, colVars)
+	for i := range req.Form {
+		req.Form[i].Value = environment.Resolve(req.Form[i].Value, envVars, colVars)
+		req.Form[i].File = environment.Resolve(req.Form[i].File, envVars, colVars)
+	}
+	for i := range req.WSMessages {
+		req.WSMessages[i].Content = environment.Resolve(req.WSMessages[i].Content, envVars, colVars)
+	}
+	if req.Auth != nil {
+		req.Auth.Username = environment.Resolve(req.Auth.Username, envVars, colVars)
+		req.Auth.Password = environment.Resolve(req.Auth.Password, envVars, colVars)
+		req.Auth.Token = environment.Resolve(req.Auth.Token, envVars, colVars)
+		req.Auth.APIKey = environment.Resolve(req.Auth.APIKey, envVars, colVars)
+		req.Auth.APIValue = environment.Resolve(req.Auth.APIValue, envVars, colVars)
+	}
+	if req.TLS != nil {
+		req.TLS.CertFile = environment.Resolve(req.TLS.CertFile, envVars, colVars)
+		req.TLS.KeyFile = environment.Resolve(req.TLS.KeyFile, envVars, colVars)
+		req.TLS.CAFile = environment.Resolve(req.TLS.CAFile, envVars, colVars)
 	}
 
 	// Run pre-request script
@@ -83,35 +270,100 @@ func (a App) sendRequest() (tea.Model, tea.Cmd) {
 		req.Body = []byte(scriptReq.Body)
 		// Apply env changes
 		for k, v := range result.EnvChanges {
-			a.store.EnvVars[k] = v
+			a.store.EffectiveVars()[k] = v
 		}
 	}
 
+	// Body files are relative to the collection file
+	if a.store.CollectionPath != "" {
+		req.ResolvePaths(filepath.Dir(a.store.CollectionPath))
+	}
+
+	// Pre-flight checks run on the final request; they never block sending
+	a.response.SetWarnings(protocol.Preflight(req))
+
 	// Handle OAuth2: check for valid token or initiate flow
+	tokenKey := ""
 	if req.Auth != nil && req.Auth.Type == "oauth2" && req.Auth.OAuth2 != nil {
 		oauth := req.Auth.OAuth2
-		if oauth.AccessToken == "" || (oauth.TokenExpiry != (time.Time{}) && time.Now().After(oauth.TokenExpiry)) {
-			return a.initiateOAuth2(req)
+		tokenKey = oauth2auth.TokenKey(oauth.TokenURL, oauth.ClientID, oauth.Scope)
+		if token, ok := a.oauthTokens.Get(tokenKey); ok {
+			oauth2auth.ApplyToken(oauth, token)
+			a.secrets.Remember(token.AccessToken, token.RefreshToken)
+		}
+		if oauth2auth.NeedsToken(oauth) {
+			return a.initiateOAuth2(req, tokenKey)
 		}
 	}
+	requestID := ""
+	if colReq := a.store.ActiveRequest(); colReq != nil {
+		requestID = colReq.ID
+	}
 
 	a.response.SetLoading(true)
+	a.wsScript, a.wsPingInterval = req.WSMessages, req.WSPingInterval
 
 	timeout := a.cfg.DefaultTimeout
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
 
+	// Report body progress at most every 100ms
+	progress := make(chan msgs.DownloadProgressMsg, 1)
+	a.progress = progress
+	req.Download = download
+	var lastProgress time.Time
+	req.OnProgress = func(received, total int64) {
+		if now := time.Now(); now.Sub(lastProgress) >= 100*time.Millisecond {
+			lastProgress = now
+			select {
+			case progress <- msgs.DownloadProgressMsg{Received: received, Total: total}:
+			default:
+			}
+		}
+	}
+
+	policy := a.activeRetry()
+	if err := policy.Validate(); err != nil {
+		a.response.SetLoading(false)
+		cmd := a.toast.Show("Retry policy: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+
 	registry := a.protocols
 	postScript := req.PostScript
 	scriptEngine := a.scriptEngine
+	var assertions []assertion.Assertion
+	if colReq := a.store.ActiveRequest(); colReq != nil {
+		assertions = colReq.Assertions
+	}
+	baseDir := ""
+	if a.store.CollectionPath != "" {
+		baseDir = filepath.Dir(a.store.CollectionPath)
+	}
 	cmd := func() tea.Msg {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+		defer close(progress)
+		started := time.Now()
 
-		resp, err := registry.Execute(ctx, req)
+		// Each attempt gets the full timeout
+		var resp *protocol.Response
+		var refreshed *oauth2auth.TokenResponse
+		var err error
+		stats := policy.Do(context.Background(), func(ctx context.Context) (int, string, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			var r *oauth2auth.TokenResponse
+			resp, r, err = oauth2auth.ExecuteWithRefresh(ctx, registry.Execute, req)
+			if r != nil {
+				refreshed = r
+			}
+			if err != nil {
+				return 0, "", err
+			}
+			return resp.StatusCode, resp.Headers.Get("Retry-After"), nil
+		})
 		if err != nil {
-			return msgs.RequestSentMsg{Err: err}
+			return msgs.RequestSentMsg{Err: err, Attempts: stats.Attempts, Request: req, Started: started}
 		}
 
 		sentMsg := msgs.RequestSentMsg{
@@ -122,6 +374,25 @@ func (a App) sendRequest() (tea.Model, tea.Cmd) {
 			ContentType: resp.ContentType,
 			Duration:    resp.Duration,
 			Size:        resp.Size,
+			Proto:       resp.Proto,
+			TLS:         resp.TLS,
+			Timing:      resp.Timing,
+			Trailers:    resp.Trailers,
+			Interim:     resp.Interim,
+			RawRequest:  resp.RawRequest,
+			BodyFile:    resp.BodyFile,
+			Attempts:    stats.Attempts,
+			Request:     req,
+			Started:     started,
+		}
+		if refreshed != nil {
+			sentMsg.TokenRefresh = &msgs.OAuth2TokenMsg{
+				AccessToken:  refreshed.AccessToken,
+				RefreshToken: refreshed.RefreshToken,
+				ExpiresIn:    refreshed.ExpiresIn,
+				Key:          tokenKey,
+				RequestID:    requestID,
+			}
 		}
 
 		// Run post-request script if present
@@ -158,95 +429,220 @@ func (a App) sendRequest() (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Declarative assertions are listed with the script tests
+		if len(assertions) > 0 {
+			if sentMsg.ScriptResult == nil {
+				sentMsg.ScriptResult = &msgs.ScriptResultMsg{}
+			}
+			checked := assertion.Evaluate(assertions, assertion.Response{
+				StatusCode: resp.StatusCode,
+				Headers:    resp.Headers,
+				Body:       resp.Body,
+				Duration:   resp.Duration,
+			}, baseDir)
+			for _, c := range checked {
+				sentMsg.ScriptResult.TestResults = append(sentMsg.ScriptResult.TestResults,
+					msgs.ScriptTestResult{Name: c.Name, Passed: c.Passed, Error: c.Error})
+			}
+		}
+
 		return sentMsg
 	}
 
-	return a, tea.Batch(cmd, a.response.Init())
+	a.sendSeq++
+	a.statusBar.StartLoading(time.Now())
+	return a, tea.Batch(cmd, a.response.Init(), listenProgress(progress), requestTick(a.sendSeq))
 }
 
-func (a App) initiateOAuth2(req *protocol.Request) (tea.Model, tea.Cmd) {
-	oauth := req.Auth.OAuth2
-	a.response.SetLoading(true)
+// requestTickInterval is how often the status bar's elapsed time and
+// transfer rate are refreshed while a request is in flight.
+const requestTickInterval = 200 * time.Millisecond
+
+// requestTick schedules the next progress refresh for send seq.
+func requestTick(seq int) tea.Cmd {
+	return tea.Tick(requestTickInterval, func(t time.Time) tea.Msg {
+		return msgs.RequestTickMsg{Seq: seq, Time: t}
+	})
+}
 
-	switch oauth.GrantType {
-	case "client_credentials":
-		cfg := oauth2auth.OAuth2Config{
-			TokenURL:     oauth.TokenURL,
-			ClientID:     oauth.ClientID,
-			ClientSecret: oauth.ClientSecret,
-			Scope:        oauth.Scope,
+// listenProgress waits for the next download progress update.
+func listenProgress(progress <-chan msgs.DownloadProgressMsg) tea.Cmd {
+	if progress == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		p, ok := <-progress
+		if !ok {
+			return nil
 		}
-		cmd := func() tea.Msg {
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-			token, err := oauth2auth.ClientCredentials(ctx, cfg)
-			if err != nil {
-				return msgs.OAuth2TokenMsg{Err: err}
-			}
-			return msgs.OAuth2TokenMsg{
-				AccessToken:  token.AccessToken,
-				RefreshToken: token.RefreshToken,
-				ExpiresIn:    token.ExpiresIn,
-			}
+		return p
+	}
+}
+
+// initiateOAuth2 obtains a token for req, to be cached under key. The
+// active request is sent again once it arrives.
+func (a App) initiateOAuth2(req *protocol.Request, key string) (tea.Model, tea.Cmd) {
+	oauth := *req.Auth.OAuth2
+	requestID := ""
+	if colReq := a.store.ActiveRequest(); colReq != nil {
+		requestID = colReq.ID
+	}
+	a.response.SetLoading(true)
+
+	// Refresh silently when a refresh token is available; otherwise run the
+	// grant, which opens the browser for authorization_code.
+	timeout := 30 * time.Second
+	var toastCmd tea.Cmd
+	if oauth.GrantType == "authorization_code" {
+		timeout = oauth2auth.AuthorizeTimeout
+		if oauth.RefreshToken == "" {
+			toastCmd = a.toast.Show("Authorize gottp in your browser to continue", false, 5*time.Second)
 		}
-		return a, cmd
+	}
 
-	case "password":
-		cfg := oauth2auth.OAuth2Config{
-			TokenURL:     oauth.TokenURL,
-			ClientID:     oauth.ClientID,
-			ClientSecret: oauth.ClientSecret,
-			Username:     oauth.Username,
-			Password:     oauth.Password,
-			Scope:        oauth.Scope,
-		}
-		cmd := func() tea.Msg {
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-			token, err := oauth2auth.PasswordGrant(ctx, cfg)
-			if err != nil {
-				return msgs.OAuth2TokenMsg{Err: err}
-			}
-			return msgs.OAuth2TokenMsg{
-				AccessToken:  token.AccessToken,
-				RefreshToken: token.RefreshToken,
-				ExpiresIn:    token.ExpiresIn,
-			}
+	cmd := func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		token, err := oauth2auth.Obtain(ctx, &oauth, oauth2auth.OpenBrowser)
+		if err != nil {
+			return msgs.OAuth2TokenMsg{Err: err}
+		}
+		return msgs.OAuth2TokenMsg{
+			AccessToken:  token.AccessToken,
+			RefreshToken: token.RefreshToken,
+			ExpiresIn:    token.ExpiresIn,
+			Key:          key,
+			RequestID:    requestID,
 		}
+	}
+	return a, tea.Batch(cmd, toastCmd)
+}
+
+func (a App) handleOAuth2Token(msg msgs.OAuth2TokenMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		a.response.SetLoading(false)
+		cmd := a.toast.Show("OAuth2 error: "+msg.Err.Error(), true, 5*time.Second)
 		return a, cmd
+	}
 
-	case "authorization_code":
+	// Keep the acquired token and retry the request it was obtained for
+	if !a.storeOAuth2Token(msg) || !a.focusRequest(msg.RequestID) {
 		a.response.SetLoading(false)
-		cmd := a.toast.Show("Auth code flow: use browser to authorize", false, 5*time.Second)
+		cmd := a.toast.Show("OAuth2 token acquired", false, 2*time.Second)
 		return a, cmd
 	}
+	m, sendCmd := a.sendRequest()
+	a = m.(App)
+	cmd := a.toast.Show("OAuth2 token acquired", false, 2*time.Second)
+	return a, tea.Batch(sendCmd, cmd)
+}
 
-	a.response.SetLoading(false)
-	cmd := a.toast.Show("Unknown OAuth2 grant type", true, 3*time.Second)
-	return a, cmd
+// storeOAuth2Token caches a token under the key it was obtained for. It
+// reports false if msg carries no token.
+func (a App) storeOAuth2Token(msg msgs.OAuth2TokenMsg) bool {
+	if msg.Key == "" || msg.AccessToken == "" {
+		return false
+	}
+	// A failed write only loses persistence; the token stays cached in memory
+	_ = a.oauthTokens.Put(msg.Key, &oauth2auth.TokenResponse{
+		AccessToken:  msg.AccessToken,
+		RefreshToken: msg.RefreshToken,
+		ExpiresIn:    msg.ExpiresIn,
+		ObtainedAt:   time.Now(),
+	})
+	return true
 }
 
-func (a App) handleOAuth2Token(msg msgs.OAuth2TokenMsg) (tea.Model, tea.Cmd) {
-	if msg.Err != nil {
-		a.response.SetLoading(false)
-		cmd := a.toast.Show("OAuth2 error: "+msg.Err.Error(), true, 5*time.Second)
+func (a App) handleManageOAuth2Tokens() (tea.Model, tea.Cmd) {
+	cached := a.oauthTokens.Entries()
+	if len(cached) == 0 {
+		cmd := a.toast.Show("No cached OAuth2 tokens", false, 2*time.Second)
+		return a, cmd
+	}
+	entries := make([]components.TokenEntry, len(cached))
+	for i, c := range cached {
+		label := c.TokenURL
+		if c.ClientID != "" {
+			label += " · " + c.ClientID
+		}
+		if c.Scope != "" {
+			label += " [" + c.Scope + "]"
+		}
+		entries[i] = components.TokenEntry{Key: c.Key, Label: label, Detail: tokenStatus(c.Token)}
+	}
+	a.commandPalette.OpenOAuth2TokenPicker(entries)
+	a.mode = msgs.ModeCommandPalette
+	return a, nil
+}
+
+func (a App) handleInvalidateOAuth2Token(msg msgs.InvalidateOAuth2TokenMsg) (tea.Model, tea.Cmd) {
+	var err error
+	text := "OAuth2 token invalidated"
+	if msg.All {
+		err = a.oauthTokens.Clear()
+		text = "All cached OAuth2 tokens invalidated"
+	} else {
+		err = a.oauthTokens.Delete(msg.Key)
+	}
+	if err != nil {
+		cmd := a.toast.Show("Token cache error: "+err.Error(), true, 3*time.Second)
 		return a, cmd
 	}
+	cmd := a.toast.Show(text, false, 2*time.Second)
+	return a, cmd
+}
 
-	// Update the auth form with the acquired token and retry
-	authCfg := a.editor.BuildAuth()
-	if authCfg != nil && authCfg.OAuth2 != nil {
-		authCfg.OAuth2.AccessToken = msg.AccessToken
-		authCfg.OAuth2.RefreshToken = msg.RefreshToken
-		if msg.ExpiresIn > 0 {
-			authCfg.OAuth2.TokenExpiry = time.Now().Add(time.Duration(msg.ExpiresIn) * time.Second)
+func (a App) handleManageResponseCache() (tea.Model, tea.Cmd) {
+	cached := a.respCache.Entries()
+	if len(cached) == 0 {
+		cmd := a.toast.Show("No cached responses", false, 2*time.Second)
+		return a, cmd
+	}
+	entries := make([]components.CacheEntry, len(cached))
+	for i, e := range cached {
+		var detail []string
+		if e.ETag != "" {
+			detail = append(detail, "ETag "+e.ETag)
 		}
+		if e.LastModified != "" {
+			detail = append(detail, "modified "+e.LastModified)
+		}
+		detail = append(detail, humanize.IBytes(uint64(len(e.Body))), fmt.Sprintf("%d hits", e.Hits))
+		entries[i] = components.CacheEntry{Key: e.Key, Label: e.Key, Detail: strings.Join(detail, " · ")}
 	}
+	a.commandPalette.OpenResponseCachePicker(entries)
+	a.mode = msgs.ModeCommandPalette
+	return a, nil
+}
 
-	cmd := a.toast.Show("OAuth2 token acquired", false, 2*time.Second)
+func (a App) handleForgetCachedResponse(msg msgs.ForgetCachedResponseMsg) (tea.Model, tea.Cmd) {
+	if msg.All {
+		n := a.respCache.Clear()
+		cmd := a.toast.Show(fmt.Sprintf("Cleared %d cached responses", n), false, 2*time.Second)
+		return a, cmd
+	}
+	a.respCache.Delete(msg.Key)
+	cmd := a.toast.Show("Forgot cached "+msg.Key, false, 2*time.Second)
 	return a, cmd
 }
 
+// tokenStatus summarizes a cached token's expiry for the token picker.
+func tokenStatus(token *oauth2auth.TokenResponse) string {
+	status := "no expiry"
+	if token.ExpiresIn > 0 {
+		left := time.Until(token.ObtainedAt.Add(time.Duration(token.ExpiresIn) * time.Second))
+		if left <= 0 {
+			status = "expired"
+		} else {
+			status = "expires in " + left.Round(time.Second).String()
+		}
+	}
+	if token.RefreshToken != "" {
+		status += ", refreshable"
+	}
+	return status
+}
+
 func convertTestResults(results []scripting.TestResult) []response.ScriptTestResult {
 	out := make([]response.ScriptTestResult, len(results))
 	for i, r := range results {
@@ -263,12 +659,49 @@ func convertScriptTestResults(results []scripting.TestResult) []msgs.ScriptTestR
 	return out
 }
 
+// maskResponse returns a copy of resp for display with known secrets masked
+// in its headers, trailers and raw request, where resolved credentials such
+// as an Authorization header show up.
+func (a App) maskResponse(resp *protocol.Response) *protocol.Response {
+	if a.secrets == nil {
+		return resp
+	}
+	maskHeader := func(h http.Header) http.Header {
+		if h == nil {
+			return nil
+		}
+		out := make(http.Header, len(h))
+		for k, vs := range h {
+			masked := make([]string, len(vs))
+			for i, v := range vs {
+				masked[i] = a.secrets.Mask(v)
+			}
+			out[k] = masked
+		}
+		return out
+	}
+	shown := *resp
+	shown.Headers = maskHeader(resp.Headers)
+	shown.Trailers = maskHeader(resp.Trailers)
+	shown.RawRequest = a.secrets.Mask(resp.RawRequest)
+	shown.Interim = nil
+	for _, ir := range resp.Interim {
+		shown.Interim = append(shown.Interim, protocol.InterimResponse{StatusCode: ir.StatusCode, Headers: maskHeader(ir.Headers)})
+	}
+	return &shown
+}
+
 func (a App) handleRequestSent(msg msgs.RequestSentMsg) (tea.Model, tea.Cmd) {
+	a.statusBar.StopLoading()
 	if msg.Err != nil {
+		errText := a.secrets.Mask(msg.Err.Error())
+		if msg.Attempts > 1 {
+			errText = fmt.Sprintf("%s (after %d attempts)", errText, msg.Attempts)
+		}
 		a.response.SetLoading(false)
-		a.statusBar.SetMessage("Error: " + msg.Err.Error())
-		cmd := a.toast.Show("Request failed: "+msg.Err.Error(), true, 5*time.Second)
-		return a, cmd
+		a.statusBar.SetMessage("Error: " + errText)
+		cmd := a.toast.Show("Request failed: "+errText, true, 5*time.Second)
+		return a, tea.Batch(cmd, a.recordExchange(msg, nil))
 	}
 
 	resp := &protocol.Response{
@@ -279,10 +712,29 @@ func (a App) handleRequestSent(msg msgs.RequestSentMsg) (tea.Model, tea.Cmd) {
 		ContentType: msg.ContentType,
 		Duration:    msg.Duration,
 		Size:        msg.Size,
+		Proto:       msg.Proto,
+		TLS:         msg.TLS,
+		Timing:      msg.Timing,
+		Trailers:    msg.Trailers,
+		Interim:     msg.Interim,
+		RawRequest:  msg.RawRequest,
+		BodyFile:    msg.BodyFile,
+	}
+
+	var toastCmd tea.Cmd
+	if msg.TokenRefresh != nil {
+		a.storeOAuth2Token(*msg.TokenRefresh)
+		toastCmd = a.toast.Show("Got 401: OAuth2 token refreshed and request retried", false, 3*time.Second)
+	} else if msg.BodyFile != "" {
+		toastCmd = a.toast.Show("Response saved to "+msg.BodyFile, false, 4*time.Second)
+	} else if msg.Attempts > 1 {
+		toastCmd = a.toast.Show(fmt.Sprintf("Got %s after %d attempts", msg.Status, msg.Attempts), msg.StatusCode >= 400, 3*time.Second)
 	}
 
-	a.response.SetResponse(resp)
+	// After storing a refreshed token, so it is masked too
+	a.response.SetResponse(a.maskResponse(resp))
 	a.statusBar.SetStatus(msg.StatusCode, msg.Duration, msg.Size, msg.ContentType)
+	toastCmd = tea.Batch(toastCmd, a.recordExchange(msg, resp))
 
 	// Process post-script results if present
 	if msg.ScriptResult != nil {
@@ -299,7 +751,7 @@ func (a App) handleRequestSent(msg msgs.RequestSentMsg) (tea.Model, tea.Cmd) {
 		a.response.SetScriptResults(msg.ScriptResult.Logs, testResults, errMsg)
 		// Apply env changes from post-script
 		for k, v := range msg.ScriptResult.EnvChanges {
-			a.store.EnvVars[k] = v
+			a.store.EffectiveVars()[k] = v
 		}
 	}
 
@@ -309,19 +761,23 @@ func (a App) handleRequestSent(msg msgs.RequestSentMsg) (tea.Model, tea.Cmd) {
 		headersJSON, _ := json.Marshal(req.Headers)
 		_, _ = a.history.Add(history.Entry{
 			Method:       req.Method,
-			URL:          req.URL,
+			URL:          a.secrets.Mask(req.URL),
 			StatusCode:   msg.StatusCode,
 			Duration:     msg.Duration,
 			Size:         msg.Size,
-			RequestBody:  string(req.Body),
-			ResponseBody: string(msg.Body),
-			Headers:      string(headersJSON),
+			RequestBody:  a.secrets.Mask(string(req.Body)),
+			ResponseBody: a.secrets.Mask(string(msg.Body)),
+			Headers:      a.secrets.Mask(string(headersJSON)),
 			Timestamp:    time.Now(),
 		})
 		a.loadHistory()
 	}
 
-	return a, nil
+	if msg.StatusCode == 101 && a.ws == nil && a.editor.Protocol() == "websocket" {
+		return a, tea.Batch(toastCmd, a.startWSSession())
+	}
+
+	return a, toastCmd
 }
 
 func (a App) handleIntrospect() (tea.Model, tea.Cmd) {
@@ -331,14 +787,27 @@ func (a App) handleIntrospect() (tea.Model, tea.Cmd) {
 		return a, cmd
 	}
 
-	url := req.URL
-	headers := req.Headers
+	// The schema is stored under the URL as written, so it follows the
+	// request across environments; the introspection query uses the
+	// resolved one.
+	endpoint := req.URL
+	envVars, colVars, err := a.resolveSecrets(req, a.requestVars(), a.collectionVars())
+	if err != nil {
+		cmd := a.toast.Show("Secret error: "+err.Error(), true, 5*time.Second)
+		return a, cmd
+	}
+	url := environment.Resolve(req.URL, envVars, colVars)
+	headers := make(map[string]string, len(req.Headers))
+	for k, v := range req.Headers {
+		headers[k] = environment.Resolve(v, envVars, colVars)
+	}
+	proxy := a.activeProxy(envVars, colVars)
 	cmd := func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
-		schema, err := graphql.RunIntrospection(ctx, url, headers)
+		schema, err := graphql.RunIntrospection(ctx, url, headers, proxy)
 		if err != nil {
-			return msgs.IntrospectionResultMsg{Err: err}
+			return msgs.IntrospectionResultMsg{Endpoint: endpoint, Err: err}
 		}
 		types := make([]msgs.SchemaType, len(schema.Types))
 		for i, t := range schema.Types {
@@ -348,7 +817,7 @@ func (a App) handleIntrospect() (tea.Model, tea.Cmd) {
 			}
 			types[i] = msgs.SchemaType{Name: t.Name, Fields: fields}
 		}
-		return msgs.IntrospectionResultMsg{Types: types}
+		return msgs.IntrospectionResultMsg{Endpoint: endpoint, Types: types, Schema: schema.SDL}
 	}
 
 	toastCmd := a.toast.Show("Running introspection...", false, 2*time.Second)
@@ -360,10 +829,75 @@ func (a App) handleIntrospectionResult(msg msgs.IntrospectionResultMsg) (tea.Mod
 		cmd := a.toast.Show("Introspection failed: "+msg.Err.Error(), true, 5*time.Second)
 		return a, cmd
 	}
+	if msg.Schema != nil {
+		a.editor.GQLForm().SetSchema(msg.Endpoint, msg.Schema)
+	}
 	cmd := a.toast.Show("Introspection complete: "+fmt.Sprintf("%d types", len(msg.Types)), false, 2*time.Second)
 	return a, cmd
 }
 
+func (a App) handleScaffoldGraphQL(msg msgs.ScaffoldGraphQLMsg) (tea.Model, tea.Cmd) {
+	path := a.schemaPath()
+	schema, err := graphql.LoadSDLFile(path)
+	if err != nil {
+		cmd := a.toast.Show("Schema error: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+
+	if msg.Operation == "" {
+		labels := schema.OperationLabels()
+		if len(labels) == 0 {
+			cmd := a.toast.Show("No operations in "+filepath.Base(path), true, 2*time.Second)
+			return a, cmd
+		}
+		a.commandPalette.OpenGraphQLOperationPicker(labels)
+		a.mode = msgs.ModeCommandPalette
+		return a, nil
+	}
+
+	op, ok := schema.FindOperation(msg.Operation)
+	if !ok {
+		cmd := a.toast.Show("Operation not found: "+msg.Operation, true, 2*time.Second)
+		return a, cmd
+	}
+	query, variables, err := schema.Scaffold(op, a.cfg.GraphQLScaffoldDepth)
+	if err != nil {
+		cmd := a.toast.Show("Scaffold failed: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+
+	// Reuse the endpoint of the current GraphQL tab, if any
+	url := ""
+	if cur := a.store.ActiveRequest(); cur != nil && cur.Protocol == "graphql" {
+		url = cur.URL
+	}
+	colReq := collection.NewRequest(op.Field.Name, "POST", url)
+	colReq.Protocol = "graphql"
+	colReq.GraphQL = &collection.GraphQLConfig{Query: query, Variables: variables}
+
+	a.store.OpenRequest(colReq)
+	a.syncTabs()
+	a.editor.LoadRequest(colReq)
+	a.response.SetMode("graphql")
+	a.focus = msgs.FocusEditor
+	a.updateFocus()
+
+	cmd := a.toast.Show("Scaffolded "+msg.Operation, false, 2*time.Second)
+	return a, cmd
+}
+
+// schemaPath returns schema.graphql next to the collection file, falling back
+// to the working directory.
+func (a App) schemaPath() string {
+	if a.store.CollectionPath != "" {
+		p := filepath.Join(filepath.Dir(a.store.CollectionPath), "schema.graphql")
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return "schema.graphql"
+}
+
 func (a App) handleScriptResult(msg msgs.ScriptResultMsg) (tea.Model, tea.Cmd) {
 	var testResults []response.ScriptTestResult
 	for _, tr := range msg.TestResults {
@@ -381,16 +915,54 @@ func (a App) handleScriptResult(msg msgs.ScriptResultMsg) (tea.Model, tea.Cmd) {
 
 	// Apply env changes
 	for k, v := range msg.EnvChanges {
-		a.store.EnvVars[k] = v
+		a.store.EffectiveVars()[k] = v
 	}
 
 	return a, nil
 }
 
 func (a App) handleGRPCReflect() (tea.Model, tea.Cmd) {
-	// gRPC reflection is a placeholder until the gRPC client is implemented
-	cmd := a.toast.Show("gRPC reflection not yet implemented", true, 2*time.Second)
-	return a, cmd
+	if a.editor.Protocol() != "grpc" {
+		cmd := a.toast.Show("Switch the request to gRPC to run reflection", true, 2*time.Second)
+		return a, cmd
+	}
+	req := a.editor.BuildRequest()
+	if req.URL == "" {
+		cmd := a.toast.Show("Server address is required for reflection", true, 2*time.Second)
+		return a, cmd
+	}
+	envVars, colVars, err := a.resolveSecrets(req, a.requestVars(), a.collectionVars())
+	if err != nil {
+		cmd := a.toast.Show("Secret error: "+err.Error(), true, 5*time.Second)
+		return a, cmd
+	}
+	addr := environment.Resolve(req.URL, envVars, colVars)
+	cmd := func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		services, err := grpcclient.DiscoverServices(ctx, addr, req.GRPCTransport)
+		if err != nil {
+			return msgs.GRPCReflectionResultMsg{Err: err}
+		}
+		infos := make([]msgs.GRPCServiceInfo, len(services))
+		for i, svc := range services {
+			infos[i] = msgs.GRPCServiceInfo{Name: svc.Name}
+			for _, mtd := range svc.Methods {
+				infos[i].Methods = append(infos[i].Methods, msgs.GRPCMethodInfo{
+					Name:           mtd.Name,
+					FullName:       mtd.FullName,
+					InputType:      mtd.InputType,
+					OutputType:     mtd.OutputType,
+					InputTemplate:  mtd.InputTemplate,
+					OutputTemplate: mtd.OutputTemplate,
+					IsClientStream: mtd.IsClientStream,
+					IsServerStream: mtd.IsServerStream,
+				})
+			}
+		}
+		return msgs.GRPCReflectionResultMsg{Services: infos}
+	}
+	return a, tea.Batch(cmd, a.toast.Show("Running gRPC reflection on "+addr+"...", false, 2*time.Second))
 }
 
 func (a App) handleGRPCReflectionResult(msg msgs.GRPCReflectionResultMsg) (tea.Model, tea.Cmd) {
@@ -400,6 +972,95 @@ func (a App) handleGRPCReflectionResult(msg msgs.GRPCReflectionResultMsg) (tea.M
 	}
 	// Pass services to gRPC form
 	a.editor.GRPCFormRef().SetServices(msg.Services)
-	cmd := a.toast.Show("gRPC reflection complete", false, 2*time.Second)
+	if len(msg.Services) == 0 {
+		cmd := a.toast.Show("gRPC reflection found no services", true, 3*time.Second)
+		return a, cmd
+	}
+	cmd := a.toast.Show(fmt.Sprintf("gRPC reflection found %d services", len(msg.Services)), false, 2*time.Second)
 	return a, cmd
 }
+
+// varsSeen identifies the request and variables the editor's variable
+// warnings were computed from.
+type varsSeen struct {
+	req     *collection.Request
+	env     string
+	vars    map[string]string
+	colVars map[string]string
+}
+
+// varsChanged reports whether the variable warnings may be out of date
+// after msg: keys may have edited the request, and anything may have
+// switched it or changed the variables.
+func (a App) varsChanged(msg tea.Msg) bool {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		return true
+	}
+	seen := a.varsSeen
+	return seen.req != a.store.ActiveRequest() || seen.env != a.store.EffectiveEnv() ||
+		!maps.Equal(seen.vars, a.requestVars()) || !maps.Equal(seen.colVars, a.collectionVars())
+}
+
+// refreshVarWarnings recomputes the variables the editor flags as empty or
+// unresolved.
+func (a *App) refreshVarWarnings() {
+	a.varsSeen = varsSeen{
+		req:     a.store.ActiveRequest(),
+		env:     a.store.EffectiveEnv(),
+		vars:    maps.Clone(a.requestVars()),
+		colVars: maps.Clone(a.collectionVars()),
+	}
+	a.editor.SetEmptyVars(a.store.EffectiveEnv(), a.emptyVars())
+	a.editor.SetUnresolvedVars(a.unresolvedVars())
+}
+
+// emptyVars returns the variables the active request references that are
+// empty or placeholders in the active environment.
+func (a App) emptyVars() []string {
+	envVars := a.requestVars()
+	if len(envVars) == 0 {
+		return nil
+	}
+	req := a.editor.BuildRequest()
+	texts := []string{req.URL, string(req.Body), req.BodyFile, req.GraphQLQuery, req.GraphQLVariables}
+	for k, v := range req.Params {
+		texts = append(texts, k, v)
+	}
+	for k, v := range req.Headers {
+		texts = append(texts, k, v)
+	}
+	for _, f := range req.Form {
+		texts = append(texts, f.Name, f.Value, f.File)
+	}
+	for _, m := range req.WSMessages {
+		texts = append(texts, m.Content)
+	}
+	if auth := req.Auth; auth != nil {
+		texts = append(texts, auth.Username, auth.Password, auth.Token, auth.APIKey, auth.APIValue)
+	}
+	return environment.EmptyVars(envVars, texts...)
+}
+
+// unresolvedVars returns the variables in the active request's URL,
+// headers and cookies that no scope defines.
+func (a App) unresolvedVars() []string {
+	req := a.editor.BuildRequest()
+	texts := []string{req.URL}
+	for k, v := range req.Headers {
+		texts = append(texts, k, v)
+	}
+	for _, c := range req.Cookies {
+		texts = append(texts, c.Value)
+	}
+	envVars, colVars := a.requestVars(), a.collectionVars()
+	var names []string
+	for _, text := range texts {
+		for _, name := range environment.Unresolved(text, envVars, colVars) {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sadopc/gottp/internal/runner"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+)
+
+// handleRunFolder asks for an environment, then runs the folder from the
+// saved collection file in the background.
+func (a App) handleRunFolder(msg msgs.RunFolderMsg) (tea.Model, tea.Cmd) {
+	if a.store.CollectionPath == "" {
+		cmd := a.toast.Show("Save the collection to a file to run folders", true, 3*time.Second)
+		return a, cmd
+	}
+	if msg.Env == "" && a.envFile != nil && len(a.envFile.Environments) > 0 {
+		a.commandPalette.OpenRunEnvPicker(msg.Folder, a.envFile.Names())
+		a.mode = msgs.ModeCommandPalette
+		return a, nil
+	}
+
+	cfg := runner.Config{
+		CollectionPath: a.store.CollectionPath,
+		Environment:    msg.Env,
+		FolderName:     msg.Folder,
+		Timeout:        a.cfg.DefaultTimeout,
+		Retry:          a.cfg.Retry,
+		Connections:    a.cfg.Connections,
+	}
+	run := func() tea.Msg {
+		r, err := runner.New(cfg)
+		if err != nil {
+			return msgs.FolderRunResultMsg{Folder: msg.Folder, Env: msg.Env, Err: err}
+		}
+		defer r.Close()
+		results, err := r.Run(context.Background(), cfg)
+		return msgs.FolderRunResultMsg{Folder: msg.Folder, Env: msg.Env, Results: results, Err: err}
+	}
+
+	text := "Running " + msg.Folder
+	if msg.Env != "" {
+		text += " in " + msg.Env
+	}
+	toast := a.toast.Show(text+"...", false, 2*time.Second)
+	return a, tea.Batch(toast, run)
+}
+
+func (a App) handleFolderRunResult(msg msgs.FolderRunResultMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		cmd := a.toast.Show("Run failed: "+msg.Err.Error(), true, 4*time.Second)
+		return a, cmd
+	}
+
+	var report bytes.Buffer
+	runner.PrintText(&report, msg.Results, false)
+
+	title := "Run: " + msg.Folder
+	if msg.Env != "" {
+		title += " (" + msg.Env + ")"
+	}
+	failures := 0
+	for _, r := range msg.Results {
+		if r.Error != nil || !r.TestsPassed {
+			failures++
+		}
+	}
+	summary := fmt.Sprintf("All %d requests passed", len(msg.Results))
+	if failures > 0 {
+		summary = fmt.Sprintf("%d of %d requests failed", failures, len(msg.Results))
+	}
+	a.runResults.SetSize(a.width, a.height)
+	a.runResults.Show(title, summary, report.String(), failures > 0)
+	a.mode = msgs.ModeModal
+	return a, nil
+}
@@ -1,20 +1,28 @@
 package app
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
 
+	oauth2auth "github.com/sadopc/gottp/internal/auth/oauth2"
 	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/core/environment"
 	"github.com/sadopc/gottp/internal/export"
-	"github.com/sadopc/gottp/internal/export/codegen"
+	"github.com/sadopc/gottp/internal/export/shell"
 	curlimport "github.com/sadopc/gottp/internal/import/curl"
 	"github.com/sadopc/gottp/internal/protocol"
 	"github.com/sadopc/gottp/internal/templates"
+	"github.com/sadopc/gottp/internal/ui/components"
 	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/panels/editor"
 )
 
 func (a App) saveCollection() (tea.Model, tea.Cmd) {
@@ -23,53 +31,404 @@ func (a App) saveCollection() (tea.Model, tea.Cmd) {
 		return a, nil
 	}
 
-	// Sync form state back to the active request before saving
+	a.syncActiveRequest()
+
+	written, err := a.writeCollection()
+	if err != nil {
+		cmd := a.toast.Show("Save failed: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	if !written {
+		cmd := a.toast.Show("No changes to save", false, 2*time.Second)
+		return a, cmd
+	}
+	cmd := a.toast.Show("Collection saved", false, 2*time.Second)
+	return a, cmd
+}
+
+// writeCollection saves the collection to its file unless it is unchanged
+// since it was loaded or last saved, and reports whether it was written.
+func (a *App) writeCollection() (bool, error) {
+	hash, written, err := collection.SaveIfChanged(a.store.Collection, a.store.CollectionPath, a.store.CollectionHash)
+	if err != nil {
+		return false, err
+	}
+	a.store.CollectionHash = hash
+	for i := range a.store.Tabs {
+		a.store.Tabs[i].Modified = false
+	}
+	// Edits still only in the editor were not saved
+	a.markModified()
+	a.syncTabs()
+	return written, nil
+}
+
+// inCollection reports whether req is saved with the collection, unlike
+// the request of a new tab.
+func (a *App) inCollection(req *collection.Request) bool {
+	return req != nil && a.store.Collection != nil && findRequest(a.store.Collection.Items, req.ID) == req
+}
+
+// editorChanged reports whether the editor holds edits not yet copied to
+// the active request. Loading a request into the editor fills in defaults
+// such as headers, so the editor is compared with a fresh one showing the
+// same request rather than with the request itself.
+func (a *App) editorChanged() bool {
 	req := a.store.ActiveRequest()
-	if req != nil {
-		built := a.editor.BuildRequest()
-		req.Method = built.Method
-		req.URL = built.URL
-
-		// Sync params
-		formParams := a.editor.GetParams()
-		req.Params = make([]collection.KVPair, len(formParams))
-		for i, p := range formParams {
-			req.Params[i] = collection.KVPair{Key: p.Key, Value: p.Value, Enabled: p.Enabled}
+	if req == nil {
+		return false
+	}
+	fresh := editor.New(a.theme, a.styles)
+	fresh.LoadRequest(req)
+	shown, loaded := cloneRequest(req), cloneRequest(req)
+	syncRequest(shown, &a.editor)
+	syncRequest(loaded, &fresh)
+	return !reflect.DeepEqual(shown, loaded)
+}
+
+// cloneRequest returns a deep copy of req.
+func cloneRequest(req *collection.Request) *collection.Request {
+	data, err := yaml.Marshal(req)
+	if err != nil {
+		return req
+	}
+	var clone collection.Request
+	if err := yaml.Unmarshal(data, &clone); err != nil {
+		return req
+	}
+	return &clone
+}
+
+// unsavedChanges reports whether the collection or the editor holds edits
+// not yet saved to the collection file.
+func (a *App) unsavedChanges() bool {
+	if a.store.Collection == nil {
+		return false
+	}
+	if hash, _ := collection.Fingerprint(a.store.Collection); hash != a.store.CollectionHash {
+		return true
+	}
+	return a.inCollection(a.store.ActiveRequest()) && a.editorChanged()
+}
+
+// markModified flags the active tab as modified once the editor changes
+// its request.
+func (a *App) markModified() {
+	i := a.store.ActiveTab
+	if i < 0 || i >= len(a.store.Tabs) || a.store.Tabs[i].Modified {
+		return
+	}
+	if a.inCollection(a.store.Tabs[i].Request) && a.editorChanged() {
+		a.store.Tabs[i].Modified = true
+		a.syncTabs()
+	}
+}
+
+// leaveTab copies the editor's edits to the active request before another
+// tab is loaded into the editor, so switching tabs doesn't lose them.
+func (a *App) leaveTab() {
+	if !a.editorChanged() {
+		return
+	}
+	a.syncActiveRequest()
+	if a.inCollection(a.store.ActiveRequest()) {
+		a.store.Tabs[a.store.ActiveTab].Modified = true
+	}
+}
+
+// revertRequest restores req to its saved version, if it has one.
+func (a *App) revertRequest(req *collection.Request) {
+	col, err := collection.LoadFromFile(a.store.CollectionPath)
+	if err != nil {
+		return
+	}
+	if saved := findRequest(col.Items, req.ID); saved != nil {
+		*req = *saved
+	}
+}
+
+// closeTab closes the active tab, first asking what to do with unsaved
+// changes to its request.
+func (a App) closeTab(msg msgs.CloseTabMsg) (tea.Model, tea.Cmd) {
+	a.markModified()
+	i := a.store.ActiveTab
+	if i >= 0 && i < len(a.store.Tabs) && a.store.Tabs[i].Modified {
+		switch {
+		case msg.Save:
+			a.syncActiveRequest()
+			if _, err := a.writeCollection(); err != nil {
+				cmd := a.toast.Show("Save failed: "+err.Error(), true, 3*time.Second)
+				return a, cmd
+			}
+		case msg.Discard:
+			a.revertRequest(a.store.Tabs[i].Request)
+		default:
+			a.modal.ShowChoices("Unsaved changes",
+				fmt.Sprintf("%q has unsaved changes. Save all changes to the collection, or discard this request's?", a.store.Tabs[i].Request.Name),
+				components.ModalChoice{Label: "Save all", Msg: msgs.CloseTabMsg{Save: true}},
+				&components.ModalChoice{Label: "Discard", Msg: msgs.CloseTabMsg{Discard: true}})
+			a.mode = msgs.ModeModal
+			return a, nil
 		}
+	}
+	a.store.CloseTab()
+	a.syncTabs()
+	a.loadActiveRequest()
+	return a, nil
+}
 
-		// Sync headers
-		formHeaders := a.editor.GetHeaders()
-		req.Headers = make([]collection.KVPair, len(formHeaders))
-		for i, h := range formHeaders {
-			req.Headers[i] = collection.KVPair{Key: h.Key, Value: h.Value, Enabled: h.Enabled}
+// quit exits, first offering to save unsaved changes.
+func (a App) quit() (tea.Model, tea.Cmd) {
+	a.markModified()
+	if !a.unsavedChanges() {
+		return a, tea.Quit
+	}
+	text := "The collection has unsaved changes."
+	n := 0
+	for _, tab := range a.store.Tabs {
+		if tab.Modified {
+			n++
 		}
+	}
+	switch {
+	case n == 1:
+		text = "1 open request has unsaved changes."
+	case n > 1:
+		text = fmt.Sprintf("%d open requests have unsaved changes.", n)
+	}
+	a.modal.ShowChoices("Quit gottp?", text+" Save them before quitting?",
+		components.ModalChoice{Label: "Save all", Msg: msgs.SaveAllMsg{Quit: true}},
+		&components.ModalChoice{Label: "Discard", Msg: tea.QuitMsg{}})
+	a.mode = msgs.ModeModal
+	return a, nil
+}
+
+// saveAll saves the collection, including the edits in every tab, and
+// quits afterwards if asked to and the save succeeded.
+func (a App) saveAll(msg msgs.SaveAllMsg) (tea.Model, tea.Cmd) {
+	m, cmd := a.saveCollection()
+	a = m.(App)
+	if msg.Quit && !a.unsavedChanges() {
+		return a, tea.Quit
+	}
+	return a, cmd
+}
+
+// syncActiveRequest copies the editor's form state back to the active
+// request.
+func (a *App) syncActiveRequest() {
+	if req := a.store.ActiveRequest(); req != nil {
+		syncRequest(req, &a.editor)
+	}
+}
+
+// syncRequest copies ed's form state to req.
+func syncRequest(req *collection.Request, ed *editor.Model) {
+	built := ed.BuildRequest()
+	req.Method = built.Method
+	req.URL = built.URL
+	req.ExpectContinue = built.ExpectContinue
+	req.Chunked = built.Chunked
+	req.NoCache = built.NoCache
+	req.TLS = built.TLS
 
-		// Sync body
-		bodyContent := a.editor.GetBodyContent()
-		if bodyContent != "" {
-			if req.Body == nil {
-				req.Body = &collection.Body{Type: "json"}
+	// Sync params
+	formParams := ed.GetParams()
+	req.Params = make([]collection.KVPair, len(formParams))
+	for i, p := range formParams {
+		req.Params[i] = collection.KVPair{Key: p.Key, Value: p.Value, Enabled: p.Enabled}
+	}
+
+	// Sync headers; the gRPC form's table is call metadata
+	formHeaders := ed.GetHeaders()
+	pairs := make([]collection.KVPair, len(formHeaders))
+	for i, h := range formHeaders {
+		pairs[i] = collection.KVPair{Key: h.Key, Value: h.Value, Enabled: h.Enabled}
+	}
+	if ed.Protocol() == "grpc" {
+		req.Headers = nil
+		req.GRPC = &collection.GRPCConfig{
+			Service:        built.GRPCService,
+			Method:         built.GRPCMethod,
+			Metadata:       pairs,
+			Transport:      built.GRPCTransport,
+			Deadline:       built.GRPCCall.Deadline,
+			Gzip:           built.GRPCCall.Gzip,
+			MaxSendMsgSize: built.GRPCCall.MaxSendMsgSize,
+			MaxRecvMsgSize: built.GRPCCall.MaxRecvMsgSize,
+			WaitForReady:   built.GRPCCall.WaitForReady,
+		}
+	} else {
+		req.Headers = pairs
+	}
+	if ed.Protocol() == "http" {
+		req.Cookies = nil
+		for _, c := range ed.Form().GetCookies() {
+			if c.Key == "" && c.Value == "" {
+				continue
 			}
-			req.Body.Content = bodyContent
-		} else {
-			req.Body = nil
+			req.Cookies = append(req.Cookies, collection.KVPair{Key: c.Key, Value: c.Value, Enabled: c.Enabled})
 		}
+	}
 
-		// Sync auth
-		authConfig := a.editor.BuildAuth()
-		if authConfig != nil && authConfig.Type != "none" {
-			req.Auth = authConfigToCollection(authConfig)
-		} else {
-			req.Auth = nil
+	// Sync body
+	bodyContent := ed.GetBodyContent()
+	if ed.Protocol() == "http" {
+		req.Body = ed.Form().BuildBody()
+	} else if bodyContent != "" {
+		if req.Body == nil {
+			req.Body = &collection.Body{Type: "json"}
 		}
+		req.Body.Content = bodyContent
+	} else {
+		req.Body = nil
 	}
 
-	err := collection.SaveToFile(a.store.Collection, a.store.CollectionPath)
-	if err != nil {
+	// Sync GraphQL
+	if ed.Protocol() == "graphql" {
+		if req.GraphQL == nil {
+			req.GraphQL = &collection.GraphQLConfig{}
+		}
+		req.GraphQL.Query = built.GraphQLQuery
+		req.GraphQL.Variables = built.GraphQLVariables
+		req.GraphQL.PersistedQuery = built.GraphQLPersisted
+	}
+
+	// Sync auth
+	authConfig := ed.BuildAuth()
+	if authConfig != nil && authConfig.Type != "none" {
+		req.Auth = authConfigToCollection(authConfig)
+	} else {
+		req.Auth = nil
+	}
+}
+
+func (a App) openFindReplace() (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil {
+		cmd := a.toast.Show("No collection to search", true, 2*time.Second)
+		return a, cmd
+	}
+	// Search what the editor shows, not the last saved state
+	a.syncActiveRequest()
+	a.findReplace.Open(a.store.Collection)
+	a.mode = msgs.ModeModal
+	return a, nil
+}
+
+func (a App) applyReplace(msg msgs.ApplyReplaceMsg) (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil {
+		return a, nil
+	}
+	n := collection.ApplyMatches(msg.Matches)
+	a.loadActiveRequest()
+	a.syncTabs()
+	a.refreshSidebar()
+
+	text := fmt.Sprintf("Replaced %d matches", n)
+	if a.store.CollectionPath == "" {
+		cmd := a.toast.Show(text+" (no collection file to save)", false, 2*time.Second)
+		return a, cmd
+	}
+	if _, err := a.writeCollection(); err != nil {
 		cmd := a.toast.Show("Save failed: "+err.Error(), true, 3*time.Second)
 		return a, cmd
 	}
-	cmd := a.toast.Show("Collection saved", false, 2*time.Second)
+	cmd := a.toast.Show(text+" and saved", false, 2*time.Second)
+	return a, cmd
+}
+
+func (a App) moveRequest(msg msgs.MoveRequestMsg) (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil {
+		return a, nil
+	}
+	req := findRequest(a.store.Collection.Items, msg.RequestID)
+	if !collection.MoveRequest(a.store.Collection, req, msg.Delta) {
+		return a, nil
+	}
+	cmd := a.commitStructure(collection.Item{Request: req}, "")
+	return a, cmd
+}
+
+func (a App) openDuplicates() (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil {
+		cmd := a.toast.Show("No collection to check", true, 2*time.Second)
+		return a, cmd
+	}
+	a.syncActiveRequest()
+	a.duplicates.Open(a.store.Collection)
+	if len(a.duplicates.Groups()) == 0 {
+		a.duplicates.Close()
+		cmd := a.toast.Show("No duplicate requests", false, 2*time.Second)
+		return a, cmd
+	}
+	a.mode = msgs.ModeModal
+	return a, nil
+}
+
+// openVariables edits the variables in scope for the active request:
+// its own, its folders' and the collection's.
+func (a App) openVariables() (tea.Model, tea.Cmd) {
+	col := a.store.Collection
+	if col == nil {
+		cmd := a.toast.Show("No collection to edit variables in", true, 2*time.Second)
+		return a, cmd
+	}
+	a.syncActiveRequest()
+	var scopes []components.VarScope
+	if req := a.store.ActiveRequest(); req != nil {
+		scopes = append(scopes, components.VarScope{Label: "Request " + req.Name, Vars: &req.Variables})
+		path := collection.FolderPath(col, req)
+		for i := len(path) - 1; i >= 0; i-- {
+			scopes = append(scopes, components.VarScope{Label: "Folder " + path[i].Name, Vars: &path[i].Variables})
+		}
+	}
+	scopes = append(scopes, components.VarScope{Label: "Collection", Vars: &col.Variables})
+	a.variables.Mask = a.secrets.Mask
+	a.variables.Open(scopes, a.store.EffectiveEnv())
+	a.mode = msgs.ModeModal
+	return a, nil
+}
+
+// saveVariables saves the collection after an edit in the variables
+// overlay.
+func (a App) saveVariables() (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil || a.store.CollectionPath == "" {
+		return a, nil
+	}
+	if _, err := a.writeCollection(); err != nil {
+		cmd := a.toast.Show("Save failed: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	return a, nil
+}
+
+func (a App) mergeDuplicates(msg msgs.MergeDuplicatesMsg) (tea.Model, tea.Cmd) {
+	if a.store.Collection == nil {
+		return a, nil
+	}
+	n := collection.MergeDuplicates(a.store.Collection, msg.Keep, msg.Remove)
+	a.store.CloseRequestTabs(msg.Remove)
+	a.loadActiveRequest()
+	a.syncTabs()
+	a.refreshSidebar()
+
+	// Stay open on the next group until none are left
+	a.duplicates.Open(a.store.Collection)
+	if len(a.duplicates.Groups()) == 0 {
+		a.duplicates.Close()
+		a.mode = msgs.ModeNormal
+	}
+
+	text := fmt.Sprintf("Merged %d duplicates into %q", n, msg.Keep.Name)
+	if a.store.CollectionPath == "" {
+		cmd := a.toast.Show(text+" (no collection file to save)", false, 2*time.Second)
+		return a, cmd
+	}
+	if _, err := a.writeCollection(); err != nil {
+		cmd := a.toast.Show("Save failed: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	cmd := a.toast.Show(text+" and saved", false, 2*time.Second)
 	return a, cmd
 }
 
@@ -118,6 +477,52 @@ func authConfigToCollection(auth *protocol.AuthConfig) *collection.Auth {
 	return ca
 }
 
+func (a App) copyText(msg msgs.CopyTextMsg) (tea.Model, tea.Cmd) {
+	if err := clipboard.WriteAll(msg.Text); err != nil {
+		cmd := a.toast.Show("Clipboard error: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	cmd := a.toast.Show("Copied "+msg.Label, false, 2*time.Second)
+	return a, cmd
+}
+
+func (a App) openAnnotate() (tea.Model, tea.Cmd) {
+	body, lang := a.response.BodyText()
+	if body == "" {
+		cmd := a.toast.Show("No response body to annotate", true, 2*time.Second)
+		return a, cmd
+	}
+	summary := export.Snippet{Lang: lang, Status: a.response.Status()}
+	if req := a.store.ActiveRequest(); req != nil {
+		summary.Method, summary.URL = req.Method, req.URL
+	}
+	a.annotate.SetSize(a.width, a.height)
+	a.annotate.Open(summary, body)
+	a.mode = msgs.ModeModal
+	return a, nil
+}
+
+func (a App) shareSnippet(msg msgs.ShareSnippetMsg) (tea.Model, tea.Cmd) {
+	// Snippets end up in bug reports, so keep secrets out of them
+	return a.copyText(msgs.CopyTextMsg{
+		Text:  a.secrets.Mask(msg.Snippet.Markdown()),
+		Label: "annotated snippet as Markdown",
+	})
+}
+
+// openURL hands a URL to the platform's default handler; tests replace it.
+var openURL = oauth2auth.OpenBrowser
+
+func (a App) openLink(msg msgs.OpenURLMsg) (tea.Model, tea.Cmd) {
+	url := environment.Resolve(msg.URL, a.requestVars(), a.collectionVars())
+	if err := openURL(url); err != nil {
+		cmd := a.toast.Show("Could not open browser: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	cmd := a.toast.Show("Opened "+a.secrets.Mask(url), false, 2*time.Second)
+	return a, cmd
+}
+
 func (a App) copyAsCurl() (tea.Model, tea.Cmd) {
 	req := a.editor.BuildRequest()
 	if req.URL == "" {
@@ -126,7 +531,7 @@ func (a App) copyAsCurl() (tea.Model, tea.Cmd) {
 	}
 
 	// Resolve env vars before export
-	envVars := a.store.EnvVars
+	envVars := a.requestVars()
 	var colVars map[string]string
 	if a.store.Collection != nil {
 		colVars = a.store.Collection.Variables
@@ -144,6 +549,10 @@ func (a App) copyAsCurl() (tea.Model, tea.Cmd) {
 	for k, v := range req.Params {
 		req.Params[k] = environment.Resolve(v, envVars, colVars)
 	}
+	for i := range req.Cookies {
+		req.Cookies[i].Value = environment.Resolve(req.Cookies[i].Value, envVars, colVars)
+	}
+	req.ApplyCookies()
 	if len(req.Body) > 0 {
 		req.Body = []byte(environment.Resolve(string(req.Body), envVars, colVars))
 	}
@@ -157,6 +566,44 @@ func (a App) copyAsCurl() (tea.Model, tea.Cmd) {
 	return a, cmd
 }
 
+// exportShellScript writes the request or folder selected in the sidebar,
+// or the whole collection, as an executable script next to the collection.
+func (a App) exportShellScript() (tea.Model, tea.Cmd) {
+	col := a.store.Collection
+	if col == nil {
+		cmd := a.toast.Show("No collection to export", true, 2*time.Second)
+		return a, cmd
+	}
+	title := col.Name
+	var steps []shell.Step
+	if item := a.sidebar.SelectedItem(); item.Folder != nil || item.Request != nil {
+		steps = shell.ItemSteps(col, item)
+		if item.Folder != nil {
+			title += ": " + item.Folder.Name
+		} else {
+			title += ": " + item.Request.Name
+		}
+	} else {
+		steps = shell.FolderSteps(col, col.Items, nil)
+	}
+	if len(steps) == 0 {
+		cmd := a.toast.Show("No requests to export", true, 2*time.Second)
+		return a, cmd
+	}
+
+	dir := "."
+	if a.store.CollectionPath != "" {
+		dir = filepath.Dir(a.store.CollectionPath)
+	}
+	path := filepath.Join(dir, "script-"+time.Now().Format("20060102-150405")+".sh")
+	if err := os.WriteFile(path, shell.Export(title, steps, col.Variables), 0755); err != nil {
+		cmd := a.toast.Show("Export failed: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	cmd := a.toast.Show(fmt.Sprintf("Exported %d requests to %s", len(steps), path), false, 3*time.Second)
+	return a, cmd
+}
+
 func (a App) handleGenerateCode(msg msgs.GenerateCodeMsg) (tea.Model, tea.Cmd) {
 	req := a.editor.BuildRequest()
 	if req.URL == "" {
@@ -165,7 +612,7 @@ func (a App) handleGenerateCode(msg msgs.GenerateCodeMsg) (tea.Model, tea.Cmd) {
 	}
 
 	// Resolve env vars before generating
-	envVars := a.store.EnvVars
+	envVars := a.requestVars()
 	var colVars map[string]string
 	if a.store.Collection != nil {
 		colVars = a.store.Collection.Variables
@@ -183,23 +630,17 @@ func (a App) handleGenerateCode(msg msgs.GenerateCodeMsg) (tea.Model, tea.Cmd) {
 	for k, v := range req.Params {
 		req.Params[k] = environment.Resolve(v, envVars, colVars)
 	}
+	for i := range req.Cookies {
+		req.Cookies[i].Value = environment.Resolve(req.Cookies[i].Value, envVars, colVars)
+	}
+	req.ApplyCookies()
 	if len(req.Body) > 0 {
 		req.Body = []byte(environment.Resolve(string(req.Body), envVars, colVars))
 	}
 
-	code, err := codegen.Generate(req, codegen.Language(msg.Language))
-	if err != nil {
-		cmd := a.toast.Show("Code generation failed: "+err.Error(), true, 3*time.Second)
-		return a, cmd
-	}
-
-	if err := clipboard.WriteAll(code); err != nil {
-		cmd := a.toast.Show("Clipboard error: "+err.Error(), true, 3*time.Second)
-		return a, cmd
-	}
-
-	cmd := a.toast.Show("Copied "+msg.Language+" code", false, 2*time.Second)
-	return a, cmd
+	a.codeGen.Open(req, msg.Language)
+	a.mode = msgs.ModeModal
+	return a, nil
 }
 
 func (a App) handleInsertTemplate(msg msgs.InsertTemplateMsg) (tea.Model, tea.Cmd) {
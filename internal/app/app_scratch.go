@@ -0,0 +1,94 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/scratch"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+)
+
+// loadScratch returns the scratch requests left open last session. A
+// scratch file that can't be read is left alone rather than overwritten on
+// exit.
+func (a *App) loadScratch() []*collection.Request {
+	reqs, err := scratch.Load(a.scratchPath)
+	if err != nil {
+		a.scratchPath = ""
+		return nil
+	}
+	return reqs
+}
+
+// saveScratch writes the open tabs that aren't part of the collection to
+// the scratch file, so they are there again next time. Tabs with neither a
+// URL nor a body are dropped.
+func (a *App) saveScratch() error {
+	if a.scratchPath == "" {
+		return nil
+	}
+	a.syncActiveRequest()
+	var reqs []*collection.Request
+	for _, tab := range a.store.Tabs {
+		if req := tab.Request; !a.inCollection(req) && a.keptTab(req) {
+			reqs = append(reqs, req)
+		}
+	}
+	return scratch.Save(a.scratchPath, reqs)
+}
+
+// keptTab reports whether the tab showing req is reopened next session:
+// it is in the collection or is a scratch request worth keeping.
+func (a *App) keptTab(req *collection.Request) bool {
+	return a.inCollection(req) || !blankRequest(req)
+}
+
+func blankRequest(req *collection.Request) bool {
+	return req.URL == "" && (req.Body == nil || req.Body.Content == "" && len(req.Body.Fields) == 0 && req.Body.File == "")
+}
+
+// saveToCollection adds the active scratch request to the collection:
+// first asking for the folder, then for its name.
+func (a App) saveToCollection(msg msgs.SaveToCollectionMsg) (tea.Model, tea.Cmd) {
+	req := a.store.ActiveRequest()
+	switch {
+	case a.store.Collection == nil:
+		cmd := a.toast.Show("No collection to save to", true, 2*time.Second)
+		return a, cmd
+	case req == nil:
+		return a, nil
+	case a.inCollection(req):
+		cmd := a.toast.Show(fmt.Sprintf("%q is already in the collection", req.Name), false, 2*time.Second)
+		return a, cmd
+	}
+	if !msg.Picked {
+		a.commandPalette.OpenSaveToFolderPicker(collection.Folders(a.store.Collection))
+		a.mode = msgs.ModeCommandPalette
+		return a, nil
+	}
+	if msg.Name == "" {
+		a.prompt.Open("Save as", req.Name, func(name string) tea.Msg {
+			msg.Name = name
+			return msg
+		})
+		a.mode = msgs.ModeModal
+		return a, nil
+	}
+
+	a.syncActiveRequest()
+	req.Name = msg.Name
+	collection.AddItem(a.store.Collection, msg.Into, collection.Item{Request: req})
+	a.syncTabs()
+	into := "the top level"
+	if msg.Into != nil {
+		into = fmt.Sprintf("%q", msg.Into.Name)
+	}
+	cmd := a.commitStructure(collection.Item{Request: req}, fmt.Sprintf("Saved %q to %s", msg.Name, into))
+	if err := a.saveScratch(); err != nil {
+		cmd = a.toast.Show("Saving scratch requests: "+err.Error(), true, 3*time.Second)
+	}
+	return a, cmd
+}
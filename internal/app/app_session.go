@@ -0,0 +1,117 @@
+package app
+
+import (
+	"slices"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/session"
+	"github.com/sadopc/gottp/internal/core/state"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+)
+
+var focusNames = map[msgs.PanelFocus]string{
+	msgs.FocusSidebar:  "sidebar",
+	msgs.FocusEditor:   "editor",
+	msgs.FocusResponse: "response",
+}
+
+// restoreTabs reopens the tabs of the last session with this collection,
+// with its environment, sidebar and focus, unless restore_session is off.
+// Scratch requests are reopened either way, after the session's tabs.
+func (a *App) restoreTabs() {
+	scratchReqs := a.loadScratch()
+	var tabs []state.OpenTab
+	active := 0
+	if a.cfg.RestoreSession && a.store.CollectionPath != "" {
+		if sess, ok, err := session.Load(a.sessionPath, session.Key(a.store.CollectionPath)); err == nil && ok {
+			tabs, active = a.sessionTabs(sess, &scratchReqs)
+			a.restoreLayout(sess)
+		}
+	}
+	for _, req := range scratchReqs {
+		tabs = append(tabs, state.OpenTab{Request: req})
+	}
+	if len(tabs) == 0 {
+		return
+	}
+	a.store.Tabs = tabs
+	a.store.ActiveTab = min(max(active, 0), len(tabs)-1)
+	a.loadActiveRequest()
+}
+
+// sessionTabs opens the session's tabs whose requests still exist, taking
+// scratch requests out of scratchReqs as they are placed. It returns the
+// tabs and the index of the active one.
+func (a *App) sessionTabs(sess session.Session, scratchReqs *[]*collection.Request) ([]state.OpenTab, int) {
+	var tabs []state.OpenTab
+	active := 0
+	for i, t := range sess.Tabs {
+		var req *collection.Request
+		if a.store.Collection != nil {
+			req = findRequest(a.store.Collection.Items, t.RequestID)
+		}
+		if req == nil {
+			j := slices.IndexFunc(*scratchReqs, func(r *collection.Request) bool { return r.ID == t.RequestID })
+			if j < 0 {
+				continue
+			}
+			req = (*scratchReqs)[j]
+			*scratchReqs = slices.Delete(*scratchReqs, j, j+1)
+		}
+		if i <= sess.ActiveTab {
+			active = len(tabs)
+		}
+		tab := state.OpenTab{Request: req}
+		if a.hasEnv(t.Env) {
+			tab.Env = t.Env
+			tab.EnvVars = a.envFile.GetVariables(t.Env)
+			a.secrets.Remember(a.envFile.SecretValues(t.Env)...)
+		}
+		tabs = append(tabs, tab)
+	}
+	return tabs, active
+}
+
+// restoreLayout brings back the session's environment, sidebar and focus.
+func (a *App) restoreLayout(sess session.Session) {
+	if a.hasEnv(sess.Env) {
+		a.store.ActiveEnv = sess.Env
+		a.store.EnvVars = a.envFile.GetVariables(sess.Env)
+		a.secrets.Remember(a.envFile.SecretValues(sess.Env)...)
+	}
+	a.sidebarVisible = !sess.SidebarHidden
+	for focus, name := range focusNames {
+		if name == sess.Focus {
+			a.focus = focus
+		}
+	}
+	a.updateFocus()
+}
+
+// hasEnv reports whether the environment file defines name.
+func (a *App) hasEnv(name string) bool {
+	return name != "" && a.envFile != nil && slices.Contains(a.envFile.Names(), name)
+}
+
+// saveSession remembers the open tabs, environment, sidebar and focus for
+// the collection. Blank scratch tabs, which aren't kept, are left out.
+func (a *App) saveSession() error {
+	if a.sessionPath == "" || a.store.CollectionPath == "" {
+		return nil
+	}
+	sess := session.Session{
+		Env:           a.store.ActiveEnv,
+		SidebarHidden: !a.sidebarVisible,
+		Focus:         focusNames[a.focus],
+	}
+	for i, tab := range a.store.Tabs {
+		if !a.keptTab(tab.Request) {
+			continue
+		}
+		if i <= a.store.ActiveTab {
+			sess.ActiveTab = len(sess.Tabs)
+		}
+		sess.Tabs = append(sess.Tabs, session.Tab{RequestID: tab.Request.ID, Env: tab.Env})
+	}
+	return session.Save(a.sessionPath, session.Key(a.store.CollectionPath), sess)
+}
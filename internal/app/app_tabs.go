@@ -2,10 +2,16 @@ package app
 
 import (
 	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/history"
+	"github.com/sadopc/gottp/internal/scripting"
 	"github.com/sadopc/gottp/internal/ui/components"
 	"github.com/sadopc/gottp/internal/ui/msgs"
 	"github.com/sadopc/gottp/internal/ui/panels/sidebar"
@@ -15,8 +21,10 @@ func (a *App) syncTabs() {
 	tabs := make([]components.TabItem, len(a.store.Tabs))
 	for i, t := range a.store.Tabs {
 		tabs[i] = components.TabItem{
-			Name:   t.Request.Name,
-			Method: t.Request.Method,
+			Name:     t.Request.Name,
+			Method:   t.Request.Method,
+			Env:      t.Env,
+			Modified: t.Modified,
 		}
 	}
 	a.tabBar.SetTabs(tabs)
@@ -27,14 +35,65 @@ func (a *App) loadActiveRequest() {
 	req := a.store.ActiveRequest()
 	if req != nil {
 		a.editor.LoadRequest(req)
+		a.recordEdit()
 	}
 }
 
+// focusRequest switches to the tab showing the request with id. It reports
+// false if that tab has been closed.
+func (a *App) focusRequest(id string) bool {
+	for i, tab := range a.store.Tabs {
+		if tab.Request.ID != id {
+			continue
+		}
+		if i != a.store.ActiveTab {
+			a.leaveTab()
+			a.store.ActiveTab = i
+			a.syncTabs()
+			a.loadActiveRequest()
+		}
+		return true
+	}
+	return false
+}
+
+// refreshSidebar shows the collection in the sidebar, marking requests
+// whose scripts do not parse, and returns how many scripts those are.
+func (a *App) refreshSidebar() int {
+	if a.store.Collection == nil {
+		return 0
+	}
+	a.sidebar.SetItems(collection.FlattenItems(a.store.Collection.Items, 0, ""))
+	diags := scripting.CheckCollection(a.store.Collection)
+	marks := make(map[*collection.Request]string, len(diags))
+	for _, d := range diags {
+		if marks[d.Request] != "" {
+			marks[d.Request] += "; "
+		}
+		marks[d.Request] += d.Script + " " + d.Err.Error()
+	}
+	a.sidebar.SetDiagnostics(marks)
+	return len(diags)
+}
+
+// reportScriptErrors tells the user about scripts that failed to parse when
+// the collection was loaded.
+func (a *App) reportScriptErrors(n int) {
+	switch {
+	case n == 1:
+		a.statusBar.SetMessage("1 script has a syntax error (marked ⚠ in the sidebar)")
+	case n > 1:
+		a.statusBar.SetMessage(fmt.Sprintf("%d scripts have syntax errors (marked ⚠ in the sidebar)", n))
+	}
+}
+
+// loadHistory shows the latest history entries in the sidebar, or those
+// matching the sidebar filter.
 func (a *App) loadHistory() {
 	if a.history == nil {
 		return
 	}
-	entries, err := a.history.List(20, 0)
+	entries, err := a.history.ListFiltered(historyFilter(a.historyQuery, 20))
 	if err != nil {
 		return
 	}
@@ -47,22 +106,122 @@ func (a *App) loadHistory() {
 			StatusCode: e.StatusCode,
 			Duration:   e.Duration,
 			Timestamp:  e.Timestamp,
+			Tags:       e.Tags,
+			Note:       e.Note,
 		}
 	}
 	a.sidebar.SetHistory(items)
 }
 
+// historyFilter turns a sidebar filter into a history query: "#tag" terms
+// must all be tags of an entry, "since:7d" and "until:2024-05-01" bound the
+// time range, and the rest is searched for in the URL, note and tags.
+func historyFilter(query string, limit int) history.Filter {
+	f := history.Filter{Limit: limit}
+	now := time.Now()
+	var text []string
+	for _, term := range strings.Fields(query) {
+		if tag, ok := strings.CutPrefix(term, "#"); ok && tag != "" {
+			f.Tags = append(f.Tags, tag)
+		} else if t, err := timeTerm(term, "since:", now); err == nil && !t.IsZero() {
+			f.Since = t
+		} else if t, err := timeTerm(term, "until:", now); err == nil && !t.IsZero() {
+			f.Until = t
+		} else {
+			text = append(text, term)
+		}
+	}
+	f.Text = strings.Join(text, " ")
+	return f
+}
+
+// timeTerm parses a filter term such as "since:24h". It returns the zero
+// time when term lacks the prefix.
+func timeTerm(term, prefix string, now time.Time) (time.Time, error) {
+	v, ok := strings.CutPrefix(term, prefix)
+	if !ok {
+		return time.Time{}, nil
+	}
+	return history.ParseTime(v, now)
+}
+
+// exportHistory saves the history entries matching the sidebar filter as a
+// collection next to the open one, so an exploratory session can be
+// replayed.
+func (a App) exportHistory() (tea.Model, tea.Cmd) {
+	if a.history == nil {
+		cmd := a.toast.Show("History is not available", true, 2*time.Second)
+		return a, cmd
+	}
+	entries, err := a.history.ListFiltered(historyFilter(a.historyQuery, 1000))
+	if err != nil {
+		cmd := a.toast.Show(err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	if len(entries) == 0 {
+		cmd := a.toast.Show("No history to export", true, 2*time.Second)
+		return a, cmd
+	}
+
+	name := "History " + time.Now().Format("2006-01-02 15:04")
+	col := history.ToCollection(name, entries)
+	dir := "."
+	if a.store.CollectionPath != "" {
+		dir = filepath.Dir(a.store.CollectionPath)
+	}
+	path := filepath.Join(dir, "history-"+time.Now().Format("20060102-150405")+".gottp.yaml")
+	if err := collection.SaveToFile(col, path); err != nil {
+		cmd := a.toast.Show("Export failed: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	cmd := a.toast.Show(fmt.Sprintf("Exported %d requests to %s", len(col.Items), path), false, 3*time.Second)
+	return a, cmd
+}
+
+func (a App) openHistoryNote(msg msgs.EditHistoryNoteMsg) (tea.Model, tea.Cmd) {
+	if a.history == nil {
+		return a, nil
+	}
+	entry, err := a.history.Get(msg.ID)
+	if err != nil {
+		cmd := a.toast.Show(err.Error(), true, 2*time.Second)
+		return a, cmd
+	}
+	a.historyNote.Open(entry)
+	a.mode = msgs.ModeModal
+	return a, nil
+}
+
+func (a App) saveHistoryNote(msg msgs.HistoryNoteSavedMsg) (tea.Model, tea.Cmd) {
+	if a.history == nil {
+		return a, nil
+	}
+	if err := a.history.Annotate(msg.ID, msg.Tags, msg.Note); err != nil {
+		cmd := a.toast.Show(err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	a.loadHistory()
+	cmd := a.toast.Show("History entry updated", false, 2*time.Second)
+	return a, cmd
+}
+
 func (a App) handleRequestSelected(msg msgs.RequestSelectedMsg) (tea.Model, tea.Cmd) {
 	if a.store.Collection == nil {
 		return a, nil
 	}
 	req := findRequest(a.store.Collection.Items, msg.RequestID)
 	if req != nil {
+		a.leaveTab()
 		a.store.OpenRequest(req)
 		a.syncTabs()
 		a.editor.LoadRequest(req)
+		a.recordEdit()
 		a.focus = msgs.FocusEditor
 		a.updateFocus()
+		if diag := a.sidebar.Diagnostic(req); diag != "" {
+			cmd := a.toast.Show("Script error: "+diag, true, 4*time.Second)
+			return a, cmd
+		}
 	}
 	return a, nil
 }
@@ -100,3 +259,68 @@ func (a App) handleHistorySelected(msg msgs.HistorySelectedMsg) (tea.Model, tea.
 	}
 	return a, nil
 }
+
+// switchEnv makes name the global environment.
+func (a App) switchEnv(name string) (tea.Model, tea.Cmd) {
+	a.store.ActiveEnv = name
+	a.store.EnvVars = a.envFile.GetVariables(name)
+	a.secrets.Remember(a.envFile.SecretValues(name)...)
+	a.statusBar.SetEnv(name)
+	cmd := a.toast.Show("Environment: "+name, false, 2*time.Second)
+	return a, cmd
+}
+
+// cycleEnv switches to the environment after the one in effect. A tab
+// pinned to an environment moves its pin; other tabs switch the global
+// environment.
+func (a App) cycleEnv() (tea.Model, tea.Cmd) {
+	if a.envFile == nil || len(a.envFile.Environments) == 0 {
+		cmd := a.toast.Show("No environments found", true, 2*time.Second)
+		return a, cmd
+	}
+	names := a.envFile.Names()
+	next := names[0]
+	for i, name := range names {
+		if name == a.store.EffectiveEnv() {
+			next = names[(i+1)%len(names)]
+			break
+		}
+	}
+	if a.store.TabPinned() {
+		return a.pinEnv(next)
+	}
+	return a.switchEnv(next)
+}
+
+func (a App) handlePinEnv(msg msgs.PinEnvMsg) (tea.Model, tea.Cmd) {
+	if a.store.ActiveRequest() == nil {
+		cmd := a.toast.Show("No open tab to pin", true, 2*time.Second)
+		return a, cmd
+	}
+	if msg.Unpin {
+		a.store.PinEnv("", nil)
+		a.syncTabs()
+		cmd := a.toast.Show("Tab follows environment "+a.store.ActiveEnv, false, 2*time.Second)
+		return a, cmd
+	}
+	if a.envFile == nil || len(a.envFile.Environments) == 0 {
+		cmd := a.toast.Show("No environments found", true, 2*time.Second)
+		return a, cmd
+	}
+	if msg.Name == "" {
+		a.commandPalette.OpenPinEnvPicker(a.envFile.Names())
+		a.mode = msgs.ModeCommandPalette
+		return a, nil
+	}
+	return a.pinEnv(msg.Name)
+}
+
+// pinEnv pins the active tab to name, leaving other tabs on their own
+// environments.
+func (a App) pinEnv(name string) (tea.Model, tea.Cmd) {
+	a.store.PinEnv(name, a.envFile.GetVariables(name))
+	a.secrets.Remember(a.envFile.SecretValues(name)...)
+	a.syncTabs()
+	cmd := a.toast.Show("Tab environment: "+name, false, 2*time.Second)
+	return a, cmd
+}
@@ -1,16 +1,45 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	oauth2auth "github.com/sadopc/gottp/internal/auth/oauth2"
 	"github.com/sadopc/gottp/internal/config"
 	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/environment"
+	"github.com/sadopc/gottp/internal/core/history"
+	"github.com/sadopc/gottp/internal/core/httpcache"
+	"github.com/sadopc/gottp/internal/protocol"
+	"github.com/sadopc/gottp/internal/protocol/graphql"
+	wsclient "github.com/sadopc/gottp/internal/protocol/websocket"
+	"github.com/sadopc/gottp/internal/runner"
 	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/panels/response"
 )
 
+// TestMain points the data directory at a temporary home, so tests
+// neither read the developer's scratch tabs nor write to their history.
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "gottp-app-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", home)
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
 // testApp creates a minimal App for testing without side effects
 // (no history DB, no env file loading).
 func testApp() App {
@@ -788,6 +817,295 @@ func TestRequestSelectedMsg_UnknownID(t *testing.T) {
 	}
 }
 
+func TestScriptDiagnostics(t *testing.T) {
+	broken := collection.NewRequest("Login", "POST", "https://api.example.com/login")
+	broken.PostScript = "gottp.setEnvVar(\"token\", "
+	col := &collection.Collection{Name: "Test", Items: []collection.Item{
+		{Request: collection.NewRequest("Get Users", "GET", "https://api.example.com/users")},
+		{Request: broken},
+	}}
+	a := New(col, "/tmp/test.gottp.yaml", config.DefaultConfig())
+	m, _ := a.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	a = m.(App)
+
+	if !strings.Contains(a.View(), "1 script has a syntax error") {
+		t.Error("loading should report the broken script")
+	}
+	if a.sidebar.Diagnostic(broken) == "" || a.sidebar.Diagnostic(col.Items[0].Request) != "" {
+		t.Error("only the broken request should be marked")
+	}
+	if !strings.Contains(a.sidebar.View(), "Login ⚠") {
+		t.Error("the sidebar should mark the request")
+	}
+
+	m, _ = a.Update(msgs.RequestSelectedMsg{RequestID: broken.ID})
+	a = m.(App)
+	if !strings.Contains(a.View(), "Script error: post_script") {
+		t.Error("opening the request should show the error")
+	}
+}
+
+func TestCollectionFileChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.gottp.yaml")
+	users := collection.NewRequest("Get Users", "GET", "https://api.example.com/users")
+	login := collection.NewRequest("Login", "POST", "https://api.example.com/login")
+	if err := collection.SaveToFile(&collection.Collection{Name: "API", Items: []collection.Item{
+		{Request: users}, {Request: login},
+	}}, path); err != nil {
+		t.Fatal(err)
+	}
+	// rewrite edits the file as another program would
+	rewrite := func(edit func(*collection.Collection)) {
+		t.Helper()
+		col, err := collection.LoadFromFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		edit(col)
+		if err := collection.SaveToFile(col, path); err != nil {
+			t.Fatal(err)
+		}
+		later := time.Now().Add(time.Minute)
+		os.Chtimes(path, later, later)
+	}
+
+	col, _ := collection.LoadFromFile(path)
+	a := New(col, path, config.DefaultConfig())
+	if a.Init() == nil {
+		t.Fatal("Init should start watching the collection file")
+	}
+	m, _ := a.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	a = m.(App)
+	m, _ = a.Update(msgs.RequestSelectedMsg{RequestID: login.ID})
+	a = m.(App)
+	m, _ = a.Update(msgs.RequestSelectedMsg{RequestID: users.ID})
+	a = m.(App)
+
+	// Unchanged file: nothing to do
+	m, cmd := a.Update(msgs.CollectionFileChangedMsg{})
+	a = m.(App)
+	if cmd == nil || a.store.Collection != col {
+		t.Fatal("an unchanged file should not reload, but keep watching")
+	}
+
+	// No unsaved edits: reload straight away, closing the removed request
+	rewrite(func(c *collection.Collection) {
+		c.Items[0].Request.URL = "https://api.example.com/v2/users"
+		c.Items = c.Items[:1]
+	})
+	m, _ = a.Update(msgs.CollectionFileChangedMsg{})
+	a = m.(App)
+	if a.store.Collection == col {
+		t.Fatal("the changed file should be reloaded")
+	}
+	if got := a.store.ActiveRequest(); got == nil || got.URL != "https://api.example.com/v2/users" {
+		t.Errorf("the open tab should show the reloaded request, got %+v", got)
+	}
+	for _, tab := range a.store.Tabs {
+		if tab.Request.ID == login.ID {
+			t.Error("the removed request's tab should be closed")
+		}
+	}
+
+	if a.unsavedChanges() {
+		t.Error("opening a request is not an unsaved change")
+	}
+	a.editor.SetBody(`{"name": "draft"}`)
+	if !a.unsavedChanges() {
+		t.Error("edits still in the editor are unsaved changes")
+	}
+	a.loadActiveRequest()
+
+	// Unsaved edits: ask before discarding them
+	a.store.Collection.Variables = map[string]string{"token": "unsaved"}
+	rewrite(func(c *collection.Collection) { c.Name = "API v2" })
+	m, _ = a.Update(msgs.CollectionFileChangedMsg{})
+	a = m.(App)
+	if a.mode != msgs.ModeModal || !strings.Contains(a.View(), "Collection changed on disk") {
+		t.Fatal("unsaved edits should prompt before reloading")
+	}
+	if a.store.Collection.Name != "API" {
+		t.Error("the collection should not change until the prompt is answered")
+	}
+	m, _ = a.Update(msgs.ReloadCollectionMsg{})
+	a = m.(App)
+	if a.store.Collection.Name != "API v2" || a.store.Collection.Variables["token"] != "" {
+		t.Errorf("confirming should reload from disk, got %q %v", a.store.Collection.Name, a.store.Collection.Variables)
+	}
+}
+
+func TestWatchCollection(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "api.gottp")
+	users := collection.NewRequest("Get Users", "GET", "https://api.example.com/users")
+	col := &collection.Collection{Name: "API", Items: []collection.Item{
+		{Folder: &collection.Folder{Name: "Users", Items: []collection.Item{{Request: users}}}},
+	}}
+	if err := collection.SaveSplit(col, dir); err != nil {
+		t.Fatal(err)
+	}
+	a := New(col, dir, config.DefaultConfig())
+	// watch runs the watch and returns what it sent, once arm has run
+	watch := func(arm func()) chan tea.Msg {
+		t.Helper()
+		got := make(chan tea.Msg, 1)
+		cmd := a.watchCollection()
+		go func() { got <- cmd() }()
+		time.Sleep(100 * time.Millisecond)
+		arm()
+		return got
+	}
+	wait := func(got chan tea.Msg) tea.Msg {
+		t.Helper()
+		select {
+		case msg := <-got:
+			return msg
+		case <-time.After(5 * time.Second):
+			t.Fatal("the watch did not return")
+			return nil
+		}
+	}
+
+	// A file added to a folder of a split collection is noticed
+	added := filepath.Join(dir, "users", "create-user.yaml")
+	got := watch(func() {
+		os.WriteFile(added, []byte("name: Create User\nmethod: POST\nurl: https://api.example.com/users\n"), 0644)
+	})
+	if _, ok := wait(got).(msgs.CollectionFileChangedMsg); !ok {
+		t.Error("adding a request file should report a change")
+	}
+
+	// Changes made before the watch starts are not missed
+	a.collectionStamp = statFile(dir)
+	os.Remove(added)
+	if _, ok := wait(watch(func() {})).(msgs.CollectionFileChangedMsg); !ok {
+		t.Error("a change made between watches should be reported")
+	}
+
+	// Shutting down ends the watch
+	a.collectionStamp = statFile(dir)
+	got = watch(func() {
+		if err := a.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+	if msg := wait(got); msg != nil {
+		t.Errorf("the watch should end on shutdown, got %T", msg)
+	}
+}
+
+func TestUnsavedChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.gottp.yaml")
+	users := collection.NewRequest("Get Users", "GET", "https://api.example.com/users")
+	login := collection.NewRequest("Login", "POST", "https://api.example.com/login")
+	col := &collection.Collection{Name: "API", Items: []collection.Item{{Request: users}, {Request: login}}}
+	if err := collection.SaveToFile(col, path); err != nil {
+		t.Fatal(err)
+	}
+	a := New(col, path, config.DefaultConfig())
+	m, _ := a.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	a = m.(App)
+	update := func(msg tea.Msg) tea.Cmd {
+		t.Helper()
+		m, cmd := a.Update(msg)
+		a = m.(App)
+		return cmd
+	}
+
+	update(msgs.RequestSelectedMsg{RequestID: login.ID})
+	if cmd := a.handleGlobalKey(tea.KeyMsg{Type: tea.KeyCtrlC}); cmd == nil {
+		t.Fatal("quit should not be blocked")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("opening a request is not an unsaved change")
+	}
+
+	// Switching tabs keeps the edits and marks the tab
+	a.editor.SetBody(`{"user": "draft"}`)
+	update(msgs.RequestSelectedMsg{RequestID: users.ID})
+	if !strings.Contains(a.tabBar.View(), "Login ●") {
+		t.Errorf("the edited tab should be marked:\n%s", a.tabBar.View())
+	}
+	update(msgs.RequestSelectedMsg{RequestID: login.ID})
+	if got := a.editor.GetBodyContent(); got != `{"user": "draft"}` {
+		t.Fatalf("edits lost when switching tabs, body is %q", got)
+	}
+
+	// Closing a modified tab asks first; discarding restores the saved request
+	update(msgs.CloseTabMsg{})
+	if a.mode != msgs.ModeModal || !strings.Contains(a.View(), "Unsaved changes") {
+		t.Fatal("closing a modified tab should ask first")
+	}
+	if a.store.ActiveRequest() != login {
+		t.Fatal("the tab should stay open until the prompt is answered")
+	}
+	update(msgs.SetModeMsg{Mode: msgs.ModeNormal})
+	update(msgs.CloseTabMsg{Discard: true})
+	if a.store.ActiveRequest() == login || login.Body != nil {
+		t.Errorf("the tab should close and its edits be dropped, body %+v", login.Body)
+	}
+
+	// Quitting with unsaved changes offers to save them
+	update(msgs.RequestSelectedMsg{RequestID: users.ID})
+	a.editor.SetBody(`{"page": 2}`)
+	cmd := a.handleGlobalKey(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if _, ok := cmd().(msgs.QuitMsg); !ok {
+		t.Fatal("quitting with unsaved changes should ask first")
+	}
+	update(msgs.QuitMsg{})
+	if !strings.Contains(a.View(), "1 open request has unsaved changes") {
+		t.Fatalf("expected the quit prompt:\n%s", a.View())
+	}
+	update(msgs.SetModeMsg{Mode: msgs.ModeNormal})
+	cmd = update(msgs.SaveAllMsg{Quit: true})
+	if cmd == nil {
+		t.Fatal("save all should quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("save all should quit once saved")
+	}
+	saved, _ := collection.LoadFromFile(path)
+	if body := saved.Items[0].Request.Body; body == nil || body.Content != `{"page": 2}` {
+		t.Errorf("edits not saved: %+v", body)
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	a := testAppResized()
+	req := a.store.Collection.Items[1].Request
+	m, _ := a.Update(msgs.RequestSelectedMsg{RequestID: req.ID})
+	a = m.(App)
+
+	a.editor.SetBody(`{"name": "a"}`)
+	a.recordEdit()
+	a.editor.SetBody(`{"name": "ab"}`)
+	a.recordEdit()
+
+	press := func(r rune) string {
+		t.Helper()
+		m, _ := a.Update(keyMsg(r))
+		a = m.(App)
+		return a.editor.GetBodyContent()
+	}
+	if got := press('u'); got != `{"name": "a"}` {
+		t.Errorf("undo: body %q", got)
+	}
+	if got := press('u'); got != "" {
+		t.Errorf("second undo should restore the loaded request, body %q", got)
+	}
+	if got := press('u'); got != "" || !strings.Contains(a.View(), "Nothing to undo") {
+		t.Errorf("undo past the start: body %q", got)
+	}
+	if got := press('U'); got != `{"name": "a"}` {
+		t.Errorf("redo: body %q", got)
+	}
+
+	// Each tab keeps its own history
+	m, _ = a.Update(msgs.NewRequestMsg{})
+	a = m.(App)
+	if press('u'); !strings.Contains(a.View(), "Nothing to undo") {
+		t.Error("a new tab has nothing to undo")
+	}
+}
+
 func TestClearBaselineMsg(t *testing.T) {
 	a := testAppResized()
 
@@ -844,6 +1162,87 @@ func TestWSMessageReceivedMsg(t *testing.T) {
 	_ = m.(App) // no panic expected
 }
 
+func TestWSSendMsg_NotConnected(t *testing.T) {
+	a := testAppResized()
+
+	_, cmd := a.Update(msgs.WSSendMsg{Content: "hi"})
+	if cmd == nil {
+		t.Error("expected toast cmd when sending without a session")
+	}
+}
+
+func TestWSEventMsg_AssertionRecorded(t *testing.T) {
+	a := testAppResized()
+	events := make(chan wsclient.Event)
+	close(events)
+	a.ws = &wsSession{events: events, cancel: func() {}}
+
+	m, cmd := a.Update(msgs.WSEventMsg{Kind: msgs.WSEventAssertion, Name: "ack", Err: errTest})
+	app := m.(App)
+	if len(app.ws.tests) != 1 || app.ws.tests[0].Passed {
+		t.Fatalf("expected one failed assertion, got %+v", app.ws.tests)
+	}
+	if app.ws.tests[0].Name != "ws expect: ack" {
+		t.Errorf("unexpected name %q", app.ws.tests[0].Name)
+	}
+	if cmd == nil {
+		t.Error("expected listen + toast cmd")
+	}
+}
+
+func TestListenWS_BatchesEvents(t *testing.T) {
+	events := make(chan wsclient.Event, 3)
+	for _, content := range []string{"a", "b"} {
+		events <- wsclient.Event{Kind: wsclient.EventReceived, Content: content}
+	}
+	events <- wsclient.Event{Kind: wsclient.EventAssertion, Name: "ack", Passed: true}
+	close(events)
+
+	batch, ok := listenWS(events)().(msgs.WSEventBatchMsg)
+	if !ok || len(batch.Events) != 3 {
+		t.Fatalf("expected one batch of 3 events, got %#v", batch)
+	}
+	if batch.Events[1].Kind != msgs.WSEventReceived || batch.Events[2].Kind != msgs.WSEventAssertion {
+		t.Errorf("unexpected kinds: %+v", batch.Events)
+	}
+	if _, ok := listenWS(events)().(msgs.WSDisconnectedMsg); !ok {
+		t.Error("expected a disconnect after the last batch")
+	}
+}
+
+func TestWSEventBatchMsg_AppliesInOrder(t *testing.T) {
+	a := testAppResized()
+	events := make(chan wsclient.Event)
+	close(events)
+	a.ws = &wsSession{events: events, cancel: func() {}}
+
+	m, cmd := a.Update(msgs.WSEventBatchMsg{Events: []msgs.WSEventMsg{
+		{Kind: msgs.WSEventSent, Content: "ping"},
+		{Kind: msgs.WSEventReceived, Content: "pong"},
+		{Kind: msgs.WSEventAssertion, Name: "pong", Passed: true},
+		{Kind: msgs.WSEventReconnecting, Content: "reconnecting"},
+		{Kind: msgs.WSEventReceived, Content: "hello again"},
+	}})
+	app := m.(App)
+	if n := app.response.WSMessageCount(); n != 4 {
+		t.Errorf("expected 4 log entries, got %d", n)
+	}
+	if len(app.ws.tests) != 1 || !app.ws.tests[0].Passed {
+		t.Errorf("expected one passed assertion, got %+v", app.ws.tests)
+	}
+	if cmd == nil {
+		t.Error("expected a listen cmd for the next batch")
+	}
+}
+
+func TestFreeMemoryMsg(t *testing.T) {
+	a := testAppResized()
+	_, cmd := a.Update(msgs.FreeMemoryMsg{})
+	if cmd == nil {
+		t.Error("expected a toast with memory usage")
+	}
+}
+
 func TestView_NotReady(t *testing.T) {
 	a := testApp()
 	view := a.View()
@@ -1016,6 +1415,28 @@ func TestCtrlEnter_SendsRequest(t *testing.T) {
 	}
 }
 
+func TestVarWarningsFollowUpdates(t *testing.T) {
+	a := testAppResized()
+	req := collection.NewRequest("Get", "GET", "https://{{host}}/users")
+	a.store.Collection.Items = append(a.store.Collection.Items, collection.Item{Request: req})
+	m, _ := a.Update(msgs.RequestSelectedMsg{RequestID: req.ID})
+	a = m.(App)
+	if !strings.Contains(a.View(), "undefined: {{host}}") {
+		t.Fatal("loading a request should flag its undefined variables")
+	}
+
+	// View only renders what Update computed
+	a.store.EnvVars = map[string]string{"host": "api.example.com"}
+	if !strings.Contains(a.View(), "undefined: {{host}}") {
+		t.Error("View should not recompute the warnings")
+	}
+	m, _ = a.Update(msgs.RequestTickMsg{})
+	a = m.(App)
+	if strings.Contains(a.View(), "undefined: {{host}}") {
+		t.Error("a change to the variables should clear the warning")
+	}
+}
+
 func TestSendRequest_EmptyURL(t *testing.T) {
 	a := testAppResized()
 
@@ -1031,6 +1452,43 @@ func TestSendRequest_EmptyURL(t *testing.T) {
 	}
 }
 
+func TestSendRequest_EmptyEnvVarNeedsConfirmation(t *testing.T) {
+	a := testAppResized()
+	a.store.ActiveEnv = "staging"
+	a.store.EnvVars = map[string]string{"base_url": "", "id": "42"}
+	a.editor.LoadRequest(&collection.Request{Name: "Get", Protocol: "http", Method: "GET", URL: "https://{{base_url}}/users/{{id}}"})
+	a.refreshVarWarnings()
+
+	if !strings.Contains(a.View(), "empty in staging: {{base_url}}") {
+		t.Error("editor should flag the empty variable")
+	}
+
+	m, cmd := a.Update(msgs.SendRequestMsg{})
+	a = m.(App)
+	if cmd != nil || !a.modal.Visible || a.mode != msgs.ModeModal {
+		t.Fatal("sending with an empty variable should ask for confirmation")
+	}
+	m, cmd = a.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	a = m.(App)
+	if cmd == nil {
+		t.Fatal("confirming should resend")
+	}
+	var confirm msgs.SendRequestMsg
+	for _, c := range cmd().(tea.BatchMsg) {
+		if msg, ok := c().(msgs.SendRequestMsg); ok {
+			confirm = msg
+		}
+	}
+	if !confirm.Confirmed {
+		t.Fatalf("expected a confirmed SendRequestMsg, got %#v", confirm)
+	}
+	m, cmd = a.Update(confirm)
+	a = m.(App)
+	if cmd == nil || a.modal.Visible {
+		t.Error("a confirmed send should go ahead")
+	}
+}
+
 func TestRequestSentMsg_Error(t *testing.T) {
 	a := testAppResized()
 
@@ -1080,12 +1538,61 @@ func TestScriptResultMsg(t *testing.T) {
 func TestGRPCReflectMsg(t *testing.T) {
 	a := testAppResized()
 
+	// Reflection needs a gRPC request
 	m, cmd := a.Update(msgs.GRPCReflectMsg{})
-	_ = m.(App)
+	a = m.(App)
+	if cmd == nil || !a.toast.Visible {
+		t.Error("expected a toast for a non-gRPC request")
+	}
 
-	// Currently returns a toast with "not yet implemented"
-	if cmd == nil {
-		t.Error("expected non-nil cmd for GRPCReflectMsg")
+	m, _ = a.Update(msgs.SwitchProtocolMsg{Protocol: "grpc"})
+	a = m.(App)
+	req := collection.NewRequest("Greet", "POST", "localhost:50051")
+	req.Protocol = "grpc"
+	a.editor.GRPCFormRef().LoadRequest(req)
+	if _, cmd = a.Update(msgs.GRPCReflectMsg{}); cmd == nil {
+		t.Error("expected a reflection command")
+	}
+}
+
+func TestGRPCReflectionResult_FillsForm(t *testing.T) {
+	a := testAppResized()
+	m, _ := a.Update(msgs.SwitchProtocolMsg{Protocol: "grpc"})
+	a = m.(App)
+
+	services := []msgs.GRPCServiceInfo{{Name: "greet.Greeter", Methods: []msgs.GRPCMethodInfo{{
+		Name: "SayHello", FullName: "greet.Greeter.SayHello",
+		InputType: "greet.HelloRequest", InputTemplate: "{\n  \"name\": \"\"\n}",
+	}}}}
+	m, _ = a.Update(msgs.GRPCReflectionResultMsg{Services: services})
+	a = m.(App)
+
+	req := a.editor.BuildRequest()
+	if req.GRPCService != "greet.Greeter" || req.GRPCMethod != "SayHello" || string(req.Body) != "{\n  \"name\": \"\"\n}" {
+		t.Errorf("expected the first method and its skeleton, got %s/%s %q", req.GRPCService, req.GRPCMethod, req.Body)
+	}
+}
+
+func TestIntrospectionResultStoresSchema(t *testing.T) {
+	a := testAppResized()
+	m, _ := a.Update(msgs.SwitchProtocolMsg{Protocol: "graphql"})
+	a = m.(App)
+	a.editor.GQLForm().FocusURL()
+	a.editor.GQLForm().SetBody("{ nope }")
+
+	schema, err := graphql.ParseSDL("type Query { ok: Boolean }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := a.editor.BuildRequest().URL
+	m, _ = a.Update(msgs.IntrospectionResultMsg{Endpoint: url, Types: []msgs.SchemaType{{Name: "Query"}}, Schema: schema})
+	a = m.(App)
+
+	if a.editor.GQLForm().Schema() != schema {
+		t.Fatal("schema should be stored for the endpoint")
+	}
+	if errs := a.editor.GQLForm().QueryErrors(); len(errs) != 1 {
+		t.Errorf("expected the query to be checked, got %v", errs)
 	}
 }
 
@@ -1210,3 +1717,989 @@ type testError struct{}
 func (e testError) Error() string { return "test error" }
 
 var errTest error = testError{}
+
+func TestScaffoldGraphQLMsg(t *testing.T) {
+	dir := t.TempDir()
+	schema := "type Query { user(id: ID!): User }\ntype User { id: ID! name: String }\n"
+	if err := os.WriteFile(filepath.Join(dir, "schema.graphql"), []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a := testApp()
+	a.store.CollectionPath = filepath.Join(dir, "api.gottp.yaml")
+
+	m, _ := a.Update(msgs.ScaffoldGraphQLMsg{})
+	a = m.(App)
+	if a.mode != msgs.ModeCommandPalette {
+		t.Fatalf("expected operation picker, got mode %v", a.mode)
+	}
+
+	tabs := len(a.store.Tabs)
+	m, _ = a.Update(msgs.ScaffoldGraphQLMsg{Operation: "query user"})
+	a = m.(App)
+	if len(a.store.Tabs) != tabs+1 {
+		t.Fatalf("expected new tab, got %d tabs", len(a.store.Tabs))
+	}
+	req := a.store.ActiveRequest()
+	if req.Protocol != "graphql" || req.GraphQL == nil {
+		t.Fatalf("expected graphql request, got %+v", req)
+	}
+	if !strings.Contains(req.GraphQL.Query, "user(id: $id)") {
+		t.Errorf("unexpected query:\n%s", req.GraphQL.Query)
+	}
+}
+
+func TestOAuth2TokenCacheManagement(t *testing.T) {
+	a := testApp()
+	a.oauthTokens = oauth2auth.NewTokenStore()
+
+	m, _ := a.Update(msgs.ManageOAuth2TokensMsg{})
+	a = m.(App)
+	if a.mode == msgs.ModeCommandPalette {
+		t.Fatal("picker should not open with an empty cache")
+	}
+
+	key := oauth2auth.TokenKey("https://auth.example.com/token", "cli", "")
+	a.oauthTokens.Put(key, &oauth2auth.TokenResponse{AccessToken: "tok", ExpiresIn: 60, ObtainedAt: time.Now()})
+	a.oauthTokens.Put("other", &oauth2auth.TokenResponse{AccessToken: "tok2"})
+
+	m, _ = a.Update(msgs.ManageOAuth2TokensMsg{})
+	a = m.(App)
+	if a.mode != msgs.ModeCommandPalette {
+		t.Fatalf("expected token picker, got mode %v", a.mode)
+	}
+
+	m, _ = a.Update(msgs.InvalidateOAuth2TokenMsg{Key: key})
+	a = m.(App)
+	if _, ok := a.oauthTokens.Get(key); ok {
+		t.Error("token should be invalidated")
+	}
+	m, _ = a.Update(msgs.InvalidateOAuth2TokenMsg{All: true})
+	a = m.(App)
+	if len(a.oauthTokens.Entries()) != 0 {
+		t.Error("expected all tokens invalidated")
+	}
+}
+
+func TestOAuth2TokenRetriesRequestItWasObtainedFor(t *testing.T) {
+	a := testApp()
+	a.oauthTokens = oauth2auth.NewTokenStore()
+	first := collection.NewRequest("Needs token", "GET", "https://api.example.com/me")
+	first.Auth = &collection.Auth{Type: "oauth2", OAuth2: &collection.OAuth2Auth{
+		GrantType: "client_credentials", TokenURL: "https://auth.example.com/token", ClientID: "cli",
+	}}
+	second := collection.NewRequest("Other", "GET", "https://example.com/")
+	a.store.OpenRequest(first)
+	a.store.OpenRequest(second)
+	a.loadActiveRequest()
+
+	// The user switched tabs while the token was being obtained
+	key := oauth2auth.TokenKey("https://auth.example.com/token", "cli", "")
+	m, cmd := a.Update(msgs.OAuth2TokenMsg{AccessToken: "tok", ExpiresIn: 60, Key: key, RequestID: first.ID})
+	a = m.(App)
+	if token, ok := a.oauthTokens.Get(key); !ok || token.AccessToken != "tok" {
+		t.Fatalf("token not cached under the sending request's key: %+v", a.oauthTokens.Entries())
+	}
+	if a.store.ActiveRequest() != first || cmd == nil {
+		t.Errorf("expected the request the token was obtained for to be resent, active %q", a.store.ActiveRequest().Name)
+	}
+
+	// A token for a closed tab is kept without resending anything else
+	m, _ = a.Update(msgs.OAuth2TokenMsg{AccessToken: "tok2", Key: "other", RequestID: "closed"})
+	a = m.(App)
+	if _, ok := a.oauthTokens.Get("other"); !ok || a.store.ActiveRequest() != first {
+		t.Error("token for a closed tab should be cached and the active tab kept")
+	}
+}
+
+func TestRequestTick(t *testing.T) {
+	a := testApp()
+	a.sendSeq = 2
+	a.response.SetLoading(true)
+	a.statusBar.StartLoading(time.Now())
+
+	m, cmd := a.Update(msgs.RequestTickMsg{Seq: 2, Time: time.Now()})
+	a = m.(App)
+	if cmd == nil || !a.statusBar.Loading() {
+		t.Fatal("ticks should continue while the request is in flight")
+	}
+
+	// A tick from an earlier send ends its chain but leaves the current one
+	m, cmd = a.Update(msgs.RequestTickMsg{Seq: 1, Time: time.Now()})
+	a = m.(App)
+	if cmd != nil || !a.statusBar.Loading() {
+		t.Fatal("stale tick should only stop itself")
+	}
+
+	a.response.SetLoading(false)
+	m, cmd = a.Update(msgs.RequestTickMsg{Seq: 2, Time: time.Now()})
+	a = m.(App)
+	if cmd != nil || a.statusBar.Loading() {
+		t.Fatal("ticks should stop once the request is done")
+	}
+}
+
+func TestResponseCacheManagement(t *testing.T) {
+	a := testApp()
+	a.respCache = httpcache.New()
+
+	m, _ := a.Update(msgs.ManageResponseCacheMsg{})
+	a = m.(App)
+	if a.mode == msgs.ModeCommandPalette {
+		t.Fatal("picker should not open with an empty cache")
+	}
+
+	etag := http.Header{"Etag": {`"v1"`}}
+	a.respCache.Store("GET", "https://api.example.com/a", nil, 200, "200 OK", etag, []byte("a"))
+	a.respCache.Store("GET", "https://api.example.com/b", nil, 200, "200 OK", etag, []byte("b"))
+
+	m, _ = a.Update(msgs.ManageResponseCacheMsg{})
+	a = m.(App)
+	if a.mode != msgs.ModeCommandPalette {
+		t.Fatalf("expected cache picker, got mode %v", a.mode)
+	}
+
+	m, _ = a.Update(msgs.ForgetCachedResponseMsg{Key: httpcache.Key("GET", "https://api.example.com/a")})
+	a = m.(App)
+	if entries := a.respCache.Entries(); len(entries) != 1 || entries[0].URL != "https://api.example.com/b" {
+		t.Errorf("entries after forget = %+v", entries)
+	}
+	m, _ = a.Update(msgs.ForgetCachedResponseMsg{All: true})
+	a = m.(App)
+	if len(a.respCache.Entries()) != 0 {
+		t.Error("expected the cache cleared")
+	}
+}
+
+func TestSessionRecording(t *testing.T) {
+	a := testApp()
+	m, _ := a.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	a = m.(App)
+	path := filepath.Join(t.TempDir(), "session.har")
+	if err := a.StartRecording(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is written by a command, not in Update
+	req := &protocol.Request{Method: "GET", URL: "https://api.example.com/a"}
+	m, cmd := a.Update(msgs.RequestSentMsg{StatusCode: 200, Status: "200 OK", Request: req, Started: time.Now()})
+	a = m.(App)
+	if cmd == nil || a.recorder.Len() != 0 {
+		t.Fatalf("Update should leave the write to a command, recorded %d", a.recorder.Len())
+	}
+	if msg := a.recordExchange(msgs.RequestSentMsg{StatusCode: 200, Request: req, Started: time.Now()}, &protocol.Response{StatusCode: 200})(); msg != nil {
+		t.Fatalf("record = %#v", msg)
+	}
+	if msg := a.recordExchange(msgs.RequestSentMsg{Err: errors.New("timeout"), Request: req, Started: time.Now()}, nil)(); msg != nil {
+		t.Fatalf("record = %#v", msg)
+	}
+	if n := a.recorder.Len(); n != 2 {
+		t.Fatalf("recorded %d exchanges, want 2", n)
+	}
+	if !strings.Contains(a.View(), "REC 2") {
+		t.Error("status bar should show the recording")
+	}
+
+	m, _ = a.Update(msgs.ToggleRecordingMsg{})
+	a = m.(App)
+	if a.recorder != nil {
+		t.Fatal("toggle should stop the recording")
+	}
+	m, _ = a.Update(msgs.RequestSentMsg{StatusCode: 200, Request: req, Started: time.Now()})
+	a = m.(App)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), `"startedDateTime"`); n != 2 {
+		t.Errorf("file has %d entries after stopping, want 2", n)
+	}
+}
+
+func TestMaskResponse(t *testing.T) {
+	a := testApp()
+	a.secrets.Remember("s3cr3t-token")
+	resp := &protocol.Response{
+		StatusCode: 200,
+		Headers:    http.Header{"X-Echo-Auth": {"Bearer s3cr3t-token"}},
+		Trailers:   http.Header{"Grpc-Message": {"bad token s3cr3t-token"}},
+		RawRequest: "GET / HTTP/1.1\r\nAuthorization: Bearer s3cr3t-token\r\n\r\n",
+		Interim:    []protocol.InterimResponse{{StatusCode: 103, Headers: http.Header{"Link": {"</s3cr3t-token>"}}}},
+	}
+
+	shown := a.maskResponse(resp)
+	for _, text := range []string{shown.Headers.Get("X-Echo-Auth"), shown.Trailers.Get("Grpc-Message"), shown.RawRequest, shown.Interim[0].Headers.Get("Link")} {
+		if strings.Contains(text, "s3cr3t-token") {
+			t.Errorf("secret shown: %q", text)
+		}
+	}
+	if resp.Headers.Get("X-Echo-Auth") != "Bearer s3cr3t-token" {
+		t.Error("maskResponse must not change the response")
+	}
+}
+
+func TestSessionRecording_WriteFailureStops(t *testing.T) {
+	a := testApp()
+	path := filepath.Join(t.TempDir(), "session.har")
+	if err := a.StartRecording(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failure from an older recording leaves this one running
+	m, _ := a.Update(msgs.RecordingFailedMsg{Path: "old.har", Err: errors.New("disk full")})
+	a = m.(App)
+	if a.recorder == nil {
+		t.Fatal("recording should continue")
+	}
+	m, cmd := a.Update(msgs.RecordingFailedMsg{Path: path, Err: errors.New("disk full")})
+	a = m.(App)
+	if a.recorder != nil || cmd == nil {
+		t.Error("a failed write should stop the recording with a toast")
+	}
+}
+
+func TestScratchTabs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	a := testAppResized()
+	m, _ := a.Update(msgs.NewRequestMsg{})
+	a = m.(App)
+	a.editor.LoadRequest(collection.NewRequest("Ad hoc", "POST", "https://api.example.com/echo"))
+	m, _ = a.Update(msgs.NewRequestMsg{}) // left blank, so not kept
+	a = m.(App)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := a.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	a = testAppResized()
+	if len(a.store.Tabs) != 1 {
+		t.Fatalf("restored %d tabs, want 1", len(a.store.Tabs))
+	}
+	req := a.store.ActiveRequest()
+	if req.URL != "https://api.example.com/echo" || a.editor.BuildRequest().URL != req.URL {
+		t.Fatalf("restored tab = %+v", req)
+	}
+
+	m, _ = a.Update(msgs.SaveToCollectionMsg{})
+	a = m.(App)
+	if a.mode != msgs.ModeCommandPalette {
+		t.Fatalf("expected folder picker, got mode %v", a.mode)
+	}
+	a.mode = msgs.ModeNormal
+	a.store.CollectionPath = filepath.Join(t.TempDir(), "api.gottp.yaml")
+	m, _ = a.Update(msgs.SaveToCollectionMsg{Picked: true, Name: "Echo"})
+	a = m.(App)
+	if !a.inCollection(req) || req.Name != "Echo" {
+		t.Fatalf("request not saved to the collection: %+v", req)
+	}
+	if _, err := os.Stat(filepath.Join(os.Getenv("HOME"), ".local", "share", "gottp", "scratch.yaml")); !os.IsNotExist(err) {
+		t.Errorf("scratch file should be gone once its request is saved, got %v", err)
+	}
+}
+
+func TestSessionRestore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	colPath := filepath.Join(dir, "api.gottp.yaml")
+	col := &collection.Collection{Name: "API", Items: []collection.Item{
+		{Request: collection.NewRequest("List", "GET", "https://api.example.com/items")},
+		{Request: collection.NewRequest("Create", "POST", "https://api.example.com/items")},
+	}}
+	if err := collection.SaveToFile(col, colPath); err != nil {
+		t.Fatal(err)
+	}
+	envs := "environments:\n  - name: dev\n    variables: {}\n  - name: prod\n    variables: {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "environments.yaml"), []byte(envs), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	open := func(cfg config.Config) App {
+		loaded, err := collection.LoadFromFile(colPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, _ := New(loaded, colPath, cfg).Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+		return m.(App)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	a := open(config.DefaultConfig())
+	for _, item := range a.store.Collection.Items {
+		a.store.OpenRequest(item.Request)
+	}
+	a.store.ActiveTab = 1 // List, after the blank tab
+	m, _ := a.Update(msgs.SwitchEnvMsg{Name: "prod"})
+	a = m.(App)
+	a.sidebarVisible = false
+	a.focus = msgs.FocusResponse
+	if err := a.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	a = open(config.DefaultConfig())
+	if len(a.store.Tabs) != 2 || a.store.Tabs[0].Request.Name != "List" || a.store.Tabs[1].Request.Name != "Create" {
+		t.Fatalf("restored tabs = %+v", a.store.Tabs)
+	}
+	if a.store.ActiveTab != 0 || a.editor.BuildRequest().Method != "GET" {
+		t.Errorf("active tab = %d", a.store.ActiveTab)
+	}
+	if a.store.ActiveEnv != "prod" || a.sidebarVisible || a.focus != msgs.FocusResponse {
+		t.Errorf("env %q, sidebar %v, focus %v", a.store.ActiveEnv, a.sidebarVisible, a.focus)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.RestoreSession = false
+	a = open(cfg)
+	if len(a.store.Tabs) != 1 || a.store.ActiveRequest().Name != "New Request" || !a.sidebarVisible {
+		t.Errorf("with restore_session off, tabs = %+v", a.store.Tabs)
+	}
+}
+
+func TestPinResponse(t *testing.T) {
+	a := testAppResized()
+	m, _ := a.Update(msgs.PinResponseMsg{})
+	a = m.(App)
+	if a.response.Pinned() {
+		t.Fatal("nothing to pin before a response")
+	}
+
+	m, _ = a.Update(msgs.RequestSentMsg{StatusCode: 200, Status: "200 OK", Body: []byte("v1"), ContentType: "text/plain"})
+	a = m.(App)
+	m, _ = a.Update(msgs.PinResponseMsg{})
+	a = m.(App)
+	if !a.response.Pinned() {
+		t.Fatal("expected the response pinned")
+	}
+	m, _ = a.Update(msgs.PinResponseMsg{Unpin: true})
+	a = m.(App)
+	if a.response.Pinned() {
+		t.Error("expected the response unpinned")
+	}
+}
+
+func TestTokenStatus(t *testing.T) {
+	if got := tokenStatus(&oauth2auth.TokenResponse{}); got != "no expiry" {
+		t.Errorf("got %q", got)
+	}
+	expired := &oauth2auth.TokenResponse{ExpiresIn: 1, ObtainedAt: time.Now().Add(-time.Hour), RefreshToken: "r"}
+	if got := tokenStatus(expired); got != "expired, refreshable" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFindReplace_AppliesAndSaves(t *testing.T) {
+	a := testAppResized()
+	a.store.CollectionPath = filepath.Join(t.TempDir(), "api.gottp.yaml")
+	a.store.OpenRequest(a.store.Collection.Items[0].Request)
+	a.loadActiveRequest()
+
+	m, _ := a.Update(msgs.FindReplaceMsg{})
+	a = m.(App)
+	if !a.findReplace.Visible || a.mode != msgs.ModeModal {
+		t.Fatal("expected find and replace overlay")
+	}
+	for _, r := range "api.example.com" {
+		m, _ = a.Update(keyMsg(r))
+		a = m.(App)
+	}
+	if n := len(a.findReplace.Matches()); n != 2 {
+		t.Fatalf("expected 2 matches, got %d", n)
+	}
+	if !strings.Contains(a.View(), "Find and Replace") {
+		t.Error("overlay should be rendered")
+	}
+
+	matches, err := collection.FindMatches(a.store.Collection, "api.example.com", "api.test", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ = a.Update(msgs.ApplyReplaceMsg{Matches: matches[:1]})
+	a = m.(App)
+
+	if got := a.editor.BuildRequest().URL; got != "https://api.test/users" {
+		t.Errorf("editor should show the replaced URL, got %q", got)
+	}
+	saved, err := collection.LoadFromFile(a.store.CollectionPath)
+	if err != nil {
+		t.Fatalf("collection should be saved: %v", err)
+	}
+	if saved.Items[0].Request.URL != "https://api.test/users" || saved.Items[1].Request.URL != "https://api.example.com/users" {
+		t.Errorf("unexpected saved URLs: %q, %q", saved.Items[0].Request.URL, saved.Items[1].Request.URL)
+	}
+}
+
+func TestDuplicates_MergeClosesTabsAndSaves(t *testing.T) {
+	a := testAppResized()
+	a.store.CollectionPath = filepath.Join(t.TempDir(), "api.gottp.yaml")
+
+	m, _ := a.Update(msgs.FindDuplicatesMsg{})
+	a = m.(App)
+	if a.duplicates.Visible {
+		t.Fatal("overlay should not open without duplicates")
+	}
+
+	copyReq := collection.NewRequest("Get Users copy", "GET", "https://api.example.com/users")
+	copyReq.PostScript = `gottp.test("ok", () => true)`
+	a.store.Collection.Items = append(a.store.Collection.Items, collection.Item{Request: copyReq})
+	removed := a.store.Collection.Items[0].Request
+	a.store.OpenRequest(removed)
+	a.store.OpenRequest(a.store.Collection.Items[1].Request)
+	a.loadActiveRequest()
+
+	m, _ = a.Update(msgs.FindDuplicatesMsg{})
+	a = m.(App)
+	if !a.duplicates.Visible || a.mode != msgs.ModeModal || len(a.duplicates.Groups()) != 1 {
+		t.Fatal("expected the duplicates overlay with one group")
+	}
+	if !strings.Contains(a.View(), "Duplicate Requests") {
+		t.Error("overlay should be rendered")
+	}
+
+	// Keep the copy, which has the script
+	m, _ = a.Update(keyMsg('j'))
+	a = m.(App)
+	m, _ = a.Update(keyMsg(' '))
+	a = m.(App)
+	m, cmd := a.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	a = m.(App)
+	m, _ = a.Update(cmd())
+	a = m.(App)
+
+	if a.duplicates.Visible || a.mode != msgs.ModeNormal {
+		t.Error("overlay should close once no duplicates remain")
+	}
+	for _, tab := range a.store.Tabs {
+		if tab.Request == removed {
+			t.Error("the removed request's tab should be closed")
+		}
+	}
+	if a.store.ActiveRequest().Name != "Create User" {
+		t.Errorf("active request = %q", a.store.ActiveRequest().Name)
+	}
+	saved, err := collection.LoadFromFile(a.store.CollectionPath)
+	if err != nil {
+		t.Fatalf("collection should be saved: %v", err)
+	}
+	if len(saved.Items) != 2 || saved.Items[1].Request.Name != "Get Users copy" {
+		t.Errorf("unexpected saved items: %+v", saved.Items)
+	}
+}
+
+func TestSaveCollection_SkipsUnchanged(t *testing.T) {
+	a := testAppResized()
+	a.store.CollectionPath = filepath.Join(t.TempDir(), "api.gottp.yaml")
+
+	m, _ := a.Update(msgs.SaveRequestMsg{})
+	a = m.(App)
+	info, err := os.Stat(a.store.CollectionPath)
+	if err != nil {
+		t.Fatalf("first save should write the file: %v", err)
+	}
+
+	past := info.ModTime().Add(-time.Hour)
+	os.Chtimes(a.store.CollectionPath, past, past)
+	m, _ = a.Update(msgs.SaveRequestMsg{})
+	a = m.(App)
+	if !strings.Contains(a.View(), "No changes to save") {
+		t.Error("expected a no-changes toast")
+	}
+	if info, _ := os.Stat(a.store.CollectionPath); !info.ModTime().Equal(past) {
+		t.Error("unchanged collection should not be rewritten")
+	}
+}
+
+func TestMoveRequest_ReordersAndSaves(t *testing.T) {
+	a := testAppResized()
+	a.store.CollectionPath = filepath.Join(t.TempDir(), "api.gottp.yaml")
+	create := a.store.Collection.Items[1].Request
+
+	m, _ := a.Update(msgs.MoveRequestMsg{RequestID: create.ID, Delta: -1})
+	a = m.(App)
+	if a.store.Collection.Items[0].Request != create {
+		t.Fatal("request should move up")
+	}
+	if a.sidebar.SelectedRequest() != create {
+		t.Error("sidebar cursor should follow the moved request")
+	}
+	saved, err := collection.LoadFromFile(a.store.CollectionPath)
+	if err != nil {
+		t.Fatalf("collection should be saved: %v", err)
+	}
+	if saved.Items[0].Request.Name != "Create User" {
+		t.Errorf("saved order starts with %q", saved.Items[0].Request.Name)
+	}
+}
+
+func TestSidebarItems_AddRenameMoveDelete(t *testing.T) {
+	a := testAppResized()
+	a.store.CollectionPath = filepath.Join(t.TempDir(), "api.gottp.yaml")
+	update := func(msg tea.Msg) {
+		t.Helper()
+		m, _ := a.Update(msg)
+		a = m.(App)
+	}
+
+	update(msgs.AddItemMsg{Folder: true})
+	if !a.prompt.Visible || a.mode != msgs.ModeModal {
+		t.Fatal("adding should ask for a name")
+	}
+	a.prompt.Close()
+	update(msgs.AddItemMsg{Folder: true, Name: "Users"})
+	folder := a.store.Collection.Items[2].Folder
+	if folder == nil || folder.Name != "Users" {
+		t.Fatalf("expected a new top-level folder, got %+v", a.store.Collection.Items)
+	}
+
+	update(msgs.AddItemMsg{At: collection.Item{Folder: folder}, Name: "Delete User"})
+	if len(folder.Items) != 1 || folder.Items[0].Request.Name != "Delete User" {
+		t.Fatalf("request should be added inside the folder, got %+v", folder.Items)
+	}
+	added := folder.Items[0].Request
+	if a.store.ActiveRequest() != added || a.sidebar.SelectedRequest() != added {
+		t.Error("a new request should open in a tab and be selected")
+	}
+
+	update(msgs.RenameItemMsg{Item: collection.Item{Request: added}, Name: "Remove User"})
+	if added.Name != "Remove User" || !strings.Contains(a.tabBar.View(), "Remove User") {
+		t.Errorf("rename: name %q", added.Name)
+	}
+
+	getUsers := a.store.Collection.Items[0].Request
+	update(msgs.MoveItemMsg{Item: collection.Item{Request: getUsers}})
+	if !a.commandPalette.Visible || !strings.Contains(a.commandPalette.View(), "Top level") {
+		t.Fatal("moving should ask for a folder")
+	}
+	a.commandPalette.Close()
+	update(msgs.MoveItemMsg{Item: collection.Item{Request: getUsers}, Into: folder, Picked: true})
+	if len(folder.Items) != 2 || folder.Items[1].Request != getUsers {
+		t.Errorf("request should move into the folder, got %+v", folder.Items)
+	}
+
+	update(msgs.DeleteItemMsg{Item: collection.Item{Folder: folder}})
+	if !a.modal.Visible || !strings.Contains(a.View(), "2 requests") {
+		t.Fatal("deleting should ask first")
+	}
+	a.modal.Visible = false
+	update(msgs.DeleteItemMsg{Item: collection.Item{Folder: folder}, Confirmed: true})
+	if len(a.store.Collection.Items) != 1 {
+		t.Errorf("folder should be deleted, got %+v", a.store.Collection.Items)
+	}
+	for _, tab := range a.store.Tabs {
+		if tab.Request == added {
+			t.Error("tabs of deleted requests should close")
+		}
+	}
+
+	saved, err := collection.LoadFromFile(a.store.CollectionPath)
+	if err != nil {
+		t.Fatalf("collection should be saved: %v", err)
+	}
+	if len(saved.Items) != 1 || saved.Items[0].Request.Name != "Create User" {
+		t.Errorf("saved items %+v", saved.Items)
+	}
+}
+
+func TestPasteItem_ReordersAndSaves(t *testing.T) {
+	a := testAppResized()
+	a.store.CollectionPath = filepath.Join(t.TempDir(), "api.gottp.yaml")
+	getUsers := a.store.Collection.Items[0].Request
+	create := a.store.Collection.Items[1].Request
+	folder := &collection.Folder{Name: "Admin"}
+	a.store.Collection.Items = append(a.store.Collection.Items, collection.Item{Folder: folder})
+
+	m, _ := a.Update(msgs.PasteItemMsg{Item: collection.Item{Request: getUsers}, At: collection.Item{Request: create}})
+	a = m.(App)
+	if a.store.Collection.Items[0].Request != create || a.store.Collection.Items[1].Request != getUsers {
+		t.Fatal("pasting below a request should reorder its folder")
+	}
+	if a.sidebar.SelectedRequest() != getUsers {
+		t.Error("sidebar cursor should follow the pasted request")
+	}
+
+	m, _ = a.Update(msgs.PasteItemMsg{Item: collection.Item{Request: create}, At: collection.Item{Folder: folder}})
+	a = m.(App)
+	if len(folder.Items) != 1 || folder.Items[0].Request != create {
+		t.Fatalf("pasting on a folder should move the request into it, got %+v", folder.Items)
+	}
+
+	m, _ = a.Update(msgs.PasteItemMsg{Item: collection.Item{Folder: folder}, At: collection.Item{Request: create}})
+	a = m.(App)
+	if !strings.Contains(a.View(), "into itself") {
+		t.Error("pasting a folder into itself should be refused")
+	}
+
+	saved, err := collection.LoadFromFile(a.store.CollectionPath)
+	if err != nil {
+		t.Fatalf("collection should be saved: %v", err)
+	}
+	if len(saved.Items) != 2 || saved.Items[0].Request.Name != "Get Users" ||
+		saved.Items[1].Folder == nil || saved.Items[1].Folder.Items[0].Request.Name != "Create User" {
+		t.Errorf("saved items %+v", saved.Items)
+	}
+}
+
+func TestRunFolder_PicksEnvAndShowsResults(t *testing.T) {
+	a := testAppResized()
+	a.store.CollectionPath = ""
+	m, _ := a.Update(msgs.RunFolderMsg{Folder: "Users"})
+	a = m.(App)
+	if a.mode == msgs.ModeCommandPalette {
+		t.Fatal("an unsaved collection can't be run")
+	}
+
+	a.store.CollectionPath = filepath.Join(t.TempDir(), "api.gottp.yaml")
+	a.envFile = &environment.EnvironmentFile{Environments: []environment.Environment{{Name: "Staging"}, {Name: "Prod"}}}
+	m, _ = a.Update(msgs.RunFolderMsg{Folder: "Users"})
+	a = m.(App)
+	if !a.commandPalette.Visible || a.mode != msgs.ModeCommandPalette {
+		t.Fatal("expected the environment picker")
+	}
+	if view := a.commandPalette.View(); !strings.Contains(view, "Staging") {
+		t.Errorf("picker should list environments:\n%s", view)
+	}
+	a.commandPalette.Close()
+
+	results := []runner.Result{
+		{Name: "List", Method: "GET", URL: "https://x/users", StatusCode: 200, TestsPassed: true},
+		{Name: "Create", Method: "POST", URL: "https://x/users", StatusCode: 500, TestsPassed: false},
+	}
+	m, _ = a.Update(msgs.FolderRunResultMsg{Folder: "Users", Env: "Staging", Results: results})
+	a = m.(App)
+	if !a.runResults.Visible || a.mode != msgs.ModeModal {
+		t.Fatal("expected the run results overlay")
+	}
+	view := a.View()
+	for _, want := range []string{"Run: Users (Staging)", "1 of 2 requests failed", "List"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("results missing %q", want)
+		}
+	}
+	m, _ = a.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.(App).runResults.Visible {
+		t.Error("esc should close the results")
+	}
+}
+
+func TestEditorAtLine(t *testing.T) {
+	tests := []struct {
+		editor string
+		line   int
+		want   []string
+	}{
+		{"vim", 12, []string{"vim", "+12", "api.gottp.yaml"}},
+		{"nvim -p", 3, []string{"nvim", "-p", "+3", "api.gottp.yaml"}},
+		{"code --wait", 7, []string{"code", "--wait", "-g", "api.gottp.yaml:7"}},
+		{"/usr/bin/hx", 5, []string{"/usr/bin/hx", "api.gottp.yaml:5"}},
+		{"nano", 0, []string{"nano", "api.gottp.yaml"}},
+	}
+	for _, tt := range tests {
+		got := editorAtLine(tt.editor, "api.gottp.yaml", tt.line).Args
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("editorAtLine(%q, %d) = %v, want %v", tt.editor, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestEditRequestSource_SavesAndReloads(t *testing.T) {
+	a := testAppResized()
+	a.store.CollectionPath = filepath.Join(t.TempDir(), "api.gottp.yaml")
+	second := a.store.Collection.Items[1].Request
+	a.store.OpenRequest(second)
+	a.loadActiveRequest()
+
+	m, cmd := a.Update(msgs.EditRequestSourceMsg{})
+	a = m.(App)
+	if cmd == nil {
+		t.Fatal("expected an editor command")
+	}
+	saved, err := collection.LoadFromFile(a.store.CollectionPath)
+	if err != nil {
+		t.Fatalf("collection should be saved before editing: %v", err)
+	}
+	if saved.Items[1].Request.Line <= saved.Items[0].Request.Line {
+		t.Errorf("expected increasing request lines, got %d and %d", saved.Items[0].Request.Line, saved.Items[1].Request.Line)
+	}
+
+	// Simulate the user editing the file
+	saved.Items[1].Request.URL = "https://api.example.com/people"
+	if err := collection.SaveToFile(saved, a.store.CollectionPath); err != nil {
+		t.Fatal(err)
+	}
+	m, _ = a.Update(msgs.CollectionEditedMsg{})
+	a = m.(App)
+	if got := a.editor.BuildRequest().URL; got != "https://api.example.com/people" {
+		t.Errorf("editor should show the edited URL, got %q", got)
+	}
+	if a.store.ActiveRequest() != a.store.Collection.Items[1].Request {
+		t.Error("active tab should point at the reloaded request")
+	}
+
+	// A broken file keeps the previous collection
+	if err := os.WriteFile(a.store.CollectionPath, []byte("items: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prev := a.store.Collection
+	m, _ = a.Update(msgs.CollectionEditedMsg{})
+	a = m.(App)
+	if a.store.Collection != prev {
+		t.Error("collection should be kept when the edited file does not parse")
+	}
+}
+
+func TestCycleEnvAndPinToTab(t *testing.T) {
+	a := testAppResized()
+	a.envFile = &environment.EnvironmentFile{Environments: []environment.Environment{
+		{Name: "staging", Variables: map[string]environment.Variable{"host": {Value: "staging.example.com"}}},
+		{Name: "production", Variables: map[string]environment.Variable{"host": {Value: "example.com"}}},
+	}}
+	a.store.ActiveEnv = "staging"
+	a.store.EnvVars = a.envFile.GetVariables("staging")
+	a.store.OpenRequest(a.store.Collection.Items[0].Request)
+	a.store.OpenRequest(a.store.Collection.Items[1].Request)
+
+	// "e" cycles the global environment
+	m, _ := a.Update(keyMsg('e'))
+	a = m.(App)
+	if a.store.ActiveEnv != "production" || a.store.EffectiveVars()["host"] != "example.com" {
+		t.Fatalf("expected production after cycling, got %q", a.store.ActiveEnv)
+	}
+	if !strings.Contains(a.View(), "[production]") {
+		t.Error("status bar should show the new environment")
+	}
+
+	// Pin the second tab to staging; the first tab stays on production
+	m, _ = a.Update(msgs.PinEnvMsg{Name: "staging"})
+	a = m.(App)
+	if got := a.store.EffectiveVars()["host"]; got != "staging.example.com" {
+		t.Fatalf("pinned tab should use staging vars, got %q", got)
+	}
+	if !strings.Contains(a.View(), "tab: staging") {
+		t.Error("status bar should show the pinned environment")
+	}
+	m, _ = a.Update(keyMsg('e'))
+	a = m.(App)
+	if a.store.EffectiveEnv() != "production" || a.store.ActiveEnv != "production" || !a.store.TabPinned() {
+		t.Fatalf("cycling a pinned tab should move its pin, got tab=%q global=%q", a.store.EffectiveEnv(), a.store.ActiveEnv)
+	}
+	m, _ = a.Update(keyMsg('e'))
+	a = m.(App)
+
+	m, _ = a.Update(msgs.PrevTabMsg{})
+	a = m.(App)
+	if a.store.EffectiveEnv() != "production" {
+		t.Errorf("unpinned tab should follow the global env, got %q", a.store.EffectiveEnv())
+	}
+
+	m, _ = a.Update(msgs.NextTabMsg{})
+	a = m.(App)
+	m, _ = a.Update(msgs.PinEnvMsg{Unpin: true})
+	a = m.(App)
+	if a.store.TabPinned() || a.store.EffectiveEnv() != "production" {
+		t.Errorf("unpinned tab should follow the global env, got %q", a.store.EffectiveEnv())
+	}
+}
+
+func TestSidebarFilter_ReceivesPanelKeys(t *testing.T) {
+	a := testAppResized()
+	a.focus = msgs.FocusSidebar
+	a.updateFocus()
+
+	for _, r := range "/be" {
+		m, _ := a.Update(keyMsg(r))
+		a = m.(App)
+	}
+	if !a.sidebarVisible {
+		t.Error("typing b in the sidebar filter should not toggle the sidebar")
+	}
+	if !a.sidebar.Filtering() {
+		t.Error("sidebar should still be filtering")
+	}
+}
+
+func TestRequestSentMsg_TimingReachesResponsePanel(t *testing.T) {
+	a := testAppResized()
+	m, _ := a.Update(msgs.RequestSentMsg{
+		StatusCode: 200,
+		Status:     "200 OK",
+		Body:       []byte("ok"),
+		Duration:   40 * time.Millisecond,
+		Proto:      "HTTP/2.0",
+		TLS:        true,
+		Timing: &protocol.TimingDetail{
+			DNSLookup: 5 * time.Millisecond,
+			TTFB:      30 * time.Millisecond,
+			Total:     40 * time.Millisecond,
+		},
+	})
+	a = m.(App)
+	a.focus = msgs.FocusResponse
+	a.updateFocus()
+	m, _ = a.Update(keyMsg('4'))
+	a = m.(App)
+
+	view := a.View()
+	for _, want := range []string{"Waterfall", "DNS Lookup", "HTTP/2.0"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("timing tab should show %q", want)
+		}
+	}
+}
+
+func TestExportWSLog(t *testing.T) {
+	a := testAppResized()
+	a.cfg.DownloadDir = t.TempDir()
+
+	m, cmd := a.Update(msgs.ExportWSLogMsg{})
+	a = m.(App)
+	if cmd == nil || !a.toast.Visible || !strings.Contains(a.View(), "No WebSocket messages") {
+		t.Fatal("exporting an empty log should report it")
+	}
+
+	a.response.AddWSMessage(response.WSMessage{Direction: "sent", Content: `{"op":"ping"}`, Timestamp: time.Now()})
+	a.response.AddWSMessage(response.WSMessage{Direction: "received", Content: "pong", Timestamp: time.Now()})
+	m, _ = a.Update(msgs.ExportWSLogMsg{})
+	a = m.(App)
+
+	files, _ := filepath.Glob(filepath.Join(a.cfg.DownloadDir, "websocket-*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("expected one exported log, got %v", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "\n"); got != 2 || !strings.Contains(string(data), `"content":"pong"`) {
+		t.Errorf("unexpected log:\n%s", data)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	a := testApp()
+	if a.history != nil {
+		a.history.Close()
+	}
+	a.response.SetWSBufferSize(2)
+	for i := range 5 {
+		a.response.AddWSMessage(response.WSMessage{Direction: "received", Content: strings.Repeat("x", i), Timestamp: time.Now()})
+	}
+	if spilled, _ := filepath.Glob(filepath.Join(tmp, "gottp-wslog-*")); len(spilled) == 0 {
+		t.Fatal("messages leaving the buffer should be written to a file")
+	}
+	store, err := history.NewStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.history = store
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := a.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if _, err := store.Add(history.Entry{Method: "GET", URL: "https://api.example.com", Timestamp: time.Now()}); err == nil {
+		t.Error("the history database should be closed")
+	}
+	if spilled, _ := filepath.Glob(filepath.Join(tmp, "gottp-wslog-*")); len(spilled) != 0 {
+		t.Errorf("the WebSocket log file should be removed, found %v", spilled)
+	}
+}
+
+func TestExportHistory(t *testing.T) {
+	a := testAppResized()
+	store, err := history.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	a.history = store
+	a.store.CollectionPath = filepath.Join(t.TempDir(), "api.gottp.yaml")
+
+	now := time.Now()
+	store.Add(history.Entry{Method: "GET", URL: "https://api.example.com/users", Tags: []string{"checkout"}, Timestamp: now.Add(-time.Hour)})
+	store.Add(history.Entry{Method: "GET", URL: "https://api.example.com/users", Tags: []string{"checkout"}, Timestamp: now.Add(-time.Minute)})
+	store.Add(history.Entry{Method: "GET", URL: "https://api.example.com/old", Tags: []string{"checkout"}, Timestamp: now.Add(-72 * time.Hour)})
+	store.Add(history.Entry{Method: "GET", URL: "https://api.example.com/other", Timestamp: now})
+
+	a.historyQuery = "#checkout since:1d"
+	m, _ := a.Update(msgs.ExportHistoryMsg{})
+	a = m.(App)
+
+	files, _ := filepath.Glob(filepath.Join(filepath.Dir(a.store.CollectionPath), "history-*.gottp.yaml"))
+	if len(files) != 1 {
+		t.Fatalf("expected one exported collection, got %v", files)
+	}
+	col, err := collection.LoadFromFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(col.Items) != 1 || col.Items[0].Request.URL != "{{baseUrl}}/users" {
+		t.Errorf("unexpected requests: %+v", col.Items)
+	}
+	if !strings.Contains(a.View(), "Exported 1 requests") {
+		t.Error("export should be reported")
+	}
+}
+
+func TestInsertQuery(t *testing.T) {
+	a := testAppResized()
+	a.store.CollectionPath = filepath.Join(t.TempDir(), "api.gottp.yaml")
+	req := a.store.Collection.Items[0].Request
+	a.store.OpenRequest(req)
+	a.store.Collection.Workflows = []collection.Workflow{{Name: "Flow", Steps: []collection.WorkflowStep{{Request: req.Name}}}}
+
+	inserts := []msgs.InsertQueryMsg{
+		{Expr: "$.items[0].id", Target: msgs.QueryAssertion, Name: "id", Value: json.Number("7"), Count: 1},
+		{Expr: "$.items", Target: msgs.QueryAssertion, Name: "items", Value: []interface{}{}, Count: 1},
+		{Expr: "$.items[0].id", Target: msgs.QueryCapture, Name: "id", Count: 1},
+		{Expr: "$.token", Target: msgs.QueryScript, Name: "token", Value: "abc", Count: 1},
+	}
+	for _, ins := range inserts {
+		m, _ := a.Update(ins)
+		a = m.(App)
+	}
+
+	saved, err := collection.LoadFromFile(a.store.CollectionPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := saved.Items[0].Request
+	if len(got.Assertions) != 2 || got.Assertions[0].Equals != 7 || got.Assertions[1].Exists == nil || !*got.Assertions[1].Exists {
+		t.Errorf("unexpected assertions: %+v", got.Assertions)
+	}
+	if saved.Workflows[0].Steps[0].Extracts["id"] != "$.items[0].id" {
+		t.Errorf("capture not added: %+v", saved.Workflows[0].Steps[0])
+	}
+	if got.PostScript != `gottp.setEnvVar("token", gottp.query("$.token"));`+"\n" {
+		t.Errorf("unexpected post-script %q", got.PostScript)
+	}
+
+	a.store.Collection.Workflows = nil
+	m, cmd := a.Update(msgs.InsertQueryMsg{Expr: "$.id", Target: msgs.QueryCapture, Name: "id"})
+	a = m.(App)
+	if !strings.Contains(a.View(), "in no workflow") || cmd == nil {
+		t.Error("a request outside workflows should fall back to copying the capture")
+	}
+}
+
+func TestOpenURL_ResolvesVariables(t *testing.T) {
+	var opened string
+	defer func(orig func(string) error) { openURL = orig }(openURL)
+	openURL = func(url string) error {
+		opened = url
+		return nil
+	}
+
+	a := testAppResized()
+	a.store.EnvVars = map[string]string{"grafana": "https://grafana.example.com"}
+	m, _ := a.Update(msgs.OpenURLMsg{URL: "{{grafana}}/d/users"})
+	a = m.(App)
+	if opened != "https://grafana.example.com/d/users" {
+		t.Errorf("opened %q", opened)
+	}
+	if !a.toast.Visible {
+		t.Error("expected a toast")
+	}
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+// editorSnapshot returns a copy of the active request as the editor shows
+// it, or nil if no tab is open.
+func (a *App) editorSnapshot() *collection.Request {
+	req := a.store.ActiveRequest()
+	if req == nil {
+		return nil
+	}
+	snap := cloneRequest(req)
+	syncRequest(snap, &a.editor)
+	return snap
+}
+
+// recordEdit adds the editor's state to the active tab's undo history.
+// Text being typed is recorded once the field is left, so undo steps back
+// a whole edit rather than a keystroke.
+func (a *App) recordEdit() {
+	if a.editor.Editing() {
+		return
+	}
+	if h := a.store.ActiveHistory(); h != nil {
+		h.Record(a.editorSnapshot())
+	}
+}
+
+// undoEdit restores the active tab's previous editor state, or the one
+// last undone if redo is set.
+func (a App) undoEdit(redo bool) (tea.Model, tea.Cmd) {
+	h := a.store.ActiveHistory()
+	if h == nil {
+		return a, nil
+	}
+	a.recordEdit()
+	snap, done, none := h.Undo, "Undone", "Nothing to undo"
+	if redo {
+		snap, done, none = h.Redo, "Redone", "Nothing to redo"
+	}
+	req := snap()
+	if req == nil {
+		a.statusBar.SetMessage(none)
+		return a, nil
+	}
+	a.editor.LoadRequest(cloneRequest(req))
+	a.markModified()
+	a.syncTabs()
+	a.statusBar.SetMessage(done + " (u: undo · U: redo)")
+	return a, nil
+}
@@ -0,0 +1,258 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	httpclient "github.com/sadopc/gottp/internal/protocol/http"
+	wsclient "github.com/sadopc/gottp/internal/protocol/websocket"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/panels/response"
+)
+
+// wsSession tracks the live WebSocket session driven by the TUI.
+type wsSession struct {
+	client *wsclient.Client
+	events <-chan wsclient.Event
+	cancel context.CancelFunc
+	tests  []response.ScriptTestResult
+}
+
+// wsClient returns the registered WebSocket client, or nil.
+func (a App) wsClient() *wsclient.Client {
+	p, ok := a.protocols.Get("websocket")
+	if !ok {
+		return nil
+	}
+	client, _ := p.(*wsclient.Client)
+	return client
+}
+
+// startWSSession begins the read pump, scripted messages and keepalive pings
+// on a freshly established connection.
+func (a *App) startWSSession() tea.Cmd {
+	client := a.wsClient()
+	if client == nil || !client.IsConnected() {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.ws = &wsSession{
+		client: client,
+		events: client.RunSession(ctx, a.wsScript, a.wsPingInterval),
+		cancel: cancel,
+	}
+	a.response.ClearWSLog()
+	a.editor.WSForm().SetConnected(true)
+	return tea.Batch(
+		listenWS(a.ws.events),
+		func() tea.Msg { return msgs.WSConnectedMsg{} },
+	)
+}
+
+const (
+	// wsFrame is how long events are gathered before the log is redrawn,
+	// capping a busy stream at about 30 updates per second.
+	wsFrame = time.Second / 30
+	// wsMaxBatch bounds the events delivered in one update.
+	wsMaxBatch = 5000
+)
+
+// listenWS waits for the next session event, then gathers whatever else
+// arrives within the same frame into a WSEventBatchMsg.
+func listenWS(events <-chan wsclient.Event) tea.Cmd {
+	return func() tea.Msg {
+		ev, ok := <-events
+		if !ok {
+			return msgs.WSDisconnectedMsg{}
+		}
+		batch := []msgs.WSEventMsg{wsEventMsg(ev)}
+		frame := time.NewTimer(wsFrame)
+		defer frame.Stop()
+		for len(batch) < wsMaxBatch {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					// The next listen reports the disconnect
+					return msgs.WSEventBatchMsg{Events: batch}
+				}
+				batch = append(batch, wsEventMsg(ev))
+			case <-frame.C:
+				return msgs.WSEventBatchMsg{Events: batch}
+			}
+		}
+		return msgs.WSEventBatchMsg{Events: batch}
+	}
+}
+
+func wsEventMsg(ev wsclient.Event) msgs.WSEventMsg {
+	out := msgs.WSEventMsg{
+		Name:      ev.Name,
+		Content:   ev.Content,
+		IsJSON:    ev.IsJSON,
+		Passed:    ev.Passed,
+		Err:       ev.Err,
+		Timestamp: ev.Timestamp,
+	}
+	switch ev.Kind {
+	case wsclient.EventSent:
+		out.Kind = msgs.WSEventSent
+	case wsclient.EventReceived:
+		out.Kind = msgs.WSEventReceived
+	case wsclient.EventAssertion:
+		out.Kind = msgs.WSEventAssertion
+	case wsclient.EventScriptDone:
+		out.Kind = msgs.WSEventScriptDone
+	case wsclient.EventError:
+		out.Kind = msgs.WSEventError
+	case wsclient.EventReconnecting:
+		out.Kind = msgs.WSEventReconnecting
+	case wsclient.EventReconnected:
+		out.Kind = msgs.WSEventReconnected
+	}
+	return out
+}
+
+func (a App) handleWSEvent(msg msgs.WSEventMsg) (tea.Model, tea.Cmd) {
+	return a.handleWSEvents([]msgs.WSEventMsg{msg})
+}
+
+// handleWSEvents applies a batch of session events in order and listens
+// for the next batch. Consecutive messages are added to the log together.
+func (a App) handleWSEvents(events []msgs.WSEventMsg) (tea.Model, tea.Cmd) {
+	if a.ws == nil {
+		return a, nil
+	}
+	cmds := []tea.Cmd{listenWS(a.ws.events)}
+	var pending []response.WSMessage
+	for _, msg := range events {
+		switch msg.Kind {
+		case msgs.WSEventSent, msgs.WSEventReceived:
+			direction := "received"
+			if msg.Kind == msgs.WSEventSent {
+				direction = "sent"
+			}
+			pending = append(pending, response.WSMessage{
+				Direction: direction,
+				Content:   msg.Content,
+				Timestamp: msg.Timestamp,
+				IsJSON:    msg.IsJSON,
+			})
+		default:
+			a.response.AddWSMessages(pending)
+			pending = nil
+			cmds = append(cmds, a.applyWSEvent(msg))
+		}
+	}
+	a.response.AddWSMessages(pending)
+	return a, tea.Batch(cmds...)
+}
+
+// applyWSEvent handles a session event other than a sent or received
+// message, returning a toast if the event warrants one.
+func (a *App) applyWSEvent(msg msgs.WSEventMsg) tea.Cmd {
+	switch msg.Kind {
+	case msgs.WSEventAssertion:
+		result := response.ScriptTestResult{Name: "ws expect: " + msg.Name, Passed: msg.Passed}
+		if msg.Err != nil {
+			result.Error = msg.Err.Error()
+		}
+		a.ws.tests = append(a.ws.tests, result)
+		a.response.SetScriptResults(nil, a.ws.tests, "")
+		if !msg.Passed {
+			return a.toast.Show("Expectation failed: "+msg.Name, true, 3*time.Second)
+		}
+
+	case msgs.WSEventScriptDone:
+		if len(a.wsScript) > 0 {
+			return a.toast.Show("Saved messages sent", false, 2*time.Second)
+		}
+
+	case msgs.WSEventReconnecting, msgs.WSEventReconnected:
+		a.response.AddWSMessage(response.WSMessage{
+			Direction: "event",
+			Content:   a.secrets.Mask(msg.Content),
+			Timestamp: msg.Timestamp,
+		})
+		if msg.Kind == msgs.WSEventReconnected {
+			return a.toast.Show("WebSocket reconnected", false, 2*time.Second)
+		}
+
+	case msgs.WSEventError:
+		return a.toast.Show("WebSocket error: "+a.secrets.Mask(msg.Err.Error()), true, 3*time.Second)
+	}
+	return nil
+}
+
+func (a App) handleWSSend(msg msgs.WSSendMsg) (tea.Model, tea.Cmd) {
+	if a.ws == nil {
+		cmd := a.toast.Show("WebSocket not connected", true, 2*time.Second)
+		return a, cmd
+	}
+	trimmed := strings.TrimSpace(msg.Content)
+	a.response.AddWSMessage(response.WSMessage{
+		Direction: "sent",
+		Content:   msg.Content,
+		Timestamp: time.Now(),
+		IsJSON:    strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "["),
+	})
+	client := a.ws.client
+	return a, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := client.Send(ctx, msg.Content); err != nil {
+			return msgs.ToastMsg{Text: "Send failed: " + err.Error(), IsError: true, Duration: 3 * time.Second}
+		}
+		return nil
+	}
+}
+
+// closeWS ends the session, if any. The read pump then reports
+// WSDisconnectedMsg.
+func (a *App) closeWS() {
+	if a.ws == nil {
+		return
+	}
+	a.ws.cancel()
+	_ = a.ws.client.Close()
+}
+
+// exportWSLog writes every message of the current WebSocket log, including
+// those no longer held in memory, to a JSON lines file in the download
+// directory.
+func (a App) exportWSLog() (tea.Model, tea.Cmd) {
+	if a.response.WSMessageCount() == 0 {
+		cmd := a.toast.Show("No WebSocket messages to export", true, 2*time.Second)
+		return a, cmd
+	}
+	dir := a.cfg.DownloadDir
+	if dir == "" {
+		dir = httpclient.DefaultDownloadDir()
+	}
+	path, n, err := writeWSLog(a.response, dir)
+	if err != nil {
+		cmd := a.toast.Show("Export failed: "+err.Error(), true, 3*time.Second)
+		return a, cmd
+	}
+	cmd := a.toast.Show(fmt.Sprintf("Exported %d messages to %s", n, path), false, 3*time.Second)
+	return a, cmd
+}
+
+func writeWSLog(resp response.Model, dir string) (string, int, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", 0, err
+	}
+	f, err := os.CreateTemp(dir, "websocket-"+time.Now().Format("20060102-150405")+"-*.jsonl")
+	if err != nil {
+		return "", 0, err
+	}
+	n, err := resp.ExportWSLog(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return f.Name(), n, err
+}
@@ -0,0 +1,103 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// AuthorizeTimeout bounds how long the authorization code flow waits for the
+// user to finish logging in.
+const AuthorizeTimeout = 5 * time.Minute
+
+// OpenBrowser opens url with the platform's default handler.
+func OpenBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+// AuthorizeCode runs the authorization_code grant: it starts a loopback
+// callback server, hands the authorization URL to open, waits for the
+// redirect and exchanges the code. A PKCE verifier is sent when
+// cfg.UsePKCE is set.
+func AuthorizeCode(ctx context.Context, cfg OAuth2Config, open func(url string) error) (*TokenResponse, error) {
+	if cfg.AuthURL == "" {
+		return nil, fmt.Errorf("auth URL is required for authorization_code grant")
+	}
+
+	state, err := GenerateCodeVerifier()
+	if err != nil {
+		return nil, err
+	}
+	var verifier, challenge string
+	if cfg.UsePKCE {
+		if verifier, err = GenerateCodeVerifier(); err != nil {
+			return nil, err
+		}
+		challenge = GenerateCodeChallenge(verifier)
+	}
+
+	server, err := NewCallbackServer(state)
+	if err != nil {
+		return nil, err
+	}
+	cfg.RedirectURI = server.RedirectURI()
+
+	authURL := BuildAuthURL(cfg, state, challenge)
+	if err := open(authURL); err != nil {
+		server.Close()
+		return nil, fmt.Errorf("opening browser: %w (visit %s)", err, authURL)
+	}
+
+	code, err := server.Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ExchangeAuthCode(ctx, cfg, code, verifier)
+}
+
+// NeedsToken reports whether cfg lacks a usable access token.
+func NeedsToken(cfg *protocol.OAuth2AuthConfig) bool {
+	return cfg.AccessToken == "" || (!cfg.TokenExpiry.IsZero() && time.Now().After(cfg.TokenExpiry))
+}
+
+// Obtain returns a fresh token for cfg. It refreshes silently when possible
+// and otherwise runs the configured grant. open starts the browser for the
+// authorization_code grant; when it is nil, that grant fails with
+// ErrNoRefresh instead of prompting.
+func Obtain(ctx context.Context, cfg *protocol.OAuth2AuthConfig, open func(url string) error) (*TokenResponse, error) {
+	switch cfg.GrantType {
+	case "client_credentials", "password", "authorization_code":
+	default:
+		return nil, fmt.Errorf("unsupported grant type %q", cfg.GrantType)
+	}
+
+	token, err := Refresh(ctx, cfg)
+	if err == nil || cfg.GrantType != "authorization_code" {
+		return token, err
+	}
+	if open == nil {
+		return nil, ErrNoRefresh
+	}
+	return AuthorizeCode(ctx, OAuth2Config{
+		GrantType:    cfg.GrantType,
+		AuthURL:      cfg.AuthURL,
+		TokenURL:     cfg.TokenURL,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Scope:        cfg.Scope,
+		UsePKCE:      cfg.UsePKCE,
+	}, open)
+}
@@ -0,0 +1,125 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// fakeBrowser follows the authorization URL straight to the redirect URI,
+// as if the user had logged in and approved.
+func fakeBrowser(t *testing.T, gotChallenge *string) func(string) error {
+	return func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		q := u.Query()
+		*gotChallenge = q.Get("code_challenge")
+		go func() {
+			resp, err := http.Get(q.Get("redirect_uri") + "?code=the-code&state=" + url.QueryEscape(q.Get("state")))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+		return nil
+	}
+}
+
+func TestAuthorizeCode_PKCE(t *testing.T) {
+	var challenge string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.Form.Get("grant_type") != "authorization_code" || r.Form.Get("code") != "the-code" {
+			t.Errorf("unexpected form: %v", r.Form)
+		}
+		if got := GenerateCodeChallenge(r.Form.Get("code_verifier")); got != challenge {
+			t.Errorf("code_verifier does not match challenge %q", challenge)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "access",
+			"refresh_token": "refresh",
+			"expires_in":    60,
+		})
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token, err := AuthorizeCode(ctx, OAuth2Config{
+		AuthURL:  "https://auth.example.com/authorize",
+		TokenURL: server.URL,
+		ClientID: "cli",
+		UsePKCE:  true,
+	}, fakeBrowser(t, &challenge))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenge == "" {
+		t.Error("expected code_challenge in authorization URL")
+	}
+	if token.AccessToken != "access" || token.RefreshToken != "refresh" {
+		t.Errorf("unexpected token %+v", token)
+	}
+}
+
+func TestObtain_SilentRefresh(t *testing.T) {
+	server := tokenServer(t, "refresh_token")
+	defer server.Close()
+
+	cfg := &protocol.OAuth2AuthConfig{
+		GrantType:    "authorization_code",
+		AuthURL:      "https://auth.example.com/authorize",
+		TokenURL:     server.URL,
+		RefreshToken: "refresh-me",
+	}
+	opened := false
+	token, err := Obtain(context.Background(), cfg, func(string) error {
+		opened = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened {
+		t.Error("browser should not open when a refresh token works")
+	}
+	if token.AccessToken != "fresh-token" {
+		t.Errorf("unexpected token %+v", token)
+	}
+}
+
+func TestObtain_NonInteractive(t *testing.T) {
+	cfg := &protocol.OAuth2AuthConfig{GrantType: "authorization_code", TokenURL: "http://127.0.0.1:0"}
+	if _, err := Obtain(context.Background(), cfg, nil); !errors.Is(err, ErrNoRefresh) {
+		t.Errorf("expected ErrNoRefresh, got %v", err)
+	}
+	if _, err := Obtain(context.Background(), &protocol.OAuth2AuthConfig{GrantType: "implicit"}, nil); err == nil {
+		t.Error("expected error for unsupported grant type")
+	}
+}
+
+func TestNeedsToken(t *testing.T) {
+	if !NeedsToken(&protocol.OAuth2AuthConfig{}) {
+		t.Error("empty token should need a token")
+	}
+	if !NeedsToken(&protocol.OAuth2AuthConfig{AccessToken: "x", TokenExpiry: time.Now().Add(-time.Minute)}) {
+		t.Error("expired token should need a token")
+	}
+	if NeedsToken(&protocol.OAuth2AuthConfig{AccessToken: "x"}) {
+		t.Error("token without expiry should be usable")
+	}
+}
@@ -3,58 +3,107 @@ package oauth2
 import (
 	"context"
 	"fmt"
+	"html"
 	"net"
 	"net/http"
 )
 
-// StartCallbackServer starts a temporary HTTP server on a random port to receive
-// the OAuth2 callback with the authorization code. It blocks until a code is
-// received or the context is cancelled.
-func StartCallbackServer(ctx context.Context) (code string, port int, err error) {
+// CallbackServer is a temporary loopback HTTP server that receives the
+// redirect of an authorization code flow.
+type CallbackServer struct {
+	listener net.Listener
+	server   *http.Server
+	state    string
+	codeCh   chan string
+	errCh    chan error
+}
+
+// NewCallbackServer starts listening on a random localhost port. Callbacks
+// whose state parameter does not match state are rejected.
+func NewCallbackServer(state string) (*CallbackServer, error) {
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
-		return "", 0, fmt.Errorf("starting callback listener: %w", err)
+		return nil, fmt.Errorf("starting callback listener: %w", err)
 	}
-	port = listener.Addr().(*net.TCPAddr).Port
 
-	codeCh := make(chan string, 1)
-	errCh := make(chan error, 1)
+	s := &CallbackServer{
+		listener: listener,
+		state:    state,
+		codeCh:   make(chan string, 1),
+		errCh:    make(chan error, 1),
+	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		c := r.URL.Query().Get("code")
-		if c == "" {
-			errMsg := r.URL.Query().Get("error")
-			if errMsg == "" {
-				errMsg = "no code in callback"
-			}
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, "<html><body><h1>Error</h1><p>%s</p></body></html>", errMsg)
-			errCh <- fmt.Errorf("OAuth2 callback error: %s", errMsg)
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprint(w, "<html><body><h1>Authorization successful!</h1><p>You can close this tab and return to gottp.</p></body></html>")
-		codeCh <- c
-	})
-
-	server := &http.Server{Handler: mux}
+	mux.HandleFunc("/callback", s.handleCallback)
+	s.server = &http.Server{Handler: mux}
 
 	go func() {
-		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
-			errCh <- err
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.fail(err)
 		}
 	}()
+	return s, nil
+}
 
+// RedirectURI returns the URI the authorization server should redirect to.
+func (s *CallbackServer) RedirectURI() string {
+	return fmt.Sprintf("http://127.0.0.1:%d/callback", s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+// Wait blocks until an authorization code is received or ctx is cancelled,
+// then shuts the server down.
+func (s *CallbackServer) Wait(ctx context.Context) (string, error) {
+	defer s.Close()
 	select {
-	case code = <-codeCh:
-		_ = server.Shutdown(context.Background())
-		return code, port, nil
-	case err = <-errCh:
-		_ = server.Shutdown(context.Background())
-		return "", port, err
+	case code := <-s.codeCh:
+		return code, nil
+	case err := <-s.errCh:
+		return "", err
 	case <-ctx.Done():
-		_ = server.Shutdown(context.Background())
-		return "", port, ctx.Err()
+		return "", ctx.Err()
+	}
+}
+
+// Close stops the server.
+func (s *CallbackServer) Close() error {
+	return s.server.Shutdown(context.Background())
+}
+
+func (s *CallbackServer) handleCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if s.state != "" && q.Get("state") != s.state {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, "<html><body><h1>Error</h1><p>state mismatch</p></body></html>")
+		s.fail(fmt.Errorf("OAuth2 callback error: state mismatch"))
+		return
+	}
+
+	c := q.Get("code")
+	if c == "" {
+		errMsg := q.Get("error")
+		if errMsg == "" {
+			errMsg = "no code in callback"
+		}
+		if desc := q.Get("error_description"); desc != "" {
+			errMsg += ": " + desc
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "<html><body><h1>Error</h1><p>%s</p></body></html>", html.EscapeString(errMsg))
+		s.fail(fmt.Errorf("OAuth2 callback error: %s", errMsg))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "<html><body><h1>Authorization successful!</h1><p>You can close this tab and return to gottp.</p></body></html>")
+	select {
+	case s.codeCh <- c:
+	default:
+	}
+}
+
+func (s *CallbackServer) fail(err error) {
+	select {
+	case s.errCh <- err:
+	default:
 	}
 }
@@ -3,7 +3,6 @@ package oauth2
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -185,52 +184,47 @@ func TestTokenExpiry(t *testing.T) {
 }
 
 func TestCallbackServer(t *testing.T) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	codeCh := make(chan string, 1)
-	errCh := make(chan error, 1)
-	portCh := make(chan int, 1)
+	server, err := NewCallbackServer("xyz")
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	go func() {
-		code, port, err := StartCallbackServer(ctx)
-		portCh <- port
-		if err != nil {
-			errCh <- err
-			return
-		}
-		codeCh <- code
-	}()
+	resp, err := http.Get(server.RedirectURI() + "?code=test-auth-code&state=xyz")
+	if err != nil {
+		t.Fatalf("callback request failed: %v", err)
+	}
+	resp.Body.Close()
 
-	// Wait for server to start
-	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	code, err := server.Wait(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "test-auth-code" {
+		t.Errorf("expected test-auth-code, got %s", code)
+	}
+}
 
-	// Read port (server writes it before blocking)
-	var port int
-	select {
-	case port = <-portCh:
-	case <-time.After(2 * time.Second):
-		// Port might not be sent yet, try hitting common callback
-		t.Skip("could not get port in time")
+func TestCallbackServer_StateMismatch(t *testing.T) {
+	server, err := NewCallbackServer("expected")
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	// Send callback request
-	callbackURL := fmt.Sprintf("http://127.0.0.1:%d/callback?code=test-auth-code&state=xyz", port)
-	resp, err := http.Get(callbackURL)
+	resp, err := http.Get(server.RedirectURI() + "?code=c&state=forged")
 	if err != nil {
-		t.Fatalf("callback request failed: %v", err)
+		t.Fatal(err)
 	}
 	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
 
-	select {
-	case code := <-codeCh:
-		if code != "test-auth-code" {
-			t.Errorf("expected test-auth-code, got %s", code)
-		}
-	case err := <-errCh:
-		t.Fatalf("unexpected error: %v", err)
-	case <-time.After(3 * time.Second):
-		t.Fatal("timeout waiting for callback code")
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if _, err := server.Wait(ctx); err == nil {
+		t.Error("expected state mismatch error")
 	}
 }
 
@@ -0,0 +1,94 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// ErrNoRefresh is returned when a token cannot be renewed without user
+// interaction (e.g. authorization_code without a refresh token).
+var ErrNoRefresh = errors.New("token cannot be refreshed without user interaction")
+
+// Executor sends a request. protocol.Registry.Execute satisfies it.
+type Executor func(ctx context.Context, req *protocol.Request) (*protocol.Response, error)
+
+// TokenKey identifies a token by the endpoint, client and scope it was issued for.
+func TokenKey(tokenURL, clientID, scope string) string {
+	return tokenURL + "|" + clientID + "|" + scope
+}
+
+// Refresh obtains a new token for cfg. It uses the refresh_token grant when a
+// refresh token is available and falls back to re-running non-interactive
+// grants (client_credentials, password).
+func Refresh(ctx context.Context, cfg *protocol.OAuth2AuthConfig) (*TokenResponse, error) {
+	if cfg.RefreshToken != "" {
+		token, err := RefreshAccessToken(ctx, cfg.TokenURL, cfg.ClientID, cfg.ClientSecret, cfg.RefreshToken)
+		if err == nil {
+			if token.RefreshToken == "" {
+				token.RefreshToken = cfg.RefreshToken
+			}
+			return token, nil
+		}
+		if cfg.GrantType != "client_credentials" && cfg.GrantType != "password" {
+			return nil, err
+		}
+	}
+
+	grant := OAuth2Config{
+		TokenURL:     cfg.TokenURL,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Scope:        cfg.Scope,
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+	}
+	switch cfg.GrantType {
+	case "client_credentials":
+		return ClientCredentials(ctx, grant)
+	case "password":
+		return PasswordGrant(ctx, grant)
+	}
+	return nil, ErrNoRefresh
+}
+
+// ApplyToken copies a token into the request's OAuth2 auth config.
+func ApplyToken(cfg *protocol.OAuth2AuthConfig, token *TokenResponse) {
+	cfg.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		cfg.RefreshToken = token.RefreshToken
+	}
+	cfg.TokenExpiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		cfg.TokenExpiry = token.ObtainedAt.Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+}
+
+// ExecuteWithRefresh sends req and, when the server answers 401 and the
+// request uses OAuth2 auth, refreshes the token once and retries. The new
+// token is returned when a refresh happened so callers can keep it.
+func ExecuteWithRefresh(ctx context.Context, exec Executor, req *protocol.Request) (*protocol.Response, *TokenResponse, error) {
+	resp, err := exec(ctx, req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil, err
+	}
+	if req.Auth == nil || req.Auth.Type != "oauth2" || req.Auth.OAuth2 == nil || req.Auth.OAuth2.TokenURL == "" {
+		return resp, nil, nil
+	}
+
+	token, refreshErr := Refresh(ctx, req.Auth.OAuth2)
+	if refreshErr != nil {
+		// Report the original 401 rather than the refresh failure.
+		return resp, nil, nil
+	}
+	ApplyToken(req.Auth.OAuth2, token)
+
+	retried, err := exec(ctx, req)
+	if err != nil {
+		return nil, token, err
+	}
+	return retried, token, nil
+}
@@ -0,0 +1,129 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+func tokenServer(t *testing.T, wantGrant string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Form.Get("grant_type"); got != wantGrant {
+			t.Errorf("grant_type = %q, want %q", got, wantGrant)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "fresh-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+}
+
+// bearerExecutor returns 401 unless the request carries the fresh token.
+func bearerExecutor(calls *int) Executor {
+	return func(ctx context.Context, req *protocol.Request) (*protocol.Response, error) {
+		*calls++
+		if req.Auth.OAuth2.AccessToken == "fresh-token" {
+			return &protocol.Response{StatusCode: 200}, nil
+		}
+		return &protocol.Response{StatusCode: 401}, nil
+	}
+}
+
+func TestExecuteWithRefresh_RefreshToken(t *testing.T) {
+	server := tokenServer(t, "refresh_token")
+	defer server.Close()
+
+	req := &protocol.Request{Auth: &protocol.AuthConfig{
+		Type: "oauth2",
+		OAuth2: &protocol.OAuth2AuthConfig{
+			GrantType:    "authorization_code",
+			TokenURL:     server.URL,
+			ClientID:     "id",
+			AccessToken:  "stale-token",
+			RefreshToken: "refresh-me",
+		},
+	}}
+
+	calls := 0
+	resp, token, err := ExecuteWithRefresh(context.Background(), bearerExecutor(&calls), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 || calls != 2 {
+		t.Errorf("status = %d after %d calls, want 200 after 2", resp.StatusCode, calls)
+	}
+	if token == nil || token.AccessToken != "fresh-token" {
+		t.Fatalf("expected refreshed token, got %+v", token)
+	}
+	if token.RefreshToken != "refresh-me" {
+		t.Errorf("refresh token should be preserved, got %q", token.RefreshToken)
+	}
+	if req.Auth.OAuth2.TokenExpiry.IsZero() {
+		t.Error("expected token expiry to be applied")
+	}
+}
+
+func TestExecuteWithRefresh_ClientCredentialsRegrant(t *testing.T) {
+	server := tokenServer(t, "client_credentials")
+	defer server.Close()
+
+	req := &protocol.Request{Auth: &protocol.AuthConfig{
+		Type: "oauth2",
+		OAuth2: &protocol.OAuth2AuthConfig{
+			GrantType:   "client_credentials",
+			TokenURL:    server.URL,
+			AccessToken: "stale-token",
+		},
+	}}
+
+	calls := 0
+	resp, token, err := ExecuteWithRefresh(context.Background(), bearerExecutor(&calls), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 || token == nil {
+		t.Errorf("expected successful retry, got %d (token %v)", resp.StatusCode, token)
+	}
+}
+
+func TestExecuteWithRefresh_NoRefreshPossible(t *testing.T) {
+	req := &protocol.Request{Auth: &protocol.AuthConfig{
+		Type: "oauth2",
+		OAuth2: &protocol.OAuth2AuthConfig{
+			GrantType:   "authorization_code",
+			TokenURL:    "http://127.0.0.1:0/token",
+			AccessToken: "stale-token",
+		},
+	}}
+
+	calls := 0
+	resp, token, err := ExecuteWithRefresh(context.Background(), bearerExecutor(&calls), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 401 || token != nil || calls != 1 {
+		t.Errorf("expected original 401 without retry, got %d, token %v, %d calls", resp.StatusCode, token, calls)
+	}
+}
+
+func TestExecuteWithRefresh_NonOAuth2(t *testing.T) {
+	req := &protocol.Request{Auth: &protocol.AuthConfig{Type: "bearer", Token: "x"}}
+	calls := 0
+	exec := func(ctx context.Context, req *protocol.Request) (*protocol.Response, error) {
+		calls++
+		return &protocol.Response{StatusCode: 401}, nil
+	}
+	if _, token, _ := ExecuteWithRefresh(context.Background(), exec, req); token != nil || calls != 1 {
+		t.Errorf("non-OAuth2 request should not be retried (calls=%d)", calls)
+	}
+}
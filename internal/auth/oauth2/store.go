@@ -0,0 +1,156 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenStore caches tokens keyed by TokenKey so every request using the same
+// token endpoint, client and scope shares one token. When backed by a file the
+// cache survives restarts. A nil *TokenStore is an empty, read-only cache.
+type TokenStore struct {
+	mu     sync.Mutex
+	path   string // empty for an in-memory store
+	tokens map[string]*TokenResponse
+}
+
+// CachedToken is a snapshot of one cache entry.
+type CachedToken struct {
+	Key      string
+	TokenURL string
+	ClientID string
+	Scope    string
+	Token    *TokenResponse
+}
+
+// storedToken keeps ObtainedAt, which TokenResponse omits from JSON.
+type storedToken struct {
+	*TokenResponse
+	ObtainedAt time.Time `json:"obtained_at"`
+}
+
+// NewTokenStore returns an empty in-memory store.
+func NewTokenStore() *TokenStore {
+	return &TokenStore{tokens: make(map[string]*TokenResponse)}
+}
+
+// OpenTokenStore loads the store persisted at path. A missing file yields an
+// empty store that will be created on the first write.
+func OpenTokenStore(path string) (*TokenStore, error) {
+	s := &TokenStore{path: path, tokens: make(map[string]*TokenResponse)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading token cache: %w", err)
+	}
+
+	var stored map[string]storedToken
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("parsing token cache: %w", err)
+	}
+	for key, st := range stored {
+		if st.TokenResponse == nil {
+			continue
+		}
+		st.TokenResponse.ObtainedAt = st.ObtainedAt
+		s.tokens[key] = st.TokenResponse
+	}
+	return s, nil
+}
+
+// Get returns the cached token for key.
+func (s *TokenStore) Get(key string) (*TokenResponse, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[key]
+	return token, ok
+}
+
+// Put caches token under key and persists the store.
+func (s *TokenStore) Put(key string, token *TokenResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[key] = token
+	return s.save()
+}
+
+// Delete removes the token cached under key and persists the store.
+func (s *TokenStore) Delete(key string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, key)
+	return s.save()
+}
+
+// Clear removes every cached token and persists the store.
+func (s *TokenStore) Clear() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]*TokenResponse)
+	return s.save()
+}
+
+// Entries lists the cached tokens sorted by key.
+func (s *TokenStore) Entries() []CachedToken {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]CachedToken, 0, len(s.tokens))
+	for key, token := range s.tokens {
+		parts := strings.SplitN(key, "|", 3)
+		for len(parts) < 3 {
+			parts = append(parts, "")
+		}
+		entries = append(entries, CachedToken{
+			Key:      key,
+			TokenURL: parts[0],
+			ClientID: parts[1],
+			Scope:    parts[2],
+			Token:    token,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+// save writes the store to disk with owner-only permissions. Callers hold mu.
+func (s *TokenStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	stored := make(map[string]storedToken, len(s.tokens))
+	for key, token := range s.tokens {
+		stored[key] = storedToken{TokenResponse: token, ObtainedAt: token.ObtainedAt}
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding token cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("creating token cache dir: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("writing token cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package oauth2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTokenStore_PersistsAcrossOpens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	store, err := OpenTokenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obtained := time.Now().Add(-time.Minute).Truncate(time.Second)
+	key := TokenKey("https://auth.example.com/token", "cli", "read")
+	if err := store.Put(key, &TokenResponse{AccessToken: "a", RefreshToken: "r", ExpiresIn: 3600, ObtainedAt: obtained}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(TokenKey("https://other/token", "x", ""), &TokenResponse{AccessToken: "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("token cache permissions = %o, want 600", perm)
+	}
+
+	reopened, err := OpenTokenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, ok := reopened.Get(key)
+	if !ok {
+		t.Fatal("token not persisted")
+	}
+	if token.AccessToken != "a" || token.RefreshToken != "r" || !token.ObtainedAt.Equal(obtained) {
+		t.Errorf("unexpected token after reload: %+v", token)
+	}
+
+	entries := reopened.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.TokenURL != "https://auth.example.com/token" || e.ClientID != "cli" || e.Scope != "read" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	if err := reopened.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reopened.Get(key); ok {
+		t.Error("token should be deleted")
+	}
+	if err := reopened.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	cleared, _ := OpenTokenStore(path)
+	if len(cleared.Entries()) != 0 {
+		t.Error("expected empty store after Clear")
+	}
+}
+
+func TestTokenStore_MissingAndNil(t *testing.T) {
+	store, err := OpenTokenStore(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("missing file should not be an error: %v", err)
+	}
+	if len(store.Entries()) != 0 {
+		t.Error("expected empty store")
+	}
+
+	var nilStore *TokenStore
+	if _, ok := nilStore.Get("k"); ok {
+		t.Error("nil store should be empty")
+	}
+	if nilStore.Delete("k") != nil || nilStore.Clear() != nil || nilStore.Entries() != nil {
+		t.Error("nil store operations should be no-ops")
+	}
+}
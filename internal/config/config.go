@@ -3,12 +3,16 @@ package config
 import (
 	"time"
 
+	"github.com/dustin/go-humanize"
+
+	"github.com/sadopc/gottp/internal/core/retry"
 	gotls "github.com/sadopc/gottp/internal/core/tls"
 )
 
 // Config holds the application configuration.
 type Config struct {
 	Theme          string        `yaml:"theme"`
+	ColorProfile   string        `yaml:"color_profile,omitempty"` // auto (default), truecolor, 256, 16 or none
 	VimMode        bool          `yaml:"vim_mode"`
 	DefaultTimeout time.Duration `yaml:"default_timeout"`
 	Editor         string        `yaml:"editor"`
@@ -17,16 +21,75 @@ type Config struct {
 	ProxyURL       string        `yaml:"proxy_url,omitempty"`
 	NoProxy        string        `yaml:"no_proxy,omitempty"`
 	TLS            gotls.Config  `yaml:"tls,omitempty"`
+	Retry          *retry.Policy `yaml:"retry,omitempty"`
+
+	// IDStyle selects how new request IDs are generated: "uuid" (default),
+	// "ulid" or "slug".
+	IDStyle string `yaml:"id_style,omitempty"`
+
+	// GraphQLScaffoldDepth limits selection set nesting when scaffolding
+	// requests from schema.graphql (default 2).
+	GraphQLScaffoldDepth int `yaml:"graphql_scaffold_depth,omitempty"`
+
+	// Response bodies larger than DownloadThreshold (e.g. "10MB", "0" to
+	// disable) are streamed to DownloadDir instead of held in memory.
+	DownloadThreshold string `yaml:"download_threshold,omitempty"`
+	DownloadDir       string `yaml:"download_dir,omitempty"`
+
+	// Connections tunes how HTTP connections are kept alive and reused.
+	Connections Connections `yaml:"connections,omitempty"`
+
+	// WSBufferSize caps the WebSocket messages held in memory (default
+	// 1000). Older messages move to a temporary file and are still
+	// included when the log is exported.
+	WSBufferSize int `yaml:"ws_buffer_size,omitempty"`
+
+	// HeadlessAction is what gottp does when started without a terminal,
+	// e.g. in CI: "help" (default) prints usage, "run" runs the collection
+	// and "validate" validates it.
+	HeadlessAction string `yaml:"headless_action,omitempty"`
+
+	// RestoreSession reopens the tabs, environment, sidebar and focus the
+	// TUI had when it last exited with the same collection (default on).
+	RestoreSession bool `yaml:"restore_session"`
 }
 
+// Connections holds HTTP connection pool settings. Zero values keep the
+// defaults.
+type Connections struct {
+	MaxIdle           int           `yaml:"max_idle,omitempty"`          // idle connections kept across hosts (100)
+	MaxIdlePerHost    int           `yaml:"max_idle_per_host,omitempty"` // idle connections kept per host (2)
+	IdleTimeout       time.Duration `yaml:"idle_timeout,omitempty"`      // how long idle connections are kept (90s)
+	DisableKeepAlives bool          `yaml:"disable_keep_alives,omitempty"`
+	ForceClose        bool          `yaml:"force_close,omitempty"` // open a new connection for every request
+}
+
+// HeadlessActions lists the valid HeadlessAction values.
+var HeadlessActions = []string{"help", "run", "validate"}
+
 // DefaultConfig returns the default configuration.
 func DefaultConfig() Config {
 	return Config{
-		Theme:          "catppuccin-mocha",
-		VimMode:        true,
-		DefaultTimeout: 30 * time.Second,
-		Editor:         "",
-		Pager:          "",
-		ScriptTimeout:  5 * time.Second,
+		Theme:             "catppuccin-mocha",
+		VimMode:           true,
+		DefaultTimeout:    30 * time.Second,
+		Editor:            "",
+		Pager:             "",
+		ScriptTimeout:     5 * time.Second,
+		DownloadThreshold: "10MB",
+		RestoreSession:    true,
+	}
+}
+
+// DownloadThresholdBytes parses DownloadThreshold. Invalid or empty values
+// disable streaming to disk.
+func (c Config) DownloadThresholdBytes() int64 {
+	if c.DownloadThreshold == "" {
+		return 0
+	}
+	n, err := humanize.ParseBytes(c.DownloadThreshold)
+	if err != nil {
+		return 0
 	}
+	return int64(n)
 }
@@ -11,12 +11,10 @@ import (
 func Load() Config {
 	cfg := DefaultConfig()
 
-	home, err := os.UserHomeDir()
-	if err != nil {
+	path := Path()
+	if path == "" {
 		return cfg
 	}
-
-	path := filepath.Join(home, ".config", "gottp", "config.yaml")
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return cfg
@@ -25,3 +23,23 @@ func Load() Config {
 	_ = yaml.Unmarshal(data, &cfg)
 	return cfg
 }
+
+// Path returns the location of the configuration file, or "" if the home
+// directory is unknown.
+func Path() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gottp", "config.yaml")
+}
+
+// DataDir returns the directory for persistent data such as request history
+// and the OAuth2 token cache (~/.local/share/gottp).
+func DataDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.Getenv("HOME")
+	}
+	return filepath.Join(home, ".local", "share", "gottp")
+}
@@ -24,6 +24,21 @@ func TestDefaultConfig(t *testing.T) {
 	}
 }
 
+func TestDownloadThresholdBytes(t *testing.T) {
+	tests := map[string]int64{
+		"10MB":  10_000_000,
+		"1 MiB": 1 << 20,
+		"0":     0,
+		"":      0,
+		"lots":  0,
+	}
+	for in, want := range tests {
+		if got := (Config{DownloadThreshold: in}).DownloadThresholdBytes(); got != want {
+			t.Errorf("DownloadThresholdBytes(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
 func TestLoadReturnsDefaultsWhenConfigMissing(t *testing.T) {
 	home := t.TempDir()
 	t.Setenv("HOME", home)
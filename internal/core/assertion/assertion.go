@@ -0,0 +1,300 @@
+// Package assertion evaluates declarative response checks written in
+// collection YAML, so simple tests need no JavaScript.
+package assertion
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sadopc/gottp/internal/core/jsonquery"
+)
+
+// Assertion is one check on a response. It names exactly one subject
+// (status, header, jsonpath, response_time_under or a schema); header and
+// jsonpath subjects take one operator (equals, contains, matches or
+// exists).
+type Assertion struct {
+	Name string `yaml:"name,omitempty"` // defaults to a description of the check
+
+	Status            int                    `yaml:"status,omitempty"`
+	Header            string                 `yaml:"header,omitempty"`
+	JSONPath          string                 `yaml:"jsonpath,omitempty"` // $.jsonpath or .jq
+	ResponseTimeUnder time.Duration          `yaml:"response_time_under,omitempty"`
+	Schema            map[string]interface{} `yaml:"schema,omitempty"`      // inline JSON Schema for the body
+	SchemaFile        string                 `yaml:"schema_file,omitempty"` // relative to the collection file
+
+	Equals   interface{} `yaml:"equals,omitempty"`
+	Contains interface{} `yaml:"contains,omitempty"`
+	Matches  string      `yaml:"matches,omitempty"` // regular expression
+	Exists   *bool       `yaml:"exists,omitempty"`
+}
+
+// Response is the part of a response the assertions can inspect.
+type Response struct {
+	StatusCode int
+	Headers    http.Header
+	Body       []byte
+	Duration   time.Duration
+}
+
+// Result is the outcome of one assertion.
+type Result struct {
+	Name   string
+	Passed bool
+	Error  string
+}
+
+// Validate checks that the assertion has one subject and usable operators.
+func (a Assertion) Validate() error {
+	subjects := 0
+	for _, set := range []bool{
+		a.Status != 0, a.Header != "", a.JSONPath != "", a.ResponseTimeUnder > 0,
+		a.Schema != nil || a.SchemaFile != "",
+	} {
+		if set {
+			subjects++
+		}
+	}
+	if subjects != 1 {
+		return errors.New("want exactly one of status, header, jsonpath, response_time_under, schema or schema_file")
+	}
+	if a.Schema != nil && a.SchemaFile != "" {
+		return errors.New("schema and schema_file are mutually exclusive")
+	}
+
+	operators := 0
+	for _, set := range []bool{a.Equals != nil, a.Contains != nil, a.Matches != "", a.Exists != nil} {
+		if set {
+			operators++
+		}
+	}
+	switch {
+	case a.Header != "" || a.JSONPath != "":
+		if operators != 1 {
+			return errors.New("want exactly one of equals, contains, matches or exists")
+		}
+	case operators > 0:
+		return errors.New("equals, contains, matches and exists apply to header and jsonpath checks only")
+	}
+	if a.Matches != "" {
+		if _, err := regexp.Compile(a.Matches); err != nil {
+			return fmt.Errorf("invalid matches pattern: %w", err)
+		}
+	}
+	if a.JSONPath != "" {
+		if err := jsonquery.Validate(a.JSONPath); err != nil {
+			return fmt.Errorf("invalid jsonpath: %w", err)
+		}
+	}
+	return nil
+}
+
+// Describe returns the assertion's name, or a summary of the check.
+func (a Assertion) Describe() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	switch {
+	case a.Status != 0:
+		return fmt.Sprintf("status is %d", a.Status)
+	case a.Header != "":
+		return "header " + a.Header + " " + a.operator()
+	case a.JSONPath != "":
+		return a.JSONPath + " " + a.operator()
+	case a.ResponseTimeUnder > 0:
+		return "response time under " + a.ResponseTimeUnder.String()
+	case a.SchemaFile != "":
+		return "body matches " + a.SchemaFile
+	case a.Schema != nil:
+		return "body matches schema"
+	}
+	return "assertion"
+}
+
+func (a Assertion) operator() string {
+	switch {
+	case a.Equals != nil:
+		return "equals " + compact(a.Equals)
+	case a.Contains != nil:
+		return "contains " + compact(a.Contains)
+	case a.Matches != "":
+		return "matches /" + a.Matches + "/"
+	case a.Exists != nil && !*a.Exists:
+		return "is absent"
+	}
+	return "exists"
+}
+
+// Evaluate runs each assertion against resp. Schema files are read relative
+// to baseDir.
+func Evaluate(assertions []Assertion, resp Response, baseDir string) []Result {
+	results := make([]Result, 0, len(assertions))
+	for _, a := range assertions {
+		r := Result{Name: a.Describe(), Passed: true}
+		if err := a.check(resp, baseDir); err != nil {
+			r.Passed = false
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func (a Assertion) check(resp Response, baseDir string) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	switch {
+	case a.Status != 0:
+		if resp.StatusCode != a.Status {
+			return fmt.Errorf("expected status %d, got %d", a.Status, resp.StatusCode)
+		}
+	case a.Header != "":
+		values, ok := resp.Headers[http.CanonicalHeaderKey(a.Header)]
+		if !ok {
+			if a.Exists != nil && !*a.Exists {
+				return nil
+			}
+			return fmt.Errorf("header %s is missing", a.Header)
+		}
+		return a.compare(strings.Join(values, ", "))
+	case a.JSONPath != "":
+		results, err := jsonquery.Query(resp.Body, a.JSONPath)
+		if err != nil {
+			return err
+		}
+		var found []interface{}
+		for _, r := range results {
+			if r != nil {
+				found = append(found, r)
+			}
+		}
+		if len(found) == 0 {
+			if a.Exists != nil && !*a.Exists {
+				return nil
+			}
+			return fmt.Errorf("%s matched nothing", a.JSONPath)
+		}
+		var v interface{} = found
+		if len(found) == 1 {
+			v = found[0]
+		}
+		return a.compare(normalize(v))
+	case a.ResponseTimeUnder > 0:
+		if resp.Duration >= a.ResponseTimeUnder {
+			return fmt.Errorf("took %s, limit %s", resp.Duration.Round(time.Millisecond), a.ResponseTimeUnder)
+		}
+	default:
+		schema := a.Schema
+		if a.SchemaFile != "" {
+			path := a.SchemaFile
+			if baseDir != "" && !filepath.IsAbs(path) {
+				path = filepath.Join(baseDir, path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading schema: %w", err)
+			}
+			if err := json.Unmarshal(data, &schema); err != nil {
+				return fmt.Errorf("parsing schema %s: %w", a.SchemaFile, err)
+			}
+		}
+		var body interface{}
+		if err := json.Unmarshal(resp.Body, &body); err != nil {
+			return fmt.Errorf("body is not JSON: %w", err)
+		}
+		if errs := validateSchema(normalize(schema), body, "$"); len(errs) > 0 {
+			return errors.New(strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// compare applies the operator to an actual value: a header string or a
+// decoded JSON value.
+func (a Assertion) compare(actual interface{}) error {
+	switch {
+	case a.Exists != nil:
+		if !*a.Exists {
+			return fmt.Errorf("expected nothing, got %s", compact(actual))
+		}
+	case a.Equals != nil:
+		want := normalize(a.Equals)
+		if s, ok := actual.(string); ok {
+			// Headers and JSON strings compare as text, so equals: 200
+			// matches "200"
+			if s != jsonquery.String(want) {
+				return fmt.Errorf("expected %s, got %s", compact(want), compact(actual))
+			}
+			return nil
+		}
+		if !reflect.DeepEqual(actual, want) {
+			return fmt.Errorf("expected %s, got %s", compact(want), compact(actual))
+		}
+	case a.Contains != nil:
+		want := normalize(a.Contains)
+		switch v := actual.(type) {
+		case string:
+			if !strings.Contains(v, jsonquery.String(want)) {
+				return fmt.Errorf("%s does not contain %s", compact(actual), compact(want))
+			}
+		case []interface{}:
+			for _, item := range v {
+				if reflect.DeepEqual(item, want) {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s does not contain %s", compact(actual), compact(want))
+		case map[string]interface{}:
+			if _, ok := v[jsonquery.String(want)]; !ok {
+				return fmt.Errorf("%s has no key %s", compact(actual), compact(want))
+			}
+		default:
+			return fmt.Errorf("contains needs a string, array or object, got %s", compact(actual))
+		}
+	case a.Matches != "":
+		re := regexp.MustCompile(a.Matches) // checked by Validate
+		s, ok := actual.(string)
+		if !ok {
+			s = compact(actual)
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("%s does not match /%s/", compact(actual), a.Matches)
+		}
+	}
+	return nil
+}
+
+// normalize converts YAML and json.Number values to the types
+// encoding/json decodes into, so they compare with reflect.DeepEqual.
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// compact renders a value as short JSON for messages.
+func compact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if s := string(data); len(s) <= 80 {
+		return s
+	}
+	return string(data[:77]) + "..."
+}
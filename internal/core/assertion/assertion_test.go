@@ -0,0 +1,110 @@
+package assertion
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestEvaluate(t *testing.T) {
+	dir := t.TempDir()
+	schema := `{"type": "object", "required": ["id", "tags"], "properties": {"id": {"type": "integer", "minimum": 1}, "tags": {"type": "array", "items": {"type": "string"}}}}`
+	if err := os.WriteFile(filepath.Join(dir, "user.json"), []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var assertions []Assertion
+	src := `
+- status: 200
+- header: content-type
+  matches: json
+- jsonpath: $.id
+  equals: 42
+- jsonpath: $.name
+  equals: Ada
+- jsonpath: $.tags
+  contains: admin
+- jsonpath: $.deleted
+  exists: false
+- response_time_under: 500ms
+- schema_file: user.json
+- name: inline schema
+  schema:
+    type: object
+    additionalProperties: false
+    properties:
+      id: { type: string }
+- status: 201
+- jsonpath: $.tags
+  contains: owner
+`
+	if err := yaml.Unmarshal([]byte(src), &assertions); err != nil {
+		t.Fatal(err)
+	}
+	resp := Response{
+		StatusCode: 200,
+		Headers:    http.Header{"Content-Type": {"application/json"}},
+		Body:       []byte(`{"id": 42, "name": "Ada", "tags": ["admin", "dev"]}`),
+		Duration:   120 * time.Millisecond,
+	}
+	results := Evaluate(assertions, resp, dir)
+
+	wantPassed := []bool{true, true, true, true, true, true, true, true, false, false, false}
+	for i, r := range results {
+		if r.Passed != wantPassed[i] {
+			t.Errorf("%s: passed = %v (%s), want %v", r.Name, r.Passed, r.Error, wantPassed[i])
+		}
+	}
+	if results[2].Name != "$.id equals 42" || results[8].Name != "inline schema" {
+		t.Errorf("names = %q, %q", results[2].Name, results[8].Name)
+	}
+	if !strings.Contains(results[8].Error, `$.id: expected string, got integer`) ||
+		!strings.Contains(results[8].Error, `unexpected property "name"`) {
+		t.Errorf("schema error = %q", results[8].Error)
+	}
+	if results[9].Error != "expected status 201, got 200" {
+		t.Errorf("status error = %q", results[9].Error)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := true
+	tests := []struct {
+		a    Assertion
+		want string
+	}{
+		{Assertion{Status: 200}, ""},
+		{Assertion{Header: "ETag", Exists: &valid}, ""},
+		{Assertion{}, "exactly one of status"},
+		{Assertion{Status: 200, Header: "X"}, "exactly one of status"},
+		{Assertion{Header: "X"}, "exactly one of equals"},
+		{Assertion{Status: 200, Equals: 1}, "header and jsonpath checks only"},
+		{Assertion{JSONPath: "$.a", Matches: "("}, "invalid matches pattern"},
+		{Assertion{JSONPath: "$[", Equals: 1}, "invalid jsonpath"},
+	}
+	for _, tt := range tests {
+		err := tt.a.Validate()
+		if (err == nil) != (tt.want == "") || (err != nil && !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%+v: err = %v, want %q", tt.a, err, tt.want)
+		}
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string", "minLength": 3.0, "pattern": "^[a-z]+$"},
+			map[string]interface{}{"type": "number", "exclusiveMaximum": 10.0},
+		},
+	}
+	for value, ok := range map[interface{}]bool{"abc": true, "ab": false, "ABC": false, 9.5: true, 10.0: false, true: false} {
+		if errs := validateSchema(schema, value, "$"); (len(errs) == 0) != ok {
+			t.Errorf("%v: errors = %v, want valid = %v", value, errs, ok)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package assertion
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// validateSchema checks value against a JSON Schema subset: type, enum,
+// const, properties, required, additionalProperties, items, pattern, the
+// min/max length, items and properties counts, numeric bounds, and allOf,
+// anyOf and oneOf. Both arguments are decoded JSON. It returns one message
+// per violation, prefixed with the value's path.
+func validateSchema(schema interface{}, value interface{}, path string) []string {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		// true/false schemas accept or reject everything
+		if b, ok := schema.(bool); ok && !b {
+			return []string{path + ": not allowed"}
+		}
+		return nil
+	}
+	var errs []string
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, value) {
+		fail("expected %s, got %s", typeList(t), jsonType(value))
+		return errs
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("%s is not one of %s", compact(value), compact(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, value) {
+		fail("expected %s, got %s", compact(c), compact(value))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		props, _ := s["properties"].(map[string]interface{})
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, ok := v[name]; !ok {
+					fail("missing required property %q", name)
+				}
+			}
+		}
+		for _, name := range sortedKeys(v) {
+			if sub, ok := props[name]; ok {
+				errs = append(errs, validateSchema(sub, v[name], path+"."+name)...)
+				continue
+			}
+			switch extra := s["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					fail("unexpected property %q", name)
+				}
+			case map[string]interface{}:
+				errs = append(errs, validateSchema(extra, v[name], path+"."+name)...)
+			}
+		}
+		checkCount(s, "minProperties", "maxProperties", len(v), "properties", fail)
+	case []interface{}:
+		if items, ok := s["items"]; ok {
+			for i, item := range v {
+				errs = append(errs, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+		checkCount(s, "minItems", "maxItems", len(v), "items", fail)
+	case string:
+		checkCount(s, "minLength", "maxLength", utf8.RuneCountInString(v), "characters", fail)
+		if pattern, ok := s["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err != nil {
+				fail("invalid pattern %q: %v", pattern, err)
+			} else if !re.MatchString(v) {
+				fail("%s does not match /%s/", compact(v), pattern)
+			}
+		}
+	case float64:
+		if m, ok := s["minimum"].(float64); ok && v < m {
+			fail("%v is less than %v", v, m)
+		}
+		if m, ok := s["maximum"].(float64); ok && v > m {
+			fail("%v is greater than %v", v, m)
+		}
+		if m, ok := s["exclusiveMinimum"].(float64); ok && v <= m {
+			fail("%v is not greater than %v", v, m)
+		}
+		if m, ok := s["exclusiveMaximum"].(float64); ok && v >= m {
+			fail("%v is not less than %v", v, m)
+		}
+	}
+
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			errs = append(errs, validateSchema(sub, value, path)...)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		if countValid(anyOf, value, path) == 0 {
+			fail("matches none of the anyOf schemas")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		if n := countValid(oneOf, value, path); n != 1 {
+			fail("matches %d of the oneOf schemas, want exactly 1", n)
+		}
+	}
+	return errs
+}
+
+func countValid(schemas []interface{}, value interface{}, path string) int {
+	n := 0
+	for _, sub := range schemas {
+		if len(validateSchema(sub, value, path)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func checkCount(s map[string]interface{}, minKey, maxKey string, n int, unit string, fail func(string, ...interface{})) {
+	if m, ok := s[minKey].(float64); ok && float64(n) < m {
+		fail("has %d %s, want at least %v", n, unit, m)
+	}
+	if m, ok := s[maxKey].(float64); ok && float64(n) > m {
+		fail("has %d %s, want at most %v", n, unit, m)
+	}
+}
+
+func matchesType(t interface{}, value interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		actual := jsonType(value)
+		return actual == t || (t == "number" && actual == "integer")
+	case []interface{}:
+		for _, option := range t {
+			if matchesType(option, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func typeList(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		names := make([]string, len(list))
+		for i, name := range list {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+// jsonType names a decoded JSON value's type; whole numbers are integers.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
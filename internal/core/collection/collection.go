@@ -1,15 +1,25 @@
 package collection
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/sadopc/gottp/internal/core/assertion"
+	"github.com/sadopc/gottp/internal/core/retry"
+	gotls "github.com/sadopc/gottp/internal/core/tls"
+)
 
 // Collection represents a collection of API requests.
 type Collection struct {
+	SchemaVersion int `yaml:"schema_version,omitempty"` // file format; see SchemaVersion
+
 	Name      string            `yaml:"name"`
 	Version   string            `yaml:"version"`
 	Auth      *Auth             `yaml:"auth,omitempty"`
 	Variables map[string]string `yaml:"variables,omitempty"`
+	Retry     *retry.Policy     `yaml:"retry,omitempty"`
 	Items     []Item            `yaml:"items"`
 	Workflows []Workflow        `yaml:"workflows,omitempty"`
+	Lint      *LintConfig       `yaml:"lint,omitempty"` // rules for gottp lint
 }
 
 // Item is a union type: either a Folder or a Request.
@@ -20,8 +30,9 @@ type Item struct {
 
 // Folder groups related requests.
 type Folder struct {
-	Name  string `yaml:"name"`
-	Items []Item `yaml:"items,omitempty"`
+	Name      string            `yaml:"name"`
+	Variables map[string]string `yaml:"variables,omitempty"` // override environment and collection variables for the folder's requests
+	Items     []Item            `yaml:"items,omitempty"`
 }
 
 // Request represents an API request.
@@ -34,6 +45,7 @@ type Request struct {
 
 	Params  []KVPair `yaml:"params,omitempty"`
 	Headers []KVPair `yaml:"headers,omitempty"`
+	Cookies []KVPair `yaml:"cookies,omitempty"` // sent in the Cookie header, encoded as needed
 	Auth    *Auth    `yaml:"auth,omitempty"`
 	Body    *Body    `yaml:"body,omitempty"`
 
@@ -44,13 +56,38 @@ type Request struct {
 	PreScript  string `yaml:"pre_script,omitempty"`
 	PostScript string `yaml:"post_script,omitempty"`
 
-	ProxyURL string `yaml:"proxy_url,omitempty"`
+	// Assertions are declarative checks reported alongside script tests
+	Assertions []assertion.Assertion `yaml:"assertions,omitempty"`
+
+	ProxyURL string        `yaml:"proxy_url,omitempty"`
+	TLS      *gotls.Config `yaml:"tls,omitempty"`   // overrides environment and global TLS settings
+	Retry    *retry.Policy `yaml:"retry,omitempty"` // overrides the collection and global retry policy
+
+	ExpectContinue bool `yaml:"expect_continue,omitempty"`
+	Chunked        bool `yaml:"chunked,omitempty"`
+
+	// NoCache bypasses the response cache: no conditional headers are
+	// added from earlier responses
+	NoCache bool `yaml:"no_cache,omitempty"`
+
+	// Links point to documentation for the endpoint: runbooks, API
+	// reference pages, dashboards
+	Links []Link `yaml:"links,omitempty"`
+
+	// Mock sets the responses `gottp mock` serves for this request
+	Mock *MockConfig `yaml:"mock,omitempty"`
+
+	// Variables override folder, environment and collection variables
+	Variables map[string]string `yaml:"variables,omitempty"`
+
+	// Line is the request's line in the file it was loaded from, 0 if unknown.
+	Line int `yaml:"-"`
 }
 
 // NewRequest creates a new request with defaults.
 func NewRequest(name, method, url string) *Request {
 	return &Request{
-		ID:       uuid.New().String(),
+		ID:       NewID(name),
 		Name:     name,
 		Protocol: "http",
 		Method:   method,
@@ -65,6 +102,12 @@ type KVPair struct {
 	Enabled bool   `yaml:"enabled"`
 }
 
+// Link is a named URL shown in the editor's Docs view.
+type Link struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
 // Auth represents authentication configuration.
 type Auth struct {
 	Type    string      `yaml:"type"` // none, basic, bearer, apikey, oauth2, awsv4, digest
@@ -122,28 +165,122 @@ type DigestAuth struct {
 	Password string `yaml:"password"`
 }
 
-// Body represents a request body.
+// Body represents a request body. Form bodies list their Fields; a
+// binary-file body streams File. File paths are relative to the collection
+// file.
 type Body struct {
-	Type    string `yaml:"type"` // none, json, xml, text, form, multipart
-	Content string `yaml:"content"`
+	Type    string      `yaml:"type"` // none, json, xml, text, form-urlencoded (or form), multipart, binary-file
+	Content string      `yaml:"content,omitempty"`
+	Fields  []BodyField `yaml:"fields,omitempty"`
+	File    string      `yaml:"file,omitempty"`
+}
+
+// BodyField is a form-urlencoded or multipart field. Multipart fields with
+// a File upload that file instead of Value.
+type BodyField struct {
+	Key     string `yaml:"key"`
+	Value   string `yaml:"value,omitempty"`
+	File    string `yaml:"file,omitempty"`
+	Enabled bool   `yaml:"enabled"`
+}
+
+// Body types with special encoding.
+const (
+	BodyFormURLEncoded = "form-urlencoded"
+	BodyMultipart      = "multipart"
+	BodyBinaryFile     = "binary-file"
+)
+
+// Kind returns the body type with legacy aliases normalized.
+func (b *Body) Kind() string {
+	if b == nil {
+		return "none"
+	}
+	if b.Type == "form" {
+		return BodyFormURLEncoded
+	}
+	return b.Type
+}
+
+// IsEmpty reports whether the body sends nothing.
+func (b *Body) IsEmpty() bool {
+	if b == nil {
+		return true
+	}
+	switch b.Kind() {
+	case "none":
+		return true
+	case BodyBinaryFile:
+		return b.File == ""
+	case BodyFormURLEncoded, BodyMultipart:
+		return len(b.Fields) == 0 && b.Content == ""
+	}
+	return b.Content == ""
 }
 
 // GraphQLConfig holds GraphQL-specific settings.
 type GraphQLConfig struct {
 	Query     string `yaml:"query"`
 	Variables string `yaml:"variables,omitempty"`
+
+	// PersistedQuery sends only the query's SHA-256 hash (Automatic
+	// Persisted Queries), and the full query when the server doesn't know it.
+	PersistedQuery bool `yaml:"persisted_query,omitempty"`
 }
 
 // WebSocketConfig holds WebSocket-specific settings.
 type WebSocketConfig struct {
+	// Messages are sent in order once the connection is established.
 	Messages []WSMessage `yaml:"messages,omitempty"`
+	// PingInterval sends keepalive pings while connected (0 disables).
+	PingInterval time.Duration `yaml:"ping_interval,omitempty"`
+	// Reconnect re-dials after the connection drops, waiting Backoff
+	// (doubled per try) for up to MaxAttempts tries, then sends Messages
+	// again so subscriptions are restored. On is ignored.
+	Reconnect *retry.Policy `yaml:"reconnect,omitempty"`
 }
 
 // WSMessage represents a pre-defined WebSocket message.
 type WSMessage struct {
-	Name    string `yaml:"name"`
-	Content string `yaml:"content"`
-	IsJSON  bool   `yaml:"is_json"`
+	Name    string        `yaml:"name"`
+	Content string        `yaml:"content"`
+	IsJSON  bool          `yaml:"is_json"`
+	Delay   time.Duration `yaml:"delay,omitempty"` // wait before sending
+	Expect  *WSExpect     `yaml:"expect,omitempty"`
+}
+
+// WSExpect asserts on the first message received after a send that matches.
+// All non-empty conditions must hold.
+type WSExpect struct {
+	Contains string        `yaml:"contains,omitempty"`
+	Equals   string        `yaml:"equals,omitempty"`
+	Matches  string        `yaml:"matches,omitempty"` // regular expression
+	Timeout  time.Duration `yaml:"timeout,omitempty"` // default 5s
+}
+
+// MockConfig holds the canned responses of a request for `gottp mock`.
+// Without examples, the request body is served.
+type MockConfig struct {
+	Status   int           `yaml:"status,omitempty"` // status of the request body response, 200 if unset
+	Select   string        `yaml:"select,omitempty"` // first (default), round-robin or sequence
+	Examples []MockExample `yaml:"examples,omitempty"`
+}
+
+// MockExample is a named response. An example with When is only served to
+// requests matching it; the others take turns according to Select. Any
+// example can be picked by name with ?__scenario= or X-Mock-Scenario.
+type MockExample struct {
+	Name    string            `yaml:"name"`
+	Status  int               `yaml:"status,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+	When    *MockMatch        `yaml:"when,omitempty"`
+}
+
+// MockMatch selects requests by exact header and query parameter values.
+type MockMatch struct {
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Query   map[string]string `yaml:"query,omitempty"`
 }
 
 // GRPCConfig holds gRPC-specific settings.
@@ -151,6 +288,17 @@ type GRPCConfig struct {
 	Service  string   `yaml:"service"`
 	Method   string   `yaml:"method"`
 	Metadata []KVPair `yaml:"metadata,omitempty"`
+
+	// grpc, grpc-web or connect; empty picks gRPC-Web for http(s):// URLs
+	// and native gRPC otherwise
+	Transport string `yaml:"transport,omitempty"`
+
+	// Call options; zero values keep the client's defaults
+	Deadline       time.Duration `yaml:"deadline,omitempty"` // replaces the request timeout
+	Gzip           bool          `yaml:"gzip,omitempty"`
+	MaxSendMsgSize int           `yaml:"max_send_msg_size,omitempty"` // bytes
+	MaxRecvMsgSize int           `yaml:"max_recv_msg_size,omitempty"` // bytes
+	WaitForReady   bool          `yaml:"wait_for_ready,omitempty"`
 }
 
 // Workflow defines a sequence of requests to execute with data passing.
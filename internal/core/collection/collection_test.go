@@ -3,7 +3,9 @@ package collection
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 const sampleYAML = `
@@ -113,6 +115,115 @@ func TestSaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestSaveIfChanged(t *testing.T) {
+	col := &Collection{Name: "Unchanged", Items: []Item{{Request: NewRequest("Ping", "GET", "https://example.com")}}}
+	path := filepath.Join(t.TempDir(), "api.gottp.yaml")
+
+	hash, written, err := SaveIfChanged(col, path, "")
+	if err != nil || !written {
+		t.Fatalf("first save: written=%v err=%v", written, err)
+	}
+	if fp, _ := Fingerprint(col); fp != hash {
+		t.Errorf("fingerprint %s does not match saved hash %s", fp, hash)
+	}
+
+	// Mark the file so a rewrite would show
+	if err := os.WriteFile(path, []byte("name: Hand edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	again, written, err := SaveIfChanged(col, path, hash)
+	if err != nil || written || again != hash {
+		t.Fatalf("unchanged save: hash=%s written=%v err=%v", again, written, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "name: Hand edited\n" {
+		t.Errorf("unchanged collection was rewritten:\n%s", data)
+	}
+
+	col.Items[0].Request.URL = "https://example.com/v2"
+	changed, written, err := SaveIfChanged(col, path, hash)
+	if err != nil || !written || changed == hash {
+		t.Fatalf("changed save: written=%v err=%v", written, err)
+	}
+
+	os.Remove(path)
+	if _, written, _ := SaveIfChanged(col, path, changed); !written {
+		t.Error("a deleted file should be written again")
+	}
+}
+
+func TestLoadFromBytes_RecordsLines(t *testing.T) {
+	data := `name: Lines
+items:
+  - request:
+      name: First
+      url: http://a
+  - folder:
+      name: Nested
+      items:
+        - request:
+            name: Second
+            url: http://b
+`
+	col, err := LoadFromBytes([]byte(data))
+	if err != nil {
+		t.Fatalf("LoadFromBytes failed: %v", err)
+	}
+	if got := col.Items[0].Request.Line; got != 4 {
+		t.Errorf("first request line = %d, want 4", got)
+	}
+	if got := col.Items[1].Folder.Items[0].Request.Line; got != 10 {
+		t.Errorf("nested request line = %d, want 10", got)
+	}
+}
+
+func TestLoadWebSocketScript(t *testing.T) {
+	data := `
+name: WS
+items:
+  - request:
+      name: Chat
+      protocol: websocket
+      url: ws://localhost/ws
+      websocket:
+        ping_interval: 30s
+        messages:
+          - name: subscribe
+            content: '{"op":"subscribe"}'
+            is_json: true
+            delay: 250ms
+            expect:
+              contains: subscribed
+              timeout: 2s
+`
+	col, err := LoadFromBytes([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := col.Items[0].Request.WebSocket
+	if ws == nil || len(ws.Messages) != 1 {
+		t.Fatalf("unexpected websocket config: %+v", ws)
+	}
+	if ws.PingInterval != 30*time.Second {
+		t.Errorf("ping_interval = %v", ws.PingInterval)
+	}
+	msg := ws.Messages[0]
+	if msg.Delay != 250*time.Millisecond {
+		t.Errorf("delay = %v", msg.Delay)
+	}
+	if msg.Expect == nil || msg.Expect.Contains != "subscribed" || msg.Expect.Timeout != 2*time.Second {
+		t.Errorf("unexpected expect: %+v", msg.Expect)
+	}
+
+	path := filepath.Join(t.TempDir(), "ws.gottp.yaml")
+	if err := SaveToFile(col, path); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := os.ReadFile(path)
+	if !strings.Contains(string(saved), "delay: 250ms") {
+		t.Errorf("durations should be saved as strings:\n%s", saved)
+	}
+}
+
 func TestFlattenItems(t *testing.T) {
 	col, err := LoadFromBytes([]byte(sampleYAML))
 	if err != nil {
@@ -175,3 +286,69 @@ func TestNewRequest(t *testing.T) {
 		t.Errorf("expected POST, got %s", req.Method)
 	}
 }
+
+func TestLoadFromBytes_MigratesLegacySchema(t *testing.T) {
+	data := `name: Legacy
+items:
+  - folder:
+      name: Forms
+      items:
+        - request:
+            name: Login
+            url: http://a
+            body:
+              type: form
+`
+	col, err := LoadFromBytes([]byte(data))
+	if err != nil {
+		t.Fatalf("LoadFromBytes failed: %v", err)
+	}
+	if col.SchemaVersion != SchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", col.SchemaVersion, SchemaVersion)
+	}
+	if got := col.Items[0].Folder.Items[0].Request.Body.Type; got != "form-urlencoded" {
+		t.Errorf("body type = %q, want form-urlencoded", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "legacy.gottp.yaml")
+	if err := SaveToFile(col, path); err != nil {
+		t.Fatalf("SaveToFile failed: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(saved), "schema_version: 1\n") {
+		t.Errorf("saved file should lead with its schema version:\n%s", saved)
+	}
+
+	if _, err := LoadFromBytes([]byte("schema_version: 99\nname: Future\n")); err == nil {
+		t.Error("expected an error for a collection from a newer gottp")
+	}
+}
+
+func TestScopedVars(t *testing.T) {
+	get := &Request{Name: "Get", Variables: map[string]string{"id": "req"}}
+	list := &Request{Name: "List"}
+	top := &Request{Name: "Top"}
+	col := &Collection{Items: []Item{
+		{Folder: &Folder{Name: "API", Variables: map[string]string{"id": "api", "version": "v1"}, Items: []Item{
+			{Folder: &Folder{Name: "Users", Variables: map[string]string{"version": "v2"}, Items: []Item{
+				{Request: get},
+				{Request: list},
+			}}},
+		}}},
+		{Request: top},
+	}}
+
+	if path := FolderPath(col, get); len(path) != 2 || path[0].Name != "API" || path[1].Name != "Users" {
+		t.Fatalf("FolderPath = %v", path)
+	}
+	vars := ScopedVars(col, get)
+	if vars["id"] != "req" || vars["version"] != "v2" {
+		t.Errorf("request scope: %v", vars)
+	}
+	if vars := ScopedVars(col, list); vars["id"] != "api" || vars["version"] != "v2" {
+		t.Errorf("folder scope: %v", vars)
+	}
+	if vars := ScopedVars(col, top); vars != nil {
+		t.Errorf("top-level request without variables: %v", vars)
+	}
+}
@@ -0,0 +1,249 @@
+package collection
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Request diff kinds.
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// CollectionDiff is what changed between two versions of a collection.
+type CollectionDiff struct {
+	Changes  []FieldChange `json:"changes,omitempty"` // collection name, variables, auth, workflows
+	Requests []RequestDiff `json:"requests,omitempty"`
+}
+
+// Empty reports whether the versions are the same.
+func (d CollectionDiff) Empty() bool {
+	return len(d.Changes) == 0 && len(d.Requests) == 0
+}
+
+// RequestDiff is a request that was added, removed or changed.
+type RequestDiff struct {
+	Kind    string        `json:"kind"`
+	Path    string        `json:"path"` // e.g. "/Users/List Users", in the old version when removed
+	Method  string        `json:"method"`
+	URL     string        `json:"url"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is a field whose value changed, named by its YAML path such
+// as "url", "headers.Accept" or "body.content". An empty Old or New means
+// the field was added or removed.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Diff compares two versions of a collection. Requests are paired by ID,
+// then by folder path and name, then by method and URL, so renamed,
+// moved and re-imported requests show as changes rather than as a removal
+// and an addition. Changed requests and additions are listed in the order
+// of b, followed by removals.
+func Diff(a, b *Collection) CollectionDiff {
+	var d CollectionDiff
+	d.Changes = diffFields("", encodeNode(a), encodeNode(b), "items", "schema_version")
+
+	old := requestsOf(a)
+	cur := requestsOf(b)
+	pair := make(map[int]int) // index in cur -> index in old
+	taken := make(map[int]bool)
+	match := func(key func(FlatItem) string) {
+		index := make(map[string]int)
+		for i, item := range old {
+			if k := key(item); k != "" && !taken[i] {
+				if _, dup := index[k]; !dup {
+					index[k] = i
+				}
+			}
+		}
+		for j, item := range cur {
+			if _, ok := pair[j]; ok {
+				continue
+			}
+			if i, ok := index[key(item)]; ok && !taken[i] {
+				pair[j] = i
+				taken[i] = true
+			}
+		}
+	}
+	match(func(f FlatItem) string { return f.Request.ID })
+	match(func(f FlatItem) string { return f.Path })
+	match(func(f FlatItem) string { return f.Request.Method + " " + f.Request.URL })
+
+	for j, item := range cur {
+		i, ok := pair[j]
+		if !ok {
+			d.Requests = append(d.Requests, requestDiff(DiffAdded, item, nil))
+			continue
+		}
+		var changes []FieldChange
+		if old[i].Path != item.Path {
+			changes = append(changes, FieldChange{Field: "path", Old: old[i].Path, New: item.Path})
+		}
+		changes = append(changes, diffFields("", encodeNode(old[i].Request), encodeNode(item.Request), "name")...)
+		if len(changes) > 0 {
+			d.Requests = append(d.Requests, requestDiff(DiffChanged, item, changes))
+		}
+	}
+	for i, item := range old {
+		if !taken[i] {
+			d.Requests = append(d.Requests, requestDiff(DiffRemoved, item, nil))
+		}
+	}
+	return d
+}
+
+func requestsOf(col *Collection) []FlatItem {
+	var reqs []FlatItem
+	for _, item := range FlattenItems(col.Items, 0, "") {
+		if item.Request != nil {
+			reqs = append(reqs, item)
+		}
+	}
+	return reqs
+}
+
+func requestDiff(kind string, item FlatItem, changes []FieldChange) RequestDiff {
+	return RequestDiff{Kind: kind, Path: item.Path, Method: item.Request.Method, URL: item.Request.URL, Changes: changes}
+}
+
+func encodeNode(v any) *yaml.Node {
+	var n yaml.Node
+	if err := n.Encode(v); err != nil {
+		return nil
+	}
+	return &n
+}
+
+// keyedLists are lists of key/value pairs compared entry by entry.
+var keyedLists = map[string]bool{"params": true, "headers": true, "cookies": true, "fields": true}
+
+// diffFields compares two YAML values, descending into mappings and keyed
+// lists so that each change names the innermost field. Keys in skip are
+// ignored at the top level.
+func diffFields(prefix string, a, b *yaml.Node, skip ...string) []FieldChange {
+	if a != nil && b != nil && a.Kind == yaml.MappingNode && b.Kind == yaml.MappingNode {
+		var changes []FieldChange
+		for _, key := range unionKeys(a, b) {
+			if prefix == "" && slices.Contains(skip, key) {
+				continue
+			}
+			field := prefix + key
+			av, bv := mappingValue(a, key), mappingValue(b, key)
+			if keyedLists[key] && (isSequence(av) || isSequence(bv)) {
+				changes = append(changes, diffKeyed(field, av, bv, key == "headers")...)
+				continue
+			}
+			changes = append(changes, diffFields(field+".", av, bv)...)
+		}
+		return changes
+	}
+	if old, cur := nodeString(a), nodeString(b); old != cur {
+		return []FieldChange{{Field: strings.TrimSuffix(prefix, "."), Old: old, New: cur}}
+	}
+	return nil
+}
+
+// diffKeyed compares lists of {key, value, enabled} entries by key.
+// Header names are matched case-insensitively.
+func diffKeyed(field string, a, b *yaml.Node, foldCase bool) []FieldChange {
+	entries := func(n *yaml.Node) (keys []string, names, values map[string]string) {
+		names, values = make(map[string]string), make(map[string]string)
+		if n == nil || n.Kind != yaml.SequenceNode {
+			return nil, names, values
+		}
+		for _, e := range n.Content {
+			name := nodeString(mappingValue(e, "key"))
+			k := name
+			if foldCase {
+				k = strings.ToLower(k)
+			}
+			v := nodeString(mappingValue(e, "value"))
+			if f := nodeString(mappingValue(e, "file")); f != "" {
+				v = "@" + f
+			}
+			if nodeString(mappingValue(e, "enabled")) != "true" {
+				v += " (disabled)"
+			}
+			if prev, seen := values[k]; seen {
+				values[k] = prev + ", " + v
+				continue
+			}
+			keys = append(keys, k)
+			names[k], values[k] = name, v
+		}
+		return keys, names, values
+	}
+	aKeys, aNames, aValues := entries(a)
+	bKeys, bNames, bValues := entries(b)
+
+	var changes []FieldChange
+	for _, k := range bKeys {
+		if aValues[k] != bValues[k] {
+			changes = append(changes, FieldChange{Field: field + "." + bNames[k], Old: aValues[k], New: bValues[k]})
+		}
+	}
+	for _, k := range aKeys {
+		if _, ok := bValues[k]; !ok {
+			changes = append(changes, FieldChange{Field: field + "." + aNames[k], Old: aValues[k]})
+		}
+	}
+	return changes
+}
+
+// unionKeys returns the keys of b, then those only in a.
+func unionKeys(a, b *yaml.Node) []string {
+	var keys []string
+	for i := 0; i+1 < len(b.Content); i += 2 {
+		keys = append(keys, b.Content[i].Value)
+	}
+	for i := 0; i+1 < len(a.Content); i += 2 {
+		if !slices.Contains(keys, a.Content[i].Value) {
+			keys = append(keys, a.Content[i].Value)
+		}
+	}
+	return keys
+}
+
+func isSequence(n *yaml.Node) bool {
+	return n != nil && n.Kind == yaml.SequenceNode
+}
+
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// nodeString renders a value for display: scalars as they are, lists and
+// mappings as one line of flow-style YAML.
+func nodeString(n *yaml.Node) string {
+	if n == nil {
+		return ""
+	}
+	if n.Kind == yaml.ScalarNode {
+		return n.Value
+	}
+	flow := *n
+	flow.Style = yaml.FlowStyle
+	data, err := yaml.Marshal(&flow)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
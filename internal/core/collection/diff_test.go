@@ -0,0 +1,100 @@
+package collection
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	old, err := LoadFromBytes([]byte(sampleYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur, _ := LoadFromBytes([]byte(sampleYAML))
+
+	if d := Diff(old, cur); !d.Empty() {
+		t.Fatalf("identical collections differ: %+v", d)
+	}
+
+	cur.Variables["base_url"] = "https://staging.example.com"
+	users := cur.Items[0].Folder
+	list := users.Items[0].Request
+	list.URL = "{{base_url}}/v2/users"
+	list.Headers = append(list.Headers, KVPair{Key: "X-Trace", Value: "1", Enabled: true})
+	list.Params = nil
+	list.Auth = &Auth{Type: "bearer", Bearer: &BearerAuth{Token: "t"}}
+	create := users.Items[1].Request
+	create.Name = "Add User"
+	create.Body.Content = `{"name":"new"}`
+	cur.Items = cur.Items[:1] // drop Products
+	users.Items = append(users.Items, Item{Request: &Request{ID: "new", Name: "Delete User", Method: "DELETE", URL: "/users/1"}})
+
+	d := Diff(old, cur)
+	if len(d.Changes) != 1 || d.Changes[0] != (FieldChange{Field: "variables.base_url", Old: "https://api.example.com", New: "https://staging.example.com"}) {
+		t.Errorf("collection changes = %+v", d.Changes)
+	}
+
+	kinds := map[string]RequestDiff{}
+	for _, r := range d.Requests {
+		kinds[r.Kind+" "+r.Path] = r
+	}
+	if len(d.Requests) != 4 {
+		t.Errorf("requests = %+v", d.Requests)
+	}
+	if _, ok := kinds["added /Users/Delete User"]; !ok {
+		t.Error("missing added request")
+	}
+	if _, ok := kinds["removed /Products/List Products"]; !ok {
+		t.Error("missing removed request")
+	}
+
+	fields := func(r RequestDiff) map[string]FieldChange {
+		m := map[string]FieldChange{}
+		for _, c := range r.Changes {
+			m[c.Field] = c
+		}
+		return m
+	}
+	listChanges := fields(kinds["changed /Users/List Users"])
+	if c := listChanges["url"]; c.Old != "{{base_url}}/users" || c.New != "{{base_url}}/v2/users" {
+		t.Errorf("url change = %+v", c)
+	}
+	if c := listChanges["headers.X-Trace"]; c.Old != "" || c.New != "1" {
+		t.Errorf("header change = %+v", c)
+	}
+	if c := listChanges["params.page"]; c.Old != "1" || c.New != "" {
+		t.Errorf("param change = %+v", c)
+	}
+	if c, ok := listChanges["auth"]; !ok || c.New != "{type: bearer, bearer: {token: t}}" {
+		t.Errorf("auth change = %+v", c)
+	}
+	if len(listChanges) != 4 {
+		t.Errorf("List Users changes = %+v", listChanges)
+	}
+
+	createChanges := fields(kinds["changed /Users/Add User"])
+	if c := createChanges["path"]; c.Old != "/Users/Create User" {
+		t.Errorf("rename = %+v", c)
+	}
+	if c := createChanges["body.content"]; c.New != `{"name":"new"}` {
+		t.Errorf("body change = %+v", c)
+	}
+}
+
+func TestDiff_PairsByMethodAndURL(t *testing.T) {
+	// Re-imported requests get new IDs and may be renamed
+	old := &Collection{Items: []Item{{Request: &Request{ID: "a", Name: "listUsers", Method: "GET", URL: "/users"}}}}
+	cur := &Collection{Items: []Item{{Request: &Request{ID: "b", Name: "List users", Method: "GET", URL: "/users"}}}}
+
+	d := Diff(old, cur)
+	if len(d.Requests) != 1 || d.Requests[0].Kind != DiffChanged {
+		t.Fatalf("requests = %+v", d.Requests)
+	}
+	if got := d.Requests[0].Changes; len(got) != 2 || got[0].Field != "path" || got[1].Field != "id" {
+		t.Errorf("changes = %+v", got)
+	}
+
+	cur.Items[0].Request.Headers = []KVPair{{Key: "accept", Value: "json", Enabled: false}}
+	old.Items[0].Request.Headers = []KVPair{{Key: "Accept", Value: "json", Enabled: true}}
+	d = Diff(old, cur)
+	if c := d.Requests[0].Changes[2]; c.Field != "headers.accept" || c.Old != "json" || c.New != "json (disabled)" {
+		t.Errorf("header change = %+v", c)
+	}
+}
@@ -0,0 +1,146 @@
+package collection
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Duplicate is a group of requests that send the same thing: the same
+// protocol, method, URL, enabled params and body. Names, headers, auth and
+// scripts may differ.
+type Duplicate struct {
+	Method string
+	URL    string
+	Items  []FlatItem // in collection order
+}
+
+// FindDuplicates groups the requests of col that send identical requests,
+// in the order each group's first request appears.
+func FindDuplicates(col *Collection) []Duplicate {
+	if col == nil {
+		return nil
+	}
+	var groups []Duplicate
+	index := make(map[string]int)
+	for _, item := range FlattenItems(col.Items, 0, "") {
+		if item.Request == nil {
+			continue
+		}
+		sig := requestSignature(item.Request)
+		i, ok := index[sig]
+		if !ok {
+			i = len(groups)
+			index[sig] = i
+			groups = append(groups, Duplicate{
+				Method: strings.ToUpper(item.Request.Method),
+				URL:    item.Request.URL,
+			})
+		}
+		groups[i].Items = append(groups[i].Items, item)
+	}
+
+	var dups []Duplicate
+	for _, g := range groups {
+		if len(g.Items) > 1 {
+			dups = append(dups, g)
+		}
+	}
+	return dups
+}
+
+// MergeDuplicates removes the requests in remove from col, keeping keep
+// with its own scripts, headers and assertions. Workflow steps that ran a
+// removed request run keep instead. It returns how many requests were
+// removed.
+func MergeDuplicates(col *Collection, keep *Request, remove []*Request) int {
+	if col == nil || keep == nil {
+		return 0
+	}
+	drop := make(map[*Request]bool)
+	for _, req := range remove {
+		if req != nil && req != keep {
+			drop[req] = true
+		}
+	}
+	n := 0
+	col.Items = dropRequests(col.Items, drop, &n)
+
+	// Steps refer to requests by name, so only follow names nothing else uses
+	names := make(map[string]bool)
+	walkRequests(col.Items, "", func(req *Request, _ string) {
+		names[req.Name] = true
+	})
+	for req := range drop {
+		if names[req.Name] {
+			continue
+		}
+		for i := range col.Workflows {
+			for j := range col.Workflows[i].Steps {
+				if col.Workflows[i].Steps[j].Request == req.Name {
+					col.Workflows[i].Steps[j].Request = keep.Name
+				}
+			}
+		}
+	}
+	return n
+}
+
+func dropRequests(items []Item, drop map[*Request]bool, n *int) []Item {
+	out := items[:0]
+	for _, item := range items {
+		if item.Request != nil && drop[item.Request] {
+			*n++
+			continue
+		}
+		if item.Folder != nil {
+			item.Folder.Items = dropRequests(item.Folder.Items, drop, n)
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// requestSignature identifies what a request sends. JSON bodies are
+// compacted so formatting differences don't hide a duplicate.
+func requestSignature(req *Request) string {
+	protocol := req.Protocol
+	if protocol == "" {
+		protocol = "http"
+	}
+	parts := []string{protocol, strings.ToUpper(req.Method), strings.TrimSpace(req.URL)}
+	for _, p := range req.Params {
+		if p.Enabled {
+			parts = append(parts, "param:"+p.Key+"="+p.Value)
+		}
+	}
+
+	if b := req.Body; !b.IsEmpty() {
+		parts = append(parts, "body:"+b.Kind(), compactJSON(b.Content), b.File)
+		for _, f := range b.Fields {
+			if f.Enabled {
+				parts = append(parts, "field:"+f.Key+"="+f.Value+"@"+f.File)
+			}
+		}
+	}
+	if g := req.GraphQL; g != nil {
+		parts = append(parts, "query:"+strings.Join(strings.Fields(g.Query), " "), compactJSON(g.Variables))
+	}
+	if g := req.GRPC; g != nil {
+		parts = append(parts, "grpc:"+g.Service+"/"+g.Method)
+	}
+	if ws := req.WebSocket; ws != nil {
+		for _, m := range ws.Messages {
+			parts = append(parts, "message:"+compactJSON(m.Content))
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func compactJSON(s string) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(s)); err != nil {
+		return s
+	}
+	return buf.String()
+}
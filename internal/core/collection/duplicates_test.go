@@ -0,0 +1,61 @@
+package collection
+
+import "testing"
+
+func TestFindAndMergeDuplicates(t *testing.T) {
+	list := NewRequest("List Users", "GET", "{{base_url}}/users")
+	again := NewRequest("Users again", "get", "{{base_url}}/users")
+	again.PostScript = `gottp.test("ok", () => true)`
+	create := NewRequest("Create", "POST", "{{base_url}}/users")
+	create.Body = &Body{Type: "json", Content: `{"name": "a"}`}
+	createCopy := NewRequest("Create copy", "POST", "{{base_url}}/users")
+	createCopy.Body = &Body{Type: "json", Content: "{\n  \"name\": \"a\"\n}"}
+	other := NewRequest("Create other", "POST", "{{base_url}}/users")
+	other.Body = &Body{Type: "json", Content: `{"name": "b"}`}
+
+	col := &Collection{
+		Name: "API",
+		Items: []Item{
+			{Request: list},
+			{Folder: &Folder{Name: "Users", Items: []Item{
+				{Request: create},
+				{Request: again},
+				{Request: other},
+			}}},
+			{Request: createCopy},
+		},
+		Workflows: []Workflow{{Name: "Flow", Steps: []WorkflowStep{{Request: "List Users"}, {Request: "Create copy"}}}},
+	}
+
+	dups := FindDuplicates(col)
+	if len(dups) != 2 {
+		t.Fatalf("expected 2 duplicate groups, got %+v", dups)
+	}
+	if dups[0].Method != "GET" || len(dups[0].Items) != 2 || dups[0].Items[1].Request != again || dups[0].Items[1].Path != "/Users/Users again" {
+		t.Errorf("first group = %+v", dups[0])
+	}
+	if len(dups[1].Items) != 2 || dups[1].Items[0].Request != create || dups[1].Items[1].Request != createCopy {
+		t.Errorf("JSON bodies differing only in formatting should match: %+v", dups[1])
+	}
+
+	// Keep the copy with scripts; the workflow follows it
+	if n := MergeDuplicates(col, again, []*Request{list, again}); n != 1 {
+		t.Errorf("removed %d requests, want 1", n)
+	}
+	if n := MergeDuplicates(col, create, []*Request{createCopy}); n != 1 {
+		t.Errorf("removed %d requests, want 1", n)
+	}
+	if len(col.Items) != 1 || len(col.Items[0].Folder.Items) != 3 {
+		t.Fatalf("unexpected tree after merge: %+v", col.Items)
+	}
+	if again.PostScript == "" {
+		t.Error("kept request lost its script")
+	}
+	steps := col.Workflows[0].Steps
+	if steps[0].Request != "Users again" || steps[1].Request != "Create" {
+		t.Errorf("workflow steps = %+v", steps)
+	}
+	if len(FindDuplicates(col)) != 0 {
+		t.Error("expected no duplicates after merging")
+	}
+}
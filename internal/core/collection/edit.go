@@ -0,0 +1,153 @@
+package collection
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrMoveIntoSelf is returned when a folder would be moved into itself or
+// one of its subfolders.
+var ErrMoveIntoSelf = errors.New("cannot move a folder into itself")
+
+// ErrNotFound is returned when an item is not part of the collection.
+var ErrNotFound = errors.New("item not in collection")
+
+// sameItem reports whether a and b hold the same request or folder.
+func sameItem(a, b Item) bool {
+	if a.Request != nil {
+		return a.Request == b.Request
+	}
+	return a.Folder != nil && a.Folder == b.Folder
+}
+
+// AddItem appends item to folder, or to the top level when folder is nil.
+func AddItem(col *Collection, folder *Folder, item Item) {
+	if folder == nil {
+		col.Items = append(col.Items, item)
+		return
+	}
+	folder.Items = append(folder.Items, item)
+}
+
+// RemoveItem removes item from col, wherever it is, and reports whether it
+// was found. Removing a folder removes everything in it.
+func RemoveItem(col *Collection, item Item) bool {
+	return removeFrom(&col.Items, item)
+}
+
+func removeFrom(items *[]Item, target Item) bool {
+	for i, item := range *items {
+		if sameItem(item, target) {
+			*items = append((*items)[:i], (*items)[i+1:]...)
+			return true
+		}
+		if item.Folder != nil && removeFrom(&item.Folder.Items, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParentFolder returns the folder holding item, or nil for a top-level
+// item. ok is false when item is not in col.
+func ParentFolder(col *Collection, item Item) (parent *Folder, ok bool) {
+	return parentIn(col.Items, nil, item)
+}
+
+func parentIn(items []Item, parent *Folder, target Item) (*Folder, bool) {
+	for _, item := range items {
+		if sameItem(item, target) {
+			return parent, true
+		}
+		if item.Folder != nil {
+			if p, ok := parentIn(item.Folder.Items, item.Folder, target); ok {
+				return p, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// MoveItem moves item to the end of folder, or of the top level when
+// folder is nil.
+func MoveItem(col *Collection, item Item, folder *Folder) error {
+	if item.Folder != nil && folder != nil && within(item.Folder, Item{Folder: folder}) {
+		return ErrMoveIntoSelf
+	}
+	if !RemoveItem(col, item) {
+		return ErrNotFound
+	}
+	AddItem(col, folder, item)
+	return nil
+}
+
+// PlaceItem moves item to just below at as the sidebar shows them: first
+// inside at when it is a folder, right after it otherwise. A zero at
+// places item at the end of the top level.
+func PlaceItem(col *Collection, item, at Item) error {
+	if at == (Item{}) {
+		return MoveItem(col, item, nil)
+	}
+	if sameItem(item, at) {
+		return nil
+	}
+	if item.Folder != nil && within(item.Folder, at) {
+		return ErrMoveIntoSelf
+	}
+	parent, ok := ParentFolder(col, at)
+	if !ok || !RemoveItem(col, item) {
+		return ErrNotFound
+	}
+
+	if at.Folder != nil {
+		at.Folder.Items = slices.Insert(at.Folder.Items, 0, item)
+		return nil
+	}
+	items := &col.Items
+	if parent != nil {
+		items = &parent.Items
+	}
+	i := slices.IndexFunc(*items, func(it Item) bool { return sameItem(it, at) })
+	*items = slices.Insert(*items, i+1, item)
+	return nil
+}
+
+// within reports whether target is f or lies anywhere inside it.
+func within(f *Folder, target Item) bool {
+	if target.Folder == f {
+		return true
+	}
+	_, inside := parentIn(f.Items, f, target)
+	return inside
+}
+
+// RenameRequest renames req and points workflow steps that ran it by its
+// old name at the new one.
+func RenameRequest(col *Collection, req *Request, name string) {
+	old := req.Name
+	req.Name = name
+	for i := range col.Workflows {
+		for j := range col.Workflows[i].Steps {
+			if col.Workflows[i].Steps[j].Request == old {
+				col.Workflows[i].Steps[j].Request = name
+			}
+		}
+	}
+}
+
+// FolderRef is a folder and its path from the top level, e.g. "Users/Admin".
+type FolderRef struct {
+	Folder *Folder
+	Path   string
+}
+
+// Folders lists the folders of col depth-first, in display order.
+func Folders(col *Collection) []FolderRef {
+	var refs []FolderRef
+	for _, item := range FlattenItems(col.Items, 0, "") {
+		if item.Folder != nil {
+			refs = append(refs, FolderRef{Folder: item.Folder, Path: item.Path[1:]})
+		}
+	}
+	return refs
+}
@@ -0,0 +1,125 @@
+package collection
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEditItems(t *testing.T) {
+	a := NewRequest("A", "GET", "/a")
+	b := NewRequest("B", "GET", "/b")
+	admin := &Folder{Name: "Admin", Items: []Item{{Request: b}}}
+	users := &Folder{Name: "Users", Items: []Item{{Request: a}, {Folder: admin}}}
+	col := &Collection{Items: []Item{{Folder: users}}}
+	order := func() string {
+		var names []string
+		for _, item := range FlattenItems(col.Items, 0, "") {
+			names = append(names, item.Path)
+		}
+		return strings.Join(names, ",")
+	}
+
+	if p, ok := ParentFolder(col, Item{Request: b}); !ok || p != admin {
+		t.Errorf("parent of B = %v, %v", p, ok)
+	}
+	if p, ok := ParentFolder(col, Item{Folder: users}); !ok || p != nil {
+		t.Errorf("top-level folder should have no parent, got %v, %v", p, ok)
+	}
+
+	c := NewRequest("C", "GET", "/c")
+	AddItem(col, admin, Item{Request: c})
+	AddItem(col, nil, Item{Folder: &Folder{Name: "Empty"}})
+	if got := order(); got != "/Users,/Users/A,/Users/Admin,/Users/Admin/B,/Users/Admin/C,/Empty" {
+		t.Errorf("after adding: %s", got)
+	}
+
+	if err := MoveItem(col, Item{Folder: users}, admin); !errors.Is(err, ErrMoveIntoSelf) {
+		t.Errorf("moving a folder into its subfolder: %v", err)
+	}
+	if err := MoveItem(col, Item{Folder: admin}, admin); !errors.Is(err, ErrMoveIntoSelf) {
+		t.Errorf("moving a folder into itself: %v", err)
+	}
+	if err := MoveItem(col, Item{Folder: admin}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := MoveItem(col, Item{Request: a}, admin); err != nil {
+		t.Fatal(err)
+	}
+	if got := order(); got != "/Users,/Empty,/Admin,/Admin/B,/Admin/C,/Admin/A" {
+		t.Errorf("after moving: %s", got)
+	}
+	if err := MoveItem(col, Item{Request: NewRequest("X", "GET", "/")}, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("moving an unknown request: %v", err)
+	}
+
+	if !RemoveItem(col, Item{Folder: admin}) || order() != "/Users,/Empty" {
+		t.Errorf("after removing Admin: %s", order())
+	}
+	if RemoveItem(col, Item{Request: b}) {
+		t.Error("B went with its folder and should not be found again")
+	}
+
+	refs := Folders(col)
+	if len(refs) != 2 || refs[0].Path != "Users" || refs[1].Folder.Name != "Empty" {
+		t.Errorf("unexpected folders %+v", refs)
+	}
+}
+
+func TestRenameRequest(t *testing.T) {
+	req := NewRequest("Login", "POST", "/login")
+	col := &Collection{
+		Items:     []Item{{Request: req}},
+		Workflows: []Workflow{{Name: "auth", Steps: []WorkflowStep{{Request: "Login"}, {Request: "Me"}}}},
+	}
+	RenameRequest(col, req, "Sign In")
+	if req.Name != "Sign In" {
+		t.Errorf("name = %q", req.Name)
+	}
+	if steps := col.Workflows[0].Steps; steps[0].Request != "Sign In" || steps[1].Request != "Me" {
+		t.Errorf("workflow steps = %+v", steps)
+	}
+}
+
+func TestPlaceItem(t *testing.T) {
+	a := NewRequest("A", "GET", "/a")
+	b := NewRequest("B", "GET", "/b")
+	c := NewRequest("C", "GET", "/c")
+	users := &Folder{Name: "Users", Items: []Item{{Request: a}, {Request: b}}}
+	col := &Collection{Items: []Item{{Folder: users}, {Request: c}}}
+	order := func() string {
+		var names []string
+		for _, item := range FlattenItems(col.Items, 0, "") {
+			names = append(names, item.Path)
+		}
+		return strings.Join(names, ",")
+	}
+
+	if err := PlaceItem(col, Item{Request: c}, Item{Request: a}); err != nil {
+		t.Fatal(err)
+	}
+	if got := order(); got != "/Users,/Users/A,/Users/C,/Users/B" {
+		t.Errorf("after pasting below A: %s", got)
+	}
+	if err := PlaceItem(col, Item{Request: b}, Item{Folder: users}); err != nil {
+		t.Fatal(err)
+	}
+	if got := order(); got != "/Users,/Users/B,/Users/A,/Users/C" {
+		t.Errorf("pasting on a folder should put the item first in it: %s", got)
+	}
+	if err := PlaceItem(col, Item{Folder: users}, Item{Request: a}); !errors.Is(err, ErrMoveIntoSelf) {
+		t.Errorf("pasting a folder into itself: %v", err)
+	}
+	if err := PlaceItem(col, Item{Request: a}, Item{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := order(); got != "/Users,/Users/B,/Users/C,/A" {
+		t.Errorf("pasting with no target should append at the top level: %s", got)
+	}
+	if err := PlaceItem(col, Item{Request: a}, Item{Request: NewRequest("X", "GET", "/")}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("pasting below an unknown request: %v", err)
+	}
+	if got := order(); got != "/Users,/Users/B,/Users/C,/A" {
+		t.Errorf("a failed paste should leave the collection alone: %s", got)
+	}
+}
@@ -0,0 +1,184 @@
+package collection
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Request ID styles.
+const (
+	IDStyleUUID = "uuid" // random UUIDv4 (default)
+	IDStyleULID = "ulid" // time-ordered ULID
+	IDStyleSlug = "slug" // slug of the request name with a short random suffix
+)
+
+// IDStyles lists the supported request ID styles.
+var IDStyles = []string{IDStyleUUID, IDStyleULID, IDStyleSlug}
+
+var idStyle = IDStyleUUID
+
+// SetIDStyle sets how IDs of new requests are generated. An empty style
+// selects UUIDv4.
+func SetIDStyle(style string) error {
+	if style == "" {
+		style = IDStyleUUID
+	}
+	if !validIDStyle(style) {
+		return fmt.Errorf("unknown ID style %q (want %s)", style, strings.Join(IDStyles, ", "))
+	}
+	idStyle = style
+	return nil
+}
+
+func validIDStyle(style string) bool {
+	for _, s := range IDStyles {
+		if s == style {
+			return true
+		}
+	}
+	return false
+}
+
+// NewID returns a new request ID in the configured style. name is used by
+// the slug style.
+func NewID(name string) string {
+	var entropy [16]byte
+	rand.Read(entropy[:])
+	switch idStyle {
+	case IDStyleULID:
+		return newULID(time.Now(), entropy[6:])
+	case IDStyleSlug:
+		return slugify(name) + "-" + hex.EncodeToString(entropy[:3])
+	default:
+		return uuid.New().String()
+	}
+}
+
+// IDChange records a request ID replaced by ReassignIDs.
+type IDChange struct {
+	Path  string // e.g. "/Users/List Users"
+	OldID string // empty when the request had no ID
+	NewID string
+}
+
+// ReassignIDs gives every request without an ID, or with an ID already used
+// earlier in the collection, a new ID in the given style. New IDs are
+// derived from the collection name and request path, so the same input
+// always yields the same output. The first request using an ID keeps it.
+func ReassignIDs(col *Collection, style string) ([]IDChange, error) {
+	if style == "" {
+		style = IDStyleUUID
+	}
+	if !validIDStyle(style) {
+		return nil, fmt.Errorf("unknown ID style %q (want %s)", style, strings.Join(IDStyles, ", "))
+	}
+
+	used := make(map[string]bool)
+	walkRequests(col.Items, "", func(req *Request, _ string) {
+		if req.ID != "" {
+			used[req.ID] = true
+		}
+	})
+
+	var changes []IDChange
+	seen := make(map[string]bool)
+	walkRequests(col.Items, "", func(req *Request, path string) {
+		if req.ID != "" && !seen[req.ID] {
+			seen[req.ID] = true
+			return
+		}
+		id := stableID(style, col.Name, path, req.Name)
+		for n := 2; used[id]; n++ {
+			if style == IDStyleSlug {
+				id = slugify(req.Name) + "-" + strconv.Itoa(n)
+			} else {
+				id = stableID(style, col.Name, path+"#"+strconv.Itoa(n), req.Name)
+			}
+		}
+		used[id] = true
+		seen[id] = true
+		changes = append(changes, IDChange{Path: path, OldID: req.ID, NewID: id})
+		req.ID = id
+	})
+	return changes, nil
+}
+
+// stableID derives an ID in style from the request's location.
+func stableID(style, colName, path, name string) string {
+	sum := sha256.Sum256([]byte(colName + "\x00" + path))
+	switch style {
+	case IDStyleULID:
+		// The timestamp part comes from the hash too, keeping IDs stable
+		ms := binary.BigEndian.Uint64(sum[:8]) >> 16
+		return newULID(time.UnixMilli(int64(ms)), sum[8:18])
+	case IDStyleSlug:
+		return slugify(name)
+	default:
+		return uuid.NewSHA1(uuid.NameSpaceURL, []byte("gottp:"+colName+path)).String()
+	}
+}
+
+func walkRequests(items []Item, path string, fn func(req *Request, path string)) {
+	for _, it := range items {
+		if it.Request != nil {
+			fn(it.Request, path+"/"+it.Request.Name)
+		}
+		if it.Folder != nil {
+			walkRequests(it.Folder.Items, path+"/"+it.Folder.Name, fn)
+		}
+	}
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID encodes a 48-bit millisecond timestamp and 80 bits of entropy as
+// a 26 character ULID.
+func newULID(t time.Time, entropy []byte) string {
+	var b [16]byte
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+	copy(b[6:], entropy)
+
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// slugify lower-cases name and joins its letters and digits with dashes.
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "request"
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package collection
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewID_Styles(t *testing.T) {
+	defer SetIDStyle("")
+
+	cases := map[string]*regexp.Regexp{
+		IDStyleUUID: regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[0-9a-f]{4}-[0-9a-f]{12}$`),
+		IDStyleULID: regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`),
+		IDStyleSlug: regexp.MustCompile(`^list-users-v2-[0-9a-f]{6}$`),
+	}
+	for style, re := range cases {
+		if err := SetIDStyle(style); err != nil {
+			t.Fatal(err)
+		}
+		if id := NewID("List Users (v2)"); !re.MatchString(id) {
+			t.Errorf("%s: unexpected ID %q", style, id)
+		}
+	}
+	if err := SetIDStyle("snowflake"); err == nil {
+		t.Error("expected unknown style error")
+	}
+}
+
+func TestNewULID(t *testing.T) {
+	if got := newULID(time.UnixMilli(0), make([]byte, 10)); got != strings.Repeat("0", 26) {
+		t.Errorf("zero ULID = %q", got)
+	}
+	if got := newULID(time.UnixMilli(1<<48-1), bytes.Repeat([]byte{0xff}, 10)); got != "7"+strings.Repeat("Z", 25) {
+		t.Errorf("max ULID = %q", got)
+	}
+	a := newULID(time.UnixMilli(1000), make([]byte, 10))
+	b := newULID(time.UnixMilli(1001), make([]byte, 10))
+	if a >= b {
+		t.Errorf("ULIDs should sort by time: %s >= %s", a, b)
+	}
+}
+
+func TestReassignIDs(t *testing.T) {
+	build := func() *Collection {
+		return &Collection{
+			Name: "API",
+			Items: []Item{
+				{Request: &Request{ID: "a", Name: "List Users"}},
+				{Request: &Request{Name: "Create User"}},
+				{Folder: &Folder{Name: "Admin", Items: []Item{
+					{Request: &Request{ID: "a", Name: "List Users"}},
+					{Request: &Request{ID: "b", Name: "Delete"}},
+				}}},
+			},
+		}
+	}
+
+	for _, style := range IDStyles {
+		col := build()
+		changes, err := ReassignIDs(col, style)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 2 || changes[0].Path != "/Create User" || changes[0].OldID != "" ||
+			changes[1].Path != "/Admin/List Users" || changes[1].OldID != "a" {
+			t.Fatalf("%s: unexpected changes %+v", style, changes)
+		}
+		if col.Items[0].Request.ID != "a" || col.Items[2].Folder.Items[1].Request.ID != "b" {
+			t.Errorf("%s: unique IDs must be kept", style)
+		}
+
+		again := build()
+		ReassignIDs(again, style)
+		if again.Items[1].Request.ID != col.Items[1].Request.ID ||
+			again.Items[2].Folder.Items[0].Request.ID != col.Items[2].Folder.Items[0].Request.ID {
+			t.Errorf("%s: reassigned IDs should be deterministic", style)
+		}
+	}
+
+	col := build()
+	ReassignIDs(col, IDStyleSlug)
+	if col.Items[1].Request.ID != "create-user" || col.Items[2].Folder.Items[0].Request.ID != "list-users" {
+		t.Errorf("unexpected slug IDs: %s, %s", col.Items[1].Request.ID, col.Items[2].Folder.Items[0].Request.ID)
+	}
+
+	if _, err := ReassignIDs(build(), "bogus"); err == nil {
+		t.Error("expected unknown style error")
+	}
+}
@@ -0,0 +1,252 @@
+package collection
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/core/secrets"
+)
+
+// Lint rules.
+const (
+	RuleHardcodedSecrets = "no-hardcoded-secrets"
+	RuleBaseURL          = "base-url-variable"
+	RuleAssertions       = "require-assertions"
+	RuleUniqueNames      = "unique-names"
+)
+
+// Lint severities. SeverityOff turns a rule off.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+	SeverityOff     = "off"
+)
+
+// LintRules lists every rule with its default severity.
+var LintRules = map[string]string{
+	RuleHardcodedSecrets: SeverityError,
+	RuleBaseURL:          SeverityWarning,
+	RuleAssertions:       SeverityWarning,
+	RuleUniqueNames:      SeverityError,
+}
+
+// LintConfig is the lint block of a collection: severity overrides by rule
+// name, and the variable every URL should start with.
+type LintConfig struct {
+	Rules   map[string]string `yaml:"rules,omitempty"`    // rule -> error, warning, info or off
+	BaseURL string            `yaml:"base_url,omitempty"` // variable name, default base_url
+}
+
+// Severities returns the severity of each rule after c's overrides. It
+// fails on unknown rules and severities, so a typo doesn't silently leave
+// a rule at its default.
+func (c *LintConfig) Severities() (map[string]string, error) {
+	out := make(map[string]string, len(LintRules))
+	for rule, sev := range LintRules {
+		out[rule] = sev
+	}
+	if c == nil {
+		return out, nil
+	}
+	for _, rule := range slices.Sorted(maps.Keys(c.Rules)) {
+		sev := c.Rules[rule]
+		if _, ok := LintRules[rule]; !ok {
+			return nil, fmt.Errorf("lint: unknown rule %q", rule)
+		}
+		switch sev {
+		case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+			out[rule] = sev
+		default:
+			return nil, fmt.Errorf("lint: rule %s has unknown severity %q (use error, warning, info or off)", rule, sev)
+		}
+	}
+	return out, nil
+}
+
+func (c *LintConfig) baseURL() string {
+	if c == nil || c.BaseURL == "" {
+		return "base_url"
+	}
+	return strings.Trim(c.BaseURL, "{}")
+}
+
+// LintIssue is a rule a request, folder or the collection breaks.
+type LintIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"`           // e.g. "/Users/List Users"; empty for the collection
+	Line     int    `json:"line,omitempty"` // of the request, when known
+	Message  string `json:"message"`
+}
+
+// Lint checks col against its lint rules and returns the issues in
+// collection order, collection-level ones first.
+func Lint(col *Collection) ([]LintIssue, error) {
+	sev, err := col.Lint.Severities()
+	if err != nil {
+		return nil, err
+	}
+	var issues []LintIssue
+	report := func(rule, path string, line int, format string, args ...any) {
+		if s := sev[rule]; s != SeverityOff {
+			issues = append(issues, LintIssue{Rule: rule, Severity: s, Path: path, Line: line, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	for _, name := range secretVariables(col.Variables) {
+		report(RuleHardcodedSecrets, "", 0, "variable %q has a hardcoded value", name)
+	}
+	for _, field := range secretAuthFields(col.Auth) {
+		report(RuleHardcodedSecrets, "", 0, "auth %s is hardcoded", field)
+	}
+
+	base := "{{" + col.Lint.baseURL() + "}}"
+	names := make(map[string]bool) // folder path + "\x00" + lower-case name
+	for _, item := range FlattenItems(col.Items, 0, "") {
+		if item.IsFolder {
+			for _, name := range secretVariables(item.Folder.Variables) {
+				report(RuleHardcodedSecrets, item.Path, 0, "variable %q has a hardcoded value", name)
+			}
+			continue
+		}
+		req := item.Request
+		at := func(rule, format string, args ...any) {
+			report(rule, item.Path, req.Line, format, args...)
+		}
+
+		// Names are compared ignoring case, the way --request finds them
+		folder := strings.TrimSuffix(item.Path, "/"+req.Name)
+		if key := folder + "\x00" + strings.ToLower(req.Name); names[key] {
+			at(RuleUniqueNames, "another request in %s has the same name", folderLabel(folder))
+		} else {
+			names[key] = true
+		}
+
+		for _, group := range []struct {
+			kind  string
+			pairs []KVPair
+		}{{"header", req.Headers}, {"query parameter", req.Params}, {"cookie", req.Cookies}} {
+			for _, p := range group.pairs {
+				if SecretName(p.Key) && hardcoded(p.Value) {
+					at(RuleHardcodedSecrets, "%s %q has a hardcoded value", group.kind, p.Key)
+				}
+			}
+		}
+		for _, field := range secretAuthFields(req.Auth) {
+			at(RuleHardcodedSecrets, "auth %s is hardcoded", field)
+		}
+		for _, name := range secretVariables(req.Variables) {
+			at(RuleHardcodedSecrets, "variable %q has a hardcoded value", name)
+		}
+
+		if url := strings.TrimSpace(req.URL); url != "" && !strings.HasPrefix(url, base) {
+			at(RuleBaseURL, "URL %s doesn't start with %s", url, base)
+		}
+		if !hasChecks(req) {
+			at(RuleAssertions, "no assertions or tests")
+		}
+	}
+	return issues, nil
+}
+
+func folderLabel(path string) string {
+	if path == "" {
+		return "the collection root"
+	}
+	return "folder " + strings.TrimPrefix(path, "/")
+}
+
+// secretWords mark header, parameter, cookie and variable names that
+// usually hold credentials.
+var secretWords = []string{"auth", "token", "secret", "password", "passwd", "apikey", "api-key", "api_key", "session", "cookie", "credential"}
+
+// SecretName reports whether a header, parameter, cookie or variable name
+// suggests its value is a credential.
+func SecretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, w := range secretWords {
+		if strings.Contains(name, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// hardcoded reports whether a value is written out rather than read from a
+// variable or a secret reference.
+func hardcoded(value string) bool {
+	value = strings.TrimSpace(value)
+	return value != "" && !strings.Contains(value, "{{") && !secrets.IsRef(value)
+}
+
+func secretVariables(vars map[string]string) []string {
+	var names []string
+	for name, value := range vars {
+		if SecretName(name) && hardcoded(value) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func secretAuthFields(a *Auth) []string {
+	if a == nil {
+		return nil
+	}
+	fields := map[string]string{}
+	switch a.Type {
+	case "basic":
+		if a.Basic != nil {
+			fields["basic password"] = a.Basic.Password
+		}
+	case "digest":
+		if a.Digest != nil {
+			fields["digest password"] = a.Digest.Password
+		}
+	case "bearer":
+		if a.Bearer != nil {
+			fields["bearer token"] = a.Bearer.Token
+		}
+	case "apikey":
+		if a.APIKey != nil {
+			fields["API key"] = a.APIKey.Value
+		}
+	case "oauth2":
+		if a.OAuth2 != nil {
+			fields["OAuth2 client secret"] = a.OAuth2.ClientSecret
+			fields["OAuth2 password"] = a.OAuth2.Password
+		}
+	case "awsv4":
+		if a.AWSAuth != nil {
+			fields["AWS secret access key"] = a.AWSAuth.SecretAccessKey
+			fields["AWS session token"] = a.AWSAuth.SessionToken
+		}
+	}
+	var out []string
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if hardcoded(fields[name]) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// hasChecks reports whether running req checks anything: an assertion, a
+// test or assert in its post-script, or a WebSocket expect.
+func hasChecks(req *Request) bool {
+	if len(req.Assertions) > 0 || strings.Contains(req.PostScript, "gottp.test(") || strings.Contains(req.PostScript, "gottp.assert(") {
+		return true
+	}
+	if ws := req.WebSocket; ws != nil {
+		for _, m := range ws.Messages {
+			if m.Expect != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
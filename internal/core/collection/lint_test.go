@@ -0,0 +1,86 @@
+package collection
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/assertion"
+)
+
+func TestLint(t *testing.T) {
+	list := NewRequest("List", "GET", "{{base_url}}/users")
+	list.Assertions = []assertion.Assertion{{Status: 200}}
+	list.Headers = []KVPair{
+		{Key: "Authorization", Value: "Bearer {{token}}", Enabled: true},
+		{Key: "X-Api-Key", Value: "sk_live_123", Enabled: true},
+	}
+	again := NewRequest("list", "GET", "https://api.example.com/users")
+	again.PostScript = `gottp.test("ok", () => gottp.response.status === 200)`
+	again.Auth = &Auth{Type: "basic", Basic: &BasicAuth{Username: "ada", Password: "secret://ada"}}
+	create := NewRequest("Create", "POST", "{{base_url}}/users")
+	create.Line = 12
+	create.Auth = &Auth{Type: "bearer", Bearer: &BearerAuth{Token: "abc"}}
+	other := NewRequest("List", "GET", "{{base_url}}/other")
+	other.Assertions = []assertion.Assertion{{Status: 200}}
+
+	col := &Collection{
+		Name:      "API",
+		Variables: map[string]string{"base_url": "https://api.example.com", "token": "t0k3n", "api_token": "{{token}}"},
+		Items: []Item{
+			{Folder: &Folder{Name: "Users", Items: []Item{{Request: list}, {Request: again}, {Request: create}}}},
+			{Request: other},
+		},
+	}
+
+	issues, err := Lint(col)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	var got []string
+	for _, i := range issues {
+		got = append(got, i.Severity+" "+i.Rule+" "+i.Path+": "+i.Message)
+	}
+	want := []string{
+		`error no-hardcoded-secrets : variable "token" has a hardcoded value`,
+		`error no-hardcoded-secrets /Users/List: header "X-Api-Key" has a hardcoded value`,
+		`error unique-names /Users/list: another request in folder Users has the same name`,
+		`warning base-url-variable /Users/list: URL https://api.example.com/users doesn't start with {{base_url}}`,
+		`error no-hardcoded-secrets /Users/Create: auth bearer token is hardcoded`,
+		`warning require-assertions /Users/Create: no assertions or tests`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if issues[4].Line != 12 {
+		t.Errorf("line = %d, want 12", issues[4].Line)
+	}
+
+	col.Lint = &LintConfig{
+		BaseURL: "{{api}}",
+		Rules:   map[string]string{RuleHardcodedSecrets: SeverityOff, RuleAssertions: SeverityInfo},
+	}
+	issues, err = Lint(col)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	counts := make(map[string]int)
+	for _, i := range issues {
+		counts[i.Rule+" "+i.Severity]++
+	}
+	if counts["no-hardcoded-secrets error"] != 0 || counts["require-assertions info"] != 1 || counts["base-url-variable warning"] != 4 {
+		t.Errorf("configured issues = %v", counts)
+	}
+}
+
+func TestLintConfig_Severities(t *testing.T) {
+	sev, err := (*LintConfig)(nil).Severities()
+	if err != nil || sev[RuleUniqueNames] != SeverityError {
+		t.Errorf("defaults = %v, %v", sev, err)
+	}
+	if _, err := (&LintConfig{Rules: map[string]string{"no-such-rule": "error"}}).Severities(); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+	if _, err := (&LintConfig{Rules: map[string]string{RuleBaseURL: "fatal"}}).Severities(); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 )
 
-// LoadFromFile loads a collection from a YAML file.
+// LoadFromFile loads a collection from a YAML file, or from the directory
+// of a split collection.
 func LoadFromFile(path string) (*Collection, error) {
+	if IsSplit(path) {
+		return LoadSplit(path)
+	}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading collection file: %w", err)
@@ -20,24 +23,79 @@ func LoadFromFile(path string) (*Collection, error) {
 
 // LoadFromBytes parses a collection from YAML bytes.
 func LoadFromBytes(data []byte) (*Collection, error) {
-	var col Collection
-	if err := yaml.Unmarshal(data, &col); err != nil {
+	col, err := ParseBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	// Ensure all requests have IDs
+	assignIDs(col.Items)
+	return col, nil
+}
+
+// ParseFile loads a collection file or split collection directory like
+// LoadFromFile, but leaves missing request IDs empty like ParseBytes.
+func ParseFile(path string) (*Collection, error) {
+	if IsSplit(path) {
+		return parseSplit(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading collection file: %w", err)
+	}
+	return ParseBytes(data)
+}
+
+// ParseBytes parses a collection from YAML bytes, leaving missing request
+// IDs empty. Files in an older format are migrated first.
+func ParseBytes(data []byte) (*Collection, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
 		return nil, fmt.Errorf("parsing collection: %w", err)
 	}
+	return parseDocument(&doc)
+}
+
+// parseDocument migrates and decodes a parsed collection document.
+func parseDocument(doc *yaml.Node) (*Collection, error) {
+	if _, err := Migrate(doc); err != nil {
+		return nil, fmt.Errorf("migrating collection: %w", err)
+	}
+	var col Collection
+	if doc.Kind != 0 {
+		if err := doc.Decode(&col); err != nil {
+			return nil, fmt.Errorf("parsing collection: %w", err)
+		}
+	}
+	col.SchemaVersion = SchemaVersion
 	if col.Version == "" {
 		col.Version = "1"
 	}
-	// Ensure all requests have IDs
-	assignIDs(col.Items)
 	return &col, nil
 }
 
-// LoadFromDir loads all .gottp.yaml files from a directory.
+// UnmarshalYAML decodes a request and records the line it starts on.
+func (r *Request) UnmarshalYAML(node *yaml.Node) error {
+	type plain Request
+	if err := node.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	r.Line = node.Line
+	return nil
+}
+
+// LoadFromDir loads all .gottp.yaml files and split .gottp collection
+// directories from a directory.
 func LoadFromDir(dir string) ([]*Collection, error) {
 	matches, err := filepath.Glob(filepath.Join(dir, "*.gottp.yaml"))
 	if err != nil {
 		return nil, fmt.Errorf("globbing collection files: %w", err)
 	}
+	dirs, _ := filepath.Glob(filepath.Join(dir, "*.gottp"))
+	for _, d := range dirs {
+		if IsSplit(d) {
+			matches = append(matches, d)
+		}
+	}
 	var collections []*Collection
 	for _, path := range matches {
 		col, err := LoadFromFile(path)
@@ -52,7 +110,7 @@ func LoadFromDir(dir string) ([]*Collection, error) {
 func assignIDs(items []Item) {
 	for i := range items {
 		if items[i].Request != nil && items[i].Request.ID == "" {
-			items[i].Request.ID = uuid.New().String()
+			items[i].Request.ID = NewID(items[i].Request.Name)
 		}
 		if items[i].Folder != nil {
 			assignIDs(items[i].Folder.Items)
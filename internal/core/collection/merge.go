@@ -0,0 +1,237 @@
+package collection
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Merge note kinds.
+const (
+	MergeDuplicate = "duplicate" // identical item dropped
+	MergeNewID     = "id"        // colliding request ID replaced
+	MergeConflict  = "conflict"  // differing item kept under a new name, or dropped
+)
+
+// MergeNote records a decision made while merging collections.
+type MergeNote struct {
+	Kind   string
+	Source string // name of the collection the item came from
+	Path   string // e.g. "/Users/List Users", "variables.base_url"
+	Detail string
+}
+
+func (n MergeNote) String() string {
+	return fmt.Sprintf("%-9s %s (from %s): %s", n.Kind, n.Path, n.Source, n.Detail)
+}
+
+// Merge combines cols into one collection named after the first. Folders
+// with the same name are merged; identical requests in the same folder are
+// kept once; requests that share a name but differ are kept under a name
+// suffixed with their source collection. Request IDs are made unique.
+// Variables and auth from earlier collections win over later ones. The
+// requests of cols are reused in the result.
+func Merge(cols []*Collection) (*Collection, []MergeNote) {
+	m := &merger{ids: make(map[string]bool)}
+	out := &Collection{SchemaVersion: SchemaVersion, Version: "1"}
+	for i, col := range cols {
+		if col == nil {
+			continue
+		}
+		m.source = col.Name
+		if m.source == "" {
+			m.source = "collection " + strconv.Itoa(i+1)
+		}
+		m.renamed = make(map[string]string)
+		if out.Name == "" {
+			out.Name = col.Name
+		}
+
+		m.mergeItems(&out.Items, col.Items, "")
+		m.mergeVariables(out, col.Variables)
+		m.mergeAuth(out, col.Auth)
+		m.mergeWorkflows(out, col.Workflows)
+	}
+	return out, m.notes
+}
+
+type merger struct {
+	ids     map[string]bool
+	notes   []MergeNote
+	source  string
+	renamed map[string]string // request renames in the current source
+}
+
+func (m *merger) note(kind, path, detail string) {
+	m.notes = append(m.notes, MergeNote{Kind: kind, Source: m.source, Path: path, Detail: detail})
+}
+
+func (m *merger) mergeItems(dst *[]Item, src []Item, path string) {
+	for _, item := range src {
+		if item.Folder != nil {
+			m.mergeFolder(dst, item.Folder, path)
+		}
+		if item.Request != nil {
+			m.mergeRequest(dst, item.Request, path)
+		}
+	}
+}
+
+func (m *merger) mergeFolder(dst *[]Item, f *Folder, path string) {
+	for _, existing := range *dst {
+		if existing.Folder != nil && existing.Folder.Name == f.Name {
+			m.mergeVars(&existing.Folder.Variables, f.Variables, path+"/"+f.Name+"/variables.")
+			m.mergeItems(&existing.Folder.Items, f.Items, path+"/"+f.Name)
+			return
+		}
+	}
+	folder := &Folder{Name: f.Name, Variables: f.Variables}
+	*dst = append(*dst, Item{Folder: folder})
+	m.mergeItems(&folder.Items, f.Items, path+"/"+f.Name)
+}
+
+func (m *merger) mergeRequest(dst *[]Item, req *Request, path string) {
+	conflict := false
+	for _, existing := range *dst {
+		if existing.Request == nil || existing.Request.Name != req.Name {
+			continue
+		}
+		if sameRequest(existing.Request, req) {
+			m.note(MergeDuplicate, path+"/"+req.Name, "identical request already present")
+			return
+		}
+		conflict = true
+	}
+
+	if conflict {
+		name := uniqueName(*dst, req.Name+" ("+m.source+")")
+		m.note(MergeConflict, path+"/"+req.Name, fmt.Sprintf("differs from existing request, kept as %q", name))
+		m.renamed[req.Name] = name
+		req.Name = name
+	}
+	if req.ID == "" || m.ids[req.ID] {
+		id := NewID(req.Name)
+		if req.ID != "" {
+			m.note(MergeNewID, path+"/"+req.Name, fmt.Sprintf("ID %s already used, assigned %s", req.ID, id))
+		}
+		req.ID = id
+	}
+	m.ids[req.ID] = true
+	*dst = append(*dst, Item{Request: req})
+}
+
+func (m *merger) mergeVariables(out *Collection, vars map[string]string) {
+	m.mergeVars(&out.Variables, vars, "variables.")
+}
+
+// mergeVars adds vars to dst, keeping dst's value on conflicts.
+func (m *merger) mergeVars(dst *map[string]string, vars map[string]string, prefix string) {
+	for _, k := range sortedKeys(vars) {
+		v := vars[k]
+		existing, ok := (*dst)[k]
+		switch {
+		case !ok:
+			if *dst == nil {
+				*dst = make(map[string]string)
+			}
+			(*dst)[k] = v
+		case existing != v:
+			m.note(MergeConflict, prefix+k, fmt.Sprintf("keeping %q, dropped %q", existing, v))
+		}
+	}
+}
+
+func (m *merger) mergeAuth(out *Collection, auth *Auth) {
+	switch {
+	case auth == nil:
+	case out.Auth == nil:
+		out.Auth = auth
+	case !sameYAML(out.Auth, auth):
+		m.note(MergeConflict, "auth", fmt.Sprintf("keeping %s auth, dropped %s auth", out.Auth.Type, auth.Type))
+	}
+}
+
+func (m *merger) mergeWorkflows(out *Collection, workflows []Workflow) {
+	for _, wf := range workflows {
+		// Follow requests renamed while merging this source
+		steps := make([]WorkflowStep, len(wf.Steps))
+		for i, s := range wf.Steps {
+			if name, ok := m.renamed[s.Request]; ok {
+				s.Request = name
+			}
+			steps[i] = s
+		}
+		wf.Steps = steps
+
+		duplicate, conflict := false, false
+		for _, existing := range out.Workflows {
+			if existing.Name != wf.Name {
+				continue
+			}
+			if sameYAML(existing, wf) {
+				duplicate = true
+			} else {
+				conflict = true
+			}
+		}
+		if duplicate {
+			m.note(MergeDuplicate, "workflows."+wf.Name, "identical workflow already present")
+			continue
+		}
+		if conflict {
+			name := wf.Name + " (" + m.source + ")"
+			m.note(MergeConflict, "workflows."+wf.Name, fmt.Sprintf("differs from existing workflow, kept as %q", name))
+			wf.Name = name
+		}
+		out.Workflows = append(out.Workflows, wf)
+	}
+}
+
+// sameRequest reports whether two requests are identical apart from IDs.
+func sameRequest(a, b *Request) bool {
+	ac, bc := *a, *b
+	ac.ID, bc.ID = "", ""
+	return sameYAML(ac, bc)
+}
+
+func sameYAML(a, b interface{}) bool {
+	ay, err := yaml.Marshal(a)
+	if err != nil {
+		return false
+	}
+	by, err := yaml.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ay, by)
+}
+
+// uniqueName returns name, or name with a numeric suffix if a request in
+// items already uses it.
+func uniqueName(items []Item, name string) string {
+	taken := func(n string) bool {
+		for _, it := range items {
+			if it.Request != nil && it.Request.Name == n {
+				return true
+			}
+		}
+		return false
+	}
+	candidate := name
+	for i := 2; taken(candidate); i++ {
+		candidate = name + " " + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
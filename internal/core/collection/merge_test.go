@@ -0,0 +1,74 @@
+package collection
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	a := &Collection{
+		Name:      "Users",
+		Variables: map[string]string{"base_url": "https://users", "token": "x"},
+		Items: []Item{
+			{Request: &Request{ID: "1", Name: "Health", Method: "GET", URL: "/health"}},
+			{Folder: &Folder{Name: "Shared", Items: []Item{
+				{Request: &Request{ID: "2", Name: "Login", Method: "POST", URL: "/login"}},
+			}}},
+		},
+		Workflows: []Workflow{{Name: "Smoke", Steps: []WorkflowStep{{Request: "Health"}}}},
+	}
+	b := &Collection{
+		Name:      "Orders",
+		Variables: map[string]string{"base_url": "https://orders", "region": "eu"},
+		Items: []Item{
+			// identical apart from the ID
+			{Request: &Request{ID: "9", Name: "Health", Method: "GET", URL: "/health"}},
+			{Folder: &Folder{Name: "Shared", Items: []Item{
+				{Request: &Request{ID: "3", Name: "Login", Method: "POST", URL: "/v2/login"}},
+				{Request: &Request{ID: "2", Name: "Logout", Method: "POST", URL: "/logout"}},
+			}}},
+		},
+		Workflows: []Workflow{{Name: "Smoke", Steps: []WorkflowStep{{Request: "Login"}}}},
+	}
+
+	merged, notes := Merge([]*Collection{a, b})
+
+	if merged.Name != "Users" {
+		t.Errorf("name = %q", merged.Name)
+	}
+	flat := FlattenItems(merged.Items, 0, "")
+	var paths []string
+	ids := map[string]bool{}
+	for _, it := range flat {
+		paths = append(paths, it.Path)
+		if it.Request != nil {
+			if ids[it.Request.ID] {
+				t.Errorf("duplicate ID %s", it.Request.ID)
+			}
+			ids[it.Request.ID] = true
+		}
+	}
+	want := "/Health,/Shared,/Shared/Login,/Shared/Login (Orders),/Shared/Logout"
+	if got := strings.Join(paths, ","); got != want {
+		t.Errorf("paths = %s, want %s", got, want)
+	}
+
+	if merged.Variables["base_url"] != "https://users" || merged.Variables["region"] != "eu" {
+		t.Errorf("unexpected variables: %v", merged.Variables)
+	}
+	if len(merged.Workflows) != 2 || merged.Workflows[1].Name != "Smoke (Orders)" ||
+		merged.Workflows[1].Steps[0].Request != "Login (Orders)" {
+		t.Errorf("workflow conflict should be renamed and follow renamed requests: %+v", merged.Workflows)
+	}
+
+	kinds := map[string]int{}
+	for _, n := range notes {
+		kinds[n.Kind]++
+	}
+	if kinds[MergeDuplicate] != 1 || kinds[MergeNewID] != 1 || kinds[MergeConflict] != 3 {
+		t.Errorf("unexpected notes: %v", notes)
+	}
+	if !strings.Contains(notes[0].String(), "/Health (from Orders)") {
+		t.Errorf("unexpected note format: %s", notes[0])
+	}
+}
@@ -0,0 +1,40 @@
+package collection
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/sadopc/gottp/internal/core/migrate"
+)
+
+// SchemaVersion is the collection file format this build reads and writes.
+// Bump it with a new entry in migrations whenever the format changes.
+const SchemaVersion = 1
+
+var migrations = []migrate.Migration{
+	{From: 0, Description: `rename body type "form" to "form-urlencoded"`, Apply: renameFormBodies},
+}
+
+// Migrate upgrades a decoded collection document to SchemaVersion in place.
+func Migrate(doc *yaml.Node) (migrate.Result, error) {
+	return migrate.Upgrade(doc, SchemaVersion, migrations)
+}
+
+func renameFormBodies(root *yaml.Node) error {
+	var walk func(items *yaml.Node)
+	walk = func(items *yaml.Node) {
+		if items == nil {
+			return
+		}
+		for _, item := range items.Content {
+			if folder := migrate.Get(item, "folder"); folder != nil {
+				walk(migrate.Get(folder, "items"))
+			}
+			body := migrate.Get(migrate.Get(item, "request"), "body")
+			if t := migrate.Get(body, "type"); t != nil && t.Value == "form" {
+				t.Value = BodyFormURLEncoded
+			}
+		}
+	}
+	walk(migrate.Get(root, "items"))
+	return nil
+}
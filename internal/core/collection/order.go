@@ -0,0 +1,34 @@
+package collection
+
+// MoveRequest moves req by delta places among the items of its folder (or
+// the top level), so folders and requests keep their relative positions.
+// It reports whether req moved; moves past either end do nothing.
+func MoveRequest(col *Collection, req *Request, delta int) bool {
+	if col == nil || req == nil || delta == 0 {
+		return false
+	}
+	return moveIn(col.Items, req, delta)
+}
+
+func moveIn(items []Item, req *Request, delta int) bool {
+	for i, item := range items {
+		if item.Request == req {
+			j := i + delta
+			if j < 0 || j >= len(items) {
+				return false
+			}
+			moved := items[i]
+			if j > i {
+				copy(items[i:j], items[i+1:j+1])
+			} else {
+				copy(items[j+1:i+1], items[j:i])
+			}
+			items[j] = moved
+			return true
+		}
+		if item.Folder != nil && moveIn(item.Folder.Items, req, delta) {
+			return true
+		}
+	}
+	return false
+}
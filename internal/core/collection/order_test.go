@@ -0,0 +1,41 @@
+package collection
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMoveRequest(t *testing.T) {
+	a := NewRequest("A", "GET", "/a")
+	b := NewRequest("B", "GET", "/b")
+	c := NewRequest("C", "GET", "/c")
+	top := NewRequest("Top", "GET", "/")
+	col := &Collection{Items: []Item{
+		{Request: top},
+		{Folder: &Folder{Name: "F", Items: []Item{{Request: a}, {Request: b}, {Request: c}}}},
+	}}
+	order := func() string {
+		var names []string
+		for _, item := range FlattenItems(col.Items, 0, "") {
+			if item.Request != nil {
+				names = append(names, item.Request.Name)
+			} else {
+				names = append(names, item.Folder.Name)
+			}
+		}
+		return strings.Join(names, ",")
+	}
+
+	if !MoveRequest(col, c, -1) || order() != "Top,F,A,C,B" {
+		t.Errorf("move up: %s", order())
+	}
+	if !MoveRequest(col, a, 2) || order() != "Top,F,C,B,A" {
+		t.Errorf("move down two: %s", order())
+	}
+	if MoveRequest(col, c, -1) || order() != "Top,F,C,B,A" {
+		t.Errorf("moving past the top of a folder should do nothing: %s", order())
+	}
+	if !MoveRequest(col, top, 1) || order() != "F,C,B,A,Top" {
+		t.Errorf("top-level requests move past folders: %s", order())
+	}
+}
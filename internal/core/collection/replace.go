@@ -0,0 +1,186 @@
+package collection
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// previewContext is how many characters of surrounding text a Match keeps
+// on each side for previews.
+const previewContext = 24
+
+// Match is one occurrence of a find pattern in a request field.
+type Match struct {
+	Request     *Request
+	Path        string // folder path of the request, e.g. "/Users"
+	Field       string // e.g. "url", "header Accept", "body"
+	Start, End  int    // byte offsets of the match in the field
+	Text        string // matched text
+	Replacement string // text the match is replaced with
+	Before      string // context preceding the match on the same line
+	After       string // context following the match on the same line
+
+	target *string
+}
+
+// FindMatches searches the names, URLs, params, headers, bodies and scripts
+// of every request in col. Literal patterns are matched verbatim; regex
+// patterns use RE2 syntax and may reference groups in replacement as $1 or
+// ${name}.
+func FindMatches(col *Collection, pattern, replacement string, regex bool) ([]Match, error) {
+	if col == nil || pattern == "" {
+		return nil, nil
+	}
+	expr := pattern
+	if !regex {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	var matches []Match
+	for _, item := range FlattenItems(col.Items, 0, "") {
+		if item.Request == nil {
+			continue
+		}
+		path := strings.TrimSuffix(item.Path, "/"+item.Request.Name)
+		for _, f := range searchFields(item.Request) {
+			s := *f.target
+			for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+				if loc[0] == loc[1] {
+					continue
+				}
+				repl := replacement
+				if regex {
+					repl = string(re.ExpandString(nil, replacement, s, loc))
+				}
+				matches = append(matches, Match{
+					Request:     item.Request,
+					Path:        path,
+					Field:       f.name,
+					Start:       loc[0],
+					End:         loc[1],
+					Text:        s[loc[0]:loc[1]],
+					Replacement: repl,
+					Before:      contextBefore(s[:loc[0]]),
+					After:       contextAfter(s[loc[1]:]),
+					target:      f.target,
+				})
+			}
+		}
+	}
+	return matches, nil
+}
+
+// ApplyMatches replaces each match in place and returns how many were
+// applied. Matches whose field has changed since FindMatches are skipped.
+func ApplyMatches(matches []Match) int {
+	byTarget := make(map[*string][]Match)
+	var order []*string
+	for _, m := range matches {
+		if m.target == nil {
+			continue
+		}
+		if _, ok := byTarget[m.target]; !ok {
+			order = append(order, m.target)
+		}
+		byTarget[m.target] = append(byTarget[m.target], m)
+	}
+
+	applied := 0
+	for _, target := range order {
+		ms := byTarget[target]
+		// Replace back to front so earlier offsets stay valid
+		sort.Slice(ms, func(i, j int) bool { return ms[i].Start > ms[j].Start })
+		s := *target
+		last := len(s) + 1
+		for _, m := range ms {
+			if m.End > last || m.End > len(s) || s[m.Start:m.End] != m.Text {
+				continue
+			}
+			s = s[:m.Start] + m.Replacement + s[m.End:]
+			last = m.Start
+			applied++
+		}
+		*target = s
+	}
+	return applied
+}
+
+type searchField struct {
+	name   string
+	target *string
+}
+
+func searchFields(req *Request) []searchField {
+	fields := []searchField{
+		{"name", &req.Name},
+		{"url", &req.URL},
+	}
+	kv := func(kind string, pairs []KVPair) {
+		for i := range pairs {
+			p := &pairs[i]
+			fields = append(fields,
+				searchField{kind + " name", &p.Key},
+				searchField{kind + " " + p.Key, &p.Value})
+		}
+	}
+	kv("param", req.Params)
+	kv("header", req.Headers)
+
+	if b := req.Body; b != nil {
+		fields = append(fields, searchField{"body", &b.Content})
+		for i := range b.Fields {
+			f := &b.Fields[i]
+			fields = append(fields,
+				searchField{"field name", &f.Key},
+				searchField{"field " + f.Key, &f.Value},
+				searchField{"field " + f.Key + " file", &f.File})
+		}
+		fields = append(fields, searchField{"body file", &b.File})
+	}
+	if g := req.GraphQL; g != nil {
+		fields = append(fields,
+			searchField{"query", &g.Query},
+			searchField{"variables", &g.Variables})
+	}
+	if ws := req.WebSocket; ws != nil {
+		for i := range ws.Messages {
+			m := &ws.Messages[i]
+			fields = append(fields, searchField{"message " + m.Name, &m.Content})
+		}
+	}
+	if g := req.GRPC; g != nil {
+		kv("metadata", g.Metadata)
+	}
+	fields = append(fields,
+		searchField{"pre_script", &req.PreScript},
+		searchField{"post_script", &req.PostScript})
+	return fields
+}
+
+func contextBefore(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	r := []rune(s)
+	if len(r) > previewContext {
+		return "…" + string(r[len(r)-previewContext:])
+	}
+	return s
+}
+
+func contextAfter(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	r := []rune(s)
+	if len(r) > previewContext {
+		return string(r[:previewContext]) + "…"
+	}
+	return s
+}
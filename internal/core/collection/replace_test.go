@@ -0,0 +1,96 @@
+package collection
+
+import "testing"
+
+func TestFindMatches_Literal(t *testing.T) {
+	col, err := LoadFromBytes([]byte(sampleYAML))
+	if err != nil {
+		t.Fatalf("LoadFromBytes failed: %v", err)
+	}
+
+	matches, err := FindMatches(col, "{{base_url}}", "{{api}}", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("expected 3 matches, got %d", len(matches))
+	}
+	m := matches[0]
+	if m.Request.Name != "List Users" || m.Field != "url" || m.Path != "/Users" {
+		t.Errorf("unexpected first match: %+v", m)
+	}
+	if m.Replacement != "{{api}}" || m.After != "/users" {
+		t.Errorf("unexpected preview: %+v", m)
+	}
+
+	// Apply all but the last match
+	if n := ApplyMatches(matches[:2]); n != 2 {
+		t.Fatalf("expected 2 applied, got %d", n)
+	}
+	flat := FlattenItems(col.Items, 0, "")
+	if flat[1].Request.URL != "{{api}}/users" || flat[2].Request.URL != "{{api}}/users" {
+		t.Errorf("selected URLs not replaced: %q, %q", flat[1].Request.URL, flat[2].Request.URL)
+	}
+	if flat[4].Request.URL != "{{base_url}}/products" {
+		t.Errorf("unselected URL changed: %q", flat[4].Request.URL)
+	}
+}
+
+func TestFindMatches_RegexGroupsAndFields(t *testing.T) {
+	col := &Collection{Items: []Item{{Request: &Request{
+		Name:       "Get v1 user",
+		URL:        "https://x/v1/users/v1",
+		Headers:    []KVPair{{Key: "X-Version", Value: "v1", Enabled: true}},
+		Body:       &Body{Type: "json", Content: "{\n  \"api\": \"v1\"\n}"},
+		PostScript: "assert(v1)",
+	}}}}
+
+	matches, err := FindMatches(col, `v(\d)`, "version-$1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]int{}
+	for _, m := range matches {
+		fields[m.Field]++
+		if m.Replacement != "version-1" {
+			t.Errorf("replacement not expanded: %q", m.Replacement)
+		}
+	}
+	want := map[string]int{"name": 1, "url": 2, "header X-Version": 1, "body": 1, "post_script": 1}
+	for f, n := range want {
+		if fields[f] != n {
+			t.Errorf("field %s: expected %d matches, got %d", f, n, fields[f])
+		}
+	}
+	for _, m := range matches {
+		if m.Field == "body" && (m.Before != `  "api": "` || m.After != `"`) {
+			t.Errorf("body preview should stay on its line: %q / %q", m.Before, m.After)
+		}
+	}
+
+	if n := ApplyMatches(matches); n != len(matches) {
+		t.Fatalf("expected %d applied, got %d", len(matches), n)
+	}
+	req := col.Items[0].Request
+	if req.URL != "https://x/version-1/users/version-1" {
+		t.Errorf("URL = %q", req.URL)
+	}
+	if req.Headers[0].Value != "version-1" || req.PostScript != "assert(version-1)" {
+		t.Errorf("header/script not replaced: %q, %q", req.Headers[0].Value, req.PostScript)
+	}
+
+	// Stale matches are skipped rather than corrupting the field
+	if n := ApplyMatches(matches); n != 0 {
+		t.Errorf("expected stale matches to be skipped, applied %d", n)
+	}
+}
+
+func TestFindMatches_InvalidRegex(t *testing.T) {
+	col := &Collection{Items: []Item{{Request: NewRequest("a", "GET", "/")}}}
+	if _, err := FindMatches(col, "(", "", true); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	if m, err := FindMatches(col, "(", "", false); err != nil || len(m) != 0 {
+		t.Errorf("literal search should not fail: %v %v", m, err)
+	}
+}
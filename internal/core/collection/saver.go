@@ -1,20 +1,77 @@
 package collection
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 
 	"gopkg.in/yaml.v3"
 )
 
-// SaveToFile saves a collection to a YAML file.
+// SaveToFile saves a collection to a YAML file in the current format, or
+// to the directory of a split collection.
 func SaveToFile(col *Collection, path string) error {
-	data, err := yaml.Marshal(col)
+	if IsSplit(path) {
+		return SaveSplit(col, path)
+	}
+	data, err := marshal(col)
 	if err != nil {
-		return fmt.Errorf("marshaling collection: %w", err)
+		return err
 	}
 	if err := os.WriteFile(path, data, 0644); err != nil {
 		return fmt.Errorf("writing collection file: %w", err)
 	}
 	return nil
 }
+
+// Fingerprint returns a hash of the collection as SaveToFile would write
+// it, for telling whether it changed since it was loaded or saved.
+func Fingerprint(col *Collection) (string, error) {
+	data, err := marshal(col)
+	if err != nil {
+		return "", err
+	}
+	return hashOf(data), nil
+}
+
+// SaveIfChanged saves col to path unless its fingerprint is still prev
+// and the file exists, which avoids rewriting the file (and waking file
+// watchers) when nothing changed. It returns the new fingerprint and
+// whether the file was written.
+func SaveIfChanged(col *Collection, path, prev string) (string, bool, error) {
+	data, err := marshal(col)
+	if err != nil {
+		return prev, false, err
+	}
+	hash := hashOf(data)
+	if hash == prev {
+		if _, err := os.Stat(path); err == nil {
+			return hash, false, nil
+		}
+	}
+	if IsSplit(path) {
+		err = SaveSplit(col, path)
+	} else {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		return prev, false, fmt.Errorf("writing collection file: %w", err)
+	}
+	return hash, true, nil
+}
+
+func marshal(col *Collection) ([]byte, error) {
+	stamped := *col
+	stamped.SchemaVersion = SchemaVersion
+	data, err := yaml.Marshal(&stamped)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling collection: %w", err)
+	}
+	return data, nil
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,398 @@
+package collection
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/core/migrate"
+	"github.com/sadopc/gottp/internal/core/retry"
+	"gopkg.in/yaml.v3"
+)
+
+// A split collection is a directory with a file per request and a
+// subdirectory per folder, so that changes to one request make small diffs
+// that merge cleanly:
+//
+//	api.gottp/
+//	  _collection.yaml   name, variables, auth, retry, workflows, item order
+//	  health.yaml        a request
+//	  users/
+//	    _folder.yaml     folder name, variables, item order
+//	    list-users.yaml
+//
+// Item order is kept in the order lists of the manifests. YAML files and
+// folder directories missing from a list, e.g. added by hand, are read
+// after the listed ones in name order.
+const (
+	SplitManifest  = "_collection.yaml"
+	FolderManifest = "_folder.yaml"
+)
+
+// splitManifest is the content of _collection.yaml.
+type splitManifest struct {
+	SchemaVersion int               `yaml:"schema_version,omitempty"`
+	Name          string            `yaml:"name"`
+	Version       string            `yaml:"version"`
+	Auth          *Auth             `yaml:"auth,omitempty"`
+	Variables     map[string]string `yaml:"variables,omitempty"`
+	Retry         *retry.Policy     `yaml:"retry,omitempty"`
+	Order         []string          `yaml:"order,omitempty"`
+	Workflows     []Workflow        `yaml:"workflows,omitempty"`
+	Lint          *LintConfig       `yaml:"lint,omitempty"`
+}
+
+// folderManifest is the content of _folder.yaml.
+type folderManifest struct {
+	Name      string            `yaml:"name"`
+	Variables map[string]string `yaml:"variables,omitempty"`
+	Order     []string          `yaml:"order,omitempty"`
+}
+
+// IsSplit reports whether path is the directory of a split collection.
+func IsSplit(path string) bool {
+	info, err := os.Stat(filepath.Join(path, SplitManifest))
+	return err == nil && !info.IsDir()
+}
+
+// Files lists the files the collection at path is stored in: path itself,
+// or the YAML files of a split collection.
+func Files(path string) []string {
+	if !IsSplit(path) {
+		return []string{path}
+	}
+	var files []string
+	_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(p, ".yaml") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files
+}
+
+// LoadSplit loads a split collection from dir. The files are assembled
+// into one document first, so older formats are migrated as usual.
+func LoadSplit(dir string) (*Collection, error) {
+	col, err := parseSplit(dir)
+	if err != nil {
+		return nil, err
+	}
+	assignIDs(col.Items)
+	return col, nil
+}
+
+func parseSplit(dir string) (*Collection, error) {
+	root, order, err := readManifest(filepath.Join(dir, SplitManifest))
+	if err != nil {
+		return nil, err
+	}
+	items, err := readItems(dir, order)
+	if err != nil {
+		return nil, err
+	}
+	setKey(root, "items", items)
+
+	return parseDocument(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}})
+}
+
+// readManifest parses a manifest into a mapping without its order list,
+// which is returned separately.
+func readManifest(path string) (*yaml.Node, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading collection file: %w", err)
+	}
+	root, err := parseMapping(path, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var order []string
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "order" {
+			if err := root.Content[i+1].Decode(&order); err != nil {
+				return nil, nil, fmt.Errorf("parsing %s: order: %w", path, err)
+			}
+			root.Content = slices.Delete(root.Content, i, i+2)
+			break
+		}
+	}
+	return root, order, nil
+}
+
+// readItems reads the requests and folders in dir: those in order first,
+// then the rest by name. Listed items that no longer exist are skipped.
+func readItems(dir string, order []string) (*yaml.Node, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading collection directory: %w", err)
+	}
+	names := slices.Clone(order)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+			if _, err := os.Stat(filepath.Join(dir, e.Name(), FolderManifest)); err != nil {
+				continue
+			}
+		} else if !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+		if !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, ".") && !slices.Contains(order, name) {
+			names = append(names, name)
+		}
+	}
+
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, name := range names {
+		path := filepath.Join(dir, strings.TrimSuffix(name, "/"))
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		key, node := "request", (*yaml.Node)(nil)
+		if strings.HasSuffix(name, "/") {
+			key = "folder"
+			node, err = readFolder(path)
+		} else {
+			node, err = readRequest(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		seq.Content = append(seq.Content, &yaml.Node{
+			Kind:    yaml.MappingNode,
+			Tag:     "!!map",
+			Content: []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, node},
+		})
+	}
+	return seq, nil
+}
+
+func readFolder(dir string) (*yaml.Node, error) {
+	root, order, err := readManifest(filepath.Join(dir, FolderManifest))
+	if err != nil {
+		return nil, err
+	}
+	items, err := readItems(dir, order)
+	if err != nil {
+		return nil, err
+	}
+	setKey(root, "items", items)
+	return root, nil
+}
+
+func readRequest(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading request file: %w", err)
+	}
+	return parseMapping(path, data)
+}
+
+// parseMapping parses a file holding a YAML mapping.
+func parseMapping(path string, data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+	if root := doc.Content[0]; root.Kind == yaml.MappingNode {
+		return root, nil
+	}
+	return nil, fmt.Errorf("parsing %s: expected a mapping", path)
+}
+
+// setKey sets key in mapping to value, adding it if needed.
+func setKey(mapping *yaml.Node, key string, value *yaml.Node) {
+	if existing := migrate.Get(mapping, key); existing != nil {
+		*existing = *value
+		return
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// SaveSplit stores col in dir as a split collection, creating dir if
+// needed. Files already holding the right content are not rewritten, and
+// YAML files of items no longer in the collection are removed.
+func SaveSplit(col *Collection, dir string) error {
+	files, err := splitLayout(col, dir)
+	if err != nil {
+		return err
+	}
+	for path, data := range files {
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("writing collection file: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("writing collection file: %w", err)
+		}
+	}
+
+	stale, err := staleFiles(dir, files)
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("removing %s: %w", path, err)
+		}
+		// Drop folder directories left empty, but nothing else
+		for d := filepath.Dir(path); d != dir && strings.HasPrefix(d, dir); d = filepath.Dir(d) {
+			if os.Remove(d) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// SplitChanges lists the files SaveSplit would write or remove to store
+// col in dir; none means dir is up to date and formatted.
+func SplitChanges(col *Collection, dir string) ([]string, error) {
+	files, err := splitLayout(col, dir)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for path, data := range files {
+		if existing, err := os.ReadFile(path); err != nil || !bytes.Equal(existing, data) {
+			changed = append(changed, path)
+		}
+	}
+	stale, err := staleFiles(dir, files)
+	if err != nil {
+		return nil, err
+	}
+	changed = append(changed, stale...)
+	slices.Sort(changed)
+	return changed, nil
+}
+
+// staleFiles lists the files in dir the loader reads that are not part of
+// files: request files and folder manifests in dir and its folder
+// directories. Other files, such as _notes.yaml or fixtures/user.yaml in a
+// directory without a folder manifest, are never touched.
+func staleFiles(dir string, files map[string][]byte) ([]string, error) {
+	var stale []string
+	var walk func(dir string, folder bool) error
+	walk = func(dir string, folder bool) error {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			name := e.Name()
+			path := filepath.Join(dir, name)
+			if e.IsDir() {
+				if strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+					continue
+				}
+				if _, err := os.Stat(filepath.Join(path, FolderManifest)); err == nil {
+					if err := walk(path, true); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			read := strings.HasSuffix(name, ".yaml") && !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, ".")
+			if folder && name == FolderManifest {
+				read = true
+			}
+			if read && files[path] == nil {
+				stale = append(stale, path)
+			}
+		}
+		return nil
+	}
+	if err := walk(dir, false); err != nil {
+		return nil, fmt.Errorf("reading collection directory: %w", err)
+	}
+	return stale, nil
+}
+
+// splitLayout returns the content of each file of col stored in dir.
+func splitLayout(col *Collection, dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	order, err := layoutItems(col.Items, dir, files)
+	if err != nil {
+		return nil, err
+	}
+	manifest := splitManifest{
+		SchemaVersion: SchemaVersion,
+		Name:          col.Name,
+		Version:       col.Version,
+		Auth:          col.Auth,
+		Variables:     col.Variables,
+		Order:         order,
+		Retry:         col.Retry,
+		Workflows:     col.Workflows,
+		Lint:          col.Lint,
+	}
+	if err := marshalTo(files, filepath.Join(dir, SplitManifest), manifest); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// layoutItems adds the files of items stored in dir and returns their
+// order list.
+func layoutItems(items []Item, dir string, files map[string][]byte) ([]string, error) {
+	var order []string
+	taken := make(map[string]bool)
+	for _, item := range items {
+		switch {
+		case item.Folder != nil:
+			name := uniqueFile(taken, slugify(item.Folder.Name), "/")
+			sub := filepath.Join(dir, strings.TrimSuffix(name, "/"))
+			subOrder, err := layoutItems(item.Folder.Items, sub, files)
+			if err != nil {
+				return nil, err
+			}
+			manifest := folderManifest{Name: item.Folder.Name, Variables: item.Folder.Variables, Order: subOrder}
+			if err := marshalTo(files, filepath.Join(sub, FolderManifest), manifest); err != nil {
+				return nil, err
+			}
+			order = append(order, name)
+		case item.Request != nil:
+			name := uniqueFile(taken, slugify(item.Request.Name), ".yaml")
+			if err := marshalTo(files, filepath.Join(dir, name), item.Request); err != nil {
+				return nil, err
+			}
+			order = append(order, name)
+		}
+	}
+	return order, nil
+}
+
+// uniqueFile returns base+ext, numbered if another item already has it.
+func uniqueFile(taken map[string]bool, base, ext string) string {
+	name := base + ext
+	for i := 2; taken[name]; i++ {
+		name = base + "-" + strconv.Itoa(i) + ext
+	}
+	taken[name] = true
+	return name
+}
+
+func marshalTo(files map[string][]byte, path string, v any) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshaling %s: %w", filepath.Base(path), err)
+	}
+	files[path] = data
+	return nil
+}
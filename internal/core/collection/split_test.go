@@ -0,0 +1,196 @@
+package collection
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitRoundTrip(t *testing.T) {
+	col, err := LoadFromBytes([]byte(sampleYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	col.Items[0].Folder.Variables = map[string]string{"role": "admin"}
+	col.Items = append(col.Items, Item{Request: &Request{ID: "req-4", Name: "Health", Protocol: "http", Method: "GET", URL: "/health"}})
+	col.Workflows = []Workflow{{Name: "smoke", Steps: []WorkflowStep{{Request: "Health"}}}}
+
+	dir := filepath.Join(t.TempDir(), "api.gottp")
+	if err := SaveSplit(col, dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"_collection.yaml", "health.yaml", "users/_folder.yaml", "users/list-users.yaml", "products/list-products.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Errorf("missing %s: %v", f, err)
+		}
+	}
+	if !IsSplit(dir) || IsSplit(filepath.Join(dir, "users")) {
+		t.Error("IsSplit should only hold for the collection directory")
+	}
+
+	loaded, err := LoadFromFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range FlattenItems(loaded.Items, 0, "") {
+		if item.Request != nil {
+			got = append(got, item.Request.ID)
+		} else {
+			got = append(got, item.Folder.Name)
+		}
+	}
+	if want := "Users req-1 req-2 Products req-3 req-4"; strings.Join(got, " ") != want {
+		t.Errorf("items = %q, want %q", strings.Join(got, " "), want)
+	}
+	if loaded.Name != "Test API" || loaded.Variables["base_url"] != "https://api.example.com" {
+		t.Errorf("collection fields lost: %+v", loaded)
+	}
+	if loaded.Items[0].Folder.Variables["role"] != "admin" {
+		t.Error("folder variables lost")
+	}
+	if len(loaded.Workflows) != 1 || loaded.Workflows[0].Steps[0].Request != "Health" {
+		t.Errorf("workflows = %+v", loaded.Workflows)
+	}
+	if loaded.Items[0].Folder.Items[1].Request.Body.Content != `{"name":"test"}` {
+		t.Error("request body lost")
+	}
+
+	changed, err := SplitChanges(loaded, dir)
+	if err != nil || len(changed) != 0 {
+		t.Errorf("SplitChanges after round trip = %v, %v; want none", changed, err)
+	}
+}
+
+func TestSplitSaveWritesOnlyChanges(t *testing.T) {
+	col, _ := LoadFromBytes([]byte(sampleYAML))
+	dir := filepath.Join(t.TempDir(), "api.gottp")
+	if err := SaveSplit(col, dir); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	products := filepath.Join(dir, "products", "list-products.yaml")
+	if err := os.Chtimes(products, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files the loader doesn't read are not the collection's to remove
+	kept := []string{"_notes.yaml", ".ci.yaml", "fixtures/x.yaml", "users/_draft.yaml"}
+	for _, name := range kept {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("a: 1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Rename a request and delete the Products folder
+	col.Items[0].Folder.Items[0].Request.Name = "All Users"
+	col.Items = col.Items[:1]
+	if err := SaveToFile(col, dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "users", "all-users.yaml")); err != nil {
+		t.Error("renamed request not written")
+	}
+	for _, stale := range []string{"users/list-users.yaml", "products"} {
+		if _, err := os.Stat(filepath.Join(dir, stale)); err == nil {
+			t.Errorf("%s should have been removed", stale)
+		}
+	}
+
+	for _, name := range kept {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s should survive a save: %v", name, err)
+		}
+	}
+
+	// Files that did not change are left alone
+	col.Items = append(col.Items, Item{Request: &Request{ID: "x", Name: "Ping"}})
+	if err := SaveSplit(col, dir); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "users", "create-user.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := info.ModTime()
+	if err := os.Chtimes(filepath.Join(dir, "users", "create-user.yaml"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveSplit(col, dir); err != nil {
+		t.Fatal(err)
+	}
+	info, _ = os.Stat(filepath.Join(dir, "users", "create-user.yaml"))
+	if !info.ModTime().Equal(old) {
+		t.Errorf("unchanged file rewritten (mod time %v, was %v)", info.ModTime(), before)
+	}
+}
+
+func TestLoadSplit_UnlistedAndMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("_collection.yaml", "name: Hand\norder: [b.yaml, gone.yaml]\n")
+	write("b.yaml", "name: B\nmethod: GET\nurl: /b\n")
+	write("a.yaml", "name: A\nmethod: GET\nurl: /a\n")
+	write("admin/_folder.yaml", "name: Admin\n")
+	write("admin/c.yaml", "name: C\nmethod: GET\nurl: /c\n")
+	write("notes.txt", "not a request")
+
+	col, err := LoadSplit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range FlattenItems(col.Items, 0, "") {
+		if item.Request != nil {
+			got = append(got, item.Request.Name)
+			if item.Request.ID == "" {
+				t.Errorf("%s has no ID", item.Request.Name)
+			}
+		} else {
+			got = append(got, item.Folder.Name)
+		}
+	}
+	if want := "B A Admin C"; strings.Join(got, " ") != want {
+		t.Errorf("items = %q, want %q", strings.Join(got, " "), want)
+	}
+
+	write("bad.yaml", "- not a mapping\n")
+	if _, err := LoadSplit(dir); err == nil || !strings.Contains(err.Error(), "bad.yaml") {
+		t.Errorf("error = %v, want it to name bad.yaml", err)
+	}
+}
+
+func TestSplitLayout_DuplicateNames(t *testing.T) {
+	col := &Collection{Name: "Dupes", Items: []Item{
+		{Request: &Request{ID: "1", Name: "Get"}},
+		{Request: &Request{ID: "2", Name: "Get"}},
+	}}
+	dir := t.TempDir()
+	if err := SaveSplit(col, dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSplit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Items) != 2 || loaded.Items[0].Request.ID != "1" || loaded.Items[1].Request.ID != "2" {
+		t.Errorf("items = %+v", loaded.Items)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "get-2.yaml")); err != nil {
+		t.Error("second request should be stored as get-2.yaml")
+	}
+}
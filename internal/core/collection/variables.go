@@ -0,0 +1,49 @@
+package collection
+
+// FolderPath returns the folders enclosing req, outermost first. It is
+// empty for top-level requests and requests not in col.
+func FolderPath(col *Collection, req *Request) []*Folder {
+	if col == nil || req == nil {
+		return nil
+	}
+	path, _ := folderPathIn(col.Items, req, nil)
+	return path
+}
+
+func folderPathIn(items []Item, req *Request, path []*Folder) ([]*Folder, bool) {
+	for _, item := range items {
+		if item.Request == req {
+			return path, true
+		}
+		if item.Folder != nil {
+			if found, ok := folderPathIn(item.Folder.Items, req, append(path, item.Folder)); ok {
+				return found, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ScopedVars returns the folder and request variables in effect for req.
+// Variables resolve from the narrowest scope: the request, its folders
+// (innermost first), the environment, the collection, then the OS
+// environment. It returns nil when neither req nor its folders define any.
+func ScopedVars(col *Collection, req *Request) map[string]string {
+	if req == nil {
+		return nil
+	}
+	var vars map[string]string
+	add := func(layer map[string]string) {
+		for k, v := range layer {
+			if vars == nil {
+				vars = make(map[string]string)
+			}
+			vars[k] = v
+		}
+	}
+	for _, f := range FolderPath(col, req) {
+		add(f.Variables)
+	}
+	add(req.Variables)
+	return vars
+}
@@ -0,0 +1,101 @@
+package environment
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// dotenvKey matches the variable names a .env file may set.
+var dotenvKey = regexp.MustCompile(`^\w+$`)
+
+// envRefPattern matches {{env:NAME}}, which reads NAME from the process
+// environment (including variables loaded from a .env file).
+var envRefPattern = regexp.MustCompile(`\{\{env:(\w+)\}\}`)
+
+// resolveEnvRefs replaces {{env:NAME}} references that are set, leaving
+// the rest in place.
+func resolveEnvRefs(input string) string {
+	if !strings.Contains(input, "{{env:") {
+		return input
+	}
+	return envRefPattern.ReplaceAllStringFunc(input, func(match string) string {
+		name := envRefPattern.FindStringSubmatch(match)[1]
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		return match
+	})
+}
+
+// LoadDotEnv reads KEY=VALUE lines from a .env file into the process
+// environment so {{env:KEY}} and plain {{KEY}} references can use them.
+// Variables already set win, so CI can override the file. A missing file
+// is not an error. It returns the names it set.
+func LoadDotEnv(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading .env: %w", err)
+	}
+	defer f.Close()
+
+	vars, err := parseDotEnv(bufio.NewScanner(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var set []string
+	for _, kv := range vars {
+		if _, ok := os.LookupEnv(kv[0]); ok {
+			continue
+		}
+		if err := os.Setenv(kv[0], kv[1]); err != nil {
+			return set, err
+		}
+		set = append(set, kv[0])
+	}
+	return set, nil
+}
+
+// parseDotEnv parses the common .env format: blank lines and # comments
+// are skipped, an "export " prefix is allowed, and values may be single
+// quoted (literal) or double quoted (with \n, \t, \" and \\ escapes).
+// Unquoted values end at " #".
+func parseDotEnv(sc *bufio.Scanner) ([][2]string, error) {
+	var vars [][2]string
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !dotenvKey.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = unescapeDouble(value[1 : len(value)-1])
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		vars = append(vars, [2]string{key, value})
+	}
+	return vars, sc.Err()
+}
+
+var doubleQuoted = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+
+func unescapeDouble(s string) string {
+	return doubleQuoted.Replace(s)
+}
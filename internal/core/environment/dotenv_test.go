@@ -0,0 +1,69 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(path, []byte(`# CI secrets
+GOTTP_DOTENV_TOKEN=abc123 # inline comment
+export GOTTP_DOTENV_QUOTED="line one\nsays \"hi\""
+GOTTP_DOTENV_LITERAL='a\nb # kept'
+GOTTP_DOTENV_SET=from-file
+
+`), 0o644)
+	t.Setenv("GOTTP_DOTENV_SET", "from-ci")
+	for _, k := range []string{"GOTTP_DOTENV_TOKEN", "GOTTP_DOTENV_QUOTED", "GOTTP_DOTENV_LITERAL"} {
+		t.Cleanup(func() { os.Unsetenv(k) })
+	}
+
+	set, err := LoadDotEnv(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 3 || slices.Contains(set, "GOTTP_DOTENV_SET") {
+		t.Errorf("set = %v, variables already in the environment should win", set)
+	}
+	want := map[string]string{
+		"GOTTP_DOTENV_TOKEN":   "abc123",
+		"GOTTP_DOTENV_QUOTED":  "line one\nsays \"hi\"",
+		"GOTTP_DOTENV_LITERAL": `a\nb # kept`,
+		"GOTTP_DOTENV_SET":     "from-ci",
+	}
+	for k, v := range want {
+		if got := os.Getenv(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+
+	if set, err := LoadDotEnv(filepath.Join(t.TempDir(), ".env")); set != nil || err != nil {
+		t.Errorf("missing file: %v, %v", set, err)
+	}
+	os.WriteFile(path, []byte("GOTTP_OK=1\nnot a pair\n"), 0o644)
+	if _, err := LoadDotEnv(path); err == nil {
+		t.Error("expected an error for a malformed line")
+	}
+}
+
+func TestResolveEnvRefs(t *testing.T) {
+	t.Setenv("GOTTP_TEST_SECRET", "s3cr3t")
+	envVars := map[string]string{"token": "{{env:GOTTP_TEST_SECRET}}", "GOTTP_TEST_SECRET": "shadowed"}
+
+	if got := Resolve("{{env:GOTTP_TEST_SECRET}}", envVars, nil); got != "s3cr3t" {
+		t.Errorf("env ref = %q, should read the OS environment only", got)
+	}
+	if got := Resolve("Bearer {{token}}", envVars, nil); got != "Bearer s3cr3t" {
+		t.Errorf("env ref in a variable = %q", got)
+	}
+	if got := Resolve("{{env:GOTTP_TEST_UNSET}}", envVars, nil); got != "{{env:GOTTP_TEST_UNSET}}" {
+		t.Errorf("unset env ref = %q, should be left in place", got)
+	}
+	got := Unresolved("{{env:GOTTP_TEST_SECRET}}/{{env:GOTTP_TEST_UNSET}}/{{env:GOTTP_TEST_UNSET}}", nil, nil)
+	if len(got) != 1 || got[0] != "env:GOTTP_TEST_UNSET" {
+		t.Errorf("Unresolved = %v", got)
+	}
+}
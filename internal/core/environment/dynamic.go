@@ -0,0 +1,70 @@
+package environment
+
+import (
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dynamicPattern matches built-in variables such as {{$uuid}} and
+// {{$randomString(8)}}.
+var dynamicPattern = regexp.MustCompile(`\{\{\$(\w+)(?:\((\d*)\))?\}\}`)
+
+// DynamicVars lists the built-in variables, which generate a new value for
+// every occurrence.
+var DynamicVars = []string{"$uuid", "$timestamp", "$isoDate", "$randomInt", "$randomEmail", "$randomString(n)"}
+
+const (
+	defaultRandomString = 16
+	maxRandomString     = 1024
+	alphanumeric        = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// ResolveDynamic replaces built-in variables in input. Unknown names are
+// left in place.
+func ResolveDynamic(input string) string {
+	if !strings.Contains(input, "{{$") {
+		return input
+	}
+	return dynamicPattern.ReplaceAllStringFunc(input, func(match string) string {
+		m := dynamicPattern.FindStringSubmatch(match)
+		if v, ok := dynamicValue(m[1], m[2]); ok {
+			return v
+		}
+		return match
+	})
+}
+
+func dynamicValue(name, arg string) (string, bool) {
+	switch name {
+	case "uuid", "guid":
+		return uuid.New().String(), true
+	case "timestamp":
+		return strconv.FormatInt(time.Now().Unix(), 10), true
+	case "isoDate", "isoTimestamp":
+		return time.Now().UTC().Format(time.RFC3339), true
+	case "randomInt":
+		return strconv.Itoa(rand.IntN(10000)), true
+	case "randomEmail":
+		return "user-" + randomString(8, "abcdefghijklmnopqrstuvwxyz0123456789") + "@example.com", true
+	case "randomString":
+		n := defaultRandomString
+		if arg != "" {
+			n, _ = strconv.Atoi(arg)
+		}
+		return randomString(min(n, maxRandomString), alphanumeric), true
+	}
+	return "", false
+}
+
+func randomString(n int, chars string) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = chars[rand.IntN(len(chars))]
+	}
+	return string(b)
+}
@@ -5,17 +5,36 @@ import (
 	"os"
 
 	"gopkg.in/yaml.v3"
+
+	gotls "github.com/sadopc/gottp/internal/core/tls"
 )
 
 // EnvironmentFile holds all environments.
 type EnvironmentFile struct {
-	Environments []Environment `yaml:"environments"`
+	SchemaVersion int           `yaml:"schema_version,omitempty"` // file format; see SchemaVersion
+	Environments  []Environment `yaml:"environments"`
 }
 
 // Environment represents a named set of variables.
 type Environment struct {
 	Name      string              `yaml:"name"`
 	Variables map[string]Variable `yaml:"variables"`
+	Proxy     *Proxy              `yaml:"proxy,omitempty"`
+	TLS       *gotls.Config       `yaml:"tls,omitempty"`
+
+	// Hosts sends connections for a host (or host:port) to another address
+	// while the environment is active, e.g. api.example.com: 127.0.0.1:8443.
+	// Values may reference variables.
+	Hosts map[string]string `yaml:"hosts,omitempty"`
+}
+
+// Proxy overrides the global proxy while its environment is active. Values
+// may reference variables.
+type Proxy struct {
+	URL      string `yaml:"url"` // http://, https://, socks5:// or "direct"
+	NoProxy  string `yaml:"no_proxy,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
 }
 
 // Variable represents an environment variable value.
@@ -33,13 +52,37 @@ func LoadEnvironments(path string) (*EnvironmentFile, error) {
 		}
 		return nil, fmt.Errorf("reading environments: %w", err)
 	}
-	var ef EnvironmentFile
-	if err := yaml.Unmarshal(data, &ef); err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
 		return nil, fmt.Errorf("parsing environments: %w", err)
 	}
+	if _, err := Migrate(&doc); err != nil {
+		return nil, fmt.Errorf("migrating environments: %w", err)
+	}
+	var ef EnvironmentFile
+	if doc.Kind != 0 {
+		if err := doc.Decode(&ef); err != nil {
+			return nil, fmt.Errorf("parsing environments: %w", err)
+		}
+	}
+	ef.SchemaVersion = SchemaVersion
 	return &ef, nil
 }
 
+// SaveEnvironments writes environments to a YAML file.
+func SaveEnvironments(ef *EnvironmentFile, path string) error {
+	stamped := *ef
+	stamped.SchemaVersion = SchemaVersion
+	data, err := yaml.Marshal(&stamped)
+	if err != nil {
+		return fmt.Errorf("marshaling environments: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing environments: %w", err)
+	}
+	return nil
+}
+
 // GetVariables returns a flat map of variable name -> value for the given environment.
 func (ef *EnvironmentFile) GetVariables(envName string) map[string]string {
 	result := make(map[string]string)
@@ -54,6 +97,38 @@ func (ef *EnvironmentFile) GetVariables(envName string) map[string]string {
 	return result
 }
 
+// GetProxy returns the proxy of the given environment, or nil if it has none.
+func (ef *EnvironmentFile) GetProxy(envName string) *Proxy {
+	for _, env := range ef.Environments {
+		if env.Name == envName {
+			return env.Proxy
+		}
+	}
+	return nil
+}
+
+// GetTLS returns the TLS settings of the given environment, or nil if it has
+// none.
+func (ef *EnvironmentFile) GetTLS(envName string) *gotls.Config {
+	for _, env := range ef.Environments {
+		if env.Name == envName {
+			return env.TLS
+		}
+	}
+	return nil
+}
+
+// GetHosts returns the host overrides of the given environment, or nil if
+// it has none.
+func (ef *EnvironmentFile) GetHosts(envName string) map[string]string {
+	for _, env := range ef.Environments {
+		if env.Name == envName {
+			return env.Hosts
+		}
+	}
+	return nil
+}
+
 // Names returns all environment names.
 func (ef *EnvironmentFile) Names() []string {
 	names := make([]string, len(ef.Environments))
@@ -62,3 +137,19 @@ func (ef *EnvironmentFile) Names() []string {
 	}
 	return names
 }
+
+// SecretValues returns the values of variables marked secret in the given environment.
+func (ef *EnvironmentFile) SecretValues(envName string) []string {
+	var values []string
+	for _, env := range ef.Environments {
+		if env.Name == envName {
+			for _, v := range env.Variables {
+				if v.Secret && v.Value != "" {
+					values = append(values, v.Value)
+				}
+			}
+			break
+		}
+	}
+	return values
+}
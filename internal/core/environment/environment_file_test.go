@@ -86,6 +86,8 @@ func TestLoadEnvironments_ValidFile(t *testing.T) {
     variables:
       base_url:
         value: "https://api.example.com"
+    hosts:
+      api.example.com: "{{local}}:8443"
 `
 	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
 		t.Fatalf("WriteFile failed: %v", err)
@@ -101,4 +103,57 @@ func TestLoadEnvironments_ValidFile(t *testing.T) {
 	if !ef.Environments[0].Variables["token"].Secret {
 		t.Fatal("expected token variable to be marked secret")
 	}
+	if got := ef.GetHosts("Production")["api.example.com"]; got != "{{local}}:8443" {
+		t.Fatalf("hosts = %v", ef.GetHosts("Production"))
+	}
+	if ef.GetHosts("Development") != nil {
+		t.Fatal("expected no hosts for Development")
+	}
+}
+
+func TestLoadEnvironments_SchemaVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "environments.yaml")
+	if err := os.WriteFile(path, []byte("environments:\n  - name: Dev\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	ef, err := LoadEnvironments(path)
+	if err != nil {
+		t.Fatalf("LoadEnvironments failed: %v", err)
+	}
+	if ef.SchemaVersion != SchemaVersion || len(ef.Environments) != 1 {
+		t.Errorf("got schema version %d and %d environments", ef.SchemaVersion, len(ef.Environments))
+	}
+
+	if err := os.WriteFile(path, []byte("schema_version: 99\nenvironments: []\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := LoadEnvironments(path); err == nil {
+		t.Fatal("expected an error for a file from a newer gottp")
+	}
+}
+
+func TestSaveEnvironments_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "environments.yaml")
+	ef := &EnvironmentFile{Environments: []Environment{{
+		Name: "Dev",
+		Variables: map[string]Variable{
+			"base_url": {Value: "http://localhost:8080"},
+			"token":    {Value: "abc", Secret: true},
+		},
+	}}}
+	if err := SaveEnvironments(ef, path); err != nil {
+		t.Fatalf("SaveEnvironments failed: %v", err)
+	}
+
+	loaded, err := LoadEnvironments(path)
+	if err != nil {
+		t.Fatalf("LoadEnvironments failed: %v", err)
+	}
+	vars := loaded.GetVariables("Dev")
+	if vars["base_url"] != "http://localhost:8080" || vars["token"] != "abc" {
+		t.Errorf("variables = %v", vars)
+	}
+	if !loaded.Environments[0].Variables["token"].Secret {
+		t.Error("secret flag lost")
+	}
 }
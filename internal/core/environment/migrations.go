@@ -0,0 +1,22 @@
+package environment
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/sadopc/gottp/internal/core/migrate"
+)
+
+// SchemaVersion is the environments file format this build reads and
+// writes. Bump it with a new entry in migrations whenever the format
+// changes.
+const SchemaVersion = 1
+
+var migrations = []migrate.Migration{
+	{From: 0, Description: "record the schema version"},
+}
+
+// Migrate upgrades a decoded environments document to SchemaVersion in
+// place.
+func Migrate(doc *yaml.Node) (migrate.Result, error) {
+	return migrate.Upgrade(doc, SchemaVersion, migrations)
+}
@@ -3,16 +3,34 @@ package environment
 import (
 	"os"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 )
 
 var varPattern = regexp.MustCompile(`\{\{(\w+)\}\}`)
 
+// templatePattern matches {{variable}} placeholders and built-in variables
+// such as {{$uuid}}, so both are replaced in a single pass.
+var templatePattern = regexp.MustCompile(varPattern.String() + "|" + dynamicPattern.String())
+
 // Resolve replaces {{variable}} placeholders in a string using the provided variable map.
 // It checks environment variables, then collection variables, then OS env vars.
+// {{env:NAME}} reads NAME from the OS environment only, including in the
+// values of other variables, so environments can pull in CI secrets.
+// Built-in variables such as {{$uuid}} are replaced too (see
+// ResolveDynamic), but only in input itself: a variable's value, such as a
+// captured response field, is used as it is.
 func Resolve(input string, envVars, colVars map[string]string) string {
-	return varPattern.ReplaceAllStringFunc(input, func(match string) string {
-		key := strings.TrimPrefix(strings.TrimSuffix(match, "}}"), "{{")
+	return resolveEnvRefs(templatePattern.ReplaceAllStringFunc(input, func(match string) string {
+		m := templatePattern.FindStringSubmatch(match)
+		if m[1] == "" {
+			if v, ok := dynamicValue(m[2], m[3]); ok {
+				return v
+			}
+			return match
+		}
+		key := m[1]
 		// Priority: environment vars > collection vars > OS env
 		if v, ok := envVars[key]; ok {
 			return v
@@ -24,7 +42,63 @@ func Resolve(input string, envVars, colVars map[string]string) string {
 			return v
 		}
 		return match // leave unreplaced
-	})
+	}))
+}
+
+// Overlay returns a copy of base with the variables in top added over it.
+// It returns base itself when top is empty.
+func Overlay(base, top map[string]string) map[string]string {
+	if len(top) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(top))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range top {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Unresolved returns the names of {{variables}} in input that Resolve
+// would leave in place, in order of first appearance. Unset {{env:NAME}}
+// references follow as "env:NAME".
+func Unresolved(input string, envVars, colVars map[string]string) []string {
+	var names []string
+	for _, m := range varPattern.FindAllStringSubmatch(input, -1) {
+		name := m[1]
+		if _, ok := envVars[name]; ok {
+			continue
+		}
+		if _, ok := colVars[name]; ok {
+			continue
+		}
+		if os.Getenv(name) != "" || slices.Contains(names, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	for _, m := range envRefPattern.FindAllStringSubmatch(input, -1) {
+		if _, ok := os.LookupEnv(m[1]); !ok && !slices.Contains(names, "env:"+m[1]) {
+			names = append(names, "env:"+m[1])
+		}
+	}
+	return names
+}
+
+// References returns the names of the {{variables}} in inputs, in order of
+// first appearance.
+func References(inputs ...string) []string {
+	var names []string
+	for _, in := range inputs {
+		for _, m := range varPattern.FindAllStringSubmatch(in, -1) {
+			if !slices.Contains(names, m[1]) {
+				names = append(names, m[1])
+			}
+		}
+	}
+	return names
 }
 
 // ResolveKVPairs resolves variables in key-value pairs.
@@ -46,3 +120,39 @@ type KVPair struct {
 	Value   string
 	Enabled bool
 }
+
+// placeholderValues are values left in environment files as reminders to
+// fill them in; they are treated like empty values.
+var placeholderValues = map[string]bool{
+	"todo": true, "tbd": true, "changeme": true, "change_me": true,
+	"replace_me": true, "xxx": true, "...": true,
+}
+
+// IsPlaceholder reports whether a variable value is empty, blank or a
+// placeholder such as "TODO", "changeme" or "<your-token>".
+func IsPlaceholder(v string) bool {
+	v = strings.TrimSpace(v)
+	if v == "" || placeholderValues[strings.ToLower(v)] {
+		return true
+	}
+	return len(v) > 2 && strings.HasPrefix(v, "<") && strings.HasSuffix(v, ">") && !strings.ContainsAny(v[1:len(v)-1], "<>")
+}
+
+// EmptyVars returns the sorted names of variables referenced in inputs
+// that are defined in envVars with an empty or placeholder value. Sending
+// such a request usually produces URLs like "https:///users".
+func EmptyVars(envVars map[string]string, inputs ...string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, in := range inputs {
+		for _, m := range varPattern.FindAllStringSubmatch(in, -1) {
+			v, ok := envVars[m[1]]
+			if ok && !seen[m[1]] && IsPlaceholder(v) {
+				seen[m[1]] = true
+				names = append(names, m[1])
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
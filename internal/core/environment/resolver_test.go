@@ -1,6 +1,11 @@
 package environment
 
-import "testing"
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
 
 func TestResolve(t *testing.T) {
 	envVars := map[string]string{
@@ -48,3 +53,84 @@ func TestResolveKVPairs(t *testing.T) {
 		t.Error("second pair should still be disabled")
 	}
 }
+
+func TestEmptyVars(t *testing.T) {
+	envVars := map[string]string{
+		"base_url": "",
+		"token":    "<your-token>",
+		"user":     "TODO",
+		"version":  "v1",
+		"html":     "<b>bold</b>",
+	}
+	got := EmptyVars(envVars,
+		"https://{{base_url}}/{{version}}/users",
+		"Bearer {{token}} {{token}}",
+		"{{html}} {{undefined}} {{user}}",
+	)
+	want := []string{"base_url", "token", "user"}
+	if len(got) != len(want) {
+		t.Fatalf("EmptyVars = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("EmptyVars = %v, want %v", got, want)
+		}
+	}
+
+	if got := EmptyVars(nil, "{{base_url}}"); got != nil {
+		t.Errorf("EmptyVars without environment = %v, want nil", got)
+	}
+}
+
+func TestOverlayAndUnresolved(t *testing.T) {
+	env := map[string]string{"host": "env", "token": "t"}
+	merged := Overlay(env, map[string]string{"host": "req"})
+	if merged["host"] != "req" || merged["token"] != "t" || env["host"] != "env" {
+		t.Errorf("Overlay = %v, base = %v", merged, env)
+	}
+
+	t.Setenv("GOTTP_TEST_HOME", "/home")
+	got := Unresolved("{{host}}/{{missing}}/{{GOTTP_TEST_HOME}}/{{version}}/{{missing}}", merged, map[string]string{"version": "v1"})
+	if len(got) != 1 || got[0] != "missing" {
+		t.Errorf("Unresolved = %v, want [missing]", got)
+	}
+}
+
+func TestResolveDynamic(t *testing.T) {
+	got := Resolve("/users/{{$uuid}}/{{$uuid}}?at={{$timestamp}}&id={{id}}", map[string]string{"id": "7"}, nil)
+	parts := strings.Split(strings.TrimPrefix(got, "/users/"), "/")
+	if len(parts) != 2 || len(parts[0]) != 36 || parts[0] == strings.Split(parts[1], "?")[0] {
+		t.Errorf("each {{$uuid}} should get a new UUID: %s", got)
+	}
+	if !strings.HasSuffix(got, "&id=7") || strings.Contains(got, "{{") {
+		t.Errorf("unexpected resolution: %s", got)
+	}
+
+	// Values are not expanded again, e.g. a captured response field
+	vars := map[string]string{"note": "literal {{$uuid}}"}
+	if got := Resolve("{{note}} {{$randomInt}}", vars, nil); !regexp.MustCompile(`^literal \{\{\$uuid\}\} \d{1,4}$`).MatchString(got) {
+		t.Errorf("built-in variables in values should be left alone: %s", got)
+	}
+
+	patterns := map[string]*regexp.Regexp{
+		"{{$isoDate}}":         regexp.MustCompile(`^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ$`),
+		"{{$randomInt}}":       regexp.MustCompile(`^\d{1,4}$`),
+		"{{$randomEmail}}":     regexp.MustCompile(`^user-[a-z0-9]{8}@example\.com$`),
+		"{{$randomString}}":    regexp.MustCompile(`^[A-Za-z0-9]{16}$`),
+		"{{$randomString(5)}}": regexp.MustCompile(`^[A-Za-z0-9]{5}$`),
+		"{{$unknown}}":         regexp.MustCompile(`^\{\{\$unknown\}\}$`),
+	}
+	for in, want := range patterns {
+		if got := ResolveDynamic(in); !want.MatchString(got) {
+			t.Errorf("ResolveDynamic(%s) = %q", in, got)
+		}
+	}
+}
+
+func TestReferences(t *testing.T) {
+	got := References("{{base_url}}/users/{{id}}", "Bearer {{token}}", "{{id}} {{$uuid}} {{env:HOME}}")
+	want := []string{"base_url", "id", "token"}
+	if !slices.Equal(got, want) {
+		t.Errorf("References = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package history
+
+import (
+	"encoding/json"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+// BaseURLVar is the collection variable ToCollection puts the most used
+// base URL in.
+const BaseURLVar = "baseUrl"
+
+// skippedHeaders are set by the client and not worth replaying.
+var skippedHeaders = map[string]bool{"content-length": true, "host": true}
+
+// ToCollection turns history entries into a collection named name, oldest
+// first. Repeats of a request (same method, URL and body) are kept once.
+// The base URL most requests share becomes the {{baseUrl}} variable.
+func ToCollection(name string, entries []Entry) *collection.Collection {
+	// Entries come newest first
+	ordered := slices.Clone(entries)
+	slices.Reverse(ordered)
+
+	seen := make(map[string]bool)
+	var unique []Entry
+	for _, e := range ordered {
+		key := e.Method + " " + e.URL + "\n" + e.RequestBody
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, e)
+	}
+
+	col := &collection.Collection{Name: name, Version: "1"}
+	base := commonBaseURL(unique)
+	if base != "" {
+		col.Variables = map[string]string{BaseURLVar: base}
+	}
+	for _, e := range unique {
+		col.Items = append(col.Items, collection.Item{Request: entryRequest(e, base)})
+	}
+	return col
+}
+
+func entryRequest(e Entry, base string) *collection.Request {
+	u := e.URL
+	if base != "" && (u == base || strings.HasPrefix(u, base+"/") || strings.HasPrefix(u, base+"?")) {
+		u = "{{" + BaseURLVar + "}}" + strings.TrimPrefix(u, base)
+	}
+	req := collection.NewRequest(requestName(e), e.Method, u)
+
+	var headers map[string]string
+	if e.Headers != "" && json.Unmarshal([]byte(e.Headers), &headers) == nil {
+		keys := make([]string, 0, len(headers))
+		for k := range headers {
+			if !skippedHeaders[strings.ToLower(k)] {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			req.Headers = append(req.Headers, collection.KVPair{Key: k, Value: headers[k], Enabled: true})
+		}
+	}
+	if e.RequestBody != "" {
+		bodyType := "text"
+		if json.Valid([]byte(e.RequestBody)) {
+			bodyType = "json"
+		}
+		req.Body = &collection.Body{Type: bodyType, Content: e.RequestBody}
+	}
+	return req
+}
+
+// requestName names a request after its method and path, e.g.
+// "GET /users/42".
+func requestName(e Entry) string {
+	path := e.URL
+	if u, err := url.Parse(e.URL); err == nil && u.Host != "" {
+		path = u.EscapedPath()
+		if path == "" {
+			path = "/"
+		}
+	}
+	return e.Method + " " + path
+}
+
+// commonBaseURL returns the origin most entries use, extended by the path
+// segments all of that origin's requests share, or "" when no URL has an
+// origin.
+func commonBaseURL(entries []Entry) string {
+	counts := make(map[string]int)
+	paths := make(map[string][][]string)
+	for _, e := range entries {
+		u, err := url.Parse(e.URL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			continue
+		}
+		origin := u.Scheme + "://" + u.Host
+		counts[origin]++
+		segs := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+		// The last segment names the resource, so it never joins the base
+		paths[origin] = append(paths[origin], segs[:len(segs)-1])
+	}
+	best := ""
+	for origin, n := range counts {
+		if n > counts[best] || (n == counts[best] && origin < best) {
+			best = origin
+		}
+	}
+	if best == "" {
+		return ""
+	}
+
+	prefix := paths[best][0]
+	for _, segs := range paths[best][1:] {
+		n := 0
+		for n < len(prefix) && n < len(segs) && prefix[n] == segs[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	if len(prefix) > 0 && prefix[0] != "" {
+		return best + "/" + strings.Join(prefix, "/")
+	}
+	return best
+}
@@ -0,0 +1,53 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToCollection(t *testing.T) {
+	now := time.Now()
+	// Newest first, as ListFiltered returns them
+	entries := []Entry{
+		{Method: "GET", URL: "https://api.example.com/v1/users", Timestamp: now},
+		{Method: "POST", URL: "https://api.example.com/v1/users", Timestamp: now.Add(-time.Minute),
+			Headers: `{"Content-Type":"application/json","Content-Length":"13"}`, RequestBody: `{"name":"a"}`},
+		{Method: "GET", URL: "https://cdn.example.com/logo.png", Timestamp: now.Add(-2 * time.Minute)},
+		{Method: "GET", URL: "https://api.example.com/v1/users", Timestamp: now.Add(-3 * time.Minute)},
+		{Method: "GET", URL: "https://api.example.com/v1/orders/7?full=1", Timestamp: now.Add(-4 * time.Minute)},
+		{Method: "GET", URL: "{{host}}/health", Timestamp: now.Add(-5 * time.Minute)},
+	}
+
+	col := ToCollection("Session", entries)
+	if col.Name != "Session" || col.Variables[BaseURLVar] != "https://api.example.com/v1" {
+		t.Fatalf("unexpected collection: %+v", col)
+	}
+	want := []struct{ name, url string }{
+		{"GET {{host}}/health", "{{host}}/health"},
+		{"GET /v1/orders/7", "{{baseUrl}}/orders/7?full=1"},
+		{"GET /v1/users", "{{baseUrl}}/users"},
+		{"GET /logo.png", "https://cdn.example.com/logo.png"},
+		{"POST /v1/users", "{{baseUrl}}/users"},
+	}
+	if len(col.Items) != len(want) {
+		t.Fatalf("expected %d requests, got %d", len(want), len(col.Items))
+	}
+	for i, w := range want {
+		req := col.Items[i].Request
+		if req.Name != w.name || req.URL != w.url {
+			t.Errorf("request %d: got %q %q, want %q %q", i, req.Name, req.URL, w.name, w.url)
+		}
+	}
+
+	post := col.Items[4].Request
+	if len(post.Headers) != 1 || post.Headers[0].Key != "Content-Type" {
+		t.Errorf("headers: %+v", post.Headers)
+	}
+	if post.Body == nil || post.Body.Type != "json" || post.Body.Content != `{"name":"a"}` {
+		t.Errorf("body: %+v", post.Body)
+	}
+
+	if col := ToCollection("Empty", nil); len(col.Items) != 0 || col.Variables != nil {
+		t.Errorf("empty history: %+v", col)
+	}
+}
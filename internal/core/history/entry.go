@@ -1,6 +1,12 @@
 package history
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Entry represents a single history entry.
 type Entry struct {
@@ -14,4 +20,45 @@ type Entry struct {
 	ResponseBody string
 	Headers      string // JSON-encoded request headers
 	Timestamp    time.Time
+
+	// Tags and Note are added afterwards to find the entry again, e.g.
+	// "prod-incident" and what went wrong.
+	Tags []string
+	Note string
+}
+
+// ParseTags splits a list of tags separated by commas or spaces, dropping
+// a leading # and duplicates.
+func ParseTags(s string) []string {
+	var tags []string
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		tag := strings.TrimPrefix(f, "#")
+		if tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// ParseTime reads a time as an age before now ("90m", "24h", "7d")
+// or a date or RFC 3339 timestamp. An empty string is the zero time.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is neither an age like 24h or 7d nor a date like 2006-01-02", s)
 }
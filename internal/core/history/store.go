@@ -3,11 +3,15 @@ package history
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// entryColumns are the columns scanEntries reads, in order.
+const entryColumns = "id, method, url, status_code, duration_ns, size, request_body, response_body, headers, timestamp, tags, note"
+
 // Store manages request history persistence.
 type Store struct {
 	db *sql.DB
@@ -48,17 +52,49 @@ func createTables(db *sql.DB) error {
 	if err != nil {
 		return fmt.Errorf("creating history table: %w", err)
 	}
+	return addColumns(db, map[string]string{
+		"tags": "TEXT NOT NULL DEFAULT ''",
+		"note": "TEXT NOT NULL DEFAULT ''",
+	})
+}
+
+// addColumns adds columns missing from history tables created by older
+// versions.
+func addColumns(db *sql.DB, columns map[string]string) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('history')")
+	if err != nil {
+		return fmt.Errorf("reading history table: %w", err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("reading history table: %w", err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	for name, def := range columns {
+		if existing[name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE history ADD COLUMN " + name + " " + def); err != nil {
+			return fmt.Errorf("adding history column %s: %w", name, err)
+		}
+	}
 	return nil
 }
 
 // Add inserts a new history entry.
 func (s *Store) Add(e Entry) (int64, error) {
 	result, err := s.db.Exec(`
-		INSERT INTO history (method, url, status_code, duration_ns, size, request_body, response_body, headers, timestamp)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		INSERT INTO history (method, url, status_code, duration_ns, size, request_body, response_body, headers, timestamp, tags, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		e.Method, e.URL, e.StatusCode, e.Duration.Nanoseconds(), e.Size,
 		e.RequestBody, e.ResponseBody, e.Headers,
 		e.Timestamp.UTC().Format(time.RFC3339Nano),
+		strings.Join(e.Tags, ","), e.Note,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("inserting history: %w", err)
@@ -66,13 +102,39 @@ func (s *Store) Add(e Entry) (int64, error) {
 	return result.LastInsertId()
 }
 
+// Get returns the entry with the given ID.
+func (s *Store) Get(id int64) (Entry, error) {
+	rows, err := s.db.Query(`SELECT `+entryColumns+` FROM history WHERE id = ?`, id)
+	if err != nil {
+		return Entry{}, fmt.Errorf("reading history: %w", err)
+	}
+	defer rows.Close()
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(entries) == 0 {
+		return Entry{}, fmt.Errorf("history entry %d not found", id)
+	}
+	return entries[0], nil
+}
+
+// Annotate replaces the tags and note of an entry.
+func (s *Store) Annotate(id int64, tags []string, note string) error {
+	_, err := s.db.Exec("UPDATE history SET tags = ?, note = ? WHERE id = ?", strings.Join(tags, ","), note, id)
+	if err != nil {
+		return fmt.Errorf("annotating history: %w", err)
+	}
+	return nil
+}
+
 // List returns the most recent entries.
 func (s *Store) List(limit, offset int) ([]Entry, error) {
 	if limit <= 0 {
 		limit = 50
 	}
 	rows, err := s.db.Query(`
-		SELECT id, method, url, status_code, duration_ns, size, request_body, response_body, headers, timestamp
+		SELECT `+entryColumns+`
 		FROM history
 		ORDER BY timestamp DESC
 		LIMIT ? OFFSET ?`, limit, offset)
@@ -87,7 +149,7 @@ func (s *Store) List(limit, offset int) ([]Entry, error) {
 // Search searches history by URL substring.
 func (s *Store) Search(query string) ([]Entry, error) {
 	rows, err := s.db.Query(`
-		SELECT id, method, url, status_code, duration_ns, size, request_body, response_body, headers, timestamp
+		SELECT `+entryColumns+`
 		FROM history
 		WHERE url LIKE ?
 		ORDER BY timestamp DESC
@@ -102,11 +164,13 @@ func (s *Store) Search(query string) ([]Entry, error) {
 
 // Filter defines criteria for filtering history entries.
 type Filter struct {
-	Method     string // filter by HTTP method (e.g. "GET")
-	StatusCode int    // filter by exact status code
-	StatusMin  int    // filter by minimum status code (inclusive)
-	StatusMax  int    // filter by maximum status code (inclusive)
-	URLPattern string // filter by URL substring
+	Method     string   // filter by HTTP method (e.g. "GET")
+	StatusCode int      // filter by exact status code
+	StatusMin  int      // filter by minimum status code (inclusive)
+	StatusMax  int      // filter by maximum status code (inclusive)
+	URLPattern string   // filter by URL substring
+	Tags       []string // entries must have all of these tags
+	Text       string   // filter by substring of the URL, note or tags
 	Since      time.Time
 	Until      time.Time
 	Limit      int
@@ -115,7 +179,7 @@ type Filter struct {
 
 // ListFiltered returns history entries matching the filter criteria.
 func (s *Store) ListFiltered(f Filter) ([]Entry, error) {
-	query := `SELECT id, method, url, status_code, duration_ns, size, request_body, response_body, headers, timestamp
+	query := `SELECT ` + entryColumns + `
 		FROM history WHERE 1=1`
 	var args []interface{}
 
@@ -139,6 +203,15 @@ func (s *Store) ListFiltered(f Filter) ([]Entry, error) {
 		query += " AND url LIKE ?"
 		args = append(args, "%"+f.URLPattern+"%")
 	}
+	for _, tag := range f.Tags {
+		query += " AND (',' || tags || ',') LIKE ?"
+		args = append(args, "%,"+tag+",%")
+	}
+	if f.Text != "" {
+		query += " AND (url LIKE ? OR note LIKE ? OR tags LIKE ?)"
+		like := "%" + f.Text + "%"
+		args = append(args, like, like, like)
+	}
 	if !f.Since.IsZero() {
 		query += " AND timestamp >= ?"
 		args = append(args, f.Since.UTC().Format(time.RFC3339Nano))
@@ -198,14 +271,17 @@ func scanEntries(rows *sql.Rows) ([]Entry, error) {
 	for rows.Next() {
 		var e Entry
 		var durationNs int64
-		var ts string
+		var ts, tags string
 		err := rows.Scan(&e.ID, &e.Method, &e.URL, &e.StatusCode, &durationNs,
-			&e.Size, &e.RequestBody, &e.ResponseBody, &e.Headers, &ts)
+			&e.Size, &e.RequestBody, &e.ResponseBody, &e.Headers, &ts, &tags, &e.Note)
 		if err != nil {
 			return nil, fmt.Errorf("scanning history row: %w", err)
 		}
 		e.Duration = time.Duration(durationNs)
 		e.Timestamp, _ = time.Parse(time.RFC3339Nano, ts)
+		if tags != "" {
+			e.Tags = strings.Split(tags, ",")
+		}
 		entries = append(entries, e)
 	}
 	return entries, rows.Err()
@@ -1,6 +1,8 @@
 package history
 
 import (
+	"database/sql"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -201,3 +203,103 @@ func TestStore_DurationRoundTrip(t *testing.T) {
 		t.Errorf("duration mismatch: got %v, want %v", entries[0].Duration, dur)
 	}
 }
+
+func TestStore_TagsAndNotes(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	id, _ := store.Add(Entry{Method: "GET", URL: "https://api.example.com/orders", StatusCode: 500, Timestamp: time.Now()})
+	store.Add(Entry{Method: "GET", URL: "https://api.example.com/users", StatusCode: 200, Timestamp: time.Now(), Tags: []string{"smoke"}})
+
+	if err := store.Annotate(id, ParseTags("#bug-1234, prod-incident bug-1234"), "500 on empty cart"); err != nil {
+		t.Fatal(err)
+	}
+	e, err := store.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Tags) != 2 || e.Tags[0] != "bug-1234" || e.Tags[1] != "prod-incident" || e.Note != "500 on empty cart" {
+		t.Errorf("entry = %+v", e)
+	}
+	if _, err := store.Get(id + 100); err == nil {
+		t.Error("expected an error for a missing entry")
+	}
+
+	tests := []struct {
+		filter Filter
+		want   int
+	}{
+		{Filter{Tags: []string{"bug-1234"}}, 1},
+		{Filter{Tags: []string{"bug-1234", "smoke"}}, 0},
+		{Filter{Tags: []string{"bug"}}, 0}, // whole tags only
+		{Filter{Text: "empty cart"}, 1},
+		{Filter{Text: "smoke"}, 1},
+		{Filter{Text: "example.com"}, 2},
+	}
+	for _, tc := range tests {
+		got, err := store.ListFiltered(tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != tc.want {
+			t.Errorf("ListFiltered(%+v) returned %d entries, want %d", tc.filter, len(got), tc.want)
+		}
+	}
+}
+
+func TestStore_AddsColumnsToOldDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, method TEXT NOT NULL, url TEXT NOT NULL,
+		status_code INTEGER, duration_ns INTEGER, size INTEGER, request_body TEXT,
+		response_body TEXT, headers TEXT, timestamp TEXT NOT NULL);
+		INSERT INTO history (method, url, status_code, duration_ns, size, request_body, response_body, headers, timestamp)
+		VALUES ('GET', 'https://old.example.com', 200, 0, 0, '', '', '', '2024-01-01T00:00:00Z');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	entries, err := store.List(10, 0)
+	if err != nil || len(entries) != 1 || entries[0].Tags != nil || entries[0].Note != "" {
+		t.Fatalf("old entries: %+v, %v", entries, err)
+	}
+	if err := store.Annotate(entries[0].ID, []string{"legacy"}, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"24h", now.Add(-24 * time.Hour)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)},
+		{"2024-05-01T08:00:00Z", time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		got, err := ParseTime(tc.in, now)
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v", tc.in, got, err, tc.want)
+		}
+	}
+	if _, err := ParseTime("last week", now); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
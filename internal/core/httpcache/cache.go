@@ -0,0 +1,224 @@
+// Package httpcache keeps the last response to each GET request that came
+// with a validator (ETag or Last-Modified), so a re-send can ask the server
+// whether it changed and reuse the body when it answers 304 Not Modified.
+package httpcache
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxEntries bounds the cache; the least recently used entry is dropped
+// to make room.
+const maxEntries = 256
+
+// Entry is a cached response and the validators to revalidate it with.
+type Entry struct {
+	Key          string
+	Method       string
+	URL          string
+	ETag         string
+	LastModified string
+	StatusCode   int
+	Status       string
+	Headers      http.Header
+	Body         []byte
+	Stored       time.Time // when the body was last received in full
+	Used         time.Time // when the entry was last stored or served
+	Hits         int       // 304 responses served from this entry
+
+	vary map[string]string // request header values the response varies on
+}
+
+// Cache holds responses by method and URL. It is safe for concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+// New creates an empty cache.
+func New() *Cache {
+	return &Cache{entries: make(map[string]*Entry)}
+}
+
+// Key identifies a request in the cache.
+func Key(method, url string) string {
+	return method + " " + url
+}
+
+// Cacheable reports whether responses to method can be revalidated.
+func Cacheable(method string) bool {
+	return method == http.MethodGet
+}
+
+// Conditional adds If-None-Match and If-Modified-Since to header from the
+// entry cached for method and url, unless header already sets either or
+// differs in a header the cached response varies on. It reports whether
+// it added them.
+func (c *Cache) Conditional(method, url string, header http.Header) bool {
+	if !Cacheable(method) || header.Get("If-None-Match") != "" || header.Get("If-Modified-Since") != "" {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[Key(method, url)]
+	if !ok || !e.matches(header) {
+		return false
+	}
+	if e.ETag != "" {
+		header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		header.Set("If-Modified-Since", e.LastModified)
+	}
+	return true
+}
+
+// Store records a 200 response to a request with the given headers. One
+// without validators, or marked no-store, removes any entry so a stale body
+// is never served. Other statuses leave the cache as it is.
+func (c *Cache) Store(method, url string, reqHeader http.Header, statusCode int, status string, respHeader http.Header, body []byte) {
+	if !Cacheable(method) || statusCode != http.StatusOK {
+		return
+	}
+	key := Key(method, url)
+	etag, lastModified := respHeader.Get("ETag"), respHeader.Get("Last-Modified")
+	noStore := strings.Contains(strings.ToLower(respHeader.Get("Cache-Control")), "no-store")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if (etag == "" && lastModified == "") || noStore || respHeader.Get("Vary") == "*" {
+		delete(c.entries, key)
+		return
+	}
+
+	now := time.Now()
+	e := &Entry{
+		Key:          key,
+		Method:       method,
+		URL:          url,
+		ETag:         etag,
+		LastModified: lastModified,
+		StatusCode:   statusCode,
+		Status:       status,
+		Headers:      respHeader.Clone(),
+		Body:         append([]byte(nil), body...),
+		Stored:       now,
+		Used:         now,
+	}
+	for _, name := range varyNames(respHeader) {
+		if e.vary == nil {
+			e.vary = make(map[string]string)
+		}
+		e.vary[name] = reqHeader.Get(name)
+	}
+	if old, ok := c.entries[key]; ok {
+		e.Hits = old.Hits
+	} else if len(c.entries) >= maxEntries {
+		c.evict()
+	}
+	c.entries[key] = e
+}
+
+// Revalidated returns the entry for method and url after the server
+// answered 304 Not Modified, with its headers updated from respHeader as
+// RFC 9111 requires. ok is false when nothing is cached for the request.
+func (c *Cache) Revalidated(method, url string, respHeader http.Header) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[Key(method, url)]
+	if !ok {
+		return Entry{}, false
+	}
+	for k, v := range respHeader {
+		// A 304 carries no body, so its framing headers don't apply
+		if k == "Content-Length" || k == "Transfer-Encoding" {
+			continue
+		}
+		e.Headers[k] = v
+	}
+	if etag := respHeader.Get("ETag"); etag != "" {
+		e.ETag = etag
+	}
+	if lm := respHeader.Get("Last-Modified"); lm != "" {
+		e.LastModified = lm
+	}
+	e.Hits++
+	e.Used = time.Now()
+	return e.copy(), true
+}
+
+// Entries returns the cached responses ordered by URL.
+func (c *Cache) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		out = append(out, e.copy())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// Delete removes the entry with key.
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// Clear removes every entry and returns how many there were.
+func (c *Cache) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.entries)
+	c.entries = make(map[string]*Entry)
+	return n
+}
+
+// evict drops the least recently used entry. c.mu must be held.
+func (c *Cache) evict() {
+	var oldest *Entry
+	for _, e := range c.entries {
+		if oldest == nil || e.Used.Before(oldest.Used) {
+			oldest = e
+		}
+	}
+	if oldest != nil {
+		delete(c.entries, oldest.Key)
+	}
+}
+
+// matches reports whether header agrees with the request the entry was
+// stored for on every header its response varies on.
+func (e *Entry) matches(header http.Header) bool {
+	for name, value := range e.vary {
+		if header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Entry) copy() Entry {
+	out := *e
+	out.Headers = e.Headers.Clone()
+	out.vary = nil
+	return out
+}
+
+// varyNames returns the canonical header names listed in Vary.
+func varyNames(h http.Header) []string {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
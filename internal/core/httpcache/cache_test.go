@@ -0,0 +1,111 @@
+package httpcache
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCache_StoreAndConditional(t *testing.T) {
+	c := New()
+	resp := http.Header{"Etag": {`"abc"`}, "Last-Modified": {"Mon, 01 Jan 2024 00:00:00 GMT"}}
+	c.Store("GET", "https://api.test/users", http.Header{}, 200, "200 OK", resp, []byte("[]"))
+
+	h := http.Header{}
+	if !c.Conditional("GET", "https://api.test/users", h) {
+		t.Fatal("expected validators for a cached URL")
+	}
+	if h.Get("If-None-Match") != `"abc"` || h.Get("If-Modified-Since") != "Mon, 01 Jan 2024 00:00:00 GMT" {
+		t.Errorf("headers = %v", h)
+	}
+
+	if c.Conditional("GET", "https://api.test/other", http.Header{}) {
+		t.Error("validators added for an uncached URL")
+	}
+	if c.Conditional("POST", "https://api.test/users", http.Header{}) {
+		t.Error("validators added for POST")
+	}
+	own := http.Header{"If-None-Match": {`"mine"`}}
+	if c.Conditional("GET", "https://api.test/users", own) || own.Get("If-None-Match") != `"mine"` {
+		t.Error("request's own validator overridden")
+	}
+}
+
+func TestCache_StoreSkipsUncacheable(t *testing.T) {
+	c := New()
+	url := "https://api.test/a"
+	c.Store("GET", url, nil, 200, "200 OK", http.Header{"Etag": {`"1"`}}, []byte("a"))
+
+	c.Store("GET", url, nil, 500, "500 Internal Server Error", http.Header{}, nil)
+	if len(c.Entries()) != 1 {
+		t.Fatal("an error response dropped the entry")
+	}
+	c.Store("GET", url, nil, 200, "200 OK", http.Header{"Etag": {`"2"`}, "Cache-Control": {"private, no-store"}}, []byte("b"))
+	if len(c.Entries()) != 0 {
+		t.Fatal("no-store response kept the entry")
+	}
+	c.Store("GET", url, nil, 200, "200 OK", http.Header{}, []byte("c"))
+	if len(c.Entries()) != 0 {
+		t.Fatal("response without validators stored")
+	}
+}
+
+func TestCache_Vary(t *testing.T) {
+	c := New()
+	url := "https://api.test/doc"
+	c.Store("GET", url, http.Header{"Accept": {"application/json"}}, 200, "200 OK",
+		http.Header{"Etag": {`"json"`}, "Vary": {"accept, Accept-Encoding"}}, []byte("{}"))
+
+	if !c.Conditional("GET", url, http.Header{"Accept": {"application/json"}}) {
+		t.Error("same Accept should revalidate")
+	}
+	if c.Conditional("GET", url, http.Header{"Accept": {"text/html"}}) {
+		t.Error("different Accept should not revalidate")
+	}
+}
+
+func TestCache_Revalidated(t *testing.T) {
+	c := New()
+	url := "https://api.test/a"
+	c.Store("GET", url, nil, 200, "200 OK", http.Header{"Etag": {`"1"`}, "Content-Length": {"5"}, "X-Version": {"1"}}, []byte("hello"))
+
+	e, ok := c.Revalidated("GET", url, http.Header{"Etag": {`"1"`}, "Content-Length": {"0"}, "X-Version": {"2"}})
+	if !ok {
+		t.Fatal("expected an entry")
+	}
+	if string(e.Body) != "hello" || e.Hits != 1 || e.StatusCode != 200 {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.Headers.Get("X-Version") != "2" || e.Headers.Get("Content-Length") != "5" {
+		t.Errorf("headers = %v", e.Headers)
+	}
+
+	// Hits survive a fresh 200
+	c.Store("GET", url, nil, 200, "200 OK", http.Header{"Etag": {`"2"`}}, []byte("world"))
+	if got := c.Entries()[0]; got.Hits != 1 || got.ETag != `"2"` {
+		t.Errorf("entry after refresh = %+v", got)
+	}
+
+	if _, ok := c.Revalidated("GET", "https://api.test/b", http.Header{}); ok {
+		t.Error("revalidated an uncached URL")
+	}
+}
+
+func TestCache_DeleteClearAndEvict(t *testing.T) {
+	c := New()
+	for i := range maxEntries + 1 {
+		c.Store("GET", fmt.Sprintf("https://api.test/%03d", i), nil, 200, "200 OK", http.Header{"Etag": {`"x"`}}, nil)
+	}
+	entries := c.Entries()
+	if len(entries) != maxEntries {
+		t.Fatalf("got %d entries, want %d", len(entries), maxEntries)
+	}
+
+	c.Delete(entries[0].Key)
+	if len(c.Entries()) != maxEntries-1 {
+		t.Error("Delete kept the entry")
+	}
+	if n := c.Clear(); n != maxEntries-1 || len(c.Entries()) != 0 {
+		t.Errorf("Clear = %d, %d left", n, len(c.Entries()))
+	}
+}
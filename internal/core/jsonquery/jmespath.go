@@ -0,0 +1,1022 @@
+package jsonquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// jmespathPrefix marks a JMESPath expression, which unlike JSONPath and jq
+// has no leading character of its own.
+const jmespathPrefix = "jmespath:"
+
+// jmesNode evaluates part of a JMESPath expression against the current
+// value. Missing values are nil.
+type jmesNode func(v interface{}) (interface{}, error)
+
+// jmesRef is an expression reference (&expr), passed unevaluated to
+// functions such as sort_by.
+type jmesRef jmesNode
+
+// parseJMESPath parses a JMESPath expression (without its prefix) into a
+// step. A JMESPath expression has a single result; null, meaning nothing
+// matched, yields no results.
+func parseJMESPath(expr string) ([]step, error) {
+	toks, err := lexJMESPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &jmesParser{toks: toks}
+	node, err := p.expr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != "eof" {
+		return nil, fmt.Errorf("at %d: unexpected %q", t.pos+1, t.text)
+	}
+	return []step{func(in []interface{}) ([]interface{}, error) {
+		var out []interface{}
+		for _, v := range in {
+			res, err := node(v)
+			if err != nil {
+				return nil, err
+			}
+			if res != nil {
+				out = append(out, res)
+			}
+		}
+		return out, nil
+	}}, nil
+}
+
+type jmesToken struct {
+	kind string // punctuation itself, or ident, quoted, number, literal, raw or eof
+	text string
+	pos  int
+}
+
+// jmesPower is how tightly each token binds to the expression on its left.
+var jmesPower = map[string]int{
+	"|":  1,
+	"||": 2,
+	"&&": 3,
+	"==": 5, "!=": 5, "<": 5, "<=": 5, ">": 5, ">=": 5,
+	"[]": 9,
+	"*":  20,
+	"[?": 21,
+	".":  40,
+	"!":  45,
+	"{":  50,
+	"[":  55,
+	"(":  60,
+}
+
+// jmesProjectionStop is the binding power below which a token ends the
+// right-hand side of a projection.
+const jmesProjectionStop = 10
+
+func lexJMESPath(s string) ([]jmesToken, error) {
+	var toks []jmesToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(s) && (s[i] == '_' || s[i] >= 'a' && s[i] <= 'z' || s[i] >= 'A' && s[i] <= 'Z' || s[i] >= '0' && s[i] <= '9') {
+				i++
+			}
+			toks = append(toks, jmesToken{"ident", s[start:i], start})
+		case c == '-' || c >= '0' && c <= '9':
+			start := i
+			i++
+			for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+				i++
+			}
+			if s[start:i] == "-" {
+				return nil, fmt.Errorf("at %d: expected a number after -", start+1)
+			}
+			toks = append(toks, jmesToken{"number", s[start:i], start})
+		case c == '"' || c == '\'' || c == '`':
+			end, text, err := jmesQuoted(s, i)
+			if err != nil {
+				return nil, err
+			}
+			kind := map[byte]string{'"': "quoted", '\'': "raw", '`': "literal"}[c]
+			toks = append(toks, jmesToken{kind, text, i})
+			i = end
+		default:
+			kind := ""
+			for _, op := range []string{"[?", "[]", "||", "&&", "==", "!=", "<=", ">=", "[", "]", "{", "}", "(", ")", ".", "*", "@", ",", ":", "|", "&", "!", "<", ">"} {
+				if strings.HasPrefix(s[i:], op) {
+					kind = op
+					break
+				}
+			}
+			if kind == "" {
+				r, _ := utf8.DecodeRuneInString(s[i:])
+				return nil, fmt.Errorf("at %d: unexpected %q", i+1, r)
+			}
+			toks = append(toks, jmesToken{kind, kind, i})
+			i += len(kind)
+		}
+	}
+	return append(toks, jmesToken{"eof", "", len(s)}), nil
+}
+
+// jmesQuoted reads the quoted token starting at s[start] and returns the
+// index after it and its text: the decoded name of a quoted identifier,
+// or the raw contents of a string or JSON literal.
+func jmesQuoted(s string, start int) (int, string, error) {
+	quote := s[start]
+	var b strings.Builder
+	for i := start + 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			// Raw strings and literals only unescape their own quote
+			if quote != '"' && s[i+1] == quote {
+				b.WriteByte(quote)
+			} else {
+				b.WriteString(s[i : i+2])
+			}
+			i++
+		case c == quote:
+			if quote != '"' {
+				return i + 1, b.String(), nil
+			}
+			var name string
+			if err := json.Unmarshal([]byte(`"`+b.String()+`"`), &name); err != nil {
+				return 0, "", fmt.Errorf("at %d: invalid quoted identifier", start+1)
+			}
+			return i + 1, name, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return 0, "", fmt.Errorf("at %d: unterminated string", start+1)
+}
+
+// jmesParser is a Pratt parser over JMESPath tokens.
+type jmesParser struct {
+	toks []jmesToken
+	i    int
+}
+
+func (p *jmesParser) peek() jmesToken { return p.toks[p.i] }
+
+func (p *jmesParser) next() jmesToken {
+	t := p.toks[p.i]
+	if t.kind != "eof" {
+		p.i++
+	}
+	return t
+}
+
+func (p *jmesParser) expect(kind string) error {
+	if t := p.next(); t.kind != kind {
+		return unexpected(t, "expected "+strconv.Quote(kind))
+	}
+	return nil
+}
+
+func unexpected(t jmesToken, want string) error {
+	if t.kind == "eof" {
+		return fmt.Errorf("unexpected end of expression; %s", want)
+	}
+	return fmt.Errorf("at %d: unexpected %q; %s", t.pos+1, t.text, want)
+}
+
+func (p *jmesParser) expr(power int) (jmesNode, error) {
+	left, err := p.nud(p.next())
+	if err != nil {
+		return nil, err
+	}
+	for power < jmesPower[p.peek().kind] {
+		if left, err = p.led(p.next(), left); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+// nud parses an expression starting with t.
+func (p *jmesParser) nud(t jmesToken) (jmesNode, error) {
+	switch t.kind {
+	case "literal":
+		dec := json.NewDecoder(strings.NewReader(t.text))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("at %d: invalid JSON literal", t.pos+1)
+		}
+		return jmesConst(v), nil
+	case "raw":
+		return jmesConst(t.text), nil
+	case "ident":
+		if p.peek().kind == "(" {
+			p.next()
+			return p.call(t)
+		}
+		return jmesField(t.text), nil
+	case "quoted":
+		if p.peek().kind == "(" {
+			return nil, fmt.Errorf("at %d: function names can't be quoted", t.pos+1)
+		}
+		return jmesField(t.text), nil
+	case "*":
+		right, err := p.projectionRHS(jmesPower["*"])
+		return jmesValues(jmesCurrent, right), err
+	case "[?":
+		return p.filter(jmesCurrent)
+	case "{":
+		return p.multiHash()
+	case "[]":
+		right, err := p.projectionRHS(jmesPower["[]"])
+		return jmesProject(jmesFlatten(jmesCurrent), right), err
+	case "[":
+		switch p.peek().kind {
+		case "number", ":":
+			return p.index(jmesCurrent)
+		case "*":
+			if p.toks[p.i+1].kind == "]" {
+				p.i += 2
+				right, err := p.projectionRHS(jmesPower["*"])
+				return jmesProject(jmesCurrent, right), err
+			}
+		}
+		return p.multiList()
+	case "@":
+		return jmesCurrent, nil
+	case "&":
+		ref, err := p.expr(0)
+		return jmesConst(jmesRef(ref)), err
+	case "!":
+		inner, err := p.expr(jmesPower["!"])
+		return func(v interface{}) (interface{}, error) {
+			res, err := inner(v)
+			return !jmesTruthy(res), err
+		}, err
+	case "(":
+		inner, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	}
+	return nil, unexpected(t, "expected an expression")
+}
+
+// led parses t continuing the expression left.
+func (p *jmesParser) led(t jmesToken, left jmesNode) (jmesNode, error) {
+	switch t.kind {
+	case ".":
+		if p.peek().kind == "*" {
+			p.next()
+			right, err := p.projectionRHS(jmesPower["."])
+			return jmesValues(left, right), err
+		}
+		right, err := p.dotRHS(jmesPower["."])
+		return jmesPipe(left, right), err
+	case "|":
+		right, err := p.expr(jmesPower["|"])
+		return jmesPipe(left, right), err
+	case "||", "&&":
+		right, err := p.expr(jmesPower[t.kind])
+		or := t.kind == "||"
+		return func(v interface{}) (interface{}, error) {
+			l, err := left(v)
+			if err != nil || jmesTruthy(l) == or {
+				return l, err
+			}
+			return right(v)
+		}, err
+	case "==", "!=", "<", "<=", ">", ">=":
+		right, err := p.expr(jmesPower[t.kind])
+		return func(v interface{}) (interface{}, error) {
+			l, err := left(v)
+			if err != nil {
+				return nil, err
+			}
+			r, err := right(v)
+			if err != nil {
+				return nil, err
+			}
+			if t.kind != "==" && t.kind != "!=" {
+				if _, ok := number(l); !ok {
+					return nil, nil
+				}
+				if _, ok := number(r); !ok {
+					return nil, nil
+				}
+			}
+			return compare(l, r, t.kind), nil
+		}, err
+	case "[?":
+		return p.filter(left)
+	case "[]":
+		right, err := p.projectionRHS(jmesPower["[]"])
+		return jmesProject(jmesFlatten(left), right), err
+	case "[":
+		if k := p.peek().kind; k == "number" || k == ":" {
+			return p.index(left)
+		}
+		if err := p.expect("*"); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		right, err := p.projectionRHS(jmesPower["*"])
+		return jmesProject(left, right), err
+	}
+	return nil, unexpected(t, "expected an operator")
+}
+
+// projectionRHS parses what a projection applies to each element.
+func (p *jmesParser) projectionRHS(power int) (jmesNode, error) {
+	switch t := p.peek(); {
+	case jmesPower[t.kind] < jmesProjectionStop:
+		return jmesCurrent, nil
+	case t.kind == "[" || t.kind == "[?":
+		return p.expr(power)
+	case t.kind == ".":
+		p.next()
+		return p.dotRHS(power)
+	default:
+		return nil, unexpected(t, "expected ., [ or [?")
+	}
+}
+
+// dotRHS parses what follows a dot.
+func (p *jmesParser) dotRHS(power int) (jmesNode, error) {
+	switch t := p.peek(); t.kind {
+	case "ident", "quoted", "*":
+		return p.expr(power)
+	case "[":
+		p.next()
+		return p.multiList()
+	case "{":
+		p.next()
+		return p.multiHash()
+	default:
+		return nil, unexpected(t, "expected a field name")
+	}
+}
+
+// index parses [n] or a slice [start:stop:step] applied to left, after
+// its opening bracket.
+func (p *jmesParser) index(left jmesNode) (jmesNode, error) {
+	var parts [3]*int
+	n := 0
+	for {
+		t := p.next()
+		switch t.kind {
+		case "number":
+			i, err := strconv.Atoi(t.text)
+			if err != nil || parts[n] != nil {
+				return nil, fmt.Errorf("at %d: invalid index", t.pos+1)
+			}
+			parts[n] = &i
+			continue
+		case ":":
+			if n++; n > 2 {
+				return nil, unexpected(t, "a slice has at most 3 parts")
+			}
+			continue
+		case "]":
+		default:
+			return nil, unexpected(t, "expected a number, : or ]")
+		}
+		break
+	}
+	if n == 0 {
+		if parts[0] == nil {
+			return nil, fmt.Errorf("expected an index")
+		}
+		i := *parts[0]
+		return jmesPipe(left, func(v interface{}) (interface{}, error) {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, nil
+			}
+			if i < 0 {
+				i += len(arr)
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, nil
+			}
+			return arr[i], nil
+		}), nil
+	}
+	if parts[2] != nil && *parts[2] == 0 {
+		return nil, fmt.Errorf("slice step can't be 0")
+	}
+	sliced := jmesPipe(left, func(v interface{}) (interface{}, error) {
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, nil
+		}
+		return sliceArray(arr, parts[0], parts[1], parts[2]), nil
+	})
+	right, err := p.projectionRHS(jmesPower["*"])
+	return jmesProject(sliced, right), err
+}
+
+// filter parses a filter projection over left, after its [?.
+func (p *jmesParser) filter(left jmesNode) (jmesNode, error) {
+	cond, err := p.expr(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	right := jmesCurrent
+	if p.peek().kind != "[]" {
+		if right, err = p.projectionRHS(jmesPower["[?"]); err != nil {
+			return nil, err
+		}
+	}
+	return func(v interface{}) (interface{}, error) {
+		base, err := left(v)
+		arr, ok := base.([]interface{})
+		if err != nil || !ok {
+			return nil, err
+		}
+		out := []interface{}{}
+		for _, el := range arr {
+			keep, err := cond(el)
+			if err != nil {
+				return nil, err
+			}
+			if !jmesTruthy(keep) {
+				continue
+			}
+			res, err := right(el)
+			if err != nil {
+				return nil, err
+			}
+			if res != nil {
+				out = append(out, res)
+			}
+		}
+		return out, nil
+	}, nil
+}
+
+// multiList parses [a, b], after its opening bracket.
+func (p *jmesParser) multiList() (jmesNode, error) {
+	var items []jmesNode
+	for {
+		item, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if t := p.next(); t.kind == "]" {
+			break
+		} else if t.kind != "," {
+			return nil, unexpected(t, "expected , or ]")
+		}
+	}
+	return func(v interface{}) (interface{}, error) {
+		if v == nil {
+			return nil, nil
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			res, err := item(v)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = res
+		}
+		return out, nil
+	}, nil
+}
+
+// multiHash parses {key: a, other: b}, after its opening brace.
+func (p *jmesParser) multiHash() (jmesNode, error) {
+	var keys []string
+	var values []jmesNode
+	for {
+		key := p.next()
+		if key.kind != "ident" && key.kind != "quoted" {
+			return nil, unexpected(key, "expected a key")
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		keys, values = append(keys, key.text), append(values, value)
+		if t := p.next(); t.kind == "}" {
+			break
+		} else if t.kind != "," {
+			return nil, unexpected(t, "expected , or }")
+		}
+	}
+	return func(v interface{}) (interface{}, error) {
+		if v == nil {
+			return nil, nil
+		}
+		out := make(map[string]interface{}, len(keys))
+		for i, key := range keys {
+			res, err := values[i](v)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = res
+		}
+		return out, nil
+	}, nil
+}
+
+// call parses the arguments of the function named by t, after its
+// opening parenthesis.
+func (p *jmesParser) call(t jmesToken) (jmesNode, error) {
+	fn, ok := jmesFunctions[t.text]
+	if !ok {
+		return nil, fmt.Errorf("at %d: unknown function %s()", t.pos+1, t.text)
+	}
+	var args []jmesNode
+	if p.peek().kind == ")" {
+		p.next()
+	} else {
+		for {
+			arg, err := p.expr(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if sep := p.next(); sep.kind == ")" {
+				break
+			} else if sep.kind != "," {
+				return nil, unexpected(sep, "expected , or )")
+			}
+		}
+	}
+	if fn.args >= 0 && len(args) != fn.args || fn.args < 0 && len(args) < -fn.args {
+		return nil, fmt.Errorf("at %d: wrong number of arguments to %s()", t.pos+1, t.text)
+	}
+	return func(v interface{}) (interface{}, error) {
+		vals := make([]interface{}, len(args))
+		for i, arg := range args {
+			res, err := arg(v)
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = res
+		}
+		res, err := fn.fn(vals)
+		if err != nil {
+			return nil, fmt.Errorf("%s(): %w", t.text, err)
+		}
+		return res, nil
+	}, nil
+}
+
+func jmesCurrent(v interface{}) (interface{}, error) { return v, nil }
+
+func jmesConst(c interface{}) jmesNode {
+	return func(interface{}) (interface{}, error) { return c, nil }
+}
+
+func jmesField(name string) jmesNode {
+	return func(v interface{}) (interface{}, error) {
+		if m, ok := v.(map[string]interface{}); ok {
+			return m[name], nil
+		}
+		return nil, nil
+	}
+}
+
+// jmesPipe evaluates right against the result of left.
+func jmesPipe(left, right jmesNode) jmesNode {
+	return func(v interface{}) (interface{}, error) {
+		res, err := left(v)
+		if err != nil {
+			return nil, err
+		}
+		return right(res)
+	}
+}
+
+// jmesProject applies right to each element of the array left returns,
+// dropping null results.
+func jmesProject(left, right jmesNode) jmesNode {
+	return func(v interface{}) (interface{}, error) {
+		base, err := left(v)
+		arr, ok := base.([]interface{})
+		if err != nil || !ok {
+			return nil, err
+		}
+		return jmesEach(arr, right)
+	}
+}
+
+// jmesValues applies right to each value of the object left returns, in
+// key order.
+func jmesValues(left, right jmesNode) jmesNode {
+	return func(v interface{}) (interface{}, error) {
+		base, err := left(v)
+		obj, ok := base.(map[string]interface{})
+		if err != nil || !ok {
+			return nil, err
+		}
+		return jmesEach(members(obj), right)
+	}
+}
+
+func jmesEach(arr []interface{}, right jmesNode) (interface{}, error) {
+	out := []interface{}{}
+	for _, el := range arr {
+		res, err := right(el)
+		if err != nil {
+			return nil, err
+		}
+		if res != nil {
+			out = append(out, res)
+		}
+	}
+	return out, nil
+}
+
+// jmesFlatten merges nested arrays one level deep.
+func jmesFlatten(left jmesNode) jmesNode {
+	return func(v interface{}) (interface{}, error) {
+		base, err := left(v)
+		arr, ok := base.([]interface{})
+		if err != nil || !ok {
+			return nil, err
+		}
+		out := []interface{}{}
+		for _, el := range arr {
+			if inner, ok := el.([]interface{}); ok {
+				out = append(out, inner...)
+			} else {
+				out = append(out, el)
+			}
+		}
+		return out, nil
+	}
+}
+
+// jmesTruthy reports whether v counts as true: anything but false, null
+// and empty strings, arrays and objects.
+func jmesTruthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// jmesFunction is a builtin; args is the argument count, or minus the
+// minimum for variadic functions.
+type jmesFunction struct {
+	args int
+	fn   func(args []interface{}) (interface{}, error)
+}
+
+var jmesFunctions = map[string]jmesFunction{
+	"abs":   {1, jmesMath(math.Abs)},
+	"ceil":  {1, jmesMath(math.Ceil)},
+	"floor": {1, jmesMath(math.Floor)},
+	"avg": {1, func(a []interface{}) (interface{}, error) {
+		nums, err := jmesNumbers(a[0])
+		if err != nil || len(nums) == 0 {
+			return nil, err
+		}
+		sum := 0.0
+		for _, n := range nums {
+			sum += n
+		}
+		return sum / float64(len(nums)), nil
+	}},
+	"sum": {1, func(a []interface{}) (interface{}, error) {
+		nums, err := jmesNumbers(a[0])
+		sum := 0.0
+		for _, n := range nums {
+			sum += n
+		}
+		return sum, err
+	}},
+	"contains": {2, func(a []interface{}) (interface{}, error) {
+		switch subject := a[0].(type) {
+		case string:
+			s, ok := a[1].(string)
+			return ok && strings.Contains(subject, s), nil
+		case []interface{}:
+			for _, el := range subject {
+				if compare(el, a[1], "==") {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return nil, errArgType
+	}},
+	"starts_with": {2, jmesStrings(strings.HasPrefix)},
+	"ends_with":   {2, jmesStrings(strings.HasSuffix)},
+	"join": {2, func(a []interface{}) (interface{}, error) {
+		sep, ok := a[0].(string)
+		arr, isArr := a[1].([]interface{})
+		if !ok || !isArr {
+			return nil, errArgType
+		}
+		parts := make([]string, len(arr))
+		for i, el := range arr {
+			if parts[i], ok = el.(string); !ok {
+				return nil, errArgType
+			}
+		}
+		return strings.Join(parts, sep), nil
+	}},
+	"keys": {1, func(a []interface{}) (interface{}, error) {
+		obj, ok := a[0].(map[string]interface{})
+		if !ok {
+			return nil, errArgType
+		}
+		out := []interface{}{}
+		for _, k := range sortedKeys(obj) {
+			out = append(out, k)
+		}
+		return out, nil
+	}},
+	"values": {1, func(a []interface{}) (interface{}, error) {
+		obj, ok := a[0].(map[string]interface{})
+		if !ok {
+			return nil, errArgType
+		}
+		return members(obj), nil
+	}},
+	"length": {1, func(a []interface{}) (interface{}, error) {
+		switch v := a[0].(type) {
+		case string:
+			return float64(utf8.RuneCountInString(v)), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		case map[string]interface{}:
+			return float64(len(v)), nil
+		}
+		return nil, errArgType
+	}},
+	"map": {2, func(a []interface{}) (interface{}, error) {
+		ref, ok := a[0].(jmesRef)
+		arr, isArr := a[1].([]interface{})
+		if !ok || !isArr {
+			return nil, errArgType
+		}
+		out := make([]interface{}, len(arr))
+		for i, el := range arr {
+			res, err := ref(el)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = res
+		}
+		return out, nil
+	}},
+	"max":    {1, jmesExtreme(1)},
+	"min":    {1, jmesExtreme(-1)},
+	"max_by": {2, jmesExtremeBy(1)},
+	"min_by": {2, jmesExtremeBy(-1)},
+	"merge": {-1, func(a []interface{}) (interface{}, error) {
+		out := map[string]interface{}{}
+		for _, arg := range a {
+			obj, ok := arg.(map[string]interface{})
+			if !ok {
+				return nil, errArgType
+			}
+			for k, v := range obj {
+				out[k] = v
+			}
+		}
+		return out, nil
+	}},
+	"not_null": {-1, func(a []interface{}) (interface{}, error) {
+		for _, v := range a {
+			if v != nil {
+				return v, nil
+			}
+		}
+		return nil, nil
+	}},
+	"reverse": {1, func(a []interface{}) (interface{}, error) {
+		switch v := a[0].(type) {
+		case string:
+			r := []rune(v)
+			for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+				r[i], r[j] = r[j], r[i]
+			}
+			return string(r), nil
+		case []interface{}:
+			out := make([]interface{}, len(v))
+			for i, el := range v {
+				out[len(v)-1-i] = el
+			}
+			return out, nil
+		}
+		return nil, errArgType
+	}},
+	"sort": {1, func(a []interface{}) (interface{}, error) {
+		arr, ok := a[0].([]interface{})
+		if !ok {
+			return nil, errArgType
+		}
+		return jmesSort(arr, jmesCurrent)
+	}},
+	"sort_by": {2, func(a []interface{}) (interface{}, error) {
+		arr, ok := a[0].([]interface{})
+		ref, isRef := a[1].(jmesRef)
+		if !ok || !isRef {
+			return nil, errArgType
+		}
+		return jmesSort(arr, jmesNode(ref))
+	}},
+	"to_array": {1, func(a []interface{}) (interface{}, error) {
+		if arr, ok := a[0].([]interface{}); ok {
+			return arr, nil
+		}
+		return []interface{}{a[0]}, nil
+	}},
+	"to_number": {1, func(a []interface{}) (interface{}, error) {
+		if _, ok := number(a[0]); ok {
+			return a[0], nil
+		}
+		if s, ok := a[0].(string); ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, nil
+	}},
+	"to_string": {1, func(a []interface{}) (interface{}, error) {
+		if s, ok := a[0].(string); ok {
+			return s, nil
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(a[0]); err != nil {
+			return nil, err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	}},
+	"type": {1, func(a []interface{}) (interface{}, error) {
+		return typeName(a[0]), nil
+	}},
+}
+
+var errArgType = errors.New("invalid argument type")
+
+func jmesMath(f func(float64) float64) func([]interface{}) (interface{}, error) {
+	return func(a []interface{}) (interface{}, error) {
+		n, ok := number(a[0])
+		if !ok {
+			return nil, errArgType
+		}
+		return f(n), nil
+	}
+}
+
+func jmesStrings(f func(s, affix string) bool) func([]interface{}) (interface{}, error) {
+	return func(a []interface{}) (interface{}, error) {
+		s, ok := a[0].(string)
+		affix, isStr := a[1].(string)
+		if !ok || !isStr {
+			return nil, errArgType
+		}
+		return f(s, affix), nil
+	}
+}
+
+func jmesNumbers(v interface{}) ([]float64, error) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, errArgType
+	}
+	nums := make([]float64, len(arr))
+	for i, el := range arr {
+		if nums[i], ok = number(el); !ok {
+			return nil, errArgType
+		}
+	}
+	return nums, nil
+}
+
+// jmesLess orders two numbers or two strings; ok is false for other pairs.
+func jmesLess(a, b interface{}) (less, ok bool) {
+	if af, isNum := number(a); isNum {
+		bf, isNum := number(b)
+		return af < bf, isNum
+	}
+	if as, isStr := a.(string); isStr {
+		bs, isStr := b.(string)
+		return as < bs, isStr
+	}
+	return false, false
+}
+
+// jmesExtreme returns max (sign 1) or min (sign -1) of an array of numbers
+// or strings.
+func jmesExtreme(sign int) func([]interface{}) (interface{}, error) {
+	return func(a []interface{}) (interface{}, error) {
+		arr, ok := a[0].([]interface{})
+		if !ok {
+			return nil, errArgType
+		}
+		return jmesPick(arr, arr, sign)
+	}
+}
+
+// jmesExtremeBy returns the element with the largest (sign 1) or smallest
+// (sign -1) key.
+func jmesExtremeBy(sign int) func([]interface{}) (interface{}, error) {
+	return func(a []interface{}) (interface{}, error) {
+		arr, ok := a[0].([]interface{})
+		ref, isRef := a[1].(jmesRef)
+		if !ok || !isRef {
+			return nil, errArgType
+		}
+		keys := make([]interface{}, len(arr))
+		for i, el := range arr {
+			var err error
+			if keys[i], err = ref(el); err != nil {
+				return nil, err
+			}
+		}
+		return jmesPick(arr, keys, sign)
+	}
+}
+
+func jmesPick(arr, keys []interface{}, sign int) (interface{}, error) {
+	best := -1
+	for i := range keys {
+		if best < 0 {
+			if _, ok := jmesLess(keys[i], keys[i]); !ok {
+				return nil, errArgType
+			}
+			best = i
+			continue
+		}
+		less, ok := jmesLess(keys[best], keys[i])
+		if !ok {
+			return nil, errArgType
+		}
+		greater, _ := jmesLess(keys[i], keys[best])
+		if sign > 0 && less || sign < 0 && greater {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, nil
+	}
+	return arr[best], nil
+}
+
+// jmesSort sorts arr by key, stably; keys must all be numbers or all
+// strings.
+func jmesSort(arr []interface{}, key jmesNode) (interface{}, error) {
+	keys := make([]interface{}, len(arr))
+	for i, el := range arr {
+		var err error
+		if keys[i], err = key(el); err != nil {
+			return nil, err
+		}
+		if _, ok := jmesLess(keys[i], keys[0]); !ok {
+			return nil, errArgType
+		}
+	}
+	idx := make([]int, len(arr))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		less, _ := jmesLess(keys[idx[i]], keys[idx[j]])
+		return less
+	})
+	out := make([]interface{}, len(arr))
+	for i, j := range idx {
+		out[i] = arr[j]
+	}
+	return out, nil
+}
@@ -0,0 +1,389 @@
+package jsonquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// cond tests a single value in a filter or select().
+type cond func(v interface{}) (bool, error)
+
+// operand resolves one side of a comparison against the current value.
+type operand func(v interface{}) (interface{}, bool, error)
+
+func parseJQ(expr string) ([]step, error) {
+	p := &parser{s: expr}
+	steps, err := p.pipeline()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return steps, nil
+}
+
+// pipeline parses terms separated by | up to the end or a closing paren.
+func (p *parser) pipeline() ([]step, error) {
+	var steps []step
+	for {
+		p.skipSpace()
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, term...)
+		if !p.consume('|') {
+			p.skipSpace()
+			return steps, nil
+		}
+	}
+}
+
+func (p *parser) term() ([]step, error) {
+	if p.peek() == '.' {
+		return p.path(true)
+	}
+	name := p.ident(true)
+	var steps []step
+	switch name {
+	case "keys":
+		steps = append(steps, keys)
+	case "length":
+		steps = append(steps, length)
+	case "type":
+		steps = append(steps, func(in []interface{}) ([]interface{}, error) {
+			out := make([]interface{}, len(in))
+			for i, v := range in {
+				out[i] = typeName(v)
+			}
+			return out, nil
+		})
+	case "first":
+		steps = append(steps, index([]int{0}))
+	case "last":
+		steps = append(steps, index([]int{-1}))
+	case "map":
+		if !p.consume('(') {
+			return nil, p.errorf("expected ( after map")
+		}
+		inner, err := p.pipeline()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(')') {
+			return nil, p.errorf("expected )")
+		}
+		steps = append(steps, mapStep(inner))
+	case "select":
+		if !p.consume('(') {
+			return nil, p.errorf("expected ( after select")
+		}
+		c, err := p.condition(true)
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(')') {
+			return nil, p.errorf("expected )")
+		}
+		steps = append(steps, selectStep(c))
+	case "":
+		return nil, p.errorf("expected a path or function")
+	default:
+		return nil, fmt.Errorf("unsupported function %q", name)
+	}
+
+	// Allow a trailing path, as in map(.id)[0]
+	if c := p.peek(); c == '.' || c == '[' {
+		rest, err := p.path(true)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, rest...)
+	}
+	return steps, nil
+}
+
+func keys(in []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(in))
+	for _, v := range in {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			var ks []interface{}
+			for _, k := range sortedKeys(v) {
+				ks = append(ks, k)
+			}
+			out = append(out, ks)
+		case []interface{}:
+			ks := make([]interface{}, len(v))
+			for i := range v {
+				ks[i] = json.Number(strconv.Itoa(i))
+			}
+			out = append(out, ks)
+		default:
+			return nil, fmt.Errorf("%s has no keys", typeName(v))
+		}
+	}
+	return out, nil
+}
+
+func length(in []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(in))
+	for _, v := range in {
+		var n int
+		switch v := v.(type) {
+		case nil:
+		case string:
+			n = utf8.RuneCountInString(v)
+		case []interface{}:
+			n = len(v)
+		case map[string]interface{}:
+			n = len(v)
+		default:
+			return nil, fmt.Errorf("%s has no length", typeName(v))
+		}
+		out = append(out, json.Number(strconv.Itoa(n)))
+	}
+	return out, nil
+}
+
+// mapStep runs inner on each element of an array and collects the results
+// into a new array.
+func mapStep(inner []step) step {
+	return func(in []interface{}) ([]interface{}, error) {
+		out := make([]interface{}, 0, len(in))
+		for _, v := range in {
+			collected := []interface{}{}
+			for _, m := range members(v) {
+				res, err := run(inner, []interface{}{m})
+				if err != nil {
+					return nil, err
+				}
+				collected = append(collected, res...)
+			}
+			out = append(out, collected)
+		}
+		return out, nil
+	}
+}
+
+func selectStep(c cond) step {
+	return func(in []interface{}) ([]interface{}, error) {
+		var out []interface{}
+		for _, v := range in {
+			ok, err := c(v)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				out = append(out, v)
+			}
+		}
+		return out, nil
+	}
+}
+
+// condition parses comparisons joined by && / || (and / or in jq),
+// evaluated left to right.
+func (p *parser) condition(jq bool) (cond, error) {
+	left, err := p.comparison(jq)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		rest := p.s[p.pos:]
+		var and bool
+		switch {
+		case strings.HasPrefix(rest, "&&") && !jq, strings.HasPrefix(rest, "and ") && jq:
+			and = true
+		case strings.HasPrefix(rest, "||") && !jq, strings.HasPrefix(rest, "or ") && jq:
+		default:
+			return left, nil
+		}
+		if jq && and {
+			p.pos += 3
+		} else {
+			p.pos += 2
+		}
+		right, err := p.comparison(jq)
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if and {
+			left = func(v interface{}) (bool, error) {
+				ok, err := l(v)
+				if err != nil || !ok {
+					return false, err
+				}
+				return right(v)
+			}
+		} else {
+			left = func(v interface{}) (bool, error) {
+				ok, err := l(v)
+				if err != nil || ok {
+					return ok, err
+				}
+				return right(v)
+			}
+		}
+	}
+}
+
+var comparisonOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func (p *parser) comparison(jq bool) (cond, error) {
+	left, err := p.operand(jq)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	op := ""
+	for _, candidate := range comparisonOps {
+		if strings.HasPrefix(p.s[p.pos:], candidate) {
+			op = candidate
+			p.pos += len(candidate)
+			break
+		}
+	}
+	if op == "" {
+		return func(v interface{}) (bool, error) {
+			val, ok, err := left(v)
+			return ok && truthy(val), err
+		}, nil
+	}
+	right, err := p.operand(jq)
+	if err != nil {
+		return nil, err
+	}
+	return func(v interface{}) (bool, error) {
+		a, aok, err := left(v)
+		if err != nil {
+			return false, err
+		}
+		b, bok, err := right(v)
+		if err != nil {
+			return false, err
+		}
+		if !aok || !bok {
+			return op == "!=" && aok != bok, nil
+		}
+		return compare(a, b, op), nil
+	}, nil
+}
+
+func (p *parser) operand(jq bool) (operand, error) {
+	p.skipSpace()
+	switch c := p.peek(); {
+	case c == '@' && !jq, c == '.' && jq:
+		if c == '@' {
+			p.pos++
+		}
+		steps, err := p.path(jq)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}) (interface{}, bool, error) {
+			res, err := run(steps, []interface{}{v})
+			if err != nil || len(res) == 0 {
+				return nil, false, err
+			}
+			return res[0], true, nil
+		}, nil
+	case c == '"' || c == '\'':
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return literal(s), nil
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.s) && strings.ContainsRune("0123456789.eE+-", rune(p.s[p.pos])) {
+			p.pos++
+		}
+		n := json.Number(p.s[start:p.pos])
+		if _, err := n.Float64(); err != nil {
+			return nil, fmt.Errorf("invalid number %q", n)
+		}
+		return literal(n), nil
+	}
+	switch word := p.ident(true); word {
+	case "true":
+		return literal(true), nil
+	case "false":
+		return literal(false), nil
+	case "null":
+		return literal(nil), nil
+	case "":
+		return nil, p.errorf("expected a value")
+	default:
+		return nil, fmt.Errorf("unexpected %q; quote strings", word)
+	}
+}
+
+func literal(v interface{}) operand {
+	return func(interface{}) (interface{}, bool, error) { return v, true, nil }
+}
+
+func truthy(v interface{}) bool {
+	return v != nil && v != false
+}
+
+func compare(a, b interface{}, op string) bool {
+	if af, ok := number(a); ok {
+		if bf, ok := number(b); ok {
+			switch op {
+			case "==":
+				return af == bf
+			case "!=":
+				return af != bf
+			case "<":
+				return af < bf
+			case "<=":
+				return af <= bf
+			case ">":
+				return af > bf
+			case ">=":
+				return af >= bf
+			}
+		}
+	}
+	if as, ok := a.(string); ok {
+		if bs, ok := b.(string); ok {
+			switch op {
+			case "<":
+				return as < bs
+			case "<=":
+				return as <= bs
+			case ">":
+				return as > bs
+			case ">=":
+				return as >= bs
+			}
+		}
+	}
+	switch op {
+	case "==":
+		return reflect.DeepEqual(a, b)
+	case "!=":
+		return !reflect.DeepEqual(a, b)
+	}
+	return false
+}
+
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,561 @@
+// Package jsonquery evaluates JSONPath ($.items[0].id), a jq subset
+// (.items[0].id | keys) and JMESPath (jmespath:items[0].id) against JSON
+// documents.
+//
+// JSONPath supports child (.name, ['name']), index and slice ([0], [-1],
+// [1:3], [::-1]), unions ([0,2], ['a','b']), wildcards (.*, [*]), recursive descent
+// (..name) and filters ([?(@.age > 30)]). The jq subset supports paths,
+// iteration (.[]), pipes and the keys, length, first, last, type, map() and
+// select() builtins. JMESPath is supported in full, including projections,
+// filters, multiselects and the builtin functions; as it has no leading
+// character of its own, it is marked with a jmespath: prefix.
+package jsonquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// step maps a stream of values to the next stream.
+type step func(in []interface{}) ([]interface{}, error)
+
+// IsQuery reports whether expr looks like a JSONPath, jq or JMESPath
+// expression rather than plain search text.
+func IsQuery(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	return strings.HasPrefix(expr, "$") || strings.HasPrefix(expr, ".") || strings.HasPrefix(expr, jmespathPrefix)
+}
+
+// Query parses body as JSON and evaluates expr against it.
+func Query(body []byte, expr string) ([]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("body is not JSON: %w", err)
+	}
+	return Eval(doc, expr)
+}
+
+// Eval evaluates expr against a decoded document. Expressions starting with
+// $ are JSONPath, those starting with . are jq and those starting with
+// jmespath: are JMESPath.
+func Eval(doc interface{}, expr string) ([]interface{}, error) {
+	steps, err := parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return run(steps, []interface{}{doc})
+}
+
+// Validate reports syntax errors in expr without evaluating it.
+func Validate(expr string) error {
+	_, err := parse(expr)
+	return err
+}
+
+func parse(expr string) (steps []step, err error) {
+	expr = strings.TrimSpace(expr)
+	switch {
+	case strings.HasPrefix(expr, "$"):
+		p := &parser{s: expr, pos: 1}
+		steps, err = p.path(false)
+		if err == nil && p.pos < len(p.s) {
+			err = p.errorf("unexpected %q", p.s[p.pos:])
+		}
+	case strings.HasPrefix(expr, "."):
+		steps, err = parseJQ(expr)
+	case strings.HasPrefix(expr, jmespathPrefix):
+		steps, err = parseJMESPath(strings.TrimPrefix(expr, jmespathPrefix))
+	default:
+		err = fmt.Errorf("expression must start with $ (JSONPath), . (jq) or jmespath: (JMESPath)")
+	}
+	return steps, err
+}
+
+var identifier = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// VarName suggests a variable name for the result of expr: the last field
+// it names, or "value".
+func VarName(expr string) string {
+	name := "value"
+	for _, id := range identifier.FindAllString(expr, -1) {
+		switch id {
+		case "keys", "length", "first", "last", "type", "map", "select", "and", "or", "true", "false", "null", "jmespath":
+			continue
+		}
+		name = id
+	}
+	return name
+}
+
+// Format renders results as indented JSON: a single result as itself and
+// several as an array.
+func Format(results []interface{}) string {
+	var v interface{} = results
+	if len(results) == 1 {
+		v = results[0]
+	} else if results == nil {
+		v = []interface{}{}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// String renders a single value for use as a variable: strings without
+// quotes, null as empty and everything else as compact JSON.
+func String(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(v)
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+}
+
+func run(steps []step, in []interface{}) ([]interface{}, error) {
+	var err error
+	for _, s := range steps {
+		if in, err = s(in); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// parser is a cursor over an expression.
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("at %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *parser) consume(c byte) bool {
+	p.skipSpace()
+	if p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// ident reads a member name. JSONPath names may contain dashes; in jq a
+// dash is subtraction.
+func (p *parser) ident(jq bool) string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= utf8.RuneSelf || (!jq && c == '-') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *parser) quoted() (string, error) {
+	quote := p.peek()
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.s):
+			b.WriteByte(p.s[p.pos+1])
+			p.pos += 2
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+// path parses a sequence of path segments. In jq mode parsing stops at the
+// first character that cannot continue a path.
+func (p *parser) path(jq bool) ([]step, error) {
+	var steps []step
+	for p.pos < len(p.s) {
+		switch {
+		case strings.HasPrefix(p.s[p.pos:], ".."):
+			if jq {
+				return nil, p.errorf("recursive descent is JSONPath only; use $..name")
+			}
+			p.pos += 2
+			steps = append(steps, descendants)
+			switch c := p.peek(); {
+			case c == '[':
+			case c == '*':
+				p.pos++
+				steps = append(steps, wildcard)
+			default:
+				name := p.ident(false)
+				if name == "" {
+					return nil, p.errorf("expected a name after ..")
+				}
+				steps = append(steps, child(name, false))
+			}
+		case p.peek() == '.':
+			p.pos++
+			switch c := p.peek(); {
+			case c == '*' && !jq:
+				p.pos++
+				steps = append(steps, wildcard)
+			case c == '"' && jq:
+				name, err := p.quoted()
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, child(name, true))
+			case jq && (c == '[' || c == 0 || c == ' ' || c == '|' || c == ')'):
+				// identity
+			default:
+				name := p.ident(jq)
+				if name == "" {
+					return nil, p.errorf("expected a name after .")
+				}
+				steps = append(steps, child(name, jq))
+			}
+		case p.peek() == '[':
+			s, err := p.bracket(jq)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		default:
+			if jq || strings.ContainsRune(" )]=!<>&|", rune(p.peek())) {
+				return steps, nil
+			}
+			return nil, p.errorf("unexpected %q", p.s[p.pos:])
+		}
+	}
+	return steps, nil
+}
+
+// bracket parses [...] after the cursor: wildcards, filters, names,
+// indexes, slices and unions.
+func (p *parser) bracket(jq bool) (step, error) {
+	p.pos++ // [
+	p.skipSpace()
+	switch p.peek() {
+	case ']':
+		p.pos++
+		return wildcard, nil
+	case '*':
+		p.pos++
+		if !p.consume(']') {
+			return nil, p.errorf("expected ]")
+		}
+		return wildcard, nil
+	case '?':
+		if jq {
+			return nil, p.errorf("use select() to filter in jq")
+		}
+		p.pos++
+		paren := p.consume('(')
+		c, err := p.condition(false)
+		if err != nil {
+			return nil, err
+		}
+		if paren && !p.consume(')') {
+			return nil, p.errorf("expected )")
+		}
+		if !p.consume(']') {
+			return nil, p.errorf("expected ]")
+		}
+		return filter(c), nil
+	case '\'', '"':
+		var names []string
+		for {
+			p.skipSpace()
+			name, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+			if !p.consume(',') {
+				break
+			}
+		}
+		if !p.consume(']') {
+			return nil, p.errorf("expected ]")
+		}
+		return children(names, jq), nil
+	}
+
+	// Indexes, slices and unions
+	var parts [][]string
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] != ']' {
+		p.pos++
+	}
+	if p.pos >= len(p.s) {
+		p.pos = start
+		return nil, p.errorf("expected ]")
+	}
+	body := p.s[start:p.pos]
+	p.pos++
+	for _, part := range strings.Split(body, ",") {
+		parts = append(parts, strings.Split(strings.TrimSpace(part), ":"))
+	}
+	if len(parts) == 1 && len(parts[0]) > 1 {
+		if len(parts[0]) > 3 || (jq && len(parts[0]) > 2) {
+			return nil, fmt.Errorf("invalid slice %q", body)
+		}
+		bounds := make([]*int, 3)
+		for i, s := range parts[0] {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid slice bound %q", s)
+			}
+			bounds[i] = &n
+		}
+		return slice(bounds[0], bounds[1], bounds[2]), nil
+	}
+	var indexes []int
+	for _, part := range parts {
+		if len(part) != 1 {
+			return nil, fmt.Errorf("slices cannot be combined with other indexes")
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(part[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q", part[0])
+		}
+		indexes = append(indexes, n)
+	}
+	return index(indexes), nil
+}
+
+func child(name string, nullMissing bool) step {
+	return children([]string{name}, nullMissing)
+}
+
+// children selects object members. jq yields null for missing members and
+// errors on non-objects; JSONPath silently selects nothing.
+func children(names []string, jq bool) step {
+	return func(in []interface{}) ([]interface{}, error) {
+		var out []interface{}
+		for _, v := range in {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				if jq && v != nil {
+					return nil, fmt.Errorf("cannot index %s with %q", typeName(v), names[0])
+				}
+				if jq {
+					out = append(out, nil)
+				}
+				continue
+			}
+			for _, name := range names {
+				if val, ok := obj[name]; ok {
+					out = append(out, val)
+				} else if jq {
+					out = append(out, nil)
+				}
+			}
+		}
+		return out, nil
+	}
+}
+
+func index(indexes []int) step {
+	return func(in []interface{}) ([]interface{}, error) {
+		var out []interface{}
+		for _, v := range in {
+			arr, ok := v.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, i := range indexes {
+				if i < 0 {
+					i += len(arr)
+				}
+				if i >= 0 && i < len(arr) {
+					out = append(out, arr[i])
+				}
+			}
+		}
+		return out, nil
+	}
+}
+
+// slice selects array elements from:to:by, as in RFC 9535; a zero step
+// selects nothing.
+func slice(from, to, by *int) step {
+	return func(in []interface{}) ([]interface{}, error) {
+		var out []interface{}
+		for _, v := range in {
+			arr, ok := v.([]interface{})
+			if !ok || (by != nil && *by == 0) {
+				continue
+			}
+			out = append(out, sliceArray(arr, from, to, by)...)
+		}
+		return out, nil
+	}
+}
+
+// sliceArray slices arr like Python, with a step that may be negative but
+// not 0.
+func sliceArray(arr []interface{}, from, to, by *int) []interface{} {
+	step := 1
+	if by != nil {
+		step = *by
+	}
+	bound := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += len(arr)
+		}
+		if step > 0 {
+			return max(0, min(i, len(arr)))
+		}
+		return max(-1, min(i, len(arr)-1))
+	}
+	out := []interface{}{}
+	if step > 0 {
+		for i := bound(from, 0); i < bound(to, len(arr)); i += step {
+			out = append(out, arr[i])
+		}
+	} else {
+		for i := bound(from, len(arr)-1); i > bound(to, -1); i += step {
+			out = append(out, arr[i])
+		}
+	}
+	return out
+}
+
+// wildcard yields array elements and object values (in key order).
+func wildcard(in []interface{}) ([]interface{}, error) {
+	var out []interface{}
+	for _, v := range in {
+		out = append(out, members(v)...)
+	}
+	return out, nil
+}
+
+// descendants yields each value and everything nested below it.
+func descendants(in []interface{}) ([]interface{}, error) {
+	var out []interface{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		out = append(out, v)
+		for _, m := range members(v) {
+			walk(m)
+		}
+	}
+	for _, v := range in {
+		walk(v)
+	}
+	return out, nil
+}
+
+func members(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, k := range sortedKeys(v) {
+			out = append(out, v[k])
+		}
+		return out
+	}
+	return nil
+}
+
+func filter(c cond) step {
+	return func(in []interface{}) ([]interface{}, error) {
+		var out []interface{}
+		for _, v := range in {
+			for _, m := range members(v) {
+				ok, err := c(m)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					out = append(out, m)
+				}
+			}
+		}
+		return out, nil
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,140 @@
+package jsonquery
+
+import (
+	"strings"
+	"testing"
+)
+
+const doc = `{
+	"store": {
+		"name": "Books & Co",
+		"books": [
+			{"title": "Go", "price": 30, "tags": ["lang"]},
+			{"title": "Rust", "price": 45.5, "tags": ["lang", "systems"]},
+			{"title": "SQL", "price": 20, "isbn": "123"}
+		]
+	},
+	"first-name": "Ada"
+}`
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string // Format output, compacted
+	}{
+		// JSONPath
+		{"$", ""},
+		{"$.store.name", `"Books & Co"`},
+		{"$['store']['name']", `"Books & Co"`},
+		{"$.first-name", `"Ada"`},
+		{"$.store.books[0].title", `"Go"`},
+		{"$.store.books[-1].title", `"SQL"`},
+		{"$.store.books[*].price", `[30,45.5,20]`},
+		{"$.store.books[0,2].title", `["Go","SQL"]`},
+		{"$.store.books[1:].title", `["Rust","SQL"]`},
+		{"$.store.books[::-1].title", `["SQL","Rust","Go"]`},
+		{"$.store.books[0:3:2].title", `["Go","SQL"]`},
+		{"$.store.books[-1:0:-1].title", `["SQL","Rust"]`},
+		{"$.store.books[::0]", `[]`},
+		{"$..isbn", `"123"`},
+		{"$..tags[0]", `["lang","lang"]`},
+		{"$.store.books[?(@.price > 25)].title", `["Go","Rust"]`},
+		{"$.store.books[?(@.isbn)].title", `"SQL"`},
+		{"$.store.books[?(@.title == 'Go' || @.price < 21)].title", `["Go","SQL"]`},
+		{"$.missing", `[]`},
+		// jq
+		{".store.books[0].title", `"Go"`},
+		{".store.books[].title", `["Go","Rust","SQL"]`},
+		{".store.books | length", `3`},
+		{".store | keys", `["books","name"]`},
+		{".store.books | map(.price)", `[30,45.5,20]`},
+		{".store.books | map(.title) | first", `"Go"`},
+		{".store.books[] | select(.price >= 30 and .tags) | .title", `["Go","Rust"]`},
+		{`."first-name"`, `"Ada"`},
+		{".store.books[0].missing", `null`},
+		{".store.name | type", `"string"`},
+		// JMESPath
+		{"jmespath:store.name", `"Books & Co"`},
+		{`jmespath:"first-name"`, `"Ada"`},
+		{"jmespath:store.books[-1].title", `"SQL"`},
+		{"jmespath:store.books[*].price", `[30,45.5,20]`},
+		{"jmespath:store.books[1:].title", `["Rust","SQL"]`},
+		{"jmespath:store.books[::-1].title", `["SQL","Rust","Go"]`},
+		{"jmespath:store.books[].tags[]", `["lang","lang","systems"]`},
+		{"jmespath:store.books[?price > `25`].title", `["Go","Rust"]`},
+		{"jmespath:store.books[?title == 'Go' || price < `21`].title | [0]", `"Go"`},
+		{"jmespath:store.books[?!isbn] | length(@)", `2`},
+		{"jmespath:store.books[*].{name: title, cost: price} | [1].name", `"Rust"`},
+		{"jmespath:store.books[0].[title, price]", `["Go",30]`},
+		{"jmespath:store.* | [1]", `"Books & Co"`},
+		{"jmespath:sort_by(store.books, &price)[*].title", `["SQL","Go","Rust"]`},
+		{"jmespath:max_by(store.books, &price).title", `"Rust"`},
+		{"jmespath:sum(store.books[*].price)", `95.5`},
+		{"jmespath:join(', ', store.books[*].title)", `"Go, Rust, SQL"`},
+		{"jmespath:contains(store.books[1].tags, 'systems') && starts_with(store.name, 'Books')", `true`},
+		{"jmespath:store.missing", `[]`},
+		{"jmespath:store.books[?price > `100`]", `[]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			res, err := Query([]byte(doc), tt.expr)
+			if err != nil {
+				t.Fatalf("Query(%q): %v", tt.expr, err)
+			}
+			if tt.want == "" {
+				return
+			}
+			got := strings.NewReplacer("\n", "", "  ", "").Replace(Format(res))
+			if got != tt.want {
+				t.Errorf("Query(%q) = %s, want %s", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	for _, expr := range []string{"store.name", "$.store[", "$.store.books[?(@.price > )]", ".store | frobnicate", "$..", ".a ..b",
+		"jmespath:store.", "jmespath:frobnicate(store)", "jmespath:length(store.name, 1)", "jmespath:store.books[::0]", "jmespath:abs(store.name)",
+		"$.store.books[0:1:2:3]", ".store.books[::2]"} {
+		if _, err := Query([]byte(doc), expr); err == nil {
+			t.Errorf("Query(%q): expected error", expr)
+		}
+	}
+	if _, err := Query([]byte("not json"), "$.a"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestString(t *testing.T) {
+	res, err := Query([]byte(doc), "$.store.books[1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := String(res[0]); got != `{"price":45.5,"tags":["lang","systems"],"title":"Rust"}` {
+		t.Errorf("String(object) = %s", got)
+	}
+	for v, want := range map[interface{}]string{"x": "x", nil: "", true: "true"} {
+		if got := String(v); got != want {
+			t.Errorf("String(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestIsQuery(t *testing.T) {
+	if !IsQuery("$.a") || !IsQuery(" .a") || !IsQuery("jmespath:a") || IsQuery("hello") {
+		t.Error("IsQuery misclassified expressions")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, expr := range []string{"$.a[?(@.b > 1)]", ".a | keys", "$..x", "jmespath:a[?b > `1`].c | sort(@)"} {
+		if err := Validate(expr); err != nil {
+			t.Errorf("Validate(%q) = %v", expr, err)
+		}
+	}
+	for _, expr := range []string{"$.[", ".a[", "items"} {
+		if err := Validate(expr); err == nil {
+			t.Errorf("Validate(%q) should fail", expr)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Package migrate upgrades YAML files written by older versions of gottp.
+// Each file format records its version in a top-level schema_version key;
+// files without one are version 0. Migrations rewrite the YAML node tree,
+// so comments and key order survive a rewrite.
+package migrate
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Key is the top-level key holding a file's schema version.
+const Key = "schema_version"
+
+// Migration upgrades a document from version From to From+1.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(root *yaml.Node) error // root is the top-level mapping; nil only bumps the version
+}
+
+// Result describes an upgrade.
+type Result struct {
+	From    int
+	To      int
+	Applied []string // descriptions of the migrations that ran, in order
+}
+
+// Changed reports whether the document was upgraded.
+func (r Result) Changed() bool {
+	return r.From != r.To
+}
+
+// Upgrade migrates doc, a decoded YAML document, to version target and
+// records the new version in it. A document newer than target is an error,
+// as this build cannot know what changed. Empty documents are left alone.
+func Upgrade(doc *yaml.Node, target int, migrations []Migration) (Result, error) {
+	root := doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return Result{From: target, To: target}, nil
+	}
+
+	version := 0
+	if v := Get(root, Key); v != nil {
+		n, err := strconv.Atoi(v.Value)
+		if err != nil || v.Kind != yaml.ScalarNode {
+			return Result{}, fmt.Errorf("line %d: %s must be an integer, got %q", v.Line, Key, v.Value)
+		}
+		version = n
+	}
+	res := Result{From: version, To: version}
+	if version > target {
+		return res, fmt.Errorf("schema version %d is newer than this gottp supports (%d); upgrade gottp", version, target)
+	}
+
+	for res.To < target {
+		m, ok := find(migrations, res.To)
+		if !ok {
+			return res, fmt.Errorf("no migration from schema version %d", res.To)
+		}
+		if m.Apply != nil {
+			if err := m.Apply(root); err != nil {
+				return res, fmt.Errorf("migrating from schema version %d (%s): %w", m.From, m.Description, err)
+			}
+		}
+		res.Applied = append(res.Applied, m.Description)
+		res.To++
+	}
+
+	if v := Get(root, Key); v != nil {
+		v.Value = strconv.Itoa(target)
+	} else {
+		// Lead with the version so it is the first thing a reader sees
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: Key}
+		val := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(target)}
+		if len(root.Content) > 0 {
+			// Keep a file's leading comment at the top
+			key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+		}
+		root.Content = append([]*yaml.Node{key, val}, root.Content...)
+	}
+	return res, nil
+}
+
+func find(migrations []Migration, from int) (Migration, bool) {
+	for _, m := range migrations {
+		if m.From == from {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+// Get returns the value for key in a mapping node, or nil.
+func Get(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
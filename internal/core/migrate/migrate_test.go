@@ -0,0 +1,81 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func decode(t *testing.T, src string) *yaml.Node {
+	t.Helper()
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return &doc
+}
+
+func encode(t *testing.T, doc *yaml.Node) string {
+	t.Helper()
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestUpgrade(t *testing.T) {
+	var order []int
+	migrations := []Migration{
+		{From: 1, Description: "second", Apply: func(root *yaml.Node) error {
+			order = append(order, 1)
+			Get(root, "name").Value = "renamed"
+			return nil
+		}},
+		{From: 0, Description: "first", Apply: func(*yaml.Node) error {
+			order = append(order, 0)
+			return nil
+		}},
+	}
+
+	doc := decode(t, "# top comment\nname: old # keep me\n")
+	res, err := Upgrade(doc, 2, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Changed() || res.From != 0 || res.To != 2 || strings.Join(res.Applied, ",") != "first,second" {
+		t.Errorf("result = %+v", res)
+	}
+	if len(order) != 2 || order[0] != 0 || order[1] != 1 {
+		t.Errorf("migrations ran in order %v", order)
+	}
+	out := encode(t, doc)
+	for _, want := range []string{"# top comment\nschema_version: 2\nname: renamed # keep me"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	// Already current: nothing runs
+	order = nil
+	res, err = Upgrade(decode(t, "schema_version: 2\nname: x\n"), 2, migrations)
+	if err != nil || res.Changed() || len(order) != 0 {
+		t.Errorf("current file: result %+v, err %v, ran %v", res, err, order)
+	}
+}
+
+func TestUpgradeErrors(t *testing.T) {
+	if _, err := Upgrade(decode(t, "schema_version: 3\n"), 2, nil); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("newer file: err = %v", err)
+	}
+	if _, err := Upgrade(decode(t, "schema_version: two\n"), 2, nil); err == nil {
+		t.Error("expected an error for a non-integer version")
+	}
+	if _, err := Upgrade(decode(t, "name: x\n"), 1, nil); err == nil {
+		t.Error("expected an error for a missing migration")
+	}
+	if res, err := Upgrade(decode(t, ""), 1, nil); err != nil || res.Changed() {
+		t.Errorf("empty document: result %+v, err %v", res, err)
+	}
+}
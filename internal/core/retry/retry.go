@@ -0,0 +1,209 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Retry conditions accepted in Policy.On besides plain status codes.
+const (
+	OnConnection = "connection" // dial, DNS and reset errors, and attempt timeouts
+	On429        = "429"
+	On5xx        = "5xx"
+)
+
+// Defaults used when a Policy leaves a field empty.
+const (
+	DefaultBackoff    = 500 * time.Millisecond
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// DefaultOn lists the conditions retried when Policy.On is empty.
+var DefaultOn = []string{OnConnection, On429, On5xx}
+
+// Policy configures automatic retries with exponential backoff and jitter.
+type Policy struct {
+	MaxAttempts int           `yaml:"max_attempts,omitempty"` // total tries including the first; 0 or 1 disables retries
+	Backoff     time.Duration `yaml:"backoff,omitempty"`      // delay before the first retry, doubled for each further one
+	MaxBackoff  time.Duration `yaml:"max_backoff,omitempty"`  // cap for backoff and Retry-After delays
+	On          []string      `yaml:"on,omitempty"`           // connection, 429, 5xx or status codes such as 502
+}
+
+// Stats describes the attempts made for one request.
+type Stats struct {
+	Attempts int           `json:"attempts"`
+	Reasons  []string      `json:"reasons,omitempty"` // why each retry happened, e.g. "503" or "connection"
+	Waited   time.Duration `json:"waited"`
+}
+
+// Enabled reports whether the policy allows more than one attempt.
+func (p *Policy) Enabled() bool {
+	return p != nil && p.MaxAttempts > 1
+}
+
+// IsEmpty reports whether no field is set.
+func (p *Policy) IsEmpty() bool {
+	return p == nil || (p.MaxAttempts == 0 && p.Backoff == 0 && p.MaxBackoff == 0 && len(p.On) == 0)
+}
+
+// Merge returns a copy of p with the fields set in over taking precedence.
+// Either may be nil.
+func (p *Policy) Merge(over *Policy) *Policy {
+	out := &Policy{}
+	if p != nil {
+		*out = *p
+	}
+	if over == nil {
+		return out
+	}
+	if over.MaxAttempts != 0 {
+		out.MaxAttempts = over.MaxAttempts
+	}
+	if over.Backoff != 0 {
+		out.Backoff = over.Backoff
+	}
+	if over.MaxBackoff != 0 {
+		out.MaxBackoff = over.MaxBackoff
+	}
+	if len(over.On) > 0 {
+		out.On = over.On
+	}
+	return out
+}
+
+// Validate checks the retry conditions.
+func (p *Policy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	for _, on := range p.On {
+		switch on {
+		case OnConnection, On5xx:
+		default:
+			if code, err := strconv.Atoi(on); err != nil || code < 100 || code > 599 {
+				return fmt.Errorf("unknown retry condition %q (want connection, 5xx or a status code)", on)
+			}
+		}
+	}
+	return nil
+}
+
+// Do calls attempt until it returns an outcome the policy does not retry,
+// the attempts are used up, or ctx is done. attempt reports the response
+// status (0 when there is none), the Retry-After header and any error; the
+// caller keeps the response of the last attempt.
+func (p *Policy) Do(ctx context.Context, attempt func(ctx context.Context) (status int, retryAfter string, err error)) Stats {
+	var stats Stats
+	for {
+		stats.Attempts++
+		status, retryAfter, err := attempt(ctx)
+		if !p.Enabled() || stats.Attempts >= p.MaxAttempts || ctx.Err() != nil {
+			return stats
+		}
+		reason := p.reason(status, err)
+		if reason == "" {
+			return stats
+		}
+
+		delay := p.Delay(stats.Attempts)
+		if d, ok := parseRetryAfter(retryAfter, time.Now()); ok {
+			delay = min(d, p.maxBackoff())
+		}
+		stats.Reasons = append(stats.Reasons, reason)
+		stats.Waited += delay
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return stats
+		case <-timer.C:
+		}
+	}
+}
+
+// reason returns the condition matched by an outcome, or "" if it is not
+// retried.
+func (p *Policy) reason(status int, err error) string {
+	on := p.On
+	if len(on) == 0 {
+		on = DefaultOn
+	}
+	code := strconv.Itoa(status)
+	for _, c := range on {
+		switch {
+		case err != nil:
+			if c == OnConnection && IsConnectionError(err) {
+				return OnConnection
+			}
+		case c == On5xx && status >= 500 && status <= 599, c == code:
+			return code
+		}
+	}
+	return ""
+}
+
+// Delay returns the wait before retry n (1-based): the base delay doubled
+// n-1 times and capped, with up to half of it replaced by jitter.
+func (p *Policy) Delay(n int) time.Duration {
+	d := p.Backoff
+	if d <= 0 {
+		d = DefaultBackoff
+	}
+	limit := p.maxBackoff()
+	for i := 1; i < n && d < limit; i++ {
+		d *= 2
+	}
+	d = min(d, limit)
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func (p *Policy) maxBackoff() time.Duration {
+	if p.MaxBackoff > 0 {
+		return p.MaxBackoff
+	}
+	return DefaultMaxBackoff
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	return max(t.Sub(now), 0), true
+}
+
+// IsConnectionError reports whether err comes from reaching the server
+// rather than from the request itself: dial and DNS failures, dropped
+// connections and attempt timeouts.
+func IsConnectionError(err error) bool {
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	return errors.As(err, &opErr) ||
+		errors.As(err, &dnsErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
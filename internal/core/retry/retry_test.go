@@ -0,0 +1,156 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDo_RetriesUntilSuccess(t *testing.T) {
+	p := &Policy{MaxAttempts: 4, Backoff: time.Millisecond}
+	statuses := []int{503, 429, 200, 200}
+	calls := 0
+	stats := p.Do(context.Background(), func(context.Context) (int, string, error) {
+		calls++
+		return statuses[calls-1], "", nil
+	})
+	if calls != 3 || stats.Attempts != 3 {
+		t.Fatalf("calls = %d, attempts = %d, want 3", calls, stats.Attempts)
+	}
+	if fmt.Sprint(stats.Reasons) != "[503 429]" {
+		t.Errorf("reasons = %v", stats.Reasons)
+	}
+}
+
+func TestDo_StopsAtMaxAttempts(t *testing.T) {
+	p := &Policy{MaxAttempts: 3, Backoff: time.Millisecond}
+	calls := 0
+	stats := p.Do(context.Background(), func(context.Context) (int, string, error) {
+		calls++
+		return 0, "", &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	})
+	if calls != 3 || stats.Attempts != 3 || len(stats.Reasons) != 2 || stats.Reasons[0] != OnConnection {
+		t.Fatalf("calls = %d, stats = %+v", calls, stats)
+	}
+}
+
+func TestDo_DisabledOrNotRetried(t *testing.T) {
+	for _, p := range []*Policy{nil, {MaxAttempts: 1}, {MaxAttempts: 3, On: []string{"502"}}} {
+		calls := 0
+		stats := p.Do(context.Background(), func(context.Context) (int, string, error) {
+			calls++
+			return 503, "", nil
+		})
+		if calls != 1 || stats.Attempts != 1 {
+			t.Errorf("policy %+v: calls = %d, want 1", p, calls)
+		}
+	}
+
+	// Errors that are not connection problems are not retried
+	p := &Policy{MaxAttempts: 3}
+	calls := 0
+	p.Do(context.Background(), func(context.Context) (int, string, error) {
+		calls++
+		return 0, "", errors.New("invalid URL")
+	})
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDo_HonorsRetryAfterAndContext(t *testing.T) {
+	p := &Policy{MaxAttempts: 5, Backoff: time.Hour, MaxBackoff: 2 * time.Millisecond}
+	calls := 0
+	stats := p.Do(context.Background(), func(context.Context) (int, string, error) {
+		calls++
+		if calls == 1 {
+			return 429, "0", nil
+		}
+		return 200, "", nil
+	})
+	if calls != 2 || stats.Waited != 0 {
+		t.Errorf("calls = %d, waited = %s; Retry-After: 0 should retry immediately", calls, stats.Waited)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p = &Policy{MaxAttempts: 5, Backoff: time.Hour}
+	calls = 0
+	done := make(chan Stats)
+	go func() {
+		done <- p.Do(ctx, func(context.Context) (int, string, error) {
+			calls++
+			return 500, "", nil
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case stats := <-done:
+		if calls != 1 || stats.Attempts != 1 {
+			t.Errorf("calls = %d, want 1 after cancel", calls)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Do should return when the context is cancelled")
+	}
+}
+
+func TestBackoffGrowsWithJitter(t *testing.T) {
+	p := &Policy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	for i := 0; i < 50; i++ {
+		if d := p.Delay(1); d < 50*time.Millisecond || d > 100*time.Millisecond {
+			t.Fatalf("Delay(1) = %s, want 50-100ms", d)
+		}
+		if d := p.Delay(2); d < 100*time.Millisecond || d > 200*time.Millisecond {
+			t.Fatalf("Delay(2) = %s, want 100-200ms", d)
+		}
+		if d := p.Delay(5); d < 150*time.Millisecond || d > 300*time.Millisecond {
+			t.Fatalf("Delay(5) = %s, want capped at 300ms", d)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"", 0, false},
+		{"7", 7 * time.Second, true},
+		{"-1", 0, false},
+		{"Fri, 02 Jan 2026 03:04:15 GMT", 10 * time.Second, true},
+		{"Fri, 02 Jan 2026 03:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.in, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %s, %v; want %s, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMergeAndValidate(t *testing.T) {
+	global := &Policy{MaxAttempts: 3, Backoff: time.Second, On: []string{"5xx"}}
+	got := global.Merge(&Policy{MaxAttempts: 1}).Merge(nil)
+	if got.MaxAttempts != 1 || got.Backoff != time.Second || len(got.On) != 1 {
+		t.Errorf("unexpected merge result %+v", got)
+	}
+	if global.MaxAttempts != 3 {
+		t.Error("Merge should not modify the receiver")
+	}
+	if (*Policy)(nil).Merge(nil) == nil {
+		t.Error("Merge on nil should return an empty policy")
+	}
+
+	if err := (&Policy{On: []string{"connection", "429", "5xx", "502"}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (&Policy{On: []string{"4xx"}}).Validate(); err == nil {
+		t.Error("expected an error for an unknown condition")
+	}
+}
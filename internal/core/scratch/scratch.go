@@ -0,0 +1,66 @@
+// Package scratch keeps ad-hoc requests, opened in new tabs and never
+// saved to a collection, from one session to the next.
+package scratch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+// FileName is the scratch file's name in the data directory.
+const FileName = "scratch.yaml"
+
+type file struct {
+	Requests []*collection.Request `yaml:"requests"`
+}
+
+// Load reads the scratch requests saved at path. A missing file holds
+// none.
+func Load(path string) ([]*collection.Request, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	var reqs []*collection.Request
+	for _, req := range f.Requests {
+		if req == nil {
+			continue
+		}
+		if req.ID == "" {
+			req.ID = collection.NewID(req.Name)
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// Save replaces the scratch requests at path with reqs, removing the file
+// when there are none.
+func Save(path string, reqs []*collection.Request) error {
+	if len(reqs) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := yaml.Marshal(file{Requests: reqs})
+	if err != nil {
+		return fmt.Errorf("marshaling scratch requests: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing scratch requests: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package scratch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if reqs, err := Load(path); err != nil || reqs != nil {
+		t.Fatalf("Load of a missing file = %v, %v", reqs, err)
+	}
+
+	req := collection.NewRequest("Ad hoc", "POST", "https://api.example.com/echo")
+	req.Body = &collection.Body{Type: "json", Content: `{"a":1}`}
+	if err := Save(path, []*collection.Request{req}); err != nil {
+		t.Fatal(err)
+	}
+	reqs, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 || reqs[0].ID != req.ID || reqs[0].URL != req.URL || reqs[0].Body.Content != `{"a":1}` {
+		t.Fatalf("loaded %+v", reqs)
+	}
+
+	if err := Save(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("saving no requests should remove the file, got %v", err)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("requests: {"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected a parse error")
+	}
+}
@@ -0,0 +1,161 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sadopc/gottp/internal/core/environment"
+)
+
+// KeychainService is the service name used for OS keychain entries.
+const KeychainService = "gottp"
+
+// EnvProvider reads secrets from GOTTP_SECRET_<NAME> OS environment variables.
+type EnvProvider struct {
+	prefix string
+}
+
+// envPrefix starts the OS environment variables read by EnvProvider.
+const envPrefix = "GOTTP_SECRET_"
+
+// NewEnvProvider creates an env passthrough provider.
+func NewEnvProvider() *EnvProvider {
+	return &EnvProvider{prefix: envPrefix}
+}
+
+// EnvVar returns the OS environment variable EnvProvider reads for a secret
+// name, e.g. GOTTP_SECRET_PROD_API_KEY for prod-api-key.
+func EnvVar(name string) string {
+	return envPrefix + envKey(name)
+}
+
+func (p *EnvProvider) Name() string { return "env" }
+
+// Get looks up the secret, normalizing the name to an env var key.
+func (p *EnvProvider) Get(name string) (string, error) {
+	if v, ok := os.LookupEnv(p.prefix + envKey(name)); ok {
+		return v, nil
+	}
+	return "", ErrNotFound
+}
+
+// envKey upper-cases name and replaces non-alphanumeric characters with '_'.
+func envKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// FileProvider reads secrets from a YAML file mapping names to values.
+// Values may be stored encrypted with environment.EncryptValue.
+type FileProvider struct {
+	path       string
+	passphrase string
+}
+
+// NewFileProvider creates a provider backed by the given secrets file.
+func NewFileProvider(path, passphrase string) *FileProvider {
+	return &FileProvider{path: path, passphrase: passphrase}
+}
+
+func (p *FileProvider) Name() string { return "file" }
+
+// Get reads the file and returns the decrypted secret.
+func (p *FileProvider) Get(name string) (string, error) {
+	entries, err := p.load()
+	if err != nil {
+		return "", err
+	}
+	v, ok := entries[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	if environment.IsEncrypted(v) {
+		if p.passphrase == "" {
+			return "", fmt.Errorf("secret %q is encrypted but %s is not set", name, PassphraseEnv)
+		}
+		return environment.DecryptValue(v, p.passphrase)
+	}
+	return v, nil
+}
+
+// Set encrypts value with the provider passphrase and writes it to the file.
+func (p *FileProvider) Set(name, value string) error {
+	if p.passphrase == "" {
+		return fmt.Errorf("%s is required to store secrets", PassphraseEnv)
+	}
+	entries, err := p.load()
+	if err != nil {
+		return err
+	}
+	enc, err := environment.EncryptValue(value, p.passphrase)
+	if err != nil {
+		return err
+	}
+	entries[name] = enc
+	data, err := yaml.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("marshaling secrets: %w", err)
+	}
+	if err := os.WriteFile(p.path, data, 0600); err != nil {
+		return fmt.Errorf("writing secrets file: %w", err)
+	}
+	return nil
+}
+
+func (p *FileProvider) load() (map[string]string, error) {
+	entries := map[string]string{}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("reading secrets file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parsing secrets file: %w", err)
+	}
+	return entries, nil
+}
+
+// KeychainProvider reads secrets from the OS keychain (macOS Keychain,
+// Secret Service on Linux, Windows Credential Manager).
+type KeychainProvider struct {
+	service string
+}
+
+// NewKeychainProvider creates a keychain-backed provider.
+func NewKeychainProvider() *KeychainProvider {
+	return &KeychainProvider{service: KeychainService}
+}
+
+func (p *KeychainProvider) Name() string { return "keychain" }
+
+// Get looks up the secret in the keychain. An unavailable keychain is
+// reported as not found so the chain can continue.
+func (p *KeychainProvider) Get(name string) (string, error) {
+	v, err := keyring.Get(p.service, name)
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return v, nil
+}
+
+// Set stores a secret in the keychain.
+func (p *KeychainProvider) Set(name, value string) error {
+	return keyring.Set(p.service, name, value)
+}
@@ -0,0 +1,196 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sadopc/gottp/internal/core/environment"
+)
+
+// Scheme is the prefix that marks a variable value as a secret reference.
+const Scheme = "secret://"
+
+// PassphraseEnv is the OS environment variable holding the passphrase used for
+// encrypted environment values and the encrypted secrets file.
+const PassphraseEnv = "GOTTP_PASSPHRASE"
+
+// MaskString replaces secret values in masked output.
+const MaskString = "********"
+
+// ErrNotFound is returned by providers that do not hold the requested secret.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up secret values by name.
+type Provider interface {
+	Name() string
+	Get(name string) (string, error)
+}
+
+// IsRef returns true if the value is a secret:// reference.
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, Scheme)
+}
+
+// RefName returns the secret name from a secret:// reference.
+func RefName(value string) string {
+	return strings.TrimPrefix(value, Scheme)
+}
+
+// Resolver resolves secret references and encrypted values using a chain of
+// providers. It remembers every value it has resolved so they can be masked.
+// It is safe for concurrent use.
+type Resolver struct {
+	providers  []Provider
+	passphrase string
+
+	mu    sync.RWMutex
+	known map[string]struct{}
+}
+
+// NewResolver creates a resolver that queries providers in order.
+func NewResolver(passphrase string, providers ...Provider) *Resolver {
+	return &Resolver{
+		providers:  providers,
+		passphrase: passphrase,
+		known:      make(map[string]struct{}),
+	}
+}
+
+// DefaultResolver returns a resolver using OS env passthrough, the encrypted
+// secrets file in ~/.config/gottp/secrets.yaml, and the OS keychain.
+func DefaultResolver() *Resolver {
+	passphrase := os.Getenv(PassphraseEnv)
+	providers := []Provider{NewEnvProvider()}
+	if home, err := os.UserHomeDir(); err == nil {
+		path := filepath.Join(home, ".config", "gottp", "secrets.yaml")
+		providers = append(providers, NewFileProvider(path, passphrase))
+	}
+	providers = append(providers, NewKeychainProvider())
+	return NewResolver(passphrase, providers...)
+}
+
+// Lookup resolves a single secret name through the provider chain.
+func (r *Resolver) Lookup(name string) (string, error) {
+	for _, p := range r.providers {
+		v, err := p.Get(name)
+		if err == nil {
+			r.remember(v)
+			return v, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return "", fmt.Errorf("%s provider: %w", p.Name(), err)
+		}
+	}
+	return "", fmt.Errorf("secret %q: %w", name, ErrNotFound)
+}
+
+// ResolveValue resolves a secret:// reference or an encrypted value. Plain
+// values are returned unchanged.
+func (r *Resolver) ResolveValue(value string) (string, error) {
+	switch {
+	case IsRef(value):
+		return r.Lookup(RefName(value))
+	case environment.IsEncrypted(value):
+		if r.passphrase == "" {
+			return "", fmt.Errorf("encrypted value requires %s to be set", PassphraseEnv)
+		}
+		v, err := environment.DecryptValue(value, r.passphrase)
+		if err != nil {
+			return "", err
+		}
+		r.remember(v)
+		return v, nil
+	}
+	return value, nil
+}
+
+// ResolveVars returns a copy of vars with all secret references and encrypted
+// values replaced by their plaintext. The first failing variable aborts.
+// A nil resolver returns vars unchanged.
+func (r *Resolver) ResolveVars(vars map[string]string) (map[string]string, error) {
+	if r == nil {
+		return vars, nil
+	}
+	out := make(map[string]string, len(vars))
+	for k, v := range vars {
+		resolved, err := r.ResolveValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("variable %q: %w", k, err)
+		}
+		out[k] = resolved
+	}
+	return out, nil
+}
+
+// ResolveUsed is ResolveVars for the variables a request uses: those
+// referenced as {{name}} in texts, or named anywhere in scripts, which
+// read variables by name. Other values are copied unresolved, so a secret
+// that can't be read fails only the requests that need it, and providers
+// such as the keychain aren't asked for the rest.
+func (r *Resolver) ResolveUsed(vars map[string]string, texts []string, scripts ...string) (map[string]string, error) {
+	if r == nil {
+		return vars, nil
+	}
+	used := make(map[string]bool)
+	for _, name := range environment.References(texts...) {
+		used[name] = true
+	}
+	out := make(map[string]string, len(vars))
+	for k, v := range vars {
+		if used[k] || slices.ContainsFunc(scripts, func(s string) bool { return strings.Contains(s, k) }) {
+			resolved, err := r.ResolveValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("variable %q: %w", k, err)
+			}
+			v = resolved
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// Remember registers additional values to be masked, such as variables marked
+// `secret: true` in environments.yaml.
+func (r *Resolver) Remember(values ...string) {
+	if r == nil {
+		return
+	}
+	for _, v := range values {
+		r.remember(v)
+	}
+}
+
+// Mask replaces every known secret value in s with MaskString.
+func (r *Resolver) Mask(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	// Replace longer values first so overlapping secrets mask fully.
+	values := make([]string, 0, len(r.known))
+	for v := range r.known {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, v := range values {
+		s = strings.ReplaceAll(s, v, MaskString)
+	}
+	return s
+}
+
+func (r *Resolver) remember(v string) {
+	// Very short values would mask unrelated text.
+	if len(v) < 4 {
+		return
+	}
+	r.mu.Lock()
+	r.known[v] = struct{}{}
+	r.mu.Unlock()
+}
@@ -0,0 +1,208 @@
+package secrets
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/environment"
+)
+
+type mapProvider map[string]string
+
+func (p mapProvider) Name() string { return "map" }
+
+func (p mapProvider) Get(name string) (string, error) {
+	if v, ok := p[name]; ok {
+		return v, nil
+	}
+	return "", ErrNotFound
+}
+
+func TestIsRef(t *testing.T) {
+	if !IsRef("secret://api_token") {
+		t.Error("expected secret:// value to be a ref")
+	}
+	if IsRef("plain") {
+		t.Error("plain value should not be a ref")
+	}
+	if got := RefName("secret://api_token"); got != "api_token" {
+		t.Errorf("RefName = %q, want api_token", got)
+	}
+}
+
+func TestResolver_ProviderChainOrder(t *testing.T) {
+	r := NewResolver("",
+		mapProvider{"token": "first-value"},
+		mapProvider{"token": "second-value", "other": "other-value"},
+	)
+
+	v, err := r.Lookup("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "first-value" {
+		t.Errorf("expected first provider to win, got %q", v)
+	}
+
+	v, err = r.Lookup("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "other-value" {
+		t.Errorf("expected fallthrough to second provider, got %q", v)
+	}
+
+	if _, err := r.Lookup("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestResolver_ResolveVars(t *testing.T) {
+	enc, err := environment.EncryptValue("decrypted-value", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver("pass", mapProvider{"token": "s3cr3t-token"})
+
+	vars := map[string]string{
+		"base_url": "https://api.example.com",
+		"token":    "secret://token",
+		"key":      enc,
+	}
+	out, err := r.ResolveVars(vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["base_url"] != "https://api.example.com" {
+		t.Errorf("plain value changed: %q", out["base_url"])
+	}
+	if out["token"] != "s3cr3t-token" {
+		t.Errorf("token = %q", out["token"])
+	}
+	if out["key"] != "decrypted-value" {
+		t.Errorf("key = %q", out["key"])
+	}
+	if vars["token"] != "secret://token" {
+		t.Error("ResolveVars must not mutate its input")
+	}
+}
+
+// countingProvider records the names looked up.
+type countingProvider struct {
+	mapProvider
+	asked []string
+}
+
+func (p *countingProvider) Get(name string) (string, error) {
+	p.asked = append(p.asked, name)
+	return p.mapProvider.Get(name)
+}
+
+func TestResolver_ResolveUsed(t *testing.T) {
+	p := &countingProvider{mapProvider: mapProvider{"token": "s3cr3t-token", "hook": "hook-value"}}
+	r := NewResolver("", p)
+	vars := map[string]string{
+		"base_url": "https://api.example.com",
+		"token":    "secret://token",
+		"hook":     "secret://hook",
+		"broken":   "secret://missing",
+	}
+
+	// An unresolvable secret the request doesn't use is left alone
+	out, err := r.ResolveUsed(vars, []string{"{{base_url}}/users", "Bearer {{token}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["token"] != "s3cr3t-token" || out["broken"] != "secret://missing" || out["hook"] != "secret://hook" {
+		t.Errorf("out = %v", out)
+	}
+	if len(p.asked) != 1 || p.asked[0] != "token" {
+		t.Errorf("providers asked for %v, want only token", p.asked)
+	}
+
+	// Scripts read variables by name
+	out, err = r.ResolveUsed(vars, nil, `gottp.setHeader("X-Hook", gottp.getEnvVar("hook"))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["hook"] != "hook-value" {
+		t.Errorf("hook = %q", out["hook"])
+	}
+
+	if _, err := r.ResolveUsed(vars, []string{"{{broken}}"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a used missing secret, got %v", err)
+	}
+}
+
+func TestResolver_EncryptedWithoutPassphrase(t *testing.T) {
+	enc, _ := environment.EncryptValue("value", "pass")
+	r := NewResolver("")
+	if _, err := r.ResolveVars(map[string]string{"k": enc}); err == nil {
+		t.Fatal("expected error without passphrase")
+	}
+}
+
+func TestResolver_Mask(t *testing.T) {
+	r := NewResolver("", mapProvider{"token": "s3cr3t-token"})
+	if _, err := r.Lookup("token"); err != nil {
+		t.Fatal(err)
+	}
+	r.Remember("abc", "another-secret")
+
+	got := r.Mask("Authorization: Bearer s3cr3t-token; key=another-secret; abc")
+	if strings.Contains(got, "s3cr3t-token") || strings.Contains(got, "another-secret") {
+		t.Errorf("secrets not masked: %q", got)
+	}
+	if !strings.HasSuffix(got, "abc") {
+		t.Errorf("short values should not be masked: %q", got)
+	}
+
+	var nilResolver *Resolver
+	if nilResolver.Mask("text") != "text" {
+		t.Error("nil resolver should return input unchanged")
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("GOTTP_SECRET_API_TOKEN", "from-env")
+	p := NewEnvProvider()
+	v, err := p.Get("api-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "from-env" {
+		t.Errorf("got %q", v)
+	}
+	if _, err := p.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if got := EnvVar("api-token"); got != "GOTTP_SECRET_API_TOKEN" {
+		t.Errorf("EnvVar = %q", got)
+	}
+}
+
+func TestFileProvider_SetGet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	p := NewFileProvider(path, "passphrase")
+
+	if _, err := p.Get("token"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for missing file, got %v", err)
+	}
+	if err := p.Set("token", "file-secret"); err != nil {
+		t.Fatal(err)
+	}
+	v, err := p.Get("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "file-secret" {
+		t.Errorf("got %q", v)
+	}
+
+	wrong := NewFileProvider(path, "wrong")
+	if _, err := wrong.Get("token"); err == nil {
+		t.Error("expected decryption error with wrong passphrase")
+	}
+}
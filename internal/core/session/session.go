@@ -0,0 +1,88 @@
+// Package session remembers the TUI's open tabs and layout per collection,
+// so gottp reopens where it was left.
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileName is the sessions file's name in the data directory.
+const FileName = "sessions.json"
+
+// Session is the state of the TUI when it last exited with a collection.
+type Session struct {
+	Tabs          []Tab  `json:"tabs"`
+	ActiveTab     int    `json:"active_tab"`
+	Env           string `json:"env,omitempty"`
+	SidebarHidden bool   `json:"sidebar_hidden,omitempty"`
+	Focus         string `json:"focus,omitempty"` // sidebar, editor or response
+}
+
+// Tab is an open tab: the ID of its request, from the collection or the
+// scratch requests, and the environment it is pinned to, if any.
+type Tab struct {
+	RequestID string `json:"request_id"`
+	Env       string `json:"env,omitempty"`
+}
+
+// Key returns the key sessions are stored under for the collection at
+// colPath: its absolute path.
+func Key(colPath string) string {
+	if abs, err := filepath.Abs(colPath); err == nil {
+		return abs
+	}
+	return colPath
+}
+
+// Load returns the session saved in the file at path for key.
+func Load(path, key string) (Session, bool, error) {
+	all, err := readAll(path)
+	if err != nil {
+		return Session{}, false, err
+	}
+	s, ok := all[key]
+	return s, ok, nil
+}
+
+// Save stores s for key in the file at path, keeping the sessions of
+// other collections that still exist.
+func Save(path, key string, s Session) error {
+	all, err := readAll(path)
+	if err != nil {
+		// Start over rather than never saving again
+		all = map[string]Session{}
+	}
+	for k := range all {
+		if _, err := os.Stat(k); err != nil {
+			delete(all, k)
+		}
+	}
+	all[key] = s
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing sessions: %w", err)
+	}
+	return nil
+}
+
+func readAll(path string) (map[string]Session, error) {
+	all := map[string]Session{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return all, nil
+}
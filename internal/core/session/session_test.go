@@ -0,0 +1,59 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	colA := filepath.Join(dir, "a.gottp.yaml")
+	colB := filepath.Join(dir, "b.gottp.yaml")
+	for _, p := range []string{colA, colB} {
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, ok, err := Load(path, Key(colA)); err != nil || ok {
+		t.Fatalf("Load from a missing file = %v, %v", ok, err)
+	}
+	a := Session{Tabs: []Tab{{RequestID: "r1"}, {RequestID: "r2", Env: "prod"}}, ActiveTab: 1, Env: "dev", SidebarHidden: true, Focus: "response"}
+	if err := Save(path, Key(colA), a); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, Key(colB), Session{Tabs: []Tab{{RequestID: "r3"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok, err := Load(path, Key(colA))
+	if err != nil || !ok {
+		t.Fatalf("Load = %v, %v", ok, err)
+	}
+	if len(got.Tabs) != 2 || got.Tabs[1].Env != "prod" || got.ActiveTab != 1 || got.Env != "dev" || !got.SidebarHidden || got.Focus != "response" {
+		t.Errorf("got %+v", got)
+	}
+
+	// Sessions of collections that no longer exist are dropped on save
+	if err := os.Remove(colB); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, Key(colA), a); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := Load(path, Key(colB)); ok {
+		t.Error("session of a deleted collection should be pruned")
+	}
+}
+
+func TestKey(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Key("api.gottp.yaml"); got != filepath.Join(wd, "api.gottp.yaml") {
+		t.Errorf("Key = %q", got)
+	}
+}
@@ -8,6 +8,14 @@ import (
 type OpenTab struct {
 	Request  *collection.Request
 	Modified bool
+
+	// Env pins the tab to an environment other than the global one. EnvVars
+	// holds its variables, including changes made by scripts.
+	Env     string
+	EnvVars map[string]string
+
+	// History undoes and redoes edits made in the tab
+	History *EditHistory
 }
 
 // Store holds the central application state.
@@ -15,6 +23,10 @@ type Store struct {
 	Collection     *collection.Collection
 	CollectionPath string
 
+	// CollectionHash fingerprints the collection as last loaded or saved,
+	// so saves that would not change the file can be skipped.
+	CollectionHash string
+
 	ActiveEnv string
 	EnvVars   map[string]string
 
@@ -62,6 +74,42 @@ func (s *Store) CloseTab() {
 	}
 }
 
+// CloseRequestTabs closes the tabs showing any of reqs, keeping the active
+// tab on the same request when it stays open.
+func (s *Store) CloseRequestTabs(reqs []*collection.Request) {
+	closing := make(map[*collection.Request]bool)
+	for _, req := range reqs {
+		closing[req] = true
+	}
+	active := s.ActiveRequest()
+	tabs := s.Tabs[:0]
+	for _, tab := range s.Tabs {
+		if !closing[tab.Request] {
+			tabs = append(tabs, tab)
+		}
+	}
+	s.Tabs = tabs
+	s.ActiveTab = min(s.ActiveTab, len(s.Tabs)-1)
+	for i, tab := range s.Tabs {
+		if tab.Request == active {
+			s.ActiveTab = i
+		}
+	}
+}
+
+// ActiveHistory returns the edit history of the active tab, or nil if no
+// tab is open.
+func (s *Store) ActiveHistory() *EditHistory {
+	if s.ActiveTab < 0 || s.ActiveTab >= len(s.Tabs) {
+		return nil
+	}
+	tab := &s.Tabs[s.ActiveTab]
+	if tab.History == nil {
+		tab.History = &EditHistory{}
+	}
+	return tab.History
+}
+
 // NewTab creates a new empty request tab.
 func (s *Store) NewTab() {
 	req := collection.NewRequest("New Request", "GET", "")
@@ -84,3 +132,41 @@ func (s *Store) PrevTab() {
 	}
 	s.ActiveTab = (s.ActiveTab - 1 + len(s.Tabs)) % len(s.Tabs)
 }
+
+// EffectiveEnv returns the environment in effect for the active tab: its
+// pinned environment, or the global one.
+func (s *Store) EffectiveEnv() string {
+	if s.ActiveTab >= 0 && s.ActiveTab < len(s.Tabs) && s.Tabs[s.ActiveTab].Env != "" {
+		return s.Tabs[s.ActiveTab].Env
+	}
+	return s.ActiveEnv
+}
+
+// EffectiveVars returns the environment variables in effect for the active
+// tab.
+func (s *Store) EffectiveVars() map[string]string {
+	if s.ActiveTab >= 0 && s.ActiveTab < len(s.Tabs) && s.Tabs[s.ActiveTab].Env != "" {
+		return s.Tabs[s.ActiveTab].EnvVars
+	}
+	return s.EnvVars
+}
+
+// TabPinned reports whether the active tab is pinned to an environment.
+func (s *Store) TabPinned() bool {
+	return s.ActiveTab >= 0 && s.ActiveTab < len(s.Tabs) && s.Tabs[s.ActiveTab].Env != ""
+}
+
+// PinEnv pins the active tab to the named environment with vars. An empty
+// name unpins it so it follows the global environment again.
+func (s *Store) PinEnv(name string, vars map[string]string) {
+	if s.ActiveTab < 0 || s.ActiveTab >= len(s.Tabs) {
+		return
+	}
+	if name == "" {
+		vars = nil
+	} else if vars == nil {
+		vars = make(map[string]string)
+	}
+	s.Tabs[s.ActiveTab].Env = name
+	s.Tabs[s.ActiveTab].EnvVars = vars
+}
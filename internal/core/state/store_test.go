@@ -165,3 +165,51 @@ func TestNextPrevTabNoopWhenEmpty(t *testing.T) {
 		t.Fatalf("ActiveTab = %d, want unchanged zero value", s.ActiveTab)
 	}
 }
+
+func TestPinEnvOverridesGlobalEnvPerTab(t *testing.T) {
+	s := NewStore()
+	s.ActiveEnv = "staging"
+	s.EnvVars = map[string]string{"base_url": "https://staging"}
+
+	s.OpenRequest(&collection.Request{ID: "req-1"})
+	s.OpenRequest(&collection.Request{ID: "req-2"})
+	s.PinEnv("production", map[string]string{"base_url": "https://prod"})
+
+	if !s.TabPinned() || s.EffectiveEnv() != "production" || s.EffectiveVars()["base_url"] != "https://prod" {
+		t.Fatalf("pinned tab should use production, got %q %v", s.EffectiveEnv(), s.EffectiveVars())
+	}
+
+	s.PrevTab()
+	if s.TabPinned() || s.EffectiveEnv() != "staging" || s.EffectiveVars()["base_url"] != "https://staging" {
+		t.Fatalf("unpinned tab should follow the global env, got %q %v", s.EffectiveEnv(), s.EffectiveVars())
+	}
+
+	s.NextTab()
+	s.PinEnv("", nil)
+	if s.TabPinned() || s.EffectiveEnv() != "staging" {
+		t.Fatalf("unpinning should restore the global env, got %q", s.EffectiveEnv())
+	}
+}
+
+func TestCloseRequestTabs(t *testing.T) {
+	s := NewStore()
+	a := &collection.Request{ID: "a"}
+	b := &collection.Request{ID: "b"}
+	c := &collection.Request{ID: "c"}
+	s.OpenRequest(a)
+	s.OpenRequest(b)
+	s.OpenRequest(c)
+
+	s.CloseRequestTabs([]*collection.Request{a})
+	if len(s.Tabs) != 2 || s.ActiveRequest() != c {
+		t.Fatalf("active request should stay on c, got %+v", s.ActiveRequest())
+	}
+	s.CloseRequestTabs([]*collection.Request{c})
+	if len(s.Tabs) != 1 || s.ActiveRequest() != b {
+		t.Fatalf("expected b active, got %+v", s.ActiveRequest())
+	}
+	s.CloseRequestTabs([]*collection.Request{b})
+	if len(s.Tabs) != 0 || s.ActiveRequest() != nil {
+		t.Fatalf("expected no tabs, got %d", len(s.Tabs))
+	}
+}
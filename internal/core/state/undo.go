@@ -0,0 +1,63 @@
+package state
+
+import (
+	"reflect"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+// maxUndo caps the snapshots kept per tab.
+const maxUndo = 100
+
+// EditHistory holds snapshots of a tab's request, as shown in the editor,
+// so edits can be undone and redone. Snapshots are complete copies that
+// the caller must not modify.
+type EditHistory struct {
+	current *collection.Request
+	undo    []*collection.Request
+	redo    []*collection.Request
+}
+
+// Record notes snap as the request's latest state and reports whether it
+// differs from the previous one, which then becomes undoable. Recording a
+// change discards what could be redone.
+func (h *EditHistory) Record(snap *collection.Request) bool {
+	if h.current == nil {
+		h.current = snap
+		return false
+	}
+	if reflect.DeepEqual(h.current, snap) {
+		return false
+	}
+	h.undo = append(h.undo, h.current)
+	if len(h.undo) > maxUndo {
+		h.undo = h.undo[len(h.undo)-maxUndo:]
+	}
+	h.current = snap
+	h.redo = nil
+	return true
+}
+
+// Undo steps back to the previous snapshot and returns it, or nil if there
+// is none.
+func (h *EditHistory) Undo() *collection.Request {
+	if len(h.undo) == 0 {
+		return nil
+	}
+	h.redo = append(h.redo, h.current)
+	h.current = h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	return h.current
+}
+
+// Redo reapplies the last undone snapshot and returns it, or nil if there
+// is none.
+func (h *EditHistory) Redo() *collection.Request {
+	if len(h.redo) == 0 {
+		return nil
+	}
+	h.undo = append(h.undo, h.current)
+	h.current = h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	return h.current
+}
@@ -0,0 +1,79 @@
+package state
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+func snapshot(url string) *collection.Request {
+	return &collection.Request{ID: "1", Name: "Users", Method: "GET", URL: url}
+}
+
+func TestEditHistory(t *testing.T) {
+	var h EditHistory
+	if h.Record(snapshot("/v1")) {
+		t.Error("the first snapshot is the starting point, not a change")
+	}
+	if h.Undo() != nil || h.Redo() != nil {
+		t.Fatal("nothing to undo or redo yet")
+	}
+	if h.Record(snapshot("/v1")) {
+		t.Error("an unchanged snapshot should not be recorded")
+	}
+	h.Record(snapshot("/v2"))
+	h.Record(snapshot("/v3"))
+
+	if got := h.Undo(); got == nil || got.URL != "/v2" {
+		t.Fatalf("undo: got %+v", got)
+	}
+	if got := h.Undo(); got == nil || got.URL != "/v1" {
+		t.Fatalf("second undo: got %+v", got)
+	}
+	if h.Undo() != nil {
+		t.Error("undo past the start")
+	}
+	if got := h.Redo(); got == nil || got.URL != "/v2" {
+		t.Fatalf("redo: got %+v", got)
+	}
+
+	// A new edit discards the redo branch
+	h.Record(snapshot("/v4"))
+	if h.Redo() != nil {
+		t.Error("redo should be cleared by a new edit")
+	}
+	if got := h.Undo(); got == nil || got.URL != "/v2" {
+		t.Fatalf("undo after branching: got %+v", got)
+	}
+}
+
+func TestEditHistoryLimit(t *testing.T) {
+	var h EditHistory
+	for i := 0; i <= maxUndo+10; i++ {
+		h.Record(snapshot("/" + strconv.Itoa(i)))
+	}
+	n := 0
+	for h.Undo() != nil {
+		n++
+	}
+	if n != maxUndo {
+		t.Errorf("kept %d undo steps, want %d", n, maxUndo)
+	}
+}
+
+func TestActiveHistory(t *testing.T) {
+	s := NewStore()
+	if s.ActiveHistory() != nil {
+		t.Error("no tab, no history")
+	}
+	s.NewTab()
+	h := s.ActiveHistory()
+	if h == nil || s.ActiveHistory() != h {
+		t.Error("the tab should keep one history")
+	}
+	s.NewTab()
+	if s.ActiveHistory() == h {
+		t.Error("each tab has its own history")
+	}
+}
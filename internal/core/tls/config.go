@@ -5,6 +5,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"os"
+	"path/filepath"
 )
 
 // Config holds TLS configuration for mTLS and certificate management.
@@ -13,6 +14,17 @@ type Config struct {
 	KeyFile            string `yaml:"key_file,omitempty"`
 	CAFile             string `yaml:"ca_file,omitempty"`
 	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
+	MinVersion         string `yaml:"min_version,omitempty"` // "1.0", "1.1", "1.2" or "1.3"
+}
+
+// Versions lists the accepted MinVersion values.
+var Versions = []string{"1.0", "1.1", "1.2", "1.3"}
+
+var versionIDs = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
 }
 
 // BuildTLSConfig creates a *tls.Config from the configuration.
@@ -25,6 +37,14 @@ func (c *Config) BuildTLSConfig() (*tls.Config, error) {
 		InsecureSkipVerify: c.InsecureSkipVerify,
 	}
 
+	if c.MinVersion != "" {
+		v, ok := versionIDs[c.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown TLS version %q (want 1.0, 1.1, 1.2 or 1.3)", c.MinVersion)
+		}
+		tlsConfig.MinVersion = v
+	}
+
 	// Load client certificate for mTLS
 	if c.CertFile != "" && c.KeyFile != "" {
 		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
@@ -34,13 +54,16 @@ func (c *Config) BuildTLSConfig() (*tls.Config, error) {
 		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
 
-	// Load CA certificate
+	// Load CA certificate, trusted in addition to the system roots
 	if c.CAFile != "" {
 		caCert, err := os.ReadFile(c.CAFile)
 		if err != nil {
 			return nil, fmt.Errorf("reading CA cert: %w", err)
 		}
-		pool := x509.NewCertPool()
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
 		if !pool.AppendCertsFromPEM(caCert) {
 			return nil, fmt.Errorf("failed to parse CA cert")
 		}
@@ -55,5 +78,40 @@ func (c *Config) IsEmpty() bool {
 	if c == nil {
 		return true
 	}
-	return c.CertFile == "" && c.KeyFile == "" && c.CAFile == "" && !c.InsecureSkipVerify
+	return c.CertFile == "" && c.KeyFile == "" && c.CAFile == "" && !c.InsecureSkipVerify && c.MinVersion == ""
+}
+
+// Merge returns c with the non-empty settings of over applied on top.
+// InsecureSkipVerify is enabled if either enables it. Either may be nil.
+func (c *Config) Merge(over *Config) *Config {
+	var out Config
+	if c != nil {
+		out = *c
+	}
+	if over == nil {
+		return &out
+	}
+	if over.CertFile != "" || over.KeyFile != "" {
+		out.CertFile, out.KeyFile = over.CertFile, over.KeyFile
+	}
+	if over.CAFile != "" {
+		out.CAFile = over.CAFile
+	}
+	if over.MinVersion != "" {
+		out.MinVersion = over.MinVersion
+	}
+	out.InsecureSkipVerify = out.InsecureSkipVerify || over.InsecureSkipVerify
+	return &out
+}
+
+// ResolvePaths rewrites relative certificate paths to be relative to dir.
+func (c *Config) ResolvePaths(dir string) {
+	if c == nil || dir == "" {
+		return
+	}
+	for _, p := range []*string{&c.CertFile, &c.KeyFile, &c.CAFile} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
 }
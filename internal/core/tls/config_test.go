@@ -4,6 +4,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
@@ -165,3 +166,43 @@ func TestIsEmpty(t *testing.T) {
 		t.Error("config with cert files should not be empty")
 	}
 }
+
+func TestBuildTLSConfig_MinVersion(t *testing.T) {
+	tlsCfg, err := (&Config{MinVersion: "1.3"}).BuildTLSConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tlsCfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("MinVersion = %x, want TLS 1.3", tlsCfg.MinVersion)
+	}
+	if _, err := (&Config{MinVersion: "1.4"}).BuildTLSConfig(); err == nil {
+		t.Error("expected error for unknown TLS version")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	global := &Config{CAFile: "global-ca.pem", MinVersion: "1.2"}
+	env := &Config{CertFile: "env.pem", KeyFile: "env.key", InsecureSkipVerify: true}
+	req := &Config{CAFile: "req-ca.pem"}
+
+	got := global.Merge(env).Merge(req)
+	want := Config{CertFile: "env.pem", KeyFile: "env.key", CAFile: "req-ca.pem", MinVersion: "1.2", InsecureSkipVerify: true}
+	if *got != want {
+		t.Errorf("Merge = %+v, want %+v", *got, want)
+	}
+	if global.CAFile != "global-ca.pem" {
+		t.Error("Merge must not modify its receiver")
+	}
+	var none *Config
+	if !none.Merge(nil).IsEmpty() {
+		t.Error("merging nil configs should be empty")
+	}
+}
+
+func TestResolvePaths(t *testing.T) {
+	cfg := &Config{CertFile: "certs/client.pem", CAFile: "/etc/ca.pem"}
+	cfg.ResolvePaths("/work")
+	if cfg.CertFile != filepath.Join("/work", "certs/client.pem") || cfg.CAFile != "/etc/ca.pem" || cfg.KeyFile != "" {
+		t.Errorf("unexpected paths: %+v", cfg)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/sadopc/gottp/internal/protocol"
@@ -21,11 +22,19 @@ const (
 	LangJava       Language = "java"
 	LangRust       Language = "rust"
 	LangPHP        Language = "php"
+	LangCSharp     Language = "csharp"
+	LangKotlin     Language = "kotlin"
+	LangSwift      Language = "swift"
+	LangPowerShell Language = "powershell"
+	LangHTTPie     Language = "httpie"
 )
 
 // Languages returns all supported languages.
 func Languages() []Language {
-	return []Language{LangGo, LangPython, LangJavaScript, LangCurl, LangRuby, LangJava, LangRust, LangPHP}
+	return []Language{
+		LangGo, LangPython, LangJavaScript, LangCurl, LangRuby, LangJava, LangRust, LangPHP,
+		LangCSharp, LangKotlin, LangSwift, LangPowerShell, LangHTTPie,
+	}
 }
 
 // Generate generates a code snippet for the given request in the specified language.
@@ -47,24 +56,36 @@ func Generate(req *protocol.Request, lang Language) (string, error) {
 		return generateRust(req), nil
 	case LangPHP:
 		return generatePHP(req), nil
+	case LangCSharp:
+		return generateCSharp(req), nil
+	case LangKotlin:
+		return generateKotlin(req), nil
+	case LangSwift:
+		return generateSwift(req), nil
+	case LangPowerShell:
+		return generatePowerShell(req), nil
+	case LangHTTPie:
+		return generateHTTPie(req), nil
 	default:
 		return "", fmt.Errorf("unsupported language: %s", lang)
 	}
 }
 
 func buildFullURL(req *protocol.Request) string {
-	u := req.URL
-	if len(req.Params) > 0 {
-		params := url.Values{}
-		keys := sortedKeys(req.Params)
-		for _, k := range keys {
-			params.Set(k, req.Params[k])
+	return withQuery(req.URL, req.Params)
+}
+
+func withQuery(u string, params map[string]string) string {
+	if len(params) > 0 {
+		values := url.Values{}
+		for _, k := range sortedKeys(params) {
+			values.Set(k, params[k])
 		}
 		sep := "?"
 		if strings.Contains(u, "?") {
 			sep = "&"
 		}
-		u += sep + params.Encode()
+		u += sep + values.Encode()
 	}
 	return u
 }
@@ -424,3 +445,268 @@ func generatePHP(req *protocol.Request) string {
 
 	return b.String()
 }
+
+// requestParts returns the headers and query params of req with an API key
+// auth added to whichever it goes in, and the Content-Type header split
+// out for languages that set it on the body.
+func requestParts(req *protocol.Request) (headers, params map[string]string, contentType string) {
+	headers = make(map[string]string, len(req.Headers)+1)
+	for k, v := range req.Headers {
+		if strings.EqualFold(k, "Content-Type") {
+			contentType = v
+			continue
+		}
+		headers[k] = v
+	}
+	params = make(map[string]string, len(req.Params)+1)
+	for k, v := range req.Params {
+		params[k] = v
+	}
+	if req.Auth != nil && req.Auth.Type == "apikey" && req.Auth.APIKey != "" {
+		if req.Auth.APIIn == "query" {
+			params[req.Auth.APIKey] = req.Auth.APIValue
+		} else {
+			headers[req.Auth.APIKey] = req.Auth.APIValue
+		}
+	}
+	return headers, params, contentType
+}
+
+func generateCSharp(req *protocol.Request) string {
+	var b strings.Builder
+	headers, params, contentType := requestParts(req)
+
+	b.WriteString("using System;\n")
+	b.WriteString("using System.Net.Http;\n")
+	b.WriteString("using System.Net.Http.Headers;\n")
+	b.WriteString("using System.Text;\n\n")
+
+	b.WriteString("using var client = new HttpClient();\n")
+	b.WriteString(fmt.Sprintf("var request = new HttpRequestMessage(new HttpMethod(%q), %q);\n",
+		req.Method, withQuery(req.URL, params)))
+
+	for _, k := range sortedKeys(headers) {
+		b.WriteString(fmt.Sprintf("request.Headers.TryAddWithoutValidation(%q, %q);\n", k, headers[k]))
+	}
+
+	if req.Auth != nil {
+		switch req.Auth.Type {
+		case "basic":
+			b.WriteString(fmt.Sprintf("request.Headers.Authorization = new AuthenticationHeaderValue(\"Basic\",\n"+
+				"    Convert.ToBase64String(Encoding.UTF8.GetBytes(%q)));\n", req.Auth.Username+":"+req.Auth.Password))
+		case "bearer":
+			b.WriteString(fmt.Sprintf("request.Headers.Authorization = new AuthenticationHeaderValue(\"Bearer\", %q);\n", req.Auth.Token))
+		}
+	}
+
+	if len(req.Body) > 0 {
+		b.WriteString(fmt.Sprintf("request.Content = new StringContent(%q, Encoding.UTF8);\n", string(req.Body)))
+		if contentType != "" {
+			b.WriteString(fmt.Sprintf("request.Content.Headers.ContentType = MediaTypeHeaderValue.Parse(%q);\n", contentType))
+		}
+	}
+
+	b.WriteString("\nvar response = await client.SendAsync(request);\n")
+	b.WriteString("Console.WriteLine((int)response.StatusCode);\n")
+	b.WriteString("Console.WriteLine(await response.Content.ReadAsStringAsync());\n")
+
+	return b.String()
+}
+
+// kotlinString quotes s for Kotlin, where $ starts a template.
+func kotlinString(s string) string {
+	return strings.ReplaceAll(strconv.Quote(s), "$", `\$`)
+}
+
+func generateKotlin(req *protocol.Request) string {
+	var b strings.Builder
+	headers, params, contentType := requestParts(req)
+	hasBody := len(req.Body) > 0
+	basic := req.Auth != nil && req.Auth.Type == "basic"
+
+	if basic {
+		b.WriteString("import okhttp3.Credentials\n")
+	}
+	if hasBody && contentType != "" {
+		b.WriteString("import okhttp3.MediaType.Companion.toMediaType\n")
+	}
+	b.WriteString("import okhttp3.OkHttpClient\n")
+	b.WriteString("import okhttp3.Request\n")
+	if hasBody || (req.Method != "GET" && req.Method != "HEAD" && req.Method != "DELETE") {
+		b.WriteString("import okhttp3.RequestBody.Companion.toRequestBody\n")
+	}
+	b.WriteString("\nfun main() {\n")
+	b.WriteString("    val client = OkHttpClient()\n\n")
+
+	switch {
+	case hasBody && contentType != "":
+		b.WriteString(fmt.Sprintf("    val body = %s.toRequestBody(%s.toMediaType())\n",
+			kotlinString(string(req.Body)), kotlinString(contentType)))
+	case hasBody:
+		b.WriteString(fmt.Sprintf("    val body = %s.toRequestBody()\n", kotlinString(string(req.Body))))
+	}
+
+	b.WriteString("    val request = Request.Builder()\n")
+	b.WriteString(fmt.Sprintf("        .url(%s)\n", kotlinString(withQuery(req.URL, params))))
+	switch {
+	case hasBody:
+		b.WriteString(fmt.Sprintf("        .method(%q, body)\n", req.Method))
+	case req.Method == "GET":
+	case req.Method == "HEAD":
+		b.WriteString("        .head()\n")
+	case req.Method == "DELETE":
+		b.WriteString("        .delete()\n")
+	default:
+		// OkHttp requires a body for POST, PUT and PATCH.
+		b.WriteString(fmt.Sprintf("        .method(%q, \"\".toRequestBody())\n", req.Method))
+	}
+
+	for _, k := range sortedKeys(headers) {
+		b.WriteString(fmt.Sprintf("        .header(%s, %s)\n", kotlinString(k), kotlinString(headers[k])))
+	}
+	if req.Auth != nil {
+		switch req.Auth.Type {
+		case "basic":
+			b.WriteString(fmt.Sprintf("        .header(\"Authorization\", Credentials.basic(%s, %s))\n",
+				kotlinString(req.Auth.Username), kotlinString(req.Auth.Password)))
+		case "bearer":
+			b.WriteString(fmt.Sprintf("        .header(\"Authorization\", %s)\n", kotlinString("Bearer "+req.Auth.Token)))
+		}
+	}
+	b.WriteString("        .build()\n\n")
+
+	b.WriteString("    client.newCall(request).execute().use { response ->\n")
+	b.WriteString("        println(response.code)\n")
+	b.WriteString("        println(response.body?.string())\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+func generateSwift(req *protocol.Request) string {
+	var b strings.Builder
+	headers, params, contentType := requestParts(req)
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+
+	b.WriteString("import Foundation\n\n")
+	b.WriteString(fmt.Sprintf("var request = URLRequest(url: URL(string: %q)!)\n", withQuery(req.URL, params)))
+	b.WriteString(fmt.Sprintf("request.httpMethod = %q\n", req.Method))
+
+	for _, k := range sortedKeys(headers) {
+		b.WriteString(fmt.Sprintf("request.setValue(%q, forHTTPHeaderField: %q)\n", headers[k], k))
+	}
+
+	if req.Auth != nil {
+		switch req.Auth.Type {
+		case "basic":
+			b.WriteString(fmt.Sprintf("let credentials = Data(%q.utf8).base64EncodedString()\n", req.Auth.Username+":"+req.Auth.Password))
+			b.WriteString("request.setValue(\"Basic \\(credentials)\", forHTTPHeaderField: \"Authorization\")\n")
+		case "bearer":
+			b.WriteString(fmt.Sprintf("request.setValue(%q, forHTTPHeaderField: \"Authorization\")\n", "Bearer "+req.Auth.Token))
+		}
+	}
+
+	if len(req.Body) > 0 {
+		b.WriteString(fmt.Sprintf("request.httpBody = Data(%q.utf8)\n", string(req.Body)))
+	}
+
+	b.WriteString("\nlet (data, response) = try await URLSession.shared.data(for: request)\n")
+	b.WriteString("print((response as! HTTPURLResponse).statusCode)\n")
+	b.WriteString("print(String(decoding: data, as: UTF8.self))\n")
+
+	return b.String()
+}
+
+// powerShellString quotes s as a PowerShell literal string, which expands
+// nothing and doubles its single quotes.
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func generatePowerShell(req *protocol.Request) string {
+	var b strings.Builder
+	headers, params, contentType := requestParts(req)
+
+	// The basic credentials are encoded by the script, so their header is
+	// the one value written as an expanding string.
+	basic := req.Auth != nil && req.Auth.Type == "basic"
+	if basic {
+		b.WriteString(fmt.Sprintf("$credentials = [Convert]::ToBase64String([Text.Encoding]::UTF8.GetBytes(%s))\n",
+			powerShellString(req.Auth.Username+":"+req.Auth.Password)))
+	}
+	if req.Auth != nil && req.Auth.Type == "bearer" {
+		headers["Authorization"] = "Bearer " + req.Auth.Token
+	}
+
+	hasHeaders := len(headers) > 0 || basic
+	if hasHeaders {
+		b.WriteString("$headers = @{\n")
+		for _, k := range sortedKeys(headers) {
+			b.WriteString(fmt.Sprintf("    %s = %s\n", powerShellString(k), powerShellString(headers[k])))
+		}
+		if basic {
+			b.WriteString("    'Authorization' = \"Basic $credentials\"\n")
+		}
+		b.WriteString("}\n")
+	}
+	if len(req.Body) > 0 {
+		b.WriteString(fmt.Sprintf("$body = %s\n", powerShellString(string(req.Body))))
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+
+	args := []string{
+		"-Uri " + powerShellString(withQuery(req.URL, params)),
+		"-Method " + powerShellString(req.Method),
+	}
+	if hasHeaders {
+		args = append(args, "-Headers $headers")
+	}
+	if contentType != "" {
+		args = append(args, "-ContentType "+powerShellString(contentType))
+	}
+	if len(req.Body) > 0 {
+		args = append(args, "-Body $body")
+	}
+	b.WriteString("$response = Invoke-RestMethod " + strings.Join(args, " `\n    ") + "\n")
+	b.WriteString("$response | ConvertTo-Json -Depth 10\n")
+
+	return b.String()
+}
+
+// shellQuote quotes s in single quotes for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func generateHTTPie(req *protocol.Request) string {
+	parts := []string{"http"}
+	if len(req.Body) > 0 {
+		parts = append(parts, "--raw "+shellQuote(string(req.Body)))
+	}
+	if req.Auth != nil {
+		switch req.Auth.Type {
+		case "basic":
+			parts = append(parts, "--auth "+shellQuote(req.Auth.Username+":"+req.Auth.Password))
+		case "bearer":
+			parts = append(parts, "--auth-type bearer --auth "+shellQuote(req.Auth.Token))
+		}
+	}
+	parts = append(parts, req.Method+" "+shellQuote(req.URL))
+
+	headers, params, contentType := requestParts(req)
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+	for _, k := range sortedKeys(headers) {
+		parts = append(parts, shellQuote(k+":"+headers[k]))
+	}
+	for _, k := range sortedKeys(params) {
+		parts = append(parts, shellQuote(k+"=="+params[k]))
+	}
+	return strings.Join(parts, " \\\n  ") + "\n"
+}
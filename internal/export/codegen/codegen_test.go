@@ -1,6 +1,9 @@
 package codegen
 
 import (
+	"flag"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -117,7 +120,70 @@ func TestGenerateUnsupportedLanguage(t *testing.T) {
 
 func TestLanguages(t *testing.T) {
 	langs := Languages()
-	if len(langs) != 8 {
-		t.Errorf("expected 8 languages, got %d", len(langs))
+	if len(langs) != 13 {
+		t.Errorf("expected 13 languages, got %d", len(langs))
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestGenerateGolden compares the generators against testdata/<lang>_<case>.golden.
+// Run with -update to rewrite them after an intended change.
+func TestGenerateGolden(t *testing.T) {
+	cases := map[string]*protocol.Request{
+		"post_basic": {
+			Method:  "POST",
+			URL:     "https://api.example.com/users",
+			Headers: map[string]string{"Content-Type": "application/json", "X-Trace": "it's $HOME"},
+			Params:  map[string]string{"notify": "true"},
+			Body:    []byte(`{"name":"O'Brien","cost":"$5"}`),
+			Auth:    &protocol.AuthConfig{Type: "basic", Username: "admin", Password: "s3cret"},
+		},
+		"get_bearer": {
+			Method: "GET",
+			URL:    "https://api.example.com/search",
+			Params: map[string]string{"q": "hello world", "page": "2"},
+			Auth:   &protocol.AuthConfig{Type: "bearer", Token: "tok-123"},
+		},
+		"delete_apikey": {
+			Method:  "DELETE",
+			URL:     "https://api.example.com/users/42",
+			Headers: map[string]string{"Accept": "application/json"},
+			Auth:    &protocol.AuthConfig{Type: "apikey", APIKey: "api_key", APIValue: "k-456", APIIn: "query"},
+		},
+		"put_apikey_header": {
+			Method:  "PUT",
+			URL:     "https://api.example.com/users/42",
+			Headers: map[string]string{"Content-Type": "text/plain"},
+			Body:    []byte("line one\nline two"),
+			Auth:    &protocol.AuthConfig{Type: "apikey", APIKey: "X-API-Key", APIValue: "k-789"},
+		},
+	}
+	for _, lang := range []Language{LangCSharp, LangKotlin, LangSwift, LangPowerShell, LangHTTPie} {
+		for name, req := range cases {
+			t.Run(string(lang)+"/"+name, func(t *testing.T) {
+				code, err := Generate(req, lang)
+				if err != nil {
+					t.Fatalf("Generate(%s) error: %v", lang, err)
+				}
+				path := filepath.Join("testdata", string(lang)+"_"+name+".golden")
+				if *update {
+					if err := os.MkdirAll("testdata", 0755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("reading golden file (run with -update to create it): %v", err)
+				}
+				if code != string(want) {
+					t.Errorf("Generate(%s) differs from %s:\n--- got ---\n%s\n--- want ---\n%s", lang, path, code, want)
+				}
+			})
+		}
 	}
 }
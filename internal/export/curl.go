@@ -36,10 +36,32 @@ func AsCurl(req *protocol.Request) string {
 		}
 	}
 
+	// Upload framing
+	if req.HasBody() && req.ExpectContinue {
+		parts = append(parts, "-H", "'Expect: 100-continue'")
+	}
+	if req.HasBody() && req.Chunked {
+		parts = append(parts, "-H", "'Transfer-Encoding: chunked'")
+	}
+
 	// Body
-	if len(req.Body) > 0 {
-		body := strings.ReplaceAll(string(req.Body), "'", "'\\''")
-		parts = append(parts, "-d", fmt.Sprintf("'%s'", body))
+	switch {
+	case req.BodyFile != "":
+		parts = append(parts, "--data-binary", shellQuote("@"+req.BodyFile))
+	case len(req.Form) > 0 && req.Multipart:
+		for _, f := range req.Form {
+			if f.File != "" {
+				parts = append(parts, "-F", shellQuote(f.Name+"=@"+f.File))
+			} else {
+				parts = append(parts, "-F", shellQuote(f.Name+"="+f.Value))
+			}
+		}
+	case len(req.Form) > 0:
+		for _, f := range req.Form {
+			parts = append(parts, "--data-urlencode", shellQuote(f.Name+"="+f.Value))
+		}
+	case len(req.Body) > 0:
+		parts = append(parts, "-d", shellQuote(string(req.Body)))
 	}
 
 	// URL with params
@@ -55,3 +77,8 @@ func AsCurl(req *protocol.Request) string {
 
 	return strings.Join(parts, " ")
 }
+
+// shellQuote wraps s in single quotes for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
+}
@@ -79,3 +79,54 @@ func TestAsCurl_WithParams(t *testing.T) {
 		t.Error("should contain query param limit")
 	}
 }
+
+func TestAsCurl_UploadFraming(t *testing.T) {
+	req := &protocol.Request{
+		Method:         "POST",
+		URL:            "https://api.example.com/upload",
+		Body:           []byte("data"),
+		ExpectContinue: true,
+		Chunked:        true,
+	}
+
+	result := AsCurl(req)
+	if !strings.Contains(result, "'Expect: 100-continue'") {
+		t.Errorf("should contain Expect header, got %s", result)
+	}
+	if !strings.Contains(result, "'Transfer-Encoding: chunked'") {
+		t.Errorf("should contain chunked header, got %s", result)
+	}
+
+	req.Body = nil
+	if strings.Contains(AsCurl(req), "Expect") {
+		t.Error("framing headers should be omitted without a body")
+	}
+}
+
+func TestAsCurl_FormAndFileBodies(t *testing.T) {
+	req := &protocol.Request{
+		Method:    "POST",
+		URL:       "https://api.example.com/upload",
+		Multipart: true,
+		Form: []protocol.FormField{
+			{Name: "title", Value: "it's"},
+			{Name: "file", File: "photo.png"},
+		},
+	}
+	result := AsCurl(req)
+	if !strings.Contains(result, `-F 'title=it'\''s'`) || !strings.Contains(result, "-F 'file=@photo.png'") {
+		t.Errorf("unexpected multipart flags: %s", result)
+	}
+
+	req.Multipart = false
+	req.Form = req.Form[:1]
+	if result := AsCurl(req); !strings.Contains(result, "--data-urlencode 'title=it") {
+		t.Errorf("unexpected form flags: %s", result)
+	}
+
+	req.Form = nil
+	req.BodyFile = "dump.bin"
+	if result := AsCurl(req); !strings.Contains(result, "--data-binary '@dump.bin'") {
+		t.Errorf("unexpected file flags: %s", result)
+	}
+}
@@ -55,6 +55,10 @@ type HARResponse struct {
 	Content     HARContent  `json:"content"`
 	HeadersSize int         `json:"headersSize"`
 	BodySize    int         `json:"bodySize"`
+
+	// Error is why no response was received, as the custom field browsers
+	// also use for failed requests.
+	Error string `json:"_error,omitempty"`
 }
 
 // HARHeader is a name/value pair for headers.
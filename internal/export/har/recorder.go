@@ -0,0 +1,198 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// Recorder captures every exchange of a session to a HAR file. Each
+// exchange is appended in place, leaving a complete HAR after every write,
+// so the file is usable even if gottp exits without stopping the
+// recording. Entries are not kept in memory.
+type Recorder struct {
+	mu     sync.Mutex
+	path   string
+	count  int
+	tailAt int64  // offset of the text closing the entries array
+	tail   []byte // that text: "]" and the rest of the document
+
+	// Mask, when set, is applied to URLs, header values and bodies before
+	// they are written, to keep secrets out of a file meant for sharing.
+	Mask func(string) string
+}
+
+// maxRecordedBody caps each request and response body in a recording.
+const maxRecordedBody = 1 << 20
+
+// entryIndent is the indentation of entries in the entries array.
+const entryIndent = "      "
+
+// NewRecorder starts recording to path. An existing HAR file is continued,
+// keeping its entries; any other existing file is an error rather than
+// being overwritten.
+func NewRecorder(path string) (*Recorder, error) {
+	var entries []HAREntry
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	case len(data) > 0:
+		var doc HAR
+		if err := json.Unmarshal(data, &doc); err != nil || doc.Log.Version == "" {
+			return nil, fmt.Errorf("%s exists and is not a HAR file", path)
+		}
+		entries = doc.Log.Entries
+	}
+
+	// Frame the document around an empty entries array, then write the
+	// existing entries into it as Record would
+	doc, err := json.MarshalIndent(HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "gottp", Version: "0.1.0"},
+		Entries: []HAREntry{},
+	}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	head, tail, ok := bytes.Cut(doc, []byte(`"entries": [`))
+	if !ok {
+		return nil, fmt.Errorf("framing HAR: no entries array")
+	}
+	head = append(head, `"entries": [`...)
+	r := &Recorder{path: path, tailAt: int64(len(head)), tail: append([]byte("\n    "), tail...)}
+
+	buf := bytes.NewBuffer(head)
+	for _, e := range entries {
+		if err := r.appendEntry(buf, e); err != nil {
+			return nil, err
+		}
+	}
+	r.tailAt = int64(buf.Len())
+	buf.Write(r.tail)
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return nil, fmt.Errorf("writing HAR: %w", err)
+	}
+	return r, nil
+}
+
+// Path returns the file being recorded to.
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// Len returns the number of exchanges recorded.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// Record adds the exchange of req, sent at started, to the file. resp may
+// be nil when the request failed with sendErr, which is then recorded as a
+// response with status 0. Bodies over maxRecordedBody are cut short.
+func (r *Recorder) Record(req *protocol.Request, resp *protocol.Response, started time.Time, sendErr error) error {
+	entry := HAREntry{
+		StartedDateTime: started.UTC().Format(time.RFC3339Nano),
+		Request:         buildHARRequest(req),
+	}
+	if resp != nil {
+		entry.Time = float64(resp.Duration.Milliseconds())
+		entry.Response = buildHARResponse(resp)
+		entry.Timings = buildHARTimings(resp)
+	} else {
+		elapsed := float64(time.Since(started).Milliseconds())
+		entry.Time = elapsed
+		entry.Response = HARResponse{HeadersSize: -1, BodySize: -1}
+		if sendErr != nil {
+			entry.Response.Error = sendErr.Error()
+		}
+		entry.Timings = HARTimings{DNS: -1, Connect: -1, SSL: -1, Wait: elapsed}
+	}
+	if entry.Request.PostData != nil {
+		entry.Request.PostData.Text = capBody(entry.Request.PostData.Text)
+	}
+	entry.Response.Content.Text = capBody(entry.Response.Content.Text)
+	if r.Mask != nil {
+		maskEntry(&entry, r.Mask)
+	}
+
+	var buf bytes.Buffer
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.appendEntry(&buf, entry); err != nil {
+		return err
+	}
+	buf.Write(r.tail)
+
+	// The new entry overwrites the old tail and the file only grows, so
+	// nothing written before needs touching
+	f, err := os.OpenFile(r.path, os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("writing HAR: %w", err)
+	}
+	if _, err := f.WriteAt(buf.Bytes(), r.tailAt); err != nil {
+		f.Close()
+		return fmt.Errorf("writing HAR: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing HAR: %w", err)
+	}
+	r.tailAt += int64(buf.Len() - len(r.tail))
+	return nil
+}
+
+// appendEntry writes e to buf as the next element of the entries array
+// and counts it.
+func (r *Recorder) appendEntry(buf *bytes.Buffer, e HAREntry) error {
+	data, err := json.MarshalIndent(e, entryIndent, "  ")
+	if err != nil {
+		return err
+	}
+	if r.count > 0 {
+		buf.WriteByte(',')
+	}
+	buf.WriteString("\n" + entryIndent)
+	buf.Write(data)
+	r.count++
+	return nil
+}
+
+// capBody cuts a body longer than maxRecordedBody, on a rune boundary, and
+// says how much was left out.
+func capBody(s string) string {
+	if len(s) <= maxRecordedBody {
+		return s
+	}
+	cut := maxRecordedBody
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf("\n[gottp: %d more bytes not recorded]", len(s)-cut)
+}
+
+func maskEntry(e *HAREntry, mask func(string) string) {
+	e.Request.URL = mask(e.Request.URL)
+	for i := range e.Request.Headers {
+		e.Request.Headers[i].Value = mask(e.Request.Headers[i].Value)
+	}
+	for i := range e.Request.QueryString {
+		e.Request.QueryString[i].Value = mask(e.Request.QueryString[i].Value)
+	}
+	if e.Request.PostData != nil {
+		e.Request.PostData.Text = mask(e.Request.PostData.Text)
+	}
+	for i := range e.Response.Headers {
+		e.Response.Headers[i].Value = mask(e.Response.Headers[i].Value)
+	}
+	e.Response.Content.Text = mask(e.Response.Content.Text)
+	e.Response.Error = mask(e.Response.Error)
+}
@@ -0,0 +1,129 @@
+package har
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+func readHAR(t *testing.T, path string) HAR {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc HAR
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("recording is not valid JSON: %v", err)
+	}
+	return doc
+}
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.har")
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Mask = func(s string) string { return strings.ReplaceAll(s, "s3cret", "••••") }
+	if doc := readHAR(t, path); doc.Log.Version != "1.2" || len(doc.Log.Entries) != 0 {
+		t.Fatalf("new recording = %+v", doc.Log)
+	}
+
+	req := &protocol.Request{
+		Method:  "GET",
+		URL:     "https://api.example.com/users?key=s3cret",
+		Headers: map[string]string{"Authorization": "Bearer s3cret"},
+	}
+	resp := &protocol.Response{
+		StatusCode: 200,
+		Status:     "200 OK",
+		Headers:    http.Header{"Content-Type": {"application/json"}},
+		Body:       []byte(`[]`),
+		Duration:   120 * time.Millisecond,
+		Proto:      "HTTP/1.1",
+		Timing:     &protocol.TimingDetail{TTFB: 100 * time.Millisecond, Total: 120 * time.Millisecond},
+	}
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := rec.Record(req, resp, started, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Record(&protocol.Request{Method: "POST", URL: "https://down.example.com"}, nil, started, errors.New("connection refused")); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Len() != 2 {
+		t.Errorf("Len = %d, want 2", rec.Len())
+	}
+
+	doc := readHAR(t, path)
+	if len(doc.Log.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(doc.Log.Entries))
+	}
+	ok := doc.Log.Entries[0]
+	if ok.StartedDateTime != "2026-01-02T03:04:05Z" || ok.Time != 120 || ok.Timings.Wait != 100 {
+		t.Errorf("entry = %+v", ok)
+	}
+	if strings.Contains(ok.Request.URL, "s3cret") || ok.Request.Headers[0].Value != "Bearer ••••" {
+		t.Errorf("secret not masked: %s %+v", ok.Request.URL, ok.Request.Headers)
+	}
+	failed := doc.Log.Entries[1]
+	if failed.Response.Status != 0 || failed.Response.Error != "connection refused" {
+		t.Errorf("failed entry response = %+v", failed.Response)
+	}
+
+	// Recording to the same file again continues it
+	again, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Len() != 2 {
+		t.Errorf("continued recording has %d entries, want 2", again.Len())
+	}
+}
+
+func TestNewRecorder_RefusesOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("keep me"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRecorder(path); err == nil {
+		t.Fatal("expected an error for a file that is not a HAR")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep me" {
+		t.Errorf("file was overwritten: %q", data)
+	}
+}
+
+func TestRecorder_CapsBodies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.har")
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	big := strings.Repeat("x", maxRecordedBody+100)
+	req := &protocol.Request{Method: "POST", URL: "https://api.example.com/upload", Body: []byte(big)}
+	resp := &protocol.Response{StatusCode: 200, Status: "200 OK", Body: []byte(big)}
+	for range 3 {
+		if err := rec.Record(req, resp, time.Now(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc := readHAR(t, path)
+	if len(doc.Log.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(doc.Log.Entries))
+	}
+	e := doc.Log.Entries[2]
+	for _, text := range []string{e.Request.PostData.Text, e.Response.Content.Text} {
+		if len(text) > maxRecordedBody+64 || !strings.HasSuffix(text, "[gottp: 100 more bytes not recorded]") {
+			t.Errorf("body not capped: %d bytes ending %q", len(text), text[len(text)-40:])
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package httpfile exports collections as .http files for the JetBrains
+// HTTP Client and the VS Code REST Client.
+package httpfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+// Export writes the collection's requests as an .http file, each under a
+// ### line naming it with its folder path. Collection variables are
+// declared at the top as @name = value. GraphQL requests are sent as JSON
+// POSTs; WebSocket and gRPC requests have no .http form and are listed as
+// comments.
+func Export(col *collection.Collection) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", col.Name)
+
+	if len(col.Variables) > 0 {
+		b.WriteString("\n")
+		for _, k := range slices.Sorted(maps.Keys(col.Variables)) {
+			fmt.Fprintf(&b, "@%s = %s\n", k, col.Variables[k])
+		}
+	}
+
+	for _, item := range collection.FlattenItems(col.Items, 0, "") {
+		if item.IsFolder {
+			continue
+		}
+		name := item.Request.Name
+		if folder := strings.TrimPrefix(strings.TrimSuffix(item.Path, "/"+name), "/"); folder != "" {
+			name = strings.ReplaceAll(folder, "/", " / ") + " / " + name
+		}
+		if err := writeRequest(&b, name, item.Request, col.Auth); err != nil {
+			return nil, fmt.Errorf("exporting %q: %w", item.Request.Name, err)
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+func writeRequest(b *strings.Builder, name string, req *collection.Request, colAuth *collection.Auth) error {
+	switch req.Protocol {
+	case "", "http", "graphql":
+	default:
+		// Under its own separator, so the comment doesn't join the body of
+		// the request before it
+		fmt.Fprintf(b, "\n### %s\n# Skipped: %s requests have no .http form\n", name, req.Protocol)
+		return nil
+	}
+
+	fmt.Fprintf(b, "\n### %s\n", name)
+	method := req.Method
+	if method == "" {
+		method = "GET"
+	}
+	var params []string
+	for _, p := range req.Params {
+		if p.Enabled && p.Key != "" {
+			params = append(params, p.Key+"="+p.Value)
+		}
+	}
+
+	headers := enabled(req.Headers)
+	auth := req.Auth
+	if auth == nil {
+		auth = colAuth
+	}
+	if auth != nil {
+		switch auth.Type {
+		case "basic":
+			if auth.Basic != nil {
+				headers = append(headers, collection.KVPair{Key: "Authorization", Value: "Basic " + auth.Basic.Username + " " + auth.Basic.Password})
+			}
+		case "digest":
+			if auth.Digest != nil {
+				headers = append(headers, collection.KVPair{Key: "Authorization", Value: "Digest " + auth.Digest.Username + " " + auth.Digest.Password})
+			}
+		case "bearer":
+			if auth.Bearer != nil {
+				headers = append(headers, collection.KVPair{Key: "Authorization", Value: "Bearer " + auth.Bearer.Token})
+			}
+		case "apikey":
+			if k := auth.APIKey; k != nil && k.In == "query" {
+				params = append(params, k.Key+"="+k.Value)
+			} else if k != nil {
+				headers = append(headers, collection.KVPair{Key: k.Key, Value: k.Value})
+			}
+		case "none", "":
+		default:
+			fmt.Fprintf(b, "# %s auth is not exported\n", auth.Type)
+		}
+	}
+	if cookies := enabled(req.Cookies); len(cookies) > 0 {
+		pairs := make([]string, len(cookies))
+		for i, c := range cookies {
+			pairs[i] = c.Key + "=" + c.Value
+		}
+		headers = append(headers, collection.KVPair{Key: "Cookie", Value: strings.Join(pairs, "; ")})
+	}
+
+	body, contentType, err := bodyText(req)
+	if err != nil {
+		return err
+	}
+	if req.Protocol == "graphql" {
+		method = "POST"
+	}
+	target := req.URL
+	if len(params) > 0 {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + strings.Join(params, "&")
+	}
+	fmt.Fprintf(b, "%s %s\n", method, target)
+
+	if contentType != "" && !hasHeader(headers, "Content-Type") {
+		headers = append(headers, collection.KVPair{Key: "Content-Type", Value: contentType})
+	}
+	for _, h := range headers {
+		fmt.Fprintf(b, "%s: %s\n", h.Key, h.Value)
+	}
+	if body != "" {
+		fmt.Fprintf(b, "\n%s\n", strings.TrimRight(body, "\n"))
+	}
+	return nil
+}
+
+// boundary separates the parts of exported multipart bodies.
+const boundary = "gottp-boundary"
+
+// bodyText renders the request body as .http body text, with the content
+// type it needs when the request's headers don't set one.
+func bodyText(req *collection.Request) (body, contentType string, err error) {
+	if req.Protocol == "graphql" && req.GraphQL != nil {
+		payload := map[string]any{"query": req.GraphQL.Query}
+		if v := strings.TrimSpace(req.GraphQL.Variables); v != "" {
+			payload["variables"] = json.RawMessage(v)
+		}
+		data, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			return "", "", fmt.Errorf("GraphQL variables: %w", err)
+		}
+		return string(data), "application/json", nil
+	}
+
+	b := req.Body
+	if b.IsEmpty() {
+		return "", "", nil
+	}
+	switch b.Kind() {
+	case collection.BodyBinaryFile:
+		return "< " + b.File, "", nil
+	case collection.BodyFormURLEncoded:
+		if len(b.Fields) == 0 {
+			return b.Content, "application/x-www-form-urlencoded", nil
+		}
+		var pairs []string
+		for _, f := range b.Fields {
+			if f.Enabled {
+				pairs = append(pairs, url.QueryEscape(f.Key)+"="+url.QueryEscape(f.Value))
+			}
+		}
+		return strings.Join(pairs, "&"), "application/x-www-form-urlencoded", nil
+	case collection.BodyMultipart:
+		var parts strings.Builder
+		for _, f := range b.Fields {
+			if !f.Enabled {
+				continue
+			}
+			fmt.Fprintf(&parts, "--%s\n", boundary)
+			if f.File != "" {
+				fmt.Fprintf(&parts, "Content-Disposition: form-data; name=%q; filename=%q\n\n< %s\n", f.Key, fileName(f.File), f.File)
+			} else {
+				fmt.Fprintf(&parts, "Content-Disposition: form-data; name=%q\n\n%s\n", f.Key, f.Value)
+			}
+		}
+		fmt.Fprintf(&parts, "--%s--", boundary)
+		return parts.String(), "multipart/form-data; boundary=" + boundary, nil
+	case "json":
+		return b.Content, "application/json", nil
+	case "xml":
+		return b.Content, "application/xml", nil
+	}
+	return b.Content, "", nil
+}
+
+func fileName(path string) string {
+	return path[strings.LastIndexAny(path, `/\`)+1:]
+}
+
+func enabled(pairs []collection.KVPair) []collection.KVPair {
+	var out []collection.KVPair
+	for _, p := range pairs {
+		if p.Enabled && p.Key != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func hasHeader(headers []collection.KVPair, key string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package httpfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	importhttp "github.com/sadopc/gottp/internal/import/httpfile"
+)
+
+func testCollection() *collection.Collection {
+	return &collection.Collection{
+		Name:      "Users API",
+		Variables: map[string]string{"base_url": "https://api.example.com"},
+		Auth:      &collection.Auth{Type: "bearer", Bearer: &collection.BearerAuth{Token: "{{token}}"}},
+		Items: []collection.Item{
+			{Request: &collection.Request{
+				Name:     "Health",
+				Protocol: "http",
+				Method:   "GET",
+				URL:      "{{base_url}}/health",
+			}},
+			{Folder: &collection.Folder{Name: "Users", Items: []collection.Item{
+				{Request: &collection.Request{
+					Name:     "Create",
+					Protocol: "http",
+					Method:   "POST",
+					URL:      "{{base_url}}/users",
+					Params:   []collection.KVPair{{Key: "notify", Value: "true", Enabled: true}, {Key: "off", Value: "1"}},
+					Body:     &collection.Body{Type: "json", Content: `{"name": "Ada"}`},
+				}},
+				{Request: &collection.Request{
+					Name:     "Login",
+					Protocol: "http",
+					Method:   "POST",
+					URL:      "{{base_url}}/login",
+					Auth:     &collection.Auth{Type: "basic", Basic: &collection.BasicAuth{Username: "ada", Password: "secret"}},
+					Body: &collection.Body{Type: collection.BodyFormURLEncoded, Fields: []collection.BodyField{
+						{Key: "remember", Value: "yes please", Enabled: true},
+					}},
+				}},
+			}}},
+			{Request: &collection.Request{Name: "Feed", Protocol: "websocket", URL: "wss://api.example.com/feed"}},
+		},
+	}
+}
+
+func TestExport(t *testing.T) {
+	data, err := Export(testCollection())
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	out := string(data)
+	for _, want := range []string{
+		"# Users API\n",
+		"@base_url = https://api.example.com\n",
+		"### Health\nGET {{base_url}}/health\nAuthorization: Bearer {{token}}\n",
+		"### Users / Create\nPOST {{base_url}}/users?notify=true\n",
+		"Content-Type: application/json\n\n{\"name\": \"Ada\"}\n",
+		"Authorization: Basic ada secret\n",
+		"remember=yes+please\n",
+		"### Feed\n# Skipped: websocket requests have no .http form\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "off=1") {
+		t.Errorf("disabled param exported:\n%s", out)
+	}
+}
+
+func TestExport_RoundTrip(t *testing.T) {
+	data, err := Export(testCollection())
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	col, err := importhttp.ParseHTTPFile(data)
+	if err != nil {
+		t.Fatalf("ParseHTTPFile: %v\n%s", err, data)
+	}
+	if col.Variables["base_url"] != "https://api.example.com" {
+		t.Errorf("variables = %v", col.Variables)
+	}
+	if len(col.Items) != 3 {
+		t.Fatalf("got %d requests back, want 3", len(col.Items))
+	}
+	login := col.Items[2].Request
+	if login.Name != "Users / Login" || login.Auth == nil || login.Auth.Basic.Password != "secret" {
+		t.Errorf("login = %+v", login)
+	}
+	if login.Body.Kind() != collection.BodyFormURLEncoded || login.Body.Fields[0].Value != "yes please" {
+		t.Errorf("login body = %+v", login.Body)
+	}
+}
+
+func TestExport_GraphQLAndMultipart(t *testing.T) {
+	col := &collection.Collection{Name: "G", Items: []collection.Item{
+		{Request: &collection.Request{
+			Name:     "Me",
+			Protocol: "graphql",
+			URL:      "https://example.com/graphql",
+			GraphQL:  &collection.GraphQLConfig{Query: "{ me { id } }", Variables: `{"a": 1}`},
+		}},
+		{Request: &collection.Request{
+			Name:     "Upload",
+			Protocol: "http",
+			Method:   "POST",
+			URL:      "https://example.com/upload",
+			Body: &collection.Body{Type: collection.BodyMultipart, Fields: []collection.BodyField{
+				{Key: "title", Value: "Report", Enabled: true},
+				{Key: "doc", File: "./docs/report.pdf", Enabled: true},
+			}},
+		}},
+	}}
+	data, err := Export(col)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	out := string(data)
+	for _, want := range []string{
+		"### Me\nPOST https://example.com/graphql\nContent-Type: application/json\n",
+		`"query": "{ me { id } }"`,
+		"Content-Type: multipart/form-data; boundary=gottp-boundary\n",
+		"Content-Disposition: form-data; name=\"doc\"; filename=\"report.pdf\"\n\n< ./docs/report.pdf\n",
+		"--gottp-boundary--\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Package hurl exports collections as Hurl files.
+package hurl
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sadopc/gottp/internal/core/assertion"
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+// Export writes the collection's requests as one Hurl file, in collection
+// order, each preceded by a comment naming it. Query parameters, cookies,
+// basic auth and form fields go in their Hurl sections; status, header
+// and JSONPath assertions become the expected response. Collection
+// variables are listed in a comment, to be passed with --variable.
+// WebSocket and gRPC requests have no Hurl form and are listed as
+// comments.
+func Export(col *collection.Collection) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", col.Name)
+	if len(col.Variables) > 0 {
+		b.WriteString("#\n# Variables (hurl --variable name=value):\n")
+		for _, k := range slices.Sorted(maps.Keys(col.Variables)) {
+			fmt.Fprintf(&b, "#   %s=%s\n", k, col.Variables[k])
+		}
+	}
+
+	for _, item := range collection.FlattenItems(col.Items, 0, "") {
+		if item.IsFolder {
+			continue
+		}
+		name := strings.TrimPrefix(item.Path, "/")
+		if err := writeEntry(&b, name, item.Request, col.Auth); err != nil {
+			return nil, fmt.Errorf("exporting %q: %w", item.Request.Name, err)
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+func writeEntry(b *strings.Builder, name string, req *collection.Request, colAuth *collection.Auth) error {
+	switch req.Protocol {
+	case "", "http", "graphql":
+	default:
+		fmt.Fprintf(b, "\n# Skipped %s: %s requests have no Hurl form\n", name, req.Protocol)
+		return nil
+	}
+
+	fmt.Fprintf(b, "\n# %s\n", name)
+	method := req.Method
+	switch {
+	case req.Protocol == "graphql":
+		method = "POST"
+	case method == "":
+		method = "GET"
+	}
+	fmt.Fprintf(b, "%s %s\n", method, req.URL)
+
+	headers := enabled(req.Headers)
+	params := enabled(req.Params)
+	var basic *collection.BasicAuth
+	auth := req.Auth
+	if auth == nil {
+		auth = colAuth
+	}
+	if auth != nil {
+		switch auth.Type {
+		case "basic":
+			basic = auth.Basic
+		case "bearer":
+			if auth.Bearer != nil {
+				headers = append(headers, collection.KVPair{Key: "Authorization", Value: "Bearer " + auth.Bearer.Token})
+			}
+		case "apikey":
+			if k := auth.APIKey; k != nil && k.In == "query" {
+				params = append(params, collection.KVPair{Key: k.Key, Value: k.Value})
+			} else if k != nil {
+				headers = append(headers, collection.KVPair{Key: k.Key, Value: k.Value})
+			}
+		case "none", "":
+		default:
+			fmt.Fprintf(b, "# %s auth is not exported\n", auth.Type)
+		}
+	}
+	if ct := contentType(req); ct != "" && !hasHeader(headers, "Content-Type") {
+		headers = append(headers, collection.KVPair{Key: "Content-Type", Value: ct})
+	}
+	for _, h := range headers {
+		fmt.Fprintf(b, "%s: %s\n", h.Key, h.Value)
+	}
+
+	writeSection(b, "QueryStringParams", params)
+	writeSection(b, "Cookies", enabled(req.Cookies))
+	if basic != nil {
+		fmt.Fprintf(b, "[BasicAuth]\n%s: %s\n", basic.Username, value(basic.Password))
+	}
+	if err := writeBody(b, req); err != nil {
+		return err
+	}
+	writeResponse(b, req.Assertions)
+	return nil
+}
+
+func writeSection(b *strings.Builder, name string, pairs []collection.KVPair) {
+	if len(pairs) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "[%s]\n", name)
+	for _, p := range pairs {
+		fmt.Fprintf(b, "%s: %s\n", p.Key, value(p.Value))
+	}
+}
+
+// value quotes a section value Hurl would otherwise trim or misread.
+func value(s string) string {
+	if s == "" || s != strings.TrimSpace(s) || strings.HasPrefix(s, `"`) || strings.Contains(s, "#") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// contentType is the Content-Type a body needs when the request doesn't
+// set one. Hurl sets it for JSON, XML, form and multipart bodies itself.
+func contentType(req *collection.Request) string {
+	if req.Protocol != "graphql" && req.Body.Kind() == "text" {
+		return "text/plain"
+	}
+	return ""
+}
+
+func writeBody(b *strings.Builder, req *collection.Request) error {
+	if req.Protocol == "graphql" {
+		if req.GraphQL == nil {
+			return nil
+		}
+		fmt.Fprintf(b, "```graphql\n%s\n", strings.TrimSpace(req.GraphQL.Query))
+		if v := strings.TrimSpace(req.GraphQL.Variables); v != "" {
+			fmt.Fprintf(b, "\nvariables %s\n", v)
+		}
+		b.WriteString("```\n")
+		return nil
+	}
+
+	body := req.Body
+	if body.IsEmpty() {
+		return nil
+	}
+	switch body.Kind() {
+	case collection.BodyBinaryFile:
+		fmt.Fprintf(b, "file,%s;\n", body.File)
+	case collection.BodyFormURLEncoded, collection.BodyMultipart:
+		section := "FormParams"
+		if body.Kind() == collection.BodyMultipart {
+			section = "MultipartFormData"
+		}
+		if len(body.Fields) == 0 {
+			fmt.Fprintf(b, "```\n%s\n```\n", body.Content)
+			return nil
+		}
+		fmt.Fprintf(b, "[%s]\n", section)
+		for _, f := range body.Fields {
+			if !f.Enabled {
+				continue
+			}
+			if f.File != "" {
+				fmt.Fprintf(b, "%s: file,%s;\n", f.Key, f.File)
+			} else {
+				fmt.Fprintf(b, "%s: %s\n", f.Key, value(f.Value))
+			}
+		}
+	case "json":
+		if json.Valid([]byte(body.Content)) {
+			fmt.Fprintf(b, "%s\n", strings.TrimSpace(body.Content))
+			return nil
+		}
+		// Hurl parses JSON bodies, so anything that isn't JSON yet goes in
+		// a multiline string
+		fmt.Fprintf(b, "```json\n%s\n```\n", strings.TrimSpace(body.Content))
+	case "xml":
+		fmt.Fprintf(b, "%s\n", strings.TrimSpace(body.Content))
+	default:
+		fmt.Fprintf(b, "```\n%s\n```\n", strings.TrimRight(body.Content, "\n"))
+	}
+	return nil
+}
+
+// writeResponse writes the expected response: the status line from a
+// status assertion and the other assertions Hurl can check.
+func writeResponse(b *strings.Builder, assertions []assertion.Assertion) {
+	if len(assertions) == 0 {
+		return
+	}
+	status := "*"
+	var asserts []string
+	for _, a := range assertions {
+		if a.Status != 0 && status == "*" {
+			status = strconv.Itoa(a.Status)
+			continue
+		}
+		if line, ok := assertLine(a); ok {
+			asserts = append(asserts, line)
+		} else {
+			asserts = append(asserts, "# not exported: "+a.Describe())
+		}
+	}
+	fmt.Fprintf(b, "HTTP %s\n", status)
+	if len(asserts) > 0 {
+		b.WriteString("[Asserts]\n" + strings.Join(asserts, "\n") + "\n")
+	}
+}
+
+// assertLine renders an assertion as a Hurl assert.
+func assertLine(a assertion.Assertion) (string, bool) {
+	var query string
+	switch {
+	case a.Status != 0:
+		return "status == " + strconv.Itoa(a.Status), true
+	case a.ResponseTimeUnder > 0:
+		return fmt.Sprintf("duration < %d", a.ResponseTimeUnder/time.Millisecond), true
+	case a.Header != "":
+		query = "header " + strconv.Quote(a.Header)
+	case strings.HasPrefix(a.JSONPath, "$"):
+		query = "jsonpath " + strconv.Quote(a.JSONPath)
+	default:
+		return "", false
+	}
+
+	switch {
+	case a.Exists != nil && *a.Exists:
+		return query + " exists", true
+	case a.Exists != nil:
+		return query + " not exists", true
+	case a.Equals != nil:
+		return query + " == " + literal(a.Equals, a.Header != ""), true
+	case a.Contains != nil:
+		return query + " contains " + literal(a.Contains, true), true
+	case a.Matches != "":
+		return query + " matches " + strconv.Quote(a.Matches), true
+	}
+	return "", false
+}
+
+// literal renders an expected value. Headers are always compared as
+// strings.
+func literal(v interface{}, asString bool) string {
+	if s, ok := v.(string); ok || asString {
+		if !ok {
+			s = fmt.Sprint(v)
+		}
+		return strconv.Quote(s)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return strconv.Quote(fmt.Sprint(v))
+	}
+	return string(data)
+}
+
+func enabled(pairs []collection.KVPair) []collection.KVPair {
+	var out []collection.KVPair
+	for _, p := range pairs {
+		if p.Enabled && p.Key != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func hasHeader(headers []collection.KVPair, key string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, key) {
+			return true
+		}
+	}
+	return false
+}
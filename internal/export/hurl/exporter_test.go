@@ -0,0 +1,118 @@
+package hurl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sadopc/gottp/internal/core/assertion"
+	"github.com/sadopc/gottp/internal/core/collection"
+	importhurl "github.com/sadopc/gottp/internal/import/hurl"
+)
+
+func testCollection() *collection.Collection {
+	yes := true
+	return &collection.Collection{
+		Name:      "Users API",
+		Variables: map[string]string{"base_url": "https://api.example.com"},
+		Items: []collection.Item{
+			{Folder: &collection.Folder{Name: "Users", Items: []collection.Item{
+				{Request: &collection.Request{
+					Name:     "Get",
+					Protocol: "http",
+					Method:   "GET",
+					URL:      "{{base_url}}/users/1",
+					Params:   []collection.KVPair{{Key: "expand", Value: "teams", Enabled: true}},
+					Cookies:  []collection.KVPair{{Key: "session", Value: "abc", Enabled: true}},
+					Auth:     &collection.Auth{Type: "basic", Basic: &collection.BasicAuth{Username: "ada", Password: "s3cret #1"}},
+					Assertions: []assertion.Assertion{
+						{Status: 200},
+						{Header: "Content-Type", Contains: "json"},
+						{JSONPath: "$.name", Equals: "Ada"},
+						{JSONPath: "$.age", Equals: 36},
+						{JSONPath: "$.teams", Exists: &yes},
+						{ResponseTimeUnder: 500 * time.Millisecond},
+						{JSONPath: "name", Equals: "Ada"},
+					},
+				}},
+				{Request: &collection.Request{
+					Name:     "Create",
+					Protocol: "http",
+					Method:   "POST",
+					URL:      "{{base_url}}/users",
+					Headers:  []collection.KVPair{{Key: "X-Trace", Value: "1", Enabled: true}},
+					Body:     &collection.Body{Type: "json", Content: `{"name": "Ada"}`},
+				}},
+			}}},
+			{Request: &collection.Request{
+				Name:     "Note",
+				Protocol: "http",
+				Method:   "POST",
+				URL:      "{{base_url}}/notes",
+				Body:     &collection.Body{Type: "text", Content: "hello\nworld"},
+			}},
+			{Request: &collection.Request{Name: "Stream", Protocol: "grpc", URL: "localhost:50051"}},
+		},
+	}
+}
+
+func TestExport(t *testing.T) {
+	data, err := Export(testCollection())
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	out := string(data)
+	for _, want := range []string{
+		"#   base_url=https://api.example.com\n",
+		"# Users/Get\nGET {{base_url}}/users/1\n[QueryStringParams]\nexpand: teams\n[Cookies]\nsession: abc\n[BasicAuth]\nada: \"s3cret #1\"\n",
+		"HTTP 200\n[Asserts]\n",
+		"header \"Content-Type\" contains \"json\"\n",
+		"jsonpath \"$.name\" == \"Ada\"\n",
+		"jsonpath \"$.age\" == 36\n",
+		"jsonpath \"$.teams\" exists\n",
+		"duration < 500\n",
+		"# not exported: ",
+		"POST {{base_url}}/users\nX-Trace: 1\n{\"name\": \"Ada\"}\n",
+		"Content-Type: text/plain\n```\nhello\nworld\n```\n",
+		"# Skipped Stream: grpc requests have no Hurl form",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestExport_RoundTrip(t *testing.T) {
+	data, err := Export(testCollection())
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	col, err := importhurl.ParseHurl(data)
+	if err != nil {
+		t.Fatalf("ParseHurl: %v\n%s", err, data)
+	}
+	if len(col.Items) != 3 {
+		t.Fatalf("got %d requests back, want 3", len(col.Items))
+	}
+
+	get := col.Items[0].Request
+	if get.Auth == nil || get.Auth.Basic.Password != "s3cret #1" {
+		t.Errorf("auth = %+v", get.Auth)
+	}
+	if len(get.Params) != 1 || len(get.Cookies) != 1 {
+		t.Errorf("params = %+v, cookies = %+v", get.Params, get.Cookies)
+	}
+	// Everything but the JSONPath without a leading $ comes back
+	if len(get.Assertions) != 6 {
+		t.Errorf("got %d assertions back, want 6: %+v", len(get.Assertions), get.Assertions)
+	}
+
+	create := col.Items[1].Request
+	if create.Body.Kind() != "json" || create.Body.Content != `{"name": "Ada"}` {
+		t.Errorf("create body = %+v", create.Body)
+	}
+	note := col.Items[2].Request
+	if note.Body.Kind() != "text" || note.Body.Content != "hello\nworld" {
+		t.Errorf("note body = %+v", note.Body)
+	}
+}
@@ -0,0 +1,528 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+var (
+	templateVar = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+	leadingVar  = regexp.MustCompile(`^\{\{\s*([\w.-]+)\s*\}\}`)
+	colonParam  = regexp.MustCompile(`^:(\w+)$`)
+	braceParam  = regexp.MustCompile(`\{(\w+)\}`)
+)
+
+type spec struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       info                `json:"info"`
+	Servers    []server            `json:"servers,omitempty"`
+	Tags       []tag               `json:"tags,omitempty"`
+	Paths      map[string]pathItem `json:"paths"`
+	Components *components         `json:"components,omitempty"`
+	Security   []securityReq       `json:"security,omitempty"`
+}
+
+type info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type server struct {
+	URL       string                    `json:"url"`
+	Variables map[string]serverVariable `json:"variables,omitempty"`
+}
+
+type serverVariable struct {
+	Default string `json:"default"`
+}
+
+type tag struct {
+	Name string `json:"name"`
+}
+
+type pathItem map[string]*operation // lowercase method -> operation
+
+type operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	OperationID string              `json:"operationId,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Servers     []server            `json:"servers,omitempty"`
+	Parameters  []parameter         `json:"parameters,omitempty"`
+	RequestBody *requestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]response `json:"responses"`
+	Security    *[]securityReq      `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name     string      `json:"name"`
+	In       string      `json:"in"` // path, query, header
+	Required bool        `json:"required,omitempty"`
+	Schema   *schema     `json:"schema"`
+	Example  interface{} `json:"example,omitempty"`
+}
+
+type requestBody struct {
+	Content map[string]mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema  *schema     `json:"schema,omitempty"`
+	Example interface{} `json:"example,omitempty"`
+}
+
+type response struct {
+	Description string `json:"description"`
+}
+
+type schema struct {
+	Type       string             `json:"type,omitempty"`
+	Properties map[string]*schema `json:"properties,omitempty"`
+	Items      *schema            `json:"items,omitempty"`
+}
+
+type components struct {
+	SecuritySchemes map[string]*securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type   string      `json:"type"`             // http, apiKey, oauth2
+	Scheme string      `json:"scheme,omitempty"` // basic, bearer, digest
+	Name   string      `json:"name,omitempty"`
+	In     string      `json:"in,omitempty"`
+	Flows  *oauthFlows `json:"flows,omitempty"`
+}
+
+type oauthFlows struct {
+	AuthorizationCode *oauthFlow `json:"authorizationCode,omitempty"`
+	ClientCredentials *oauthFlow `json:"clientCredentials,omitempty"`
+	Password          *oauthFlow `json:"password,omitempty"`
+}
+
+type oauthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl"`
+	Scopes           map[string]string `json:"scopes"`
+}
+
+type securityReq map[string][]string
+
+// exporter accumulates state shared by all operations of one spec.
+type exporter struct {
+	col          *collection.Collection
+	spec         spec
+	tagSeen      map[string]bool
+	operationIDs map[string]bool
+	schemes      map[string]*securityScheme
+}
+
+// Export converts the HTTP requests of a gottp Collection to an OpenAPI 3.1
+// document. Paths, parameters, request body schemas and security schemes are
+// inferred from the requests; {{variables}} at the start of URLs become server
+// variables and {{variables}} or :params in paths become path parameters.
+func Export(col *collection.Collection) ([]byte, error) {
+	if col == nil {
+		return nil, fmt.Errorf("collection is nil")
+	}
+
+	version := col.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+	e := &exporter{
+		col: col,
+		spec: spec{
+			OpenAPI: "3.1.0",
+			Info:    info{Title: col.Name, Version: version},
+			Paths:   make(map[string]pathItem),
+		},
+		tagSeen:      make(map[string]bool),
+		operationIDs: make(map[string]bool),
+		schemes:      make(map[string]*securityScheme),
+	}
+
+	if name := e.securityScheme(col.Auth); name != "" {
+		e.spec.Security = []securityReq{{name: scopes(col.Auth)}}
+	}
+	e.items(col.Items, "")
+
+	if len(e.schemes) > 0 {
+		e.spec.Components = &components{SecuritySchemes: e.schemes}
+	}
+	return json.MarshalIndent(e.spec, "", "  ")
+}
+
+func (e *exporter) items(items []collection.Item, folder string) {
+	for _, item := range items {
+		if item.Folder != nil {
+			e.items(item.Folder.Items, item.Folder.Name)
+		}
+		if item.Request != nil {
+			e.request(item.Request, folder)
+		}
+	}
+}
+
+func (e *exporter) request(req *collection.Request, folder string) {
+	if req.Protocol != "" && req.Protocol != "http" {
+		return
+	}
+	method := strings.ToLower(req.Method)
+	if method == "" {
+		method = "get"
+	}
+
+	srv, path, query := splitURL(req.URL, e.col.Variables)
+	path, pathParams := templatePath(path)
+
+	item := e.spec.Paths[path]
+	if item == nil {
+		item = make(pathItem)
+		e.spec.Paths[path] = item
+	}
+	if _, exists := item[method]; exists {
+		return // the first request for a method and path wins
+	}
+
+	op := &operation{
+		Summary:     req.Name,
+		OperationID: e.operationID(req.Name, method, path),
+		Responses:   map[string]response{"default": {Description: "Default response"}},
+	}
+	item[method] = op
+
+	if folder != "" {
+		op.Tags = []string{folder}
+		if !e.tagSeen[folder] {
+			e.tagSeen[folder] = true
+			e.spec.Tags = append(e.spec.Tags, tag{Name: folder})
+		}
+	}
+
+	if srv != nil {
+		if len(e.spec.Servers) == 0 {
+			e.spec.Servers = []server{*srv}
+		} else if !reflect.DeepEqual(e.spec.Servers[0], *srv) {
+			op.Servers = []server{*srv}
+		}
+	}
+
+	for _, name := range pathParams {
+		op.Parameters = append(op.Parameters, parameter{
+			Name: name, In: "path", Required: true, Schema: &schema{Type: "string"},
+		})
+	}
+	for _, p := range append(query, req.Params...) {
+		if p.Key == "" {
+			continue
+		}
+		op.Parameters = append(op.Parameters, parameter{
+			Name: p.Key, In: "query", Required: p.Enabled,
+			Schema: &schema{Type: "string"}, Example: example(p.Value),
+		})
+	}
+
+	contentType := ""
+	for _, h := range req.Headers {
+		switch strings.ToLower(h.Key) {
+		case "":
+		case "content-type":
+			contentType = h.Value
+		case "accept", "authorization":
+			// Described by the spec itself, not as parameters
+		default:
+			op.Parameters = append(op.Parameters, parameter{
+				Name: h.Key, In: "header", Required: h.Enabled,
+				Schema: &schema{Type: "string"}, Example: example(h.Value),
+			})
+		}
+	}
+
+	op.RequestBody = body(req.Body, contentType)
+
+	if req.Auth != nil {
+		if req.Auth.Type == "none" {
+			if len(e.spec.Security) > 0 {
+				op.Security = &[]securityReq{}
+			}
+		} else if name := e.securityScheme(req.Auth); name != "" {
+			reqs := []securityReq{{name: scopes(req.Auth)}}
+			if !reflect.DeepEqual(reqs, e.spec.Security) {
+				op.Security = &reqs
+			}
+		}
+	}
+}
+
+// splitURL separates a request URL into its server, path and query. A URL
+// starting with {{var}} is served from "{var}" with the variable's collection
+// value as default.
+func splitURL(raw string, vars map[string]string) (*server, string, []collection.KVPair) {
+	rest := raw
+	var srv *server
+	if m := leadingVar.FindStringSubmatch(raw); m != nil {
+		srv = &server{
+			URL:       "{" + m[1] + "}",
+			Variables: map[string]serverVariable{m[1]: {Default: vars[m[1]]}},
+		}
+		rest = raw[len(m[0]):]
+	} else if i := strings.Index(raw, "://"); i >= 0 {
+		end := strings.IndexAny(raw[i+3:], "/?#")
+		if end < 0 {
+			end = len(raw) - i - 3
+		}
+		srv = &server{URL: raw[:i+3+end]}
+		rest = raw[i+3+end:]
+	}
+
+	if i := strings.IndexByte(rest, '#'); i >= 0 {
+		rest = rest[:i]
+	}
+	var query []collection.KVPair
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		for _, part := range strings.Split(rest[i+1:], "&") {
+			if part == "" {
+				continue
+			}
+			k, v, _ := strings.Cut(part, "=")
+			if uk, err := url.QueryUnescape(k); err == nil {
+				k = uk
+			}
+			if uv, err := url.QueryUnescape(v); err == nil {
+				v = uv
+			}
+			query = append(query, collection.KVPair{Key: k, Value: v, Enabled: true})
+		}
+		rest = rest[:i]
+	}
+
+	if !strings.HasPrefix(rest, "/") {
+		rest = "/" + rest
+	}
+	return srv, rest, query
+}
+
+// templatePath rewrites :param and {{var}} path segments to OpenAPI {param}
+// templates and returns the parameter names in order of appearance.
+func templatePath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if m := colonParam.FindStringSubmatch(seg); m != nil {
+			segments[i] = "{" + m[1] + "}"
+			continue
+		}
+		segments[i] = templateVar.ReplaceAllStringFunc(seg, func(v string) string {
+			return "{" + strings.ReplaceAll(templateVar.FindStringSubmatch(v)[1], ".", "_") + "}"
+		})
+	}
+	path = strings.Join(segments, "/")
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range braceParam.FindAllStringSubmatch(path, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return path, names
+}
+
+// operationID derives a unique camelCase identifier from the request name,
+// falling back to the method and path.
+func (e *exporter) operationID(name, method, path string) string {
+	base := camelCase(name)
+	if base == "" {
+		base = camelCase(method + " " + path)
+	}
+	id := base
+	for n := 2; e.operationIDs[id]; n++ {
+		id = fmt.Sprintf("%s%d", base, n)
+	}
+	e.operationIDs[id] = true
+	return id
+}
+
+func camelCase(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for i, w := range words {
+		r := []rune(w)
+		if i == 0 {
+			r[0] = unicode.ToLower(r[0])
+		} else {
+			r[0] = unicode.ToUpper(r[0])
+		}
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+// example returns v as a parameter example, omitting unresolved templates.
+func example(v string) interface{} {
+	if v == "" || templateVar.MatchString(v) {
+		return nil
+	}
+	return v
+}
+
+func body(b *collection.Body, contentType string) *requestBody {
+	if b == nil || b.Type == "none" || strings.TrimSpace(b.Content) == "" {
+		return nil
+	}
+
+	mt := mediaType{Example: b.Content}
+	mime := "text/plain"
+	switch b.Type {
+	case "json":
+		mime = "application/json"
+	case "xml":
+		mime = "application/xml"
+	case "form":
+		mime = "application/x-www-form-urlencoded"
+	case "multipart":
+		mime = "multipart/form-data"
+	}
+	if contentType != "" {
+		mime, _, _ = strings.Cut(contentType, ";")
+		mime = strings.TrimSpace(mime)
+	}
+
+	switch {
+	case mime == "application/json" || strings.HasSuffix(mime, "+json"):
+		dec := json.NewDecoder(bytes.NewReader([]byte(b.Content)))
+		dec.UseNumber()
+		var v interface{}
+		if dec.Decode(&v) == nil {
+			mt = mediaType{Schema: inferSchema(v), Example: v}
+		}
+	case mime == "application/x-www-form-urlencoded" || mime == "multipart/form-data":
+		props := make(map[string]*schema)
+		fields := make(map[string]interface{})
+		for _, line := range strings.FieldsFunc(b.Content, func(r rune) bool { return r == '&' || r == '\n' }) {
+			k, v, _ := strings.Cut(strings.TrimSpace(line), "=")
+			if k == "" {
+				continue
+			}
+			props[k] = &schema{Type: "string"}
+			fields[k] = v
+		}
+		if len(props) > 0 {
+			mt = mediaType{Schema: &schema{Type: "object", Properties: props}, Example: fields}
+		}
+	}
+	return &requestBody{Content: map[string]mediaType{mime: mt}}
+}
+
+// inferSchema describes a decoded JSON value. Arrays take the schema of their
+// first element.
+func inferSchema(v interface{}) *schema {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		s := &schema{Type: "object", Properties: make(map[string]*schema, len(val))}
+		for k, child := range val {
+			s.Properties[k] = inferSchema(child)
+		}
+		return s
+	case []interface{}:
+		s := &schema{Type: "array", Items: &schema{}}
+		if len(val) > 0 {
+			s.Items = inferSchema(val[0])
+		}
+		return s
+	case string:
+		return &schema{Type: "string"}
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return &schema{Type: "integer"}
+		}
+		return &schema{Type: "number"}
+	case bool:
+		return &schema{Type: "boolean"}
+	default:
+		return &schema{Type: "null"}
+	}
+}
+
+// securityScheme registers the scheme described by auth and returns its
+// name, or "" when auth has no OpenAPI equivalent. Identical schemes share a
+// name.
+func (e *exporter) securityScheme(auth *collection.Auth) string {
+	if auth == nil {
+		return ""
+	}
+	var name string
+	var s *securityScheme
+	switch auth.Type {
+	case "basic":
+		name, s = "basicAuth", &securityScheme{Type: "http", Scheme: "basic"}
+	case "bearer":
+		name, s = "bearerAuth", &securityScheme{Type: "http", Scheme: "bearer"}
+	case "digest":
+		name, s = "digestAuth", &securityScheme{Type: "http", Scheme: "digest"}
+	case "apikey":
+		if auth.APIKey == nil || auth.APIKey.Key == "" {
+			return ""
+		}
+		in := auth.APIKey.In
+		if in != "query" {
+			in = "header"
+		}
+		name, s = "apiKeyAuth", &securityScheme{Type: "apiKey", Name: auth.APIKey.Key, In: in}
+	case "oauth2":
+		if auth.OAuth2 == nil {
+			return ""
+		}
+		o := auth.OAuth2
+		flow := &oauthFlow{TokenURL: o.TokenURL, Scopes: make(map[string]string)}
+		for _, sc := range strings.Fields(o.Scope) {
+			flow.Scopes[sc] = ""
+		}
+		flows := &oauthFlows{}
+		switch o.GrantType {
+		case "authorization_code":
+			flow.AuthorizationURL = o.AuthURL
+			flows.AuthorizationCode = flow
+		case "password":
+			flows.Password = flow
+		default:
+			flows.ClientCredentials = flow
+		}
+		name, s = "oauth2", &securityScheme{Type: "oauth2", Flows: flows}
+	default:
+		return ""
+	}
+
+	base := name
+	for n := 2; ; n++ {
+		existing, ok := e.schemes[name]
+		if !ok {
+			e.schemes[name] = s
+			return name
+		}
+		if reflect.DeepEqual(existing, s) {
+			return name
+		}
+		name = fmt.Sprintf("%s%d", base, n)
+	}
+}
+
+// scopes lists the OAuth2 scopes a security requirement asks for.
+func scopes(auth *collection.Auth) []string {
+	out := []string{}
+	if auth != nil && auth.Type == "oauth2" && auth.OAuth2 != nil {
+		out = append(out, strings.Fields(auth.OAuth2.Scope)...)
+		sort.Strings(out)
+	}
+	return out
+}
@@ -0,0 +1,251 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	importer "github.com/sadopc/gottp/internal/import/openapi"
+)
+
+func exportSpec(t *testing.T, col *collection.Collection) spec {
+	t.Helper()
+	data, err := Export(col)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestExportNil(t *testing.T) {
+	if _, err := Export(nil); err == nil {
+		t.Error("expected error for nil collection")
+	}
+}
+
+func TestExportPathsAndParameters(t *testing.T) {
+	col := &collection.Collection{
+		Name:      "Users API",
+		Variables: map[string]string{"baseUrl": "https://api.example.com"},
+		Items: []collection.Item{
+			{Folder: &collection.Folder{Name: "Users", Items: []collection.Item{
+				{Request: &collection.Request{
+					Name: "Get User", Method: "GET",
+					URL: "{{baseUrl}}/users/:id?expand=profile",
+					Params: []collection.KVPair{
+						{Key: "fields", Value: "name", Enabled: false},
+					},
+					Headers: []collection.KVPair{
+						{Key: "X-Trace", Value: "{{trace}}", Enabled: true},
+						{Key: "Authorization", Value: "Bearer x", Enabled: true},
+					},
+				}},
+				{Request: &collection.Request{
+					Name: "Get Order", Method: "GET",
+					URL: "{{baseUrl}}/users/{{userId}}/orders/{{orderId}}",
+				}},
+			}}},
+			{Request: &collection.Request{Name: "Stream", Protocol: "websocket", URL: "wss://x/ws"}},
+		},
+	}
+
+	s := exportSpec(t, col)
+	if s.OpenAPI != "3.1.0" || s.Info.Title != "Users API" {
+		t.Errorf("unexpected header: %s %q", s.OpenAPI, s.Info.Title)
+	}
+	if len(s.Servers) != 1 || s.Servers[0].URL != "{baseUrl}" ||
+		s.Servers[0].Variables["baseUrl"].Default != "https://api.example.com" {
+		t.Errorf("unexpected servers: %+v", s.Servers)
+	}
+	if len(s.Paths) != 2 {
+		t.Fatalf("expected 2 paths (websocket skipped), got %v", s.Paths)
+	}
+
+	op := s.Paths["/users/{id}"]["get"]
+	if op == nil {
+		t.Fatalf("missing GET /users/{id}: %v", s.Paths)
+	}
+	if op.OperationID != "getUser" || len(op.Tags) != 1 || op.Tags[0] != "Users" {
+		t.Errorf("unexpected operation: %+v", op)
+	}
+	want := []struct {
+		name, in string
+		required bool
+	}{
+		{"id", "path", true},
+		{"expand", "query", true},
+		{"fields", "query", false},
+		{"X-Trace", "header", true},
+	}
+	if len(op.Parameters) != len(want) {
+		t.Fatalf("expected %d parameters, got %+v", len(want), op.Parameters)
+	}
+	for i, w := range want {
+		p := op.Parameters[i]
+		if p.Name != w.name || p.In != w.in || p.Required != w.required {
+			t.Errorf("param %d = %+v, want %+v", i, p, w)
+		}
+	}
+	if op.Parameters[3].Example != nil {
+		t.Errorf("templated header should have no example, got %v", op.Parameters[3].Example)
+	}
+
+	orders := s.Paths["/users/{userId}/orders/{orderId}"]["get"]
+	if orders == nil || len(orders.Parameters) != 2 || orders.Parameters[1].Name != "orderId" {
+		t.Errorf("unexpected orders operation: %+v", orders)
+	}
+}
+
+func TestExportInfersJSONSchema(t *testing.T) {
+	col := &collection.Collection{
+		Name: "API",
+		Items: []collection.Item{
+			{Request: &collection.Request{
+				Name: "Create User", Method: "POST", URL: "https://api.example.com/users",
+				Body: &collection.Body{Type: "json", Content: `{"name":"Ada","age":36,"score":9.5,"admin":false,"tags":["a"],"address":{"city":"London"},"manager":null}`},
+			}},
+		},
+	}
+
+	s := exportSpec(t, col)
+	if len(s.Servers) != 1 || s.Servers[0].URL != "https://api.example.com" {
+		t.Errorf("unexpected servers: %+v", s.Servers)
+	}
+	op := s.Paths["/users"]["post"]
+	if op == nil || op.RequestBody == nil {
+		t.Fatalf("missing request body: %+v", op)
+	}
+	mt, ok := op.RequestBody.Content["application/json"]
+	if !ok || mt.Schema == nil || mt.Example == nil {
+		t.Fatalf("unexpected content: %+v", op.RequestBody.Content)
+	}
+	props := mt.Schema.Properties
+	types := map[string]string{
+		"name": "string", "age": "integer", "score": "number", "admin": "boolean",
+		"tags": "array", "address": "object", "manager": "null",
+	}
+	for field, typ := range types {
+		if props[field] == nil || props[field].Type != typ {
+			t.Errorf("%s: expected type %s, got %+v", field, typ, props[field])
+		}
+	}
+	if props["tags"].Items.Type != "string" {
+		t.Errorf("expected string items, got %+v", props["tags"].Items)
+	}
+	if props["address"].Properties["city"].Type != "string" {
+		t.Errorf("expected nested property, got %+v", props["address"])
+	}
+}
+
+func TestExportTemplatedJSONBody(t *testing.T) {
+	col := &collection.Collection{
+		Items: []collection.Item{
+			{Request: &collection.Request{
+				Name: "Create", Method: "POST", URL: "https://x/items",
+				Body: &collection.Body{Type: "json", Content: `{"id": {{id}}}`},
+			}},
+		},
+	}
+	op := exportSpec(t, col).Paths["/items"]["post"]
+	mt := op.RequestBody.Content["application/json"]
+	if mt.Schema != nil || mt.Example != `{"id": {{id}}}` {
+		t.Errorf("expected raw example without schema, got %+v", mt)
+	}
+}
+
+func TestExportSecuritySchemes(t *testing.T) {
+	col := &collection.Collection{
+		Name: "Secure",
+		Auth: &collection.Auth{Type: "bearer", Bearer: &collection.BearerAuth{Token: "t"}},
+		Items: []collection.Item{
+			{Request: &collection.Request{Name: "Inherited", Method: "GET", URL: "https://x/a"}},
+			{Request: &collection.Request{Name: "Public", Method: "GET", URL: "https://x/b",
+				Auth: &collection.Auth{Type: "none"}}},
+			{Request: &collection.Request{Name: "Key", Method: "GET", URL: "https://x/c",
+				Auth: &collection.Auth{Type: "apikey", APIKey: &collection.APIKeyAuth{Key: "api_key", Value: "v", In: "query"}}}},
+			{Request: &collection.Request{Name: "OAuth", Method: "GET", URL: "https://x/d",
+				Auth: &collection.Auth{Type: "oauth2", OAuth2: &collection.OAuth2Auth{
+					GrantType: "authorization_code", AuthURL: "https://auth/authorize",
+					TokenURL: "https://auth/token", Scope: "write read",
+				}}}},
+		},
+	}
+
+	s := exportSpec(t, col)
+	if len(s.Security) != 1 || s.Security[0]["bearerAuth"] == nil {
+		t.Errorf("expected global bearer security, got %+v", s.Security)
+	}
+	schemes := s.Components.SecuritySchemes
+	if len(schemes) != 3 {
+		t.Fatalf("expected 3 schemes, got %+v", schemes)
+	}
+	if b := schemes["bearerAuth"]; b.Type != "http" || b.Scheme != "bearer" {
+		t.Errorf("unexpected bearer scheme: %+v", b)
+	}
+	if k := schemes["apiKeyAuth"]; k.Type != "apiKey" || k.Name != "api_key" || k.In != "query" {
+		t.Errorf("unexpected apikey scheme: %+v", k)
+	}
+	flow := schemes["oauth2"].Flows.AuthorizationCode
+	if flow == nil || flow.AuthorizationURL != "https://auth/authorize" || flow.TokenURL != "https://auth/token" || len(flow.Scopes) != 2 {
+		t.Errorf("unexpected oauth2 flow: %+v", flow)
+	}
+
+	if op := s.Paths["/a"]["get"]; op.Security != nil {
+		t.Errorf("inherited auth should not override security, got %+v", *op.Security)
+	}
+	if op := s.Paths["/b"]["get"]; op.Security == nil || len(*op.Security) != 0 {
+		t.Errorf("auth none should clear security, got %+v", op.Security)
+	}
+	op := s.Paths["/d"]["get"]
+	if op.Security == nil || len((*op.Security)[0]["oauth2"]) != 2 || (*op.Security)[0]["oauth2"][0] != "read" {
+		t.Errorf("unexpected oauth2 requirement: %+v", op.Security)
+	}
+}
+
+func TestExportDuplicateOperationIDs(t *testing.T) {
+	col := &collection.Collection{
+		Items: []collection.Item{
+			{Request: &collection.Request{Name: "List", Method: "GET", URL: "https://x/a"}},
+			{Request: &collection.Request{Name: "List", Method: "GET", URL: "https://x/b"}},
+			{Request: &collection.Request{Name: "Other", Method: "GET", URL: "https://x/a"}},
+		},
+	}
+	s := exportSpec(t, col)
+	if s.Paths["/a"]["get"].OperationID != "list" || s.Paths["/b"]["get"].OperationID != "list2" {
+		t.Errorf("unexpected operation ids: %q %q", s.Paths["/a"]["get"].OperationID, s.Paths["/b"]["get"].OperationID)
+	}
+	if s.Paths["/a"]["get"].Summary != "List" {
+		t.Errorf("first request for a path should win, got %q", s.Paths["/a"]["get"].Summary)
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	col := &collection.Collection{
+		Name: "Round Trip",
+		Items: []collection.Item{
+			{Folder: &collection.Folder{Name: "Pets", Items: []collection.Item{
+				{Request: &collection.Request{Name: "Add Pet", Method: "POST", URL: "https://pets.example.com/pets",
+					Body: &collection.Body{Type: "json", Content: `{"name":"Rex"}`}}},
+			}}},
+		},
+	}
+	data, err := Export(col)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := importer.ParseOpenAPI(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Name != "Round Trip" || len(back.Items) != 1 || back.Items[0].Folder == nil {
+		t.Fatalf("unexpected import: %+v", back)
+	}
+	req := back.Items[0].Folder.Items[0].Request
+	if req.Method != "POST" || req.Name != "Add Pet" || req.Body == nil {
+		t.Errorf("unexpected request: %+v", req)
+	}
+}
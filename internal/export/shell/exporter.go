@@ -0,0 +1,607 @@
+// Package shell exports requests as a standalone bash script of curl calls.
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+// Step is a request in the script with the variables read from its
+// response for the requests after it.
+type Step struct {
+	Request *collection.Request
+	// Auth is the auth the request is sent with, its own or the
+	// collection's.
+	Auth *collection.Auth
+	// Variables are folder and request variables, written into the
+	// request rather than read from the environment.
+	Variables map[string]string
+	// Extracts maps variable names to JSONPath or jq expressions.
+	Extracts map[string]string
+	// Condition is a workflow step condition, noted in the script.
+	Condition string
+}
+
+var (
+	placeholder = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+	// capturePattern matches a post-script line such as
+	// gottp.setEnvVar("token", gottp.query("$.token"));
+	capturePattern = regexp.MustCompile(`gottp\.setEnvVar\(\s*["']([^"']+)["']\s*,\s*gottp\.query\(\s*["']([^"']+)["']\s*\)\s*\)`)
+	identPart      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	bracketName    = regexp.MustCompile(`\['([^']*)'\]`)
+	steppedSlice   = regexp.MustCompile(`\[[^\]]*:[^\]]*:`)
+	randomString   = regexp.MustCompile(`^\$randomString(?:\((\d*)\))?$`)
+)
+
+// maxNesting bounds variables that refer to other variables.
+const maxNesting = 8
+
+// Export renders steps as an executable bash script. Variables the
+// requests use come from the environment, with defaults as fallbacks;
+// those without a default must be set before running. Extracted values
+// are read with jq and available to later requests. The script stops at
+// the first failed request.
+func Export(title string, steps []Step, defaults map[string]string) []byte {
+	var b strings.Builder
+	b.WriteString("#!/usr/bin/env bash\n")
+	fmt.Fprintf(&b, "#\n# %s, exported from gottp.\n", oneLine(title))
+	b.WriteString("# Variables are read from the environment, e.g. GOTTP_base_url=http://localhost:8080 ./script.sh\n")
+	b.WriteString("set -euo pipefail\n\n")
+
+	extracted := map[string]bool{}
+	needJQ := false
+	for _, s := range steps {
+		for name := range s.Extracts {
+			extracted[name] = true
+			needJQ = true
+		}
+	}
+	if needJQ {
+		b.WriteString("command -v jq >/dev/null || { echo \"jq is required to read values from responses\" >&2; exit 1; }\n\n")
+	}
+
+	// Variables from the environment
+	used := map[string]bool{}
+	for _, s := range steps {
+		for _, name := range s.placeholders() {
+			if !extracted[name] {
+				used[name] = true
+			}
+		}
+	}
+	if len(used) > 0 {
+		names := make([]string, 0, len(used))
+		for name := range used {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			v := shellName(name)
+			if def, ok := defaults[name]; ok {
+				fmt.Fprintf(&b, "%s=\"${%s:-%s}\"\n", v, v, quoteInner(def, nil, 0))
+			} else {
+				fmt.Fprintf(&b, ": \"${%s:?set %s}\"\n", v, v)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	for i, s := range steps {
+		writeStep(&b, i+1, s)
+	}
+	return []byte(b.String())
+}
+
+// FolderSteps returns the requests in items, in order, with folder
+// variables and the collection's auth applied. Values a post-script saves
+// with gottp.setEnvVar(name, gottp.query(expr)) become extracts.
+func FolderSteps(col *collection.Collection, items []collection.Item, vars map[string]string) []Step {
+	var steps []Step
+	for _, item := range items {
+		switch {
+		case item.Folder != nil:
+			steps = append(steps, FolderSteps(col, item.Folder.Items, overlay(vars, item.Folder.Variables))...)
+		case item.Request != nil:
+			step := newStep(col, item.Request, vars)
+			for _, m := range capturePattern.FindAllStringSubmatch(item.Request.PostScript, -1) {
+				if step.Extracts == nil {
+					step.Extracts = make(map[string]string)
+				}
+				step.Extracts[m[1]] = m[2]
+			}
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// ItemSteps returns the steps for one request, or for a folder's
+// requests, with the variables of the folders around it.
+func ItemSteps(col *collection.Collection, target collection.Item) []Step {
+	steps, _ := itemSteps(col, col.Items, target, nil)
+	return steps
+}
+
+func itemSteps(col *collection.Collection, items []collection.Item, target collection.Item, vars map[string]string) ([]Step, bool) {
+	for _, item := range items {
+		switch {
+		case target.Folder != nil && item.Folder == target.Folder:
+			return FolderSteps(col, []collection.Item{item}, vars), true
+		case target.Request != nil && item.Request == target.Request:
+			return FolderSteps(col, []collection.Item{item}, vars), true
+		case item.Folder != nil:
+			if steps, ok := itemSteps(col, item.Folder.Items, target, overlay(vars, item.Folder.Variables)); ok {
+				return steps, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// WorkflowSteps returns the steps of a workflow, finding requests by name
+// as the runner does.
+func WorkflowSteps(col *collection.Collection, wf collection.Workflow) ([]Step, error) {
+	var steps []Step
+	for _, ws := range wf.Steps {
+		req, vars := findRequest(col.Items, ws.Request, nil)
+		if req == nil {
+			return nil, fmt.Errorf("workflow %q: request %q not found", wf.Name, ws.Request)
+		}
+		step := newStep(col, req, vars)
+		step.Extracts = ws.Extracts
+		step.Condition = ws.Condition
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func newStep(col *collection.Collection, req *collection.Request, vars map[string]string) Step {
+	step := Step{Request: req, Auth: req.Auth, Variables: overlay(vars, req.Variables)}
+	if step.Auth == nil {
+		step.Auth = col.Auth
+	}
+	return step
+}
+
+func findRequest(items []collection.Item, name string, vars map[string]string) (*collection.Request, map[string]string) {
+	for _, item := range items {
+		if item.Request != nil && strings.EqualFold(item.Request.Name, name) {
+			return item.Request, vars
+		}
+		if item.Folder != nil {
+			if req, v := findRequest(item.Folder.Items, name, overlay(vars, item.Folder.Variables)); req != nil {
+				return req, v
+			}
+		}
+	}
+	return nil, nil
+}
+
+func overlay(base, top map[string]string) map[string]string {
+	if len(top) == 0 {
+		return base
+	}
+	out := make(map[string]string, len(base)+len(top))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range top {
+		out[k] = v
+	}
+	return out
+}
+
+// writeStep writes the curl call for s, and the extracts reading its
+// response.
+func writeStep(b *strings.Builder, n int, s Step) {
+	req := s.Request
+	fmt.Fprintf(b, "# %d. %s\n", n, oneLine(req.Name))
+	if s.Condition != "" {
+		fmt.Fprintf(b, "# Condition, not checked here: %s\n", oneLine(s.Condition))
+	}
+	if req.Protocol != "" && req.Protocol != "http" && req.Protocol != "graphql" {
+		fmt.Fprintf(b, "# Skipped: %s requests can't be sent with curl\n\n", req.Protocol)
+		return
+	}
+	fmt.Fprintf(b, "echo %s >&2\n", s.quote("==> "+oneLine(req.Name)))
+
+	args := s.curlArgs()
+	if len(s.Extracts) > 0 {
+		b.WriteString("response=$(")
+	}
+	b.WriteString("curl --silent --show-error --fail-with-body")
+	for _, arg := range args {
+		b.WriteString(" \\\n  " + arg)
+	}
+	if len(s.Extracts) == 0 {
+		b.WriteString("\necho\n\n")
+		return
+	}
+	b.WriteString(")\nprintf '%s\\n' \"$response\"\n")
+
+	names := make([]string, 0, len(s.Extracts))
+	for name := range s.Extracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		expr := s.Extracts[name]
+		filter, ok := jqFilter(expr)
+		if !ok {
+			fmt.Fprintf(b, "# %s: jq has no equivalent of %s; set it by hand\n%s=\"\"\n", shellName(name), oneLine(expr), shellName(name))
+			continue
+		}
+		fmt.Fprintf(b, "%s=$(jq -er %s <<<\"$response\")\n", shellName(name), singleQuote(filter))
+	}
+	b.WriteString("\n")
+}
+
+// curlArgs returns the curl arguments for the step's request, quoted.
+func (s Step) curlArgs() []string {
+	req := s.Request
+	var args []string
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+
+	headers := map[string]bool{}
+	header := func(key, value string) {
+		headers[strings.ToLower(key)] = true
+		args = append(args, "--header "+s.quote(key+": "+value))
+	}
+	for _, h := range req.Headers {
+		if h.Enabled && h.Key != "" {
+			header(h.Key, h.Value)
+		}
+	}
+
+	// curl percent-encodes query values, including those from variables
+	var params []string
+	query := func(key, value string) {
+		params = append(params, "--url-query "+s.quote(key+"="+value))
+	}
+	for _, p := range req.Params {
+		if p.Enabled && p.Key != "" {
+			query(p.Key, p.Value)
+		}
+	}
+
+	// Auth
+	if auth := s.Auth; auth != nil {
+		switch auth.Type {
+		case "basic":
+			if auth.Basic != nil {
+				args = append(args, "--user "+s.quote(auth.Basic.Username+":"+auth.Basic.Password))
+			}
+		case "digest":
+			if auth.Digest != nil {
+				args = append(args, "--digest", "--user "+s.quote(auth.Digest.Username+":"+auth.Digest.Password))
+			}
+		case "bearer":
+			if auth.Bearer != nil {
+				header("Authorization", "Bearer "+auth.Bearer.Token)
+			}
+		case "apikey":
+			if auth.APIKey != nil {
+				if auth.APIKey.In == "query" {
+					query(auth.APIKey.Key, auth.APIKey.Value)
+				} else {
+					header(auth.APIKey.Key, auth.APIKey.Value)
+				}
+			}
+		case "oauth2":
+			// The token is fetched outside the script
+			header("Authorization", "Bearer {{oauth2_access_token}}")
+		case "awsv4":
+			if a := auth.AWSAuth; a != nil {
+				args = append(args, "--aws-sigv4 "+s.quote("aws:amz:"+a.Region+":"+a.Service),
+					"--user "+s.quote(a.AccessKeyID+":"+a.SecretAccessKey))
+				if a.SessionToken != "" {
+					header("X-Amz-Security-Token", a.SessionToken)
+				}
+			}
+		}
+	}
+
+	var cookies []string
+	for _, c := range req.Cookies {
+		if c.Enabled && c.Key != "" {
+			cookies = append(cookies, c.Key+"="+c.Value)
+		}
+	}
+	if len(cookies) > 0 {
+		args = append(args, "--cookie "+s.quote(strings.Join(cookies, "; ")))
+	}
+
+	// Body
+	var body []string
+	contentType := ""
+	switch {
+	case req.Protocol == "graphql" && req.GraphQL != nil:
+		payload := `{"query": ` + jsonString(req.GraphQL.Query)
+		if v := strings.TrimSpace(req.GraphQL.Variables); v != "" {
+			payload += `, "variables": ` + v
+		}
+		payload += "}"
+		body = append(body, "--data-raw "+s.quote(payload))
+		contentType = "application/json"
+		if req.Method == "" {
+			method = "POST"
+		}
+	case !req.Body.IsEmpty():
+		switch req.Body.Kind() {
+		case collection.BodyFormURLEncoded:
+			for _, f := range req.Body.Fields {
+				if f.Enabled && f.Key != "" {
+					body = append(body, "--data-urlencode "+s.quote(f.Key+"="+f.Value))
+				}
+			}
+			if len(req.Body.Fields) == 0 {
+				body = append(body, "--data-raw "+s.quote(req.Body.Content))
+			}
+		case collection.BodyMultipart:
+			for _, f := range req.Body.Fields {
+				if !f.Enabled || f.Key == "" {
+					continue
+				}
+				if f.File != "" {
+					body = append(body, "--form "+s.quote(f.Key+"=@"+f.File))
+				} else {
+					body = append(body, "--form-string "+s.quote(f.Key+"="+f.Value))
+				}
+			}
+		case collection.BodyBinaryFile:
+			body = append(body, "--data-binary "+s.quote("@"+req.Body.File))
+		default:
+			body = append(body, "--data-raw "+s.quote(req.Body.Content))
+			switch req.Body.Kind() {
+			case "json":
+				contentType = "application/json"
+			case "xml":
+				contentType = "application/xml"
+			}
+		}
+	}
+	if contentType != "" && !headers["content-type"] {
+		header("Content-Type", contentType)
+	}
+	args = append(args, body...)
+
+	// curl picks POST for a body and GET otherwise
+	switch {
+	case method == "HEAD":
+		args = append([]string{"--head"}, args...)
+	case method == "GET" && len(body) > 0, method != "GET" && method != "POST", method == "POST" && len(body) == 0:
+		args = append([]string{"--request " + method}, args...)
+	}
+
+	args = append(args, params...)
+	return append(args, s.quote(req.URL))
+}
+
+// placeholders returns the variables the step's request reads from the
+// environment.
+func (s Step) placeholders() []string {
+	var texts []string
+	req := s.Request
+	texts = append(texts, req.URL)
+	for _, list := range [][]collection.KVPair{req.Params, req.Headers, req.Cookies} {
+		for _, kv := range list {
+			if kv.Enabled {
+				texts = append(texts, kv.Key, kv.Value)
+			}
+		}
+	}
+	if req.Body != nil {
+		texts = append(texts, req.Body.Content, req.Body.File)
+		for _, f := range req.Body.Fields {
+			if f.Enabled {
+				texts = append(texts, f.Key, f.Value, f.File)
+			}
+		}
+	}
+	if req.GraphQL != nil {
+		texts = append(texts, req.GraphQL.Query, req.GraphQL.Variables)
+	}
+	if a := s.Auth; a != nil {
+		switch {
+		case a.Basic != nil && a.Type == "basic":
+			texts = append(texts, a.Basic.Username, a.Basic.Password)
+		case a.Digest != nil && a.Type == "digest":
+			texts = append(texts, a.Digest.Username, a.Digest.Password)
+		case a.Bearer != nil && a.Type == "bearer":
+			texts = append(texts, a.Bearer.Token)
+		case a.APIKey != nil && a.Type == "apikey":
+			texts = append(texts, a.APIKey.Key, a.APIKey.Value)
+		case a.Type == "oauth2":
+			texts = append(texts, "{{oauth2_access_token}}")
+		case a.AWSAuth != nil && a.Type == "awsv4":
+			texts = append(texts, a.AWSAuth.AccessKeyID, a.AWSAuth.SecretAccessKey, a.AWSAuth.SessionToken, a.AWSAuth.Region, a.AWSAuth.Service)
+		}
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	var visit func(text string, depth int)
+	visit = func(text string, depth int) {
+		for _, m := range placeholder.FindAllStringSubmatch(text, -1) {
+			name := m[1]
+			if strings.HasPrefix(name, "$") || seen[name] || depth > maxNesting {
+				continue
+			}
+			if v, ok := s.Variables[name]; ok {
+				visit(v, depth+1)
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, t := range texts {
+		visit(t, 0)
+	}
+	return names
+}
+
+// quote returns text as a double-quoted shell word, with {{name}}
+// placeholders as shell variables or the step's values for them.
+func (s Step) quote(text string) string {
+	return `"` + quoteInner(text, s.Variables, 0) + `"`
+}
+
+func quoteInner(text string, vars map[string]string, depth int) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range placeholder.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(escape(text[last:loc[0]]))
+		last = loc[1]
+		name := text[loc[2]:loc[3]]
+		if v, ok := vars[name]; ok && depth < maxNesting {
+			b.WriteString(quoteInner(v, vars, depth+1))
+			continue
+		}
+		if dyn, ok := dynamic(name); ok {
+			b.WriteString(dyn)
+			continue
+		}
+		if strings.HasPrefix(name, "$") {
+			b.WriteString(escape(text[loc[0]:loc[1]]))
+			continue
+		}
+		b.WriteString("${" + shellName(name) + "}")
+	}
+	b.WriteString(escape(text[last:]))
+	return b.String()
+}
+
+// dynamic returns shell equivalents of built-in variables.
+func dynamic(name string) (string, bool) {
+	switch name {
+	case "$uuid", "$guid":
+		return "$(uuidgen)", true
+	case "$timestamp":
+		return "$(date +%s)", true
+	case "$isoDate", "$isoTimestamp":
+		return "$(date -u +%Y-%m-%dT%H:%M:%SZ)", true
+	case "$randomInt":
+		return "$((RANDOM % 10000))", true
+	case "$randomEmail":
+		return "user-" + randomChars("a-z0-9", 8) + "@example.com", true
+	}
+	if m := randomString.FindStringSubmatch(name); m != nil {
+		// The same length and limit as environment.ResolveDynamic
+		n := 16
+		if m[1] != "" {
+			n, _ = strconv.Atoi(m[1])
+		}
+		return randomChars("A-Za-z0-9", min(n, 1024)), true
+	}
+	return "", false
+}
+
+// randomChars returns a command printing n random characters from set, a
+// tr character class. cut reads all of its input, so unlike head it
+// doesn't fail the pipeline with SIGPIPE under pipefail.
+func randomChars(set string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("$(head -c %d /dev/urandom | LC_ALL=C tr -dc %s | cut -c1-%d)", 8*n+512, set, n)
+}
+
+// escape escapes the characters special inside double quotes.
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return r.Replace(s)
+}
+
+func singleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellName turns a variable name into a valid shell variable name. The
+// GOTTP_ prefix keeps names such as PATH, HOME or response from clobbering
+// the environment or the script's own variables.
+func shellName(name string) string {
+	var b strings.Builder
+	b.WriteString("GOTTP_")
+	for _, r := range name {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// jqFilter converts a workflow extract, a JSONPath, jq expression or bare
+// field name, to a jq filter. ok is false for JMESPath and for JSONPath jq
+// can't express simply, such as filters and recursive descent.
+func jqFilter(expr string) (string, bool) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, ".") {
+		return expr, true
+	}
+	if strings.HasPrefix(expr, "jmespath:") {
+		return "", false
+	}
+	path, ok := strings.CutPrefix(expr, "$")
+	if !ok {
+		path = "." + expr
+	}
+	if strings.Contains(path, "..") || strings.Contains(path, "[?") || strings.Contains(path, ",") || steppedSlice.MatchString(path) {
+		return "", false
+	}
+	path = strings.ReplaceAll(path, "[*]", "[]")
+	if strings.Contains(path, "*") {
+		return "", false
+	}
+	path = bracketName.ReplaceAllString(path, `["$1"]`)
+
+	// Quote names jq doesn't accept bare, such as .content-type
+	var b strings.Builder
+	for i := 0; i < len(path); {
+		if path[i] != '.' {
+			b.WriteByte(path[i])
+			i++
+			continue
+		}
+		end := i + 1
+		for end < len(path) && path[end] != '.' && path[end] != '[' {
+			end++
+		}
+		name := path[i+1 : end]
+		if name == "" || identPart.MatchString(name) {
+			b.WriteString("." + name)
+		} else {
+			b.WriteString("." + jsonString(name))
+		}
+		i = end
+	}
+	filter := b.String()
+	if !strings.HasPrefix(filter, ".") {
+		filter = "." + filter // [0] alone would be an array literal
+	}
+	return filter, true
+}
+
+// jsonString returns s as a JSON string literal.
+func jsonString(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
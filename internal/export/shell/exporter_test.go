@@ -0,0 +1,227 @@
+package shell
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+func testCollection() *collection.Collection {
+	return &collection.Collection{
+		Name:      "Shop",
+		Variables: map[string]string{"base_url": "http://localhost:8080"},
+		Auth:      &collection.Auth{Type: "bearer", Bearer: &collection.BearerAuth{Token: "{{token}}"}},
+		Items: []collection.Item{
+			{Folder: &collection.Folder{
+				Name:      "Orders",
+				Variables: map[string]string{"api": "{{base_url}}/v2"},
+				Items: []collection.Item{
+					{Request: &collection.Request{
+						Name:   "Create Order",
+						Method: "POST",
+						URL:    "{{api}}/orders",
+						Body:   &collection.Body{Type: "json", Content: `{"sku": "{{sku}}", "note": "costs $5"}`},
+						PostScript: `gottp.setEnvVar("order_id", gottp.query("$.order.id"));
+gottp.log("created");`,
+					}},
+					{Request: &collection.Request{
+						Name:   "Get Order",
+						Method: "GET",
+						URL:    "{{api}}/orders/{{order_id}}",
+						Params: []collection.KVPair{{Key: "expand", Value: "items", Enabled: true}},
+						Auth:   &collection.Auth{Type: "basic", Basic: &collection.BasicAuth{Username: "admin", Password: "it's"}},
+					}},
+				},
+			}},
+		},
+		Workflows: []collection.Workflow{{
+			Name: "Checkout",
+			Steps: []collection.WorkflowStep{
+				{Request: "create order", Extracts: map[string]string{"order_id": "$.order.id", "tags": "$..tag"}},
+				{Request: "Get Order", Condition: "status == 200"},
+			},
+		}},
+	}
+}
+
+func TestExportFolder(t *testing.T) {
+	col := testCollection()
+	steps := FolderSteps(col, col.Items, nil)
+	if len(steps) != 2 {
+		t.Fatalf("got %d steps, want 2", len(steps))
+	}
+	if steps[0].Extracts["order_id"] != "$.order.id" {
+		t.Errorf("capture not read from post-script: %v", steps[0].Extracts)
+	}
+
+	script := string(Export(col.Name, steps, col.Variables))
+	for _, want := range []string{
+		"#!/usr/bin/env bash\n",
+		"set -euo pipefail\n",
+		"command -v jq",
+		`GOTTP_base_url="${GOTTP_base_url:-http://localhost:8080}"`,
+		`: "${GOTTP_sku:?set GOTTP_sku}"`,
+		`: "${GOTTP_token:?set GOTTP_token}"`,
+		`--header "Authorization: Bearer ${GOTTP_token}"`,
+		`--header "Content-Type: application/json"`,
+		`--data-raw "{\"sku\": \"${GOTTP_sku}\", \"note\": \"costs \$5\"}"`,
+		`"${GOTTP_base_url}/v2/orders")`,
+		`GOTTP_order_id=$(jq -er '.order.id' <<<"$response")`,
+		`--user "admin:it's"`,
+		`--url-query "expand=items"`,
+		`"${GOTTP_base_url}/v2/orders/${GOTTP_order_id}"` + "\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "${GOTTP_order_id:?") || strings.Contains(script, "${GOTTP_api") {
+		t.Errorf("extracted or folder variables read from the environment:\n%s", script)
+	}
+	if strings.Contains(script, "--request POST") {
+		t.Error("POST with a body should leave the method to curl")
+	}
+
+	if bash, err := exec.LookPath("bash"); err == nil {
+		path := filepath.Join(t.TempDir(), "script.sh")
+		if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command(bash, "-n", path).CombinedOutput(); err != nil {
+			t.Errorf("bash -n: %v\n%s", err, out)
+		}
+	}
+}
+
+func TestExportWorkflow(t *testing.T) {
+	col := testCollection()
+	steps, err := WorkflowSteps(col, col.Workflows[0])
+	if err != nil {
+		t.Fatalf("WorkflowSteps: %v", err)
+	}
+	script := string(Export("Checkout", steps, nil))
+	for _, want := range []string{
+		`GOTTP_order_id=$(jq -er '.order.id' <<<"$response")`,
+		"# GOTTP_tags: jq has no equivalent of $..tag; set it by hand",
+		"# Condition, not checked here: status == 200",
+		`: "${GOTTP_base_url:?set GOTTP_base_url}"`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+
+	col.Workflows[0].Steps[0].Request = "Missing"
+	if _, err := WorkflowSteps(col, col.Workflows[0]); err == nil {
+		t.Error("expected an error for a missing request")
+	}
+}
+
+func TestItemSteps(t *testing.T) {
+	col := testCollection()
+	get := col.Items[0].Folder.Items[1].Request
+	steps := ItemSteps(col, collection.Item{Request: get})
+	if len(steps) != 1 || steps[0].Request != get {
+		t.Fatalf("steps = %+v", steps)
+	}
+	if steps[0].Variables["api"] != "{{base_url}}/v2" {
+		t.Errorf("folder variables not applied: %v", steps[0].Variables)
+	}
+	if steps[0].Auth.Type != "basic" {
+		t.Errorf("auth = %+v", steps[0].Auth)
+	}
+}
+
+func TestJQFilter(t *testing.T) {
+	tests := []struct {
+		expr, want string
+		ok         bool
+	}{
+		{"$.data.token", ".data.token", true},
+		{"$.items[0].id", ".items[0].id", true},
+		{"$.items[*].id", ".items[].id", true},
+		{"$['content-type']", `.["content-type"]`, true},
+		{"$.headers.x-id", `.headers."x-id"`, true},
+		{".items[] | .id", ".items[] | .id", true},
+		{"token", ".token", true},
+		{"$", ".", true},
+		{"$..id", "", false},
+		{"$.items[?(@.ok)]", "", false},
+		{"$.items[::-1]", "", false},
+		{"jmespath:items[0].id", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := jqFilter(tt.expr)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("jqFilter(%q) = %q, %v; want %q, %v", tt.expr, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDynamicVariables(t *testing.T) {
+	s := Step{}
+	word := s.quote("{{$randomEmail}} {{$randomString(4)}} {{$randomString}} {{PATH}}")
+	if strings.Contains(word, "{{") || !strings.Contains(word, "${GOTTP_PATH}") {
+		t.Fatalf("quote = %s", word)
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	out, err := exec.Command(bash, "-c", "set -euo pipefail; GOTTP_PATH=p; v="+word+"; printf '%s' \"$v\"").CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash: %v\n%s", err, out)
+	}
+	if ok, _ := regexp.MatchString(`^user-[a-z0-9]{8}@example\.com [A-Za-z0-9]{4} [A-Za-z0-9]{16} p$`, string(out)); !ok {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestQueryParamsEncoded(t *testing.T) {
+	var got url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+	}))
+	defer server.Close()
+
+	req := &collection.Request{
+		Name:   "Search",
+		Method: "GET",
+		URL:    server.URL + "/search?page=1",
+		Params: []collection.KVPair{{Key: "q", Value: "a b&c", Enabled: true}, {Key: "tag", Value: "{{tag}}", Enabled: true}},
+	}
+	auth := &collection.Auth{Type: "apikey", APIKey: &collection.APIKeyAuth{Key: "key", Value: "x+y#z", In: "query"}}
+	script := string(Export("Search", []Step{{Request: req, Auth: auth}}, nil))
+	for _, want := range []string{`--url-query "q=a b&c"`, `--url-query "tag=${GOTTP_tag}"`, `--url-query "key=x+y#z"`} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	if help, err := exec.Command("curl", "--help", "all").Output(); err != nil || !strings.Contains(string(help), "--url-query") {
+		t.Skip("curl with --url-query not found")
+	}
+	cmd := exec.Command(bash, "-c", script)
+	cmd.Env = append(os.Environ(), "GOTTP_tag=p&q")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("script: %v\n%s", err, out)
+	}
+	want := url.Values{"page": {"1"}, "q": {"a b&c"}, "tag": {"p&q"}, "key": {"x+y#z"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("server saw query %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Snippet is an annotated excerpt of a response body, shared as Markdown
+// in bug reports and chats.
+type Snippet struct {
+	Method    string
+	URL       string
+	Status    string
+	Lang      string   // code fence language, e.g. json
+	StartLine int      // 1-based line number of Lines[0] in the body
+	Lines     []string // the highlighted lines
+	Total     int      // number of lines in the body
+	Note      string
+}
+
+// Markdown renders the snippet: the request, the highlighted lines in a
+// fenced code block, and the note as a quote.
+func (s Snippet) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "**%s %s**", s.Method, s.URL)
+	if s.Status != "" {
+		fmt.Fprintf(&b, " → `%s`", s.Status)
+	}
+	b.WriteString("\n\n")
+
+	end := s.StartLine + len(s.Lines) - 1
+	switch {
+	case s.StartLine == 1 && end >= s.Total:
+		b.WriteString("Response body:\n\n")
+	case len(s.Lines) == 1:
+		fmt.Fprintf(&b, "Response body, line %d of %d:\n\n", s.StartLine, s.Total)
+	default:
+		fmt.Fprintf(&b, "Response body, lines %d-%d of %d:\n\n", s.StartLine, end, s.Total)
+	}
+
+	body := strings.Join(s.Lines, "\n")
+	fence := codeFence(body)
+	b.WriteString(fence + s.Lang + "\n" + body + "\n" + fence + "\n")
+
+	if note := strings.TrimSpace(s.Note); note != "" {
+		b.WriteString("\n")
+		for _, line := range strings.Split(note, "\n") {
+			b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// codeFence returns a backtick fence longer than any run of backticks in
+// body, so the body cannot close it early.
+func codeFence(body string) string {
+	longest, run := 0, 0
+	for _, r := range body {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
@@ -0,0 +1,39 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSnippetMarkdown(t *testing.T) {
+	s := Snippet{
+		Method:    "GET",
+		URL:       "https://api.example.com/users",
+		Status:    "500 Internal Server Error",
+		Lang:      "json",
+		StartLine: 3,
+		Lines:     []string{`  "error": "boom",`, `  "code": 17`},
+		Total:     5,
+		Note:      "Started after the deploy.\n\nOnly for admins.",
+	}
+	want := "**GET https://api.example.com/users** → `500 Internal Server Error`\n\n" +
+		"Response body, lines 3-4 of 5:\n\n" +
+		"```json\n  \"error\": \"boom\",\n  \"code\": 17\n```\n\n" +
+		"> Started after the deploy.\n>\n> Only for admins.\n"
+	if got := s.Markdown(); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+
+	// The whole body, no note
+	s = Snippet{Method: "POST", URL: "/x", Lines: []string{"ok"}, StartLine: 1, Total: 1}
+	if got := s.Markdown(); got != "**POST /x**\n\nResponse body:\n\n```\nok\n```\n" {
+		t.Errorf("whole body: %q", got)
+	}
+
+	// Backticks in the body lengthen the fence
+	s = Snippet{Method: "GET", URL: "/md", Lines: []string{"see ```code```"}, StartLine: 2, Total: 4}
+	got := s.Markdown()
+	if !strings.Contains(got, "line 2 of 4") || !strings.Contains(got, "````\nsee ```code```\n````") {
+		t.Errorf("fenced body: %q", got)
+	}
+}
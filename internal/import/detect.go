@@ -2,9 +2,19 @@ package importutil
 
 import (
 	"encoding/json"
+	"regexp"
 	"strings"
 )
 
+var (
+	// requestLine matches the first request of an .http or Hurl file; the
+	// target must be absolute or start with a {{variable}}.
+	requestLine = regexp.MustCompile(`^(?:(?:GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|TRACE|CONNECT)\s+)?(?:https?://|\{\{)`)
+	// hurlMarker matches lines only Hurl files have: expected status lines
+	// and [Section] headers.
+	hurlMarker = regexp.MustCompile(`(?m)^\s*(?:HTTP(?:/[\d.]+)?\s+(?:\d{3}|\*)|\[(?:QueryStringParams|Query|FormParams|Form|MultipartFormData|Multipart|Cookies|BasicAuth|Options|Captures|Asserts)\])\s*$`)
+)
+
 // DetectFormat inspects the data and returns the detected import format.
 func DetectFormat(data []byte) string {
 	s := strings.TrimSpace(string(data))
@@ -50,5 +60,20 @@ func DetectFormat(data []byte) string {
 		return "openapi"
 	}
 
+	// .http and Hurl files: comments and variables, then a request line
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "@") {
+			continue
+		}
+		if !requestLine.MatchString(line) {
+			break
+		}
+		if hurlMarker.MatchString(s) {
+			return "hurl"
+		}
+		return "http"
+	}
+
 	return "unknown"
 }
@@ -43,6 +43,16 @@ func TestDetectFormat(t *testing.T) {
 			data: `{"log":{"version":"1.2","entries":[{"request":{"method":"GET","url":"http://example.com"}}]}}`,
 			want: "har",
 		},
+		{
+			name: "detects http file",
+			data: "@host = https://api.example.com\n\n### List users\nGET {{host}}/users\nAccept: application/json\n",
+			want: "http",
+		},
+		{
+			name: "detects hurl file",
+			data: "# Login\nPOST https://api.example.com/login\n[FormParams]\nuser: bob\nHTTP 200\n",
+			want: "hurl",
+		},
 		{
 			name: "returns unknown for plain text",
 			data: "GET /users HTTP/1.1",
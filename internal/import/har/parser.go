@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sadopc/gottp/internal/core/collection"
@@ -44,6 +46,7 @@ type HARRequest struct {
 	HTTPVersion string       `json:"httpVersion"`
 	Headers     []HARHeader  `json:"headers"`
 	QueryString []HARQuery   `json:"queryString"`
+	Cookies     []HARCookie  `json:"cookies,omitempty"`
 	PostData    *HARPostData `json:"postData,omitempty"`
 	HeadersSize int          `json:"headersSize"`
 	BodySize    int          `json:"bodySize"`
@@ -72,10 +75,26 @@ type HARQuery struct {
 	Value string `json:"value"`
 }
 
-// HARPostData is the body of a request.
+// HARCookie is a cookie sent with a request.
+type HARCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the body of a request. Browsers record form bodies either
+// as Text or, for some multipart uploads, only as Params.
 type HARPostData struct {
-	MimeType string `json:"mimeType"`
-	Text     string `json:"text"`
+	MimeType string     `json:"mimeType"`
+	Text     string     `json:"text"`
+	Params   []HARParam `json:"params,omitempty"`
+}
+
+// HARParam is a posted form field or file.
+type HARParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
 }
 
 // HARContent is the body of a response.
@@ -95,7 +114,8 @@ type HARTimings struct {
 	Receive float64 `json:"receive"`
 }
 
-// ParseHAR parses a HAR file and returns a collection.
+// ParseHAR parses a HAR file and returns a collection with one folder per
+// host. Requests are ordered by the time they were started.
 func ParseHAR(data []byte) (*collection.Collection, error) {
 	var har HAR
 	if err := json.Unmarshal(data, &har); err != nil {
@@ -116,9 +136,32 @@ func ParseHAR(data []byte) (*collection.Collection, error) {
 		Version: "1.0",
 	}
 
-	for _, entry := range har.Log.Entries {
-		item := convertEntry(entry)
-		col.Items = append(col.Items, item)
+	// Replay order follows the capture, not the order entries were written
+	entries := append([]HAREntry(nil), har.Log.Entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		ti, erri := time.Parse(time.RFC3339Nano, entries[i].StartedDateTime)
+		tj, errj := time.Parse(time.RFC3339Nano, entries[j].StartedDateTime)
+		return erri == nil && errj == nil && ti.Before(tj)
+	})
+
+	// One folder per host, in order of first appearance
+	folders := make(map[string]*collection.Folder)
+	var hosts []string
+	for _, entry := range entries {
+		host := "Other"
+		if u, err := url.Parse(entry.Request.URL); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		folder, ok := folders[host]
+		if !ok {
+			folder = &collection.Folder{Name: host}
+			folders[host] = folder
+			hosts = append(hosts, host)
+		}
+		folder.Items = append(folder.Items, convertEntry(entry))
+	}
+	for _, host := range hosts {
+		col.Items = append(col.Items, collection.Item{Folder: folders[host]})
 	}
 
 	return col, nil
@@ -154,11 +197,15 @@ func convertEntry(entry HAREntry) collection.Item {
 	}
 
 	// Headers (skip pseudo-headers and common browser headers)
+	hasCookie := false
 	for _, h := range entry.Request.Headers {
 		lowerName := strings.ToLower(h.Name)
 		if strings.HasPrefix(lowerName, ":") {
 			continue // skip HTTP/2 pseudo-headers
 		}
+		if lowerName == "cookie" {
+			hasCookie = true
+		}
 		req.Headers = append(req.Headers, collection.KVPair{
 			Key:     h.Name,
 			Value:   h.Value,
@@ -166,6 +213,19 @@ func convertEntry(entry HAREntry) collection.Item {
 		})
 	}
 
+	// Cookies, unless the capture already kept the Cookie header
+	if !hasCookie && len(entry.Request.Cookies) > 0 {
+		pairs := make([]string, 0, len(entry.Request.Cookies))
+		for _, c := range entry.Request.Cookies {
+			pairs = append(pairs, c.Name+"="+c.Value)
+		}
+		req.Headers = append(req.Headers, collection.KVPair{
+			Key:     "Cookie",
+			Value:   strings.Join(pairs, "; "),
+			Enabled: true,
+		})
+	}
+
 	// Query params
 	for _, q := range entry.Request.QueryString {
 		req.Params = append(req.Params, collection.KVPair{
@@ -176,17 +236,53 @@ func convertEntry(entry HAREntry) collection.Item {
 	}
 
 	// Body
-	if entry.Request.PostData != nil && entry.Request.PostData.Text != "" {
-		bodyType := detectBodyType(entry.Request.PostData.MimeType)
-		req.Body = &collection.Body{
-			Type:    bodyType,
-			Content: entry.Request.PostData.Text,
+	if pd := entry.Request.PostData; pd != nil {
+		if pd.Text == "" && len(pd.Params) > 0 {
+			req.Body = paramsBody(pd)
+			if req.Body.Type == collection.BodyMultipart {
+				// The captured boundary doesn't match the one sent on replay
+				req.Headers = dropHeader(req.Headers, "Content-Type")
+			}
+		} else if pd.Text != "" {
+			req.Body = &collection.Body{
+				Type:    detectBodyType(pd.MimeType),
+				Content: pd.Text,
+			}
 		}
 	}
 
 	return collection.Item{Request: req}
 }
 
+// paramsBody turns posted fields into a form body. Uploaded files keep only
+// their name since HAR does not record their contents.
+func paramsBody(pd *HARPostData) *collection.Body {
+	body := &collection.Body{Type: detectBodyType(pd.MimeType)}
+	for _, p := range pd.Params {
+		field := collection.BodyField{Key: p.Name, Value: p.Value, Enabled: true}
+		if p.FileName != "" {
+			field = collection.BodyField{Key: p.Name, File: p.FileName, Enabled: true}
+			body.Type = collection.BodyMultipart
+		}
+		body.Fields = append(body.Fields, field)
+	}
+	if body.Type != collection.BodyMultipart {
+		body.Type = collection.BodyFormURLEncoded
+	}
+	return body
+}
+
+// dropHeader removes every header named name.
+func dropHeader(headers []collection.KVPair, name string) []collection.KVPair {
+	kept := headers[:0]
+	for _, h := range headers {
+		if !strings.EqualFold(h.Key, name) {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
 func detectBodyType(mimeType string) string {
 	lower := strings.ToLower(mimeType)
 	switch {
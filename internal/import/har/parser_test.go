@@ -1,7 +1,10 @@
 package har
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
 )
 
 func TestParseHAR(t *testing.T) {
@@ -88,12 +91,19 @@ func TestParseHAR(t *testing.T) {
 		t.Errorf("expected 'HAR Import (Browser DevTools)', got %q", col.Name)
 	}
 
-	if len(col.Items) != 2 {
-		t.Fatalf("expected 2 items, got %d", len(col.Items))
+	if len(col.Items) != 1 || col.Items[0].Folder == nil {
+		t.Fatalf("expected 1 host folder, got %+v", col.Items)
+	}
+	folder := col.Items[0].Folder
+	if folder.Name != "api.example.com" {
+		t.Errorf("expected folder 'api.example.com', got %q", folder.Name)
+	}
+	if len(folder.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(folder.Items))
 	}
 
 	// First entry: GET with query params
-	getReq := col.Items[0].Request
+	getReq := folder.Items[0].Request
 	if getReq == nil {
 		t.Fatal("expected request for first item")
 	}
@@ -111,7 +121,7 @@ func TestParseHAR(t *testing.T) {
 	}
 
 	// Second entry: POST with body
-	postReq := col.Items[1].Request
+	postReq := folder.Items[1].Request
 	if postReq == nil {
 		t.Fatal("expected request for second item")
 	}
@@ -167,7 +177,7 @@ func TestParseHAR_PseudoHeaders(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	req := col.Items[0].Request
+	req := col.Items[0].Folder.Items[0].Request
 	// Should only have "Accept", not :method or :path
 	if len(req.Headers) != 1 {
 		t.Errorf("expected 1 header (pseudo-headers filtered), got %d", len(req.Headers))
@@ -212,3 +222,131 @@ func TestParseHAR_BodyTypes(t *testing.T) {
 		}
 	}
 }
+
+func harEntry(started, method, rawURL, extra string) string {
+	return `{
+		"startedDateTime": "` + started + `",
+		"time": 10,
+		"request": {
+			"method": "` + method + `",
+			"url": "` + rawURL + `",
+			"httpVersion": "HTTP/1.1",
+			"headers": [],
+			"queryString": []` + extra + `
+		},
+		"response": {"status": 200, "statusText": "OK", "headers": [], "content": {"size": 0, "mimeType": ""}}
+	}`
+}
+
+func TestParseHAR_GroupsByHostInCaptureOrder(t *testing.T) {
+	data := []byte(`{"log": {"version": "1.2", "entries": [` +
+		harEntry("2024-01-01T00:00:03.000Z", "GET", "https://b.example.com/third", "") + `,` +
+		harEntry("2024-01-01T00:00:01.000Z", "GET", "https://a.example.com/first", "") + `,` +
+		harEntry("2024-01-01T00:00:02.000Z", "GET", "https://b.example.com/second", "") + `,` +
+		harEntry("2024-01-01T00:00:04.000Z", "GET", "https://a.example.com/fourth", "") +
+		`]}}`)
+
+	col, err := ParseHAR(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string][]string{
+		"a.example.com": {"GET /first", "GET /fourth"},
+		"b.example.com": {"GET /second", "GET /third"},
+	}
+	if len(col.Items) != 2 || col.Items[0].Folder.Name != "a.example.com" || col.Items[1].Folder.Name != "b.example.com" {
+		t.Fatalf("unexpected folders: %+v", col.Items)
+	}
+	for _, item := range col.Items {
+		names := want[item.Folder.Name]
+		if len(item.Folder.Items) != len(names) {
+			t.Fatalf("%s: expected %d requests, got %d", item.Folder.Name, len(names), len(item.Folder.Items))
+		}
+		for i, name := range names {
+			if got := item.Folder.Items[i].Request.Name; got != name {
+				t.Errorf("%s[%d] = %q, want %q", item.Folder.Name, i, got, name)
+			}
+		}
+	}
+}
+
+func TestParseHAR_CookiesAndParams(t *testing.T) {
+	extra := `,
+			"headers": [{"name": "Content-Type", "value": "multipart/form-data; boundary=x"}],
+			"cookies": [{"name": "session", "value": "abc"}, {"name": "theme", "value": "dark"}],
+			"postData": {
+				"mimeType": "multipart/form-data; boundary=x",
+				"params": [
+					{"name": "title", "value": "a b"},
+					{"name": "upload", "fileName": "photo.png", "contentType": "image/png"}
+				]
+			}`
+	data := []byte(`{"log": {"version": "1.2", "entries": [` +
+		harEntry("2024-01-01T00:00:00.000Z", "POST", "https://example.com/upload", extra) + `]}}`)
+
+	col, err := ParseHAR(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := col.Items[0].Folder.Items[0].Request
+
+	if len(req.Headers) != 1 || req.Headers[0].Key != "Cookie" || req.Headers[0].Value != "session=abc; theme=dark" {
+		t.Errorf("expected Cookie header from cookies, got %+v", req.Headers)
+	}
+	if req.Body == nil || req.Body.Type != "multipart" {
+		t.Fatalf("expected multipart body, got %+v", req.Body)
+	}
+	want := []collection.BodyField{
+		{Key: "title", Value: "a b", Enabled: true},
+		{Key: "upload", File: "photo.png", Enabled: true},
+	}
+	if req.Body.Content != "" || !reflect.DeepEqual(req.Body.Fields, want) {
+		t.Errorf("unexpected body: %+v", req.Body)
+	}
+}
+
+func TestParseHAR_URLEncodedParams(t *testing.T) {
+	extra := `,
+			"headers": [{"name": "Content-Type", "value": "application/x-www-form-urlencoded"}],
+			"postData": {
+				"mimeType": "application/x-www-form-urlencoded",
+				"params": [{"name": "q", "value": "a&b"}]
+			}`
+	data := []byte(`{"log": {"version": "1.2", "entries": [` +
+		harEntry("2024-01-01T00:00:00.000Z", "POST", "https://example.com/search", extra) + `]}}`)
+
+	col, err := ParseHAR(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := col.Items[0].Folder.Items[0].Request
+	if req.Body.Kind() != collection.BodyFormURLEncoded || len(req.Body.Fields) != 1 || req.Body.Fields[0].Value != "a&b" {
+		t.Errorf("unexpected body: %+v", req.Body)
+	}
+	if len(req.Headers) != 1 || req.Headers[0].Key != "Content-Type" {
+		t.Errorf("urlencoded Content-Type should be kept, got %+v", req.Headers)
+	}
+}
+
+func TestParseHAR_CookieHeaderWins(t *testing.T) {
+	data := []byte(`{"log": {"version": "1.2", "entries": [{
+		"startedDateTime": "2024-01-01T00:00:00.000Z",
+		"request": {
+			"method": "GET",
+			"url": "https://example.com/",
+			"headers": [{"name": "Cookie", "value": "session=abc"}],
+			"cookies": [{"name": "session", "value": "abc"}]
+		},
+		"response": {"status": 200}
+	}]}}`)
+
+	col, err := ParseHAR(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := col.Items[0].Folder.Items[0].Request
+	if len(req.Headers) != 1 {
+		t.Errorf("expected the recorded Cookie header only, got %+v", req.Headers)
+	}
+}
@@ -0,0 +1,61 @@
+package httpfile
+
+import "testing"
+
+func FuzzParseHTTPFile(f *testing.F) {
+	// Seed: variables, named requests, a body and a response handler
+	f.Add([]byte(`@host = https://api.example.com
+
+### Create user
+# @name createUser
+POST {{host}}/users HTTP/1.1
+Content-Type: application/json
+
+{"name": "Ada"}
+
+> {% client.global.set("id", response.body.id); %}
+`))
+
+	// Seed: query continuation lines and basic auth shorthand
+	f.Add([]byte(`GET https://example.com/search
+    ?q=go
+    &page=2
+Authorization: Basic user:pass
+`))
+
+	// Seed: form body and file body
+	f.Add([]byte(`POST https://example.com/login
+Content-Type: application/x-www-form-urlencoded
+
+a=1&b=%zz
+
+###
+PUT https://example.com/upload
+
+< ./file.bin
+`))
+
+	// Invalid inputs
+	f.Add([]byte(`###`))
+	f.Add([]byte(`> {%`))
+	f.Add([]byte("@=\n"))
+	f.Add([]byte(``))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		col, err := ParseHTTPFile(data)
+		if err != nil {
+			return
+		}
+		if col == nil {
+			t.Fatal("ParseHTTPFile returned nil collection without error")
+		}
+		if len(col.Items) == 0 {
+			t.Fatal("ParseHTTPFile returned collection with no items without error")
+		}
+		for _, item := range col.Items {
+			if item.Request == nil || item.Request.URL == "" {
+				t.Fatal("ParseHTTPFile returned a request without a URL")
+			}
+		}
+	})
+}
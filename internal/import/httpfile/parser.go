@@ -0,0 +1,258 @@
+// Package httpfile imports .http files written for the JetBrains HTTP
+// Client and the VS Code REST Client.
+package httpfile
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sadopc/gottp/internal/core/collection"
+	importutil "github.com/sadopc/gottp/internal/import"
+)
+
+// methods are the request methods a request line may start with; a line
+// with only a URL is a GET.
+var methods = map[string]bool{
+	"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
+	"HEAD": true, "OPTIONS": true, "TRACE": true, "CONNECT": true,
+}
+
+// ParseHTTPFile parses an .http file. Requests are separated by ### lines,
+// whose text names the request unless a "# @name" comment does. File
+// variables (@name = value) become collection variables; {{variables}} are
+// kept as they are, since gottp uses the same syntax. Response handler
+// scripts are dropped.
+func ParseHTTPFile(data []byte) (*collection.Collection, error) {
+	col := &collection.Collection{
+		Name:    "HTTP File Import",
+		Version: "1.0",
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	var block []string
+	name := ""
+	flush := func() {
+		if req := parseRequest(block, name, col); req != nil {
+			col.Items = append(col.Items, collection.Item{Request: req})
+		}
+		block, name = nil, ""
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "###") {
+			flush()
+			name = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+		block = append(block, line)
+	}
+	flush()
+
+	if len(col.Items) == 0 {
+		return nil, fmt.Errorf("no requests found in .http file")
+	}
+	return col, nil
+}
+
+// parseRequest parses the lines between two ### separators: comments and
+// variables, the request line, headers, a blank line and the body. It
+// returns nil when the block holds no request.
+func parseRequest(lines []string, name string, col *collection.Collection) *collection.Request {
+	i := 0
+	var method, target string
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "":
+			continue
+		case isComment(line):
+			if n, ok := nameDirective(line); ok {
+				name = n
+			}
+			continue
+		case strings.HasPrefix(line, "@"):
+			if k, v, ok := strings.Cut(line[1:], "="); ok {
+				if col.Variables == nil {
+					col.Variables = make(map[string]string)
+				}
+				col.Variables[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+			continue
+		}
+		method, target = requestLine(line)
+		i++
+		break
+	}
+	if target == "" {
+		return nil
+	}
+
+	// Query parameters may continue on the following lines
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&") {
+			break
+		}
+		target += line
+	}
+
+	if name == "" {
+		name = importutil.RequestName(method, target)
+	}
+	req := &collection.Request{
+		ID:       uuid.New().String(),
+		Name:     name,
+		Protocol: "http",
+		Method:   method,
+		URL:      target,
+	}
+
+	contentType := ""
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			break
+		}
+		if isComment(line) {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if strings.EqualFold(key, "Content-Type") {
+			contentType = strings.ToLower(value)
+		}
+		if strings.EqualFold(key, "Authorization") {
+			if auth := convertAuth(value); auth != nil {
+				req.Auth = auth
+				continue
+			}
+		}
+		req.Headers = append(req.Headers, collection.KVPair{Key: key, Value: value, Enabled: true})
+	}
+
+	req.Body = convertBody(bodyLines(lines[i:]), contentType)
+	return req
+}
+
+func isComment(line string) bool {
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//")
+}
+
+// nameDirective returns the name set by a "# @name getUsers" comment.
+func nameDirective(line string) (string, bool) {
+	line = strings.TrimSpace(strings.TrimLeft(line, "#/"))
+	rest, ok := strings.CutPrefix(line, "@name")
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '=') {
+		return "", false
+	}
+	rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), "="))
+	return rest, rest != ""
+}
+
+// requestLine splits "POST https://host/path HTTP/1.1" into its method and
+// target.
+func requestLine(line string) (method, target string) {
+	fields := strings.Fields(line)
+	method = "GET"
+	if len(fields) > 1 && methods[strings.ToUpper(fields[0])] {
+		method, fields = strings.ToUpper(fields[0]), fields[1:]
+	}
+	if len(fields) > 1 && strings.HasPrefix(fields[len(fields)-1], "HTTP/") {
+		fields = fields[:len(fields)-1]
+	}
+	return method, strings.Join(fields, " ")
+}
+
+// convertAuth turns the Authorization shorthands both clients encode for
+// you, "Basic user password" (or user:password) and "Digest user
+// password", into gottp auth. Anything else stays a header.
+func convertAuth(value string) *collection.Auth {
+	fields := strings.Fields(value)
+	if len(fields) < 2 {
+		return nil
+	}
+	var user, pass string
+	switch {
+	case len(fields) == 3:
+		user, pass = fields[1], fields[2]
+	case len(fields) == 2 && strings.Contains(fields[1], ":"):
+		user, pass, _ = strings.Cut(fields[1], ":")
+	default:
+		return nil
+	}
+	switch strings.ToLower(fields[0]) {
+	case "basic":
+		return &collection.Auth{Type: "basic", Basic: &collection.BasicAuth{Username: user, Password: pass}}
+	case "digest":
+		return &collection.Auth{Type: "digest", Digest: &collection.DigestAuth{Username: user, Password: pass}}
+	}
+	return nil
+}
+
+// bodyLines returns the body, leaving out response handler scripts
+// ("> {% ... %}" or "> script.js") and response references ("<> file").
+func bodyLines(lines []string) []string {
+	var body []string
+	inHandler := false
+	for _, line := range lines {
+		t := strings.TrimSpace(line)
+		switch {
+		case inHandler:
+			inHandler = !strings.Contains(t, "%}")
+			continue
+		case strings.HasPrefix(t, "> {%"):
+			inHandler = !strings.Contains(t[4:], "%}")
+			continue
+		case strings.HasPrefix(t, "<>") || strings.HasPrefix(t, "> "):
+			continue
+		}
+		body = append(body, line)
+	}
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+	return body
+}
+
+// convertBody builds the request body. "< ./file.json" sends a file;
+// form-urlencoded bodies are split into fields.
+func convertBody(lines []string, contentType string) *collection.Body {
+	if len(lines) == 0 {
+		return nil
+	}
+	first := strings.TrimSpace(lines[0])
+	if len(lines) == 1 && (strings.HasPrefix(first, "< ") || strings.HasPrefix(first, "<@ ")) {
+		_, file, _ := strings.Cut(first, " ")
+		return &collection.Body{Type: collection.BodyBinaryFile, File: strings.TrimSpace(file)}
+	}
+	content := strings.Join(lines, "\n")
+	switch {
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		body := &collection.Body{Type: collection.BodyFormURLEncoded}
+		for _, pair := range strings.Split(strings.Join(strings.Fields(content), ""), "&") {
+			if pair == "" {
+				continue
+			}
+			k, v, _ := strings.Cut(pair, "=")
+			body.Fields = append(body.Fields, collection.BodyField{Key: unescape(k), Value: unescape(v), Enabled: true})
+		}
+		return body
+	case strings.Contains(contentType, "json"):
+		return &collection.Body{Type: "json", Content: content}
+	case strings.Contains(contentType, "xml"):
+		return &collection.Body{Type: "xml", Content: content}
+	}
+	return &collection.Body{Type: "text", Content: content}
+}
+
+func unescape(s string) string {
+	if u, err := url.QueryUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
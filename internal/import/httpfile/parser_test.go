@@ -0,0 +1,115 @@
+package httpfile
+
+import (
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+func TestParseHTTPFile(t *testing.T) {
+	data := []byte(`@host = https://api.example.com
+@token = abc123
+
+### List users
+GET {{host}}/users
+    ?page=1
+    &limit=10
+Accept: application/json
+
+### Create user
+# @name createUser
+POST {{host}}/users HTTP/1.1
+Content-Type: application/json
+Authorization: Bearer {{token}}
+
+{
+  "name": "Ada"
+}
+
+> {%
+  client.global.set("id", response.body.id);
+%}
+
+###
+POST {{host}}/login
+Content-Type: application/x-www-form-urlencoded
+Authorization: Basic admin secret
+
+user=ada&note=hello%20world
+
+###
+PUT {{host}}/avatar
+Content-Type: image/png
+
+< ./avatar.png
+`)
+
+	col, err := ParseHTTPFile(data)
+	if err != nil {
+		t.Fatalf("ParseHTTPFile: %v", err)
+	}
+	if col.Variables["host"] != "https://api.example.com" || col.Variables["token"] != "abc123" {
+		t.Errorf("variables = %v", col.Variables)
+	}
+	if len(col.Items) != 4 {
+		t.Fatalf("got %d items, want 4", len(col.Items))
+	}
+
+	list := col.Items[0].Request
+	if list.Name != "List users" || list.Method != "GET" {
+		t.Errorf("list = %q %s", list.Name, list.Method)
+	}
+	if list.URL != "{{host}}/users?page=1&limit=10" {
+		t.Errorf("list URL = %q", list.URL)
+	}
+	if list.Body != nil {
+		t.Errorf("list body = %+v, want nil", list.Body)
+	}
+
+	create := col.Items[1].Request
+	if create.Name != "createUser" {
+		t.Errorf("@name not applied: %q", create.Name)
+	}
+	if create.URL != "{{host}}/users" {
+		t.Errorf("create URL = %q", create.URL)
+	}
+	if create.Body == nil || create.Body.Type != "json" || create.Body.Content != "{\n  \"name\": \"Ada\"\n}" {
+		t.Errorf("create body = %+v", create.Body)
+	}
+	if len(create.Headers) != 2 || create.Headers[1].Value != "Bearer {{token}}" {
+		t.Errorf("create headers = %+v", create.Headers)
+	}
+
+	login := col.Items[2].Request
+	if login.Name != "POST {{host}}/login" {
+		t.Errorf("unnamed request name = %q", login.Name)
+	}
+	if login.Auth == nil || login.Auth.Type != "basic" || login.Auth.Basic.Username != "admin" || login.Auth.Basic.Password != "secret" {
+		t.Errorf("login auth = %+v", login.Auth)
+	}
+	if login.Body.Kind() != collection.BodyFormURLEncoded || len(login.Body.Fields) != 2 || login.Body.Fields[1].Value != "hello world" {
+		t.Errorf("login body = %+v", login.Body)
+	}
+
+	avatar := col.Items[3].Request
+	if avatar.Body.Kind() != collection.BodyBinaryFile || avatar.Body.File != "./avatar.png" {
+		t.Errorf("avatar body = %+v", avatar.Body)
+	}
+}
+
+func TestParseHTTPFile_URLOnly(t *testing.T) {
+	col, err := ParseHTTPFile([]byte("https://example.com/health\n"))
+	if err != nil {
+		t.Fatalf("ParseHTTPFile: %v", err)
+	}
+	req := col.Items[0].Request
+	if req.Method != "GET" || req.URL != "https://example.com/health" {
+		t.Errorf("got %s %s", req.Method, req.URL)
+	}
+}
+
+func TestParseHTTPFile_Empty(t *testing.T) {
+	if _, err := ParseHTTPFile([]byte("# only a comment\n###\n")); err == nil {
+		t.Error("expected an error for a file without requests")
+	}
+}
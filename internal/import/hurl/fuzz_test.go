@@ -0,0 +1,47 @@
+package hurl
+
+import "testing"
+
+func FuzzParseHurl(f *testing.F) {
+	// Seed: form params, captures and asserts
+	f.Add([]byte(`POST https://example.com/login
+[FormParams]
+user: ada
+HTTP 200
+[Captures]
+token: jsonpath "$.token"
+[Asserts]
+jsonpath "$.ok" == true
+duration < 300
+`))
+
+	// Seed: JSON body and response headers
+	f.Add([]byte(`PUT {{base_url}}/items/1
+Content-Type: application/json
+{"name": "Widget"}
+HTTP/1.1 204
+X-Trace: abc
+`))
+
+	// Seed: fenced and encoded bodies
+	f.Add([]byte("POST https://example.com/graphql\n```graphql\n{ me { id } }\nvariables {}\n```\n\nPOST https://example.com/raw\nhex,00ff;\n"))
+
+	// Invalid inputs
+	f.Add([]byte("GET\n"))
+	f.Add([]byte("```"))
+	f.Add([]byte("[Asserts]\nstatus == x\n"))
+	f.Add([]byte(``))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		col, err := ParseHurl(data)
+		if err != nil {
+			return
+		}
+		if col == nil {
+			t.Fatal("ParseHurl returned nil collection without error")
+		}
+		if len(col.Items) == 0 {
+			t.Fatal("ParseHurl returned collection with no items without error")
+		}
+	})
+}
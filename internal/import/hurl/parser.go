@@ -0,0 +1,396 @@
+// Package hurl imports Hurl files: requests with their headers, query,
+// form and multipart sections and bodies, and the expected responses as
+// assertions and captures.
+package hurl
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sadopc/gottp/internal/core/assertion"
+	"github.com/sadopc/gottp/internal/core/collection"
+	importutil "github.com/sadopc/gottp/internal/import"
+)
+
+var (
+	requestLine = regexp.MustCompile(`^([A-Z]+)\s+(\S.*)$`)
+	statusLine  = regexp.MustCompile(`^HTTP(?:/[\d.]+)?\s+(\d{3}|\*)$`)
+	sectionLine = regexp.MustCompile(`^\[([A-Za-z]+)\]$`)
+	headerLine  = regexp.MustCompile("^([A-Za-z0-9!#$%&'*+.^_`|~-]+)\\s*:\\s*(.*)$")
+)
+
+// entry is the part of the file being read.
+type entry struct {
+	req      *collection.Request
+	response bool   // past the HTTP status line
+	section  string // current [Section], lower-case
+	inBody   bool
+	fence    bool // inside a ``` multiline string
+	lang     string
+	body     []string
+	captures []string // post-script lines
+}
+
+// ParseHurl parses a Hurl file into a collection with one request per
+// entry. The expected status and response headers, and the asserts gottp
+// can express, become assertions; jsonpath and header captures set
+// environment variables from a post-script. Asserts and captures with no
+// gottp equivalent are kept as comments in the post-script.
+func ParseHurl(data []byte) (*collection.Collection, error) {
+	col := &collection.Collection{
+		Name:    "Hurl Import",
+		Version: "1.0",
+	}
+
+	var cur *entry
+	finish := func() {
+		if cur == nil {
+			return
+		}
+		cur.finishBody()
+		cur.req.PostScript = strings.Join(cur.captures, "\n")
+		col.Items = append(col.Items, collection.Item{Request: cur.req})
+		cur = nil
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if cur != nil && cur.fence {
+			if line == "```" {
+				cur.fence = false
+				if !cur.response {
+					cur.finishBody()
+				}
+				continue
+			}
+			if !cur.response {
+				cur.body = append(cur.body, raw)
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			if cur != nil && cur.inBody && line == "" {
+				cur.body = append(cur.body, "")
+			}
+			continue
+		}
+
+		if m := requestLine.FindStringSubmatch(line); m != nil && isTarget(m[2]) {
+			finish()
+			method, target := m[1], strings.TrimSpace(m[2])
+			cur = &entry{req: &collection.Request{
+				ID:       uuid.New().String(),
+				Name:     importutil.RequestName(method, target),
+				Protocol: "http",
+				Method:   method,
+				URL:      target,
+			}}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if m := statusLine.FindStringSubmatch(line); m != nil {
+			cur.finishBody()
+			cur.response, cur.section = true, ""
+			if code, err := strconv.Atoi(m[1]); err == nil {
+				cur.req.Assertions = append(cur.req.Assertions, assertion.Assertion{Status: code})
+			}
+			continue
+		}
+		if m := sectionLine.FindStringSubmatch(line); m != nil && !cur.inBody {
+			cur.section = strings.ToLower(m[1])
+			continue
+		}
+		if !cur.inBody && bodyStart(line) {
+			cur.inBody = true
+		}
+		if cur.inBody {
+			if !cur.response {
+				cur.bodyLine(raw, line)
+			} else if rest, ok := strings.CutPrefix(line, "```"); ok && !strings.HasSuffix(rest, "```") {
+				cur.fence = true // the expected body, which isn't imported
+			}
+			continue
+		}
+		cur.sectionLine(line)
+	}
+	finish()
+
+	if len(col.Items) == 0 {
+		return nil, fmt.Errorf("no requests found in Hurl file")
+	}
+	return col, nil
+}
+
+// isTarget reports whether s is a request URL rather than, say, the rest
+// of a body line that happens to start with a capitalised word.
+func isTarget(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "{{")
+}
+
+// bodyStart reports whether line starts a request or response body.
+func bodyStart(line string) bool {
+	for _, p := range []string{"{", "[", "<", "`", "base64,", "hex,", "file,"} {
+		if strings.HasPrefix(line, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyLine adds a line of the request body, handling the one-line forms
+// (`text`, base64,...; hex,...; file,...;) and ``` fences.
+func (e *entry) bodyLine(raw, line string) {
+	if len(e.body) > 0 {
+		e.body = append(e.body, raw)
+		return
+	}
+	switch {
+	case strings.HasPrefix(line, "```"):
+		rest := line[3:]
+		if text, ok := strings.CutSuffix(rest, "```"); ok && rest != "" {
+			e.body = append(e.body, text)
+			e.inBody = false
+			e.finishBody()
+			return
+		}
+		e.fence, e.lang = true, strings.TrimSpace(rest)
+		e.body = []string{}
+	case strings.HasPrefix(line, "`") && strings.HasSuffix(line, "`") && len(line) > 1:
+		e.req.Body = &collection.Body{Type: "text", Content: line[1 : len(line)-1]}
+		e.inBody = false
+	case strings.HasPrefix(line, "file,"):
+		file := strings.TrimSuffix(strings.TrimPrefix(line, "file,"), ";")
+		e.req.Body = &collection.Body{Type: collection.BodyBinaryFile, File: strings.TrimSpace(file)}
+		e.inBody = false
+	case strings.HasPrefix(line, "base64,") || strings.HasPrefix(line, "hex,"):
+		kind, enc, _ := strings.Cut(strings.TrimSuffix(line, ";"), ",")
+		var data []byte
+		var err error
+		if kind == "base64" {
+			data, err = base64.StdEncoding.DecodeString(strings.TrimSpace(enc))
+		} else {
+			data, err = hex.DecodeString(strings.TrimSpace(enc))
+		}
+		if err == nil {
+			e.req.Body = &collection.Body{Type: "text", Content: string(data)}
+		}
+		e.inBody = false
+	default:
+		e.body = append(e.body, raw)
+	}
+}
+
+// finishBody stores the lines collected for the request body.
+func (e *entry) finishBody() {
+	for len(e.body) > 0 && strings.TrimSpace(e.body[len(e.body)-1]) == "" {
+		e.body = e.body[:len(e.body)-1]
+	}
+	if len(e.body) == 0 {
+		e.body, e.inBody = nil, false
+		return
+	}
+	content := strings.Join(e.body, "\n")
+	e.body, e.inBody = nil, false
+
+	if e.lang == "graphql" {
+		query, vars := content, ""
+		if i := strings.LastIndex(content, "\nvariables"); i >= 0 {
+			if v := strings.TrimSpace(strings.TrimPrefix(content[i+1:], "variables")); strings.HasPrefix(v, "{") {
+				query, vars = content[:i], v
+			}
+		}
+		e.req.Protocol = "graphql"
+		e.req.GraphQL = &collection.GraphQLConfig{Query: strings.TrimSpace(query), Variables: vars}
+		return
+	}
+	kind := "text"
+	switch first := strings.TrimSpace(content); {
+	case e.lang == "json" || strings.HasPrefix(first, "{") || strings.HasPrefix(first, "["):
+		kind = "json"
+	case e.lang == "xml" || strings.HasPrefix(first, "<"):
+		kind = "xml"
+	}
+	e.req.Body = &collection.Body{Type: kind, Content: content}
+}
+
+// sectionLine handles a header line or a line of the current section.
+func (e *entry) sectionLine(line string) {
+	req := e.req
+	if e.response {
+		switch e.section {
+		case "captures":
+			e.capture(line)
+		case "asserts":
+			e.assert(line)
+		case "":
+			if m := headerLine.FindStringSubmatch(line); m != nil {
+				req.Assertions = append(req.Assertions, assertion.Assertion{Header: m[1], Equals: unquote(m[2])})
+			}
+		}
+		return
+	}
+
+	m := headerLine.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	key, value := m[1], unquote(m[2])
+	switch e.section {
+	case "":
+		req.Headers = append(req.Headers, collection.KVPair{Key: key, Value: value, Enabled: true})
+	case "querystringparams", "query":
+		req.Params = append(req.Params, collection.KVPair{Key: key, Value: value, Enabled: true})
+	case "cookies":
+		req.Cookies = append(req.Cookies, collection.KVPair{Key: key, Value: value, Enabled: true})
+	case "basicauth":
+		req.Auth = &collection.Auth{Type: "basic", Basic: &collection.BasicAuth{Username: key, Password: value}}
+	case "formparams", "form":
+		e.field(collection.BodyFormURLEncoded, collection.BodyField{Key: key, Value: value, Enabled: true})
+	case "multipartformdata", "multipart":
+		field := collection.BodyField{Key: key, Value: value, Enabled: true}
+		if file, ok := strings.CutPrefix(m[2], "file,"); ok {
+			// file,path; or file,path; content/type
+			file, _, _ = strings.Cut(file, ";")
+			field.Value, field.File = "", strings.TrimSpace(file)
+		}
+		e.field(collection.BodyMultipart, field)
+	}
+}
+
+func (e *entry) field(kind string, f collection.BodyField) {
+	if e.req.Body == nil || e.req.Body.Type != kind {
+		e.req.Body = &collection.Body{Type: kind}
+	}
+	e.req.Body.Fields = append(e.req.Body.Fields, f)
+}
+
+// query matches the start of a capture or assert: the query and its
+// quoted argument, if any.
+var query = regexp.MustCompile(`^(status|duration|body|jsonpath|header|xpath|regex|cookie|variable|sha256|md5|bytes|url|certificate|ip|version|redirects)\s*("(?:[^"\\]|\\.)*")?\s*(.*)$`)
+
+// capture turns "name: jsonpath "$.id"" into a post-script line.
+func (e *entry) capture(line string) {
+	name, rest, ok := strings.Cut(line, ":")
+	m := query.FindStringSubmatch(strings.TrimSpace(rest))
+	if ok && m != nil && m[3] == "" {
+		varName := strconv.Quote(strings.TrimSpace(name))
+		switch m[1] {
+		case "jsonpath":
+			e.captures = append(e.captures, "gottp.setEnvVar("+varName+", gottp.query("+m[2]+"));")
+			return
+		case "header":
+			key := strconv.Quote(http.CanonicalHeaderKey(unquote(m[2])))
+			e.captures = append(e.captures, "gottp.setEnvVar("+varName+", gottp.response.Headers["+key+"]);")
+			return
+		case "body":
+			e.captures = append(e.captures, "gottp.setEnvVar("+varName+", gottp.response.Body);")
+			return
+		}
+	}
+	e.captures = append(e.captures, "// Hurl capture not imported: "+line)
+}
+
+// assert turns a Hurl assert into an assertion when gottp has the same
+// check.
+func (e *entry) assert(line string) {
+	if a, ok := convertAssert(line); ok {
+		e.req.Assertions = append(e.req.Assertions, a)
+		return
+	}
+	e.captures = append(e.captures, "// Hurl assert not imported: "+line)
+}
+
+func convertAssert(line string) (assertion.Assertion, bool) {
+	var a assertion.Assertion
+	m := query.FindStringSubmatch(line)
+	if m == nil {
+		return a, false
+	}
+	predicate, arg, _ := strings.Cut(m[3], " ")
+	arg = strings.TrimSpace(arg)
+
+	switch m[1] {
+	case "status":
+		code, err := strconv.Atoi(arg)
+		if predicate != "==" || err != nil {
+			return a, false
+		}
+		a.Status = code
+		return a, true
+	case "duration":
+		ms, err := strconv.Atoi(arg)
+		if (predicate != "<" && predicate != "<=") || err != nil {
+			return a, false
+		}
+		if predicate == "<=" {
+			ms++
+		}
+		a.ResponseTimeUnder = time.Duration(ms) * time.Millisecond
+		return a, true
+	case "jsonpath":
+		a.JSONPath = unquote(m[2])
+	case "header":
+		a.Header = unquote(m[2])
+	default:
+		return a, false
+	}
+
+	switch predicate {
+	case "==":
+		a.Equals = literal(arg)
+	case "contains":
+		a.Contains = literal(arg)
+	case "matches":
+		if re, ok := strings.CutPrefix(arg, "/"); ok {
+			a.Matches = strings.TrimSuffix(re, "/")
+		} else {
+			a.Matches = unquote(arg)
+		}
+	case "startsWith":
+		a.Matches = "^" + regexp.QuoteMeta(unquote(arg))
+	case "endsWith":
+		a.Matches = regexp.QuoteMeta(unquote(arg)) + "$"
+	case "exists":
+		yes := true
+		a.Exists = &yes
+	case "not":
+		if arg != "exists" {
+			return a, false
+		}
+		no := false
+		a.Exists = &no
+	default:
+		return a, false
+	}
+	return a, true
+}
+
+// literal parses a predicate value: a quoted string, number, boolean or
+// null.
+func literal(s string) interface{} {
+	var v interface{}
+	if json.Unmarshal([]byte(s), &v) == nil {
+		return v
+	}
+	return unquote(s)
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if u, err := strconv.Unquote(s); err == nil && strings.HasPrefix(s, `"`) {
+		return u
+	}
+	return s
+}
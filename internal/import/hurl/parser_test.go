@@ -0,0 +1,170 @@
+package hurl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+func TestParseHurl(t *testing.T) {
+	data := []byte(`# Log in and fetch the profile
+POST {{base_url}}/login
+[FormParams]
+user: ada
+password: "s3cret #1"
+HTTP 200
+[Captures]
+token: jsonpath "$.token"
+session: header "set-cookie"
+count: xpath "count(//item)"
+
+GET {{base_url}}/me
+Authorization: Bearer {{token}}
+[QueryStringParams]
+expand: teams
+HTTP 200
+Content-Type: application/json
+[Asserts]
+jsonpath "$.name" == "Ada"
+jsonpath "$.age" == 36
+jsonpath "$.teams" exists
+header "X-Trace" startsWith "abc"
+duration < 500
+xpath "//title" == "x"
+
+PUT https://api.example.com/items/1
+{
+  "name": "Widget"
+}
+HTTP *
+`)
+
+	col, err := ParseHurl(data)
+	if err != nil {
+		t.Fatalf("ParseHurl: %v", err)
+	}
+	if len(col.Items) != 3 {
+		t.Fatalf("got %d items, want 3", len(col.Items))
+	}
+
+	login := col.Items[0].Request
+	if login.Method != "POST" || login.URL != "{{base_url}}/login" {
+		t.Errorf("login = %s %s", login.Method, login.URL)
+	}
+	if login.Body.Kind() != collection.BodyFormURLEncoded || len(login.Body.Fields) != 2 || login.Body.Fields[1].Value != "s3cret #1" {
+		t.Errorf("login body = %+v", login.Body)
+	}
+	if len(login.Assertions) != 1 || login.Assertions[0].Status != 200 {
+		t.Errorf("login assertions = %+v", login.Assertions)
+	}
+	for _, want := range []string{
+		`gottp.setEnvVar("token", gottp.query("$.token"));`,
+		`gottp.setEnvVar("session", gottp.response.Headers["Set-Cookie"]);`,
+		`// Hurl capture not imported: count: xpath "count(//item)"`,
+	} {
+		if !strings.Contains(login.PostScript, want) {
+			t.Errorf("post-script missing %q:\n%s", want, login.PostScript)
+		}
+	}
+
+	me := col.Items[1].Request
+	if len(me.Params) != 1 || me.Params[0].Key != "expand" || me.Params[0].Value != "teams" {
+		t.Errorf("me params = %+v", me.Params)
+	}
+	if len(me.Headers) != 1 || me.Headers[0].Value != "Bearer {{token}}" {
+		t.Errorf("me headers = %+v", me.Headers)
+	}
+	a := me.Assertions
+	if len(a) != 7 {
+		t.Fatalf("got %d assertions, want 7: %+v", len(a), a)
+	}
+	if a[1].Header != "Content-Type" || a[1].Equals != "application/json" {
+		t.Errorf("response header assertion = %+v", a[1])
+	}
+	if a[2].JSONPath != "$.name" || a[2].Equals != "Ada" {
+		t.Errorf("jsonpath == = %+v", a[2])
+	}
+	if a[3].Equals != float64(36) {
+		t.Errorf("numeric equals = %#v", a[3].Equals)
+	}
+	if a[4].Exists == nil || !*a[4].Exists {
+		t.Errorf("exists = %+v", a[4])
+	}
+	if a[5].Header != "X-Trace" || a[5].Matches != "^abc" {
+		t.Errorf("startsWith = %+v", a[5])
+	}
+	if a[6].ResponseTimeUnder != 500*time.Millisecond {
+		t.Errorf("duration = %+v", a[6])
+	}
+	if !strings.Contains(me.PostScript, `// Hurl assert not imported: xpath "//title" == "x"`) {
+		t.Errorf("unsupported assert not kept: %q", me.PostScript)
+	}
+
+	put := col.Items[2].Request
+	if put.Body.Kind() != "json" || put.Body.Content != "{\n  \"name\": \"Widget\"\n}" {
+		t.Errorf("put body = %+v", put.Body)
+	}
+	if len(put.Assertions) != 0 {
+		t.Errorf("HTTP * should add no assertion: %+v", put.Assertions)
+	}
+}
+
+func TestParseHurl_Bodies(t *testing.T) {
+	data := []byte("POST https://example.com/graphql\n" +
+		"```graphql\n" +
+		"query User($id: ID!) { user(id: $id) { name } }\n" +
+		"\n" +
+		"variables {\"id\": \"1\"}\n" +
+		"```\n" +
+		"HTTP 200\n" +
+		"```\n" +
+		"not part of the request\n" +
+		"```\n" +
+		"\n" +
+		"POST https://example.com/upload\n" +
+		"[MultipartFormData]\n" +
+		"title: Report\n" +
+		"doc: file,report.pdf; application/pdf\n" +
+		"\n" +
+		"POST https://example.com/raw\n" +
+		"base64,aGVsbG8=;\n" +
+		"\n" +
+		"POST https://example.com/echo\n" +
+		"`hello`\n")
+
+	col, err := ParseHurl(data)
+	if err != nil {
+		t.Fatalf("ParseHurl: %v", err)
+	}
+	if len(col.Items) != 4 {
+		t.Fatalf("got %d items, want 4", len(col.Items))
+	}
+
+	gql := col.Items[0].Request
+	if gql.Protocol != "graphql" || gql.GraphQL == nil {
+		t.Fatalf("graphql request = %+v", gql)
+	}
+	if gql.GraphQL.Query != "query User($id: ID!) { user(id: $id) { name } }" || gql.GraphQL.Variables != `{"id": "1"}` {
+		t.Errorf("graphql = %+v", gql.GraphQL)
+	}
+
+	upload := col.Items[1].Request.Body
+	if upload.Kind() != collection.BodyMultipart || len(upload.Fields) != 2 || upload.Fields[1].File != "report.pdf" {
+		t.Errorf("multipart body = %+v", upload)
+	}
+
+	if raw := col.Items[2].Request.Body; raw == nil || raw.Content != "hello" {
+		t.Errorf("base64 body = %+v", raw)
+	}
+	if echo := col.Items[3].Request.Body; echo == nil || echo.Type != "text" || echo.Content != "hello" {
+		t.Errorf("one-line body = %+v", echo)
+	}
+}
+
+func TestParseHurl_Empty(t *testing.T) {
+	if _, err := ParseHurl([]byte("# nothing here\n")); err == nil {
+		t.Error("expected an error for a file without requests")
+	}
+}
@@ -0,0 +1,105 @@
+package insomnia
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sadopc/gottp/internal/core/environment"
+)
+
+// ParseInsomniaEnvironments returns the environments of an Insomnia v4
+// export. Each sub-environment becomes an environment holding the base
+// environment's variables overridden by its own; an export with only a
+// base environment yields that one. Nested values are flattened into
+// dotted names, as Insomnia templates refer to them.
+func ParseInsomniaEnvironments(data []byte) ([]environment.Environment, error) {
+	var export insomniaExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("parsing Insomnia JSON: %w", err)
+	}
+
+	envs := map[string]*insomniaResource{}
+	for i := range export.Resources {
+		if r := &export.Resources[i]; r.Type == "environment" {
+			envs[r.ID] = r
+		}
+	}
+
+	tc := newTemplateConverter(nil)
+	var bases, subs []*insomniaResource
+	for i := range export.Resources {
+		r := &export.Resources[i]
+		if r.Type != "environment" {
+			continue
+		}
+		if _, nested := envs[r.ParentID]; nested {
+			subs = append(subs, r)
+		} else {
+			bases = append(bases, r)
+		}
+	}
+
+	base := map[string]string{}
+	for _, r := range bases {
+		for k, v := range flattenData(r.Data) {
+			base[k] = tc.convert(v)
+		}
+	}
+
+	var out []environment.Environment
+	for _, r := range subs {
+		vars := make(map[string]environment.Variable, len(base))
+		for k, v := range base {
+			vars[k] = environment.Variable{Value: v}
+		}
+		for k, v := range flattenData(r.Data) {
+			vars[k] = environment.Variable{Value: tc.convert(v)}
+		}
+		out = append(out, environment.Environment{Name: r.Name, Variables: vars})
+	}
+	if len(out) == 0 && len(base) > 0 {
+		vars := make(map[string]environment.Variable, len(base))
+		for k, v := range base {
+			vars[k] = environment.Variable{Value: v}
+		}
+		name := bases[0].Name
+		if name == "" {
+			name = "Base Environment"
+		}
+		out = append(out, environment.Environment{Name: name, Variables: vars})
+	}
+	return out, nil
+}
+
+// flattenData returns the variables of an environment's data, with nested
+// objects joined into dotted names and other values as JSON.
+func flattenData(raw json.RawMessage) map[string]string {
+	var data map[string]interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &data) != nil {
+		return nil
+	}
+	out := map[string]string{}
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch x := v.(type) {
+		case map[string]interface{}:
+			for k, val := range x {
+				walk(prefix+k+".", val)
+			}
+			return
+		case string:
+			out[prefix[:len(prefix)-1]] = x
+		case nil:
+			out[prefix[:len(prefix)-1]] = ""
+		case float64, bool:
+			out[prefix[:len(prefix)-1]] = fmt.Sprint(x)
+		default:
+			b, _ := json.Marshal(x)
+			out[prefix[:len(prefix)-1]] = string(b)
+		}
+	}
+	for k, v := range data {
+		walk(k+".", v)
+	}
+	return out
+}
@@ -25,6 +25,10 @@ type insomniaResource struct {
 	Headers        []insomniaKV  `json:"headers,omitempty"`
 	Parameters     []insomniaKV  `json:"parameters,omitempty"`
 	Authentication *insomniaAuth `json:"authentication,omitempty"`
+
+	// Data holds an environment's variables; Environment a folder's.
+	Data        json.RawMessage `json:"data,omitempty"`
+	Environment json.RawMessage `json:"environment,omitempty"`
 }
 
 type insomniaBody struct {
@@ -46,6 +50,9 @@ type insomniaAuth struct {
 }
 
 // ParseInsomnia parses an Insomnia v4 export into a gottp Collection.
+// Environment references become gottp variables, and response tags that
+// chain requests become variables captured by a post-script on the
+// request they read from.
 func ParseInsomnia(data []byte) (*collection.Collection, error) {
 	var export insomniaExport
 	if err := json.Unmarshal(data, &export); err != nil {
@@ -54,6 +61,7 @@ func ParseInsomnia(data []byte) (*collection.Collection, error) {
 
 	// Build parent-children map
 	children := map[string][]insomniaResource{}
+	titles := map[string]string{}
 	var workspace *insomniaResource
 	for i := range export.Resources {
 		r := &export.Resources[i]
@@ -61,6 +69,9 @@ func ParseInsomnia(data []byte) (*collection.Collection, error) {
 			workspace = r
 			continue
 		}
+		if r.Type == "request" {
+			titles[r.ID] = r.Name
+		}
 		children[r.ParentID] = append(children[r.ParentID], *r)
 	}
 
@@ -76,11 +87,13 @@ func ParseInsomnia(data []byte) (*collection.Collection, error) {
 		Version: "1.0",
 	}
 
-	col.Items = buildItems(children, rootID, nil)
+	tc := newTemplateConverter(titles)
+	col.Items = buildItems(children, rootID, nil, tc)
+	tc.addCaptures()
 	return col, nil
 }
 
-func buildItems(children map[string][]insomniaResource, parentID string, visited map[string]bool) []collection.Item {
+func buildItems(children map[string][]insomniaResource, parentID string, visited map[string]bool, tc *templateConverter) []collection.Item {
 	if visited == nil {
 		visited = make(map[string]bool)
 	}
@@ -94,7 +107,13 @@ func buildItems(children map[string][]insomniaResource, parentID string, visited
 		switch r.Type {
 		case "request_group":
 			folder := &collection.Folder{Name: r.Name}
-			folder.Items = buildItems(children, r.ID, visited)
+			if vars := flattenData(r.Environment); len(vars) > 0 {
+				folder.Variables = make(map[string]string, len(vars))
+				for k, v := range vars {
+					folder.Variables[k] = tc.convert(v)
+				}
+			}
+			folder.Items = buildItems(children, r.ID, visited, tc)
 			items = append(items, collection.Item{Folder: folder})
 		case "request":
 			req := &collection.Request{
@@ -102,16 +121,17 @@ func buildItems(children map[string][]insomniaResource, parentID string, visited
 				Name:     r.Name,
 				Protocol: "http",
 				Method:   strings.ToUpper(r.Method),
-				URL:      r.URL,
+				URL:      tc.convert(r.URL),
 			}
+			tc.requests[r.ID] = req
 			for _, h := range r.Headers {
 				req.Headers = append(req.Headers, collection.KVPair{
-					Key: h.Name, Value: h.Value, Enabled: !h.Disabled,
+					Key: h.Name, Value: tc.convert(h.Value), Enabled: !h.Disabled,
 				})
 			}
 			for _, p := range r.Parameters {
 				req.Params = append(req.Params, collection.KVPair{
-					Key: p.Name, Value: p.Value, Enabled: !p.Disabled,
+					Key: p.Name, Value: tc.convert(p.Value), Enabled: !p.Disabled,
 				})
 			}
 			if r.Body != nil && r.Body.Text != "" {
@@ -121,10 +141,10 @@ func buildItems(children map[string][]insomniaResource, parentID string, visited
 				} else if strings.Contains(r.Body.MimeType, "xml") {
 					bodyType = "xml"
 				}
-				req.Body = &collection.Body{Type: bodyType, Content: r.Body.Text}
+				req.Body = &collection.Body{Type: bodyType, Content: tc.convert(r.Body.Text)}
 			}
 			if r.Authentication != nil {
-				req.Auth = convertInsomniaAuth(r.Authentication)
+				req.Auth = convertInsomniaAuth(r.Authentication, tc)
 			}
 			items = append(items, collection.Item{Request: req})
 		}
@@ -132,7 +152,7 @@ func buildItems(children map[string][]insomniaResource, parentID string, visited
 	return items
 }
 
-func convertInsomniaAuth(auth *insomniaAuth) *collection.Auth {
+func convertInsomniaAuth(auth *insomniaAuth, tc *templateConverter) *collection.Auth {
 	if auth == nil || auth.Type == "" {
 		return nil
 	}
@@ -140,12 +160,12 @@ func convertInsomniaAuth(auth *insomniaAuth) *collection.Auth {
 	case "basic":
 		return &collection.Auth{
 			Type:  "basic",
-			Basic: &collection.BasicAuth{Username: auth.Username, Password: auth.Password},
+			Basic: &collection.BasicAuth{Username: tc.convert(auth.Username), Password: tc.convert(auth.Password)},
 		}
 	case "bearer":
 		return &collection.Auth{
 			Type:   "bearer",
-			Bearer: &collection.BearerAuth{Token: auth.Token},
+			Bearer: &collection.BearerAuth{Token: tc.convert(auth.Token)},
 		}
 	}
 	return nil
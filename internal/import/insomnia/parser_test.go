@@ -78,3 +78,107 @@ func TestParseInsomnia(t *testing.T) {
 		t.Error("expected bearer auth on health check")
 	}
 }
+
+func TestParseInsomniaChaining(t *testing.T) {
+	data := []byte(`{
+		"_type": "export",
+		"resources": [
+			{"_id": "wrk_1", "_type": "workspace", "name": "Chain"},
+			{"_id": "fld_1", "_type": "request_group", "parentId": "wrk_1", "name": "Auth", "environment": {"realm": "users"}},
+			{
+				"_id": "req_login", "_type": "request", "parentId": "fld_1", "name": "Log In",
+				"method": "POST", "url": "{{ _.base_url }}/login"
+			},
+			{
+				"_id": "req_me", "_type": "request", "parentId": "wrk_1", "name": "Me",
+				"method": "GET",
+				"url": "{{ _.base_url }}/users/{% response 'body', 'req_login', 'b64::JC51c2VyLmlk::46b', 'never', 60 %}",
+				"headers": [
+					{"name": "Authorization", "value": "Bearer {% response 'body', 'req_login', '$.token' %}"},
+					{"name": "X-Session", "value": "{% response 'header', 'req_login', 'x-session-id' %}"},
+					{"name": "X-Request-Id", "value": "{% uuid 'v4' %}"}
+				]
+			}
+		]
+	}`)
+
+	col, err := ParseInsomnia(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	folder := col.Items[0].Folder
+	if folder.Variables["realm"] != "users" {
+		t.Errorf("folder variables = %v", folder.Variables)
+	}
+	login := folder.Items[0].Request
+	if login.URL != "{{base_url}}/login" {
+		t.Errorf("URL = %q", login.URL)
+	}
+
+	me := col.Items[1].Request
+	if want := "{{base_url}}/users/{{log_in_id}}"; me.URL != want {
+		t.Errorf("URL = %q, want %q", me.URL, want)
+	}
+	wantHeaders := []string{"Bearer {{log_in_token}}", "{{log_in_x_session_id}}", "{{$uuid}}"}
+	for i, want := range wantHeaders {
+		if me.Headers[i].Value != want {
+			t.Errorf("header %d = %q, want %q", i, me.Headers[i].Value, want)
+		}
+	}
+
+	wantScript := `// Captures for chained requests
+gottp.setEnvVar("log_in_id", gottp.query("$.user.id"));
+gottp.setEnvVar("log_in_token", gottp.query("$.token"));
+gottp.setEnvVar("log_in_x_session_id", gottp.response.Headers["X-Session-Id"]);`
+	if login.PostScript != wantScript {
+		t.Errorf("post-script = %q, want %q", login.PostScript, wantScript)
+	}
+	if me.PostScript != "" {
+		t.Errorf("unexpected post-script on Me: %q", me.PostScript)
+	}
+}
+
+func TestParseInsomniaEnvironments(t *testing.T) {
+	data := []byte(`{
+		"_type": "export",
+		"resources": [
+			{"_id": "wrk_1", "_type": "workspace", "name": "Envs"},
+			{"_id": "env_base", "_type": "environment", "parentId": "wrk_1", "name": "Base Environment",
+				"data": {"base_url": "http://localhost:8080", "api": {"version": 2}, "token": "{{ _.dev_token }}"}},
+			{"_id": "env_prod", "_type": "environment", "parentId": "env_base", "name": "Production",
+				"data": {"base_url": "https://api.example.com"}},
+			{"_id": "env_dev", "_type": "environment", "parentId": "env_base", "name": "Dev", "data": {}}
+		]
+	}`)
+
+	envs, err := ParseInsomniaEnvironments(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(envs) != 2 || envs[0].Name != "Production" || envs[1].Name != "Dev" {
+		t.Fatalf("environments = %+v", envs)
+	}
+	prod, dev := envs[0].Variables, envs[1].Variables
+	if prod["base_url"].Value != "https://api.example.com" || dev["base_url"].Value != "http://localhost:8080" {
+		t.Errorf("base_url: prod %q, dev %q", prod["base_url"].Value, dev["base_url"].Value)
+	}
+	if prod["api.version"].Value != "2" {
+		t.Errorf("api.version = %q", prod["api.version"].Value)
+	}
+	if dev["token"].Value != "{{dev_token}}" {
+		t.Errorf("token = %q", dev["token"].Value)
+	}
+
+	// Only a base environment
+	data = []byte(`{"_type": "export", "resources": [
+		{"_id": "env_base", "_type": "environment", "parentId": "wrk_1", "name": "Base Environment", "data": {"a": "1"}}
+	]}`)
+	envs, err = ParseInsomniaEnvironments(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(envs) != 1 || envs[0].Variables["a"].Value != "1" {
+		t.Errorf("environments = %+v", envs)
+	}
+}
@@ -0,0 +1,231 @@
+package insomnia
+
+import (
+	"encoding/base64"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+var (
+	// templateVar matches a Nunjucks variable such as {{ _.base_url }}.
+	templateVar = regexp.MustCompile(`\{\{\s*(?:_\.)?([A-Za-z0-9_.\-]+)\s*\}\}`)
+	// templateTag matches a tag such as {% response 'body', 'req_1', '$.id' %}.
+	templateTag = regexp.MustCompile(`\{%\s*(\w+)\s*(.*?)\s*%\}`)
+	identifier  = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+)
+
+// capture is a value a chained request reads from another's response.
+type capture struct {
+	name      string
+	attribute string // body, header, raw, url
+	path      string // JSONPath or XPath for body, name for header
+}
+
+// templateConverter rewrites Insomnia templates into gottp variables and
+// records the captures chained requests depend on.
+type templateConverter struct {
+	titles   map[string]string              // request names by Insomnia ID
+	requests map[string]*collection.Request // by Insomnia ID
+	captures map[string][]capture           // by source request ID
+	sources  []string                       // source request IDs in order of use
+	names    map[string]string              // capture key -> variable name
+	taken    map[string]bool
+}
+
+func newTemplateConverter(titles map[string]string) *templateConverter {
+	return &templateConverter{
+		titles:   titles,
+		requests: make(map[string]*collection.Request),
+		captures: make(map[string][]capture),
+		names:    make(map[string]string),
+		taken:    make(map[string]bool),
+	}
+}
+
+// convert returns s with environment references as {{name}} and response
+// tags as the variables that capture them. Tags gottp has no equivalent
+// for are left as they are.
+func (tc *templateConverter) convert(s string) string {
+	if !strings.Contains(s, "{") {
+		return s
+	}
+	s = templateVar.ReplaceAllString(s, "{{$1}}")
+	return templateTag.ReplaceAllStringFunc(s, func(tag string) string {
+		m := templateTag.FindStringSubmatch(tag)
+		args := tagArgs(m[2])
+		switch m[1] {
+		case "response":
+			if len(args) < 2 {
+				return tag
+			}
+			path := ""
+			if len(args) > 2 {
+				path = decodeTagArg(args[2])
+			}
+			return "{{" + tc.captureVar(args[1], capture{attribute: args[0], path: path}) + "}}"
+		case "uuid":
+			return "{{$uuid}}"
+		case "now":
+			format := "iso-8601"
+			if len(args) > 0 {
+				format = args[0]
+			}
+			switch format {
+			case "unix":
+				return "{{$timestamp}}"
+			case "iso-8601":
+				return "{{$isoTimestamp}}"
+			}
+		}
+		return tag
+	})
+}
+
+// captureVar returns the variable holding c from the response of the
+// request with ID source, naming it after that request and the value.
+func (tc *templateConverter) captureVar(source string, c capture) string {
+	key := source + "\x00" + c.attribute + "\x00" + c.path
+	if name, ok := tc.names[key]; ok {
+		return name
+	}
+
+	var field string
+	switch c.attribute {
+	case "body":
+		idents := identifier.FindAllString(c.path, -1)
+		if len(idents) > 0 {
+			field = idents[len(idents)-1]
+		}
+	case "header":
+		field = c.path
+	case "raw":
+		field = "body"
+	default:
+		field = c.attribute
+	}
+	prefix := "response"
+	if title, ok := tc.titles[source]; ok {
+		prefix = title
+	}
+	name := varName(prefix + " " + field)
+	unique := name
+	for i := 2; tc.taken[unique]; i++ {
+		unique = name + "_" + strconv.Itoa(i)
+	}
+	c.name = unique
+	tc.names[key] = unique
+	tc.taken[unique] = true
+
+	if _, seen := tc.captures[source]; !seen {
+		tc.sources = append(tc.sources, source)
+	}
+	tc.captures[source] = append(tc.captures[source], c)
+	return unique
+}
+
+// addCaptures appends a post-script to each request other requests read
+// from, setting the variables they use. The request has to run first, as
+// it does when it comes earlier in the collection.
+func (tc *templateConverter) addCaptures() {
+	for _, source := range tc.sources {
+		req, ok := tc.requests[source]
+		if !ok {
+			continue
+		}
+		lines := []string{"// Captures for chained requests"}
+		for _, c := range tc.captures[source] {
+			lines = append(lines, captureLine(c))
+		}
+		script := strings.Join(lines, "\n")
+		if req.PostScript != "" {
+			script = req.PostScript + "\n\n" + script
+		}
+		req.PostScript = script
+	}
+}
+
+func captureLine(c capture) string {
+	name := strconv.Quote(c.name)
+	switch c.attribute {
+	case "body":
+		if strings.HasPrefix(c.path, "$") {
+			return "gottp.setEnvVar(" + name + ", gottp.query(" + strconv.Quote(c.path) + "));"
+		}
+		return "// set " + name + " from XPath " + c.path + " by hand"
+	case "header":
+		return "gottp.setEnvVar(" + name + ", gottp.response.Headers[" + strconv.Quote(http.CanonicalHeaderKey(c.path)) + "]);"
+	case "raw":
+		return "gottp.setEnvVar(" + name + ", gottp.response.Body);"
+	}
+	return "// set " + name + " from the response " + c.attribute + " by hand"
+}
+
+// tagArgs splits a tag's comma-separated arguments, unquoting them.
+func tagArgs(s string) []string {
+	var args []string
+	var cur strings.Builder
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ',':
+			args = append(args, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if strings.TrimSpace(cur.String()) != "" || len(args) > 0 {
+		args = append(args, strings.TrimSpace(cur.String()))
+	}
+	return args
+}
+
+// decodeTagArg decodes an argument Insomnia stored as b64::<base64>::46b.
+func decodeTagArg(arg string) string {
+	enc, ok := strings.CutPrefix(arg, "b64::")
+	if !ok {
+		return arg
+	}
+	enc = strings.TrimSuffix(enc, "::46b")
+	if b, err := base64.StdEncoding.DecodeString(enc); err == nil {
+		return string(b)
+	}
+	if b, err := base64.RawStdEncoding.DecodeString(enc); err == nil {
+		return string(b)
+	}
+	return arg
+}
+
+// varName turns s into a lower-case variable name joined by underscores.
+func varName(s string) string {
+	var b strings.Builder
+	sep := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if sep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			sep = false
+		} else {
+			sep = true
+		}
+	}
+	if b.Len() == 0 {
+		return "value"
+	}
+	return b.String()
+}
@@ -0,0 +1,16 @@
+package importutil
+
+import "net/url"
+
+// RequestName names an imported request after its method and path, using
+// the whole URL when it has no path or starts with a {{variable}} host.
+func RequestName(method, rawURL string) string {
+	name := method + " " + rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" && u.Path != "" {
+		name = method + " " + u.Path
+	}
+	if len(name) > 60 {
+		name = name[:57] + "..."
+	}
+	return name
+}
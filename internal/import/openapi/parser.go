@@ -3,6 +3,9 @@ package openapi
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
@@ -11,10 +14,16 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// pathTemplate matches an OpenAPI path parameter such as {petId}.
+var pathTemplate = regexp.MustCompile(`\{([^{}/]+)\}`)
+
 type openAPISpec struct {
-	OpenAPI string              `json:"openapi" yaml:"openapi"`
-	Info    openAPIInfo         `json:"info" yaml:"info"`
-	Paths   map[string]pathItem `json:"paths" yaml:"paths"`
+	OpenAPI    string              `json:"openapi" yaml:"openapi"`
+	Info       openAPIInfo         `json:"info" yaml:"info"`
+	Tags       []tagObj            `json:"tags" yaml:"tags"`
+	Paths      map[string]pathItem `json:"paths" yaml:"paths"`
+	Components components          `json:"components" yaml:"components"`
+	Security   []securityReq       `json:"security" yaml:"security"`
 }
 
 type openAPIInfo struct {
@@ -22,39 +31,100 @@ type openAPIInfo struct {
 	Version string `json:"version" yaml:"version"`
 }
 
-type pathItem map[string]operation // method -> operation
+type tagObj struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `json:"parameters" yaml:"parameters"`
+	Get        *operation   `json:"get" yaml:"get"`
+	Post       *operation   `json:"post" yaml:"post"`
+	Put        *operation   `json:"put" yaml:"put"`
+	Patch      *operation   `json:"patch" yaml:"patch"`
+	Delete     *operation   `json:"delete" yaml:"delete"`
+	Head       *operation   `json:"head" yaml:"head"`
+	Options    *operation   `json:"options" yaml:"options"`
+}
+
+// operations returns the item's operations by lowercase method, in the
+// order they are imported.
+func (p pathItem) operations() ([]string, []*operation) {
+	return []string{"get", "post", "put", "patch", "delete", "head", "options"},
+		[]*operation{p.Get, p.Post, p.Put, p.Patch, p.Delete, p.Head, p.Options}
+}
 
 type operation struct {
-	Summary     string       `json:"summary" yaml:"summary"`
-	OperationID string       `json:"operationId" yaml:"operationId"`
-	Tags        []string     `json:"tags" yaml:"tags"`
-	Parameters  []parameter  `json:"parameters" yaml:"parameters"`
-	RequestBody *requestBody `json:"requestBody" yaml:"requestBody"`
+	Summary     string         `json:"summary" yaml:"summary"`
+	OperationID string         `json:"operationId" yaml:"operationId"`
+	Tags        []string       `json:"tags" yaml:"tags"`
+	Parameters  []*parameter   `json:"parameters" yaml:"parameters"`
+	RequestBody *requestBody   `json:"requestBody" yaml:"requestBody"`
+	Security    *[]securityReq `json:"security" yaml:"security"` // nil inherits the spec's
 }
 
 type parameter struct {
-	Name     string      `json:"name" yaml:"name"`
-	In       string      `json:"in" yaml:"in"` // query, header, path
-	Required bool        `json:"required" yaml:"required"`
-	Schema   *schemaObj  `json:"schema" yaml:"schema"`
-	Example  interface{} `json:"example" yaml:"example"`
+	Ref      string                 `json:"$ref" yaml:"$ref"`
+	Name     string                 `json:"name" yaml:"name"`
+	In       string                 `json:"in" yaml:"in"` // query, header, path, cookie
+	Required bool                   `json:"required" yaml:"required"`
+	Schema   *schemaObj             `json:"schema" yaml:"schema"`
+	Example  interface{}            `json:"example" yaml:"example"`
+	Examples map[string]*exampleObj `json:"examples" yaml:"examples"`
 }
 
 type requestBody struct {
+	Ref     string               `json:"$ref" yaml:"$ref"`
 	Content map[string]mediaType `json:"content" yaml:"content"`
 }
 
 type mediaType struct {
-	Schema  *schemaObj  `json:"schema" yaml:"schema"`
-	Example interface{} `json:"example" yaml:"example"`
+	Schema   *schemaObj             `json:"schema" yaml:"schema"`
+	Example  interface{}            `json:"example" yaml:"example"`
+	Examples map[string]*exampleObj `json:"examples" yaml:"examples"`
+}
+
+type exampleObj struct {
+	Ref   string      `json:"$ref" yaml:"$ref"`
+	Value interface{} `json:"value" yaml:"value"`
+}
+
+type components struct {
+	Schemas         map[string]*schemaObj      `json:"schemas" yaml:"schemas"`
+	Parameters      map[string]*parameter      `json:"parameters" yaml:"parameters"`
+	RequestBodies   map[string]*requestBody    `json:"requestBodies" yaml:"requestBodies"`
+	Examples        map[string]*exampleObj     `json:"examples" yaml:"examples"`
+	SecuritySchemes map[string]*securityScheme `json:"securitySchemes" yaml:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Ref    string      `json:"$ref" yaml:"$ref"`
+	Type   string      `json:"type" yaml:"type"`     // http, apiKey, oauth2, openIdConnect
+	Scheme string      `json:"scheme" yaml:"scheme"` // basic, bearer, digest
+	Name   string      `json:"name" yaml:"name"`
+	In     string      `json:"in" yaml:"in"`
+	Flows  *oauthFlows `json:"flows" yaml:"flows"`
+}
+
+type oauthFlows struct {
+	AuthorizationCode *oauthFlow `json:"authorizationCode" yaml:"authorizationCode"`
+	ClientCredentials *oauthFlow `json:"clientCredentials" yaml:"clientCredentials"`
+	Password          *oauthFlow `json:"password" yaml:"password"`
 }
 
-type schemaObj struct {
-	Type    string      `json:"type" yaml:"type"`
-	Example interface{} `json:"example" yaml:"example"`
+type oauthFlow struct {
+	AuthorizationURL string `json:"authorizationUrl" yaml:"authorizationUrl"`
+	TokenURL         string `json:"tokenUrl" yaml:"tokenUrl"`
 }
 
-// ParseOpenAPI parses an OpenAPI 3.0 spec (JSON or YAML) into a gottp Collection.
+// securityReq maps security scheme names to the scopes an operation needs.
+type securityReq map[string][]string
+
+// ParseOpenAPI parses an OpenAPI 3.x spec (JSON or YAML) into a gottp
+// Collection. Operations are grouped into a folder per tag; parameters
+// become query params, headers, cookies or request variables for path
+// templates; request bodies are filled from examples or synthesized from
+// their schemas; and security schemes become auth settings, with
+// {{variables}} standing in for credentials.
 func ParseOpenAPI(data []byte) (*collection.Collection, error) {
 	var spec openAPISpec
 
@@ -70,9 +140,11 @@ func ParseOpenAPI(data []byte) (*collection.Collection, error) {
 		return nil, fmt.Errorf("not a valid OpenAPI spec: missing openapi version")
 	}
 
+	r := &resolver{c: spec.Components}
 	col := &collection.Collection{
 		Name:    spec.Info.Title,
 		Version: "1.0",
+		Auth:    r.auth(spec.Security),
 	}
 
 	// Group by tags -> folders
@@ -86,15 +158,14 @@ func ParseOpenAPI(data []byte) (*collection.Collection, error) {
 	}
 	sort.Strings(paths)
 
-	methods := []string{"get", "post", "put", "patch", "delete", "head", "options"}
-
 	for _, path := range paths {
-		pathOps := spec.Paths[path]
-		for _, method := range methods {
-			op, ok := pathOps[method]
-			if !ok {
+		item := spec.Paths[path]
+		methods, ops := item.operations()
+		for i, op := range ops {
+			if op == nil {
 				continue
 			}
+			method := methods[i]
 
 			name := op.Summary
 			if name == "" {
@@ -109,70 +180,48 @@ func ParseOpenAPI(data []byte) (*collection.Collection, error) {
 				Name:     name,
 				Protocol: "http",
 				Method:   strings.ToUpper(method),
-				URL:      path, // relative, user adds base URL
+				// Relative, user adds base URL; {id} becomes {{id}}
+				URL: pathTemplate.ReplaceAllString(path, "{{$1}}"),
 			}
 
-			// Parameters
-			for _, p := range op.Parameters {
-				switch p.In {
-				case "query":
-					val := ""
-					if p.Example != nil {
-						val = fmt.Sprintf("%v", p.Example)
-					}
-					req.Params = append(req.Params, collection.KVPair{
-						Key: p.Name, Value: val, Enabled: p.Required,
-					})
-				case "header":
-					val := ""
-					if p.Example != nil {
-						val = fmt.Sprintf("%v", p.Example)
-					}
-					req.Headers = append(req.Headers, collection.KVPair{
-						Key: p.Name, Value: val, Enabled: true,
-					})
-				}
-			}
+			r.addParameters(req, item.Parameters, op.Parameters)
+			r.addBody(req, op.RequestBody)
 
-			// Request body example
-			if op.RequestBody != nil {
-				for ct, mt := range op.RequestBody.Content {
-					bodyType := "text"
-					if strings.Contains(ct, "json") {
-						bodyType = "json"
-					} else if strings.Contains(ct, "xml") {
-						bodyType = "xml"
-					}
-					content := ""
-					if mt.Example != nil {
-						if b, err := json.MarshalIndent(mt.Example, "", "  "); err == nil {
-							content = string(b)
-						}
-					}
-					req.Body = &collection.Body{Type: bodyType, Content: content}
-					req.Headers = append(req.Headers, collection.KVPair{
-						Key: "Content-Type", Value: ct, Enabled: true,
-					})
-					break // use first content type
+			if op.Security != nil {
+				auth := r.auth(*op.Security)
+				if auth == nil {
+					auth = &collection.Auth{Type: "none"}
+				}
+				if !reflect.DeepEqual(auth, col.Auth) && (col.Auth != nil || auth.Type != "none") {
+					req.Auth = auth
 				}
 			}
 
-			item := collection.Item{Request: req}
-
+			reqItem := collection.Item{Request: req}
 			if len(op.Tags) > 0 {
-				tagMap[op.Tags[0]] = append(tagMap[op.Tags[0]], item)
+				tagMap[op.Tags[0]] = append(tagMap[op.Tags[0]], reqItem)
 			} else {
-				untagged = append(untagged, item)
+				untagged = append(untagged, reqItem)
 			}
 		}
 	}
 
-	// Build folders from tags
-	tags := make([]string, 0, len(tagMap))
+	// Build folders from tags, in the order the spec declares them, then
+	// undeclared ones alphabetically
+	var tags []string
+	for _, t := range spec.Tags {
+		if _, ok := tagMap[t.Name]; ok && !slices.Contains(tags, t.Name) {
+			tags = append(tags, t.Name)
+		}
+	}
+	var rest []string
 	for t := range tagMap {
-		tags = append(tags, t)
+		if !slices.Contains(tags, t) {
+			rest = append(rest, t)
+		}
 	}
-	sort.Strings(tags)
+	sort.Strings(rest)
+	tags = append(tags, rest...)
 
 	for _, tag := range tags {
 		col.Items = append(col.Items, collection.Item{
@@ -186,3 +235,243 @@ func ParseOpenAPI(data []byte) (*collection.Collection, error) {
 
 	return col, nil
 }
+
+// addParameters adds an operation's parameters, including those declared
+// for its whole path unless the operation overrides them. Query params are
+// enabled when required; path parameters become request variables.
+func (r *resolver) addParameters(req *collection.Request, shared, own []*parameter) {
+	var params []*parameter
+	seen := make(map[string]bool)
+	for _, p := range own {
+		if p = r.parameter(p); p != nil {
+			params = append(params, p)
+			seen[p.In+":"+p.Name] = true
+		}
+	}
+	for _, p := range shared {
+		if p = r.parameter(p); p != nil && !seen[p.In+":"+p.Name] {
+			params = append(params, p)
+		}
+	}
+
+	for _, p := range params {
+		val := r.paramValue(p)
+		switch p.In {
+		case "query":
+			req.Params = append(req.Params, collection.KVPair{
+				Key: p.Name, Value: val, Enabled: p.Required,
+			})
+		case "header":
+			req.Headers = append(req.Headers, collection.KVPair{
+				Key: p.Name, Value: val, Enabled: true,
+			})
+		case "cookie":
+			req.Cookies = append(req.Cookies, collection.KVPair{
+				Key: p.Name, Value: val, Enabled: p.Required,
+			})
+		case "path":
+			if val == "" {
+				continue // left for the user to fill in
+			}
+			if req.Variables == nil {
+				req.Variables = make(map[string]string)
+			}
+			req.Variables[p.Name] = val
+		}
+	}
+}
+
+// addBody sets the request body from the first JSON media type, or the
+// first one alphabetically, using its example or a sample built from its
+// schema.
+func (r *resolver) addBody(req *collection.Request, rb *requestBody) {
+	rb = r.requestBody(rb)
+	if rb == nil || len(rb.Content) == 0 {
+		return
+	}
+	types := make([]string, 0, len(rb.Content))
+	for ct := range rb.Content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+	ct := types[0]
+	for _, t := range types {
+		if strings.Contains(t, "json") {
+			ct = t
+			break
+		}
+	}
+	mt := rb.Content[ct]
+
+	value, ok := r.mediaExample(mt)
+	if !ok && mt.Schema != nil {
+		value, ok = r.sample(mt.Schema, nil)
+	}
+
+	body := &collection.Body{Type: "text"}
+	switch {
+	case strings.Contains(ct, "json"):
+		body.Type = "json"
+		if s, isString := value.(string); isString {
+			body.Content = s
+		} else if ok {
+			if b, err := json.MarshalIndent(value, "", "  "); err == nil {
+				body.Content = string(b)
+			}
+		}
+	case strings.Contains(ct, "x-www-form-urlencoded"), strings.Contains(ct, "multipart/"):
+		body.Type = "form-urlencoded"
+		if strings.Contains(ct, "multipart/") {
+			body.Type = "multipart"
+		}
+		if fields, isObject := value.(map[string]interface{}); isObject {
+			keys := make([]string, 0, len(fields))
+			for k := range fields {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				body.Fields = append(body.Fields, collection.BodyField{Key: k, Value: scalarString(fields[k]), Enabled: true})
+			}
+		}
+	default:
+		if strings.Contains(ct, "xml") {
+			body.Type = "xml"
+		}
+		if s, isString := value.(string); isString {
+			body.Content = s
+		}
+	}
+	req.Body = body
+
+	// Multipart needs the boundary gottp picks when sending
+	if body.Type != "multipart" {
+		req.Headers = append(req.Headers, collection.KVPair{
+			Key: "Content-Type", Value: ct, Enabled: true,
+		})
+	}
+}
+
+// auth converts the first usable alternative of a security requirement
+// list. It returns nil when there is none, including for an empty
+// requirement, which makes auth optional.
+func (r *resolver) auth(reqs []securityReq) *collection.Auth {
+	for _, req := range reqs {
+		if len(req) == 0 {
+			return nil
+		}
+		names := make([]string, 0, len(req))
+		for name := range req {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if auth := convertScheme(r.securityScheme(name), req[name]); auth != nil {
+				return auth
+			}
+		}
+	}
+	return nil
+}
+
+// convertScheme maps a security scheme to gottp auth with placeholder
+// credentials, or nil for schemes gottp cannot send.
+func convertScheme(s *securityScheme, scopes []string) *collection.Auth {
+	if s == nil {
+		return nil
+	}
+	switch strings.ToLower(s.Type) {
+	case "http":
+		switch strings.ToLower(s.Scheme) {
+		case "basic":
+			return &collection.Auth{Type: "basic", Basic: &collection.BasicAuth{Username: "{{username}}", Password: "{{password}}"}}
+		case "bearer":
+			return &collection.Auth{Type: "bearer", Bearer: &collection.BearerAuth{Token: "{{token}}"}}
+		case "digest":
+			return &collection.Auth{Type: "digest", Digest: &collection.DigestAuth{Username: "{{username}}", Password: "{{password}}"}}
+		}
+	case "apikey":
+		if s.In != "header" && s.In != "query" {
+			return nil
+		}
+		return &collection.Auth{Type: "apikey", APIKey: &collection.APIKeyAuth{Key: s.Name, Value: "{{api_key}}", In: s.In}}
+	case "oauth2":
+		if s.Flows == nil {
+			return nil
+		}
+		o := &collection.OAuth2Auth{ClientID: "{{client_id}}", Scope: strings.Join(scopes, " ")}
+		switch {
+		case s.Flows.AuthorizationCode != nil:
+			o.GrantType = "authorization_code"
+			o.AuthURL = s.Flows.AuthorizationCode.AuthorizationURL
+			o.TokenURL = s.Flows.AuthorizationCode.TokenURL
+			o.UsePKCE = true
+		case s.Flows.ClientCredentials != nil:
+			o.GrantType = "client_credentials"
+			o.TokenURL = s.Flows.ClientCredentials.TokenURL
+			o.ClientSecret = "{{client_secret}}"
+		case s.Flows.Password != nil:
+			o.GrantType = "password"
+			o.TokenURL = s.Flows.Password.TokenURL
+			o.Username, o.Password = "{{username}}", "{{password}}"
+		default:
+			return nil
+		}
+		return &collection.Auth{Type: "oauth2", OAuth2: o}
+	}
+	return nil
+}
+
+// paramValue returns a parameter's example, default or first enum value,
+// or "" when the spec gives none.
+func (r *resolver) paramValue(p *parameter) string {
+	if p.Example != nil {
+		return scalarString(p.Example)
+	}
+	if v, ok := r.firstExample(p.Examples); ok {
+		return scalarString(v)
+	}
+	if s := r.schema(p.Schema); s != nil {
+		if v, ok := s.given(); ok {
+			return scalarString(v)
+		}
+	}
+	return ""
+}
+
+// mediaExample returns the example given for a media type, if any.
+func (r *resolver) mediaExample(mt mediaType) (interface{}, bool) {
+	if mt.Example != nil {
+		return mt.Example, true
+	}
+	return r.firstExample(mt.Examples)
+}
+
+// firstExample returns the value of the alphabetically first example.
+func (r *resolver) firstExample(examples map[string]*exampleObj) (interface{}, bool) {
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ex := r.example(examples[name]); ex != nil && ex.Value != nil {
+			return ex.Value, true
+		}
+	}
+	return nil, false
+}
+
+// scalarString formats a parameter or form value: scalars as text, lists
+// and objects as JSON.
+func scalarString(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return ""
+	case map[string]interface{}, []interface{}:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -2,6 +2,8 @@ package openapi
 
 import (
 	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
 )
 
 func TestParseOpenAPIJSON(t *testing.T) {
@@ -100,3 +102,140 @@ func TestParseOpenAPIInvalid(t *testing.T) {
 		t.Error("expected error")
 	}
 }
+
+func TestParseOpenAPIComponents(t *testing.T) {
+	data := []byte(`
+openapi: "3.1.0"
+info: {title: Pets, version: "1"}
+tags:
+  - name: Pets
+  - name: Admin
+security:
+  - bearerAuth: []
+paths:
+  /admin/stats:
+    get:
+      tags: [Admin]
+      summary: Stats
+      security:
+        - apiKey: []
+  /pets/{petId}:
+    parameters:
+      - $ref: "#/components/parameters/PetId"
+    put:
+      tags: [Pets]
+      summary: Update Pet
+      parameters:
+        - {name: X-Request-ID, in: header, schema: {type: string, format: uuid, default: abc}}
+        - {name: verbose, in: query, schema: {type: boolean, enum: [true, false]}}
+        - {name: session, in: cookie, required: true, example: s1}
+      requestBody:
+        $ref: "#/components/requestBodies/Pet"
+    delete:
+      tags: [Pets]
+      summary: Delete Pet
+      security: []
+  /pets/{petId}/photo:
+    post:
+      tags: [Pets]
+      summary: Upload Photo
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                caption: {type: string, example: Fido}
+                file: {type: string, format: binary}
+components:
+  parameters:
+    PetId: {name: petId, in: path, required: true, schema: {type: integer, example: 42}}
+  requestBodies:
+    Pet:
+      content:
+        application/xml: {}
+        application/json:
+          schema: {$ref: "#/components/schemas/Pet"}
+  schemas:
+    Pet:
+      allOf:
+        - $ref: "#/components/schemas/Named"
+        - type: object
+          properties:
+            id: {type: [integer, "null"], readOnly: true}
+            born: {type: string, format: date}
+            tags: {type: array, items: {type: string}}
+            status: {type: string, enum: [available, sold]}
+            parent: {$ref: "#/components/schemas/Pet"}
+    Named:
+      type: object
+      properties:
+        name: {type: string, example: Fido}
+  securitySchemes:
+    bearerAuth: {type: http, scheme: bearer}
+    apiKey: {type: apiKey, in: header, name: X-API-Key}
+`)
+
+	col, err := ParseOpenAPI(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if col.Auth == nil || col.Auth.Type != "bearer" || col.Auth.Bearer.Token != "{{token}}" {
+		t.Errorf("collection auth = %+v", col.Auth)
+	}
+	if len(col.Items) != 2 || col.Items[0].Folder.Name != "Pets" || col.Items[1].Folder.Name != "Admin" {
+		t.Fatalf("folders should follow the declared tag order: %+v", col.Items)
+	}
+
+	stats := col.Items[1].Folder.Items[0].Request
+	if stats.Auth == nil || stats.Auth.Type != "apikey" || stats.Auth.APIKey.Key != "X-API-Key" || stats.Auth.APIKey.In != "header" {
+		t.Errorf("operation auth = %+v", stats.Auth)
+	}
+
+	pets := col.Items[0].Folder.Items
+	update, del, upload := pets[0].Request, pets[1].Request, pets[2].Request
+	if update.Name != "Update Pet" || del.Name != "Delete Pet" || upload.Name != "Upload Photo" {
+		t.Fatalf("unexpected order: %s, %s, %s", update.Name, del.Name, upload.Name)
+	}
+	if update.URL != "/pets/{{petId}}" || update.Variables["petId"] != "42" {
+		t.Errorf("path parameter: url %q, variables %v", update.URL, update.Variables)
+	}
+	if update.Auth != nil {
+		t.Errorf("update should inherit the collection auth, got %+v", update.Auth)
+	}
+	if del.Auth == nil || del.Auth.Type != "none" {
+		t.Errorf("security: [] should disable auth, got %+v", del.Auth)
+	}
+
+	if len(update.Headers) != 2 || update.Headers[0] != (collection.KVPair{Key: "X-Request-ID", Value: "abc", Enabled: true}) ||
+		update.Headers[1].Value != "application/json" {
+		t.Errorf("headers = %+v", update.Headers)
+	}
+	if len(update.Params) != 1 || update.Params[0] != (collection.KVPair{Key: "verbose", Value: "true"}) {
+		t.Errorf("params = %+v", update.Params)
+	}
+	if len(update.Cookies) != 1 || update.Cookies[0] != (collection.KVPair{Key: "session", Value: "s1", Enabled: true}) {
+		t.Errorf("cookies = %+v", update.Cookies)
+	}
+
+	want := `{
+  "born": "2024-01-01",
+  "id": 0,
+  "name": "Fido",
+  "status": "available",
+  "tags": [
+    "string"
+  ]
+}`
+	if update.Body == nil || update.Body.Type != "json" || update.Body.Content != want {
+		t.Errorf("body = %+v, want content\n%s", update.Body, want)
+	}
+
+	if upload.Body == nil || upload.Body.Type != "multipart" || len(upload.Body.Fields) != 2 ||
+		upload.Body.Fields[0] != (collection.BodyField{Key: "caption", Value: "Fido", Enabled: true}) {
+		t.Errorf("multipart body = %+v", upload.Body)
+	}
+	if len(upload.Headers) != 0 {
+		t.Errorf("multipart should not set Content-Type, got %+v", upload.Headers)
+	}
+}
@@ -0,0 +1,357 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	"gopkg.in/yaml.v3"
+)
+
+// Fetcher reads a spec document from a file path or an http(s) URL.
+type Fetcher func(location string) ([]byte, error)
+
+// maxFetchSize bounds the size of a fetched spec document.
+const maxFetchSize = 32 << 20
+
+// Fetch reads a spec document from a file, or with a GET request for an
+// http(s) URL.
+func Fetch(location string) ([]byte, error) {
+	if !IsURL(location) {
+		return os.ReadFile(location)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", location, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxFetchSize))
+}
+
+// IsURL reports whether location is an http(s) URL rather than a file.
+func IsURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// ParseOpenAPIFrom parses a spec read from location, a file path or URL,
+// like ParseOpenAPI, but first resolves $refs to other files and URLs
+// relative to the document they appear in, reading them with fetch.
+// Referenced content is inlined; references that lead back to themselves
+// are kept as local schema components.
+func ParseOpenAPIFrom(data []byte, location string, fetch Fetcher) (*collection.Collection, error) {
+	root, err := decodeDocument(data)
+	if err != nil {
+		return ParseOpenAPI(data) // reports the error
+	}
+	if location != "" && !IsURL(location) {
+		if abs, err := filepath.Abs(location); err == nil {
+			location = abs
+		}
+	}
+
+	b := &bundler{
+		fetch:   fetch,
+		root:    location,
+		docs:    map[string]interface{}{location: root},
+		inlined: make(map[string]interface{}),
+		active:  make(map[string]bool),
+		kept:    make(map[string]string),
+		schemas: make(map[string]interface{}),
+	}
+	bundled, err := b.walk(root, location)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.schemas) > 0 {
+		if doc, ok := bundled.(map[string]interface{}); ok {
+			comps, _ := doc["components"].(map[string]interface{})
+			if comps == nil {
+				comps = make(map[string]interface{})
+				doc["components"] = comps
+			}
+			schemas, _ := comps["schemas"].(map[string]interface{})
+			if schemas == nil {
+				schemas = make(map[string]interface{})
+				comps["schemas"] = schemas
+			}
+			for name, s := range b.schemas {
+				schemas[name] = s
+			}
+		}
+	}
+
+	out, err := json.Marshal(bundled)
+	if err != nil {
+		return nil, fmt.Errorf("bundling OpenAPI spec: %w", err)
+	}
+	return ParseOpenAPI(out)
+}
+
+// bundler inlines the external references of a spec.
+type bundler struct {
+	fetch   Fetcher
+	root    string                 // location of the root document
+	docs    map[string]interface{} // parsed documents by location
+	inlined map[string]interface{} // resolved targets by location#pointer
+	active  map[string]bool        // targets being resolved, to detect cycles
+	kept    map[string]string      // cyclic targets -> their local component name
+	schemas map[string]interface{} // components added to the root
+}
+
+// walk returns node with external references resolved. base is the
+// location of the document node comes from.
+func (b *bundler) walk(node interface{}, base string) (interface{}, error) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok {
+			return b.resolve(ref, base)
+		}
+		out := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			w, err := b.walk(v, base)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = w
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(n))
+		for i, v := range n {
+			w, err := b.walk(v, base)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = w
+		}
+		return out, nil
+	}
+	return node, nil
+}
+
+// resolve returns the target of ref, found relative to base, with its
+// own references resolved. Local references in the root document are
+// left for the parser.
+func (b *bundler) resolve(ref, base string) (interface{}, error) {
+	loc, pointer, _ := strings.Cut(ref, "#")
+	if loc == "" && base == b.root {
+		return map[string]interface{}{"$ref": ref}, nil
+	}
+	target := b.locate(loc, base)
+	key := target + "#" + pointer
+	if target == b.root {
+		// A reference back into the root document from another file
+		return map[string]interface{}{"$ref": "#" + pointer}, nil
+	}
+	if name, ok := b.kept[key]; ok {
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}, nil
+	}
+	if v, ok := b.inlined[key]; ok {
+		return v, nil
+	}
+	if b.active[key] {
+		// A cycle: keep the target as a component and refer to it
+		name := b.componentName(target, pointer)
+		b.kept[key] = name
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}, nil
+	}
+
+	doc, err := b.document(target)
+	if err != nil {
+		return nil, fmt.Errorf("resolving $ref %q: %w", ref, err)
+	}
+	node, err := lookupPointer(doc, pointer)
+	if err != nil {
+		return nil, fmt.Errorf("resolving $ref %q: %w", ref, err)
+	}
+
+	b.active[key] = true
+	resolved, err := b.walk(node, target)
+	delete(b.active, key)
+	if err != nil {
+		return nil, err
+	}
+	if name, ok := b.kept[key]; ok {
+		b.schemas[name] = resolved
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}, nil
+	}
+	b.inlined[key] = resolved
+	return resolved, nil
+}
+
+// locate resolves a reference location against the document it is in.
+func (b *bundler) locate(loc, base string) string {
+	if loc == "" {
+		return base
+	}
+	if IsURL(loc) {
+		return loc
+	}
+	if IsURL(base) {
+		u, err := url.Parse(base)
+		if err != nil {
+			return loc
+		}
+		r, err := url.Parse(loc)
+		if err != nil {
+			return loc
+		}
+		return u.ResolveReference(r).String()
+	}
+	if filepath.IsAbs(loc) {
+		return filepath.Clean(loc)
+	}
+	return filepath.Join(filepath.Dir(base), filepath.FromSlash(loc))
+}
+
+// document returns the parsed document at location, reading it once.
+func (b *bundler) document(location string) (interface{}, error) {
+	if doc, ok := b.docs[location]; ok {
+		return doc, nil
+	}
+	if b.fetch == nil {
+		return nil, fmt.Errorf("external references are not supported here")
+	}
+	data, err := b.fetch(location)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", location, err)
+	}
+	b.docs[location] = doc
+	return doc, nil
+}
+
+// componentName picks an unused schema name for a kept target, from the
+// last pointer segment or the file name.
+func (b *bundler) componentName(location, pointer string) string {
+	name := pointer[strings.LastIndex(pointer, "/")+1:]
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(location), filepath.Ext(location))
+	}
+	name = unescapePointer(name)
+	unique := name
+	for i := 2; b.nameTaken(unique); i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	return unique
+}
+
+func (b *bundler) nameTaken(name string) bool {
+	for _, kept := range b.kept {
+		if kept == name {
+			return true
+		}
+	}
+	if root, ok := b.docs[b.root].(map[string]interface{}); ok {
+		comps, _ := root["components"].(map[string]interface{})
+		schemas, _ := comps["schemas"].(map[string]interface{})
+		_, taken := schemas[name]
+		return taken
+	}
+	return false
+}
+
+// lookupPointer follows a JSON pointer such as "/components/schemas/Pet"
+// into doc. An empty pointer is the whole document.
+func lookupPointer(doc interface{}, pointer string) (interface{}, error) {
+	if pointer == "" || pointer == "/" {
+		return doc, nil
+	}
+	node := doc
+	for _, seg := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		seg = unescapePointer(seg)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[seg]
+			if !ok {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+			node = v
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("%s not found", pointer)
+		}
+	}
+	return node, nil
+}
+
+func unescapePointer(seg string) string {
+	if s, err := url.PathUnescape(seg); err == nil {
+		seg = s
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+}
+
+// decodeDocument parses JSON or YAML into maps with string keys, slices
+// and scalars. YAML dates stay strings, as they would be in JSON.
+func decodeDocument(data []byte) (interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err == nil {
+		return doc, nil
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("not valid JSON or YAML")
+	}
+	return nodeValue(&node, 0), nil
+}
+
+// maxAliasDepth bounds YAML alias expansion.
+const maxAliasDepth = 64
+
+func nodeValue(n *yaml.Node, depth int) interface{} {
+	if depth > maxAliasDepth {
+		return nil
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil
+		}
+		return nodeValue(n.Content[0], depth)
+	case yaml.AliasNode:
+		return nodeValue(n.Alias, depth+1)
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			m[n.Content[i].Value] = nodeValue(n.Content[i+1], depth)
+		}
+		return m
+	case yaml.SequenceNode:
+		list := make([]interface{}, len(n.Content))
+		for i, c := range n.Content {
+			list[i] = nodeValue(c, depth)
+		}
+		return list
+	}
+	switch n.Tag {
+	case "!!null":
+		return nil
+	case "!!bool", "!!int", "!!float":
+		var v interface{}
+		if n.Decode(&v) == nil {
+			return v
+		}
+	}
+	return n.Value
+}
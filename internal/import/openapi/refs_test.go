@@ -0,0 +1,147 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSpecFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParseOpenAPIFrom_SiblingFiles(t *testing.T) {
+	dir := writeSpecFiles(t, map[string]string{
+		"openapi.yaml": `
+openapi: "3.0.3"
+info: {title: Split, version: "1"}
+paths:
+  /pets:
+    $ref: paths/pets.yaml
+`,
+		"paths/pets.yaml": `
+post:
+  summary: Create Pet
+  parameters:
+    - $ref: "../common.yaml#/parameters/Trace"
+  requestBody:
+    content:
+      application/json:
+        schema:
+          $ref: "../schemas/pet.yaml"
+`,
+		"common.yaml": `
+parameters:
+  Trace: {name: X-Trace, in: header, example: t-1}
+`,
+		"schemas/pet.yaml": `
+type: object
+properties:
+  name: {type: string, example: Fido}
+  born: {type: string, example: 2020-05-01}
+  owner:
+    $ref: "#/definitions/Owner"
+  children:
+    type: array
+    items: {$ref: "pet.yaml"}
+definitions:
+  Owner:
+    type: object
+    properties:
+      email: {type: string, format: email}
+`,
+	})
+
+	path := filepath.Join(dir, "openapi.yaml")
+	data, _ := os.ReadFile(path)
+	col, err := ParseOpenAPIFrom(data, path, Fetch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(col.Items) != 1 || col.Items[0].Request == nil {
+		t.Fatalf("items = %+v", col.Items)
+	}
+	req := col.Items[0].Request
+	if len(req.Headers) == 0 || req.Headers[0].Key != "X-Trace" || req.Headers[0].Value != "t-1" {
+		t.Errorf("headers = %+v", req.Headers)
+	}
+	// The recursive children list is left empty
+	want := `{
+  "born": "2020-05-01",
+  "children": [],
+  "name": "Fido",
+  "owner": {
+    "email": "user@example.com"
+  }
+}`
+	if req.Body == nil || req.Body.Content != want {
+		t.Errorf("body = %+v, want\n%s", req.Body, want)
+	}
+}
+
+func TestParseOpenAPIFrom_RemoteRefs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/openapi.json":
+			w.Write([]byte(`{"openapi": "3.1.0", "info": {"title": "Remote", "version": "1"},
+				"paths": {"/items": {"post": {"summary": "Add", "requestBody": {"content": {"application/json": {
+					"schema": {"$ref": "models/item.json#/Item"}}}}}}}}`))
+		case "/api/models/item.json":
+			w.Write([]byte(`{"Item": {"type": "object", "properties": {"sku": {"type": "string", "example": "A1"}}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	location := srv.URL + "/api/openapi.json"
+	data, err := Fetch(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	col, err := ParseOpenAPIFrom(data, location, Fetch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body := col.Items[0].Request.Body; body == nil || !strings.Contains(body.Content, `"sku": "A1"`) {
+		t.Errorf("body = %+v", body)
+	}
+
+	if _, err := Fetch(srv.URL + "/missing.json"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Fetch error = %v, want a 404", err)
+	}
+}
+
+func TestParseOpenAPIFrom_Errors(t *testing.T) {
+	dir := writeSpecFiles(t, map[string]string{
+		"a.yaml": `{openapi: "3.0.0", info: {title: A}, paths: {/x: {$ref: "missing.yaml"}}}`,
+		"b.yaml": `{openapi: "3.0.0", info: {title: B}, paths: {/x: {$ref: "c.yaml#/nope"}}}`,
+		"c.yaml": `other: {}`,
+	})
+	for name, want := range map[string]string{"a.yaml": "missing.yaml", "b.yaml": "/nope not found"} {
+		path := filepath.Join(dir, name)
+		data, _ := os.ReadFile(path)
+		if _, err := ParseOpenAPIFrom(data, path, Fetch); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error = %v, want it to mention %q", name, err, want)
+		}
+	}
+
+	// Without a fetcher, only local references work
+	if _, err := ParseOpenAPIFrom([]byte(`{"openapi": "3.0.0", "paths": {"/x": {"$ref": "other.yaml"}}}`), "", nil); err == nil {
+		t.Error("expected an error for an external reference without a fetcher")
+	}
+}
@@ -0,0 +1,264 @@
+package openapi
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxSampleDepth bounds how deep samples of nested schemas go.
+const maxSampleDepth = 8
+
+type schemaObj struct {
+	Ref        string                `json:"$ref" yaml:"$ref"`
+	Type       schemaType            `json:"type" yaml:"type"`
+	Format     string                `json:"format" yaml:"format"`
+	Example    interface{}           `json:"example" yaml:"example"`
+	Examples   interface{}           `json:"examples" yaml:"examples"` // a list in OpenAPI 3.1
+	Default    interface{}           `json:"default" yaml:"default"`
+	Const      interface{}           `json:"const" yaml:"const"`
+	Enum       []interface{}         `json:"enum" yaml:"enum"`
+	Properties map[string]*schemaObj `json:"properties" yaml:"properties"`
+	Items      *schemaObj            `json:"items" yaml:"items"`
+	AllOf      []*schemaObj          `json:"allOf" yaml:"allOf"`
+	OneOf      []*schemaObj          `json:"oneOf" yaml:"oneOf"`
+	AnyOf      []*schemaObj          `json:"anyOf" yaml:"anyOf"`
+}
+
+// schemaType is a schema's type, which OpenAPI 3.1 allows to be a list
+// such as [string, "null"].
+type schemaType []string
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = schemaType{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+func (t *schemaType) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = schemaType{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// primary returns the first type other than null.
+func (t schemaType) primary() string {
+	for _, s := range t {
+		if s != "null" {
+			return s
+		}
+	}
+	return ""
+}
+
+// given returns the value the schema itself suggests: its example, first
+// listed example, default, const or first enum value.
+func (s *schemaObj) given() (interface{}, bool) {
+	if s.Example != nil {
+		return s.Example, true
+	}
+	if list, ok := s.Examples.([]interface{}); ok && len(list) > 0 {
+		return list[0], true
+	}
+	if s.Default != nil {
+		return s.Default, true
+	}
+	if s.Const != nil {
+		return s.Const, true
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[0], true
+	}
+	return nil, false
+}
+
+// sample builds an example value for s, preferring the values the spec
+// gives and falling back to placeholders by type and format. refs holds
+// the references being expanded, so recursive schemas stop; ok is false
+// for such a cycle and for schemas without a usable type.
+func (r *resolver) sample(s *schemaObj, refs []string) (interface{}, bool) {
+	if s != nil && s.Ref != "" {
+		if slices.Contains(refs, s.Ref) || len(refs) >= maxSampleDepth {
+			return nil, false
+		}
+		refs = append(refs, s.Ref)
+		s = r.schema(s)
+	}
+	if s == nil {
+		return nil, false
+	}
+	if v, ok := s.given(); ok {
+		return v, true
+	}
+
+	if len(s.AllOf) > 0 {
+		merged := map[string]interface{}{}
+		for _, part := range s.AllOf {
+			if v, ok := r.sample(part, refs); ok {
+				if m, isObject := v.(map[string]interface{}); isObject {
+					for k, val := range m {
+						merged[k] = val
+					}
+				}
+			}
+		}
+		for k, v := range r.sampleProperties(s, refs) {
+			merged[k] = v
+		}
+		return merged, true
+	}
+	for _, alts := range [][]*schemaObj{s.OneOf, s.AnyOf} {
+		for _, alt := range alts {
+			if v, ok := r.sample(alt, refs); ok {
+				return v, true
+			}
+		}
+	}
+
+	typ := s.Type.primary()
+	if typ == "" {
+		switch {
+		case s.Properties != nil:
+			typ = "object"
+		case s.Items != nil:
+			typ = "array"
+		}
+	}
+	switch typ {
+	case "object":
+		return r.sampleProperties(s, refs), true
+	case "array":
+		if v, ok := r.sample(s.Items, refs); ok {
+			return []interface{}{v}, true
+		}
+		return []interface{}{}, true
+	case "string":
+		return sampleString(s.Format), true
+	case "integer", "number":
+		return 0, true
+	case "boolean":
+		return false, true
+	}
+	return nil, false
+}
+
+// sampleProperties samples each property of an object schema.
+func (r *resolver) sampleProperties(s *schemaObj, refs []string) map[string]interface{} {
+	obj := map[string]interface{}{}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v, ok := r.sample(s.Properties[name], refs); ok {
+			obj[name] = v
+		}
+	}
+	return obj
+}
+
+func sampleString(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "00:00:00"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "binary", "byte":
+		return ""
+	}
+	return "string"
+}
+
+// maxRefHops bounds chains of references to references.
+const maxRefHops = 16
+
+// resolver looks up local "#/components/..." references.
+type resolver struct {
+	c components
+}
+
+func (r *resolver) schema(s *schemaObj) *schemaObj {
+	for i := 0; s != nil && s.Ref != "" && i < maxRefHops; i++ {
+		s = r.c.Schemas[refName(s.Ref, "schemas")]
+	}
+	return s
+}
+
+func (r *resolver) parameter(p *parameter) *parameter {
+	for i := 0; p != nil && p.Ref != "" && i < maxRefHops; i++ {
+		p = r.c.Parameters[refName(p.Ref, "parameters")]
+	}
+	if p != nil && p.Ref != "" {
+		return nil
+	}
+	return p
+}
+
+func (r *resolver) requestBody(rb *requestBody) *requestBody {
+	for i := 0; rb != nil && rb.Ref != "" && i < maxRefHops; i++ {
+		rb = r.c.RequestBodies[refName(rb.Ref, "requestBodies")]
+	}
+	if rb != nil && rb.Ref != "" {
+		return nil
+	}
+	return rb
+}
+
+func (r *resolver) example(ex *exampleObj) *exampleObj {
+	for i := 0; ex != nil && ex.Ref != "" && i < maxRefHops; i++ {
+		ex = r.c.Examples[refName(ex.Ref, "examples")]
+	}
+	return ex
+}
+
+func (r *resolver) securityScheme(name string) *securityScheme {
+	s := r.c.SecuritySchemes[name]
+	for i := 0; s != nil && s.Ref != "" && i < maxRefHops; i++ {
+		s = r.c.SecuritySchemes[refName(s.Ref, "securitySchemes")]
+	}
+	return s
+}
+
+// refName returns the component name a "#/components/<kind>/<name>"
+// reference points to, or "" for other references.
+func refName(ref, kind string) string {
+	name, ok := strings.CutPrefix(ref, "#/components/"+kind+"/")
+	if !ok {
+		return ""
+	}
+	// JSON pointer escapes
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+}
@@ -3,6 +3,7 @@ package postman
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/google/uuid"
@@ -15,15 +16,19 @@ type postmanCollection struct {
 		Name   string `json:"name"`
 		Schema string `json:"schema"`
 	} `json:"info"`
-	Item     []postmanItem `json:"item"`
-	Auth     *postmanAuth  `json:"auth,omitempty"`
-	Variable []postmanVar  `json:"variable,omitempty"`
+	Item     []postmanItem  `json:"item"`
+	Auth     *postmanAuth   `json:"auth,omitempty"`
+	Variable []postmanVar   `json:"variable,omitempty"`
+	Event    []postmanEvent `json:"event,omitempty"`
 }
 
 type postmanItem struct {
-	Name    string        `json:"name"`
-	Item    []postmanItem `json:"item,omitempty"` // folder
-	Request *postmanReq   `json:"request,omitempty"`
+	Name     string         `json:"name"`
+	Item     []postmanItem  `json:"item,omitempty"` // folder
+	Request  *postmanReq    `json:"request,omitempty"`
+	Auth     *postmanAuth   `json:"auth,omitempty"`     // folder
+	Variable []postmanVar   `json:"variable,omitempty"` // folder
+	Event    []postmanEvent `json:"event,omitempty"`
 }
 
 type postmanReq struct {
@@ -35,8 +40,26 @@ type postmanReq struct {
 }
 
 type postmanBody struct {
-	Mode string `json:"mode"`
-	Raw  string `json:"raw"`
+	Mode       string             `json:"mode"`
+	Raw        string             `json:"raw"`
+	URLEncoded []postmanFormParam `json:"urlencoded,omitempty"`
+	FormData   []postmanFormParam `json:"formdata,omitempty"`
+	File       *struct {
+		Src string `json:"src"`
+	} `json:"file,omitempty"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanFormParam struct {
+	Key      string          `json:"key"`
+	Value    string          `json:"value"`
+	Type     string          `json:"type"` // text, file
+	Src      json.RawMessage `json:"src,omitempty"`
+	Disabled bool            `json:"disabled"`
 }
 
 type postmanKV struct {
@@ -46,15 +69,33 @@ type postmanKV struct {
 }
 
 type postmanAuth struct {
-	Type   string      `json:"type"`
-	Basic  []postmanKV `json:"basic,omitempty"`
-	Bearer []postmanKV `json:"bearer,omitempty"`
-	Apikey []postmanKV `json:"apikey,omitempty"`
+	Type   string        `json:"type"`
+	Basic  []postmanAttr `json:"basic,omitempty"`
+	Bearer []postmanAttr `json:"bearer,omitempty"`
+	Apikey []postmanAttr `json:"apikey,omitempty"`
+	Digest []postmanAttr `json:"digest,omitempty"`
+	OAuth2 []postmanAttr `json:"oauth2,omitempty"`
+	AWSv4  []postmanAttr `json:"awsv4,omitempty"`
+}
+
+// postmanAttr is an auth setting; values may be strings, numbers or
+// booleans.
+type postmanAttr struct {
+	Key   string      `json:"key"`
+	Value scalarValue `json:"value"`
 }
 
 type postmanVar struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
+	Key      string      `json:"key"`
+	Value    scalarValue `json:"value"`
+	Disabled bool        `json:"disabled"`
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"` // prerequest, test
+	Script struct {
+		Exec json.RawMessage `json:"exec"` // a list of lines or one string
+	} `json:"script"`
 }
 
 type postmanURLObj struct {
@@ -62,7 +103,34 @@ type postmanURLObj struct {
 	Query []postmanKV `json:"query,omitempty"`
 }
 
+// scalarValue is a JSON string, number or boolean read as a string.
+type scalarValue string
+
+func (v *scalarValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = scalarValue(s)
+		return nil
+	}
+	var x interface{}
+	if err := json.Unmarshal(data, &x); err != nil {
+		return err
+	}
+	switch x.(type) {
+	case nil:
+		*v = ""
+	case map[string]interface{}, []interface{}:
+		*v = scalarValue(data)
+	default:
+		*v = scalarValue(fmt.Sprint(x))
+	}
+	return nil
+}
+
 // ParsePostman parses a Postman Collection v2.1 JSON into a gottp Collection.
+// Collection and folder auth is inherited by requests without their own,
+// and pre-request and test scripts are translated to gottp scripts, with
+// collection and folder scripts running before the request's.
 func ParsePostman(data []byte) (*collection.Collection, error) {
 	var pc postmanCollection
 	if err := json.Unmarshal(data, &pc); err != nil {
@@ -74,30 +142,51 @@ func ParsePostman(data []byte) (*collection.Collection, error) {
 	}
 
 	col := &collection.Collection{
-		Name:    pc.Info.Name,
-		Version: "1.0",
-	}
-
-	if len(pc.Variable) > 0 {
-		col.Variables = make(map[string]string)
-		for _, v := range pc.Variable {
-			col.Variables[v.Key] = v.Value
-		}
+		Name:      pc.Info.Name,
+		Version:   "1.0",
+		Auth:      convertAuth(pc.Auth),
+		Variables: convertVariables(pc.Variable),
 	}
 
+	top := scope{colAuth: col.Auth}
+	top = top.with(nil, pc.Event)
 	for _, item := range pc.Item {
-		col.Items = append(col.Items, convertItem(item))
+		col.Items = append(col.Items, convertItem(item, top))
 	}
 
 	return col, nil
 }
 
-func convertItem(pi postmanItem) collection.Item {
-	if len(pi.Item) > 0 {
+// scope is what an item inherits from the collection and its folders.
+type scope struct {
+	colAuth *collection.Auth
+	auth    *collection.Auth // nearest folder auth, nil for the collection's
+	pre     []string
+	post    []string
+}
+
+// with returns the scope inside a folder with auth and events.
+func (s scope) with(auth *postmanAuth, events []postmanEvent) scope {
+	if a := convertAuth(auth); a != nil {
+		s.auth = a
+	}
+	pre, post := convertEvents(events)
+	if pre != "" {
+		s.pre = append(s.pre[:len(s.pre):len(s.pre)], pre)
+	}
+	if post != "" {
+		s.post = append(s.post[:len(s.post):len(s.post)], post)
+	}
+	return s
+}
+
+func convertItem(pi postmanItem, sc scope) collection.Item {
+	if pi.Item != nil && pi.Request == nil {
 		// It's a folder
-		folder := &collection.Folder{Name: pi.Name}
+		folder := &collection.Folder{Name: pi.Name, Variables: convertVariables(pi.Variable)}
+		inner := sc.with(pi.Auth, pi.Event)
 		for _, child := range pi.Item {
-			folder.Items = append(folder.Items, convertItem(child))
+			folder.Items = append(folder.Items, convertItem(child, inner))
 		}
 		return collection.Item{Folder: folder}
 	}
@@ -130,29 +219,128 @@ func convertItem(pi postmanItem) collection.Item {
 			})
 		}
 
-		// Body
-		if pi.Request.Body != nil && pi.Request.Body.Raw != "" {
-			bodyType := "text"
-			if pi.Request.Body.Mode == "raw" {
-				bodyType = "json" // default assumption
-			}
-			req.Body = &collection.Body{
-				Type:    bodyType,
-				Content: pi.Request.Body.Raw,
-			}
-		}
+		req.Body = convertBody(pi.Request.Body)
 
-		// Auth
-		if pi.Request.Auth != nil {
-			req.Auth = convertAuth(pi.Request.Auth)
+		// Auth: the request's own, else the nearest folder's. Auth the
+		// same as the collection's is left for the collection to supply.
+		auth := convertAuth(pi.Request.Auth)
+		if auth == nil {
+			auth = sc.auth
+		}
+		if auth != nil && !reflect.DeepEqual(auth, sc.colAuth) && (sc.colAuth != nil || auth.Type != "none") {
+			req.Auth = auth
 		}
 
+		// Scripts
+		inner := sc.with(nil, pi.Event)
+		req.PreScript = strings.Join(inner.pre, "\n\n")
+		req.PostScript = strings.Join(inner.post, "\n\n")
+
 		return collection.Item{Request: req}
 	}
 
 	return collection.Item{}
 }
 
+// convertBody maps a Postman body. Raw bodies default to JSON unless
+// their language says otherwise.
+func convertBody(pb *postmanBody) *collection.Body {
+	if pb == nil {
+		return nil
+	}
+	switch pb.Mode {
+	case "urlencoded", "formdata":
+		params, typ := pb.URLEncoded, collection.BodyFormURLEncoded
+		if pb.Mode == "formdata" {
+			params, typ = pb.FormData, collection.BodyMultipart
+		}
+		if len(params) == 0 {
+			return nil
+		}
+		body := &collection.Body{Type: typ}
+		for _, p := range params {
+			field := collection.BodyField{Key: p.Key, Enabled: !p.Disabled}
+			if p.Type == "file" {
+				field.File = formFileSrc(p.Src)
+			} else {
+				field.Value = p.Value
+			}
+			body.Fields = append(body.Fields, field)
+		}
+		return body
+	case "file":
+		if pb.File == nil || pb.File.Src == "" {
+			return nil
+		}
+		return &collection.Body{Type: collection.BodyBinaryFile, File: pb.File.Src}
+	}
+	if pb.Raw == "" {
+		return nil
+	}
+	bodyType := "text"
+	if pb.Mode == "raw" {
+		switch pb.Options.Raw.Language {
+		case "", "json":
+			bodyType = "json"
+		case "xml":
+			bodyType = "xml"
+		}
+	}
+	return &collection.Body{Type: bodyType, Content: pb.Raw}
+}
+
+// formFileSrc returns the file a form field uploads; Postman allows a
+// list, of which gottp keeps the first.
+func formFileSrc(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil && len(list) > 0 {
+		return list[0]
+	}
+	return ""
+}
+
+func convertVariables(vars []postmanVar) map[string]string {
+	var out map[string]string
+	for _, v := range vars {
+		if v.Disabled || v.Key == "" {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		out[v.Key] = string(v.Value)
+	}
+	return out
+}
+
+// convertEvents returns the translated pre-request and test scripts.
+func convertEvents(events []postmanEvent) (pre, post string) {
+	for _, e := range events {
+		var lines []string
+		var s string
+		if json.Unmarshal(e.Script.Exec, &s) == nil {
+			lines = strings.Split(s, "\n")
+		} else if json.Unmarshal(e.Script.Exec, &lines) != nil {
+			continue
+		}
+		script := translateScript(lines)
+		if script == "" {
+			continue
+		}
+		switch e.Listen {
+		case "prerequest":
+			pre = script
+		case "test":
+			post = script
+		}
+	}
+	return pre, post
+}
+
 func extractURL(raw json.RawMessage) string {
 	// Try as string first
 	var s string
@@ -180,38 +368,70 @@ func convertAuth(pa *postmanAuth) *collection.Auth {
 		return nil
 	}
 	switch pa.Type {
+	case "noauth":
+		return &collection.Auth{Type: "none"}
 	case "basic":
-		auth := &collection.Auth{Type: "basic", Basic: &collection.BasicAuth{}}
-		for _, kv := range pa.Basic {
-			switch kv.Key {
-			case "username":
-				auth.Basic.Username = kv.Value
-			case "password":
-				auth.Basic.Password = kv.Value
-			}
-		}
-		return auth
+		attrs := authAttrs(pa.Basic)
+		return &collection.Auth{Type: "basic", Basic: &collection.BasicAuth{
+			Username: attrs["username"],
+			Password: attrs["password"],
+		}}
 	case "bearer":
-		auth := &collection.Auth{Type: "bearer", Bearer: &collection.BearerAuth{}}
-		for _, kv := range pa.Bearer {
-			if kv.Key == "token" {
-				auth.Bearer.Token = kv.Value
-			}
-		}
-		return auth
+		return &collection.Auth{Type: "bearer", Bearer: &collection.BearerAuth{Token: authAttrs(pa.Bearer)["token"]}}
 	case "apikey":
-		auth := &collection.Auth{Type: "apikey", APIKey: &collection.APIKeyAuth{In: "header"}}
-		for _, kv := range pa.Apikey {
-			switch kv.Key {
-			case "key":
-				auth.APIKey.Key = kv.Value
-			case "value":
-				auth.APIKey.Value = kv.Value
-			case "in":
-				auth.APIKey.In = kv.Value
-			}
+		attrs := authAttrs(pa.Apikey)
+		auth := &collection.Auth{Type: "apikey", APIKey: &collection.APIKeyAuth{
+			Key:   attrs["key"],
+			Value: attrs["value"],
+			In:    attrs["in"],
+		}}
+		if auth.APIKey.In == "" {
+			auth.APIKey.In = "header"
 		}
 		return auth
+	case "digest":
+		attrs := authAttrs(pa.Digest)
+		return &collection.Auth{Type: "digest", Digest: &collection.DigestAuth{
+			Username: attrs["username"],
+			Password: attrs["password"],
+		}}
+	case "oauth2":
+		attrs := authAttrs(pa.OAuth2)
+		grant := attrs["grant_type"]
+		switch grant {
+		case "", "authorization_code_with_pkce":
+			grant = "authorization_code"
+		case "password_credentials":
+			grant = "password"
+		}
+		return &collection.Auth{Type: "oauth2", OAuth2: &collection.OAuth2Auth{
+			GrantType:    grant,
+			AuthURL:      attrs["authUrl"],
+			TokenURL:     attrs["accessTokenUrl"],
+			ClientID:     attrs["clientId"],
+			ClientSecret: attrs["clientSecret"],
+			Scope:        attrs["scope"],
+			Username:     attrs["username"],
+			Password:     attrs["password"],
+			UsePKCE:      attrs["grant_type"] == "authorization_code_with_pkce",
+		}}
+	case "awsv4":
+		attrs := authAttrs(pa.AWSv4)
+		return &collection.Auth{Type: "awsv4", AWSAuth: &collection.AWSAuth{
+			AccessKeyID:     attrs["accessKey"],
+			SecretAccessKey: attrs["secretKey"],
+			SessionToken:    attrs["sessionToken"],
+			Region:          attrs["region"],
+			Service:         attrs["service"],
+		}}
 	}
 	return nil
 }
+
+func authAttrs(list []postmanAttr) map[string]string {
+	attrs := make(map[string]string, len(list))
+	for _, a := range list {
+		attrs[a.Key] = string(a.Value)
+	}
+	return attrs
+}
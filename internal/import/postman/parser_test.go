@@ -1,6 +1,7 @@
 package postman
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -97,3 +98,132 @@ func TestParsePostmanInvalid(t *testing.T) {
 		t.Error("expected error for invalid JSON")
 	}
 }
+
+func TestParsePostmanInheritance(t *testing.T) {
+	data := []byte(`{
+		"info": {"name": "Inherit"},
+		"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]},
+		"event": [{"listen": "prerequest", "script": {"exec": ["pm.environment.set(\"ts\", Date.now());"]}}],
+		"item": [
+			{
+				"name": "Admin",
+				"auth": {"type": "basic", "basic": [
+					{"key": "username", "value": "admin"},
+					{"key": "password", "value": "secret"},
+					{"key": "showPassword", "value": false}
+				]},
+				"variable": [
+					{"key": "role", "value": "admin"},
+					{"key": "limit", "value": 5},
+					{"key": "old", "value": "x", "disabled": true}
+				],
+				"event": [{"listen": "test", "script": {"exec": "pm.test(\"ok\", function () {\n    pm.response.to.have.status(200);\n});"}}],
+				"item": [
+					{"name": "Stats", "request": {"method": "GET", "url": "{{base}}/stats"}},
+					{"name": "Public", "request": {"method": "GET", "url": "{{base}}/public", "auth": {"type": "noauth"}}}
+				]
+			},
+			{"name": "Me", "request": {"method": "GET", "url": "{{base}}/me"}}
+		]
+	}`)
+
+	col, err := ParsePostman(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if col.Auth == nil || col.Auth.Type != "bearer" || col.Auth.Bearer.Token != "{{token}}" {
+		t.Fatalf("collection auth = %+v", col.Auth)
+	}
+
+	folder := col.Items[0].Folder
+	if folder.Variables["role"] != "admin" || folder.Variables["limit"] != "5" {
+		t.Errorf("folder variables = %v", folder.Variables)
+	}
+	if _, ok := folder.Variables["old"]; ok {
+		t.Error("disabled variable imported")
+	}
+
+	stats := folder.Items[0].Request
+	if stats.Auth == nil || stats.Auth.Type != "basic" || stats.Auth.Basic.Username != "admin" {
+		t.Errorf("folder auth not inherited: %+v", stats.Auth)
+	}
+	if public := folder.Items[1].Request; public.Auth == nil || public.Auth.Type != "none" {
+		t.Errorf("noauth = %+v, want none", public.Auth)
+	}
+	if me := col.Items[1].Request; me.Auth != nil {
+		t.Errorf("request inheriting collection auth got %+v", me.Auth)
+	}
+
+	if want := `gottp.setEnvVar("ts", Date.now());`; stats.PreScript != want {
+		t.Errorf("pre-script = %q, want %q", stats.PreScript, want)
+	}
+	wantPost := "gottp.test(\"ok\", function () {\n    gottp.assert(gottp.response.StatusCode === 200, \"expected status 200\");\n});"
+	if stats.PostScript != wantPost {
+		t.Errorf("post-script = %q, want %q", stats.PostScript, wantPost)
+	}
+	if col.Items[1].Request.PostScript != "" {
+		t.Error("folder script applied outside the folder")
+	}
+}
+
+func TestParsePostmanFormBodies(t *testing.T) {
+	data := []byte(`{
+		"info": {"name": "Forms"},
+		"item": [
+			{"name": "Login", "request": {"method": "POST", "url": "/login", "body": {"mode": "urlencoded", "urlencoded": [
+				{"key": "user", "value": "ann"},
+				{"key": "debug", "value": "1", "disabled": true}
+			]}}},
+			{"name": "Upload", "request": {"method": "POST", "url": "/upload", "body": {"mode": "formdata", "formdata": [
+				{"key": "title", "value": "cat", "type": "text"},
+				{"key": "photo", "type": "file", "src": ["/tmp/cat.png"]}
+			]}}},
+			{"name": "XML", "request": {"method": "POST", "url": "/xml", "body": {"mode": "raw", "raw": "<a/>", "options": {"raw": {"language": "xml"}}}}}
+		]
+	}`)
+
+	col, err := ParsePostman(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	login := col.Items[0].Request.Body
+	if login.Type != "form-urlencoded" || len(login.Fields) != 2 {
+		t.Fatalf("urlencoded body = %+v", login)
+	}
+	if login.Fields[0].Key != "user" || login.Fields[0].Value != "ann" || !login.Fields[0].Enabled || login.Fields[1].Enabled {
+		t.Errorf("urlencoded fields = %+v", login.Fields)
+	}
+
+	upload := col.Items[1].Request.Body
+	if upload.Type != "multipart" || len(upload.Fields) != 2 {
+		t.Fatalf("formdata body = %+v", upload)
+	}
+	if upload.Fields[0].Value != "cat" || upload.Fields[1].File != "/tmp/cat.png" {
+		t.Errorf("formdata fields = %+v", upload.Fields)
+	}
+
+	if xml := col.Items[2].Request.Body; xml.Type != "xml" || xml.Content != "<a/>" {
+		t.Errorf("raw xml body = %+v", xml)
+	}
+}
+
+func TestTranslateScript(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`pm.collectionVariables.set("id", pm.response.json().id);`, `gottp.setEnvVar("id", JSON.parse(gottp.response.Body).id);`},
+		{`var token = pm.environment.get("token");`, `var token = gottp.getEnvVar("token");`},
+		{`pm.expect(pm.response.code).to.equal(201);`, `gottp.assert((gottp.response.StatusCode) === (201), "pm.expect(pm.response.code).to.equal(201);");`},
+		{`  pm.expect(data.tags).to.not.include("x");`, `  gottp.assert(!((data.tags).includes("x")), "pm.expect(data.tags).to.not.include(\"x\");");`},
+		{`pm.expect(data.ok).to.be.true;`, `gottp.assert((data.ok) === true, "pm.expect(data.ok).to.be.true;");`},
+		{`tests["Status is 200"] = responseCode.code === 200;`, `gottp.test("Status is 200", function() { gottp.assert(gottp.response.StatusCode === 200); });`},
+		{`pm.response.to.have.header("X-Id");`, `// postman: pm.response.to.have.header("X-Id");`},
+		{`pm.sendRequest("https://example.com", function (err, res) {`, `pm.sendRequest("https://example.com", function (err, res) {`},
+	}
+	for _, tt := range tests {
+		if got := translateScript([]string{tt.in}); got != strings.TrimSpace(tt.want) {
+			t.Errorf("translateScript(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
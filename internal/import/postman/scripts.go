@@ -0,0 +1,109 @@
+package postman
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// scriptReplacer rewrites the pm.* and legacy Postman calls that have a
+// direct gottp equivalent.
+var scriptReplacer = strings.NewReplacer(
+	"pm.test(", "gottp.test(",
+	"pm.environment.set(", "gottp.setEnvVar(",
+	"pm.environment.get(", "gottp.getEnvVar(",
+	"pm.collectionVariables.set(", "gottp.setEnvVar(",
+	"pm.collectionVariables.get(", "gottp.getEnvVar(",
+	"pm.variables.set(", "gottp.setEnvVar(",
+	"pm.variables.get(", "gottp.getEnvVar(",
+	"pm.globals.set(", "gottp.setEnvVar(",
+	"pm.globals.get(", "gottp.getEnvVar(",
+	"postman.setEnvironmentVariable(", "gottp.setEnvVar(",
+	"postman.getEnvironmentVariable(", "gottp.getEnvVar(",
+	"postman.setGlobalVariable(", "gottp.setEnvVar(",
+	"postman.getGlobalVariable(", "gottp.getEnvVar(",
+	"pm.response.json()", "JSON.parse(gottp.response.Body)",
+	"pm.response.text()", "gottp.response.Body",
+	"pm.response.code", "gottp.response.StatusCode",
+	"pm.response.responseTime", "gottp.response.Duration",
+	"pm.response.responseSize", "gottp.response.Size",
+	"responseCode.code", "gottp.response.StatusCode",
+	"console.log(", "gottp.log(",
+)
+
+var (
+	statusAssertion = regexp.MustCompile(`pm\.response\.to\.have\.status\((\d+)\)`)
+	expectAssertion = regexp.MustCompile(`^(\s*)pm\.expect\((.+)\)\.to\.(not\.)?(?:be\.)?(equal|eql|include|above|below|true|false|exist)(?:\((.*)\))?;?\s*$`)
+	legacyTest      = regexp.MustCompile(`^(\s*)tests\[(.+?)\]\s*=\s*(.+?);?\s*$`)
+)
+
+// translateScript converts a Postman script to a gottp script. Calls
+// without an equivalent are left in as comments marked "postman:" when
+// that keeps the script valid, so nothing is silently lost.
+func translateScript(lines []string) string {
+	var out []string
+	for _, line := range lines {
+		out = append(out, translateLine(line))
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+func translateLine(line string) string {
+	line = statusAssertion.ReplaceAllString(line, `gottp.assert(gottp.response.StatusCode === $1, "expected status $1")`)
+	if m := expectAssertion.FindStringSubmatch(line); m != nil {
+		if cond, ok := expectCondition(scriptReplacer.Replace(m[2]), m[4], scriptReplacer.Replace(m[5])); ok {
+			if m[3] != "" {
+				cond = "!(" + cond + ")"
+			}
+			return m[1] + "gottp.assert(" + cond + ", " + strconv.Quote(strings.TrimSpace(line)) + ");"
+		}
+	}
+	if m := legacyTest.FindStringSubmatch(line); m != nil {
+		return m[1] + "gottp.test(" + m[2] + ", function() { gottp.assert(" + scriptReplacer.Replace(m[3]) + "); });"
+	}
+
+	translated := scriptReplacer.Replace(line)
+	if !strings.Contains(translated, "pm.") && !strings.Contains(translated, "postman.") {
+		return translated
+	}
+	if balanced(line) {
+		return "// postman: " + strings.TrimSpace(line)
+	}
+	return translated
+}
+
+// expectCondition builds the boolean expression for a pm.expect chain.
+func expectCondition(actual, check, expected string) (string, bool) {
+	switch check {
+	case "equal":
+		return "(" + actual + ") === (" + expected + ")", expected != ""
+	case "eql":
+		return "JSON.stringify(" + actual + ") === JSON.stringify(" + expected + ")", expected != ""
+	case "include":
+		return "(" + actual + ").includes(" + expected + ")", expected != ""
+	case "above":
+		return "(" + actual + ") > (" + expected + ")", expected != ""
+	case "below":
+		return "(" + actual + ") < (" + expected + ")", expected != ""
+	case "true", "false":
+		return "(" + actual + ") === " + check, expected == ""
+	case "exist":
+		return "(" + actual + ") != null", expected == ""
+	}
+	return "", false
+}
+
+// balanced reports whether line opens as many brackets as it closes, so
+// commenting it out leaves the surrounding code intact.
+func balanced(line string) bool {
+	depth := 0
+	for _, r := range line {
+		switch r {
+		case '(', '{', '[':
+			depth++
+		case ')', '}', ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
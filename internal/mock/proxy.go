@@ -0,0 +1,266 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/export/har"
+)
+
+// RecordedFolder is the collection folder recorded responses are added to.
+const RecordedFolder = "Recorded"
+
+// maxRecordBody caps the upstream response body held for recording.
+const maxRecordBody = 10 << 20
+
+// hopHeaders apply to a single connection and are not forwarded.
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// WithUpstream forwards requests that match no route to upstream, so the
+// mock can stand in for part of a real backend.
+func WithUpstream(upstream *url.URL) Option {
+	return func(s *Server) {
+		s.upstream = upstream
+	}
+}
+
+// WithRecording adds each proxied response to the collection as a request
+// with a mock example and saves the collection to path. Recorded routes
+// are replayed from then on instead of being forwarded.
+func WithRecording(path string) Option {
+	return func(s *Server) {
+		s.recordPath = path
+	}
+}
+
+// WithHARRecording writes every proxied exchange to a HAR file at path.
+func WithHARRecording(path string) Option {
+	return func(s *Server) {
+		s.harPath = path
+	}
+}
+
+// forward sends a request that matched no route to the upstream server and
+// copies back its response, recording it if enabled.
+func (s *Server) forward(w http.ResponseWriter, r *http.Request, start time.Time) {
+	reqBody, _ := io.ReadAll(r.Body)
+
+	target := *s.upstream
+	target.Path = joinPath(s.upstream.Path, r.URL.Path)
+	target.RawQuery = r.URL.RawQuery
+	out, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), bytes.NewReader(reqBody))
+	if err != nil {
+		s.upstreamError(w, r, start, err)
+		return
+	}
+	out.Header = r.Header.Clone()
+	for _, h := range hopHeaders {
+		out.Header.Del(h)
+	}
+	if s.recording() {
+		// Recorded bodies must be readable, not compressed
+		out.Header.Set("Accept-Encoding", "identity")
+	}
+
+	resp, err := s.client.Do(out)
+	if err != nil {
+		s.upstreamError(w, r, start, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	// CORS headers set by the mock give way to the upstream's
+	for k, vals := range resp.Header {
+		w.Header()[k] = vals
+	}
+	for _, h := range hopHeaders {
+		w.Header().Del(h)
+	}
+
+	if !s.recording() {
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+		log.Printf("%-7s %s -> %d (proxied) (%s)", r.Method, r.URL.Path, resp.StatusCode, time.Since(start))
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRecordBody+1))
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(body)
+	if err != nil || len(body) > maxRecordBody {
+		_, _ = io.Copy(w, resp.Body)
+		log.Printf("%-7s %s -> %d (proxied, too large to record) (%s)", r.Method, r.URL.Path, resp.StatusCode, time.Since(start))
+		return
+	}
+	if err := s.record(r, reqBody, &target, resp, body, time.Since(start)); err != nil {
+		log.Printf("%-7s %s -> %d (proxied, recording failed: %v) (%s)", r.Method, r.URL.Path, resp.StatusCode, err, time.Since(start))
+		return
+	}
+	log.Printf("%-7s %s -> %d (recorded) (%s)", r.Method, r.URL.Path, resp.StatusCode, time.Since(start))
+}
+
+func (s *Server) upstreamError(w http.ResponseWriter, r *http.Request, start time.Time, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadGateway)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":   "Upstream request failed",
+		"message": err.Error(),
+	})
+	log.Printf("%-7s %s -> 502 (upstream error: %v) (%s)", r.Method, r.URL.Path, err, time.Since(start))
+}
+
+func (s *Server) recording() bool {
+	return s.recordPath != "" || s.harPath != ""
+}
+
+// record saves an upstream exchange to the collection and the HAR file.
+func (s *Server) record(r *http.Request, reqBody []byte, target *url.URL, resp *http.Response, body []byte, elapsed time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.harPath != "" {
+		s.harEntries = append(s.harEntries, harEntry(r, reqBody, target, resp, body, elapsed))
+		doc := har.HAR{Log: har.HARLog{
+			Version: "1.2",
+			Creator: har.HARCreator{Name: "gottp mock"},
+			Entries: s.harEntries,
+		}}
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(s.harPath, data, 0o644); err != nil {
+			return fmt.Errorf("writing HAR: %w", err)
+		}
+	}
+
+	if s.recordPath == "" {
+		return nil
+	}
+	req := recordedRequest(r, reqBody, resp, body)
+	folder := recordedFolder(s.collection)
+	folder.Items = append(folder.Items, collection.Item{Request: req})
+	if rt := requestToRoute(req); rt != nil {
+		s.routes = append(s.routes, *rt)
+	}
+	return collection.SaveToFile(s.collection, s.recordPath)
+}
+
+// recordedRequest turns an exchange into a collection request: the
+// client's request, with the upstream response as its mock example.
+func recordedRequest(r *http.Request, reqBody []byte, resp *http.Response, body []byte) *collection.Request {
+	req := collection.NewRequest(r.Method+" "+r.URL.Path, r.Method, "{{baseUrl}}"+r.URL.RequestURI())
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		req.Headers = append(req.Headers, collection.KVPair{Key: "Content-Type", Value: ct, Enabled: true})
+	}
+	if len(reqBody) > 0 {
+		req.Body = &collection.Body{Type: bodyType(r.Header.Get("Content-Type")), Content: string(reqBody)}
+	}
+
+	example := collection.MockExample{Name: "recorded", Status: resp.StatusCode, Body: string(body)}
+	for k := range resp.Header {
+		switch http.CanonicalHeaderKey(k) {
+		case "Content-Length", "Date", "Content-Encoding", "Access-Control-Allow-Origin",
+			"Access-Control-Allow-Methods", "Access-Control-Allow-Headers", "Access-Control-Max-Age":
+			continue
+		}
+		if example.Headers == nil {
+			example.Headers = make(map[string]string)
+		}
+		example.Headers[k] = resp.Header.Get(k)
+	}
+	req.Mock = &collection.MockConfig{Examples: []collection.MockExample{example}}
+	return req
+}
+
+// recordedFolder returns the top-level Recorded folder of col, adding it
+// if needed.
+func recordedFolder(col *collection.Collection) *collection.Folder {
+	for _, item := range col.Items {
+		if item.Folder != nil && item.Folder.Name == RecordedFolder {
+			return item.Folder
+		}
+	}
+	folder := &collection.Folder{Name: RecordedFolder}
+	col.Items = append(col.Items, collection.Item{Folder: folder})
+	return folder
+}
+
+func harEntry(r *http.Request, reqBody []byte, target *url.URL, resp *http.Response, body []byte, elapsed time.Duration) har.HAREntry {
+	entry := har.HAREntry{
+		StartedDateTime: time.Now().Add(-elapsed).UTC().Format(time.RFC3339Nano),
+		Time:            float64(elapsed.Milliseconds()),
+		Request: har.HARRequest{
+			Method:      r.Method,
+			URL:         target.String(),
+			HTTPVersion: r.Proto,
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: har.HARResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			HeadersSize: -1,
+			BodySize:    len(body),
+			Content: har.HARContent{
+				Size:     len(body),
+				MimeType: resp.Header.Get("Content-Type"),
+				Text:     string(body),
+			},
+		},
+		Timings: har.HARTimings{DNS: -1, Connect: -1, SSL: -1, Wait: float64(elapsed.Milliseconds())},
+	}
+	for k, vals := range r.Header {
+		for _, v := range vals {
+			entry.Request.Headers = append(entry.Request.Headers, har.HARHeader{Name: k, Value: v})
+		}
+	}
+	for k, vals := range target.Query() {
+		for _, v := range vals {
+			entry.Request.QueryString = append(entry.Request.QueryString, har.HARQuery{Name: k, Value: v})
+		}
+	}
+	if len(reqBody) > 0 {
+		entry.Request.PostData = &har.HARPostData{MimeType: r.Header.Get("Content-Type"), Text: string(reqBody)}
+	}
+	for k, vals := range resp.Header {
+		for _, v := range vals {
+			entry.Response.Headers = append(entry.Response.Headers, har.HARHeader{Name: k, Value: v})
+		}
+	}
+	return entry
+}
+
+// bodyType maps a Content-Type to a collection body type.
+func bodyType(contentType string) string {
+	switch {
+	case strings.Contains(contentType, "json"):
+		return "json"
+	case strings.Contains(contentType, "xml"):
+		return "xml"
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		return "form"
+	}
+	return "text"
+}
+
+// joinPath joins the upstream base path and a request path with one slash.
+func joinPath(base, path string) string {
+	if base == "" || base == "/" {
+		return path
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
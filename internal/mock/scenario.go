@@ -0,0 +1,91 @@
+package mock
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+// Ways of picking among a route's examples that have no match rule.
+const (
+	SelectFirst      = "first"
+	SelectRoundRobin = "round-robin"
+	SelectSequence   = "sequence" // like round-robin, but stays on the last example
+)
+
+// ScenarioParam and ScenarioHeader let a client pick an example by name.
+const (
+	ScenarioParam  = "__scenario"
+	ScenarioHeader = "X-Mock-Scenario"
+)
+
+// selectResponse picks the response for a request: the example named by
+// the request or the server's scenario, then the first example whose match
+// rule fits, then the remaining examples in the route's selection order.
+// Routes without examples serve the request body.
+func (s *Server) selectResponse(rt *route, r *http.Request) response {
+	if len(rt.examples) == 0 {
+		return rt.response
+	}
+
+	for _, name := range []string{r.URL.Query().Get(ScenarioParam), r.Header.Get(ScenarioHeader), s.scenario} {
+		if name == "" {
+			continue
+		}
+		for _, ex := range rt.examples {
+			if ex.name == name {
+				return ex
+			}
+		}
+	}
+
+	var rest []response
+	for _, ex := range rt.examples {
+		if ex.when == nil {
+			rest = append(rest, ex)
+		} else if matchesRule(ex.when, r) {
+			return ex
+		}
+	}
+	if len(rest) == 0 {
+		return rt.response
+	}
+
+	switch rt.selection {
+	case SelectRoundRobin:
+		n := rt.served.Add(1) - 1
+		return rest[n%uint64(len(rest))]
+	case SelectSequence:
+		n := rt.served.Add(1) - 1
+		return rest[min(n, uint64(len(rest)-1))]
+	}
+	return rest[0]
+}
+
+// matchesRule reports whether every header and query parameter of the rule
+// has the given value in the request.
+func matchesRule(rule *collection.MockMatch, r *http.Request) bool {
+	for k, v := range rule.Headers {
+		if r.Header.Get(k) != v {
+			return false
+		}
+	}
+	query := r.URL.Query()
+	for k, v := range rule.Query {
+		if !query.Has(k) || query.Get(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidSelection reports whether name is a known example selection mode.
+// Empty selects the first example.
+func ValidSelection(name string) bool {
+	switch strings.ToLower(name) {
+	case "", SelectFirst, SelectRoundRobin, SelectSequence:
+		return true
+	}
+	return false
+}
@@ -4,25 +4,39 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/environment"
+	"github.com/sadopc/gottp/internal/export/har"
 )
 
 // route represents a matched collection request mapped to an HTTP endpoint.
 type route struct {
-	method  string
-	path    string
+	method string
+	path   string
+	response
+	examples  []response
+	selection string
+	served    *atomic.Uint64 // responses served by round-robin and sequence selection
+}
+
+// response is a canned answer: the request body or one of its examples.
+type response struct {
+	name    string
 	body    string
 	headers map[string]string
 	status  int
+	when    *collection.MockMatch
 }
 
 // Method returns the HTTP method for this route.
@@ -42,6 +56,14 @@ type Server struct {
 	errorRate  float64
 	port       int
 	corsOrigin string
+	scenario   string
+
+	upstream   *url.URL
+	client     *http.Client
+	recordPath string
+	harPath    string
+	harEntries []har.HAREntry
+	mu         sync.RWMutex // guards routes, collection and harEntries while recording
 }
 
 // Option configures a Server.
@@ -82,12 +104,24 @@ func WithCORSOrigin(origin string) Option {
 	}
 }
 
+// WithScenario serves the example of this name, on routes that have one,
+// unless a request picks another.
+func WithScenario(name string) Option {
+	return func(s *Server) {
+		s.scenario = name
+	}
+}
+
 // New creates a new mock Server from a collection.
 func New(col *collection.Collection, opts ...Option) *Server {
 	s := &Server{
 		collection: col,
 		port:       8080,
 		corsOrigin: "*",
+		client: &http.Client{
+			// Redirects go back to the client unchanged
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
 	}
 	for _, opt := range opts {
 		opt(s)
@@ -98,7 +132,9 @@ func New(col *collection.Collection, opts ...Option) *Server {
 
 // Routes returns the list of registered routes.
 func (s *Server) Routes() []route {
-	return s.routes
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]route(nil), s.routes...)
 }
 
 // Handler returns the http.Handler for the mock server. Useful for testing.
@@ -132,6 +168,9 @@ func (s *Server) Start(ctx context.Context) error {
 	for _, r := range s.routes {
 		log.Printf("  %-7s %s", r.method, r.path)
 	}
+	if s.upstream != nil {
+		log.Printf("Forwarding unmatched requests to %s", s.upstream)
+	}
 
 	errCh := make(chan error, 1)
 	go func() {
@@ -196,50 +235,130 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Find matching route
-	matched := s.matchRoute(r.Method, r.URL.Path)
+	matched, params := s.matchRoute(r.Method, r.URL.Path)
 	if matched == nil {
+		if s.upstream != nil {
+			s.forward(w, r, start)
+			return
+		}
 		s.handleNotFound(w, r, start)
 		return
 	}
 
+	resp := s.selectResponse(matched, r)
+
 	// Set response headers
-	for k, v := range matched.headers {
+	for k, v := range resp.headers {
 		w.Header().Set(k, v)
 	}
 
 	// Auto-detect Content-Type if not explicitly set
-	if w.Header().Get("Content-Type") == "" && matched.body != "" {
-		w.Header().Set("Content-Type", detectContentType(matched.body))
+	if w.Header().Get("Content-Type") == "" && resp.body != "" {
+		w.Header().Set("Content-Type", detectContentType(resp.body))
 	}
 
-	body := expandTemplateVars(matched.body)
+	// Request data for response templates
+	data := requestData{r: r, params: params}
+	if strings.Contains(resp.body, "request.body") {
+		data.body, _ = io.ReadAll(io.LimitReader(r.Body, maxTemplateBody))
+	}
+	body := expandTemplateVars(resp.body)
+	body = expandRequestVars(body, data, strings.Contains(w.Header().Get("Content-Type"), "json"))
 
-	w.WriteHeader(matched.status)
+	w.WriteHeader(resp.status)
 	if body != "" {
 		fmt.Fprint(w, body)
 	}
 
-	log.Printf("%-7s %s -> %d (%s)", r.Method, r.URL.Path, matched.status, time.Since(start))
+	if resp.name != "" {
+		log.Printf("%-7s %s -> %d [%s] (%s)", r.Method, r.URL.Path, resp.status, resp.name, time.Since(start))
+		return
+	}
+	log.Printf("%-7s %s -> %d (%s)", r.Method, r.URL.Path, resp.status, time.Since(start))
 }
 
-func (s *Server) matchRoute(method, path string) *route {
+// matchRoute finds the route for a request and its path parameters. When
+// several patterns match, the one with the most literal segments wins, so
+// /users/me takes precedence over /users/{id}.
+func (s *Server) matchRoute(method, path string) (*route, map[string]string) {
 	// Normalize path
 	path = normalizePath(path)
 
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var best *route
+	var bestParams map[string]string
+	bestLiteral := -1
 	for i := range s.routes {
-		if strings.EqualFold(s.routes[i].method, method) && s.routes[i].path == path {
-			return &s.routes[i]
+		if !strings.EqualFold(s.routes[i].method, method) {
+			continue
+		}
+		params, literal, ok := matchPath(s.routes[i].path, path)
+		if ok && literal > bestLiteral {
+			best, bestParams, bestLiteral = &s.routes[i], params, literal
 		}
 	}
-	return nil
+	return best, bestParams
+}
+
+// matchPath matches a path against a route pattern. Pattern segments may be
+// parameters ({id}, :id or {{id}}), * for any one segment, or a final ** for
+// any number of segments. literal counts the segments matched exactly.
+func matchPath(pattern, path string) (params map[string]string, literal int, ok bool) {
+	want, got := splitPath(pattern), splitPath(path)
+	params = map[string]string{}
+	for i, seg := range want {
+		if seg == "**" && i == len(want)-1 {
+			return params, literal, true
+		}
+		if i >= len(got) {
+			return nil, 0, false
+		}
+		switch name := paramName(seg); {
+		case name != "":
+			params[name] = got[i]
+		case seg == "*":
+		case seg == got[i]:
+			literal++
+		default:
+			return nil, 0, false
+		}
+	}
+	if len(got) != len(want) {
+		return nil, 0, false
+	}
+	return params, literal, true
+}
+
+// paramName returns the name of a parameter segment, or "" for others.
+func paramName(seg string) string {
+	switch {
+	case strings.HasPrefix(seg, "{{") && strings.HasSuffix(seg, "}}"):
+		return strings.TrimSpace(seg[2 : len(seg)-2])
+	case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+		return seg[1 : len(seg)-1]
+	case strings.HasPrefix(seg, ":") && len(seg) > 1:
+		return seg[1:]
+	}
+	return ""
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
 }
 
 func (s *Server) handleNotFound(w http.ResponseWriter, r *http.Request, start time.Time) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNotFound)
 
-	available := make([]map[string]string, 0, len(s.routes))
-	for _, rt := range s.routes {
+	routes := s.Routes()
+	available := make([]map[string]string, 0, len(routes))
+	for _, rt := range routes {
 		available = append(available, map[string]string{
 			"method": rt.method,
 			"path":   rt.path,
@@ -288,10 +407,13 @@ func requestToRoute(req *collection.Request) *route {
 	path := extractPath(req.URL)
 
 	r := &route{
-		method:  method,
-		path:    path,
-		status:  http.StatusOK,
-		headers: make(map[string]string),
+		method: method,
+		path:   path,
+		response: response{
+			status:  http.StatusOK,
+			headers: make(map[string]string),
+		},
+		served: new(atomic.Uint64),
 	}
 
 	// Use request body as the mock response body
@@ -316,48 +438,49 @@ func requestToRoute(req *collection.Request) *route {
 		r.headers["Content-Type"] = "application/json"
 	}
 
-	return r
-}
-
-// extractPath parses a URL and returns just the path component.
-// If the URL has no scheme, it tries to extract a path-like portion.
-func extractPath(rawURL string) string {
-	if rawURL == "" {
-		return "/"
-	}
-
-	// If the URL contains template variables like {{baseUrl}}/users,
-	// strip everything before the first slash that follows }}.
-	if strings.Contains(rawURL, "{{") {
-		// Find the closing }} and then the next /
-		idx := strings.Index(rawURL, "}}")
-		if idx >= 0 {
-			rest := rawURL[idx+2:]
-			slashIdx := strings.Index(rest, "/")
-			if slashIdx >= 0 {
-				return normalizePath(rest[slashIdx:])
+	if m := req.Mock; m != nil {
+		if m.Status != 0 {
+			r.status = m.Status
+		}
+		r.selection = strings.ToLower(m.Select)
+		for _, ex := range m.Examples {
+			resp := response{name: ex.Name, body: ex.Body, headers: ex.Headers, status: ex.Status, when: ex.When}
+			if resp.status == 0 {
+				resp.status = http.StatusOK
 			}
+			r.examples = append(r.examples, resp)
 		}
-		return "/"
 	}
 
-	// Try standard URL parsing for URLs with scheme.
-	parsed, err := url.Parse(rawURL)
-	if err == nil && parsed.Scheme != "" {
-		path := parsed.Path
-		if path == "" || path == "/" {
+	return r
+}
+
+// extractPath returns the path of a collection request URL. A leading
+// variable such as {{baseUrl}} stands for the scheme and host; variables
+// later in the path are kept, and become route parameters.
+func extractPath(rawURL string) string {
+	path := rawURL
+	switch {
+	case strings.HasPrefix(path, "{{"):
+		idx := strings.Index(path, "}}")
+		if idx < 0 {
 			return "/"
 		}
-		return normalizePath(path)
+		path = path[idx+2:]
+	case strings.Contains(path, "://"):
+		path = path[strings.Index(path, "://")+3:]
 	}
 
-	// Fallback: if URL contains no scheme but has slash, extract from first slash.
-	idx := strings.Index(rawURL, "/")
-	if idx >= 0 {
-		return normalizePath(rawURL[idx:])
+	// The path starts at the first slash after the host
+	idx := strings.Index(path, "/")
+	if idx < 0 {
+		return "/"
 	}
-
-	return "/"
+	path = path[idx:]
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	return normalizePath(path)
 }
 
 // normalizePath ensures the path starts with / and has no trailing slash (except root).
@@ -396,11 +519,5 @@ func detectContentType(body string) string {
 
 // expandTemplateVars replaces dynamic template variables in response bodies.
 func expandTemplateVars(body string) string {
-	if !strings.Contains(body, "{{") {
-		return body
-	}
-	body = strings.ReplaceAll(body, "{{$timestamp}}", fmt.Sprintf("%d", time.Now().Unix()))
-	body = strings.ReplaceAll(body, "{{$uuid}}", uuid.New().String())
-	body = strings.ReplaceAll(body, "{{$randomInt}}", fmt.Sprintf("%d", rand.Intn(10000)))
-	return body
+	return environment.ResolveDynamic(body)
 }
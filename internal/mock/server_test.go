@@ -2,9 +2,13 @@ package mock
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -393,6 +397,111 @@ func TestDynamicTemplateVariables(t *testing.T) {
 	}
 }
 
+func TestRequestTemplates(t *testing.T) {
+	col := &collection.Collection{
+		Name: "Echo",
+		Items: []collection.Item{
+			{Request: &collection.Request{
+				ID:     "1",
+				Name:   "Update User",
+				Method: "PUT",
+				URL:    "{{baseUrl}}/users/42",
+				Body: &collection.Body{
+					Type: "json",
+					Content: `{"id":"{{request.path.1}}","name":"{{request.body.name}}","tags":{{request.body.tags}},` +
+						`"age":{{request.body.$.age}},"q":"{{request.query.q}}","trace":"{{ request.header.X-Trace }}",` +
+						`"via":"{{request.method}} {{request.path}}","missing":"{{request.body.nope}}{{request.path.9}}"}`,
+				},
+			}},
+			{Request: &collection.Request{
+				ID:     "2",
+				Name:   "Echo",
+				Method: "POST",
+				URL:    "{{baseUrl}}/echo",
+				Body:   &collection.Body{Type: "text", Content: "you sent: {{request.body}}"},
+			}},
+		},
+	}
+	handler := New(col).Handler()
+
+	req := httptest.NewRequest("PUT", "/users/42?q=a%22b", strings.NewReader(`{"name":"Ada \"the\" Countess","tags":["x","y"],"age":36}`))
+	req.Header.Set("X-Trace", "abc")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not JSON: %v\n%s", err, rec.Body.String())
+	}
+	want := map[string]interface{}{
+		"id":      "42",
+		"name":    `Ada "the" Countess`,
+		"tags":    []interface{}{"x", "y"},
+		"age":     float64(36),
+		"q":       `a"b`,
+		"trace":   "abc",
+		"via":     "PUT /users/42",
+		"missing": "",
+	}
+	for k, v := range want {
+		if gotV, _ := json.Marshal(got[k]); string(gotV) != mustJSON(v) {
+			t.Errorf("%s = %s, want %s", k, gotV, mustJSON(v))
+		}
+	}
+
+	// Text responses are not escaped
+	req = httptest.NewRequest("POST", "/echo", strings.NewReader(`"hi"`))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Body.String() != `you sent: "hi"` {
+		t.Errorf("echo = %q", rec.Body.String())
+	}
+}
+
+func mustJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestPathParameters(t *testing.T) {
+	route := func(id, method, url, body string) collection.Item {
+		return collection.Item{Request: &collection.Request{
+			ID: id, Name: id, Method: method, URL: url,
+			Body: &collection.Body{Type: "json", Content: body},
+		}}
+	}
+	col := &collection.Collection{Name: "Params", Items: []collection.Item{
+		route("1", "GET", "https://api.example.com/users/{{userId}}", `{"id":"{{request.params.userId}}"}`),
+		route("2", "GET", "{{baseUrl}}/users/me", `{"id":"me"}`),
+		route("3", "GET", "{{baseUrl}}/orgs/:org/repos/{repo}?page=1", `{"repo":"{{request.params.org}}/{{request.params.repo}}"}`),
+		route("4", "GET", "{{baseUrl}}/files/**", `{"path":"{{request.path}}"}`),
+		route("5", "DELETE", "{{baseUrl}}/items/*", `{"deleted":"{{request.path.1}}"}`),
+	}}
+	handler := New(col).Handler()
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{"GET", "/users/42", 200, `{"id":"42"}`},
+		{"GET", "/users/me", 200, `{"id":"me"}`},
+		{"GET", "/users/42/posts", 404, ""},
+		{"GET", "/orgs/acme/repos/api", 200, `{"repo":"acme/api"}`},
+		{"GET", "/files/a/b/c.txt", 200, `{"path":"/files/a/b/c.txt"}`},
+		{"GET", "/files", 200, `{"path":"/files"}`},
+		{"DELETE", "/items/7", 200, `{"deleted":"7"}`},
+		{"DELETE", "/items/7/8", 404, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.status || (tt.body != "" && rec.Body.String() != tt.body) {
+			t.Errorf("%s %s = %d %s, want %d %s", tt.method, tt.path, rec.Code, rec.Body.String(), tt.status, tt.body)
+		}
+	}
+}
+
 func TestExtractPath(t *testing.T) {
 	tests := []struct {
 		url  string
@@ -405,6 +514,8 @@ func TestExtractPath(t *testing.T) {
 		{"http://localhost:3000/api/v1/items", "/api/v1/items"},
 		{"/users", "/users"},
 		{"{{baseUrl}}/users", "/users"},
+		{"https://api.example.com/users/{{userId}}?expand=1", "/users/{{userId}}"},
+		{"https://{{host}}/users", "/users"},
 		{"", "/"},
 	}
 
@@ -612,3 +723,192 @@ func TestDefaultMethodIsGET(t *testing.T) {
 		t.Errorf("got method %q, want GET", routes[0].method)
 	}
 }
+
+func TestMockExamples(t *testing.T) {
+	col := &collection.Collection{
+		Name: "Scenarios",
+		Items: []collection.Item{
+			{Request: &collection.Request{
+				Name: "Get Order", Method: "GET", URL: "{{baseUrl}}/orders/{{id}}",
+				Body: &collection.Body{Type: "json", Content: `{"id": "{{request.params.id}}"}`},
+				Mock: &collection.MockConfig{
+					Select: "round-robin",
+					Examples: []collection.MockExample{
+						{Name: "pending", Body: `{"state":"pending"}`},
+						{Name: "shipped", Body: `{"state":"shipped"}`},
+						{Name: "missing", Status: 404, Headers: map[string]string{"X-Reason": "gone"}, Body: `{"error":"not found"}`,
+							When: &collection.MockMatch{Query: map[string]string{"id": "0"}}},
+						{Name: "unauthorized", Status: 401, When: &collection.MockMatch{Headers: map[string]string{"Authorization": ""}}},
+					},
+				},
+			}},
+			{Request: &collection.Request{
+				Name: "Create Order", Method: "POST", URL: "/orders",
+				Body: &collection.Body{Type: "json", Content: `{"ok":true}`},
+				Mock: &collection.MockConfig{
+					Status: 201,
+					Select: "sequence",
+					Examples: []collection.MockExample{
+						{Name: "rate-limited", Status: 429, When: &collection.MockMatch{Headers: map[string]string{"X-Burst": "1"}}},
+					},
+				},
+			}},
+			{Request: &collection.Request{
+				Name: "Poll Job", Method: "GET", URL: "/jobs/1",
+				Mock: &collection.MockConfig{
+					Select: "sequence",
+					Examples: []collection.MockExample{
+						{Name: "queued", Status: 202},
+						{Name: "done", Body: "finished"},
+					},
+				},
+			}},
+		},
+	}
+	handler := New(col).Handler()
+	serve := func(method, target string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer t")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Examples without a rule take turns
+	var states []string
+	for range 3 {
+		states = append(states, serve("GET", "/orders/7", nil).Body.String())
+	}
+	if strings.Join(states, " ") != `{"state":"pending"} {"state":"shipped"} {"state":"pending"}` {
+		t.Errorf("round-robin served %v", states)
+	}
+
+	// Rules match on query and headers
+	rec := serve("GET", "/orders/7?id=0", nil)
+	if rec.Code != 404 || rec.Header().Get("X-Reason") != "gone" || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("missing: got %d %v", rec.Code, rec.Header())
+	}
+	if rec := serve("GET", "/orders/7", map[string]string{"Authorization": ""}); rec.Code != 401 {
+		t.Errorf("unauthorized: got %d", rec.Code)
+	}
+
+	// Picking a scenario by name wins over rules and turns
+	if rec := serve("GET", "/orders/7?__scenario=shipped&id=0", nil); rec.Body.String() != `{"state":"shipped"}` {
+		t.Errorf("?__scenario: got %s", rec.Body)
+	}
+	if rec := serve("GET", "/orders/7", map[string]string{ScenarioHeader: "missing"}); rec.Code != 404 {
+		t.Errorf("%s: got %d", ScenarioHeader, rec.Code)
+	}
+
+	// The status override applies to the request body when no example fits
+	if rec := serve("POST", "/orders", nil); rec.Code != 201 || rec.Body.String() != `{"ok":true}` {
+		t.Errorf("create: got %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve("POST", "/orders", map[string]string{"X-Burst": "1"}); rec.Code != 429 {
+		t.Errorf("rate-limited: got %d", rec.Code)
+	}
+
+	// A sequence stays on its last example
+	var codes []int
+	for range 3 {
+		codes = append(codes, serve("GET", "/jobs/1", nil).Code)
+	}
+	if codes[0] != 202 || codes[1] != 200 || codes[2] != 200 {
+		t.Errorf("sequence served %v", codes)
+	}
+
+	// A server-wide scenario applies to routes that have it
+	handler = New(col, WithScenario("queued")).Handler()
+	for range 2 {
+		if rec := serve("GET", "/jobs/1", nil); rec.Code != 202 {
+			t.Errorf("WithScenario: got %d", rec.Code)
+		}
+	}
+	if rec := serve("POST", "/orders", nil); rec.Code != 201 {
+		t.Errorf("WithScenario on a route without it: got %d", rec.Code)
+	}
+}
+
+func TestProxyAndRecord(t *testing.T) {
+	var upstreamHits int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHits++
+		if r.Header.Get("Accept-Encoding") != "identity" {
+			t.Errorf("recorded requests should not ask for compression")
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Upstream", "real")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"path":%q,"query":%q,"sent":%q}`, r.URL.Path, r.URL.RawQuery, body)
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	colPath := filepath.Join(dir, "api.gottp.yaml")
+	harPath := filepath.Join(dir, "traffic.har")
+	u, _ := url.Parse(upstream.URL + "/v1")
+	srv := New(testCollection(), WithUpstream(u), WithRecording(colPath), WithHARRecording(harPath))
+	handler := srv.Handler()
+
+	// Matched routes are still mocked
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/users", nil))
+	if rec.Code != 200 || upstreamHits != 0 {
+		t.Fatalf("mocked route: %d, upstream hits %d", rec.Code, upstreamHits)
+	}
+
+	// Unmatched ones go upstream and are recorded
+	req := httptest.NewRequest("POST", "/orders?dry=1", strings.NewReader(`{"qty":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	want := `{"path":"/v1/orders","query":"dry=1","sent":"{\"qty\":2}"}`
+	if rec.Code != 201 || rec.Body.String() != want || rec.Header().Get("X-Upstream") != "real" {
+		t.Fatalf("proxied: %d %s %v", rec.Code, rec.Body, rec.Header())
+	}
+
+	// The recording is replayed without reaching upstream
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/orders", nil))
+	if rec.Code != 201 || rec.Body.String() != want || rec.Header().Get("X-Upstream") != "real" || upstreamHits != 1 {
+		t.Errorf("replay: %d %s, upstream hits %d", rec.Code, rec.Body, upstreamHits)
+	}
+
+	col, err := collection.LoadFromFile(colPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := col.Items[len(col.Items)-1]
+	if last.Folder == nil || last.Folder.Name != RecordedFolder || len(last.Folder.Items) != 1 {
+		t.Fatalf("expected a Recorded folder, got %+v", last)
+	}
+	recorded := last.Folder.Items[0].Request
+	if recorded.URL != "{{baseUrl}}/orders?dry=1" || recorded.Body.Content != `{"qty":2}` || recorded.Mock.Examples[0].Status != 201 {
+		t.Errorf("recorded request: %+v", recorded)
+	}
+
+	data, err := os.ReadFile(harPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), upstream.URL+"/v1/orders?dry=1") || !strings.Contains(string(data), `"status": 201`) {
+		t.Errorf("HAR missing the exchange:\n%s", data)
+	}
+}
+
+func TestProxyUpstreamDown(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	u, _ := url.Parse(upstream.URL)
+	upstream.Close()
+
+	rec := httptest.NewRecorder()
+	New(testCollection(), WithUpstream(u)).Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/nope", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("got %d, want 502", rec.Code)
+	}
+}
@@ -0,0 +1,133 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/core/jsonquery"
+)
+
+// maxTemplateBody caps how much of a request body response templates read.
+const maxTemplateBody = 10 << 20
+
+// requestVarPattern matches request templates such as {{request.query.name}}.
+var requestVarPattern = regexp.MustCompile(`\{\{\s*request\.([^{}]+?)\s*\}\}`)
+
+// requestData is what response templates can read about a request.
+type requestData struct {
+	r      *http.Request
+	body   []byte
+	params map[string]string // path parameters of the matched route
+}
+
+// expandRequestVars fills request templates in a response from the
+// request:
+//
+//	{{request.method}}         the request method
+//	{{request.path}}           the request path
+//	{{request.path.N}}         the Nth path segment, counting from 0
+//	{{request.params.id}}      a path parameter, for routes like /users/{id}
+//	{{request.query.name}}     a query parameter
+//	{{request.header.Name}}    a request header
+//	{{request.body}}           the raw request body
+//	{{request.body.a.b[0]}}    a JSONPath into a JSON request body
+//
+// Missing values expand to nothing. When jsonOut is set, strings are
+// escaped so the template can sit inside a JSON string, while objects,
+// arrays, numbers and booleans from the body are inserted as JSON.
+func expandRequestVars(tmpl string, req requestData, jsonOut bool) string {
+	if !strings.Contains(tmpl, "{{") {
+		return tmpl
+	}
+	return requestVarPattern.ReplaceAllStringFunc(tmpl, func(m string) string {
+		ref := requestVarPattern.FindStringSubmatch(m)[1]
+		value, isJSON := requestValue(ref, req)
+		if jsonOut && !isJSON {
+			return escapeJSONString(value)
+		}
+		return value
+	})
+}
+
+// requestValue looks up a template reference. isJSON reports a JSON value
+// from the body rather than a string.
+func requestValue(ref string, req requestData) (value string, isJSON bool) {
+	r := req.r
+	kind, name, _ := strings.Cut(ref, ".")
+	switch kind {
+	case "method":
+		return r.Method, false
+	case "path":
+		if name == "" {
+			return r.URL.Path, false
+		}
+		segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(segments) {
+			return segments[i], false
+		}
+	case "params":
+		return req.params[name], false
+	case "query":
+		return r.URL.Query().Get(name), false
+	case "header":
+		return r.Header.Get(name), false
+	case "body":
+		if name == "" {
+			return string(req.body), false
+		}
+		return bodyValue(req.body, name)
+	}
+	return "", false
+}
+
+// bodyValue evaluates a JSONPath, with or without its leading $, against
+// a JSON body.
+func bodyValue(body []byte, path string) (string, bool) {
+	expr := path
+	if !strings.HasPrefix(expr, "$") {
+		expr = "$." + expr
+		if strings.HasPrefix(path, "[") {
+			expr = "$" + path
+		}
+	}
+	results, err := jsonquery.Query(body, expr)
+	if err != nil || len(results) == 0 {
+		return "", false
+	}
+	if len(results) > 1 {
+		return compactJSON(results), true
+	}
+	switch v := results[0].(type) {
+	case string:
+		return v, false
+	case nil:
+		return "null", true
+	case map[string]interface{}, []interface{}:
+		return compactJSON(v), true
+	default:
+		return jsonquery.String(v), true
+	}
+}
+
+func compactJSON(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// escapeJSONString escapes s for use inside a JSON string literal.
+func escapeJSONString(s string) string {
+	quoted := compactJSON(s)
+	if len(quoted) < 2 {
+		return ""
+	}
+	return quoted[1 : len(quoted)-1]
+}
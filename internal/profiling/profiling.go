@@ -0,0 +1,110 @@
+// Package profiling exposes runtime profiles and memory statistics, so
+// slowness on large collections can be captured and reported.
+package profiling
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// DefaultInterval is how often memory statistics are written to the debug
+// log.
+const DefaultInterval = 30 * time.Second
+
+// Serve starts the pprof HTTP endpoints on addr under /debug/pprof/ and
+// returns the address actually listened on. The server runs until ctx is
+// cancelled.
+func Serve(ctx context.Context, addr string) (string, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("pprof: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = srv.Serve(lis) }()
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	return lis.Addr().String(), nil
+}
+
+// MemStats is a summary of the Go runtime's memory usage.
+type MemStats struct {
+	HeapInUse  uint64 // bytes in in-use heap spans
+	HeapAlloc  uint64 // bytes of allocated heap objects
+	Sys        uint64 // bytes obtained from the OS
+	Objects    uint64 // live heap objects
+	NumGC      uint32
+	Goroutines int
+}
+
+// ReadMemStats returns the current memory usage.
+func ReadMemStats() MemStats {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return MemStats{
+		HeapInUse:  ms.HeapInuse,
+		HeapAlloc:  ms.HeapAlloc,
+		Sys:        ms.Sys,
+		Objects:    ms.HeapObjects,
+		NumGC:      ms.NumGC,
+		Goroutines: runtime.NumGoroutine(),
+	}
+}
+
+// String formats the stats on one line.
+func (s MemStats) String() string {
+	return fmt.Sprintf("heap %s allocated (%s in use), %s from OS, %d objects, %d GCs, %d goroutines",
+		FormatSize(s.HeapAlloc), FormatSize(s.HeapInUse), FormatSize(s.Sys), s.Objects, s.NumGC, s.Goroutines)
+}
+
+// FreeMemory runs a garbage collection and returns as much memory to the
+// OS as possible, reporting usage before and after.
+func FreeMemory() (before, after MemStats) {
+	before = ReadMemStats()
+	debug.FreeOSMemory()
+	return before, ReadMemStats()
+}
+
+// LogMemStats writes the memory usage to logger every interval until ctx
+// is cancelled.
+func LogMemStats(ctx context.Context, logger *log.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		logger.Printf("memory: %s", ReadMemStats())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FormatSize formats a byte count as B, KB or MB.
+func FormatSize(bytes uint64) string {
+	const kb = 1024
+	const mb = kb * 1024
+	switch {
+	case bytes >= mb:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/float64(mb))
+	case bytes >= kb:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/float64(kb))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
@@ -0,0 +1,87 @@
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr, err := Serve(ctx, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Serve() error: %v", err)
+	}
+	resp, err := http.Get("http://" + addr + "/debug/pprof/heap?debug=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "heap profile") {
+		t.Errorf("heap profile: %d %.80s", resp.StatusCode, body)
+	}
+
+	if _, err := Serve(ctx, addr); err == nil {
+		t.Error("expected an error for an address in use")
+	}
+}
+
+func TestMemStats(t *testing.T) {
+	before, after := FreeMemory()
+	if after.NumGC <= before.NumGC {
+		t.Errorf("NumGC %d -> %d, expected a collection", before.NumGC, after.NumGC)
+	}
+	s := after.String()
+	if !strings.Contains(s, "from OS") || !strings.Contains(s, "goroutines") {
+		t.Errorf("String() = %q", s)
+	}
+
+	if got := FormatSize(1536); got != "1.5 KB" {
+		t.Errorf("FormatSize(1536) = %q", got)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for a logger writing from another
+// goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLogMemStats(t *testing.T) {
+	var buf syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		LogMemStats(ctx, log.New(&buf, "", 0), 5*time.Millisecond)
+		close(done)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	<-done
+
+	if n := strings.Count(buf.String(), "memory: heap "); n < 2 {
+		t.Errorf("expected periodic entries, got %q", buf.String())
+	}
+}
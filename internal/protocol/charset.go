@@ -0,0 +1,157 @@
+package protocol
+
+import (
+	"bytes"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// declaredCharset finds a <meta charset> or <?xml encoding?> declaration.
+var declaredCharset = regexp.MustCompile(`(?i)(?:charset|encoding)\s*=\s*["']?([\w.:-]+)`)
+
+// DecodeText converts a response body to UTF-8 for display. The encoding is
+// taken from the Content-Type charset, a byte order mark or an in-document
+// declaration, and otherwise guessed: valid UTF-8 is kept as is, then
+// Shift_JIS and ISO-8859-1 are tried. The returned name is empty when the
+// body was already UTF-8 or is not text.
+func DecodeText(body []byte, contentType string) (string, string) {
+	if len(body) == 0 || !isTextual(contentType) {
+		return string(body), ""
+	}
+
+	name := charsetParam(contentType)
+	switch {
+	case name != "":
+	case bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}):
+		return string(body[3:]), ""
+	case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+		name = "UTF-16LE"
+	case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+		name = "UTF-16BE"
+	default:
+		head := body
+		if len(head) > 1024 {
+			head = head[:1024]
+		}
+		if m := declaredCharset.FindSubmatch(head); m != nil {
+			name = string(m[1])
+		}
+	}
+
+	if name != "" {
+		if isUTF8Label(name) {
+			return string(body), ""
+		}
+		if enc := lookupEncoding(name); enc != nil {
+			if text, err := enc.NewDecoder().Bytes(body); err == nil {
+				return string(text), strings.ToUpper(name)
+			}
+		}
+	}
+
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	if looksShiftJIS(body) {
+		if text, err := japanese.ShiftJIS.NewDecoder().Bytes(body); err == nil {
+			return string(text), "Shift_JIS"
+		}
+	}
+	text, _ := charmap.ISO8859_1.NewDecoder().Bytes(body)
+	return string(text), "ISO-8859-1"
+}
+
+func charsetParam(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(params["charset"], `"' `)
+}
+
+func isUTF8Label(name string) bool {
+	switch strings.ToLower(name) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return true
+	}
+	return false
+}
+
+func lookupEncoding(name string) encoding.Encoding {
+	switch strings.ToUpper(name) {
+	case "UTF-16LE":
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case "UTF-16BE":
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	case "ISO-8859-1", "LATIN1":
+		// htmlindex maps these to windows-1252; keep the exact charset
+		return charmap.ISO8859_1
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil
+	}
+	return enc
+}
+
+// isTextual reports whether a body with this content type should be decoded
+// as text. Bodies without a content type are treated as text.
+func isTextual(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(contentType)
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	for _, s := range []string{"json", "xml", "javascript", "html", "x-www-form-urlencoded", "yaml", "csv"} {
+		if strings.Contains(mediaType, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksShiftJIS reports whether every non-ASCII byte in b forms a valid
+// Shift_JIS double-byte sequence or half-width katakana. Lead bytes in
+// 0xE0-0xFC double as accented Latin-1 letters, so at least one lead byte
+// in 0x81-0x9F (kana, punctuation, common kanji) is required.
+func looksShiftJIS(b []byte) bool {
+	low := 0
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c < 0x80, c >= 0xA1 && c <= 0xDF:
+			continue
+		case (c >= 0x81 && c <= 0x9F) || (c >= 0xE0 && c <= 0xFC):
+			if i+1 >= len(b) {
+				return false
+			}
+			t := b[i+1]
+			if t < 0x40 || t == 0x7F || t > 0xFC {
+				return false
+			}
+			if c <= 0x9F {
+				low++
+			}
+			i++
+		default:
+			return false
+		}
+	}
+	return low > 0
+}
@@ -0,0 +1,31 @@
+package protocol
+
+import "testing"
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+		encoding    string
+	}{
+		{"utf-8", []byte("héllo"), "application/json", "héllo", ""},
+		{"declared latin1", []byte("caf\xe9"), "text/plain; charset=iso-8859-1", "café", "ISO-8859-1"},
+		{"declared shift_jis", []byte("\x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd"), "text/plain; charset=Shift_JIS", "こんにちは", "SHIFT_JIS"},
+		{"sniffed shift_jis", []byte(`{"msg":"` + "\x93\xfa\x96\x7b" + `"}`), "application/json", `{"msg":"日本"}`, "Shift_JIS"},
+		{"sniffed latin1", []byte("M\xe1laga caf\xe9"), "text/plain", "Málaga café", "ISO-8859-1"},
+		{"html meta", []byte(`<meta charset="windows-1252"><p>` + "\x93hi\x94"), "text/html", `<meta charset="windows-1252"><p>“hi”`, "WINDOWS-1252"},
+		{"utf-8 bom", []byte("\xef\xbb\xbfok"), "text/plain", "ok", ""},
+		{"utf-16 bom", []byte{0xFF, 0xFE, 'o', 0, 'k', 0}, "text/plain", "ok", "UTF-16LE"},
+		{"binary untouched", []byte{0x89, 'P', 'N', 'G'}, "image/png", "\x89PNG", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, enc := DecodeText(tt.body, tt.contentType)
+			if got != tt.want || enc != tt.encoding {
+				t.Errorf("DecodeText() = %q, %q; want %q, %q", got, enc, tt.want, tt.encoding)
+			}
+		})
+	}
+}
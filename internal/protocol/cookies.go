@@ -0,0 +1,63 @@
+package protocol
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApplyCookies moves the request's cookies into its Cookie header, after
+// any cookies already written there by hand, and clears Cookies. Call it
+// once variables in the cookies are resolved.
+func (r *Request) ApplyCookies() {
+	if len(r.Cookies) == 0 {
+		return
+	}
+	if r.Headers == nil {
+		r.Headers = make(map[string]string)
+	}
+	key, existing := "Cookie", ""
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, "Cookie") {
+			key, existing = k, v
+			break
+		}
+	}
+	parts := make([]string, 0, len(r.Cookies)+1)
+	if existing = strings.TrimSpace(existing); existing != "" {
+		parts = append(parts, existing)
+	}
+	parts = append(parts, CookieHeader(r.Cookies))
+	r.Headers[key] = strings.Join(parts, "; ")
+	r.Cookies = nil
+}
+
+// CookieHeader serializes cookies as a Cookie header value. Bytes a cookie
+// may not contain (spaces, quotes, commas, semicolons, backslashes,
+// control and non-ASCII characters) are percent-encoded, as are "=" in
+// names; values that are already percent-encoded pass through unchanged.
+func CookieHeader(cookies []Cookie) string {
+	parts := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		parts = append(parts, encodeCookie(c.Name, true)+"="+encodeCookie(c.Value, false))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func encodeCookie(s string, name bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if validCookieByte(c) && !(name && c == '=') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// validCookieByte reports whether c is a cookie-octet (RFC 6265 4.1.1).
+func validCookieByte(c byte) bool {
+	return c == 0x21 || (c >= 0x23 && c <= 0x2B) || (c >= 0x2D && c <= 0x3A) ||
+		(c >= 0x3C && c <= 0x5B) || (c >= 0x5D && c <= 0x7E)
+}
@@ -0,0 +1,36 @@
+package protocol
+
+import "testing"
+
+func TestCookieHeader(t *testing.T) {
+	got := CookieHeader([]Cookie{
+		{Name: "session", Value: "abc123"},
+		{Name: "prefs", Value: `dark mode; lang="en",x\y`},
+		{Name: "a=b", Value: "héllo%20"},
+	})
+	want := `session=abc123; prefs=dark%20mode%3B%20lang=%22en%22%2Cx%5Cy; a%3Db=h%C3%A9llo%20`
+	if got != want {
+		t.Errorf("CookieHeader =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestApplyCookies(t *testing.T) {
+	req := &Request{Headers: map[string]string{"cookie": "theme=dark"}, Cookies: []Cookie{{Name: "id", Value: "42"}}}
+	req.ApplyCookies()
+	if req.Headers["cookie"] != "theme=dark; id=42" || len(req.Headers) != 1 {
+		t.Errorf("headers = %v, cookies should join the existing header", req.Headers)
+	}
+	if req.Cookies != nil {
+		t.Error("cookies should be cleared once applied")
+	}
+	req.ApplyCookies()
+	if req.Headers["cookie"] != "theme=dark; id=42" {
+		t.Errorf("applying twice changed the header: %v", req.Headers)
+	}
+
+	req = &Request{Cookies: []Cookie{{Name: "id", Value: "42"}}}
+	req.ApplyCookies()
+	if req.Headers["Cookie"] != "id=42" {
+		t.Errorf("headers = %v", req.Headers)
+	}
+}
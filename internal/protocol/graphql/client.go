@@ -3,12 +3,15 @@ package graphql
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 
+	"github.com/sadopc/gottp/internal/core/retry"
 	"github.com/sadopc/gottp/internal/protocol"
 )
 
@@ -16,6 +19,9 @@ import (
 // GraphQL subscriptions over WebSocket using the graphql-ws protocol.
 type Client struct {
 	subscription *SubscriptionClient
+	proxy        *protocol.ProxyConfig // used for subscriptions
+	reconnect    *retry.Policy         // used for subscriptions
+	keepalive    time.Duration         // used for subscriptions
 }
 
 // New creates a new GraphQL client.
@@ -54,17 +60,78 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 		}, nil
 	}
 
-	// Build GraphQL request body
-	gqlBody := map[string]interface{}{
-		"query": req.GraphQLQuery,
-	}
+	var vars map[string]interface{}
 	if req.GraphQLVariables != "" {
-		var vars map[string]interface{}
-		if err := json.Unmarshal([]byte(req.GraphQLVariables), &vars); err == nil {
-			gqlBody["variables"] = vars
+		if err := json.Unmarshal([]byte(req.GraphQLVariables), &vars); err != nil {
+			vars = nil
 		}
 	}
+	if !req.GraphQLPersisted {
+		return c.post(ctx, req, requestBody(req.GraphQLQuery, vars, nil))
+	}
 
+	// Automatic Persisted Queries: try the hash alone, and register the
+	// query with it when the server doesn't have it yet
+	hash := sha256.Sum256([]byte(req.GraphQLQuery))
+	extensions := map[string]interface{}{
+		"persistedQuery": map[string]interface{}{
+			"version":    1,
+			"sha256Hash": hex.EncodeToString(hash[:]),
+		},
+	}
+	resp, err := c.post(ctx, req, requestBody("", vars, extensions))
+	if err != nil || !persistedQueryMissing(resp.Body) {
+		return resp, err
+	}
+	full, err := c.post(ctx, req, requestBody(req.GraphQLQuery, vars, extensions))
+	if err != nil {
+		return nil, err
+	}
+	full.Duration += resp.Duration
+	return full, nil
+}
+
+// requestBody builds a GraphQL-over-HTTP body; an empty query is left out.
+func requestBody(query string, vars, extensions map[string]interface{}) map[string]interface{} {
+	body := map[string]interface{}{}
+	if query != "" {
+		body["query"] = query
+	}
+	if vars != nil {
+		body["variables"] = vars
+	}
+	if extensions != nil {
+		body["extensions"] = extensions
+	}
+	return body
+}
+
+// persistedQueryMissing reports whether a response asks for the full
+// query: the server doesn't know the hash, or doesn't support APQ.
+func persistedQueryMissing(body []byte) bool {
+	var result struct {
+		Errors []struct {
+			Message    string `json:"message"`
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &result) != nil {
+		return false
+	}
+	for _, e := range result.Errors {
+		switch {
+		case e.Message == "PersistedQueryNotFound", e.Extensions.Code == "PERSISTED_QUERY_NOT_FOUND",
+			e.Message == "PersistedQueryNotSupported", e.Extensions.Code == "PERSISTED_QUERY_NOT_SUPPORTED":
+			return true
+		}
+	}
+	return false
+}
+
+// post sends one GraphQL body to the request's endpoint.
+func (c *Client) post(ctx context.Context, req *protocol.Request, gqlBody map[string]interface{}) (*protocol.Response, error) {
 	bodyBytes, err := json.Marshal(gqlBody)
 	if err != nil {
 		return nil, fmt.Errorf("marshaling GraphQL body: %w", err)
@@ -94,7 +161,11 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 		timeout = 30 * time.Second
 	}
 
-	client := &http.Client{Timeout: timeout}
+	transport, err := protocol.ProxyTransport(req.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("configuring transport: %w", err)
+	}
+	client := &http.Client{Timeout: timeout, Transport: req.Hosts.Transport(transport)}
 
 	start := time.Now()
 	resp, err := client.Do(httpReq)
@@ -122,6 +193,18 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 	}, nil
 }
 
+// SetProxy routes subscription connections through p.
+func (c *Client) SetProxy(p *protocol.ProxyConfig) {
+	c.proxy = p
+}
+
+// SetSubscriptionKeepalive sets the graphql-ws ping interval and reconnect
+// policy of subscriptions connected afterwards.
+func (c *Client) SetSubscriptionKeepalive(keepalive time.Duration, reconnect *retry.Policy) {
+	c.keepalive = keepalive
+	c.reconnect = reconnect
+}
+
 // ConnectSubscription establishes a WebSocket connection for GraphQL
 // subscriptions using the graphql-ws protocol. Headers from the request are
 // forwarded to the WebSocket handshake.
@@ -130,6 +213,9 @@ func (c *Client) ConnectSubscription(ctx context.Context, url string, headers ma
 		return fmt.Errorf("subscription already connected")
 	}
 	c.subscription = NewSubscriptionClient()
+	c.subscription.SetProxy(c.proxy)
+	c.subscription.SetKeepalive(c.keepalive)
+	c.subscription.SetReconnect(c.reconnect)
 	return c.subscription.Connect(ctx, url, headers)
 }
 
@@ -154,6 +240,11 @@ func (c *Client) CloseSubscription() error {
 	return err
 }
 
+// Close closes the subscription connection, if any.
+func (c *Client) Close() error {
+	return c.CloseSubscription()
+}
+
 // IsSubscriptionConnected returns whether the subscription client holds an
 // open connection.
 func (c *Client) IsSubscriptionConnected() bool {
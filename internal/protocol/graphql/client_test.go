@@ -2,6 +2,8 @@ package graphql
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -99,6 +101,69 @@ func TestGraphQLWithVariables(t *testing.T) {
 	}
 }
 
+func TestGraphQLPersistedQuery(t *testing.T) {
+	query := `{ countries { name } }`
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+
+	stored := map[string]string{}
+	var calls []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		calls = append(calls, body)
+
+		ext, _ := body["extensions"].(map[string]interface{})
+		pq, _ := ext["persistedQuery"].(map[string]interface{})
+		got, _ := pq["sha256Hash"].(string)
+		if q, ok := body["query"].(string); ok {
+			stored[got] = q
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, ok := stored[got]; !ok {
+			w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"countries":[]}}`))
+	}))
+	defer server.Close()
+
+	req := &protocol.Request{
+		Protocol:         "graphql",
+		URL:              server.URL,
+		GraphQLQuery:     query,
+		GraphQLVariables: `{"first": 2}`,
+		GraphQLPersisted: true,
+	}
+
+	// The first send registers the query after the miss
+	resp, err := New().Execute(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != `{"data":{"countries":[]}}` || len(calls) != 2 {
+		t.Fatalf("expected a miss then the full query, got %d calls and %s", len(calls), resp.Body)
+	}
+	if _, ok := calls[0]["query"]; ok {
+		t.Error("the first request should send only the hash")
+	}
+	if calls[1]["query"] != query || calls[1]["variables"] == nil {
+		t.Errorf("fallback request = %v", calls[1])
+	}
+	if stored[hash] != query {
+		t.Errorf("query not registered under its SHA-256 hash %s: %v", hash, stored)
+	}
+
+	// Later sends hit with the hash alone
+	calls = nil
+	if _, err := New().Execute(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("expected a single hashed request, got %d", len(calls))
+	}
+}
+
 func TestGraphQLValidate(t *testing.T) {
 	client := New()
 
@@ -157,7 +222,7 @@ func TestIntrospection(t *testing.T) {
 	}))
 	defer server.Close()
 
-	schema, err := RunIntrospection(context.Background(), server.URL, nil)
+	schema, err := RunIntrospection(context.Background(), server.URL, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -174,6 +239,12 @@ func TestIntrospection(t *testing.T) {
 	if schema.Types[0].Fields[0].Type != "[User]" {
 		t.Errorf("expected [User], got %s", schema.Types[0].Fields[0].Type)
 	}
+	if schema.SDL == nil || schema.SDL.QueryType != "Query" {
+		t.Fatalf("expected SDL schema with Query root, got %+v", schema.SDL)
+	}
+	if got := schema.SDL.Types["Query"].Fields[0].Type.String(); got != "[User]" {
+		t.Errorf("expected SDL field type [User], got %s", got)
+	}
 }
 
 func TestGraphQLWithBearerAuth(t *testing.T) {
@@ -8,11 +8,14 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/sadopc/gottp/internal/protocol"
 )
 
 // Schema holds introspection results.
 type Schema struct {
 	Types []SchemaType
+	SDL   *SDLSchema // the full schema, for query validation and completion
 }
 
 // SchemaType represents a GraphQL type.
@@ -30,48 +33,111 @@ type SchemaField struct {
 
 const introspectionQuery = `{
   __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
     types {
       name
       kind
-      fields {
+      fields(includeDeprecated: true) {
+        name
+        args { name type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+      }
+      inputFields { name type { ...TypeRef } defaultValue }
+      enumValues(includeDeprecated: true) { name }
+      possibleTypes { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  name
+  kind
+  ofType {
+    name
+    kind
+    ofType {
+      name
+      kind
+      ofType {
         name
-        type {
-          name
-          kind
-          ofType {
-            name
-            kind
-          }
-        }
+        kind
+        ofType { name kind }
       }
     }
   }
 }`
 
+type introspectedTypeRef struct {
+	Name   *string              `json:"name"`
+	Kind   string               `json:"kind"`
+	OfType *introspectedTypeRef `json:"ofType"`
+}
+
+// typeRef converts NON_NULL and LIST wrappers to a TypeRef.
+func (t *introspectedTypeRef) typeRef() TypeRef {
+	if t == nil {
+		return TypeRef{}
+	}
+	switch t.Kind {
+	case "NON_NULL":
+		ref := t.OfType.typeRef()
+		ref.NonNull = true
+		return ref
+	case "LIST":
+		inner := t.OfType.typeRef()
+		return TypeRef{OfType: &inner}
+	}
+	if t.Name == nil {
+		return TypeRef{}
+	}
+	return TypeRef{Name: *t.Name}
+}
+
+type introspectedValue struct {
+	Name         string              `json:"name"`
+	Type         introspectedTypeRef `json:"type"`
+	DefaultValue *string             `json:"defaultValue"`
+}
+
+func (v introspectedValue) sdlField() SDLField {
+	f := SDLField{Name: v.Name, Type: v.Type.typeRef()}
+	if v.DefaultValue != nil {
+		f.DefaultValue = *v.DefaultValue
+	}
+	return f
+}
+
+type namedRef struct {
+	Name string `json:"name"`
+}
+
 type introspectionResponse struct {
 	Data struct {
 		Schema struct {
-			Types []struct {
+			QueryType        *namedRef `json:"queryType"`
+			MutationType     *namedRef `json:"mutationType"`
+			SubscriptionType *namedRef `json:"subscriptionType"`
+			Types            []struct {
 				Name   string `json:"name"`
 				Kind   string `json:"kind"`
 				Fields []struct {
-					Name string `json:"name"`
-					Type struct {
-						Name   *string `json:"name"`
-						Kind   string  `json:"kind"`
-						OfType *struct {
-							Name *string `json:"name"`
-							Kind string  `json:"kind"`
-						} `json:"ofType"`
-					} `json:"type"`
+					Name string              `json:"name"`
+					Args []introspectedValue `json:"args"`
+					Type introspectedTypeRef `json:"type"`
 				} `json:"fields"`
+				InputFields   []introspectedValue `json:"inputFields"`
+				EnumValues    []namedRef          `json:"enumValues"`
+				PossibleTypes []namedRef          `json:"possibleTypes"`
 			} `json:"types"`
 		} `json:"__schema"`
 	} `json:"data"`
 }
 
-// RunIntrospection sends an introspection query and parses the result.
-func RunIntrospection(ctx context.Context, url string, headers map[string]string) (*Schema, error) {
+// RunIntrospection sends an introspection query through proxy (nil for a
+// direct connection) and parses the result.
+func RunIntrospection(ctx context.Context, url string, headers map[string]string, proxy *protocol.ProxyConfig) (*Schema, error) {
 	body, _ := json.Marshal(map[string]string{"query": introspectionQuery})
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
@@ -84,7 +150,11 @@ func RunIntrospection(ctx context.Context, url string, headers map[string]string
 		req.Header.Set(k, v)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	transport, err := protocol.ProxyTransport(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("configuring transport: %w", err)
+	}
+	client := &http.Client{Timeout: 30 * time.Second, Transport: transport}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("introspection request failed: %w", err)
@@ -105,8 +175,19 @@ func RunIntrospection(ctx context.Context, url string, headers map[string]string
 		return nil, fmt.Errorf("parsing introspection: %w", err)
 	}
 
-	schema := &Schema{}
-	for _, t := range result.Data.Schema.Types {
+	raw := result.Data.Schema
+	sdl := &SDLSchema{Types: make(map[string]*SDLType)}
+	if raw.QueryType != nil {
+		sdl.QueryType = raw.QueryType.Name
+	}
+	if raw.MutationType != nil {
+		sdl.MutationType = raw.MutationType.Name
+	}
+	if raw.SubscriptionType != nil {
+		sdl.SubscriptionType = raw.SubscriptionType.Name
+	}
+	schema := &Schema{SDL: sdl}
+	for _, t := range raw.Types {
 		// Skip internal types
 		if len(t.Name) > 0 && t.Name[0] == '_' {
 			continue
@@ -115,19 +196,39 @@ func RunIntrospection(ctx context.Context, url string, headers map[string]string
 			Name: t.Name,
 			Kind: t.Kind,
 		}
+		def := &SDLType{Name: t.Name, Kind: t.Kind}
 		for _, f := range t.Fields {
-			typeName := "unknown"
-			if f.Type.Name != nil {
-				typeName = *f.Type.Name
-			} else if f.Type.OfType != nil && f.Type.OfType.Name != nil {
-				typeName = "[" + *f.Type.OfType.Name + "]"
+			field := SDLField{Name: f.Name, Type: f.Type.typeRef()}
+			for _, arg := range f.Args {
+				field.Args = append(field.Args, arg.sdlField())
+			}
+			def.Fields = append(def.Fields, field)
+
+			typeName := field.Type.String()
+			if typeName == "" {
+				typeName = "unknown"
 			}
 			st.Fields = append(st.Fields, SchemaField{
 				Name: f.Name,
 				Type: typeName,
 			})
 		}
+		for _, f := range t.InputFields {
+			def.Fields = append(def.Fields, f.sdlField())
+		}
+		for _, v := range t.EnumValues {
+			def.EnumValues = append(def.EnumValues, v.Name)
+		}
+		for _, pt := range t.PossibleTypes {
+			def.PossibleTypes = append(def.PossibleTypes, pt.Name)
+		}
 		schema.Types = append(schema.Types, st)
+		sdl.Types[t.Name] = def
+	}
+	if sdl.QueryType == "" {
+		if _, ok := sdl.Types["Query"]; ok {
+			sdl.QueryType = "Query"
+		}
 	}
 
 	return schema, nil
@@ -0,0 +1,580 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// QueryError is a problem in a query document, at a 1-based line and
+// column. Len is the length of the offending token in runes.
+type QueryError struct {
+	Line    int
+	Col     int
+	Len     int
+	Message string
+}
+
+func (e QueryError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Message)
+}
+
+// Completion is a suggestion for the name being typed.
+type Completion struct {
+	Label  string
+	Detail string // field, argument or enum type
+}
+
+// ValidateQuery checks a query document against the schema: syntax, field
+// and argument names, required arguments, selections on leaf and composite
+// types, fragment type conditions and fragment spreads. Errors are sorted by
+// position; parsing stops at the first syntax error.
+func (s *SDLSchema) ValidateQuery(query string) []QueryError {
+	p := newQueryParser(s, query)
+	p.parseDocument()
+	if !p.failed {
+		for _, spread := range p.spreads {
+			if !p.fragments[spread.val] {
+				p.errorAt(spread, "Unknown fragment %q", spread.val)
+			}
+		}
+	}
+	sort.SliceStable(p.errs, func(i, j int) bool {
+		if p.errs[i].Line != p.errs[j].Line {
+			return p.errs[i].Line < p.errs[j].Line
+		}
+		return p.errs[i].Col < p.errs[j].Col
+	})
+	return p.errs
+}
+
+// Complete suggests names for the cursor at offset (in runes): fields in a
+// selection set, arguments in an argument list, enum and boolean values,
+// type conditions and operation keywords. It returns the partial name
+// before the cursor and the suggestions that extend it.
+func (s *SDLSchema) Complete(query string, offset int) (string, []Completion) {
+	src := []rune(query)
+	offset = max(0, min(offset, len(src)))
+	start := offset
+	for start > 0 && isNameRune(src[start-1]) {
+		start--
+	}
+	prefix := string(src[start:offset])
+	if start > 0 && src[start-1] == '$' || prefix != "" && unicode.IsDigit(src[start]) {
+		// Variables and numbers have nothing to complete
+		return prefix, nil
+	}
+	line := string(src[:start])
+	line = line[strings.LastIndex(line, "\n")+1:]
+	if strings.Contains(line, "#") && !strings.Contains(line, `"`) {
+		return prefix, nil // inside a comment
+	}
+
+	p := newQueryParser(s, string(src[:start]))
+	p.completing = true
+	p.parseDocument()
+	var items []Completion
+	for _, c := range p.items {
+		if c.Label != prefix && strings.HasPrefix(c.Label, prefix) {
+			items = append(items, c)
+		}
+	}
+	return prefix, items
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// queryParser walks an executable document, checking names against the
+// schema. In completion mode the source ends at the cursor, and reaching
+// the end where a name is expected records the suggestions for it.
+type queryParser struct {
+	schema *SDLSchema
+	src    []rune
+	lex    *sdlLexer
+	tok    sdlToken
+	failed bool // a syntax error or the cursor ended parsing
+
+	errs      []QueryError
+	fragments map[string]bool
+	spreads   []sdlToken
+
+	completing bool
+	items      []Completion
+}
+
+func newQueryParser(schema *SDLSchema, query string) *queryParser {
+	return &queryParser{
+		schema:    schema,
+		src:       []rune(query),
+		lex:       newSDLLexer(query),
+		fragments: map[string]bool{},
+	}
+}
+
+func (p *queryParser) next() {
+	if p.failed {
+		return
+	}
+	tok, err := p.lex.next()
+	if err != nil {
+		msg := err.Error()
+		if i := strings.Index(msg, ": "); i >= 0 {
+			msg = msg[i+2:] // drop the lexer's "line N: " prefix
+		}
+		p.tok = sdlToken{kind: tokEOF, pos: p.lex.pos}
+		p.fail("%s", msg)
+		return
+	}
+	p.tok = tok
+}
+
+func (p *queryParser) is(val string) bool {
+	return (p.tok.kind == tokPunct || p.tok.kind == tokName) && p.tok.val == val
+}
+
+// errorAt records a validation error at tok. Completion ignores errors.
+func (p *queryParser) errorAt(tok sdlToken, format string, args ...interface{}) {
+	if p.completing {
+		return
+	}
+	line, col := 1, 1
+	for _, r := range p.src[:min(tok.pos, len(p.src))] {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	p.errs = append(p.errs, QueryError{
+		Line:    line,
+		Col:     col,
+		Len:     max(1, len([]rune(tok.val))),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// fail records a syntax error at the current token and stops parsing.
+func (p *queryParser) fail(format string, args ...interface{}) {
+	if p.failed {
+		return
+	}
+	p.errorAt(p.tok, "Syntax error: "+format, args...)
+	p.failed = true
+}
+
+func (p *queryParser) describe() string {
+	if p.tok.kind == tokEOF {
+		return "end of query"
+	}
+	return strconv.Quote(p.tok.val)
+}
+
+func (p *queryParser) expect(val string) bool {
+	if p.failed {
+		return false
+	}
+	if !p.is(val) {
+		p.fail("expected %q, got %s", val, p.describe())
+		return false
+	}
+	p.next()
+	return !p.failed
+}
+
+func (p *queryParser) name() (sdlToken, bool) {
+	if p.failed {
+		return sdlToken{}, false
+	}
+	if p.tok.kind != tokName {
+		p.fail("expected name, got %s", p.describe())
+		return sdlToken{}, false
+	}
+	tok := p.tok
+	p.next()
+	return tok, !p.failed
+}
+
+// complete records the suggestions when the parser has reached the cursor,
+// and stops parsing.
+func (p *queryParser) complete(items []Completion) bool {
+	if !p.completing || p.failed || p.tok.kind != tokEOF {
+		return false
+	}
+	p.items = items
+	p.failed = true
+	return true
+}
+
+func (p *queryParser) parseDocument() {
+	p.next()
+	for !p.failed && p.tok.kind != tokEOF {
+		p.parseDefinition()
+	}
+	p.complete(keywordCompletions("query", "mutation", "subscription", "fragment"))
+}
+
+func (p *queryParser) parseDefinition() {
+	if p.is("{") {
+		p.parseSelectionSet(p.schema.QueryType)
+		return
+	}
+	if p.tok.kind != tokName {
+		p.fail("unexpected %s", p.describe())
+		return
+	}
+	switch op := p.tok; op.val {
+	case "query", "mutation", "subscription":
+		p.next()
+		root := map[string]string{
+			"query":        p.schema.QueryType,
+			"mutation":     p.schema.MutationType,
+			"subscription": p.schema.SubscriptionType,
+		}[op.val]
+		if root == "" {
+			p.errorAt(op, "Schema does not support %ss", op.val)
+		}
+		if p.tok.kind == tokName {
+			p.next() // operation name
+		}
+		if p.is("(") {
+			p.parseVariableDefinitions()
+		}
+		p.parseDirectives()
+		p.parseSelectionSet(root)
+	case "fragment":
+		p.next()
+		name, ok := p.name()
+		if !ok {
+			return
+		}
+		p.fragments[name.val] = true
+		if p.complete(keywordCompletions("on")) || !p.expect("on") {
+			return
+		}
+		if p.complete(p.typeCompletions("")) {
+			return
+		}
+		cond, ok := p.name()
+		if !ok {
+			return
+		}
+		typeName := p.typeCondition(cond)
+		p.parseDirectives()
+		p.parseSelectionSet(typeName)
+	default:
+		p.fail("unexpected %s", p.describe())
+	}
+}
+
+// parseSelectionSet parses `{ selections }` on typeName, which is empty
+// when the type is unknown so that nested selections go unchecked.
+func (p *queryParser) parseSelectionSet(typeName string) {
+	if !p.expect("{") {
+		return
+	}
+	for !p.failed && !p.is("}") {
+		if p.complete(p.fieldCompletions(typeName)) {
+			return
+		}
+		if p.tok.kind == tokEOF {
+			p.fail("expected \"}\", got end of query")
+			return
+		}
+		p.parseSelection(typeName)
+	}
+	p.next()
+}
+
+func (p *queryParser) parseSelection(typeName string) {
+	if p.is("...") {
+		p.next()
+		switch {
+		case p.is("on"):
+			p.next()
+			if p.complete(p.typeCompletions(typeName)) {
+				return
+			}
+			cond, ok := p.name()
+			if !ok {
+				return
+			}
+			inner := p.typeCondition(cond)
+			p.parseDirectives()
+			p.parseSelectionSet(inner)
+		case p.is("{") || p.is("@"):
+			p.parseDirectives()
+			p.parseSelectionSet(typeName)
+		default:
+			spread, ok := p.name()
+			if !ok {
+				return
+			}
+			p.spreads = append(p.spreads, spread)
+			p.parseDirectives()
+		}
+		return
+	}
+
+	fieldTok, ok := p.name()
+	if !ok {
+		return
+	}
+	if p.is(":") {
+		// fieldTok was an alias
+		p.next()
+		if p.complete(p.fieldCompletions(typeName)) {
+			return
+		}
+		if fieldTok, ok = p.name(); !ok {
+			return
+		}
+	}
+	field := p.lookupField(typeName, fieldTok)
+	p.parseArguments(fieldTok, typeName, field)
+	p.parseDirectives()
+	if p.failed {
+		return
+	}
+
+	var fieldType string
+	if field != nil {
+		fieldType = field.Type.NamedType()
+	}
+	t := p.schema.Types[fieldType]
+	composite := t != nil && (t.Kind == "OBJECT" || t.Kind == "INTERFACE" || t.Kind == "UNION")
+	if p.is("{") {
+		if t != nil && !composite {
+			p.errorAt(fieldTok, "Field %q must not have a selection since type %q has no subfields", fieldTok.val, field.Type)
+			fieldType = ""
+		}
+		p.parseSelectionSet(fieldType)
+	} else if composite {
+		p.errorAt(fieldTok, "Field %q of type %q must have a selection of subfields", fieldTok.val, field.Type)
+	}
+}
+
+var typenameField = SDLField{Name: "__typename", Type: TypeRef{Name: "String", NonNull: true}}
+
+// lookupField resolves a selected field, reporting unknown names. It
+// returns nil when the field or its parent type is unknown.
+func (p *queryParser) lookupField(typeName string, tok sdlToken) *SDLField {
+	if tok.val == "__typename" {
+		return &typenameField
+	}
+	t := p.schema.Types[typeName]
+	if t == nil || strings.HasPrefix(tok.val, "__") {
+		// Unknown parent, or an introspection field such as __schema
+		return nil
+	}
+	for i := range t.Fields {
+		if t.Fields[i].Name == tok.val {
+			return &t.Fields[i]
+		}
+	}
+	p.errorAt(tok, "Cannot query field %q on type %q", tok.val, typeName)
+	return nil
+}
+
+// parseArguments parses an optional `(name: value ...)` list for field, and
+// reports unknown and missing required arguments. A nil field (directives,
+// unknown fields) skips the checks.
+func (p *queryParser) parseArguments(fieldTok sdlToken, typeName string, field *SDLField) {
+	seen := map[string]bool{}
+	if p.is("(") {
+		p.next()
+		for !p.failed && !p.is(")") {
+			if p.complete(argCompletions(field, seen)) {
+				return
+			}
+			nameTok, ok := p.name()
+			if !ok {
+				return
+			}
+			seen[nameTok.val] = true
+			var arg *SDLField
+			if field != nil {
+				for i := range field.Args {
+					if field.Args[i].Name == nameTok.val {
+						arg = &field.Args[i]
+					}
+				}
+				if arg == nil {
+					p.errorAt(nameTok, "Unknown argument %q on field %q", nameTok.val, typeName+"."+field.Name)
+				}
+			}
+			if !p.expect(":") {
+				return
+			}
+			if arg != nil && p.complete(p.valueCompletions(arg.Type)) {
+				return
+			}
+			p.parseValue()
+		}
+		if !p.expect(")") {
+			return
+		}
+	}
+	if field == nil {
+		return
+	}
+	for _, arg := range field.Args {
+		if arg.Type.NonNull && arg.DefaultValue == "" && !seen[arg.Name] {
+			p.errorAt(fieldTok, "Field %q argument %q of type %q is required", field.Name, arg.Name, arg.Type)
+		}
+	}
+}
+
+func (p *queryParser) parseValue() {
+	switch {
+	case p.failed:
+	case p.is("$"):
+		p.next()
+		p.name()
+	case p.is("["):
+		p.next()
+		for !p.failed && !p.is("]") {
+			p.parseValue()
+		}
+		p.expect("]")
+	case p.is("{"):
+		p.next()
+		for !p.failed && !p.is("}") {
+			if _, ok := p.name(); !ok || !p.expect(":") {
+				return
+			}
+			p.parseValue()
+		}
+		p.expect("}")
+	case p.tok.kind == tokName || p.tok.kind == tokString || p.tok.kind == tokNumber:
+		p.next()
+	default:
+		p.fail("expected value, got %s", p.describe())
+	}
+}
+
+// parseVariableDefinitions parses `($name: Type = default @directives ...)`.
+func (p *queryParser) parseVariableDefinitions() {
+	p.next()
+	for !p.failed && !p.is(")") {
+		if !p.expect("$") {
+			return
+		}
+		if _, ok := p.name(); !ok || !p.expect(":") {
+			return
+		}
+		p.parseType()
+		if p.is("=") {
+			p.next()
+			p.parseValue()
+		}
+		p.parseDirectives()
+	}
+	p.expect(")")
+}
+
+func (p *queryParser) parseType() {
+	if p.is("[") {
+		p.next()
+		p.parseType()
+		p.expect("]")
+	} else {
+		p.name()
+	}
+	if p.is("!") {
+		p.next()
+	}
+}
+
+func (p *queryParser) parseDirectives() {
+	for !p.failed && p.is("@") {
+		p.next()
+		if name, ok := p.name(); ok {
+			p.parseArguments(name, "", nil)
+		}
+	}
+}
+
+// typeCondition checks the type named in `on Type` and returns it, or ""
+// when the schema has no such type.
+func (p *queryParser) typeCondition(tok sdlToken) string {
+	if _, ok := p.schema.Types[tok.val]; !ok {
+		p.errorAt(tok, "Unknown type %q", tok.val)
+		return ""
+	}
+	return tok.val
+}
+
+func (p *queryParser) fieldCompletions(typeName string) []Completion {
+	t := p.schema.Types[typeName]
+	if t == nil {
+		return nil
+	}
+	var items []Completion
+	for _, f := range t.Fields {
+		items = append(items, Completion{Label: f.Name, Detail: f.Type.String()})
+	}
+	return append(items, Completion{Label: typenameField.Name, Detail: typenameField.Type.String()})
+}
+
+// typeCompletions lists the types a fragment on typeName can name: a
+// union's members, or every object, interface and union type.
+func (p *queryParser) typeCompletions(typeName string) []Completion {
+	var names []string
+	if t := p.schema.Types[typeName]; t != nil && t.Kind == "UNION" {
+		names = append(names, t.PossibleTypes...)
+	} else {
+		for name, t := range p.schema.Types {
+			if t.Kind == "OBJECT" || t.Kind == "INTERFACE" || t.Kind == "UNION" {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	items := make([]Completion, len(names))
+	for i, name := range names {
+		items[i] = Completion{Label: name, Detail: strings.ToLower(p.schema.Types[name].Kind)}
+	}
+	return items
+}
+
+func argCompletions(field *SDLField, seen map[string]bool) []Completion {
+	if field == nil {
+		return nil
+	}
+	var items []Completion
+	for _, arg := range field.Args {
+		if !seen[arg.Name] {
+			items = append(items, Completion{Label: arg.Name, Detail: arg.Type.String()})
+		}
+	}
+	return items
+}
+
+func (p *queryParser) valueCompletions(ref TypeRef) []Completion {
+	name := ref.NamedType()
+	if name == "Boolean" {
+		return keywordCompletions("true", "false")
+	}
+	t := p.schema.Types[name]
+	if t == nil || t.Kind != "ENUM" {
+		return nil
+	}
+	items := make([]Completion, len(t.EnumValues))
+	for i, v := range t.EnumValues {
+		items[i] = Completion{Label: v, Detail: name}
+	}
+	return items
+}
+
+func keywordCompletions(words ...string) []Completion {
+	items := make([]Completion, len(words))
+	for i, w := range words {
+		items[i] = Completion{Label: w}
+	}
+	return items
+}
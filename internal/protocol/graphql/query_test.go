@@ -0,0 +1,104 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateQuery(t *testing.T) {
+	s, err := ParseSDL(testSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := []string{
+		`{ users { id name posts(first: 5) { title } } }`,
+		`query Get($id: ID!) { user(id: $id) { ...UserFields role } }
+fragment UserFields on User { name __typename }`,
+		`query { search(term: "x") { ... on Post { title } ... on User { name } } }`,
+		`mutation { createUser(input: {name: "a", role: ADMIN, tags: ["x"]}) @skip(if: false) { id } }`,
+		`{ __schema { types { name } } }`,
+	}
+	for _, q := range valid {
+		if errs := s.ValidateQuery(q); len(errs) > 0 {
+			t.Errorf("ValidateQuery(%q) = %v, want no errors", q, errs)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"{ users {\n  id\n  nme\n} }", `3:3: Cannot query field "nme" on type "User"`},
+		{`{ user { id } }`, `1:3: Field "user" argument "id" of type "ID!" is required`},
+		{`{ user(id: 1, limit: 2) { id } }`, `1:15: Unknown argument "limit" on field "RootQuery.user"`},
+		{`{ users }`, `1:3: Field "users" of type "[User!]!" must have a selection of subfields`},
+		{`{ version { id } }`, `1:3: Field "version" must not have a selection since type "String" has no subfields`},
+		{`{ search(term: "x") { ... on Comment { id } } }`, `1:30: Unknown type "Comment"`},
+		{`{ users { ...Missing } }`, `1:14: Unknown fragment "Missing"`},
+		{`subscription { users { id } }`, `1:1: Schema does not support subscriptions`},
+		{`{ users { id }`, `1:15: Syntax error: expected "}", got end of query`},
+		{`{ users { id ? } }`, `1:14: Syntax error: unexpected character '?'`},
+	}
+	for _, tt := range tests {
+		errs := s.ValidateQuery(tt.query)
+		if len(errs) != 1 || errs[0].Error() != tt.want {
+			t.Errorf("ValidateQuery(%q) = %v, want [%s]", tt.query, errs, tt.want)
+		}
+	}
+
+	errs := s.ValidateQuery(`{ users { nme } usr { id } }`)
+	if len(errs) != 2 || errs[0].Col != 11 || errs[0].Len != 3 || errs[1].Col != 17 {
+		t.Errorf("expected two errors in order, got %+v", errs)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s, err := ParseSDL(testSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := func(items []Completion) string {
+		var names []string
+		for _, c := range items {
+			names = append(names, c.Label)
+		}
+		return strings.Join(names, ",")
+	}
+	tests := []struct {
+		query  string // | marks the cursor
+		prefix string
+		want   string
+	}{
+		{"{ us|", "us", "user,users"},
+		{"{ users { |", "", "id,name,role,createdAt,posts,friends,__typename"},
+		{"{ users { id n|", "n", "name"},
+		{"{ users { alias: p| } }", "p", "posts"},
+		{"{ users { posts(|", "", "first"},
+		{"{ user(id: 1) { friends(after: \"x\", |", "", ""},
+		{"{ search(term: \"x\") { ... on |", "", "Post,User"},
+		{"mutation { createUser(input: {role: A|", "A", ""},
+		{"fragment F on U|", "U", "User"},
+		{"q|", "q", "query"},
+		{"{ user(id: $i|", "i", ""},
+		{"{ users { # na|", "na", ""},
+	}
+	for _, tt := range tests {
+		offset := len([]rune(tt.query[:strings.Index(tt.query, "|")]))
+		query := strings.Replace(tt.query, "|", "", 1)
+		prefix, items := s.Complete(query, offset)
+		if prefix != tt.prefix || labels(items) != tt.want {
+			t.Errorf("Complete(%q) = %q, [%s], want %q, [%s]", tt.query, prefix, labels(items), tt.prefix, tt.want)
+		}
+	}
+
+	// Enum arguments complete their values
+	s.Types["RootQuery"].Fields = append(s.Types["RootQuery"].Fields, SDLField{
+		Name: "byRole", Type: TypeRef{Name: "User"},
+		Args: []SDLField{{Name: "role", Type: TypeRef{Name: "Role", NonNull: true}}},
+	})
+	if _, items := s.Complete("{ byRole(role: ", 15); labels(items) != "ADMIN,USER" {
+		t.Errorf("enum completion = [%s]", labels(items))
+	}
+}
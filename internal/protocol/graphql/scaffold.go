@@ -0,0 +1,214 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultScaffoldDepth is the selection set depth used when none is configured.
+const DefaultScaffoldDepth = 2
+
+// Operation is a root field that can be scaffolded into a request.
+type Operation struct {
+	Kind  string // query, mutation or subscription
+	Field SDLField
+}
+
+// Label returns a display label such as "query users".
+func (o Operation) Label() string {
+	return o.Kind + " " + o.Field.Name
+}
+
+// Operations lists all root query, mutation and subscription fields.
+func (s *SDLSchema) Operations() []Operation {
+	var ops []Operation
+	for _, root := range []struct{ kind, typ string }{
+		{"query", s.QueryType},
+		{"mutation", s.MutationType},
+		{"subscription", s.SubscriptionType},
+	} {
+		t, ok := s.Types[root.typ]
+		if !ok || root.typ == "" {
+			continue
+		}
+		for _, f := range t.Fields {
+			ops = append(ops, Operation{Kind: root.kind, Field: f})
+		}
+	}
+	return ops
+}
+
+// FindOperation looks up an operation by its label ("query users").
+func (s *SDLSchema) FindOperation(label string) (Operation, bool) {
+	for _, op := range s.Operations() {
+		if op.Label() == label {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
+
+// Scaffold builds a query document with a complete selection set down to
+// depth levels of nesting, plus a JSON variables skeleton for its arguments.
+func (s *SDLSchema) Scaffold(op Operation, depth int) (query, variables string, err error) {
+	if depth < 1 {
+		depth = DefaultScaffoldDepth
+	}
+
+	var b strings.Builder
+	b.WriteString(op.Kind)
+	b.WriteString(" ")
+	b.WriteString(operationName(op.Field.Name))
+
+	vars := make(map[string]any)
+	if len(op.Field.Args) > 0 {
+		defs := make([]string, len(op.Field.Args))
+		for i, a := range op.Field.Args {
+			defs[i] = "$" + a.Name + ": " + a.Type.String()
+			vars[a.Name] = s.sampleValue(a.Type, 3)
+		}
+		b.WriteString("(" + strings.Join(defs, ", ") + ")")
+	}
+	b.WriteString(" {\n  ")
+	b.WriteString(op.Field.Name)
+	if len(op.Field.Args) > 0 {
+		args := make([]string, len(op.Field.Args))
+		for i, a := range op.Field.Args {
+			args[i] = a.Name + ": $" + a.Name
+		}
+		b.WriteString("(" + strings.Join(args, ", ") + ")")
+	}
+
+	if _, ok := s.Types[op.Field.Type.NamedType()]; !ok {
+		return "", "", fmt.Errorf("unknown type %q for field %s", op.Field.Type.NamedType(), op.Field.Name)
+	}
+	if sel := s.selectionSet(op.Field.Type.NamedType(), depth, 2); sel != "" {
+		b.WriteString(" " + sel)
+	}
+	b.WriteString("\n}\n")
+
+	varsJSON, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return "", "", fmt.Errorf("encoding variables: %w", err)
+	}
+	return b.String(), string(varsJSON), nil
+}
+
+// selectionSet renders `{ ... }` for the named type, or "" for leaf types.
+func (s *SDLSchema) selectionSet(typeName string, depth, indent int) string {
+	t, ok := s.Types[typeName]
+	if !ok {
+		return ""
+	}
+	pad := strings.Repeat("  ", indent)
+	var lines []string
+
+	switch t.Kind {
+	case "SCALAR", "ENUM":
+		return ""
+	case "UNION":
+		lines = append(lines, pad+"__typename")
+		if depth > 1 {
+			for _, member := range t.PossibleTypes {
+				if sel := s.selectionSet(member, depth-1, indent+1); sel != "" {
+					lines = append(lines, pad+"... on "+member+" "+sel)
+				}
+			}
+		}
+	default:
+		for _, f := range t.Fields {
+			if hasRequiredArgs(f) {
+				continue
+			}
+			ft, ok := s.Types[f.Type.NamedType()]
+			if !ok {
+				continue
+			}
+			if ft.Kind == "SCALAR" || ft.Kind == "ENUM" {
+				lines = append(lines, pad+f.Name)
+				continue
+			}
+			if depth <= 1 {
+				continue
+			}
+			if sel := s.selectionSet(ft.Name, depth-1, indent+1); sel != "" {
+				lines = append(lines, pad+f.Name+" "+sel)
+			}
+		}
+		if len(lines) == 0 {
+			lines = append(lines, pad+"__typename")
+		}
+	}
+
+	return "{\n" + strings.Join(lines, "\n") + "\n" + strings.Repeat("  ", indent-1) + "}"
+}
+
+// sampleValue returns a placeholder JSON value for an input type.
+func (s *SDLSchema) sampleValue(ref TypeRef, depth int) any {
+	if ref.OfType != nil {
+		return []any{s.sampleValue(*ref.OfType, depth)}
+	}
+	switch ref.Name {
+	case "Int":
+		return 0
+	case "Float":
+		return 0.0
+	case "Boolean":
+		return false
+	case "String", "ID":
+		return ""
+	}
+	t, ok := s.Types[ref.Name]
+	if !ok {
+		return nil
+	}
+	switch t.Kind {
+	case "ENUM":
+		if len(t.EnumValues) > 0 {
+			return t.EnumValues[0]
+		}
+		return ""
+	case "INPUT_OBJECT":
+		obj := make(map[string]any)
+		if depth <= 0 {
+			return obj
+		}
+		for _, f := range t.Fields {
+			// Recursive input types only get required fields past the first level.
+			if !f.Type.NonNull && depth < 3 {
+				continue
+			}
+			obj[f.Name] = s.sampleValue(f.Type, depth-1)
+		}
+		return obj
+	}
+	return ""
+}
+
+func hasRequiredArgs(f SDLField) bool {
+	for _, a := range f.Args {
+		if a.Type.NonNull && a.DefaultValue == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// operationName turns a field name into an operation name (users -> Users).
+func operationName(field string) string {
+	if field == "" {
+		return "Operation"
+	}
+	return strings.ToUpper(field[:1]) + field[1:]
+}
+
+// OperationLabels returns the labels of all schema operations, queries first.
+func (s *SDLSchema) OperationLabels() []string {
+	ops := s.Operations()
+	labels := make([]string, len(ops))
+	for i, op := range ops {
+		labels[i] = op.Label()
+	}
+	return labels
+}
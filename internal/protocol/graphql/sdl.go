@@ -0,0 +1,653 @@
+package graphql
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// SDLSchema is a GraphQL schema parsed from SDL source.
+type SDLSchema struct {
+	Types            map[string]*SDLType
+	QueryType        string
+	MutationType     string
+	SubscriptionType string
+}
+
+// SDLType is a named type definition.
+type SDLType struct {
+	Name          string
+	Kind          string // OBJECT, INTERFACE, INPUT_OBJECT, ENUM, SCALAR, UNION
+	Fields        []SDLField
+	EnumValues    []string
+	PossibleTypes []string // union members
+}
+
+// SDLField is a field (or input field / argument) definition.
+type SDLField struct {
+	Name         string
+	Type         TypeRef
+	Args         []SDLField
+	DefaultValue string
+}
+
+// TypeRef is a possibly wrapped type reference such as [User!]!.
+type TypeRef struct {
+	Name    string   // set for named types
+	OfType  *TypeRef // set for list types
+	NonNull bool
+}
+
+// String renders the type reference in SDL notation.
+func (t TypeRef) String() string {
+	var s string
+	if t.OfType != nil {
+		s = "[" + t.OfType.String() + "]"
+	} else {
+		s = t.Name
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// NamedType returns the innermost named type.
+func (t TypeRef) NamedType() string {
+	if t.OfType != nil {
+		return t.OfType.NamedType()
+	}
+	return t.Name
+}
+
+var builtinScalars = []string{"Int", "Float", "String", "Boolean", "ID"}
+
+// LoadSDLFile reads and parses a schema.graphql file.
+func LoadSDLFile(path string) (*SDLSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema file: %w", err)
+	}
+	return ParseSDL(string(data))
+}
+
+// ParseSDL parses GraphQL schema definition language source.
+func ParseSDL(src string) (*SDLSchema, error) {
+	p := &sdlParser{lex: newSDLLexer(src)}
+	p.next()
+
+	schema := &SDLSchema{Types: make(map[string]*SDLType)}
+	for _, name := range builtinScalars {
+		schema.Types[name] = &SDLType{Name: name, Kind: "SCALAR"}
+	}
+
+	for p.tok.kind != tokEOF {
+		if err := p.parseDefinition(schema); err != nil {
+			return nil, err
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	if schema.QueryType == "" {
+		if _, ok := schema.Types["Query"]; ok {
+			schema.QueryType = "Query"
+		}
+	}
+	if schema.MutationType == "" {
+		if _, ok := schema.Types["Mutation"]; ok {
+			schema.MutationType = "Mutation"
+		}
+	}
+	if schema.SubscriptionType == "" {
+		if _, ok := schema.Types["Subscription"]; ok {
+			schema.SubscriptionType = "Subscription"
+		}
+	}
+	return schema, nil
+}
+
+// --- lexer ---
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokName
+	tokPunct
+	tokString
+	tokNumber
+)
+
+type sdlToken struct {
+	kind tokKind
+	val  string
+	line int
+	pos  int // rune offset of the token's start
+}
+
+type sdlLexer struct {
+	src  []rune
+	pos  int
+	line int
+}
+
+func newSDLLexer(src string) *sdlLexer {
+	return &sdlLexer{src: []rune(src), line: 1}
+}
+
+func (l *sdlLexer) next() (sdlToken, error) {
+	l.skipIgnored()
+	start := l.pos
+	tok, err := l.scan()
+	tok.pos = start
+	return tok, err
+}
+
+// skipIgnored skips whitespace, commas, comments and the BOM.
+func (l *sdlLexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '\n' {
+			l.line++
+			l.pos++
+		} else if c == ',' || c == '\uFEFF' || unicode.IsSpace(c) {
+			l.pos++
+		} else if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		} else {
+			break
+		}
+	}
+}
+
+func (l *sdlLexer) scan() (sdlToken, error) {
+	if l.pos >= len(l.src) {
+		return sdlToken{kind: tokEOF, line: l.line}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case c == '_' || unicode.IsLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || unicode.IsLetter(l.src[l.pos]) || unicode.IsDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return sdlToken{kind: tokName, val: string(l.src[start:l.pos]), line: l.line}, nil
+	case c == '-' || unicode.IsDigit(c):
+		l.pos++
+		for l.pos < len(l.src) && strings.ContainsRune("0123456789.eE+-", l.src[l.pos]) {
+			l.pos++
+		}
+		return sdlToken{kind: tokNumber, val: string(l.src[start:l.pos]), line: l.line}, nil
+	case c == '"':
+		return l.readString()
+	case c == '.':
+		if l.pos+2 < len(l.src) && l.src[l.pos+1] == '.' && l.src[l.pos+2] == '.' {
+			l.pos += 3
+			return sdlToken{kind: tokPunct, val: "...", line: l.line}, nil
+		}
+	case strings.ContainsRune("!$&()=:@[]{}|", c):
+		l.pos++
+		return sdlToken{kind: tokPunct, val: string(c), line: l.line}, nil
+	}
+	return sdlToken{}, fmt.Errorf("line %d: unexpected character %q", l.line, c)
+}
+
+func (l *sdlLexer) readString() (sdlToken, error) {
+	line := l.line
+	// Block string
+	if l.pos+2 < len(l.src) && l.src[l.pos+1] == '"' && l.src[l.pos+2] == '"' {
+		l.pos += 3
+		start := l.pos
+		for l.pos+2 < len(l.src) {
+			if l.src[l.pos] == '"' && l.src[l.pos+1] == '"' && l.src[l.pos+2] == '"' {
+				val := string(l.src[start:l.pos])
+				l.pos += 3
+				return sdlToken{kind: tokString, val: val, line: line}, nil
+			}
+			if l.src[l.pos] == '\n' {
+				l.line++
+			}
+			l.pos++
+		}
+		return sdlToken{}, fmt.Errorf("line %d: unterminated block string", line)
+	}
+	l.pos++
+	start := l.pos
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos += 2
+			continue
+		case '"':
+			val := string(l.src[start:l.pos])
+			l.pos++
+			return sdlToken{kind: tokString, val: val, line: line}, nil
+		case '\n':
+			return sdlToken{}, fmt.Errorf("line %d: unterminated string", line)
+		}
+		l.pos++
+	}
+	return sdlToken{}, fmt.Errorf("line %d: unterminated string", line)
+}
+
+// --- parser ---
+
+type sdlParser struct {
+	lex *sdlLexer
+	tok sdlToken
+	err error
+}
+
+func (p *sdlParser) next() {
+	if p.err != nil {
+		return
+	}
+	tok, err := p.lex.next()
+	if err != nil {
+		p.err = err
+		p.tok = sdlToken{kind: tokEOF}
+		return
+	}
+	p.tok = tok
+}
+
+func (p *sdlParser) is(val string) bool {
+	return (p.tok.kind == tokPunct || p.tok.kind == tokName) && p.tok.val == val
+}
+
+func (p *sdlParser) expect(val string) error {
+	if p.err != nil {
+		return p.err
+	}
+	if !p.is(val) {
+		return fmt.Errorf("line %d: expected %q, got %q", p.tok.line, val, p.tok.val)
+	}
+	p.next()
+	return p.err
+}
+
+func (p *sdlParser) name() (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	if p.tok.kind != tokName {
+		return "", fmt.Errorf("line %d: expected name, got %q", p.tok.line, p.tok.val)
+	}
+	n := p.tok.val
+	p.next()
+	return n, p.err
+}
+
+func (p *sdlParser) skipDescription() {
+	if p.tok.kind == tokString {
+		p.next()
+	}
+}
+
+func (p *sdlParser) parseDefinition(schema *SDLSchema) error {
+	p.skipDescription()
+	if p.err != nil {
+		return p.err
+	}
+	if p.tok.kind == tokEOF {
+		return nil
+	}
+
+	extend := false
+	if p.is("extend") {
+		extend = true
+		p.next()
+	}
+
+	kw, err := p.name()
+	if err != nil {
+		return err
+	}
+
+	switch kw {
+	case "schema":
+		if err := p.skipDirectives(); err != nil {
+			return err
+		}
+		return p.parseSchemaBlock(schema)
+	case "scalar":
+		n, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.skipDirectives(); err != nil {
+			return err
+		}
+		if _, ok := schema.Types[n]; !ok {
+			schema.Types[n] = &SDLType{Name: n, Kind: "SCALAR"}
+		}
+		return nil
+	case "type", "interface", "input":
+		kind := map[string]string{"type": "OBJECT", "interface": "INTERFACE", "input": "INPUT_OBJECT"}[kw]
+		n, err := p.name()
+		if err != nil {
+			return err
+		}
+		if p.is("implements") {
+			p.next()
+			if p.is("&") {
+				p.next()
+			}
+			for p.tok.kind == tokName && !p.is("{") {
+				p.next()
+				if p.is("&") {
+					p.next()
+				} else {
+					break
+				}
+			}
+		}
+		if err := p.skipDirectives(); err != nil {
+			return err
+		}
+		var fields []SDLField
+		if p.is("{") {
+			if fields, err = p.parseFields(kind == "INPUT_OBJECT"); err != nil {
+				return err
+			}
+		}
+		t := p.typeFor(schema, n, kind, extend)
+		t.Fields = append(t.Fields, fields...)
+		return nil
+	case "enum":
+		n, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.skipDirectives(); err != nil {
+			return err
+		}
+		t := p.typeFor(schema, n, "ENUM", extend)
+		if p.is("{") {
+			p.next()
+			for !p.is("}") {
+				if p.tok.kind == tokEOF {
+					return fmt.Errorf("unterminated enum %s", n)
+				}
+				p.skipDescription()
+				v, err := p.name()
+				if err != nil {
+					return err
+				}
+				t.EnumValues = append(t.EnumValues, v)
+				if err := p.skipDirectives(); err != nil {
+					return err
+				}
+			}
+			p.next()
+		}
+		return p.err
+	case "union":
+		n, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.skipDirectives(); err != nil {
+			return err
+		}
+		t := p.typeFor(schema, n, "UNION", extend)
+		if p.is("=") {
+			p.next()
+			if p.is("|") {
+				p.next()
+			}
+			for {
+				member, err := p.name()
+				if err != nil {
+					return err
+				}
+				t.PossibleTypes = append(t.PossibleTypes, member)
+				if !p.is("|") {
+					break
+				}
+				p.next()
+			}
+		}
+		return p.err
+	case "directive":
+		return p.skipDirectiveDefinition()
+	}
+	return fmt.Errorf("line %d: unexpected definition %q", p.tok.line, kw)
+}
+
+func (p *sdlParser) typeFor(schema *SDLSchema, name, kind string, extend bool) *SDLType {
+	if t, ok := schema.Types[name]; ok && (extend || t.Kind == kind) {
+		return t
+	}
+	t := &SDLType{Name: name, Kind: kind}
+	schema.Types[name] = t
+	return t
+}
+
+func (p *sdlParser) parseSchemaBlock(schema *SDLSchema) error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.is("}") {
+		op, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		t, err := p.name()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case "query":
+			schema.QueryType = t
+		case "mutation":
+			schema.MutationType = t
+		case "subscription":
+			schema.SubscriptionType = t
+		}
+	}
+	p.next()
+	return p.err
+}
+
+func (p *sdlParser) parseFields(input bool) ([]SDLField, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var fields []SDLField
+	for !p.is("}") {
+		if p.tok.kind == tokEOF {
+			return nil, fmt.Errorf("unterminated field list")
+		}
+		f, err := p.parseInputValue(!input)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	p.next()
+	return fields, p.err
+}
+
+// parseInputValue parses `name(args): Type = default @directives`.
+func (p *sdlParser) parseInputValue(allowArgs bool) (SDLField, error) {
+	p.skipDescription()
+	var f SDLField
+	n, err := p.name()
+	if err != nil {
+		return f, err
+	}
+	f.Name = n
+	if allowArgs && p.is("(") {
+		p.next()
+		for !p.is(")") {
+			if p.tok.kind == tokEOF {
+				return f, fmt.Errorf("unterminated arguments for %s", n)
+			}
+			arg, err := p.parseInputValue(false)
+			if err != nil {
+				return f, err
+			}
+			f.Args = append(f.Args, arg)
+		}
+		p.next()
+	}
+	if err := p.expect(":"); err != nil {
+		return f, err
+	}
+	if f.Type, err = p.parseType(); err != nil {
+		return f, err
+	}
+	if p.is("=") {
+		p.next()
+		if f.DefaultValue, err = p.skipValue(); err != nil {
+			return f, err
+		}
+	}
+	return f, p.skipDirectives()
+}
+
+func (p *sdlParser) parseType() (TypeRef, error) {
+	var t TypeRef
+	if p.is("[") {
+		p.next()
+		inner, err := p.parseType()
+		if err != nil {
+			return t, err
+		}
+		if err := p.expect("]"); err != nil {
+			return t, err
+		}
+		t.OfType = &inner
+	} else {
+		n, err := p.name()
+		if err != nil {
+			return t, err
+		}
+		t.Name = n
+	}
+	if p.is("!") {
+		t.NonNull = true
+		p.next()
+	}
+	return t, p.err
+}
+
+// skipValue consumes a constant value and returns its source text.
+func (p *sdlParser) skipValue() (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	switch {
+	case p.is("[") || p.is("{"):
+		open := p.tok.val
+		closeTok := "]"
+		if open == "{" {
+			closeTok = "}"
+		}
+		var parts []string
+		p.next()
+		for !p.is(closeTok) {
+			if p.tok.kind == tokEOF {
+				return "", fmt.Errorf("unterminated value")
+			}
+			if p.is(":") {
+				parts = append(parts, ":")
+				p.next()
+				continue
+			}
+			v, err := p.skipValue()
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, v)
+		}
+		p.next()
+		return open + strings.Join(parts, " ") + closeTok, p.err
+	case p.is("$"):
+		p.next()
+		n, err := p.name()
+		return "$" + n, err
+	}
+	v := p.tok.val
+	if p.tok.kind == tokString {
+		v = `"` + v + `"`
+	}
+	p.next()
+	return v, p.err
+}
+
+func (p *sdlParser) skipDirectives() error {
+	for p.is("@") {
+		p.next()
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if p.is("(") {
+			p.next()
+			for !p.is(")") {
+				if p.tok.kind == tokEOF {
+					return fmt.Errorf("unterminated directive arguments")
+				}
+				if p.is(":") {
+					p.next()
+					continue
+				}
+				if _, err := p.skipValue(); err != nil {
+					return err
+				}
+			}
+			p.next()
+		}
+	}
+	return p.err
+}
+
+func (p *sdlParser) skipDirectiveDefinition() error {
+	if err := p.expect("@"); err != nil {
+		return err
+	}
+	if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.is("(") {
+		p.next()
+		for !p.is(")") {
+			if p.tok.kind == tokEOF {
+				return fmt.Errorf("unterminated directive arguments")
+			}
+			if _, err := p.parseInputValue(false); err != nil {
+				return err
+			}
+		}
+		p.next()
+	}
+	if p.is("repeatable") {
+		p.next()
+	}
+	if err := p.expect("on"); err != nil {
+		return err
+	}
+	if p.is("|") {
+		p.next()
+	}
+	for {
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if !p.is("|") {
+			break
+		}
+		p.next()
+	}
+	return p.err
+}
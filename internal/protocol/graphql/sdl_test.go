@@ -0,0 +1,188 @@
+package graphql
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testSDL = `
+"""
+Example schema.
+"""
+schema {
+  query: RootQuery
+  mutation: Mutation
+}
+
+scalar DateTime
+
+directive @auth(role: String = "user") on FIELD_DEFINITION | OBJECT
+
+enum Role { ADMIN USER }
+
+interface Node { id: ID! }
+
+type User implements Node @auth {
+  id: ID!
+  "display name"
+  name: String
+  role: Role!
+  createdAt: DateTime
+  posts(first: Int = 10): [Post!]!
+  friends(after: String!): [User]
+}
+
+type Post implements Node {
+  id: ID!
+  title: String!
+  author: User!
+}
+
+union SearchResult = User | Post
+
+input CreateUserInput {
+  name: String!
+  role: Role = USER
+  tags: [String!]
+}
+
+type RootQuery {
+  user(id: ID!): User
+  users: [User!]!
+  search(term: String!): [SearchResult!]!
+}
+
+type Mutation {
+  createUser(input: CreateUserInput!): User! @auth(role: "admin")
+}
+
+extend type RootQuery {
+  version: String
+}
+`
+
+func TestParseSDL(t *testing.T) {
+	s, err := ParseSDL(testSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.QueryType != "RootQuery" || s.MutationType != "Mutation" {
+		t.Errorf("root types = %q/%q", s.QueryType, s.MutationType)
+	}
+
+	user := s.Types["User"]
+	if user == nil || user.Kind != "OBJECT" {
+		t.Fatalf("User type = %+v", user)
+	}
+	if len(user.Fields) != 6 {
+		t.Errorf("expected 6 User fields, got %d", len(user.Fields))
+	}
+	if got := user.Fields[4].Type.String(); got != "[Post!]!" {
+		t.Errorf("posts type = %q", got)
+	}
+	if got := user.Fields[4].Args[0].DefaultValue; got != "10" {
+		t.Errorf("posts first default = %q", got)
+	}
+	if got := s.Types["SearchResult"].PossibleTypes; len(got) != 2 {
+		t.Errorf("union members = %v", got)
+	}
+	if got := s.Types["Role"].EnumValues; len(got) != 2 || got[0] != "ADMIN" {
+		t.Errorf("enum values = %v", got)
+	}
+
+	labels := s.OperationLabels()
+	want := []string{"query user", "query users", "query search", "query version", "mutation createUser"}
+	if strings.Join(labels, ",") != strings.Join(want, ",") {
+		t.Errorf("labels = %v, want %v", labels, want)
+	}
+}
+
+func TestParseSDL_Errors(t *testing.T) {
+	for _, src := range []string{
+		`type User { id: ID!`,
+		`type User { id }`,
+		`"unterminated`,
+		`bogus Thing`,
+	} {
+		if _, err := ParseSDL(src); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestScaffold_Query(t *testing.T) {
+	s, err := ParseSDL(testSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, ok := s.FindOperation("query user")
+	if !ok {
+		t.Fatal("query user not found")
+	}
+
+	query, vars, err := s.Scaffold(op, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"query User($id: ID!) {",
+		"user(id: $id) {",
+		"createdAt",
+		"posts {",
+		"title",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+	// Fields with required args are skipped; depth 2 stops at posts' scalars.
+	if strings.Contains(query, "friends") || strings.Contains(query, "author") {
+		t.Errorf("unexpected fields in query:\n%s", query)
+	}
+
+	var v map[string]any
+	if err := json.Unmarshal([]byte(vars), &v); err != nil {
+		t.Fatalf("invalid variables JSON %q: %v", vars, err)
+	}
+	if _, ok := v["id"]; !ok {
+		t.Errorf("variables missing id: %s", vars)
+	}
+}
+
+func TestScaffold_MutationInputSkeleton(t *testing.T) {
+	s, _ := ParseSDL(testSDL)
+	op, _ := s.FindOperation("mutation createUser")
+
+	_, vars, err := s.Scaffold(op, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct {
+		Input map[string]any `json:"input"`
+	}
+	if err := json.Unmarshal([]byte(vars), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Input["role"] != "ADMIN" {
+		t.Errorf("enum sample = %v", v.Input["role"])
+	}
+	if _, ok := v.Input["tags"].([]any); !ok {
+		t.Errorf("list sample = %v", v.Input["tags"])
+	}
+}
+
+func TestScaffold_Union(t *testing.T) {
+	s, _ := ParseSDL(testSDL)
+	op, _ := s.FindOperation("query search")
+
+	query, _, err := s.Scaffold(op, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"__typename", "... on User {", "... on Post {"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+}
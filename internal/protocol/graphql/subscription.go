@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/coder/websocket"
+	"github.com/sadopc/gottp/internal/core/retry"
 	"github.com/sadopc/gottp/internal/protocol"
 )
 
@@ -47,7 +49,14 @@ type SubscriptionClient struct {
 	conn      *websocket.Conn
 	connected bool
 	subID     string
+	proxy     *protocol.ProxyConfig
+	reconnect *retry.Policy
+	keepalive time.Duration
 	mu        sync.Mutex
+
+	// Handshake of the last successful Connect, reused when reconnecting
+	url     string
+	headers map[string]string
 }
 
 // NewSubscriptionClient creates a new SubscriptionClient.
@@ -57,6 +66,28 @@ func NewSubscriptionClient() *SubscriptionClient {
 	}
 }
 
+// SetProxy routes the WebSocket connection through p.
+func (c *SubscriptionClient) SetProxy(p *protocol.ProxyConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.proxy = p
+}
+
+// SetReconnect sets the policy Subscribe uses to re-dial and resubscribe
+// after the connection drops. nil or MaxAttempts 0 disables reconnecting.
+func (c *SubscriptionClient) SetReconnect(p *retry.Policy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reconnect = p
+}
+
+// SetKeepalive makes Subscribe send a graphql-ws ping every d (0 disables).
+func (c *SubscriptionClient) SetKeepalive(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keepalive = d
+}
+
 // Connect establishes the WebSocket connection and performs the graphql-ws
 // handshake (connection_init / connection_ack).
 func (c *SubscriptionClient) Connect(ctx context.Context, url string, headers map[string]string) error {
@@ -75,7 +106,15 @@ func (c *SubscriptionClient) Connect(ctx context.Context, url string, headers ma
 		httpHeaders[k] = []string{v}
 	}
 
+	c.mu.Lock()
+	transport, err := protocol.ProxyTransport(c.proxy)
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("configuring transport: %w", err)
+	}
+
 	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		HTTPClient:   &http.Client{Transport: transport},
 		HTTPHeader:   httpHeaders,
 		Subprotocols: []string{graphqlWSSubprotocol},
 	})
@@ -112,6 +151,7 @@ func (c *SubscriptionClient) Connect(ctx context.Context, url string, headers ma
 
 	c.mu.Lock()
 	c.connected = true
+	c.url, c.headers = url, headers
 	c.mu.Unlock()
 	return nil
 }
@@ -157,6 +197,15 @@ func (c *SubscriptionClient) Subscribe(ctx context.Context, query string, variab
 		return fmt.Errorf("sending subscribe: %w", err)
 	}
 
+	c.mu.Lock()
+	keepalive := c.keepalive
+	c.mu.Unlock()
+	if keepalive > 0 {
+		stop := make(chan struct{})
+		defer close(stop)
+		go c.sendPings(ctx, keepalive, stop)
+	}
+
 	// Send the subscribe message itself as a "sent" stream message.
 	select {
 	case msgChan <- protocol.StreamMessage{
@@ -176,6 +225,15 @@ func (c *SubscriptionClient) Subscribe(ctx context.Context, query string, variab
 			if websocket.CloseStatus(err) == websocket.StatusNormalClosure {
 				return nil
 			}
+			if ctx.Err() == nil {
+				var ok bool
+				if ok, err = c.resubscribe(ctx, err, subMsg, msgChan); ok {
+					c.mu.Lock()
+					conn = c.conn
+					c.mu.Unlock()
+					continue
+				}
+			}
 			select {
 			case msgChan <- protocol.StreamMessage{
 				Err:       err,
@@ -243,6 +301,82 @@ func (c *SubscriptionClient) Subscribe(ctx context.Context, query string, variab
 	}
 }
 
+// resubscribe re-dials with backoff after the connection dropped with
+// cause and sends subMsg again on the new connection. Attempts are reported
+// on msgChan as "event" messages. It returns false with the last error once
+// the policy's attempts are used up, or straight away without a policy.
+func (c *SubscriptionClient) resubscribe(ctx context.Context, cause error, subMsg gqlWSMessage, msgChan chan<- protocol.StreamMessage) (bool, error) {
+	c.mu.Lock()
+	policy, url, headers := c.reconnect, c.url, c.headers
+	c.mu.Unlock()
+	if policy == nil || policy.MaxAttempts <= 0 {
+		return false, cause
+	}
+	notify := func(content string) bool {
+		select {
+		case msgChan <- protocol.StreamMessage{Content: content, Timestamp: time.Now(), Direction: "event"}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		delay := policy.Delay(attempt)
+		if !notify(fmt.Sprintf("connection lost (%v), reconnecting in %s (attempt %d/%d)",
+			cause, delay.Round(time.Millisecond), attempt, policy.MaxAttempts)) {
+			return false, ctx.Err()
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, ctx.Err()
+		case <-timer.C:
+		}
+
+		c.mu.Lock()
+		if c.conn != nil {
+			_ = c.conn.CloseNow()
+		}
+		c.conn, c.connected = nil, false
+		c.mu.Unlock()
+		if err := c.Connect(ctx, url, headers); err != nil {
+			cause = err
+			continue
+		}
+		if err := c.writeJSON(ctx, subMsg); err != nil {
+			cause = fmt.Errorf("resending subscribe: %w", err)
+			continue
+		}
+		return notify(fmt.Sprintf("reconnected and resubscribed after %d attempt(s)", attempt)), nil
+	}
+	return false, fmt.Errorf("giving up after %d reconnect attempts: %w", policy.MaxAttempts, cause)
+}
+
+// sendPings sends a graphql-ws ping every interval until stop is closed.
+// Failures are left to the read loop, which sees the broken connection.
+func (c *SubscriptionClient) sendPings(ctx context.Context, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.Lock()
+			if c.connected && c.conn != nil {
+				pingCtx, cancel := context.WithTimeout(ctx, interval)
+				_ = c.writeJSONLocked(pingCtx, gqlWSMessage{Type: msgPing})
+				cancel()
+			}
+			c.mu.Unlock()
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // Close gracefully closes the subscription and the underlying WebSocket
 // connection.
 func (c *SubscriptionClient) Close() error {
@@ -3,13 +3,16 @@ package graphql
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/coder/websocket"
+	"github.com/sadopc/gottp/internal/core/retry"
 	"github.com/sadopc/gottp/internal/protocol"
 )
 
@@ -617,3 +620,75 @@ func TestSubscribePayloadWithVariables(t *testing.T) {
 		t.Errorf("expected ch=general, got %v", variables["ch"])
 	}
 }
+
+func TestSubscriptionReconnectsAndResubscribes(t *testing.T) {
+	var conns atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{Subprotocols: []string{graphqlWSSubprotocol}})
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		ctx := r.Context()
+		read := func() gqlWSMessage {
+			_, data, _ := conn.Read(ctx)
+			var msg gqlWSMessage
+			json.Unmarshal(data, &msg)
+			return msg
+		}
+		write := func(msg gqlWSMessage) {
+			data, _ := json.Marshal(msg)
+			conn.Write(ctx, websocket.MessageText, data)
+		}
+
+		read() // connection_init
+		write(gqlWSMessage{Type: msgConnectionAck})
+		sub := read()
+		n := conns.Add(1)
+		if n == 2 {
+			// Wait for a keepalive ping before answering
+			for i := 0; i < 100 && read().Type != msgPing; i++ {
+			}
+		}
+		write(gqlWSMessage{ID: sub.ID, Type: msgNext, Payload: json.RawMessage(fmt.Sprintf(`{"data":{"seq":%d}}`, n))})
+		if n == 1 {
+			return // drop without a close frame
+		}
+		write(gqlWSMessage{ID: sub.ID, Type: msgComplete})
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	sc := NewSubscriptionClient()
+	sc.SetReconnect(&retry.Policy{MaxAttempts: 3, Backoff: time.Millisecond})
+	sc.SetKeepalive(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sc.Connect(ctx, wsURLFromHTTP(srv), nil); err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	msgChan := make(chan protocol.StreamMessage, 20)
+	errc := make(chan error, 1)
+	go func() { errc <- sc.Subscribe(ctx, "subscription { seq }", "", msgChan) }()
+
+	var received, events []string
+	for msg := range msgChan {
+		switch msg.Direction {
+		case "received":
+			received = append(received, msg.Content)
+		case "event":
+			events = append(events, msg.Content)
+		}
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if fmt.Sprint(received) != `[{"data":{"seq":1}} {"data":{"seq":2}}]` {
+		t.Errorf("received %v", received)
+	}
+	if len(events) != 2 || !strings.Contains(events[0], "reconnecting") || !strings.Contains(events[1], "resubscribed") {
+		t.Errorf("events %v", events)
+	}
+}
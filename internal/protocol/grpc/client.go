@@ -18,6 +18,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
@@ -29,6 +30,7 @@ import (
 type Client struct {
 	mu    sync.Mutex
 	conns map[string]*grpc.ClientConn
+	web   map[string]*webConn // gRPC-Web and Connect, keyed by transport and address
 
 	// Streaming state for client-streaming and bidi-streaming RPCs.
 	streamMu    sync.Mutex
@@ -40,6 +42,7 @@ type Client struct {
 func New() *Client {
 	return &Client{
 		conns: make(map[string]*grpc.ClientConn),
+		web:   make(map[string]*webConn),
 	}
 }
 
@@ -55,6 +58,11 @@ func (c *Client) Validate(req *protocol.Request) error {
 	if req.GRPCMethod == "" {
 		return fmt.Errorf("gRPC method name is required")
 	}
+	switch req.GRPCTransport {
+	case "", TransportGRPC, TransportGRPCWeb, TransportConnect:
+	default:
+		return fmt.Errorf("unknown gRPC transport %q (want grpc, grpc-web or connect)", req.GRPCTransport)
+	}
 	return nil
 }
 
@@ -64,7 +72,8 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 	}
 
 	// Get or create a connection for this address.
-	conn, err := c.getConn(req.URL)
+	transport := ResolveTransport(req.GRPCTransport, req.URL)
+	conn, err := c.channel(transport, req.URL)
 	if err != nil {
 		return nil, fmt.Errorf("connecting to %s: %w", req.URL, err)
 	}
@@ -108,12 +117,15 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 		formatter: formatter,
 	}
 
-	// Set timeout.
+	// Set timeout. A per-call deadline takes precedence.
 	timeout := req.Timeout
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
-	invokeCtx, cancel := context.WithTimeout(ctx, timeout)
+	if req.GRPCCall.Deadline > 0 {
+		timeout = req.GRPCCall.Deadline
+	}
+	invokeCtx, cancel := context.WithTimeout(withCallOptions(ctx, req.GRPCCall), timeout)
 	defer cancel()
 
 	// Invoke the RPC.
@@ -179,13 +191,13 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 		ContentType: "application/json",
 		Duration:    duration,
 		Size:        int64(len(respBody)),
-		Proto:       "gRPC",
-		TLS:         strings.HasPrefix(req.URL, "dns:///") || strings.Contains(req.URL, ":443"),
+		Proto:       transportName(transport),
+		TLS:         strings.HasPrefix(req.URL, "https://") || strings.HasPrefix(req.URL, "dns:///") || strings.Contains(req.URL, ":443"),
 	}, nil
 }
 
 // Close closes all cached gRPC connections.
-func (c *Client) Close() {
+func (c *Client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -193,6 +205,11 @@ func (c *Client) Close() {
 		conn.Close()
 		delete(c.conns, addr)
 	}
+	for key, conn := range c.web {
+		conn.Close()
+		delete(c.web, key)
+	}
+	return nil
 }
 
 // IsStreaming uses server reflection to detect whether the given method uses
@@ -202,7 +219,7 @@ func (c *Client) IsStreaming(ctx context.Context, req *protocol.Request) (server
 		return false, false, err
 	}
 
-	conn, err := c.getConn(req.URL)
+	conn, err := c.channel(ResolveTransport(req.GRPCTransport, req.URL), req.URL)
 	if err != nil {
 		return false, false, fmt.Errorf("connecting to %s: %w", req.URL, err)
 	}
@@ -247,7 +264,7 @@ func (c *Client) StreamExecute(ctx context.Context, req *protocol.Request, msgCh
 		return err
 	}
 
-	conn, err := c.getConn(req.URL)
+	conn, err := c.channel(ResolveTransport(req.GRPCTransport, req.URL), req.URL)
 	if err != nil {
 		return fmt.Errorf("connecting to %s: %w", req.URL, err)
 	}
@@ -284,7 +301,10 @@ func (c *Client) StreamExecute(ctx context.Context, req *protocol.Request, msgCh
 	if timeout == 0 {
 		timeout = 5 * time.Minute // longer timeout for streaming
 	}
-	invokeCtx, cancel := context.WithTimeout(ctx, timeout)
+	if req.GRPCCall.Deadline > 0 {
+		timeout = req.GRPCCall.Deadline
+	}
+	invokeCtx, cancel := context.WithTimeout(withCallOptions(ctx, req.GRPCCall), timeout)
 	defer cancel()
 
 	if cliStream && detectErr == nil {
@@ -421,6 +441,23 @@ func (c *Client) CloseStream() error {
 	return nil
 }
 
+// channel returns the connection for addr over transport.
+func (c *Client) channel(transport, addr string) (grpc.ClientConnInterface, error) {
+	if transport == TransportGRPC {
+		return c.getConn(addr)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := transport + " " + addr
+	conn, ok := c.web[key]
+	if !ok {
+		conn = newWebConn(transport, addr)
+		c.web[key] = conn
+	}
+	return conn, nil
+}
+
 // getConn returns an existing connection or creates a new one for the given address.
 func (c *Client) getConn(addr string) (*grpc.ClientConn, error) {
 	c.mu.Lock()
@@ -439,7 +476,9 @@ func (c *Client) getConn(addr string) (*grpc.ClientConn, error) {
 	conn, err := grpc.NewClient(
 		target,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(50*1024*1024)),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(defaultMaxRecvMsgSize)),
+		grpc.WithChainUnaryInterceptor(unaryCallOptions),
+		grpc.WithChainStreamInterceptor(streamCallOptions),
 	)
 	if err != nil {
 		return nil, err
@@ -449,6 +488,50 @@ func (c *Client) getConn(addr string) (*grpc.ClientConn, error) {
 	return conn, nil
 }
 
+// defaultMaxRecvMsgSize is the largest response message accepted unless a
+// request sets its own limit.
+const defaultMaxRecvMsgSize = 50 * 1024 * 1024
+
+type callOptionsKey struct{}
+
+// withCallOptions attaches a request's call options to ctx. Connections are
+// shared between requests, so the options travel with each call rather than
+// as connection defaults; reflection calls made on the same connection are
+// left alone.
+func withCallOptions(ctx context.Context, opts protocol.GRPCCallOptions) context.Context {
+	return context.WithValue(ctx, callOptionsKey{}, opts)
+}
+
+// callOptions converts a request's call options to gRPC call options.
+func callOptions(opts protocol.GRPCCallOptions) []grpc.CallOption {
+	var out []grpc.CallOption
+	if opts.Gzip {
+		out = append(out, grpc.UseCompressor(gzip.Name))
+	}
+	if opts.MaxSendMsgSize > 0 {
+		out = append(out, grpc.MaxCallSendMsgSize(opts.MaxSendMsgSize))
+	}
+	if opts.MaxRecvMsgSize > 0 {
+		out = append(out, grpc.MaxCallRecvMsgSize(opts.MaxRecvMsgSize))
+	}
+	if opts.WaitForReady {
+		out = append(out, grpc.WaitForReady(true))
+	}
+	return out
+}
+
+func contextCallOptions(ctx context.Context) []grpc.CallOption {
+	return callOptions(contextOptions(ctx))
+}
+
+func unaryCallOptions(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(ctx, method, req, reply, cc, append(opts, contextCallOptions(ctx)...)...)
+}
+
+func streamCallOptions(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(ctx, desc, cc, method, append(opts, contextCallOptions(ctx)...)...)
+}
+
 // buildMetadata constructs gRPC metadata from the request's Metadata map
 // and Auth configuration.
 func buildMetadata(req *protocol.Request) metadata.MD {
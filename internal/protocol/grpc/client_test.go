@@ -3,9 +3,12 @@ package grpc
 import (
 	"context"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/fullstorydev/grpcurl"
+	"github.com/jhump/protoreflect/desc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
@@ -300,6 +303,71 @@ func TestExecuteWithMetadata(t *testing.T) {
 	}
 }
 
+// TestExecuteCallOptions verifies that per-request call options reach the
+// invoked RPC and only that RPC.
+func TestExecuteCallOptions(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	var deadlines []time.Duration
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if d, ok := ctx.Deadline(); ok {
+			deadlines = append(deadlines, time.Until(d))
+		}
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(srv, &healthServer{})
+	reflection.Register(srv)
+
+	go func() {
+		srv.Serve(lis)
+	}()
+	defer srv.Stop()
+
+	client := New()
+	defer client.Close()
+
+	req := &protocol.Request{
+		Protocol:    "grpc",
+		URL:         lis.Addr().String(),
+		GRPCService: "grpc.health.v1.Health",
+		GRPCMethod:  "Check",
+		Body:        []byte(`{"service": ""}`),
+		Timeout:     time.Minute,
+		GRPCCall:    protocol.GRPCCallOptions{Deadline: 2 * time.Second, Gzip: true, WaitForReady: true},
+	}
+
+	resp, err := client.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %s: %s", resp.Status, resp.Body)
+	}
+	if len(deadlines) != 1 || deadlines[0] > 2*time.Second {
+		t.Errorf("expected the call deadline to replace the timeout, got %v", deadlines)
+	}
+
+	// A receive limit smaller than the response fails the call
+	req.GRPCCall = protocol.GRPCCallOptions{MaxRecvMsgSize: 1}
+	resp, err = client.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if got := resp.Headers.Get("grpc-status"); got != codes.ResourceExhausted.String() {
+		t.Errorf("expected ResourceExhausted with a 1-byte receive limit, got %s", got)
+	}
+
+	// The limit belongs to that request; the shared connection is unaffected
+	req.GRPCCall = protocol.GRPCCallOptions{}
+	resp, err = client.Execute(context.Background(), req)
+	if err != nil || resp.StatusCode != 200 {
+		t.Errorf("expected a plain call to succeed, got %v, %v", resp, err)
+	}
+}
+
 // TestExecuteNotFoundService verifies error handling for unknown services.
 func TestExecuteNotFoundService(t *testing.T) {
 	lis, err := net.Listen("tcp", "127.0.0.1:0")
@@ -385,7 +453,7 @@ func TestDiscoverServicesIntegration(t *testing.T) {
 
 	addr := lis.Addr().String()
 
-	services, err := DiscoverServices(context.Background(), addr)
+	services, err := DiscoverServices(context.Background(), addr, "")
 	if err != nil {
 		t.Fatalf("DiscoverServices() error: %v", err)
 	}
@@ -408,7 +476,7 @@ func TestDiscoverServicesConnectionFailed(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	_, err := DiscoverServices(ctx, "127.0.0.1:1")
+	_, err := DiscoverServices(ctx, "127.0.0.1:1", "")
 	if err == nil {
 		t.Error("expected error for unreachable server")
 	}
@@ -441,3 +509,19 @@ func TestIsInternalService(t *testing.T) {
 
 // Ensure that the Client implements protocol.Protocol at compile time.
 var _ protocol.Protocol = (*Client)(nil)
+
+func TestMessageTemplate(t *testing.T) {
+	md, err := desc.LoadMessageDescriptorForMessage(&healthpb.HealthCheckResponse{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := grpcurl.DescriptorSourceFromFileDescriptors(md.GetFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatter := grpcurl.NewJSONFormatter(true, grpcurl.AnyResolverFromDescriptorSource(source))
+	got := messageTemplate(formatter, md)
+	if !strings.Contains(got, `"status": "UNKNOWN"`) {
+		t.Errorf("expected every field with its zero value, got:\n%s", got)
+	}
+}
@@ -26,26 +26,40 @@ type MethodInfo struct {
 	OutputType     string
 	IsClientStream bool
 	IsServerStream bool
+
+	// JSON skeletons of the request and response messages, with every
+	// field set to its zero value and repeated fields holding one element
+	InputTemplate  string
+	OutputTemplate string
 }
 
-// DiscoverServices connects to a gRPC server at the given address, uses
-// server reflection to enumerate all services and their methods, and returns
-// the result. Internal reflection services (grpc.reflection.*) are excluded.
-func DiscoverServices(ctx context.Context, addr string) ([]ServiceInfo, error) {
-	// Strip scheme prefixes for the dial target.
-	target := addr
-	target = strings.TrimPrefix(target, "http://")
-	target = strings.TrimPrefix(target, "https://")
-	target = strings.TrimPrefix(target, "grpc://")
-
-	conn, err := grpc.NewClient(
-		target,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+// DiscoverServices connects to a gRPC server at the given address over
+// transport (see ResolveTransport), uses server reflection to enumerate all
+// services and their methods, and returns the result. Internal reflection
+// services (grpc.reflection.*) are excluded.
+func DiscoverServices(ctx context.Context, addr, transport string) ([]ServiceInfo, error) {
+	var conn grpc.ClientConnInterface
+	if transport = ResolveTransport(transport, addr); transport == TransportGRPC {
+		// Strip scheme prefixes for the dial target.
+		target := addr
+		target = strings.TrimPrefix(target, "http://")
+		target = strings.TrimPrefix(target, "https://")
+		target = strings.TrimPrefix(target, "grpc://")
+
+		native, err := grpc.NewClient(
+			target,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+		}
+		defer native.Close()
+		conn = native
+	} else {
+		web := newWebConn(transport, addr)
+		defer web.Close()
+		conn = web
 	}
-	defer conn.Close()
 
 	refClient := grpcreflect.NewClientAuto(ctx, conn)
 	defer refClient.Reset()
@@ -58,6 +72,8 @@ func DiscoverServices(ctx context.Context, addr string) ([]ServiceInfo, error) {
 		return nil, fmt.Errorf("listing services: %w", err)
 	}
 
+	formatter := grpcurl.NewJSONFormatter(true, grpcurl.AnyResolverFromDescriptorSource(descSource))
+
 	var services []ServiceInfo
 	for _, svcName := range svcNames {
 		// Skip internal reflection services.
@@ -90,9 +106,11 @@ func DiscoverServices(ctx context.Context, addr string) ([]ServiceInfo, error) {
 			}
 			if md.GetInputType() != nil {
 				mi.InputType = md.GetInputType().GetFullyQualifiedName()
+				mi.InputTemplate = messageTemplate(formatter, md.GetInputType())
 			}
 			if md.GetOutputType() != nil {
 				mi.OutputType = md.GetOutputType().GetFullyQualifiedName()
+				mi.OutputTemplate = messageTemplate(formatter, md.GetOutputType())
 			}
 			svcInfo.Methods = append(svcInfo.Methods, mi)
 		}
@@ -103,6 +121,16 @@ func DiscoverServices(ctx context.Context, addr string) ([]ServiceInfo, error) {
 	return services, nil
 }
 
+// messageTemplate renders a JSON skeleton of a message, or "" when it
+// cannot be formatted.
+func messageTemplate(formatter grpcurl.Formatter, md *desc.MessageDescriptor) string {
+	out, err := formatter(grpcurl.MakeTemplate(md))
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
 // isInternalService returns true for gRPC-internal services that should not
 // be shown to the user (reflection, health, channelz, etc.).
 func isInternalService(name string) bool {
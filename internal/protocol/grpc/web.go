@@ -0,0 +1,649 @@
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// Transports a gRPC request can be sent over.
+const (
+	TransportGRPC    = "grpc"     // native gRPC over HTTP/2
+	TransportGRPCWeb = "grpc-web" // gRPC-Web, for servers behind proxies such as Envoy
+	TransportConnect = "connect"  // the Connect protocol
+)
+
+// ResolveTransport returns the transport a request is sent over: the one
+// chosen, or when none is, gRPC-Web for http:// and https:// URLs and
+// native gRPC for everything else.
+func ResolveTransport(transport, addr string) string {
+	if transport != "" {
+		return transport
+	}
+	if strings.HasPrefix(addr, "http://") || strings.HasPrefix(addr, "https://") {
+		return TransportGRPCWeb
+	}
+	return TransportGRPC
+}
+
+// transportName is the protocol shown on responses.
+func transportName(transport string) string {
+	switch transport {
+	case TransportGRPCWeb:
+		return "gRPC-Web"
+	case TransportConnect:
+		return "Connect"
+	default:
+		return "gRPC"
+	}
+}
+
+// webConn sends RPCs over gRPC-Web or Connect. It implements
+// grpc.ClientConnInterface, so grpcurl and the reflection client use it
+// like a native connection.
+//
+// HTTP/1.1 cannot stream both ways at once, so streams are half-duplex:
+// messages sent on a stream are buffered and posted together once the
+// stream is closed or a response is awaited. A bidi stream posts a new
+// request for each such batch, which is what server reflection needs.
+type webConn struct {
+	transport string
+	baseURL   string
+	client    *http.Client
+}
+
+func newWebConn(transport, addr string) *webConn {
+	base := strings.TrimRight(addr, "/")
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		base = "http://" + strings.TrimPrefix(base, "grpc://")
+	}
+	return &webConn{transport: transport, baseURL: base, client: &http.Client{}}
+}
+
+// Close releases idle HTTP connections.
+func (c *webConn) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// Invoke performs a unary RPC.
+func (c *webConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	var header, trailer metadata.MD
+	var err error
+	if c.transport == TransportConnect {
+		header, trailer, err = c.connectUnary(ctx, method, args, reply)
+	} else {
+		header, trailer, err = c.webUnary(ctx, method, args, reply)
+	}
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = header
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = trailer
+		}
+	}
+	return err
+}
+
+// NewStream opens a stream. Nothing is posted until the first response
+// or the response headers are awaited.
+func (c *webConn) NewStream(ctx context.Context, _ *grpc.StreamDesc, method string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	return &webStream{
+		ctx:    ctx,
+		conn:   c,
+		method: method,
+		opts:   contextOptions(ctx),
+		wake:   make(chan struct{}, 1),
+	}, nil
+}
+
+// webUnary sends a unary gRPC-Web call, which is a stream of one message
+// each way.
+func (c *webConn) webUnary(ctx context.Context, method string, args, reply any) (metadata.MD, metadata.MD, error) {
+	cs, _ := c.NewStream(ctx, nil, method)
+	s := cs.(*webStream)
+	if err := s.SendMsg(args); err != nil {
+		return nil, nil, err
+	}
+	_ = s.CloseSend()
+
+	s.recvMu.Lock()
+	defer s.recvMu.Unlock()
+	data, err := s.next()
+	switch {
+	case err == io.EOF:
+		err = status.Error(codes.Internal, "server sent no response message")
+	case err == nil:
+		if err = unmarshal(data, reply); err == nil {
+			if _, err = s.next(); err == nil {
+				err = status.Error(codes.Internal, "server sent more than one response message")
+			} else if err == io.EOF {
+				err = nil
+			}
+		}
+	}
+	return s.header, s.trailer, err
+}
+
+// connectUnary sends a unary Connect call: the message is the request
+// body, and trailers come back as Trailer- prefixed headers.
+func (c *webConn) connectUnary(ctx context.Context, method string, args, reply any) (metadata.MD, metadata.MD, error) {
+	opts := contextOptions(ctx)
+	data, err := marshal(args, opts.MaxSendMsgSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Gzip {
+		data = gzipBytes(data)
+	}
+	req, err := c.newRequest(ctx, method, bytes.NewReader(data), opts.Gzip, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, transportError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	header, trailer := metadata.MD{}, metadata.MD{}
+	for k, v := range resp.Header {
+		k = strings.ToLower(k)
+		if name, ok := strings.CutPrefix(k, "trailer-"); ok {
+			trailer[name] = v
+		} else {
+			header[k] = v
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return header, trailer, c.httpError(resp)
+	}
+	limit := maxRecvMsgSize(opts)
+	payload, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return header, trailer, transportError(ctx, err)
+	}
+	if len(payload) > limit {
+		return header, trailer, status.Errorf(codes.ResourceExhausted, "response is larger than the %d byte limit", limit)
+	}
+	return header, trailer, unmarshal(payload, reply)
+}
+
+// newRequest builds the HTTP request for a call, carrying the outgoing
+// metadata as headers.
+func (c *webConn) newRequest(ctx context.Context, method string, body io.Reader, compressed, stream bool) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+method, body)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for k, vals := range md {
+		for _, v := range vals {
+			if strings.HasSuffix(k, "-bin") {
+				v = base64.RawStdEncoding.EncodeToString([]byte(v))
+			}
+			req.Header.Add(k, v)
+		}
+	}
+
+	deadline, hasDeadline := ctx.Deadline()
+	timeout := max(time.Until(deadline).Milliseconds(), 1)
+	switch {
+	case c.transport == TransportGRPCWeb:
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		req.Header.Set("X-Grpc-Web", "1")
+		req.Header.Set("Grpc-Accept-Encoding", "gzip")
+		if compressed {
+			req.Header.Set("Grpc-Encoding", "gzip")
+		}
+		if hasDeadline {
+			req.Header.Set("Grpc-Timeout", fmt.Sprintf("%dm", timeout))
+		}
+		return req, nil
+	case stream:
+		req.Header.Set("Content-Type", "application/connect+proto")
+		req.Header.Set("Connect-Accept-Encoding", "gzip")
+		if compressed {
+			req.Header.Set("Connect-Content-Encoding", "gzip")
+		}
+	default:
+		req.Header.Set("Content-Type", "application/proto")
+		if compressed {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+	}
+	req.Header.Set("Connect-Protocol-Version", "1")
+	if hasDeadline {
+		req.Header.Set("Connect-Timeout-Ms", strconv.FormatInt(timeout, 10))
+	}
+	return req, nil
+}
+
+// httpError converts a non-200 response to a status error.
+func (c *webConn) httpError(resp *http.Response) error {
+	if c.transport == TransportGRPCWeb {
+		if resp.Header.Get("Grpc-Status") != "" {
+			return grpcWebStatus(headerMetadata(resp.Header))
+		}
+	} else {
+		var e connectError
+		if json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&e) == nil && e.Code != "" {
+			return status.Error(connectCode(e.Code), e.Message)
+		}
+	}
+	return status.Errorf(httpStatusCode(resp.StatusCode), "unexpected HTTP status %s", resp.Status)
+}
+
+// webStream is a half-duplex gRPC-Web or Connect stream.
+type webStream struct {
+	ctx    context.Context
+	conn   *webConn
+	method string
+	opts   protocol.GRPCCallOptions
+
+	mu      sync.Mutex
+	pending [][]byte // messages not yet posted
+	closed  bool
+	wake    chan struct{} // signalled on SendMsg and CloseSend
+
+	recvMu  sync.Mutex // serializes Header and RecvMsg
+	sent    bool       // at least one request was posted
+	resp    *http.Response
+	gzipped bool // response messages may be compressed
+	header  metadata.MD
+	trailer metadata.MD
+	err     error // terminal error; io.EOF is never stored
+}
+
+func (s *webStream) Context() context.Context { return s.ctx }
+
+func (s *webStream) Trailer() metadata.MD { return s.trailer }
+
+func (s *webStream) SendMsg(m any) error {
+	data, err := marshal(m, s.opts.MaxSendMsgSize)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return status.Error(codes.Internal, "SendMsg called after CloseSend")
+	}
+	s.pending = append(s.pending, data)
+	s.signal()
+	return nil
+}
+
+func (s *webStream) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.signal()
+	return nil
+}
+
+func (s *webStream) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the messages to post and whether the stream is closed.
+func (s *webStream) take() ([][]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	batch := s.pending
+	s.pending = nil
+	return batch, s.closed
+}
+
+// wait blocks until a message is sent or the stream is closed.
+func (s *webStream) wait() error {
+	select {
+	case <-s.wake:
+		return nil
+	case <-s.ctx.Done():
+		return status.FromContextError(s.ctx.Err()).Err()
+	}
+}
+
+// Header waits for the first request to be posted and returns the
+// response headers.
+func (s *webStream) Header() (metadata.MD, error) {
+	s.recvMu.Lock()
+	defer s.recvMu.Unlock()
+	for !s.sent && s.err == nil {
+		if batch, closed := s.take(); len(batch) > 0 || closed {
+			s.err = s.post(batch)
+		} else {
+			s.err = s.wait()
+		}
+	}
+	if s.header == nil {
+		return nil, s.err
+	}
+	return s.header, nil
+}
+
+func (s *webStream) RecvMsg(m any) error {
+	s.recvMu.Lock()
+	defer s.recvMu.Unlock()
+	data, err := s.next()
+	if err != nil {
+		return err
+	}
+	return unmarshal(data, m)
+}
+
+// next returns the next response message, posting buffered messages when
+// there is no response left to read. It returns io.EOF once the stream is
+// closed and every response ended with an OK status.
+func (s *webStream) next() ([]byte, error) {
+	for s.err == nil {
+		if s.resp == nil {
+			batch, closed := s.take()
+			switch {
+			case len(batch) > 0 || (closed && !s.sent):
+				s.err = s.post(batch)
+			case closed:
+				return nil, io.EOF
+			default:
+				s.err = s.wait()
+			}
+			continue
+		}
+		data, end, err := s.readMessage()
+		if err != nil || end {
+			s.resp.Body.Close()
+			s.resp = nil
+			s.err = err
+			continue
+		}
+		return data, nil
+	}
+	return nil, s.err
+}
+
+// post sends batch as one request.
+func (s *webStream) post(batch [][]byte) error {
+	var body bytes.Buffer
+	for _, data := range batch {
+		flags := byte(0)
+		if s.opts.Gzip {
+			flags, data = 1, gzipBytes(data)
+		}
+		prefix := [5]byte{flags}
+		binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+		body.Write(prefix[:])
+		body.Write(data)
+	}
+	req, err := s.conn.newRequest(s.ctx, s.method, &body, s.opts.Gzip, true)
+	if err != nil {
+		return err
+	}
+	resp, err := s.conn.client.Do(req)
+	if err != nil {
+		return transportError(s.ctx, err)
+	}
+	s.sent = true
+	s.header = headerMetadata(resp.Header)
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return s.conn.httpError(resp)
+	}
+	encoding := resp.Header.Get("Grpc-Encoding")
+	if s.conn.transport == TransportConnect {
+		encoding = resp.Header.Get("Connect-Content-Encoding")
+	}
+	s.gzipped = encoding == "gzip"
+	s.resp = resp
+	return nil
+}
+
+// readMessage reads the next frame of the current response. end is true
+// once the response is over, with err holding its non-OK status.
+func (s *webStream) readMessage() (data []byte, end bool, err error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(s.resp.Body, prefix[:]); err != nil {
+		if err != io.EOF {
+			return nil, false, transportError(s.ctx, err)
+		}
+		// No end-of-stream frame: a gRPC-Web server may send the status
+		// as HTTP trailers, or as headers when there is no body at all.
+		if s.conn.transport == TransportGRPCWeb {
+			md := headerMetadata(s.resp.Trailer)
+			if len(md.Get("grpc-status")) == 0 {
+				md = s.header
+			}
+			s.trailer = trailerMetadata(md)
+			return nil, true, grpcWebStatus(md)
+		}
+		return nil, true, status.Error(codes.Internal, "server closed the stream without an end-of-stream message")
+	}
+
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if limit := maxRecvMsgSize(s.opts); int64(size) > int64(limit) {
+		return nil, false, status.Errorf(codes.ResourceExhausted, "message is %d bytes, larger than the %d byte limit", size, limit)
+	}
+	data = make([]byte, size)
+	if _, err := io.ReadFull(s.resp.Body, data); err != nil {
+		return nil, false, transportError(s.ctx, err)
+	}
+	if prefix[0]&1 != 0 {
+		if !s.gzipped {
+			return nil, false, status.Error(codes.Internal, "server sent a compressed message without an encoding")
+		}
+		if data, err = gunzipBytes(data); err != nil {
+			return nil, false, status.Errorf(codes.Internal, "decompressing message: %v", err)
+		}
+	}
+
+	switch {
+	case s.conn.transport == TransportGRPCWeb && prefix[0]&0x80 != 0:
+		md := parseGRPCWebTrailers(data)
+		s.trailer = trailerMetadata(md)
+		return nil, true, grpcWebStatus(md)
+	case s.conn.transport == TransportConnect && prefix[0]&0x02 != 0:
+		var endStream struct {
+			Error    *connectError       `json:"error"`
+			Metadata map[string][]string `json:"metadata"`
+		}
+		if err := json.Unmarshal(data, &endStream); err != nil {
+			return nil, true, status.Errorf(codes.Internal, "invalid end-of-stream message: %v", err)
+		}
+		s.trailer = metadata.MD{}
+		for k, v := range endStream.Metadata {
+			s.trailer[strings.ToLower(k)] = v
+		}
+		if endStream.Error != nil {
+			return nil, true, status.Error(connectCode(endStream.Error.Code), endStream.Error.Message)
+		}
+		return nil, true, nil
+	}
+	return data, false, nil
+}
+
+// connectError is the JSON error of the Connect protocol.
+type connectError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var connectCodes = map[string]codes.Code{
+	"canceled":            codes.Canceled,
+	"unknown":             codes.Unknown,
+	"invalid_argument":    codes.InvalidArgument,
+	"deadline_exceeded":   codes.DeadlineExceeded,
+	"not_found":           codes.NotFound,
+	"already_exists":      codes.AlreadyExists,
+	"permission_denied":   codes.PermissionDenied,
+	"resource_exhausted":  codes.ResourceExhausted,
+	"failed_precondition": codes.FailedPrecondition,
+	"aborted":             codes.Aborted,
+	"out_of_range":        codes.OutOfRange,
+	"unimplemented":       codes.Unimplemented,
+	"internal":            codes.Internal,
+	"unavailable":         codes.Unavailable,
+	"data_loss":           codes.DataLoss,
+	"unauthenticated":     codes.Unauthenticated,
+}
+
+func connectCode(code string) codes.Code {
+	if c, ok := connectCodes[code]; ok {
+		return c
+	}
+	return codes.Unknown
+}
+
+// httpStatusCode maps the HTTP status of a failed call to a gRPC code, as
+// both the gRPC-Web and Connect specs do.
+func httpStatusCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.Internal
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+// grpcWebStatus reads the status from gRPC-Web trailers. It returns nil
+// for OK.
+func grpcWebStatus(md metadata.MD) error {
+	vals := md.Get("grpc-status")
+	if len(vals) == 0 {
+		return status.Error(codes.Internal, "server sent no grpc-status")
+	}
+	code, err := strconv.Atoi(vals[0])
+	if err != nil {
+		return status.Errorf(codes.Internal, "invalid grpc-status %q", vals[0])
+	}
+	var msg string
+	if vals := md.Get("grpc-message"); len(vals) > 0 {
+		msg = vals[0]
+		if unescaped, err := url.PathUnescape(msg); err == nil {
+			msg = unescaped
+		}
+	}
+	return status.Error(codes.Code(code), msg)
+}
+
+// parseGRPCWebTrailers parses the "name: value" lines of a trailer frame.
+func parseGRPCWebTrailers(data []byte) metadata.MD {
+	md := metadata.MD{}
+	for _, line := range strings.Split(string(data), "\r\n") {
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			md.Append(strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v))
+		}
+	}
+	return md
+}
+
+// trailerMetadata drops the status fields, which native gRPC doesn't
+// report as trailers either.
+func trailerMetadata(md metadata.MD) metadata.MD {
+	out := md.Copy()
+	delete(out, "grpc-status")
+	delete(out, "grpc-message")
+	return out
+}
+
+func headerMetadata(h http.Header) metadata.MD {
+	md := metadata.MD{}
+	for k, v := range h {
+		md[strings.ToLower(k)] = v
+	}
+	return md
+}
+
+// contextOptions returns the call options attached by withCallOptions.
+func contextOptions(ctx context.Context) protocol.GRPCCallOptions {
+	opts, _ := ctx.Value(callOptionsKey{}).(protocol.GRPCCallOptions)
+	return opts
+}
+
+func maxRecvMsgSize(opts protocol.GRPCCallOptions) int {
+	if opts.MaxRecvMsgSize > 0 {
+		return opts.MaxRecvMsgSize
+	}
+	return defaultMaxRecvMsgSize
+}
+
+// transportError converts an HTTP error to a status error.
+func transportError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
+
+func marshal(m any, limit int) ([]byte, error) {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "cannot marshal %T", m)
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "marshaling request: %v", err)
+	}
+	if limit > 0 && len(data) > limit {
+		return nil, status.Errorf(codes.ResourceExhausted, "message is %d bytes, larger than the %d byte limit", len(data), limit)
+	}
+	return data, nil
+}
+
+func unmarshal(data []byte, m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "cannot unmarshal into %T", m)
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return status.Errorf(codes.Internal, "unmarshaling response: %v", err)
+	}
+	return nil
+}
+
+func gzipBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+func gunzipBytes(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
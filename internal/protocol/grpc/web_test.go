@@ -0,0 +1,268 @@
+package grpc
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// rawCodec passes messages through as bytes, so the bridge below can
+// forward calls without knowing their types.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) { return *v.(*[]byte), nil }
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
+
+// webBridge serves gRPC-Web and Connect by forwarding each call to a
+// native gRPC server, like an Envoy or Connect proxy would. It records the
+// headers of the last call.
+type webBridge struct {
+	conn    *grpc.ClientConn
+	headers http.Header
+}
+
+func (b *webBridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.headers = r.Header.Clone()
+	ct := r.Header.Get("Content-Type")
+	body, _ := io.ReadAll(r.Body)
+
+	var in [][]byte
+	if ct == "application/proto" {
+		in = [][]byte{body}
+	} else {
+		for len(body) >= 5 {
+			n := binary.BigEndian.Uint32(body[1:5])
+			in = append(in, body[5:5+n])
+			body = body[5+n:]
+		}
+	}
+
+	ctx := metadata.NewOutgoingContext(r.Context(), metadata.Pairs("x-tenant", r.Header.Get("X-Tenant")))
+	stream, err := b.conn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}, r.URL.Path, grpc.ForceCodec(rawCodec{}))
+	var out [][]byte
+	if err == nil {
+		for _, msg := range in {
+			if err = stream.SendMsg(&msg); err != nil {
+				break
+			}
+		}
+		_ = stream.CloseSend()
+		for {
+			var msg []byte
+			if err = stream.RecvMsg(&msg); err != nil {
+				break
+			}
+			out = append(out, msg)
+		}
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	st := status.Convert(err)
+	connectErr := fmt.Sprintf(`{"code":%q,"message":%q}`, strings.ToLower(snake(st.Code().String())), st.Message())
+
+	frame := func(flags byte, data []byte) {
+		prefix := [5]byte{flags}
+		binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+		w.Write(prefix[:])
+		w.Write(data)
+	}
+	switch ct {
+	case "application/proto":
+		if st.Code() != codes.OK {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotImplemented)
+			io.WriteString(w, connectErr)
+			return
+		}
+		w.Header().Set("Content-Type", "application/proto")
+		w.Header().Set("Trailer-X-Served-By", "bridge")
+		w.Write(out[0])
+	case "application/connect+proto":
+		w.Header().Set("Content-Type", ct)
+		for _, msg := range out {
+			frame(0, msg)
+		}
+		end := `{"metadata":{"x-served-by":["bridge"]}}`
+		if st.Code() != codes.OK {
+			end = `{"error":` + connectErr + `}`
+		}
+		frame(2, []byte(end))
+	default:
+		w.Header().Set("Content-Type", "application/grpc-web+proto")
+		for _, msg := range out {
+			frame(0, msg)
+		}
+		frame(0x80, []byte(fmt.Sprintf("grpc-status: %d\r\ngrpc-message: %s\r\nx-served-by: bridge\r\n", st.Code(), st.Message())))
+	}
+}
+
+// snake converts a code name like InvalidArgument to invalid_argument.
+func snake(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// startWebBridge runs a native server with Health and reflection behind a
+// gRPC-Web/Connect bridge and returns the bridge.
+func startWebBridge(t *testing.T) (*webBridge, string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, &healthServer{})
+	reflection.Register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	bridge := &webBridge{conn: conn}
+	ts := httptest.NewServer(bridge)
+	t.Cleanup(ts.Close)
+	return bridge, ts.URL
+}
+
+func TestResolveTransport(t *testing.T) {
+	tests := []struct{ transport, addr, want string }{
+		{"", "localhost:50051", TransportGRPC},
+		{"", "grpc://localhost:50051", TransportGRPC},
+		{"", "http://localhost:8080", TransportGRPCWeb},
+		{"", "https://api.example.com", TransportGRPCWeb},
+		{TransportConnect, "https://api.example.com", TransportConnect},
+		{TransportGRPC, "http://localhost:50051", TransportGRPC},
+	}
+	for _, tt := range tests {
+		if got := ResolveTransport(tt.transport, tt.addr); got != tt.want {
+			t.Errorf("ResolveTransport(%q, %q) = %q, want %q", tt.transport, tt.addr, got, tt.want)
+		}
+	}
+
+	if err := New().Validate(&protocol.Request{URL: "x", GRPCService: "s", GRPCMethod: "m", GRPCTransport: "websocket"}); err == nil {
+		t.Error("expected an error for an unknown transport")
+	}
+}
+
+func TestExecuteWebTransports(t *testing.T) {
+	bridge, url := startWebBridge(t)
+	client := New()
+	defer client.Close()
+
+	for _, transport := range []string{"", TransportConnect} {
+		name := transportName(ResolveTransport(transport, url))
+		t.Run(name, func(t *testing.T) {
+			req := &protocol.Request{
+				Protocol:      "grpc",
+				URL:           url,
+				GRPCService:   "grpc.health.v1.Health",
+				GRPCMethod:    "Check",
+				GRPCTransport: transport,
+				Metadata:      map[string]string{"x-tenant": "acme"},
+				Body:          []byte(`{"service": ""}`),
+			}
+			resp, err := client.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+			if resp.StatusCode != 200 || resp.Proto != name {
+				t.Errorf("got %s over %s", resp.Status, resp.Proto)
+			}
+			if !strings.Contains(string(resp.Body), "SERVING") {
+				t.Errorf("body = %s", resp.Body)
+			}
+			if bridge.headers.Get("X-Tenant") != "acme" {
+				t.Errorf("metadata not sent as headers: %v", bridge.headers)
+			}
+			if resp.Headers.Get("trailer-x-served-by") != "bridge" {
+				t.Errorf("trailers = %v", resp.Headers)
+			}
+
+			// Watch is server-streaming and unimplemented: the status comes
+			// back in the end-of-stream message.
+			req.GRPCMethod = "Watch"
+			resp, err = client.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Execute(Watch) error: %v", err)
+			}
+			if resp.StatusCode != 501 || resp.Headers.Get("grpc-status") != "Unimplemented" {
+				t.Errorf("Watch: got %s, headers %v", resp.Status, resp.Headers)
+			}
+		})
+	}
+
+	// Unary Connect errors come back as JSON with an HTTP status
+	conn := newWebConn(TransportConnect, url)
+	var reply healthpb.HealthCheckResponse
+	err := conn.Invoke(context.Background(), "/grpc.health.v1.Health/Nope", &healthpb.HealthCheckRequest{}, &reply)
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("unknown method over Connect: err = %v", err)
+	}
+	if got := bridge.headers.Get("Connect-Protocol-Version"); got != "1" {
+		t.Errorf("Connect-Protocol-Version = %q", got)
+	}
+}
+
+func TestDiscoverServicesOverGRPCWeb(t *testing.T) {
+	_, url := startWebBridge(t)
+
+	// Reflection is a bidi stream, which gRPC-Web runs one batch at a time
+	for _, transport := range []string{"", TransportConnect} {
+		if _, err := DiscoverServices(context.Background(), url, transport); err != nil {
+			t.Errorf("DiscoverServices(%q) error: %v", transport, err)
+		}
+	}
+}
+
+func TestConnectEndOfStreamError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		end, _ := json.Marshal(map[string]any{"error": map[string]string{"code": "permission_denied", "message": "no"}})
+		prefix := [5]byte{2}
+		binary.BigEndian.PutUint32(prefix[1:], uint32(len(end)))
+		w.Write(prefix[:])
+		w.Write(end)
+	}))
+	defer ts.Close()
+
+	stream, _ := newWebConn(TransportConnect, ts.URL).NewStream(context.Background(), nil, "/svc/Method")
+	_ = stream.CloseSend()
+	var reply healthpb.HealthCheckResponse
+	err := stream.RecvMsg(&reply)
+	if st := status.Convert(err); st.Code() != codes.PermissionDenied || st.Message() != "no" {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package protocol
+
+import (
+	"context"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Hosts overrides where connections to a host go, like an /etc/hosts entry
+// scoped to gottp. Keys are host names, optionally with a port; values are
+// the address to dial instead, with or without a port:
+//
+//	api.example.com: 127.0.0.1           # keeps the request's port
+//	api.example.com:443: localhost:8443  # only for port 443
+//
+// The request's Host header and TLS server name stay those of the URL.
+type Hosts map[string]string
+
+// Resolve returns the address to dial for addr (host:port): the override
+// for its host and port, else for its host, else addr unchanged.
+func (h Hosts) Resolve(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	host = strings.ToLower(host)
+	target, ok := h.lookup(net.JoinHostPort(host, port))
+	if !ok {
+		if target, ok = h.lookup(host); !ok {
+			return addr
+		}
+	}
+	if _, _, err := net.SplitHostPort(target); err == nil {
+		return target
+	}
+	return net.JoinHostPort(strings.Trim(target, "[]"), port)
+}
+
+// lookup finds key ignoring case, so entries may be written as in URLs.
+func (h Hosts) lookup(key string) (string, bool) {
+	if v, ok := h[key]; ok {
+		return v, true
+	}
+	for k, v := range h {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// String renders the overrides in a stable order, for display and for
+// telling transports with different overrides apart.
+func (h Hosts) String() string {
+	var parts []string
+	for _, k := range slices.Sorted(maps.Keys(h)) {
+		parts = append(parts, k+"="+h[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+// Apply makes t dial overridden hosts at their new address. It wraps any
+// dialer already set, such as a SOCKS5 proxy's, so apply the proxy first.
+// Connections through an HTTP proxy are to the proxy, which resolves the
+// request's host itself.
+func (h Hosts) Apply(t *http.Transport) {
+	if len(h) == 0 {
+		return
+	}
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dial(ctx, network, h.Resolve(addr))
+	}
+}
+
+// Transport returns rt with the overrides applied, copying it first so a
+// shared transport such as http.DefaultTransport is left alone. rt is
+// returned as is when there are no overrides or it is not an
+// *http.Transport.
+func (h Hosts) Transport(rt http.RoundTripper) http.RoundTripper {
+	t, ok := rt.(*http.Transport)
+	if len(h) == 0 || !ok {
+		return rt
+	}
+	t = t.Clone()
+	h.Apply(t)
+	return t
+}
@@ -0,0 +1,61 @@
+package protocol
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHosts_Resolve(t *testing.T) {
+	h := Hosts{
+		"api.example.com":     "127.0.0.1",
+		"api.example.com:443": "localhost:8443",
+		"Web.Example.com":     "::1",
+		"db.internal":         "[fd00::5]:5433",
+	}
+	cases := map[string]string{
+		"api.example.com:80":  "127.0.0.1:80",
+		"api.example.com:443": "localhost:8443",
+		"API.example.com:443": "localhost:8443",
+		"web.example.com:80":  "[::1]:80",
+		"db.internal:5432":    "[fd00::5]:5433",
+		"other.example.com:1": "other.example.com:1",
+		"no-port":             "no-port",
+	}
+	for addr, want := range cases {
+		if got := h.Resolve(addr); got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", addr, got, want)
+		}
+	}
+	if got := h.String(); got != "Web.Example.com=::1,api.example.com=127.0.0.1,api.example.com:443=localhost:8443,db.internal=[fd00::5]:5433" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestHosts_Transport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Host)
+	}))
+	defer srv.Close()
+
+	h := Hosts{"staging.example.test": strings.TrimPrefix(srv.URL, "http://")}
+	rt := h.Transport(http.DefaultTransport)
+	if rt == http.DefaultTransport {
+		t.Fatal("the default transport should be copied, not changed")
+	}
+	resp, err := (&http.Client{Transport: rt}).Get("http://staging.example.test/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "staging.example.test" {
+		t.Errorf("server saw Host %q, want the original host", body)
+	}
+
+	if Hosts(nil).Transport(http.DefaultTransport) != http.DefaultTransport {
+		t.Error("no overrides should keep the transport")
+	}
+}
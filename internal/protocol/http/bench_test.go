@@ -323,7 +323,7 @@ func BenchmarkBuildTransport(b *testing.B) {
 		b.ReportAllocs()
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_, err := client.buildTransport("")
+			_, err := client.buildTransport(nil)
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -336,7 +336,7 @@ func BenchmarkBuildTransport(b *testing.B) {
 		b.ReportAllocs()
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_, err := client.buildTransport("")
+			_, err := client.buildTransport(nil)
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -349,7 +349,7 @@ func BenchmarkBuildTransport(b *testing.B) {
 		b.ReportAllocs()
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_, err := client.buildTransport("")
+			_, err := client.buildTransport(nil)
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -361,7 +361,7 @@ func BenchmarkBuildTransport(b *testing.B) {
 		b.ReportAllocs()
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_, err := client.buildTransport("http://override-proxy.example.com:3128")
+			_, err := client.buildTransport(&protocol.ProxyConfig{URL: "http://override-proxy.example.com:3128"})
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -374,7 +374,7 @@ func BenchmarkParseNoProxy(b *testing.B) {
 		b.ReportAllocs()
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_ = parseNoProxy("localhost,127.0.0.1")
+			_ = protocol.ParseNoProxy("localhost,127.0.0.1")
 		}
 	})
 
@@ -388,19 +388,19 @@ func BenchmarkParseNoProxy(b *testing.B) {
 		b.ReportAllocs()
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_ = parseNoProxy(hosts)
+			_ = protocol.ParseNoProxy(hosts)
 		}
 	})
 }
 
 func BenchmarkShouldBypassProxy(b *testing.B) {
-	hosts := parseNoProxy("localhost,127.0.0.1,.internal.corp,.dev.local,api.example.com")
+	hosts := protocol.ParseNoProxy("localhost,127.0.0.1,.internal.corp,.dev.local,api.example.com")
 
 	b.Run("Match", func(b *testing.B) {
 		b.ReportAllocs()
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_ = shouldBypassProxy("api.example.com", hosts)
+			_ = protocol.BypassProxy("api.example.com", hosts)
 		}
 	})
 
@@ -408,7 +408,7 @@ func BenchmarkShouldBypassProxy(b *testing.B) {
 		b.ReportAllocs()
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_ = shouldBypassProxy("service.internal.corp", hosts)
+			_ = protocol.BypassProxy("service.internal.corp", hosts)
 		}
 	})
 
@@ -416,7 +416,7 @@ func BenchmarkShouldBypassProxy(b *testing.B) {
 		b.ReportAllocs()
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_ = shouldBypassProxy("external.service.io", hosts)
+			_ = protocol.BypassProxy("external.service.io", hosts)
 		}
 	})
 }
@@ -0,0 +1,187 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// requestBody is an encoded request body. File contents are streamed from
+// disk; data is only set for bodies held in memory.
+type requestBody struct {
+	reader      io.ReadCloser
+	data        []byte
+	length      int64
+	contentType string
+	force       bool // replace a user-set Content-Type (form encodings)
+}
+
+// newBody encodes the body of req. Files are opened here and closed by the
+// transport once the body has been sent.
+func newBody(req *protocol.Request) (*requestBody, error) {
+	switch {
+	case req.BodyFile != "":
+		f, err := os.Open(req.BodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("opening body file: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("reading body file: %w", err)
+		}
+		return &requestBody{reader: f, length: info.Size(), contentType: fileContentType(req.BodyFile)}, nil
+	case len(req.Form) > 0 && req.Multipart:
+		return multipartBody(req.Form)
+	case len(req.Form) > 0:
+		parts := make([]string, 0, len(req.Form))
+		for _, f := range req.Form {
+			parts = append(parts, url.QueryEscape(f.Name)+"="+url.QueryEscape(f.Value))
+		}
+		b := memoryBody([]byte(strings.Join(parts, "&")))
+		b.contentType, b.force = "application/x-www-form-urlencoded", true
+		return b, nil
+	case len(req.Body) > 0:
+		return memoryBody(req.Body), nil
+	}
+	return &requestBody{}, nil
+}
+
+func memoryBody(data []byte) *requestBody {
+	return &requestBody{
+		reader: io.NopCloser(bytes.NewReader(data)),
+		data:   data,
+		length: int64(len(data)),
+	}
+}
+
+// apply attaches the body to httpReq and sets its Content-Type.
+func (b *requestBody) apply(httpReq *http.Request) {
+	if b.reader == nil {
+		return
+	}
+	httpReq.Body = b.reader
+	httpReq.ContentLength = b.length
+	if b.data != nil {
+		data := b.data
+		httpReq.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+	if b.contentType != "" && (b.force || httpReq.Header.Get("Content-Type") == "") {
+		httpReq.Header.Set("Content-Type", b.contentType)
+	}
+}
+
+// multipartBody frames fields as multipart/form-data. The part headers are
+// rendered up front so the exact Content-Length is known while file
+// contents are still streamed.
+func multipartBody(fields []protocol.FormField) (*requestBody, error) {
+	var (
+		buf     bytes.Buffer
+		readers []io.Reader
+		files   []io.Closer
+		length  int64
+	)
+	closeFiles := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	flush := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		chunk := append([]byte(nil), buf.Bytes()...)
+		readers = append(readers, bytes.NewReader(chunk))
+		length += int64(len(chunk))
+		buf.Reset()
+	}
+
+	mw := multipart.NewWriter(&buf)
+	for _, field := range fields {
+		if field.File == "" {
+			if err := mw.WriteField(field.Name, field.Value); err != nil {
+				closeFiles()
+				return nil, fmt.Errorf("encoding field %s: %w", field.Name, err)
+			}
+			continue
+		}
+
+		f, err := os.Open(field.File)
+		if err != nil {
+			closeFiles()
+			return nil, fmt.Errorf("opening file for field %s: %w", field.Name, err)
+		}
+		files = append(files, f)
+		info, err := f.Stat()
+		if err != nil {
+			closeFiles()
+			return nil, fmt.Errorf("reading file for field %s: %w", field.Name, err)
+		}
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(field.Name), quoteEscaper.Replace(filepath.Base(field.File))))
+		h.Set("Content-Type", fileContentType(field.File))
+		if _, err := mw.CreatePart(h); err != nil {
+			closeFiles()
+			return nil, fmt.Errorf("encoding field %s: %w", field.Name, err)
+		}
+		flush()
+		readers = append(readers, f)
+		length += info.Size()
+	}
+	if err := mw.Close(); err != nil {
+		closeFiles()
+		return nil, fmt.Errorf("encoding multipart body: %w", err)
+	}
+	flush()
+
+	b := &requestBody{
+		reader:      &multiReadCloser{Reader: io.MultiReader(readers...), closers: files},
+		length:      length,
+		contentType: mw.FormDataContentType(),
+		force:       true,
+	}
+	if len(files) == 0 {
+		b.data, _ = io.ReadAll(b.reader)
+		b.reader = io.NopCloser(bytes.NewReader(b.data))
+	}
+	return b, nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// fileContentType guesses a file's media type from its extension.
+func fileContentType(path string) string {
+	if ct := mime.TypeByExtension(filepath.Ext(path)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+type multiReadCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiReadCloser) Close() error {
+	var first error
+	for _, c := range m.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+func TestExecute_MultipartStreamsFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("file contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotTitle, gotFile, gotName, gotType string
+	var gotLen int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLen = r.ContentLength
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parsing multipart: %v", err)
+			return
+		}
+		gotTitle = r.FormValue("title")
+		f, hdr, err := r.FormFile("upload")
+		if err != nil {
+			t.Errorf("reading upload: %v", err)
+			return
+		}
+		defer f.Close()
+		data, _ := io.ReadAll(f)
+		gotFile, gotName, gotType = string(data), hdr.Filename, hdr.Header.Get("Content-Type")
+	}))
+	defer srv.Close()
+
+	resp, err := New().Execute(context.Background(), &protocol.Request{
+		Method:    "POST",
+		URL:       srv.URL,
+		Headers:   map[string]string{"Content-Type": "application/json"},
+		Multipart: true,
+		Form: []protocol.FormField{
+			{Name: "title", Value: "hello"},
+			{Name: "upload", File: path},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if gotTitle != "hello" || gotFile != "file contents" || gotName != "notes.txt" {
+		t.Fatalf("unexpected form: title=%q file=%q name=%q", gotTitle, gotFile, gotName)
+	}
+	if !strings.HasPrefix(gotType, "text/plain") {
+		t.Fatalf("expected text/plain part, got %q", gotType)
+	}
+	if gotLen <= 0 {
+		t.Fatalf("expected an exact Content-Length, got %d", gotLen)
+	}
+	if !strings.Contains(resp.RawRequest, "multipart/form-data; boundary=") || !strings.Contains(resp.RawRequest, "body omitted") {
+		t.Fatalf("unexpected raw request:\n%s", resp.RawRequest)
+	}
+}
+
+func TestExecute_FormURLEncoded(t *testing.T) {
+	var gotType string
+	var gotForm map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotType = r.Header.Get("Content-Type")
+		_ = r.ParseForm()
+		gotForm = r.PostForm
+	}))
+	defer srv.Close()
+
+	_, err := New().Execute(context.Background(), &protocol.Request{
+		Method:  "POST",
+		URL:     srv.URL,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Form:    []protocol.FormField{{Name: "q", Value: "a b&c"}, {Name: "n", Value: "1"}},
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if gotType != "application/x-www-form-urlencoded" {
+		t.Fatalf("expected urlencoded content type, got %q", gotType)
+	}
+	if gotForm["q"][0] != "a b&c" || gotForm["n"][0] != "1" {
+		t.Fatalf("unexpected form: %v", gotForm)
+	}
+}
+
+func TestExecute_BinaryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blob.bin")
+	data := []byte(strings.Repeat("x", maxRawBody+1))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotLen int64
+	var gotType string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLen, gotType = r.ContentLength, r.Header.Get("Content-Type")
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	resp, err := New().Execute(context.Background(), &protocol.Request{
+		Method:   "PUT",
+		URL:      srv.URL,
+		BodyFile: path,
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if gotLen != int64(len(data)) || len(gotBody) != len(data) {
+		t.Fatalf("expected %d bytes, got length=%d body=%d", len(data), gotLen, len(gotBody))
+	}
+	if gotType != "application/octet-stream" {
+		t.Fatalf("expected octet-stream, got %q", gotType)
+	}
+	if !strings.Contains(resp.RawRequest, "body omitted") {
+		t.Fatalf("streamed body should be omitted from raw request:\n%s", resp.RawRequest)
+	}
+}
+
+func TestExecute_MissingBodyFile(t *testing.T) {
+	_, err := New().Execute(context.Background(), &protocol.Request{
+		Method:   "POST",
+		URL:      "http://127.0.0.1:1",
+		BodyFile: filepath.Join(t.TempDir(), "missing.bin"),
+	})
+	if err == nil || !strings.Contains(err.Error(), "opening body file") {
+		t.Fatalf("expected body file error, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/sadopc/gottp/internal/core/httpcache"
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// addValidators adds conditional headers from the response cache to
+// httpReq, reporting whether it did. Requests that bypass the cache or
+// set their own validators are sent as they are.
+func (c *Client) addValidators(httpReq *http.Request, req *protocol.Request) bool {
+	if c.cache == nil || req.NoCache {
+		return false
+	}
+	return c.cache.Conditional(httpReq.Method, httpReq.URL.String(), httpReq.Header)
+}
+
+// updateCache stores resp for later revalidation or, when the server
+// answered 304 to validators added by addValidators, fills resp in from
+// the cached response.
+func (c *Client) updateCache(httpReq *http.Request, req *protocol.Request, resp *protocol.Response, conditional bool) {
+	if c.cache == nil || req.NoCache {
+		return
+	}
+	method, url := httpReq.Method, httpReq.URL.String()
+
+	if resp.StatusCode == http.StatusNotModified {
+		if !conditional {
+			return
+		}
+		entry, ok := c.cache.Revalidated(method, url, resp.Headers)
+		if !ok {
+			return
+		}
+		resp.StatusCode = entry.StatusCode
+		resp.Status = entry.Status
+		resp.Headers = entry.Headers
+		resp.Body = entry.Body
+		resp.ContentType = entry.Headers.Get("Content-Type")
+		resp.Size = int64(len(entry.Body))
+		resp.FromCache = true
+		return
+	}
+
+	if resp.BodyFile != "" {
+		// Only a preview is in memory, so there is nothing to serve later
+		c.cache.Delete(httpcache.Key(method, url))
+		return
+	}
+	c.cache.Store(method, url, httpReq.Header, resp.StatusCode, resp.Status, resp.Headers, resp.Body)
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/httpcache"
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+func TestExecute_RevalidatesCachedResponse(t *testing.T) {
+	var conditional []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.Header().Set("X-Fresh", "yes")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer srv.Close()
+
+	cache := httpcache.New()
+	c := New()
+	c.SetCache(cache)
+	req := &protocol.Request{Method: "GET", URL: srv.URL + "/users/1", Protocol: "http"}
+
+	first, err := c.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.FromCache || string(first.Body) != `{"id":1}` {
+		t.Fatalf("first response = %+v", first)
+	}
+
+	second, err := c.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.FromCache || second.StatusCode != http.StatusOK || string(second.Body) != `{"id":1}` {
+		t.Fatalf("second response: from cache %v, status %d, body %q", second.FromCache, second.StatusCode, second.Body)
+	}
+	if second.ContentType != "application/json" || second.Headers.Get("X-Fresh") != "yes" {
+		t.Errorf("headers not merged: %v", second.Headers)
+	}
+	if entries := cache.Entries(); len(entries) != 1 || entries[0].Hits != 1 {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	// Bypassing the cache sends no validator and leaves the entry alone
+	bypass := *req
+	bypass.NoCache = true
+	third, err := c.Execute(context.Background(), &bypass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.FromCache {
+		t.Error("bypassed request served from cache")
+	}
+	want := []string{"", `"v1"`, ""}
+	for i := range want {
+		if conditional[i] != want[i] {
+			t.Fatalf("If-None-Match sent = %q, want %q", conditional, want)
+		}
+	}
+}
+
+func TestExecute_UserValidatorsPassThrough(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	c := New()
+	c.SetCache(httpcache.New())
+	if _, err := c.Execute(context.Background(), &protocol.Request{Method: "GET", URL: srv.URL, Protocol: "http"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A request that sets its own If-None-Match sees the real 304
+	resp, err := c.Execute(context.Background(), &protocol.Request{
+		Method: "GET", URL: srv.URL, Protocol: "http",
+		Headers: map[string]string{"If-None-Match": `"v0"`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNotModified || resp.FromCache {
+		t.Fatalf("status %d, from cache %v", resp.StatusCode, resp.FromCache)
+	}
+}
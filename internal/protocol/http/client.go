@@ -1,15 +1,14 @@
 package http
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"fmt"
-	"io"
-	"net"
 	"net/http"
 	"net/http/httptrace"
+	"net/http/httputil"
+	"net/textproto"
 	"net/url"
 	"strings"
 	"time"
@@ -17,15 +16,12 @@ import (
 	"github.com/sadopc/gottp/internal/auth/awsv4"
 	"github.com/sadopc/gottp/internal/auth/digest"
 	"github.com/sadopc/gottp/internal/core/cookies"
+	"github.com/sadopc/gottp/internal/core/httpcache"
 	"github.com/sadopc/gottp/internal/protocol"
-	"golang.org/x/net/proxy"
 )
 
 // ProxyConfig holds proxy settings.
-type ProxyConfig struct {
-	URL     string // http://, https://, or socks5:// proxy URL
-	NoProxy string // comma-separated list of hosts to bypass proxy
-}
+type ProxyConfig = protocol.ProxyConfig
 
 // Client implements the HTTP protocol.
 type Client struct {
@@ -33,6 +29,13 @@ type Client struct {
 	proxyConf  *ProxyConfig
 	cookieJar  *cookies.Jar
 	tlsConfig  *tls.Config
+	cache      *httpcache.Cache
+	pool       pool
+	poolOpts   PoolOptions
+
+	// Bodies larger than downloadThreshold are streamed to downloadDir
+	downloadThreshold int64
+	downloadDir       string
 }
 
 // New creates a new HTTP client.
@@ -50,6 +53,13 @@ func New() *Client {
 	}
 }
 
+// Close closes idle keep-alive connections.
+func (c *Client) Close() error {
+	c.httpClient.CloseIdleConnections()
+	c.resetPool()
+	return nil
+}
+
 // SetTimeout sets the default client timeout.
 func (c *Client) SetTimeout(d time.Duration) {
 	c.httpClient.Timeout = d
@@ -57,6 +67,7 @@ func (c *Client) SetTimeout(d time.Duration) {
 
 // SetProxy configures proxy settings for the client.
 func (c *Client) SetProxy(proxyURL, noProxy string) {
+	defer c.resetPool()
 	if proxyURL == "" {
 		c.proxyConf = nil
 		return
@@ -69,9 +80,23 @@ func (c *Client) SetCookieJar(jar *cookies.Jar) {
 	c.cookieJar = jar
 }
 
+// SetCache makes GET requests revalidate earlier responses with
+// If-None-Match / If-Modified-Since and serve the cached body on 304.
+func (c *Client) SetCache(cache *httpcache.Cache) {
+	c.cache = cache
+}
+
 // SetTLSConfig sets the TLS configuration for mTLS and certificate management.
 func (c *Client) SetTLSConfig(cfg *tls.Config) {
 	c.tlsConfig = cfg
+	c.resetPool()
+}
+
+// SetDownloadThreshold streams response bodies larger than n bytes to a file
+// in dir (DefaultDownloadDir when empty). Zero keeps every body in memory.
+func (c *Client) SetDownloadThreshold(n int64, dir string) {
+	c.downloadThreshold = n
+	c.downloadDir = dir
 }
 
 func (c *Client) Name() string { return "http" }
@@ -108,14 +133,8 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 		u.RawQuery = q.Encode()
 	}
 
-	// Build body
-	var body io.Reader
-	if len(req.Body) > 0 {
-		body = bytes.NewReader(req.Body)
-	}
-
 	// Create HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, req.Method, u.String(), body)
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, u.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
@@ -125,8 +144,18 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 		httpReq.Header.Set(k, v)
 	}
 
+	// Build body; files are streamed, not read into memory
+	body, err := newBody(req)
+	if err != nil {
+		return nil, err
+	}
+	body.apply(httpReq)
+
 	// Apply auth
-	applyAuth(httpReq, req.Auth, req.Body)
+	applyAuth(httpReq, req.Auth, body.data)
+	applyFraming(httpReq, req)
+	conditional := c.addValidators(httpReq, req)
+	rawRequest := dumpRequest(httpReq, body)
 
 	// Set timeout
 	timeout := req.Timeout
@@ -134,10 +163,17 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 		timeout = 30 * time.Second
 	}
 
-	// Build transport with proxy and TLS settings
-	transport, err := c.buildTransport(req.ProxyURL)
+	// Share a transport with earlier requests using the same proxy and TLS
+	// settings, so their kept-alive connections can be reused
+	transport, err := c.transportFor(req)
 	if err != nil {
-		return nil, fmt.Errorf("configuring transport: %w", err)
+		if httpReq.Body != nil {
+			httpReq.Body.Close()
+		}
+		return nil, err
+	}
+	if c.poolOpts.ForceClose {
+		transport.CloseIdleConnections()
 	}
 
 	client := &http.Client{
@@ -154,6 +190,10 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 	// Set up httptrace for detailed timing
 	var dnsStart, connStart, tlsStart, gotConn, gotFirstByte time.Time
 	var dnsDuration, connDuration, tlsDuration time.Duration
+	var interim []protocol.InterimResponse
+	var reused bool
+	var idle time.Duration
+	var remoteAddr string
 
 	trace := &httptrace.ClientTrace{
 		DNSStart: func(_ httptrace.DNSStartInfo) {
@@ -174,12 +214,24 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 		TLSHandshakeDone: func(_ tls.ConnectionState, _ error) {
 			tlsDuration = time.Since(tlsStart)
 		},
-		GotConn: func(_ httptrace.GotConnInfo) {
+		GotConn: func(info httptrace.GotConnInfo) {
 			gotConn = time.Now()
+			reused, idle = info.Reused, info.IdleTime
+			if info.Conn != nil {
+				remoteAddr = info.Conn.RemoteAddr().String()
+			}
+			c.countConn(info.Reused)
 		},
 		GotFirstResponseByte: func() {
 			gotFirstByte = time.Now()
 		},
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			interim = append(interim, protocol.InterimResponse{
+				StatusCode: code,
+				Headers:    http.Header(header).Clone(),
+			})
+			return nil
+		},
 	}
 
 	httpReq = httpReq.WithContext(httptrace.WithClientTrace(httpReq.Context(), trace))
@@ -195,7 +247,7 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 
 	// Read body
 	transferStart := time.Now()
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, bodyFile, size, err := c.readBody(resp, req)
 	transferDuration := time.Since(transferStart)
 	if err != nil {
 		return nil, fmt.Errorf("reading response: %w", err)
@@ -219,22 +271,28 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 					ch,
 				)
 
-				// Rebuild the request for retry
-				var retryBody io.Reader
-				if len(req.Body) > 0 {
-					retryBody = bytes.NewReader(req.Body)
+				// Rebuild the request for retry, reopening any body files
+				retryReq, retryErr := http.NewRequestWithContext(ctx, req.Method, u.String(), nil)
+				var retryBody *requestBody
+				if retryErr == nil {
+					retryBody, retryErr = newBody(req)
 				}
-				retryReq, retryErr := http.NewRequestWithContext(ctx, req.Method, u.String(), retryBody)
 				if retryErr == nil {
 					// Copy original headers
 					for k, v := range req.Headers {
 						retryReq.Header.Set(k, v)
 					}
+					retryBody.apply(retryReq)
 					retryReq.Header.Set("Authorization", authHeader)
+					applyFraming(retryReq, req)
+					conditional = c.addValidators(retryReq, req)
+					rawRequest = dumpRequest(retryReq, retryBody)
 
 					// Reset timing for the retry request
 					dnsStart, connStart, tlsStart, gotConn, gotFirstByte = time.Time{}, time.Time{}, time.Time{}, time.Time{}, time.Time{}
 					dnsDuration, connDuration, tlsDuration = 0, 0, 0
+					reused, idle, remoteAddr = false, 0, ""
+					interim = nil
 
 					retryReq = retryReq.WithContext(httptrace.WithClientTrace(retryReq.Context(), trace))
 
@@ -247,7 +305,7 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 						duration = retryDuration
 
 						transferStart = time.Now()
-						respBody, err = io.ReadAll(resp.Body)
+						respBody, bodyFile, size, err = c.readBody(resp, req)
 						transferDuration = time.Since(transferStart)
 						if err != nil {
 							resp.Body.Close()
@@ -271,31 +329,104 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 		TLSHandshake: tlsDuration,
 		TTFB:         ttfb,
 		Transfer:     transferDuration,
-		Total:        duration,
+		Total:        duration + transferDuration,
+		ConnReused:   reused,
+		ConnIdle:     idle,
+		RemoteAddr:   remoteAddr,
 	}
+	stats := c.PoolStats()
+	timing.Pool = &stats
 
-	return &protocol.Response{
+	response := &protocol.Response{
 		StatusCode:  resp.StatusCode,
 		Status:      resp.Status,
 		Headers:     resp.Header,
 		Body:        respBody,
 		ContentType: resp.Header.Get("Content-Type"),
 		Duration:    duration,
-		Size:        int64(len(respBody)),
+		Size:        size,
 		Proto:       resp.Proto,
 		TLS:         resp.TLS != nil,
 		Timing:      timing,
-	}, nil
+		Trailers:    receivedTrailers(resp.Trailer),
+		Interim:     interim,
+		RawRequest:  rawRequest,
+		BodyFile:    bodyFile,
+	}
+	c.updateCache(httpReq, req, response, conditional)
+	return response, nil
+}
+
+// maxRawBody caps how much of the body is included in the raw request dump.
+const maxRawBody = 64 << 10
+
+// applyFraming sets the Expect and chunked transfer encoding options. Both
+// only matter when there is a body to upload.
+func applyFraming(httpReq *http.Request, req *protocol.Request) {
+	if httpReq.Body == nil {
+		return
+	}
+	if req.ExpectContinue {
+		httpReq.Header.Set("Expect", "100-continue")
+	}
+	if req.Chunked {
+		httpReq.ContentLength = -1
+		httpReq.TransferEncoding = []string{"chunked"}
+	}
+}
+
+// dumpRequest renders httpReq as it will be written on an HTTP/1.1
+// connection, including chunk framing. Large and streamed bodies are left
+// out. It must run before the trace is attached since it performs a fake
+// round trip.
+func dumpRequest(httpReq *http.Request, body *requestBody) string {
+	withBody := body.reader == nil || (body.data != nil && len(body.data) <= maxRawBody)
+	dump, err := httputil.DumpRequestOut(httpReq, withBody)
+	if err != nil {
+		return ""
+	}
+	raw := string(dump)
+	if !withBody {
+		raw += fmt.Sprintf("[%d byte body omitted]\n", body.length)
+	}
+	return raw
+}
+
+// receivedTrailers drops trailers that were announced in the Trailer header
+// but never sent. It must be called after the body has been read.
+func receivedTrailers(trailer http.Header) http.Header {
+	var out http.Header
+	for k, v := range trailer {
+		if len(v) == 0 {
+			continue
+		}
+		if out == nil {
+			out = make(http.Header)
+		}
+		out[k] = v
+	}
+	return out
 }
 
 // buildTransport creates an http.Transport configured with proxy and TLS settings.
-// perRequestProxy overrides the client-level proxy config if non-empty.
-func (c *Client) buildTransport(perRequestProxy string) (http.RoundTripper, error) {
+// perRequest overrides the client-level proxy config if non-nil.
+func (c *Client) buildTransport(perRequest *protocol.ProxyConfig) (http.RoundTripper, error) {
 	transport := &http.Transport{
 		// Sensible defaults
-		MaxIdleConns:        100,
-		IdleConnTimeout:     90 * time.Second,
-		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		DisableKeepAlives:     c.poolOpts.DisableKeepAlives,
+	}
+	if c.poolOpts.MaxIdle > 0 {
+		transport.MaxIdleConns = c.poolOpts.MaxIdle
+	}
+	if c.poolOpts.MaxIdlePerHost > 0 {
+		transport.MaxIdleConnsPerHost = c.poolOpts.MaxIdlePerHost
+	}
+	if c.poolOpts.IdleTimeout > 0 {
+		transport.IdleConnTimeout = c.poolOpts.IdleTimeout
 	}
 
 	// Apply TLS config
@@ -303,87 +434,18 @@ func (c *Client) buildTransport(perRequestProxy string) (http.RoundTripper, erro
 		transport.TLSClientConfig = c.tlsConfig
 	}
 
-	// Determine effective proxy URL (per-request overrides global)
-	proxyURL := perRequestProxy
-	noProxy := ""
-	if proxyURL == "" && c.proxyConf != nil {
-		proxyURL = c.proxyConf.URL
-		noProxy = c.proxyConf.NoProxy
+	// Per-request proxy overrides global
+	proxyConf := perRequest
+	if proxyConf == nil {
+		proxyConf = c.proxyConf
 	}
-
-	if proxyURL != "" {
-		parsed, err := url.Parse(proxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("parsing proxy URL: %w", err)
-		}
-
-		switch parsed.Scheme {
-		case "socks5", "socks5h":
-			// SOCKS5 proxy via x/net/proxy
-			var auth *proxy.Auth
-			if parsed.User != nil {
-				password, _ := parsed.User.Password()
-				auth = &proxy.Auth{
-					User:     parsed.User.Username(),
-					Password: password,
-				}
-			}
-			dialer, err := proxy.SOCKS5("tcp", parsed.Host, auth, proxy.Direct)
-			if err != nil {
-				return nil, fmt.Errorf("creating SOCKS5 dialer: %w", err)
-			}
-			transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
-				return dialer.Dial(network, addr)
-			}
-		case "http", "https":
-			// HTTP/HTTPS proxy
-			if noProxy != "" {
-				noProxyHosts := parseNoProxy(noProxy)
-				transport.Proxy = func(r *http.Request) (*url.URL, error) {
-					if shouldBypassProxy(r.URL.Hostname(), noProxyHosts) {
-						return nil, nil
-					}
-					return parsed, nil
-				}
-			} else {
-				transport.Proxy = http.ProxyURL(parsed)
-			}
-		default:
-			return nil, fmt.Errorf("unsupported proxy scheme: %s", parsed.Scheme)
-		}
+	if err := proxyConf.Apply(transport); err != nil {
+		return nil, err
 	}
 
 	return transport, nil
 }
 
-// parseNoProxy splits a comma-separated no-proxy string into trimmed host entries.
-func parseNoProxy(noProxy string) []string {
-	parts := strings.Split(noProxy, ",")
-	hosts := make([]string, 0, len(parts))
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p != "" {
-			hosts = append(hosts, strings.ToLower(p))
-		}
-	}
-	return hosts
-}
-
-// shouldBypassProxy checks whether a host should bypass the proxy.
-func shouldBypassProxy(host string, noProxyHosts []string) bool {
-	host = strings.ToLower(host)
-	for _, h := range noProxyHosts {
-		if h == host {
-			return true
-		}
-		// Support wildcard suffix matching (e.g., .example.com)
-		if strings.HasPrefix(h, ".") && strings.HasSuffix(host, h) {
-			return true
-		}
-	}
-	return false
-}
-
 func applyAuth(req *http.Request, auth *protocol.AuthConfig, body []byte) {
 	if auth == nil || auth.Type == "none" {
 		return
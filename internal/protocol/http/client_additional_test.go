@@ -3,32 +3,36 @@ package http
 import (
 	"context"
 	"crypto/tls"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"testing"
 
+	gotls "github.com/sadopc/gottp/internal/core/tls"
 	"github.com/sadopc/gottp/internal/protocol"
 )
 
 func TestParseNoProxyAndShouldBypassProxy(t *testing.T) {
-	hosts := parseNoProxy("example.com, .internal, LOCALHOST ,")
+	hosts := protocol.ParseNoProxy("example.com, .internal, LOCALHOST ,")
 	if len(hosts) != 3 {
 		t.Fatalf("expected 3 hosts, got %d (%v)", len(hosts), hosts)
 	}
 
-	if !shouldBypassProxy("example.com", hosts) {
+	if !protocol.BypassProxy("example.com", hosts) {
 		t.Fatal("expected exact host to bypass proxy")
 	}
-	if !shouldBypassProxy("api.internal", hosts) {
+	if !protocol.BypassProxy("api.internal", hosts) {
 		t.Fatal("expected suffix wildcard host to bypass proxy")
 	}
-	if !shouldBypassProxy("localhost", hosts) {
+	if !protocol.BypassProxy("localhost", hosts) {
 		t.Fatal("expected case-insensitive host to bypass proxy")
 	}
-	if shouldBypassProxy("golang.org", hosts) {
+	if protocol.BypassProxy("golang.org", hosts) {
 		t.Fatal("did not expect unrelated host to bypass proxy")
 	}
 }
@@ -37,7 +41,7 @@ func TestBuildTransport_HTTPProxyWithNoProxy(t *testing.T) {
 	c := New()
 	c.SetProxy("http://proxy.example.com:8080", "example.com,.internal")
 
-	rt, err := c.buildTransport("")
+	rt, err := c.buildTransport(nil)
 	if err != nil {
 		t.Fatalf("buildTransport failed: %v", err)
 	}
@@ -83,7 +87,7 @@ func TestBuildTransport_PerRequestOverride(t *testing.T) {
 	// Global proxy would be invalid if used.
 	c.SetProxy("://bad-url", "")
 
-	rt, err := c.buildTransport("http://override.proxy:9090")
+	rt, err := c.buildTransport(&protocol.ProxyConfig{URL: "http://override.proxy:9090"})
 	if err != nil {
 		t.Fatalf("buildTransport should use per-request override and succeed: %v", err)
 	}
@@ -103,12 +107,12 @@ func TestBuildTransportErrors(t *testing.T) {
 	c := New()
 
 	c.SetProxy("://bad-url", "")
-	if _, err := c.buildTransport(""); err == nil {
+	if _, err := c.buildTransport(nil); err == nil {
 		t.Fatal("expected parsing proxy URL error")
 	}
 
 	c.SetProxy("ftp://proxy.example.com", "")
-	if _, err := c.buildTransport(""); err == nil {
+	if _, err := c.buildTransport(nil); err == nil {
 		t.Fatal("expected unsupported proxy scheme error")
 	}
 }
@@ -118,7 +122,7 @@ func TestBuildTransportAppliesTLSConfig(t *testing.T) {
 	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
 	c.SetTLSConfig(tlsCfg)
 
-	rt, err := c.buildTransport("")
+	rt, err := c.buildTransport(nil)
 	if err != nil {
 		t.Fatalf("buildTransport failed: %v", err)
 	}
@@ -231,3 +235,141 @@ func TestExecute_DigestRetry(t *testing.T) {
 		t.Fatalf("expected at least two calls (challenge + retry), got %d", callCount)
 	}
 }
+
+func TestExecute_TrailersAndInterimResponses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Header().Del("Link")
+
+		w.Header().Set("Trailer", "X-Checksum, X-Unsent")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+		w.Header().Set("X-Checksum", "abc123")
+	}))
+	defer srv.Close()
+
+	resp, err := New().Execute(context.Background(), &protocol.Request{Method: "GET", URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if got := resp.Trailers.Get("X-Checksum"); got != "abc123" {
+		t.Fatalf("expected trailer X-Checksum=abc123, got %q (%v)", got, resp.Trailers)
+	}
+	if _, ok := resp.Trailers["X-Unsent"]; ok {
+		t.Fatalf("announced but unsent trailer should be dropped: %v", resp.Trailers)
+	}
+	if len(resp.Interim) != 1 || resp.Interim[0].StatusCode != http.StatusEarlyHints {
+		t.Fatalf("expected one 103 interim response, got %+v", resp.Interim)
+	}
+	if !strings.Contains(resp.Interim[0].Headers.Get("Link"), "preload") {
+		t.Fatalf("expected Link header on 103, got %v", resp.Interim[0].Headers)
+	}
+}
+
+func TestExecute_ExpectContinueAndChunked(t *testing.T) {
+	var gotExpect string
+	var gotTE []string
+	var gotLen int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotExpect = r.Header.Get("Expect")
+		gotTE = r.TransferEncoding
+		gotLen = r.ContentLength
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	resp, err := New().Execute(context.Background(), &protocol.Request{
+		Method:         "POST",
+		URL:            srv.URL,
+		Body:           []byte("hello"),
+		ExpectContinue: true,
+		Chunked:        true,
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if gotExpect != "100-continue" {
+		t.Fatalf("expected Expect: 100-continue, got %q", gotExpect)
+	}
+	if len(gotTE) != 1 || gotTE[0] != "chunked" || gotLen != -1 {
+		t.Fatalf("expected chunked upload, got TE=%v len=%d", gotTE, gotLen)
+	}
+	for _, want := range []string{"POST / HTTP/1.1", "Transfer-Encoding: chunked", "Expect: 100-continue", "5\r\nhello\r\n0\r\n"} {
+		if !strings.Contains(resp.RawRequest, want) {
+			t.Fatalf("raw request missing %q:\n%s", want, resp.RawRequest)
+		}
+	}
+}
+
+func TestExecute_RawRequestDefaultFraming(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	resp, err := New().Execute(context.Background(), &protocol.Request{
+		Method:   "PUT",
+		URL:      srv.URL + "/items",
+		Body:     []byte("abc"),
+		Chunked:  false,
+		Protocol: "http",
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(resp.RawRequest, "Content-Length: 3") || strings.Contains(resp.RawRequest, "chunked") {
+		t.Fatalf("expected Content-Length framing:\n%s", resp.RawRequest)
+	}
+	if strings.Contains(resp.RawRequest, "Expect:") {
+		t.Fatalf("did not expect an Expect header:\n%s", resp.RawRequest)
+	}
+}
+
+func TestExecute_PerRequestTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := New()
+	if _, err := c.Execute(context.Background(), &protocol.Request{Method: "GET", URL: srv.URL}); err == nil {
+		t.Fatal("expected certificate error without TLS settings")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Execute(context.Background(), &protocol.Request{
+		Method: "GET",
+		URL:    srv.URL,
+		TLS:    &gotls.Config{CAFile: caFile, MinVersion: "1.2"},
+	})
+	if err != nil {
+		t.Fatalf("Execute with CA bundle failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	if _, err := c.Execute(context.Background(), &protocol.Request{
+		Method: "GET",
+		URL:    srv.URL,
+		TLS:    &gotls.Config{InsecureSkipVerify: true},
+	}); err != nil {
+		t.Fatalf("Execute with skip verify failed: %v", err)
+	}
+
+	_, err = c.Execute(context.Background(), &protocol.Request{
+		Method: "GET",
+		URL:    srv.URL,
+		TLS:    &gotls.Config{MinVersion: "0.9"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "configuring TLS") {
+		t.Fatalf("expected TLS configuration error, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// previewSize caps how much of a downloaded body is kept in memory.
+const previewSize = 64 << 10
+
+// DefaultDownloadDir is where oversized bodies are saved when no directory
+// is configured.
+func DefaultDownloadDir() string {
+	return filepath.Join(os.TempDir(), "gottp-downloads")
+}
+
+// readBody reads the response body into memory, or streams it to disk when
+// the request asks for a download or the body exceeds the download
+// threshold. For streamed bodies only a preview is returned.
+func (c *Client) readBody(resp *http.Response, req *protocol.Request) (body []byte, file string, size int64, err error) {
+	var r io.Reader = resp.Body
+	if req.OnProgress != nil {
+		r = &progressReader{r: r, total: resp.ContentLength, fn: req.OnProgress}
+	}
+
+	if !req.Download && c.downloadThreshold <= 0 {
+		body, err = io.ReadAll(r)
+		return body, "", int64(len(body)), err
+	}
+
+	var head []byte
+	if !req.Download && resp.ContentLength <= c.downloadThreshold {
+		head, err = io.ReadAll(io.LimitReader(r, c.downloadThreshold+1))
+		if err != nil {
+			return nil, "", 0, err
+		}
+		if int64(len(head)) <= c.downloadThreshold {
+			return head, "", int64(len(head)), nil
+		}
+	}
+
+	dir := c.downloadDir
+	if dir == "" {
+		dir = DefaultDownloadDir()
+	}
+	f, err := createUnique(dir, downloadName(resp))
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return saveBody(f, head, r)
+}
+
+// saveBody writes head and the rest of r to f, keeping a preview.
+func saveBody(f *os.File, head []byte, r io.Reader) ([]byte, string, int64, error) {
+	defer f.Close()
+	preview := &previewBuffer{max: previewSize}
+	w := io.MultiWriter(f, preview)
+	n, err := io.Copy(w, io.MultiReader(bytes.NewReader(head), r))
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("saving response to %s: %w", f.Name(), err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, "", 0, fmt.Errorf("saving response to %s: %w", f.Name(), err)
+	}
+	return preview.Bytes(), f.Name(), n, nil
+}
+
+// createUnique creates name in dir, adding a numeric suffix instead of
+// overwriting an existing file.
+func createUnique(dir, name string) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating download dir: %w", err)
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 0; i < 1000; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = base + "-" + strconv.Itoa(i) + ext
+		}
+		f, err := os.OpenFile(filepath.Join(dir, candidate), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("creating download file: %w", err)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("creating download file: too many files named %s", name)
+}
+
+// downloadName picks a file name from Content-Disposition or the URL path.
+func downloadName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := filepath.Base(params["filename"]); name != "." && name != "/" && name != "" {
+			return name
+		}
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		if name := path.Base(resp.Request.URL.Path); name != "." && name != "/" {
+			return name
+		}
+	}
+	return "response"
+}
+
+// previewBuffer keeps the first max bytes written to it.
+type previewBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *previewBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+type progressReader struct {
+	r        io.Reader
+	received int64
+	total    int64
+	fn       func(received, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.received += int64(n)
+		p.fn(p.received, p.total)
+	}
+	return n, err
+}
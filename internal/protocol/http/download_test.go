@@ -0,0 +1,87 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+func TestExecute_LargeBodyStreamsToDisk(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 20<<10) // 200 KiB
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="dump.bin"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		w.Write(payload)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	c := New()
+	c.SetDownloadThreshold(100<<10, dir)
+
+	var last, total int64
+	resp, err := c.Execute(context.Background(), &protocol.Request{
+		Method: "GET", URL: srv.URL + "/files/1", Protocol: "http",
+		OnProgress: func(received, n int64) { last, total = received, n },
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if resp.BodyFile != filepath.Join(dir, "dump.bin") {
+		t.Fatalf("BodyFile = %q", resp.BodyFile)
+	}
+	if resp.Size != int64(len(payload)) || len(resp.Body) != previewSize {
+		t.Fatalf("size = %d, preview = %d", resp.Size, len(resp.Body))
+	}
+	saved, err := os.ReadFile(resp.BodyFile)
+	if err != nil || !bytes.Equal(saved, payload) {
+		t.Fatalf("saved body mismatch (err %v, %d bytes)", err, len(saved))
+	}
+	if last != int64(len(payload)) || total != int64(len(payload)) {
+		t.Fatalf("progress = %d/%d", last, total)
+	}
+
+	// A second download of the same name does not overwrite the first
+	resp, err = c.Execute(context.Background(), &protocol.Request{Method: "GET", URL: srv.URL, Protocol: "http"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.BodyFile != filepath.Join(dir, "dump-1.bin") {
+		t.Fatalf("BodyFile = %q, want dump-1.bin", resp.BodyFile)
+	}
+}
+
+func TestExecute_SmallBodyStaysInMemory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("small"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	c := New()
+	c.SetDownloadThreshold(1<<10, dir)
+
+	resp, err := c.Execute(context.Background(), &protocol.Request{Method: "GET", URL: srv.URL + "/report.csv", Protocol: "http"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.BodyFile != "" || string(resp.Body) != "small" {
+		t.Fatalf("expected in-memory body, got file %q body %q", resp.BodyFile, resp.Body)
+	}
+
+	// Download forces streaming whatever the size
+	resp, err = c.Execute(context.Background(), &protocol.Request{Method: "GET", URL: srv.URL + "/report.csv", Protocol: "http", Download: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.BodyFile != filepath.Join(dir, "report.csv") || string(resp.Body) != "small" || resp.Size != 5 {
+		t.Fatalf("unexpected download: file %q body %q size %d", resp.BodyFile, resp.Body, resp.Size)
+	}
+}
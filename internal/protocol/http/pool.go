@@ -0,0 +1,114 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	gotls "github.com/sadopc/gottp/internal/core/tls"
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// PoolOptions tune connection reuse. Zero values keep the defaults.
+type PoolOptions struct {
+	MaxIdle        int           // idle connections kept across all hosts (100)
+	MaxIdlePerHost int           // idle connections kept per host (2)
+	IdleTimeout    time.Duration // how long an idle connection is kept (90s)
+
+	// DisableKeepAlives closes each connection after one request, sending
+	// Connection: close. ForceClose instead drops idle connections before
+	// each request, so every request pays for a new connection while the
+	// server still sees keep-alive requests.
+	DisableKeepAlives bool
+	ForceClose        bool
+}
+
+// pool shares transports between requests so kept-alive connections are
+// reused. Requests with a different proxy, TLS or hosts override get their
+// own transport.
+type pool struct {
+	mu         sync.Mutex
+	transports map[transportKey]*http.Transport
+	stats      protocol.PoolStats
+}
+
+type transportKey struct {
+	proxy  protocol.ProxyConfig
+	hasTLS bool
+	tls    gotls.Config
+	hosts  string
+}
+
+// SetPoolOptions sets how connections are kept alive and reused. It drops
+// the connections already open.
+func (c *Client) SetPoolOptions(opts PoolOptions) {
+	c.poolOpts = opts
+	c.resetPool()
+}
+
+// PoolStats returns how many connections have been opened and reused.
+func (c *Client) PoolStats() protocol.PoolStats {
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	return c.pool.stats
+}
+
+// transportFor returns the shared transport for req's proxy, TLS and host
+// override settings, creating it on first use.
+func (c *Client) transportFor(req *protocol.Request) (*http.Transport, error) {
+	var key transportKey
+	if req.Proxy != nil {
+		key.proxy = *req.Proxy
+	}
+	if req.TLS != nil {
+		key.hasTLS, key.tls = true, *req.TLS
+	}
+	key.hosts = req.Hosts.String()
+
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	if t, ok := c.pool.transports[key]; ok {
+		return t, nil
+	}
+	rt, err := c.buildTransport(req.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("configuring transport: %w", err)
+	}
+	t := rt.(*http.Transport)
+	if req.TLS != nil {
+		tlsCfg, err := req.TLS.BuildTLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("configuring TLS: %w", err)
+		}
+		t.TLSClientConfig = tlsCfg
+	}
+	req.Hosts.Apply(t)
+	if c.pool.transports == nil {
+		c.pool.transports = make(map[transportKey]*http.Transport)
+	}
+	c.pool.transports[key] = t
+	return t, nil
+}
+
+// countConn records a connection handed to a request.
+func (c *Client) countConn(reused bool) {
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	if reused {
+		c.pool.stats.Reused++
+	} else {
+		c.pool.stats.Opened++
+	}
+}
+
+// resetPool closes idle connections and drops the transports, so the next
+// request builds one with the current settings.
+func (c *Client) resetPool() {
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	for _, t := range c.pool.transports {
+		t.CloseIdleConnections()
+	}
+	c.pool.transports = nil
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gotls "github.com/sadopc/gottp/internal/core/tls"
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+func TestExecute_ReusesConnections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	send := func(c *Client) *protocol.TimingDetail {
+		t.Helper()
+		resp, err := c.Execute(context.Background(), &protocol.Request{Method: "GET", URL: srv.URL, Protocol: "http"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Timing
+	}
+
+	c := New()
+	defer c.Close()
+	if first := send(c); first.ConnReused || first.RemoteAddr == "" {
+		t.Fatalf("first request: %+v", first)
+	}
+	second := send(c)
+	if !second.ConnReused {
+		t.Fatal("second request should reuse the kept-alive connection")
+	}
+	if *second.Pool != (protocol.PoolStats{Opened: 1, Reused: 1}) {
+		t.Errorf("pool = %+v", *second.Pool)
+	}
+
+	for _, opts := range []PoolOptions{{DisableKeepAlives: true}, {ForceClose: true}} {
+		c := New()
+		c.SetPoolOptions(opts)
+		send(c)
+		if td := send(c); td.ConnReused || td.Pool.Opened != 2 {
+			t.Errorf("%+v: second request reused a connection: %+v", opts, td)
+		}
+		c.Close()
+	}
+}
+
+func TestTransportFor_KeyedByProxyAndTLS(t *testing.T) {
+	c := New()
+	defer c.Close()
+	plain := &protocol.Request{Method: "GET", URL: "http://example.com"}
+	a, err := c.transportFor(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := c.transportFor(plain)
+	if a != b {
+		t.Error("requests with the same settings should share a transport")
+	}
+
+	insecure := &protocol.Request{Method: "GET", URL: "https://example.com", TLS: &gotls.Config{InsecureSkipVerify: true}}
+	if tr, _ := c.transportFor(insecure); tr == a || !tr.TLSClientConfig.InsecureSkipVerify {
+		t.Error("a TLS override should get its own transport")
+	}
+	proxied := &protocol.Request{Method: "GET", URL: "http://example.com", Proxy: &protocol.ProxyConfig{URL: "http://proxy:3128"}}
+	if tr, _ := c.transportFor(proxied); tr == a {
+		t.Error("a proxy override should get its own transport")
+	}
+
+	c.SetPoolOptions(PoolOptions{MaxIdlePerHost: 8, IdleTimeout: 5 * time.Second})
+	tr, _ := c.transportFor(plain)
+	if tr == a || tr.MaxIdleConnsPerHost != 8 || tr.IdleConnTimeout != 5*time.Second {
+		t.Errorf("new options not applied: %+v", tr)
+	}
+}
@@ -0,0 +1,137 @@
+package protocol
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	unresolvedVar = regexp.MustCompile(`\{\{\s*([\w.$-]+)\s*\}\}`)
+	pathParam     = regexp.MustCompile(`^(?::(\w+)|\{(\w+)\})$`)
+)
+
+// Warning is a problem found by Preflight that will likely make the request
+// fail or behave unexpectedly, but does not stop it from being sent.
+type Warning struct {
+	Field   string // url, header, body, ...
+	Message string
+}
+
+func (w Warning) String() string {
+	return w.Field + ": " + w.Message
+}
+
+// Preflight statically checks a fully resolved request: malformed URLs,
+// bodies on GET/HEAD, unfilled path parameters, leftover {{variables}} and
+// headers set more than once.
+func Preflight(req *Request) []Warning {
+	var warnings []Warning
+	add := func(field, format string, args ...interface{}) {
+		warnings = append(warnings, Warning{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	checkURL(req, add)
+
+	if req.Protocol == "" || req.Protocol == "http" {
+		method := strings.ToUpper(req.Method)
+		if (method == "GET" || method == "HEAD") && req.HasBody() {
+			add("body", "%s request has a body; many servers and proxies ignore or reject it", method)
+		}
+	}
+
+	// Leftover variables, reported once per name and field
+	seen := make(map[string]bool)
+	unresolved := func(field, s string) {
+		for _, m := range unresolvedVar.FindAllStringSubmatch(s, -1) {
+			if key := field + "|" + m[1]; !seen[key] {
+				seen[key] = true
+				add(field, "variable {{%s}} is not defined", m[1])
+			}
+		}
+	}
+	unresolved("url", req.URL)
+	for _, k := range sortedKeys(req.Params) {
+		unresolved("param", k+req.Params[k])
+	}
+	for _, k := range sortedKeys(req.Headers) {
+		unresolved("header", k+req.Headers[k])
+	}
+	unresolved("body", string(req.Body)+req.BodyFile)
+	for _, f := range req.Form {
+		unresolved("body", f.Name+f.Value+f.File)
+	}
+	unresolved("query", req.GraphQLQuery)
+	unresolved("variables", req.GraphQLVariables)
+	for _, m := range req.WSMessages {
+		unresolved("message", m.Content)
+	}
+	if a := req.Auth; a != nil {
+		unresolved("auth", a.Username+a.Password+a.Token+a.APIKey+a.APIValue)
+	}
+
+	// Header names are case-insensitive, so "Accept" and "accept" collide
+	byName := make(map[string][]string)
+	for k := range req.Headers {
+		lower := strings.ToLower(k)
+		byName[lower] = append(byName[lower], k)
+	}
+	for _, lower := range sortedKeys(byName) {
+		if names := byName[lower]; len(names) > 1 {
+			sort.Strings(names)
+			add("header", "%s is set %d times (%s); only one value will be sent", names[0], len(names), strings.Join(names, ", "))
+		}
+	}
+
+	return warnings
+}
+
+func checkURL(req *Request, add func(field, format string, args ...interface{})) {
+	if req.URL == "" || req.Protocol == "grpc" {
+		return
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		add("url", "invalid URL: %v", err)
+		return
+	}
+
+	if unresolvedVar.MatchString(req.URL) {
+		return // reported as an undefined variable
+	}
+
+	switch req.Protocol {
+	case "websocket":
+		if u.Scheme != "ws" && u.Scheme != "wss" {
+			add("url", "scheme should be ws:// or wss://, got %q", u.Scheme)
+		}
+	default:
+		if u.Scheme != "http" && u.Scheme != "https" {
+			if u.Scheme == "" {
+				add("url", "missing scheme; use http:// or https://")
+			} else {
+				add("url", "unsupported scheme %q; use http:// or https://", u.Scheme)
+			}
+		}
+	}
+	if u.Scheme != "" && u.Host == "" {
+		add("url", "missing host")
+	}
+
+	for _, seg := range strings.Split(u.Path, "/") {
+		if m := pathParam.FindStringSubmatch(seg); m != nil {
+			add("url", "path parameter %q has no value", m[1]+m[2])
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
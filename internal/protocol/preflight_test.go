@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *Request
+		want []string // substrings, one per expected warning
+	}{
+		{
+			name: "clean request",
+			req:  &Request{Protocol: "http", Method: "GET", URL: "https://api.example.com/users", Headers: map[string]string{"Accept": "application/json"}},
+		},
+		{
+			name: "get with body",
+			req:  &Request{Protocol: "http", Method: "GET", URL: "https://api.example.com", Body: []byte(`{}`)},
+			want: []string{"GET request has a body"},
+		},
+		{
+			name: "head with form body",
+			req: &Request{Protocol: "http", Method: "HEAD", URL: "https://api.example.com",
+				Form: []FormField{{Name: "file", File: "{{dir}}/a.txt"}}},
+			want: []string{"HEAD request has a body", "{{dir}}"},
+		},
+		{
+			name: "missing scheme",
+			req:  &Request{Protocol: "http", Method: "GET", URL: "api.example.com/users"},
+			want: []string{"missing scheme"},
+		},
+		{
+			name: "invalid url",
+			req:  &Request{Protocol: "http", Method: "GET", URL: "http://[::1"},
+			want: []string{"invalid URL"},
+		},
+		{
+			name: "path params",
+			req:  &Request{Protocol: "http", Method: "GET", URL: "https://api.example.com/users/:id/posts/{postId}"},
+			want: []string{`"id" has no value`, `"postId" has no value`},
+		},
+		{
+			name: "unresolved variables",
+			req: &Request{Protocol: "http", Method: "POST", URL: "{{base_url}}/users",
+				Headers: map[string]string{"Authorization": "Bearer {{token}}"},
+				Body:    []byte(`{"a":"{{token}}"}`)},
+			want: []string{"{{base_url}}", "{{token}}", "{{token}}"},
+		},
+		{
+			name: "duplicate headers",
+			req: &Request{Protocol: "http", Method: "GET", URL: "https://api.example.com",
+				Headers: map[string]string{"Accept": "a", "accept": "b"}},
+			want: []string{"Accept is set 2 times"},
+		},
+		{
+			name: "websocket scheme",
+			req:  &Request{Protocol: "websocket", URL: "https://example.com/socket"},
+			want: []string{"ws:// or wss://"},
+		},
+		{
+			name: "grpc address is not a URL",
+			req:  &Request{Protocol: "grpc", URL: "localhost:50051"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Preflight(tt.req)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d warnings %v, want %d", len(got), got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				if !strings.Contains(got[i].String(), w) {
+					t.Errorf("warning %d = %q, want it to contain %q", i, got[i], w)
+				}
+			}
+		})
+	}
+}
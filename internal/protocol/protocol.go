@@ -3,7 +3,11 @@ package protocol
 import (
 	"context"
 	"net/http"
+	"path/filepath"
 	"time"
+
+	"github.com/sadopc/gottp/internal/core/retry"
+	gotls "github.com/sadopc/gottp/internal/core/tls"
 )
 
 // Protocol defines the interface all protocol clients must implement.
@@ -24,14 +28,33 @@ type Request struct {
 	Body     []byte
 	Auth     *AuthConfig
 
+	// Cookies are sent in the Cookie header once variables are resolved;
+	// see ApplyCookies.
+	Cookies []Cookie
+
+	// Form bodies are encoded by the client, as multipart/form-data when
+	// Multipart is set and urlencoded otherwise. BodyFile streams a file as
+	// the whole body. Both take precedence over Body.
+	Form      []FormField
+	Multipart bool
+	BodyFile  string
+
 	// GraphQL-specific
 	GraphQLQuery     string
 	GraphQLVariables string
+	GraphQLPersisted bool // Automatic Persisted Queries: send the query's hash first
 
 	// gRPC-specific
-	GRPCService string
-	GRPCMethod  string
-	Metadata    map[string]string
+	GRPCService   string
+	GRPCMethod    string
+	Metadata      map[string]string
+	GRPCCall      GRPCCallOptions
+	GRPCTransport string // grpc, grpc-web or connect; empty picks one from the URL
+
+	// WebSocket-specific
+	WSMessages     []WSScriptMessage
+	WSPingInterval time.Duration
+	WSReconnect    *retry.Policy // re-dial after a dropped connection; nil disables
 
 	// Scripting
 	PreScript  string
@@ -40,8 +63,125 @@ type Request struct {
 	// Timeout
 	Timeout time.Duration
 
-	// Proxy
-	ProxyURL string
+	// Proxy overrides the client's global proxy when set
+	Proxy *ProxyConfig
+
+	// TLS overrides the client's TLS settings when set
+	TLS *gotls.Config
+
+	// Hosts sends connections for some hosts to another address
+	Hosts Hosts
+
+	// Upload framing: send Expect: 100-continue and wait for the server
+	// before the body, and/or force chunked transfer encoding.
+	ExpectContinue bool
+	Chunked        bool
+
+	// NoCache skips the client's response cache, if it has one: no
+	// If-None-Match or If-Modified-Since is added and nothing is stored.
+	NoCache bool
+
+	// Download streams the response body to the client's download
+	// directory whatever its size. OnProgress, if set, is called as the body
+	// is read with the bytes received so far and the expected total (-1
+	// when unknown).
+	Download   bool
+	OnProgress func(received, total int64)
+}
+
+// GRPCCallOptions tune a single gRPC call. Zero values keep the client's
+// defaults.
+type GRPCCallOptions struct {
+	Deadline       time.Duration // overrides Timeout for the call
+	Gzip           bool          // gzip-compress request messages
+	MaxSendMsgSize int           // bytes
+	MaxRecvMsgSize int           // bytes
+	WaitForReady   bool          // block until the connection is ready instead of failing fast
+}
+
+// FormField is a form body field. In multipart bodies a field with a File
+// uploads that file.
+type FormField struct {
+	Name  string
+	Value string
+	File  string
+}
+
+// Cookie is a cookie set explicitly on a request.
+type Cookie struct {
+	Name  string
+	Value string
+}
+
+// Templates returns the fields of r that may hold {{variables}} resolved
+// before sending, scripts aside, in no particular order.
+func (r *Request) Templates() []string {
+	texts := []string{r.URL, string(r.Body), r.BodyFile, r.GraphQLQuery, r.GraphQLVariables}
+	for k, v := range r.Headers {
+		texts = append(texts, k, v)
+	}
+	for k, v := range r.Params {
+		texts = append(texts, k, v)
+	}
+	for _, c := range r.Cookies {
+		texts = append(texts, c.Value)
+	}
+	for _, f := range r.Form {
+		texts = append(texts, f.Name, f.Value, f.File)
+	}
+	for _, m := range r.WSMessages {
+		texts = append(texts, m.Content)
+	}
+	if a := r.Auth; a != nil {
+		texts = append(texts, a.Username, a.Password, a.Token, a.APIKey, a.APIValue)
+	}
+	if t := r.TLS; t != nil {
+		texts = append(texts, t.CertFile, t.KeyFile, t.CAFile)
+	}
+	if p := r.Proxy; p != nil {
+		texts = append(texts, p.URL, p.NoProxy, p.Username, p.Password)
+	}
+	for _, v := range r.Hosts {
+		texts = append(texts, v)
+	}
+	return texts
+}
+
+// HasBody reports whether the request sends a body.
+func (r *Request) HasBody() bool {
+	return len(r.Body) > 0 || len(r.Form) > 0 || r.BodyFile != ""
+}
+
+// ResolvePaths makes relative body file and certificate paths relative to
+// baseDir.
+func (r *Request) ResolvePaths(baseDir string) {
+	resolve := func(p string) string {
+		if p == "" || baseDir == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(baseDir, p)
+	}
+	r.BodyFile = resolve(r.BodyFile)
+	for i := range r.Form {
+		r.Form[i].File = resolve(r.Form[i].File)
+	}
+	r.TLS.ResolvePaths(baseDir)
+}
+
+// WSScriptMessage is a WebSocket message sent automatically after connecting.
+type WSScriptMessage struct {
+	Name    string
+	Content string
+	Delay   time.Duration
+	Expect  *WSExpectation
+}
+
+// WSExpectation describes the response expected after a scripted message.
+type WSExpectation struct {
+	Contains string
+	Equals   string
+	Matches  string
+	Timeout  time.Duration
 }
 
 // AuthConfig holds authentication settings.
@@ -95,9 +235,20 @@ type TimingDetail struct {
 	DNSLookup    time.Duration `json:"dns_lookup"`
 	TCPConnect   time.Duration `json:"tcp_connect"`
 	TLSHandshake time.Duration `json:"tls_handshake"`
-	TTFB         time.Duration `json:"ttfb"`     // Time to first byte (server processing)
-	Transfer     time.Duration `json:"transfer"` // Response body transfer
-	Total        time.Duration `json:"total"`
+	TTFB         time.Duration `json:"ttfb"`                  // Time to first byte (server processing)
+	Transfer     time.Duration `json:"transfer"`              // Response body transfer
+	Total        time.Duration `json:"total"`                 // Whole exchange, including the transfer
+	ConnReused   bool          `json:"conn_reused,omitempty"` // Kept-alive connection; no DNS, connect or TLS
+	ConnIdle     time.Duration `json:"conn_idle,omitempty"`   // How long a reused connection sat idle
+	RemoteAddr   string        `json:"remote_addr,omitempty"` // Address of the connection used
+	Pool         *PoolStats    `json:"pool,omitempty"`        // The client's connections after this request
+}
+
+// PoolStats counts the connections a client has opened and how often
+// requests were given a kept-alive one instead.
+type PoolStats struct {
+	Opened int `json:"opened"`
+	Reused int `json:"reused"`
 }
 
 // StreamMessage represents a message in a streaming RPC.
@@ -105,7 +256,7 @@ type StreamMessage struct {
 	Content   string
 	IsJSON    bool
 	Timestamp time.Time
-	Direction string // "sent" or "received"
+	Direction string // "sent", "received", or "event" for connection changes such as reconnects
 	Err       error
 }
 
@@ -121,4 +272,26 @@ type Response struct {
 	Proto       string
 	TLS         bool
 	Timing      *TimingDetail
+
+	// Trailers holds headers sent after the body. Interim lists 1xx
+	// responses (e.g. 103 Early Hints) received before the final one.
+	Trailers http.Header
+	Interim  []InterimResponse
+
+	// RawRequest is the request as framed on the wire (HTTP/1.1 form).
+	RawRequest string
+
+	// BodyFile is set when the body was streamed to disk; Body then only
+	// holds a preview and Size is the full length.
+	BodyFile string
+
+	// FromCache is set when the server answered 304 Not Modified and Body
+	// and StatusCode come from the client's response cache.
+	FromCache bool
+}
+
+// InterimResponse is a 1xx informational response.
+type InterimResponse struct {
+	StatusCode int
+	Headers    http.Header
 }
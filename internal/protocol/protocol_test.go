@@ -0,0 +1,23 @@
+package protocol
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequest_Templates(t *testing.T) {
+	r := &Request{
+		URL:     "{{base_url}}/users",
+		Headers: map[string]string{"Authorization": "Bearer {{token}}"},
+		Cookies: []Cookie{{Name: "session", Value: "{{sid}}"}},
+		Auth:    &AuthConfig{Password: "{{pass}}"},
+		Proxy:   &ProxyConfig{URL: "{{proxy}}"},
+		Hosts:   Hosts{"api.example.com": "{{host}}"},
+	}
+	texts := strings.Join(r.Templates(), " ")
+	for _, want := range []string{"{{base_url}}", "{{token}}", "{{sid}}", "{{pass}}", "{{proxy}}", "{{host}}"} {
+		if !strings.Contains(texts, want) {
+			t.Errorf("Templates missing %s: %q", want, texts)
+		}
+	}
+}
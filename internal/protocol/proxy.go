@@ -0,0 +1,163 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// ProxyDirect as a proxy URL disables any proxy configured at a lower
+// level, e.g. a request that must bypass the environment's proxy.
+const ProxyDirect = "direct"
+
+// ProxyConfig routes connections through an HTTP, HTTPS or SOCKS5 proxy.
+type ProxyConfig struct {
+	URL      string // http://, https://, socks5:// or socks5h://, or ProxyDirect
+	NoProxy  string // comma-separated hosts to reach directly; NO_PROXY when empty
+	Username string // proxy auth, overrides credentials in URL
+	Password string
+}
+
+// Direct reports whether the config disables proxying.
+func (p *ProxyConfig) Direct() bool {
+	return p == nil || p.URL == "" || strings.EqualFold(p.URL, ProxyDirect)
+}
+
+// Label returns the proxy URL without credentials for display, or "" when
+// connections are made directly.
+func (p *ProxyConfig) Label() string {
+	if p.Direct() {
+		return ""
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil || u.Host == "" {
+		return p.URL
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// Apply routes t through the proxy. Hosts matching NoProxy are dialed
+// directly. A direct config leaves t unchanged.
+func (p *ProxyConfig) Apply(t *http.Transport) error {
+	if p.Direct() {
+		return nil
+	}
+	parsed, err := url.Parse(p.URL)
+	if err != nil {
+		return fmt.Errorf("parsing proxy URL: %w", err)
+	}
+	if p.Username != "" {
+		parsed.User = url.UserPassword(p.Username, p.Password)
+	}
+
+	noProxy := p.NoProxy
+	if noProxy == "" {
+		noProxy = os.Getenv("NO_PROXY")
+	}
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	noProxyHosts := ParseNoProxy(noProxy)
+
+	switch parsed.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if parsed.User != nil {
+			password, _ := parsed.User.Password()
+			auth = &proxy.Auth{
+				User:     parsed.User.Username(),
+				Password: password,
+			}
+		}
+		direct := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		dialer, err := proxy.SOCKS5("tcp", parsed.Host, auth, direct)
+		if err != nil {
+			return fmt.Errorf("creating SOCKS5 dialer: %w", err)
+		}
+		socks := dialer.(proxy.ContextDialer)
+		t.Proxy = nil
+		t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				host = addr
+			}
+			if BypassProxy(host, noProxyHosts) {
+				return direct.DialContext(ctx, network, addr)
+			}
+			return socks.DialContext(ctx, network, addr)
+		}
+	case "http", "https":
+		t.Proxy = func(r *http.Request) (*url.URL, error) {
+			if BypassProxy(r.URL.Hostname(), noProxyHosts) {
+				return nil, nil
+			}
+			return parsed, nil
+		}
+	default:
+		return fmt.Errorf("unsupported proxy scheme: %s", parsed.Scheme)
+	}
+	return nil
+}
+
+// ProxyTransport returns a transport that routes through p, or the default
+// transport when p is direct.
+func ProxyTransport(p *ProxyConfig) (http.RoundTripper, error) {
+	if p.Direct() {
+		return http.DefaultTransport, nil
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if err := p.Apply(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ParseNoProxy splits a comma-separated no-proxy string into trimmed,
+// lower-cased host entries.
+func ParseNoProxy(noProxy string) []string {
+	parts := strings.Split(noProxy, ",")
+	hosts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			hosts = append(hosts, strings.ToLower(p))
+		}
+	}
+	return hosts
+}
+
+// BypassProxy reports whether host matches a no-proxy entry. Entries are
+// exact hosts, ".suffix" domains, IP addresses, CIDR ranges, or "*" for
+// every host. A plain domain also matches its subdomains.
+func BypassProxy(host string, noProxyHosts []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	ip := net.ParseIP(host)
+	for _, h := range noProxyHosts {
+		if h == "*" || h == host {
+			return true
+		}
+		if strings.HasPrefix(h, ".") {
+			if strings.HasSuffix(host, h) {
+				return true
+			}
+			continue
+		}
+		if ip != nil {
+			if _, cidr, err := net.ParseCIDR(h); err == nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,7 +2,11 @@ package protocol
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 )
 
 // Registry manages protocol implementations.
@@ -28,15 +32,22 @@ func (r *Registry) Get(name string) (Protocol, bool) {
 	return p, ok
 }
 
+// Lookup returns the protocol for a request's protocol field. Names are
+// case-insensitive, empty means http, and common aliases such as ws map to
+// their protocol. An unregistered protocol yields an *UnsupportedError.
+func (r *Registry) Lookup(name string) (Protocol, error) {
+	canonical := CanonicalName(name)
+	if p, ok := r.protocols[canonical]; ok {
+		return p, nil
+	}
+	return nil, &UnsupportedError{Protocol: name, Available: r.Names()}
+}
+
 // Execute dispatches a request to the appropriate protocol handler.
 func (r *Registry) Execute(ctx context.Context, req *Request) (*Response, error) {
-	proto := req.Protocol
-	if proto == "" {
-		proto = "http"
-	}
-	p, ok := r.protocols[proto]
-	if !ok {
-		return nil, fmt.Errorf("unknown protocol: %s", proto)
+	p, err := r.Lookup(req.Protocol)
+	if err != nil {
+		return nil, err
 	}
 	if err := p.Validate(req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
@@ -44,6 +55,29 @@ func (r *Registry) Execute(ctx context.Context, req *Request) (*Response, error)
 	return p.Execute(ctx, req)
 }
 
+// Close closes the protocols that hold connections, i.e. those that
+// implement io.Closer. It gives up waiting when ctx is done.
+func (r *Registry) Close(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		var errs []error
+		for _, name := range r.Names() {
+			if c, ok := r.protocols[name].(io.Closer); ok {
+				if err := c.Close(); err != nil {
+					errs = append(errs, fmt.Errorf("closing %s: %w", name, err))
+				}
+			}
+		}
+		done <- errors.Join(errs...)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Names returns all registered protocol names.
 func (r *Registry) Names() []string {
 	names := make([]string, 0, len(r.protocols))
@@ -52,3 +86,89 @@ func (r *Registry) Names() []string {
 	}
 	return names
 }
+
+// Builtin lists the protocols every gottp build registers.
+var Builtin = []string{"http", "graphql", "websocket", "grpc"}
+
+// aliases map other spellings of a protocol to its registered name.
+var aliases = map[string]string{
+	"":      "http",
+	"https": "http",
+	"rest":  "http",
+	"gql":   "graphql",
+	"ws":    "websocket",
+	"wss":   "websocket",
+}
+
+// CanonicalName returns the registered name for a protocol field value.
+func CanonicalName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := aliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// UnsupportedError reports a request whose protocol has no registered
+// client.
+type UnsupportedError struct {
+	Protocol  string
+	Available []string
+}
+
+func (e *UnsupportedError) Error() string {
+	available := append([]string(nil), e.Available...)
+	sort.Strings(available)
+	return fmt.Sprintf("unknown protocol: %s (this build supports %s)", e.Protocol, strings.Join(available, ", "))
+}
+
+// Hint suggests how to make the request runnable.
+func (e *UnsupportedError) Hint() string {
+	if guess := closestName(CanonicalName(e.Protocol), e.Available); guess != "" {
+		return fmt.Sprintf("did you mean protocol: %s? Fix the request's protocol field", guess)
+	}
+	return "set the request's protocol to one of the supported ones, or upgrade gottp to a version that supports " + e.Protocol
+}
+
+// CheckProtocol returns an *UnsupportedError if name is not one of the
+// available protocols.
+func CheckProtocol(name string, available []string) error {
+	canonical := CanonicalName(name)
+	for _, a := range available {
+		if a == canonical {
+			return nil
+		}
+	}
+	return &UnsupportedError{Protocol: name, Available: available}
+}
+
+// closestName returns the name within two edits of s, if any.
+func closestName(s string, names []string) string {
+	best, bestDist := "", 3
+	for _, name := range names {
+		if d := editDistance(s, name); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
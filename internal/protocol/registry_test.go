@@ -129,3 +129,76 @@ func TestRegistryExecuteReturnsProtocolExecutionError(t *testing.T) {
 		t.Fatalf("unexpected calls validate=%d execute=%d", httpProtocol.validateCalls, httpProtocol.executeCalls)
 	}
 }
+
+func TestRegistryLookupAliasesAndUnsupported(t *testing.T) {
+	r := NewRegistry()
+	ws := &stubProtocol{name: "websocket"}
+	r.Register(ws)
+	r.Register(&stubProtocol{name: "http"})
+
+	for _, name := range []string{"websocket", "WS", "wss"} {
+		if got, err := r.Lookup(name); err != nil || got != ws {
+			t.Errorf("Lookup(%q) = (%v, %v), want websocket", name, got, err)
+		}
+	}
+
+	_, err := r.Lookup("websockt")
+	var unsupported *UnsupportedError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Lookup(websockt) error = %v, want *UnsupportedError", err)
+	}
+	if !strings.Contains(err.Error(), "this build supports http, websocket") {
+		t.Errorf("error does not list available protocols: %v", err)
+	}
+	if !strings.Contains(unsupported.Hint(), "did you mean protocol: websocket") {
+		t.Errorf("Hint() = %q, want a suggestion", unsupported.Hint())
+	}
+
+	if err := CheckProtocol("mqtt", Builtin); err == nil || !strings.Contains(err.(*UnsupportedError).Hint(), "upgrade gottp") {
+		t.Errorf("CheckProtocol(mqtt) = %v, want unsupported with upgrade hint", err)
+	}
+	if err := CheckProtocol("", Builtin); err != nil {
+		t.Errorf("CheckProtocol(\"\") = %v, want http", err)
+	}
+}
+
+type closingProtocol struct {
+	stubProtocol
+	closeErr error
+	block    chan struct{}
+	closed   bool
+}
+
+func (c *closingProtocol) Close() error {
+	if c.block != nil {
+		<-c.block
+	}
+	c.closed = true
+	return c.closeErr
+}
+
+func TestRegistryClose(t *testing.T) {
+	r := NewRegistry()
+	grpcProtocol := &closingProtocol{stubProtocol: stubProtocol{name: "grpc"}, closeErr: errors.New("conn reset")}
+	wsProtocol := &closingProtocol{stubProtocol: stubProtocol{name: "websocket"}}
+	r.Register(&stubProtocol{name: "http"})
+	r.Register(grpcProtocol)
+	r.Register(wsProtocol)
+
+	err := r.Close(context.Background())
+	if !grpcProtocol.closed || !wsProtocol.closed {
+		t.Error("every closable protocol should be closed")
+	}
+	if err == nil || !strings.Contains(err.Error(), "closing grpc: conn reset") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	stuck := &closingProtocol{stubProtocol: stubProtocol{name: "grpc"}, block: make(chan struct{})}
+	defer close(stuck.block)
+	r.Register(stuck)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.Close(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("a protocol that doesn't close should not hold up shutdown, got %v", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/coder/websocket"
+	"github.com/sadopc/gottp/internal/core/retry"
 	"github.com/sadopc/gottp/internal/protocol"
 )
 
@@ -27,6 +28,14 @@ type Client struct {
 	mu        sync.Mutex
 	conn      *websocket.Conn
 	connected bool
+	proxy     *protocol.ProxyConfig
+	hosts     protocol.Hosts
+	reconnect *retry.Policy
+
+	// Handshake of the last successful Connect, reused by Reconnect
+	url     string
+	headers map[string]string
+	auth    *protocol.AuthConfig
 }
 
 // New creates a new WebSocket client.
@@ -57,6 +66,9 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 	c.mu.Unlock()
 
 	if !alreadyConnected {
+		c.SetProxy(req.Proxy)
+		c.SetHosts(req.Hosts)
+		c.SetReconnect(req.WSReconnect)
 		start := time.Now()
 		if err := c.Connect(ctx, req.URL, req.Headers, req.Auth); err != nil {
 			return nil, fmt.Errorf("websocket connect: %w", err)
@@ -106,6 +118,29 @@ func (c *Client) Execute(ctx context.Context, req *protocol.Request) (*protocol.
 	}, nil
 }
 
+// SetProxy routes the next connection through p.
+func (c *Client) SetProxy(p *protocol.ProxyConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.proxy = p
+}
+
+// SetHosts sends the next connection for overridden hosts to their new
+// address.
+func (c *Client) SetHosts(h protocol.Hosts) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hosts = h
+}
+
+// SetReconnect sets the policy RunSession uses to re-dial after the
+// connection drops. nil or MaxAttempts 0 disables reconnecting.
+func (c *Client) SetReconnect(p *retry.Policy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reconnect = p
+}
+
 // Connect establishes a WebSocket connection to the given URL. Custom headers
 // and auth configuration are applied to the initial HTTP handshake.
 func (c *Client) Connect(ctx context.Context, url string, headers map[string]string, auth *protocol.AuthConfig) error {
@@ -123,7 +158,13 @@ func (c *Client) Connect(ctx context.Context, url string, headers map[string]str
 
 	applyAuth(httpHeaders, auth)
 
+	transport, err := protocol.ProxyTransport(c.proxy)
+	if err != nil {
+		return fmt.Errorf("configuring transport: %w", err)
+	}
+
 	conn, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{
+		HTTPClient: &http.Client{Transport: c.hosts.Transport(transport)},
 		HTTPHeader: httpHeaders,
 	})
 	if err != nil {
@@ -132,9 +173,27 @@ func (c *Client) Connect(ctx context.Context, url string, headers map[string]str
 
 	c.conn = conn
 	c.connected = true
+	c.url, c.headers, c.auth = url, headers, auth
 	return nil
 }
 
+// Reconnect drops the current connection, if any, and dials again with the
+// URL, headers and auth of the last successful Connect.
+func (c *Client) Reconnect(ctx context.Context) error {
+	c.mu.Lock()
+	if c.url == "" {
+		c.mu.Unlock()
+		return fmt.Errorf("never connected")
+	}
+	if c.conn != nil {
+		c.conn.CloseNow()
+	}
+	c.conn, c.connected = nil, false
+	url, headers, auth := c.url, c.headers, c.auth
+	c.mu.Unlock()
+	return c.Connect(ctx, url, headers, auth)
+}
+
 // Send writes a text message on the open WebSocket connection.
 func (c *Client) Send(ctx context.Context, content string) error {
 	c.mu.Lock()
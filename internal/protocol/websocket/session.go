@@ -0,0 +1,348 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// DefaultExpectTimeout is how long an expectation waits for a matching message.
+const DefaultExpectTimeout = 5 * time.Second
+
+// EventKind identifies a session event.
+type EventKind int
+
+const (
+	EventSent EventKind = iota
+	EventReceived
+	EventAssertion
+	EventScriptDone
+	EventError
+	EventReconnecting // the connection dropped; Content describes the next attempt
+	EventReconnected
+)
+
+// Event reports session activity: scripted sends, received messages,
+// expectation outcomes and errors.
+type Event struct {
+	Kind      EventKind
+	Name      string // scripted message name (sent/assertion events)
+	Content   string
+	IsJSON    bool
+	Passed    bool // assertion outcome
+	Err       error
+	Timestamp time.Time
+}
+
+// Ping sends a ping and waits for the pong. A concurrent reader (such as
+// ReadMessages) must be running for the pong to be processed.
+func (c *Client) Ping(ctx context.Context) error {
+	c.mu.Lock()
+	conn := c.conn
+	connected := c.connected
+	c.mu.Unlock()
+
+	if !connected || conn == nil {
+		return fmt.Errorf("not connected")
+	}
+	return conn.Ping(ctx)
+}
+
+// RunSession drives an established connection: it sends the scripted
+// messages in order (honouring delays), checks expectations against
+// incoming messages, and sends keepalive pings every pingInterval. All
+// activity is reported on the returned channel, which is closed when the
+// connection ends or ctx is cancelled. Received messages keep flowing after
+// the script completes (signalled by EventScriptDone).
+//
+// With a reconnect policy set, a connection that drops with an error is
+// dialed again with backoff and the script is replayed, restoring any
+// subscriptions it makes. Attempts are reported as EventReconnecting and
+// EventReconnected.
+func (c *Client) RunSession(ctx context.Context, script []protocol.WSScriptMessage, pingInterval time.Duration) <-chan Event {
+	events := make(chan Event, 16)
+	stop := make(chan struct{})
+
+	// recv is replaced on every reconnect; dropErr records why the last
+	// connection ended (nil for a normal closure).
+	var recv chan WSClientMessage
+	var dropErr error
+	listen := func() {
+		recv = make(chan WSClientMessage, 16)
+		dropErr = nil
+		go c.ReadMessages(ctx, recv)
+	}
+	listen()
+
+	emit := func(e Event) bool {
+		e.Timestamp = time.Now()
+		select {
+		case events <- e:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	var wg sync.WaitGroup
+	if pingInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(pingInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if !c.IsConnected() {
+						continue // reconnecting
+					}
+					pingCtx, cancel := context.WithTimeout(ctx, pingInterval)
+					err := c.Ping(pingCtx)
+					cancel()
+					if err != nil && ctx.Err() == nil {
+						select {
+						case events <- Event{Kind: EventError, Err: fmt.Errorf("ping: %w", err), Timestamp: time.Now()}:
+						case <-stop:
+							return
+						}
+					}
+				case <-stop:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	// forward relays received messages until d elapses (d <= 0 waits
+	// indefinitely) or match accepts one. It reports whether a message
+	// matched and whether the connection is still usable.
+	forward := func(d time.Duration, match func(string) bool) (matched, open bool) {
+		var timeout <-chan time.Time
+		if d > 0 {
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		for {
+			select {
+			case msg, ok := <-recv:
+				if !ok {
+					return false, false
+				}
+				if msg.Err != nil {
+					dropErr = msg.Err
+					emit(Event{Kind: EventError, Err: msg.Err})
+					continue
+				}
+				if !emit(Event{Kind: EventReceived, Content: msg.Content, IsJSON: msg.IsJSON}) {
+					return false, false
+				}
+				if match != nil && match(msg.Content) {
+					return true, true
+				}
+			case <-timeout:
+				return false, true
+			case <-ctx.Done():
+				return false, false
+			}
+		}
+	}
+
+	// runScript sends the scripted messages and reports whether the
+	// connection is still open afterwards.
+	runScript := func() bool {
+		for _, m := range script {
+			if m.Delay > 0 {
+				if _, open := forward(m.Delay, nil); !open {
+					return false
+				}
+			}
+
+			if err := c.Send(ctx, m.Content); err != nil {
+				dropErr = err
+				emit(Event{Kind: EventError, Name: m.Name, Err: fmt.Errorf("sending %q: %w", m.Name, err)})
+				return false
+			}
+			if !emit(Event{Kind: EventSent, Name: m.Name, Content: m.Content, IsJSON: looksLikeJSON(m.Content)}) {
+				return false
+			}
+
+			if m.Expect == nil {
+				continue
+			}
+			match, err := NewMatcher(m.Expect)
+			if err != nil {
+				emit(Event{Kind: EventAssertion, Name: m.Name, Err: err})
+				continue
+			}
+			timeout := m.Expect.Timeout
+			if timeout <= 0 {
+				timeout = DefaultExpectTimeout
+			}
+			matched, open := forward(timeout, match)
+			if matched {
+				emit(Event{Kind: EventAssertion, Name: m.Name, Passed: true})
+			} else {
+				emit(Event{Kind: EventAssertion, Name: m.Name,
+					Err: fmt.Errorf("no matching message within %s", timeout)})
+			}
+			if !open {
+				return false
+			}
+		}
+		return true
+	}
+
+	// reconnect re-dials with backoff until the policy's attempts run out.
+	reconnect := func() bool {
+		c.mu.Lock()
+		policy := c.reconnect
+		c.mu.Unlock()
+		if policy == nil || policy.MaxAttempts <= 0 || dropErr == nil || ctx.Err() != nil {
+			return false
+		}
+		for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+			delay := policy.Delay(attempt)
+			if !emit(Event{Kind: EventReconnecting, Err: dropErr, Content: fmt.Sprintf(
+				"connection lost (%v), reconnecting in %s (attempt %d/%d)",
+				dropErr, delay.Round(time.Millisecond), attempt, policy.MaxAttempts)}) {
+				return false
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return false
+			case <-timer.C:
+			}
+			if err := c.Reconnect(ctx); err != nil {
+				dropErr = err
+				continue
+			}
+			return emit(Event{Kind: EventReconnected, Content: fmt.Sprintf("reconnected after %d attempt(s)", attempt)})
+		}
+		emit(Event{Kind: EventError, Err: fmt.Errorf("giving up after %d reconnect attempts: %w", policy.MaxAttempts, dropErr)})
+		return false
+	}
+
+	go func() {
+		defer func() {
+			close(stop)
+			wg.Wait()
+			close(events)
+		}()
+
+		scriptDone := false
+		for {
+			if runScript() {
+				if !scriptDone {
+					scriptDone = true
+					if !emit(Event{Kind: EventScriptDone}) {
+						return
+					}
+				}
+				forward(0, nil)
+			}
+			if !reconnect() {
+				return
+			}
+			listen()
+		}
+	}()
+
+	return events
+}
+
+// NewMatcher compiles an expectation into a predicate over message content.
+func NewMatcher(exp *protocol.WSExpectation) (func(string) bool, error) {
+	var re *regexp.Regexp
+	if exp.Matches != "" {
+		var err error
+		if re, err = regexp.Compile(exp.Matches); err != nil {
+			return nil, fmt.Errorf("invalid expect pattern: %w", err)
+		}
+	}
+	return func(content string) bool {
+		if exp.Equals != "" && strings.TrimSpace(content) != strings.TrimSpace(exp.Equals) {
+			return false
+		}
+		if exp.Contains != "" && !strings.Contains(content, exp.Contains) {
+			return false
+		}
+		if re != nil && !re.MatchString(content) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// RunScript connects with a dedicated client, runs the request's scripted
+// messages and closes the connection. The response body is a transcript of
+// the exchange; assertion events are returned separately.
+func RunScript(ctx context.Context, req *protocol.Request) (*protocol.Response, []Event, error) {
+	c := New()
+	c.SetProxy(req.Proxy)
+	c.SetHosts(req.Hosts)
+	c.SetReconnect(req.WSReconnect)
+	start := time.Now()
+	if err := c.Connect(ctx, req.URL, req.Headers, req.Auth); err != nil {
+		return nil, nil, fmt.Errorf("websocket connect: %w", err)
+	}
+	defer c.Close()
+
+	sessCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var transcript strings.Builder
+	var assertions []Event
+	var sessionErr error
+	done := false
+	for ev := range c.RunSession(sessCtx, req.WSMessages, req.WSPingInterval) {
+		switch ev.Kind {
+		case EventSent:
+			fmt.Fprintf(&transcript, ">>> %s\n", ev.Content)
+		case EventReceived:
+			fmt.Fprintf(&transcript, "<<< %s\n", ev.Content)
+		case EventReconnecting, EventReconnected:
+			fmt.Fprintf(&transcript, "--- %s\n", ev.Content)
+		case EventAssertion:
+			assertions = append(assertions, ev)
+		case EventError:
+			// Errors after the script finished come from our own shutdown.
+			if !done && sessionErr == nil {
+				sessionErr = ev.Err
+			}
+		case EventScriptDone:
+			done = true
+			cancel()
+		}
+	}
+
+	body := []byte(transcript.String())
+	resp := &protocol.Response{
+		StatusCode:  101,
+		Status:      "101 Switching Protocols",
+		Headers:     http.Header{"Upgrade": []string{"websocket"}},
+		Body:        body,
+		ContentType: "text/plain",
+		Duration:    time.Since(start),
+		Size:        int64(len(body)),
+		Proto:       "websocket",
+		TLS:         strings.HasPrefix(req.URL, "wss:"),
+	}
+	return resp, assertions, sessionErr
+}
+
+func looksLikeJSON(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")
+}
@@ -0,0 +1,191 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/sadopc/gottp/internal/core/retry"
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+func TestRunScript_SendsInOrderAndAsserts(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	req := &protocol.Request{
+		URL: wsURL(server),
+		WSMessages: []protocol.WSScriptMessage{
+			{Name: "hello", Content: "hello", Expect: &protocol.WSExpectation{Equals: "hello"}},
+			{Name: "json", Content: `{"op":"sub"}`, Delay: 20 * time.Millisecond,
+				Expect: &protocol.WSExpectation{Contains: `"op"`, Matches: `sub`}},
+			{Name: "fire-and-forget", Content: "bye"},
+		},
+		WSPingInterval: 10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, assertions, err := RunScript(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 101 {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+	if len(assertions) != 2 {
+		t.Fatalf("expected 2 assertions, got %d", len(assertions))
+	}
+	for _, a := range assertions {
+		if !a.Passed {
+			t.Errorf("assertion %q failed: %v", a.Name, a.Err)
+		}
+	}
+	body := string(resp.Body)
+	if !strings.HasPrefix(body, ">>> hello\n<<< hello\n>>> {\"op\":\"sub\"}") {
+		t.Errorf("unexpected transcript:\n%s", body)
+	}
+}
+
+func TestRunScript_ExpectationTimeout(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	req := &protocol.Request{
+		URL: wsURL(server),
+		WSMessages: []protocol.WSScriptMessage{
+			{Name: "ping", Content: "ping", Expect: &protocol.WSExpectation{
+				Equals:  "pong",
+				Timeout: 50 * time.Millisecond,
+			}},
+		},
+	}
+
+	_, assertions, err := RunScript(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assertions) != 1 || assertions[0].Passed {
+		t.Fatalf("expected failed assertion, got %+v", assertions)
+	}
+	if assertions[0].Err == nil {
+		t.Error("expected assertion error message")
+	}
+}
+
+func TestRunSession_ReconnectsAndReplaysScript(t *testing.T) {
+	var conns atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		n := conns.Add(1)
+		_, data, err := conn.Read(r.Context())
+		if err != nil {
+			return
+		}
+		conn.Write(r.Context(), websocket.MessageText, []byte(fmt.Sprintf("%s-%d", data, n)))
+		if n == 1 {
+			return // drop the first connection without a close frame
+		}
+		conn.Read(r.Context())
+	}))
+	defer server.Close()
+
+	c := New()
+	c.SetReconnect(&retry.Policy{MaxAttempts: 3, Backoff: time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx, wsURL(server), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var kinds []EventKind
+	var received []string
+	for ev := range c.RunSession(ctx, []protocol.WSScriptMessage{{Name: "sub", Content: "subscribed"}}, 0) {
+		kinds = append(kinds, ev.Kind)
+		if ev.Kind == EventReceived {
+			received = append(received, ev.Content)
+		}
+		if len(received) == 2 {
+			cancel()
+		}
+	}
+
+	if fmt.Sprint(received) != "[subscribed-1 subscribed-2]" {
+		t.Fatalf("received %v, want the script replayed on the new connection", received)
+	}
+	var reconnecting, reconnected, done int
+	for _, k := range kinds {
+		switch k {
+		case EventReconnecting:
+			reconnecting++
+		case EventReconnected:
+			reconnected++
+		case EventScriptDone:
+			done++
+		}
+	}
+	if reconnecting != 1 || reconnected != 1 || done != 1 {
+		t.Errorf("events = %v", kinds)
+	}
+}
+
+func TestRunSession_GivesUpAfterMaxAttempts(t *testing.T) {
+	var conns atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conns.Add(1) > 1 {
+			http.Error(w, "gone", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.CloseNow()
+	}))
+	defer server.Close()
+
+	c := New()
+	c.SetReconnect(&retry.Policy{MaxAttempts: 2, Backoff: time.Millisecond})
+	if err := c.Connect(context.Background(), wsURL(server), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	var last Event
+	attempts := 0
+	for ev := range c.RunSession(context.Background(), nil, 0) {
+		if ev.Kind == EventReconnecting {
+			attempts++
+		}
+		last = ev
+	}
+	if attempts != 2 || last.Kind != EventError || !strings.Contains(last.Err.Error(), "giving up after 2") {
+		t.Errorf("attempts = %d, last event = %+v", attempts, last)
+	}
+}
+
+func TestNewMatcher(t *testing.T) {
+	if _, err := NewMatcher(&protocol.WSExpectation{Matches: "("}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	match, err := NewMatcher(&protocol.WSExpectation{Contains: "ok", Matches: `^\{`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match(`{"status":"ok"}`) {
+		t.Error("expected match")
+	}
+	if match(`status ok`) {
+		t.Error("pattern should not match")
+	}
+}
@@ -5,8 +5,12 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/sadopc/gottp/internal/core/retry"
+	"github.com/sadopc/gottp/internal/protocol"
 )
 
 // PrintText outputs results in human-readable format.
@@ -39,6 +43,13 @@ func PrintText(w io.Writer, results []Result, verbose bool) {
 				statusStr, durationStr, sizeStr)
 		}
 
+		if r.TokenRefreshed {
+			fmt.Fprintf(w, "  \u21bb OAuth2 token refreshed after 401, request retried\n")
+		}
+		if line := formatRetry(r.Retry); line != "" {
+			fmt.Fprintf(w, "  \u21bb %s\n", line)
+		}
+
 		// Print test results
 		for _, tr := range r.TestResults {
 			if tr.Passed {
@@ -57,6 +68,10 @@ func PrintText(w io.Writer, results []Result, verbose bool) {
 			}
 		}
 
+		if verbose && r.Timing != nil {
+			fmt.Fprintf(w, "  [timing] %s\n", formatTiming(r.Timing))
+		}
+
 		// Print response body in verbose mode
 		if verbose && len(r.Body) > 0 {
 			fmt.Fprintf(w, "  --- Response Body ---\n")
@@ -83,20 +98,41 @@ func PrintJSON(w io.Writer, results []Result) error {
 	return enc.Encode(results)
 }
 
+// RunInfo describes a run in report headers.
+type RunInfo struct {
+	Collection  string
+	Environment string
+	Version     string // of gottp
+	Workflow    string // when running one
+	Failure     string // why the workflow stopped, if it failed
+	File        string // collection file that Result.Line refers to, if any
+}
+
 // junitTestSuites is the root JUnit XML element.
 type junitTestSuites struct {
-	XMLName xml.Name         `xml:"testsuites"`
-	Suites  []junitTestSuite `xml:"testsuite"`
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr,omitempty"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
 }
 
 type junitTestSuite struct {
-	XMLName  xml.Name        `xml:"testsuite"`
-	Name     string          `xml:"name,attr"`
-	Tests    int             `xml:"tests,attr"`
-	Failures int             `xml:"failures,attr"`
-	Errors   int             `xml:"errors,attr"`
-	Time     float64         `xml:"time,attr"`
-	Cases    []junitTestCase `xml:"testcase"`
+	XMLName    xml.Name        `xml:"testsuite"`
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       float64         `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
 }
 
 type junitTestCase struct {
@@ -106,6 +142,8 @@ type junitTestCase struct {
 	Time      float64       `xml:"time,attr"`
 	Failure   *junitFailure `xml:"failure,omitempty"`
 	Error     *junitError   `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
 }
 
 type junitFailure struct {
@@ -120,14 +158,20 @@ type junitError struct {
 	Content string `xml:",chardata"`
 }
 
-// PrintJUnit outputs results as JUnit XML for CI.
-func PrintJUnit(w io.Writer, results []Result) error {
-	suites := junitTestSuites{}
+// PrintJUnit outputs results as JUnit XML for CI, a suite per request.
+// Each suite lists the run's collection, environment and gottp version and
+// the request's method, URL and status as properties. Every test case
+// takes the request's duration and carries the script logs as system-out,
+// and a failure on a 4xx or 5xx response carries the response as
+// system-err.
+func PrintJUnit(w io.Writer, results []Result, info RunInfo) error {
+	suites := junitTestSuites{Name: info.Collection}
 
 	for _, r := range results {
 		suite := junitTestSuite{
-			Name: r.Name,
-			Time: r.Duration.Seconds(),
+			Name:       r.Name,
+			Time:       r.Duration.Seconds(),
+			Properties: junitProperties(info, r),
 		}
 
 		// If request had an error, add it as an error test case
@@ -182,9 +226,25 @@ func PrintJUnit(w io.Writer, results []Result) error {
 			suite.Cases = append(suite.Cases, tc)
 		}
 
-		suites.Suites = append(suites.Suites, suite)
+		for i := range suite.Cases {
+			attachOutput(&suite.Cases[i], r)
+		}
+		suites.add(suite)
 	}
 
+	return writeJUnit(w, suites)
+}
+
+// add appends suite, adding its counts to the totals.
+func (s *junitTestSuites) add(suite junitTestSuite) {
+	s.Tests += suite.Tests
+	s.Failures += suite.Failures
+	s.Errors += suite.Errors
+	s.Time += suite.Time
+	s.Suites = append(s.Suites, suite)
+}
+
+func writeJUnit(w io.Writer, suites junitTestSuites) error {
 	fmt.Fprint(w, xml.Header)
 	enc := xml.NewEncoder(w)
 	enc.Indent("", "  ")
@@ -228,6 +288,32 @@ func truncate(s string, max int) string {
 	return s[:max-3] + "..."
 }
 
+// formatRetry describes the retries made for a request, or returns "" if
+// there were none.
+func formatRetry(s *retry.Stats) string {
+	if s == nil || s.Attempts < 2 {
+		return ""
+	}
+	return fmt.Sprintf("%d attempts, retried on %s (waited %s)",
+		s.Attempts, strings.Join(s.Reasons, ", "), formatDuration(s.Waited))
+}
+
+// formatTiming renders a one-line breakdown of a request's phases.
+func formatTiming(td *protocol.TimingDetail) string {
+	s := fmt.Sprintf("dns %s, connect %s, tls %s, server %s, transfer %s",
+		formatDuration(td.DNSLookup), formatDuration(td.TCPConnect),
+		formatDuration(td.TLSHandshake), formatDuration(td.TTFB),
+		formatDuration(td.Transfer))
+	if td.ConnReused {
+		s += " (connection reused"
+		if td.ConnIdle > 0 {
+			s += " after " + formatDuration(td.ConnIdle) + " idle"
+		}
+		s += ")"
+	}
+	return s
+}
+
 // PrintWorkflowText outputs workflow results in human-readable format.
 func PrintWorkflowText(w io.Writer, wf *WorkflowResult, verbose bool) {
 	fmt.Fprintf(w, "Workflow: %s\n", wf.Name)
@@ -254,6 +340,10 @@ func PrintWorkflowText(w io.Writer, wf *WorkflowResult, verbose bool) {
 				statusStr, durationStr, sizeStr)
 		}
 
+		if line := formatRetry(step.Retry); line != "" {
+			fmt.Fprintf(w, "  \u21bb %s\n", line)
+		}
+
 		for _, tr := range step.TestResults {
 			if tr.Passed {
 				fmt.Fprintf(w, "  \u2713 %s\n", tr.Name)
@@ -267,6 +357,9 @@ func PrintWorkflowText(w io.Writer, wf *WorkflowResult, verbose bool) {
 				fmt.Fprintf(w, "  [log] %s\n", log)
 			}
 		}
+		if verbose && step.Timing != nil {
+			fmt.Fprintf(w, "  [timing] %s\n", formatTiming(step.Timing))
+		}
 	}
 
 	fmt.Fprintln(w)
@@ -284,9 +377,11 @@ func PrintWorkflowJSON(w io.Writer, wf *WorkflowResult) error {
 	return enc.Encode(wf)
 }
 
-// PrintWorkflowJUnit outputs workflow results as JUnit XML.
-func PrintWorkflowJUnit(w io.Writer, wf *WorkflowResult) error {
-	suites := junitTestSuites{}
+// PrintWorkflowJUnit outputs workflow results as JUnit XML: one suite
+// with a test case per step, with the same properties and output as
+// PrintJUnit.
+func PrintWorkflowJUnit(w io.Writer, wf *WorkflowResult, info RunInfo) error {
+	suites := junitTestSuites{Name: info.Collection}
 
 	var totalTime float64
 	for _, step := range wf.Steps {
@@ -294,9 +389,10 @@ func PrintWorkflowJUnit(w io.Writer, wf *WorkflowResult) error {
 	}
 
 	suite := junitTestSuite{
-		Name:  "Workflow: " + wf.Name,
-		Tests: len(wf.Steps),
-		Time:  totalTime,
+		Name:       "Workflow: " + wf.Name,
+		Tests:      len(wf.Steps),
+		Time:       totalTime,
+		Properties: append(junitProperties(info, Result{}), junitProperty{Name: "workflow", Value: wf.Name}),
 	}
 
 	for i, step := range wf.Steps {
@@ -328,6 +424,7 @@ func PrintWorkflowJUnit(w io.Writer, wf *WorkflowResult) error {
 			}
 		}
 
+		attachOutput(&tc, step)
 		suite.Cases = append(suite.Cases, tc)
 	}
 
@@ -345,16 +442,44 @@ func PrintWorkflowJUnit(w io.Writer, wf *WorkflowResult) error {
 		})
 	}
 
-	suites.Suites = append(suites.Suites, suite)
+	suites.add(suite)
+	return writeJUnit(w, suites)
+}
 
-	fmt.Fprint(w, xml.Header)
-	enc := xml.NewEncoder(w)
-	enc.Indent("", "  ")
-	if err := enc.Encode(suites); err != nil {
-		return err
+// junitProperties lists the run's details and, for a request, its method,
+// URL and status.
+func junitProperties(info RunInfo, r Result) []junitProperty {
+	var props []junitProperty
+	add := func(name, value string) {
+		if value != "" {
+			props = append(props, junitProperty{Name: name, Value: value})
+		}
+	}
+	add("collection", info.Collection)
+	add("environment", info.Environment)
+	add("gottp.version", info.Version)
+	add("method", r.Method)
+	add("url", r.URL)
+	if r.StatusCode != 0 {
+		add("status", strconv.Itoa(r.StatusCode))
+	}
+	return props
+}
+
+// attachOutput adds r's script logs to tc and, when tc failed on a 4xx or
+// 5xx response, the response's status and body.
+func attachOutput(tc *junitTestCase, r Result) {
+	tc.SystemOut = strings.Join(r.ScriptLogs, "\n")
+	if tc.Failure == nil || r.StatusCode < 400 {
+		return
+	}
+	tc.SystemErr = r.Status
+	if tc.SystemErr == "" {
+		tc.SystemErr = fmt.Sprintf("HTTP %d", r.StatusCode)
+	}
+	if r.ErrorBody != "" {
+		tc.SystemErr += "\n\n" + r.ErrorBody
 	}
-	fmt.Fprintln(w)
-	return nil
 }
 
 // PrintPerfComparison outputs performance comparison results.
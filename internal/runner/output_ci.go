@@ -0,0 +1,242 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// passed reports whether r counts as passing: it was sent and its tests
+// passed. A 4xx or 5xx response without tests passes, as in ExitCode.
+func passed(r Result) bool {
+	return r.Error == nil && r.TestsPassed
+}
+
+// errorText is r's error as shown to users, with secrets masked.
+func errorText(r Result) string {
+	if r.ErrorString != "" {
+		return r.ErrorString
+	}
+	return r.Error.Error()
+}
+
+// PrintTAP outputs results as TAP version 13, a test point per request.
+// Failing points carry a YAML block with the request, its status and the
+// failed tests or error.
+func PrintTAP(w io.Writer, results []Result) {
+	fmt.Fprintln(w, "TAP version 13")
+	fmt.Fprintf(w, "1..%d\n", len(results))
+	for i, r := range results {
+		status := "ok"
+		if !passed(r) {
+			status = "not ok"
+		}
+		fmt.Fprintf(w, "%s %d - %s\n", status, i+1, tapEscape(r.Name))
+		if passed(r) {
+			continue
+		}
+
+		fmt.Fprintln(w, "  ---")
+		fmt.Fprintf(w, "  method: %s\n", strconv.Quote(r.Method))
+		fmt.Fprintf(w, "  url: %s\n", strconv.Quote(r.URL))
+		if r.StatusCode != 0 {
+			fmt.Fprintf(w, "  status: %d\n", r.StatusCode)
+		}
+		fmt.Fprintf(w, "  duration_ms: %d\n", r.Duration.Milliseconds())
+		if r.Error != nil {
+			fmt.Fprintf(w, "  error: %s\n", strconv.Quote(errorText(r)))
+		}
+		var failed []string
+		for _, tr := range r.TestResults {
+			if !tr.Passed {
+				failed = append(failed, tr.Name+": "+tr.Error)
+			}
+		}
+		if len(failed) > 0 {
+			fmt.Fprintln(w, "  failures:")
+			for _, f := range failed {
+				fmt.Fprintf(w, "    - %s\n", strconv.Quote(f))
+			}
+		}
+		if len(r.ScriptLogs) > 0 {
+			fmt.Fprintln(w, "  logs:")
+			for _, l := range r.ScriptLogs {
+				fmt.Fprintf(w, "    - %s\n", strconv.Quote(l))
+			}
+		}
+		fmt.Fprintln(w, "  ...")
+	}
+}
+
+// tapEscape keeps a description on its line and stops a # in it from
+// starting a directive.
+func tapEscape(s string) string {
+	s = strings.NewReplacer("\r", " ", "\n", " ", "#", `\#`).Replace(s)
+	return s
+}
+
+// PrintGitHub outputs a line per request, as text output does, followed by
+// a GitHub Actions annotation for each failure: an ::error for a request
+// error or failed test, pointing at the request's line in the collection
+// file, and a ::warning for a 4xx or 5xx response nothing tested.
+func PrintGitHub(w io.Writer, results []Result, info RunInfo) {
+	failed, errored := 0, 0
+	for _, r := range results {
+		icon := "✓"
+		if !passed(r) {
+			icon = "✗"
+		}
+		status := "-"
+		if r.StatusCode != 0 {
+			status = strconv.Itoa(r.StatusCode)
+		}
+		fmt.Fprintf(w, "%s %s  %s %s  %s  %s\n", icon, r.Name, r.Method, r.URL, status, formatDuration(r.Duration))
+
+		location := ghLocation(info, r)
+		switch {
+		case r.Error != nil:
+			errored++
+			fmt.Fprintf(w, "::error %stitle=%s::%s\n", location, ghProperty(r.Name), ghMessage(errorText(r)))
+		case !r.TestsPassed:
+			failed++
+			for _, tr := range r.TestResults {
+				if !tr.Passed {
+					fmt.Fprintf(w, "::error %stitle=%s::%s\n", location, ghProperty(r.Name+": "+tr.Name), ghMessage(tr.Error))
+				}
+			}
+		case r.StatusCode >= 400 && len(r.TestResults) == 0:
+			fmt.Fprintf(w, "::warning %stitle=%s::%s\n", location, ghProperty(r.Name), ghMessage(r.Method+" "+r.URL+" returned "+r.Status))
+		}
+	}
+	fmt.Fprintf(w, "\nRequests: %d total, %d failed, %d errors\n", len(results), failed, errored)
+}
+
+// ghLocation is the file and line properties of r's annotation, with a
+// trailing comma, or "" when the file isn't known.
+func ghLocation(info RunInfo, r Result) string {
+	if info.File == "" {
+		return ""
+	}
+	loc := "file=" + ghProperty(info.File)
+	if r.Line > 0 {
+		loc += ",line=" + strconv.Itoa(r.Line)
+	}
+	return loc + ","
+}
+
+// ghMessage escapes an annotation message for a workflow command.
+func ghMessage(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// ghProperty escapes an annotation property, which also can't hold the
+// separators between properties.
+func ghProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// PrintMarkdown outputs a summary table of the run, a row per request, for
+// a pull request comment or $GITHUB_STEP_SUMMARY, followed by the failed
+// tests and errors.
+func PrintMarkdown(w io.Writer, results []Result, info RunInfo) {
+	title := "gottp run"
+	switch {
+	case info.Workflow != "":
+		title += ": " + info.Workflow
+	case info.Collection != "":
+		title += ": " + info.Collection
+	}
+	if info.Environment != "" {
+		title += " (" + info.Environment + ")"
+	}
+	fmt.Fprintf(w, "### %s\n\n", mdEscape(title))
+
+	fmt.Fprintln(w, "| | Request | Status | Time | Tests |")
+	fmt.Fprintln(w, "|---|---|---|---|---|")
+	var total time.Duration
+	ok, testsPassed, tests := 0, 0, 0
+	var problems []string
+	for _, r := range results {
+		total += r.Duration
+		icon := "✅"
+		if passed(r) {
+			ok++
+		} else {
+			icon = "❌"
+		}
+		status := "—"
+		if r.StatusCode != 0 {
+			status = strconv.Itoa(r.StatusCode)
+		}
+		n := 0
+		for _, tr := range r.TestResults {
+			if tr.Passed {
+				n++
+			} else {
+				problems = append(problems, fmt.Sprintf("**%s** — %s: %s", mdEscape(r.Name), mdEscape(tr.Name), mdEscape(tr.Error)))
+			}
+		}
+		testsPassed += n
+		tests += len(r.TestResults)
+		testCell := "—"
+		if len(r.TestResults) > 0 {
+			testCell = fmt.Sprintf("%d/%d", n, len(r.TestResults))
+		}
+		if r.Error != nil {
+			status = "error"
+			problems = append(problems, fmt.Sprintf("**%s** — %s", mdEscape(r.Name), mdEscape(errorText(r))))
+		}
+		fmt.Fprintf(w, "| %s | %s %s | %s | %s | %s |\n",
+			icon, mdEscape(r.Name), mdCode(r.Method+" "+r.URL), status, formatDuration(r.Duration), testCell)
+	}
+
+	fmt.Fprintf(w, "\n**%d/%d requests passed**", ok, len(results))
+	if tests > 0 {
+		fmt.Fprintf(w, " · %d/%d tests passed", testsPassed, tests)
+	}
+	fmt.Fprintf(w, " · %s", formatDuration(total))
+	if info.Version != "" {
+		fmt.Fprintf(w, " · gottp %s", info.Version)
+	}
+	fmt.Fprintln(w)
+
+	if len(problems) > 0 {
+		noun := "failures"
+		if len(problems) == 1 {
+			noun = "failure"
+		}
+		fmt.Fprintf(w, "\n<details open><summary>%d %s</summary>\n\n", len(problems), noun)
+		for _, p := range problems {
+			fmt.Fprintf(w, "- %s\n", p)
+		}
+		fmt.Fprintln(w, "\n</details>")
+	}
+}
+
+// mdEscape keeps s inside a table cell or list item.
+func mdEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r", " ", "\n", " ", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// mdCode renders s as a code span in a table cell. Entities aren't decoded
+// in code spans, so only pipes are escaped, and the span is fenced with
+// more backticks than s contains in a row.
+func mdCode(s string) string {
+	s = strings.NewReplacer("|", `\|`, "\r", " ", "\n", " ").Replace(s)
+	longest, run := 0, 0
+	for _, c := range s {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	fence := strings.Repeat("`", longest+1)
+	return fence + s + fence
+}
@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ciResults() []Result {
+	return []Result{
+		{Name: "List", Method: "GET", URL: "https://example.com/users", StatusCode: 200, Status: "200 OK", Duration: 40 * time.Millisecond, Line: 4,
+			TestResults: []TestResult{{Name: "ok", Passed: true}}, TestsPassed: true},
+		{Name: "Create, user", Method: "POST", URL: "https://example.com/users", StatusCode: 500, Status: "500 Internal Server Error", Duration: 60 * time.Millisecond, Line: 12,
+			TestResults: []TestResult{{Name: "created", Passed: false, Error: "expected 201\ngot 500"}}},
+		{Name: "Gone", Method: "GET", URL: "https://example.com/old", StatusCode: 404, Status: "404 Not Found", Duration: 20 * time.Millisecond, Line: 20, TestsPassed: true},
+		{Name: "Down", Method: "GET", URL: "https://down.example.com", Error: errors.New("connection refused"), ErrorString: "connection refused", Line: 25, TestsPassed: true},
+	}
+}
+
+func TestPrintTAP(t *testing.T) {
+	var buf bytes.Buffer
+	PrintTAP(&buf, ciResults())
+	out := buf.String()
+
+	for _, want := range []string{
+		"TAP version 13\n1..4\n",
+		"ok 1 - List\n",
+		"not ok 2 - Create, user\n  ---\n  method: \"POST\"\n",
+		"  status: 500\n  duration_ms: 60\n  failures:\n    - \"created: expected 201\\ngot 500\"\n  ...\n",
+		"ok 3 - Gone\n",
+		"not ok 4 - Down\n",
+		"  error: \"connection refused\"\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("TAP output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "  ---") != 2 {
+		t.Errorf("want a YAML block per failure:\n%s", out)
+	}
+}
+
+func TestPrintGitHub(t *testing.T) {
+	var buf bytes.Buffer
+	PrintGitHub(&buf, ciResults(), RunInfo{File: "api.gottp.yaml"})
+	out := buf.String()
+
+	for _, want := range []string{
+		"::error file=api.gottp.yaml,line=12,title=Create%2C user%3A created::expected 201%0Agot 500\n",
+		"::warning file=api.gottp.yaml,line=20,title=Gone::GET https://example.com/old returned 404 Not Found\n",
+		"::error file=api.gottp.yaml,line=25,title=Down::connection refused\n",
+		"Requests: 4 total, 1 failed, 1 errors\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("GitHub output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "line=4") {
+		t.Errorf("passing request should not be annotated:\n%s", out)
+	}
+}
+
+func TestPrintGitHub_NoFile(t *testing.T) {
+	var buf bytes.Buffer
+	PrintGitHub(&buf, ciResults()[3:], RunInfo{})
+	if !strings.Contains(buf.String(), "::error title=Down::connection refused\n") {
+		t.Errorf("annotation without a file:\n%s", buf.String())
+	}
+}
+
+func TestPrintMarkdown(t *testing.T) {
+	results := ciResults()
+	results[0].URL = "https://example.com/users?a=1|2&q=<b>"
+	results[2].URL = "https://example.com/`old`"
+	var buf bytes.Buffer
+	PrintMarkdown(&buf, results, RunInfo{Collection: "Users API", Environment: "Staging", Version: "1.2.3"})
+	out := buf.String()
+
+	for _, want := range []string{
+		"### gottp run: Users API (Staging)\n",
+		"| ✅ | List `GET https://example.com/users?a=1\\|2&q=<b>` | 200 | 40ms | 1/1 |\n",
+		"| ❌ | Create, user `POST https://example.com/users` | 500 | 60ms | 0/1 |\n",
+		"| ✅ | Gone `` GET https://example.com/`old` `` | 404 | 20ms | — |\n",
+		"| ❌ | Down `GET https://down.example.com` | error |",
+		"**2/4 requests passed** · 1/2 tests passed · 120ms · gottp 1.2.3\n",
+		"<summary>2 failures</summary>",
+		"- **Create, user** — created: expected 201 got 500\n",
+		"- **Down** — connection refused\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Markdown output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintMarkdown_Workflow(t *testing.T) {
+	var buf bytes.Buffer
+	PrintMarkdown(&buf, ciResults()[:1], RunInfo{Collection: "Users API", Workflow: "Signup"})
+	out := buf.String()
+	if !strings.HasPrefix(out, "### gottp run: Signup\n") {
+		t.Errorf("heading should name the workflow:\n%s", out)
+	}
+	if strings.Contains(out, "failures") {
+		t.Errorf("no failures section expected:\n%s", out)
+	}
+}
@@ -104,7 +104,7 @@ func TestPrintWorkflowJUnit(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	if err := PrintWorkflowJUnit(&buf, wf); err != nil {
+	if err := PrintWorkflowJUnit(&buf, wf, RunInfo{}); err != nil {
 		t.Fatalf("PrintWorkflowJUnit failed: %v", err)
 	}
 	out := buf.String()
@@ -121,7 +121,7 @@ func TestPrintWorkflowJUnit_EmptyFailedWorkflow(t *testing.T) {
 	wf := &WorkflowResult{Name: "Empty Failure", Success: false, Error: "workflow failed before steps"}
 
 	var buf bytes.Buffer
-	if err := PrintWorkflowJUnit(&buf, wf); err != nil {
+	if err := PrintWorkflowJUnit(&buf, wf, RunInfo{}); err != nil {
 		t.Fatalf("PrintWorkflowJUnit failed: %v", err)
 	}
 	out := buf.String()
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	oauth2auth "github.com/sadopc/gottp/internal/auth/oauth2"
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/environment"
+)
+
+// runParallel runs up to cfg.Parallel requests at once. Each host has its
+// own queue, worked in collection order by at most cfg.PerHost requests at
+// a time, so a parallel run spreads across hosts instead of flooding one.
+// Results keep the order of requests.
+func (r *Runner) runParallel(ctx context.Context, requests []*collection.Request, cfg Config) []Result {
+	// Created up front so parallel token refreshes share one store
+	if r.tokens == nil {
+		r.tokens = oauth2auth.NewTokenStore()
+	}
+
+	results := make([]Result, len(requests))
+	inFlight := make(chan struct{}, cfg.Parallel)
+	var wg sync.WaitGroup
+	for _, queue := range r.hostQueues(requests) {
+		workers := len(queue)
+		if cfg.PerHost > 0 {
+			workers = min(workers, cfg.PerHost)
+		}
+		next := make(chan int, len(queue))
+		for _, i := range queue {
+			next <- i
+		}
+		close(next)
+		for range workers {
+			wg.Go(func() {
+				for i := range next {
+					inFlight <- struct{}{}
+					results[i] = r.executeRequest(ctx, requests[i], cfg.Verbose)
+					<-inFlight
+				}
+			})
+		}
+	}
+	wg.Wait()
+	return results
+}
+
+// hostQueues groups the indexes of requests by host, in order of first
+// appearance.
+func (r *Runner) hostQueues(requests []*collection.Request) [][]int {
+	var queues [][]int
+	byHost := make(map[string]int)
+	for i, req := range requests {
+		host := r.requestHost(req)
+		q, ok := byHost[host]
+		if !ok {
+			q = len(queues)
+			byHost[host] = q
+			queues = append(queues, nil)
+		}
+		queues[q] = append(queues[q], i)
+	}
+	return queues
+}
+
+// requestHost returns the host a request is sent to, with variables
+// resolved, or its URL when that has no host.
+func (r *Runner) requestHost(req *collection.Request) string {
+	raw := environment.Resolve(req.URL, environment.Overlay(r.envSnapshot(), collection.ScopedVars(r.collection, req)), r.colVars)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return strings.ToLower(u.Host)
+	}
+	return req.URL
+}
@@ -0,0 +1,223 @@
+package runner
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"time"
+)
+
+// reportData is what the HTML report template renders.
+type reportData struct {
+	Info      RunInfo
+	Generated string
+	Requests  []reportRequest
+	Passed    int
+	Failed    int
+	Tests     int
+	TestsOK   int
+	Duration  string
+}
+
+// reportRequest is a result with what the template works out for it.
+type reportRequest struct {
+	Result
+	ID       string
+	Passed   bool
+	Duration string
+	Width    float64 // of the timing bar, as a percentage of the slowest
+}
+
+// SaveHTMLReport writes the HTML report for results to path.
+func SaveHTMLReport(path string, results []Result, info RunInfo) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating report: %w", err)
+	}
+	if err := WriteHTMLReport(f, results, info); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteHTMLReport writes a self-contained HTML page describing a run: the
+// run's collection, environment and totals, a chart of request timings,
+// and a section per request with its tests, logs and, when the results
+// carry an Exchange, its request and response.
+func WriteHTMLReport(w io.Writer, results []Result, info RunInfo) error {
+	data := reportData{
+		Info:      info,
+		Generated: time.Now().Format("2006-01-02 15:04:05 MST"),
+	}
+	var slowest, total time.Duration
+	for _, r := range results {
+		slowest = max(slowest, r.Duration)
+		total += r.Duration
+	}
+	for i, r := range results {
+		rr := reportRequest{
+			Result:   r,
+			ID:       fmt.Sprintf("request-%d", i+1),
+			Passed:   passed(r),
+			Duration: formatDuration(r.Duration),
+		}
+		if slowest > 0 {
+			rr.Width = float64(r.Duration) / float64(slowest) * 100
+		}
+		if rr.Passed {
+			data.Passed++
+		} else {
+			data.Failed++
+		}
+		for _, tr := range r.TestResults {
+			data.Tests++
+			if tr.Passed {
+				data.TestsOK++
+			}
+		}
+		data.Requests = append(data.Requests, rr)
+	}
+	data.Duration = formatDuration(total)
+
+	if err := reportTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ms": func(d time.Duration) string { return formatDuration(d) },
+}).Parse(reportHTML))
+
+// reportHTML needs no network: styles are inline and the chart is drawn
+// with CSS, so the file can be mailed or attached to a ticket.
+const reportHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>gottp report{{with .Info.Collection}}: {{.}}{{end}}</title>
+<style>
+body { font: 14px/1.5 system-ui, -apple-system, "Segoe UI", sans-serif; color: #1f2328; background: #f6f8fa; margin: 0; padding: 24px; }
+main { max-width: 1100px; margin: 0 auto; }
+h1 { font-size: 22px; margin: 0 0 4px; }
+h2 { font-size: 16px; margin: 28px 0 8px; }
+code, pre { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 12px; }
+pre { background: #f6f8fa; border: 1px solid #d0d7de; border-radius: 6px; padding: 8px; overflow: auto; max-height: 400px; white-space: pre-wrap; word-break: break-all; }
+.meta { color: #59636e; margin: 0; }
+.meta span { margin-right: 16px; }
+.banner { background: #ffebe9; border: 1px solid #ff818266; border-radius: 6px; padding: 8px 12px; margin-top: 16px; }
+.cards { display: flex; gap: 12px; margin-top: 16px; flex-wrap: wrap; }
+.card { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 10px 16px; min-width: 110px; }
+.card b { display: block; font-size: 22px; }
+.pass { color: #1a7f37; }
+.fail { color: #d1242f; }
+.chart { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 12px; }
+.row { display: grid; grid-template-columns: 220px 1fr 70px; gap: 8px; align-items: center; margin: 2px 0; }
+.row a { color: inherit; text-decoration: none; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+.track { background: #eff2f5; border-radius: 3px; height: 14px; }
+.bar { background: #54aeff; border-radius: 3px; height: 14px; min-width: 2px; }
+.bar.failed { background: #ff8182; }
+.row .time { text-align: right; color: #59636e; }
+details.request { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; margin: 8px 0; }
+details.request > summary { cursor: pointer; padding: 8px 12px; display: flex; gap: 12px; align-items: baseline; }
+details.request > summary .url { flex: 1; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; color: #59636e; }
+.body { padding: 0 12px 12px; }
+.body details { margin: 6px 0; }
+.body details summary { cursor: pointer; font-weight: 600; }
+ul.tests { list-style: none; padding: 0; margin: 4px 0; }
+ul.tests li::before { margin-right: 6px; }
+ul.tests li.pass::before { content: "✓"; }
+ul.tests li.fail::before { content: "✗"; }
+table.headers { border-collapse: collapse; font-size: 12px; }
+table.headers td { border-top: 1px solid #eff2f5; padding: 2px 12px 2px 0; vertical-align: top; word-break: break-all; }
+table.headers td:first-child { font-weight: 600; white-space: nowrap; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{with .Info.Workflow}}Workflow {{.}}{{else}}{{or .Info.Collection "gottp run"}}{{end}}</h1>
+<p class="meta">
+{{- with .Info.Collection}}<span>Collection: <b>{{.}}</b></span>{{end}}
+{{- with .Info.Environment}}<span>Environment: <b>{{.}}</b></span>{{end}}
+{{- with .Info.Version}}<span>gottp {{.}}</span>{{end}}
+<span>Generated {{.Generated}}</span>
+</p>
+{{with .Info.Failure}}<div class="banner fail">Workflow failed: {{.}}</div>{{end}}
+
+<div class="cards">
+<div class="card"><b>{{len .Requests}}</b>requests</div>
+<div class="card"><b class="pass">{{.Passed}}</b>passed</div>
+<div class="card"><b class="{{if .Failed}}fail{{end}}">{{.Failed}}</b>failed</div>
+{{- if .Tests}}
+<div class="card"><b>{{.TestsOK}}/{{.Tests}}</b>tests passed</div>
+{{- end}}
+<div class="card"><b>{{.Duration}}</b>total time</div>
+</div>
+
+{{if .Requests -}}
+<h2>Timings</h2>
+<div class="chart">
+{{- range .Requests}}
+<div class="row"><a href="#{{.ID}}" title="{{.Name}}">{{.Name}}</a><div class="track"><div class="bar{{if not .Passed}} failed{{end}}" style="width: {{printf "%.1f" .Width}}%"></div></div><span class="time">{{.Duration}}</span></div>
+{{- end}}
+</div>
+{{- end}}
+
+<h2>Requests</h2>
+{{- range .Requests}}
+<details class="request" id="{{.ID}}"{{if not .Passed}} open{{end}}>
+<summary><b class="{{if .Passed}}pass{{else}}fail{{end}}">{{if .Passed}}✓{{else}}✗{{end}}</b><b>{{.Name}}</b><code>{{.Method}}</code><span class="url">{{.URL}}</span><span>{{if .StatusCode}}{{.Status}}{{else}}—{{end}}</span><span>{{.Duration}}</span></summary>
+<div class="body">
+{{- if .ErrorString}}
+<p class="fail">Error: {{.ErrorString}}</p>
+{{- end}}
+{{- if .TestResults}}
+<ul class="tests">
+{{- range .TestResults}}
+<li class="{{if .Passed}}pass{{else}}fail{{end}}">{{.Name}}{{with .Error}}: {{.}}{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- with .Timing}}
+<p class="meta"><span>DNS {{ms .DNSLookup}}</span><span>Connect {{ms .TCPConnect}}</span><span>TLS {{ms .TLSHandshake}}</span><span>Server {{ms .TTFB}}</span><span>Transfer {{ms .Transfer}}</span></p>
+{{- end}}
+{{- if .ScriptLogs}}
+<details><summary>Script logs</summary>
+<pre>{{range .ScriptLogs}}{{.}}
+{{end}}</pre>
+</details>
+{{- end}}
+{{- with .Exchange}}
+<details><summary>Request</summary>
+{{- if .RequestHeaders}}
+<table class="headers">{{range $k, $v := .RequestHeaders}}<tr><td>{{$k}}</td><td>{{$v}}</td></tr>{{end}}</table>
+{{- end}}
+{{- with .RequestBody}}
+<pre>{{.}}</pre>
+{{- end}}
+</details>
+{{- if .ResponseHeaders}}
+<details><summary>Response</summary>
+<table class="headers">{{range $k, $v := .ResponseHeaders}}<tr><td>{{$k}}</td><td>{{$v}}</td></tr>{{end}}</table>
+{{- with .ResponseBody}}
+<pre>{{.}}</pre>
+{{- end}}
+</details>
+{{- end}}
+{{- else}}
+{{- with .ErrorBody}}
+<details><summary>Response</summary>
+<pre>{{.}}</pre>
+</details>
+{{- end}}
+{{- end}}
+</div>
+</details>
+{{- end}}
+</main>
+</body>
+</html>
+`
@@ -0,0 +1,171 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/protocol"
+	httpclient "github.com/sadopc/gottp/internal/protocol/http"
+	"github.com/sadopc/gottp/internal/scripting"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	results := []Result{
+		{Name: "List", Method: "GET", URL: "https://example.com/users", StatusCode: 200, Status: "200 OK", Duration: 50 * time.Millisecond,
+			TestResults: []TestResult{{Name: "status is 200", Passed: true}}, TestsPassed: true,
+			Exchange: &Exchange{
+				RequestHeaders:  map[string]string{"Accept": "application/json"},
+				ResponseHeaders: map[string]string{"Content-Type": "application/json"},
+				ResponseBody:    `[{"name":"<Alice>"}]`,
+			}},
+		{Name: "Create", Method: "POST", URL: "https://example.com/users", StatusCode: 500, Status: "500 Internal Server Error", Duration: 100 * time.Millisecond,
+			TestResults: []TestResult{{Name: "created", Error: "expected 201"}}, ScriptLogs: []string{"sending user"}, ErrorBody: "db down"},
+		{Name: "Down", Method: "GET", URL: "https://down.example.com", Error: errors.New("refused"), ErrorString: "connection refused", TestsPassed: true},
+	}
+	var buf bytes.Buffer
+	err := WriteHTMLReport(&buf, results, RunInfo{Collection: "Users API", Environment: "Staging", Version: "1.2.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<title>gottp report: Users API</title>",
+		"Environment: <b>Staging</b>",
+		"gottp 1.2.3",
+		`<b class="pass">1</b>passed`,
+		`<b class="fail">2</b>failed`,
+		"<b>1/2</b>tests passed",
+		"<b>150ms</b>total time",
+		`style="width: 50.0%"`,
+		`style="width: 100.0%"`,
+		`<details class="request" id="request-1">`,
+		`<details class="request" id="request-2" open>`,
+		`<li class="fail">created: expected 201</li>`,
+		"<td>Accept</td><td>application/json</td>",
+		"[{&#34;name&#34;:&#34;&lt;Alice&gt;&#34;}]",
+		"sending user",
+		"<pre>db down</pre>",
+		"Error: connection refused",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	if strings.Contains(out, "<Alice>") {
+		t.Error("response body should be escaped")
+	}
+	if strings.Contains(out, "Workflow failed") {
+		t.Error("no workflow failure expected")
+	}
+}
+
+func TestWriteHTMLReport_Workflow(t *testing.T) {
+	var buf bytes.Buffer
+	info := RunInfo{Collection: "Users API", Workflow: "Signup", Failure: `step "Login" failed`}
+	if err := WriteHTMLReport(&buf, nil, info); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"<h1>Workflow Signup</h1>", "Workflow failed: step &#34;Login&#34; failed", "<b>0</b>requests"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSaveHTMLReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	if err := SaveHTMLReport(path, []Result{{Name: "List", TestsPassed: true}}, RunInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("<!DOCTYPE html>")) {
+		t.Errorf("report = %.40q", data)
+	}
+
+	if err := SaveHTMLReport(filepath.Join(t.TempDir(), "missing", "report.html"), nil, RunInfo{}); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestRunCapturesExchange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	registry := protocol.NewRegistry()
+	registry.Register(httpclient.New())
+	req := &collection.Request{
+		Name:     "Create",
+		Protocol: "http",
+		Method:   "POST",
+		URL:      server.URL + "/users",
+		Headers: []collection.KVPair{
+			{Key: "Content-Type", Value: "application/json", Enabled: true},
+			{Key: "Authorization", Value: "Bearer hunter2", Enabled: true},
+		},
+		Body: &collection.Body{Type: "json", Content: `{"name":"Alice"}`},
+	}
+	r := &Runner{
+		collection:   &collection.Collection{Items: []collection.Item{{Request: req}}},
+		registry:     registry,
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{},
+		colVars:      map[string]string{},
+		timeout:      10 * time.Second,
+	}
+
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Exchange != nil {
+		t.Error("Exchange should only be kept for a report")
+	}
+
+	r.capture = true
+	results, err = r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := results[0].Exchange
+	if ex == nil {
+		t.Fatal("expected an Exchange")
+	}
+	if ex.RequestBody != `{"name":"Alice"}` || ex.ResponseBody != `{"ok":true}` {
+		t.Errorf("bodies = %q, %q", ex.RequestBody, ex.ResponseBody)
+	}
+	if ex.RequestHeaders["Authorization"] != hiddenValue || ex.ResponseHeaders["Set-Cookie"] != hiddenValue {
+		t.Errorf("credential headers should be hidden: %v %v", ex.RequestHeaders, ex.ResponseHeaders)
+	}
+	if ex.ResponseHeaders["Content-Type"] != "application/json" {
+		t.Errorf("Content-Type = %q", ex.ResponseHeaders["Content-Type"])
+	}
+}
+
+func TestExchangeBody(t *testing.T) {
+	r := &Runner{}
+	if got := r.exchangeBody([]byte{0xff, 0xfe, 0x00}); got != "(3 bytes of binary data)" {
+		t.Errorf("binary body = %q", got)
+	}
+	long := strings.Repeat("a", maxExchangeBody+10)
+	if got := r.exchangeBody([]byte(long)); !strings.HasSuffix(got, "… 10 more bytes") || len(got) > maxExchangeBody+20 {
+		t.Errorf("long body ends %q", got[len(got)-20:])
+	}
+}
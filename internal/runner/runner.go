@@ -2,13 +2,24 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
+	oauth2auth "github.com/sadopc/gottp/internal/auth/oauth2"
+	"github.com/sadopc/gottp/internal/config"
+	"github.com/sadopc/gottp/internal/core/assertion"
 	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/core/environment"
+	"github.com/sadopc/gottp/internal/core/retry"
+	"github.com/sadopc/gottp/internal/core/secrets"
+	gotls "github.com/sadopc/gottp/internal/core/tls"
 	"github.com/sadopc/gottp/internal/protocol"
 	"github.com/sadopc/gottp/internal/protocol/graphql"
 	grpcclient "github.com/sadopc/gottp/internal/protocol/grpc"
@@ -26,6 +37,19 @@ type Runner struct {
 	envVars      map[string]string
 	colVars      map[string]string
 	timeout      time.Duration
+	secrets      *secrets.Resolver
+	tokens       *oauth2auth.TokenStore // OAuth2 tokens, shared with the TUI
+	oauthBrowser bool                   // allow the browser-based authorization_code flow
+	baseDir      string                 // body file paths are relative to this
+	proxy        *environment.Proxy     // active environment's proxy, if any
+	tls          *gotls.Config          // active environment's TLS settings, if any
+	hosts        map[string]string      // active environment's host overrides, if any
+	retryPolicy  *retry.Policy          // global and collection retry policy
+	dotenv       []string               // variables loaded from .env, unset by Close
+	envName      string                 // active environment, "" if none
+	capture      bool                   // keep each request's Exchange for a report
+
+	mu sync.Mutex // guards envVars while requests run in parallel
 }
 
 // Config holds runner configuration.
@@ -35,9 +59,15 @@ type Config struct {
 	RequestName    string // run single request by name
 	FolderName     string // run all requests in folder
 	WorkflowName   string // run a named workflow
-	OutputFormat   string // "text", "json", "junit"
+	OutputFormat   string // "text", "json", "junit", "tap", "github", "markdown"
+	Report         string // path of an HTML report to write; keeps each Result's Exchange
 	Verbose        bool
 	Timeout        time.Duration
+	OAuthBrowser   bool          // open the browser for OAuth2 authorization_code grants
+	Retry          *retry.Policy // global retry policy, overridden by the collection and requests
+	Parallel       int           // requests in flight at once; 0 or 1 runs them in order
+	PerHost        int           // requests in flight at once per host when parallel; 0 for no limit
+	Connections    config.Connections
 }
 
 // Result holds execution results for a single request.
@@ -57,6 +87,66 @@ type Result struct {
 	Body        []byte              `json:"-"`
 	BodyString  string              `json:"body,omitempty"`
 	Headers     map[string][]string `json:"headers,omitempty"`
+
+	// Line is the request's line in the collection file, 0 if unknown.
+	Line int `json:"-"`
+
+	// ErrorBody is the start of a 4xx or 5xx response's body, with secrets
+	// masked, kept for reports even without verbose output.
+	ErrorBody string `json:"-"`
+
+	// Exchange is the request as sent and the response, kept for the HTML
+	// report when Config.Report is set.
+	Exchange *Exchange `json:"-"`
+
+	// Retry reports the attempts made when a retry policy applies.
+	Retry *retry.Stats `json:"retry,omitempty"`
+
+	// Timing breaks Duration down into DNS, connect, TLS, server and
+	// transfer phases when the protocol reports them.
+	Timing *protocol.TimingDetail `json:"timing,omitempty"`
+
+	// TokenRefreshed is set when a 401 triggered an OAuth2 token refresh
+	// and the request was retried.
+	TokenRefreshed bool `json:"token_refreshed,omitempty"`
+}
+
+// maxErrorBody caps the error response body kept in a Result.
+const maxErrorBody = 4 << 10
+
+// Exchange is a request and its response as shown in a report. Bodies are
+// capped at maxExchangeBody, secrets are masked and the values of headers
+// named like credentials are hidden.
+type Exchange struct {
+	RequestHeaders  map[string]string
+	RequestBody     string
+	ResponseHeaders map[string]string
+	ResponseBody    string
+}
+
+// maxExchangeBody caps each body kept in an Exchange.
+const maxExchangeBody = 64 << 10
+
+// hiddenValue replaces credential header values in an Exchange.
+const hiddenValue = "••••••"
+
+// exchangeHeader is value as kept in an Exchange for the header name.
+func (r *Runner) exchangeHeader(name, value string) string {
+	if collection.SecretName(name) {
+		return hiddenValue
+	}
+	return r.secrets.Mask(value)
+}
+
+// exchangeBody is body as kept in an Exchange.
+func (r *Runner) exchangeBody(body []byte) string {
+	if !utf8.Valid(body) {
+		return fmt.Sprintf("(%d bytes of binary data)", len(body))
+	}
+	if len(body) > maxExchangeBody {
+		return r.secrets.Mask(string(body[:maxExchangeBody])) + fmt.Sprintf("\n… %d more bytes", len(body)-maxExchangeBody)
+	}
+	return r.secrets.Mask(string(body))
 }
 
 // TestResult holds the result of a script test assertion.
@@ -76,9 +166,23 @@ func New(cfg Config) (*Runner, error) {
 	if err != nil {
 		return nil, fmt.Errorf("loading collection: %w", err)
 	}
+	return NewForCollection(col, filepath.Dir(cfg.CollectionPath), cfg)
+}
 
-	// Load environments
-	dir := filepath.Dir(cfg.CollectionPath)
+// NewForCollection creates a runner for a collection already in memory,
+// loading environments.yaml and .env from dir. cfg.CollectionPath is
+// ignored.
+func NewForCollection(col *collection.Collection, dir string, cfg Config) (r *Runner, err error) {
+	// Load environments, after any .env file they may reference
+	dotenv, err := environment.LoadDotEnv(filepath.Join(dir, ".env"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			unsetEnv(dotenv)
+		}
+	}()
 	envFile, err := environment.LoadEnvironments(filepath.Join(dir, "environments.yaml"))
 	if err != nil {
 		return nil, fmt.Errorf("loading environments: %w", err)
@@ -107,34 +211,91 @@ func New(cfg Config) (*Runner, error) {
 		envVars = envFile.GetVariables(envFile.Environments[0].Name)
 	}
 
+	retryPolicy := cfg.Retry.Merge(col.Retry)
+	if err := retryPolicy.Validate(); err != nil {
+		return nil, err
+	}
+
 	colVars := map[string]string{}
 	if col.Variables != nil {
 		colVars = col.Variables
 	}
 
+	activeEnv := cfg.Environment
+	if activeEnv == "" && len(envFile.Environments) > 0 {
+		activeEnv = envFile.Environments[0].Name
+	}
+
+	secretResolver := secrets.DefaultResolver()
+	if activeEnv != "" {
+		secretResolver.Remember(envFile.SecretValues(activeEnv)...)
+	}
+
 	// Set up protocol registry
 	registry := protocol.NewRegistry()
-	registry.Register(httpclient.New())
+	httpClient := httpclient.New()
+	httpClient.SetPoolOptions(httpclient.PoolOptions(cfg.Connections))
+	registry.Register(httpClient)
 	registry.Register(graphql.New())
 	registry.Register(wsclient.New())
 	registry.Register(grpcclient.New())
 
+	// Scripts send chained requests through the same registry
+	scriptEngine := scripting.NewEngine(5 * time.Second)
+	scriptEngine.SetRegistry(registry)
+
 	timeout := cfg.Timeout
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
 
+	tokens, err := oauth2auth.OpenTokenStore(filepath.Join(config.DataDir(), "oauth2_tokens.json"))
+	if err != nil {
+		tokens = oauth2auth.NewTokenStore()
+	}
+
 	return &Runner{
 		collection:   col,
 		envFile:      envFile,
 		registry:     registry,
-		scriptEngine: scripting.NewEngine(5 * time.Second),
+		scriptEngine: scriptEngine,
 		envVars:      envVars,
 		colVars:      colVars,
 		timeout:      timeout,
+		secrets:      secretResolver,
+		tokens:       tokens,
+		oauthBrowser: cfg.OAuthBrowser,
+		baseDir:      dir,
+		proxy:        envFile.GetProxy(activeEnv),
+		tls:          envFile.GetTLS(activeEnv),
+		hosts:        envFile.GetHosts(activeEnv),
+		retryPolicy:  retryPolicy,
+		dotenv:       dotenv,
+		envName:      activeEnv,
+		capture:      cfg.Report != "",
 	}, nil
 }
 
+// Info describes the run for report headers. Version is left for the
+// caller to fill in.
+func (r *Runner) Info() RunInfo {
+	return RunInfo{Collection: r.collection.Name, Environment: r.envName}
+}
+
+// Close unsets the variables New loaded from .env, so a later New picks up
+// edits to the file, and closes connections kept open between requests.
+func (r *Runner) Close() {
+	unsetEnv(r.dotenv)
+	r.dotenv = nil
+	_ = r.registry.Close(context.Background())
+}
+
+func unsetEnv(keys []string) {
+	for _, k := range keys {
+		os.Unsetenv(k)
+	}
+}
+
 // Run executes the configured requests and returns results.
 func (r *Runner) Run(ctx context.Context, cfg Config) ([]Result, error) {
 	requests := r.collectRequests(cfg)
@@ -148,6 +309,10 @@ func (r *Runner) Run(ctx context.Context, cfg Config) ([]Result, error) {
 		return nil, fmt.Errorf("no requests found in collection")
 	}
 
+	if cfg.Parallel > 1 {
+		return r.runParallel(ctx, requests, cfg), nil
+	}
+
 	results := make([]Result, 0, len(requests))
 	for _, req := range requests {
 		result := r.executeRequest(ctx, req, cfg.Verbose)
@@ -205,14 +370,34 @@ func (r *Runner) executeRequest(ctx context.Context, colReq *collection.Request,
 		Name:   colReq.Name,
 		Method: colReq.Method,
 		URL:    colReq.URL,
+		Line:   colReq.Line,
 	}
 
 	// Build protocol request from collection request
 	req := buildProtocolRequest(colReq)
+	if req.Proxy == nil && r.proxy != nil && r.proxy.URL != "" {
+		req.Proxy = &protocol.ProxyConfig{
+			URL:      r.proxy.URL,
+			NoProxy:  r.proxy.NoProxy,
+			Username: r.proxy.Username,
+			Password: r.proxy.Password,
+		}
+	}
+	if req.TLS != nil || r.tls != nil {
+		req.TLS = r.tls.Merge(req.TLS)
+	}
+	if len(r.hosts) > 0 {
+		req.Hosts = protocol.Hosts(maps.Clone(r.hosts))
+	}
 
 	// Resolve environment variables
-	r.resolveVars(req)
-	result.URL = req.URL // update with resolved URL
+	if err := r.resolveVars(req, collection.ScopedVars(r.collection, colReq)); err != nil {
+		result.Error = err
+		result.ErrorString = err.Error()
+		return result
+	}
+	req.ResolvePaths(r.baseDir)
+	result.URL = r.secrets.Mask(req.URL) // update with resolved URL
 
 	// Run pre-request script
 	if colReq.PreScript != "" {
@@ -223,7 +408,7 @@ func (r *Runner) executeRequest(ctx context.Context, colReq *collection.Request,
 			Params:  req.Params,
 			Body:    string(req.Body),
 		}
-		scriptResult := r.scriptEngine.RunPreScript(colReq.PreScript, scriptReq, r.envVars)
+		scriptResult := r.scriptEngine.RunPreScript(colReq.PreScript, scriptReq, r.envSnapshot())
 		result.ScriptLogs = append(result.ScriptLogs, scriptResult.Logs...)
 
 		if scriptResult.Err != nil {
@@ -239,9 +424,16 @@ func (r *Runner) executeRequest(ctx context.Context, colReq *collection.Request,
 		req.Params = scriptReq.Params
 		req.Body = []byte(scriptReq.Body)
 
-		// Apply env changes
-		for k, v := range scriptResult.EnvChanges {
-			r.envVars[k] = v
+		r.setEnvVars(scriptResult.EnvChanges)
+	}
+
+	if r.capture {
+		result.Exchange = &Exchange{
+			RequestHeaders: make(map[string]string, len(req.Headers)),
+			RequestBody:    r.exchangeBody(req.Body),
+		}
+		for k, v := range req.Headers {
+			result.Exchange.RequestHeaders[k] = r.exchangeHeader(k, v)
 		}
 	}
 
@@ -249,10 +441,61 @@ func (r *Runner) executeRequest(ctx context.Context, colReq *collection.Request,
 	reqCtx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	resp, err := r.registry.Execute(reqCtx, req)
+	// Reuse tokens refreshed by earlier requests in this run
+	var tokenKey string
+	if req.Auth != nil && req.Auth.Type == "oauth2" && req.Auth.OAuth2 != nil {
+		oauth := req.Auth.OAuth2
+		tokenKey = oauth2auth.TokenKey(oauth.TokenURL, oauth.ClientID, oauth.Scope)
+		if token, ok := r.tokens.Get(tokenKey); ok {
+			oauth2auth.ApplyToken(oauth, token)
+			r.secrets.Remember(token.AccessToken, token.RefreshToken)
+		}
+		if err := r.prepareOAuth2(ctx, tokenKey, oauth); err != nil {
+			result.Error = err
+			result.ErrorString = r.secrets.Mask(err.Error())
+			return result
+		}
+	}
+
+	var resp *protocol.Response
+	var wsAssertions []wsclient.Event
+	var err error
+	if req.Protocol == "websocket" {
+		// A dedicated connection per request: send the scripted messages,
+		// check expectations, then close.
+		resp, wsAssertions, err = wsclient.RunScript(reqCtx, req)
+	} else {
+		policy := r.retryPolicy.Merge(colReq.Retry)
+		if err := policy.Validate(); err != nil {
+			result.Error = err
+			result.ErrorString = err.Error()
+			return result
+		}
+		stats := policy.Do(ctx, func(ctx context.Context) (int, string, error) {
+			attemptCtx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+			var refreshed *oauth2auth.TokenResponse
+			resp, refreshed, err = oauth2auth.ExecuteWithRefresh(attemptCtx, r.registry.Execute, req)
+			if refreshed != nil {
+				r.storeToken(tokenKey, refreshed)
+				result.TokenRefreshed = true
+			}
+			if err != nil {
+				return 0, "", err
+			}
+			return resp.StatusCode, resp.Headers.Get("Retry-After"), nil
+		})
+		if policy.Enabled() {
+			result.Retry = &stats
+		}
+	}
 	if err != nil {
 		result.Error = err
-		result.ErrorString = err.Error()
+		result.ErrorString = r.secrets.Mask(err.Error())
+		var unsupported *protocol.UnsupportedError
+		if errors.As(err, &unsupported) {
+			result.ErrorString += "; " + unsupported.Hint()
+		}
 		return result
 	}
 
@@ -260,6 +503,21 @@ func (r *Runner) executeRequest(ctx context.Context, colReq *collection.Request,
 	result.Status = resp.Status
 	result.Duration = resp.Duration
 	result.Size = resp.Size
+	result.Timing = resp.Timing
+	if resp.StatusCode >= 400 {
+		body := resp.Body
+		if len(body) > maxErrorBody {
+			body = body[:maxErrorBody]
+		}
+		result.ErrorBody = r.secrets.Mask(string(body))
+	}
+	if result.Exchange != nil {
+		result.Exchange.ResponseHeaders = make(map[string]string, len(resp.Headers))
+		for k, v := range resp.Headers {
+			result.Exchange.ResponseHeaders[k] = r.exchangeHeader(k, strings.Join(v, ", "))
+		}
+		result.Exchange.ResponseBody = r.exchangeBody(resp.Body)
+	}
 	if verbose {
 		result.Body = resp.Body
 		result.BodyString = string(resp.Body)
@@ -292,7 +550,7 @@ func (r *Runner) executeRequest(ctx context.Context, colReq *collection.Request,
 			Size:        resp.Size,
 			ContentType: resp.ContentType,
 		}
-		scriptResult := r.scriptEngine.RunPostScript(colReq.PostScript, scriptReq, scriptResp, r.envVars)
+		scriptResult := r.scriptEngine.RunPostScript(colReq.PostScript, scriptReq, scriptResp, r.envSnapshot())
 		result.ScriptLogs = append(result.ScriptLogs, scriptResult.Logs...)
 
 		if scriptResult.Err != nil {
@@ -316,17 +574,48 @@ func (r *Runner) executeRequest(ctx context.Context, colReq *collection.Request,
 			result.TestsPassed = true
 		}
 
-		// Apply env changes
-		for k, v := range scriptResult.EnvChanges {
-			r.envVars[k] = v
-		}
+		r.setEnvVars(scriptResult.EnvChanges)
 	} else {
 		result.TestsPassed = true
 	}
 
+	// Declarative assertions count as tests
+	if len(colReq.Assertions) > 0 {
+		checked := assertion.Evaluate(colReq.Assertions, assertion.Response{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Headers,
+			Body:       resp.Body,
+			Duration:   resp.Duration,
+		}, r.baseDir)
+		for _, a := range checked {
+			result.TestResults = append(result.TestResults, TestResult{Name: a.Name, Passed: a.Passed, Error: a.Error})
+			if !a.Passed {
+				result.TestsPassed = false
+			}
+		}
+	}
+
+	// WebSocket expectations count as tests
+	for _, a := range wsAssertions {
+		tr := TestResult{Name: "ws expect: " + a.Name, Passed: a.Passed}
+		if a.Err != nil {
+			tr.Error = a.Err.Error()
+		}
+		result.TestResults = append(result.TestResults, tr)
+		if !a.Passed {
+			result.TestsPassed = false
+		}
+	}
+
 	return result
 }
 
+// RunRequest sends req with the runner's environment, running its scripts
+// and assertions as Run does. req need not belong to the collection.
+func (r *Runner) RunRequest(ctx context.Context, req *collection.Request, verbose bool) Result {
+	return r.executeRequest(ctx, req, verbose)
+}
+
 // buildProtocolRequest converts a collection.Request to a protocol.Request.
 func buildProtocolRequest(colReq *collection.Request) *protocol.Request {
 	req := &protocol.Request{
@@ -337,11 +626,19 @@ func buildProtocolRequest(colReq *collection.Request) *protocol.Request {
 		Params:     make(map[string]string),
 		PreScript:  colReq.PreScript,
 		PostScript: colReq.PostScript,
-	}
 
-	if req.Protocol == "" {
-		req.Protocol = "http"
+		ExpectContinue: colReq.ExpectContinue,
+		Chunked:        colReq.Chunked,
+	}
+	if colReq.ProxyURL != "" {
+		req.Proxy = &protocol.ProxyConfig{URL: colReq.ProxyURL}
 	}
+	if colReq.TLS != nil {
+		tlsCfg := *colReq.TLS
+		req.TLS = &tlsCfg
+	}
+
+	req.Protocol = protocol.CanonicalName(req.Protocol)
 
 	// Params
 	for _, p := range colReq.Params {
@@ -356,11 +653,14 @@ func buildProtocolRequest(colReq *collection.Request) *protocol.Request {
 			req.Headers[h.Key] = h.Value
 		}
 	}
+	for _, c := range colReq.Cookies {
+		if c.Enabled && c.Key != "" {
+			req.Cookies = append(req.Cookies, protocol.Cookie{Name: c.Key, Value: c.Value})
+		}
+	}
 
 	// Body
-	if colReq.Body != nil && colReq.Body.Content != "" {
-		req.Body = []byte(colReq.Body.Content)
-	}
+	applyBody(req, colReq.Body)
 
 	// Auth
 	if colReq.Auth != nil {
@@ -371,6 +671,14 @@ func buildProtocolRequest(colReq *collection.Request) *protocol.Request {
 	if colReq.GraphQL != nil {
 		req.GraphQLQuery = colReq.GraphQL.Query
 		req.GraphQLVariables = colReq.GraphQL.Variables
+		req.GraphQLPersisted = colReq.GraphQL.PersistedQuery
+	}
+
+	// WebSocket
+	if colReq.WebSocket != nil {
+		req.WSPingInterval = colReq.WebSocket.PingInterval
+		req.WSReconnect = colReq.WebSocket.Reconnect
+		req.WSMessages = buildWSScript(colReq.WebSocket.Messages)
 	}
 
 	// gRPC
@@ -383,11 +691,111 @@ func buildProtocolRequest(colReq *collection.Request) *protocol.Request {
 				req.Metadata[m.Key] = m.Value
 			}
 		}
+		req.GRPCCall = protocol.GRPCCallOptions{
+			Deadline:       colReq.GRPC.Deadline,
+			Gzip:           colReq.GRPC.Gzip,
+			MaxSendMsgSize: colReq.GRPC.MaxSendMsgSize,
+			MaxRecvMsgSize: colReq.GRPC.MaxRecvMsgSize,
+			WaitForReady:   colReq.GRPC.WaitForReady,
+		}
+		req.GRPCTransport = colReq.GRPC.Transport
 	}
 
 	return req
 }
 
+// applyBody copies a collection body onto req. Form and file bodies are
+// encoded and streamed by the HTTP client.
+func applyBody(req *protocol.Request, body *collection.Body) {
+	if body.IsEmpty() {
+		return
+	}
+	switch body.Kind() {
+	case collection.BodyBinaryFile:
+		req.BodyFile = body.File
+	case collection.BodyFormURLEncoded, collection.BodyMultipart:
+		if len(body.Fields) == 0 {
+			req.Body = []byte(body.Content) // pre-encoded content
+			return
+		}
+		req.Multipart = body.Kind() == collection.BodyMultipart
+		for _, f := range body.Fields {
+			if f.Enabled && f.Key != "" {
+				req.Form = append(req.Form, protocol.FormField{Name: f.Key, Value: f.Value, File: f.File})
+			}
+		}
+	default:
+		req.Body = []byte(body.Content)
+	}
+}
+
+// prepareOAuth2 makes sure an OAuth2 request carries a usable token before
+// it is sent. Expired tokens are refreshed silently; the authorization_code
+// grant opens the browser when the run allows it. Other grants without a
+// token are left to the 401 retry.
+func (r *Runner) prepareOAuth2(ctx context.Context, key string, oauth *protocol.OAuth2AuthConfig) error {
+	if !oauth2auth.NeedsToken(oauth) {
+		return nil
+	}
+	if oauth.RefreshToken == "" && oauth.GrantType != "authorization_code" {
+		return nil
+	}
+
+	timeout := r.timeout
+	var open func(string) error
+	if r.oauthBrowser {
+		timeout = oauth2auth.AuthorizeTimeout
+		open = func(url string) error {
+			fmt.Fprintf(os.Stderr, "Authorize in your browser: %s\n", url)
+			return oauth2auth.OpenBrowser(url)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	token, err := oauth2auth.Obtain(ctx, oauth, open)
+	if errors.Is(err, oauth2auth.ErrNoRefresh) {
+		return fmt.Errorf("oauth2: %w (rerun with --oauth-browser to authorize)", err)
+	}
+	if err != nil {
+		return fmt.Errorf("oauth2: %w", err)
+	}
+	oauth2auth.ApplyToken(oauth, token)
+	r.storeToken(key, token)
+	return nil
+}
+
+// storeToken caches a token for later requests and runs, and masks it in output.
+func (r *Runner) storeToken(key string, token *oauth2auth.TokenResponse) {
+	if r.tokens == nil {
+		r.tokens = oauth2auth.NewTokenStore()
+	}
+	// A failed write only loses persistence; the token stays cached in memory
+	_ = r.tokens.Put(key, token)
+	r.secrets.Remember(token.AccessToken, token.RefreshToken)
+}
+
+// buildWSScript converts collection WebSocket messages to scripted messages.
+func buildWSScript(messages []collection.WSMessage) []protocol.WSScriptMessage {
+	script := make([]protocol.WSScriptMessage, len(messages))
+	for i, m := range messages {
+		script[i] = protocol.WSScriptMessage{
+			Name:    m.Name,
+			Content: m.Content,
+			Delay:   m.Delay,
+		}
+		if m.Expect != nil {
+			script[i].Expect = &protocol.WSExpectation{
+				Contains: m.Expect.Contains,
+				Equals:   m.Expect.Equals,
+				Matches:  m.Expect.Matches,
+				Timeout:  m.Expect.Timeout,
+			}
+		}
+	}
+	return script
+}
+
 // buildAuthConfig converts collection auth to protocol auth config.
 func buildAuthConfig(auth *collection.Auth) *protocol.AuthConfig {
 	if auth == nil || auth.Type == "" || auth.Type == "none" {
@@ -443,38 +851,98 @@ func buildAuthConfig(auth *collection.Auth) *protocol.AuthConfig {
 	return cfg
 }
 
-// resolveVars replaces {{variable}} placeholders in all request fields.
-func (r *Runner) resolveVars(req *protocol.Request) {
-	if len(r.envVars) == 0 && len(r.colVars) == 0 {
+// envSnapshot returns a copy of the environment variables, so requests
+// running in parallel see a consistent set.
+func (r *Runner) envSnapshot() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.envVars)
+}
+
+// setEnvVars applies environment changes made by a script.
+func (r *Runner) setEnvVars(changes map[string]string) {
+	if len(changes) == 0 {
 		return
 	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.envVars == nil {
+		r.envVars = make(map[string]string)
+	}
+	maps.Copy(r.envVars, changes)
+}
+
+// resolveVars replaces {{variable}} placeholders and built-in variables
+// such as {{$uuid}} in all request fields, with the request's folder and
+// request variables taking precedence over the environment. The secret
+// references and encrypted values the request uses are resolved first.
+func (r *Runner) resolveVars(req *protocol.Request, scoped map[string]string) error {
+	texts := req.Templates()
+	envVars, err := r.secrets.ResolveUsed(environment.Overlay(r.envSnapshot(), scoped), texts)
+	if err != nil {
+		return fmt.Errorf("resolving secrets: %w", err)
+	}
+	colVars, err := r.secrets.ResolveUsed(r.colVars, texts)
+	if err != nil {
+		return fmt.Errorf("resolving secrets: %w", err)
+	}
 
-	req.URL = environment.Resolve(req.URL, r.envVars, r.colVars)
+	req.URL = environment.Resolve(req.URL, envVars, colVars)
 
 	for k, v := range req.Headers {
-		req.Headers[k] = environment.Resolve(v, r.envVars, r.colVars)
+		req.Headers[k] = environment.Resolve(v, envVars, colVars)
 	}
 	for k, v := range req.Params {
-		req.Params[k] = environment.Resolve(v, r.envVars, r.colVars)
+		req.Params[k] = environment.Resolve(v, envVars, colVars)
 	}
+	for i := range req.Cookies {
+		req.Cookies[i].Value = environment.Resolve(req.Cookies[i].Value, envVars, colVars)
+	}
+	req.ApplyCookies()
 	if len(req.Body) > 0 {
-		req.Body = []byte(environment.Resolve(string(req.Body), r.envVars, r.colVars))
+		req.Body = []byte(environment.Resolve(string(req.Body), envVars, colVars))
+	}
+	req.BodyFile = environment.Resolve(req.BodyFile, envVars, colVars)
+	for i := range req.Form {
+		req.Form[i].Value = environment.Resolve(req.Form[i].Value, envVars, colVars)
+		req.Form[i].File = environment.Resolve(req.Form[i].File, envVars, colVars)
 	}
 	if req.Auth != nil {
-		req.Auth.Username = environment.Resolve(req.Auth.Username, r.envVars, r.colVars)
-		req.Auth.Password = environment.Resolve(req.Auth.Password, r.envVars, r.colVars)
-		req.Auth.Token = environment.Resolve(req.Auth.Token, r.envVars, r.colVars)
-		req.Auth.APIKey = environment.Resolve(req.Auth.APIKey, r.envVars, r.colVars)
-		req.Auth.APIValue = environment.Resolve(req.Auth.APIValue, r.envVars, r.colVars)
+		req.Auth.Username = environment.Resolve(req.Auth.Username, envVars, colVars)
+		req.Auth.Password = environment.Resolve(req.Auth.Password, envVars, colVars)
+		req.Auth.Token = environment.Resolve(req.Auth.Token, envVars, colVars)
+		req.Auth.APIKey = environment.Resolve(req.Auth.APIKey, envVars, colVars)
+		req.Auth.APIValue = environment.Resolve(req.Auth.APIValue, envVars, colVars)
 	}
 
 	// GraphQL
 	if req.GraphQLQuery != "" {
-		req.GraphQLQuery = environment.Resolve(req.GraphQLQuery, r.envVars, r.colVars)
+		req.GraphQLQuery = environment.Resolve(req.GraphQLQuery, envVars, colVars)
 	}
 	if req.GraphQLVariables != "" {
-		req.GraphQLVariables = environment.Resolve(req.GraphQLVariables, r.envVars, r.colVars)
+		req.GraphQLVariables = environment.Resolve(req.GraphQLVariables, envVars, colVars)
+	}
+
+	// WebSocket
+	for i := range req.WSMessages {
+		req.WSMessages[i].Content = environment.Resolve(req.WSMessages[i].Content, envVars, colVars)
+	}
+
+	if req.TLS != nil {
+		req.TLS.CertFile = environment.Resolve(req.TLS.CertFile, envVars, colVars)
+		req.TLS.KeyFile = environment.Resolve(req.TLS.KeyFile, envVars, colVars)
+		req.TLS.CAFile = environment.Resolve(req.TLS.CAFile, envVars, colVars)
+	}
+	if req.Proxy != nil {
+		req.Proxy.URL = environment.Resolve(req.Proxy.URL, envVars, colVars)
+		req.Proxy.NoProxy = environment.Resolve(req.Proxy.NoProxy, envVars, colVars)
+		req.Proxy.Username = environment.Resolve(req.Proxy.Username, envVars, colVars)
+		req.Proxy.Password = environment.Resolve(req.Proxy.Password, envVars, colVars)
+	}
+	for k, v := range req.Hosts {
+		req.Hosts[k] = environment.Resolve(v, envVars, colVars)
 	}
+	return nil
 }
 
 // ExitCode returns the appropriate exit code based on results.
@@ -5,15 +5,25 @@ import (
 	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/coder/websocket"
+	oauth2auth "github.com/sadopc/gottp/internal/auth/oauth2"
+	"github.com/sadopc/gottp/internal/core/assertion"
 	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/core/environment"
+	"github.com/sadopc/gottp/internal/core/retry"
+	"github.com/sadopc/gottp/internal/core/secrets"
 	"github.com/sadopc/gottp/internal/protocol"
 	httpclient "github.com/sadopc/gottp/internal/protocol/http"
 	"github.com/sadopc/gottp/internal/scripting"
@@ -137,7 +147,7 @@ func TestResolveVars(t *testing.T) {
 		},
 	}
 
-	r.resolveVars(req)
+	r.resolveVars(req, nil)
 
 	if req.URL != "https://example.com/api/v1/users" {
 		t.Errorf("URL not resolved: %s", req.URL)
@@ -297,6 +307,296 @@ func TestRunWithTestServer(t *testing.T) {
 	if results[1].StatusCode != 500 {
 		t.Errorf("expected status 500, got %d", results[1].StatusCode)
 	}
+	// Kept for reports without verbose output
+	if results[1].ErrorBody != "internal server error" || results[0].ErrorBody != "" {
+		t.Errorf("error bodies = %q, %q", results[0].ErrorBody, results[1].ErrorBody)
+	}
+
+	if results[0].Timing == nil || results[0].Timing.TCPConnect <= 0 || results[0].Timing.Total < results[0].Timing.TTFB {
+		t.Errorf("expected a timing breakdown, got %+v", results[0].Timing)
+	}
+}
+
+func TestRunSendsRequestCookies(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Cookie")
+	}))
+	defer server.Close()
+
+	registry := protocol.NewRegistry()
+	registry.Register(httpclient.New())
+
+	r := &Runner{
+		collection: &collection.Collection{
+			Items: []collection.Item{
+				{Request: &collection.Request{
+					Name:     "Profile",
+					Protocol: "http",
+					Method:   "GET",
+					URL:      server.URL,
+					Headers:  []collection.KVPair{{Key: "Cookie", Value: "theme=dark", Enabled: true}},
+					Cookies: []collection.KVPair{
+						{Key: "session", Value: "{{session}}", Enabled: true},
+						{Key: "off", Value: "x", Enabled: false},
+					},
+				}},
+			},
+		},
+		registry:     registry,
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{"session": "a b;c"},
+		colVars:      map[string]string{},
+		timeout:      10 * time.Second,
+	}
+
+	if _, err := r.Run(context.Background(), Config{}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if want := "theme=dark; session=a%20b%3Bc"; got != want {
+		t.Errorf("Cookie = %q, want %q", got, want)
+	}
+}
+
+func TestRunUnsupportedProtocol(t *testing.T) {
+	registry := protocol.NewRegistry()
+	registry.Register(httpclient.New())
+
+	r := &Runner{
+		collection: &collection.Collection{
+			Items: []collection.Item{
+				{Request: &collection.Request{Name: "Publish", Protocol: "mqtt", URL: "mqtt://localhost"}},
+			},
+		},
+		registry:     registry,
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{},
+		colVars:      map[string]string{},
+		timeout:      10 * time.Second,
+	}
+
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	var unsupported *protocol.UnsupportedError
+	if !errors.As(results[0].Error, &unsupported) || unsupported.Protocol != "mqtt" {
+		t.Fatalf("expected an unsupported protocol error, got %v", results[0].Error)
+	}
+	if !strings.Contains(results[0].ErrorString, "this build supports http") || !strings.Contains(results[0].ErrorString, "upgrade gottp") {
+		t.Errorf("error does not explain the fix: %s", results[0].ErrorString)
+	}
+}
+
+func TestRunRefreshesOAuth2TokenOn401(t *testing.T) {
+	tokenCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenCalls++
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"fresh-token","expires_in":3600}`))
+		default:
+			if r.Header.Get("Authorization") != "Bearer fresh-token" {
+				w.WriteHeader(401)
+				return
+			}
+			w.WriteHeader(200)
+		}
+	}))
+	defer server.Close()
+
+	registry := protocol.NewRegistry()
+	registry.Register(httpclient.New())
+
+	oauth := &collection.Auth{
+		Type: "oauth2",
+		OAuth2: &collection.OAuth2Auth{
+			GrantType: "client_credentials",
+			TokenURL:  server.URL + "/token",
+			ClientID:  "id",
+		},
+	}
+	r := &Runner{
+		collection: &collection.Collection{
+			Items: []collection.Item{
+				{Request: &collection.Request{Name: "First", Protocol: "http", Method: "GET", URL: server.URL + "/a", Auth: oauth}},
+				{Request: &collection.Request{Name: "Second", Protocol: "http", Method: "GET", URL: server.URL + "/b", Auth: oauth}},
+			},
+		},
+		registry:     registry,
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{},
+		colVars:      map[string]string{},
+		timeout:      10 * time.Second,
+	}
+
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if results[0].StatusCode != 200 || !results[0].TokenRefreshed {
+		t.Errorf("first request: status %d, refreshed %v", results[0].StatusCode, results[0].TokenRefreshed)
+	}
+	if results[1].StatusCode != 200 || results[1].TokenRefreshed {
+		t.Errorf("second request should reuse the token: status %d, refreshed %v", results[1].StatusCode, results[1].TokenRefreshed)
+	}
+	if tokenCalls != 1 {
+		t.Errorf("expected 1 token request, got %d", tokenCalls)
+	}
+}
+
+func TestRunSilentlyRefreshesExpiredAuthCodeToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			r.ParseForm()
+			if r.Form.Get("grant_type") != "refresh_token" {
+				t.Errorf("grant_type = %q, want refresh_token", r.Form.Get("grant_type"))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"fresh-token","expires_in":3600}`))
+		default:
+			if r.Header.Get("Authorization") != "Bearer fresh-token" {
+				w.WriteHeader(401)
+				return
+			}
+			w.WriteHeader(200)
+		}
+	}))
+	defer server.Close()
+
+	registry := protocol.NewRegistry()
+	registry.Register(httpclient.New())
+
+	tokenURL := server.URL + "/token"
+	r := &Runner{
+		collection: &collection.Collection{
+			Items: []collection.Item{
+				{Request: &collection.Request{Name: "Me", Protocol: "http", Method: "GET", URL: server.URL + "/me", Auth: &collection.Auth{
+					Type: "oauth2",
+					OAuth2: &collection.OAuth2Auth{
+						GrantType: "authorization_code",
+						AuthURL:   server.URL + "/authorize",
+						TokenURL:  tokenURL,
+						ClientID:  "id",
+					},
+				}}},
+			},
+		},
+		registry:     registry,
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{},
+		colVars:      map[string]string{},
+		timeout:      10 * time.Second,
+		tokens:       oauth2auth.NewTokenStore(),
+	}
+	r.tokens.Put(oauth2auth.TokenKey(tokenURL, "id", ""), &oauth2auth.TokenResponse{
+		AccessToken:  "stale-token",
+		RefreshToken: "refresh-me",
+		ExpiresIn:    60,
+		ObtainedAt:   time.Now().Add(-time.Hour),
+	})
+
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if results[0].Error != nil || results[0].StatusCode != 200 {
+		t.Fatalf("expected 200, got %d (%v)", results[0].StatusCode, results[0].Error)
+	}
+	if results[0].TokenRefreshed {
+		t.Error("silent refresh should not be reported as a 401 retry")
+	}
+}
+
+func TestRunAuthCodeWithoutBrowser(t *testing.T) {
+	r := &Runner{
+		collection: &collection.Collection{
+			Items: []collection.Item{
+				{Request: &collection.Request{Name: "Me", Protocol: "http", Method: "GET", URL: "http://127.0.0.1:0/me", Auth: &collection.Auth{
+					Type: "oauth2",
+					OAuth2: &collection.OAuth2Auth{
+						GrantType: "authorization_code",
+						AuthURL:   "http://127.0.0.1:0/authorize",
+						TokenURL:  "http://127.0.0.1:0/token",
+					},
+				}}},
+			},
+		},
+		registry:     protocol.NewRegistry(),
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{},
+		colVars:      map[string]string{},
+		timeout:      time.Second,
+	}
+
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !strings.Contains(results[0].ErrorString, "--oauth-browser") {
+		t.Errorf("expected hint about --oauth-browser, got %q", results[0].ErrorString)
+	}
+}
+
+func TestRunWebSocketScript(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		for {
+			typ, data, err := conn.Read(r.Context())
+			if err != nil {
+				return
+			}
+			conn.Write(r.Context(), typ, append([]byte("echo:"), data...))
+		}
+	}))
+	defer server.Close()
+
+	r := &Runner{
+		collection: &collection.Collection{
+			Items: []collection.Item{
+				{Request: &collection.Request{
+					Name:     "Chat",
+					Protocol: "websocket",
+					URL:      "ws" + strings.TrimPrefix(server.URL, "http"),
+					WebSocket: &collection.WebSocketConfig{
+						Messages: []collection.WSMessage{
+							{Name: "greet", Content: "hi {{user}}", Expect: &collection.WSExpect{Equals: "echo:hi alice"}},
+							{Name: "wrong", Content: "x", Expect: &collection.WSExpect{Equals: "nope", Timeout: 50 * time.Millisecond}},
+						},
+					},
+				}},
+			},
+		},
+		registry:     protocol.NewRegistry(),
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{"user": "alice"},
+		colVars:      map[string]string{},
+		timeout:      5 * time.Second,
+	}
+
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	res := results[0]
+	if res.Error != nil {
+		t.Fatalf("unexpected error: %v", res.Error)
+	}
+	if res.StatusCode != 101 {
+		t.Errorf("expected 101, got %d", res.StatusCode)
+	}
+	if len(res.TestResults) != 2 || !res.TestResults[0].Passed || res.TestResults[1].Passed {
+		t.Errorf("unexpected test results: %+v", res.TestResults)
+	}
+	if res.TestsPassed {
+		t.Error("failed expectation should fail the request")
+	}
 }
 
 func TestRunWithScripts(t *testing.T) {
@@ -406,6 +706,213 @@ func TestRunWithEnvResolution(t *testing.T) {
 	}
 }
 
+func TestRunWithScopedVariables(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	registry := protocol.NewRegistry()
+	registry.Register(httpclient.New())
+
+	col := &collection.Collection{
+		Variables: map[string]string{"version": "col", "id": "col"},
+		Items: []collection.Item{
+			{Folder: &collection.Folder{Name: "Users", Variables: map[string]string{"version": "v2", "id": "folder"}, Items: []collection.Item{
+				{Request: &collection.Request{Name: "Get", Protocol: "http", Method: "GET",
+					URL: "{{base_url}}/{{version}}/{{id}}", Variables: map[string]string{"id": "7"}}},
+			}}},
+			{Request: &collection.Request{Name: "Top", Protocol: "http", Method: "GET", URL: "{{base_url}}/{{version}}/{{id}}"}},
+		},
+	}
+	r := &Runner{
+		collection:   col,
+		registry:     registry,
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{"base_url": server.URL, "version": "env"},
+		colVars:      col.Variables,
+		timeout:      10 * time.Second,
+	}
+
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for i, want := range []string{"/v2/7", "/env/col"} {
+		if !strings.HasSuffix(results[i].URL, want) {
+			t.Errorf("%s: URL %s, want suffix %s", results[i].Name, results[i].URL, want)
+		}
+	}
+}
+
+func TestRunParallelPerHost(t *testing.T) {
+	var mu sync.Mutex
+	inFlight := map[string]int{}
+	peak := map[string]int{}
+	total, peakTotal := 0, 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight[r.Host]++
+		total++
+		peak[r.Host] = max(peak[r.Host], inFlight[r.Host])
+		peakTotal = max(peakTotal, total)
+		mu.Unlock()
+		time.Sleep(30 * time.Millisecond)
+		mu.Lock()
+		inFlight[r.Host]--
+		total--
+		mu.Unlock()
+		w.Write([]byte(r.URL.Path))
+	})
+	a := httptest.NewServer(handler)
+	defer a.Close()
+	b := httptest.NewServer(handler)
+	defer b.Close()
+
+	var items []collection.Item
+	for i := range 6 {
+		base := "{{a}}"
+		if i%2 == 1 {
+			base = "{{b}}"
+		}
+		items = append(items, collection.Item{Request: &collection.Request{
+			Name: fmt.Sprintf("r%d", i), Protocol: "http", Method: "GET", URL: fmt.Sprintf("%s/%d", base, i),
+		}})
+	}
+	registry := protocol.NewRegistry()
+	registry.Register(httpclient.New())
+	r := &Runner{
+		collection:   &collection.Collection{Items: items},
+		registry:     registry,
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{"a": a.URL, "b": b.URL},
+		colVars:      map[string]string{},
+		timeout:      10 * time.Second,
+	}
+
+	results, err := r.Run(context.Background(), Config{Parallel: 4, PerHost: 1})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for i, res := range results {
+		if res.Name != fmt.Sprintf("r%d", i) || res.StatusCode != 200 {
+			t.Errorf("result %d: %s %d %s", i, res.Name, res.StatusCode, res.ErrorString)
+		}
+	}
+	for host, n := range peak {
+		if n != 1 {
+			t.Errorf("%s had %d requests in flight, want 1", host, n)
+		}
+	}
+	if peakTotal != 2 {
+		t.Errorf("expected both hosts in parallel, peak was %d", peakTotal)
+	}
+}
+
+func TestRunner_EnvironmentProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String()+" "+r.Header.Get("Proxy-Authorization"))
+		w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	registry := protocol.NewRegistry()
+	registry.Register(httpclient.New())
+
+	r := &Runner{
+		collection: &collection.Collection{
+			Items: []collection.Item{
+				{Request: &collection.Request{Name: "Env", Method: "GET", URL: "http://upstream.invalid/a"}},
+				{Request: &collection.Request{Name: "Override", Method: "GET", URL: "http://upstream.invalid/b", ProxyURL: "{{proxy}}"}},
+			},
+		},
+		registry:     registry,
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{"proxy": proxy.URL, "proxy_pass": "s3cret"},
+		colVars:      map[string]string{},
+		timeout:      10 * time.Second,
+		proxy:        &environment.Proxy{URL: "{{proxy}}", Username: "me", Password: "{{proxy_pass}}"},
+	}
+
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, res := range results {
+		if res.Error != nil || res.StatusCode != 200 {
+			t.Fatalf("%s: status %d, err %v", res.Name, res.StatusCode, res.Error)
+		}
+	}
+	if len(proxied) != 2 {
+		t.Fatalf("expected both requests through the proxy, got %v", proxied)
+	}
+	if !strings.HasPrefix(proxied[0], "http://upstream.invalid/a Basic ") {
+		t.Errorf("environment proxy should send credentials: %q", proxied[0])
+	}
+	if proxied[1] != "http://upstream.invalid/b " {
+		t.Errorf("request proxy_url should override the environment proxy: %q", proxied[1])
+	}
+}
+
+func TestRunner_RetryPolicy(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path == "/flaky" && hits < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	registry := protocol.NewRegistry()
+	registry.Register(httpclient.New())
+
+	r := &Runner{
+		collection: &collection.Collection{
+			Items: []collection.Item{
+				{Request: &collection.Request{Name: "Flaky", Method: "GET", URL: server.URL + "/flaky"}},
+				{Request: &collection.Request{Name: "No Retry", Method: "GET", URL: server.URL + "/ok",
+					Retry: &retry.Policy{MaxAttempts: 1}}},
+			},
+		},
+		registry:     registry,
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{},
+		colVars:      map[string]string{},
+		timeout:      10 * time.Second,
+		retryPolicy:  &retry.Policy{MaxAttempts: 3, Backoff: time.Millisecond},
+	}
+
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	flaky := results[0]
+	if flaky.StatusCode != 200 || flaky.Retry == nil || flaky.Retry.Attempts != 3 {
+		t.Fatalf("expected success on the third attempt, got %d %+v", flaky.StatusCode, flaky.Retry)
+	}
+	if results[1].Retry != nil {
+		t.Errorf("max_attempts: 1 on the request should disable retries, got %+v", results[1].Retry)
+	}
+
+	var buf bytes.Buffer
+	PrintText(&buf, results, false)
+	if !strings.Contains(buf.String(), "3 attempts, retried on 503, 503") {
+		t.Errorf("expected attempt count in output:\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := PrintJSON(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"attempts": 3`) {
+		t.Errorf("expected retry stats in JSON:\n%s", buf.String())
+	}
+}
+
 func TestNewFromFile(t *testing.T) {
 	// Create temp collection file
 	dir := t.TempDir()
@@ -463,6 +970,113 @@ items:
 	}
 }
 
+func TestRunRequestOutsideCollection(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	envContent := "environments:\n  - name: dev\n    variables:\n      base:\n        value: " + server.URL + "\n      token:\n        value: t-1\n"
+	if err := os.WriteFile(filepath.Join(dir, "environments.yaml"), []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewForCollection(&collection.Collection{}, dir, Config{Environment: "dev"})
+	if err != nil {
+		t.Fatalf("NewForCollection failed: %v", err)
+	}
+	defer r.Close()
+
+	req := collection.NewRequest("Me", "GET", "{{base}}/me")
+	req.Headers = []collection.KVPair{{Key: "Authorization", Value: "Bearer {{token}}", Enabled: true}}
+	result := r.RunRequest(context.Background(), req, true)
+	if result.Error != nil {
+		t.Fatalf("RunRequest failed: %v", result.Error)
+	}
+	if result.StatusCode != 200 || string(result.Body) != "/me" || gotAuth != "Bearer t-1" {
+		t.Errorf("got status %d, body %q, auth %q", result.StatusCode, result.Body, gotAuth)
+	}
+	if req.URL != "{{base}}/me" {
+		t.Errorf("running the request changed it: %s", req.URL)
+	}
+}
+
+func TestEnvironmentHostsOverride(t *testing.T) {
+	var gotHost string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	addr := strings.TrimPrefix(server.URL, "http://")
+	envContent := "environments:\n  - name: staging\n    variables:\n      local:\n        value: " + addr + "\n    hosts:\n      api.staging.test: \"{{local}}\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "environments.yaml"), []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewForCollection(&collection.Collection{}, dir, Config{Environment: "staging"})
+	if err != nil {
+		t.Fatalf("NewForCollection failed: %v", err)
+	}
+	defer r.Close()
+
+	result := r.RunRequest(context.Background(), collection.NewRequest("Health", "GET", "http://api.staging.test/health"), false)
+	if result.Error != nil {
+		t.Fatalf("RunRequest failed: %v", result.Error)
+	}
+	if gotHost != "api.staging.test" {
+		t.Errorf("server saw Host %q", gotHost)
+	}
+}
+
+func TestEnvironmentHostsOverrideWebSocket(t *testing.T) {
+	var gotHost string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		typ, data, err := conn.Read(r.Context())
+		if err != nil {
+			return
+		}
+		conn.Write(r.Context(), typ, data)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	addr := strings.TrimPrefix(server.URL, "http://")
+	envContent := "environments:\n  - name: staging\n    variables: {}\n    hosts:\n      ws.staging.test: " + addr + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "environments.yaml"), []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewForCollection(&collection.Collection{}, dir, Config{Environment: "staging"})
+	if err != nil {
+		t.Fatalf("NewForCollection failed: %v", err)
+	}
+	defer r.Close()
+
+	req := collection.NewRequest("Chat", "", "ws://ws.staging.test/chat")
+	req.Protocol = "websocket"
+	req.WebSocket = &collection.WebSocketConfig{Messages: []collection.WSMessage{
+		{Name: "ping", Content: "ping", Expect: &collection.WSExpect{Equals: "ping"}},
+	}}
+	result := r.RunRequest(context.Background(), req, false)
+	if result.Error != nil {
+		t.Fatalf("RunRequest failed: %v", result.Error)
+	}
+	if gotHost != "ws.staging.test" || !result.TestsPassed {
+		t.Errorf("server saw Host %q, tests %+v", gotHost, result.TestResults)
+	}
+}
+
 func TestPrintText(t *testing.T) {
 	var buf bytes.Buffer
 	results := []Result{
@@ -511,6 +1125,53 @@ func TestPrintText(t *testing.T) {
 	}
 }
 
+func TestPrintTextVerboseTiming(t *testing.T) {
+	results := []Result{{
+		Name:        "Get Users",
+		Method:      "GET",
+		URL:         "https://api.example.com/users",
+		StatusCode:  200,
+		Duration:    145 * time.Millisecond,
+		TestsPassed: true,
+		Timing: &protocol.TimingDetail{
+			DNSLookup:    2 * time.Millisecond,
+			TCPConnect:   5 * time.Millisecond,
+			TLSHandshake: 20 * time.Millisecond,
+			TTFB:         110 * time.Millisecond,
+			Transfer:     8 * time.Millisecond,
+			Total:        145 * time.Millisecond,
+		},
+	}}
+
+	var quiet bytes.Buffer
+	PrintText(&quiet, results, false)
+	if strings.Contains(quiet.String(), "[timing]") {
+		t.Error("timing should only be printed in verbose mode")
+	}
+
+	var buf bytes.Buffer
+	PrintText(&buf, results, true)
+	want := "[timing] dns 2ms, connect 5ms, tls 20ms, server 110ms, transfer 8ms"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %q in output:\n%s", want, buf.String())
+	}
+
+	var js bytes.Buffer
+	if err := PrintJSON(&js, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(js.String(), `"ttfb": 110000000`) {
+		t.Errorf("expected timing in JSON output:\n%s", js.String())
+	}
+
+	results[0].Timing = &protocol.TimingDetail{TTFB: 30 * time.Millisecond, ConnReused: true, ConnIdle: 2 * time.Second}
+	buf.Reset()
+	PrintText(&buf, results, true)
+	if want := "(connection reused after 2.0s idle)"; !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %q in output:\n%s", want, buf.String())
+	}
+}
+
 func TestPrintJSON(t *testing.T) {
 	var buf bytes.Buffer
 	results := []Result{
@@ -554,7 +1215,7 @@ func TestPrintJUnit(t *testing.T) {
 		},
 	}
 
-	if err := PrintJUnit(&buf, results); err != nil {
+	if err := PrintJUnit(&buf, results, RunInfo{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -575,6 +1236,62 @@ func TestPrintJUnit(t *testing.T) {
 	}
 }
 
+func TestPrintJUnit_Details(t *testing.T) {
+	var buf bytes.Buffer
+	results := []Result{
+		{
+			Name:        "Create",
+			Method:      "POST",
+			URL:         "https://example.com/users",
+			StatusCode:  500,
+			Status:      "500 Internal Server Error",
+			Duration:    250 * time.Millisecond,
+			ErrorBody:   `{"error":"db down"}`,
+			ScriptLogs:  []string{"creating user", "payload sent"},
+			TestResults: []TestResult{{Name: "created", Passed: false, Error: "status 500"}},
+		},
+		{Name: "List", Method: "GET", URL: "https://example.com/users", StatusCode: 200, Duration: 50 * time.Millisecond},
+	}
+	info := RunInfo{Collection: "Users API", Environment: "Staging", Version: "1.2.3"}
+	if err := PrintJUnit(&buf, results, info); err != nil {
+		t.Fatal(err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, buf.String())
+	}
+	if suites.Name != "Users API" || suites.Tests != 2 || suites.Failures != 1 || suites.Time != 0.3 {
+		t.Errorf("totals = %q tests=%d failures=%d time=%v", suites.Name, suites.Tests, suites.Failures, suites.Time)
+	}
+	props := make(map[string]string)
+	for _, p := range suites.Suites[0].Properties {
+		props[p.Name] = p.Value
+	}
+	for name, want := range map[string]string{
+		"collection": "Users API", "environment": "Staging", "gottp.version": "1.2.3",
+		"method": "POST", "url": "https://example.com/users", "status": "500",
+	} {
+		if props[name] != want {
+			t.Errorf("property %s = %q, want %q", name, props[name], want)
+		}
+	}
+
+	failed := suites.Suites[0].Cases[0]
+	if failed.Time != 0.25 {
+		t.Errorf("test case time = %v, want 0.25", failed.Time)
+	}
+	if failed.SystemOut != "creating user\npayload sent" {
+		t.Errorf("system-out = %q", failed.SystemOut)
+	}
+	if failed.SystemErr != "500 Internal Server Error\n\n{\"error\":\"db down\"}" {
+		t.Errorf("system-err = %q", failed.SystemErr)
+	}
+	if passed := suites.Suites[1].Cases[0]; passed.SystemErr != "" || passed.SystemOut != "" {
+		t.Errorf("passing case has output: %+v", passed)
+	}
+}
+
 // Ensure environment.KVPair is distinct (resolver has its own copy to avoid circular imports).
 func TestResolverKVPairReuse(t *testing.T) {
 	envVars := map[string]string{"host": "api.example.com"}
@@ -585,3 +1302,181 @@ func TestResolverKVPairReuse(t *testing.T) {
 		t.Errorf("unexpected resolution: %s", result)
 	}
 }
+
+func TestRunFileBodiesRelativeToCollection(t *testing.T) {
+	got := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/multipart":
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("parsing multipart: %v", err)
+				return
+			}
+			f, _, err := r.FormFile("doc")
+			if err != nil {
+				t.Errorf("reading file: %v", err)
+				return
+			}
+			defer f.Close()
+			data, _ := io.ReadAll(f)
+			got["multipart"] = r.FormValue("title") + "|" + string(data)
+		case "/form":
+			_ = r.ParseForm()
+			got["form"] = r.PostForm.Get("q") + "|" + r.PostForm.Get("skipped")
+		case "/binary":
+			data, _ := io.ReadAll(r.Body)
+			got["binary"] = string(data)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "files"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "files", "doc.txt"), []byte("doc body"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	colPath := filepath.Join(dir, "test.gottp.yaml")
+	colContent := `name: Uploads
+variables:
+  base: ` + server.URL + `
+items:
+  - request:
+      name: Multipart
+      method: POST
+      url: "{{base}}/multipart"
+      body:
+        type: multipart
+        fields:
+          - { key: title, value: Report, enabled: true }
+          - { key: doc, file: files/doc.txt, enabled: true }
+  - request:
+      name: Form
+      method: POST
+      url: "{{base}}/form"
+      body:
+        type: form-urlencoded
+        fields:
+          - { key: q, value: "a b", enabled: true }
+          - { key: skipped, value: x, enabled: false }
+  - request:
+      name: Binary
+      method: PUT
+      url: "{{base}}/binary"
+      body:
+        type: binary-file
+        file: files/doc.txt
+`
+	if err := os.WriteFile(colPath, []byte(colContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New(Config{CollectionPath: colPath})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, res := range results {
+		if res.Error != nil {
+			t.Fatalf("%s failed: %v", res.Name, res.Error)
+		}
+	}
+
+	want := map[string]string{
+		"multipart": "Report|doc body",
+		"form":      "a b|",
+		"binary":    "doc body",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: got %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestRunEvaluatesAssertions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 7, "roles": ["admin"]}`))
+	}))
+	defer server.Close()
+
+	registry := protocol.NewRegistry()
+	registry.Register(httpclient.New())
+	r := &Runner{
+		collection: &collection.Collection{
+			Items: []collection.Item{
+				{Request: &collection.Request{
+					Name: "User", Protocol: "http", Method: "GET", URL: server.URL,
+					Assertions: []assertion.Assertion{
+						{Status: 200},
+						{JSONPath: "$.roles", Contains: "admin"},
+						{JSONPath: "$.id", Equals: 8},
+					},
+				}},
+			},
+		},
+		registry:     registry,
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{},
+		colVars:      map[string]string{},
+		timeout:      10 * time.Second,
+	}
+
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	tests := results[0].TestResults
+	if len(tests) != 3 || !tests[0].Passed || !tests[1].Passed || tests[2].Passed {
+		t.Fatalf("unexpected test results: %+v", tests)
+	}
+	if results[0].TestsPassed {
+		t.Error("a failed assertion should fail the request's tests")
+	}
+	if tests[2].Name != "$.id equals 8" || tests[2].Error != "expected 8, got 7" {
+		t.Errorf("failed assertion = %+v", tests[2])
+	}
+}
+
+func TestRunResolvesOnlyUsedSecrets(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	registry := protocol.NewRegistry()
+	registry.Register(httpclient.New())
+	t.Setenv("GOTTP_SECRET_API_TOKEN", "s3cr3t-token")
+	r := &Runner{
+		collection: &collection.Collection{Items: []collection.Item{{Request: &collection.Request{
+			Name:     "Get",
+			Protocol: "http",
+			Method:   "GET",
+			URL:      server.URL,
+			Headers:  []collection.KVPair{{Key: "Authorization", Value: "Bearer {{token}}", Enabled: true}},
+		}}}},
+		registry:     registry,
+		scriptEngine: scripting.NewEngine(5 * time.Second),
+		envVars:      map[string]string{"token": "secret://api_token", "unused": "secret://missing"},
+		colVars:      map[string]string{},
+		timeout:      10 * time.Second,
+		secrets:      secrets.NewResolver("", secrets.NewEnvProvider()),
+	}
+
+	results, err := r.Run(context.Background(), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Error != nil {
+		t.Fatalf("an unused missing secret failed the request: %v", results[0].Error)
+	}
+	if gotAuth != "Bearer s3cr3t-token" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+}
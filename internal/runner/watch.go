@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+// WatchedFiles lists the files a run of the collection at colPath depends
+// on: the collection's files, environments.yaml and .env next to it, and the body,
+// form and schema files its requests refer to. Files that do not exist are
+// listed too, so creating one triggers a run.
+func WatchedFiles(colPath string) []string {
+	dir := filepath.Dir(colPath)
+	seen := make(map[string]bool)
+	var files []string
+	add := func(p string) {
+		// Paths with variables are only known at send time
+		if p == "" || strings.Contains(p, "{{") {
+			return
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			files = append(files, p)
+		}
+	}
+	for _, f := range collection.Files(colPath) {
+		add(f)
+	}
+	add("environments.yaml")
+	add(".env")
+
+	col, err := collection.LoadFromFile(colPath)
+	if err != nil {
+		return files
+	}
+	for _, item := range collection.FlattenItems(col.Items, 0, "") {
+		req := item.Request
+		if req == nil {
+			continue
+		}
+		if req.Body != nil {
+			add(req.Body.File)
+			for _, f := range req.Body.Fields {
+				if f.Enabled {
+					add(f.File)
+				}
+			}
+		}
+		for _, a := range req.Assertions {
+			add(a.SchemaFile)
+		}
+	}
+	return files
+}
+
+// fileState is what WaitForChange compares to notice a change.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func statFiles(paths []string) map[string]fileState {
+	states := make(map[string]fileState, len(paths))
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil {
+			states[p] = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+		} else {
+			states[p] = fileState{}
+		}
+	}
+	return states
+}
+
+// WaitForChange polls paths every interval until one is created, modified
+// or removed, then waits for writes to settle and returns the changed
+// paths. It returns ctx.Err() when ctx is cancelled first.
+func WaitForChange(ctx context.Context, paths []string, interval time.Duration) ([]string, error) {
+	before := statFiles(paths)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	changed := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+		now := statFiles(paths)
+		settled := len(changed) > 0
+		for _, p := range paths {
+			if now[p] != before[p] {
+				changed[p] = true
+				settled = false
+			}
+		}
+		before = now
+		// Editors save in several writes, so wait for a quiet interval
+		if settled {
+			break
+		}
+	}
+
+	names := make([]string, 0, len(changed))
+	for p := range changed {
+		names = append(names, p)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// outcome summarises a result for ResultChanges.
+func outcome(r Result) string {
+	switch {
+	case r.Error != nil:
+		return "error"
+	case !r.TestsPassed:
+		return "failing"
+	}
+	return "passing"
+}
+
+// ResultChanges describes how results differ from the previous run's: the
+// requests that now pass, fail or error, and those that are new.
+func ResultChanges(prev, cur []Result) []string {
+	before := make(map[string]string, len(prev))
+	for _, r := range prev {
+		before[r.Name] = outcome(r)
+	}
+	var changes []string
+	for _, r := range cur {
+		was, ok := before[r.Name]
+		now := outcome(r)
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("%s: new, %s", r.Name, now))
+		case was != now:
+			changes = append(changes, fmt.Sprintf("%s: %s → %s", r.Name, was, now))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWatchedFiles(t *testing.T) {
+	dir := t.TempDir()
+	colPath := filepath.Join(dir, "api.gottp.yaml")
+	os.WriteFile(colPath, []byte(`name: API
+items:
+  - request:
+      name: Upload
+      method: POST
+      url: http://localhost/upload
+      body:
+        type: binary-file
+        file: data/payload.bin
+      assertions:
+        - schema_file: schemas/user.json
+  - request:
+      name: Form
+      method: POST
+      url: http://localhost/form
+      body:
+        type: multipart
+        fields:
+          - key: avatar
+            file: "{{avatarPath}}"
+            enabled: true
+          - key: doc
+            file: /abs/doc.pdf
+            enabled: true
+`), 0o644)
+
+	want := []string{
+		colPath,
+		filepath.Join(dir, "environments.yaml"),
+		filepath.Join(dir, ".env"),
+		filepath.Join(dir, "data/payload.bin"),
+		filepath.Join(dir, "schemas/user.json"),
+		"/abs/doc.pdf",
+	}
+	if got := WatchedFiles(colPath); !slices.Equal(got, want) {
+		t.Errorf("WatchedFiles = %v, want %v", got, want)
+	}
+}
+
+func TestWaitForChange(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "api.gottp.yaml")
+	missing := filepath.Join(dir, ".env")
+	os.WriteFile(existing, []byte("name: API\n"), 0o644)
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		os.WriteFile(missing, []byte("TOKEN=abc\n"), 0o644)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	changed, err := WaitForChange(ctx, []string{existing, missing}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(changed, []string{missing}) {
+		t.Errorf("changed = %v, want the created file", changed)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := WaitForChange(ctx, []string{existing}, 10*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context error, got %v", err)
+	}
+}
+
+func TestResultChanges(t *testing.T) {
+	prev := []Result{
+		{Name: "List", TestsPassed: true},
+		{Name: "Create", TestsPassed: false},
+		{Name: "Delete", TestsPassed: true},
+	}
+	cur := []Result{
+		{Name: "List", TestsPassed: true},
+		{Name: "Create", TestsPassed: true},
+		{Name: "Delete", Error: errors.New("connection refused")},
+		{Name: "Update", TestsPassed: true},
+	}
+	want := []string{"Create: failing → passing", "Delete: passing → error", "Update: new, passing"}
+	if got := ResultChanges(prev, cur); !slices.Equal(got, want) {
+		t.Errorf("ResultChanges = %q, want %q", got, want)
+	}
+}
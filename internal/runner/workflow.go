@@ -2,11 +2,11 @@ package runner
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/jsonquery"
 )
 
 // WorkflowResult holds the results of a workflow execution.
@@ -111,76 +111,19 @@ func (r *Runner) buildRequestMap() map[string]*collection.Request {
 	return m
 }
 
-// extractValue extracts a value from JSON response body using a simple JSONPath-like expression.
-// Supports: $.field, $.field.nested, $.array[0].field
+// extractValue extracts a value from a JSON response body using a JSONPath
+// ($.items[0].id) or jq (.items[0].id) expression. A bare name is treated as
+// a top-level field. Only the first match is used.
 func extractValue(body []byte, expr string) string {
 	expr = strings.TrimSpace(expr)
-
-	// Simple JSONPath: $.key or $.key.nested
-	if strings.HasPrefix(expr, "$.") {
-		path := strings.TrimPrefix(expr, "$.")
-		return jsonPathExtract(body, path)
-	}
-
-	// If it's just a key name, treat it as a top-level field
-	return jsonPathExtract(body, expr)
-}
-
-// jsonPathExtract does simple dot-notation JSON extraction.
-func jsonPathExtract(body []byte, path string) string {
-	parts := strings.Split(path, ".")
-	var current interface{}
-
-	if err := json.Unmarshal(body, &current); err != nil {
-		return ""
+	if !jsonquery.IsQuery(expr) {
+		expr = "$." + expr
 	}
-
-	for _, part := range parts {
-		// Handle array indexing: field[0]
-		if idx := strings.Index(part, "["); idx > 0 {
-			field := part[:idx]
-			indexStr := strings.TrimSuffix(part[idx+1:], "]")
-			var arrayIdx int
-			_, _ = fmt.Sscanf(indexStr, "%d", &arrayIdx)
-
-			obj, ok := current.(map[string]interface{})
-			if !ok {
-				return ""
-			}
-			arr, ok := obj[field].([]interface{})
-			if !ok || arrayIdx >= len(arr) {
-				return ""
-			}
-			current = arr[arrayIdx]
-			continue
-		}
-
-		obj, ok := current.(map[string]interface{})
-		if !ok {
-			return ""
-		}
-		current, ok = obj[part]
-		if !ok {
-			return ""
-		}
-	}
-
-	switch v := current.(type) {
-	case string:
-		return v
-	case float64:
-		if v == float64(int(v)) {
-			return fmt.Sprintf("%d", int(v))
-		}
-		return fmt.Sprintf("%g", v)
-	case bool:
-		return fmt.Sprintf("%v", v)
-	case nil:
+	results, err := jsonquery.Query(body, expr)
+	if err != nil || len(results) == 0 {
 		return ""
-	default:
-		b, _ := json.Marshal(v)
-		return string(b)
 	}
+	return jsonquery.String(results[0])
 }
 
 // evaluateCondition checks simple conditions against a result.
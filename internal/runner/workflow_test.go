@@ -18,6 +18,9 @@ func TestExtractValue(t *testing.T) {
 		{"$.tags[1]", "api"},
 		{"id", "123"},
 		{"$.nonexistent", ""},
+		{"$.address", `{"city":"NYC"}`},
+		{".tags | length", "2"},
+		{"$.tags[-1]", "api"},
 	}
 
 	for _, tt := range tests {
@@ -1,11 +1,13 @@
 package scripting
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
@@ -13,6 +15,9 @@ import (
 
 	"github.com/dop251/goja"
 	"github.com/google/uuid"
+
+	"github.com/sadopc/gottp/internal/core/jsonquery"
+	"github.com/sadopc/gottp/internal/protocol"
 )
 
 // ScriptAPI is the `gottp` global object exposed to scripts.
@@ -23,6 +28,11 @@ type ScriptAPI struct {
 	testResults []TestResult
 	request     *ScriptRequest
 	response    *ScriptResponse
+
+	// ctx carries the script deadline to requests made with
+	// gottp.sendRequest; registry is nil when sending is disabled.
+	ctx      context.Context
+	registry *protocol.Registry
 }
 
 // TestResult holds the result of a gottp.test() call.
@@ -169,6 +179,17 @@ func (a *ScriptAPI) registerOnRuntime(vm *goja.Runtime) {
 		}
 		return vm.ToValue(string(data))
 	})
+	_ = gottpObj.Set("query", func(call goja.FunctionCall) goja.Value {
+		return a.query(vm, call)
+	})
+
+	// Standard library: gottp.crypto, gottp.encode and gottp.faker
+	_ = gottpObj.Set("crypto", newCryptoObject(vm))
+	_ = gottpObj.Set("encode", newEncodeObject(vm))
+	_ = gottpObj.Set("faker", newFakerObject(vm))
+
+	// Chained requests
+	_ = gottpObj.Set("sendRequest", a.sendRequest(vm))
 
 	// Request/Response objects
 	_ = gottpObj.Set("request", a.request)
@@ -176,3 +197,32 @@ func (a *ScriptAPI) registerOnRuntime(vm *goja.Runtime) {
 
 	_ = vm.Set("gottp", gottpObj)
 }
+
+// query implements gottp.query(expr, json?): it evaluates a JSONPath or jq
+// expression against json, or the response body, and returns the only
+// result, an array of several, or undefined when nothing matches.
+func (a *ScriptAPI) query(vm *goja.Runtime, call goja.FunctionCall) goja.Value {
+	expr := call.Argument(0).String()
+	var body string
+	switch {
+	case len(call.Arguments) > 1:
+		body = call.Argument(1).String()
+	case a.response != nil:
+		body = a.response.Body
+	default:
+		throw(vm, fmt.Errorf("gottp.query: no response to query"))
+	}
+	results, err := jsonquery.Query([]byte(body), expr)
+	if err != nil {
+		throw(vm, fmt.Errorf("gottp.query: %w", err))
+	}
+	if len(results) == 0 {
+		return goja.Undefined()
+	}
+	// Round-trip through JSON so numbers reach scripts as numbers
+	var v interface{}
+	if err := json.Unmarshal([]byte(jsonquery.Format(results)), &v); err != nil {
+		throw(vm, fmt.Errorf("gottp.query: %w", err))
+	}
+	return vm.ToValue(v)
+}
@@ -0,0 +1,83 @@
+package scripting
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/parser"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+// SyntaxError is a script that does not parse.
+type SyntaxError struct {
+	Line    int // 1-based; 0 when unknown
+	Column  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("line %d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// Check parses script without running it, so syntax errors show up before
+// the request is sent.
+func Check(script string) *SyntaxError {
+	if strings.TrimSpace(script) == "" {
+		return nil
+	}
+	if _, err := parser.ParseFile(nil, "", script, 0); err != nil {
+		var list parser.ErrorList
+		if errors.As(err, &list) && len(list) > 0 {
+			// Later errors usually follow from the first
+			first := list[0]
+			return &SyntaxError{Line: first.Position.Line, Column: first.Position.Column, Message: first.Message}
+		}
+		return &SyntaxError{Message: err.Error()}
+	}
+	// Some errors, such as redeclared let bindings, surface when compiling
+	if _, err := goja.Compile("", script, false); err != nil {
+		var syntax *goja.CompilerSyntaxError
+		if errors.As(err, &syntax) {
+			return &SyntaxError{Message: syntax.Message}
+		}
+		return &SyntaxError{Message: err.Error()}
+	}
+	return nil
+}
+
+// Diagnostic is a syntax error in one of a request's scripts.
+type Diagnostic struct {
+	Request *collection.Request
+	Path    string // folders and request name, e.g. "Auth/Login"
+	Script  string // pre_script or post_script
+	Err     *SyntaxError
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("request %q %s: %v", d.Path, d.Script, d.Err)
+}
+
+// CheckCollection parses the pre- and post-scripts of every request in col.
+func CheckCollection(col *collection.Collection) []Diagnostic {
+	var diags []Diagnostic
+	for _, item := range collection.FlattenItems(col.Items, 0, "") {
+		req := item.Request
+		if req == nil {
+			continue
+		}
+		path := strings.TrimPrefix(item.Path, "/")
+		if err := Check(req.PreScript); err != nil {
+			diags = append(diags, Diagnostic{Request: req, Path: path, Script: "pre_script", Err: err})
+		}
+		if err := Check(req.PostScript); err != nil {
+			diags = append(diags, Diagnostic{Request: req, Path: path, Script: "post_script", Err: err})
+		}
+	}
+	return diags
+}
@@ -0,0 +1,51 @@
+package scripting
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+)
+
+func TestCheck(t *testing.T) {
+	if err := Check(`gottp.test("ok", function() { gottp.assert(true); });`); err != nil {
+		t.Errorf("valid script: %v", err)
+	}
+	if err := Check("  \n"); err != nil {
+		t.Errorf("empty script: %v", err)
+	}
+
+	err := Check("var a = 1;\nif (a { }\n")
+	if err == nil || err.Line != 2 || err.Column != 7 || !strings.Contains(err.Message, "Unexpected token") {
+		t.Errorf("unexpected result %+v", err)
+	}
+	if err := Check("return 1;"); err == nil {
+		t.Error("scripts run at top level, so return is a syntax error")
+	}
+	if err := Check("let a = 1;\nlet a = 2;"); err == nil || err.Line != 0 {
+		t.Errorf("redeclaration should be caught when compiling: %+v", err)
+	}
+}
+
+func TestCheckCollection(t *testing.T) {
+	login := collection.NewRequest("Login", "POST", "http://x/login")
+	login.PostScript = `gottp.setEnvVar("token", JSON.parse(gottp.response.Body).token`
+	list := collection.NewRequest("List", "GET", "http://x/items")
+	list.PreScript = `gottp.log("ok");`
+	col := &collection.Collection{Items: []collection.Item{
+		{Folder: &collection.Folder{Name: "Auth", Items: []collection.Item{{Request: login}}}},
+		{Request: list},
+	}}
+
+	diags := CheckCollection(col)
+	if len(diags) != 1 {
+		t.Fatalf("expected one diagnostic, got %v", diags)
+	}
+	d := diags[0]
+	if d.Request != login || d.Path != "Auth/Login" || d.Script != "post_script" {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+	if !strings.HasPrefix(d.String(), `request "Auth/Login" post_script: line 1:`) {
+		t.Errorf("unexpected message %q", d.String())
+	}
+}
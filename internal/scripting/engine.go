@@ -6,11 +6,14 @@ import (
 	"time"
 
 	"github.com/dop251/goja"
+
+	"github.com/sadopc/gottp/internal/protocol"
 )
 
 // Engine executes JavaScript pre/post-request scripts.
 type Engine struct {
-	timeout time.Duration
+	timeout  time.Duration
+	registry *protocol.Registry
 }
 
 // NewEngine creates a new scripting engine with the given timeout.
@@ -21,6 +24,11 @@ func NewEngine(timeout time.Duration) *Engine {
 	return &Engine{timeout: timeout}
 }
 
+// SetRegistry enables gottp.sendRequest, which sends requests through reg.
+func (e *Engine) SetRegistry(reg *protocol.Registry) {
+	e.registry = reg
+}
+
 // Result holds script execution results.
 type Result struct {
 	Logs        []string
@@ -54,12 +62,14 @@ func (e *Engine) RunPostScript(script string, req *ScriptRequest, resp *ScriptRe
 }
 
 func (e *Engine) run(script string, api *ScriptAPI) error {
-	vm := goja.New()
-	api.registerOnRuntime(vm)
-
-	// Set up timeout via context
+	// Set up timeout via context; requests sent by the script share it
 	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
 	defer cancel()
+	api.ctx = ctx
+	api.registry = e.registry
+
+	vm := goja.New()
+	api.registerOnRuntime(vm)
 
 	// Interrupt VM on timeout
 	done := make(chan struct{})
@@ -3,6 +3,7 @@ package scripting
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -268,3 +269,33 @@ func TestReadFileNotFound(t *testing.T) {
 		t.Errorf("expected undefined for missing file, got %s", result.Logs[0])
 	}
 }
+
+func TestQuery(t *testing.T) {
+	engine := NewEngine(5 * time.Second)
+	resp := &ScriptResponse{StatusCode: 200, Body: `{"items":[{"id":7,"name":"a"},{"id":8,"name":"b"}]}`}
+
+	result := engine.RunPostScript(`
+		gottp.setEnvVar("first", gottp.query("$.items[0].id") + 1);
+		gottp.log(gottp.query(".items[].name").join(","));
+		gottp.log(String(gottp.query("$.missing")));
+		gottp.log(gottp.query("$.n", '{"n": 2}'));
+	`, &ScriptRequest{}, resp, nil)
+
+	if result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if result.EnvChanges["first"] != "8" {
+		t.Errorf("numbers should stay numbers, got %q", result.EnvChanges["first"])
+	}
+	want := []string{"a,b", "undefined", "2"}
+	for i, w := range want {
+		if result.Logs[i] != w {
+			t.Errorf("log %d: got %q, want %q", i, result.Logs[i], w)
+		}
+	}
+
+	result = engine.RunPostScript(`gottp.query("$[")`, &ScriptRequest{}, resp, nil)
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "gottp.query") {
+		t.Errorf("expected a query error, got %v", result.Err)
+	}
+}
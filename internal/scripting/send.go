@@ -0,0 +1,167 @@
+package scripting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/sadopc/gottp/internal/protocol"
+)
+
+// sendOptions are the fields accepted by gottp.sendRequest.
+type sendOptions struct {
+	Protocol  string            `json:"protocol"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers"`
+	Params    map[string]string `json:"params"`
+	Body      interface{}       `json:"body"`
+	Query     string            `json:"query"`     // GraphQL
+	Variables interface{}       `json:"variables"` // GraphQL
+	Service   string            `json:"service"`   // gRPC
+	RPC       string            `json:"rpc"`       // gRPC method
+	Metadata  map[string]string `json:"metadata"`  // gRPC
+	Timeout   int64             `json:"timeout"`   // milliseconds
+}
+
+// sendRequest implements gottp.sendRequest(options): it runs a request
+// through the protocol registry and returns a response shaped like
+// gottp.response. The request shares the script's deadline, so a slow
+// server cannot hold a script past its timeout.
+func (a *ScriptAPI) sendRequest(vm *goja.Runtime) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		if a.registry == nil {
+			throw(vm, errors.New("gottp.sendRequest is not available here"))
+		}
+		req, err := buildSendRequest(call.Argument(0))
+		if err != nil {
+			throw(vm, fmt.Errorf("gottp.sendRequest: %w", err))
+		}
+
+		ctx := a.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if req.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+			defer cancel()
+		}
+		resp, err := a.registry.Execute(ctx, req)
+		if err != nil {
+			if a.ctx != nil && a.ctx.Err() != nil {
+				err = errors.New("script timeout exceeded")
+			}
+			throw(vm, fmt.Errorf("gottp.sendRequest %s %s: %w", req.Method, req.URL, err))
+		}
+		a.logs = append(a.logs, fmt.Sprintf("sendRequest: %s %s -> %s (%s)",
+			req.Method, req.URL, resp.Status, resp.Duration.Round(time.Millisecond)))
+
+		headers := make(map[string]string, len(resp.Headers))
+		for k := range resp.Headers {
+			headers[k] = resp.Headers.Get(k)
+		}
+		return vm.ToValue(&ScriptResponse{
+			StatusCode:  resp.StatusCode,
+			Status:      resp.Status,
+			Body:        string(resp.Body),
+			Headers:     headers,
+			Duration:    float64(resp.Duration.Milliseconds()),
+			Size:        resp.Size,
+			ContentType: resp.ContentType,
+		})
+	}
+}
+
+// buildSendRequest converts the options object passed from JavaScript. A
+// string is shorthand for a GET of that URL, and an object or array body is
+// sent as JSON.
+func buildSendRequest(arg goja.Value) (*protocol.Request, error) {
+	if goja.IsUndefined(arg) || goja.IsNull(arg) {
+		return nil, errors.New("missing options")
+	}
+	var opts sendOptions
+	if s, ok := arg.Export().(string); ok {
+		opts.URL = s
+	} else {
+		// Round-trip through JSON so nested bodies keep their shape
+		data, err := json.Marshal(arg.Export())
+		if err != nil {
+			return nil, fmt.Errorf("invalid options: %w", err)
+		}
+		if err := json.Unmarshal(data, &opts); err != nil {
+			return nil, fmt.Errorf("invalid options: %w", err)
+		}
+	}
+	if strings.TrimSpace(opts.URL) == "" {
+		return nil, errors.New("url is required")
+	}
+
+	req := &protocol.Request{
+		Protocol:     strings.ToLower(opts.Protocol),
+		Method:       strings.ToUpper(opts.Method),
+		URL:          opts.URL,
+		Headers:      opts.Headers,
+		Params:       opts.Params,
+		GraphQLQuery: opts.Query,
+		GRPCService:  opts.Service,
+		GRPCMethod:   opts.RPC,
+		Metadata:     opts.Metadata,
+		Timeout:      time.Duration(opts.Timeout) * time.Millisecond,
+	}
+	switch req.Protocol {
+	case "", "http", "graphql", "grpc":
+	default:
+		return nil, fmt.Errorf("protocol %q is not supported; use http, graphql or grpc", opts.Protocol)
+	}
+	if req.Method == "" {
+		req.Method = "GET"
+		if opts.Body != nil || req.Protocol == "graphql" {
+			req.Method = "POST"
+		}
+	}
+	if req.Headers == nil {
+		req.Headers = map[string]string{}
+	}
+
+	switch body := opts.Body.(type) {
+	case nil:
+	case string:
+		req.Body = []byte(body)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body: %w", err)
+		}
+		req.Body = data
+		if !hasHeader(req.Headers, "Content-Type") {
+			req.Headers["Content-Type"] = "application/json"
+		}
+	}
+	if opts.Variables != nil {
+		if s, ok := opts.Variables.(string); ok {
+			req.GraphQLVariables = s
+		} else {
+			data, err := json.Marshal(opts.Variables)
+			if err != nil {
+				return nil, fmt.Errorf("invalid variables: %w", err)
+			}
+			req.GraphQLVariables = string(data)
+		}
+	}
+	return req, nil
+}
+
+func hasHeader(headers map[string]string, name string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
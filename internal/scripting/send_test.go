@@ -0,0 +1,99 @@
+package scripting
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sadopc/gottp/internal/protocol"
+	httpclient "github.com/sadopc/gottp/internal/protocol/http"
+)
+
+func newSendEngine(timeout time.Duration) *Engine {
+	reg := protocol.NewRegistry()
+	reg.Register(httpclient.New())
+	engine := NewEngine(timeout)
+	engine.SetRegistry(reg)
+	return engine
+}
+
+func TestSendRequest_ChainsToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" || !strings.Contains(string(body), `"user":"ada"`) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token": "abc123"}`))
+	}))
+	defer srv.Close()
+
+	req := &ScriptRequest{Headers: map[string]string{}}
+	result := newSendEngine(5*time.Second).RunPreScript(`
+		var res = gottp.sendRequest({method: "post", url: gottp.getEnvVar("base") + "/login", body: {user: "ada"}});
+		gottp.assert(res.StatusCode === 200, "status " + res.StatusCode);
+		gottp.request.SetHeader("Authorization", "Bearer " + JSON.parse(res.Body).token);
+	`, req, map[string]string{"base": srv.URL})
+	if result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if req.Headers["Authorization"] != "Bearer abc123" {
+		t.Errorf("Authorization = %q", req.Headers["Authorization"])
+	}
+	if len(result.Logs) != 1 || !strings.Contains(result.Logs[0], "POST "+srv.URL+"/login -> 200") {
+		t.Errorf("logs = %v", result.Logs)
+	}
+}
+
+func TestSendRequest_Errors(t *testing.T) {
+	// Without a registry the function is present but throws
+	result := NewEngine(time.Second).RunPreScript(`gottp.sendRequest("http://localhost")`, &ScriptRequest{}, nil)
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "not available") {
+		t.Errorf("err = %v, want not available", result.Err)
+	}
+
+	engine := newSendEngine(time.Second)
+	for script, want := range map[string]string{
+		`gottp.sendRequest({})`: "url is required",
+		`gottp.sendRequest({url: "ws://x", protocol: "websocket"})`: "not supported",
+	} {
+		result := engine.RunPreScript(script, &ScriptRequest{}, nil)
+		if result.Err == nil || !strings.Contains(result.Err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", script, result.Err, want)
+		}
+	}
+
+	// Errors can be caught by the script
+	result = engine.RunPreScript(`
+		try { gottp.sendRequest({url: "http://127.0.0.1:1"}); } catch (e) { gottp.log("caught"); }
+	`, &ScriptRequest{}, nil)
+	if result.Err != nil || len(result.Logs) != 1 || result.Logs[0] != "caught" {
+		t.Errorf("err = %v, logs = %v", result.Err, result.Logs)
+	}
+}
+
+func TestSendRequest_BoundedByScriptTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	result := newSendEngine(200*time.Millisecond).RunPreScript(
+		`gottp.sendRequest({url: "`+srv.URL+`"});`, &ScriptRequest{}, nil)
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "timeout") {
+		t.Errorf("err = %v, want a timeout", result.Err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("script ran for %s, want it stopped near its timeout", elapsed)
+	}
+}
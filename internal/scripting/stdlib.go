@@ -0,0 +1,228 @@
+package scripting
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/rand/v2"
+	"net/url"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/google/uuid"
+)
+
+// throw raises err as a JavaScript exception.
+func throw(vm *goja.Runtime, err error) {
+	panic(vm.NewGoError(err))
+}
+
+// hashFuncs are the algorithms accepted by gottp.crypto.hmac.
+var hashFuncs = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// encodeDigest renders a digest as hex (default), base64 or base64url.
+func encodeDigest(vm *goja.Runtime, sum []byte, encoding goja.Value) goja.Value {
+	enc := "hex"
+	if !goja.IsUndefined(encoding) {
+		enc = encoding.String()
+	}
+	switch enc {
+	case "hex":
+		return vm.ToValue(hex.EncodeToString(sum))
+	case "base64":
+		return vm.ToValue(base64.StdEncoding.EncodeToString(sum))
+	case "base64url":
+		return vm.ToValue(base64.RawURLEncoding.EncodeToString(sum))
+	}
+	throw(vm, fmt.Errorf("unknown encoding %q (want hex, base64 or base64url)", enc))
+	return nil
+}
+
+// newCryptoObject builds gottp.crypto: hashes, HMAC signatures, UUIDs and
+// JWT decoding. Digests are hex unless an encoding is passed last.
+func newCryptoObject(vm *goja.Runtime) *goja.Object {
+	obj := vm.NewObject()
+	digest := func(newHash func() hash.Hash) func(goja.FunctionCall) goja.Value {
+		return func(call goja.FunctionCall) goja.Value {
+			h := newHash()
+			h.Write([]byte(call.Argument(0).String()))
+			return encodeDigest(vm, h.Sum(nil), call.Argument(1))
+		}
+	}
+	_ = obj.Set("md5", digest(md5.New))
+	_ = obj.Set("sha1", digest(sha1.New))
+	_ = obj.Set("sha256", digest(sha256.New))
+	_ = obj.Set("sha512", digest(sha512.New))
+
+	// hmac(algorithm, key, message, encoding?)
+	_ = obj.Set("hmac", func(call goja.FunctionCall) goja.Value {
+		alg := strings.ToLower(strings.ReplaceAll(call.Argument(0).String(), "-", ""))
+		newHash, ok := hashFuncs[alg]
+		if !ok {
+			throw(vm, fmt.Errorf("unknown HMAC algorithm %q (want md5, sha1, sha256 or sha512)", call.Argument(0).String()))
+		}
+		mac := hmac.New(newHash, []byte(call.Argument(1).String()))
+		mac.Write([]byte(call.Argument(2).String()))
+		return encodeDigest(vm, mac.Sum(nil), call.Argument(3))
+	})
+	_ = obj.Set("uuid", func(call goja.FunctionCall) goja.Value {
+		return vm.ToValue(uuid.New().String())
+	})
+
+	// jwtDecode returns {header, payload, signature} without verifying the
+	// signature.
+	_ = obj.Set("jwtDecode", func(call goja.FunctionCall) goja.Value {
+		parts := strings.Split(strings.TrimSpace(call.Argument(0).String()), ".")
+		if len(parts) != 3 {
+			throw(vm, fmt.Errorf("invalid JWT: want 3 dot-separated parts, got %d", len(parts)))
+		}
+		decodePart := func(name, part string) interface{} {
+			data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+			if err != nil {
+				throw(vm, fmt.Errorf("invalid JWT %s: %w", name, err))
+			}
+			var v interface{}
+			if err := json.Unmarshal(data, &v); err != nil {
+				throw(vm, fmt.Errorf("invalid JWT %s: %w", name, err))
+			}
+			return v
+		}
+		return vm.ToValue(map[string]interface{}{
+			"header":    decodePart("header", parts[0]),
+			"payload":   decodePart("payload", parts[1]),
+			"signature": parts[2],
+		})
+	})
+	return obj
+}
+
+// newEncodeObject builds gottp.encode: base64, base64url, URL component
+// and hex encoding, each with a matching decoder that throws on bad input.
+func newEncodeObject(vm *goja.Runtime) *goja.Object {
+	obj := vm.NewObject()
+	codec := func(name string, encode func([]byte) string, decode func(string) ([]byte, error)) {
+		_ = obj.Set(name, func(call goja.FunctionCall) goja.Value {
+			return vm.ToValue(encode([]byte(call.Argument(0).String())))
+		})
+		_ = obj.Set(name+"Decode", func(call goja.FunctionCall) goja.Value {
+			data, err := decode(call.Argument(0).String())
+			if err != nil {
+				throw(vm, fmt.Errorf("%s decode: %w", name, err))
+			}
+			return vm.ToValue(string(data))
+		})
+	}
+	codec("base64", base64.StdEncoding.EncodeToString, base64.StdEncoding.DecodeString)
+	codec("base64url", base64.RawURLEncoding.EncodeToString, func(s string) ([]byte, error) {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	})
+	codec("hex", hex.EncodeToString, hex.DecodeString)
+	// Like encodeURIComponent: spaces become %20, not +
+	codec("url", func(b []byte) string {
+		return strings.ReplaceAll(url.QueryEscape(string(b)), "+", "%20")
+	}, func(s string) ([]byte, error) {
+		v, err := url.QueryUnescape(s)
+		return []byte(v), err
+	})
+	return obj
+}
+
+var (
+	fakeFirstNames = []string{"Ada", "Alan", "Grace", "Linus", "Margaret", "Dennis", "Barbara", "Ken", "Frances", "Edsger", "Radia", "Guido", "Hedy", "Tim", "Katherine", "Bjarne"}
+	fakeLastNames  = []string{"Lovelace", "Turing", "Hopper", "Torvalds", "Hamilton", "Ritchie", "Liskov", "Thompson", "Allen", "Dijkstra", "Perlman", "Rossum", "Lamarr", "Berners-Lee", "Johnson", "Stroustrup"}
+	fakeDomains    = []string{"example.com", "example.org", "example.net", "test.dev", "mail.test"}
+	fakeWords      = []string{"alpha", "bravo", "cache", "delta", "echo", "field", "gamma", "hotel", "index", "json", "kilo", "lambda", "mock", "node", "orbit", "proxy", "query", "route", "stream", "token", "unit", "vector", "widget", "yaml", "zone"}
+	fakeStreets    = []string{"Main St", "Oak Ave", "Maple Rd", "Cedar Ln", "Elm St", "Pine Ct", "Lake Dr", "Hill Rd"}
+	fakeCities     = []string{"Springfield", "Riverton", "Fairview", "Greenville", "Madison", "Georgetown", "Franklin", "Clinton"}
+)
+
+func pick(list []string) string {
+	return list[rand.IntN(len(list))]
+}
+
+// newFakerObject builds gottp.faker, which generates random test data.
+// Emails use reserved example domains so they never reach a real inbox.
+func newFakerObject(vm *goja.Runtime) *goja.Object {
+	obj := vm.NewObject()
+	firstName := func() string { return pick(fakeFirstNames) }
+	lastName := func() string { return pick(fakeLastNames) }
+	str := func(fn func() string) func(goja.FunctionCall) goja.Value {
+		return func(goja.FunctionCall) goja.Value { return vm.ToValue(fn()) }
+	}
+	// bounds reads optional (min, max) arguments with the given defaults.
+	bounds := func(call goja.FunctionCall, lo, hi float64) (float64, float64) {
+		if len(call.Arguments) >= 2 {
+			return call.Argument(0).ToFloat(), call.Argument(1).ToFloat()
+		}
+		if len(call.Arguments) == 1 {
+			return lo, call.Argument(0).ToFloat()
+		}
+		return lo, hi
+	}
+
+	_ = obj.Set("firstName", str(firstName))
+	_ = obj.Set("lastName", str(lastName))
+	_ = obj.Set("name", str(func() string { return firstName() + " " + lastName() }))
+	_ = obj.Set("username", str(func() string {
+		return strings.ToLower(firstName()) + fmt.Sprint(rand.IntN(1000))
+	}))
+	_ = obj.Set("email", str(func() string {
+		local := strings.ToLower(firstName() + "." + strings.ReplaceAll(lastName(), "-", ""))
+		return fmt.Sprintf("%s%d@%s", local, rand.IntN(100), pick(fakeDomains))
+	}))
+	_ = obj.Set("phone", str(func() string {
+		return fmt.Sprintf("+1-555-%03d-%04d", rand.IntN(1000), rand.IntN(10000))
+	}))
+	_ = obj.Set("address", str(func() string {
+		return fmt.Sprintf("%d %s, %s", 1+rand.IntN(9999), pick(fakeStreets), pick(fakeCities))
+	}))
+	_ = obj.Set("word", str(func() string { return pick(fakeWords) }))
+	_ = obj.Set("sentence", func(call goja.FunctionCall) goja.Value {
+		n := 6
+		if len(call.Arguments) > 0 {
+			n = max(int(call.Argument(0).ToInteger()), 1)
+		}
+		words := make([]string, n)
+		for i := range words {
+			words[i] = pick(fakeWords)
+		}
+		s := strings.Join(words, " ")
+		return vm.ToValue(strings.ToUpper(s[:1]) + s[1:] + ".")
+	})
+
+	// int(min, max) and float(min, max) return values in [min, max)
+	_ = obj.Set("int", func(call goja.FunctionCall) goja.Value {
+		lo, hi := bounds(call, 0, 1000)
+		if hi <= lo {
+			return vm.ToValue(int64(lo))
+		}
+		return vm.ToValue(int64(lo) + rand.Int64N(int64(hi)-int64(lo)))
+	})
+	_ = obj.Set("float", func(call goja.FunctionCall) goja.Value {
+		lo, hi := bounds(call, 0, 1)
+		return vm.ToValue(lo + rand.Float64()*(hi-lo))
+	})
+	_ = obj.Set("bool", func(goja.FunctionCall) goja.Value {
+		return vm.ToValue(rand.IntN(2) == 1)
+	})
+	_ = obj.Set("pick", func(call goja.FunctionCall) goja.Value {
+		var items []interface{}
+		if err := vm.ExportTo(call.Argument(0), &items); err != nil || len(items) == 0 {
+			return goja.Undefined()
+		}
+		return vm.ToValue(items[rand.IntN(len(items))])
+	})
+	return obj
+}
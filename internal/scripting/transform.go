@@ -0,0 +1,60 @@
+package scripting
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// Transform evaluates a one-line JavaScript expression against a response
+// body and renders the result: strings as-is, everything else as indented
+// JSON. The expression sees `body` (the parsed JSON, or the text when the
+// body is not JSON) and `text` (the raw body), plus the gottp.crypto,
+// gottp.encode and gottp.faker helpers. It is stopped after timeout.
+func Transform(expr, body string, timeout time.Duration) (string, error) {
+	vm := goja.New()
+	var parsed interface{} = body
+	var doc interface{}
+	if err := json.Unmarshal([]byte(body), &doc); err == nil {
+		parsed = doc
+	}
+	_ = vm.Set("body", parsed)
+	_ = vm.Set("text", body)
+	gottpObj := vm.NewObject()
+	_ = gottpObj.Set("crypto", newCryptoObject(vm))
+	_ = gottpObj.Set("encode", newEncodeObject(vm))
+	_ = gottpObj.Set("faker", newFakerObject(vm))
+	_ = vm.Set("gottp", gottpObj)
+
+	timer := time.AfterFunc(timeout, func() {
+		vm.Interrupt("transform timeout exceeded")
+	})
+	defer timer.Stop()
+
+	v, err := vm.RunString(expr)
+	if err != nil {
+		if exc, ok := err.(*goja.Exception); ok {
+			// Drop the stack trace; the expression is a single line
+			return "", errors.New(exc.Value().String())
+		}
+		return "", err
+	}
+	if v == nil || goja.IsUndefined(v) {
+		return "undefined", nil
+	}
+	if s, ok := v.Export().(string); ok {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v.Export()); err != nil {
+		return v.String(), nil
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,36 @@
+package scripting
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransform(t *testing.T) {
+	body := `{"items": [{"id": 1, "tag": "a<b"}, {"id": 2}]}`
+	tests := []struct {
+		expr, want string
+	}{
+		{`body.items.map(function(i) { return i.id })`, "[\n  1,\n  2\n]"},
+		{`body.items[0].tag`, "a<b"},
+		{`text.length`, "47"},
+		{`gottp.encode.base64("hi")`, "aGk="},
+		{`body.missing`, "undefined"},
+	}
+	for _, tt := range tests {
+		got, err := Transform(tt.expr, body, time.Second)
+		if err != nil || got != tt.want {
+			t.Errorf("Transform(%s) = %q, %v; want %q", tt.expr, got, err, tt.want)
+		}
+	}
+
+	if got, err := Transform(`body.toUpperCase()`, "plain", time.Second); err != nil || got != "PLAIN" {
+		t.Errorf("non-JSON body: got %q, %v", got, err)
+	}
+	if _, err := Transform(`body.items.nope()`, body, time.Second); err == nil || !strings.Contains(err.Error(), "TypeError") {
+		t.Errorf("expected a TypeError, got %v", err)
+	}
+	if _, err := Transform(`while (true) {}`, body, 50*time.Millisecond); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sadopc/gottp/internal/export"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// Annotate lets the user highlight lines of a response body and attach a
+// note, then shares the excerpt as Markdown.
+type Annotate struct {
+	Visible bool
+	snippet export.Snippet // request summary; lines and note are filled in on share
+	lines   []string
+	cursor  int
+	anchor  int // other end of the selection, or -1 for just the cursor line
+	offset  int
+	note    textinput.Model
+	editing bool
+	width   int
+	height  int
+	theme   theme.Theme
+	styles  theme.Styles
+}
+
+// NewAnnotate creates a new annotation overlay.
+func NewAnnotate(t theme.Theme, s theme.Styles) Annotate {
+	note := textinput.New()
+	note.Placeholder = "What's wrong here?"
+	note.Prompt = "Note: "
+	note.CharLimit = 500
+	return Annotate{theme: t, styles: s, note: note, anchor: -1, width: 100, height: 30}
+}
+
+// Open shows body for annotation. summary carries the request, status and
+// code fence language of the snippet.
+func (m *Annotate) Open(summary export.Snippet, body string) {
+	m.Visible = true
+	m.snippet = summary
+	m.lines = strings.Split(body, "\n")
+	m.cursor = 0
+	m.anchor = -1
+	m.offset = 0
+	m.editing = false
+	m.note.SetValue("")
+	m.note.Blur()
+}
+
+// SetSize fits the overlay to the terminal.
+func (m *Annotate) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+// Close hides the overlay.
+func (m *Annotate) Close() {
+	m.Visible = false
+	m.lines = nil
+	m.note.Blur()
+}
+
+// Selection returns the first and last selected line, 0-based.
+func (m Annotate) Selection() (int, int) {
+	if m.anchor < 0 {
+		return m.cursor, m.cursor
+	}
+	return min(m.anchor, m.cursor), max(m.anchor, m.cursor)
+}
+
+// Snippet returns the selected lines and note as a snippet.
+func (m Annotate) Snippet() export.Snippet {
+	s := m.snippet
+	first, last := m.Selection()
+	s.StartLine = first + 1
+	s.Lines = append([]string(nil), m.lines[first:last+1]...)
+	s.Total = len(m.lines)
+	s.Note = m.note.Value()
+	return s
+}
+
+// visibleLines is how many body lines fit in the box.
+func (m Annotate) visibleLines() int {
+	// Border, padding, title, note, hint and blank lines
+	return max(m.height-14, 3)
+}
+
+func (m *Annotate) moveCursor(delta int) {
+	m.cursor = min(max(m.cursor+delta, 0), len(m.lines)-1)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+m.visibleLines() {
+		m.offset = m.cursor - m.visibleLines() + 1
+	}
+}
+
+// Update handles key input while the overlay is visible.
+func (m Annotate) Update(msg tea.Msg) (Annotate, tea.Cmd) {
+	if !m.Visible {
+		return m, nil
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if m.editing {
+		if ok && (key.String() == "enter" || key.String() == "esc") {
+			m.editing = false
+			m.note.Blur()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.note, cmd = m.note.Update(msg)
+		return m, cmd
+	}
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "esc", "q":
+		m.Close()
+		return m, func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} }
+	case "j", "down":
+		m.moveCursor(1)
+	case "k", "up":
+		m.moveCursor(-1)
+	case "ctrl+d", "pgdown":
+		m.moveCursor(m.visibleLines() / 2)
+	case "ctrl+u", "pgup":
+		m.moveCursor(-m.visibleLines() / 2)
+	case "g":
+		m.moveCursor(-len(m.lines))
+	case "G":
+		m.moveCursor(len(m.lines))
+	case "v", " ":
+		if m.anchor < 0 {
+			m.anchor = m.cursor
+		} else {
+			m.anchor = -1
+		}
+	case "a", "n":
+		m.editing = true
+		m.note.CursorEnd()
+		return m, m.note.Focus()
+	case "enter", "y":
+		snippet := m.Snippet()
+		m.Close()
+		return m, tea.Batch(
+			func() tea.Msg { return msgs.ShareSnippetMsg{Snippet: snippet} },
+			func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} },
+		)
+	}
+	return m, nil
+}
+
+// View renders the overlay.
+func (m Annotate) View() string {
+	if !m.Visible {
+		return ""
+	}
+
+	boxWidth := min(max(m.width-4, 40), 110)
+	inner := boxWidth - 6
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Text).
+		Bold(true).
+		Width(inner).
+		Align(lipgloss.Center)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.theme.Muted)
+	selectedStyle := lipgloss.NewStyle().Background(m.theme.Overlay).Foreground(m.theme.Text)
+	gutterWidth := len(fmt.Sprint(len(m.lines)))
+
+	lines := []string{
+		titleStyle.Render("Annotate Response"),
+		mutedStyle.Render(m.snippet.Method + " " + m.snippet.URL + "  " + m.snippet.Status),
+		"",
+	}
+	first, last := m.Selection()
+	end := min(m.offset+m.visibleLines(), len(m.lines))
+	for i := m.offset; i < end; i++ {
+		marker := "  "
+		if i == m.cursor {
+			marker = "> "
+		}
+		line := fmt.Sprintf("%s%*d  %s", marker, gutterWidth, i+1, m.lines[i])
+		line = lipgloss.NewStyle().MaxWidth(inner).Render(line)
+		if i >= first && i <= last {
+			line = selectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "")
+	if m.editing || m.note.Value() != "" {
+		lines = append(lines, m.note.View())
+	} else {
+		lines = append(lines, mutedStyle.Render("No note"))
+	}
+
+	selected := fmt.Sprintf("line %d", first+1)
+	if last > first {
+		selected = fmt.Sprintf("lines %d-%d", first+1, last+1)
+	}
+	hint := selected + " · j/k: move · v: select range · a: note · enter: copy as Markdown · esc: cancel"
+	if m.editing {
+		hint = "enter/esc: done"
+	}
+	lines = append(lines, "", mutedStyle.Render(hint))
+
+	return lipgloss.NewStyle().
+		Width(boxWidth).
+		Background(m.theme.Surface).
+		Foreground(m.theme.Text).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.BorderFocused).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+}
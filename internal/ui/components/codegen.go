@@ -0,0 +1,241 @@
+package components
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sadopc/gottp/internal/export/codegen"
+	"github.com/sadopc/gottp/internal/protocol"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// codegenLanguages holds the tab label and chroma lexer of each language.
+var codegenLanguages = map[codegen.Language]struct{ label, lexer string }{
+	codegen.LangGo:         {"Go", "go"},
+	codegen.LangPython:     {"Python", "python"},
+	codegen.LangJavaScript: {"JavaScript", "javascript"},
+	codegen.LangCurl:       {"cURL", "bash"},
+	codegen.LangRuby:       {"Ruby", "ruby"},
+	codegen.LangJava:       {"Java", "java"},
+	codegen.LangRust:       {"Rust", "rust"},
+	codegen.LangPHP:        {"PHP", "php"},
+	codegen.LangCSharp:     {"C#", "csharp"},
+	codegen.LangKotlin:     {"Kotlin", "kotlin"},
+	codegen.LangSwift:      {"Swift", "swift"},
+	codegen.LangPowerShell: {"PowerShell", "powershell"},
+	codegen.LangHTTPie:     {"HTTPie", "bash"},
+}
+
+const codegenLines = 18
+
+// CodeGen shows the current request as a snippet in each language codegen
+// supports, highlighted and scrollable, and copies the one shown.
+type CodeGen struct {
+	Visible bool
+	req     *protocol.Request
+	langs   []codegen.Language
+	lang    int
+	code    map[codegen.Language][]string // highlighted lines, by language
+	source  map[codegen.Language]string
+	err     string
+	offset  int
+	theme   theme.Theme
+	styles  theme.Styles
+}
+
+// NewCodeGen creates a new code generation overlay.
+func NewCodeGen(t theme.Theme, s theme.Styles) CodeGen {
+	return CodeGen{langs: codegen.Languages(), theme: t, styles: s}
+}
+
+// Open shows the overlay for req, starting at lang when it is supported.
+func (m *CodeGen) Open(req *protocol.Request, lang string) {
+	m.Visible = true
+	m.req = req
+	m.code = make(map[codegen.Language][]string)
+	m.source = make(map[codegen.Language]string)
+	for i, l := range m.langs {
+		if string(l) == lang {
+			m.lang = i
+		}
+	}
+	m.generate()
+}
+
+// Close hides the overlay and drops the generated snippets.
+func (m *CodeGen) Close() {
+	m.Visible = false
+	m.req = nil
+	m.code = nil
+	m.source = nil
+}
+
+// Language returns the language shown.
+func (m CodeGen) Language() codegen.Language {
+	return m.langs[m.lang]
+}
+
+// Code returns the snippet shown, without highlighting.
+func (m CodeGen) Code() string {
+	return m.source[m.Language()]
+}
+
+// generate builds the snippet for the language shown, once per language.
+func (m *CodeGen) generate() {
+	m.offset, m.err = 0, ""
+	lang := m.Language()
+	if _, ok := m.source[lang]; ok {
+		return
+	}
+	code, err := codegen.Generate(m.req, lang)
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.source[lang] = code
+	m.code[lang] = strings.Split(strings.TrimRight(highlightCode(code, codegenLanguages[lang].lexer), "\n"), "\n")
+}
+
+func (m *CodeGen) scroll(delta int) {
+	limit := max(len(m.code[m.Language()])-codegenLines, 0)
+	m.offset = min(max(m.offset+delta, 0), limit)
+}
+
+// Update handles key input while the overlay is visible.
+func (m CodeGen) Update(msg tea.Msg) (CodeGen, tea.Cmd) {
+	if !m.Visible {
+		return m, nil
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.String() {
+	case "esc", "q":
+		m.Close()
+		return m, func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} }
+	case "tab", "right", "l":
+		m.lang = (m.lang + 1) % len(m.langs)
+		m.generate()
+	case "shift+tab", "left", "h":
+		m.lang = (m.lang + len(m.langs) - 1) % len(m.langs)
+		m.generate()
+	case "j", "down":
+		m.scroll(1)
+	case "k", "up":
+		m.scroll(-1)
+	case "pgdown", "ctrl+d":
+		m.scroll(codegenLines)
+	case "pgup", "ctrl+u":
+		m.scroll(-codegenLines)
+	case "g", "home":
+		m.offset = 0
+	case "G", "end":
+		m.scroll(len(m.code[m.Language()]))
+	case "enter", "y", "c":
+		if m.err != "" {
+			return m, nil
+		}
+		text := msgs.CopyTextMsg{Text: m.Code(), Label: codegenLanguages[m.Language()].label + " code"}
+		return m, func() tea.Msg { return text }
+	}
+	return m, nil
+}
+
+// View renders the overlay.
+func (m CodeGen) View() string {
+	if !m.Visible {
+		return ""
+	}
+
+	boxWidth := 90
+	inner := boxWidth - 6
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Text).
+		Bold(true).
+		Width(inner).
+		Align(lipgloss.Center)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.theme.Muted)
+	selectedStyle := lipgloss.NewStyle().Foreground(m.theme.Mauve).Bold(true)
+
+	var tabs []string
+	for i, l := range m.langs {
+		label := string(l)
+		if info, ok := codegenLanguages[l]; ok {
+			label = info.label
+		}
+		if i == m.lang {
+			tabs = append(tabs, selectedStyle.Render("["+label+"]"))
+		} else {
+			tabs = append(tabs, mutedStyle.Render(label))
+		}
+	}
+
+	lines := []string{
+		titleStyle.Render("Generate Code"),
+		"",
+		lipgloss.NewStyle().Width(inner).Render(strings.Join(tabs, "  ")),
+		"",
+	}
+
+	code := m.code[m.Language()]
+	if m.err != "" {
+		lines = append(lines, m.styles.Error.MaxWidth(inner).Render(m.err))
+	} else {
+		end := min(m.offset+codegenLines, len(code))
+		for _, line := range code[m.offset:end] {
+			lines = append(lines, lipgloss.NewStyle().MaxWidth(inner).Render(line))
+		}
+		if len(code) > end {
+			lines = append(lines, mutedStyle.Render(fmt.Sprintf("… %d more lines", len(code)-end)))
+		}
+	}
+
+	lines = append(lines, "", mutedStyle.Render("←/→: language · j/k: scroll · enter: copy · esc: close"))
+
+	return lipgloss.NewStyle().
+		Width(boxWidth).
+		Background(m.theme.Surface).
+		Foreground(m.theme.Text).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.BorderFocused).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+}
+
+// highlightCode colors source for the terminal with the chroma lexer named
+// lexerName, returning it unchanged when tokenizing fails.
+func highlightCode(source, lexerName string) string {
+	lexer := lexers.Get(lexerName)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	style := chromastyles.Get("monokai")
+	if style == nil {
+		style = chromastyles.Fallback
+	}
+	formatter := formatters.Get("terminal256")
+	if formatter == nil {
+		formatter = formatters.Fallback
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, source)
+	if err != nil {
+		return source
+	}
+	var buf bytes.Buffer
+	if err := formatter.Format(&buf, style, iterator); err != nil {
+		return source
+	}
+	return buf.String()
+}
@@ -7,6 +7,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/sahilm/fuzzy"
+	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/ui/msgs"
 	"github.com/sadopc/gottp/internal/ui/theme"
 )
@@ -20,36 +21,56 @@ type paletteCommand struct {
 
 var defaultCommands = []paletteCommand{
 	{Name: "Send Request", Shortcut: "Ctrl+Enter", Msg: msgs.SendRequestMsg{}},
+	{Name: "Send and Save Response to File", Shortcut: "", Msg: msgs.SendRequestMsg{Download: true}},
 	{Name: "New Request", Shortcut: "Ctrl+N", Msg: msgs.NewRequestMsg{}},
 	{Name: "Close Tab", Shortcut: "Ctrl+W", Msg: msgs.CloseTabMsg{}},
 	{Name: "Save Request", Shortcut: "Ctrl+S", Msg: msgs.SaveRequestMsg{}},
+	{Name: "Save All", Shortcut: "", Msg: msgs.SaveAllMsg{}},
+	{Name: "Save to Collection…", Shortcut: "", Msg: msgs.SaveToCollectionMsg{}},
 	{Name: "Switch Environment", Shortcut: "Ctrl+E", Msg: msgs.SwitchEnvMsg{}},
+	{Name: "Cycle Environment", Shortcut: "e", Msg: msgs.CycleEnvMsg{}},
+	{Name: "Pin Environment to Tab", Shortcut: "", Msg: msgs.PinEnvMsg{}},
+	{Name: "Unpin Tab Environment", Shortcut: "", Msg: msgs.PinEnvMsg{Unpin: true}},
 	{Name: "Switch Theme", Shortcut: "", Msg: msgs.SwitchThemeMsg{}},
 	{Name: "Toggle Sidebar", Shortcut: "b", Msg: msgs.ToggleSidebarMsg{}},
 	{Name: "Help", Shortcut: "?", Msg: msgs.ShowHelpMsg{}},
 	{Name: "Copy as cURL", Shortcut: "", Msg: msgs.CopyAsCurlMsg{}},
+	{Name: "Export WebSocket Message Log", Shortcut: "", Msg: msgs.ExportWSLogMsg{}},
+	{Name: "Export as Shell Script", Shortcut: "", Msg: msgs.ExportShellScriptMsg{}},
+	{Name: "Export History as Collection", Shortcut: "", Msg: msgs.ExportHistoryMsg{}},
+	{Name: "Record Session to HAR (start/stop)", Shortcut: "", Msg: msgs.ToggleRecordingMsg{}},
 	{Name: "Import from cURL", Shortcut: "", Msg: msgs.ImportCurlMsg{}},
 	{Name: "Import from Postman", Shortcut: "", Msg: msgs.ImportFileMsg{Path: "postman"}},
 	{Name: "Import from Insomnia", Shortcut: "", Msg: msgs.ImportFileMsg{Path: "insomnia"}},
 	{Name: "Import from OpenAPI", Shortcut: "", Msg: msgs.ImportFileMsg{Path: "openapi"}},
+	{Name: "Import from HAR", Shortcut: "", Msg: msgs.ImportFileMsg{Path: "har"}},
+	{Name: "Import from .http File", Shortcut: "", Msg: msgs.ImportFileMsg{Path: "http"}},
+	{Name: "Import from Hurl", Shortcut: "", Msg: msgs.ImportFileMsg{Path: "hurl"}},
+	{Name: "Find and Replace in Collection", Shortcut: "", Msg: msgs.FindReplaceMsg{}},
+	{Name: "Edit Variables", Shortcut: "", Msg: msgs.EditVariablesMsg{}},
+	{Name: "Find Duplicate Requests", Shortcut: "", Msg: msgs.FindDuplicatesMsg{}},
+	{Name: "JSONPath Playground", Shortcut: "p", Msg: msgs.OpenPlaygroundMsg{}},
 	{Name: "Set Response as Baseline", Shortcut: "", Msg: msgs.SetBaselineMsg{}},
 	{Name: "Clear Baseline", Shortcut: "", Msg: msgs.ClearBaselineMsg{}},
+	{Name: "Pin Response for Side-by-Side Compare", Shortcut: "", Msg: msgs.PinResponseMsg{}},
+	{Name: "Unpin Response", Shortcut: "", Msg: msgs.PinResponseMsg{Unpin: true}},
 	{Name: "Edit Body in $EDITOR", Shortcut: "E", Msg: msgs.OpenEditorMsg{}},
-	{Name: "Generate Code: Go", Shortcut: "", Msg: msgs.GenerateCodeMsg{Language: "go"}},
-	{Name: "Generate Code: Python", Shortcut: "", Msg: msgs.GenerateCodeMsg{Language: "python"}},
-	{Name: "Generate Code: JavaScript", Shortcut: "", Msg: msgs.GenerateCodeMsg{Language: "javascript"}},
-	{Name: "Generate Code: cURL", Shortcut: "", Msg: msgs.GenerateCodeMsg{Language: "curl"}},
-	{Name: "Generate Code: Ruby", Shortcut: "", Msg: msgs.GenerateCodeMsg{Language: "ruby"}},
-	{Name: "Generate Code: Java", Shortcut: "", Msg: msgs.GenerateCodeMsg{Language: "java"}},
-	{Name: "Generate Code: Rust", Shortcut: "", Msg: msgs.GenerateCodeMsg{Language: "rust"}},
-	{Name: "Generate Code: PHP", Shortcut: "", Msg: msgs.GenerateCodeMsg{Language: "php"}},
+	{Name: "Open Request in $EDITOR", Shortcut: "", Msg: msgs.EditRequestSourceMsg{}},
+	{Name: "GraphQL: Scaffold from schema.graphql", Shortcut: "", Msg: msgs.ScaffoldGraphQLMsg{}},
+	{Name: "GraphQL: Introspect Schema", Shortcut: "", Msg: msgs.IntrospectMsg{}},
+	{Name: "gRPC: Reflect Services", Shortcut: "", Msg: msgs.GRPCReflectMsg{}},
+	{Name: "OAuth2: Manage Cached Tokens", Shortcut: "", Msg: msgs.ManageOAuth2TokensMsg{}},
+	{Name: "Inspect Response Cache", Shortcut: "", Msg: msgs.ManageResponseCacheMsg{}},
+	{Name: "Clear Response Cache", Shortcut: "", Msg: msgs.ForgetCachedResponseMsg{All: true}},
+	{Name: "Generate Code", Shortcut: "", Msg: msgs.GenerateCodeMsg{}},
 	{Name: "Template: GET JSON API", Shortcut: "", Msg: msgs.InsertTemplateMsg{TemplateName: "GET JSON API"}},
 	{Name: "Template: POST JSON", Shortcut: "", Msg: msgs.InsertTemplateMsg{TemplateName: "POST JSON"}},
 	{Name: "Template: PUT Update", Shortcut: "", Msg: msgs.InsertTemplateMsg{TemplateName: "PUT Update"}},
 	{Name: "Template: GraphQL Query", Shortcut: "", Msg: msgs.InsertTemplateMsg{TemplateName: "GraphQL Query"}},
 	{Name: "Template: OAuth2 Token", Shortcut: "", Msg: msgs.InsertTemplateMsg{TemplateName: "OAuth2 Token Request"}},
 	{Name: "Template: WebSocket Echo", Shortcut: "", Msg: msgs.InsertTemplateMsg{TemplateName: "WebSocket Echo"}},
-	{Name: "Quit", Shortcut: "Ctrl+C", Msg: tea.Quit()},
+	{Name: "Free Memory and Show Usage", Shortcut: "", Msg: msgs.FreeMemoryMsg{}},
+	{Name: "Quit", Shortcut: "Ctrl+C", Msg: msgs.QuitMsg{}},
 }
 
 // CommandPalette is a fuzzy command palette overlay.
@@ -112,6 +133,69 @@ func (m *CommandPalette) OpenEnvPicker(envNames []string) {
 	m.cursor = 0
 }
 
+// OpenPinEnvPicker opens the palette to pick the environment pinned to the
+// active tab.
+func (m *CommandPalette) OpenPinEnvPicker(envNames []string) {
+	m.OpenEnvPicker(envNames)
+	for i, name := range envNames {
+		m.commands[i].Msg = msgs.PinEnvMsg{Name: name}
+	}
+	m.input.Placeholder = "Pin environment to tab..."
+}
+
+// OpenRunEnvPicker opens the palette to pick the environment a folder
+// runs in.
+func (m *CommandPalette) OpenRunEnvPicker(folder string, envNames []string) {
+	m.OpenEnvPicker(envNames)
+	for i, name := range envNames {
+		m.commands[i].Msg = msgs.RunFolderMsg{Folder: folder, Env: name}
+	}
+	m.input.Placeholder = "Run " + folder + " in environment..."
+}
+
+// OpenMovePicker opens the palette to pick the folder item moves into.
+func (m *CommandPalette) OpenMovePicker(item collection.Item, folders []collection.FolderRef) {
+	cmds := []paletteCommand{{
+		Name: "Top level",
+		Msg:  msgs.MoveItemMsg{Item: item, Picked: true},
+	}}
+	for _, ref := range folders {
+		cmds = append(cmds, paletteCommand{
+			Name: ref.Path,
+			Msg:  msgs.MoveItemMsg{Item: item, Into: ref.Folder, Picked: true},
+		})
+	}
+	m.Visible = true
+	m.input.SetValue("")
+	m.input.Placeholder = "Move to folder..."
+	m.input.Focus()
+	m.commands = cmds
+	m.filtered = cmds
+	m.cursor = 0
+}
+
+// OpenSaveToFolderPicker opens the palette to pick the folder a scratch
+// request is saved into.
+func (m *CommandPalette) OpenSaveToFolderPicker(folders []collection.FolderRef) {
+	cmds := []paletteCommand{{
+		Name: "Top level",
+		Msg:  msgs.SaveToCollectionMsg{Picked: true},
+	}}
+	for _, ref := range folders {
+		cmds = append(cmds, paletteCommand{
+			Name: ref.Path,
+			Msg:  msgs.SaveToCollectionMsg{Into: ref.Folder, Picked: true},
+		})
+	}
+	m.Visible = true
+	m.input.SetValue("")
+	m.input.Placeholder = "Save to folder..."
+	m.input.Focus()
+	m.commands = cmds
+	m.filtered = cmds
+	m.cursor = 0
+}
+
 // OpenThemePicker opens the palette in theme selection mode.
 func (m *CommandPalette) OpenThemePicker(themeNames []string) {
 	cmds := make([]paletteCommand, len(themeNames))
@@ -130,6 +214,84 @@ func (m *CommandPalette) OpenThemePicker(themeNames []string) {
 	m.cursor = 0
 }
 
+// OpenGraphQLOperationPicker opens the palette in GraphQL operation selection mode.
+func (m *CommandPalette) OpenGraphQLOperationPicker(labels []string) {
+	cmds := make([]paletteCommand, len(labels))
+	for i, label := range labels {
+		cmds[i] = paletteCommand{
+			Name: label,
+			Msg:  msgs.ScaffoldGraphQLMsg{Operation: label},
+		}
+	}
+	m.Visible = true
+	m.input.SetValue("")
+	m.input.Placeholder = "Select operation..."
+	m.input.Focus()
+	m.commands = cmds
+	m.filtered = cmds
+	m.cursor = 0
+}
+
+// TokenEntry describes a cached OAuth2 token for the token picker.
+type TokenEntry struct {
+	Key    string // cache key passed back in InvalidateOAuth2TokenMsg
+	Label  string // token URL, client and scope
+	Detail string // expiry and refresh state
+}
+
+// OpenOAuth2TokenPicker lists cached OAuth2 tokens; selecting one invalidates it.
+func (m *CommandPalette) OpenOAuth2TokenPicker(entries []TokenEntry) {
+	cmds := make([]paletteCommand, 0, len(entries)+1)
+	cmds = append(cmds, paletteCommand{
+		Name: "Invalidate all cached tokens",
+		Msg:  msgs.InvalidateOAuth2TokenMsg{All: true},
+	})
+	for _, e := range entries {
+		cmds = append(cmds, paletteCommand{
+			Name:     "Invalidate " + e.Label,
+			Shortcut: e.Detail,
+			Msg:      msgs.InvalidateOAuth2TokenMsg{Key: e.Key},
+		})
+	}
+	m.Visible = true
+	m.input.SetValue("")
+	m.input.Placeholder = "Select token to invalidate..."
+	m.input.Focus()
+	m.commands = cmds
+	m.filtered = cmds
+	m.cursor = 0
+}
+
+// CacheEntry describes a cached response for the response cache picker.
+type CacheEntry struct {
+	Key    string // cache key passed back in ForgetCachedResponseMsg
+	Label  string // method and URL
+	Detail string // validators, size and hits
+}
+
+// OpenResponseCachePicker lists cached responses; selecting one forgets it.
+func (m *CommandPalette) OpenResponseCachePicker(entries []CacheEntry) {
+	cmds := make([]paletteCommand, 0, len(entries)+1)
+	cmds = append(cmds, paletteCommand{
+		Name: "Clear response cache",
+		Msg:  msgs.ForgetCachedResponseMsg{All: true},
+	})
+	for _, e := range entries {
+		cmds = append(cmds, paletteCommand{
+			Name:     "Forget " + e.Label,
+			Shortcut: e.Detail,
+			Msg:      msgs.ForgetCachedResponseMsg{Key: e.Key},
+		})
+	}
+	m.Visible = true
+	m.input.SetValue("")
+	m.input.Placeholder = "Select response to forget..."
+	m.input.Focus()
+	m.commands = cmds
+	m.filtered = cmds
+	m.cursor = 0
+}
+
 // ResetCommands restores default commands after env picker.
 func (m *CommandPalette) ResetCommands() {
 	m.commands = defaultCommands
@@ -6,6 +6,10 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/export"
+	"github.com/sadopc/gottp/internal/export/codegen"
+	"github.com/sadopc/gottp/internal/protocol"
 	"github.com/sadopc/gottp/internal/ui/msgs"
 	"github.com/sadopc/gottp/internal/ui/theme"
 )
@@ -215,6 +219,89 @@ func TestKVTable_ToggleEnabled(t *testing.T) {
 	}
 }
 
+func TestKVTable_MoveRows(t *testing.T) {
+	kv := NewKVTable(testStyles())
+	kv.SetPairs([]KVPair{
+		{Key: "a", Value: "1", Enabled: true},
+		{Key: "b", Value: "2", Enabled: true},
+		{Key: "c", Value: "3", Enabled: false},
+	})
+
+	// Move "a" to the bottom; the cursor follows the row
+	kv, _ = kv.Update(keyMsg("J"))
+	kv, _ = kv.Update(tea.KeyMsg{Type: tea.KeyDown, Alt: true})
+	kv, _ = kv.Update(keyMsg("J")) // already last: no-op
+	if got := keysOf(kv.GetPairs()); got != "bca" {
+		t.Fatalf("after moving down: order = %q, want bca", got)
+	}
+	if kv.cursor != 2 {
+		t.Errorf("cursor = %d, want 2", kv.cursor)
+	}
+
+	kv, _ = kv.Update(keyMsg("K"))
+	if got := keysOf(kv.GetPairs()); got != "bac" {
+		t.Fatalf("after moving up: order = %q, want bac", got)
+	}
+	if pairs := kv.GetPairs(); pairs[2].Enabled || pairs[2].Value != "3" {
+		t.Error("moved rows should keep their value and enabled state")
+	}
+}
+
+func TestKVTable_BulkEdit(t *testing.T) {
+	kv := NewKVTable(testStyles())
+	kv.SetPairs([]KVPair{
+		{Key: "Accept", Value: "application/json", Enabled: true},
+		{Key: "X-Debug", Value: "1", Enabled: false},
+	})
+
+	kv, _ = kv.Update(keyMsg("B"))
+	if !kv.Editing() {
+		t.Fatal("bulk mode should report editing")
+	}
+	if got := kv.bulkArea.Value(); got != "Accept: application/json\n# X-Debug: 1" {
+		t.Fatalf("bulk text = %q", got)
+	}
+
+	kv.bulkArea.SetValue(":authority: example.com\nAccept: */*\nCookie: a=b; c=d\n\n// X-Off: yes\nlimit=10")
+	kv, _ = kv.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if kv.Editing() {
+		t.Fatal("esc should leave bulk mode")
+	}
+
+	want := []KVPair{
+		{Key: "Accept", Value: "*/*", Enabled: true},
+		{Key: "Cookie", Value: "a=b; c=d", Enabled: true},
+		{Key: "X-Off", Value: "yes", Enabled: false},
+		{Key: "limit", Value: "10", Enabled: true},
+	}
+	got := kv.GetPairs()
+	if len(got) != len(want) {
+		t.Fatalf("got %d pairs %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("pair %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestKVTable_BulkEditEmpty(t *testing.T) {
+	kv := NewKVTable(testStyles())
+	kv, _ = kv.Update(keyMsg("B"))
+	kv, _ = kv.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if pairs := kv.GetPairs(); len(pairs) != 1 || pairs[0].Key != "" {
+		t.Errorf("empty bulk text should leave one blank row, got %+v", pairs)
+	}
+}
+
+func keysOf(pairs []KVPair) string {
+	var b strings.Builder
+	for _, p := range pairs {
+		b.WriteString(p.Key)
+	}
+	return b.String()
+}
+
 func TestKVTable_EnterStartsEditing(t *testing.T) {
 	kv := NewKVTable(testStyles())
 	kv.SetPairs([]KVPair{
@@ -619,6 +706,38 @@ func TestStatusBar_SetEnv(t *testing.T) {
 	}
 }
 
+func TestStatusBar_LiveProgress(t *testing.T) {
+	sb := NewStatusBar(testTheme(), testStyles())
+	sb.SetWidth(160)
+	sb.SetStatus(200, 150*time.Millisecond, 1024, "application/json")
+
+	start := time.Now()
+	sb.StartLoading(start)
+	sb.Tick(start.Add(500 * time.Millisecond))
+	if view := sb.View(); strings.Contains(view, "⏱") || strings.Contains(view, "200") {
+		t.Fatalf("young request should show neither timer nor old status: %q", view)
+	}
+
+	sb.Tick(start.Add(1500 * time.Millisecond))
+	if view := sb.View(); !strings.Contains(view, "⏱ 1.5s") {
+		t.Fatalf("expected elapsed time: %q", view)
+	}
+
+	sb.SetProgress(1<<20, 4<<20)
+	sb.Tick(start.Add(2500 * time.Millisecond))
+	view := sb.View()
+	for _, want := range []string{"⏱ 2.5s", "1.0 MiB of 4.0 MiB (25%)", "1.0 MiB/s"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("missing %q: %q", want, view)
+		}
+	}
+
+	sb.StopLoading()
+	if view := sb.View(); strings.Contains(view, "⏱") || !strings.Contains(view, "200") {
+		t.Fatalf("expected the status back after loading: %q", view)
+	}
+}
+
 func TestStatusBar_UpdateClearsMessage(t *testing.T) {
 	sb := NewStatusBar(testTheme(), testStyles())
 	sb.SetMessage("temporary")
@@ -677,6 +796,27 @@ func TestStatusBar_View_ContainsMessage(t *testing.T) {
 	}
 }
 
+func TestStatusBar_View_Breadcrumb(t *testing.T) {
+	sb := NewStatusBar(testTheme(), testStyles())
+	sb.SetWidth(60)
+	sb.SetBreadcrumb("$.data.users[12].address.street")
+
+	view := sb.View()
+	if !strings.Contains(view, "…") || !strings.Contains(view, "street") {
+		t.Errorf("long breadcrumbs should be shortened from the left: %q", view)
+	}
+}
+
+func TestStatusBar_View_Proxy(t *testing.T) {
+	sb := NewStatusBar(testTheme(), testStyles())
+	sb.SetWidth(120)
+	sb.SetProxy("socks5://127.0.0.1:1080")
+
+	if view := sb.View(); !strings.Contains(view, "socks5://127.0.0.1:1080") {
+		t.Errorf("view should show the active proxy: %q", view)
+	}
+}
+
 func TestStatusBar_View_ContainsHelpHint(t *testing.T) {
 	sb := NewStatusBar(testTheme(), testStyles())
 	sb.SetWidth(120)
@@ -871,6 +1011,50 @@ func TestModal_Enter_FocusCancel_NoConfirm(t *testing.T) {
 	}
 }
 
+func TestModal_ShowChoices(t *testing.T) {
+	m := NewModal(testTheme(), testStyles())
+	m.ShowChoices("Unsaved changes", "Quit anyway?",
+		ModalChoice{Label: "Save all", Msg: msgs.SaveRequestMsg{}},
+		&ModalChoice{Label: "Discard", Msg: msgs.CloseTabMsg{}})
+
+	view := m.View()
+	for _, label := range []string{"Save all", "Discard", "Cancel"} {
+		if !strings.Contains(view, label) {
+			t.Errorf("view should show %q:\n%s", label, view)
+		}
+	}
+
+	// Tab moves OK → alternative → Cancel → OK; shift+tab goes back
+	m, _ = m.Update(specialKeyMsg(tea.KeyTab))
+	if m.focusOK || !m.focusAlt {
+		t.Fatal("first tab should focus the alternative")
+	}
+	m, _ = m.Update(specialKeyMsg(tea.KeyShiftTab))
+	if !m.focusOK {
+		t.Fatal("shift+tab should return to OK")
+	}
+	m, _ = m.Update(specialKeyMsg(tea.KeyShiftTab))
+	if m.focusOK || m.focusAlt {
+		t.Fatal("shift+tab from OK should focus Cancel")
+	}
+	m, _ = m.Update(specialKeyMsg(tea.KeyShiftTab))
+	if !m.focusAlt {
+		t.Fatal("shift+tab from Cancel should focus the alternative")
+	}
+
+	m, cmd := m.Update(specialKeyMsg(tea.KeyEnter))
+	if m.Visible || cmd == nil {
+		t.Fatal("enter should close the modal and emit the choice")
+	}
+	var got []tea.Msg
+	for _, c := range cmd().(tea.BatchMsg) {
+		got = append(got, c())
+	}
+	if _, ok := got[1].(msgs.CloseTabMsg); !ok {
+		t.Errorf("expected the alternative's message, got %#v", got)
+	}
+}
+
 func TestModal_IgnoresInputWhenHidden(t *testing.T) {
 	m := NewModal(testTheme(), testStyles())
 	// Not visible, should do nothing
@@ -1544,3 +1728,358 @@ func TestTruncate(t *testing.T) {
 		})
 	}
 }
+
+// ─────────────────────────────────────────────────────────────────────────────
+// FindReplace tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestFindReplace_SelectAndApply(t *testing.T) {
+	col := &collection.Collection{Items: []collection.Item{
+		{Request: collection.NewRequest("Users v1", "GET", "https://x/v1/users")},
+		{Request: collection.NewRequest("Orders", "GET", "https://x/v2/orders")},
+	}}
+	fr := NewFindReplace(testTheme(), testStyles())
+	fr.Open(col)
+
+	for _, r := range `v\d` {
+		fr, _ = fr.Update(keyMsg(string(r)))
+	}
+	if len(fr.Matches()) != 0 {
+		t.Fatalf("literal search should not match, got %d", len(fr.Matches()))
+	}
+	fr, _ = fr.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if len(fr.Matches()) != 3 {
+		t.Fatalf("expected 3 regex matches, got %d", len(fr.Matches()))
+	}
+
+	fr, _ = fr.Update(specialKeyMsg(tea.KeyTab))
+	for _, r := range "version" {
+		fr, _ = fr.Update(keyMsg(string(r)))
+	}
+	if fr.Matches()[0].Replacement != "version" {
+		t.Errorf("replacement should update previews, got %q", fr.Matches()[0].Replacement)
+	}
+	if !strings.Contains(fr.View(), "3 matches in 2 requests") {
+		t.Errorf("summary missing:\n%s", fr.View())
+	}
+
+	// Deselect the first match and apply the rest
+	fr, _ = fr.Update(specialKeyMsg(tea.KeyEnter))
+	fr, _ = fr.Update(keyMsg(" "))
+	fr, cmd := fr.Update(specialKeyMsg(tea.KeyEnter))
+	if fr.Visible || cmd == nil {
+		t.Fatal("enter in the match list should apply and close")
+	}
+	var applied msgs.ApplyReplaceMsg
+	for _, c := range cmd().(tea.BatchMsg) {
+		if msg, ok := c().(msgs.ApplyReplaceMsg); ok {
+			applied = msg
+		}
+	}
+	if len(applied.Matches) != 2 || applied.Matches[0].Field != "url" {
+		t.Fatalf("unexpected applied matches: %+v", applied.Matches)
+	}
+}
+
+func TestFindReplace_InvalidRegexKeepsMatches(t *testing.T) {
+	col := &collection.Collection{Items: []collection.Item{
+		{Request: collection.NewRequest("a", "GET", "https://x/(a)")},
+	}}
+	fr := NewFindReplace(testTheme(), testStyles())
+	fr.Open(col)
+	fr, _ = fr.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	fr, _ = fr.Update(keyMsg("a"))
+	fr, _ = fr.Update(keyMsg("("))
+	if len(fr.Matches()) != 2 {
+		t.Errorf("expected previous matches kept, got %d", len(fr.Matches()))
+	}
+	if !strings.Contains(fr.View(), "invalid pattern") {
+		t.Error("expected the regex error in the view")
+	}
+}
+
+func TestDuplicates_ChooseAndMerge(t *testing.T) {
+	keep := collection.NewRequest("Users copy", "GET", "https://x/users")
+	keep.PreScript = "gottp.setVar('a', 1)"
+	col := &collection.Collection{Items: []collection.Item{
+		{Request: collection.NewRequest("Users", "GET", "https://x/users")},
+		{Request: keep},
+		{Request: collection.NewRequest("Orders", "GET", "https://x/orders")},
+	}}
+	d := NewDuplicates(testTheme(), testStyles())
+	d.Open(col)
+	if len(d.Groups()) != 1 {
+		t.Fatalf("expected 1 group, got %d", len(d.Groups()))
+	}
+	view := d.View()
+	for _, want := range []string{"1 groups · 1 redundant requests", "(•) Users", "( ) Users copy · pre-script"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	d, _ = d.Update(keyMsg("j"))
+	d, _ = d.Update(keyMsg(" "))
+	d, cmd := d.Update(specialKeyMsg(tea.KeyEnter))
+	if cmd == nil {
+		t.Fatal("enter should merge the group")
+	}
+	msg, ok := cmd().(msgs.MergeDuplicatesMsg)
+	if !ok || msg.Keep != keep || len(msg.Remove) != 1 || msg.Remove[0].Name != "Users" {
+		t.Errorf("unexpected merge message %+v", msg)
+	}
+
+	d, _ = d.Update(specialKeyMsg(tea.KeyEsc))
+	if d.Visible {
+		t.Error("esc should close the overlay")
+	}
+}
+
+func TestVariables_Mask(t *testing.T) {
+	colVars := map[string]string{"token": "s3cr3t-token", "host": "x"}
+	v := NewVariables(testTheme(), testStyles())
+	v.Mask = func(s string) string { return strings.ReplaceAll(s, "s3cr3t-token", "********") }
+	v.Open([]VarScope{{Label: "Collection", Vars: &colVars}}, "Dev")
+
+	view := v.View()
+	if strings.Contains(view, "s3cr3t-token") || !strings.Contains(view, "token = ********") {
+		t.Errorf("secret shown in the overlay:\n%s", view)
+	}
+}
+
+func TestVariables_EditScopes(t *testing.T) {
+	reqVars := map[string]string{"id": "7"}
+	var folderVars map[string]string
+	colVars := map[string]string{"id": "1", "host": "x"}
+	v := NewVariables(testTheme(), testStyles())
+	v.Open([]VarScope{
+		{Label: "Request Get user", Vars: &reqVars},
+		{Label: "Folder Users", Vars: &folderVars},
+		{Label: "Collection", Vars: &colVars},
+	}, "Dev")
+
+	view := v.View()
+	for _, want := range []string{"environment (Dev)", "Folder Users", "(none)", "id = 1 (overridden)", "host = x"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	// Add a folder variable on the empty folder's row
+	v, _ = v.Update(keyMsg("j"))
+	v, _ = v.Update(keyMsg("a"))
+	for _, r := range "token=abc" {
+		v, _ = v.Update(keyMsg(string(r)))
+	}
+	v, cmd := v.Update(specialKeyMsg(tea.KeyEnter))
+	if cmd == nil || cmd() != (msgs.VariablesChangedMsg{}) {
+		t.Fatal("adding a variable should report a change")
+	}
+	if folderVars["token"] != "abc" {
+		t.Errorf("folder vars = %v", folderVars)
+	}
+
+	// Rename the request variable
+	v, _ = v.Update(keyMsg("k"))
+	v, _ = v.Update(keyMsg("e"))
+	v.input.SetValue("user_id=8")
+	v, _ = v.Update(specialKeyMsg(tea.KeyEnter))
+	if len(reqVars) != 1 || reqVars["user_id"] != "8" {
+		t.Errorf("request vars = %v", reqVars)
+	}
+
+	v, _ = v.Update(keyMsg("a"))
+	v.input.SetValue("bad name=1")
+	v, cmd = v.Update(specialKeyMsg(tea.KeyEnter))
+	if cmd != nil || !v.editing || !strings.Contains(v.View(), "only letters") {
+		t.Error("invalid names should be rejected")
+	}
+	v, _ = v.Update(specialKeyMsg(tea.KeyEsc))
+
+	v, cmd = v.Update(keyMsg("d"))
+	if cmd == nil || len(reqVars) != 0 {
+		t.Errorf("delete: request vars = %v", reqVars)
+	}
+
+	v, _ = v.Update(specialKeyMsg(tea.KeyEsc))
+	if v.Visible {
+		t.Error("esc should close the overlay")
+	}
+}
+
+func TestAnnotate_SelectNoteAndShare(t *testing.T) {
+	a := NewAnnotate(testTheme(), testStyles())
+	a.Open(export.Snippet{Method: "GET", URL: "https://x/users", Status: "500 Internal Server Error", Lang: "json"},
+		"{\n  \"error\": \"boom\",\n  \"code\": 17\n}")
+
+	a, _ = a.Update(keyMsg("j"))
+	a, _ = a.Update(keyMsg("v"))
+	a, _ = a.Update(keyMsg("j"))
+	if first, last := a.Selection(); first != 1 || last != 2 {
+		t.Fatalf("selection = %d-%d, want 1-2", first, last)
+	}
+	if !strings.Contains(a.View(), "lines 2-3") {
+		t.Errorf("view should show the selected range:\n%s", a.View())
+	}
+
+	a, _ = a.Update(keyMsg("a"))
+	for _, r := range "only in prod" {
+		a, _ = a.Update(keyMsg(string(r)))
+	}
+	a, _ = a.Update(specialKeyMsg(tea.KeyEnter))
+	if !a.Visible {
+		t.Fatal("enter in the note should only finish the note")
+	}
+
+	a, cmd := a.Update(specialKeyMsg(tea.KeyEnter))
+	if a.Visible || cmd == nil {
+		t.Fatal("enter should share the snippet and close")
+	}
+	var shared *msgs.ShareSnippetMsg
+	for _, c := range cmd().(tea.BatchMsg) {
+		if msg, ok := c().(msgs.ShareSnippetMsg); ok {
+			shared = &msg
+		}
+	}
+	if shared == nil {
+		t.Fatal("expected a ShareSnippetMsg")
+	}
+	s := shared.Snippet
+	if s.StartLine != 2 || len(s.Lines) != 2 || s.Lines[1] != `  "code": 17` || s.Total != 4 || s.Note != "only in prod" || s.Lang != "json" {
+		t.Errorf("unexpected snippet %+v", s)
+	}
+}
+
+func TestPlayground_LiveResultsAndInsert(t *testing.T) {
+	p := NewPlayground(testTheme(), testStyles())
+	p.Open([]byte(`{"items":[{"id":7},{"id":8}]}`), "$.items[*]")
+	if !strings.Contains(p.View(), "2 results") {
+		t.Fatalf("opening should evaluate the expression:\n%s", p.View())
+	}
+
+	for _, r := range ".id" {
+		p, _ = p.Update(keyMsg(string(r)))
+	}
+	if !strings.Contains(p.View(), "2 results") || !strings.Contains(p.View(), "8") {
+		t.Errorf("typing should update the results:\n%s", p.View())
+	}
+
+	p, _ = p.Update(keyMsg("["))
+	if !strings.Contains(p.View(), "expected ]") {
+		t.Errorf("a broken expression should show its error:\n%s", p.View())
+	}
+	if _, cmd := p.Update(specialKeyMsg(tea.KeyEnter)); cmd != nil {
+		t.Error("enter should not insert a broken expression")
+	}
+
+	p.Open([]byte(`{"items":[{"id":7},{"id":8}]}`), "$.items[0].id")
+	p, _ = p.Update(specialKeyMsg(tea.KeyTab))
+	p, cmd := p.Update(specialKeyMsg(tea.KeyEnter))
+	if p.Visible || cmd == nil {
+		t.Fatal("enter should insert and close")
+	}
+	var ins *msgs.InsertQueryMsg
+	for _, c := range cmd().(tea.BatchMsg) {
+		if msg, ok := c().(msgs.InsertQueryMsg); ok {
+			ins = &msg
+		}
+	}
+	if ins == nil || ins.Target != msgs.QueryCapture || ins.Name != "id" || ins.Expr != "$.items[0].id" || ins.Count != 1 {
+		t.Errorf("unexpected insert %+v", ins)
+	}
+
+	p.Open([]byte("<html>"), "")
+	if !strings.Contains(p.View(), "not JSON") {
+		t.Errorf("non-JSON bodies should be reported:\n%s", p.View())
+	}
+}
+
+func TestCodeGen_PickScrollAndCopy(t *testing.T) {
+	req := &protocol.Request{
+		Method:  "POST",
+		URL:     "https://api.example.com/items",
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    []byte(`{"name":"widget"}`),
+	}
+	c := NewCodeGen(testTheme(), testStyles())
+	c.Open(req, "python")
+	if c.Language() != codegen.LangPython || !strings.Contains(c.Code(), "requests.post") {
+		t.Fatalf("opening should show the requested language, got %s:\n%s", c.Language(), c.Code())
+	}
+	if !strings.Contains(c.View(), "[Python]") {
+		t.Errorf("the picker should mark the language shown:\n%s", c.View())
+	}
+
+	c, _ = c.Update(specialKeyMsg(tea.KeyRight))
+	if c.Language() != codegen.LangJavaScript {
+		t.Errorf("right should move to the next language, got %s", c.Language())
+	}
+	c, _ = c.Update(specialKeyMsg(tea.KeyLeft))
+	c, _ = c.Update(specialKeyMsg(tea.KeyLeft))
+	if c.Language() != codegen.LangGo || !strings.Contains(c.Code(), "http.NewRequest") {
+		t.Errorf("left should move back, got %s", c.Language())
+	}
+	c, _ = c.Update(specialKeyMsg(tea.KeyLeft))
+	if c.Language() != codegen.LangHTTPie {
+		t.Errorf("left from the first language should wrap, got %s", c.Language())
+	}
+
+	c.Open(req, "go")
+	c, _ = c.Update(keyMsg("G"))
+	if c.offset == 0 {
+		t.Error("G should scroll a long snippet to its end")
+	}
+	c, _ = c.Update(keyMsg("j"))
+	if want := len(c.code[codegen.LangGo]) - codegenLines; c.offset != want {
+		t.Errorf("offset = %d, want it clamped to %d", c.offset, want)
+	}
+
+	_, cmd := c.Update(specialKeyMsg(tea.KeyEnter))
+	if cmd == nil {
+		t.Fatal("enter should copy the snippet")
+	}
+	copied, ok := cmd().(msgs.CopyTextMsg)
+	if !ok || copied.Text != c.Code() || copied.Label != "Go code" {
+		t.Errorf("unexpected copy %+v", copied)
+	}
+
+	c, cmd = c.Update(specialKeyMsg(tea.KeyEscape))
+	if c.Visible || cmd == nil {
+		t.Error("esc should close the overlay")
+	}
+}
+
+func TestPrompt_SubmitAndCancel(t *testing.T) {
+	p := NewPrompt(testTheme(), testStyles())
+	p.Open("Rename", "Old", func(name string) tea.Msg { return msgs.RenameItemMsg{Name: name} })
+	if !strings.Contains(p.View(), "Rename") {
+		t.Errorf("view missing title:\n%s", p.View())
+	}
+
+	p.input.SetValue("   ")
+	p, cmd := p.Update(specialKeyMsg(tea.KeyEnter))
+	if cmd != nil || !p.Visible || !strings.Contains(p.View(), "Enter a name") {
+		t.Fatal("blank names should be rejected")
+	}
+
+	p.input.SetValue(" New ")
+	p, cmd = p.Update(specialKeyMsg(tea.KeyEnter))
+	if p.Visible || cmd == nil {
+		t.Fatal("enter should close the prompt and submit")
+	}
+	var got msgs.RenameItemMsg
+	for _, c := range cmd().(tea.BatchMsg) {
+		if rename, ok := c().(msgs.RenameItemMsg); ok {
+			got = rename
+		}
+	}
+	if got.Name != "New" {
+		t.Errorf("submitted %+v", got)
+	}
+
+	p.Open("Rename", "Old", func(string) tea.Msg { t.Error("esc should not submit"); return nil })
+	p, _ = p.Update(specialKeyMsg(tea.KeyEsc))
+	if p.Visible {
+		t.Error("esc should close the prompt")
+	}
+}
@@ -0,0 +1,206 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// Duplicates lists groups of requests that send the same method, URL and
+// body. One request per group is chosen to keep; merging removes the rest.
+type Duplicates struct {
+	Visible bool
+	groups  []collection.Duplicate
+	keep    []int // index of the request kept in each group
+	cursor  int   // row over every request of every group
+	theme   theme.Theme
+	styles  theme.Styles
+}
+
+// NewDuplicates creates a new duplicate requests overlay.
+func NewDuplicates(t theme.Theme, s theme.Styles) Duplicates {
+	return Duplicates{theme: t, styles: s}
+}
+
+// Open shows the overlay with the duplicates in col. The cursor stays put
+// so merging one group moves on to the next.
+func (m *Duplicates) Open(col *collection.Collection) {
+	m.Visible = true
+	m.groups = collection.FindDuplicates(col)
+	m.keep = make([]int, len(m.groups))
+	if m.cursor >= m.rows() {
+		m.cursor = max(m.rows()-1, 0)
+	}
+}
+
+// Close hides the overlay.
+func (m *Duplicates) Close() {
+	m.Visible = false
+	m.groups = nil
+	m.keep = nil
+}
+
+// Groups returns the duplicate groups shown.
+func (m Duplicates) Groups() []collection.Duplicate {
+	return m.groups
+}
+
+func (m Duplicates) rows() int {
+	n := 0
+	for _, g := range m.groups {
+		n += len(g.Items)
+	}
+	return n
+}
+
+// locate returns the group and item index of a row.
+func (m Duplicates) locate(row int) (int, int) {
+	for g, group := range m.groups {
+		if row < len(group.Items) {
+			return g, row
+		}
+		row -= len(group.Items)
+	}
+	return -1, -1
+}
+
+// Update handles key input while the overlay is visible.
+func (m Duplicates) Update(msg tea.Msg) (Duplicates, tea.Cmd) {
+	if !m.Visible {
+		return m, nil
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.String() {
+	case "esc", "q":
+		m.Close()
+		return m, func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} }
+	case "j", "down":
+		if m.cursor < m.rows()-1 {
+			m.cursor++
+		}
+	case "k", "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case " ", "x":
+		if g, i := m.locate(m.cursor); g >= 0 {
+			m.keep[g] = i
+		}
+	case "enter":
+		g, _ := m.locate(m.cursor)
+		if g < 0 {
+			return m, nil
+		}
+		group := m.groups[g]
+		keep := group.Items[m.keep[g]].Request
+		var remove []*collection.Request
+		for _, item := range group.Items {
+			if item.Request != keep {
+				remove = append(remove, item.Request)
+			}
+		}
+		return m, func() tea.Msg { return msgs.MergeDuplicatesMsg{Keep: keep, Remove: remove} }
+	}
+	return m, nil
+}
+
+// View renders the overlay.
+func (m Duplicates) View() string {
+	if !m.Visible {
+		return ""
+	}
+
+	boxWidth := 76
+	inner := boxWidth - 6
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Text).
+		Bold(true).
+		Width(inner).
+		Align(lipgloss.Center)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.theme.Muted)
+
+	redundant := m.rows() - len(m.groups)
+	summary := fmt.Sprintf("%d groups · %d redundant requests", len(m.groups), redundant)
+	if len(m.groups) == 0 {
+		summary = "No duplicate requests"
+	}
+
+	var body []string
+	cursorLine := 0
+	row := 0
+	for g, group := range m.groups {
+		method := lipgloss.NewStyle().Foreground(m.theme.MethodColor(group.Method)).Bold(true).Render(group.Method)
+		body = append(body, lipgloss.NewStyle().MaxWidth(inner).Render(method+" "+group.URL))
+		for i, item := range group.Items {
+			mark := "( )"
+			if m.keep[g] == i {
+				mark = "(•)"
+			}
+			label := fmt.Sprintf("  %s %s", mark, strings.TrimPrefix(item.Path, "/"))
+			if notes := scriptNotes(item.Request); notes != "" {
+				label += mutedStyle.Render(" · " + notes)
+			}
+			style := lipgloss.NewStyle().MaxWidth(inner)
+			if row == m.cursor {
+				cursorLine = len(body)
+				style = style.Background(m.theme.Overlay).Foreground(m.theme.Text).Width(inner)
+			}
+			body = append(body, style.Render(label))
+			row++
+		}
+	}
+
+	maxLines := 14
+	start := 0
+	if cursorLine >= maxLines {
+		start = cursorLine - maxLines + 1
+	}
+	end := min(start+maxLines, len(body))
+
+	lines := []string{
+		titleStyle.Render("Duplicate Requests"),
+		"",
+		mutedStyle.Render(summary),
+		"",
+	}
+	lines = append(lines, body[start:end]...)
+	if len(body) > end {
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("  … %d more lines", len(body)-end)))
+	}
+	lines = append(lines, "", mutedStyle.Render("j/k: move · space: keep this one · enter: merge group · esc: close"))
+
+	return lipgloss.NewStyle().
+		Width(boxWidth).
+		Background(m.theme.Surface).
+		Foreground(m.theme.Text).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.BorderFocused).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+}
+
+// scriptNotes summarizes what a request carries beyond what it sends, to
+// help pick the one to keep.
+func scriptNotes(req *collection.Request) string {
+	var notes []string
+	if req.PreScript != "" {
+		notes = append(notes, "pre-script")
+	}
+	if req.PostScript != "" {
+		notes = append(notes, "post-script")
+	}
+	if n := len(req.Assertions); n > 0 {
+		notes = append(notes, fmt.Sprintf("%d assertions", n))
+	}
+	return strings.Join(notes, ", ")
+}
@@ -0,0 +1,308 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// Find and replace focus targets.
+const (
+	frFocusFind = iota
+	frFocusReplace
+	frFocusMatches
+)
+
+// FindReplace is a collection-wide find and replace overlay. Every match is
+// listed with a preview and can be deselected before applying.
+type FindReplace struct {
+	Visible  bool
+	find     textinput.Model
+	replace  textinput.Model
+	regex    bool
+	focus    int
+	col      *collection.Collection
+	matches  []collection.Match
+	selected []bool
+	cursor   int
+	err      string
+	theme    theme.Theme
+	styles   theme.Styles
+}
+
+// NewFindReplace creates a new find and replace overlay.
+func NewFindReplace(t theme.Theme, s theme.Styles) FindReplace {
+	find := textinput.New()
+	find.Prompt = "Find:    "
+	find.Placeholder = "text or pattern"
+	find.Width = 60
+	replace := textinput.New()
+	replace.Prompt = "Replace: "
+	replace.Placeholder = "replacement ($1 for groups in regex mode)"
+	replace.Width = 60
+	return FindReplace{
+		find:    find,
+		replace: replace,
+		theme:   t,
+		styles:  s,
+	}
+}
+
+// Open shows the overlay searching col. Previous inputs are kept so a
+// search can be refined after applying.
+func (m *FindReplace) Open(col *collection.Collection) {
+	m.Visible = true
+	m.col = col
+	m.setFocus(frFocusFind)
+	m.search()
+}
+
+// Close hides the overlay.
+func (m *FindReplace) Close() {
+	m.Visible = false
+	m.find.Blur()
+	m.replace.Blur()
+	m.col = nil
+	m.matches = nil
+	m.selected = nil
+}
+
+// Matches returns the current matches.
+func (m FindReplace) Matches() []collection.Match {
+	return m.matches
+}
+
+// Selected returns the matches that will be applied.
+func (m FindReplace) Selected() []collection.Match {
+	var out []collection.Match
+	for i, match := range m.matches {
+		if m.selected[i] {
+			out = append(out, match)
+		}
+	}
+	return out
+}
+
+// Update handles key input while the overlay is visible.
+func (m FindReplace) Update(msg tea.Msg) (FindReplace, tea.Cmd) {
+	if !m.Visible {
+		return m, nil
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.String() {
+	case "esc":
+		m.Close()
+		return m, func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} }
+	case "tab":
+		m.setFocus((m.focus + 1) % 3)
+		return m, nil
+	case "shift+tab":
+		m.setFocus((m.focus + 2) % 3)
+		return m, nil
+	case "ctrl+r":
+		m.regex = !m.regex
+		m.search()
+		return m, nil
+	case "ctrl+a":
+		m.selectAll(!m.allSelected())
+		return m, nil
+	}
+
+	if m.focus == frFocusMatches {
+		return m.updateMatches(key)
+	}
+
+	if key.String() == "enter" {
+		m.setFocus(frFocusMatches)
+		return m, nil
+	}
+	var cmd tea.Cmd
+	if m.focus == frFocusFind {
+		m.find, cmd = m.find.Update(msg)
+	} else {
+		m.replace, cmd = m.replace.Update(msg)
+	}
+	m.search()
+	return m, cmd
+}
+
+func (m FindReplace) updateMatches(key tea.KeyMsg) (FindReplace, tea.Cmd) {
+	switch key.String() {
+	case "j", "down":
+		if m.cursor < len(m.matches)-1 {
+			m.cursor++
+		}
+	case "k", "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case " ", "x":
+		if m.cursor < len(m.selected) {
+			m.selected[m.cursor] = !m.selected[m.cursor]
+		}
+	case "a":
+		m.selectAll(!m.allSelected())
+	case "enter":
+		selected := m.Selected()
+		if len(selected) == 0 {
+			return m, nil
+		}
+		m.Close()
+		return m, tea.Batch(
+			func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} },
+			func() tea.Msg { return msgs.ApplyReplaceMsg{Matches: selected} },
+		)
+	}
+	return m, nil
+}
+
+func (m *FindReplace) setFocus(f int) {
+	m.focus = f
+	m.find.Blur()
+	m.replace.Blur()
+	switch f {
+	case frFocusFind:
+		m.find.Focus()
+	case frFocusReplace:
+		m.replace.Focus()
+	}
+}
+
+func (m *FindReplace) selectAll(on bool) {
+	for i := range m.selected {
+		m.selected[i] = on
+	}
+}
+
+func (m FindReplace) allSelected() bool {
+	for _, s := range m.selected {
+		if !s {
+			return false
+		}
+	}
+	return true
+}
+
+// search re-runs the search, keeping every match selected.
+func (m *FindReplace) search() {
+	m.err = ""
+	matches, err := collection.FindMatches(m.col, m.find.Value(), m.replace.Value(), m.regex)
+	if err != nil {
+		// Keep the last matches while a regex is being typed
+		m.err = err.Error()
+		return
+	}
+	m.matches = matches
+	m.selected = make([]bool, len(matches))
+	m.selectAll(true)
+	if m.cursor >= len(matches) {
+		m.cursor = max(len(matches)-1, 0)
+	}
+}
+
+// View renders the overlay.
+func (m FindReplace) View() string {
+	if !m.Visible {
+		return ""
+	}
+
+	boxWidth := 76
+	inner := boxWidth - 6
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Text).
+		Bold(true).
+		Width(inner).
+		Align(lipgloss.Center)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.theme.Muted)
+	oldStyle := lipgloss.NewStyle().Foreground(m.theme.Red).Strikethrough(true)
+	newStyle := lipgloss.NewStyle().Foreground(m.theme.Green)
+
+	regex := "off"
+	if m.regex {
+		regex = "on"
+	}
+	requests := make(map[*collection.Request]bool)
+	count := 0
+	for i, match := range m.matches {
+		requests[match.Request] = true
+		if m.selected[i] {
+			count++
+		}
+	}
+	summary := fmt.Sprintf("Regex: %s · %d matches in %d requests · %d selected",
+		regex, len(m.matches), len(requests), count)
+	if m.err != "" {
+		summary = lipgloss.NewStyle().Foreground(m.theme.Red).Render(m.err)
+	}
+
+	lines := []string{
+		titleStyle.Render("Find and Replace"),
+		"",
+		m.find.View(),
+		m.replace.View(),
+		"",
+		mutedStyle.MaxWidth(inner).Render(summary),
+		"",
+	}
+
+	// Each match takes two lines: location and preview
+	maxItems := 8
+	start := 0
+	if m.cursor >= maxItems {
+		start = m.cursor - maxItems + 1
+	}
+	end := min(start+maxItems, len(m.matches))
+	for i := start; i < end; i++ {
+		match := m.matches[i]
+		check := "[ ]"
+		if m.selected[i] {
+			check = "[x]"
+		}
+		location := fmt.Sprintf("%s %s%s · %s", check, pathPrefix(match.Path), match.Request.Name, match.Field)
+		line := lipgloss.NewStyle().MaxWidth(inner).Render(location)
+		if i == m.cursor && m.focus == frFocusMatches {
+			line = lipgloss.NewStyle().
+				Background(m.theme.Overlay).
+				Foreground(m.theme.Text).
+				Width(inner).
+				MaxWidth(inner).
+				Render(location)
+		}
+		preview := "    " + mutedStyle.Render(match.Before) +
+			oldStyle.Render(match.Text) + newStyle.Render(match.Replacement) +
+			mutedStyle.Render(match.After)
+		lines = append(lines, line, lipgloss.NewStyle().MaxWidth(inner).Render(preview))
+	}
+	if len(m.matches) > end {
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("  … %d more", len(m.matches)-end)))
+	}
+
+	lines = append(lines, "", mutedStyle.Render("tab: next field · ctrl+r: regex · space: toggle · a: all · enter: apply · esc: close"))
+
+	return lipgloss.NewStyle().
+		Width(boxWidth).
+		Background(m.theme.Surface).
+		Foreground(m.theme.Text).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.BorderFocused).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+}
+
+func pathPrefix(path string) string {
+	if path == "" {
+		return ""
+	}
+	return strings.TrimPrefix(path, "/") + "/"
+}
@@ -45,7 +45,7 @@ var helpSections = []helpSection{
 		Bindings: []helpBinding{
 			{"b", "Toggle sidebar"},
 			{"j / k", "Move cursor down / up"},
-			{"Enter", "Open selected request"},
+			{"Enter / r / R", "Open request / rename item / run folder"},
 			{"/", "Search collections"},
 		},
 	},
@@ -63,8 +63,10 @@ var helpSections = []helpSection{
 			{"j / k", "Scroll down / up"},
 			{"1-4", "Switch response tabs (Body, Headers, Cookies, Timing)"},
 			{"/ / Ctrl+F", "Search in response body"},
+			{"x", "Transform response body with JavaScript or jq"},
 			{"n / N", "Next / previous search match"},
 			{"w", "Toggle word wrap"},
+			{"!", "Expand / collapse pre-flight warnings"},
 		},
 	},
 }
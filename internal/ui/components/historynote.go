@@ -0,0 +1,140 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sadopc/gottp/internal/core/history"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// HistoryNote edits the tags and note of a history entry.
+type HistoryNote struct {
+	Visible bool
+	id      int64
+	summary string
+	tags    textinput.Model
+	note    textinput.Model
+	theme   theme.Theme
+	styles  theme.Styles
+}
+
+// NewHistoryNote creates a new history note overlay.
+func NewHistoryNote(t theme.Theme, s theme.Styles) HistoryNote {
+	tags := textinput.New()
+	tags.Prompt = "Tags: "
+	tags.Placeholder = "bug-1234, prod-incident"
+	tags.CharLimit = 200
+	tags.Width = 56
+	note := textinput.New()
+	note.Prompt = "Note: "
+	note.Placeholder = "Why this request matters"
+	note.CharLimit = 500
+	note.Width = 56
+	return HistoryNote{tags: tags, note: note, theme: t, styles: s}
+}
+
+// Open shows the overlay for entry.
+func (m *HistoryNote) Open(entry history.Entry) {
+	m.Visible = true
+	m.id = entry.ID
+	m.summary = entry.Method + " " + entry.URL
+	m.tags.SetValue(strings.Join(entry.Tags, ", "))
+	m.tags.CursorEnd()
+	m.note.SetValue(entry.Note)
+	m.note.CursorEnd()
+	m.note.Blur()
+	m.tags.Focus()
+}
+
+// Close hides the overlay.
+func (m *HistoryNote) Close() {
+	m.Visible = false
+	m.tags.Blur()
+	m.note.Blur()
+}
+
+// Update handles key input while the overlay is visible.
+func (m HistoryNote) Update(msg tea.Msg) (HistoryNote, tea.Cmd) {
+	if !m.Visible {
+		return m, nil
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.String() {
+	case "esc":
+		m.Close()
+		return m, func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} }
+	case "tab", "shift+tab", "up", "down":
+		if m.tags.Focused() {
+			m.tags.Blur()
+			m.note.Focus()
+		} else {
+			m.note.Blur()
+			m.tags.Focus()
+		}
+		return m, nil
+	case "enter":
+		saved := msgs.HistoryNoteSavedMsg{
+			ID:   m.id,
+			Tags: history.ParseTags(m.tags.Value()),
+			Note: strings.TrimSpace(m.note.Value()),
+		}
+		m.Close()
+		return m, tea.Batch(
+			func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} },
+			func() tea.Msg { return saved },
+		)
+	}
+
+	var cmd tea.Cmd
+	if m.tags.Focused() {
+		m.tags, cmd = m.tags.Update(msg)
+	} else {
+		m.note, cmd = m.note.Update(msg)
+	}
+	return m, cmd
+}
+
+// View renders the overlay.
+func (m HistoryNote) View() string {
+	if !m.Visible {
+		return ""
+	}
+
+	boxWidth := 70
+	inner := boxWidth - 6
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Text).
+		Bold(true).
+		Width(inner).
+		Align(lipgloss.Center)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.theme.Muted)
+
+	lines := []string{
+		titleStyle.Render("Tag History Entry"),
+		"",
+		mutedStyle.MaxWidth(inner).Render(m.summary),
+		"",
+		m.tags.View(),
+		m.note.View(),
+		"",
+		mutedStyle.Render("tab: switch field · enter: save · esc: cancel"),
+	}
+
+	return lipgloss.NewStyle().
+		Width(boxWidth).
+		Background(m.theme.Surface).
+		Foreground(m.theme.Text).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.BorderFocused).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+}
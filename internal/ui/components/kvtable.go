@@ -3,6 +3,7 @@ package components
 import (
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -33,6 +34,11 @@ type KVTable struct {
 	input   textinput.Model
 	width   int
 	styles  theme.Styles
+
+	bulk     bool // editing all rows as "Key: value" text
+	bulkArea textarea.Model
+
+	unresolved []string // {{variables}} highlighted as undefined
 }
 
 // NewKVTable creates a new KVTable.
@@ -40,12 +46,34 @@ func NewKVTable(styles theme.Styles) KVTable {
 	ti := textinput.New()
 	ti.CharLimit = 256
 
+	ta := textarea.New()
+	ta.Placeholder = "Key: value"
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+
 	return KVTable{
-		pairs:  []KVPair{{Key: "", Value: "", Enabled: true}},
-		styles: styles,
-		input:  ti,
-		width:  60,
+		pairs:    []KVPair{{Key: "", Value: "", Enabled: true}},
+		styles:   styles,
+		input:    ti,
+		width:    60,
+		bulkArea: ta,
+	}
+}
+
+// SetUnresolved highlights values that use any of the named variables,
+// which are not defined in any scope.
+func (m *KVTable) SetUnresolved(names []string) {
+	m.unresolved = names
+}
+
+// usesUnresolved reports whether s references an unresolved variable.
+func (m KVTable) usesUnresolved(s string) bool {
+	for _, name := range m.unresolved {
+		if strings.Contains(s, "{{"+name+"}}") {
+			return true
+		}
 	}
+	return false
 }
 
 // SetPairs replaces all pairs.
@@ -71,9 +99,9 @@ func (m *KVTable) SetSize(w int) {
 	m.width = w
 }
 
-// Editing returns whether the table is in edit mode.
+// Editing returns whether the table is in edit mode (including bulk edit).
 func (m KVTable) Editing() bool {
-	return m.editing
+	return m.editing || m.bulk
 }
 
 // Init implements tea.Model.
@@ -83,6 +111,9 @@ func (m KVTable) Init() tea.Cmd {
 
 // Update implements tea.Model.
 func (m KVTable) Update(msg tea.Msg) (KVTable, tea.Cmd) {
+	if m.bulk {
+		return m.updateBulk(msg)
+	}
 	if m.editing {
 		return m.updateEditing(msg)
 	}
@@ -101,6 +132,10 @@ func (m KVTable) updateNormal(msg tea.Msg) (KVTable, tea.Cmd) {
 			if m.cursor > 0 {
 				m.cursor--
 			}
+		case "K", "alt+up":
+			m.moveRow(-1)
+		case "J", "alt+down":
+			m.moveRow(1)
 		case "tab":
 			if m.column == ColKey {
 				m.column = ColValue
@@ -127,11 +162,99 @@ func (m KVTable) updateNormal(msg tea.Msg) (KVTable, tea.Cmd) {
 			}
 		case " ":
 			m.pairs[m.cursor].Enabled = !m.pairs[m.cursor].Enabled
+		case "B":
+			m.startBulk()
+			return m, textarea.Blink
 		}
 	}
 	return m, nil
 }
 
+// moveRow swaps the row under the cursor with its neighbour in direction
+// delta (-1 up, +1 down); the cursor follows the row.
+func (m *KVTable) moveRow(delta int) {
+	target := m.cursor + delta
+	if target < 0 || target >= len(m.pairs) {
+		return
+	}
+	m.pairs[m.cursor], m.pairs[target] = m.pairs[target], m.pairs[m.cursor]
+	m.cursor = target
+}
+
+func (m KVTable) updateBulk(msg tea.Msg) (KVTable, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "esc" {
+		m.SetPairs(parseBulk(m.bulkArea.Value()))
+		m.bulkArea.Blur()
+		m.bulk = false
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.bulkArea, cmd = m.bulkArea.Update(msg)
+	return m, cmd
+}
+
+func (m *KVTable) startBulk() {
+	text := formatBulk(m.pairs)
+	lines := strings.Count(text, "\n") + 2
+	m.bulkArea.SetHeight(min(max(lines, 5), 15))
+	m.bulkArea.SetWidth(max(m.width-2, 20))
+	m.bulkArea.SetValue(text)
+	m.bulkArea.Focus()
+	m.bulk = true
+}
+
+// formatBulk renders pairs as "Key: value" lines; disabled pairs are
+// commented out with "#" and empty rows are dropped.
+func formatBulk(pairs []KVPair) string {
+	var lines []string
+	for _, p := range pairs {
+		if p.Key == "" && p.Value == "" {
+			continue
+		}
+		line := p.Key + ": " + p.Value
+		if !p.Enabled {
+			line = "# " + line
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseBulk parses "Key: value" lines as copied from browser devtools.
+// Lines starting with "#" or "//" become disabled pairs, "key=value" is
+// accepted for query strings, and HTTP/2 pseudo-headers (":authority")
+// are skipped.
+func parseBulk(text string) []KVPair {
+	var pairs []KVPair
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		enabled := true
+		for _, prefix := range []string{"#", "//"} {
+			if strings.HasPrefix(line, prefix) {
+				line = strings.TrimSpace(strings.TrimPrefix(line, prefix))
+				enabled = false
+			}
+		}
+		if line == "" || strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		sep := ":"
+		if eq := strings.Index(line, "="); eq >= 0 {
+			if colon := strings.Index(line, ":"); colon < 0 || eq < colon {
+				sep = "="
+			}
+		}
+		key, value, _ := strings.Cut(line, sep)
+		pairs = append(pairs, KVPair{
+			Key:     strings.TrimSpace(key),
+			Value:   strings.TrimSpace(value),
+			Enabled: enabled,
+		})
+	}
+	return pairs
+}
+
 func (m KVTable) updateEditing(msg tea.Msg) (KVTable, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -186,6 +309,10 @@ func (m *KVTable) commitEdit() {
 
 // View implements tea.Model.
 func (m KVTable) View() string {
+	if m.bulk {
+		return m.bulkArea.View() + "\n" +
+			m.styles.Muted.Render("  Bulk edit: one Key: value per line, # to disable · Esc to apply")
+	}
 	if len(m.pairs) == 0 {
 		return m.styles.Muted.Render("  No entries")
 	}
@@ -279,6 +406,8 @@ func (m KVTable) View() string {
 			}
 			if pair.Value == "" {
 				valStr = m.styles.Muted.Render(padRight(valStr, valW))
+			} else if m.usesUnresolved(pair.Key + pair.Value) {
+				valStr = m.styles.Error.Render(padRight(valStr, valW))
 			} else {
 				valStr = m.styles.KVValue.Render(padRight(valStr, valW))
 			}
@@ -13,11 +13,20 @@ type Modal struct {
 	Title     string
 	Message   string
 	onConfirm tea.Msg
+	okLabel   string
+	alt       *ModalChoice // optional button between OK and Cancel
 	focusOK   bool
+	focusAlt  bool
 	theme     theme.Theme
 	styles    theme.Styles
 }
 
+// ModalChoice is a dialog button and the message it sends.
+type ModalChoice struct {
+	Label string
+	Msg   tea.Msg
+}
+
 // NewModal creates a new modal dialog.
 func NewModal(t theme.Theme, s theme.Styles) Modal {
 	return Modal{
@@ -29,11 +38,20 @@ func NewModal(t theme.Theme, s theme.Styles) Modal {
 
 // Show displays the modal with the given title, message, and confirm action.
 func (m *Modal) Show(title, message string, onConfirm tea.Msg) {
+	m.ShowChoices(title, message, ModalChoice{Label: "OK", Msg: onConfirm}, nil)
+}
+
+// ShowChoices displays the modal with a confirm button, an optional second
+// choice and Cancel.
+func (m *Modal) ShowChoices(title, message string, confirm ModalChoice, alt *ModalChoice) {
 	m.Visible = true
 	m.Title = title
 	m.Message = message
-	m.onConfirm = onConfirm
+	m.onConfirm = confirm.Msg
+	m.okLabel = confirm.Label
+	m.alt = alt
 	m.focusOK = true
+	m.focusAlt = false
 }
 
 // Init implements tea.Model.
@@ -53,15 +71,39 @@ func (m Modal) Update(msg tea.Msg) (Modal, tea.Cmd) {
 		case "esc":
 			m.Visible = false
 			return m, func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} }
-		case "tab", "shift+tab":
-			m.focusOK = !m.focusOK
+		case "tab":
+			switch {
+			case m.focusOK:
+				m.focusOK, m.focusAlt = false, m.alt != nil
+			case m.focusAlt:
+				m.focusAlt = false
+			default:
+				m.focusOK = true
+			}
+			return m, nil
+		case "shift+tab":
+			switch {
+			case m.focusOK:
+				m.focusOK = false
+			case m.focusAlt:
+				m.focusOK, m.focusAlt = true, false
+			default:
+				m.focusOK, m.focusAlt = m.alt == nil, m.alt != nil
+			}
 			return m, nil
 		case "enter":
 			m.Visible = false
-			if m.focusOK && m.onConfirm != nil {
+			chosen := m.onConfirm
+			if !m.focusOK {
+				chosen = nil
+				if m.focusAlt {
+					chosen = m.alt.Msg
+				}
+			}
+			if chosen != nil {
 				return m, tea.Batch(
 					func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} },
-					func() tea.Msg { return m.onConfirm },
+					func() tea.Msg { return chosen },
 				)
 			}
 			return m, func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} }
@@ -91,34 +133,44 @@ func (m Modal) View() string {
 		Align(lipgloss.Center)
 
 	// Buttons
-	okStyle := lipgloss.NewStyle().
-		Padding(0, 3)
-	cancelStyle := lipgloss.NewStyle().
-		Padding(0, 3)
+	padding := 3
+	if m.alt != nil {
+		padding = 1
+	}
+	idle := lipgloss.NewStyle().
+		Padding(0, padding).
+		Background(m.theme.Surface).
+		Foreground(m.theme.Subtext)
+	okStyle, altStyle, cancelStyle := idle, idle, idle
 
-	if m.focusOK {
+	switch {
+	case m.focusOK:
 		okStyle = okStyle.
 			Background(m.theme.Mauve).
 			Foreground(m.theme.Base).
 			Bold(true)
-		cancelStyle = cancelStyle.
-			Background(m.theme.Surface).
-			Foreground(m.theme.Subtext)
-	} else {
-		okStyle = okStyle.
-			Background(m.theme.Surface).
-			Foreground(m.theme.Subtext)
+	case m.focusAlt:
+		altStyle = altStyle.
+			Background(m.theme.Mauve).
+			Foreground(m.theme.Base).
+			Bold(true)
+	default:
 		cancelStyle = cancelStyle.
 			Background(m.theme.Red).
 			Foreground(m.theme.Base).
 			Bold(true)
 	}
 
-	buttons := lipgloss.JoinHorizontal(lipgloss.Center,
-		okStyle.Render("OK"),
-		"  ",
-		cancelStyle.Render("Cancel"),
-	)
+	okLabel := m.okLabel
+	if okLabel == "" {
+		okLabel = "OK"
+	}
+	buttonList := []string{okStyle.Render(okLabel), "  "}
+	if m.alt != nil {
+		buttonList = append(buttonList, altStyle.Render(m.alt.Label), "  ")
+	}
+	buttonList = append(buttonList, cancelStyle.Render("Cancel"))
+	buttons := lipgloss.JoinHorizontal(lipgloss.Center, buttonList...)
 
 	buttonsRow := lipgloss.NewStyle().
 		Width(boxWidth - 4).
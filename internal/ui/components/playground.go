@@ -0,0 +1,230 @@
+package components
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sadopc/gottp/internal/core/jsonquery"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// playgroundTargets are cycled with tab; enter inserts the expression into
+// the selected one.
+var playgroundTargets = []struct {
+	target msgs.QueryTarget
+	label  string
+}{
+	{msgs.QueryAssertion, "Assertion"},
+	{msgs.QueryCapture, "Workflow capture"},
+	{msgs.QueryScript, "Post-script variable"},
+}
+
+const playgroundLines = 14
+
+// Playground evaluates JSONPath, JMESPath and jq expressions against a
+// response body as they are typed, and inserts a working one into the active request.
+type Playground struct {
+	Visible bool
+	doc     interface{}
+	docErr  string
+	input   textinput.Model
+	results []interface{}
+	output  []string
+	err     string
+	offset  int
+	target  int
+	theme   theme.Theme
+	styles  theme.Styles
+}
+
+// NewPlayground creates a new playground overlay.
+func NewPlayground(t theme.Theme, s theme.Styles) Playground {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "$.items[0].id, jmespath:items[*].id or .items[] | .id"
+	input.CharLimit = 512
+	input.Width = 68
+	return Playground{input: input, theme: t, styles: s}
+}
+
+// Open shows the overlay querying body, starting from expr.
+func (m *Playground) Open(body []byte, expr string) {
+	m.Visible = true
+	m.doc, m.docErr = nil, ""
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&m.doc); err != nil {
+		m.docErr = "Response body is not JSON"
+	}
+	if expr == "" {
+		expr = "$"
+	}
+	m.input.SetValue(expr)
+	m.input.CursorEnd()
+	m.input.Focus()
+	m.evaluate()
+}
+
+// Close hides the overlay and drops the parsed body.
+func (m *Playground) Close() {
+	m.Visible = false
+	m.input.Blur()
+	m.doc = nil
+	m.results = nil
+	m.output = nil
+}
+
+func (m *Playground) evaluate() {
+	m.results, m.output, m.err, m.offset = nil, nil, "", 0
+	if m.docErr != "" {
+		return
+	}
+	expr := strings.TrimSpace(m.input.Value())
+	if expr == "" {
+		return
+	}
+	results, err := jsonquery.Eval(m.doc, expr)
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.results = results
+	m.output = strings.Split(jsonquery.Format(results), "\n")
+}
+
+// insert returns the message adding the expression to the selected target.
+func (m Playground) insert() msgs.InsertQueryMsg {
+	ins := msgs.InsertQueryMsg{
+		Expr:   strings.TrimSpace(m.input.Value()),
+		Target: playgroundTargets[m.target].target,
+		Name:   jsonquery.VarName(m.input.Value()),
+		Count:  len(m.results),
+	}
+	if len(m.results) == 1 {
+		ins.Value = m.results[0]
+	}
+	return ins
+}
+
+// Update handles key input while the overlay is visible.
+func (m Playground) Update(msg tea.Msg) (Playground, tea.Cmd) {
+	if !m.Visible {
+		return m, nil
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.String() {
+	case "esc":
+		m.Close()
+		return m, func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} }
+	case "tab":
+		m.target = (m.target + 1) % len(playgroundTargets)
+		return m, nil
+	case "shift+tab":
+		m.target = (m.target + len(playgroundTargets) - 1) % len(playgroundTargets)
+		return m, nil
+	case "down", "ctrl+n":
+		if m.offset < len(m.output)-playgroundLines {
+			m.offset++
+		}
+		return m, nil
+	case "up", "ctrl+p":
+		if m.offset > 0 {
+			m.offset--
+		}
+		return m, nil
+	case "enter":
+		if m.err != "" || m.docErr != "" || strings.TrimSpace(m.input.Value()) == "" {
+			return m, nil
+		}
+		ins := m.insert()
+		m.Close()
+		return m, tea.Batch(
+			func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} },
+			func() tea.Msg { return ins },
+		)
+	}
+
+	before := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != before {
+		m.evaluate()
+	}
+	return m, cmd
+}
+
+// View renders the overlay.
+func (m Playground) View() string {
+	if !m.Visible {
+		return ""
+	}
+
+	boxWidth := 80
+	inner := boxWidth - 6
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Text).
+		Bold(true).
+		Width(inner).
+		Align(lipgloss.Center)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.theme.Muted)
+	selectedStyle := lipgloss.NewStyle().Foreground(m.theme.Mauve).Bold(true)
+
+	lines := []string{
+		titleStyle.Render("JSONPath Playground"),
+		"",
+		m.input.View(),
+		"",
+	}
+
+	switch {
+	case m.docErr != "":
+		lines = append(lines, m.styles.Error.Render(m.docErr))
+	case m.err != "":
+		lines = append(lines, m.styles.Error.MaxWidth(inner).Render(m.err))
+	case m.output != nil:
+		noun := "results"
+		if len(m.results) == 1 {
+			noun = "result"
+		}
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("%d %s", len(m.results), noun)))
+		end := min(m.offset+playgroundLines, len(m.output))
+		for _, line := range m.output[m.offset:end] {
+			lines = append(lines, lipgloss.NewStyle().MaxWidth(inner).Render(line))
+		}
+		if len(m.output) > end {
+			lines = append(lines, mutedStyle.Render(fmt.Sprintf("… %d more lines", len(m.output)-end)))
+		}
+	}
+
+	lines = append(lines, "")
+	var targets []string
+	for i, t := range playgroundTargets {
+		if i == m.target {
+			targets = append(targets, selectedStyle.Render("["+t.label+"]"))
+		} else {
+			targets = append(targets, mutedStyle.Render(t.label))
+		}
+	}
+	lines = append(lines, "Insert as: "+strings.Join(targets, "  "))
+	lines = append(lines, mutedStyle.Render("tab: target · enter: insert · ↑/↓: scroll · esc: close"))
+
+	return lipgloss.NewStyle().
+		Width(boxWidth).
+		Background(m.theme.Surface).
+		Foreground(m.theme.Text).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.BorderFocused).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+}
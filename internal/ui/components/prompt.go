@@ -0,0 +1,120 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// Prompt asks for a single line of text, such as the name of a new
+// request.
+type Prompt struct {
+	Visible bool
+	title   string
+	submit  func(string) tea.Msg
+	input   textinput.Model
+	err     string
+	theme   theme.Theme
+	styles  theme.Styles
+}
+
+// NewPrompt creates a new prompt overlay.
+func NewPrompt(t theme.Theme, s theme.Styles) Prompt {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.CharLimit = 200
+	input.Width = 56
+	return Prompt{input: input, theme: t, styles: s}
+}
+
+// Open shows the prompt with value filled in. On enter, the trimmed text
+// is passed to submit and the message it returns is sent.
+func (m *Prompt) Open(title, value string, submit func(string) tea.Msg) {
+	m.Visible = true
+	m.title = title
+	m.submit = submit
+	m.err = ""
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	m.input.Focus()
+}
+
+// Close hides the prompt.
+func (m *Prompt) Close() {
+	m.Visible = false
+	m.submit = nil
+	m.input.Blur()
+}
+
+// Update handles key input while the prompt is visible.
+func (m Prompt) Update(msg tea.Msg) (Prompt, tea.Cmd) {
+	if !m.Visible {
+		return m, nil
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.String() {
+	case "esc":
+		m.Close()
+		return m, func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} }
+	case "enter":
+		value := strings.TrimSpace(m.input.Value())
+		if value == "" {
+			m.err = "Enter a name"
+			return m, nil
+		}
+		submitted := m.submit(value)
+		m.Close()
+		return m, tea.Batch(
+			func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} },
+			func() tea.Msg { return submitted },
+		)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// View renders the prompt.
+func (m Prompt) View() string {
+	if !m.Visible {
+		return ""
+	}
+
+	boxWidth := 64
+	inner := boxWidth - 6
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Text).
+		Bold(true).
+		Width(inner).
+		Align(lipgloss.Center)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.theme.Muted)
+
+	lines := []string{
+		titleStyle.Render(m.title),
+		"",
+		m.input.View(),
+	}
+	if m.err != "" {
+		lines = append(lines, m.styles.Error.Render(m.err))
+	}
+	lines = append(lines, "", mutedStyle.Render("enter: confirm · esc: cancel"))
+
+	return lipgloss.NewStyle().
+		Width(boxWidth).
+		Background(m.theme.Surface).
+		Foreground(m.theme.Text).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.BorderFocused).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+}
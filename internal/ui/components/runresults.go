@@ -0,0 +1,134 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// RunResults shows the report of a headless run, as gottp run prints it.
+type RunResults struct {
+	Visible bool
+	title   string
+	summary string
+	failed  bool
+	lines   []string
+	offset  int
+	width   int
+	height  int
+	theme   theme.Theme
+	styles  theme.Styles
+}
+
+// NewRunResults creates a new run results overlay.
+func NewRunResults(t theme.Theme, s theme.Styles) RunResults {
+	return RunResults{theme: t, styles: s, width: 100, height: 30}
+}
+
+// Show opens the overlay with a report. failed colors the summary line.
+func (m *RunResults) Show(title, summary, report string, failed bool) {
+	m.Visible = true
+	m.title = title
+	m.summary = summary
+	m.failed = failed
+	m.lines = strings.Split(strings.TrimRight(report, "\n"), "\n")
+	m.offset = 0
+}
+
+// SetSize fits the overlay to the terminal.
+func (m *RunResults) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+// Close hides the overlay.
+func (m *RunResults) Close() {
+	m.Visible = false
+	m.lines = nil
+}
+
+// visibleLines is how many report lines fit in the box.
+func (m RunResults) visibleLines() int {
+	// Border, padding, title, summary, hint and blank lines
+	return max(m.height-12, 3)
+}
+
+// Update handles scrolling and closing.
+func (m RunResults) Update(msg tea.Msg) (RunResults, tea.Cmd) {
+	if !m.Visible {
+		return m, nil
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	last := max(len(m.lines)-m.visibleLines(), 0)
+	switch key.String() {
+	case "esc", "q", "enter":
+		m.Close()
+		return m, func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} }
+	case "j", "down":
+		m.offset = min(m.offset+1, last)
+	case "k", "up":
+		m.offset = max(m.offset-1, 0)
+	case "ctrl+d", "pgdown":
+		m.offset = min(m.offset+m.visibleLines(), last)
+	case "ctrl+u", "pgup":
+		m.offset = max(m.offset-m.visibleLines(), 0)
+	case "g":
+		m.offset = 0
+	case "G":
+		m.offset = last
+	}
+	return m, nil
+}
+
+// View renders the overlay.
+func (m RunResults) View() string {
+	if !m.Visible {
+		return ""
+	}
+
+	boxWidth := min(max(m.width-4, 40), 110)
+	inner := boxWidth - 6
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Text).
+		Bold(true).
+		Width(inner).
+		Align(lipgloss.Center)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.theme.Muted)
+	summaryStyle := lipgloss.NewStyle().Foreground(m.theme.Green).Bold(true)
+	if m.failed {
+		summaryStyle = summaryStyle.Foreground(m.theme.Red)
+	}
+
+	lines := []string{
+		titleStyle.Render(m.title),
+		"",
+		summaryStyle.Render(m.summary),
+		"",
+	}
+	end := min(m.offset+m.visibleLines(), len(m.lines))
+	for _, line := range m.lines[m.offset:end] {
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(inner).Render(line))
+	}
+	hint := "j/k: scroll · esc: close"
+	if len(m.lines) > end || m.offset > 0 {
+		hint = fmt.Sprintf("lines %d-%d of %d · %s", m.offset+1, end, len(m.lines), hint)
+	}
+	lines = append(lines, "", mutedStyle.Render(hint))
+
+	return lipgloss.NewStyle().
+		Width(boxWidth).
+		Background(m.theme.Surface).
+		Foreground(m.theme.Text).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.BorderFocused).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+}
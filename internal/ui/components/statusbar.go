@@ -24,11 +24,29 @@ type StatusBar struct {
 	mode        msgs.AppMode
 	message     string
 	envName     string
+	proxy       string
+	recording   string
+	breadcrumb  string
 	width       int
+	transfer    transfer
 	theme       theme.Theme
 	styles      theme.Styles
 }
 
+// transfer tracks the request in flight: how long it has taken and how
+// fast its body is arriving.
+type transfer struct {
+	start    time.Time // zero when nothing is loading
+	now      time.Time // time of the last tick
+	received int64
+	total    int64   // -1 when the length is unknown
+	counted  int64   // received as of the last tick
+	rate     float64 // bytes per second, smoothed across ticks
+}
+
+// liveAfter is how long a request runs before its elapsed time is shown.
+const liveAfter = time.Second
+
 // NewStatusBar creates a new status bar.
 func NewStatusBar(t theme.Theme, s theme.Styles) StatusBar {
 	return StatusBar{
@@ -66,6 +84,86 @@ func (m *StatusBar) SetEnv(name string) {
 	m.envName = name
 }
 
+// SetProxy sets the active proxy displayed next to the environment. Empty
+// hides the indicator.
+func (m *StatusBar) SetProxy(label string) {
+	m.proxy = label
+}
+
+// SetRecording sets the session recording indicator. Empty hides it.
+func (m *StatusBar) SetRecording(label string) {
+	m.recording = label
+}
+
+// SetBreadcrumb sets the path of the selected response node, shown after
+// the response info.
+func (m *StatusBar) SetBreadcrumb(path string) {
+	m.breadcrumb = path
+}
+
+// StartLoading shows live progress for a request sent at start, in place
+// of the last response's status, until StopLoading.
+func (m *StatusBar) StartLoading(start time.Time) {
+	m.transfer = transfer{start: start, now: start}
+}
+
+// StopLoading hides the live progress.
+func (m *StatusBar) StopLoading() {
+	m.transfer = transfer{}
+}
+
+// Loading reports whether live progress is shown.
+func (m StatusBar) Loading() bool {
+	return !m.transfer.start.IsZero()
+}
+
+// SetProgress records how much of the response body has arrived. Total is
+// -1 when the length is unknown.
+func (m *StatusBar) SetProgress(received, total int64) {
+	m.transfer.received, m.transfer.total = received, total
+}
+
+// Tick advances the elapsed time to now and updates the transfer rate from
+// the bytes received since the last tick.
+func (m *StatusBar) Tick(now time.Time) {
+	t := &m.transfer
+	if t.start.IsZero() {
+		return
+	}
+	if dt := now.Sub(t.now).Seconds(); dt > 0 && t.received > 0 {
+		current := float64(t.received-t.counted) / dt
+		if t.rate == 0 {
+			t.rate = current
+		} else {
+			t.rate = 0.7*t.rate + 0.3*current
+		}
+		t.counted = t.received
+	}
+	t.now = now
+}
+
+// progress describes the request in flight, or returns "" while it is
+// too young to be worth showing.
+func (m StatusBar) progress() string {
+	t := m.transfer
+	elapsed := t.now.Sub(t.start)
+	if t.received == 0 && elapsed < liveAfter {
+		return ""
+	}
+	parts := []string{"⏱ " + formatElapsed(elapsed)}
+	if t.received > 0 {
+		size := humanize.IBytes(uint64(t.received))
+		if t.total > 0 {
+			size += fmt.Sprintf(" of %s (%d%%)", humanize.IBytes(uint64(t.total)), t.received*100/t.total)
+		}
+		parts = append(parts, size)
+		if t.rate > 0 {
+			parts = append(parts, humanize.IBytes(uint64(t.rate))+"/s")
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
 // Init implements tea.Model.
 func (m StatusBar) Init() tea.Cmd {
 	return nil
@@ -95,6 +193,13 @@ func (m StatusBar) View() string {
 			Foreground(m.theme.Text).
 			Background(m.theme.Surface).
 			Render(m.message))
+	} else if m.Loading() {
+		if p := m.progress(); p != "" {
+			leftParts = append(leftParts, lipgloss.NewStyle().
+				Foreground(m.theme.Yellow).
+				Background(m.theme.Surface).
+				Render(p))
+		}
 	} else {
 		if m.statusCode > 0 {
 			statusColor := m.theme.StatusColor(m.statusCode)
@@ -129,6 +234,18 @@ func (m StatusBar) View() string {
 				Render(m.contentType)
 			leftParts = append(leftParts, ct)
 		}
+
+		if m.breadcrumb != "" {
+			crumb := m.breadcrumb
+			if limit := m.width / 3; limit > 1 && len([]rune(crumb)) > limit {
+				r := []rune(crumb)
+				crumb = "…" + string(r[len(r)-limit+1:])
+			}
+			leftParts = append(leftParts, lipgloss.NewStyle().
+				Foreground(m.theme.Sky).
+				Background(m.theme.Surface).
+				Render(crumb))
+		}
 	}
 
 	left := strings.Join(leftParts, " │ ")
@@ -150,6 +267,19 @@ func (m StatusBar) View() string {
 			Render("[" + m.envName + "]")
 		rightParts = append(rightParts, envStr)
 	}
+	if m.proxy != "" {
+		rightParts = append(rightParts, lipgloss.NewStyle().
+			Foreground(m.theme.Peach).
+			Background(m.theme.Surface).
+			Render("⇄ "+m.proxy))
+	}
+	if m.recording != "" {
+		rightParts = append(rightParts, lipgloss.NewStyle().
+			Foreground(m.theme.Red).
+			Background(m.theme.Surface).
+			Bold(true).
+			Render("● "+m.recording))
+	}
 	rightParts = append(rightParts, lipgloss.NewStyle().
 		Foreground(m.theme.Muted).
 		Background(m.theme.Surface).
@@ -183,6 +313,15 @@ func (m StatusBar) View() string {
 	return barStyle.Render(line)
 }
 
+// formatElapsed shows a running time to a tenth of a second, so it ticks
+// visibly without jittering.
+func formatElapsed(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return d.Truncate(time.Second).String()
+}
+
 func formatDuration(d time.Duration) string {
 	switch {
 	case d < time.Millisecond:
@@ -14,6 +14,10 @@ import (
 type TabItem struct {
 	Name   string
 	Method string
+	Env    string // pinned environment, shown after the name
+
+	// Modified marks a tab whose request has unsaved changes
+	Modified bool
 }
 
 // TabBar is a horizontal tab bar for open requests.
@@ -123,12 +127,20 @@ func (m TabBar) View() string {
 		if nameWidth < 1 {
 			nameWidth = 1
 		}
+		marker := ""
+		if tab.Modified {
+			marker = " ●"
+			nameWidth = max(nameWidth-2, 1)
+		}
 		name := tab.Name
+		if tab.Env != "" {
+			name += " @" + tab.Env
+		}
 		if len(name) > nameWidth {
 			name = name[:nameWidth-1] + "…"
 		}
 
-		label := badge + " " + name
+		label := badge + " " + name + marker
 
 		var rendered string
 		if i == m.active {
@@ -0,0 +1,308 @@
+package components
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// varName matches the names {{placeholders}} can refer to.
+var varName = regexp.MustCompile(`^\w+$`)
+
+// VarScope is a set of variables edited in place by the Variables overlay.
+type VarScope struct {
+	Label string
+	Vars  *map[string]string
+}
+
+// varRow is a variable in a scope, or an empty scope's placeholder row
+// when key is "".
+type varRow struct {
+	scope int
+	key   string
+}
+
+// Variables edits the collection, folder and request variables in effect
+// for a request. Scopes are listed in precedence order, so a variable is
+// overridden by any with the same name above it.
+type Variables struct {
+	Visible bool
+
+	// Mask, when set, hides known secrets in the values listed; editing a
+	// variable shows its value in full.
+	Mask func(string) string
+
+	scopes  []VarScope
+	env     string
+	rows    []varRow
+	cursor  int
+	editing bool
+	editKey string // variable being edited, "" when adding
+	input   textinput.Model
+	err     string
+	theme   theme.Theme
+	styles  theme.Styles
+}
+
+// NewVariables creates a new variables overlay.
+func NewVariables(t theme.Theme, s theme.Styles) Variables {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "name=value"
+	input.CharLimit = 1024
+	input.Width = 60
+	return Variables{input: input, theme: t, styles: s}
+}
+
+// Open shows the overlay editing scopes, narrowest first. env names the
+// active environment, which sits between the folders and the collection.
+func (m *Variables) Open(scopes []VarScope, env string) {
+	m.Visible = true
+	m.scopes = scopes
+	m.env = env
+	m.cursor = 0
+	m.stopEditing()
+	m.refresh()
+}
+
+// Close hides the overlay.
+func (m *Variables) Close() {
+	m.Visible = false
+	m.stopEditing()
+	m.scopes = nil
+	m.rows = nil
+}
+
+func (m *Variables) refresh() {
+	m.rows = m.rows[:0]
+	for i, scope := range m.scopes {
+		keys := make([]string, 0, len(*scope.Vars))
+		for k := range *scope.Vars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if len(keys) == 0 {
+			keys = []string{""}
+		}
+		for _, k := range keys {
+			m.rows = append(m.rows, varRow{scope: i, key: k})
+		}
+	}
+	m.cursor = min(m.cursor, max(len(m.rows)-1, 0))
+}
+
+func (m *Variables) startEditing(key, value string) {
+	m.editing = true
+	m.editKey = key
+	m.err = ""
+	if key != "" {
+		m.input.SetValue(key + "=" + value)
+	} else {
+		m.input.SetValue("")
+	}
+	m.input.CursorEnd()
+	m.input.Focus()
+}
+
+func (m *Variables) stopEditing() {
+	m.editing = false
+	m.editKey = ""
+	m.input.Blur()
+}
+
+// commit stores the input as a variable in the cursor's scope, replacing
+// the one being edited.
+func (m *Variables) commit() bool {
+	name, value, _ := strings.Cut(m.input.Value(), "=")
+	name = strings.TrimSpace(name)
+	if !varName.MatchString(name) {
+		m.err = "Names may contain only letters, digits and _"
+		return false
+	}
+	scope := m.rows[m.cursor].scope
+	vars := m.scopes[scope].Vars
+	if *vars == nil {
+		*vars = make(map[string]string)
+	}
+	if m.editKey != "" && m.editKey != name {
+		delete(*vars, m.editKey)
+	}
+	(*vars)[name] = value
+	m.stopEditing()
+	m.refresh()
+	for i, row := range m.rows {
+		if row.scope == scope && row.key == name {
+			m.cursor = i
+		}
+	}
+	return true
+}
+
+func changed() tea.Msg { return msgs.VariablesChangedMsg{} }
+
+// Update handles key input while the overlay is visible.
+func (m Variables) Update(msg tea.Msg) (Variables, tea.Cmd) {
+	if !m.Visible {
+		return m, nil
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if m.editing {
+		switch key.String() {
+		case "esc":
+			m.stopEditing()
+			m.err = ""
+			return m, nil
+		case "enter":
+			if m.commit() {
+				return m, changed
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	switch key.String() {
+	case "esc", "q":
+		m.Close()
+		return m, func() tea.Msg { return msgs.SetModeMsg{Mode: msgs.ModeNormal} }
+	case "j", "down":
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+	case "k", "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "a":
+		if len(m.rows) > 0 {
+			m.startEditing("", "")
+		}
+	case "enter", "e":
+		if len(m.rows) == 0 {
+			return m, nil
+		}
+		row := m.rows[m.cursor]
+		m.startEditing(row.key, (*m.scopes[row.scope].Vars)[row.key])
+	case "d", "x":
+		if len(m.rows) == 0 || m.rows[m.cursor].key == "" {
+			return m, nil
+		}
+		row := m.rows[m.cursor]
+		delete(*m.scopes[row.scope].Vars, row.key)
+		m.refresh()
+		return m, changed
+	}
+	return m, nil
+}
+
+// overridden reports whether a scope narrower than row's defines its key.
+func (m Variables) overridden(row varRow) bool {
+	for _, scope := range m.scopes[:row.scope] {
+		if _, ok := (*scope.Vars)[row.key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// View renders the overlay.
+func (m Variables) View() string {
+	if !m.Visible {
+		return ""
+	}
+
+	boxWidth := 76
+	inner := boxWidth - 6
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Text).
+		Bold(true).
+		Width(inner).
+		Align(lipgloss.Center)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.theme.Muted)
+	headerStyle := lipgloss.NewStyle().Foreground(m.theme.Mauve).Bold(true)
+
+	env := m.env
+	if env == "" {
+		env = "none"
+	}
+	chain := "request › folders › environment (" + env + ") › collection › OS"
+
+	var body []string
+	cursorLine := 0
+	for i, row := range m.rows {
+		if i == 0 || m.rows[i-1].scope != row.scope {
+			if row.scope == len(m.scopes)-1 {
+				body = append(body, mutedStyle.Render("Environment "+env+" (edit in environments.yaml)"))
+			}
+			body = append(body, headerStyle.Render(m.scopes[row.scope].Label))
+		}
+		label := mutedStyle.Render("  (none)")
+		if row.key != "" {
+			value := (*m.scopes[row.scope].Vars)[row.key]
+			if m.Mask != nil {
+				value = m.Mask(value)
+			}
+			label = fmt.Sprintf("  %s = %s", row.key, value)
+			if m.overridden(row) {
+				label = mutedStyle.Render(label + " (overridden)")
+			}
+		}
+		style := lipgloss.NewStyle().MaxWidth(inner)
+		if i == m.cursor {
+			cursorLine = len(body)
+			style = style.Background(m.theme.Overlay).Foreground(m.theme.Text).Width(inner)
+		}
+		body = append(body, style.Render(label))
+	}
+
+	maxLines := 14
+	start := 0
+	if cursorLine >= maxLines {
+		start = cursorLine - maxLines + 1
+	}
+	end := min(start+maxLines, len(body))
+
+	lines := []string{
+		titleStyle.Render("Variables"),
+		"",
+		mutedStyle.Render("Precedence: " + chain),
+		"",
+	}
+	lines = append(lines, body[start:end]...)
+	if len(body) > end {
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("  … %d more lines", len(body)-end)))
+	}
+	lines = append(lines, "")
+	if m.editing {
+		lines = append(lines, m.input.View())
+		if m.err != "" {
+			lines = append(lines, m.styles.Error.Render(m.err))
+		}
+		lines = append(lines, mutedStyle.Render("enter: save · esc: cancel"))
+	} else {
+		lines = append(lines, mutedStyle.Render("j/k: move · a: add · enter: edit · d: delete · esc: close"))
+	}
+
+	return lipgloss.NewStyle().
+		Width(boxWidth).
+		Background(m.theme.Surface).
+		Foreground(m.theme.Text).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.BorderFocused).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+}
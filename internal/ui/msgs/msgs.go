@@ -5,6 +5,10 @@ import (
 	"time"
 
 	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/export"
+	"github.com/sadopc/gottp/internal/protocol"
+	"github.com/sadopc/gottp/internal/protocol/graphql"
+	"github.com/sadopc/gottp/internal/runner"
 )
 
 // Panel focus targets
@@ -60,8 +64,27 @@ type CycleFocusMsg struct {
 // ToggleSidebarMsg toggles sidebar visibility.
 type ToggleSidebarMsg struct{}
 
-// SendRequestMsg triggers sending the current request.
-type SendRequestMsg struct{}
+// SendRequestMsg triggers sending the current request. Download streams
+// the response body to a file whatever its size. Confirmed skips the
+// warning about variables empty in the active environment.
+type SendRequestMsg struct {
+	Download  bool
+	Confirmed bool
+}
+
+// DownloadProgressMsg reports how much of a response body has been read.
+// Total is -1 when the length is unknown.
+type DownloadProgressMsg struct {
+	Received int64
+	Total    int64
+}
+
+// RequestTickMsg updates the elapsed time and transfer rate of the request
+// in flight. Seq identifies the send that scheduled it.
+type RequestTickMsg struct {
+	Seq  int
+	Time time.Time
+}
 
 // RequestSentMsg is emitted when a request completes.
 type RequestSentMsg struct {
@@ -74,16 +97,48 @@ type RequestSentMsg struct {
 	Size        int64
 	Err         error
 
+	// Proto, TLS and Timing are shown in the Timing tab
+	Proto  string
+	TLS    bool
+	Timing *protocol.TimingDetail
+
+	// Trailers and 1xx interim responses, shown in the Headers tab
+	Trailers http.Header
+	Interim  []protocol.InterimResponse
+
+	// RawRequest is the request as framed on the wire, shown in the Raw tab
+	RawRequest string
+
+	// BodyFile is set when the body was saved to disk; Body is a preview
+	BodyFile string
+
 	// Post-script results (attached if script ran)
 	ScriptResult *ScriptResultMsg
 	ScriptErr    *string
+
+	// TokenRefresh is set when a 401 triggered an OAuth2 token refresh and
+	// the request was retried with the new token.
+	TokenRefresh *OAuth2TokenMsg
+
+	// Attempts counts tries made under the retry policy
+	Attempts int
+
+	// Request is the request as sent and Started when, for session
+	// recording
+	Request *protocol.Request
+	Started time.Time
 }
 
 // NewRequestMsg opens a new empty request tab.
 type NewRequestMsg struct{}
 
-// CloseTabMsg closes the current tab.
-type CloseTabMsg struct{}
+// CloseTabMsg closes the current tab. If its request has unsaved changes,
+// the user is asked whether to save or discard them unless Save or Discard
+// already says.
+type CloseTabMsg struct {
+	Save    bool
+	Discard bool
+}
 
 // SwitchTabMsg switches to a specific tab.
 type SwitchTabMsg struct {
@@ -97,6 +152,15 @@ type PrevTabMsg struct{}
 // SaveRequestMsg saves the current request.
 type SaveRequestMsg struct{}
 
+// SaveAllMsg saves the changes in every open tab, then quits if Quit is
+// set.
+type SaveAllMsg struct {
+	Quit bool
+}
+
+// QuitMsg quits gottp, asking first if there are unsaved changes.
+type QuitMsg struct{}
+
 // OpenCommandPaletteMsg opens the command palette.
 type OpenCommandPaletteMsg struct{}
 
@@ -126,6 +190,74 @@ type RequestSelectedMsg struct {
 	RequestID string
 }
 
+// MoveRequestMsg moves a request up (Delta -1) or down (Delta 1) within
+// its folder and saves the collection.
+type MoveRequestMsg struct {
+	RequestID string
+	Delta     int
+}
+
+// PasteItemMsg moves a cut request or folder to just below At in the
+// sidebar: first inside At when it is a folder, after it otherwise.
+type PasteItemMsg struct {
+	Item collection.Item
+	At   collection.Item
+}
+
+// AddItemMsg adds a request, or a folder when Folder is set, next to At:
+// inside At when it is a folder, beside it otherwise. An empty Name asks
+// for one first.
+type AddItemMsg struct {
+	At     collection.Item
+	Folder bool
+	Name   string
+}
+
+// RenameItemMsg renames a request or folder. An empty Name asks for one
+// first.
+type RenameItemMsg struct {
+	Item collection.Item
+	Name string
+}
+
+// DeleteItemMsg deletes a request or folder once Confirmed.
+type DeleteItemMsg struct {
+	Item      collection.Item
+	Confirmed bool
+}
+
+// MoveItemMsg moves a request or folder into Into, or to the top level
+// when Into is nil. Without Picked, the user picks the folder first.
+type MoveItemMsg struct {
+	Item   collection.Item
+	Into   *collection.Folder
+	Picked bool
+}
+
+// SaveToCollectionMsg adds the active tab's scratch request to the
+// collection, in Into or at the top level when Into is nil. Without Picked
+// the user picks the folder first, and without Name they name it.
+type SaveToCollectionMsg struct {
+	Into   *collection.Folder
+	Picked bool
+	Name   string
+}
+
+// RunFolderMsg runs a folder's requests headlessly, as gottp run --folder
+// does. An empty Env asks for an environment first when there are any.
+type RunFolderMsg struct {
+	Folder string
+	Env    string
+}
+
+// FolderRunResultMsg carries the results of a folder run.
+type FolderRunResultMsg struct {
+	Folder  string
+	Env     string
+	Results []runner.Result
+	Err     error
+}
+
 // CollectionLoadedMsg is emitted when a collection is loaded.
 type CollectionLoadedMsg struct {
 	Err error
@@ -136,9 +268,49 @@ type SwitchEnvMsg struct {
 	Name string
 }
 
+// CycleEnvMsg switches to the next environment: the active tab's pinned
+// one if it has one, the global one otherwise.
+type CycleEnvMsg struct{}
+
+// PinEnvMsg pins the active tab to an environment. An empty Name opens the
+// environment picker; Unpin makes the tab follow the global environment.
+type PinEnvMsg struct {
+	Name  string
+	Unpin bool
+}
+
+// CopyTextMsg copies Text to the clipboard; Label names it in the toast.
+type CopyTextMsg struct {
+	Text  string
+	Label string
+}
+
+// AnnotateResponseMsg opens the annotation overlay over the response body.
+type AnnotateResponseMsg struct{}
+
+// ShareSnippetMsg copies an annotated response snippet as Markdown.
+type ShareSnippetMsg struct {
+	Snippet export.Snippet
+}
+
+// OpenURLMsg opens URL, which may reference variables, in the browser.
+type OpenURLMsg struct {
+	URL string
+}
+
 // CopyAsCurlMsg triggers copying the current request as cURL.
 type CopyAsCurlMsg struct{}
 
+// ExportWSLogMsg saves the WebSocket message log to a file.
+type ExportWSLogMsg struct{}
+
+// ExportShellScriptMsg saves the selected request or folder, or the whole
+// collection, as a bash script of curl calls.
+type ExportShellScriptMsg struct{}
+
+// FreeMemoryMsg forces a garbage collection and reports memory usage.
+type FreeMemoryMsg struct{}
+
 // ImportCurlMsg triggers importing a request from clipboard cURL.
 type ImportCurlMsg struct{}
 
@@ -150,11 +322,77 @@ type EditorDoneMsg struct {
 	Content string
 }
 
+// EditRequestSourceMsg opens the collection file in $EDITOR at the
+// selected request.
+type EditRequestSourceMsg struct{}
+
+// CollectionEditedMsg is emitted when $EDITOR exits after editing the
+// collection file.
+type CollectionEditedMsg struct {
+	Err error
+}
+
+// CollectionFileChangedMsg reports that the collection's files may have
+// changed on disk.
+type CollectionFileChangedMsg struct{}
+
+// ReloadCollectionMsg reloads the collection from its file, discarding
+// unsaved edits.
+type ReloadCollectionMsg struct{}
+
 // HistorySelectedMsg is emitted when a history entry is selected.
 type HistorySelectedMsg struct {
 	ID int64
 }
 
+// EditHistoryNoteMsg opens the tags and note of a history entry.
+type EditHistoryNoteMsg struct {
+	ID int64
+}
+
+// HistoryNoteSavedMsg stores new tags and a note for a history entry.
+type HistoryNoteSavedMsg struct {
+	ID   int64
+	Tags []string
+	Note string
+}
+
+// FilterHistoryMsg is emitted as the sidebar filter changes, so history
+// beyond the entries shown can be searched. "#tag" terms match tags.
+type FilterHistoryMsg struct {
+	Query string
+}
+
+// OpenPlaygroundMsg opens the JSONPath playground on the response body,
+// starting from Expr.
+type OpenPlaygroundMsg struct {
+	Expr string
+}
+
+// QueryTarget is where the playground inserts an expression.
+type QueryTarget int
+
+const (
+	QueryAssertion QueryTarget = iota // a jsonpath assertion on the request
+	QueryCapture                      // an extract in workflow steps running the request
+	QueryScript                       // a gottp.setEnvVar line in the post-script
+)
+
+// InsertQueryMsg adds a JSONPath, JMESPath or jq expression from the
+// playground to the active request. Value is the only result, if there
+// was exactly one.
+type InsertQueryMsg struct {
+	Expr   string
+	Target QueryTarget
+	Name   string // suggested variable name
+	Value  interface{}
+	Count  int
+}
+
+// ExportHistoryMsg saves the history matching the sidebar filter as a new
+// collection.
+type ExportHistoryMsg struct{}
+
 // --- Phase 3A: Theme switching ---
 
 // SwitchThemeMsg requests switching to a named theme.
@@ -164,12 +402,74 @@ type SwitchThemeMsg struct {
 
 // --- Phase 3B: OAuth2 ---
 
-// OAuth2TokenMsg is emitted when an OAuth2 token is acquired.
+// OAuth2TokenMsg is emitted when an OAuth2 token is acquired. Key and
+// RequestID are taken when the request is sent, since the user may switch
+// tabs before the token arrives.
 type OAuth2TokenMsg struct {
 	AccessToken  string
 	RefreshToken string
 	ExpiresIn    int
 	Err          error
+
+	// Key caches the token; RequestID is the request to retry with it
+	Key       string
+	RequestID string
+}
+
+// FindReplaceMsg opens the collection-wide find and replace overlay.
+type FindReplaceMsg struct{}
+
+// ApplyReplaceMsg applies the selected find and replace matches and saves
+// the collection.
+type ApplyReplaceMsg struct {
+	Matches []collection.Match
+}
+
+// EditVariablesMsg opens the variables overlay for the active request.
+type EditVariablesMsg struct{}
+
+// VariablesChangedMsg reports an edit in the variables overlay; the
+// collection is saved.
+type VariablesChangedMsg struct{}
+
+// FindDuplicatesMsg opens the duplicate requests overlay.
+type FindDuplicatesMsg struct{}
+
+// MergeDuplicatesMsg removes duplicate requests, keeping Keep, and saves
+// the collection.
+type MergeDuplicatesMsg struct {
+	Keep   *collection.Request
+	Remove []*collection.Request
+}
+
+// ManageOAuth2TokensMsg opens the cached OAuth2 token picker.
+type ManageOAuth2TokensMsg struct{}
+
+// InvalidateOAuth2TokenMsg drops a cached OAuth2 token, or all of them.
+type InvalidateOAuth2TokenMsg struct {
+	Key string
+	All bool
+}
+
+// ManageResponseCacheMsg opens the response cache picker.
+type ManageResponseCacheMsg struct{}
+
+// ToggleRecordingMsg starts recording sent requests and their responses
+// to a HAR file, or stops the recording in progress.
+type ToggleRecordingMsg struct{}
+
+// RecordingFailedMsg reports that an exchange couldn't be written to the
+// session recording at Path.
+type RecordingFailedMsg struct {
+	Path string
+	Err  error
+}
+
+// ForgetCachedResponseMsg drops a cached response, or all of them, so the
+// next send fetches it in full.
+type ForgetCachedResponseMsg struct {
+	Key string
+	All bool
 }
 
 // OAuth2BrowserMsg requests opening the browser for OAuth2 auth code flow.
@@ -198,6 +498,12 @@ type SetBaselineMsg struct{}
 // ClearBaselineMsg removes the saved diff baseline.
 type ClearBaselineMsg struct{}
 
+// PinResponseMsg keeps the current response beside the next ones for a
+// side-by-side comparison, or with Unpin closes it.
+type PinResponseMsg struct {
+	Unpin bool
+}
+
 // --- Phase 4: Multi-Protocol ---
 
 // SwitchProtocolMsg requests switching the editor protocol form.
@@ -208,10 +514,19 @@ type SwitchProtocolMsg struct {
 // IntrospectMsg triggers GraphQL introspection.
 type IntrospectMsg struct{}
 
-// IntrospectionResultMsg carries GraphQL introspection results.
+// IntrospectionResultMsg carries GraphQL introspection results. Endpoint is
+// the unresolved URL the schema is stored under.
 type IntrospectionResultMsg struct {
-	Types []SchemaType
-	Err   error
+	Endpoint string
+	Types    []SchemaType
+	Schema   *graphql.SDLSchema
+	Err      error
+}
+
+// ScaffoldGraphQLMsg scaffolds a GraphQL request from a local schema.graphql.
+// An empty Operation opens the operation picker.
+type ScaffoldGraphQLMsg struct {
+	Operation string // e.g. "query users"
 }
 
 // SchemaType is a simplified GraphQL type for display.
@@ -256,6 +571,38 @@ type WSMessageReceivedMsg struct {
 	Timestamp time.Time
 }
 
+// WSEventKind identifies a WebSocket session event.
+type WSEventKind int
+
+const (
+	WSEventSent WSEventKind = iota
+	WSEventReceived
+	WSEventAssertion
+	WSEventScriptDone
+	WSEventError
+	WSEventReconnecting
+	WSEventReconnected
+)
+
+// WSEventMsg reports activity on the live WebSocket session: scripted or
+// manual sends, received messages, expectation results, reconnects and
+// errors.
+type WSEventMsg struct {
+	Kind      WSEventKind
+	Name      string
+	Content   string
+	IsJSON    bool
+	Passed    bool
+	Err       error
+	Timestamp time.Time
+}
+
+// WSEventBatchMsg carries the session events that arrived within one
+// frame, so a busy stream redraws the log at a bounded rate.
+type WSEventBatchMsg struct {
+	Events []WSEventMsg
+}
+
 // --- Phase 6: gRPC ---
 
 // GRPCReflectMsg triggers gRPC server reflection.
@@ -279,13 +626,16 @@ type GRPCMethodInfo struct {
 	FullName       string
 	InputType      string
 	OutputType     string
+	InputTemplate  string // JSON skeleton of the request message
+	OutputTemplate string // JSON skeleton of the response message
 	IsClientStream bool
 	IsServerStream bool
 }
 
 // --- Code Generation ---
 
-// GenerateCodeMsg triggers code generation for the current request.
+// GenerateCodeMsg opens the code generation overlay for the current
+// request. Language selects the language shown first; empty starts at Go.
 type GenerateCodeMsg struct {
 	Language string // go, python, javascript, curl, ruby, java, rust, php
 }
@@ -0,0 +1,98 @@
+package editor
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/ui/msgs"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// DocsSection lists a request's documentation links. Links are edited in
+// the collection file; here they are only opened.
+type DocsSection struct {
+	links  []collection.Link
+	cursor int
+	width  int
+	styles theme.Styles
+}
+
+// NewDocsSection creates an empty DocsSection.
+func NewDocsSection(styles theme.Styles) DocsSection {
+	return DocsSection{styles: styles}
+}
+
+// SetSize updates the section width.
+func (m *DocsSection) SetSize(w int) {
+	m.width = w
+}
+
+// Load shows the links of a request.
+func (m *DocsSection) Load(links []collection.Link) {
+	m.links = links
+	m.cursor = 0
+}
+
+// Links returns the links shown.
+func (m DocsSection) Links() []collection.Link {
+	return m.links
+}
+
+// Update handles navigation and opening links.
+func (m DocsSection) Update(msg tea.Msg) (DocsSection, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok || len(m.links) == 0 {
+		return m, nil
+	}
+	switch key.String() {
+	case "j", "down":
+		m.cursor = min(m.cursor+1, len(m.links)-1)
+	case "k", "up":
+		m.cursor = max(m.cursor-1, 0)
+	case "enter", "o":
+		url := m.links[m.cursor].URL
+		if url == "" {
+			return m, nil
+		}
+		return m, func() tea.Msg { return msgs.OpenURLMsg{URL: url} }
+	}
+	return m, nil
+}
+
+// View renders the links, one per line.
+func (m DocsSection) View() string {
+	if len(m.links) == 0 {
+		return m.styles.Hint.Render("No links. Add a links: list (name and url) to the request in the collection file.")
+	}
+	var lines []string
+	for i, link := range m.links {
+		name := link.Name
+		if name == "" {
+			name = link.URL
+		}
+		prefix := "  "
+		style := m.styles.Normal
+		if i == m.cursor {
+			prefix = "> "
+			style = m.styles.Cursor
+		}
+		line := prefix + style.Render(name)
+		if link.Name != "" {
+			line += "  " + m.styles.Muted.Render(truncateURL(link.URL, m.width-len(name)-6))
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", m.styles.Hint.Render("j/k: select · enter/o: open in browser · D: back to the request"))
+	return strings.Join(lines, "\n")
+}
+
+// truncateURL shortens url to at most n runes.
+func truncateURL(url string, n int) string {
+	r := []rune(url)
+	if n < 2 || len(r) <= n {
+		return url
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -23,6 +23,13 @@ type Model struct {
 	protocol         string // "http", "graphql", "websocket", "grpc"
 	protoFocused     bool   // whether protocol selector has focus
 
+	docs     DocsSection
+	showDocs bool // the Docs view replaces the form
+
+	emptyVars  []string // referenced variables empty in the active environment
+	emptyEnv   string
+	unresolved []string // URL and header variables not defined in any scope
+
 	focused bool
 	width   int
 	height  int
@@ -36,6 +43,7 @@ func New(t theme.Theme, styles theme.Styles) Model {
 		graphqlForm:      NewGraphQLForm(styles),
 		wsForm:           NewWebSocketForm(styles),
 		grpcForm:         NewGRPCForm(styles),
+		docs:             NewDocsSection(styles),
 		protocolSelector: NewProtocolSelector(t, styles),
 		protocol:         "http",
 		styles:           styles,
@@ -49,6 +57,20 @@ func (m *Model) SetFocused(focused bool) {
 	m.focused = focused
 }
 
+// SetEmptyVars flags variables the request uses that are empty or
+// placeholders in env; they are shown in place of the send hint.
+func (m *Model) SetEmptyVars(env string, names []string) {
+	m.emptyEnv = env
+	m.emptyVars = names
+}
+
+// SetUnresolvedVars flags variables in the URL and headers that no scope
+// defines; they are highlighted and listed in place of the send hint.
+func (m *Model) SetUnresolvedVars(names []string) {
+	m.unresolved = names
+	m.httpForm.SetUnresolved(names)
+}
+
 // SetSize sets the panel dimensions.
 func (m *Model) SetSize(w, h int) {
 	m.width = w
@@ -66,6 +88,7 @@ func (m *Model) SetSize(w, h int) {
 	m.graphqlForm.SetSize(innerW, innerH)
 	m.wsForm.SetSize(innerW, innerH)
 	m.grpcForm.SetSize(innerW, innerH)
+	m.docs.SetSize(innerW)
 }
 
 // Protocol returns the current protocol.
@@ -81,6 +104,9 @@ func (m *Model) SetProtocol(proto string) {
 
 // Editing returns whether the editor has an active text input.
 func (m Model) Editing() bool {
+	if m.showDocs {
+		return false
+	}
 	switch m.protocol {
 	case "graphql":
 		return m.graphqlForm.Editing()
@@ -200,6 +226,7 @@ func (m Model) BuildAuth() *protocol.AuthConfig {
 
 // FocusURL focuses the URL input on the active form.
 func (m *Model) FocusURL() {
+	m.showDocs = false
 	switch m.protocol {
 	case "graphql":
 		m.graphqlForm.FocusURL()
@@ -226,6 +253,7 @@ func (m *Model) LoadRequest(req *collection.Request) {
 
 	m.protocol = proto
 	m.protocolSelector.SetProtocol(proto)
+	m.docs.Load(req.Links)
 
 	switch proto {
 	case "graphql":
@@ -266,6 +294,20 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			m.protocol = m.protocolSelector.Current()
 			return m, nil
 		}
+
+		if msg.String() == "D" && !m.Editing() {
+			m.showDocs = !m.showDocs
+			return m, nil
+		}
+		if m.showDocs {
+			if msg.String() == "esc" {
+				m.showDocs = false
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.docs, cmd = m.docs.Update(msg)
+			return m, cmd
+		}
 	}
 
 	// Delegate to active form
@@ -293,6 +335,21 @@ func (m Model) View() string {
 	// Protocol selector line
 	protoView := m.protocolSelector.View(m.protoFocused)
 	sendHint := m.styles.Hint.Render("ctrl+enter to send  ctrl+p protocol")
+	var warnings []string
+	if len(m.unresolved) > 0 {
+		warnings = append(warnings, "undefined: "+varRefs(m.unresolved))
+	}
+	if len(m.emptyVars) > 0 {
+		warnings = append(warnings, "empty in "+m.emptyEnv+": "+varRefs(m.emptyVars))
+	}
+	if len(warnings) > 0 {
+		warning := "⚠ " + strings.Join(warnings, " · ")
+		maxW := max(innerW-lipgloss.Width(protoView)-1, 10)
+		if lipgloss.Width(warning) > maxW {
+			warning = string([]rune(warning)[:maxW-1]) + "…"
+		}
+		sendHint = m.styles.Error.Render(warning)
+	}
 
 	protoLineLen := lipgloss.Width(protoView)
 	hintLen := lipgloss.Width(sendHint)
@@ -314,6 +371,9 @@ func (m Model) View() string {
 	default:
 		formView = m.httpForm.View()
 	}
+	if m.showDocs {
+		formView = m.styles.TabActive.Render("Docs") + "\n\n" + m.docs.View()
+	}
 
 	content := protoLine + "\n" + formView
 
@@ -328,3 +388,12 @@ func (m Model) View() string {
 
 	return borderStyle.Render(content)
 }
+
+// varRefs formats variable names as {{name}} references.
+func varRefs(names []string) string {
+	refs := make([]string, len(names))
+	for i, name := range names {
+		refs[i] = "{{" + name + "}}"
+	}
+	return strings.Join(refs, " ")
+}
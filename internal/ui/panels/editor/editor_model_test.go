@@ -1,10 +1,15 @@
 package editor
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/sadopc/gottp/internal/core/collection"
+	gotls "github.com/sadopc/gottp/internal/core/tls"
+	"github.com/sadopc/gottp/internal/protocol"
+	"github.com/sadopc/gottp/internal/protocol/graphql"
 	"github.com/sadopc/gottp/internal/ui/msgs"
 	"github.com/sadopc/gottp/internal/ui/theme"
 )
@@ -91,6 +96,34 @@ func TestEditorModel_LoadRequestDetectsProtocol(t *testing.T) {
 	}
 }
 
+func TestEditorModel_LoadRequestClearsPrevious(t *testing.T) {
+	m := newEditorModelForTest()
+
+	full := collection.NewRequest("Create", "POST", "https://example.com/users")
+	full.Params = []collection.KVPair{{Key: "page", Value: "2", Enabled: true}}
+	full.Headers = []collection.KVPair{{Key: "X-Trace", Value: "1", Enabled: true}}
+	full.Body = &collection.Body{Type: "json", Content: `{"name":"a"}`}
+	m.LoadRequest(full)
+
+	m.LoadRequest(collection.NewRequest("List", "GET", "https://example.com/users"))
+	if got := m.GetBodyContent(); got != "" {
+		t.Errorf("body carried over: %q", got)
+	}
+	for _, p := range m.GetParams() {
+		if p.Key != "" {
+			t.Errorf("param carried over: %+v", p)
+		}
+	}
+	for _, h := range m.GetHeaders() {
+		if h.Key == "X-Trace" {
+			t.Error("header carried over")
+		}
+	}
+	if fresh := newEditorModelForTest(); len(m.GetHeaders()) != len(fresh.GetHeaders()) {
+		t.Errorf("a request without headers should show the defaults, got %+v", m.GetHeaders())
+	}
+}
+
 func TestEditorModel_UpdateSwitchProtocolAndCycle(t *testing.T) {
 	m := newEditorModelForTest()
 
@@ -126,3 +159,382 @@ func TestEditorModel_UpdateCtrlEnterCommandAndView(t *testing.T) {
 		t.Fatal("expected non-empty editor view")
 	}
 }
+
+func TestHTTPForm_OptionsToggleAndLoad(t *testing.T) {
+	th := theme.Resolve("catppuccin-mocha")
+	f := NewHTTPForm(theme.NewStyles(th))
+	f.SetSize(80, 20)
+
+	key := func(s string) tea.KeyMsg {
+		if s == "enter" {
+			return tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+
+	// Focus the sub-tab content and open Options
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	f, _ = f.Update(key("5"))
+	if f.activeTab != TabOptions {
+		t.Fatalf("active tab = %d, want Options", f.activeTab)
+	}
+	f, _ = f.Update(key("enter"))
+	f, _ = f.Update(key("j"))
+	f, _ = f.Update(key("enter"))
+
+	req := f.BuildRequest()
+	if !req.ExpectContinue || !req.Chunked {
+		t.Fatalf("expected both options on, got expect=%v chunked=%v", req.ExpectContinue, req.Chunked)
+	}
+
+	colReq := collection.NewRequest("Upload", "POST", "https://example.com")
+	colReq.Chunked = true
+	f.LoadRequest(colReq)
+	req = f.BuildRequest()
+	if req.ExpectContinue || !req.Chunked {
+		t.Fatalf("expected options loaded from request, got expect=%v chunked=%v", req.ExpectContinue, req.Chunked)
+	}
+}
+
+func TestHTTPForm_Cookies(t *testing.T) {
+	th := theme.Resolve("catppuccin-mocha")
+	f := NewHTTPForm(theme.NewStyles(th))
+	f.SetSize(80, 20)
+
+	colReq := collection.NewRequest("Profile", "GET", "https://example.com")
+	colReq.Cookies = []collection.KVPair{
+		{Key: "session", Value: "abc", Enabled: true},
+		{Key: "debug", Value: "1", Enabled: false},
+	}
+	f.LoadRequest(colReq)
+
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("7")})
+	if f.activeTab != TabCookies || !strings.Contains(f.View(), "session") {
+		t.Fatalf("expected the Cookies tab listing the request's cookies:\n%s", f.View())
+	}
+	if got := f.GetCookies(); len(got) != 2 || got[1].Enabled {
+		t.Errorf("GetCookies = %+v", got)
+	}
+	req := f.BuildRequest()
+	if len(req.Cookies) != 1 || req.Cookies[0] != (protocol.Cookie{Name: "session", Value: "abc"}) {
+		t.Errorf("BuildRequest cookies = %+v, want only the enabled one", req.Cookies)
+	}
+
+	f.LoadRequest(collection.NewRequest("Other", "GET", "https://example.com"))
+	if got := f.GetCookies(); len(got) != 1 || got[0].Key != "" {
+		t.Error("cookies should not carry over to another request")
+	}
+}
+
+func TestHTTPForm_TLSSection(t *testing.T) {
+	th := theme.Resolve("catppuccin-mocha")
+	f := NewHTTPForm(theme.NewStyles(th))
+	f.SetSize(80, 20)
+
+	key := func(s string) tea.KeyMsg {
+		if s == "enter" {
+			return tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+
+	if f.BuildRequest().TLS != nil {
+		t.Fatal("expected no TLS settings by default")
+	}
+
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	f, _ = f.Update(key("6"))
+	if f.activeTab != TabTLS {
+		t.Fatalf("active tab = %d, want TLS", f.activeTab)
+	}
+	// Type a client certificate path
+	f, _ = f.Update(key("enter"))
+	if !f.Editing() {
+		t.Fatal("expected the cert field to be edited")
+	}
+	f, _ = f.Update(key("client.pem"))
+	f, _ = f.Update(key("enter"))
+	// Min version: default -> 1.0 -> 1.1 -> 1.2
+	f, _ = f.Update(key("j"))
+	f, _ = f.Update(key("j"))
+	f, _ = f.Update(key("j"))
+	for i := 0; i < 3; i++ {
+		f, _ = f.Update(key("enter"))
+	}
+	f, _ = f.Update(key("j"))
+	f, _ = f.Update(key("enter"))
+
+	got := f.BuildRequest().TLS
+	if got == nil || got.CertFile != "client.pem" || got.MinVersion != "1.2" || !got.InsecureSkipVerify {
+		t.Fatalf("unexpected TLS settings: %+v", got)
+	}
+	if !strings.Contains(f.View(), "off (insecure)") {
+		t.Error("view should flag disabled verification")
+	}
+
+	colReq := collection.NewRequest("mTLS", "GET", "https://example.com")
+	colReq.TLS = &gotls.Config{CAFile: "ca.pem", MinVersion: "1.3"}
+	f.LoadRequest(colReq)
+	got = f.BuildRequest().TLS
+	if got == nil || got.CAFile != "ca.pem" || got.CertFile != "" || got.MinVersion != "1.3" || got.InsecureSkipVerify {
+		t.Fatalf("expected TLS settings loaded from request, got %+v", got)
+	}
+}
+
+func TestHTTPForm_BodyTypes(t *testing.T) {
+	th := theme.Resolve("catppuccin-mocha")
+	f := NewHTTPForm(theme.NewStyles(th))
+	f.SetSize(80, 20)
+
+	colReq := collection.NewRequest("Upload", "POST", "https://example.com")
+	colReq.Body = &collection.Body{Type: collection.BodyMultipart, Fields: []collection.BodyField{
+		{Key: "title", Value: "cat", Enabled: true},
+		{Key: "photo", File: "cat.png", Enabled: true},
+	}}
+	f.LoadRequest(colReq)
+
+	req := f.BuildRequest()
+	if !req.Multipart || len(req.Form) != 2 || req.Form[1].File != "cat.png" || len(req.Body) != 0 {
+		t.Fatalf("unexpected multipart request: %+v", req)
+	}
+	body := f.BuildBody()
+	if body.Type != collection.BodyMultipart || body.Fields[1].File != "cat.png" || body.Fields[1].Value != "" {
+		t.Fatalf("unexpected saved body: %+v", body)
+	}
+
+	// "t" in the Body tab cycles to the next type
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyTab})
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("4")})
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if f.BodyType() != collection.BodyBinaryFile {
+		t.Fatalf("body type = %q, want binary-file", f.BodyType())
+	}
+	if f.BuildBody() != nil {
+		t.Error("binary-file body without a path should be empty")
+	}
+}
+
+func TestGraphQLForm_SchemaValidationAndCompletion(t *testing.T) {
+	schema, err := graphql.ParseSDL(`
+type Query { user(id: ID!): User }
+type User { id: ID! name: String email: String }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	styles := theme.NewStyles(theme.Resolve("catppuccin-mocha"))
+	f := NewGraphQLForm(styles)
+	f.SetSize(80, 20)
+	req := collection.NewRequest("User", "POST", "{{base}}/graphql")
+	req.GraphQL = &collection.GraphQLConfig{Query: `{ user(id: 1) { nme } }`}
+	f.LoadRequest(req)
+	if len(f.QueryErrors()) != 0 {
+		t.Fatal("no schema yet, the query should not be checked")
+	}
+
+	f.SetSchema("{{base}}/graphql", schema)
+	errs := f.QueryErrors()
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, `"nme"`) {
+		t.Fatalf("QueryErrors() = %v", errs)
+	}
+	if view := f.View(); !strings.Contains(view, `1:17 Cannot query field "nme"`) {
+		t.Errorf("view should show the schema error:\n%s", view)
+	}
+
+	// Retype the query: "n" offers name, tab accepts it
+	f.SetBody("")
+	f.focusField = 1
+	f.query.Focus()
+	for _, r := range "{ user(id: 1) { n" {
+		f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if c := f.Completions(); len(c) != 1 || c[0].Label != "name" {
+		t.Fatalf("Completions() = %v", c)
+	}
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyTab})
+	for _, r := range " } }" {
+		f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if got := f.GetBodyContent(); got != "{ user(id: 1) { name } }" {
+		t.Errorf("query = %q", got)
+	}
+	if len(f.QueryErrors()) != 0 {
+		t.Errorf("completed query should be valid, got %v", f.QueryErrors())
+	}
+
+	// ctrl+space lists every field; another endpoint has no schema
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyCtrlAt})
+	if len(f.Completions()) == 0 {
+		t.Error("ctrl+space should list completions")
+	}
+	f.url.SetValue("https://other.example.com/graphql")
+	f.validate()
+	if f.Schema() != nil {
+		t.Error("schema should be per endpoint")
+	}
+}
+
+func TestGraphQLForm_PersistedQueryOption(t *testing.T) {
+	styles := theme.NewStyles(theme.Resolve("catppuccin-mocha"))
+	f := NewGraphQLForm(styles)
+	f.SetSize(80, 20)
+	req := collection.NewRequest("Users", "POST", "https://api.example.com/graphql")
+	req.GraphQL = &collection.GraphQLConfig{Query: `{ users { id } }`, PersistedQuery: true}
+	f.LoadRequest(req)
+	if !f.BuildRequest().GraphQLPersisted {
+		t.Fatal("persisted_query should load into the Options tab")
+	}
+
+	// Toggle it off from the Options tab
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyTab})
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'5'}})
+	if !strings.Contains(f.View(), "[x] Persisted query (APQ)") {
+		t.Errorf("Options tab should show the toggle:\n%s", f.View())
+	}
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if f.BuildRequest().GraphQLPersisted {
+		t.Error("enter should toggle persisted queries off")
+	}
+}
+
+func TestGRPCForm_CallOptions(t *testing.T) {
+	styles := theme.NewStyles(theme.Resolve("catppuccin-mocha"))
+	f := NewGRPCForm(styles)
+	f.SetSize(80, 20)
+	req := collection.NewRequest("Check", "POST", "localhost:50051")
+	req.GRPC = &collection.GRPCConfig{
+		Service:        "grpc.health.v1.Health",
+		Method:         "Check",
+		Metadata:       []collection.KVPair{{Key: "x-tenant", Value: "acme", Enabled: true}},
+		Deadline:       5 * time.Second,
+		Gzip:           true,
+		MaxRecvMsgSize: 1024,
+		Transport:      "connect",
+	}
+	f.LoadRequest(req)
+	built := f.BuildRequest()
+	if built.GRPCTransport != "connect" {
+		t.Errorf("GRPCTransport = %q, want connect", built.GRPCTransport)
+	}
+	want := protocol.GRPCCallOptions{Deadline: 5 * time.Second, Gzip: true, MaxRecvMsgSize: 1024}
+	if built.GRPCCall != want {
+		t.Errorf("GRPCCall = %+v, want %+v", built.GRPCCall, want)
+	}
+	if built.Metadata["x-tenant"] != "acme" {
+		t.Errorf("metadata should load from grpc.metadata, got %v", built.Metadata)
+	}
+
+	// Edit the deadline from the Options tab
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyTab})
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'5'}})
+	if !strings.Contains(f.View(), "Deadline: 5s") || !strings.Contains(f.View(), "[x] Gzip compression") {
+		t.Errorf("Options tab should show the call options:\n%s", f.View())
+	}
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !f.Editing() {
+		t.Fatal("enter on a value should start editing it")
+	}
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyBackspace}, {Type: tea.KeyBackspace}, {Type: tea.KeyRunes, Runes: []rune("750ms")}, {Type: tea.KeyEnter}} {
+		f, _ = f.Update(key)
+	}
+	if f.Editing() || f.BuildRequest().GRPCCall.Deadline != 750*time.Millisecond {
+		t.Errorf("deadline = %v, editing %v", f.BuildRequest().GRPCCall.Deadline, f.Editing())
+	}
+}
+
+func TestGRPCForm_ReflectionBrowser(t *testing.T) {
+	styles := theme.NewStyles(theme.Resolve("catppuccin-mocha"))
+	f := NewGRPCForm(styles)
+	f.SetSize(100, 30)
+	req := collection.NewRequest("Goodbye", "POST", "localhost:50051")
+	req.GRPC = &collection.GRPCConfig{Service: "greet.Greeter", Method: "SayGoodbye"}
+	f.LoadRequest(req)
+
+	f.SetServices([]msgs.GRPCServiceInfo{{Name: "greet.Greeter", Methods: []msgs.GRPCMethodInfo{
+		{Name: "SayHello", InputType: "greet.HelloRequest", InputTemplate: `{"name": ""}`, OutputType: "greet.HelloReply", OutputTemplate: `{"message": ""}`},
+		{Name: "SayGoodbye", InputType: "greet.GoodbyeRequest", InputTemplate: `{"name": "", "reason": ""}`, IsServerStream: true},
+	}}})
+	if got := f.BuildRequest().GRPCMethod; got != "SayGoodbye" {
+		t.Fatalf("reflection should keep the request's method, got %s", got)
+	}
+
+	// The cursor starts on the request's method; browsing shows schemas
+	// without changing the request
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyTab})
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	view := f.View()
+	for _, want := range []string{"> SayHello", "• SayGoodbye [Server Streaming]", "Request: greet.HelloRequest", `{"message": ""}`} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	if f.BuildRequest().GRPCMethod != "SayGoodbye" {
+		t.Error("browsing should not change the method")
+	}
+
+	// Enter picks the method and fills in its skeleton
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	built := f.BuildRequest()
+	if built.GRPCMethod != "SayHello" || string(built.Body) != `{"name": ""}` || f.activeTab != GRPCTabRequest {
+		t.Errorf("after enter: method %s, body %q, tab %d", built.GRPCMethod, built.Body, f.activeTab)
+	}
+
+	// An untouched skeleton is replaced; a hand-written body is kept
+	f.activeTab = GRPCTabService
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := f.GetBodyContent(); got != `{"name": "", "reason": ""}` {
+		t.Errorf("skeleton should follow the method, got %q", got)
+	}
+	f.SetBody(`{"name": "Ada"}`)
+	f.activeTab = GRPCTabService
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := f.GetBodyContent(); got != `{"name": "Ada"}` || f.BuildRequest().GRPCMethod != "SayHello" {
+		t.Errorf("hand-written body should be kept, got %q", got)
+	}
+
+	// r runs reflection from the Service tab
+	f.activeTab = GRPCTabService
+	if _, cmd := f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}}); cmd == nil {
+		t.Error("r should trigger reflection")
+	} else if _, ok := cmd().(msgs.GRPCReflectMsg); !ok {
+		t.Errorf("unexpected message %#v", cmd())
+	}
+}
+
+func TestEditor_DocsLinks(t *testing.T) {
+	m := newEditorModelForTest()
+	m.SetSize(100, 30)
+	req := collection.NewRequest("Users", "GET", "https://api.example.com/users")
+	req.Links = []collection.Link{
+		{Name: "API reference", URL: "https://docs.example.com/users"},
+		{Name: "Dashboard", URL: "{{grafana}}/d/users"},
+	}
+	m.LoadRequest(req)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
+	view := m.View()
+	for _, want := range []string{"Docs", "> API reference", "https://docs.example.com/users", "Dashboard"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("docs view missing %q:\n%s", want, view)
+		}
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	if cmd == nil {
+		t.Fatal("o should open the selected link")
+	}
+	if msg, ok := cmd().(msgs.OpenURLMsg); !ok || msg.URL != "{{grafana}}/d/users" {
+		t.Errorf("unexpected message %#v", cmd())
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if strings.Contains(m.View(), "Dashboard") {
+		t.Error("esc should return to the request form")
+	}
+}
@@ -1,6 +1,8 @@
 package editor
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textarea"
@@ -8,6 +10,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/protocol"
+	"github.com/sadopc/gottp/internal/protocol/graphql"
 	"github.com/sadopc/gottp/internal/ui/components"
 	"github.com/sadopc/gottp/internal/ui/theme"
 )
@@ -20,9 +23,10 @@ const (
 	GQLTabVariables
 	GQLTabHeaders
 	GQLTabAuth
+	GQLTabOptions
 )
 
-var gqlSubTabNames = []string{"Query", "Variables", "Headers", "Auth"}
+var gqlSubTabNames = []string{"Query", "Variables", "Headers", "Auth", "Options"}
 
 // GraphQLForm is the GraphQL request form component.
 type GraphQLForm struct {
@@ -31,13 +35,26 @@ type GraphQLForm struct {
 	variables textarea.Model
 	headers   components.KVTable
 	auth      AuthSection
+	options   OptionsSection
 
 	activeTab  GQLSubTab
 	focusField int // 0=url, 1=sub-tab content
 
-	width  int
-	height int
-	styles theme.Styles
+	// Introspected schemas by endpoint URL, and what they say about the query
+	schemas          map[string]*graphql.SDLSchema
+	queryErrors      []graphql.QueryError
+	completions      []graphql.Completion
+	completionPrefix string
+	completionIdx    int
+
+	width      int
+	height     int
+	bodyHeight int
+	styles     theme.Styles
+}
+
+func defaultGraphQLHeaders() []components.KVPair {
+	return []components.KVPair{{Key: "Content-Type", Value: "application/json", Enabled: true}}
 }
 
 // NewGraphQLForm creates a new GraphQL form.
@@ -62,20 +79,20 @@ func NewGraphQLForm(styles theme.Styles) GraphQLForm {
 	varsArea.SetHeight(6)
 
 	headers := components.NewKVTable(styles)
-	headers.SetPairs([]components.KVPair{
-		{Key: "Content-Type", Value: "application/json", Enabled: true},
-	})
+	headers.SetPairs(defaultGraphQLHeaders())
 
 	return GraphQLForm{
-		url:       urlInput,
-		query:     queryArea,
-		variables: varsArea,
-		headers:   headers,
-		auth:      NewAuthSection(styles),
-		activeTab: GQLTabQuery,
-		styles:    styles,
-		width:     60,
-		height:    20,
+		url:        urlInput,
+		query:      queryArea,
+		variables:  varsArea,
+		headers:    headers,
+		auth:       NewAuthSection(styles),
+		options:    NewGraphQLOptionsSection(styles),
+		activeTab:  GQLTabQuery,
+		styles:     styles,
+		width:      60,
+		height:     20,
+		bodyHeight: 8,
 	}
 }
 
@@ -96,17 +113,99 @@ func (m *GraphQLForm) SetSize(w, h int) {
 	}
 	m.headers.SetSize(contentW)
 	m.auth.SetSize(contentW)
+	m.options.SetSize(contentW)
 
 	bodyH := h - 6
 	if bodyH < 3 {
 		bodyH = 3
 	}
+	m.bodyHeight = bodyH
 	m.query.SetWidth(contentW)
-	m.query.SetHeight(bodyH)
+	m.resizeQuery()
 	m.variables.SetWidth(contentW)
 	m.variables.SetHeight(bodyH)
 }
 
+// SetSchema stores the schema introspected from endpoint. While the form
+// shows that endpoint, the query is validated against it and completion
+// suggests fields, arguments and values.
+func (m *GraphQLForm) SetSchema(endpoint string, schema *graphql.SDLSchema) {
+	if m.schemas == nil {
+		m.schemas = make(map[string]*graphql.SDLSchema)
+	}
+	m.schemas[strings.TrimSpace(endpoint)] = schema
+	m.validate()
+}
+
+// Schema returns the schema introspected from the current endpoint, or nil.
+func (m GraphQLForm) Schema() *graphql.SDLSchema {
+	return m.schemas[strings.TrimSpace(m.url.Value())]
+}
+
+// QueryErrors returns the schema errors in the current query.
+func (m GraphQLForm) QueryErrors() []graphql.QueryError {
+	return m.queryErrors
+}
+
+// Completions returns the suggestions for the name at the cursor.
+func (m GraphQLForm) Completions() []graphql.Completion {
+	return m.completions
+}
+
+func (m *GraphQLForm) validate() {
+	m.queryErrors = nil
+	if schema := m.Schema(); schema != nil && strings.TrimSpace(m.query.Value()) != "" {
+		m.queryErrors = schema.ValidateQuery(m.query.Value())
+	}
+	m.resizeQuery()
+}
+
+// resizeQuery leaves a line under the query for schema status when the
+// endpoint has a schema.
+func (m *GraphQLForm) resizeQuery() {
+	h := m.bodyHeight
+	if m.Schema() != nil {
+		h--
+	}
+	h = max(h, 3)
+	if m.query.Height() != h {
+		m.query.SetHeight(h)
+	}
+}
+
+// updateCompletions refreshes the suggestions for the name at the cursor.
+// They show once a name is started, or on demand with force.
+func (m *GraphQLForm) updateCompletions(force bool) {
+	m.completions, m.completionIdx = nil, 0
+	schema := m.Schema()
+	if schema == nil {
+		return
+	}
+	prefix, items := schema.Complete(m.query.Value(), m.queryOffset())
+	if prefix == "" && !force {
+		return
+	}
+	m.completionPrefix, m.completions = prefix, items
+}
+
+// queryOffset returns the cursor position in the query, in runes.
+func (m GraphQLForm) queryOffset() int {
+	lines := strings.Split(m.query.Value(), "\n")
+	offset := 0
+	for i := 0; i < m.query.Line() && i < len(lines); i++ {
+		offset += len([]rune(lines[i])) + 1
+	}
+	info := m.query.LineInfo()
+	return offset + info.StartColumn + info.ColumnOffset
+}
+
+func (m *GraphQLForm) acceptCompletion() {
+	item := m.completions[m.completionIdx]
+	m.query.InsertString(strings.TrimPrefix(item.Label, m.completionPrefix))
+	m.completions = nil
+	m.validate()
+}
+
 // FocusURL focuses the URL input.
 func (m *GraphQLForm) FocusURL() {
 	m.focusField = 0
@@ -143,6 +242,7 @@ func (m GraphQLForm) BuildRequest() *protocol.Request {
 		Headers:          make(map[string]string),
 		GraphQLQuery:     strings.TrimSpace(m.query.Value()),
 		GraphQLVariables: strings.TrimSpace(m.variables.Value()),
+		GraphQLPersisted: m.options.PersistedQuery(),
 	}
 
 	for _, h := range m.headers.GetPairs() {
@@ -173,6 +273,7 @@ func (m GraphQLForm) GetBodyContent() string {
 // SetBody sets the query text.
 func (m *GraphQLForm) SetBody(content string) {
 	m.query.SetValue(content)
+	m.validate()
 }
 
 // GetParams returns empty params (GraphQL doesn't use params).
@@ -188,6 +289,9 @@ func (m *GraphQLForm) LoadRequest(req *collection.Request) {
 		m.query.SetValue(req.GraphQL.Query)
 		m.variables.SetValue(req.GraphQL.Variables)
 	}
+	m.options.LoadGraphQL(req.GraphQL != nil && req.GraphQL.PersistedQuery)
+	m.completions = nil
+	m.validate()
 
 	if len(req.Headers) > 0 {
 		kvPairs := make([]components.KVPair, len(req.Headers))
@@ -195,6 +299,8 @@ func (m *GraphQLForm) LoadRequest(req *collection.Request) {
 			kvPairs[i] = components.KVPair{Key: h.Key, Value: h.Value, Enabled: h.Enabled}
 		}
 		m.headers.SetPairs(kvPairs)
+	} else {
+		m.headers.SetPairs(defaultGraphQLHeaders())
 	}
 
 	m.auth.LoadAuth(req.Auth)
@@ -247,7 +353,7 @@ func (m GraphQLForm) updateNormal(msg tea.KeyMsg) (GraphQLForm, tea.Cmd) {
 			m.activeTab--
 		}
 	case "l", "right":
-		if m.focusField == 1 && m.activeTab < GQLTabAuth {
+		if m.focusField == 1 && m.activeTab < GQLTabOptions {
 			m.activeTab++
 		}
 	case "1":
@@ -258,6 +364,8 @@ func (m GraphQLForm) updateNormal(msg tea.KeyMsg) (GraphQLForm, tea.Cmd) {
 		m.activeTab = GQLTabHeaders
 	case "4":
 		m.activeTab = GQLTabAuth
+	case "5":
+		m.activeTab = GQLTabOptions
 	default:
 		if m.focusField == 1 {
 			return m.updateTabContent(msg)
@@ -274,19 +382,15 @@ func (m GraphQLForm) updateEditing(msg tea.KeyMsg) (GraphQLForm, tea.Cmd) {
 		}
 		var cmd tea.Cmd
 		m.url, cmd = m.url.Update(msg)
+		// The endpoint picks the schema
+		m.validate()
 		return m, cmd
 	}
 
 	if m.focusField == 1 {
 		switch m.activeTab {
 		case GQLTabQuery:
-			if msg.String() == "esc" {
-				m.query.Blur()
-				return m, nil
-			}
-			var cmd tea.Cmd
-			m.query, cmd = m.query.Update(msg)
-			return m, cmd
+			return m.updateQuery(msg)
 		case GQLTabVariables:
 			if msg.String() == "esc" {
 				m.variables.Blur()
@@ -314,6 +418,47 @@ func (m GraphQLForm) updateEditing(msg tea.KeyMsg) (GraphQLForm, tea.Cmd) {
 	return m, nil
 }
 
+// updateQuery edits the query, driving the completion list: tab accepts
+// the selected suggestion, ctrl+n/ctrl+p move through them, esc dismisses
+// them and ctrl+space asks for suggestions without typing.
+func (m GraphQLForm) updateQuery(msg tea.KeyMsg) (GraphQLForm, tea.Cmd) {
+	if len(m.completions) > 0 {
+		switch msg.String() {
+		case "tab":
+			m.acceptCompletion()
+			return m, nil
+		case "ctrl+n":
+			m.completionIdx = (m.completionIdx + 1) % len(m.completions)
+			return m, nil
+		case "ctrl+p":
+			m.completionIdx = (m.completionIdx + len(m.completions) - 1) % len(m.completions)
+			return m, nil
+		case "esc":
+			m.completions = nil
+			return m, nil
+		}
+	}
+	switch msg.String() {
+	case "esc":
+		m.query.Blur()
+		return m, nil
+	case "ctrl+@":
+		m.updateCompletions(true)
+		return m, nil
+	}
+
+	before := m.query.Value()
+	var cmd tea.Cmd
+	m.query, cmd = m.query.Update(msg)
+	if m.query.Value() != before {
+		m.validate()
+		m.updateCompletions(false)
+	} else {
+		m.completions = nil
+	}
+	return m, cmd
+}
+
 func (m *GraphQLForm) enterTabContent() (GraphQLForm, tea.Cmd) {
 	switch m.activeTab {
 	case GQLTabQuery:
@@ -330,6 +475,10 @@ func (m *GraphQLForm) enterTabContent() (GraphQLForm, tea.Cmd) {
 		var cmd tea.Cmd
 		m.auth, cmd = m.auth.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		return *m, cmd
+	case GQLTabOptions:
+		var cmd tea.Cmd
+		m.options, cmd = m.options.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		return *m, cmd
 	}
 	return *m, nil
 }
@@ -345,6 +494,8 @@ func (m GraphQLForm) updateTabContent(msg tea.Msg) (GraphQLForm, tea.Cmd) {
 		m.headers, cmd = m.headers.Update(msg)
 	case GQLTabAuth:
 		m.auth, cmd = m.auth.Update(msg)
+	case GQLTabOptions:
+		m.options, cmd = m.options.Update(msg)
 	}
 	return m, cmd
 }
@@ -382,14 +533,116 @@ func (m GraphQLForm) View() string {
 	// Tab content
 	switch m.activeTab {
 	case GQLTabQuery:
-		b.WriteString(m.query.View())
+		if len(m.queryErrors) > 0 && !m.query.Focused() {
+			b.WriteString(m.highlightedQuery())
+		} else {
+			b.WriteString(m.query.View())
+		}
+		if schema := m.Schema(); schema != nil {
+			b.WriteString("\n" + m.schemaStatus(schema))
+		}
 	case GQLTabVariables:
 		b.WriteString(m.variables.View())
 	case GQLTabHeaders:
 		b.WriteString(m.headers.View())
 	case GQLTabAuth:
 		b.WriteString(m.auth.View())
+	case GQLTabOptions:
+		b.WriteString(m.options.View())
 	}
 
 	return b.String()
 }
+
+// schemaStatus renders the line under the query: completions while there
+// are some, else the first schema error, else a check mark.
+func (m GraphQLForm) schemaStatus(schema *graphql.SDLSchema) string {
+	if len(m.completions) > 0 {
+		parts := make([]string, 0, len(m.completions))
+		used := 0
+		for i, c := range m.completions {
+			text := c.Label
+			if c.Detail != "" {
+				text += " " + c.Detail
+			}
+			used += len(text) + 2
+			if used > m.width && i > 0 {
+				parts = append(parts, m.styles.Muted.Render(fmt.Sprintf("+%d", len(m.completions)-i)))
+				break
+			}
+			if i == m.completionIdx {
+				parts = append(parts, m.styles.Selected.Render(text))
+			} else {
+				parts = append(parts, m.styles.Normal.Render(text))
+			}
+		}
+		return strings.Join(parts, "  ")
+	}
+	if len(m.queryErrors) > 0 {
+		e := m.queryErrors[0]
+		line := fmt.Sprintf("✗ %d:%d %s", e.Line, e.Col, e.Message)
+		if more := len(m.queryErrors) - 1; more > 0 {
+			line += fmt.Sprintf(" (+%d more)", more)
+		}
+		if r := []rune(line); len(r) > m.width && m.width > 3 {
+			line = string(r[:m.width-3]) + "..."
+		}
+		return m.styles.Error.Render(line)
+	}
+	return m.styles.Hint.Render(fmt.Sprintf("✓ valid against schema (%d types) · ctrl+space: complete", len(schema.Types)))
+}
+
+// highlightedQuery renders the unfocused query like the textarea does, with
+// the tokens that have schema errors marked. The window starts at the top,
+// or scrolls to keep the first error in view.
+func (m GraphQLForm) highlightedQuery() string {
+	style := m.query.BlurredStyle
+	lines := strings.Split(m.query.Value(), "\n")
+	height := m.query.Height()
+	start := 0
+	if first := m.queryErrors[0].Line - 1; first >= height {
+		start = first - height + 1
+	}
+	digits := len(strconv.Itoa(m.query.MaxHeight))
+	errStyle := m.styles.Error.Underline(true)
+
+	var b strings.Builder
+	for row := start; row < start+height; row++ {
+		if row > start {
+			b.WriteString("\n")
+		}
+		b.WriteString(style.Prompt.Render(m.query.Prompt))
+		if row >= len(lines) {
+			b.WriteString(style.EndOfBuffer.Render(string(m.query.EndOfBufferCharacter)))
+			continue
+		}
+		b.WriteString(style.LineNumber.Render(fmt.Sprintf(" %*d ", digits, row+1)))
+
+		text := []rune(lines[row])
+		if w := m.query.Width(); len(text) > w {
+			text = text[:w]
+		}
+		marked := make([]bool, len(text))
+		for _, e := range m.queryErrors {
+			if e.Line != row+1 {
+				continue
+			}
+			for i := e.Col - 1; i < e.Col-1+e.Len && i < len(text); i++ {
+				marked[i] = true
+			}
+		}
+		for i := 0; i < len(text); {
+			j := i
+			for j < len(text) && marked[j] == marked[i] {
+				j++
+			}
+			if marked[i] {
+				b.WriteString(errStyle.Render(string(text[i:j])))
+			} else {
+				b.WriteString(style.Text.Render(string(text[i:j])))
+			}
+			i = j
+		}
+	}
+	return b.String()
+}
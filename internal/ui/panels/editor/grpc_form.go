@@ -1,11 +1,14 @@
 package editor
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/protocol"
 	"github.com/sadopc/gottp/internal/ui/components"
@@ -21,9 +24,10 @@ const (
 	GRPCTabRequest
 	GRPCTabMetadata
 	GRPCTabAuth
+	GRPCTabOptions
 )
 
-var grpcSubTabNames = []string{"Service", "Request", "Metadata", "Auth"}
+var grpcSubTabNames = []string{"Service", "Request", "Metadata", "Auth", "Options"}
 
 // GRPCForm is the gRPC request form component.
 type GRPCForm struct {
@@ -33,10 +37,12 @@ type GRPCForm struct {
 	body     textarea.Model
 	metadata components.KVTable
 	auth     AuthSection
+	options  OptionsSection
 
 	services []msgs.GRPCServiceInfo
-	svcIdx   int
-	mtdIdx   int
+	svcIdx   int    // browse cursor in the Service tab
+	mtdIdx   int    // browse cursor in the Service tab
+	template string // skeleton body last filled in, replaced on the next pick
 
 	activeTab  GRPCSubTab
 	focusField int // 0=server, 1=sub-tab content
@@ -65,6 +71,7 @@ func NewGRPCForm(styles theme.Styles) GRPCForm {
 		body:      bodyArea,
 		metadata:  components.NewKVTable(styles),
 		auth:      NewAuthSection(styles),
+		options:   NewGRPCOptionsSection(styles),
 		activeTab: GRPCTabService,
 		styles:    styles,
 		width:     60,
@@ -87,6 +94,7 @@ func (m *GRPCForm) SetSize(w, h int) {
 	}
 	m.metadata.SetSize(contentW)
 	m.auth.SetSize(contentW)
+	m.options.SetSize(contentW)
 	bodyH := h - 6
 	if bodyH < 3 {
 		bodyH = 3
@@ -102,17 +110,58 @@ func (m *GRPCForm) FocusURL() {
 	m.server.CursorEnd()
 }
 
-// SetServices populates discovered services.
+// SetServices populates discovered services and puts the browse cursor on
+// the request's method. A form without a method picks the first one.
 func (m *GRPCForm) SetServices(services []msgs.GRPCServiceInfo) {
 	m.services = services
-	if len(services) > 0 {
-		m.svcIdx = 0
-		m.service = services[0].Name
-		if len(services[0].Methods) > 0 {
-			m.mtdIdx = 0
-			m.method = services[0].Methods[0].FullName
+	m.svcIdx, m.mtdIdx = 0, 0
+	if si, mi, ok := m.findMethod(); ok {
+		m.svcIdx, m.mtdIdx = si, mi
+		return
+	}
+	if m.method == "" {
+		m.selectMethod()
+	}
+}
+
+// findMethod locates the request's method among the discovered services.
+func (m GRPCForm) findMethod() (int, int, bool) {
+	for si, svc := range m.services {
+		if svc.Name != m.service {
+			continue
+		}
+		for mi, mtd := range svc.Methods {
+			if mtd.Name == m.method || mtd.FullName == m.method {
+				return si, mi, true
+			}
 		}
 	}
+	return 0, 0, false
+}
+
+// cursorMethod returns the method under the browse cursor.
+func (m GRPCForm) cursorMethod() (msgs.GRPCMethodInfo, bool) {
+	if m.svcIdx >= len(m.services) || m.mtdIdx >= len(m.services[m.svcIdx].Methods) {
+		return msgs.GRPCMethodInfo{}, false
+	}
+	return m.services[m.svcIdx].Methods[m.mtdIdx], true
+}
+
+// selectMethod makes the method under the cursor the request's, and fills
+// in its request skeleton unless the body has been written by hand.
+func (m *GRPCForm) selectMethod() bool {
+	mtd, ok := m.cursorMethod()
+	if !ok {
+		return false
+	}
+	m.service = m.services[m.svcIdx].Name
+	m.method = mtd.Name
+	body := strings.TrimSpace(m.body.Value())
+	if mtd.InputTemplate != "" && (body == "" || body == strings.TrimSpace(m.template)) {
+		m.body.SetValue(mtd.InputTemplate)
+		m.template = mtd.InputTemplate
+	}
+	return true
 }
 
 // Editing returns whether any input is in editing mode.
@@ -128,6 +177,8 @@ func (m GRPCForm) Editing() bool {
 			return m.metadata.Editing()
 		case GRPCTabAuth:
 			return m.auth.Editing()
+		case GRPCTabOptions:
+			return m.options.Editing()
 		}
 	}
 	return false
@@ -143,6 +194,21 @@ func (m GRPCForm) BuildRequest() *protocol.Request {
 		GRPCService: m.service,
 		GRPCMethod:  m.method,
 		Metadata:    make(map[string]string),
+		GRPCCall: protocol.GRPCCallOptions{
+			Gzip:         m.options.GRPCGzip(),
+			WaitForReady: m.options.GRPCWaitForReady(),
+		},
+		GRPCTransport: strings.ToLower(strings.TrimSpace(m.options.GRPCTransport())),
+	}
+	// Values that don't parse keep the defaults
+	if d, err := time.ParseDuration(m.options.GRPCDeadline()); err == nil && d > 0 {
+		req.GRPCCall.Deadline = d
+	}
+	if n, err := strconv.Atoi(m.options.GRPCMaxSend()); err == nil && n > 0 {
+		req.GRPCCall.MaxSendMsgSize = n
+	}
+	if n, err := strconv.Atoi(m.options.GRPCMaxRecv()); err == nil && n > 0 {
+		req.GRPCCall.MaxRecvMsgSize = n
 	}
 
 	body := strings.TrimSpace(m.body.Value())
@@ -188,13 +254,31 @@ func (m *GRPCForm) SetBody(content string) {
 // LoadRequest populates from a collection request.
 func (m *GRPCForm) LoadRequest(req *collection.Request) {
 	m.server.SetValue(req.URL)
+	metadata := req.Headers // files saved before metadata was kept under grpc:
 	if req.GRPC != nil {
 		m.service = req.GRPC.Service
 		m.method = req.GRPC.Method
+		if len(req.GRPC.Metadata) > 0 {
+			metadata = req.GRPC.Metadata
+		}
+		m.options.LoadGRPC(req.GRPC.Transport, formatDuration(req.GRPC.Deadline), formatSize(req.GRPC.MaxSendMsgSize),
+			formatSize(req.GRPC.MaxRecvMsgSize), req.GRPC.Gzip, req.GRPC.WaitForReady)
+	} else {
+		m.options.LoadGRPC("", "", "", "", false, false)
 	}
+	pairs := make([]components.KVPair, len(metadata))
+	for i, kv := range metadata {
+		pairs[i] = components.KVPair{Key: kv.Key, Value: kv.Value, Enabled: kv.Enabled}
+	}
+	m.metadata.SetPairs(pairs)
+	m.body.SetValue("")
 	if req.Body != nil {
 		m.body.SetValue(req.Body.Content)
 	}
+	m.template = ""
+	if si, mi, ok := m.findMethod(); ok {
+		m.svcIdx, m.mtdIdx = si, mi
+	}
 	m.auth.LoadAuth(req.Auth)
 	m.focusField = 0
 }
@@ -228,21 +312,28 @@ func (m GRPCForm) updateNormal(msg tea.KeyMsg) (GRPCForm, tea.Cmd) {
 	case "shift+tab":
 		m.focusField = (m.focusField + 1) % 2
 		m.syncFocus()
-	case "ctrl+i":
-		// Trigger reflection
-		return m, func() tea.Msg { return msgs.GRPCReflectMsg{} }
+	case "r":
+		if m.focusField == 1 && m.activeTab == GRPCTabService {
+			return m, func() tea.Msg { return msgs.GRPCReflectMsg{} }
+		}
 	case "enter":
 		if m.focusField == 0 {
 			m.server.Focus()
 			return m, textinput.Blink
 		}
+		if m.activeTab == GRPCTabService {
+			if m.selectMethod() {
+				m.activeTab = GRPCTabRequest
+			}
+			return m, nil
+		}
 		return m.enterTabContent()
 	case "h", "left":
 		if m.focusField == 1 && m.activeTab > GRPCTabService {
 			m.activeTab--
 		}
 	case "l", "right":
-		if m.focusField == 1 && m.activeTab < GRPCTabAuth {
+		if m.focusField == 1 && m.activeTab < GRPCTabOptions {
 			m.activeTab++
 		}
 	case "j", "down":
@@ -261,6 +352,8 @@ func (m GRPCForm) updateNormal(msg tea.KeyMsg) (GRPCForm, tea.Cmd) {
 		m.activeTab = GRPCTabMetadata
 	case "4":
 		m.activeTab = GRPCTabAuth
+	case "5":
+		m.activeTab = GRPCTabOptions
 	default:
 		if m.focusField == 1 {
 			return m.updateTabContent(msg)
@@ -273,7 +366,7 @@ func (m *GRPCForm) cycleService(dir int) {
 	if len(m.services) == 0 {
 		return
 	}
-	// Cycle through methods in current service, then move to next service
+	// Move through methods in current service, then on to the next service
 	svc := m.services[m.svcIdx]
 	m.mtdIdx += dir
 	if m.mtdIdx >= len(svc.Methods) {
@@ -286,11 +379,6 @@ func (m *GRPCForm) cycleService(dir int) {
 			m.mtdIdx = 0
 		}
 	}
-	svc = m.services[m.svcIdx]
-	m.service = svc.Name
-	if len(svc.Methods) > 0 && m.mtdIdx < len(svc.Methods) {
-		m.method = svc.Methods[m.mtdIdx].FullName
-	}
 }
 
 func (m GRPCForm) updateEditing(msg tea.KeyMsg) (GRPCForm, tea.Cmd) {
@@ -327,6 +415,10 @@ func (m GRPCForm) updateEditing(msg tea.KeyMsg) (GRPCForm, tea.Cmd) {
 			var cmd tea.Cmd
 			m.auth, cmd = m.auth.Update(msg)
 			return m, cmd
+		case GRPCTabOptions:
+			var cmd tea.Cmd
+			m.options, cmd = m.options.Update(msg)
+			return m, cmd
 		}
 	}
 	return m, nil
@@ -345,6 +437,10 @@ func (m *GRPCForm) enterTabContent() (GRPCForm, tea.Cmd) {
 		var cmd tea.Cmd
 		m.auth, cmd = m.auth.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		return *m, cmd
+	case GRPCTabOptions:
+		var cmd tea.Cmd
+		m.options, cmd = m.options.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		return *m, cmd
 	}
 	return *m, nil
 }
@@ -358,6 +454,8 @@ func (m GRPCForm) updateTabContent(msg tea.Msg) (GRPCForm, tea.Cmd) {
 		m.metadata, cmd = m.metadata.Update(msg)
 	case GRPCTabAuth:
 		m.auth, cmd = m.auth.Update(msg)
+	case GRPCTabOptions:
+		m.options, cmd = m.options.Update(msg)
 	}
 	return m, cmd
 }
@@ -393,30 +491,7 @@ func (m GRPCForm) View() string {
 
 	switch m.activeTab {
 	case GRPCTabService:
-		if len(m.services) == 0 {
-			b.WriteString(m.styles.Hint.Render("No services discovered. Press Ctrl+I to run reflection."))
-		} else {
-			for si, svc := range m.services {
-				svcStyle := m.styles.Muted
-				if si == m.svcIdx {
-					svcStyle = m.styles.TabActive
-				}
-				b.WriteString(svcStyle.Render(svc.Name) + "\n")
-				for mi, mtd := range svc.Methods {
-					prefix := "  "
-					mtdStyle := m.styles.Muted
-					if si == m.svcIdx && mi == m.mtdIdx {
-						prefix = "> "
-						mtdStyle = m.styles.Cursor
-					}
-					label := mtd.Name
-					if tag := streamingTag(mtd); tag != "" {
-						label += " " + tag
-					}
-					b.WriteString(prefix + mtdStyle.Render(label) + "\n")
-				}
-			}
-		}
+		b.WriteString(m.viewServices())
 	case GRPCTabRequest:
 		if m.method != "" {
 			methodLabel := "Method: " + m.method
@@ -430,11 +505,78 @@ func (m GRPCForm) View() string {
 		b.WriteString(m.metadata.View())
 	case GRPCTabAuth:
 		b.WriteString(m.auth.View())
+	case GRPCTabOptions:
+		b.WriteString(m.options.View())
 	}
 
 	return b.String()
 }
 
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func formatSize(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// viewServices renders the discovered services with the request and
+// response schemas of the method under the cursor.
+func (m GRPCForm) viewServices() string {
+	if len(m.services) == 0 {
+		return m.styles.Hint.Render("No services discovered. Press r to run server reflection.")
+	}
+	var list []string
+	for si, svc := range m.services {
+		svcStyle := m.styles.Muted
+		if si == m.svcIdx {
+			svcStyle = m.styles.TabActive
+		}
+		list = append(list, svcStyle.Render(svc.Name))
+		for mi, mtd := range svc.Methods {
+			prefix := "  "
+			mtdStyle := m.styles.Muted
+			if si == m.svcIdx && mi == m.mtdIdx {
+				prefix = "> "
+				mtdStyle = m.styles.Cursor
+			}
+			label := mtd.Name
+			if svc.Name == m.service && (mtd.Name == m.method || mtd.FullName == m.method) {
+				label = "• " + label
+			}
+			if tag := streamingTag(mtd); tag != "" {
+				label += " " + tag
+			}
+			list = append(list, prefix+mtdStyle.Render(label))
+		}
+	}
+	list = append(list, "", m.styles.Hint.Render("j/k: browse · enter: use method · r: reflect again"))
+
+	mtd, ok := m.cursorMethod()
+	if !ok {
+		return strings.Join(list, "\n")
+	}
+	schema := []string{
+		m.styles.Hint.Render("Request: " + mtd.InputType),
+		mtd.InputTemplate,
+		"",
+		m.styles.Hint.Render("Response: " + mtd.OutputType),
+		mtd.OutputTemplate,
+	}
+	left := strings.Join(list, "\n")
+	right := strings.Join(schema, "\n")
+	if m.width < 80 {
+		return left + "\n\n" + right
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().Width(m.width/2).Render(left), right)
+}
+
 // streamingTag returns a short label describing the streaming mode of a gRPC
 // method, or an empty string for unary methods.
 func streamingTag(mtd msgs.GRPCMethodInfo) string {
@@ -450,16 +592,13 @@ func streamingTag(mtd msgs.GRPCMethodInfo) string {
 	return ""
 }
 
-// selectedStreamingTag returns the streaming tag for the currently selected method.
+// selectedStreamingTag returns the streaming tag for the request's method.
 func (m GRPCForm) selectedStreamingTag() string {
-	if len(m.services) == 0 || m.svcIdx >= len(m.services) {
-		return ""
-	}
-	svc := m.services[m.svcIdx]
-	if m.mtdIdx >= len(svc.Methods) {
+	si, mi, ok := m.findMethod()
+	if !ok {
 		return ""
 	}
-	mtd := svc.Methods[m.mtdIdx]
+	mtd := m.services[si].Methods[mi]
 	if mtd.IsClientStream && mtd.IsServerStream {
 		return "Bidirectional"
 	}
@@ -6,6 +6,7 @@ import (
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/protocol"
 	"github.com/sadopc/gottp/internal/ui/components"
@@ -14,6 +15,9 @@ import (
 
 var httpMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
 
+// bodyTypes are cycled with "t" in the Body tab.
+var bodyTypes = []string{"json", "text", "xml", collection.BodyFormURLEncoded, collection.BodyMultipart, collection.BodyBinaryFile}
+
 // SubTab identifies the active sub-tab in the HTTP form.
 type SubTab int
 
@@ -22,9 +26,12 @@ const (
 	TabHeaders
 	TabAuth
 	TabBody
+	TabOptions
+	TabTLS
+	TabCookies
 )
 
-var subTabNames = []string{"Params", "Headers", "Auth", "Body"}
+var subTabNames = []string{"Params", "Headers", "Auth", "Body", "Options", "TLS", "Cookies"}
 
 // HTTPForm is the HTTP request form component.
 type HTTPForm struct {
@@ -36,8 +43,16 @@ type HTTPForm struct {
 	activeTab SubTab
 	params    components.KVTable
 	headers   components.KVTable
+	cookies   components.KVTable
 	auth      AuthSection
 	body      textarea.Model
+	options   OptionsSection
+	tls       TLSSection
+
+	// Body type and the editors for form and file bodies
+	bodyType   string
+	bodyFields components.KVTable
+	bodyFile   textinput.Model
 
 	// Focus tracking: 0=method, 1=url, 2=sub-tab content
 	focusField int
@@ -47,6 +62,14 @@ type HTTPForm struct {
 	styles theme.Styles
 }
 
+// defaultHeaders are shown for requests that set no headers.
+func defaultHeaders() []components.KVPair {
+	return []components.KVPair{
+		{Key: "Content-Type", Value: "application/json", Enabled: true},
+		{Key: "Accept", Value: "*/*", Enabled: true},
+	}
+}
+
 // NewHTTPForm creates a new HTTPForm.
 func NewHTTPForm(styles theme.Styles) HTTPForm {
 	urlInput := textinput.New()
@@ -64,11 +87,11 @@ func NewHTTPForm(styles theme.Styles) HTTPForm {
 	params := components.NewKVTable(styles)
 	headers := components.NewKVTable(styles)
 
-	// Default headers
-	headers.SetPairs([]components.KVPair{
-		{Key: "Content-Type", Value: "application/json", Enabled: true},
-		{Key: "Accept", Value: "*/*", Enabled: true},
-	})
+	fileInput := textinput.New()
+	fileInput.Placeholder = "path/to/file (relative to the collection)"
+	fileInput.Width = 40
+
+	headers.SetPairs(defaultHeaders())
 
 	return HTTPForm{
 		Method:      "GET",
@@ -77,8 +100,14 @@ func NewHTTPForm(styles theme.Styles) HTTPForm {
 		activeTab:   TabParams,
 		params:      params,
 		headers:     headers,
+		cookies:     components.NewKVTable(styles),
 		auth:        NewAuthSection(styles),
 		body:        bodyArea,
+		options:     NewOptionsSection(styles),
+		tls:         NewTLSSection(styles),
+		bodyType:    "json",
+		bodyFields:  components.NewKVTable(styles),
+		bodyFile:    fileInput,
 		styles:      styles,
 		width:       60,
 		height:      20,
@@ -102,9 +131,14 @@ func (m *HTTPForm) SetSize(w, h int) {
 	}
 	m.params.SetSize(contentW)
 	m.headers.SetSize(contentW)
+	m.cookies.SetSize(contentW)
 	m.auth.SetSize(contentW)
+	m.options.SetSize(contentW)
+	m.tls.SetSize(contentW)
+	m.bodyFields.SetSize(contentW)
+	m.bodyFile.Width = contentW - 2
 
-	bodyH := h - 6 // url bar + tab bar + padding
+	bodyH := h - 8 // url bar + tab bar + body type line + padding
 	if bodyH < 3 {
 		bodyH = 3
 	}
@@ -135,10 +169,20 @@ func (m HTTPForm) Editing() bool {
 			return m.params.Editing()
 		case TabHeaders:
 			return m.headers.Editing()
+		case TabCookies:
+			return m.cookies.Editing()
 		case TabAuth:
 			return m.auth.Editing()
 		case TabBody:
+			switch m.bodyKind() {
+			case bodyKindFields:
+				return m.bodyFields.Editing()
+			case bodyKindFile:
+				return m.bodyFile.Focused()
+			}
 			return m.body.Focused()
+		case TabTLS:
+			return m.tls.Editing()
 		}
 	}
 	return false
@@ -204,7 +248,7 @@ func (m HTTPForm) updateNormal(msg tea.KeyMsg) (HTTPForm, tea.Cmd) {
 		}
 	case "l", "right":
 		if m.focusField == 2 {
-			if m.activeTab < TabBody {
+			if m.activeTab < TabCookies {
 				m.activeTab++
 			}
 		}
@@ -216,6 +260,21 @@ func (m HTTPForm) updateNormal(msg tea.KeyMsg) (HTTPForm, tea.Cmd) {
 		m.activeTab = TabAuth
 	case "4":
 		m.activeTab = TabBody
+	case "5":
+		m.activeTab = TabOptions
+	case "6":
+		m.activeTab = TabTLS
+	case "7":
+		m.activeTab = TabCookies
+	case "t":
+		if m.focusField == 2 && m.activeTab == TabBody {
+			m.cycleBodyType()
+			return m, nil
+		}
+		if m.focusField == 2 {
+			cmds := m.updateTabContent(msg)
+			return m, tea.Batch(cmds...)
+		}
 	default:
 		if m.focusField == 2 {
 			cmds := m.updateTabContent(msg)
@@ -253,6 +312,13 @@ func (m HTTPForm) updateEditing(msg tea.KeyMsg) (HTTPForm, tea.Cmd) {
 			var cmd tea.Cmd
 			m.headers, cmd = m.headers.Update(msg)
 			return m, cmd
+		case TabCookies:
+			if msg.String() == "esc" && !m.cookies.Editing() {
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.cookies, cmd = m.cookies.Update(msg)
+			return m, cmd
 		case TabAuth:
 			if msg.String() == "esc" && !m.auth.Editing() {
 				return m, nil
@@ -261,6 +327,23 @@ func (m HTTPForm) updateEditing(msg tea.KeyMsg) (HTTPForm, tea.Cmd) {
 			m.auth, cmd = m.auth.Update(msg)
 			return m, cmd
 		case TabBody:
+			switch m.bodyKind() {
+			case bodyKindFields:
+				if msg.String() == "esc" && !m.bodyFields.Editing() {
+					return m, nil
+				}
+				var cmd tea.Cmd
+				m.bodyFields, cmd = m.bodyFields.Update(msg)
+				return m, cmd
+			case bodyKindFile:
+				if msg.String() == "esc" || msg.String() == "enter" {
+					m.bodyFile.Blur()
+					return m, nil
+				}
+				var cmd tea.Cmd
+				m.bodyFile, cmd = m.bodyFile.Update(msg)
+				return m, cmd
+			}
 			switch msg.String() {
 			case "esc":
 				m.body.Blur()
@@ -269,6 +352,10 @@ func (m HTTPForm) updateEditing(msg tea.KeyMsg) (HTTPForm, tea.Cmd) {
 			var cmd tea.Cmd
 			m.body, cmd = m.body.Update(msg)
 			return m, cmd
+		case TabTLS:
+			var cmd tea.Cmd
+			m.tls, cmd = m.tls.Update(msg)
+			return m, cmd
 		}
 	}
 	return m, nil
@@ -284,13 +371,34 @@ func (m *HTTPForm) enterTabContent() (HTTPForm, tea.Cmd) {
 		var cmd tea.Cmd
 		m.headers, cmd = m.headers.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		return *m, cmd
+	case TabCookies:
+		var cmd tea.Cmd
+		m.cookies, cmd = m.cookies.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		return *m, cmd
 	case TabAuth:
 		var cmd tea.Cmd
 		m.auth, cmd = m.auth.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		return *m, cmd
 	case TabBody:
+		switch m.bodyKind() {
+		case bodyKindFields:
+			var cmd tea.Cmd
+			m.bodyFields, cmd = m.bodyFields.Update(tea.KeyMsg{Type: tea.KeyEnter})
+			return *m, cmd
+		case bodyKindFile:
+			cmd := m.bodyFile.Focus()
+			return *m, cmd
+		}
 		cmd := m.body.Focus()
 		return *m, cmd
+	case TabOptions:
+		var cmd tea.Cmd
+		m.options, cmd = m.options.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		return *m, cmd
+	case TabTLS:
+		var cmd tea.Cmd
+		m.tls, cmd = m.tls.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		return *m, cmd
 	}
 	return *m, nil
 }
@@ -310,6 +418,12 @@ func (m *HTTPForm) updateTabContent(msg tea.Msg) []tea.Cmd {
 		if cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case TabCookies:
+		var cmd tea.Cmd
+		m.cookies, cmd = m.cookies.Update(msg)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	case TabAuth:
 		var cmd tea.Cmd
 		m.auth, cmd = m.auth.Update(msg)
@@ -318,7 +432,26 @@ func (m *HTTPForm) updateTabContent(msg tea.Msg) []tea.Cmd {
 		}
 	case TabBody:
 		var cmd tea.Cmd
-		m.body, cmd = m.body.Update(msg)
+		switch m.bodyKind() {
+		case bodyKindFields:
+			m.bodyFields, cmd = m.bodyFields.Update(msg)
+		case bodyKindFile:
+			m.bodyFile, cmd = m.bodyFile.Update(msg)
+		default:
+			m.body, cmd = m.body.Update(msg)
+		}
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case TabOptions:
+		var cmd tea.Cmd
+		m.options, cmd = m.options.Update(msg)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case TabTLS:
+		var cmd tea.Cmd
+		m.tls, cmd = m.tls.Update(msg)
 		if cmd != nil {
 			cmds = append(cmds, cmd)
 		}
@@ -329,6 +462,71 @@ func (m *HTTPForm) updateTabContent(msg tea.Msg) []tea.Cmd {
 func (m *HTTPForm) syncFocus() {
 	m.url.Blur()
 	m.body.Blur()
+	m.bodyFile.Blur()
+}
+
+type bodyKind int
+
+const (
+	bodyKindText bodyKind = iota
+	bodyKindFields
+	bodyKindFile
+)
+
+// bodyKind reports which editor the current body type uses.
+func (m HTTPForm) bodyKind() bodyKind {
+	switch m.bodyType {
+	case collection.BodyFormURLEncoded, collection.BodyMultipart:
+		return bodyKindFields
+	case collection.BodyBinaryFile:
+		return bodyKindFile
+	}
+	return bodyKindText
+}
+
+func (m *HTTPForm) cycleBodyType() {
+	for i, t := range bodyTypes {
+		if t == m.bodyType {
+			m.bodyType = bodyTypes[(i+1)%len(bodyTypes)]
+			return
+		}
+	}
+	m.bodyType = bodyTypes[0]
+}
+
+// BodyType returns the selected body type.
+func (m HTTPForm) BodyType() string {
+	return m.bodyType
+}
+
+// BuildBody returns the body for saving to a collection, or nil when empty.
+// In multipart bodies a value starting with @ references a file.
+func (m HTTPForm) BuildBody() *collection.Body {
+	body := &collection.Body{Type: m.bodyType}
+	switch m.bodyKind() {
+	case bodyKindFields:
+		for _, p := range m.bodyFields.GetPairs() {
+			if p.Key == "" && p.Value == "" {
+				continue
+			}
+			field := collection.BodyField{Key: p.Key, Value: p.Value, Enabled: p.Enabled}
+			if m.bodyType == collection.BodyMultipart && strings.HasPrefix(p.Value, "@") {
+				field.Value, field.File = "", strings.TrimPrefix(p.Value, "@")
+			}
+			body.Fields = append(body.Fields, field)
+		}
+		if len(body.Fields) == 0 {
+			body.Content = strings.TrimSpace(m.body.Value()) // pre-encoded content
+		}
+	case bodyKindFile:
+		body.File = strings.TrimSpace(m.bodyFile.Value())
+	default:
+		body.Content = strings.TrimSpace(m.body.Value())
+	}
+	if body.IsEmpty() {
+		return nil
+	}
+	return body
 }
 
 func (m *HTTPForm) cycleMethod() {
@@ -346,11 +544,30 @@ func (m HTTPForm) GetHeaders() []components.KVPair {
 	return m.headers.GetPairs()
 }
 
+// GetCookies returns the current cookie pairs.
+func (m HTTPForm) GetCookies() []components.KVPair {
+	return m.cookies.GetPairs()
+}
+
 // GetBodyContent returns the current body text.
 func (m HTTPForm) GetBodyContent() string {
 	return strings.TrimSpace(m.body.Value())
 }
 
+// SetUnresolved highlights the URL and header values that use any of the
+// named variables, which are not defined in any scope.
+func (m *HTTPForm) SetUnresolved(names []string) {
+	m.url.TextStyle = lipgloss.NewStyle()
+	for _, name := range names {
+		if strings.Contains(m.url.Value(), "{{"+name+"}}") {
+			m.url.TextStyle = m.styles.Error
+			break
+		}
+	}
+	m.headers.SetUnresolved(names)
+	m.cookies.SetUnresolved(names)
+}
+
 // SetBody sets the body content.
 func (m *HTTPForm) SetBody(content string) {
 	m.body.SetValue(content)
@@ -383,12 +600,36 @@ func (m HTTPForm) BuildRequest() *protocol.Request {
 		}
 	}
 
-	body := strings.TrimSpace(m.body.Value())
-	if body != "" {
-		req.Body = []byte(body)
+	for _, c := range m.cookies.GetPairs() {
+		if c.Enabled && c.Key != "" {
+			req.Cookies = append(req.Cookies, protocol.Cookie{Name: c.Key, Value: c.Value})
+		}
+	}
+
+	if b := m.BuildBody(); b != nil {
+		switch m.bodyKind() {
+		case bodyKindFields:
+			req.Multipart = m.bodyType == collection.BodyMultipart
+			for _, f := range b.Fields {
+				if f.Enabled && f.Key != "" {
+					req.Form = append(req.Form, protocol.FormField{Name: f.Key, Value: f.Value, File: f.File})
+				}
+			}
+			if len(b.Fields) == 0 {
+				req.Body = []byte(b.Content)
+			}
+		case bodyKindFile:
+			req.BodyFile = b.File
+		default:
+			req.Body = []byte(b.Content)
+		}
 	}
 
 	req.Auth = m.auth.BuildAuth()
+	req.ExpectContinue = m.options.ExpectContinue()
+	req.Chunked = m.options.Chunked()
+	req.NoCache = m.options.NoCache()
+	req.TLS = m.tls.Build()
 
 	return req
 }
@@ -405,14 +646,12 @@ func (m *HTTPForm) LoadRequest(req *collection.Request) {
 
 	m.url.SetValue(req.URL)
 
-	// Load params
-	if len(req.Params) > 0 {
-		kvPairs := make([]components.KVPair, len(req.Params))
-		for i, p := range req.Params {
-			kvPairs[i] = components.KVPair{Key: p.Key, Value: p.Value, Enabled: p.Enabled}
-		}
-		m.params.SetPairs(kvPairs)
+	// Load params; the previous request's must not linger
+	kvPairs := make([]components.KVPair, len(req.Params))
+	for i, p := range req.Params {
+		kvPairs[i] = components.KVPair{Key: p.Key, Value: p.Value, Enabled: p.Enabled}
 	}
+	m.params.SetPairs(kvPairs)
 
 	// Load headers
 	if len(req.Headers) > 0 {
@@ -421,16 +660,46 @@ func (m *HTTPForm) LoadRequest(req *collection.Request) {
 			kvPairs[i] = components.KVPair{Key: h.Key, Value: h.Value, Enabled: h.Enabled}
 		}
 		m.headers.SetPairs(kvPairs)
+	} else {
+		m.headers.SetPairs(defaultHeaders())
 	}
 
+	// Load cookies
+	cookies := make([]components.KVPair, len(req.Cookies))
+	for i, c := range req.Cookies {
+		cookies[i] = components.KVPair{Key: c.Key, Value: c.Value, Enabled: c.Enabled}
+	}
+	m.cookies.SetPairs(cookies)
+
 	// Load body
+	m.bodyType = "json"
 	if req.Body != nil {
+		if kind := req.Body.Kind(); kind != "none" && kind != "" {
+			m.bodyType = kind
+		}
 		m.body.SetValue(req.Body.Content)
+		m.bodyFile.SetValue(req.Body.File)
+		fields := make([]components.KVPair, len(req.Body.Fields))
+		for i, f := range req.Body.Fields {
+			value := f.Value
+			if f.File != "" {
+				value = "@" + f.File
+			}
+			fields[i] = components.KVPair{Key: f.Key, Value: value, Enabled: f.Enabled}
+		}
+		m.bodyFields.SetPairs(fields)
+	} else {
+		m.body.SetValue("")
+		m.bodyFile.SetValue("")
+		m.bodyFields.SetPairs(nil)
 	}
 
 	// Load auth
 	m.auth.LoadAuth(req.Auth)
 
+	m.options.Load(req.ExpectContinue, req.Chunked, req.NoCache)
+	m.tls.Load(req.TLS)
+
 	m.focusField = 1
 }
 
@@ -468,7 +737,26 @@ func (m HTTPForm) View() string {
 	case TabAuth:
 		b.WriteString(m.auth.View())
 	case TabBody:
-		b.WriteString(m.body.View())
+		hint := "t: change type"
+		if m.bodyType == collection.BodyMultipart {
+			hint += " · @path uploads a file"
+		}
+		b.WriteString(m.styles.Key.Render("Type: "+m.bodyType) + "  " + m.styles.Muted.Render(hint) + "\n\n")
+		switch m.bodyKind() {
+		case bodyKindFields:
+			b.WriteString(m.bodyFields.View())
+		case bodyKindFile:
+			b.WriteString(m.bodyFile.View())
+		default:
+			b.WriteString(m.body.View())
+		}
+	case TabOptions:
+		b.WriteString(m.options.View())
+	case TabTLS:
+		b.WriteString(m.tls.View())
+	case TabCookies:
+		b.WriteString(m.styles.Muted.Render("Sent in the Cookie header alongside the cookie jar's; values are percent-encoded as needed") + "\n\n")
+		b.WriteString(m.cookies.View())
 	}
 
 	return b.String()
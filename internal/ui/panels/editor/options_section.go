@@ -0,0 +1,224 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// option is a per-request setting shown in the Options sub-tab: a toggle,
+// or a text value when field is set.
+type option struct {
+	label string
+	hint  string
+	on    bool
+	field bool
+	value string
+}
+
+// HTTP options.
+const (
+	optExpectContinue = iota
+	optChunked
+	optNoCache
+)
+
+// GraphQL options.
+const (
+	optPersistedQuery = iota
+)
+
+// gRPC options.
+const (
+	optGRPCDeadline = iota
+	optGRPCGzip
+	optGRPCMaxSend
+	optGRPCMaxRecv
+	optGRPCWaitForReady
+	optGRPCTransport
+)
+
+// OptionsSection lists per-request transport settings.
+type OptionsSection struct {
+	options []option
+	cursor  int
+	input   textinput.Model // edits the value of the option under the cursor
+	editing bool
+	width   int
+	styles  theme.Styles
+}
+
+// NewOptionsSection creates the HTTP OptionsSection with every toggle off.
+func NewOptionsSection(styles theme.Styles) OptionsSection {
+	return OptionsSection{
+		options: []option{
+			optExpectContinue: {label: "Expect: 100-continue", hint: "wait for the server before sending the body"},
+			optChunked:        {label: "Chunked upload", hint: "send the body with Transfer-Encoding: chunked"},
+			optNoCache:        {label: "Bypass response cache", hint: "never send If-None-Match / If-Modified-Since from cached responses"},
+		},
+		styles: styles,
+	}
+}
+
+// NewGraphQLOptionsSection creates the GraphQL OptionsSection with every
+// toggle off.
+func NewGraphQLOptionsSection(styles theme.Styles) OptionsSection {
+	return OptionsSection{
+		options: []option{
+			optPersistedQuery: {label: "Persisted query (APQ)", hint: "send the query's SHA-256 hash, and the query only if the server asks"},
+		},
+		styles: styles,
+	}
+}
+
+// NewGRPCOptionsSection creates the gRPC OptionsSection with every call
+// option at its default.
+func NewGRPCOptionsSection(styles theme.Styles) OptionsSection {
+	return OptionsSection{
+		options: []option{
+			optGRPCDeadline:     {label: "Deadline", hint: "per-call deadline, e.g. 500ms or 5s; the request timeout when empty", field: true},
+			optGRPCGzip:         {label: "Gzip compression", hint: "compress request messages"},
+			optGRPCMaxSend:      {label: "Max send size", hint: "largest request message in bytes", field: true},
+			optGRPCMaxRecv:      {label: "Max receive size", hint: "largest response message in bytes; 50 MiB when empty", field: true},
+			optGRPCWaitForReady: {label: "Wait for ready", hint: "wait for the connection instead of failing fast"},
+			optGRPCTransport:    {label: "Transport", hint: "grpc, grpc-web or connect; gRPC-Web for http(s):// URLs when empty", field: true},
+		},
+		input:  textinput.New(),
+		styles: styles,
+	}
+}
+
+// SetSize updates the section width.
+func (m *OptionsSection) SetSize(w int) {
+	m.width = w
+}
+
+// ExpectContinue reports whether Expect: 100-continue is enabled.
+func (m OptionsSection) ExpectContinue() bool { return m.options[optExpectContinue].on }
+
+// Chunked reports whether chunked uploads are forced.
+func (m OptionsSection) Chunked() bool { return m.options[optChunked].on }
+
+// NoCache reports whether the response cache is bypassed.
+func (m OptionsSection) NoCache() bool { return m.options[optNoCache].on }
+
+// PersistedQuery reports whether Automatic Persisted Queries are enabled.
+// Only meaningful for the GraphQL section.
+func (m OptionsSection) PersistedQuery() bool { return m.options[optPersistedQuery].on }
+
+// LoadGraphQL sets the GraphQL toggles from a saved request.
+func (m *OptionsSection) LoadGraphQL(persistedQuery bool) {
+	m.options[optPersistedQuery].on = persistedQuery
+}
+
+// GRPCTransport returns the transport field as typed.
+func (m OptionsSection) GRPCTransport() string { return m.options[optGRPCTransport].value }
+
+// GRPCDeadline returns the deadline field as typed.
+func (m OptionsSection) GRPCDeadline() string { return m.options[optGRPCDeadline].value }
+
+// GRPCGzip reports whether request messages are compressed.
+func (m OptionsSection) GRPCGzip() bool { return m.options[optGRPCGzip].on }
+
+// GRPCMaxSend returns the max send size field as typed.
+func (m OptionsSection) GRPCMaxSend() string { return m.options[optGRPCMaxSend].value }
+
+// GRPCMaxRecv returns the max receive size field as typed.
+func (m OptionsSection) GRPCMaxRecv() string { return m.options[optGRPCMaxRecv].value }
+
+// GRPCWaitForReady reports whether calls wait for the connection.
+func (m OptionsSection) GRPCWaitForReady() bool { return m.options[optGRPCWaitForReady].on }
+
+// LoadGRPC sets the gRPC options from a saved request.
+func (m *OptionsSection) LoadGRPC(transport, deadline, maxSend, maxRecv string, gzip, waitForReady bool) {
+	m.options[optGRPCTransport].value = transport
+	m.options[optGRPCDeadline].value = deadline
+	m.options[optGRPCGzip].on = gzip
+	m.options[optGRPCMaxSend].value = maxSend
+	m.options[optGRPCMaxRecv].value = maxRecv
+	m.options[optGRPCWaitForReady].on = waitForReady
+	m.editing = false
+	m.input.Blur()
+}
+
+// Editing reports whether a value is being typed.
+func (m OptionsSection) Editing() bool { return m.editing }
+
+// Load sets the toggles from a saved request.
+func (m *OptionsSection) Load(expectContinue, chunked, noCache bool) {
+	m.options[optExpectContinue].on = expectContinue
+	m.options[optChunked].on = chunked
+	m.options[optNoCache].on = noCache
+}
+
+// Update handles navigation and toggling.
+func (m OptionsSection) Update(msg tea.Msg) (OptionsSection, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if m.editing {
+		if ok {
+			switch key.String() {
+			case "enter":
+				m.options[m.cursor].value = strings.TrimSpace(m.input.Value())
+				fallthrough
+			case "esc":
+				m.editing = false
+				m.input.Blur()
+				return m, nil
+			}
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "j", "down":
+		if m.cursor < len(m.options)-1 {
+			m.cursor++
+		}
+	case "k", "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "enter", " ":
+		if m.options[m.cursor].field {
+			m.editing = true
+			m.input.SetValue(m.options[m.cursor].value)
+			m.input.CursorEnd()
+			return m, m.input.Focus()
+		}
+		m.options[m.cursor].on = !m.options[m.cursor].on
+	}
+	return m, nil
+}
+
+// View renders the toggles as a checklist.
+func (m OptionsSection) View() string {
+	var lines []string
+	for i, opt := range m.options {
+		box := "[ ]"
+		if opt.on {
+			box = "[x]"
+		}
+		line := box + " " + opt.label
+		if opt.field {
+			line = opt.label + ": " + opt.value
+			if m.editing && i == m.cursor {
+				lines = append(lines, m.styles.Cursor.Render(opt.label+":")+" "+m.input.View())
+				continue
+			}
+		}
+		if i == m.cursor {
+			line = m.styles.Cursor.Render(line)
+		} else {
+			line = m.styles.Normal.Render(line)
+		}
+		lines = append(lines, line+"  "+m.styles.Muted.Render(opt.hint))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,226 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	gotls "github.com/sadopc/gottp/internal/core/tls"
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// TLS section rows.
+const (
+	tlsCert = iota
+	tlsKey
+	tlsCA
+	tlsMinVersion
+	tlsSkipVerify
+	tlsRows
+)
+
+// tlsVersions are cycled in the Min version row; "" uses the Go default.
+var tlsVersions = append([]string{""}, gotls.Versions...)
+
+// TLSSection edits per-request TLS settings: client certificate for mutual
+// TLS, an extra CA bundle, minimum version and certificate verification.
+// Empty fields fall back to the environment and global settings.
+type TLSSection struct {
+	cert       textinput.Model
+	key        textinput.Model
+	ca         textinput.Model
+	versionIdx int
+	skipVerify bool
+	cursor     int
+	editing    bool
+	width      int
+	styles     theme.Styles
+}
+
+// NewTLSSection creates an empty TLS section.
+func NewTLSSection(styles theme.Styles) TLSSection {
+	mkInput := func(placeholder string) textinput.Model {
+		ti := textinput.New()
+		ti.Placeholder = placeholder
+		ti.CharLimit = 1024
+		ti.Width = 40
+		return ti
+	}
+	return TLSSection{
+		cert:   mkInput("client.pem (relative to the collection)"),
+		key:    mkInput("client-key.pem"),
+		ca:     mkInput("extra CA bundle, trusted with the system roots"),
+		styles: styles,
+	}
+}
+
+// SetSize updates the section width.
+func (m *TLSSection) SetSize(w int) {
+	m.width = w
+	inputW := max(w-16, 10)
+	m.cert.Width = inputW
+	m.key.Width = inputW
+	m.ca.Width = inputW
+}
+
+// Editing returns whether a text field is being edited.
+func (m TLSSection) Editing() bool {
+	return m.editing
+}
+
+// Build returns the TLS settings, or nil when none are set.
+func (m TLSSection) Build() *gotls.Config {
+	cfg := &gotls.Config{
+		CertFile:           strings.TrimSpace(m.cert.Value()),
+		KeyFile:            strings.TrimSpace(m.key.Value()),
+		CAFile:             strings.TrimSpace(m.ca.Value()),
+		MinVersion:         tlsVersions[m.versionIdx],
+		InsecureSkipVerify: m.skipVerify,
+	}
+	if cfg.IsEmpty() {
+		return nil
+	}
+	return cfg
+}
+
+// Load populates the section from saved settings.
+func (m *TLSSection) Load(cfg *gotls.Config) {
+	if cfg == nil {
+		cfg = &gotls.Config{}
+	}
+	m.cert.SetValue(cfg.CertFile)
+	m.key.SetValue(cfg.KeyFile)
+	m.ca.SetValue(cfg.CAFile)
+	m.skipVerify = cfg.InsecureSkipVerify
+	m.versionIdx = 0
+	for i, v := range tlsVersions {
+		if v == cfg.MinVersion {
+			m.versionIdx = i
+		}
+	}
+}
+
+// Update handles navigation, toggles and text editing.
+func (m TLSSection) Update(msg tea.Msg) (TLSSection, tea.Cmd) {
+	if m.editing {
+		if key, ok := msg.(tea.KeyMsg); ok && (key.String() == "esc" || key.String() == "enter") {
+			m.blurAll()
+			m.editing = false
+			return m, nil
+		}
+		var cmd tea.Cmd
+		switch m.cursor {
+		case tlsCert:
+			m.cert, cmd = m.cert.Update(msg)
+		case tlsKey:
+			m.key, cmd = m.key.Update(msg)
+		case tlsCA:
+			m.ca, cmd = m.ca.Update(msg)
+		}
+		return m, cmd
+	}
+
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "j", "down":
+		if m.cursor < tlsRows-1 {
+			m.cursor++
+		}
+	case "k", "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "enter", " ":
+		switch m.cursor {
+		case tlsMinVersion:
+			m.versionIdx = (m.versionIdx + 1) % len(tlsVersions)
+		case tlsSkipVerify:
+			m.skipVerify = !m.skipVerify
+		default:
+			if key.String() == "enter" {
+				return m, m.startEditing()
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *TLSSection) startEditing() tea.Cmd {
+	m.editing = true
+	var input *textinput.Model
+	switch m.cursor {
+	case tlsCert:
+		input = &m.cert
+	case tlsKey:
+		input = &m.key
+	default:
+		input = &m.ca
+	}
+	input.CursorEnd()
+	return input.Focus()
+}
+
+func (m *TLSSection) blurAll() {
+	m.cert.Blur()
+	m.key.Blur()
+	m.ca.Blur()
+}
+
+// View renders the TLS settings.
+func (m TLSSection) View() string {
+	lines := []string{
+		m.renderField("Cert", m.cert, tlsCert),
+		m.renderField("Key", m.key, tlsKey),
+		m.renderField("CA", m.ca, tlsCA),
+	}
+
+	var versions []string
+	for i, v := range tlsVersions {
+		label := v
+		if v == "" {
+			label = "default"
+		}
+		if i == m.versionIdx {
+			versions = append(versions, m.styles.TabActive.Render(label))
+		} else {
+			versions = append(versions, m.styles.TabInactive.Render(label))
+		}
+	}
+	lines = append(lines, m.prefix(tlsMinVersion)+m.label("Min TLS")+" "+strings.Join(versions, " "))
+
+	verify := "on"
+	if m.skipVerify {
+		verify = m.styles.Error.Render("off (insecure)")
+	}
+	lines = append(lines, m.prefix(tlsSkipVerify)+m.label("Verify")+" "+verify)
+
+	lines = append(lines, "", m.styles.Muted.Render("  Empty fields use the environment's and global TLS settings"))
+	return strings.Join(lines, "\n")
+}
+
+func (m TLSSection) prefix(row int) string {
+	if m.cursor == row {
+		return "> "
+	}
+	return "  "
+}
+
+func (m TLSSection) label(text string) string {
+	return m.styles.Key.Render(lipgloss.NewStyle().Width(10).Render(text))
+}
+
+func (m TLSSection) renderField(label string, input textinput.Model, row int) string {
+	head := m.prefix(row) + m.label(label) + " "
+	if m.cursor == row && m.editing {
+		return head + input.View()
+	}
+	if input.Value() == "" {
+		return head + m.styles.Muted.Render(input.Placeholder)
+	}
+	return head + m.styles.Normal.Render(input.Value())
+}
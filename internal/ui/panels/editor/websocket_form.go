@@ -1,12 +1,15 @@
 package editor
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/sadopc/gottp/internal/core/collection"
+	"github.com/sadopc/gottp/internal/core/retry"
 	"github.com/sadopc/gottp/internal/protocol"
 	"github.com/sadopc/gottp/internal/ui/components"
 	"github.com/sadopc/gottp/internal/ui/msgs"
@@ -36,6 +39,12 @@ type WebSocketForm struct {
 	focusField int // 0=url, 1=sub-tab content
 	connected  bool
 
+	// Saved messages sent on connect; also usable as composer templates
+	script       []collection.WSMessage
+	pingInterval time.Duration
+	reconnect    *retry.Policy
+	templateIdx  int
+
 	width  int
 	height int
 	styles theme.Styles
@@ -134,6 +143,20 @@ func (m WebSocketForm) BuildRequest() *protocol.Request {
 		}
 	}
 	req.Auth = m.auth.BuildAuth()
+	req.WSPingInterval = m.pingInterval
+	req.WSReconnect = m.reconnect
+	for _, msg := range m.script {
+		sm := protocol.WSScriptMessage{Name: msg.Name, Content: msg.Content, Delay: msg.Delay}
+		if msg.Expect != nil {
+			sm.Expect = &protocol.WSExpectation{
+				Contains: msg.Expect.Contains,
+				Equals:   msg.Expect.Equals,
+				Matches:  msg.Expect.Matches,
+				Timeout:  msg.Expect.Timeout,
+			}
+		}
+		req.WSMessages = append(req.WSMessages, sm)
+	}
 	return req
 }
 
@@ -171,11 +194,31 @@ func (m *WebSocketForm) LoadRequest(req *collection.Request) {
 			kvPairs[i] = components.KVPair{Key: h.Key, Value: h.Value, Enabled: h.Enabled}
 		}
 		m.headers.SetPairs(kvPairs)
+	} else {
+		m.headers.SetPairs(nil)
 	}
 	m.auth.LoadAuth(req.Auth)
+	m.script = nil
+	m.pingInterval = 0
+	m.reconnect = nil
+	m.templateIdx = 0
+	if req.WebSocket != nil {
+		m.script = req.WebSocket.Messages
+		m.pingInterval = req.WebSocket.PingInterval
+		m.reconnect = req.WebSocket.Reconnect
+	}
 	m.focusField = 0
 }
 
+// loadNextTemplate copies the next saved message into the composer.
+func (m *WebSocketForm) loadNextTemplate() {
+	if len(m.script) == 0 {
+		return
+	}
+	m.message.SetValue(m.script[m.templateIdx].Content)
+	m.templateIdx = (m.templateIdx + 1) % len(m.script)
+}
+
 func (m WebSocketForm) Init() tea.Cmd { return nil }
 
 func (m WebSocketForm) Update(msg tea.Msg) (WebSocketForm, tea.Cmd) {
@@ -231,6 +274,10 @@ func (m WebSocketForm) updateNormal(msg tea.KeyMsg) (WebSocketForm, tea.Cmd) {
 		m.activeTab = WSTabAuth
 	case "4":
 		m.activeTab = WSTabMessages
+	case "t":
+		if m.focusField == 1 && m.activeTab == WSTabMessages {
+			m.loadNextTemplate()
+		}
 	default:
 		if m.focusField == 1 {
 			return m.updateTabContent(msg)
@@ -361,6 +408,18 @@ func (m WebSocketForm) View() string {
 		} else {
 			b.WriteString(m.styles.Hint.Render("Press Ctrl+Enter to connect"))
 		}
+		if len(m.script) > 0 {
+			b.WriteString("\n\n")
+			b.WriteString(m.styles.Hint.Render(fmt.Sprintf("%d saved message(s) sent on connect", len(m.script))))
+		}
+		if m.pingInterval > 0 {
+			b.WriteString("\n")
+			b.WriteString(m.styles.Hint.Render("Ping every " + m.pingInterval.String()))
+		}
+		if m.reconnect != nil && m.reconnect.MaxAttempts > 0 {
+			b.WriteString("\n")
+			b.WriteString(m.styles.Hint.Render(fmt.Sprintf("Reconnect up to %d time(s) when the connection drops", m.reconnect.MaxAttempts)))
+		}
 	case WSTabHeaders:
 		b.WriteString(m.headers.View())
 	case WSTabAuth:
@@ -369,7 +428,11 @@ func (m WebSocketForm) View() string {
 		if m.connected {
 			b.WriteString(m.message.View())
 			b.WriteString("\n")
-			b.WriteString(m.styles.Hint.Render("Ctrl+Enter to send"))
+			hint := "Ctrl+Enter to send"
+			if len(m.script) > 0 {
+				hint += " · t: next saved message (" + m.script[m.templateIdx].Name + ")"
+			}
+			b.WriteString(m.styles.Hint.Render(hint))
 		} else {
 			b.WriteString(m.styles.Hint.Render("Connect first to send messages"))
 		}
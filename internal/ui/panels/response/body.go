@@ -2,7 +2,10 @@ package response
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/formatters"
@@ -11,41 +14,144 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/tidwall/pretty"
 
+	"github.com/sadopc/gottp/internal/core/jsonquery"
+	"github.com/sadopc/gottp/internal/protocol"
+	"github.com/sadopc/gottp/internal/scripting"
+	"github.com/sadopc/gottp/internal/ui/msgs"
 	"github.com/sadopc/gottp/internal/ui/theme"
 )
 
+// transformTimeout bounds each evaluation of a transform expression, which
+// runs on every keystroke.
+const transformTimeout = 250 * time.Millisecond
+
 // BodyModel displays the response body with syntax highlighting.
 type BodyModel struct {
-	viewport  viewport.Model
-	search    SearchBar
-	styles    theme.Styles
-	width     int
-	height    int
-	wrap      bool
-	hasBody   bool
-	searching bool
-	raw       []byte
-	contType  string
+	viewport     viewport.Model
+	search       SearchBar
+	transform    SearchBar // JavaScript or jq expression applied to the body
+	styles       theme.Styles
+	width        int
+	height       int
+	wrap         bool
+	hasBody      bool
+	searching    bool
+	transforming bool
+	raw          []byte
+	text         string // raw decoded to UTF-8 for display
+	encoding     string // original charset when transcoded
+	contType     string
+	filtered     string // filter or transform result shown instead of the body
+	filteredLang string // lexer for filtered
+	tree         *jsonTree
+	views        []bodyView // the views t cycles through, default first
+	view         int        // index into views
+	preview      string     // HTML as text or CSV as a table
+	jwts         string     // decoded tokens from the body or Authorization header
+	rawRequest   string     // the request on the wire, for its Authorization header
 }
 
 // NewBodyModel creates a new body viewer.
 func NewBodyModel(s theme.Styles) BodyModel {
 	vp := viewport.New(0, 0)
+	transform := NewSearchBar(s)
+	transform.input.Prompt = "ƒ "
+	transform.input.Placeholder = "Transform with JavaScript (body, text), e.g. body.items.length, or $.jsonpath / .jq"
 	return BodyModel{
-		viewport: vp,
-		search:   NewSearchBar(s),
-		styles:   s,
+		viewport:  vp,
+		search:    NewSearchBar(s),
+		transform: transform,
+		styles:    s,
 	}
 }
 
 // SetContent sets the body content and highlights it.
 func (m *BodyModel) SetContent(body []byte, contentType string) {
 	m.raw = body
+	m.text, m.encoding = protocol.DecodeText(body, contentType)
 	m.contType = contentType
 	m.hasBody = len(body) > 0
+	m.filtered = ""
+	m.tree = nil
+	lang := detectLexer(contentType)
+	if lang == "json" {
+		m.tree = newJSONTree([]byte(m.text))
+	}
+	if m.tree != nil {
+		m.tree.height = m.viewport.Height
+		m.tree.clamp()
+	}
+	m.setViews(lang)
 	m.renderContent()
+	if m.searching && jsonquery.IsQuery(m.search.Query()) {
+		m.applyFilter()
+	}
+	if m.transforming {
+		m.applyTransform()
+	}
+}
+
+// SetRequest records the request as sent, whose Authorization header is
+// checked for a JWT to decode. Call it before SetContent.
+func (m *BodyModel) SetRequest(raw string) {
+	m.rawRequest = raw
+}
+
+// setViews lists the ways of showing the current body: the JSON tree, or
+// an HTML or CSV preview, ahead of the source, and any JWTs found. A body
+// that is just a token opens decoded.
+func (m *BodyModel) setViews(lang string) {
+	m.views, m.view = nil, 0
+	m.preview = ""
+	if m.tree != nil {
+		m.views = append(m.views, viewTree)
+	}
+	if lang == "html" {
+		m.preview = htmlText(m.text)
+	} else if comma := csvComma(m.contType); comma != 0 {
+		m.preview = csvTable(m.text, comma)
+	}
+	if m.preview != "" {
+		m.views = append(m.views, viewPreview)
+	}
+	m.views = append(m.views, viewSource)
+
+	m.jwts = decodeJWTs(findJWTs(m.text, m.rawRequest), time.Now())
+	if m.jwts != "" {
+		if jwtOnly(m.text) {
+			m.views = append([]bodyView{viewJWT}, m.views...)
+		} else {
+			m.views = append(m.views, viewJWT)
+		}
+	}
+}
+
+// currentView returns the view being shown.
+func (m BodyModel) currentView() bodyView {
+	if m.view < len(m.views) {
+		return m.views[m.view]
+	}
+	return viewSource
+}
+
+// shown returns the text of the current view, not counting a filter or
+// transform, and its lexer.
+func (m BodyModel) shown() (text, lang string) {
+	switch m.currentView() {
+	case viewPreview:
+		return m.preview, "text"
+	case viewJWT:
+		return m.jwts, "text"
+	}
+	lang = detectLexer(m.contType)
+	return formatSource(m.text, lang), lang
+}
+
+// Encoding returns the charset the body was transcoded from, or "" when it
+// was already UTF-8.
+func (m BodyModel) Encoding() string {
+	return m.encoding
 }
 
 // SetSize updates the viewport dimensions.
@@ -53,17 +159,71 @@ func (m *BodyModel) SetSize(w, h int) {
 	m.width = w
 	m.height = h
 	m.search.SetWidth(w)
+	m.transform.SetWidth(w)
 	vpH := h
-	if m.searching {
-		vpH-- // Reserve 1 line for search bar
+	if m.searching || m.transforming {
+		vpH-- // Reserve 1 line for search or transform bar
 	}
 	m.viewport.Width = w
 	m.viewport.Height = vpH
+	if m.tree != nil {
+		m.tree.height = vpH
+		m.tree.clamp()
+	}
 	if m.hasBody {
 		m.renderContent()
 	}
 }
 
+// Editing reports whether the search or transform bar is taking text
+// input.
+func (m BodyModel) Editing() bool {
+	return (m.searching && m.search.input.Focused()) || (m.transforming && m.transform.input.Focused())
+}
+
+// treeActive reports whether the body is shown as a JSON tree.
+func (m BodyModel) treeActive() bool {
+	return m.tree != nil && m.currentView() == viewTree && m.filtered == ""
+}
+
+// Breadcrumb returns the JSONPath of the tree node under the cursor, or ""
+// when the tree is not shown.
+func (m BodyModel) Breadcrumb() string {
+	if !m.treeActive() {
+		return ""
+	}
+	if n := m.tree.current(); n != nil {
+		return n.path()
+	}
+	return ""
+}
+
+// setViewHeight resizes the viewport and tree, e.g. when the search bar
+// opens or closes.
+func (m *BodyModel) setViewHeight(h int) {
+	m.viewport.Height = h
+	if m.tree != nil {
+		m.tree.height = h
+		m.tree.clamp()
+	}
+}
+
+// PlainText returns the body as shown, without highlighting, and the
+// name of its syntax ("" for plain text).
+func (m BodyModel) PlainText() (text, lang string) {
+	if !m.hasBody {
+		return "", ""
+	}
+	text, lang = m.filtered, m.filteredLang
+	if m.filtered == "" {
+		text, lang = m.shown()
+	}
+	if lang == "text" {
+		lang = ""
+	}
+	return strings.TrimRight(text, "\n"), lang
+}
+
 // Searching returns whether search is active.
 func (m BodyModel) Searching() bool {
 	return m.searching
@@ -74,15 +234,13 @@ func (m *BodyModel) renderContent() {
 		return
 	}
 
-	src := m.raw
-	lexerName := detectLexer(m.contType)
-
-	// Pretty-print JSON before highlighting
-	if lexerName == "json" {
-		src = pretty.Pretty(src)
+	if m.filtered != "" {
+		m.viewport.SetContent(highlight(m.filtered, m.filteredLang, m.width, m.wrap))
+		return
 	}
 
-	highlighted := highlight(string(src), lexerName, m.width, m.wrap)
+	text, lexerName := m.shown()
+	highlighted := highlight(text, lexerName, m.width, m.wrap)
 	m.viewport.SetContent(highlighted)
 }
 
@@ -91,14 +249,8 @@ func (m *BodyModel) renderContentWithSearch() {
 		return
 	}
 
-	src := m.raw
-	lexerName := detectLexer(m.contType)
-	if lexerName == "json" {
-		src = pretty.Pretty(src)
-	}
-
 	// For search highlighting, use plain text to avoid ANSI interference
-	content := string(src)
+	content, _ := m.shown()
 	if m.wrap && m.width > 0 {
 		content = wrapText(content, m.width)
 	}
@@ -113,6 +265,90 @@ func (m *BodyModel) renderContentWithSearch() {
 	}
 }
 
+// applyFilter shows the result of the JSONPath or jq expression in the
+// search bar. On errors the last good result stays visible.
+func (m *BodyModel) applyFilter() {
+	results, err := jsonquery.Query([]byte(m.text), m.search.Query())
+	if err != nil {
+		m.search.SetFilterStatus(err.Error(), true)
+		return
+	}
+	noun := "results"
+	if len(results) == 1 {
+		noun = "result"
+	}
+	m.search.SetFilterStatus(fmt.Sprintf("%d %s · y copy · Y copy as capture", len(results), noun), false)
+	m.filtered = jsonquery.Format(results)
+	m.filteredLang = "json"
+	m.renderContent()
+	m.viewport.GotoTop()
+}
+
+// applyTransform shows the result of the transform expression: jq and
+// JSONPath queries like the filter, anything else as JavaScript. On errors
+// the last good result stays visible.
+func (m *BodyModel) applyTransform() {
+	expr := strings.TrimSpace(m.transform.Query())
+	if expr == "" {
+		m.clearFilter()
+		return
+	}
+	var out string
+	if jsonquery.IsQuery(expr) {
+		results, err := jsonquery.Query([]byte(m.text), expr)
+		if err != nil {
+			m.transform.SetFilterStatus(err.Error(), true)
+			return
+		}
+		out = jsonquery.Format(results)
+	} else {
+		var err error
+		if out, err = scripting.Transform(expr, m.text, transformTimeout); err != nil {
+			m.transform.SetFilterStatus(err.Error(), true)
+			return
+		}
+		if out == "" {
+			out = `""`
+		}
+	}
+	m.filtered = out
+	m.filteredLang = "text"
+	if json.Valid([]byte(out)) {
+		m.filteredLang = "json"
+	}
+	m.transform.SetFilterStatus(fmt.Sprintf("%d lines · y copy", strings.Count(out, "\n")+1), false)
+	m.renderContent()
+	m.viewport.GotoTop()
+}
+
+// clearFilter restores the full body.
+func (m *BodyModel) clearFilter() {
+	m.filtered = ""
+	m.search.SetFilterStatus("", false)
+	m.transform.SetFilterStatus("", false)
+	m.renderContent()
+}
+
+// closeTransform hides the transform bar and restores the body.
+func (m *BodyModel) closeTransform() {
+	m.transforming = false
+	m.transform.Close()
+	m.setViewHeight(m.height)
+	m.clearFilter()
+}
+
+// Filter returns the active filter or transform expression and its
+// formatted result, or empty strings when neither is applied.
+func (m BodyModel) Filter() (expr, result string) {
+	if m.filtered == "" {
+		return "", ""
+	}
+	if m.transforming {
+		return strings.TrimSpace(m.transform.Query()), m.filtered
+	}
+	return strings.TrimSpace(m.search.Query()), m.filtered
+}
+
 func (m BodyModel) Init() tea.Cmd {
 	return nil
 }
@@ -122,26 +358,87 @@ func (m BodyModel) Update(msg tea.Msg) (BodyModel, tea.Cmd) {
 	if m.searching && m.search.input.Focused() {
 		var cmd tea.Cmd
 		m.search, cmd = m.search.Update(msg)
-		if !m.search.Active() {
+		switch {
+		case !m.search.Active():
 			// Search was closed with Esc
 			m.searching = false
-			m.viewport.Height = m.height
-			m.renderContent()
-		} else if m.search.Query() != "" {
-			// Re-render with highlights
-			m.renderContentWithSearch()
+			m.setViewHeight(m.height)
+			m.clearFilter()
+		case jsonquery.IsQuery(m.search.Query()):
+			// $... and .... are JSONPath/jq filters
+			m.applyFilter()
+		case m.filtered != "":
+			m.clearFilter()
+			fallthrough
+		case m.search.Query() != "" || m.treeActive():
+			if m.treeActive() {
+				m.searchTree()
+			} else {
+				// Re-render with highlights
+				m.renderContentWithSearch()
+			}
+		}
+		return m, cmd
+	}
+	if m.transforming && m.transform.input.Focused() {
+		var cmd tea.Cmd
+		m.transform, cmd = m.transform.Update(msg)
+		if !m.transform.Active() {
+			// Closed with Esc
+			m.closeTransform()
+		} else {
+			m.applyTransform()
 		}
 		return m, cmd
 	}
 
+	if key, ok := msg.(tea.KeyMsg); ok && m.treeActive() && m.updateTree(key) {
+		return m, nil
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "/", "ctrl+f":
+			if m.transforming {
+				m.closeTransform()
+			}
 			m.searching = true
 			m.search.Open()
-			m.viewport.Height = m.height - 1
+			m.setViewHeight(m.height - 1)
 			return m, nil
+		case "x":
+			if !m.hasBody {
+				break
+			}
+			if m.transforming {
+				// Edit the current expression again
+				m.transform.input.CursorEnd()
+				return m, m.transform.input.Focus()
+			}
+			if m.searching {
+				m.searching = false
+				m.search.Close()
+				m.clearFilter()
+			}
+			m.transforming = true
+			m.transform.Open()
+			m.setViewHeight(m.height - 1)
+			return m, nil
+		case "t":
+			if len(m.views) > 1 && m.filtered == "" {
+				m.view = (m.view + 1) % len(m.views)
+				m.renderContent()
+				m.viewport.GotoTop()
+				if m.searching && m.search.Query() != "" {
+					if m.treeActive() {
+						m.searchTree()
+					} else {
+						m.renderContentWithSearch()
+					}
+				}
+				return m, nil
+			}
 		case "w":
 			m.wrap = !m.wrap
 			m.renderContent()
@@ -155,29 +452,69 @@ func (m BodyModel) Update(msg tea.Msg) (BodyModel, tea.Cmd) {
 		case "n":
 			if m.searching && m.search.Query() != "" {
 				m.search.NextMatch()
-				line := m.search.CurrentMatchLine()
-				if line >= 0 {
-					m.viewport.SetYOffset(line)
-				}
+				m.showCurrentMatch()
 				return m, nil
 			}
 		case "N":
 			if m.searching && m.search.Query() != "" {
 				m.search.PrevMatch()
-				line := m.search.CurrentMatchLine()
-				if line >= 0 {
-					m.viewport.SetYOffset(line)
-				}
+				m.showCurrentMatch()
 				return m, nil
 			}
 		case "esc":
+			if m.transforming {
+				m.closeTransform()
+				return m, nil
+			}
 			if m.searching {
 				m.searching = false
 				m.search.Close()
-				m.viewport.Height = m.height
-				m.renderContent()
+				m.setViewHeight(m.height)
+				m.clearFilter()
 				return m, nil
 			}
+		case "a":
+			if m.hasBody {
+				return m, func() tea.Msg { return msgs.AnnotateResponseMsg{} }
+			}
+		case "p":
+			if !m.hasBody {
+				break
+			}
+			expr, _ := m.Filter()
+			if m.treeActive() {
+				if n := m.tree.current(); n != nil {
+					expr = n.path()
+				}
+			}
+			if !jsonquery.IsQuery(expr) {
+				expr = ""
+			}
+			return m, func() tea.Msg { return msgs.OpenPlaygroundMsg{Expr: expr} }
+		case "y", "Y":
+			expr, result := m.Filter()
+			if m.treeActive() {
+				if n := m.tree.current(); n != nil {
+					expr, result = n.path(), n.text()
+				}
+			}
+			if expr == "" {
+				break
+			}
+			copyMsg := msgs.CopyTextMsg{Text: result, Label: "filter result"}
+			if m.treeActive() {
+				copyMsg.Label = "value at " + expr
+			} else if m.transforming {
+				copyMsg.Label = "transform result"
+				if msg.String() == "Y" && !jsonquery.IsQuery(expr) {
+					// Only queries make workflow captures
+					break
+				}
+			}
+			if msg.String() == "Y" {
+				copyMsg = msgs.CopyTextMsg{Text: captureSnippet(expr), Label: "capture expression"}
+			}
+			return m, func() tea.Msg { return copyMsg }
 		}
 	}
 
@@ -190,10 +527,80 @@ func (m BodyModel) View() string {
 	if !m.hasBody {
 		return m.styles.Muted.Render("No response yet")
 	}
+	content := m.viewport.View()
+	if m.treeActive() {
+		content = lipgloss.NewStyle().Height(m.viewport.Height).Render(m.tree.view(m.styles, m.width))
+	}
 	if m.searching {
-		return m.viewport.View() + "\n" + m.search.View()
+		return content + "\n" + m.search.View()
 	}
-	return m.viewport.View()
+	if m.transforming {
+		return content + "\n" + m.transform.View()
+	}
+	return content
+}
+
+// updateTree handles navigation and folding keys in the JSON tree. It
+// reports whether the key was used.
+func (m *BodyModel) updateTree(msg tea.KeyMsg) bool {
+	t := m.tree
+	switch msg.String() {
+	case "j", "down":
+		t.move(1)
+	case "k", "up":
+		t.move(-1)
+	case "ctrl+d", "pgdown":
+		t.move(max(t.height/2, 1))
+	case "ctrl+u", "pgup":
+		t.move(-max(t.height/2, 1))
+	case "g", "home":
+		t.move(-len(t.lines))
+	case "G", "end":
+		t.move(len(t.lines))
+	case "enter", " ":
+		t.toggle()
+	case "h", "left":
+		t.collapse()
+	case "l", "right":
+		t.expand()
+	case "z":
+		t.setAll(true)
+	case "Z":
+		t.setAll(false)
+	default:
+		return false
+	}
+	return true
+}
+
+// searchTree highlights keys matching the search query and jumps to the
+// first one.
+func (m *BodyModel) searchTree() {
+	n := m.tree.search(m.search.Query())
+	// The search bar only counts matches here; the tree tracks the nodes
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	m.search.SetMatches(idx)
+	m.tree.jumpToMatch(0)
+}
+
+// showCurrentMatch scrolls to the search bar's current match.
+func (m *BodyModel) showCurrentMatch() {
+	if m.treeActive() {
+		m.tree.jumpToMatch(m.search.current)
+		return
+	}
+	if line := m.search.CurrentMatchLine(); line >= 0 {
+		m.viewport.SetYOffset(line)
+	}
+}
+
+// captureSnippet renders expr as a workflow step extract, named after the
+// last field in the expression.
+func captureSnippet(expr string) string {
+	return fmt.Sprintf("%s: %q", jsonquery.VarName(expr), expr)
 }
 
 // detectLexer maps Content-Type to a chroma lexer name.
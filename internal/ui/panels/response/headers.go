@@ -9,34 +9,101 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/sadopc/gottp/internal/protocol"
+	"github.com/sadopc/gottp/internal/ui/msgs"
 	"github.com/sadopc/gottp/internal/ui/theme"
 )
 
-// HeadersModel displays response headers as a two-column list.
+// HeadersModel displays response headers as a two-column list, followed by
+// trailers and 1xx interim responses when the server sent any. j/k select a
+// header, y copies its value and Y the whole line, and / filters by name or
+// value. Security-relevant headers are highlighted.
 type HeadersModel struct {
 	viewport   viewport.Model
+	filter     SearchBar
+	filtering  bool
 	styles     theme.Styles
 	width      int
 	height     int
 	hasHeaders bool
+
+	rows   []headerRow
+	shown  []int // indices into rows that match the filter
+	cursor int   // index into shown
+}
+
+// headerRow is one header line in its section: the response headers
+// (section ""), the trailers, or an interim response.
+type headerRow struct {
+	section string
+	key     string
+	value   string
 }
 
 // NewHeadersModel creates a new headers viewer.
 func NewHeadersModel(s theme.Styles) HeadersModel {
 	vp := viewport.New(0, 0)
+	filter := NewSearchBar(s)
+	filter.input.Placeholder = "Filter headers by name or value"
 	return HeadersModel{
 		viewport: vp,
+		filter:   filter,
 		styles:   s,
 	}
 }
 
 // SetHeaders populates the header display.
 func (m *HeadersModel) SetHeaders(headers http.Header) {
-	m.hasHeaders = len(headers) > 0
-	if !m.hasHeaders {
-		return
+	m.SetResponse(headers, nil, nil)
+}
+
+// SetResponse populates the header display along with trailers and interim
+// responses. gRPC responses carry their trailers as Trailer- prefixed
+// headers, with the call status in Grpc-Status and Grpc-Message; those are
+// listed under Trailers.
+func (m *HeadersModel) SetResponse(headers, trailers http.Header, interim []protocol.InterimResponse) {
+	headers, trailers = splitGRPCTrailers(headers, trailers)
+	m.hasHeaders = len(headers) > 0 || len(trailers) > 0 || len(interim) > 0
+
+	m.rows = headerRows("", headers)
+	m.rows = append(m.rows, headerRows("Trailers", trailers)...)
+	for _, ir := range interim {
+		title := fmt.Sprintf("%d %s", ir.StatusCode, http.StatusText(ir.StatusCode))
+		section := "Interim: " + strings.TrimSpace(title)
+		rows := headerRows(section, ir.Headers)
+		if len(rows) == 0 {
+			rows = []headerRow{{section: section}}
+		}
+		m.rows = append(m.rows, rows...)
+	}
+	m.cursor = 0
+	m.applyFilter()
+}
+
+// splitGRPCTrailers moves the trailers of a gRPC response out of its
+// headers. Other responses are returned unchanged.
+func splitGRPCTrailers(headers, trailers http.Header) (http.Header, http.Header) {
+	if headers.Get("Grpc-Status") == "" {
+		return headers, trailers
 	}
+	h, t := make(http.Header), trailers.Clone()
+	if t == nil {
+		t = make(http.Header)
+	}
+	for k, v := range headers {
+		switch name, ok := strings.CutPrefix(k, "Trailer-"); {
+		case ok:
+			t[http.CanonicalHeaderKey(name)] = v
+		case k == "Grpc-Status" || k == "Grpc-Message":
+			t[k] = v
+		default:
+			h[k] = v
+		}
+	}
+	return h, t
+}
 
+func headerRows(section string, headers http.Header) []headerRow {
 	// Sort header keys for consistent display
 	keys := make([]string, 0, len(headers))
 	for k := range headers {
@@ -44,24 +111,129 @@ func (m *HeadersModel) SetHeaders(headers http.Header) {
 	}
 	sort.Strings(keys)
 
-	var b strings.Builder
+	rows := make([]headerRow, 0, len(keys))
 	for _, k := range keys {
-		values := headers[k]
-		key := m.styles.Key.Render(k)
+		rows = append(rows, headerRow{section: section, key: k, value: strings.Join(headers[k], ", ")})
+	}
+	return rows
+}
+
+// securityHeader reports whether name is a header that governs CORS,
+// transport security, caching or what the browser may do with the page.
+func securityHeader(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasPrefix(name, "access-control-") || strings.HasPrefix(name, "cross-origin-") {
+		return true
+	}
+	switch name {
+	case "strict-transport-security", "cache-control", "pragma", "expires",
+		"content-security-policy", "content-security-policy-report-only",
+		"x-frame-options", "x-content-type-options", "referrer-policy",
+		"permissions-policy", "set-cookie", "vary":
+		return true
+	}
+	return false
+}
+
+// applyFilter lists the rows matching the filter and re-renders.
+func (m *HeadersModel) applyFilter() {
+	query := strings.ToLower(m.filter.Query())
+	m.shown = m.shown[:0]
+	for i, r := range m.rows {
+		if r.key == "" && query != "" {
+			continue
+		}
+		if query == "" || strings.Contains(strings.ToLower(r.key), query) || strings.Contains(strings.ToLower(r.value), query) {
+			m.shown = append(m.shown, i)
+		}
+	}
+	m.cursor = min(m.cursor, max(len(m.shown)-1, 0))
+	if m.filtering && query != "" {
+		m.filter.SetFilterStatus(fmt.Sprintf("%d of %d · y copy value · Y copy line", len(m.shown), m.headerCount()), len(m.shown) == 0)
+	} else {
+		m.filter.SetFilterStatus("", false)
+	}
+	m.render()
+}
+
+func (m HeadersModel) headerCount() int {
+	n := 0
+	for _, r := range m.rows {
+		if r.key != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// render lays out the shown rows under their section titles, marks the
+// selected one and scrolls it into view.
+func (m *HeadersModel) render() {
+	var lines []string
+	selectedLine := 0
+	section := ""
+	for i, idx := range m.shown {
+		r := m.rows[idx]
+		if r.section != section {
+			if len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, m.styles.Title.Render(r.section))
+			section = r.section
+		}
+		if r.key == "" {
+			continue
+		}
+		marker := "  "
+		if i == m.cursor {
+			marker = m.styles.Cursor.Render("› ")
+			selectedLine = len(lines)
+		}
+		key := m.styles.Key.Render(r.key)
+		if securityHeader(r.key) {
+			key = m.styles.Warning.Render(r.key)
+		}
 		sep := m.styles.Muted.Render(" : ")
-		val := m.styles.Normal.Render(strings.Join(values, ", "))
-		fmt.Fprintf(&b, "%s%s%s\n", key, sep, val)
+		val := m.styles.Normal.Render(r.value)
+		lines = append(lines, marker+key+sep+val)
+	}
+	if len(lines) == 0 && m.hasHeaders {
+		lines = append(lines, m.styles.Muted.Render("No headers match"))
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+
+	if selectedLine < m.viewport.YOffset {
+		m.viewport.SetYOffset(selectedLine)
+	} else if h := m.viewport.Height; h > 0 && selectedLine >= m.viewport.YOffset+h {
+		m.viewport.SetYOffset(selectedLine - h + 1)
 	}
+}
 
-	m.viewport.SetContent(strings.TrimRight(b.String(), "\n"))
+// Selected returns the name and value of the selected header.
+func (m HeadersModel) Selected() (key, value string, ok bool) {
+	if m.cursor >= len(m.shown) {
+		return "", "", false
+	}
+	r := m.rows[m.shown[m.cursor]]
+	return r.key, r.value, r.key != ""
+}
+
+// Editing reports whether the filter is taking text input.
+func (m HeadersModel) Editing() bool {
+	return m.filtering && m.filter.input.Focused()
 }
 
 // SetSize updates the viewport dimensions.
 func (m *HeadersModel) SetSize(w, h int) {
 	m.width = w
 	m.height = h
+	m.filter.SetWidth(w)
 	m.viewport.Width = w
 	m.viewport.Height = h
+	if m.filtering {
+		m.viewport.Height = max(h-1, 0)
+	}
+	m.render()
 }
 
 func (m HeadersModel) Init() tea.Cmd {
@@ -69,6 +241,57 @@ func (m HeadersModel) Init() tea.Cmd {
 }
 
 func (m HeadersModel) Update(msg tea.Msg) (HeadersModel, tea.Cmd) {
+	if m.Editing() {
+		var cmd tea.Cmd
+		m.filter, cmd = m.filter.Update(msg)
+		if !m.filter.Active() {
+			m.filtering = false
+			m.SetSize(m.width, m.height)
+		}
+		m.applyFilter()
+		return m, cmd
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "/", "ctrl+f":
+			m.filtering = true
+			m.filter.Open()
+			m.SetSize(m.width, m.height)
+			return m, nil
+		case "esc":
+			if m.filtering {
+				m.filtering = false
+				m.filter.Close()
+				m.SetSize(m.width, m.height)
+				m.applyFilter()
+				return m, nil
+			}
+		case "j", "down":
+			if m.cursor < len(m.shown)-1 {
+				m.cursor++
+				m.render()
+			}
+			return m, nil
+		case "k", "up":
+			if m.cursor > 0 {
+				m.cursor--
+				m.render()
+			}
+			return m, nil
+		case "y", "Y":
+			key, value, ok := m.Selected()
+			if !ok {
+				return m, nil
+			}
+			copyMsg := msgs.CopyTextMsg{Text: value, Label: key}
+			if msg.String() == "Y" {
+				copyMsg.Text = key + ": " + value
+			}
+			return m, func() tea.Msg { return copyMsg }
+		}
+	}
+
 	var cmd tea.Cmd
 	m.viewport, cmd = m.viewport.Update(msg)
 	return m, cmd
@@ -78,5 +301,8 @@ func (m HeadersModel) View() string {
 	if !m.hasHeaders {
 		return m.styles.Muted.Render("No headers")
 	}
+	if m.filtering {
+		return m.filter.View() + "\n" + m.viewport.View()
+	}
 	return m.viewport.View()
 }
@@ -0,0 +1,53 @@
+package response
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// RawModel shows the request exactly as it was framed on the wire, so
+// Expect, Content-Length and chunked encoding can be checked.
+type RawModel struct {
+	viewport viewport.Model
+	styles   theme.Styles
+	hasRaw   bool
+}
+
+// NewRawModel creates a new raw request viewer.
+func NewRawModel(s theme.Styles) RawModel {
+	return RawModel{
+		viewport: viewport.New(0, 0),
+		styles:   s,
+	}
+}
+
+// SetRaw sets the raw request text, normalizing CRLF line endings for
+// display.
+func (m *RawModel) SetRaw(raw string) {
+	m.hasRaw = raw != ""
+	m.viewport.SetContent(strings.ReplaceAll(raw, "\r\n", "\n"))
+	m.viewport.GotoTop()
+}
+
+// SetSize updates the viewport dimensions.
+func (m *RawModel) SetSize(w, h int) {
+	m.viewport.Width = w
+	m.viewport.Height = h
+}
+
+func (m RawModel) Update(msg tea.Msg) (RawModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m RawModel) View() string {
+	if !m.hasRaw {
+		return m.styles.Muted.Render("No raw request captured")
+	}
+	return m.viewport.View()
+}
@@ -2,11 +2,13 @@ package response
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
 
 	"github.com/sadopc/gottp/internal/protocol"
 	"github.com/sadopc/gottp/internal/ui/theme"
@@ -21,6 +23,7 @@ const (
 	tabTiming
 	tabDiff
 	tabConsole
+	tabRaw
 )
 
 // responseMode determines which tab set to show.
@@ -31,7 +34,7 @@ const (
 	modeWebSocket
 )
 
-var httpTabLabels = []string{"Body", "Headers", "Cookies", "Timing", "Diff", "Console"}
+var httpTabLabels = []string{"Body", "Headers", "Cookies", "Timing", "Diff", "Console", "Raw"}
 var wsTabLabels = []string{"Messages", "Headers", "Timing"}
 
 // ws-specific tabs
@@ -41,7 +44,7 @@ const (
 	wsTabTiming   subTab = 2
 )
 
-// Model is the response panel container wrapping body, headers, cookies, timing, diff, console, and WS log.
+// Model is the response panel container wrapping body, headers, cookies, timing, diff, console, raw request, and WS log.
 type Model struct {
 	body    BodyModel
 	headers HeadersModel
@@ -49,6 +52,7 @@ type Model struct {
 	timing  TimingModel
 	diff    DiffModel
 	console ConsoleModel
+	raw     RawModel
 	wslog   WSLogModel
 	spinner spinner.Model
 
@@ -64,6 +68,23 @@ type Model struct {
 	width    int
 	height   int
 	baseline []byte
+
+	// Download progress while loading, and where a streamed body was saved
+	received int64
+	total    int64
+	bodyFile string
+	size     int64
+
+	// fromCache marks a 304 whose body came from the response cache
+	fromCache bool
+
+	warnings     []protocol.Warning
+	warningsOpen bool
+
+	// last is the response shown, and pinned a panel showing an earlier
+	// one beside it for comparison
+	last   *protocol.Response
+	pinned *Model
 }
 
 // New creates a new response panel model.
@@ -79,6 +100,7 @@ func New(t theme.Theme, s theme.Styles) Model {
 		timing:  NewTimingModel(t, s),
 		diff:    NewDiffModel(t, s),
 		console: NewConsoleModel(t, s),
+		raw:     NewRawModel(s),
 		wslog:   NewWSLogModel(t, s),
 		spinner: sp,
 		styles:  s,
@@ -91,6 +113,7 @@ func (m *Model) SetMode(proto string) {
 	if proto == "websocket" {
 		m.mode = modeWebSocket
 		m.active = wsTabMessages
+		m.Unpin()
 	} else {
 		m.mode = modeHTTP
 		m.active = tabBody
@@ -100,6 +123,7 @@ func (m *Model) SetMode(proto string) {
 // SetResponse populates all sub-models from a response.
 func (m *Model) SetResponse(resp *protocol.Response) {
 	m.loading = false
+	m.last = resp
 	if resp == nil {
 		m.hasResp = false
 		return
@@ -107,11 +131,16 @@ func (m *Model) SetResponse(resp *protocol.Response) {
 	m.hasResp = true
 	m.code = resp.StatusCode
 	m.status = resp.Status
+	m.bodyFile = resp.BodyFile
+	m.size = resp.Size
+	m.fromCache = resp.FromCache
 
+	m.body.SetRequest(resp.RawRequest)
 	m.body.SetContent(resp.Body, resp.ContentType)
-	m.headers.SetHeaders(resp.Headers)
+	m.headers.SetResponse(resp.Headers, resp.Trailers, resp.Interim)
 	m.cookies.SetHeaders(resp.Headers)
 	m.timing.SetResponse(resp)
+	m.raw.SetRaw(resp.RawRequest)
 
 	// Auto-compute diff if baseline exists
 	if m.baseline != nil {
@@ -119,6 +148,34 @@ func (m *Model) SetResponse(resp *protocol.Response) {
 	}
 }
 
+// Pin keeps the current response in a pane beside the responses that
+// follow, showing the same sub-tab, until Unpin. It reports false when
+// there is no HTTP response to pin.
+func (m *Model) Pin() bool {
+	if !m.hasResp || m.loading || m.mode != modeHTTP || m.last == nil {
+		return false
+	}
+	pinned := New(m.th, m.styles)
+	pinned.SetResponse(m.last)
+	m.pinned = &pinned
+	m.SetSize(m.width, m.height)
+	return true
+}
+
+// Unpin closes the pinned response's pane.
+func (m *Model) Unpin() {
+	if m.pinned == nil {
+		return
+	}
+	m.pinned = nil
+	m.SetSize(m.width, m.height)
+}
+
+// Pinned reports whether a pinned response is shown.
+func (m Model) Pinned() bool {
+	return m.pinned != nil
+}
+
 // SetBaseline saves the current response body as the diff baseline.
 func (m *Model) SetBaseline(body []byte) {
 	m.baseline = make([]byte, len(body))
@@ -141,9 +198,33 @@ func (m Model) ResponseBody() []byte {
 	return m.body.raw
 }
 
+// Status returns the status line of the current response.
+func (m Model) Status() string {
+	return m.status
+}
+
+// BodyText returns the body as shown, without highlighting: the filter or
+// transform result when one is active, pretty-printed JSON otherwise. lang
+// names its syntax for code fences ("" for plain text).
+func (m Model) BodyText() (text, lang string) {
+	return m.body.PlainText()
+}
+
 // SetLoading puts the panel into loading state.
 func (m *Model) SetLoading(loading bool) {
 	m.loading = loading
+	m.received, m.total = 0, 0
+}
+
+// Loading reports whether a request is in flight.
+func (m Model) Loading() bool {
+	return m.loading
+}
+
+// SetProgress updates the download progress shown while loading. Total is
+// -1 when the length is unknown.
+func (m *Model) SetProgress(received, total int64) {
+	m.received, m.total = received, total
 }
 
 // SetFocused sets whether this panel has focus.
@@ -157,11 +238,45 @@ func (m *Model) AddWSMessage(msg WSMessage) {
 	m.hasResp = true
 }
 
+// AddWSMessages adds several WebSocket messages to the log, redrawing it
+// once.
+func (m *Model) AddWSMessages(batch []WSMessage) {
+	if len(batch) == 0 {
+		return
+	}
+	m.wslog.AddMessages(batch)
+	m.hasResp = true
+}
+
+// SetWSBufferSize sets how many WebSocket messages are kept in memory.
+func (m *Model) SetWSBufferSize(n int) {
+	m.wslog.SetBufferSize(n)
+}
+
+// ExportWSLog writes the full WebSocket message log to w as JSON lines and
+// returns the number of messages written.
+func (m Model) ExportWSLog(w io.Writer) (int, error) {
+	return m.wslog.WriteLog(w)
+}
+
+// WSMessageCount returns how many WebSocket messages the log has seen since
+// it was last cleared.
+func (m Model) WSMessageCount() int {
+	return m.wslog.MessageCount()
+}
+
 // ClearWSLog clears the WebSocket message log.
 func (m *Model) ClearWSLog() {
 	m.wslog.Clear()
 }
 
+// SetWarnings replaces the pre-flight warnings shown above the response.
+// The banner starts collapsed.
+func (m *Model) SetWarnings(warnings []protocol.Warning) {
+	m.warnings = warnings
+	m.warningsOpen = false
+}
+
 // SetScriptResults sets the script console output.
 func (m *Model) SetScriptResults(logs []string, tests []ScriptTestResult, errMsg string) {
 	m.console.SetResults(logs, tests, errMsg)
@@ -181,13 +296,28 @@ func (m *Model) SetSize(w, h int) {
 	if innerH < 0 {
 		innerH = 0
 	}
+	if m.pinned != nil {
+		paneW := pinnedWidth(innerW)
+		m.pinned.sizeTabs(paneW, innerH)
+		innerW -= paneW + 1
+	}
+	m.sizeTabs(innerW, innerH)
+}
 
+// pinnedWidth is the width of the pinned pane when the panel's content is
+// w wide: the left half, less the separator.
+func pinnedWidth(w int) int {
+	return max((w-1)/2, 0)
+}
+
+func (m *Model) sizeTabs(innerW, innerH int) {
 	m.body.SetSize(innerW, innerH)
 	m.headers.SetSize(innerW, innerH)
 	m.cookies.SetSize(innerW, innerH)
 	m.timing.SetSize(innerW, innerH)
 	m.diff.SetSize(innerW, innerH)
 	m.console.SetSize(innerW, innerH)
+	m.raw.SetSize(innerW, innerH)
 	m.wslog.SetSize(innerW, innerH)
 }
 
@@ -206,7 +336,41 @@ func (m Model) tabCount() int {
 	return len(m.tabLabels())
 }
 
+// Breadcrumb returns the JSONPath of the selected node in the body's JSON
+// tree, or "" when the tree is not shown.
+func (m Model) Breadcrumb() string {
+	if m.mode != modeHTTP || m.active != tabBody || m.loading {
+		return ""
+	}
+	return m.body.Breadcrumb()
+}
+
+// Editing reports whether the body search/filter bar, the headers filter or
+// the WebSocket log filter is taking text input, in which case every key
+// belongs to it.
+func (m Model) Editing() bool {
+	if m.active == tabHeaders && m.headers.Editing() {
+		return true
+	}
+	if m.mode == modeWebSocket {
+		return m.active == wsTabMessages && m.wslog.Editing()
+	}
+	return m.mode == modeHTTP && m.active == tabBody && m.body.Editing()
+}
+
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if _, ok := msg.(tea.KeyMsg); ok && m.Editing() {
+		var cmd tea.Cmd
+		if m.active == tabHeaders && m.headers.Editing() {
+			m.headers, cmd = m.headers.Update(msg)
+		} else if m.mode == modeWebSocket {
+			m.wslog, cmd = m.wslog.Update(msg)
+		} else {
+			m.body, cmd = m.body.Update(msg)
+		}
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -248,6 +412,16 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				m.active = 5
 			}
 			return m, nil
+		case "7":
+			if m.tabCount() > 6 {
+				m.active = 6
+			}
+			return m, nil
+		case "!":
+			if len(m.warnings) > 0 {
+				m.warningsOpen = !m.warningsOpen
+				return m, nil
+			}
 		}
 	case spinner.TickMsg:
 		if m.loading {
@@ -282,6 +456,8 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			m.diff, cmd = m.diff.Update(msg)
 		case tabConsole:
 			m.console, cmd = m.console.Update(msg)
+		case tabRaw:
+			m.raw, cmd = m.raw.Update(msg)
 		}
 	}
 
@@ -303,20 +479,64 @@ func (m Model) View() string {
 		innerH = 0
 	}
 
+	banner := m.renderWarnings(innerW)
+	contentH := innerH - lipgloss.Height(banner)
+	if banner == "" {
+		contentH = innerH
+	}
+	if contentH < 0 {
+		contentH = 0
+	}
+
 	var content string
-	if m.loading {
-		content = m.renderLoading(innerW, innerH)
-	} else if !m.hasResp {
-		content = m.renderEmpty(innerW, innerH)
+	if m.pinned != nil {
+		content = m.renderSplit(innerW, contentH)
 	} else {
-		content = m.renderResponse(innerW, innerH)
+		content = m.renderPane(innerW, contentH)
+	}
+	if banner != "" {
+		content = lipgloss.JoinVertical(lipgloss.Left, banner, content)
 	}
 
 	return border.Width(innerW).Height(innerH).Render(content)
 }
 
+func (m Model) renderPane(w, h int) string {
+	switch {
+	case m.loading:
+		return m.renderLoading(w, h)
+	case !m.hasResp:
+		return m.renderEmpty(w, h)
+	default:
+		return m.renderResponse(w, h)
+	}
+}
+
+// renderSplit shows the pinned response on the left and the current one on
+// the right, both on the current sub-tab.
+func (m Model) renderSplit(w, h int) string {
+	leftW := pinnedWidth(w)
+	rightW := max(w-leftW-1, 0)
+
+	pinned := *m.pinned
+	pinned.active = m.active
+	title := lipgloss.NewStyle().Foreground(m.th.Mauve).Bold(true).Width(leftW).Render("Pinned")
+	body := lipgloss.NewStyle().Width(leftW).Height(max(h-2, 0)).MaxHeight(max(h-2, 0)).Render(pinned.tabView())
+	left := lipgloss.JoinVertical(lipgloss.Left, title, pinned.renderStatus(leftW), body)
+	left = lipgloss.NewStyle().Width(leftW).Height(h).MaxHeight(h).Render(left)
+
+	sep := m.styles.Muted.Render(strings.TrimSuffix(strings.Repeat("│\n", max(h, 1)), "\n"))
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, sep, m.renderPane(rightW, h))
+}
+
 func (m Model) renderLoading(w, h int) string {
 	msg := fmt.Sprintf("%s Sending request...", m.spinner.View())
+	if m.received > 0 {
+		msg = fmt.Sprintf("%s Downloading %s", m.spinner.View(), humanize.IBytes(uint64(m.received)))
+		if m.total > 0 {
+			msg += fmt.Sprintf(" of %s (%d%%)", humanize.IBytes(uint64(m.total)), m.received*100/m.total)
+		}
+	}
 	return lipgloss.Place(w, h, lipgloss.Center, lipgloss.Center, msg)
 }
 
@@ -335,36 +555,66 @@ func (m Model) renderResponse(w, h int) string {
 		contentH = 0
 	}
 
-	var body string
+	body := lipgloss.NewStyle().Width(w).Height(contentH).MaxHeight(contentH).Render(m.tabView())
+
+	return lipgloss.JoinVertical(lipgloss.Left, tabs, status, body)
+}
+
+// tabView renders the active sub-tab.
+func (m Model) tabView() string {
 	if m.mode == modeWebSocket {
 		switch m.active {
 		case wsTabMessages:
-			body = m.wslog.View()
+			return m.wslog.View()
 		case wsTabHeaders:
-			body = m.headers.View()
+			return m.headers.View()
 		case wsTabTiming:
-			body = m.timing.View()
-		}
-	} else {
-		switch m.active {
-		case tabBody:
-			body = m.body.View()
-		case tabHeaders:
-			body = m.headers.View()
-		case tabCookies:
-			body = m.cookies.View()
-		case tabTiming:
-			body = m.timing.View()
-		case tabDiff:
-			body = m.diff.View()
-		case tabConsole:
-			body = m.console.View()
+			return m.timing.View()
 		}
+		return ""
 	}
+	switch m.active {
+	case tabBody:
+		return m.body.View()
+	case tabHeaders:
+		return m.headers.View()
+	case tabCookies:
+		return m.cookies.View()
+	case tabTiming:
+		return m.timing.View()
+	case tabDiff:
+		return m.diff.View()
+	case tabConsole:
+		return m.console.View()
+	case tabRaw:
+		return m.raw.View()
+	}
+	return ""
+}
 
-	body = lipgloss.NewStyle().Width(w).Height(contentH).Render(body)
-
-	return lipgloss.JoinVertical(lipgloss.Left, tabs, status, body)
+// renderWarnings draws the pre-flight warning banner: a one-line summary
+// when collapsed, the full list when expanded.
+func (m Model) renderWarnings(width int) string {
+	if len(m.warnings) == 0 {
+		return ""
+	}
+	style := lipgloss.NewStyle().Foreground(m.th.Yellow).Width(width)
+	noun := "warning"
+	if len(m.warnings) > 1 {
+		noun = "warnings"
+	}
+	if !m.warningsOpen {
+		first := m.warnings[0].String()
+		if len(m.warnings) > 1 {
+			first += fmt.Sprintf(" (+%d more)", len(m.warnings)-1)
+		}
+		return style.MaxHeight(1).Render(fmt.Sprintf("⚠ %s · ! to expand", first))
+	}
+	lines := []string{fmt.Sprintf("⚠ %d pre-flight %s · ! to collapse", len(m.warnings), noun)}
+	for _, w := range m.warnings {
+		lines = append(lines, "  • "+w.String())
+	}
+	return style.Render(strings.Join(lines, "\n"))
 }
 
 func (m Model) renderTabs(width int) string {
@@ -378,7 +628,12 @@ func (m Model) renderTabs(width int) string {
 		}
 	}
 	row := strings.Join(tabs, " ")
-	return lipgloss.NewStyle().Width(width).Render(row)
+	if lipgloss.Width(row) > width {
+		// Too narrow, e.g. beside a pinned response: name the active tab
+		row = m.styles.TabActive.Render(labels[m.active]) +
+			m.styles.Muted.Render(fmt.Sprintf(" %d/%d", m.active+1, len(labels)))
+	}
+	return lipgloss.NewStyle().Width(width).MaxHeight(1).Render(row)
 }
 
 func (m Model) renderStatus(width int) string {
@@ -391,5 +646,18 @@ func (m Model) renderStatus(width int) string {
 	}
 	color := m.th.StatusColor(m.code)
 	statusStyle := lipgloss.NewStyle().Foreground(color).Bold(true)
+	var notes []string
+	if m.fromCache {
+		notes = append(notes, "304 served from cache")
+	}
+	if m.bodyFile != "" {
+		notes = append(notes, fmt.Sprintf("saved %s to %s (preview below)", humanize.IBytes(uint64(m.size)), m.bodyFile))
+	}
+	if enc := m.body.Encoding(); enc != "" {
+		notes = append(notes, "decoded from "+enc)
+	}
+	if len(notes) > 0 {
+		return lipgloss.NewStyle().Width(width).MaxHeight(1).Render(statusStyle.Render(m.status) + m.styles.Muted.Render("  · "+strings.Join(notes, " · ")))
+	}
 	return statusStyle.Width(width).Render(m.status)
 }
@@ -1,6 +1,8 @@
 package response
 
 import (
+	"encoding/base64"
+	"fmt"
 	"net/http"
 	"strings"
 	"testing"
@@ -8,8 +10,10 @@ import (
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/sadopc/gottp/internal/diff"
 	"github.com/sadopc/gottp/internal/protocol"
+	"github.com/sadopc/gottp/internal/ui/msgs"
 	"github.com/sadopc/gottp/internal/ui/theme"
 )
 
@@ -22,8 +26,8 @@ func newResponseModelForTest() Model {
 
 func TestResponseModel_ModeTabsAndSetResponse(t *testing.T) {
 	m := newResponseModelForTest()
-	if got := len(m.tabLabels()); got != 6 {
-		t.Fatalf("http tab count = %d, want 6", got)
+	if got := len(m.tabLabels()); got != 7 {
+		t.Fatalf("http tab count = %d, want 7", got)
 	}
 
 	m.SetMode("websocket")
@@ -45,6 +49,7 @@ func TestResponseModel_ModeTabsAndSetResponse(t *testing.T) {
 		Size:        128,
 		Proto:       "HTTP/1.1",
 		TLS:         true,
+		RawRequest:  "POST /upload HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n",
 	}
 	m.SetBaseline([]byte(`{"ok":false}`))
 	m.SetResponse(resp)
@@ -65,6 +70,14 @@ func TestResponseModel_ModeTabsAndSetResponse(t *testing.T) {
 		t.Fatalf("unexpected response body: %q", got)
 	}
 
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'7'}})
+	if m.active != tabRaw {
+		t.Fatalf("active tab after '7' = %d, want %d", m.active, tabRaw)
+	}
+	if got := m.View(); !strings.Contains(got, "Transfer-Encoding: chunked") {
+		t.Fatalf("raw view missing request framing: %q", got)
+	}
+
 	m.ClearBaseline()
 	if m.HasBaseline() {
 		t.Fatal("expected baseline to be cleared")
@@ -152,6 +165,17 @@ func TestResponseSubmodels_BodyHeadersCookiesTimingDiffConsoleWS(t *testing.T) {
 	if !strings.Contains(headers.View(), "X-Alpha") {
 		t.Fatalf("headers view missing X-Alpha: %q", headers.View())
 	}
+	headers.SetSize(60, 12)
+	headers.SetResponse(
+		http.Header{"X-Alpha": {"1"}},
+		http.Header{"Grpc-Status": {"0"}},
+		[]protocol.InterimResponse{{StatusCode: 103, Headers: http.Header{"Link": {"</a.css>"}}}},
+	)
+	for _, want := range []string{"Trailers", "Grpc-Status", "Interim: 103 Early Hints", "Link"} {
+		if !strings.Contains(headers.View(), want) {
+			t.Fatalf("headers view missing %q: %q", want, headers.View())
+		}
+	}
 
 	cookies := NewCookiesModel(styles)
 	cookies.SetSize(80, 8)
@@ -182,6 +206,13 @@ func TestResponseSubmodels_BodyHeadersCookiesTimingDiffConsoleWS(t *testing.T) {
 	if !strings.Contains(timing.View(), "Waterfall") {
 		t.Fatalf("timing view missing Waterfall: %q", timing.View())
 	}
+	if !strings.Contains(timing.View(), "new (cold)") {
+		t.Fatalf("timing view missing connection row: %q", timing.View())
+	}
+	warm := &protocol.TimingDetail{ConnReused: true, ConnIdle: 1500 * time.Millisecond, RemoteAddr: "10.0.0.1:443"}
+	if got := connectionSummary(warm); got != "reused (warm), idle 1.50s · 10.0.0.1:443" {
+		t.Fatalf("connectionSummary = %q", got)
+	}
 	if got := formatSize(2048); got != "2.0 KB" {
 		t.Fatalf("formatSize(2048) = %q", got)
 	}
@@ -237,6 +268,138 @@ func TestResponseSubmodels_BodyHeadersCookiesTimingDiffConsoleWS(t *testing.T) {
 	}
 }
 
+func TestWSLog_RingBufferPagingAndExport(t *testing.T) {
+	th := theme.Default()
+	ws := NewWSLogModel(th, theme.NewStyles(th))
+	ws.SetSize(80, 8)
+	ws.SetBufferSize(150)
+	t.Cleanup(ws.Clear)
+	for i := 1; i <= 400; i++ {
+		ws.AddMessage(WSMessage{Direction: "received", Content: fmt.Sprintf("msg-%d", i), Timestamp: time.Now()})
+	}
+
+	if ws.MessageCount() != 400 || ws.count != 150 {
+		t.Fatalf("total = %d, in memory = %d; want 400, 150", ws.MessageCount(), ws.count)
+	}
+	if v := ws.View(); !strings.Contains(v, "400 messages (last 150 in memory)") || !strings.Contains(v, "showing 301-400") {
+		t.Fatalf("unexpected header: %q", v)
+	}
+
+	// Page back to the oldest message still in memory, then follow again
+	ws, _ = ws.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("<")})
+	if !strings.Contains(ws.View(), "showing 251-350") {
+		t.Fatalf("expected the older page, got %q", ws.View())
+	}
+	ws, _ = ws.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("<")})
+	ws.AddMessage(WSMessage{Direction: "sent", Content: "msg-401", Timestamp: time.Now()})
+	if !strings.Contains(ws.View(), "showing 252-351") {
+		t.Fatalf("paged view should stay put as messages arrive, got %q", ws.View())
+	}
+	ws, _ = ws.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(">")})
+	ws, _ = ws.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(">")})
+	if !strings.Contains(ws.View(), "showing 302-401") {
+		t.Fatalf("expected to follow new messages, got %q", ws.View())
+	}
+
+	var buf strings.Builder
+	n, err := ws.WriteLog(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if n != 401 || len(lines) != 401 {
+		t.Fatalf("exported %d messages (%d lines), want 401", n, len(lines))
+	}
+	if !strings.Contains(lines[0], `"content":"msg-1"`) || !strings.Contains(lines[400], `"direction":"sent"`) {
+		t.Errorf("unexpected export: %s ... %s", lines[0], lines[400])
+	}
+}
+
+func TestWSLog_AddMessagesBatch(t *testing.T) {
+	m := newResponseModelForTest()
+	m.SetMode("websocket")
+	m.AddWSMessages(nil)
+	if m.hasResp {
+		t.Fatal("an empty batch should not count as a response")
+	}
+
+	var batch []WSMessage
+	for i := 1; i <= 3; i++ {
+		batch = append(batch, WSMessage{Direction: "received", Content: fmt.Sprintf("tick-%d", i), Timestamp: time.Now()})
+	}
+	m.AddWSMessages(batch)
+	if m.WSMessageCount() != 3 || !m.hasResp {
+		t.Fatalf("count = %d, hasResp = %v", m.WSMessageCount(), m.hasResp)
+	}
+	if v := m.wslog.View(); !strings.Contains(v, "tick-1") || !strings.Contains(v, "tick-3") {
+		t.Fatalf("log missing batched messages: %q", v)
+	}
+}
+
+func TestWSLog_FiltersAndPause(t *testing.T) {
+	m := newResponseModelForTest()
+	m.SetMode("websocket")
+	add := func(dir, content string) {
+		m.AddWSMessage(WSMessage{Direction: dir, Content: content, Timestamp: time.Now()})
+	}
+	add("sent", `{"op":"subscribe"}`)
+	add("received", `{"op":"tick","price":10}`)
+	add("received", `{"op":"error","code":42}`)
+	add("received", "plain ERROR text")
+	typeKeys := func(s string) {
+		for _, r := range s {
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	typeKeys("/")
+	if !m.Editing() {
+		t.Fatal("/ should open the filter input")
+	}
+	typeKeys("error")
+	if v := m.View(); !strings.Contains(v, "2 matching") || strings.Contains(v, "tick") {
+		t.Fatalf("substring filter should be case-insensitive:\n%s", v)
+	}
+
+	// Regex, then JSONPath; an invalid expression keeps the last good filter
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	typeKeys("/^plain/")
+	if !strings.Contains(m.View(), "1 matching") {
+		t.Fatalf("regex filter:\n%s", m.View())
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	typeKeys("$.price")
+	if v := m.View(); !strings.Contains(v, "1 matching") || !strings.Contains(v, `"price":10`) {
+		t.Fatalf("JSONPath filter:\n%s", v)
+	}
+	typeKeys("[")
+	if v := m.View(); !strings.Contains(v, `"price":10`) || strings.Contains(v, "subscribe") || !strings.Contains(v, "expected ]") {
+		t.Fatalf("invalid JSONPath should show an error and keep the filter:\n%s", v)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.Editing() || strings.Contains(m.View(), "matching") {
+		t.Fatal("esc should close and clear the filter")
+	}
+
+	// Direction filter cycles sent, received, all
+	typeKeys("d")
+	if v := m.View(); !strings.Contains(v, "1 matching") || !strings.Contains(v, "[sent only]") {
+		t.Fatalf("direction filter:\n%s", v)
+	}
+	typeKeys("dd")
+
+	// Paused logs stop redrawing until resumed
+	typeKeys("p")
+	add("received", "late message")
+	if v := m.View(); strings.Contains(v, "late message") || !strings.Contains(v, "paused (1 new)") {
+		t.Fatalf("paused log should not show new messages:\n%s", v)
+	}
+	typeKeys("p")
+	if !strings.Contains(m.View(), "late message") {
+		t.Fatal("resuming should show new messages")
+	}
+}
+
 func TestResponseModel_WebSocketStatusAndScriptResults(t *testing.T) {
 	m := newResponseModelForTest()
 	m.SetMode("websocket")
@@ -260,3 +423,464 @@ func TestResponseModel_WebSocketStatusAndScriptResults(t *testing.T) {
 		t.Fatal("expected hasResp false after nil response")
 	}
 }
+
+func TestResponseModel_PreflightWarningsBanner(t *testing.T) {
+	m := newResponseModelForTest()
+	m.SetWarnings([]protocol.Warning{
+		{Field: "url", Message: "missing scheme; use http:// or https://"},
+		{Field: "body", Message: "GET request has a body"},
+	})
+
+	view := m.View()
+	if !strings.Contains(view, "(+1 more)") || strings.Contains(view, "GET request has a body") {
+		t.Fatalf("collapsed banner should summarize warnings:\n%s", view)
+	}
+	if got := lipgloss.Height(view); got != 24 {
+		t.Errorf("view height = %d, want 24", got)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'!'}})
+	view = m.View()
+	if !strings.Contains(view, "2 pre-flight warnings") || !strings.Contains(view, "GET request has a body") {
+		t.Fatalf("expanded banner should list all warnings:\n%s", view)
+	}
+
+	m.SetResponse(&protocol.Response{StatusCode: 200, Status: "200 OK", Body: []byte(strings.Repeat("line\n", 50)), ContentType: "text/plain"})
+	if got := lipgloss.Height(m.View()); got != 24 {
+		t.Errorf("view height with response and banner = %d, want 24", got)
+	}
+
+	m.SetWarnings(nil)
+	if strings.Contains(m.View(), "⚠") {
+		t.Error("banner should disappear when warnings are cleared")
+	}
+}
+
+func TestResponseModel_TranscodedBody(t *testing.T) {
+	m := newResponseModelForTest()
+	m.SetResponse(&protocol.Response{StatusCode: 200, Status: "200 OK",
+		Body: []byte("caf\xe9"), ContentType: "text/plain; charset=ISO-8859-1"})
+
+	view := m.View()
+	if !strings.Contains(view, "café") || !strings.Contains(view, "decoded from ISO-8859-1") {
+		t.Fatalf("expected transcoded body and indicator:\n%s", view)
+	}
+	if string(m.ResponseBody()) != "caf\xe9" {
+		t.Errorf("raw body should be kept for copying, got %q", m.ResponseBody())
+	}
+
+	m.SetResponse(&protocol.Response{StatusCode: 200, Status: "200 OK", Body: []byte("café"), ContentType: "text/plain"})
+	if strings.Contains(m.View(), "decoded from") {
+		t.Error("UTF-8 bodies should not show an encoding indicator")
+	}
+}
+
+func TestResponseModel_FromCache(t *testing.T) {
+	m := newResponseModelForTest()
+	m.SetResponse(&protocol.Response{StatusCode: 200, Status: "200 OK", Body: []byte("{}"), ContentType: "application/json", FromCache: true})
+	if !strings.Contains(m.View(), "304 served from cache") {
+		t.Fatalf("expected cache indicator:\n%s", m.View())
+	}
+
+	m.SetResponse(&protocol.Response{StatusCode: 200, Status: "200 OK", Body: []byte("{}"), ContentType: "application/json"})
+	if strings.Contains(m.View(), "served from cache") {
+		t.Error("fresh responses should not show the cache indicator")
+	}
+}
+
+func TestResponseModel_PinCompare(t *testing.T) {
+	m := newResponseModelForTest()
+	if m.Pin() {
+		t.Fatal("nothing to pin before a response")
+	}
+	m.SetResponse(&protocol.Response{StatusCode: 200, Status: "200 OK", Body: []byte("first-body"), ContentType: "text/plain"})
+	if !m.Pin() || !m.Pinned() {
+		t.Fatal("expected the response pinned")
+	}
+
+	m.SetLoading(true)
+	if view := m.View(); !strings.Contains(view, "first-body") || !strings.Contains(view, "Sending request") {
+		t.Fatalf("pinned response should stay while the next one loads:\n%s", view)
+	}
+	m.SetResponse(&protocol.Response{StatusCode: 404, Status: "404 Not Found", Body: []byte("second-body"), ContentType: "text/plain"})
+	view := m.View()
+	for _, want := range []string{"Pinned", "200 OK", "first-body", "404 Not Found", "second-body"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("split view missing %q:\n%s", want, view)
+		}
+	}
+	if w := lipgloss.Width(view); w != 100 {
+		t.Errorf("split view is %d wide, want 100", w)
+	}
+
+	m.Unpin()
+	if view := m.View(); m.Pinned() || strings.Contains(view, "first-body") {
+		t.Fatalf("expected the pinned pane closed:\n%s", view)
+	}
+}
+
+func TestResponseModel_DownloadProgressAndSavedBody(t *testing.T) {
+	m := newResponseModelForTest()
+	m.SetLoading(true)
+	m.SetProgress(512<<10, 1<<20)
+	if view := m.View(); !strings.Contains(view, "Downloading 512 KiB of 1.0 MiB (50%)") {
+		t.Fatalf("expected download progress:\n%s", view)
+	}
+
+	m.SetResponse(&protocol.Response{StatusCode: 200, Status: "200 OK", Body: []byte("preview"),
+		ContentType: "text/plain", Size: 1 << 20, BodyFile: "/tmp/dump.bin"})
+	view := m.View()
+	if !strings.Contains(view, "saved 1.0 MiB to /tmp/dump.bin") || !strings.Contains(view, "preview") {
+		t.Fatalf("expected saved-file note and preview:\n%s", view)
+	}
+
+	m.SetLoading(true)
+	if strings.Contains(m.View(), "Downloading") {
+		t.Error("progress should reset when a new request starts")
+	}
+}
+
+func TestResponseModel_JSONFilterBar(t *testing.T) {
+	m := newResponseModelForTest()
+	m.SetResponse(&protocol.Response{StatusCode: 200, Status: "200 OK", ContentType: "application/json",
+		Body: []byte(`{"items":[{"id":"a1","name":"first"},{"id":"b2","name":"second"}]}`)})
+
+	typeKeys := func(s string) {
+		for _, r := range s {
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	typeKeys("/$.items[1].id")
+	if m.active != tabBody || !m.Editing() {
+		t.Fatalf("typing a filter should stay in the body bar (tab %d)", m.active)
+	}
+	view := m.View()
+	if !strings.Contains(view, `"b2"`) || strings.Contains(view, "first") || !strings.Contains(view, "1 result") {
+		t.Fatalf("expected filtered result:\n%s", view)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'Y'}})
+	if cmd == nil {
+		t.Fatal("expected a copy command")
+	}
+	if copyMsg, ok := cmd().(msgs.CopyTextMsg); !ok || copyMsg.Text != `id: "$.items[1].id"` {
+		t.Fatalf("unexpected capture copy: %#v", cmd())
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if !strings.Contains(m.View(), "first") {
+		t.Error("closing the bar should restore the full body")
+	}
+}
+
+func TestResponseModel_HeadersFilterAndCopy(t *testing.T) {
+	m := newResponseModelForTest()
+	m.SetResponse(&protocol.Response{StatusCode: 200, Status: "200 OK", Headers: http.Header{
+		"Content-Type":                {"application/json"},
+		"Strict-Transport-Security":   {"max-age=63072000"},
+		"Access-Control-Allow-Origin": {"*"},
+		"X-Request-Id":                {"r-42"},
+	}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}})
+	if m.active != tabHeaders {
+		t.Fatalf("active tab = %d, want headers", m.active)
+	}
+
+	for _, r := range "/request" {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if !m.Editing() {
+		t.Fatal("typing a filter should keep the headers bar editing")
+	}
+	view := m.View()
+	if !strings.Contains(view, "X-Request-Id") || strings.Contains(view, "Content-Type") || !strings.Contains(view, "1 of 4") {
+		t.Fatalf("expected only X-Request-Id:\n%s", view)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if cmd == nil {
+		t.Fatal("expected a copy command")
+	}
+	if copyMsg, ok := cmd().(msgs.CopyTextMsg); !ok || copyMsg.Text != "r-42" || copyMsg.Label != "X-Request-Id" {
+		t.Fatalf("unexpected value copy: %#v", cmd())
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if !strings.Contains(m.View(), "Content-Type") {
+		t.Fatal("closing the filter should list every header again")
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'Y'}})
+	if copyMsg := cmd().(msgs.CopyTextMsg); copyMsg.Text != "Content-Type: application/json" {
+		t.Fatalf("unexpected line copy: %q", copyMsg.Text)
+	}
+
+	for name, want := range map[string]bool{
+		"Access-Control-Allow-Origin": true, "strict-transport-security": true, "Cache-Control": true,
+		"Content-Security-Policy": true, "Cross-Origin-Opener-Policy": true, "X-Request-Id": false,
+	} {
+		if securityHeader(name) != want {
+			t.Errorf("securityHeader(%q) = %v, want %v", name, !want, want)
+		}
+	}
+}
+
+func TestHeadersModel_GRPCTrailers(t *testing.T) {
+	headers := NewHeadersModel(theme.NewStyles(theme.Default()))
+	headers.SetSize(60, 12)
+	headers.SetHeaders(http.Header{
+		"Content-Type":        {"application/grpc"},
+		"Grpc-Status":         {"0"},
+		"Trailer-X-Served-By": {"backend-1"},
+	})
+	view := headers.View()
+	trailers := strings.Index(view, "Trailers")
+	if trailers < 0 || strings.Index(view, "X-Served-By") < trailers || strings.Index(view, "Grpc-Status") < trailers {
+		t.Fatalf("gRPC trailers should be listed under Trailers:\n%s", view)
+	}
+	if strings.Contains(view, "Trailer-X-Served-By") {
+		t.Fatalf("trailer prefix should be dropped:\n%s", view)
+	}
+}
+
+func TestResponseModel_TransformBar(t *testing.T) {
+	m := newResponseModelForTest()
+	m.SetResponse(&protocol.Response{StatusCode: 200, Status: "200 OK", ContentType: "application/json",
+		Body: []byte(`{"items":[{"id":"a1","name":"first"},{"id":"b2","name":"second"}]}`)})
+
+	typeKeys := func(s string) {
+		for _, r := range s {
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	typeKeys("xbody.items.map(function(i) { return i.name.toUpperCase() }).join()")
+	if !m.Editing() {
+		t.Fatal("typing a transform should stay in the transform bar")
+	}
+	view := m.View()
+	if !strings.Contains(view, "FIRST,SECOND") || strings.Contains(view, `"a1"`) {
+		t.Fatalf("expected the transformed body:\n%s", view)
+	}
+
+	// A broken expression keeps the last result and shows the error
+	typeKeys(" +")
+	if view := m.View(); !strings.Contains(view, "FIRST,SECOND") || !strings.Contains(view, "SyntaxError") {
+		t.Fatalf("expected last result with error:\n%s", view)
+	}
+	for range " +" {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if cmd == nil {
+		t.Fatal("expected a copy command")
+	}
+	if copyMsg, ok := cmd().(msgs.CopyTextMsg); !ok || copyMsg.Text != "FIRST,SECOND" || copyMsg.Label != "transform result" {
+		t.Fatalf("unexpected copy: %#v", cmd())
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if !strings.Contains(m.View(), "first") {
+		t.Error("closing the bar should restore the full body")
+	}
+}
+
+func TestBodyModel_FilterErrorsKeepLastResult(t *testing.T) {
+	body := NewBodyModel(theme.NewStyles(theme.Default()))
+	body.SetSize(60, 10)
+	body.SetContent([]byte(`{"a":{"b":42}}`), "application/json")
+	body, _ = body.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	for _, r := range ".a.b |" {
+		body, _ = body.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	view := body.View()
+	if !strings.Contains(view, "42") || !strings.Contains(view, "at 7: expected a") {
+		t.Fatalf("expected last result with error:\n%s", view)
+	}
+}
+
+func TestBodyModel_JSONTree(t *testing.T) {
+	body := NewBodyModel(theme.NewStyles(theme.Default()))
+	body.SetSize(60, 10)
+	body.SetContent([]byte(`{"zeta":1,"user":{"name":"Ann","tags":["a","b"]},"ok":true}`), "application/json")
+	if !body.treeActive() {
+		t.Fatal("JSON bodies should open as a tree")
+	}
+	view := body.View()
+	// Keys keep document order
+	if strings.Index(view, `"zeta"`) > strings.Index(view, `"user"`) || !strings.Contains(view, `"Ann"`) {
+		t.Fatalf("unexpected tree:\n%s", view)
+	}
+
+	key := func(k string) {
+		body, _ = body.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+	key("j")
+	key("j")
+	if got := body.Breadcrumb(); got != "$.user" {
+		t.Fatalf("breadcrumb = %q", got)
+	}
+	body, _ = body.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	view = body.View()
+	if strings.Contains(view, `"Ann"`) || !strings.Contains(view, "2 keys") {
+		t.Fatalf("user should be folded:\n%s", view)
+	}
+	key("l")
+	key("l")
+	key("j")
+	key("l")
+	key("l")
+	if got := body.Breadcrumb(); got != "$.user.tags[0]" {
+		t.Fatalf("breadcrumb = %q", got)
+	}
+
+	// y copies the value under the cursor, Y its capture expression
+	key("h")
+	_, cmd := body.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if copyMsg := cmd().(msgs.CopyTextMsg); !strings.Contains(copyMsg.Text, `"a",`) {
+		t.Errorf("unexpected copy: %q", copyMsg.Text)
+	}
+	_, cmd = body.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'Y'}})
+	if copyMsg := cmd().(msgs.CopyTextMsg); copyMsg.Text != `tags: "$.user.tags"` {
+		t.Errorf("unexpected capture: %q", copyMsg.Text)
+	}
+
+	// Folding everything, then searching a key, unfolds its ancestors
+	key("z")
+	key("/")
+	for _, r := range "NAME" {
+		key(string(r))
+	}
+	if got := body.Breadcrumb(); got != "$.user.name" {
+		t.Fatalf("search should jump to the matching key, at %q", got)
+	}
+	if !strings.Contains(body.View(), "1/1") {
+		t.Errorf("expected match count:\n%s", body.View())
+	}
+
+	// t switches to the highlighted text view
+	body, _ = body.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	key("t")
+	if body.treeActive() || body.Breadcrumb() != "" {
+		t.Error("t should show the flat body")
+	}
+}
+
+func TestJSONTree_LargeDocumentsRenderLazily(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(`{"items":[`)
+	for i := 0; i < 30000; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"id":%d}`, i)
+	}
+	b.WriteString(`]}`)
+
+	tree := newJSONTree([]byte(b.String()))
+	if tree == nil {
+		t.Fatal("expected a tree")
+	}
+	if len(tree.lines) != 3 {
+		t.Fatalf("large documents should open folded below the top level, got %d lines", len(tree.lines))
+	}
+	tree.height = 5
+	tree.setAll(false)
+	tree.move(len(tree.lines))
+	view := tree.view(theme.NewStyles(theme.Default()), 40)
+	if lines := strings.Count(view, "\n") + 1; lines != 5 {
+		t.Errorf("only the window should render, got %d lines", lines)
+	}
+	if tree.current().path() != "$" {
+		t.Errorf("last line should be the root's closing brace, got %s", tree.current().path())
+	}
+
+	if newJSONTree([]byte(`{"a":1} trailing`)) != nil || newJSONTree([]byte(`not json`)) != nil {
+		t.Error("invalid JSON should not produce a tree")
+	}
+}
+
+func TestResponseModel_AnnotateBody(t *testing.T) {
+	m := newResponseModelForTest()
+	m.SetResponse(&protocol.Response{StatusCode: 500, Status: "500 Internal Server Error", ContentType: "application/json",
+		Body: []byte(`{"error":"boom"}`)})
+
+	text, lang := m.BodyText()
+	if text != "{\n  \"error\": \"boom\"\n}" || lang != "json" {
+		t.Errorf("BodyText() = %q, %q", text, lang)
+	}
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if cmd == nil {
+		t.Fatal("a should open the annotation overlay")
+	}
+	if _, ok := cmd().(msgs.AnnotateResponseMsg); !ok {
+		t.Errorf("unexpected message %#v", cmd())
+	}
+
+	m.SetResponse(&protocol.Response{StatusCode: 200, Status: "200 OK", ContentType: "text/plain", Body: []byte("ok\n")})
+	if text, lang := m.BodyText(); text != "ok" || lang != "" {
+		t.Errorf("plain BodyText() = %q, %q", text, lang)
+	}
+}
+
+func TestBodyModel_Viewers(t *testing.T) {
+	styles := theme.NewStyles(theme.Default())
+	view := func(body, contentType, rawRequest string) (BodyModel, string) {
+		m := NewBodyModel(styles)
+		m.SetSize(100, 30)
+		m.SetRequest(rawRequest)
+		m.SetContent([]byte(body), contentType)
+		text, _ := m.PlainText()
+		return m, text
+	}
+
+	_, xmlText := view(`<?xml version="1.0"?><soap:Envelope xmlns:soap="urn:s"><soap:Body><item id="1">Ann</item></soap:Body></soap:Envelope>`, "application/xml", "")
+	if want := "  <soap:Body>\n    <item id=\"1\">Ann</item>"; !strings.Contains(xmlText, want) {
+		t.Fatalf("XML should be indented with prefixes kept:\n%s", xmlText)
+	}
+	if _, bad := view("<a><b></a>", "text/xml", ""); bad != "<a><b></a>" {
+		t.Errorf("malformed XML should be shown as is, got %q", bad)
+	}
+
+	page := `<html><head><title>Oops</title><style>p{color:red}</style></head><body><h1>Not  found</h1><p>The page <b>is</b> gone.</p><ul><li>one</li><li>two</li></ul><script>alert(1)</script></body></html>`
+	htmlModel, htmlText := view(page, "text/html; charset=utf-8", "")
+	if want := "Oops\n\n# Not found\n\nThe page is gone.\n\n• one\n• two"; htmlText != want {
+		t.Fatalf("HTML preview = %q, want %q", htmlText, want)
+	}
+	htmlModel, _ = htmlModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	if src, lang := htmlModel.PlainText(); lang != "html" || !strings.Contains(src, "<script>") {
+		t.Errorf("t should show the HTML source, got %s %q", lang, src)
+	}
+
+	_, table := view("id,name\n1,Ann\n22,\"Bo, Jr\"\n", "text/csv", "")
+	if want := "id │ name\n───┼───────\n1  │ Ann\n22 │ Bo, Jr"; table != want {
+		t.Fatalf("CSV table =\n%s\nwant\n%s", table, want)
+	}
+
+	seg := func(v string) string { return base64.RawURLEncoding.EncodeToString([]byte(v)) }
+	expired := seg(`{"alg":"HS256","typ":"JWT"}`) + "." + seg(`{"sub":"42","exp":1000000000}`) + ".sig"
+	jwtModel, decoded := view(expired, "text/plain", "")
+	for _, want := range []string{"JWT in body", `"alg": "HS256"`, `"sub": "42"`, "Expires     2001-09-09 01:46:40 UTC", "expired"} {
+		if !strings.Contains(decoded, want) {
+			t.Fatalf("a token body should open decoded, missing %q:\n%s", want, decoded)
+		}
+	}
+	jwtModel, _ = jwtModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	if raw, _ := jwtModel.PlainText(); raw != expired {
+		t.Errorf("t should show the raw token, got %q", raw)
+	}
+
+	jsonModel, _ := view(`{"ok":true}`, "application/json", "GET /me HTTP/1.1\r\nHost: api\r\nAuthorization: Bearer "+expired+"\r\n\r\n")
+	if !jsonModel.treeActive() {
+		t.Fatal("JSON should still open as a tree")
+	}
+	for range 2 {
+		jsonModel, _ = jsonModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	}
+	if text, _ := jsonModel.PlainText(); !strings.Contains(text, "JWT in Authorization header") {
+		t.Fatalf("t should reach the token from the Authorization header:\n%s", text)
+	}
+}
@@ -19,12 +19,16 @@ type SearchBar struct {
 	current int   // index into matches
 	styles  theme.Styles
 	width   int
+
+	// Status of a JSONPath/jq filter query, shown instead of match counts
+	filterInfo string
+	filterErr  bool
 }
 
 // NewSearchBar creates a new search bar.
 func NewSearchBar(s theme.Styles) SearchBar {
 	ti := textinput.New()
-	ti.Placeholder = "Search..."
+	ti.Placeholder = "Search, or filter with $.jsonpath / .jq"
 	ti.CharLimit = 256
 	ti.Prompt = "/ "
 	return SearchBar{
@@ -51,6 +55,7 @@ func (m *SearchBar) Open() {
 	m.query = ""
 	m.matches = nil
 	m.current = 0
+	m.filterInfo = ""
 }
 
 // Close deactivates the search bar.
@@ -60,6 +65,7 @@ func (m *SearchBar) Close() {
 	m.query = ""
 	m.matches = nil
 	m.current = 0
+	m.filterInfo = ""
 }
 
 // SetWidth sets the search bar width.
@@ -96,6 +102,12 @@ func (m SearchBar) Update(msg tea.Msg) (SearchBar, tea.Cmd) {
 	return m, cmd
 }
 
+// SetFilterStatus sets the result summary or error of a filter query.
+func (m *SearchBar) SetFilterStatus(info string, isErr bool) {
+	m.filterInfo = info
+	m.filterErr = isErr
+}
+
 // SetMatches updates the match positions.
 func (m *SearchBar) SetMatches(matches []int) {
 	m.matches = matches
@@ -135,7 +147,13 @@ func (m SearchBar) View() string {
 	}
 
 	var info string
-	if m.query != "" {
+	if m.filterInfo != "" {
+		if m.filterErr {
+			info = m.styles.Error.Render(" " + m.filterInfo)
+		} else {
+			info = m.styles.Muted.Render(" " + m.filterInfo)
+		}
+	} else if m.query != "" {
 		if len(m.matches) == 0 {
 			info = m.styles.Error.Render(" No matches")
 		} else {
@@ -144,7 +162,7 @@ func (m SearchBar) View() string {
 	}
 
 	bar := m.input.View() + info
-	return lipgloss.NewStyle().Width(m.width).Render(bar)
+	return lipgloss.NewStyle().Width(m.width).MaxHeight(1).Render(bar)
 }
 
 // HighlightMatches highlights all occurrences of query in content.
@@ -60,8 +60,16 @@ func (m *TimingModel) SetResponse(resp *protocol.Response) {
 
 	// Add waterfall if detailed timing is available
 	if resp.Timing != nil {
+		row("Connection", connectionSummary(resp.Timing))
+		if p := resp.Timing.Pool; p != nil {
+			row("Pool", fmt.Sprintf("%d opened · %d reused", p.Opened, p.Reused))
+		}
+
 		b.WriteString("\n")
 		b.WriteString(m.styles.Bold.Render("Waterfall"))
+		if resp.Timing.ConnReused {
+			b.WriteString(m.styles.Muted.Render("  (warm: no DNS, connect or TLS)"))
+		}
 		b.WriteString("\n\n")
 		b.WriteString(m.renderWaterfall(resp.Timing))
 	}
@@ -69,6 +77,22 @@ func (m *TimingModel) SetResponse(resp *protocol.Response) {
 	m.content = strings.TrimRight(b.String(), "\n")
 }
 
+// connectionSummary says whether the request got a new or a kept-alive
+// connection, and to where.
+func connectionSummary(td *protocol.TimingDetail) string {
+	s := "new (cold)"
+	if td.ConnReused {
+		s = "reused (warm)"
+		if td.ConnIdle > 0 {
+			s += ", idle " + formatDuration(td.ConnIdle)
+		}
+	}
+	if td.RemoteAddr != "" {
+		s += " · " + td.RemoteAddr
+	}
+	return s
+}
+
 // renderWaterfall renders a horizontal bar chart waterfall of timing phases.
 func (m *TimingModel) renderWaterfall(td *protocol.TimingDetail) string {
 	type phase struct {
@@ -0,0 +1,467 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/sadopc/gottp/internal/ui/theme"
+)
+
+// treeExpandLimit is the node count above which a tree opens with only the
+// top level expanded.
+const treeExpandLimit = 20000
+
+type nodeKind int
+
+const (
+	kindObject nodeKind = iota
+	kindArray
+	kindString
+	kindNumber
+	kindBool
+	kindNull
+)
+
+// jsonNode is a parsed JSON value. Unlike map[string]interface{} it keeps
+// object keys in document order.
+type jsonNode struct {
+	key       string // object key; empty for array elements and the root
+	index     int    // array index, or -1
+	kind      nodeKind
+	value     string // scalar value as JSON text
+	children  []*jsonNode
+	parent    *jsonNode
+	depth     int
+	last      bool // last child of its parent (no trailing comma)
+	collapsed bool
+}
+
+func (n *jsonNode) container() bool {
+	return n.kind == kindObject || n.kind == kindArray
+}
+
+// treeLine is one visible line: a node, or the closing bracket of an
+// expanded container.
+type treeLine struct {
+	node    *jsonNode
+	closing bool
+}
+
+// jsonTree is a foldable view of a JSON document. Only the lines inside the
+// window are rendered, so large documents scroll as fast as small ones.
+type jsonTree struct {
+	root    *jsonNode
+	lines   []treeLine
+	cursor  int
+	offset  int
+	height  int
+	matches []*jsonNode // nodes whose key matches the search query
+	query   string
+}
+
+// newJSONTree parses body into a tree, or returns nil if it is not JSON.
+func newJSONTree(body []byte) *jsonTree {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	count := 0
+	root, err := parseNode(dec, nil, &count)
+	if err != nil {
+		return nil
+	}
+	// Trailing data means this is not a single JSON document
+	if _, err := dec.Token(); err != io.EOF {
+		return nil
+	}
+	if count > treeExpandLimit {
+		for _, c := range root.children {
+			setCollapsed(c, true)
+		}
+	}
+	t := &jsonTree{root: root}
+	t.rebuild()
+	return t
+}
+
+func parseNode(dec *json.Decoder, parent *jsonNode, count *int) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	*count++
+	n := &jsonNode{parent: parent, index: -1}
+	if parent != nil {
+		n.depth = parent.depth + 1
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			n.kind = kindObject
+		} else if v == '[' {
+			n.kind = kindArray
+		} else {
+			return nil, fmt.Errorf("unexpected %v", v)
+		}
+		for dec.More() {
+			var key string
+			if n.kind == kindObject {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ = kt.(string)
+			}
+			child, err := parseNode(dec, n, count)
+			if err != nil {
+				return nil, err
+			}
+			if n.kind == kindObject {
+				child.key = key
+			} else {
+				child.index = len(n.children)
+			}
+			n.children = append(n.children, child)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		if len(n.children) > 0 {
+			n.children[len(n.children)-1].last = true
+		}
+	case string:
+		n.kind, n.value = kindString, strconv.Quote(v)
+	case json.Number:
+		n.kind, n.value = kindNumber, v.String()
+	case bool:
+		n.kind, n.value = kindBool, strconv.FormatBool(v)
+	case nil:
+		n.kind, n.value = kindNull, "null"
+	}
+	return n, nil
+}
+
+func setCollapsed(n *jsonNode, collapsed bool) {
+	if !n.container() {
+		return
+	}
+	n.collapsed = collapsed
+	for _, c := range n.children {
+		setCollapsed(c, collapsed)
+	}
+}
+
+// rebuild recomputes the visible lines after folding, keeping the cursor on
+// the same node.
+func (t *jsonTree) rebuild() {
+	var current *jsonNode
+	if t.cursor < len(t.lines) {
+		current = t.lines[t.cursor].node
+	}
+	t.lines = t.lines[:0]
+	t.appendLines(t.root)
+	if current != nil {
+		// Land on the outermost folded ancestor if the node is now hidden
+		for p := current.parent; p != nil; p = p.parent {
+			if p.collapsed {
+				current = p
+			}
+		}
+		t.cursor = t.lineOf(current)
+	}
+	t.clamp()
+}
+
+func (t *jsonTree) lineOf(n *jsonNode) int {
+	for i, l := range t.lines {
+		if l.node == n && !l.closing {
+			return i
+		}
+	}
+	return 0
+}
+
+func (t *jsonTree) appendLines(n *jsonNode) {
+	t.lines = append(t.lines, treeLine{node: n})
+	if !n.container() || n.collapsed || len(n.children) == 0 {
+		return
+	}
+	for _, c := range n.children {
+		t.appendLines(c)
+	}
+	t.lines = append(t.lines, treeLine{node: n, closing: true})
+}
+
+// moveTo puts the cursor on n, expanding its ancestors if needed.
+func (t *jsonTree) moveTo(n *jsonNode) {
+	expanded := false
+	for p := n.parent; p != nil; p = p.parent {
+		if p.collapsed {
+			p.collapsed = false
+			expanded = true
+		}
+	}
+	if expanded {
+		t.lines = t.lines[:0]
+		t.appendLines(t.root)
+	}
+	t.cursor = t.lineOf(n)
+	t.clamp()
+}
+
+func (t *jsonTree) current() *jsonNode {
+	if t.cursor < len(t.lines) {
+		return t.lines[t.cursor].node
+	}
+	return nil
+}
+
+// clamp keeps the cursor in range and inside the window.
+func (t *jsonTree) clamp() {
+	if t.cursor >= len(t.lines) {
+		t.cursor = len(t.lines) - 1
+	}
+	if t.cursor < 0 {
+		t.cursor = 0
+	}
+	h := max(t.height, 1)
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+h {
+		t.offset = t.cursor - h + 1
+	}
+	if maxOffset := max(len(t.lines)-h, 0); t.offset > maxOffset {
+		t.offset = maxOffset
+	}
+}
+
+func (t *jsonTree) move(delta int) {
+	t.cursor += delta
+	t.clamp()
+}
+
+// toggle folds or unfolds the container under the cursor.
+func (t *jsonTree) toggle() {
+	n := t.current()
+	if n == nil || !n.container() || len(n.children) == 0 {
+		return
+	}
+	n.collapsed = !n.collapsed
+	t.rebuild()
+}
+
+// collapse folds the container under the cursor, or moves to its parent.
+func (t *jsonTree) collapse() {
+	n := t.current()
+	if n == nil {
+		return
+	}
+	if n.container() && !n.collapsed && len(n.children) > 0 {
+		n.collapsed = true
+		t.rebuild()
+		return
+	}
+	if n.parent != nil {
+		t.moveTo(n.parent)
+	}
+}
+
+// expand unfolds the container under the cursor, or moves into it.
+func (t *jsonTree) expand() {
+	n := t.current()
+	if n == nil || !n.container() || len(n.children) == 0 {
+		return
+	}
+	if n.collapsed {
+		n.collapsed = false
+		t.rebuild()
+		return
+	}
+	t.moveTo(n.children[0])
+}
+
+// setAll folds everything below the root, or unfolds everything.
+func (t *jsonTree) setAll(collapsed bool) {
+	for _, c := range t.root.children {
+		setCollapsed(c, collapsed)
+	}
+	t.root.collapsed = false
+	t.rebuild()
+}
+
+// search finds keys containing query (case-insensitive) and returns the
+// number of matches.
+func (t *jsonTree) search(query string) int {
+	t.query = query
+	t.matches = t.matches[:0]
+	if query != "" {
+		t.collectMatches(t.root, strings.ToLower(query))
+	}
+	return len(t.matches)
+}
+
+func (t *jsonTree) collectMatches(n *jsonNode, q string) {
+	if n.key != "" && strings.Contains(strings.ToLower(n.key), q) {
+		t.matches = append(t.matches, n)
+	}
+	for _, c := range n.children {
+		t.collectMatches(c, q)
+	}
+}
+
+// jumpToMatch moves the cursor to the i-th match and scrolls it into view.
+func (t *jsonTree) jumpToMatch(i int) {
+	if i < 0 || i >= len(t.matches) {
+		return
+	}
+	t.moveTo(t.matches[i])
+	// Show some context above the match
+	if t.cursor-t.offset < 2 {
+		t.offset = max(t.cursor-2, 0)
+	}
+}
+
+var plainKey = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// path returns the JSONPath of n, usable as a filter or workflow extract.
+func (n *jsonNode) path() string {
+	var parts []string
+	for c := n; c.parent != nil; c = c.parent {
+		switch {
+		case c.index >= 0:
+			parts = append(parts, "["+strconv.Itoa(c.index)+"]")
+		case plainKey.MatchString(c.key):
+			parts = append(parts, "."+c.key)
+		default:
+			parts = append(parts, "['"+strings.ReplaceAll(c.key, "'", `\'`)+"']")
+		}
+	}
+	var b strings.Builder
+	b.WriteString("$")
+	for i := len(parts) - 1; i >= 0; i-- {
+		b.WriteString(parts[i])
+	}
+	return b.String()
+}
+
+// text renders n as indented JSON.
+func (n *jsonNode) text() string {
+	var b strings.Builder
+	n.writeText(&b, "")
+	return b.String()
+}
+
+func (n *jsonNode) writeText(b *strings.Builder, indent string) {
+	if !n.container() {
+		b.WriteString(n.value)
+		return
+	}
+	open, close := "{", "}"
+	if n.kind == kindArray {
+		open, close = "[", "]"
+	}
+	if len(n.children) == 0 {
+		b.WriteString(open + close)
+		return
+	}
+	b.WriteString(open + "\n")
+	for i, c := range n.children {
+		b.WriteString(indent + "  ")
+		if n.kind == kindObject {
+			b.WriteString(strconv.Quote(c.key) + ": ")
+		}
+		c.writeText(b, indent+"  ")
+		if i < len(n.children)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(indent + close)
+}
+
+// view renders the lines in the window.
+func (t *jsonTree) view(s theme.Styles, width int) string {
+	matched := make(map[*jsonNode]bool, len(t.matches))
+	for _, n := range t.matches {
+		matched[n] = true
+	}
+	matchStyle := lipgloss.NewStyle().
+		Background(lipgloss.Color("#f9e2af")).
+		Foreground(lipgloss.Color("#1e1e2e")).
+		Bold(true)
+
+	end := min(t.offset+max(t.height, 1), len(t.lines))
+	out := make([]string, 0, end-t.offset)
+	for i := t.offset; i < end; i++ {
+		line := renderTreeLine(t.lines[i], s, matched, matchStyle)
+		if i == t.cursor {
+			line = s.Muted.Render("›") + line
+		} else {
+			line = " " + line
+		}
+		out = append(out, lipgloss.NewStyle().MaxWidth(width).Render(line))
+	}
+	return strings.Join(out, "\n")
+}
+
+func renderTreeLine(l treeLine, s theme.Styles, matched map[*jsonNode]bool, matchStyle lipgloss.Style) string {
+	n := l.node
+	indent := strings.Repeat("  ", n.depth)
+	comma := ""
+	if n.parent != nil && !n.last {
+		comma = s.Muted.Render(",")
+	}
+
+	open, close := "{", "}"
+	if n.kind == kindArray {
+		open, close = "[", "]"
+	}
+	if l.closing {
+		return indent + "  " + s.Muted.Render(close) + comma
+	}
+
+	marker := "  "
+	if n.container() && len(n.children) > 0 {
+		marker = s.Muted.Render("▾ ")
+		if n.collapsed {
+			marker = s.Muted.Render("▸ ")
+		}
+	}
+
+	var key string
+	if n.parent != nil && n.parent.kind == kindObject {
+		key = s.Key.Render(strconv.Quote(n.key))
+		if matched[n] {
+			key = matchStyle.Render(strconv.Quote(n.key))
+		}
+		key += s.Muted.Render(": ")
+	}
+
+	var value string
+	switch {
+	case n.container() && len(n.children) == 0:
+		value = s.Muted.Render(open + close)
+	case n.container() && n.collapsed:
+		noun := "keys"
+		if n.kind == kindArray {
+			noun = "items"
+		}
+		value = s.Muted.Render(open+"…"+close) + s.Hint.Render(fmt.Sprintf(" %d %s", len(n.children), noun))
+	case n.container():
+		value = s.Muted.Render(open)
+		comma = ""
+	case n.kind == kindString:
+		value = s.Success.Render(n.value)
+	case n.kind == kindNull:
+		value = s.Muted.Render(n.value)
+	default:
+		value = s.Warning.Render(n.value)
+	}
+	return indent + marker + key + value + comma
+}
@@ -0,0 +1,366 @@
+package response
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+	"github.com/tidwall/pretty"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// bodyView is one way of showing the body; t cycles through those that
+// apply to the response.
+type bodyView int
+
+const (
+	viewSource  bodyView = iota // highlighted source, JSON and XML indented
+	viewTree                    // foldable JSON tree
+	viewPreview                 // HTML as text, CSV as a table
+	viewJWT                     // decoded JSON Web Tokens
+)
+
+// formatSource pretty-prints JSON and XML for display. Anything else, and
+// XML that doesn't parse, is returned as is.
+func formatSource(text, lang string) string {
+	switch lang {
+	case "json":
+		return string(pretty.Pretty([]byte(text)))
+	case "xml":
+		if indented, err := indentXML(text); err == nil {
+			return indented
+		}
+	}
+	return text
+}
+
+// indentXML re-encodes an XML document with two-space indentation.
+// Namespace prefixes are kept as written.
+func indentXML(src string) (string, error) {
+	dec := xml.NewDecoder(strings.NewReader(src))
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			// The encoder's indentation replaces whitespace between elements
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+		case xml.StartElement:
+			t.Name = prefixedName(t.Name)
+			attrs := make([]xml.Attr, len(t.Attr))
+			for i, a := range t.Attr {
+				attrs[i] = xml.Attr{Name: prefixedName(a.Name), Value: a.Value}
+			}
+			t.Attr = attrs
+			tok = t
+		case xml.EndElement:
+			t.Name = prefixedName(t.Name)
+			tok = t
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// prefixedName folds a raw token's namespace prefix into the local name,
+// since the encoder would otherwise turn it into an xmlns attribute.
+func prefixedName(n xml.Name) xml.Name {
+	if n.Space == "" {
+		return n
+	}
+	return xml.Name{Local: n.Space + ":" + n.Local}
+}
+
+// htmlText renders an HTML document as readable text: scripts and styles
+// are dropped, block elements start new lines, headings and list items are
+// marked and whitespace is collapsed outside <pre>.
+func htmlText(src string) string {
+	z := html.NewTokenizer(strings.NewReader(src))
+	var b strings.Builder
+	skip, pre := 0, 0
+	newline := func() {
+		if s := b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch tok.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg:
+				if tt == html.StartTagToken {
+					skip++
+				}
+			case atom.Pre:
+				pre++
+				newline()
+			case atom.Br:
+				b.WriteByte('\n')
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				newline()
+				b.WriteString("\n" + strings.Repeat("#", int(tok.Data[1]-'0')) + " ")
+			case atom.Li:
+				newline()
+				b.WriteString("• ")
+			case atom.Td, atom.Th:
+				b.WriteString("  ")
+			case atom.Hr:
+				newline()
+				b.WriteString("────\n")
+			default:
+				if blockElement(tok.DataAtom) {
+					newline()
+				}
+			}
+		case html.EndTagToken:
+			switch tok.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg:
+				skip = max(skip-1, 0)
+			case atom.Pre:
+				pre = max(pre-1, 0)
+				newline()
+			case atom.P, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Table, atom.Ul, atom.Ol:
+				newline()
+				b.WriteByte('\n')
+			default:
+				if blockElement(tok.DataAtom) {
+					newline()
+				}
+			}
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			text := tok.Data
+			if pre == 0 {
+				text = strings.Join(strings.Fields(text), " ")
+				if text == "" {
+					continue
+				}
+				if s := b.String(); s != "" && !strings.HasSuffix(s, "\n") && !strings.HasSuffix(s, " ") {
+					b.WriteByte(' ')
+				}
+			}
+			b.WriteString(text)
+		}
+	}
+	// Keep at most one blank line between paragraphs
+	lines := strings.Split(b.String(), "\n")
+	out := lines[:0]
+	for _, l := range lines {
+		l = strings.TrimRight(l, " ")
+		if l == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, l)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+func blockElement(a atom.Atom) bool {
+	switch a {
+	case atom.Address, atom.Article, atom.Aside, atom.Blockquote, atom.Dd, atom.Div, atom.Dl, atom.Dt,
+		atom.Fieldset, atom.Figcaption, atom.Figure, atom.Footer, atom.Form, atom.Header, atom.Main,
+		atom.Nav, atom.Ol, atom.P, atom.Section, atom.Table, atom.Tr, atom.Title, atom.Ul:
+		return true
+	}
+	return false
+}
+
+// maxCellWidth caps CSV columns so one long value doesn't push the rest
+// off screen.
+const maxCellWidth = 40
+
+// csvComma returns the field separator for a CSV or TSV content type, or 0
+// for anything else.
+func csvComma(contentType string) rune {
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(ct, "tab-separated-values"):
+		return '\t'
+	case strings.Contains(ct, "csv"):
+		return ','
+	}
+	return 0
+}
+
+// csvTable lays out CSV records as aligned columns with the first record
+// underlined as the header. It returns "" when the body isn't valid CSV.
+func csvTable(src string, comma rune) string {
+	r := csv.NewReader(strings.NewReader(src))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil || len(records) == 0 {
+		return ""
+	}
+
+	var widths []int
+	for _, rec := range records {
+		for i, cell := range rec {
+			rec[i] = truncateCell(strings.ReplaceAll(cell, "\n", "↵"))
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], lipgloss.Width(rec[i]))
+		}
+	}
+
+	var b strings.Builder
+	for n, rec := range records {
+		cells := make([]string, len(rec))
+		for i, cell := range rec {
+			cells[i] = cell
+			if i < len(rec)-1 {
+				cells[i] += strings.Repeat(" ", widths[i]-lipgloss.Width(cell))
+			}
+		}
+		b.WriteString(strings.Join(cells, " │ ") + "\n")
+		if n == 0 && len(records) > 1 {
+			rules := make([]string, len(widths))
+			for i, w := range widths {
+				rules[i] = strings.Repeat("─", w)
+			}
+			b.WriteString(strings.Join(rules, "─┼─") + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func truncateCell(s string) string {
+	if lipgloss.Width(s) <= maxCellWidth {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && lipgloss.Width(string(r)) > maxCellWidth-1 {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
+
+// jwtPattern matches compact JWS tokens, whose header and payload are JSON
+// objects and so start with "eyJ" once base64url-encoded.
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)
+
+// foundJWT is a token found in the response body or the request's
+// Authorization header.
+type foundJWT struct {
+	where string
+	token string
+}
+
+// findJWTs returns the distinct tokens in body and in the Authorization
+// header of rawRequest, the request as framed on the wire.
+func findJWTs(body, rawRequest string) []foundJWT {
+	var found []foundJWT
+	seen := make(map[string]bool)
+	add := func(where, token string) {
+		if !seen[token] {
+			seen[token] = true
+			found = append(found, foundJWT{where: where, token: token})
+		}
+	}
+	for _, token := range jwtPattern.FindAllString(body, -1) {
+		add("body", token)
+	}
+
+	for _, line := range strings.Split(rawRequest, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break // end of the headers
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(name, "Authorization") {
+			if token := jwtPattern.FindString(value); token != "" {
+				add("Authorization header", token)
+			}
+		}
+	}
+	return found
+}
+
+// jwtOnly reports whether the body is nothing but a token, possibly quoted.
+func jwtOnly(body string) bool {
+	body = strings.Trim(strings.TrimSpace(body), `"`)
+	return body != "" && jwtPattern.FindString(body) == body
+}
+
+// decodeJWTs renders the header and payload of each token, with the
+// registered time claims as dates relative to now. Signatures are not
+// verified.
+func decodeJWTs(tokens []foundJWT, now time.Time) string {
+	var sections []string
+	for _, t := range tokens {
+		parts := strings.Split(t.token, ".")
+		header, err := decodeSegment(parts[0])
+		if err != nil {
+			continue
+		}
+		payload, err := decodeSegment(parts[1])
+		if err != nil {
+			continue
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "JWT in %s\n\nHeader\n%s\nPayload\n%s", t.where, pretty.Pretty(header), pretty.Pretty(payload))
+		var claims map[string]any
+		if json.Unmarshal(payload, &claims) == nil {
+			for _, c := range []struct{ claim, label string }{{"iat", "Issued"}, {"nbf", "Not before"}, {"exp", "Expires"}} {
+				secs, ok := claims[c.claim].(float64)
+				if !ok {
+					continue
+				}
+				at := time.Unix(int64(secs), 0).UTC()
+				fmt.Fprintf(&b, "\n%-11s %s (%s)", c.label, at.Format("2006-01-02 15:04:05 MST"), humanize.RelTime(at, now, "ago", "from now"))
+				if c.claim == "exp" && at.Before(now) {
+					b.WriteString(" — expired")
+				}
+			}
+		}
+		sections = append(sections, strings.TrimRight(b.String(), "\n"))
+	}
+	return strings.Join(sections, "\n\n────\n\n")
+}
+
+func decodeSegment(seg string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(seg, "="))
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(data) {
+		return nil, errors.New("not JSON")
+	}
+	return data, nil
+}
@@ -0,0 +1,74 @@
+package response
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sadopc/gottp/internal/core/jsonquery"
+)
+
+// wsFilter selects the messages shown in the WebSocket log.
+type wsFilter struct {
+	direction string // "", "sent" or "received"
+
+	expr  string
+	text  string         // lower-cased substring
+	re    *regexp.Regexp // /pattern/
+	query string         // $.jsonpath or .jq; matches when it yields a non-empty result
+}
+
+// parseWSFilter parses a filter expression: /pattern/ is a regular
+// expression, $... and .... are JSONPath/jq queries, anything else is a
+// case-insensitive substring.
+func parseWSFilter(expr string) (wsFilter, error) {
+	f := wsFilter{expr: expr}
+	trimmed := strings.TrimSpace(expr)
+	switch {
+	case trimmed == "":
+	case len(trimmed) >= 2 && strings.HasPrefix(trimmed, "/") && strings.HasSuffix(trimmed, "/"):
+		re, err := regexp.Compile(trimmed[1 : len(trimmed)-1])
+		if err != nil {
+			return f, fmt.Errorf("invalid regex: %w", err)
+		}
+		f.re = re
+	case jsonquery.IsQuery(trimmed):
+		// Check the syntax once so typos show up before any message arrives
+		if err := jsonquery.Validate(trimmed); err != nil {
+			return f, err
+		}
+		f.query = trimmed
+	default:
+		f.text = strings.ToLower(trimmed)
+	}
+	return f, nil
+}
+
+// active reports whether the filter hides any messages.
+func (f wsFilter) active() bool {
+	return f.direction != "" || f.text != "" || f.re != nil || f.query != ""
+}
+
+func (f wsFilter) match(msg WSMessage) bool {
+	if f.direction != "" && msg.Direction != f.direction {
+		return false
+	}
+	switch {
+	case f.re != nil:
+		return f.re.MatchString(msg.Content)
+	case f.query != "":
+		results, err := jsonquery.Query([]byte(msg.Content), f.query)
+		if err != nil {
+			return false
+		}
+		for _, r := range results {
+			if r != nil {
+				return true
+			}
+		}
+		return false
+	case f.text != "":
+		return strings.Contains(strings.ToLower(msg.Content), f.text)
+	}
+	return true
+}
@@ -1,53 +1,162 @@
 package response
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/sadopc/gottp/internal/ui/theme"
 )
 
+// DefaultWSBufferSize is how many WebSocket messages are kept in memory
+// when no size is configured.
+const DefaultWSBufferSize = 1000
+
+// wsPageSize is how many messages the log renders at once.
+const wsPageSize = 100
+
 // WSMessage represents a WebSocket message.
 type WSMessage struct {
-	Direction string // "sent" or "received"
-	Content   string
-	Timestamp time.Time
-	IsJSON    bool
+	Direction string    `json:"direction"` // "sent", "received" or "event" (reconnects)
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+	IsJSON    bool      `json:"-"`
 }
 
-// WSLogModel displays a scrollable log of WebSocket messages.
+// WSLogModel displays a scrollable log of WebSocket messages. The newest
+// messages are kept in a ring buffer; older ones are moved to a temporary
+// file so long sessions can still be exported in full. Only one page of
+// the messages passing the filter is rendered at a time.
 type WSLogModel struct {
 	viewport viewport.Model
-	messages []WSMessage
-	styles   theme.Styles
-	th       theme.Theme
-	width    int
-	height   int
+	ring     []WSMessage // capacity is the buffer size
+	head     int         // index of the oldest message in ring
+	count    int         // messages in ring
+	total    int         // messages added since the last Clear
+	spill    *os.File    // evicted messages as JSON lines
+	matches  []int       // indexes, counted since Clear, of buffered messages passing the filter
+	pageEnd  int         // index after the shown page; 0 follows new messages
+	paused   bool        // stop redrawing and auto-scrolling on new messages
+	unseen   int         // matching messages received while paused
+
+	filter      wsFilter
+	filterInput textinput.Model
+	filterErr   string
+
+	styles theme.Styles
+	th     theme.Theme
+	width  int
+	height int
 }
 
 // NewWSLogModel creates a new WebSocket log model.
 func NewWSLogModel(t theme.Theme, s theme.Styles) WSLogModel {
 	vp := viewport.New(40, 10)
+	ti := textinput.New()
+	ti.Placeholder = "text, /regex/ or $.jsonpath"
+	ti.CharLimit = 256
+	ti.Prompt = "filter: "
 	return WSLogModel{
-		viewport: vp,
-		styles:   s,
-		th:       t,
+		viewport:    vp,
+		ring:        make([]WSMessage, DefaultWSBufferSize),
+		filterInput: ti,
+		styles:      s,
+		th:          t,
 	}
 }
 
-// AddMessage appends a message to the log.
+// SetBufferSize sets how many messages are kept in memory; n <= 0 selects
+// DefaultWSBufferSize. The log is cleared.
+func (m *WSLogModel) SetBufferSize(n int) {
+	if n <= 0 {
+		n = DefaultWSBufferSize
+	}
+	m.Clear()
+	m.ring = make([]WSMessage, n)
+}
+
+// AddMessage appends a message to the log, moving the oldest one to the
+// spill file when the buffer is full.
 func (m *WSLogModel) AddMessage(msg WSMessage) {
-	m.messages = append(m.messages, msg)
-	m.updateContent()
+	if m.add(msg) {
+		m.updateContent()
+	}
 }
 
-// Clear removes all messages.
+// AddMessages appends several messages and redraws the log once.
+func (m *WSLogModel) AddMessages(batch []WSMessage) {
+	redraw := false
+	for _, msg := range batch {
+		redraw = m.add(msg) || redraw
+	}
+	if redraw {
+		m.updateContent()
+	}
+}
+
+// add appends a message and reports whether the shown page changed.
+func (m *WSLogModel) add(msg WSMessage) bool {
+	if len(m.ring) == 0 {
+		m.ring = make([]WSMessage, DefaultWSBufferSize)
+	}
+	shown, _, _ := m.window()
+	if m.count == len(m.ring) {
+		m.evict(m.ring[m.head])
+		m.ring[m.head] = msg
+		m.head = (m.head + 1) % len(m.ring)
+		if len(m.matches) > 0 && m.matches[0] < m.oldest()+1 {
+			m.matches = m.matches[1:]
+		}
+	} else {
+		m.ring[(m.head+m.count)%len(m.ring)] = msg
+		m.count++
+	}
+	if m.filter.match(msg) {
+		m.matches = append(m.matches, m.total)
+		if m.paused {
+			m.unseen++
+		}
+	}
+	m.total++
+	// A page being read is only redrawn when its messages leave memory
+	evicted := len(shown) > 0 && shown[0] < m.oldest()
+	return (m.pageEnd == 0 && !m.paused) || evicted
+}
+
+// evict writes a message leaving the ring buffer to the spill file. If the
+// file cannot be created the message is dropped.
+func (m *WSLogModel) evict(msg WSMessage) {
+	if m.spill == nil {
+		f, err := os.CreateTemp("", "gottp-wslog-*.jsonl")
+		if err != nil {
+			return
+		}
+		m.spill = f
+	}
+	line, _ := json.Marshal(msg)
+	m.spill.Write(append(line, '\n'))
+}
+
+// Clear removes all messages. The filter is kept.
 func (m *WSLogModel) Clear() {
-	m.messages = nil
+	if m.spill != nil {
+		m.spill.Close()
+		os.Remove(m.spill.Name())
+		m.spill = nil
+	}
+	clear(m.ring)
+	m.head, m.count, m.total, m.pageEnd, m.unseen = 0, 0, 0, 0, 0
+	m.matches = nil
+	m.paused = false
 	m.viewport.SetContent("")
 }
 
@@ -56,13 +165,58 @@ func (m *WSLogModel) SetSize(w, h int) {
 	m.width = w
 	m.height = h
 	m.viewport.Width = w
-	m.viewport.Height = h
+	m.viewport.Height = max(h-1, 1)     // header line
+	m.filterInput.Width = max(w-40, 10) // room for the match count or error
+	m.updateContent()
+}
+
+// Editing reports whether the filter input is taking text.
+func (m WSLogModel) Editing() bool {
+	return m.filterInput.Focused()
+}
+
+// at returns the i-th buffered message, oldest first.
+func (m WSLogModel) at(i int) WSMessage {
+	return m.ring[(m.head+i)%len(m.ring)]
+}
+
+// oldest returns the index, counted since Clear, of the oldest buffered
+// message.
+func (m WSLogModel) oldest() int {
+	return m.total - m.count
+}
+
+// setFilter re-selects the buffered messages after the filter changed and
+// returns to the newest page.
+func (m *WSLogModel) setFilter(f wsFilter) {
+	m.filter = f
+	m.matches = m.matches[:0]
+	for i := 0; i < m.count; i++ {
+		if f.match(m.at(i)) {
+			m.matches = append(m.matches, m.oldest()+i)
+		}
+	}
+	m.pageEnd = 0
 	m.updateContent()
 }
 
+// window returns the indexes of the messages on the shown page and their
+// range within matches.
+func (m WSLogModel) window() (shown []int, lo, hi int) {
+	end := len(m.matches)
+	if m.pageEnd > 0 {
+		end = sort.SearchInts(m.matches, m.pageEnd)
+	}
+	lo = max(end-wsPageSize, 0)
+	hi = min(lo+wsPageSize, len(m.matches))
+	return m.matches[lo:hi], lo, hi
+}
+
 func (m *WSLogModel) updateContent() {
 	var lines []string
-	for _, msg := range m.messages {
+	shown, _, _ := m.window()
+	for _, idx := range shown {
+		msg := m.at(idx - m.oldest())
 		ts := msg.Timestamp.Format("15:04:05")
 		var prefix string
 		var style lipgloss.Style
@@ -76,6 +230,10 @@ func (m *WSLogModel) updateContent() {
 		}
 
 		tsStyle := lipgloss.NewStyle().Foreground(m.th.Muted)
+		if msg.Direction == "event" {
+			lines = append(lines, tsStyle.Render(ts)+" "+lipgloss.NewStyle().Foreground(m.th.Yellow).Render("--- "+msg.Content), "")
+			continue
+		}
 		header := tsStyle.Render(ts) + " " + style.Render(prefix+msg.Direction)
 		lines = append(lines, header)
 
@@ -87,26 +245,184 @@ func (m *WSLogModel) updateContent() {
 	}
 
 	if len(lines) == 0 {
-		lines = append(lines, m.styles.Muted.Render("No messages yet"))
+		if m.count > 0 {
+			lines = append(lines, m.styles.Muted.Render("No messages match the filter"))
+		} else {
+			lines = append(lines, m.styles.Muted.Render("No messages yet"))
+		}
 	}
 
 	m.viewport.SetContent(strings.Join(lines, "\n"))
-	m.viewport.GotoBottom()
+	if m.pageEnd == 0 {
+		m.viewport.GotoBottom()
+	} else {
+		m.viewport.GotoTop()
+	}
 }
 
-// MessageCount returns the number of messages.
+// MessageCount returns the number of messages received since the log was
+// cleared, including those no longer held in memory.
 func (m WSLogModel) MessageCount() int {
-	return len(m.messages)
+	return m.total
+}
+
+// WriteLog writes every message since the last Clear to w as JSON lines,
+// oldest first, and returns how many were written. The filter does not
+// apply.
+func (m WSLogModel) WriteLog(w io.Writer) (int, error) {
+	bw := bufio.NewWriter(w)
+	n := 0
+	if m.spill != nil {
+		f, err := os.Open(m.spill.Name())
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		sc.Buffer(nil, 64<<20)
+		for sc.Scan() {
+			bw.Write(sc.Bytes())
+			bw.WriteByte('\n')
+			n++
+		}
+		if err := sc.Err(); err != nil {
+			return n, err
+		}
+	}
+	enc := json.NewEncoder(bw)
+	for i := 0; i < m.count; i++ {
+		if err := enc.Encode(m.at(i)); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, bw.Flush()
 }
 
 func (m WSLogModel) Update(msg tea.Msg) (WSLogModel, tea.Cmd) {
+	if m.filterInput.Focused() {
+		return m.updateFilterInput(msg)
+	}
+	if key, ok := msg.(tea.KeyMsg); ok {
+		shown, lo, hi := m.window()
+		switch key.String() {
+		case "/":
+			m.filterInput.CursorEnd()
+			return m, m.filterInput.Focus()
+		case "esc":
+			if m.filter.active() || m.filterInput.Value() != "" {
+				m.filterInput.SetValue("")
+				m.filterErr = ""
+				m.setFilter(wsFilter{})
+			}
+			return m, nil
+		case "d":
+			// Cycle the direction filter: all, sent, received
+			f := m.filter
+			switch f.direction {
+			case "":
+				f.direction = "sent"
+			case "sent":
+				f.direction = "received"
+			default:
+				f.direction = ""
+			}
+			m.setFilter(f)
+			return m, nil
+		case "p", " ":
+			m.paused = !m.paused
+			m.unseen = 0
+			if !m.paused {
+				m.updateContent()
+			}
+			return m, nil
+		case "<":
+			if lo > 0 {
+				m.pageEnd = shown[0]
+				m.updateContent()
+			}
+			return m, nil
+		case ">":
+			if m.pageEnd > 0 {
+				if hi+wsPageSize >= len(m.matches) {
+					m.pageEnd = 0
+				} else {
+					m.pageEnd = m.matches[hi+wsPageSize-1] + 1
+				}
+				m.updateContent()
+			}
+			return m, nil
+		}
+	}
 	var cmd tea.Cmd
 	m.viewport, cmd = m.viewport.Update(msg)
 	return m, cmd
 }
 
+// updateFilterInput applies the filter as it is typed. Enter keeps it and
+// Esc removes it. Invalid expressions leave the last good filter in place.
+func (m WSLogModel) updateFilterInput(msg tea.Msg) (WSLogModel, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "enter":
+			m.filterInput.Blur()
+			return m, nil
+		case "esc":
+			m.filterInput.Blur()
+			m.filterInput.SetValue("")
+			m.filterErr = ""
+			f := m.filter
+			f.text, f.re, f.query, f.expr = "", nil, "", ""
+			m.setFilter(f)
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	if m.filterInput.Value() == m.filter.expr {
+		return m, cmd
+	}
+	f, err := parseWSFilter(m.filterInput.Value())
+	if err != nil {
+		m.filterErr = err.Error()
+		return m, cmd
+	}
+	m.filterErr = ""
+	f.direction = m.filter.direction
+	m.setFilter(f)
+	return m, cmd
+}
+
 // View renders the WebSocket log.
 func (m WSLogModel) View() string {
-	header := m.styles.Hint.Render(fmt.Sprintf("%d messages", len(m.messages)))
-	return header + "\n" + m.viewport.View()
+	header := fmt.Sprintf("%d messages", m.total)
+	if m.total > m.count {
+		header += fmt.Sprintf(" (last %d in memory)", m.count)
+	}
+	if m.filter.active() {
+		header += fmt.Sprintf(", %d matching", len(m.matches))
+	}
+	if len(m.matches) > wsPageSize {
+		shown, _, _ := m.window()
+		header += fmt.Sprintf("  showing %d-%d  < older  > newer", shown[0]+1, shown[len(shown)-1]+1)
+	}
+	if m.filter.direction != "" {
+		header += "  [" + m.filter.direction + " only]"
+	}
+	if m.paused {
+		header += fmt.Sprintf("  paused (%d new)", m.unseen)
+	}
+	top := m.styles.Hint.Render(header)
+	switch {
+	case m.filterInput.Focused():
+		top = m.filterInput.View() + "  "
+		if m.filterErr != "" {
+			top += m.styles.Error.MaxWidth(max(m.width-lipgloss.Width(top), 1)).Render(m.filterErr)
+		} else {
+			top += m.styles.Hint.Render(fmt.Sprintf("%d matching", len(m.matches)))
+		}
+	case m.filter.expr != "":
+		top += "  " + m.styles.Muted.Render("filter: "+m.filter.expr)
+	}
+	return top + "\n" + m.viewport.View()
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,6 +12,7 @@ import (
 	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/ui/msgs"
 	"github.com/sadopc/gottp/internal/ui/theme"
+	"github.com/sahilm/fuzzy"
 )
 
 // HistoryItem represents a request history entry for sidebar display.
@@ -21,6 +23,8 @@ type HistoryItem struct {
 	StatusCode int
 	Duration   time.Duration
 	Timestamp  time.Time
+	Tags       []string
+	Note       string
 }
 
 // Model is the sidebar panel showing collections and history.
@@ -29,6 +33,9 @@ type Model struct {
 	filtered []int // indices into items that match the filter
 	cursor   int   // index into filtered
 
+	// diagnostics maps requests whose scripts do not parse to the error
+	diagnostics map[*collection.Request]string
+
 	historyItems  []HistoryItem
 	historyCursor int
 	inHistory     bool // whether cursor is in history section
@@ -39,6 +46,10 @@ type Model struct {
 
 	filtering   bool
 	filterInput textinput.Model
+	matches     map[int][]int // item index to matched name bytes while searching
+
+	pending string          // first key of a two-key command such as dd
+	cut     collection.Item // item to move on the next paste
 
 	theme  theme.Theme
 	styles theme.Styles
@@ -57,15 +68,100 @@ func New(t theme.Theme, s theme.Styles) Model {
 	}
 }
 
-// SetItems replaces the displayed items.
+// SetItems replaces the displayed items. Folders that were collapsed stay
+// collapsed.
 func (m *Model) SetItems(items []collection.FlatItem) {
+	collapsed := make(map[*collection.Folder]bool)
+	for _, item := range m.items {
+		if item.IsFolder && !item.Expanded {
+			collapsed[item.Folder] = true
+		}
+	}
+	for i := range items {
+		if items[i].IsFolder && collapsed[items[i].Folder] {
+			items[i].Expanded = false
+		}
+	}
 	m.items = items
+	if !m.contains(m.cut) {
+		m.cut = collection.Item{}
+	}
 	m.applyFilter()
 	if m.cursor >= len(m.filtered) {
 		m.cursor = max(0, len(m.filtered)-1)
 	}
 }
 
+// SetDiagnostics marks requests with script errors, which are shown with a
+// warning icon.
+func (m *Model) SetDiagnostics(diags map[*collection.Request]string) {
+	m.diagnostics = diags
+}
+
+// Diagnostic returns the script error reported for req, if any.
+func (m Model) Diagnostic(req *collection.Request) string {
+	return m.diagnostics[req]
+}
+
+// SelectedRequest returns the request under the cursor, or nil when the
+// cursor is on a folder or in the history section.
+func (m Model) SelectedRequest() *collection.Request {
+	if m.inHistory || m.cursor >= len(m.filtered) {
+		return nil
+	}
+	return m.items[m.filtered[m.cursor]].Request
+}
+
+// SelectedItem returns the request or folder under the cursor, or the
+// zero Item when there is none.
+func (m Model) SelectedItem() collection.Item {
+	if m.inHistory || m.cursor >= len(m.filtered) {
+		return collection.Item{}
+	}
+	item := m.items[m.filtered[m.cursor]]
+	return collection.Item{Folder: item.Folder, Request: item.Request}
+}
+
+// isItem reports whether a sidebar row shows target.
+func isItem(row collection.FlatItem, target collection.Item) bool {
+	if target.Request != nil {
+		return row.Request == target.Request
+	}
+	return target.Folder != nil && row.IsFolder && row.Folder == target.Folder
+}
+
+// contains reports whether target is one of the items, shown or not.
+func (m Model) contains(target collection.Item) bool {
+	for _, item := range m.items {
+		if isItem(item, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectRequest moves the cursor to req if it is visible.
+func (m *Model) SelectRequest(req *collection.Request) {
+	m.SelectItem(collection.Item{Request: req})
+}
+
+// SelectItem moves the cursor to a request or folder if it is visible.
+func (m *Model) SelectItem(target collection.Item) {
+	for vi, idx := range m.filtered {
+		item := m.items[idx]
+		if isItem(item, target) {
+			m.cursor = vi
+			m.inHistory = false
+			return
+		}
+	}
+}
+
+// Filtering reports whether the filter input has focus.
+func (m Model) Filtering() bool {
+	return m.filtering
+}
+
 // SetHistory replaces the history items.
 func (m *Model) SetHistory(items []HistoryItem) {
 	m.historyItems = items
@@ -105,6 +201,9 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 }
 
 func (m Model) handleKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	pending := m.pending
+	m.pending = ""
+
 	switch msg.String() {
 	case "/":
 		m.filtering = true
@@ -116,6 +215,13 @@ func (m Model) handleKey(msg tea.KeyMsg) (Model, tea.Cmd) {
 		return m.handleHistoryKey(msg)
 	}
 
+	switch msg.String() {
+	case "a", "A":
+		// Works on an empty collection too, adding at the top level
+		add := msgs.AddItemMsg{At: m.SelectedItem(), Folder: msg.String() == "A"}
+		return m, func() tea.Msg { return add }
+	}
+
 	if len(m.filtered) == 0 && len(m.historyItems) > 0 {
 		if msg.String() == "j" || msg.String() == "down" {
 			m.inHistory = true
@@ -160,6 +266,58 @@ func (m Model) handleKey(msg tea.KeyMsg) (Model, tea.Cmd) {
 				}
 			}
 		}
+	case "r":
+		if item := m.SelectedItem(); item != (collection.Item{}) {
+			return m, func() tea.Msg { return msgs.RenameItemMsg{Item: item} }
+		}
+	case "R":
+		if item := m.SelectedItem(); item.Folder != nil {
+			name := item.Folder.Name
+			return m, func() tea.Msg { return msgs.RunFolderMsg{Folder: name} }
+		}
+	case "d":
+		if pending != "d" {
+			m.pending = "d"
+			return m, nil
+		}
+		if item := m.SelectedItem(); item != (collection.Item{}) {
+			return m, func() tea.Msg { return msgs.DeleteItemMsg{Item: item} }
+		}
+	case "m":
+		if item := m.SelectedItem(); item != (collection.Item{}) {
+			return m, func() tea.Msg { return msgs.MoveItemMsg{Item: item} }
+		}
+	case "K", "alt+up", "J", "alt+down":
+		if req := m.SelectedRequest(); req != nil {
+			delta := 1
+			if msg.String() == "K" || msg.String() == "alt+up" {
+				delta = -1
+			}
+			return m, func() tea.Msg {
+				return msgs.MoveRequestMsg{RequestID: req.ID, Delta: delta}
+			}
+		}
+	case "n", "N":
+		// Step through the results of a search, as in the response body
+		if m.searching() {
+			if msg.String() == "n" {
+				m.jump(1)
+			} else {
+				m.jump(-1)
+			}
+		}
+	case "x":
+		item := m.SelectedItem()
+		if item == m.cut {
+			item = collection.Item{}
+		}
+		m.cut = item
+	case "p":
+		if m.cut != (collection.Item{}) {
+			paste := msgs.PasteItemMsg{Item: m.cut, At: m.SelectedItem()}
+			m.cut = collection.Item{}
+			return m, func() tea.Msg { return paste }
+		}
 	case "h":
 		if len(m.filtered) > 0 {
 			idx := m.filtered[m.cursor]
@@ -201,6 +359,11 @@ func (m Model) handleHistoryKey(msg tea.KeyMsg) (Model, tea.Cmd) {
 				return msgs.HistorySelectedMsg{ID: entry.ID}
 			}
 		}
+	case "t":
+		if m.historyCursor < len(m.historyItems) {
+			id := m.historyItems[m.historyCursor].ID
+			return m, func() tea.Msg { return msgs.EditHistoryNoteMsg{ID: id} }
+		}
 	}
 	return m, nil
 }
@@ -212,23 +375,49 @@ func (m Model) updateFilter(msg tea.Msg) (Model, tea.Cmd) {
 		case "enter", "esc":
 			m.filtering = false
 			m.filterInput.Blur()
-			if msg.String() == "esc" {
+			if msg.String() == "esc" && m.filterInput.Value() != "" {
 				m.filterInput.SetValue("")
 				m.applyFilter()
+				return m, filterHistory("")
 			}
 			return m, nil
+		case "down":
+			m.jump(1)
+			return m, nil
+		case "up":
+			m.jump(-1)
+			return m, nil
 		}
 	}
 
+	prev := m.filterInput.Value()
 	var cmd tea.Cmd
 	m.filterInput, cmd = m.filterInput.Update(msg)
-	m.applyFilter()
-	if m.cursor >= len(m.filtered) {
-		m.cursor = max(0, len(m.filtered)-1)
+	if query := m.filterInput.Value(); query != prev {
+		// Start again from the best match
+		m.applyFilter()
+		m.cursor = 0
+		m.inHistory = false
+		return m, tea.Batch(cmd, filterHistory(query))
 	}
 	return m, cmd
 }
 
+// jump moves the cursor delta results on, wrapping around the ends.
+func (m *Model) jump(delta int) {
+	if len(m.filtered) == 0 {
+		return
+	}
+	m.inHistory = false
+	m.cursor = ((m.cursor+delta)%len(m.filtered) + len(m.filtered)) % len(m.filtered)
+}
+
+// filterHistory asks for the history matching query, which is searched in
+// the history database rather than among the entries shown.
+func filterHistory(query string) tea.Cmd {
+	return func() tea.Msg { return msgs.FilterHistoryMsg{Query: query} }
+}
+
 func (m *Model) toggleFolder(idx int) {
 	folder := &m.items[idx]
 	folder.Expanded = !folder.Expanded
@@ -246,9 +435,18 @@ func (m *Model) rebuildFromToggle() {
 	// by checking if ancestors are expanded.
 }
 
+// searching reports whether a filter query is in effect.
+func (m Model) searching() bool {
+	return strings.TrimSpace(m.filterInput.Value()) != ""
+}
+
 func (m *Model) applyFilter() {
-	query := strings.ToLower(m.filterInput.Value())
 	m.filtered = m.filtered[:0]
+	m.matches = nil
+	if m.searching() {
+		m.filtered, m.matches = search(m.items, strings.TrimSpace(m.filterInput.Value()), m.filtered)
+		return
+	}
 
 	// Track collapsed folder depth: if > 0, skip items at deeper depths.
 	skipDepth := -1
@@ -263,22 +461,39 @@ func (m *Model) applyFilter() {
 		if item.IsFolder && !item.Expanded {
 			skipDepth = item.Depth
 		}
+		m.filtered = append(m.filtered, i)
+	}
+}
 
-		if query == "" {
-			m.filtered = append(m.filtered, i)
-			continue
+// search fuzzy-matches query against the names of items and the methods
+// and URLs of requests, inside collapsed folders too. Name matches come
+// first, best first, then the rest. The indices of the results are
+// appended to dst; the map holds the matched bytes of each result's name.
+func search(items []collection.FlatItem, query string, dst []int) ([]int, map[int][]int) {
+	names := make([]string, len(items))
+	targets := make([]string, len(items))
+	for i, item := range items {
+		switch {
+		case item.IsFolder && item.Folder != nil:
+			names[i] = item.Folder.Name
+		case item.Request != nil:
+			names[i] = item.Request.Name
+			targets[i] = item.Request.Method + " " + item.Request.URL
 		}
+	}
 
-		name := ""
-		if item.IsFolder && item.Folder != nil {
-			name = item.Folder.Name
-		} else if item.Request != nil {
-			name = item.Request.Name
-		}
-		if strings.Contains(strings.ToLower(name), query) {
-			m.filtered = append(m.filtered, i)
+	matches := make(map[int][]int)
+	for _, match := range fuzzy.Find(query, names) {
+		dst = append(dst, match.Index)
+		matches[match.Index] = match.MatchedIndexes
+	}
+	for _, match := range fuzzy.Find(query, targets) {
+		if _, ok := matches[match.Index]; !ok {
+			dst = append(dst, match.Index)
+			matches[match.Index] = nil
 		}
 	}
+	return dst, matches
 }
 
 // View implements tea.Model.
@@ -306,16 +521,6 @@ func (m Model) View() string {
 	lines = append(lines, title)
 	lines = append(lines, "")
 
-	if len(m.filtered) == 0 {
-		lines = append(lines, m.styles.Muted.Render("  No items"))
-	} else {
-		for vi, idx := range m.filtered {
-			item := m.items[idx]
-			line := m.renderItem(item, vi == m.cursor, innerW)
-			lines = append(lines, line)
-		}
-	}
-
 	// History section
 	var historyLines []string
 	historyLines = append(historyLines, "")
@@ -331,22 +536,42 @@ func (m Model) View() string {
 	}
 	historyHeader := strings.Join(historyLines, "\n")
 
-	// Calculate available space
-	treeContent := strings.Join(lines, "\n")
-	filterLine := ""
+	availH := innerH
 	if m.filtering {
-		filterLine = m.filterInput.View()
+		// Reserve 1 line for filter at bottom
+		availH--
+	}
+
+	if len(m.filtered) == 0 {
+		lines = append(lines, m.styles.Muted.Render("  No items"))
+	} else {
+		// Scroll long trees to keep the cursor in view, leaving room for
+		// some history below
+		rows := max(availH-len(lines)-min(len(historyLines), availH/3), 1)
+		start := 0
+		if m.cursor >= rows {
+			start = m.cursor - rows + 1
+		}
+		end := min(start+rows, len(m.filtered))
+		for vi := start; vi < end; vi++ {
+			idx := m.filtered[vi]
+			lines = append(lines, m.renderItem(m.items[idx], m.matches[idx], vi == m.cursor, innerW))
+		}
 	}
 
 	// Compose content
-	var content string
+	treeContent := strings.Join(lines, "\n")
+	content := m.fitHeight(treeContent+historyHeader, availH)
 	if m.filtering {
-		// Reserve 1 line for filter at bottom
-		availH := innerH - 1
-		content = m.fitHeight(treeContent+historyHeader, availH)
+		filterLine := m.filterInput.View()
+		if m.searching() {
+			count := fmt.Sprintf("%d matches", len(m.filtered))
+			if len(m.filtered) > 0 && !m.inHistory {
+				count = fmt.Sprintf("%d/%d", m.cursor+1, len(m.filtered))
+			}
+			filterLine += " " + m.styles.Muted.Render(count)
+		}
 		content += "\n" + filterLine
-	} else {
-		content = m.fitHeight(treeContent+historyHeader, innerH)
 	}
 
 	return border.
@@ -355,8 +580,14 @@ func (m Model) View() string {
 		Render(content)
 }
 
-func (m Model) renderItem(item collection.FlatItem, isCursor bool, maxWidth int) string {
+// renderItem renders a tree row. While searching, rows are listed flat
+// with their folder path, and the name bytes in matched are highlighted.
+func (m Model) renderItem(item collection.FlatItem, matched []int, isCursor bool, maxWidth int) string {
 	indent := strings.Repeat("  ", item.Depth)
+	searching := m.matches != nil
+	if searching {
+		indent = ""
+	}
 
 	var line string
 	if item.IsFolder && item.Folder != nil {
@@ -364,15 +595,22 @@ func (m Model) renderItem(item collection.FlatItem, isCursor bool, maxWidth int)
 		if item.Expanded {
 			icon = "▼ "
 		}
-		name := item.Folder.Name
-		line = indent + m.styles.TreeFolder.Render(icon+name)
+		line = indent + m.styles.TreeFolder.Render(icon) + m.highlight(item.Folder.Name, matched, m.styles.TreeFolder)
 	} else if item.Request != nil {
 		method := padMethod(item.Request.Method)
 		badge := m.styles.MethodStyle(item.Request.Method).Render(method)
-		name := m.styles.TreeItem.
-			PaddingLeft(0). // override default padding; we handle indent ourselves
-			Render(item.Request.Name)
+		// override default padding; we handle indent ourselves
+		name := m.highlight(item.Request.Name, matched, m.styles.TreeItem.PaddingLeft(0))
 		line = indent + badge + " " + name
+		if m.diagnostics[item.Request] != "" {
+			line += " " + lipgloss.NewStyle().Foreground(m.theme.StatusWarning).Render("⚠")
+		}
+	}
+	if isItem(item, m.cut) {
+		line += " " + m.styles.Muted.Render("✂")
+	}
+	if parent := item.Path[:strings.LastIndex(item.Path, "/")+1]; searching && len(parent) > 1 {
+		line += " " + m.styles.Muted.Render(strings.Trim(parent, "/"))
 	}
 
 	if isCursor {
@@ -384,6 +622,31 @@ func (m Model) renderItem(item collection.FlatItem, isCursor bool, maxWidth int)
 	return line
 }
 
+// highlight renders name in style, with the bytes at matched emphasized.
+func (m Model) highlight(name string, matched []int, style lipgloss.Style) string {
+	if len(matched) == 0 {
+		return style.Render(name)
+	}
+	hit := style.Foreground(m.theme.Yellow).Bold(true)
+	var b strings.Builder
+	run, next := 0, 0
+	for i, r := range name {
+		if next < len(matched) && matched[next] == i {
+			if run < i {
+				b.WriteString(style.Render(name[run:i]))
+			}
+			end := i + utf8.RuneLen(r)
+			b.WriteString(hit.Render(name[i:end]))
+			run = end
+			next++
+		}
+	}
+	if run < len(name) {
+		b.WriteString(style.Render(name[run:]))
+	}
+	return b.String()
+}
+
 func (m Model) renderHistoryItem(entry HistoryItem, isCursor bool, maxWidth int) string {
 	method := padMethod(entry.Method)
 	badge := m.styles.MethodStyle(entry.Method).Render(method)
@@ -403,6 +666,9 @@ func (m Model) renderHistoryItem(entry HistoryItem, isCursor bool, maxWidth int)
 	agoStr := m.styles.Muted.Render(ago)
 
 	line := badge + " " + m.styles.TreeItem.PaddingLeft(0).Render(url) + " " + agoStr
+	if len(entry.Tags) > 0 {
+		line += " " + m.styles.Muted.Render("#"+strings.Join(entry.Tags, " #"))
+	}
 
 	if isCursor {
 		plain := stripForWidth(line, maxWidth)
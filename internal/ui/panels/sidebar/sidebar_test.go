@@ -1,11 +1,13 @@
 package sidebar
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/sadopc/gottp/internal/core/collection"
 	"github.com/sadopc/gottp/internal/ui/msgs"
 	"github.com/sadopc/gottp/internal/ui/theme"
@@ -140,3 +142,306 @@ func TestSidebar_ViewAndHelpers(t *testing.T) {
 		t.Fatalf("fitHeight truncate = %q", got)
 	}
 }
+
+func TestSidebar_MoveRequestAndKeepCollapsed(t *testing.T) {
+	m := newSidebarModelForTest()
+	folder := &collection.Folder{Name: "Folder"}
+	child := &collection.Request{ID: "r1", Name: "Child", Method: "GET"}
+	root := &collection.Request{ID: "r2", Name: "Root", Method: "POST"}
+	items := func() []collection.FlatItem {
+		return []collection.FlatItem{
+			{IsFolder: true, Expanded: true, Depth: 0, Folder: folder},
+			{Depth: 1, Request: child},
+			{Depth: 0, Request: root},
+		}
+	}
+	m.SetItems(items())
+	m.cursor = 2
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'K'}})
+	if cmd == nil {
+		t.Fatal("expected a move command")
+	}
+	if mv, ok := cmd().(msgs.MoveRequestMsg); !ok || mv.RequestID != "r2" || mv.Delta != -1 {
+		t.Fatalf("unexpected move message %+v", cmd())
+	}
+	m.cursor = 0
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'J'}}); cmd != nil {
+		t.Error("folders should not emit move commands")
+	}
+
+	// Collapse the folder, then reload the tree as the app does after a move
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.SetItems(items())
+	if len(m.filtered) != 2 {
+		t.Fatalf("folder should stay collapsed, %d visible items", len(m.filtered))
+	}
+	m.SelectRequest(root)
+	if m.SelectedRequest() != root {
+		t.Errorf("SelectRequest should move the cursor to the request")
+	}
+}
+
+func TestSidebar_RunFolder(t *testing.T) {
+	m := newSidebarModelForTest()
+	m.SetItems([]collection.FlatItem{
+		{IsFolder: true, Expanded: true, Depth: 0, Folder: &collection.Folder{Name: "Users"}},
+		{Depth: 1, Request: &collection.Request{ID: "r1", Name: "Child", Method: "GET"}},
+	})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'R'}})
+	if cmd == nil {
+		t.Fatal("expected a run command on a folder")
+	}
+	if run, ok := cmd().(msgs.RunFolderMsg); !ok || run.Folder != "Users" || run.Env != "" {
+		t.Fatalf("unexpected message %+v", cmd())
+	}
+
+	m.cursor = 1
+	if _, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'R'}}); cmd != nil {
+		t.Errorf("R on a request should do nothing, got %+v", cmd())
+	}
+}
+
+func TestSidebar_EditKeys(t *testing.T) {
+	folder := &collection.Folder{Name: "Users"}
+	req := &collection.Request{ID: "r1", Name: "Child", Method: "GET"}
+	m := newSidebarModelForTest()
+	m.SetItems([]collection.FlatItem{
+		{IsFolder: true, Expanded: true, Depth: 0, Folder: folder},
+		{Depth: 1, Request: req},
+	})
+	press := func(r rune) tea.Cmd {
+		var cmd tea.Cmd
+		m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		return cmd
+	}
+
+	cmd := press('A')
+	if add, ok := cmd().(msgs.AddItemMsg); !ok || !add.Folder || add.At.Folder != folder {
+		t.Errorf("A: unexpected message %+v", cmd())
+	}
+	cmd = press('r')
+	if rename, ok := cmd().(msgs.RenameItemMsg); !ok || rename.Item.Folder != folder {
+		t.Errorf("r: unexpected message %+v", cmd())
+	}
+	m.cursor = 1
+	cmd = press('r')
+	if rename, ok := cmd().(msgs.RenameItemMsg); !ok || rename.Item.Request != req {
+		t.Errorf("r: unexpected message %+v", cmd())
+	}
+
+	m.SelectRequest(req)
+	cmd = press('a')
+	if add, ok := cmd().(msgs.AddItemMsg); !ok || add.Folder || add.At.Request != req {
+		t.Errorf("a: unexpected message %+v", cmd())
+	}
+	cmd = press('m')
+	if move, ok := cmd().(msgs.MoveItemMsg); !ok || move.Item.Request != req || move.Picked {
+		t.Errorf("m: unexpected message %+v", cmd())
+	}
+
+	if press('d') != nil {
+		t.Fatal("a single d should wait for the second")
+	}
+	cmd = press('d')
+	if cmd == nil {
+		t.Fatal("dd should delete")
+	}
+	if del, ok := cmd().(msgs.DeleteItemMsg); !ok || del.Item.Request != req || del.Confirmed {
+		t.Errorf("dd: unexpected message %+v", cmd())
+	}
+
+	press('d')
+	press('k')
+	if press('d') != nil {
+		t.Error("another key between the two d presses should cancel the delete")
+	}
+}
+
+func TestSidebar_HistoryTagsAndFilter(t *testing.T) {
+	m := newSidebarModelForTest()
+	m.SetHistory([]HistoryItem{{ID: 3, Method: "GET", URL: "https://api.example.com/orders", Timestamp: time.Now(), Tags: []string{"prod-incident"}}})
+	if !strings.Contains(m.View(), "#prod-incident") {
+		t.Errorf("tags should be shown:\n%s", m.View())
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	if cmd == nil {
+		t.Fatal("expected a command for t on a history entry")
+	}
+	if msg, ok := cmd().(msgs.EditHistoryNoteMsg); !ok || msg.ID != 3 {
+		t.Errorf("got %#v, want EditHistoryNoteMsg{ID: 3}", cmd())
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'#'}})
+	found := false
+	for _, msg := range batchMsgs(cmd) {
+		if f, ok := msg.(msgs.FilterHistoryMsg); ok && f.Query == "#" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("typing in the filter should ask for matching history")
+	}
+}
+
+// batchMsgs runs cmd and any commands it batches, returning their messages.
+func batchMsgs(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var out []tea.Msg
+		for _, c := range batch {
+			out = append(out, batchMsgs(c)...)
+		}
+		return out
+	}
+	return []tea.Msg{msg}
+}
+
+func TestSidebar_CutAndPaste(t *testing.T) {
+	folder := &collection.Folder{Name: "Users"}
+	a := &collection.Request{ID: "a", Name: "A", Method: "GET"}
+	b := &collection.Request{ID: "b", Name: "B", Method: "GET"}
+	m := newSidebarModelForTest()
+	m.SetSize(40, 20)
+	m.SetItems([]collection.FlatItem{
+		{IsFolder: true, Expanded: true, Depth: 0, Folder: folder},
+		{Depth: 1, Request: a},
+		{Depth: 0, Request: b},
+	})
+	press := func(r rune) tea.Cmd {
+		var cmd tea.Cmd
+		m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		return cmd
+	}
+
+	if press('p') != nil {
+		t.Error("p with nothing cut should do nothing")
+	}
+	m.SelectRequest(b)
+	press('x')
+	if !strings.Contains(m.View(), "✂") {
+		t.Error("the cut item should be marked")
+	}
+	press('x')
+	if strings.Contains(m.View(), "✂") {
+		t.Error("x again should cancel the cut")
+	}
+
+	press('x')
+	m.SelectRequest(a)
+	cmd := press('p')
+	if cmd == nil {
+		t.Fatal("p should paste the cut item")
+	}
+	if paste, ok := cmd().(msgs.PasteItemMsg); !ok || paste.Item.Request != b || paste.At.Request != a {
+		t.Errorf("unexpected message %+v", cmd())
+	}
+	if press('p') != nil {
+		t.Error("an item is pasted only once")
+	}
+
+	press('x')
+	m.SetItems([]collection.FlatItem{{IsFolder: true, Expanded: true, Folder: folder}})
+	if press('p') != nil {
+		t.Error("a cut item that left the collection should not be pasted")
+	}
+}
+
+func TestSidebar_FuzzySearch(t *testing.T) {
+	folder := &collection.Folder{Name: "Users"}
+	list := &collection.Request{ID: "list", Name: "List Users", Method: "GET", URL: "{{base}}/users"}
+	create := &collection.Request{ID: "create", Name: "Create User", Method: "POST", URL: "{{base}}/users"}
+	health := &collection.Request{ID: "health", Name: "Health", Method: "GET", URL: "{{base}}/status"}
+	m := newSidebarModelForTest()
+	m.SetItems([]collection.FlatItem{
+		{IsFolder: true, Expanded: false, Folder: folder, Path: "/Users"},
+		{Depth: 1, Request: list, Path: "/Users/List Users"},
+		{Depth: 1, Request: create, Path: "/Users/Create User"},
+		{Request: health, Path: "/Health"},
+	})
+	typeQuery := func(q string) {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+		m.filterInput.SetValue("")
+		for _, r := range q {
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	results := func() []string {
+		var names []string
+		for _, idx := range m.filtered {
+			item := m.items[idx]
+			if item.IsFolder {
+				names = append(names, item.Folder.Name)
+			} else {
+				names = append(names, item.Request.Name)
+			}
+		}
+		return names
+	}
+
+	typeQuery("cu")
+	if got := results(); len(got) != 1 || got[0] != "Create User" {
+		t.Errorf("fuzzy name search inside a collapsed folder: %v", got)
+	}
+	if view := m.View(); !strings.Contains(view, "Users") || !strings.Contains(view, "1/1") {
+		t.Errorf("results should show their folder and position:\n%s", view)
+	}
+
+	typeQuery("status")
+	if got := results(); len(got) != 1 || got[0] != "Health" {
+		t.Errorf("URL search: %v", got)
+	}
+
+	typeQuery("post")
+	if got := results(); len(got) != 1 || got[0] != "Create User" {
+		t.Errorf("method search: %v", got)
+	}
+
+	// Name matches rank ahead of URL matches
+	typeQuery("users")
+	if got := results(); len(got) < 3 || got[len(got)-1] != "Create User" {
+		t.Errorf("ranking: %v", got)
+	}
+	if m.cursor != 0 {
+		t.Errorf("a new query should start at the best match, cursor %d", m.cursor)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.filtering || m.cursor != 1 {
+		t.Fatalf("down should move among results while typing, cursor %d", m.cursor)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'N'}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'N'}})
+	if m.cursor != len(m.filtered)-1 {
+		t.Errorf("N should wrap to the last result, cursor %d", m.cursor)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if m.cursor != 0 {
+		t.Errorf("n should wrap to the first result, cursor %d", m.cursor)
+	}
+}
+
+func TestSidebar_HighlightAndScroll(t *testing.T) {
+	m := newSidebarModelForTest()
+	if got := m.highlight("Login", []int{0, 3}, lipgloss.NewStyle()); got != "Login" {
+		t.Errorf("highlighting should keep the text, got %q", got)
+	}
+
+	var items []collection.FlatItem
+	for i := range 50 {
+		name := fmt.Sprintf("Request %02d", i)
+		items = append(items, collection.FlatItem{Request: &collection.Request{ID: name, Name: name, Method: "GET"}, Path: "/" + name})
+	}
+	m.SetItems(items)
+	m.cursor = 40
+	if view := m.View(); !strings.Contains(view, "Request 40") {
+		t.Errorf("the cursor row should be scrolled into view:\n%s", view)
+	}
+}
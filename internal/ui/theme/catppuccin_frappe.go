@@ -34,4 +34,12 @@ var CatppuccinFrappe = Theme{
 	StatusOK:        lipgloss.Color("#a6d189"),
 	StatusError:     lipgloss.Color("#e78284"),
 	StatusWarning:   lipgloss.Color("#e5c890"),
+
+	ANSI: &Theme{
+		Base: "0", Mantle: "0", Crust: "0", Surface: "0", Overlay: "8",
+		Text: "15", Subtext: "7", Muted: "8",
+		Rosewater: "15", Flamingo: "13", Pink: "13", Mauve: "5", Red: "9", Maroon: "1", Peach: "11",
+		Yellow: "3", Green: "2", Teal: "6", Sky: "14", Sapphire: "6", Blue: "12", Lavender: "13",
+		BorderFocused: "5", BorderUnfocused: "8", StatusOK: "2", StatusError: "1", StatusWarning: "3",
+	},
 }
@@ -34,4 +34,12 @@ var CatppuccinLatte = Theme{
 	StatusOK:        lipgloss.Color("#40a02b"),
 	StatusError:     lipgloss.Color("#d20f39"),
 	StatusWarning:   lipgloss.Color("#df8e1d"),
+
+	ANSI: &Theme{
+		Base: "15", Mantle: "15", Crust: "7", Surface: "7", Overlay: "7",
+		Text: "0", Subtext: "8", Muted: "8",
+		Rosewater: "1", Flamingo: "1", Pink: "5", Mauve: "5", Red: "1", Maroon: "1", Peach: "3",
+		Yellow: "3", Green: "2", Teal: "6", Sky: "6", Sapphire: "4", Blue: "4", Lavender: "4",
+		BorderFocused: "5", BorderUnfocused: "8", StatusOK: "2", StatusError: "1", StatusWarning: "3",
+	},
 }
@@ -34,4 +34,12 @@ var Dracula = Theme{
 	StatusOK:        lipgloss.Color("#50fa7b"),
 	StatusError:     lipgloss.Color("#ff5555"),
 	StatusWarning:   lipgloss.Color("#f1fa8c"),
+
+	ANSI: &Theme{
+		Base: "0", Mantle: "0", Crust: "0", Surface: "0", Overlay: "8",
+		Text: "15", Subtext: "7", Muted: "8",
+		Rosewater: "11", Flamingo: "13", Pink: "13", Mauve: "5", Red: "9", Maroon: "1", Peach: "11",
+		Yellow: "11", Green: "10", Teal: "14", Sky: "14", Sapphire: "14", Blue: "4", Lavender: "13",
+		BorderFocused: "5", BorderUnfocused: "8", StatusOK: "2", StatusError: "1", StatusWarning: "3",
+	},
 }
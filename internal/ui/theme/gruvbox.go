@@ -34,4 +34,12 @@ var GruvboxDark = Theme{
 	StatusOK:        lipgloss.Color("#98971a"),
 	StatusError:     lipgloss.Color("#cc241d"),
 	StatusWarning:   lipgloss.Color("#d79921"),
+
+	ANSI: &Theme{
+		Base: "0", Mantle: "0", Crust: "0", Surface: "0", Overlay: "8",
+		Text: "7", Subtext: "7", Muted: "8",
+		Rosewater: "15", Flamingo: "13", Pink: "13", Mauve: "5", Red: "9", Maroon: "1", Peach: "3",
+		Yellow: "3", Green: "2", Teal: "6", Sky: "14", Sapphire: "6", Blue: "4", Lavender: "5",
+		BorderFocused: "3", BorderUnfocused: "8", StatusOK: "2", StatusError: "1", StatusWarning: "3",
+	},
 }
@@ -43,6 +43,9 @@ type yamlTheme struct {
 	StatusOK        string `yaml:"status_ok"`
 	StatusError     string `yaml:"status_error"`
 	StatusWarning   string `yaml:"status_warning"`
+
+	// Colors for 16-color terminals, as ANSI color numbers "0" to "15"
+	ANSI *yamlTheme `yaml:"ansi,omitempty"`
 }
 
 // LoadCustomTheme loads a theme from a YAML file.
@@ -62,7 +65,11 @@ func LoadCustomTheme(path string) (Theme, error) {
 		yt.Name = strings.TrimSuffix(base, filepath.Ext(base))
 	}
 
-	return Theme{
+	return yt.theme(), nil
+}
+
+func (yt yamlTheme) theme() Theme {
+	t := Theme{
 		Name:            yt.Name,
 		Base:            lipgloss.Color(yt.Base),
 		Mantle:          lipgloss.Color(yt.Mantle),
@@ -91,7 +98,12 @@ func LoadCustomTheme(path string) (Theme, error) {
 		StatusOK:        lipgloss.Color(yt.StatusOK),
 		StatusError:     lipgloss.Color(yt.StatusError),
 		StatusWarning:   lipgloss.Color(yt.StatusWarning),
-	}, nil
+	}
+	if yt.ANSI != nil {
+		ansi := yt.ANSI.theme()
+		t.ANSI = &ansi
+	}
+	return t
 }
 
 // LoadCustomThemes loads all YAML themes from a directory.
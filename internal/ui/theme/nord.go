@@ -34,4 +34,12 @@ var Nord = Theme{
 	StatusOK:        lipgloss.Color("#a3be8c"),
 	StatusError:     lipgloss.Color("#bf616a"),
 	StatusWarning:   lipgloss.Color("#ebcb8b"),
+
+	ANSI: &Theme{
+		Base: "0", Mantle: "0", Crust: "0", Surface: "0", Overlay: "8",
+		Text: "15", Subtext: "7", Muted: "8",
+		Rosewater: "15", Flamingo: "13", Pink: "13", Mauve: "5", Red: "9", Maroon: "1", Peach: "3",
+		Yellow: "3", Green: "2", Teal: "6", Sky: "14", Sapphire: "4", Blue: "4", Lavender: "6",
+		BorderFocused: "6", BorderUnfocused: "8", StatusOK: "2", StatusError: "1", StatusWarning: "3",
+	},
 }
@@ -0,0 +1,91 @@
+package theme
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// ansiDark and ansiLight stand in for themes without their own ANSI
+// palette.
+var (
+	ansiDark = Theme{
+		Base: "0", Mantle: "0", Crust: "0", Surface: "0", Overlay: "8",
+		Text: "15", Subtext: "7", Muted: "8",
+		Rosewater: "15", Flamingo: "13", Pink: "13", Mauve: "5", Red: "9", Maroon: "1", Peach: "11",
+		Yellow: "3", Green: "2", Teal: "6", Sky: "14", Sapphire: "6", Blue: "12", Lavender: "13",
+		BorderFocused: "5", BorderUnfocused: "8", StatusOK: "2", StatusError: "1", StatusWarning: "3",
+	}
+	ansiLight = Theme{
+		Base: "15", Mantle: "15", Crust: "7", Surface: "7", Overlay: "7",
+		Text: "0", Subtext: "8", Muted: "8",
+		Rosewater: "1", Flamingo: "1", Pink: "5", Mauve: "5", Red: "1", Maroon: "1", Peach: "3",
+		Yellow: "3", Green: "2", Teal: "6", Sky: "6", Sapphire: "4", Blue: "4", Lavender: "4",
+		BorderFocused: "5", BorderUnfocused: "8", StatusOK: "2", StatusError: "1", StatusWarning: "3",
+	}
+)
+
+// ForProfile adapts t to a terminal's color profile. On 16-color terminals
+// the theme's ANSI palette replaces its hex colors, because approximating
+// them turns neighbouring shades into one color and pastel text into plain
+// white. Other profiles keep t: lipgloss maps hex colors to the nearest of
+// 256 itself, and drops them when the terminal has no color.
+func ForProfile(t Theme, p termenv.Profile) Theme {
+	if p != termenv.ANSI {
+		return t
+	}
+	out := ansiDark
+	if isLight(t.Base) {
+		out = ansiLight
+	}
+	if t.ANSI != nil {
+		// Custom themes may set only some colors
+		src, dst := reflect.ValueOf(*t.ANSI), reflect.ValueOf(&out).Elem()
+		for i := 0; i < src.NumField(); i++ {
+			if c, ok := src.Field(i).Interface().(lipgloss.Color); ok && c != "" {
+				dst.Field(i).Set(src.Field(i))
+			}
+		}
+	}
+	out.Name = t.Name
+	out.ANSI = t.ANSI
+	return out
+}
+
+// DetectProfile returns the color profile for the color_profile setting:
+// "truecolor", "256", "16" or "none", or the terminal's own profile for ""
+// and "auto".
+func DetectProfile(setting string) (termenv.Profile, error) {
+	switch strings.ToLower(strings.TrimSpace(setting)) {
+	case "", "auto":
+		return lipgloss.ColorProfile(), nil
+	case "truecolor", "24bit":
+		return termenv.TrueColor, nil
+	case "256":
+		return termenv.ANSI256, nil
+	case "16":
+		return termenv.ANSI, nil
+	case "none":
+		return termenv.Ascii, nil
+	}
+	return termenv.Ascii, fmt.Errorf("unknown color_profile %q (want auto, truecolor, 256, 16 or none)", setting)
+}
+
+// isLight reports whether a #rrggbb color is light. Anything else counts
+// as dark.
+func isLight(c lipgloss.Color) bool {
+	hex := strings.TrimPrefix(string(c), "#")
+	if len(hex) != 6 {
+		return false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return false
+	}
+	r, g, b := float64(v>>16&0xff), float64(v>>8&0xff), float64(v&0xff)
+	return 0.299*r+0.587*g+0.114*b > 128
+}
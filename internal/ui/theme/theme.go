@@ -40,6 +40,11 @@ type Theme struct {
 	StatusOK        lipgloss.Color
 	StatusError     lipgloss.Color
 	StatusWarning   lipgloss.Color
+
+	// ANSI is the theme for 16-color terminals, with each color an ANSI
+	// color number from "0" to "15". Nil falls back to a generic light or
+	// dark palette. See ForProfile.
+	ANSI *Theme
 }
 
 // MethodColor returns the color for an HTTP method.
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/muesli/termenv"
 )
 
 func TestNormalizeKey(t *testing.T) {
@@ -173,3 +175,65 @@ func TestThemeStatusColor(t *testing.T) {
 		t.Fatalf("default color = %q, want %q", got, theme.Text)
 	}
 }
+
+func TestForProfile(t *testing.T) {
+	// Truecolor and 256-color terminals keep the hex colors
+	for _, p := range []termenv.Profile{termenv.TrueColor, termenv.ANSI256, termenv.Ascii} {
+		if got := ForProfile(Dracula, p); got.Text != Dracula.Text {
+			t.Errorf("profile %v: Text = %q, want %q", p, got.Text, Dracula.Text)
+		}
+	}
+
+	// Every built-in theme has a complete, readable 16-color palette
+	for _, th := range Catalog {
+		got := ForProfile(th, termenv.ANSI)
+		if got.Name != th.Name || th.ANSI == nil {
+			t.Errorf("%s: name %q, ANSI palette %v", th.Name, got.Name, th.ANSI)
+			continue
+		}
+		if got.Text == got.Base || got.Text == "" || got.BorderFocused == "" {
+			t.Errorf("%s: text and base are both %q", th.Name, got.Text)
+		}
+	}
+	if got := ForProfile(CatppuccinLatte, termenv.ANSI); got.Text != "0" || got.Base != "15" {
+		t.Errorf("Latte is light: text %q on %q", got.Text, got.Base)
+	}
+
+	// Custom themes fall back to the generic palette for colors they leave out
+	dir := t.TempDir()
+	path := filepath.Join(dir, "paper.yaml")
+	yaml := "name: Paper\nbase: \"#fafafa\"\ntext: \"#222222\"\nansi:\n  border_focused: \"6\"\n"
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	custom, err := LoadCustomTheme(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ForProfile(custom, termenv.ANSI)
+	if got.BorderFocused != "6" || got.Text != ansiLight.Text || got.Name != "Paper" {
+		t.Errorf("custom theme: border %q, text %q, name %q", got.BorderFocused, got.Text, got.Name)
+	}
+	if got := ForProfile(Theme{Name: "bare"}, termenv.ANSI); got.Text != ansiDark.Text {
+		t.Errorf("theme without colors: text %q, want the dark palette", got.Text)
+	}
+}
+
+func TestDetectProfile(t *testing.T) {
+	for setting, want := range map[string]termenv.Profile{
+		"truecolor": termenv.TrueColor,
+		"256":       termenv.ANSI256,
+		" 16 ":      termenv.ANSI,
+		"none":      termenv.Ascii,
+	} {
+		if got, err := DetectProfile(setting); err != nil || got != want {
+			t.Errorf("DetectProfile(%q) = %v, %v; want %v", setting, got, err, want)
+		}
+	}
+	if _, err := DetectProfile("auto"); err != nil {
+		t.Errorf("auto: %v", err)
+	}
+	if _, err := DetectProfile("8"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
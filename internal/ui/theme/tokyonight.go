@@ -34,4 +34,12 @@ var TokyoNight = Theme{
 	StatusOK:        lipgloss.Color("#9ece6a"),
 	StatusError:     lipgloss.Color("#f7768e"),
 	StatusWarning:   lipgloss.Color("#e0af68"),
+
+	ANSI: &Theme{
+		Base: "0", Mantle: "0", Crust: "0", Surface: "0", Overlay: "8",
+		Text: "15", Subtext: "7", Muted: "8",
+		Rosewater: "15", Flamingo: "13", Pink: "13", Mauve: "5", Red: "9", Maroon: "1", Peach: "11",
+		Yellow: "3", Green: "2", Teal: "6", Sky: "14", Sapphire: "4", Blue: "12", Lavender: "13",
+		BorderFocused: "5", BorderUnfocused: "8", StatusOK: "2", StatusError: "1", StatusWarning: "3",
+	},
 }